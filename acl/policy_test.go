@@ -80,6 +80,8 @@ func TestParse(t *testing.T) {
 							NamespaceCapabilityDispatchJob,
 							NamespaceCapabilityReadLogs,
 							NamespaceCapabilityReadFS,
+							NamespaceCapabilityReadVariables,
+							NamespaceCapabilityWriteVariables,
 						},
 					},
 					{
@@ -154,6 +154,86 @@ func (a *ACLTokens) Self(q *QueryOptions) (*ACLToken, *QueryMeta, error) {
 	return &resp, wm, nil
 }
 
+// ACLAuthMethods is used to query the ACL auth method endpoints.
+type ACLAuthMethods struct {
+	client *Client
+}
+
+// ACLAuthMethods returns a new handle on the ACL auth methods.
+func (c *Client) ACLAuthMethods() *ACLAuthMethods {
+	return &ACLAuthMethods{client: c}
+}
+
+// List is used to dump all of the auth methods.
+func (a *ACLAuthMethods) List(q *QueryOptions) ([]*ACLAuthMethodListStub, *QueryMeta, error) {
+	var resp []*ACLAuthMethodListStub
+	qm, err := a.client.query("/v1/acl/auth-methods", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
+// Upsert is used to create or update an auth method
+func (a *ACLAuthMethods) Upsert(method *ACLAuthMethod, q *WriteOptions) (*WriteMeta, error) {
+	if method == nil || method.Name == "" {
+		return nil, fmt.Errorf("missing auth method name")
+	}
+	wm, err := a.client.write("/v1/acl/auth-method/"+method.Name, method, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// Delete is used to delete an auth method
+func (a *ACLAuthMethods) Delete(methodName string, q *WriteOptions) (*WriteMeta, error) {
+	if methodName == "" {
+		return nil, fmt.Errorf("missing auth method name")
+	}
+	wm, err := a.client.delete("/v1/acl/auth-method/"+methodName, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// Info is used to query a specific auth method
+func (a *ACLAuthMethods) Info(methodName string, q *QueryOptions) (*ACLAuthMethod, *QueryMeta, error) {
+	if methodName == "" {
+		return nil, nil, fmt.Errorf("missing auth method name")
+	}
+	var resp ACLAuthMethod
+	qm, err := a.client.query("/v1/acl/auth-method/"+methodName, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// GetAuthURL is used to start the OIDC flow of an auth method. It returns the
+// URL of the provider the user must authenticate at.
+func (a *ACLAuthMethods) GetAuthURL(req *ACLOIDCAuthURLRequest, q *WriteOptions) (*ACLOIDCAuthURLResponse, *WriteMeta, error) {
+	var resp ACLOIDCAuthURLResponse
+	wm, err := a.client.write("/v1/acl/oidc/auth-url", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// CompleteAuth is used to complete the OIDC flow of an auth method with the
+// state and code the provider redirected the user with. It returns the ACL
+// token created for the user.
+func (a *ACLAuthMethods) CompleteAuth(req *ACLOIDCCompleteAuthRequest, q *WriteOptions) (*ACLToken, *WriteMeta, error) {
+	var resp ACLToken
+	wm, err := a.client.write("/v1/acl/oidc/complete-auth", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
 // ACLPolicyListStub is used to for listing ACL policies
 type ACLPolicyListStub struct {
 	Name        string
@@ -173,24 +253,99 @@ type ACLPolicy struct {
 
 // ACLToken represents a client token which is used to Authenticate
 type ACLToken struct {
-	AccessorID  string
-	SecretID    string
+	AccessorID string
+	SecretID   string
+	Name       string
+	Type       string
+	Policies   []string
+	Global     bool
+	CreateTime time.Time
+
+	// ExpirationTime is when the token expires, if it does
+	ExpirationTime *time.Time
+
+	// AuthMethod is the name of the auth method that created the token
+	AuthMethod string
+
+	// AllocID is the allocation the token is the workload token of, if any
+	AllocID string
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+type ACLTokenListStub struct {
+	AccessorID string
+	Name       string
+	Type       string
+	Policies   []string
+	Global     bool
+	CreateTime time.Time
+
+	// ExpirationTime is when the token expires, if it does
+	ExpirationTime *time.Time
+
+	// AuthMethod is the name of the auth method that created the token
+	AuthMethod string
+
+	// AllocID is the allocation the token is the workload token of, if any
+	AllocID string
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// ACLAuthMethodListStub is used for listing ACL auth methods
+type ACLAuthMethodListStub struct {
 	Name        string
 	Type        string
-	Policies    []string
-	Global      bool
-	CreateTime  time.Time
 	CreateIndex uint64
 	ModifyIndex uint64
 }
 
-type ACLTokenListStub struct {
-	AccessorID  string
+// ACLAuthMethod is used to represent a method users authenticate with to be
+// issued an ACL token
+type ACLAuthMethod struct {
 	Name        string
 	Type        string
-	Policies    []string
-	Global      bool
-	CreateTime  time.Time
+	MaxTokenTTL time.Duration
+	Config      *ACLAuthMethodConfig
 	CreateIndex uint64
 	ModifyIndex uint64
 }
+
+// ACLAuthMethodConfig is the configuration of the identity provider of an ACL
+// auth method
+type ACLAuthMethodConfig struct {
+	OIDCDiscoveryURL    string
+	OIDCClientID        string
+	OIDCClientSecret    string
+	OIDCScopes          []string
+	BoundAudiences      []string
+	AllowedRedirectURIs []string
+	Policies            []string
+	GroupsClaim         string
+	GroupPolicies       map[string][]string
+}
+
+// ACLOIDCAuthURLRequest is used to start the OIDC flow of an auth method
+type ACLOIDCAuthURLRequest struct {
+	AuthMethod  string
+	RedirectURI string
+	ClientNonce string
+}
+
+// ACLOIDCAuthURLResponse is the URL of the provider to authenticate at
+type ACLOIDCAuthURLResponse struct {
+	AuthURL string
+}
+
+// ACLOIDCCompleteAuthRequest is used to complete the OIDC flow of an auth
+// method
+type ACLOIDCCompleteAuthRequest struct {
+	AuthMethod  string
+	ClientNonce string
+	State       string
+	Code        string
+	RedirectURI string
+}
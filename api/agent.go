@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"time"
 )
 
 // Agent encapsulates an API client which talks to Nomad's
@@ -305,4 +306,25 @@ type AgentHealth struct {
 
 	// Message describes why the agent is unhealthy
 	Message string `json:"message"`
+
+	// Subsystems is the health of the subsystems of a server by name
+	Subsystems map[string]*AgentSubsystemHealth `json:"subsystems,omitempty"`
+}
+
+// AgentSubsystemHealth describes the health of a subsystem of a server, such
+// as raft, the state store, the eval broker, the plan queue, Vault or Consul.
+type AgentSubsystemHealth struct {
+	// Ok is false if the subsystem is unhealthy
+	Ok bool `json:"ok"`
+
+	// Message describes the state of the subsystem, or why it is unhealthy
+	Message string `json:"message"`
+
+	// Latency is the time taken to check the subsystem
+	Latency time.Duration `json:"latency"`
+
+	// LastError is the last error of the subsystem, which is retained once
+	// it is healthy again, and LastErrorTime when it occurred
+	LastError     string     `json:"last_error"`
+	LastErrorTime *time.Time `json:"last_error_time"`
 }
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"time"
@@ -58,6 +59,86 @@ func (a *Allocations) Stats(alloc *Allocation, q *QueryOptions) (*AllocResourceU
 	return &resp, err
 }
 
+// StatsStream streams the resource usage of the allocation from its client,
+// which sends a sample at the interval, or its stats collection interval if
+// longer or zero. The stream is closed when the cancel channel is closed.
+func (a *Allocations) StatsStream(alloc *Allocation, interval time.Duration, cancel <-chan struct{},
+	q *QueryOptions) (<-chan *AllocResourceUsage, <-chan error) {
+
+	errCh := make(chan error, 1)
+	nodeClient, err := a.client.GetNodeClient(alloc.NodeID, q)
+	if err != nil {
+		errCh <- err
+		return nil, errCh
+	}
+
+	if q == nil {
+		q = &QueryOptions{}
+	}
+	if q.Params == nil {
+		q.Params = make(map[string]string)
+	}
+	q.Params["follow"] = "true"
+	if interval != 0 {
+		q.Params["interval"] = interval.String()
+	}
+
+	r, err := nodeClient.rawQuery("/v1/client/allocation/"+alloc.ID+"/stats", q)
+	if err != nil {
+		errCh <- err
+		return nil, errCh
+	}
+
+	// Create the output channel
+	samples := make(chan *AllocResourceUsage, 10)
+
+	go func() {
+		// Close the body
+		defer r.Close()
+
+		// Create a decoder
+		dec := json.NewDecoder(r)
+
+		for {
+			// Check if we have been cancelled
+			select {
+			case <-cancel:
+				return
+			default:
+			}
+
+			// Decode the next sample
+			var usage AllocResourceUsage
+			if err := dec.Decode(&usage); err != nil {
+				errCh <- err
+				close(samples)
+				return
+			}
+
+			select {
+			case samples <- &usage:
+			case <-cancel:
+				return
+			}
+		}
+	}()
+
+	return samples, errCh
+}
+
+// Checks queries the client of the allocation for the status of the service
+// checks of its tasks.
+func (a *Allocations) Checks(alloc *Allocation, q *QueryOptions) ([]*AllocCheckStatus, error) {
+	nodeClient, err := a.client.GetNodeClient(alloc.NodeID, q)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp []*AllocCheckStatus
+	_, err = nodeClient.query("/v1/client/allocation/"+alloc.ID+"/checks", &resp, q)
+	return resp, err
+}
+
 func (a *Allocations) GC(alloc *Allocation, q *QueryOptions) error {
 	nodeClient, err := a.client.GetNodeClient(alloc.NodeID, q)
 	if err != nil {
@@ -69,6 +150,34 @@ func (a *Allocations) GC(alloc *Allocation, q *QueryOptions) error {
 	return err
 }
 
+// StopBatch is used to stop a batch of the running allocations of the
+// namespace that match the filter, so that they are replaced. The request is
+// repeated with the returned NextToken until it is empty to stop all the
+// matching allocations.
+func (a *Allocations) StopBatch(req *AllocBatchStopRequest, q *WriteOptions) (*AllocBatchStopResponse, *WriteMeta, error) {
+	var resp AllocBatchStopResponse
+	wm, err := a.client.write("/v1/allocations/stop", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// AllocBatchStopRequest is used to stop a batch of allocations that match a
+// filter expression, such as `NodeID == "..."`.
+type AllocBatchStopRequest struct {
+	Filter    string
+	BatchSize int
+	NextToken string
+}
+
+// AllocBatchStopResponse is the response of a batch of allocation stops.
+type AllocBatchStopResponse struct {
+	Stopped   int
+	EvalIDs   []string
+	NextToken string
+}
+
 // Allocation is used for serialization of allocations.
 type Allocation struct {
 	ID                 string
@@ -87,6 +196,7 @@ type Allocation struct {
 	DesiredDescription string
 	ClientStatus       string
 	ClientDescription  string
+	StopReason         string
 	TaskStates         map[string]*TaskState
 	DeploymentID       string
 	DeploymentStatus   *AllocDeploymentStatus
@@ -121,6 +231,7 @@ type AllocationListStub struct {
 	ID                 string
 	EvalID             string
 	Name               string
+	Namespace          string
 	NodeID             string
 	JobID              string
 	JobVersion         uint64
@@ -129,6 +240,7 @@ type AllocationListStub struct {
 	DesiredDescription string
 	ClientStatus       string
 	ClientDescription  string
+	StopReason         string
 	TaskStates         map[string]*TaskState
 	DeploymentStatus   *AllocDeploymentStatus
 	CreateIndex        uint64
@@ -137,6 +249,42 @@ type AllocationListStub struct {
 	ModifyTime         int64
 }
 
+// ArchivedAllocation is the summary of a terminal allocation written to the
+// allocation archive before the allocation was garbage collected.
+type ArchivedAllocation struct {
+	ID                 string
+	EvalID             string
+	Name               string
+	Namespace          string
+	NodeID             string
+	NodeName           string
+	JobID              string
+	JobVersion         uint64
+	TaskGroup          string
+	Resources          *Resources
+	DesiredStatus      string
+	DesiredDescription string
+	ClientStatus       string
+	ClientDescription  string
+	TaskStates         map[string]*ArchivedTaskState
+	CreateTime         int64
+	ModifyTime         int64
+	ArchiveTime        int64
+}
+
+// ArchivedTaskState is the summary of the state of a task of an archived
+// allocation.
+type ArchivedTaskState struct {
+	State       string
+	Failed      bool
+	Restarts    uint64
+	StartedAt   time.Time
+	FinishedAt  time.Time
+	ExitCode    int
+	Signal      int
+	ExitMessage string
+}
+
 // AllocDeploymentStatus captures the status of the allocation as part of the
 // deployment. This can include things like if the allocation has been marked as
 // healthy.
@@ -146,6 +294,28 @@ type AllocDeploymentStatus struct {
 }
 
 // AllocIndexSort reverse sorts allocs by CreateIndex.
+// AllocCheckStatus is the status of a service check of an allocation.
+type AllocCheckStatus struct {
+	Task    string
+	Service string
+	Name    string
+	CheckID string
+	Type    string
+
+	// Provider is who runs the check: "consul" or "nomad" for script checks
+	Provider string
+
+	Status string
+	Output string
+
+	Interval time.Duration
+	Timeout  time.Duration
+
+	// LastRun and LastDuration are only known for checks run by Nomad
+	LastRun      time.Time
+	LastDuration time.Duration
+}
+
 type AllocIndexSort []*AllocationListStub
 
 func (a AllocIndexSort) Len() int {
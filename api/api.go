@@ -42,6 +42,10 @@ type QueryOptions struct {
 	// If set, used as prefix for resource list searches
 	Prefix string
 
+	// IndexAt is used to read the state as of a past Raft index. It is served
+	// from the state history retained by the servers.
+	IndexAt uint64
+
 	// Set HTTP parameters on the query.
 	Params map[string]string
 
@@ -288,7 +292,9 @@ func (c *Config) ConfigureTLS() error {
 		clientTLSConfig.Certificates = []tls.Certificate{clientCert}
 	}
 	if c.TLSConfig.TLSServerName != "" {
-		clientTLSConfig.ServerName = c.TLSConfig.TLSServerName
+		// Brackets are removed so IPv6 server names match the IP SANs of
+		// the certificate
+		clientTLSConfig.ServerName = strings.TrimSuffix(strings.TrimPrefix(c.TLSConfig.TLSServerName, "["), "]")
 	}
 
 	return nil
@@ -388,6 +394,46 @@ func (c *Client) SetSecretID(secretID string) {
 	c.config.SecretID = secretID
 }
 
+// RoundTrip sends the request to the Nomad agent with the ACL token, the HTTP
+// authentication and the TLS configuration of the client. It allows requests
+// such as the ones of the web UI to be proxied to the agent.
+func (c *Client) RoundTrip(req *http.Request) (*http.Response, error) {
+	base, err := url.Parse(c.config.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copy the request since the transport must not modify it
+	out := new(http.Request)
+	*out = *req
+	u := *req.URL
+	u.Scheme = base.Scheme
+	u.Host = base.Host
+	u.User = nil
+	out.URL = &u
+	out.Host = base.Host
+	out.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		out.Header[k] = v
+	}
+
+	if base.User != nil {
+		password, _ := base.User.Password()
+		out.SetBasicAuth(base.User.Username(), password)
+	} else if c.config.HttpAuth != nil {
+		out.SetBasicAuth(c.config.HttpAuth.Username, c.config.HttpAuth.Password)
+	}
+	if c.config.SecretID != "" && out.Header.Get("X-Nomad-Token") == "" {
+		out.Header.Set("X-Nomad-Token", c.config.SecretID)
+	}
+
+	transport := c.config.httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return transport.RoundTrip(out)
+}
+
 // request is used to help build up a request
 type request struct {
 	config *Config
@@ -426,6 +472,9 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 	if q.Prefix != "" {
 		r.params.Set("prefix", q.Prefix)
 	}
+	if q.IndexAt != 0 {
+		r.params.Set("index_at", strconv.FormatUint(q.IndexAt, 10))
+	}
 	for k, v := range q.Params {
 		r.params.Set(k, v)
 	}
@@ -685,11 +734,18 @@ func (c *Client) write(endpoint string, in, out interface{}, q *WriteOptions) (*
 // delete is used to do a DELETE request against an endpoint
 // and serialize/deserialized using the standard Nomad conventions.
 func (c *Client) delete(endpoint string, out interface{}, q *WriteOptions) (*WriteMeta, error) {
+	return c.deleteWithBody(endpoint, nil, out, q)
+}
+
+// deleteWithBody is used to do a DELETE request with a body against an
+// endpoint and serialize/deserialized using the standard Nomad conventions.
+func (c *Client) deleteWithBody(endpoint string, in, out interface{}, q *WriteOptions) (*WriteMeta, error) {
 	r, err := c.newRequest("DELETE", endpoint)
 	if err != nil {
 		return nil, err
 	}
 	r.setWriteOptions(q)
+	r.obj = in
 	rtt, resp, err := requireOK(c.doRequest(r))
 	if err != nil {
 		return nil, err
@@ -111,6 +111,44 @@ func TestRequestTime(t *testing.T) {
 	}
 }
 
+func TestClient_RoundTrip(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	var got *http.Request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	conf := DefaultConfig()
+	conf.Address = srv.URL
+	conf.SecretID = "secret"
+	client, err := NewClient(conf)
+	assert.Nil(err)
+
+	// The request is sent to the agent with the token of the client
+	req, err := http.NewRequest("GET", "http://127.0.0.1:1234/ui/jobs?page=2", nil)
+	assert.Nil(err)
+	resp, err := client.RoundTrip(req)
+	assert.Nil(err)
+	resp.Body.Close()
+	assert.Equal(200, resp.StatusCode)
+	assert.Equal("/ui/jobs", got.URL.Path)
+	assert.Equal("page=2", got.URL.RawQuery)
+	assert.Equal("secret", got.Header.Get("X-Nomad-Token"))
+	assert.Empty(req.Header.Get("X-Nomad-Token"))
+	assert.Equal("127.0.0.1:1234", req.URL.Host)
+
+	// The token of the request takes precedence
+	req.Header.Set("X-Nomad-Token", "other")
+	resp, err = client.RoundTrip(req)
+	assert.Nil(err)
+	resp.Body.Close()
+	assert.Equal("other", got.Header.Get("X-Nomad-Token"))
+}
+
 func TestDefaultConfig_env(t *testing.T) {
 	t.Parallel()
 	url := "http://1.2.3.4:5678"
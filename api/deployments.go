@@ -2,6 +2,7 @@ package api
 
 import (
 	"sort"
+	"time"
 )
 
 // Deployments is used to query the deployments endpoints.
@@ -147,6 +148,31 @@ type DeploymentState struct {
 	PlacedAllocs    int
 	HealthyAllocs   int
 	UnhealthyAllocs int
+	CanaryAnalysis  []*CanaryAnalysisSnapshot
+}
+
+// CanaryAnalysisSnapshot compares the canaries of a task group with its
+// stable allocations at a point in time.
+type CanaryAnalysisSnapshot struct {
+	Time           time.Time
+	Canary         *CanaryAnalysisStats
+	Stable         *CanaryAnalysisStats
+	CPUDelta       float64
+	MemoryRSSDelta int64
+}
+
+// CanaryAnalysisStats are the statistics of a set of allocations of a canary
+// analysis snapshot.
+type CanaryAnalysisStats struct {
+	Allocs        int
+	Running       int
+	Unhealthy     int
+	TaskRestarts  uint64
+	TaskFailures  int
+	CheckFailures int
+	MeanCPUTicks  float64
+	MeanMemoryRSS uint64
+	UsageReported int
 }
 
 // DeploymentIndexSort is a wrapper to sort deployments by CreateIndex. We
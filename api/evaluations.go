@@ -52,6 +52,59 @@ func (e *Evaluations) Allocations(evalID string, q *QueryOptions) ([]*Allocation
 	return resp, qm, nil
 }
 
+// DeleteBatch is used to delete a batch of the evaluations of the namespace
+// that match the filter. The request is repeated with the returned NextToken
+// until it is empty to delete all the matching evaluations.
+func (e *Evaluations) DeleteBatch(req *EvalBatchDeleteRequest, q *WriteOptions) (*EvalBatchDeleteResponse, *WriteMeta, error) {
+	var resp EvalBatchDeleteResponse
+	wm, err := e.client.write("/v1/evaluations/delete", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Delete is used to delete evaluations by ID, including the blocked
+// evaluations that are stuck. Pending evaluations are skipped.
+func (e *Evaluations) Delete(evalIDs []string, q *WriteOptions) (*EvalDeleteResponse, *WriteMeta, error) {
+	var resp EvalDeleteResponse
+	req := &EvalDeleteRequest{EvalIDs: evalIDs}
+	wm, err := e.client.deleteWithBody("/v1/evaluations", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// EvalDeleteRequest is used to delete evaluations by ID.
+type EvalDeleteRequest struct {
+	EvalIDs []string
+}
+
+// EvalDeleteResponse lists the deleted evaluations and the pending evaluations
+// that were skipped.
+type EvalDeleteResponse struct {
+	Deleted []string
+	Skipped []string
+}
+
+// EvalBatchDeleteRequest is used to delete a batch of evaluations that match a
+// filter expression, such as `Status == "failed"`.
+type EvalBatchDeleteRequest struct {
+	Filter    string
+	BatchSize int
+	NextToken string
+}
+
+// EvalBatchDeleteResponse is the response of a batch of evaluation deletions.
+// Skipped counts the matching evaluations that weren't deleted because they
+// are pending.
+type EvalBatchDeleteResponse struct {
+	Deleted   int
+	Skipped   int
+	NextToken string
+}
+
 // Evaluation is used to serialize an evaluation.
 type Evaluation struct {
 	ID                   string
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+const (
+	// The topics of the event stream
+	TopicJob        = "Job"
+	TopicAllocation = "Allocation"
+	TopicDeployment = "Deployment"
+	TopicNode       = "Node"
+
+	// AllNamespaces is the namespace that selects the events of every
+	// namespace the token can read.
+	AllNamespaces = "*"
+)
+
+// Event is a change to an object, as seen by the servers.
+type Event struct {
+	Topic     string
+	Type      string
+	Key       string
+	Namespace string
+	Index     uint64
+	Payload   *EventPayload
+}
+
+// EventPayload holds the object of an event. Only the field of the event's
+// topic is set.
+type EventPayload struct {
+	Job        *Job
+	Allocation *Allocation
+	Deployment *Deployment
+	Node       *Node
+}
+
+// Events is a batch of events of the event stream.
+type Events struct {
+	Index  uint64
+	Events []*Event
+}
+
+// IsHeartbeat returns whether the batch is a heartbeat without events.
+func (e *Events) IsHeartbeat() bool {
+	return e.Index == 0 && len(e.Events) == 0
+}
+
+// EventRetention is the range of events retained by the servers. A subscriber
+// that has received the events up to OldestIndex - 1 or later can resume
+// without missing any.
+type EventRetention struct {
+	OldestIndex uint64
+	LatestIndex uint64
+	Durable     bool
+}
+
+// EventStream is used to subscribe to the event stream.
+type EventStream struct {
+	client *Client
+}
+
+// EventStream returns a handle to the event stream endpoint.
+func (c *Client) EventStream() *EventStream {
+	return &EventStream{client: c}
+}
+
+// Stream subscribes to the events after the index that are of one of the
+// topics, all topics if none are given. The events are filtered by the
+// namespace of the query options, or every namespace if it is AllNamespaces,
+// and by the key prefix of the query options. The stream is closed when the
+// cancel channel is closed.
+func (e *EventStream) Stream(topics []string, index uint64, cancel <-chan struct{},
+	q *QueryOptions) (<-chan *Events, <-chan error) {
+
+	errCh := make(chan error, 1)
+	r, err := e.client.newRequest("GET", "/v1/event/stream")
+	if err != nil {
+		errCh <- err
+		return nil, errCh
+	}
+	r.setQueryOptions(q)
+	if index != 0 {
+		r.params.Set("index", strconv.FormatUint(index, 10))
+	}
+	for _, topic := range topics {
+		r.params.Add("topic", topic)
+	}
+
+	_, resp, err := requireOK(e.client.doRequest(r))
+	if err != nil {
+		errCh <- err
+		return nil, errCh
+	}
+
+	// Create the output channel
+	eventsCh := make(chan *Events, 10)
+
+	go func() {
+		// Close the body
+		defer resp.Body.Close()
+
+		// Create a decoder
+		dec := json.NewDecoder(resp.Body)
+
+		for {
+			// Check if we have been cancelled
+			select {
+			case <-cancel:
+				return
+			default:
+			}
+
+			// Decode the next batch
+			var events Events
+			if err := dec.Decode(&events); err != nil {
+				errCh <- err
+				close(eventsCh)
+				return
+			}
+
+			// Discard heartbeats
+			if events.IsHeartbeat() {
+				continue
+			}
+
+			eventsCh <- &events
+		}
+	}()
+
+	return eventsCh, errCh
+}
+
+// Retention returns the range of events retained by the servers, so a
+// subscriber can determine whether it can resume from an index.
+func (e *EventStream) Retention(q *QueryOptions) (*EventRetention, *QueryMeta, error) {
+	var resp EventRetention
+	qm, err := e.client.query("/v1/event/retention", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
@@ -299,6 +299,109 @@ func (a *AllocFS) Logs(alloc *Allocation, follow bool, task, logType, origin str
 	return frames, errCh
 }
 
+// MultiplexedStreamFrame is a StreamFrame of a single log stream tagged with
+// the allocation and task it was read from.
+type MultiplexedStreamFrame struct {
+	AllocID string       `json:",omitempty"`
+	Task    string       `json:",omitempty"`
+	Type    string       `json:",omitempty"`
+	Frame   *StreamFrame `json:",omitempty"`
+	Error   string       `json:",omitempty"`
+}
+
+// IsHeartbeat returns if the frame is a heartbeat frame
+func (m *MultiplexedStreamFrame) IsHeartbeat() bool {
+	return m.Frame == nil && m.Error == ""
+}
+
+// MultiplexedLogs streams the logs of every started task of the allocation
+// over a single connection. If logType is empty both stdout and stderr are
+// streamed. The frames returned carry the task and log type they belong to.
+func (a *AllocFS) MultiplexedLogs(alloc *Allocation, follow bool, logType, origin string,
+	offset int64, cancel <-chan struct{}, q *QueryOptions) (<-chan *MultiplexedStreamFrame, <-chan error) {
+
+	path := fmt.Sprintf("/v1/client/fs/logs-multiplexed/%s", alloc.ID)
+	return a.multiplexedLogs(alloc.NodeID, path, "", follow, logType, origin, offset, cancel, q)
+}
+
+// JobLogs streams the logs of every started task of the job's allocations
+// running on the given node over a single connection. If logType is empty
+// both stdout and stderr are streamed.
+func (a *AllocFS) JobLogs(nodeID, jobID string, follow bool, logType, origin string,
+	offset int64, cancel <-chan struct{}, q *QueryOptions) (<-chan *MultiplexedStreamFrame, <-chan error) {
+
+	return a.multiplexedLogs(nodeID, "/v1/client/fs/logs-multiplexed", jobID, follow, logType, origin, offset, cancel, q)
+}
+
+func (a *AllocFS) multiplexedLogs(nodeID, path, jobID string, follow bool, logType, origin string,
+	offset int64, cancel <-chan struct{}, q *QueryOptions) (<-chan *MultiplexedStreamFrame, <-chan error) {
+
+	errCh := make(chan error, 1)
+	nodeClient, err := a.client.GetNodeClient(nodeID, q)
+	if err != nil {
+		errCh <- err
+		return nil, errCh
+	}
+
+	if q == nil {
+		q = &QueryOptions{}
+	}
+	if q.Params == nil {
+		q.Params = make(map[string]string)
+	}
+
+	if jobID != "" {
+		q.Params["job"] = jobID
+	}
+	q.Params["follow"] = strconv.FormatBool(follow)
+	q.Params["type"] = logType
+	q.Params["origin"] = origin
+	q.Params["offset"] = strconv.FormatInt(offset, 10)
+
+	r, err := nodeClient.rawQuery(path, q)
+	if err != nil {
+		errCh <- err
+		return nil, errCh
+	}
+
+	// Create the output channel
+	frames := make(chan *MultiplexedStreamFrame, 10)
+
+	go func() {
+		// Close the body
+		defer r.Close()
+
+		// Create a decoder
+		dec := json.NewDecoder(r)
+
+		for {
+			// Check if we have been cancelled
+			select {
+			case <-cancel:
+				return
+			default:
+			}
+
+			// Decode the next frame
+			var frame MultiplexedStreamFrame
+			if err := dec.Decode(&frame); err != nil {
+				errCh <- err
+				close(frames)
+				return
+			}
+
+			// Discard heartbeat frames
+			if frame.IsHeartbeat() {
+				continue
+			}
+
+			frames <- &frame
+		}
+	}()
+
+	return frames, errCh
+}
+
 // FrameReader is used to convert a stream of frames into a read closer.
 type FrameReader struct {
 	frames   <-chan *StreamFrame
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"bytes"
 	"fmt"
 	"net/url"
 	"sort"
@@ -51,11 +52,45 @@ func (j *Jobs) Validate(job *Job, q *WriteOptions) (*JobValidateResponse, *Write
 	return &resp, wm, err
 }
 
+// ValidateEnforcement is used to validate a job and run the admission
+// checks, Sentinel policies and quota checks it would be subject to if it
+// was registered, without creating an evaluation.
+func (j *Jobs) ValidateEnforcement(job *Job, q *WriteOptions) (*JobValidateResponse, *WriteMeta, error) {
+	var resp JobValidateResponse
+	req := &JobValidateRequest{Job: job, Enforcement: true}
+	if q != nil {
+		req.WriteRequest = WriteRequest{Region: q.Region}
+	}
+	wm, err := j.client.write("/v1/validate/job", req, &resp, q)
+	return &resp, wm, err
+}
+
+// Render is used to parse a job specification with the given variables and
+// validate it without registering it. The response includes the job as it
+// would be registered and the diagnostics of the specification.
+func (j *Jobs) Render(req *JobRenderRequest, q *WriteOptions) (*JobRenderResponse, *WriteMeta, error) {
+	var resp JobRenderResponse
+	wm, err := j.client.write("/v1/jobs/render", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
 // RegisterOptions is used to pass through job registration parameters
 type RegisterOptions struct {
-	EnforceIndex   bool
-	ModifyIndex    uint64
-	PolicyOverride bool
+	EnforceIndex     bool
+	ModifyIndex      uint64
+	PolicyOverride   bool
+	IdempotencyToken string
+
+	// Submission is the source the job was parsed from, stored with the job
+	// version.
+	Submission *JobSubmission
+
+	// Source is an optional description of where the job comes from, such as
+	// a git SHA, recorded in the provenance of the job version.
+	Source string
 }
 
 // Register is used to register a new job. It returns the ID
@@ -85,6 +120,9 @@ func (j *Jobs) RegisterOpts(job *Job, opts *RegisterOptions, q *WriteOptions) (*
 		if opts.PolicyOverride {
 			req.PolicyOverride = true
 		}
+		req.IdempotencyToken = opts.IdempotencyToken
+		req.Submission = opts.Submission
+		req.Source = opts.Source
 	}
 
 	var resp JobRegisterResponse
@@ -133,6 +171,33 @@ func (j *Jobs) Versions(jobID string, diffs bool, q *QueryOptions) ([]*Job, []*J
 	return resp.Versions, resp.Diffs, qm, nil
 }
 
+// Submission is used to retrieve the source a version of a job was submitted
+// with. If the version is nil, the source of the current version is returned.
+func (j *Jobs) Submission(jobID string, version *uint64, q *QueryOptions) (*JobSubmission, *QueryMeta, error) {
+	endpoint := fmt.Sprintf("/v1/job/%s/submission", jobID)
+	if version != nil {
+		endpoint = fmt.Sprintf("%s?version=%d", endpoint, *version)
+	}
+	var resp JobSubmission
+	qm, err := j.client.query(endpoint, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// DiffVersions is used to retrieve the structured diff between two versions of
+// a job.
+func (j *Jobs) DiffVersions(jobID string, oldVersion, newVersion uint64, q *QueryOptions) (*JobDiff, *QueryMeta, error) {
+	var resp JobDiff
+	endpoint := fmt.Sprintf("/v1/job/%s/diff?old=%d&new=%d", jobID, oldVersion, newVersion)
+	qm, err := j.client.query(endpoint, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
 // Allocations is used to return the allocs for a given job ID.
 func (j *Jobs) Allocations(jobID string, allAllocs bool, q *QueryOptions) ([]*AllocationListStub, *QueryMeta, error) {
 	var resp []*AllocationListStub
@@ -153,6 +218,29 @@ func (j *Jobs) Allocations(jobID string, allAllocs bool, q *QueryOptions) ([]*Al
 	return resp, qm, nil
 }
 
+// AllocationHistory is used to query the archived allocations of a job,
+// including those that were garbage collected. Only allocations that stopped
+// at or after since are returned, unless it is zero.
+func (j *Jobs) AllocationHistory(jobID string, since time.Time, q *QueryOptions) ([]*ArchivedAllocation, *QueryMeta, error) {
+	var resp []*ArchivedAllocation
+	u, err := url.Parse("/v1/job/" + jobID + "/allocations/history")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !since.IsZero() {
+		v := u.Query()
+		v.Add("since", since.UTC().Format(time.RFC3339))
+		u.RawQuery = v.Encode()
+	}
+
+	qm, err := j.client.query(u.String(), &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
 // Deployments is used to query the deployments associated with the given job
 // ID.
 func (j *Jobs) Deployments(jobID string, q *QueryOptions) ([]*Deployment, *QueryMeta, error) {
@@ -210,6 +298,18 @@ func (j *Jobs) ForceEvaluate(jobID string, q *WriteOptions) (string, *WriteMeta,
 	return resp.EvalID, wm, nil
 }
 
+// EvaluateBatch is used to force the evaluation of a batch of the jobs of the
+// namespace. The request is repeated with the returned NextToken until it is
+// empty to evaluate all the jobs.
+func (j *Jobs) EvaluateBatch(req *JobBatchEvaluateRequest, q *WriteOptions) (*JobBatchEvaluateResponse, *WriteMeta, error) {
+	var resp JobBatchEvaluateResponse
+	wm, err := j.client.write("/v1/jobs/evaluate", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
 // PeriodicForce spawns a new instance of the periodic job and returns the eval ID
 func (j *Jobs) PeriodicForce(jobID string, q *WriteOptions) (string, *WriteMeta, error) {
 	var resp periodicForceResponse
@@ -277,6 +377,55 @@ func (j *Jobs) Dispatch(jobID string, meta map[string]string,
 	return &resp, wm, nil
 }
 
+// DispatchBatch is used to dispatch many instances of a parameterized job at
+// once. The results are in the same order as the items; items that were
+// rejected have their Error set.
+func (j *Jobs) DispatchBatch(jobID string, items []*JobDispatchItem,
+	q *WriteOptions) (*JobDispatchBatchResponse, *WriteMeta, error) {
+	var resp JobDispatchBatchResponse
+	req := &JobDispatchBatchRequest{
+		JobID: jobID,
+		Items: items,
+	}
+	wm, err := j.client.write("/v1/job/"+jobID+"/dispatch-batch", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
+// Patch is used to update a job by applying a JSON merge patch to its current
+// version on the servers. Arrays of named objects, such as task groups and
+// tasks, are merged by name, and an element with "$delete": true removes the
+// element of its name. If modifyIndex is non-zero, the patch is only
+// applied if the job's modify index matches.
+func (j *Jobs) Patch(jobID string, patch []byte, modifyIndex uint64, q *WriteOptions) (*JobRegisterResponse, *WriteMeta, error) {
+	r, err := j.client.newRequest("PATCH", "/v1/job/"+jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	r.setWriteOptions(q)
+	if modifyIndex != 0 {
+		r.params.Set("cas", strconv.FormatUint(modifyIndex, 10))
+	}
+	r.body = bytes.NewReader(patch)
+
+	rtt, resp, err := requireOK(j.client.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	wm := &WriteMeta{RequestTime: rtt}
+	parseWriteMeta(resp, wm)
+
+	var out JobRegisterResponse
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, wm, nil
+}
+
 // Revert is used to revert the given job to the passed version. If
 // enforceVersion is set, the job is only reverted if the current version is at
 // the passed version.
@@ -296,6 +445,25 @@ func (j *Jobs) Revert(jobID string, version uint64, enforcePriorVersion *uint64,
 	return &resp, wm, nil
 }
 
+// Restart is used to replace the allocations of the given job without changing
+// its specification, following the update strategy of the job. If
+// enforcePriorVersion is set, the job is only restarted if the current version
+// is at the passed version.
+func (j *Jobs) Restart(jobID string, enforcePriorVersion *uint64,
+	q *WriteOptions) (*JobRegisterResponse, *WriteMeta, error) {
+
+	var resp JobRegisterResponse
+	req := &JobRestartRequest{
+		JobID:               jobID,
+		EnforcePriorVersion: enforcePriorVersion,
+	}
+	wm, err := j.client.write("/v1/job/"+jobID+"/restart", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
 // Stable is used to mark a job version's stability.
 func (j *Jobs) Stable(jobID string, version uint64, stable bool,
 	q *WriteOptions) (*JobStabilityResponse, *WriteMeta, error) {
@@ -557,6 +725,7 @@ type Job struct {
 	Update            *UpdateStrategy
 	Periodic          *PeriodicConfig
 	ParameterizedJob  *ParameterizedJobConfig
+	Secrets           *SecretDependencies
 	Payload           []byte
 	Meta              map[string]string
 	VaultToken        *string `mapstructure:"vault_token"`
@@ -565,11 +734,28 @@ type Job struct {
 	Stable            *bool
 	Version           *uint64
 	SubmitTime        *int64
+	Restarts          *uint64
+	Provenance        *JobProvenance
 	CreateIndex       *uint64
 	ModifyIndex       *uint64
 	JobModifyIndex    *uint64
 }
 
+// SecretDependencies are the Vault paths, variables and Consul KV keys the
+// tasks of a job read. They are verified when the job is planned.
+type SecretDependencies struct {
+	Vault     []string
+	Variables []string
+	Consul    []string
+}
+
+// JobProvenance records who submitted a version of a job and from where
+type JobProvenance struct {
+	TokenAccessor string
+	SourceIP      string
+	Source        string
+}
+
 // IsPeriodic returns whether a job is periodic.
 func (j *Job) IsPeriodic() bool {
 	return j.Periodic != nil
@@ -792,6 +978,11 @@ type WriteRequest struct {
 // JobValidateRequest is used to validate a job
 type JobValidateRequest struct {
 	Job *Job
+
+	// Enforcement runs the admission checks, Sentinel policies and quota
+	// checks the job would be subject to if it was registered.
+	Enforcement bool `json:",omitempty"`
+
 	WriteRequest
 }
 
@@ -810,6 +1001,68 @@ type JobValidateResponse struct {
 	// Warnings contains any warnings about the given job. These may include
 	// deprecation warnings.
 	Warnings string
+
+	// EnforcementResults is the result of each enforcement check if
+	// enforcement was requested and the job is valid.
+	EnforcementResults []*JobEnforcementResult
+
+	// Job is the job as it would be registered, after it was canonicalized
+	// and mutated by the servers.
+	Job *Job
+}
+
+// JobRenderRequest is used to render and validate a job specification
+// without registering it.
+type JobRenderRequest struct {
+	// JobHCL is the job specification
+	JobHCL string
+
+	// Variables are the values of the variables referenced in the job
+	// specification as "${var.<name>}"
+	Variables map[string]string
+
+	// Filename is the name of the file of the job specification. It is
+	// returned with the diagnostics.
+	Filename string
+
+	WriteRequest
+}
+
+// JobRenderResponse is the response of rendering a job specification.
+type JobRenderResponse struct {
+	// Job is the job as it would be registered. It is nil if the job
+	// specification couldn't be parsed.
+	Job *Job
+
+	// Diagnostics are the errors and warnings about the job specification
+	Diagnostics []*JobDiagnostic
+
+	// EnforcementResults is the result of each enforcement check if the job
+	// is valid.
+	EnforcementResults []*JobEnforcementResult
+}
+
+// JobDiagnostic is an error or warning about a job specification. Errors
+// found while parsing the specification are positioned in its source.
+type JobDiagnostic struct {
+	// Severity is either "error" or "warning"
+	Severity string
+	Summary  string
+
+	// Filename, Line and Column are the position of the diagnostic in the
+	// source. Line and Column are zero if the position is unknown.
+	Filename string
+	Line     int
+	Column   int
+}
+
+// JobEnforcementResult is the result of an enforcement check run when
+// validating a job.
+type JobEnforcementResult struct {
+	Check    string
+	Passed   bool
+	Error    string
+	Warnings string
 }
 
 // JobRevertRequest is used to revert a job to a prior version.
@@ -827,6 +1080,19 @@ type JobRevertRequest struct {
 	WriteRequest
 }
 
+// JobRestartRequest is used to replace the allocations of a job without
+// changing its specification.
+type JobRestartRequest struct {
+	// JobID is the ID of the job being restarted
+	JobID string
+
+	// EnforcePriorVersion if set will enforce that the job is at the given
+	// version before restarting.
+	EnforcePriorVersion *uint64
+
+	WriteRequest
+}
+
 // JobUpdateRequest is used to update a job
 type JobRegisterRequest struct {
 	Job *Job
@@ -837,15 +1103,48 @@ type JobRegisterRequest struct {
 	JobModifyIndex uint64
 	PolicyOverride bool
 
+	// IdempotencyToken makes retried registrations return the result of the
+	// original registration instead of creating a new job version.
+	IdempotencyToken string
+
+	// Submission is the source the job is parsed from. If Job is not set, the
+	// server parses the job from the source.
+	Submission *JobSubmission
+
+	// Source is an optional description of where the job comes from, such as
+	// a git SHA, recorded in the provenance of the job version.
+	Source string
+
 	WriteRequest
 }
 
 // RegisterJobRequest is used to serialize a job registration
 type RegisterJobRequest struct {
-	Job            *Job
-	EnforceIndex   bool   `json:",omitempty"`
-	JobModifyIndex uint64 `json:",omitempty"`
-	PolicyOverride bool   `json:",omitempty"`
+	Job              *Job
+	EnforceIndex     bool           `json:",omitempty"`
+	JobModifyIndex   uint64         `json:",omitempty"`
+	PolicyOverride   bool           `json:",omitempty"`
+	IdempotencyToken string         `json:",omitempty"`
+	Submission       *JobSubmission `json:",omitempty"`
+	Source           string         `json:",omitempty"`
+}
+
+// JobSubmission is the source a job was submitted with
+type JobSubmission struct {
+	// Source is the job specification
+	Source string
+
+	// Format is the format of the source. Only "hcl" is supported.
+	Format string
+
+	// Variables are the values of the variables referenced in the source as
+	// "${var.<name>}"
+	Variables map[string]string
+
+	Namespace   string
+	JobID       string
+	Version     uint64
+	CreateIndex uint64
 }
 
 // JobRegisterResponse is used to respond to a job registration
@@ -895,6 +1194,20 @@ type JobDiff struct {
 	Fields     []*FieldDiff
 	Objects    []*ObjectDiff
 	TaskGroups []*TaskGroupDiff
+
+	// RoutingChanges are the changes of the diff that affect how traffic
+	// reaches the job's tasks
+	RoutingChanges []*RoutingChange
+}
+
+// RoutingChange is a change to a port or service of a task.
+type RoutingChange struct {
+	Type      string
+	Kind      string
+	TaskGroup string
+	Task      string
+	Name      string
+	Old, New  string
 }
 
 type TaskGroupDiff struct {
@@ -956,6 +1269,51 @@ type JobDispatchResponse struct {
 	WriteMeta
 }
 
+// JobDispatchBatchRequest is used to dispatch many instances of a
+// parameterized job.
+type JobDispatchBatchRequest struct {
+	JobID string
+	Items []*JobDispatchItem
+}
+
+// JobDispatchItem is the payload and meta data of a single dispatch in a
+// batch.
+type JobDispatchItem struct {
+	Payload []byte
+	Meta    map[string]string
+}
+
+// JobDispatchBatchResponse is the response to a batch dispatch.
+type JobDispatchBatchResponse struct {
+	Results         []*JobDispatchItemResult
+	EvalCreateIndex uint64
+	JobCreateIndex  uint64
+	WriteMeta
+}
+
+// JobDispatchItemResult is the result of a single dispatch in a batch.
+type JobDispatchItemResult struct {
+	DispatchedJobID string
+	EvalID          string
+	Error           string
+}
+
+// JobBatchEvaluateRequest is used to force the evaluation of a batch of the
+// jobs of a namespace.
+type JobBatchEvaluateRequest struct {
+	BatchSize int
+	NextToken string
+}
+
+// JobBatchEvaluateResponse is the response of a batch of job evaluations.
+// Skipped counts the periodic, parameterized and stopped jobs that weren't
+// evaluated.
+type JobBatchEvaluateResponse struct {
+	EvalIDs   []string
+	Skipped   int
+	NextToken string
+}
+
 // JobVersionsResponse is used for a job get versions request
 type JobVersionsResponse struct {
 	Versions []*Job
@@ -84,6 +84,32 @@ func TestJobs_Validate(t *testing.T) {
 	}
 }
 
+func TestJobs_ValidateEnforcement(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t, nil, nil)
+	defer s.Stop()
+	jobs := c.Jobs()
+
+	job := testJob()
+	resp, _, err := jobs.ValidateEnforcement(job, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(resp.EnforcementResults) != 3 {
+		t.Fatalf("bad %v", resp)
+	}
+	for _, result := range resp.EnforcementResults {
+		if !result.Passed {
+			t.Fatalf("bad %#v", result)
+		}
+	}
+
+	// Validating does not register the job
+	if _, _, err := jobs.Info(*job.ID, nil); err == nil {
+		t.Fatalf("expected job to not be registered")
+	}
+}
+
 func TestJobs_Canonicalize(t *testing.T) {
 	t.Parallel()
 	testCases := []struct {
@@ -0,0 +1,91 @@
+package api
+
+import "fmt"
+
+// NetworkPolicies is used to query the network policy endpoints.
+type NetworkPolicies struct {
+	client *Client
+}
+
+// NetworkPolicies returns a new handle on the network policies.
+func (c *Client) NetworkPolicies() *NetworkPolicies {
+	return &NetworkPolicies{client: c}
+}
+
+// List is used to list the policies of the namespace.
+func (n *NetworkPolicies) List(q *QueryOptions) ([]*NetworkPolicy, *QueryMeta, error) {
+	var resp []*NetworkPolicy
+	qm, err := n.client.query("/v1/network-policies", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
+// Upsert is used to create or update a policy
+func (n *NetworkPolicies) Upsert(policy *NetworkPolicy, q *WriteOptions) (*WriteMeta, error) {
+	if policy == nil || policy.Name == "" {
+		return nil, fmt.Errorf("missing policy name")
+	}
+	wm, err := n.client.write("/v1/network-policy/"+policy.Name, policy, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// Delete is used to delete a policy
+func (n *NetworkPolicies) Delete(policyName string, q *WriteOptions) (*WriteMeta, error) {
+	if policyName == "" {
+		return nil, fmt.Errorf("missing policy name")
+	}
+	wm, err := n.client.delete("/v1/network-policy/"+policyName, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// Info is used to query a specific policy
+func (n *NetworkPolicies) Info(policyName string, q *QueryOptions) (*NetworkPolicy, *QueryMeta, error) {
+	if policyName == "" {
+		return nil, nil, fmt.Errorf("missing policy name")
+	}
+	var resp NetworkPolicy
+	qm, err := n.client.query("/v1/network-policy/"+policyName, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// NetworkPolicy controls the traffic between the allocations on the bridge
+// network of a node. Deny rules take precedence over allow rules.
+type NetworkPolicy struct {
+	Name        string
+	Namespace   string
+	Description string
+
+	// Services selects the allocations of the namespace the policy applies
+	// to, all of them if empty
+	Services []string
+
+	// DefaultDeny denies the traffic no rule allows
+	DefaultDeny bool
+
+	Rules []*NetworkPolicyRule
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// NetworkPolicyRule allows or denies traffic from the allocations of a
+// namespace, "*" for all namespaces, or from a CIDR block, optionally
+// restricted to a service and destination ports.
+type NetworkPolicyRule struct {
+	Action    string
+	Namespace string
+	Service   string
+	CIDR      string `json:",omitempty"`
+	Ports     []int
+}
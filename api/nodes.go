@@ -94,6 +94,71 @@ func (n *Nodes) GC(nodeID string, q *QueryOptions) error {
 	return err
 }
 
+// Meta returns the metadata of the node, including the dynamic metadata set
+// at runtime.
+func (n *Nodes) Meta(nodeID string, q *QueryOptions) (*NodeMetaResponse, error) {
+	nodeClient, err := n.client.GetNodeClient(nodeID, q)
+	if err != nil {
+		return nil, err
+	}
+	var resp NodeMetaResponse
+	if _, err := nodeClient.query("/v1/client/metadata", &resp, nil); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ApplyMeta sets the dynamic metadata of the node. A nil value unsets the
+// key. The changes persist across restarts of the client.
+func (n *Nodes) ApplyMeta(nodeID string, meta map[string]*string, q *QueryOptions) (*NodeMetaResponse, error) {
+	nodeClient, err := n.client.GetNodeClient(nodeID, q)
+	if err != nil {
+		return nil, err
+	}
+	req := &NodeMetaApplyRequest{Meta: meta}
+	var resp NodeMetaResponse
+	if _, err := nodeClient.write("/v1/client/metadata", req, &resp, nil); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Migrations returns the migrations of sticky ephemeral disks from previous
+// allocations that are pending on the node.
+func (n *Nodes) Migrations(nodeID string, q *QueryOptions) ([]*AllocMigration, error) {
+	nodeClient, err := n.client.GetNodeClient(nodeID, q)
+	if err != nil {
+		return nil, err
+	}
+	var resp []*AllocMigration
+	if _, err := nodeClient.query("/v1/client/migrations", &resp, nil); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AllocMigration is a migration of the sticky ephemeral disk of an allocation
+// from its previous allocation. Local migrations hand the data of a previous
+// allocation on the same node over in place.
+type AllocMigration struct {
+	AllocID         string
+	PreviousAllocID string
+	Local           bool
+	Status          string
+}
+
+// NodeMetaApplyRequest is used to update the dynamic metadata of a node.
+type NodeMetaApplyRequest struct {
+	Meta map[string]*string
+}
+
+// NodeMetaResponse is the metadata of a node. Dynamic holds the metadata set
+// or unset at runtime, a nil value marking a key unset.
+type NodeMetaResponse struct {
+	Meta    map[string]string
+	Dynamic map[string]*string
+}
+
 // Node is used to deserialize a node entry.
 type Node struct {
 	ID                string
@@ -0,0 +1,33 @@
+package api
+
+// DeploymentConcurrencyConfig limits how many deployments may be running at
+// once across the cluster and per namespace. Zero means no limit. While a
+// limit is set, deployments are queued and started in the order they were
+// created.
+type DeploymentConcurrencyConfig struct {
+	Limit           int
+	NamespaceLimits map[string]int
+	CreateIndex     uint64
+	ModifyIndex     uint64
+}
+
+// DeploymentConcurrencyGetConfig returns the deployment concurrency
+// configuration.
+func (op *Operator) DeploymentConcurrencyGetConfig(q *QueryOptions) (*DeploymentConcurrencyConfig, *QueryMeta, error) {
+	var resp DeploymentConcurrencyConfig
+	qm, err := op.c.query("/v1/operator/deployment-concurrency", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// DeploymentConcurrencySetConfig sets the deployment concurrency
+// configuration.
+func (op *Operator) DeploymentConcurrencySetConfig(conf *DeploymentConcurrencyConfig, q *WriteOptions) (*WriteMeta, error) {
+	wm, err := op.c.write("/v1/operator/deployment-concurrency", conf, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
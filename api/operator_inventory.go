@@ -0,0 +1,59 @@
+package api
+
+import "net/url"
+
+// InventoryNode describes a node and the allocations placed on it.
+type InventoryNode struct {
+	ID          string
+	Name        string
+	Datacenter  string
+	NodeClass   string
+	Status      string
+	Drain       bool
+	Resources   *Resources
+	Allocations []*InventoryAllocation
+}
+
+// InventoryAllocation describes an allocation and the tasks it runs.
+type InventoryAllocation struct {
+	ID            string
+	Namespace     string
+	JobID         string
+	JobVersion    uint64
+	TaskGroup     string
+	DesiredStatus string
+	ClientStatus  string
+	Tasks         []*InventoryTask
+}
+
+// InventoryTask describes the software a task runs and the resources it
+// was allocated.
+type InventoryTask struct {
+	Name      string
+	Driver    string
+	Image     string
+	Artifacts []string
+	Resources *Resources
+}
+
+// Inventory returns a point-in-time inventory of the nodes in the cluster and
+// the allocations placed on them.
+func (op *Operator) Inventory(q *QueryOptions) ([]*InventoryNode, *QueryMeta, error) {
+	var resp []*InventoryNode
+	qm, err := op.c.query("/v1/operator/inventory", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
+// ImageQuery returns the running allocations with a task that was started from
+// the image with the given digest.
+func (op *Operator) ImageQuery(digest string, q *QueryOptions) ([]*AllocationListStub, *QueryMeta, error) {
+	var resp []*AllocationListStub
+	qm, err := op.c.query("/v1/operator/query?image_digest="+url.QueryEscape(digest), &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
@@ -0,0 +1,59 @@
+package api
+
+import "net/url"
+
+const (
+	// QuarantineTypeImage marks an entry as the digest of an image.
+	QuarantineTypeImage = "image"
+
+	// QuarantineTypeArtifact marks an entry as the checksum of an artifact.
+	QuarantineTypeArtifact = "artifact"
+)
+
+// QuarantineEntry is an image digest or artifact checksum that is denied
+// cluster-wide.
+type QuarantineEntry struct {
+	Digest      string
+	Type        string
+	Reason      string
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// QuarantineUpsertRequest is used to add entries to the quarantine list.
+type QuarantineUpsertRequest struct {
+	Entries []*QuarantineEntry
+	WriteRequest
+}
+
+// QuarantineList returns the entries of the quarantine list.
+func (op *Operator) QuarantineList(q *QueryOptions) ([]*QuarantineEntry, *QueryMeta, error) {
+	var resp []*QuarantineEntry
+	qm, err := op.c.query("/v1/operator/quarantine", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
+// QuarantineUpsert adds or updates entries of the quarantine list.
+func (op *Operator) QuarantineUpsert(entries []*QuarantineEntry, q *WriteOptions) (*WriteMeta, error) {
+	req := &QuarantineUpsertRequest{
+		Entries: entries,
+	}
+	wm, err := op.c.write("/v1/operator/quarantine", req, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// QuarantineDelete removes the entry with the given digest from the
+// quarantine list.
+func (op *Operator) QuarantineDelete(digest string, q *WriteOptions) (*WriteMeta, error) {
+	wm, err := op.c.delete("/v1/operator/quarantine/"+url.PathEscape(digest), nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
@@ -0,0 +1,30 @@
+package api
+
+// UtilizationGroup is the resource utilization of the ready nodes of a
+// datacenter and node class.
+type UtilizationGroup struct {
+	Datacenter string
+	NodeClass  string
+	Nodes      int
+	Total      *UtilizationResources
+	Allocated  *UtilizationResources
+	Running    *UtilizationResources
+}
+
+// UtilizationResources is an amount of CPU, memory and disk.
+type UtilizationResources struct {
+	CPU      int
+	MemoryMB int
+	DiskMB   int
+}
+
+// Utilization returns the resource utilization of the cluster grouped by
+// datacenter and node class.
+func (op *Operator) Utilization(q *QueryOptions) ([]*UtilizationGroup, *QueryMeta, error) {
+	var resp []*UtilizationGroup
+	qm, err := op.c.query("/v1/operator/utilization", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
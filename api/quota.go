@@ -79,6 +79,20 @@ func (q *Quotas) Usage(name string, qo *QueryOptions) (*QuotaUsage, *QueryMeta,
 	return &resp, qm, nil
 }
 
+// UsageVerbose is used to query a single quota usage by its name, including
+// the contribution of each namespace attached to the quota.
+func (q *Quotas) UsageVerbose(name string, qo *QueryOptions) (*QuotaUsage, *QueryMeta, error) {
+	if qo == nil {
+		qo = &QueryOptions{}
+	}
+	if qo.Params == nil {
+		qo.Params = make(map[string]string)
+	}
+	qo.Params["verbose"] = "true"
+
+	return q.Usage(name, qo)
+}
+
 // Register is used to register a quota spec.
 func (q *Quotas) Register(spec *QuotaSpec, qo *WriteOptions) (*WriteMeta, error) {
 	wm, err := q.client.write("/v1/quota", spec, nil, qo)
@@ -88,6 +102,18 @@ func (q *Quotas) Register(spec *QuotaSpec, qo *WriteOptions) (*WriteMeta, error)
 	return wm, nil
 }
 
+// RegisterCAS is used to register a quota spec only if the existing spec is
+// at the passed modify index. An index of zero only registers the spec if it
+// does not yet exist. The index is checked by Nomad Enterprise servers.
+func (q *Quotas) RegisterCAS(spec *QuotaSpec, modifyIndex uint64, qo *WriteOptions) (*WriteMeta, error) {
+	endpoint := fmt.Sprintf("/v1/quota?cas=%d", modifyIndex)
+	wm, err := q.client.write(endpoint, spec, nil, qo)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
 // Delete is used to delete a quota spec
 func (q *Quotas) Delete(quota string, qo *WriteOptions) (*WriteMeta, error) {
 	wm, err := q.client.delete(fmt.Sprintf("/v1/quota/%s", quota), nil, qo)
@@ -110,6 +136,15 @@ type QuotaSpec struct {
 	// particular priority range and datacenter set.
 	Limits []*QuotaLimit
 
+	// ExemptJobTypes is the set of job types, such as "system", whose
+	// allocations are not accounted toward the quota limits.
+	ExemptJobTypes []string
+
+	// ExemptJobs is the set of glob patterns of job names whose allocations
+	// are not accounted toward the quota limits. It is used to exempt
+	// infrastructure jobs running in tenant namespaces.
+	ExemptJobs []string
+
 	// Raft indexes to track creation and modification
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -126,18 +161,43 @@ type QuotaLimit struct {
 	// useful for once we support GPUs
 	RegionLimit *Resources
 
+	// DeviceSeconds is the number of device-seconds, such as GPU-seconds,
+	// that allocations within a referencing namespace may accumulate in the
+	// region. For quota usages it is the number of device-seconds
+	// accumulated. It follows the same semantics as the region limit.
+	DeviceSeconds *int `mapstructure:"device_seconds"`
+
 	// Hash is the hash of the object and is used to make replication efficient.
 	Hash []byte
 }
 
 // QuotaUsage is the resource usage of a Quota
 type QuotaUsage struct {
-	Name        string
-	Used        map[string]*QuotaLimit
+	Name string
+	Used map[string]*QuotaLimit
+
+	// Namespaces is the contribution of each namespace attached to the quota
+	// to the usage. It is only set by verbose usage queries.
+	Namespaces []*QuotaNamespaceUsage
+
 	CreateIndex uint64
 	ModifyIndex uint64
 }
 
+// QuotaNamespaceUsage is the contribution of a namespace to the usage of a
+// quota
+type QuotaNamespaceUsage struct {
+	// Namespace is the name of the namespace
+	Namespace string
+
+	// Used is the resource usage of the namespace, keyed like the usage of
+	// the quota by the hash of the limits.
+	Used map[string]*QuotaLimit
+
+	// Allocs is the number of non-terminal allocations of the namespace
+	Allocs int
+}
+
 // QuotaSpecIndexSort is a wrapper to sort QuotaSpecs by CreateIndex. We
 // reverse the test so that we get the highest index first.
 type QuotaSpecIndexSort []*QuotaSpec
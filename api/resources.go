@@ -5,11 +5,17 @@ import "github.com/hashicorp/nomad/helper"
 // Resources encapsulates the required resources of
 // a given task or task group.
 type Resources struct {
-	CPU      *int
-	MemoryMB *int `mapstructure:"memory"`
-	DiskMB   *int `mapstructure:"disk"`
-	IOPS     *int
-	Networks []*NetworkResource
+	CPU         *int
+	MemoryMB    *int `mapstructure:"memory"`
+	DiskMB      *int `mapstructure:"disk"`
+	IOPS        *int
+	Networks    []*NetworkResource
+	Devices     []*DeviceResource
+	Cores       *int
+	CoreIDs     []int
+	IOWeight    *int `mapstructure:"io_weight"`
+	IOReadMBps  *int `mapstructure:"io_read_mbps"`
+	IOWriteMBps *int `mapstructure:"io_write_mbps"`
 }
 
 // Canonicalize will supply missing values in the cases
@@ -28,6 +34,9 @@ func (r *Resources) Canonicalize() {
 	for _, n := range r.Networks {
 		n.Canonicalize()
 	}
+	for _, d := range r.Devices {
+		d.Canonicalize()
+	}
 }
 
 // DefaultResources is a small resources object that contains the
@@ -75,6 +84,24 @@ func (r *Resources) Merge(other *Resources) {
 	if len(other.Networks) != 0 {
 		r.Networks = other.Networks
 	}
+	if len(other.Devices) != 0 {
+		r.Devices = other.Devices
+	}
+	if other.Cores != nil {
+		r.Cores = other.Cores
+	}
+	if len(other.CoreIDs) != 0 {
+		r.CoreIDs = other.CoreIDs
+	}
+	if other.IOWeight != nil {
+		r.IOWeight = other.IOWeight
+	}
+	if other.IOReadMBps != nil {
+		r.IOReadMBps = other.IOReadMBps
+	}
+	if other.IOWriteMBps != nil {
+		r.IOWriteMBps = other.IOWriteMBps
+	}
 }
 
 type Port struct {
@@ -98,3 +125,26 @@ func (n *NetworkResource) Canonicalize() {
 		n.MBits = helper.IntToPtr(10)
 	}
 }
+
+// DeviceResource is used to describe the devices of a type required by a
+// task, or those assigned to it.
+type DeviceResource struct {
+	Type      string
+	Name      string
+	Count     *int
+	MBits     int
+	Instances []*DeviceInstance
+}
+
+func (d *DeviceResource) Canonicalize() {
+	if d.Count == nil {
+		d.Count = helper.IntToPtr(1)
+	}
+}
+
+// DeviceInstance is a single device assigned to a task
+type DeviceInstance struct {
+	ID        string
+	NUMANode  int
+	LocalCPUs string
+}
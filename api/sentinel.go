@@ -34,6 +34,55 @@ func (a *SentinelPolicies) Upsert(policy *SentinelPolicy, q *WriteOptions) (*Wri
 	return wm, nil
 }
 
+// UpsertCAS is used to create or update a policy only if the existing policy
+// is at the passed modify index. An index of zero only creates the policy if
+// it does not yet exist. The index is checked by Nomad Enterprise servers.
+func (a *SentinelPolicies) UpsertCAS(policy *SentinelPolicy, modifyIndex uint64, q *WriteOptions) (*WriteMeta, error) {
+	if policy == nil || policy.Name == "" {
+		return nil, fmt.Errorf("missing policy name")
+	}
+	endpoint := fmt.Sprintf("/v1/sentinel/policy/%s?cas=%d", policy.Name, modifyIndex)
+	wm, err := a.client.write(endpoint, policy, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// UpdateEnforcement is used to change the enforcement level and scope of a
+// policy without rewriting its body. Empty fields of the update are left
+// unchanged.
+func (a *SentinelPolicies) UpdateEnforcement(policyName string, update *SentinelPolicyEnforcement, q *WriteOptions) (*WriteMeta, error) {
+	if policyName == "" {
+		return nil, fmt.Errorf("missing policy name")
+	}
+	if update == nil || (update.EnforcementLevel == "" && update.Scope == "") {
+		return nil, fmt.Errorf("missing enforcement level or scope")
+	}
+	wm, err := a.client.write("/v1/sentinel/policy/"+policyName+"/enforcement", update, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// Test is used to evaluate a policy against jobs without writing the policy
+// or registering the jobs. The results are returned in the order of the jobs.
+func (a *SentinelPolicies) Test(req *SentinelTestRequest, q *WriteOptions) (*SentinelTestResponse, *WriteMeta, error) {
+	if req == nil || req.Policy == "" {
+		return nil, nil, fmt.Errorf("missing policy")
+	}
+	if len(req.Jobs) == 0 {
+		return nil, nil, fmt.Errorf("missing jobs")
+	}
+	var resp SentinelTestResponse
+	wm, err := a.client.write("/v1/sentinel/test", req, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
 // Delete is used to delete a policy
 func (a *SentinelPolicies) Delete(policyName string, q *WriteOptions) (*WriteMeta, error) {
 	if policyName == "" {
@@ -65,15 +114,53 @@ type SentinelPolicy struct {
 	Scope            string
 	EnforcementLevel string
 	Policy           string
+	Version          uint64
 	CreateIndex      uint64
 	ModifyIndex      uint64
 }
 
+// SentinelPolicyEnforcement is used to change the enforcement level and scope
+// of a policy.
+type SentinelPolicyEnforcement struct {
+	EnforcementLevel string
+	Scope            string
+}
+
 type SentinelPolicyListStub struct {
 	Name             string
 	Description      string
 	Scope            string
 	EnforcementLevel string
+	Version          uint64
 	CreateIndex      uint64
 	ModifyIndex      uint64
 }
+
+// SentinelTestRequest is used to evaluate a policy against jobs.
+type SentinelTestRequest struct {
+	// Policy is the source of the policy
+	Policy string
+
+	// Jobs are the jobs to evaluate the policy against
+	Jobs []*Job
+}
+
+// SentinelTestResponse is the result of evaluating a policy against jobs.
+type SentinelTestResponse struct {
+	Results []*SentinelTestResult
+}
+
+// SentinelTestResult is the result of evaluating a policy against a job.
+type SentinelTestResult struct {
+	// JobID is the ID of the job the policy was evaluated against
+	JobID string
+
+	// Passed is whether the policy passed
+	Passed bool
+
+	// Error is set if the policy failed to be evaluated
+	Error string
+
+	// Trace is the trace of the evaluation of the policy rules
+	Trace string
+}
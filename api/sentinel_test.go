@@ -107,3 +107,75 @@ func TestSentinelPolicies_Info(t *testing.T) {
 	assertQueryMeta(t, qm)
 	assert.Equal(t, policy.Name, out.Name)
 }
+
+func TestSentinelPolicies_UpdateEnforcement(t *testing.T) {
+	t.Parallel()
+	c, s, _ := makeACLClient(t, nil, nil)
+	defer s.Stop()
+	ap := c.SentinelPolicies()
+
+	// Register a policy
+	policy := &SentinelPolicy{
+		Name:             "test",
+		Description:      "test",
+		EnforcementLevel: "hard-mandatory",
+		Scope:            "submit-job",
+		Policy:           "main = rule { true }",
+	}
+	wm, err := ap.Upsert(policy, nil)
+	assert.Nil(t, err)
+	assertWriteMeta(t, wm)
+
+	orig, _, err := ap.Info(policy.Name, nil)
+	assert.Nil(t, err)
+
+	// Relax the enforcement level
+	update := &SentinelPolicyEnforcement{EnforcementLevel: "advisory"}
+	wm, err = ap.UpdateEnforcement(policy.Name, update, nil)
+	assert.Nil(t, err)
+	assertWriteMeta(t, wm)
+
+	// The level changed and the body and scope were kept
+	out, _, err := ap.Info(policy.Name, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "advisory", out.EnforcementLevel)
+	assert.Equal(t, policy.Scope, out.Scope)
+	assert.Equal(t, policy.Policy, out.Policy)
+	assert.Equal(t, orig.Version+1, out.Version)
+
+	// An empty update is rejected
+	_, err = ap.UpdateEnforcement(policy.Name, &SentinelPolicyEnforcement{}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestSentinelPolicies_Test(t *testing.T) {
+	t.Parallel()
+	c, s, _ := makeACLClient(t, nil, nil)
+	defer s.Stop()
+	ap := c.SentinelPolicies()
+
+	job := testJob()
+	policy := `main = rule { job.datacenters contains "dc1" }`
+	resp, wm, err := ap.Test(&SentinelTestRequest{Policy: policy, Jobs: []*Job{job}}, nil)
+	assert.Nil(t, err)
+	assertWriteMeta(t, wm)
+	if assert.Len(t, resp.Results, 1) {
+		assert.Equal(t, *job.ID, resp.Results[0].JobID)
+		assert.True(t, resp.Results[0].Passed)
+	}
+
+	// Failing policies are reported per job
+	job.Datacenters = []string{"dc2"}
+	resp, _, err = ap.Test(&SentinelTestRequest{Policy: policy, Jobs: []*Job{job}}, nil)
+	assert.Nil(t, err)
+	if assert.Len(t, resp.Results, 1) {
+		assert.False(t, resp.Results[0].Passed)
+		assert.NotEmpty(t, resp.Results[0].Trace)
+	}
+
+	// The policy and jobs are required
+	_, _, err = ap.Test(&SentinelTestRequest{Jobs: []*Job{job}}, nil)
+	assert.NotNil(t, err)
+	_, _, err = ap.Test(&SentinelTestRequest{Policy: policy}, nil)
+	assert.NotNil(t, err)
+}
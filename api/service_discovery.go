@@ -0,0 +1,29 @@
+package api
+
+// ServiceDiscovery is used to query the service discovery endpoints.
+type ServiceDiscovery struct {
+	client *Client
+}
+
+// ServiceDiscovery returns a handle on the service discovery endpoints.
+func (c *Client) ServiceDiscovery() *ServiceDiscovery {
+	return &ServiceDiscovery{client: c}
+}
+
+// PrometheusTargetGroup is a set of scrape targets sharing labels in the
+// Prometheus HTTP service discovery format.
+type PrometheusTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// Prometheus returns the scrape targets of the services of running
+// allocations.
+func (s *ServiceDiscovery) Prometheus(q *QueryOptions) ([]*PrometheusTargetGroup, *QueryMeta, error) {
+	var resp []*PrometheusTargetGroup
+	qm, err := s.client.query("/v1/service-discovery/prometheus", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
@@ -1,5 +1,11 @@
 package api
 
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
 // Status is used to query the status-related endpoints.
 type System struct {
 	client *Client
@@ -16,8 +22,58 @@ func (s *System) GarbageCollect() error {
 	return err
 }
 
+// GarbageCollectScoped is used to garbage collect the eligible objects of the
+// given collections, or of all the collections if none are given. The garbage
+// collection is restricted to the namespace of the write options if it is set.
+// The response lists the objects that were garbage collected, or would be for
+// a dry run.
+func (s *System) GarbageCollectScoped(opts *GCOptions, q *WriteOptions) (*GCResponse, *WriteMeta, error) {
+	v := url.Values{}
+	if opts != nil {
+		if len(opts.Collections) != 0 {
+			v.Set("collections", strings.Join(opts.Collections, ","))
+		}
+		if opts.DryRun {
+			v.Set("dry_run", strconv.FormatBool(opts.DryRun))
+		}
+	}
+	endpoint := "/v1/system/gc"
+	if len(v) != 0 {
+		endpoint += "?" + v.Encode()
+	}
+
+	var resp GCResponse
+	wm, err := s.client.write(endpoint, nil, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, wm, nil
+}
+
 func (s *System) ReconcileSummaries() error {
 	var req struct{}
 	_, err := s.client.write("/v1/system/reconcile/summaries", &req, nil, nil)
 	return err
 }
+
+// GCOptions restricts a garbage collection to some collections, such as
+// "evals", or makes it a dry run.
+type GCOptions struct {
+	Collections []string
+	DryRun      bool
+}
+
+// GCResponse lists the objects that were garbage collected.
+type GCResponse struct {
+	Jobs        []NamespacedID
+	Evals       []string
+	Allocs      []string
+	Nodes       []string
+	Deployments []string
+}
+
+// NamespacedID is the ID of an object of a namespace.
+type NamespacedID struct {
+	ID        string
+	Namespace string
+}
@@ -32,10 +32,20 @@ type CpuStats struct {
 	Measured         []string
 }
 
-// ResourceUsage holds information related to cpu and memory stats
+// DiskStats holds the block IO stats of a task
+type DiskStats struct {
+	ReadBytes  uint64
+	WriteBytes uint64
+	ReadRate   float64
+	WriteRate  float64
+	Measured   []string
+}
+
+// ResourceUsage holds information related to cpu, memory and disk stats
 type ResourceUsage struct {
 	MemoryStats *MemoryStats
 	CpuStats    *CpuStats
+	DiskStats   *DiskStats
 }
 
 // TaskResourceUsage holds aggregated resource usage of all processes in a Task
@@ -229,6 +239,8 @@ type TaskGroup struct {
 	RestartPolicy *RestartPolicy
 	EphemeralDisk *EphemeralDisk
 	Update        *UpdateStrategy
+	DNS           *DNSConfig
+	Migrate       *MigrateStrategy
 	Meta          map[string]string
 }
 
@@ -325,8 +337,16 @@ func (g *TaskGroup) RequireDisk(disk *EphemeralDisk) *TaskGroup {
 
 // LogConfig provides configuration for log rotation
 type LogConfig struct {
-	MaxFiles      *int `mapstructure:"max_files"`
-	MaxFileSizeMB *int `mapstructure:"max_file_size"`
+	MaxFiles      *int       `mapstructure:"max_files"`
+	MaxFileSizeMB *int       `mapstructure:"max_file_size"`
+	Sinks         []*LogSink `mapstructure:"sink"`
+}
+
+// LogSink is a remote destination the logs of a task are shipped to
+type LogSink struct {
+	Type    string
+	Address string            `mapstructure:"address"`
+	Labels  map[string]string `mapstructure:"labels"`
 }
 
 func DefaultLogConfig() *LogConfig {
@@ -350,26 +370,42 @@ type DispatchPayloadConfig struct {
 	File string
 }
 
+// MigrateStrategy overrides how a draining client migrates the allocations of
+// a task group
+type MigrateStrategy struct {
+	Deadline time.Duration
+	Ignore   bool
+}
+
+// DNSConfig configures the resolver of the tasks of an allocation
+type DNSConfig struct {
+	Servers  []string
+	Searches []string
+	Options  []string
+}
+
 // Task is a single process in a task group.
 type Task struct {
-	Name            string
-	Driver          string
-	User            string
-	Config          map[string]interface{}
-	Constraints     []*Constraint
-	Env             map[string]string
-	Services        []*Service
-	Resources       *Resources
-	Meta            map[string]string
-	KillTimeout     *time.Duration `mapstructure:"kill_timeout"`
-	LogConfig       *LogConfig     `mapstructure:"logs"`
-	Artifacts       []*TaskArtifact
-	Vault           *Vault
-	Templates       []*Template
-	DispatchPayload *DispatchPayloadConfig
-	Leader          bool
-	ShutdownDelay   time.Duration `mapstructure:"shutdown_delay"`
-	KillSignal      string        `mapstructure:"kill_signal"`
+	Name               string
+	Driver             string
+	User               string
+	Config             map[string]interface{}
+	Constraints        []*Constraint
+	Env                map[string]string
+	Services           []*Service
+	Resources          *Resources
+	Meta               map[string]string
+	KillTimeout        *time.Duration `mapstructure:"kill_timeout"`
+	LogConfig          *LogConfig     `mapstructure:"logs"`
+	Artifacts          []*TaskArtifact
+	Vault              *Vault
+	Templates          []*Template
+	ConnectivityChecks []*ConnectivityCheck
+	DispatchPayload    *DispatchPayloadConfig
+	DNS                *DNSConfig
+	Leader             bool
+	ShutdownDelay      time.Duration `mapstructure:"shutdown_delay"`
+	KillSignal         string        `mapstructure:"kill_signal"`
 }
 
 func (t *Task) Canonicalize(tg *TaskGroup, job *Job) {
@@ -397,6 +433,33 @@ func (t *Task) Canonicalize(tg *TaskGroup, job *Job) {
 	for _, s := range t.Services {
 		s.Canonicalize(t, tg, job)
 	}
+	for _, c := range t.ConnectivityChecks {
+		c.Canonicalize()
+	}
+}
+
+// ConnectivityCheck verifies that an address the task depends on is reachable
+// once the task is started.
+type ConnectivityCheck struct {
+	Name      string
+	Type      string
+	Address   string
+	PortLabel string `mapstructure:"port"`
+	Path      string
+	Interval  time.Duration
+	Timeout   time.Duration
+}
+
+func (c *ConnectivityCheck) Canonicalize() {
+	if c.Type == "" {
+		c.Type = "tcp"
+	}
+	if c.Interval == 0 {
+		c.Interval = 5 * time.Second
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 2 * time.Second
+	}
 }
 
 // TaskArtifact is used to download artifacts before running a task.
@@ -554,13 +617,24 @@ func (t *Task) SetLogConfig(l *LogConfig) *Task {
 // TaskState tracks the current state of a task and events that caused state
 // transitions.
 type TaskState struct {
-	State       string
-	Failed      bool
-	Restarts    uint64
-	LastRestart time.Time
-	StartedAt   time.Time
-	FinishedAt  time.Time
-	Events      []*TaskEvent
+	State         string
+	Failed        bool
+	Restarts      uint64
+	LastRestart   time.Time
+	StartedAt     time.Time
+	FinishedAt    time.Time
+	ImageDigest   string
+	ImageSBOM     string
+	ResourceUsage *TaskResourceUsageSummary
+	Events        []*TaskEvent
+}
+
+// TaskResourceUsageSummary is the latest resource usage of a task reported
+// for the canary analysis of deployments.
+type TaskResourceUsageSummary struct {
+	CPUTicks  float64
+	MemoryRSS uint64
+	Timestamp int64
 }
 
 const (
@@ -583,6 +657,8 @@ const (
 	TaskRestartSignal          = "Restart Signaled"
 	TaskLeaderDead             = "Leader Task Dead"
 	TaskBuildingTaskDir        = "Building Task Directory"
+	TaskCheckpointed           = "Checkpointed"
+	TaskRestored               = "Restored"
 )
 
 // TaskEvent is an event that effects the state of a task and contains meta-data
@@ -614,4 +690,10 @@ type TaskEvent struct {
 	TaskSignalReason string
 	TaskSignal       string
 	GenericSource    string
+
+	// Task termination diagnostics
+	OOMKilled     bool
+	MemoryLimitMB int
+	CPULimit      int
+	StderrTail    []string
 }
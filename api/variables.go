@@ -0,0 +1,79 @@
+package api
+
+import "fmt"
+
+// Variables is used to query the variable endpoints.
+type Variables struct {
+	client *Client
+}
+
+// Variables returns a new handle on the variables.
+func (c *Client) Variables() *Variables {
+	return &Variables{client: c}
+}
+
+// List is used to list the variables of the namespace. The prefix of the
+// query options filters them by path.
+func (v *Variables) List(q *QueryOptions) ([]*VariableMetadata, *QueryMeta, error) {
+	var resp []*VariableMetadata
+	qm, err := v.client.query("/v1/vars", &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, qm, nil
+}
+
+// Put is used to create or update a variable
+func (v *Variables) Put(variable *Variable, q *WriteOptions) (*WriteMeta, error) {
+	if variable == nil || variable.Path == "" {
+		return nil, fmt.Errorf("missing variable path")
+	}
+	wm, err := v.client.write("/v1/var/"+variable.Path, variable, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// Delete is used to delete a variable
+func (v *Variables) Delete(path string, q *WriteOptions) (*WriteMeta, error) {
+	if path == "" {
+		return nil, fmt.Errorf("missing variable path")
+	}
+	wm, err := v.client.delete("/v1/var/"+path, nil, q)
+	if err != nil {
+		return nil, err
+	}
+	return wm, nil
+}
+
+// Read is used to read a variable and its items
+func (v *Variables) Read(path string, q *QueryOptions) (*Variable, *QueryMeta, error) {
+	if path == "" {
+		return nil, nil, fmt.Errorf("missing variable path")
+	}
+	var resp Variable
+	qm, err := v.client.query("/v1/var/"+path, &resp, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &resp, qm, nil
+}
+
+// Variable is a set of secret items stored at a path of a namespace.
+type Variable struct {
+	Namespace   string
+	Path        string
+	Items       map[string]string
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// VariableMetadata is the metadata of a variable returned when listing
+// variables.
+type VariableMetadata struct {
+	Namespace   string
+	Path        string
+	CreateIndex uint64
+	ModifyIndex uint64
+}
@@ -84,6 +84,9 @@ func (c *Client) ResolveToken(secretID string) (*acl.ACL, error) {
 	if token == nil {
 		return nil, structs.ErrTokenNotFound
 	}
+	if token.IsExpired(time.Now()) {
+		return nil, structs.ErrTokenExpired
+	}
 
 	// Check if this is a management token
 	if token.Type == structs.ACLManagementToken {
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"fmt"
+	"sort"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/command/agent/consul"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// AllocChecks returns the status of the service checks of the allocation's
+// tasks.
+func (c *Client) AllocChecks(allocID string) ([]*cstructs.CheckStatus, error) {
+	c.allocLock.RLock()
+	_, ok := c.allocs[allocID]
+	c.allocLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown allocation ID %q", allocID)
+	}
+
+	reg, err := c.consulService.AllocRegistrations(allocID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Consul registrations: %v", err)
+	}
+	return allocCheckStatuses(reg), nil
+}
+
+// allocCheckStatuses returns the status of the checks of an allocation's
+// registrations sorted by task, service and check name.
+func allocCheckStatuses(reg *consul.AllocRegistration) []*cstructs.CheckStatus {
+	out := []*cstructs.CheckStatus{}
+	if reg == nil {
+		return out
+	}
+
+	for task, treg := range reg.Tasks {
+		for _, sreg := range treg.Services {
+			for _, check := range sreg.Checks {
+				status := &cstructs.CheckStatus{
+					Task:     task,
+					Service:  check.ServiceName,
+					Name:     check.Name,
+					CheckID:  check.CheckID,
+					Provider: cstructs.CheckProviderConsul,
+					Status:   check.Status,
+					Output:   check.Output,
+				}
+				if def, ok := sreg.CheckDefinitions[check.CheckID]; ok {
+					status.Type = def.Type
+					status.Interval = def.Interval
+					status.Timeout = def.Timeout
+					if def.Type == structs.ServiceCheckScript {
+						status.Provider = cstructs.CheckProviderNomad
+					}
+				}
+
+				if run, ok := sreg.CheckRuns[check.CheckID]; ok {
+					status.LastRun = run.Time
+					status.LastDuration = run.Duration
+				}
+
+				out = append(out, status)
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Task != out[j].Task {
+			return out[i].Task < out[j].Task
+		}
+		if out[i].Service != out[j].Service {
+			return out[i].Service < out[j].Service
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
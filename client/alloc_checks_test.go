@@ -0,0 +1,97 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/command/agent/consul"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocCheckStatuses(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	// No registrations
+	require.Empty(allocCheckStatuses(nil))
+
+	lastRun := time.Now()
+	reg := &consul.AllocRegistration{
+		Tasks: map[string]*consul.TaskRegistration{
+			"web": {
+				Services: map[string]*consul.ServiceRegistration{
+					"_nomad-task-web": {
+						Checks: []*api.AgentCheck{
+							{
+								CheckID:     "script",
+								Name:        "ready",
+								Status:      api.HealthWarning,
+								Output:      "slow",
+								ServiceName: "web",
+							},
+							{
+								CheckID:     "http",
+								Name:        "alive",
+								Status:      api.HealthPassing,
+								ServiceName: "web",
+							},
+						},
+						CheckDefinitions: map[string]*structs.ServiceCheck{
+							"script": {
+								Name:     "ready",
+								Type:     structs.ServiceCheckScript,
+								Interval: 10 * time.Second,
+								Timeout:  2 * time.Second,
+							},
+							"http": {
+								Name:     "alive",
+								Type:     structs.ServiceCheckHTTP,
+								Interval: 5 * time.Second,
+								Timeout:  time.Second,
+							},
+						},
+						CheckRuns: map[string]*consul.CheckRun{
+							"script": {
+								Time:     lastRun,
+								Duration: 300 * time.Millisecond,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	out := allocCheckStatuses(reg)
+	require.Len(out, 2)
+
+	// Checks are sorted by name
+	require.Equal(&cstructs.CheckStatus{
+		Task:     "web",
+		Service:  "web",
+		Name:     "alive",
+		CheckID:  "http",
+		Type:     structs.ServiceCheckHTTP,
+		Provider: cstructs.CheckProviderConsul,
+		Status:   api.HealthPassing,
+		Interval: 5 * time.Second,
+		Timeout:  time.Second,
+	}, out[0])
+	require.Equal(&cstructs.CheckStatus{
+		Task:         "web",
+		Service:      "web",
+		Name:         "ready",
+		CheckID:      "script",
+		Type:         structs.ServiceCheckScript,
+		Provider:     cstructs.CheckProviderNomad,
+		Status:       api.HealthWarning,
+		Output:       "slow",
+		Interval:     10 * time.Second,
+		Timeout:      2 * time.Second,
+		LastRun:      lastRun,
+		LastDuration: 300 * time.Millisecond,
+	}, out[1])
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -14,6 +15,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/getter"
 	"github.com/hashicorp/nomad/client/vaultclient"
 	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -29,6 +31,13 @@ var (
 	allocRunnerStateAllocDirKey  = []byte("alloc-dir")
 )
 
+const (
+	// resourceUsageReportInterval is the interval at which the resource usage
+	// of the tasks of groups using canaries is reported to the servers for
+	// the canary analysis of deployments
+	resourceUsageReportInterval = 1 * time.Minute
+)
+
 // AllocStateUpdater is used to update the status of an allocation
 type AllocStateUpdater func(alloc *structs.Allocation)
 
@@ -77,6 +86,35 @@ type AllocRunner struct {
 	// to call.
 	prevAlloc prevAllocWatcher
 
+	// quarantine is passed to task runners to refuse starting tasks from
+	// quarantined images and artifacts. It may be nil.
+	quarantine *quarantineList
+
+	// variables is passed to task runners to read the variables of their
+	// tasks. It may be nil.
+	variables taskVariablesFn
+
+	// templateSource is passed to task runners to read the Nomad variables
+	// and services of their templates. It may be nil.
+	templateSource taskTemplateSourceFn
+
+	// netPolicies is passed to task runners to enforce the network policies
+	// once they start. It may be nil.
+	netPolicies *networkPolicyEnforcer
+
+	// artifactCache is passed to task runners to cache the artifacts they
+	// download. It may be nil.
+	artifactCache *getter.Cache
+
+	// dynamicUsers is passed to task runners to run their tasks as dynamic
+	// users, which are released once the alloc dir is destroyed. It may be
+	// nil.
+	dynamicUsers *dynamicUserPool
+
+	// workloadAPI serves the workload API socket of the allocation while its
+	// tasks run. It may be nil.
+	workloadAPI workloadAPIFn
+
 	// ctx is cancelled with exitFn to cause the alloc to be destroyed
 	// (stopped and GC'd).
 	ctx    context.Context
@@ -176,6 +214,8 @@ func NewAllocRunner(logger *log.Logger, config *config.Config, stateDB *bolt.DB,
 		consulClient:   consulClient,
 	}
 
+	ar.allocDir.SetMountDriver(config.MountDriver)
+
 	// TODO Should be passed a context
 	ar.ctx, ar.exitFn = context.WithCancel(context.TODO())
 
@@ -205,6 +245,31 @@ func (r *AllocRunner) setBaseLabels() {
 			Value: r.config.Node.ID,
 		})
 	}
+	r.baseLabels = append(r.baseLabels, tenantMetricLabels(r.config, r.alloc)...)
+}
+
+// tenantMetricLabels returns the namespace and node class labels of the
+// allocation metrics enabled by the telemetry configuration. They are opt-in
+// as they increase the cardinality of the metrics.
+func tenantMetricLabels(c *config.Config, alloc *structs.Allocation) []metrics.Label {
+	if c == nil {
+		return nil
+	}
+
+	var labels []metrics.Label
+	if c.MetricsNamespaceLabels {
+		labels = append(labels, metrics.Label{
+			Name:  "namespace",
+			Value: alloc.Namespace,
+		})
+	}
+	if c.MetricsNodeClassLabels && c.Node != nil {
+		labels = append(labels, metrics.Label{
+			Name:  "node_class",
+			Value: c.Node.NodeClass,
+		})
+	}
+	return labels
 }
 
 // pre060StateFilePath returns the path to our state file that would have been
@@ -308,6 +373,9 @@ func (r *AllocRunner) RestoreState() error {
 		return e
 	}
 
+	// The mount driver isn't persisted with the alloc dir
+	r.allocDir.SetMountDriver(r.config.MountDriver)
+
 	tg := r.alloc.Job.LookupTaskGroup(r.alloc.TaskGroup)
 	if tg == nil {
 		return fmt.Errorf("restored allocation doesn't contain task group %q", r.alloc.TaskGroup)
@@ -338,12 +406,20 @@ func (r *AllocRunner) RestoreState() error {
 			td = r.allocDir.NewTaskDir(name)
 		}
 
-		// Skip tasks in terminal states.
+		// Skip tasks in terminal states. Their dynamic user is held until
+		// the alloc dir is destroyed.
 		if state.State == structs.TaskStateDead {
+			restoreDynamicUser(r.stateDB, r.dynamicUsers, r.allocID, name)
 			continue
 		}
 
 		tr := NewTaskRunner(r.logger, r.config, r.stateDB, r.setTaskState, td, r.Alloc(), task, r.vaultClient, r.consulClient)
+		tr.quarantine = r.quarantine
+		tr.variables = r.variables
+		tr.templateSource = r.templateSource
+		tr.netPolicies = r.netPolicies
+		tr.artifactCache = r.artifactCache
+		tr.dynamicUsers = r.dynamicUsers
 		r.tasks[name] = tr
 
 		if restartReason, err := tr.RestoreState(); err != nil {
@@ -534,6 +610,9 @@ func (r *AllocRunner) Alloc() *structs.Allocation {
 	if r.allocClientStatus != "" || r.allocClientDescription != "" {
 		alloc.ClientStatus = r.allocClientStatus
 		alloc.ClientDescription = r.allocClientDescription
+		if alloc.ClientStatus == structs.AllocClientStatusFailed {
+			alloc.StopReason = structs.AllocStopReasonSetupFailed
+		}
 
 		// Copy over the task states so we don't lose them
 		r.taskStatusLock.RLock()
@@ -557,6 +636,9 @@ func (r *AllocRunner) Alloc() *structs.Allocation {
 	r.taskStatusLock.RLock()
 	alloc.TaskStates = copyTaskStates(r.taskStates)
 	alloc.ClientStatus = getClientStatus(r.taskStates)
+	if alloc.ClientStatus == structs.AllocClientStatusFailed {
+		alloc.StopReason = getFailedReason(r.taskStates)
+	}
 	r.taskStatusLock.RUnlock()
 
 	// If the client status is failed and we are part of a deployment, mark the
@@ -606,6 +688,22 @@ func getClientStatus(taskStates map[string]*structs.TaskState) string {
 	return ""
 }
 
+// getFailedReason takes in the task states of a failed allocation and returns
+// the reason it failed.
+func getFailedReason(taskStates map[string]*structs.TaskState) string {
+	for _, state := range taskStates {
+		if !state.Failed {
+			continue
+		}
+		for _, event := range state.Events {
+			if event.Type == structs.TaskTerminated && event.Details["oom_killed"] == "true" {
+				return structs.AllocStopReasonOOM
+			}
+		}
+	}
+	return structs.AllocStopReasonTaskFailed
+}
+
 // dirtySyncState is used to watch for state being marked dirty to sync
 func (r *AllocRunner) dirtySyncState() {
 	for {
@@ -686,6 +784,10 @@ func (r *AllocRunner) setTaskState(taskName, state string, event *structs.TaskEv
 			taskState.Restarts++
 			taskState.LastRestart = time.Unix(0, event.Time)
 		}
+		if event.Type == structs.TaskStarted {
+			taskState.ImageDigest = event.Details["image_digest"]
+			taskState.ImageSBOM = event.Details["image_sbom"]
+		}
 		r.appendTaskEvent(taskState, event)
 	}
 
@@ -781,6 +883,53 @@ func (r *AllocRunner) setTaskState(taskName, state string, event *structs.TaskEv
 	}
 }
 
+// reportResourceUsage periodically stores the latest resource usage of the
+// tasks in their state so the servers can compare canaries with the stable
+// allocations of the group.
+func (r *AllocRunner) reportResourceUsage() {
+	ticker := time.NewTicker(resourceUsageReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.ctx.Done():
+			return
+		}
+
+		updated := false
+		r.taskStatusLock.Lock()
+		for _, tr := range r.getTaskRunners() {
+			usage := tr.LatestResourceUsage()
+			if usage == nil || usage.ResourceUsage == nil {
+				continue
+			}
+			taskState, ok := r.taskStates[tr.task.Name]
+			if !ok {
+				continue
+			}
+
+			summary := &structs.TaskResourceUsageSummary{Timestamp: usage.Timestamp}
+			if cpu := usage.ResourceUsage.CpuStats; cpu != nil {
+				summary.CPUTicks = cpu.TotalTicks
+			}
+			if mem := usage.ResourceUsage.MemoryStats; mem != nil {
+				summary.MemoryRSS = mem.RSS
+			}
+			taskState.ResourceUsage = summary
+			updated = true
+		}
+		r.taskStatusLock.Unlock()
+
+		if updated {
+			select {
+			case r.dirtyCh <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
 // appendTaskEvent updates the task status by appending the new event.
 func (r *AllocRunner) appendTaskEvent(state *structs.TaskState, event *structs.TaskEvent) {
 	capacity := 10
@@ -879,6 +1028,15 @@ func (r *AllocRunner) Run() {
 	wCtx, watcherCancel := context.WithCancel(r.ctx)
 	go r.watchHealth(wCtx)
 
+	// Serve the workload API while the tasks run
+	var workloadAPI io.Closer
+	if r.workloadAPI != nil {
+		socket := filepath.Join(r.allocDir.SharedDir, workloadAPISocket)
+		if workloadAPI, err = r.workloadAPI(alloc, socket); err != nil {
+			r.logger.Printf("[WARN] client: alloc %q failed to serve the workload API: %v", r.allocID, err)
+		}
+	}
+
 	// Start the task runners
 	r.logger.Printf("[DEBUG] client: starting task runners for alloc '%s'", r.allocID)
 	r.taskLock.Lock()
@@ -892,6 +1050,12 @@ func (r *AllocRunner) Run() {
 		r.allocDirLock.Unlock()
 
 		tr := NewTaskRunner(r.logger, r.config, r.stateDB, r.setTaskState, taskdir, r.Alloc(), task.Copy(), r.vaultClient, r.consulClient)
+		tr.quarantine = r.quarantine
+		tr.variables = r.variables
+		tr.templateSource = r.templateSource
+		tr.netPolicies = r.netPolicies
+		tr.artifactCache = r.artifactCache
+		tr.dynamicUsers = r.dynamicUsers
 		r.tasks[task.Name] = tr
 		tr.MarkReceived()
 
@@ -899,6 +1063,11 @@ func (r *AllocRunner) Run() {
 	}
 	r.taskLock.Unlock()
 
+	// Report the resource usage of the tasks if the group uses canaries
+	if tg.Update != nil && tg.Update.Canary > 0 {
+		go r.reportResourceUsage()
+	}
+
 	// taskDestroyEvent contains an event that caused the destroyment of a task
 	// in the allocation.
 	var taskDestroyEvent *structs.TaskEvent
@@ -927,6 +1096,14 @@ OUTER:
 			// Check if we're in a terminal status
 			if update.TerminalStatus() {
 				taskDestroyEvent = structs.NewTaskEvent(structs.TaskKilled)
+
+				// Checkpoint the tasks of migrated allocations so that
+				// their replacements restore them
+				if shouldCheckpoint(update) {
+					for _, tr := range r.getTaskRunners() {
+						tr.CheckpointOnDestroy()
+					}
+				}
 				break OUTER
 			}
 
@@ -949,6 +1126,9 @@ OUTER:
 
 	// Kill the task runners
 	r.destroyTaskRunners(taskDestroyEvent)
+	if workloadAPI != nil {
+		workloadAPI.Close()
+	}
 
 	// Block until we should destroy the state of the alloc
 	r.handleDestroy()
@@ -959,6 +1139,17 @@ OUTER:
 	r.logger.Printf("[DEBUG] client: terminating runner for alloc '%s'", r.allocID)
 }
 
+// shouldCheckpoint returns whether the tasks of the allocation should be
+// checkpointed as it is stopped. Tasks are checkpointed when the allocation is
+// drained and its data migrated, which includes the checkpoints.
+func shouldCheckpoint(alloc *structs.Allocation) bool {
+	if alloc.StopReason != structs.AllocStopReasonDrained {
+		return false
+	}
+	tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+	return tg != nil && tg.EphemeralDisk != nil && tg.EphemeralDisk.Sticky && tg.EphemeralDisk.Migrate
+}
+
 // destroyTaskRunners destroys the task runners, waits for them to terminate and
 // then saves state.
 func (r *AllocRunner) destroyTaskRunners(destroyEvent *structs.TaskEvent) {
@@ -1045,6 +1236,7 @@ func (r *AllocRunner) handleDestroy() {
 				r.logger.Printf("[ERR] client: failed to destroy context for alloc '%s': %v",
 					r.allocID, err)
 			}
+			r.dynamicUsers.release(r.allocID)
 			if err := r.DestroyState(); err != nil {
 				r.logger.Printf("[ERR] client: failed to destroy state for alloc '%s': %v",
 					r.allocID, err)
@@ -1069,6 +1261,12 @@ func (r *AllocRunner) IsMigrating() bool {
 	return r.prevAlloc.IsMigrating()
 }
 
+// Migration returns the pending migration of data from the previous
+// allocation, or nil if there is none.
+func (r *AllocRunner) Migration() *cstructs.AllocMigration {
+	return r.prevAlloc.Migration()
+}
+
 // Update is used to update the allocation of the context
 func (r *AllocRunner) Update(update *structs.Allocation) {
 	select {
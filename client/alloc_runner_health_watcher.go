@@ -54,7 +54,7 @@ func (r *AllocRunner) watchHealth(ctx context.Context) {
 	r.logger.Printf("[DEBUG] client.alloc_watcher: deadline (%v) for alloc %q is at %v", tg.Update.HealthyDeadline, alloc.ID, deadline)
 
 	// Create the health tracker object
-	tracker := newAllocHealthTracker(healthCtx, r.logger, alloc, l, r.consulClient)
+	tracker := newAllocHealthTracker(healthCtx, r.logger, alloc, l, r.consulClient, r.driverHealthGated)
 	tracker.Start()
 
 	allocHealthy := false
@@ -117,6 +117,10 @@ type allocHealthTracker struct {
 	// consulClient is used to look up the state of the task's checks
 	consulClient ConsulServiceAPI
 
+	// driverHealthGated returns whether the health of the task reported by
+	// its driver gates the health of the allocation. It may be nil.
+	driverHealthGated func(task string) bool
+
 	// healthy is used to signal whether we have determined the allocation to be
 	// healthy or unhealthy
 	healthy chan bool
@@ -144,18 +148,21 @@ type allocHealthTracker struct {
 
 // newAllocHealthTracker returns a health tracker for the given allocation. An
 // alloc listener and consul API object are given so that the watcher can detect
-// health changes.
+// health changes, and driverHealthGated returns which tasks must be reported
+// healthy by their driver.
 func newAllocHealthTracker(parentCtx context.Context, logger *log.Logger, alloc *structs.Allocation,
-	allocUpdates *cstructs.AllocListener, consulClient ConsulServiceAPI) *allocHealthTracker {
+	allocUpdates *cstructs.AllocListener, consulClient ConsulServiceAPI,
+	driverHealthGated func(task string) bool) *allocHealthTracker {
 
 	a := &allocHealthTracker{
-		logger:       logger,
-		healthy:      make(chan bool, 1),
-		allocStopped: make(chan struct{}),
-		alloc:        alloc,
-		tg:           alloc.Job.LookupTaskGroup(alloc.TaskGroup),
-		allocUpdates: allocUpdates,
-		consulClient: consulClient,
+		logger:            logger,
+		healthy:           make(chan bool, 1),
+		allocStopped:      make(chan struct{}),
+		alloc:             alloc,
+		tg:                alloc.Job.LookupTaskGroup(alloc.TaskGroup),
+		allocUpdates:      allocUpdates,
+		consulClient:      consulClient,
+		driverHealthGated: driverHealthGated,
 	}
 
 	a.taskHealth = make(map[string]*taskHealthState, len(a.tg.Tasks))
@@ -272,6 +279,7 @@ func (a *allocHealthTracker) markAllocStopped() {
 func (a *allocHealthTracker) watchTaskEvents() {
 	alloc := a.alloc
 	allStartedTime := time.Time{}
+	minHealthyReached := false
 	healthyTimer := time.NewTimer(0)
 	if !healthyTimer.Stop() {
 		select {
@@ -289,10 +297,18 @@ func (a *allocHealthTracker) watchTaskEvents() {
 			return
 		}
 
-		// Store the task states
+		// Store the task states. Whether the drivers gate the health of the
+		// tasks is looked up before locking as it locks the alloc runner.
+		gated := make(map[string]bool, len(alloc.TaskStates))
+		if a.driverHealthGated != nil {
+			for task := range alloc.TaskStates {
+				gated[task] = a.driverHealthGated(task)
+			}
+		}
 		a.l.Lock()
 		for task, state := range alloc.TaskStates {
 			a.taskHealth[task].state = state
+			a.taskHealth[task].driverHealthGated = gated[task]
 		}
 		a.l.Unlock()
 
@@ -335,12 +351,20 @@ func (a *allocHealthTracker) watchTaskEvents() {
 			}
 
 			// Set the timer since all tasks are started
+			minHealthyReached = false
 			if !latestStartTime.IsZero() {
 				allStartedTime = latestStartTime
 				healthyTimer.Reset(a.tg.Update.MinHealthyTime)
 			}
 		}
 
+		// The tasks are healthy once they have been running for the minimum
+		// healthy time, their connectivity checks have passed and their
+		// drivers report them healthy when required
+		if minHealthyReached && a.connectivityPassing(alloc) && a.driverHealthPassing(alloc) {
+			a.setTaskHealth(true, false)
+		}
+
 		select {
 		case <-a.ctx.Done():
 			return
@@ -350,9 +374,34 @@ func (a *allocHealthTracker) watchTaskEvents() {
 			}
 			alloc = newAlloc
 		case <-healthyTimer.C:
-			a.setTaskHealth(true, false)
+			minHealthyReached = true
+		}
+	}
+}
+
+// connectivityPassing returns whether the connectivity checks of all the
+// allocation's tasks have passed since the tasks were last started.
+func (a *allocHealthTracker) connectivityPassing(alloc *structs.Allocation) bool {
+	for _, task := range a.tg.Tasks {
+		if len(failingConnectivityChecks(task, alloc.TaskStates[task.Name])) != 0 {
+			return false
 		}
 	}
+	return true
+}
+
+// driverHealthPassing returns whether the drivers of the allocation's tasks
+// gating its health on theirs reported them healthy since they were last
+// started.
+func (a *allocHealthTracker) driverHealthPassing(alloc *structs.Allocation) bool {
+	a.l.Lock()
+	defer a.l.Unlock()
+	for task, t := range a.taskHealth {
+		if t.driverHealthGated && !driverHealthy(alloc.TaskStates[task]) {
+			return false
+		}
+	}
+	return true
 }
 
 // watchConsulEvents iis a long lived watcher that watches for the health of the
@@ -465,6 +514,10 @@ type taskHealthState struct {
 	task              *structs.Task
 	state             *structs.TaskState
 	taskRegistrations *consul.TaskRegistration
+
+	// driverHealthGated marks whether the task must be reported healthy by
+	// its driver
+	driverHealthGated bool
 }
 
 // event takes the deadline time for the allocation to be healthy and the update
@@ -493,6 +546,14 @@ func (t *taskHealthState) event(deadline time.Time, update *structs.UpdateStrate
 		if t.state.StartedAt.Add(update.MinHealthyTime).After(deadline) {
 			return fmt.Sprintf("Task not running for min_healthy_time of %v by deadline", update.MinHealthyTime), true
 		}
+
+		if failing := failingConnectivityChecks(t.task, t.state); len(failing) != 0 {
+			return fmt.Sprintf("Connectivity checks not passing by deadline: %s", strings.Join(failing, ", ")), true
+		}
+
+		if t.driverHealthGated && !driverHealthy(t.state) {
+			return "Task not reported healthy by driver by deadline", true
+		}
 	}
 
 	if t.taskRegistrations != nil {
@@ -11,6 +11,7 @@ import (
 	"text/template"
 	"time"
 
+	metrics "github.com/armon/go-metrics"
 	"github.com/boltdb/bolt"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/go-multierror"
@@ -1534,3 +1535,43 @@ func TestAllocRunner_MoveAllocDir(t *testing.T) {
 		t.Fatalf("file %v not found", dataFile)
 	}
 }
+
+func TestAllocRunner_GetFailedReason(t *testing.T) {
+	t.Parallel()
+
+	oom := structs.NewTaskEvent(structs.TaskTerminated).SetExitCode(137).SetOOMKilled(true)
+	exited := structs.NewTaskEvent(structs.TaskTerminated).SetExitCode(1)
+
+	states := map[string]*structs.TaskState{
+		"web":     {State: structs.TaskStateDead, Failed: true, Events: []*structs.TaskEvent{exited}},
+		"sidecar": {State: structs.TaskStateDead, Events: []*structs.TaskEvent{oom}},
+	}
+	if reason := getFailedReason(states); reason != structs.AllocStopReasonTaskFailed {
+		t.Fatalf("expected %q; got %q", structs.AllocStopReasonTaskFailed, reason)
+	}
+
+	states["sidecar"].Failed = true
+	if reason := getFailedReason(states); reason != structs.AllocStopReasonOOM {
+		t.Fatalf("expected %q; got %q", structs.AllocStopReasonOOM, reason)
+	}
+}
+
+func TestAllocRunner_TenantMetricLabels(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	alloc := mock.Alloc()
+	conf := config.DefaultConfig()
+	conf.Node = mock.Node()
+	conf.Node.NodeClass = "batch"
+
+	// Disabled by default
+	assert.Empty(tenantMetricLabels(conf, alloc))
+
+	conf.MetricsNamespaceLabels = true
+	conf.MetricsNodeClassLabels = true
+	assert.Equal([]metrics.Label{
+		{Name: "namespace", Value: alloc.Namespace},
+		{Name: "node_class", Value: "batch"},
+	}, tenantMetricLabels(conf, alloc))
+}
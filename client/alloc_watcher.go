@@ -47,6 +47,10 @@ type prevAllocWatcher interface {
 
 	// IsMigrating returns true if a concurrent caller is in Migrate
 	IsMigrating() bool
+
+	// Migration returns the pending migration of the data of the previous
+	// alloc, or nil if there is no data to migrate or it has been migrated
+	Migration() *cstructs.AllocMigration
 }
 
 // newAllocWatcher creates a prevAllocWatcher appropriate for whether this
@@ -80,6 +84,7 @@ func newAllocWatcher(alloc *structs.Allocation, prevAR *AllocRunner, rpc rpcer,
 		prevAllocID:  alloc.PreviousAllocation,
 		tasks:        tg.Tasks,
 		config:       config,
+		sticky:       tg.EphemeralDisk != nil && tg.EphemeralDisk.Sticky,
 		migrate:      tg.EphemeralDisk != nil && tg.EphemeralDisk.Migrate,
 		rpc:          rpc,
 		logger:       l,
@@ -117,10 +122,12 @@ type localPrevAlloc struct {
 	prevWaitCh <-chan struct{}
 
 	// waiting and migrating are true when alloc runner is waiting on the
-	// prevAllocWatcher. Writers must acquire the waitingLock and readers
-	// should use the helper methods IsWaiting and IsMigrating.
+	// prevAllocWatcher, and migrated once Migrate returned. Writers must
+	// acquire the waitingLock and readers should use the helper methods
+	// IsWaiting and IsMigrating.
 	waiting     bool
 	migrating   bool
+	migrated    bool
 	waitingLock sync.RWMutex
 
 	logger *log.Logger
@@ -142,6 +149,25 @@ func (p *localPrevAlloc) IsMigrating() bool {
 	return b
 }
 
+// Migration returns the pending move of the sticky data of the previous alloc
+func (p *localPrevAlloc) Migration() *cstructs.AllocMigration {
+	if !p.sticky {
+		return nil
+	}
+
+	p.waitingLock.RLock()
+	defer p.waitingLock.RUnlock()
+	if p.migrated {
+		return nil
+	}
+	return &cstructs.AllocMigration{
+		AllocID:         p.allocID,
+		PreviousAllocID: p.prevAllocID,
+		Local:           true,
+		Status:          migrationStatus(p.waiting, p.migrating),
+	}
+}
+
 // Wait on a local alloc to become terminal, exit, or the context to be done.
 func (p *localPrevAlloc) Wait(ctx context.Context) error {
 	p.waitingLock.Lock()
@@ -189,21 +215,41 @@ func (p *localPrevAlloc) Migrate(ctx context.Context, dest *allocdir.AllocDir) e
 	defer func() {
 		p.waitingLock.Lock()
 		p.migrating = false
+		p.migrated = true
 		p.waitingLock.Unlock()
 	}()
 
-	p.logger.Printf("[DEBUG] client: alloc %q copying previous alloc %q", p.allocID, p.prevAllocID)
+	p.logger.Printf("[DEBUG] client: alloc %q moving previous alloc %q in place", p.allocID, p.prevAllocID)
+	return moveLocalAllocDir(p.logger, dest, p.prevAllocDir, p.tasks)
+}
 
-	moveErr := dest.Move(p.prevAllocDir, p.tasks)
+// moveLocalAllocDir hands the data of a previous alloc on this node over to
+// dest by renaming its directories, so that no data is copied. The previous
+// alloc dir is always destroyed.
+func moveLocalAllocDir(logger *log.Logger, dest, prevAllocDir *allocdir.AllocDir, tasks []*structs.Task) error {
+	moveErr := dest.Move(prevAllocDir, tasks)
 
 	// Always cleanup previous alloc
-	if err := p.prevAllocDir.Destroy(); err != nil {
-		p.logger.Printf("[ERR] client: error destroying allocdir %v: %v", p.prevAllocDir.AllocDir, err)
+	if err := prevAllocDir.Destroy(); err != nil {
+		logger.Printf("[ERR] client: error destroying allocdir %v: %v", prevAllocDir.AllocDir, err)
 	}
 
 	return moveErr
 }
 
+// migrationStatus returns the status of a migration given whether it is
+// waiting on or migrating from the previous alloc
+func migrationStatus(waiting, migrating bool) string {
+	switch {
+	case waiting:
+		return cstructs.AllocMigrationStatusWaiting
+	case migrating:
+		return cstructs.AllocMigrationStatusMigrating
+	default:
+		return cstructs.AllocMigrationStatusPending
+	}
+}
+
 // remotePrevAlloc is a prevAllcWatcher for previous allocations on remote
 // nodes as an updated allocation.
 type remotePrevAlloc struct {
@@ -219,6 +265,10 @@ type remotePrevAlloc struct {
 	// config for the Client to get AllocDir, Region, and Node.SecretID
 	config *config.Config
 
+	// sticky is true if data should be moved when the previous alloc turns
+	// out to be on this node
+	sticky bool
+
 	// migrate is true if data should be moved between nodes
 	migrate bool
 
@@ -226,17 +276,24 @@ type remotePrevAlloc struct {
 	// alloc and determining what node it was on.
 	rpc rpcer
 
-	// nodeID is the node the previous alloc. Set by Wait() for use in
-	// Migrate() iff the previous alloc has not already been GC'd.
+	// nodeID is the node the previous alloc. Set by Wait() under the
+	// waitingLock for use in Migrate() iff the previous alloc has not already
+	// been GC'd.
 	nodeID string
 
 	// waiting and migrating are true when alloc runner is waiting on the
-	// prevAllocWatcher. Writers must acquire the waitingLock and readers
-	// should use the helper methods IsWaiting and IsMigrating.
+	// prevAllocWatcher, and migrated once Migrate returned. Writers must
+	// acquire the waitingLock and readers should use the helper methods
+	// IsWaiting and IsMigrating.
 	waiting     bool
 	migrating   bool
+	migrated    bool
 	waitingLock sync.RWMutex
 
+	// local is true if the data is being moved from the alloc dir of the
+	// previous alloc on this node. Set by Migrate() under the waitingLock.
+	local bool
+
 	logger *log.Logger
 
 	// migrateToken allows a client to migrate data in an ACL-protected remote
@@ -260,6 +317,49 @@ func (p *remotePrevAlloc) IsMigrating() bool {
 	return b
 }
 
+// Migration returns the pending migration of the data of the previous alloc.
+// The migration is local if the previous alloc dir is on this node.
+func (p *remotePrevAlloc) Migration() *cstructs.AllocMigration {
+	p.waitingLock.RLock()
+	defer p.waitingLock.RUnlock()
+	if p.migrated {
+		return nil
+	}
+
+	// The data of a remote alloc is downloaded to its alloc dir on this node
+	// so only check for it before migrating
+	local := p.local
+	if !p.migrating {
+		local = p.localAllocDir() != nil
+	}
+	if !p.migrate && !(local && p.sticky) {
+		return nil
+	}
+	return &cstructs.AllocMigration{
+		AllocID:         p.allocID,
+		PreviousAllocID: p.prevAllocID,
+		Local:           local,
+		Status:          migrationStatus(p.waiting, p.migrating),
+	}
+}
+
+// localAllocDir returns the alloc dir of the previous alloc if it is on this
+// node, which happens when its alloc runner is gone before the alloc dir is
+// destroyed, such as when the client restarted. The node of the previous
+// alloc is only known once Wait returned.
+func (p *remotePrevAlloc) localAllocDir() *allocdir.AllocDir {
+	if p.nodeID == "" || p.config.Node == nil || p.nodeID != p.config.Node.ID {
+		return nil
+	}
+	dir := filepath.Join(p.config.AllocDir, p.prevAllocID)
+	if fi, err := os.Stat(filepath.Join(dir, allocdir.SharedAllocName)); err != nil || !fi.IsDir() {
+		return nil
+	}
+	allocDir := allocdir.NewAllocDir(p.logger, dir)
+	allocDir.SetMountDriver(p.config.MountDriver)
+	return allocDir
+}
+
 // Wait until the remote previousl allocation has terminated.
 func (p *remotePrevAlloc) Wait(ctx context.Context) error {
 	p.waitingLock.Lock()
@@ -309,7 +409,9 @@ func (p *remotePrevAlloc) Wait(ctx context.Context) error {
 		}
 		if resp.Alloc.Terminated() {
 			// Terminated!
+			p.waitingLock.Lock()
 			p.nodeID = resp.Alloc.NodeID
+			p.waitingLock.Unlock()
 			return nil
 		}
 
@@ -323,22 +425,31 @@ func (p *remotePrevAlloc) Wait(ctx context.Context) error {
 }
 
 // Migrate alloc data from a remote node if the new alloc has migration enabled
-// and the old alloc hasn't been GC'd.
+// and the old alloc hasn't been GC'd. If the old alloc dir is on this node its
+// data is moved in place instead.
 func (p *remotePrevAlloc) Migrate(ctx context.Context, dest *allocdir.AllocDir) error {
-	if !p.migrate {
+	prevAllocDir := p.localAllocDir()
+	if !p.migrate && !(prevAllocDir != nil && p.sticky) {
 		// Volume wasn't configured to be migrated, return early
 		return nil
 	}
 
 	p.waitingLock.Lock()
 	p.migrating = true
+	p.local = prevAllocDir != nil
 	p.waitingLock.Unlock()
 	defer func() {
 		p.waitingLock.Lock()
 		p.migrating = false
+		p.migrated = true
 		p.waitingLock.Unlock()
 	}()
 
+	if prevAllocDir != nil {
+		p.logger.Printf("[DEBUG] client: alloc %q moving previous alloc %q on this node in place", p.allocID, p.prevAllocID)
+		return moveLocalAllocDir(p.logger, dest, prevAllocDir, p.tasks)
+	}
+
 	p.logger.Printf("[DEBUG] client: alloc %q copying from remote previous alloc %q", p.allocID, p.prevAllocID)
 
 	if p.nodeID == "" {
@@ -353,7 +464,7 @@ func (p *remotePrevAlloc) Migrate(ctx context.Context, dest *allocdir.AllocDir)
 		return err
 	}
 
-	prevAllocDir, err := p.migrateAllocDir(ctx, addr)
+	prevAllocDir, err = p.migrateAllocDir(ctx, addr)
 	if err != nil {
 		return err
 	}
@@ -413,6 +524,7 @@ func (p *remotePrevAlloc) getNodeAddr(ctx context.Context, nodeID string) (strin
 func (p *remotePrevAlloc) migrateAllocDir(ctx context.Context, nodeAddr string) (*allocdir.AllocDir, error) {
 	// Create the previous alloc dir
 	prevAllocDir := allocdir.NewAllocDir(p.logger, filepath.Join(p.config.AllocDir, p.prevAllocID))
+	prevAllocDir.SetMountDriver(p.config.MountDriver)
 	if err := prevAllocDir.Build(); err != nil {
 		return nil, fmt.Errorf("error building alloc dir for previous alloc %q: %v", p.prevAllocID, err)
 	}
@@ -577,3 +689,5 @@ func (noopPrevAlloc) Migrate(context.Context, *allocdir.AllocDir) error { return
 
 func (noopPrevAlloc) IsWaiting() bool   { return false }
 func (noopPrevAlloc) IsMigrating() bool { return false }
+
+func (noopPrevAlloc) Migration() *cstructs.AllocMigration { return nil }
@@ -16,6 +16,7 @@ import (
 
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
+	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/client/testutil"
 	"github.com/hashicorp/nomad/nomad/mock"
 )
@@ -72,6 +73,65 @@ func TestPrevAlloc_LocalPrevAlloc(t *testing.T) {
 	}
 }
 
+// TestPrevAlloc_RemotePrevAlloc_LocalDir asserts that the data of a previous
+// alloc on this node without an alloc runner is moved in place.
+func TestPrevAlloc_RemotePrevAlloc_LocalDir(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := config.DefaultConfig()
+	conf.AllocDir = dir
+	conf.Node = mock.Node()
+
+	newAlloc := mock.Alloc()
+	newAlloc.PreviousAllocation = "prev"
+	newAlloc.Job.TaskGroups[0].EphemeralDisk.Sticky = true
+
+	// Build the previous alloc dir with data to move
+	prevAllocDir := allocdir.NewAllocDir(testLogger(), filepath.Join(dir, "prev"))
+	if err := prevAllocDir.Build(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	dataFile := filepath.Join(allocdir.SharedDataDir, "foo")
+	if err := ioutil.WriteFile(filepath.Join(prevAllocDir.SharedDir, dataFile), []byte("bar"), 0666); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	waiter := newAllocWatcher(newAlloc, nil, nil, conf, testLogger(), "").(*remotePrevAlloc)
+
+	// The node of the previous alloc is unknown until Wait returns
+	if m := waiter.Migration(); m != nil {
+		t.Fatalf("unexpected migration: %+v", m)
+	}
+	waiter.nodeID = conf.Node.ID
+	m := waiter.Migration()
+	if m == nil || !m.Local || m.Status != cstructs.AllocMigrationStatusPending || m.PreviousAllocID != "prev" {
+		t.Fatalf("unexpected migration: %+v", m)
+	}
+
+	dest := allocdir.NewAllocDir(testLogger(), filepath.Join(dir, newAlloc.ID))
+	if err := dest.Build(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := waiter.Migrate(context.Background(), dest); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if data, err := ioutil.ReadFile(filepath.Join(dest.SharedDir, dataFile)); err != nil || string(data) != "bar" {
+		t.Fatalf("data not moved: %q %v", data, err)
+	}
+	if _, err := os.Stat(prevAllocDir.AllocDir); !os.IsNotExist(err) {
+		t.Fatalf("previous alloc dir not destroyed: %v", err)
+	}
+	if m := waiter.Migration(); m != nil {
+		t.Fatalf("unexpected migration after migrating: %+v", m)
+	}
+}
+
 // TestPrevAlloc_StreamAllocDir_Ok asserts that streaming a tar to an alloc dir
 // works.
 func TestPrevAlloc_StreamAllocDir_Ok(t *testing.T) {
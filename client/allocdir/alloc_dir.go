@@ -53,6 +53,11 @@ var (
 	// directory
 	TaskSecrets = "secrets"
 
+	// CheckpointDirName is the name of the directory of the alloc dir the
+	// tasks are checkpointed into. Complete checkpoints are included in
+	// snapshots.
+	CheckpointDirName = "checkpoints"
+
 	// TaskDirs is the set of directories created in each tasks directory.
 	TaskDirs = map[string]os.FileMode{TmpDirName: os.ModeSticky | 0777}
 )
@@ -72,6 +77,9 @@ type AllocDir struct {
 	// built is true if Build has successfully run
 	built bool
 
+	// mountDriver selects how the directories of the tasks are mounted
+	mountDriver string
+
 	logger *log.Logger
 }
 
@@ -112,10 +120,11 @@ func (d *AllocDir) Copy() *AllocDir {
 		return nil
 	}
 	dcopy := &AllocDir{
-		AllocDir:  d.AllocDir,
-		SharedDir: d.SharedDir,
-		TaskDirs:  make(map[string]*TaskDir, len(d.TaskDirs)),
-		logger:    d.logger,
+		AllocDir:    d.AllocDir,
+		SharedDir:   d.SharedDir,
+		TaskDirs:    make(map[string]*TaskDir, len(d.TaskDirs)),
+		mountDriver: d.mountDriver,
+		logger:      d.logger,
 	}
 	for k, v := range d.TaskDirs {
 		dcopy.TaskDirs[k] = v.Copy()
@@ -126,10 +135,21 @@ func (d *AllocDir) Copy() *AllocDir {
 // NewTaskDir creates a new TaskDir and adds it to the AllocDirs TaskDirs map.
 func (d *AllocDir) NewTaskDir(name string) *TaskDir {
 	td := newTaskDir(d.logger, d.AllocDir, name)
+	td.mountDriver = d.mountDriver
 	d.TaskDirs[name] = td
 	return td
 }
 
+// SetMountDriver sets how the directories of the tasks are mounted, as the
+// MountDriver of the client config. It is not persisted so it must be set
+// again on restored alloc dirs.
+func (d *AllocDir) SetMountDriver(driver string) {
+	d.mountDriver = driver
+	for _, td := range d.TaskDirs {
+		td.mountDriver = driver
+	}
+}
+
 // Snapshot creates an archive of the files and directories in the data dir of
 // the allocation and the task local directories
 //
@@ -141,6 +161,14 @@ func (d *AllocDir) Snapshot(w io.Writer) error {
 	rootPaths := []string{allocDataDir}
 	for _, taskdir := range d.TaskDirs {
 		rootPaths = append(rootPaths, taskdir.LocalDir)
+
+		// Include the checkpoints of the tasks so that they're restored
+		// on the destination
+		if c, err := taskdir.ReadCheckpoint(); err != nil {
+			d.logger.Printf("[WARN] client: failed to read checkpoint of task dir %q: %v", taskdir.Dir, err)
+		} else if c != nil {
+			rootPaths = append(rootPaths, taskdir.CheckpointDir)
+		}
 	}
 
 	tw := tar.NewWriter(w)
@@ -247,6 +275,18 @@ func (d *AllocDir) Move(other *AllocDir, tasks []*structs.Task) error {
 				return fmt.Errorf("error moving task %q local dir: %v", task.Name, err)
 			}
 		}
+
+		otherCheckpoint := filepath.Join(other.AllocDir, CheckpointDirName, task.Name)
+		if fileInfo, err := os.Stat(otherCheckpoint); fileInfo != nil && err == nil {
+			checkpointDir := filepath.Join(d.AllocDir, CheckpointDirName, task.Name)
+			if err := os.MkdirAll(filepath.Dir(checkpointDir), 0700); err != nil {
+				return fmt.Errorf("error creating checkpoints dir: %v", err)
+			}
+			os.RemoveAll(checkpointDir) // remove a previous checkpoint if it exists
+			if err := os.Rename(otherCheckpoint, checkpointDir); err != nil {
+				return fmt.Errorf("error moving task %q checkpoint dir: %v", task.Name, err)
+			}
+		}
 	}
 
 	return nil
@@ -276,7 +316,7 @@ func (d *AllocDir) UnmountAll() error {
 	for _, dir := range d.TaskDirs {
 		// Check if the directory has the shared alloc mounted.
 		if pathExists(dir.SharedTaskDir) {
-			if err := unlinkDir(dir.SharedTaskDir); err != nil {
+			if err := unlinkDir(dir.mountDriver, dir.SharedTaskDir); err != nil {
 				mErr.Errors = append(mErr.Errors,
 					fmt.Errorf("failed to unmount shared alloc dir %q: %v", dir.SharedTaskDir, err))
 			} else if err := os.RemoveAll(dir.SharedTaskDir); err != nil {
@@ -286,7 +326,7 @@ func (d *AllocDir) UnmountAll() error {
 		}
 
 		if pathExists(dir.SecretsDir) {
-			if err := removeSecretDir(dir.SecretsDir); err != nil {
+			if err := removeSecretDir(dir.mountDriver, dir.SecretsDir); err != nil {
 				mErr.Errors = append(mErr.Errors,
 					fmt.Errorf("failed to remove the secret dir %q: %v", dir.SecretsDir, err))
 			}
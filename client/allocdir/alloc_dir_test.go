@@ -284,6 +284,93 @@ func TestAllocDir_Move(t *testing.T) {
 	}
 }
 
+func TestAllocDir_Checkpoint(t *testing.T) {
+	tmp1, err := ioutil.TempDir("", "AllocDir")
+	if err != nil {
+		t.Fatalf("Couldn't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp1)
+
+	tmp2, err := ioutil.TempDir("", "AllocDir")
+	if err != nil {
+		t.Fatalf("Couldn't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp2)
+
+	d1 := NewAllocDir(testLogger(), tmp1)
+	if err := d1.Build(); err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	defer d1.Destroy()
+
+	td1 := d1.NewTaskDir(t1.Name)
+	if err := td1.Build(false, nil, cstructs.FSIsolationImage); err != nil {
+		t.Fatalf("TaskDir.Build() failed: %v", err)
+	}
+
+	// An incomplete checkpoint isn't snapshotted
+	if err := td1.BuildCheckpointDir(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(td1.CheckpointDir, "pages.img"), []byte("foo"), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	snapshotted := func() bool {
+		var b bytes.Buffer
+		if err := d1.Snapshot(&b); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		tr := tar.NewReader(&b)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return false
+			} else if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if hdr.Name == filepath.Join(CheckpointDirName, t1.Name, "pages.img") {
+				return true
+			}
+		}
+	}
+	if snapshotted() {
+		t.Fatalf("incomplete checkpoint snapshotted")
+	}
+
+	if err := td1.CommitCheckpoint("exec"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !snapshotted() {
+		t.Fatalf("checkpoint not snapshotted")
+	}
+
+	// The checkpoint is moved along with the alloc dir
+	d2 := NewAllocDir(testLogger(), tmp2)
+	if err := d2.Build(); err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	defer d2.Destroy()
+	td2 := d2.NewTaskDir(t1.Name)
+	if err := d2.Move(d1, []*structs.Task{t1}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	c, err := td2.ReadCheckpoint()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if c == nil || c.Driver != "exec" || c.TaskDir != td1.Dir {
+		t.Fatalf("unexpected checkpoint: %+v", c)
+	}
+
+	if err := td2.RemoveCheckpoint(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if c, err := td2.ReadCheckpoint(); err != nil || c != nil {
+		t.Fatalf("checkpoint not removed: %+v %v", c, err)
+	}
+}
+
 func TestAllocDir_EscapeChecking(t *testing.T) {
 	tmp, err := ioutil.TempDir("", "AllocDir")
 	if err != nil {
@@ -0,0 +1,78 @@
+package allocdir
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// checkpointFile is written in the checkpoint dir of a task once its
+// checkpoint is complete
+const checkpointFile = "checkpoint.json"
+
+// Checkpoint describes the checkpoint of a task, from which the task is
+// restored when it starts.
+type Checkpoint struct {
+	// Driver is the driver that checkpointed the task
+	Driver string
+
+	// TaskDir is the task dir the task was checkpointed from, which the
+	// files of restored tasks refer to
+	TaskDir string
+
+	// Time is when the task was checkpointed
+	Time time.Time
+}
+
+// BuildCheckpointDir creates the empty checkpoint dir of the task, removing
+// any previous checkpoint.
+func (t *TaskDir) BuildCheckpointDir() error {
+	if err := os.RemoveAll(t.CheckpointDir); err != nil {
+		return err
+	}
+	return os.MkdirAll(t.CheckpointDir, 0700)
+}
+
+// CommitCheckpoint marks the checkpoint in the checkpoint dir of the task as
+// complete.
+func (t *TaskDir) CommitCheckpoint(driver string) error {
+	data, err := json.Marshal(&Checkpoint{
+		Driver:  driver,
+		TaskDir: t.Dir,
+		Time:    time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(t.CheckpointDir, checkpointFile), data, 0600)
+}
+
+// ReadCheckpoint returns the checkpoint of the task, or nil if the task has
+// no complete checkpoint.
+func (t *TaskDir) ReadCheckpoint() (*Checkpoint, error) {
+	data, err := ioutil.ReadFile(filepath.Join(t.CheckpointDir, checkpointFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var c Checkpoint
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// RemoveCheckpoint removes the checkpoint of the task so it is started anew.
+// The images of the checkpoint are kept until the next checkpoint since they
+// may still be read by a restore.
+func (t *TaskDir) RemoveCheckpoint() error {
+	err := os.Remove(filepath.Join(t.CheckpointDir, checkpointFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -6,7 +6,8 @@ import (
 	"path/filepath"
 	"syscall"
 
-	"golang.org/x/sys/unix"
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/helper/capabilities"
 )
 
 const (
@@ -20,18 +21,24 @@ const (
 
 // linkDir bind mounts src to dst as Linux doesn't support hardlinking
 // directories.
-func linkDir(src, dst string) error {
+func linkDir(driver, src, dst string) error {
 	if err := os.MkdirAll(dst, 0777); err != nil {
 		return err
 	}
 
+	if driver == config.MountDriverSystemd {
+		return systemdMount(src, dst, "none", "bind")
+	}
 	return syscall.Mount(src, dst, "", syscall.MS_BIND, "")
 }
 
 // unlinkDir unmounts a bind mounted directory as Linux doesn't support
 // hardlinking directories. If the dir is already unmounted no error is
 // returned.
-func unlinkDir(dir string) error {
+func unlinkDir(driver, dir string) error {
+	if driver == config.MountDriverSystemd {
+		return systemdUnmount(dir)
+	}
 	if err := syscall.Unmount(dir, 0); err != nil {
 		if err != syscall.EINVAL {
 			return err
@@ -42,9 +49,9 @@ func unlinkDir(dir string) error {
 
 // createSecretDir creates the secrets dir folder at the given path using a
 // tmpfs
-func createSecretDir(dir string) error {
-	// Only mount the tmpfs if we are root
-	if unix.Geteuid() == 0 {
+func createSecretDir(driver, dir string) error {
+	// Only mount the tmpfs if we are allowed to mount or systemd mounts it
+	if canMount(driver) {
 		if err := os.MkdirAll(dir, 0777); err != nil {
 			return err
 		}
@@ -55,11 +62,18 @@ func createSecretDir(dir string) error {
 			return nil
 		}
 
-		var flags uintptr
-		flags = syscall.MS_NOEXEC
-		options := fmt.Sprintf("size=%dm", secretDirTmpfsSize)
-		if err := syscall.Mount("tmpfs", dir, "tmpfs", flags, options); err != nil {
-			return os.NewSyscallError("mount", err)
+		if driver == config.MountDriverSystemd {
+			options := fmt.Sprintf("noexec,size=%dm", secretDirTmpfsSize)
+			if err := systemdMount("tmpfs", dir, "tmpfs", options); err != nil {
+				return err
+			}
+		} else {
+			var flags uintptr
+			flags = syscall.MS_NOEXEC
+			options := fmt.Sprintf("size=%dm", secretDirTmpfsSize)
+			if err := syscall.Mount("tmpfs", dir, "tmpfs", flags, options); err != nil {
+				return os.NewSyscallError("mount", err)
+			}
 		}
 
 		// Create the marker file so we don't try to mount more than once
@@ -76,9 +90,9 @@ func createSecretDir(dir string) error {
 }
 
 // createSecretDir removes the secrets dir folder
-func removeSecretDir(dir string) error {
-	if unix.Geteuid() == 0 {
-		if err := unlinkDir(dir); err != nil {
+func removeSecretDir(driver, dir string) error {
+	if canMount(driver) {
+		if err := unlinkDir(driver, dir); err != nil {
 			// Ignore invalid path errors
 			if err != syscall.ENOENT {
 				return os.NewSyscallError("unmount", err)
@@ -88,3 +102,9 @@ func removeSecretDir(dir string) error {
 	}
 	return os.RemoveAll(dir)
 }
+
+// canMount returns whether the directories of tasks can be mounted with the
+// mount driver.
+func canMount(driver string) bool {
+	return driver == config.MountDriverSystemd || capabilities.Has(capabilities.SysAdmin)
+}
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/client/config"
 	"golang.org/x/sys/unix"
 )
 
@@ -61,20 +62,20 @@ func TestLinuxRootSecretDir(t *testing.T) {
 	secretsDir := filepath.Join(tmpdir, TaskSecrets)
 
 	// removing a nonexistent secrets dir should NOT error
-	if err := removeSecretDir(secretsDir); err != nil {
+	if err := removeSecretDir(config.MountDriverSyscall, secretsDir); err != nil {
 		t.Fatalf("error removing nonexistent secrets dir %q: %v", secretsDir, err)
 	}
 	// run twice as it should be idemptotent
-	if err := removeSecretDir(secretsDir); err != nil {
+	if err := removeSecretDir(config.MountDriverSyscall, secretsDir); err != nil {
 		t.Fatalf("error removing nonexistent secrets dir %q: %v", secretsDir, err)
 	}
 
 	// creating a secrets dir should work
-	if err := createSecretDir(secretsDir); err != nil {
+	if err := createSecretDir(config.MountDriverSyscall, secretsDir); err != nil {
 		t.Fatalf("error creating secrets dir %q: %v", secretsDir, err)
 	}
 	// creating it again should be a noop (NO error)
-	if err := createSecretDir(secretsDir); err != nil {
+	if err := createSecretDir(config.MountDriverSyscall, secretsDir); err != nil {
 		t.Fatalf("error creating secrets dir %q: %v", secretsDir, err)
 	}
 
@@ -91,7 +92,7 @@ func TestLinuxRootSecretDir(t *testing.T) {
 	}
 
 	// now remove it
-	if err := removeSecretDir(secretsDir); err != nil {
+	if err := removeSecretDir(config.MountDriverSyscall, secretsDir); err != nil {
 		t.Fatalf("error removing secrets dir %q: %v", secretsDir, err)
 	}
 
@@ -101,7 +102,7 @@ func TestLinuxRootSecretDir(t *testing.T) {
 	}
 
 	// removing again should be a noop
-	if err := removeSecretDir(secretsDir); err != nil {
+	if err := removeSecretDir(config.MountDriverSyscall, secretsDir); err != nil {
 		t.Fatalf("error removing nonexistent secrets dir %q: %v", secretsDir, err)
 	}
 }
@@ -121,20 +122,20 @@ func TestLinuxUnprivilegedSecretDir(t *testing.T) {
 	secretsDir := filepath.Join(tmpdir, TaskSecrets)
 
 	// removing a nonexistent secrets dir should NOT error
-	if err := removeSecretDir(secretsDir); err != nil {
+	if err := removeSecretDir(config.MountDriverSyscall, secretsDir); err != nil {
 		t.Fatalf("error removing nonexistent secrets dir %q: %v", secretsDir, err)
 	}
 	// run twice as it should be idemptotent
-	if err := removeSecretDir(secretsDir); err != nil {
+	if err := removeSecretDir(config.MountDriverSyscall, secretsDir); err != nil {
 		t.Fatalf("error removing nonexistent secrets dir %q: %v", secretsDir, err)
 	}
 
 	// creating a secrets dir should work
-	if err := createSecretDir(secretsDir); err != nil {
+	if err := createSecretDir(config.MountDriverSyscall, secretsDir); err != nil {
 		t.Fatalf("error creating secrets dir %q: %v", secretsDir, err)
 	}
 	// creating it again should be a noop (NO error)
-	if err := createSecretDir(secretsDir); err != nil {
+	if err := createSecretDir(config.MountDriverSyscall, secretsDir); err != nil {
 		t.Fatalf("error creating secrets dir %q: %v", secretsDir, err)
 	}
 
@@ -151,7 +152,7 @@ func TestLinuxUnprivilegedSecretDir(t *testing.T) {
 	}
 
 	// now remove it
-	if err := removeSecretDir(secretsDir); err != nil {
+	if err := removeSecretDir(config.MountDriverSyscall, secretsDir); err != nil {
 		t.Fatalf("error removing secrets dir %q: %v", secretsDir, err)
 	}
 
@@ -161,7 +162,35 @@ func TestLinuxUnprivilegedSecretDir(t *testing.T) {
 	}
 
 	// removing again should be a noop
-	if err := removeSecretDir(secretsDir); err != nil {
+	if err := removeSecretDir(config.MountDriverSyscall, secretsDir); err != nil {
 		t.Fatalf("error removing nonexistent secrets dir %q: %v", secretsDir, err)
 	}
 }
+
+func TestLinuxParseMountPoint(t *testing.T) {
+	t.Parallel()
+	mountinfo := `22 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw
+36 22 0:31 / /var/lib/nomad/alloc/x/web/secrets rw,noexec shared:20 - tmpfs tmpfs rw,size=1024k
+37 22 8:1 /var/lib/nomad/alloc/x/alloc /var/lib/nomad/alloc/x/web\040task/alloc rw shared:1 - ext4 /dev/sda1 rw
+`
+	cases := map[string]bool{
+		"/":                                     true,
+		"/var/lib/nomad/alloc/x/web/secrets":    true,
+		"/var/lib/nomad/alloc/x/web task/alloc": true,
+		"/var/lib/nomad/alloc/x/web":            false,
+		"/var/lib/nomad/alloc/x/alloc":          false,
+	}
+	for path, expected := range cases {
+		mounted, err := parseMountPoint(strings.NewReader(mountinfo), path)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if mounted != expected {
+			t.Errorf("expected mount point %q to be mounted %v, got %v", path, expected, mounted)
+		}
+	}
+
+	if out := unescapeMountPath(`/a\040b\134c\011`); out != "/a b\\c\t" {
+		t.Fatalf("unexpected unescaped path %q", out)
+	}
+}
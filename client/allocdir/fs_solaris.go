@@ -6,22 +6,22 @@ import (
 )
 
 // linkDir hardlinks src to dst. The src and dst must be on the same filesystem.
-func linkDir(src, dst string) error {
+func linkDir(driver, src, dst string) error {
 	return syscall.Link(src, dst)
 }
 
 // unlinkDir removes a directory link.
-func unlinkDir(dir string) error {
+func unlinkDir(driver, dir string) error {
 	return syscall.Unlink(dir)
 }
 
 // createSecretDir creates the secrets dir folder at the given path
-func createSecretDir(dir string) error {
+func createSecretDir(driver, dir string) error {
 	// TODO solaris has support for tmpfs so use that
 	return os.MkdirAll(dir, 0777)
 }
 
 // removeSecretDir removes the secrets dir folder
-func removeSecretDir(dir string) error {
+func removeSecretDir(driver, dir string) error {
 	return os.RemoveAll(dir)
 }
@@ -10,7 +10,7 @@ import (
 	"strconv"
 	"syscall"
 
-	"golang.org/x/sys/unix"
+	"github.com/hashicorp/nomad/helper/capabilities"
 )
 
 var (
@@ -34,8 +34,8 @@ func dropDirPermissions(path string, desired os.FileMode) error {
 		return fmt.Errorf("Chmod(%v) failed: %v", path, err)
 	}
 
-	// Can't change owner if not root.
-	if unix.Geteuid() != 0 {
+	// Can't change owner without CAP_CHOWN.
+	if !capabilities.Has(capabilities.Chown) {
 		return nil
 	}
 
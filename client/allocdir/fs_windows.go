@@ -25,22 +25,22 @@ func linkOrCopy(src, dst string, uid, gid int, perm os.FileMode) error {
 }
 
 // The windows version does nothing currently.
-func linkDir(src, dst string) error {
+func linkDir(driver, src, dst string) error {
 	return nil
 }
 
 // The windows version does nothing currently.
-func unlinkDir(dir string) error {
+func unlinkDir(driver, dir string) error {
 	return nil
 }
 
 // createSecretDir creates the secrets dir folder at the given path
-func createSecretDir(dir string) error {
+func createSecretDir(driver, dir string) error {
 	return os.MkdirAll(dir, 0777)
 }
 
 // removeSecretDir removes the secrets dir folder
-func removeSecretDir(dir string) error {
+func removeSecretDir(driver, dir string) error {
 	return os.RemoveAll(dir)
 }
 
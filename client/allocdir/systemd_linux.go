@@ -0,0 +1,133 @@
+package allocdir
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/helper/systemd"
+)
+
+const (
+	// systemdMountTimeout is how long to wait for systemd to mount or
+	// unmount a directory of a task.
+	systemdMountTimeout = 10 * time.Second
+)
+
+// systemdMount mounts a file system at where through a transient mount unit,
+// so that systemd performs the mount on behalf of the client.
+func systemdMount(what, where, fstype, options string) error {
+	where, err := filepath.EvalSymlinks(where)
+	if err != nil {
+		return err
+	}
+
+	unit := systemd.EscapePath(where) + ".mount"
+	_, err = systemd.Busctl("call", "org.freedesktop.systemd1", "/org/freedesktop/systemd1",
+		"org.freedesktop.systemd1.Manager", "StartTransientUnit", "ssa(sv)a(sa(sv))",
+		unit, "fail", "4",
+		"Description", "s", fmt.Sprintf("Nomad task directory %s", where),
+		"What", "s", what,
+		"Type", "s", fstype,
+		"Options", "s", options,
+		"0")
+	if err != nil {
+		return fmt.Errorf("failed to start mount unit %q: %v", unit, err)
+	}
+	return waitMountPoint(unit, where, true)
+}
+
+// systemdUnmount unmounts the file system at where by stopping its mount
+// unit. If the path is not mounted no error is returned.
+func systemdUnmount(where string) error {
+	where, err := filepath.EvalSymlinks(where)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if mounted, err := isMountPoint(where); err != nil || !mounted {
+		return err
+	}
+
+	unit := systemd.EscapePath(where) + ".mount"
+	_, err = systemd.Busctl("call", "org.freedesktop.systemd1", "/org/freedesktop/systemd1",
+		"org.freedesktop.systemd1.Manager", "StopUnit", "ss", unit, "replace")
+	if err != nil {
+		return fmt.Errorf("failed to stop mount unit %q: %v", unit, err)
+	}
+	return waitMountPoint(unit, where, false)
+}
+
+// waitMountPoint waits for the job of the mount unit to mount or unmount the
+// path, as the jobs of units run asynchronously.
+func waitMountPoint(unit, path string, mounted bool) error {
+	deadline := time.Now().Add(systemdMountTimeout)
+	for {
+		ok, err := isMountPoint(path)
+		if err != nil {
+			return err
+		}
+		if ok == mounted {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for mount unit %q, see its logs with journalctl -u %q", unit, unit)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// isMountPoint returns whether a file system is mounted at the path.
+func isMountPoint(path string) (bool, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	return parseMountPoint(f, path)
+}
+
+// parseMountPoint returns whether the path is the mount point of any of the
+// mounts of a mountinfo file.
+func parseMountPoint(r io.Reader, path string) (bool, error) {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		if unescapeMountPath(fields[4]) == path {
+			return true, nil
+		}
+	}
+	return false, s.Err()
+}
+
+// unescapeMountPath unescapes the octal escapes of the whitespace and
+// backslashes in the paths of mountinfo files.
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, `\`) {
+		return path
+	}
+
+	var buf bytes.Buffer
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+4 <= len(path) {
+			if c, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				buf.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		buf.WriteByte(path[i])
+	}
+	return buf.String()
+}
@@ -37,6 +37,14 @@ type TaskDir struct {
 	// <task_dir>/secrets/
 	SecretsDir string
 
+	// CheckpointDir is the path to the directory the task is checkpointed
+	// into. It is kept out of the task directory so that tasks can't see it.
+	// <alloc_dir>/checkpoints/<task>/
+	CheckpointDir string
+
+	// mountDriver selects how the directories of the task are mounted
+	mountDriver string
+
 	logger *log.Logger
 }
 
@@ -53,6 +61,7 @@ func newTaskDir(logger *log.Logger, allocDir, taskName string) *TaskDir {
 		SharedTaskDir:  filepath.Join(taskDir, SharedAllocName),
 		LocalDir:       filepath.Join(taskDir, TaskLocal),
 		SecretsDir:     filepath.Join(taskDir, TaskSecrets),
+		CheckpointDir:  filepath.Join(allocDir, CheckpointDirName, taskName),
 		logger:         logger,
 	}
 }
@@ -107,14 +116,14 @@ func (t *TaskDir) Build(chrootCreated bool, chroot map[string]string, fsi cstruc
 		// If the path doesn't exist OR it exists and is empty, link it
 		empty, _ := pathEmpty(t.SharedTaskDir)
 		if !pathExists(t.SharedTaskDir) || empty {
-			if err := linkDir(t.SharedAllocDir, t.SharedTaskDir); err != nil {
+			if err := linkDir(t.mountDriver, t.SharedAllocDir, t.SharedTaskDir); err != nil {
 				return fmt.Errorf("Failed to mount shared directory for task: %v", err)
 			}
 		}
 	}
 
 	// Create the secret directory
-	if err := createSecretDir(t.SecretsDir); err != nil {
+	if err := createSecretDir(t.mountDriver, t.SecretsDir); err != nil {
 		return err
 	}
 
@@ -132,6 +141,32 @@ func (t *TaskDir) Build(chrootCreated bool, chroot map[string]string, fsi cstruc
 	return nil
 }
 
+// SetOwner gives the task directory, its local and secrets directories and
+// their content to the user, removing the access of any other user. The shared
+// alloc directory and the chroot are left as is.
+func (t *TaskDir) SetOwner(uid, gid int) error {
+	if err := os.Chown(t.Dir, uid, gid); err != nil {
+		return fmt.Errorf("Couldn't change owner/group of %v to (uid: %v, gid: %v): %v", t.Dir, uid, gid, err)
+	}
+	for _, dir := range []string{t.LocalDir, t.SecretsDir} {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			return os.Lchown(path, uid, gid)
+		})
+		if err != nil {
+			return fmt.Errorf("Couldn't change owner/group of %v to (uid: %v, gid: %v): %v", dir, uid, gid, err)
+		}
+	}
+	for _, dir := range []string{t.Dir, t.LocalDir, t.SecretsDir} {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return fmt.Errorf("Chmod(%v) failed: %v", dir, err)
+		}
+	}
+	return nil
+}
+
 // buildChroot takes a mapping of absolute directory or file paths on the host
 // to their intended, relative location within the task directory. This
 // attempts hardlink and then defaults to copying. If the path exists on the
@@ -7,6 +7,7 @@ import (
 	"syscall"
 
 	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/client/config"
 )
 
 // mountSpecialDirs mounts the dev and proc file system from the host to the
@@ -24,7 +25,7 @@ func (t *TaskDir) mountSpecialDirs() error {
 		return fmt.Errorf("error listing %q: %v", dev, err)
 	}
 	if devEmpty {
-		if err := syscall.Mount("none", dev, "devtmpfs", syscall.MS_RDONLY, ""); err != nil {
+		if err := t.mountReadOnly(dev, "devtmpfs"); err != nil {
 			return fmt.Errorf("Couldn't mount /dev to %v: %v", dev, err)
 		}
 	}
@@ -41,7 +42,7 @@ func (t *TaskDir) mountSpecialDirs() error {
 		return fmt.Errorf("error listing %q: %v", proc, err)
 	}
 	if procEmpty {
-		if err := syscall.Mount("none", proc, "proc", syscall.MS_RDONLY, ""); err != nil {
+		if err := t.mountReadOnly(proc, "proc"); err != nil {
 			return fmt.Errorf("Couldn't mount /proc to %v: %v", proc, err)
 		}
 	}
@@ -49,6 +50,14 @@ func (t *TaskDir) mountSpecialDirs() error {
 	return nil
 }
 
+// mountReadOnly mounts a read-only file system of the type at the path
+func (t *TaskDir) mountReadOnly(path, fstype string) error {
+	if t.mountDriver == config.MountDriverSystemd {
+		return systemdMount("none", path, fstype, "ro")
+	}
+	return syscall.Mount("none", path, fstype, syscall.MS_RDONLY, "")
+}
+
 // unmountSpecialDirs unmounts the dev and proc file system from the chroot. No
 // error is returned if the directories do not exist or have already been
 // unmounted.
@@ -56,7 +65,7 @@ func (t *TaskDir) unmountSpecialDirs() error {
 	errs := new(multierror.Error)
 	dev := filepath.Join(t.Dir, "dev")
 	if pathExists(dev) {
-		if err := unlinkDir(dev); err != nil {
+		if err := unlinkDir(t.mountDriver, dev); err != nil {
 			errs = multierror.Append(errs, fmt.Errorf("Failed to unmount dev %q: %v", dev, err))
 		} else if err := os.RemoveAll(dev); err != nil {
 			errs = multierror.Append(errs, fmt.Errorf("Failed to delete dev directory %q: %v", dev, err))
@@ -66,7 +75,7 @@ func (t *TaskDir) unmountSpecialDirs() error {
 	// Unmount proc.
 	proc := filepath.Join(t.Dir, "proc")
 	if pathExists(proc) {
-		if err := unlinkDir(proc); err != nil {
+		if err := unlinkDir(t.mountDriver, proc); err != nil {
 			errs = multierror.Append(errs, fmt.Errorf("Failed to unmount proc %q: %v", proc, err))
 		} else if err := os.RemoveAll(proc); err != nil {
 			errs = multierror.Append(errs, fmt.Errorf("Failed to delete proc directory %q: %v", dev, err))
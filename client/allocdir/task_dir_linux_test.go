@@ -70,3 +70,44 @@ func TestLinuxSpecialDirs(t *testing.T) {
 		t.Fatalf("error re-unmounting special dirs in %q: %v", td.Dir, err)
 	}
 }
+
+// TestTaskDir_SetOwner ensures the task dir is given to the user.
+func TestTaskDir_SetOwner(t *testing.T) {
+	if unix.Geteuid() != 0 {
+		t.Skip("Must be run as root")
+	}
+
+	allocDir, err := ioutil.TempDir("", "nomadtest-setowner")
+	if err != nil {
+		t.Fatalf("unable to create tempdir for test: %v", err)
+	}
+	defer os.RemoveAll(allocDir)
+
+	td := newTaskDir(testLogger(), allocDir, "test")
+	for _, dir := range []string{td.LocalDir, td.SecretsDir} {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			t.Fatalf("error creating dir %q: %v", dir, err)
+		}
+	}
+	artifact := filepath.Join(td.LocalDir, "artifact")
+	if err := ioutil.WriteFile(artifact, []byte("content"), 0644); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+
+	if err := td.SetOwner(70000, 70000); err != nil {
+		t.Fatalf("error setting owner: %v", err)
+	}
+
+	for _, path := range []string{td.Dir, td.LocalDir, td.SecretsDir, artifact} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("error stating %q: %v", path, err)
+		}
+		if uid, gid := getOwner(fi); uid != 70000 || gid != 70000 {
+			t.Errorf("%q is owned by %d:%d; want 70000:70000", path, uid, gid)
+		}
+		if path != artifact && fi.Mode().Perm() != 0700 {
+			t.Errorf("%q has mode %v; want 0700", path, fi.Mode().Perm())
+		}
+	}
+}
@@ -8,6 +8,9 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,6 +25,7 @@ import (
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver"
 	"github.com/hashicorp/nomad/client/fingerprint"
+	"github.com/hashicorp/nomad/client/getter"
 	"github.com/hashicorp/nomad/client/stats"
 	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/client/vaultclient"
@@ -61,6 +65,10 @@ const (
 	// devModeRetryIntv is the retry interval used for development
 	devModeRetryIntv = time.Second
 
+	// artifactOCIAuthConfigOption is the option naming the Docker config
+	// file holding the credentials of OCI registries
+	artifactOCIAuthConfigOption = "artifact.oci.auth.config"
+
 	// stateSnapshotIntv is how often the client snapshots state
 	stateSnapshotIntv = 60 * time.Second
 
@@ -80,6 +88,10 @@ const (
 	// allocSyncRetryIntv is the interval on which we retry updating
 	// the status of the allocation
 	allocSyncRetryIntv = 5 * time.Second
+
+	// drainPollIntv is how often the client checks whether its allocations
+	// have stopped when draining on leave.
+	drainPollIntv = time.Second
 )
 
 // ClientStatsReporter exposes all the APIs related to resource usage of a Nomad
@@ -120,9 +132,18 @@ type Client struct {
 	haveHeartbeated bool
 	heartbeatLock   sync.Mutex
 
+	// registeredNode is the node as last registered, which registration
+	// updates are sent as deltas of
+	registeredNode     *structs.Node
+	registeredNodeLock sync.Mutex
+
 	// triggerDiscoveryCh triggers Consul discovery; see triggerDiscovery
 	triggerDiscoveryCh chan struct{}
 
+	// triggerNodeUpdateCh triggers an immediate check for node changes; see
+	// triggerNodeUpdate
+	triggerNodeUpdateCh chan struct{}
+
 	// discovered will be ticked whenever Consul discovery completes
 	// successfully
 	serversDiscoveredCh chan struct{}
@@ -156,6 +177,36 @@ type Client struct {
 	// in the node automatically
 	garbageCollector *AllocGarbageCollector
 
+	// quarantine is the list of image digests and artifact checksums that
+	// must not be run, kept in sync with the servers
+	quarantine *quarantineList
+
+	// netPolicies enforces the network policies on the traffic between the
+	// tasks of the node
+	netPolicies *networkPolicyEnforcer
+
+	// externalDNS registers the addresses of the allocations with an
+	// external DNS provider. It is nil unless external_dns is configured.
+	externalDNS *externalDNSRegistrar
+
+	// dynamicUsers is the pool of the UIDs of the tasks run as dynamic users.
+	// It is nil unless "user.dynamic_range" is set.
+	dynamicUsers *dynamicUserPool
+
+	// artifactCache is the cache of the artifacts downloaded by the tasks of
+	// the node. It is nil if disabled. It is guarded by allocLock once the
+	// client runs since it is swapped when the configuration is reloaded.
+	artifactCache *getter.Cache
+
+	// reservedNetworks are the reserved networks of the configuration, to
+	// which the globally reserved ports are added. It is guarded by
+	// configLock.
+	reservedNetworks []*structs.NetworkResource
+
+	// dynamicMeta is the node metadata set or unset at runtime. It is
+	// guarded by configLock.
+	dynamicMeta map[string]*string
+
 	// clientACLResolver holds the ACL resolution state
 	clientACLResolver
 
@@ -196,13 +247,45 @@ func NewClient(cfg *config.Config, consulCatalog consul.CatalogAPI, consulServic
 		shutdownCh:          make(chan struct{}),
 		servers:             newServerList(),
 		triggerDiscoveryCh:  make(chan struct{}),
+		triggerNodeUpdateCh: make(chan struct{}, 1),
 		serversDiscoveredCh: make(chan struct{}),
+		quarantine:          newQuarantineList(),
+		netPolicies:         newNetworkPolicyEnforcer(),
 	}
 
 	// Initialize the client
 	if err := c.init(); err != nil {
 		return nil, fmt.Errorf("failed to initialize client: %v", err)
 	}
+	c.connPool.SetCompression(cfg.RPCCompression)
+
+	// Setup the artifact cache
+	if cfg.ArtifactCacheMaxMB > 0 {
+		dir := filepath.Join(c.config.StateDir, "artifacts")
+		c.artifactCache = getter.NewCache(dir, int64(cfg.ArtifactCacheMaxMB)*1024*1024)
+	}
+	getter.SetRegistryAuthConfig(c.config.Read(artifactOCIAuthConfigOption))
+
+	// Setup the pool of dynamic users
+	if spec := c.config.Read("user.dynamic_range"); spec != "" {
+		if runtime.GOOS != "linux" {
+			return nil, fmt.Errorf("dynamic users are only supported on Linux")
+		}
+		pool, err := newDynamicUserPool(spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup dynamic users: %v", err)
+		}
+		c.dynamicUsers = pool
+	}
+
+	// Setup the registration of the allocations with an external DNS provider
+	if cfg.ExternalDNS != nil {
+		d, err := newExternalDNSRegistrar(cfg.ExternalDNS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup external DNS: %v", err)
+		}
+		c.externalDNS = d
+	}
 
 	// Initialize the ACL state
 	if err := c.clientACLResolver.init(); err != nil {
@@ -221,6 +304,7 @@ func NewClient(cfg *config.Config, consulCatalog consul.CatalogAPI, consulServic
 		Interval:            cfg.GCInterval,
 		ParallelDestroys:    cfg.GCParallelDestroys,
 		ReservedDiskMB:      cfg.Node.Reserved.DiskMB,
+		Policies:            cfg.GCPolicies,
 	}
 	c.garbageCollector = NewAllocGarbageCollector(logger, statsCollector, c, gcConfig)
 	go c.garbageCollector.Run()
@@ -389,17 +473,209 @@ func (c *Client) reloadTLSConnections(newConfig *nconfig.TLSConfig) error {
 	return nil
 }
 
-// Reload allows a client to reload its configuration on the fly
+// Reload allows a client to reload its configuration on the fly. Besides the
+// TLS configuration, the chroot, the reserved resources, the max kill timeout
+// and the artifact settings are reloaded. Running allocations keep the
+// configuration they were started with.
 func (c *Client) Reload(newConfig *config.Config) error {
-	return c.reloadTLSConnections(newConfig.TLSConfig)
+	c.configLock.RLock()
+	tlsChanged := !c.config.TLSConfig.Equals(newConfig.TLSConfig)
+	c.configLock.RUnlock()
+	if tlsChanged {
+		if err := c.reloadTLSConnections(newConfig.TLSConfig); err != nil {
+			return err
+		}
+	}
+
+	c.configLock.Lock()
+	reserved := c.config.Node.Reserved
+	newReserved := newConfig.Node.Reserved
+	if newReserved == nil {
+		newReserved = &structs.Resources{}
+	}
+	reservedChanged := reserved.CPU != newReserved.CPU ||
+		reserved.MemoryMB != newReserved.MemoryMB ||
+		reserved.DiskMB != newReserved.DiskMB ||
+		reserved.IOPS != newReserved.IOPS ||
+		!reflect.DeepEqual(c.config.GloballyReservedPorts, newConfig.GloballyReservedPorts)
+
+	c.config.ChrootEnv = helper.CopyMapStringString(newConfig.ChrootEnv)
+	c.config.MaxKillTimeout = newConfig.MaxKillTimeout
+	c.config.ArtifactCacheMaxMB = newConfig.ArtifactCacheMaxMB
+	options := helper.CopyMapStringString(c.config.Options)
+	if options == nil {
+		options = make(map[string]string)
+	}
+	if authConfig := newConfig.Read(artifactOCIAuthConfigOption); authConfig != "" {
+		options[artifactOCIAuthConfigOption] = authConfig
+	} else {
+		delete(options, artifactOCIAuthConfigOption)
+	}
+	c.config.Options = options
+	getter.SetRegistryAuthConfig(newConfig.Read(artifactOCIAuthConfigOption))
+
+	if reservedChanged {
+		reserved.CPU = newReserved.CPU
+		reserved.MemoryMB = newReserved.MemoryMB
+		reserved.DiskMB = newReserved.DiskMB
+		reserved.IOPS = newReserved.IOPS
+		c.config.GloballyReservedPorts = helper.CopySliceInt(newConfig.GloballyReservedPorts)
+		c.reserveGlobalPorts()
+	}
+
+	// Allocations added from now on run with the new configuration
+	c.configCopy = c.config.Copy()
+	maxMB := c.config.ArtifactCacheMaxMB
+	c.configLock.Unlock()
+
+	c.reloadArtifactCache(maxMB)
+
+	if reservedChanged {
+		c.logger.Printf("[INFO] client: reserved resources changed, updating node")
+		go c.retryRegisterNode()
+	}
+	return nil
 }
 
-// Leave is used to prepare the client to leave the cluster
+// reloadArtifactCache resizes, enables or disables the artifact cache.
+// Running allocations keep the cache they were started with.
+func (c *Client) reloadArtifactCache(maxMB int) {
+	c.allocLock.Lock()
+	defer c.allocLock.Unlock()
+
+	switch {
+	case maxMB <= 0:
+		c.artifactCache = nil
+	case c.artifactCache == nil:
+		dir := filepath.Join(c.config.StateDir, "artifacts")
+		c.artifactCache = getter.NewCache(dir, int64(maxMB)*1024*1024)
+	default:
+		if err := c.artifactCache.SetMaxSize(int64(maxMB) * 1024 * 1024); err != nil {
+			c.logger.Printf("[WARN] client: failed to resize artifact cache: %v", err)
+		}
+	}
+}
+
+// Leave is used to prepare the client to leave the cluster. When draining on
+// leave, it returns once the allocations have stopped.
 func (c *Client) Leave() error {
-	// TODO
+	if !c.config.DrainOnLeave {
+		return nil
+	}
+	return c.drain(c.config.DrainDeadline)
+}
+
+// drain marks the node as draining, so that the servers migrate or stop its
+// allocations, and waits for the allocations to stop. The tasks are stopped as
+// usual, respecting their shutdown delay and kill timeout. Each allocation is
+// waited for up to the migrate deadline of its task group or else the
+// deadline, and the allocations of the groups ignoring drains are killed
+// without being waited for.
+func (c *Client) drain(deadline time.Duration) error {
+	c.logger.Printf("[INFO] client: draining node before leaving")
+	req := structs.NodeUpdateDrainRequest{
+		NodeID: c.NodeID(),
+		Drain:  true,
+		WriteRequest: structs.WriteRequest{
+			Region:    c.Region(),
+			AuthToken: c.secretNodeID(),
+		},
+	}
+	var resp structs.NodeDrainUpdateResponse
+	if err := c.RPC("Node.UpdateDrain", &req, &resp); err != nil {
+		return fmt.Errorf("failed to drain node: %v", err)
+	}
+
+	for _, ar := range c.getAllocRunners() {
+		alloc := ar.Alloc()
+		if alloc == nil || alloc.Terminated() {
+			continue
+		}
+		if m := allocMigrateStrategy(alloc); m != nil && m.Ignore {
+			c.logger.Printf("[DEBUG] client: killing alloc %q ignoring drains", alloc.ID)
+			ar.Destroy()
+		}
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(drainPollIntv)
+	defer ticker.Stop()
+	for {
+		running, waiting := 0, 0
+		for _, ar := range c.getAllocRunners() {
+			alloc := ar.Alloc()
+			if alloc == nil || alloc.Terminated() {
+				continue
+			}
+			m := allocMigrateStrategy(alloc)
+			if m != nil && m.Ignore {
+				continue
+			}
+			running++
+			if time.Since(start) < allocDrainDeadline(m, deadline) {
+				waiting++
+			}
+		}
+		if running == 0 {
+			c.logger.Printf("[INFO] client: node drained")
+			return nil
+		}
+		if waiting == 0 {
+			return fmt.Errorf("drain deadline reached with %d allocations running", running)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-c.shutdownCh:
+			return fmt.Errorf("client shut down while draining")
+		}
+	}
+}
+
+// DrainDeadline returns how long draining the node may take, which is the
+// longest of the drain deadline of the client and the migrate deadlines of the
+// task groups of its allocations.
+func (c *Client) DrainDeadline() time.Duration {
+	longest := c.config.DrainDeadline
+	for _, ar := range c.getAllocRunners() {
+		alloc := ar.Alloc()
+		if alloc == nil || alloc.Terminated() {
+			continue
+		}
+		if d := allocDrainDeadline(allocMigrateStrategy(alloc), c.config.DrainDeadline); d > longest {
+			longest = d
+		}
+	}
+	return longest
+}
+
+// allocMigrateStrategy returns the migrate strategy of the task group of the
+// allocation, or nil if it doesn't override how it is drained
+func allocMigrateStrategy(alloc *structs.Allocation) *structs.MigrateStrategy {
+	if alloc.Job == nil {
+		return nil
+	}
+	if tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup); tg != nil {
+		return tg.Migrate
+	}
 	return nil
 }
 
+// allocDrainDeadline returns how long a drain waits for an allocation with the
+// migrate strategy to stop
+func allocDrainDeadline(m *structs.MigrateStrategy, deadline time.Duration) time.Duration {
+	switch {
+	case m == nil:
+		return deadline
+	case m.Ignore:
+		return 0
+	case m.Deadline != 0:
+		return m.Deadline
+	default:
+		return deadline
+	}
+}
+
 // GetConfig returns the config of the client for testing purposes only
 func (c *Client) GetConfig() *config.Config {
 	return c.config
@@ -596,6 +872,16 @@ func (c *Client) GetClientAlloc(allocID string) (*structs.Allocation, error) {
 	return alloc, nil
 }
 
+// GetClientAllocs returns all the allocations managed by the client
+func (c *Client) GetClientAllocs() []*structs.Allocation {
+	all := c.allAllocs()
+	allocs := make([]*structs.Allocation, 0, len(all))
+	for _, alloc := range all {
+		allocs = append(allocs, alloc)
+	}
+	return allocs
+}
+
 // GetServers returns the list of nomad servers this client is aware of.
 func (c *Client) GetServers() []string {
 	endpoints := c.servers.all()
@@ -681,15 +967,25 @@ func (c *Client) restoreState() error {
 
 	// Load each alloc back
 	var mErr multierror.Error
+	var restored []*AllocRunner
 	for _, id := range allocs {
 		alloc := &structs.Allocation{ID: id}
 
-		// don't worry about blocking/migrating when restoring
+		// the watcher is set once all allocs are restored
 		watcher := noopPrevAlloc{}
 
 		c.configLock.RLock()
 		ar := NewAllocRunner(c.logger, c.configCopy, c.stateDB, c.updateAllocStatus, alloc, c.vaultClient, c.consulService, watcher)
 		c.configLock.RUnlock()
+		ar.quarantine = c.quarantine
+		ar.variables = c.taskVariables
+		ar.templateSource = c.taskTemplateSource
+		ar.netPolicies = c.netPolicies
+		ar.artifactCache = c.artifactCache
+		ar.dynamicUsers = c.dynamicUsers
+		if c.config.WorkloadAPI {
+			ar.workloadAPI = c.serveWorkloadAPI
+		}
 
 		c.allocLock.Lock()
 		c.allocs[id] = ar
@@ -699,12 +995,17 @@ func (c *Client) restoreState() error {
 			c.logger.Printf("[ERR] client: failed to restore state for alloc %q: %v", id, err)
 			mErr.Errors = append(mErr.Errors, err)
 		} else {
-			go ar.Run()
+			restored = append(restored, ar)
+		}
+	}
 
-			if upgrading {
-				if err := ar.SaveState(); err != nil {
-					c.logger.Printf("[WARN] client: initial save state for alloc %q failed: %v", id, err)
-				}
+	for _, ar := range restored {
+		ar.prevAlloc = c.restoredAllocWatcher(ar)
+		go ar.Run()
+
+		if upgrading {
+			if err := ar.SaveState(); err != nil {
+				c.logger.Printf("[WARN] client: initial save state for alloc %q failed: %v", ar.allocID, err)
 			}
 		}
 	}
@@ -719,6 +1020,46 @@ func (c *Client) restoreState() error {
 	return mErr.ErrorOrNil()
 }
 
+// restoredAllocWatcher returns the prevAllocWatcher of a restored alloc. An
+// alloc whose tasks never started was blocked on or migrating from its
+// previous alloc when the client stopped, so it resumes waiting on it. Other
+// allocs don't block.
+func (c *Client) restoredAllocWatcher(ar *AllocRunner) prevAllocWatcher {
+	alloc := ar.Alloc()
+	if alloc.PreviousAllocation == "" || alloc.Job == nil || len(alloc.TaskStates) != 0 ||
+		alloc.ClientStatus != structs.AllocClientStatusPending {
+		return noopPrevAlloc{}
+	}
+	if alloc.Job.LookupTaskGroup(alloc.TaskGroup) == nil {
+		return noopPrevAlloc{}
+	}
+
+	c.allocLock.RLock()
+	prevAR := c.allocs[alloc.PreviousAllocation]
+	c.allocLock.RUnlock()
+
+	// The migrate token isn't persisted, so migrating from a remote alloc
+	// protected by ACLs fails and the alloc starts with an empty alloc dir
+	c.configLock.RLock()
+	defer c.configLock.RUnlock()
+	return newAllocWatcher(alloc, prevAR, c, c.configCopy, c.logger, "")
+}
+
+// PendingMigrations returns the migrations of data from previous allocations
+// that are pending on this node, sorted by allocation ID.
+func (c *Client) PendingMigrations() []*cstructs.AllocMigration {
+	var migrations []*cstructs.AllocMigration
+	for _, ar := range c.getAllocRunners() {
+		if m := ar.Migration(); m != nil {
+			migrations = append(migrations, m)
+		}
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].AllocID < migrations[j].AllocID
+	})
+	return migrations
+}
+
 // saveState is used to snapshot our state into the data dir.
 func (c *Client) saveState() error {
 	if c.config.DevMode {
@@ -861,6 +1202,9 @@ func (c *Client) setupNode() error {
 	if node.Meta == nil {
 		node.Meta = make(map[string]string)
 	}
+	if err := c.restoreNodeMeta(); err != nil {
+		return fmt.Errorf("restoring node metadata failed: %v", err)
+	}
 	if node.Resources == nil {
 		node.Resources = &structs.Resources{}
 	}
@@ -882,18 +1226,40 @@ func (c *Client) setupNode() error {
 
 // reservePorts is used to reserve ports on the fingerprinted network devices.
 func (c *Client) reservePorts() {
-	c.configLock.RLock()
-	defer c.configLock.RUnlock()
+	c.configLock.Lock()
+	defer c.configLock.Unlock()
+	c.reserveGlobalPorts()
+}
+
+// reserveGlobalPorts reserves the globally reserved ports on top of the
+// reserved networks of the configuration, which are recorded on the first
+// call so that the ports can be reserved anew on reload. The configLock must
+// be held.
+func (c *Client) reserveGlobalPorts() {
+	node := c.config.Node
+	if node.Reserved == nil {
+		node.Reserved = new(structs.Resources)
+	}
+	if c.reservedNetworks == nil {
+		c.reservedNetworks = make([]*structs.NetworkResource, 0, len(node.Reserved.Networks))
+		for _, n := range node.Reserved.Networks {
+			c.reservedNetworks = append(c.reservedNetworks, n.Copy())
+		}
+	}
+
 	global := c.config.GloballyReservedPorts
 	if len(global) == 0 {
+		node.Reserved.Networks = nil
+		for _, n := range c.reservedNetworks {
+			node.Reserved.Networks = append(node.Reserved.Networks, n.Copy())
+		}
 		return
 	}
 
-	node := c.config.Node
 	networks := node.Resources.Networks
 	reservedIndex := make(map[string]*structs.NetworkResource, len(networks))
-	for _, resNet := range node.Reserved.Networks {
-		reservedIndex[resNet.IP] = resNet
+	for _, resNet := range c.reservedNetworks {
+		reservedIndex[resNet.IP] = resNet.Copy()
 	}
 
 	// Go through each network device and reserve ports on it.
@@ -912,11 +1278,7 @@ func (c *Client) reservePorts() {
 	}
 
 	// Clear the reserved networks.
-	if node.Reserved == nil {
-		node.Reserved = new(structs.Resources)
-	} else {
-		node.Reserved.Networks = nil
-	}
+	node.Reserved.Networks = nil
 
 	// Restore the reserved networks
 	for _, net := range reservedIndex {
@@ -981,9 +1343,43 @@ func (c *Client) fingerprint() error {
 	if len(skippedFingerprints) != 0 {
 		c.logger.Printf("[DEBUG] client: fingerprint modules skipped due to white/blacklist: %v", skippedFingerprints)
 	}
+
+	c.fingerprintPlugins()
 	return nil
 }
 
+// fingerprintPlugins runs the external fingerprinter plugins after the builtin
+// fingerprinters so that their devices are merged with the detected ones.
+func (c *Client) fingerprintPlugins() {
+	var detected []string
+	for _, conf := range c.config.FingerprintPlugins {
+		f := fingerprint.NewExternalFingerprint(conf, c.logger)
+
+		c.configLock.Lock()
+		request := &cstructs.FingerprintRequest{Config: c.config, Node: c.config.Node}
+		var response cstructs.FingerprintResponse
+		err := f.Fingerprint(request, &response)
+		c.configLock.Unlock()
+		if err != nil {
+			c.logger.Printf("[WARN] client: fingerprint plugin %q failed: %v", conf.Name, err)
+			continue
+		}
+
+		if response.Detected {
+			detected = append(detected, conf.Name)
+		}
+		c.updateNodeFromFingerprint(&response)
+
+		if p, period := f.Periodic(); p {
+			go c.fingerprintPeriodic(conf.Name, f, period)
+		}
+	}
+
+	if len(detected) != 0 {
+		c.logger.Printf("[DEBUG] client: detected fingerprint plugins %v", detected)
+	}
+}
+
 // fingerprintPeriodic runs a fingerprinter at the specified duration.
 func (c *Client) fingerprintPeriodic(name string, f fingerprint.Fingerprint, d time.Duration) {
 	c.logger.Printf("[DEBUG] client: fingerprinting %v every %v", name, d)
@@ -1190,6 +1586,19 @@ func (c *Client) run() {
 	allocUpdates := make(chan *allocUpdates, 8)
 	go c.watchAllocations(allocUpdates)
 
+	// Watch for changes to the quarantine list
+	go c.watchQuarantine()
+
+	// Watch for changes to the network policies and enforce them
+	go c.watchNetworkPolicies()
+	go c.enforceNetworkPolicies()
+
+	// Register the addresses of the allocations with the external DNS
+	// provider
+	if c.externalDNS != nil {
+		go c.registerExternalDNS()
+	}
+
 	for {
 		select {
 		case update := <-allocUpdates:
@@ -1267,11 +1676,43 @@ func (c *Client) registerNode() error {
 		Node:         node,
 		WriteRequest: structs.WriteRequest{Region: c.Region()},
 	}
+
+	// Only send the changes of the node since its last registration
+	c.registeredNodeLock.Lock()
+	base := c.registeredNode
+	c.registeredNodeLock.Unlock()
+	c.configLock.RLock()
+	registered := node.Copy()
+	if base != nil {
+		delta, err := structs.NewNodeDelta(base, node)
+		if err != nil {
+			c.logger.Printf("[DEBUG] client: unable to compute node delta: %v", err)
+		} else {
+			req.Node = nil
+			req.Delta = delta
+		}
+	}
+	c.configLock.RUnlock()
+
 	var resp structs.NodeUpdateResponse
 	if err := c.RPC("Node.Register", &req, &resp); err != nil {
-		return err
+		if req.Delta == nil || err == noServersErr {
+			return err
+		}
+
+		// The servers may not support deltas or have lost the registration
+		c.logger.Printf("[DEBUG] client: node delta registration failed, registering node: %v", err)
+		req.Node = node
+		req.Delta = nil
+		if err := c.RPC("Node.Register", &req, &resp); err != nil {
+			return err
+		}
 	}
 
+	c.registeredNodeLock.Lock()
+	c.registeredNode = registered
+	c.registeredNodeLock.Unlock()
+
 	// Update the node status to ready after we register.
 	c.configLock.Lock()
 	node.Status = structs.NodeStatusReady
@@ -1364,6 +1805,9 @@ func (c *Client) updateNodeStatus() error {
 
 // updateAllocStatus is used to update the status of an allocation
 func (c *Client) updateAllocStatus(alloc *structs.Allocation) {
+	// Register or deregister the allocation with the external DNS provider
+	c.externalDNS.trigger()
+
 	if alloc.Terminated() {
 		// Terminated, mark for GC if we're still tracking this alloc
 		// runner. If it's not being tracked that means the server has
@@ -1389,6 +1833,10 @@ func (c *Client) updateAllocStatus(alloc *structs.Allocation) {
 	stripped.TaskStates = alloc.TaskStates
 	stripped.ClientStatus = alloc.ClientStatus
 	stripped.ClientDescription = alloc.ClientDescription
+	if alloc.ClientStatus == structs.AllocClientStatusFailed {
+		// Only send the reasons determined by the client
+		stripped.StopReason = alloc.StopReason
+	}
 	stripped.DeploymentStatus = alloc.DeploymentStatus
 
 	select {
@@ -1638,21 +2086,23 @@ func (c *Client) watchNodeUpdates(attrHash, metaHash uint64) {
 	for {
 		select {
 		case <-time.After(c.retryIntv(nodeUpdateRetryIntv)):
-			changed, attrHash, metaHash = c.hasNodeChanged(attrHash, metaHash)
-			if changed {
-				c.logger.Printf("[DEBUG] client: state changed, updating node.")
-
-				// Update the config copy.
-				c.configLock.Lock()
-				node := c.config.Node.Copy()
-				c.configCopy.Node = node
-				c.configLock.Unlock()
-
-				c.retryRegisterNode()
-			}
+		case <-c.triggerNodeUpdateCh:
 		case <-c.shutdownCh:
 			return
 		}
+
+		changed, attrHash, metaHash = c.hasNodeChanged(attrHash, metaHash)
+		if changed {
+			c.logger.Printf("[DEBUG] client: state changed, updating node.")
+
+			// Update the config copy.
+			c.configLock.Lock()
+			node := c.config.Node.Copy()
+			c.configCopy.Node = node
+			c.configLock.Unlock()
+
+			c.retryRegisterNode()
+		}
 	}
 }
 
@@ -1717,6 +2167,11 @@ func (c *Client) removeAlloc(alloc *structs.Allocation) {
 	delete(c.allocs, alloc.ID)
 	c.allocLock.Unlock()
 
+	// Remove the addresses of its tasks from the network policies and the
+	// external DNS provider
+	c.netPolicies.trigger()
+	c.externalDNS.trigger()
+
 	// Ensure the GC has a reference and then collect. Collecting through the GC
 	// applies rate limiting
 	c.garbageCollector.MarkForCollection(ar)
@@ -1761,6 +2216,15 @@ func (c *Client) addAlloc(alloc *structs.Allocation, migrateToken string) error
 
 	ar := NewAllocRunner(c.logger, c.configCopy, c.stateDB, c.updateAllocStatus, alloc, c.vaultClient, c.consulService, prevAlloc)
 	c.configLock.RUnlock()
+	ar.quarantine = c.quarantine
+	ar.variables = c.taskVariables
+	ar.templateSource = c.taskTemplateSource
+	ar.netPolicies = c.netPolicies
+	ar.artifactCache = c.artifactCache
+	ar.dynamicUsers = c.dynamicUsers
+	if c.config.WorkloadAPI {
+		ar.workloadAPI = c.serveWorkloadAPI
+	}
 
 	// Store the alloc runner.
 	c.allocs[alloc.ID] = ar
@@ -2200,6 +2664,39 @@ func (c *Client) setGaugeForUptime(hStats *stats.HostStats) {
 	}
 }
 
+// setGaugeForPowerStats emits the fingerprinted power profile of the node along
+// with its estimated power draw and carbon emission rate. The power draw is
+// estimated from the thermal design power and the CPU utilization.
+func (c *Client) setGaugeForPowerStats(hStats *stats.HostStats) {
+	if c.config.DisableTaggedMetrics {
+		return
+	}
+
+	node := c.Node()
+	tdp, err := strconv.ParseFloat(node.Attributes["power.tdp_watts"], 64)
+	if err != nil {
+		return
+	}
+	metrics.SetGaugeWithLabels([]string{"client", "host", "power", "tdp_watts"}, float32(tdp), c.baseLabels)
+
+	var utilization float64
+	if len(hStats.CPU) != 0 {
+		for _, cpu := range hStats.CPU {
+			utilization += cpu.Total
+		}
+		utilization /= float64(100 * len(hStats.CPU))
+	}
+	watts := tdp * utilization
+	metrics.SetGaugeWithLabels([]string{"client", "host", "power", "estimated_watts"}, float32(watts), c.baseLabels)
+
+	intensity, err := strconv.ParseFloat(node.Attributes["power.carbon_intensity"], 64)
+	if err != nil {
+		return
+	}
+	metrics.SetGaugeWithLabels([]string{"client", "host", "power", "carbon_intensity"}, float32(intensity), c.baseLabels)
+	metrics.SetGaugeWithLabels([]string{"client", "host", "power", "carbon_rate"}, float32(watts/1000*intensity), c.baseLabels)
+}
+
 // emitHostStats pushes host resource usage stats to remote metrics collection sinks
 func (c *Client) emitHostStats() {
 	nodeID := c.NodeID()
@@ -2209,6 +2706,7 @@ func (c *Client) emitHostStats() {
 	c.setGaugeForUptime(hStats)
 	c.setGaugeForCPUStats(nodeID, hStats)
 	c.setGaugeForDiskStats(nodeID, hStats)
+	c.setGaugeForPowerStats(hStats)
 }
 
 // emitClientMetrics emits lower volume client metrics
@@ -8,6 +8,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -26,6 +27,7 @@ import (
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/mitchellh/hashstructure"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	ctestutil "github.com/hashicorp/nomad/client/testutil"
 )
@@ -88,7 +90,7 @@ func testServer(t *testing.T, cb func(*nomad.Config)) (*nomad.Server, string) {
 		config.SerfConfig.MemberlistConfig.BindPort = ports[1]
 
 		// Create server
-		server, err := nomad.NewServer(config, catalog, logger)
+		server, err := nomad.NewServer(config, catalog, consul.NewMockKV(), logger)
 		if err == nil {
 			return server, config.RPCAddr.String()
 		} else if i == 0 {
@@ -593,6 +595,190 @@ func TestClient_Register(t *testing.T) {
 	})
 }
 
+func TestClient_Register_Delta(t *testing.T) {
+	t.Parallel()
+	s1, addr := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	c1 := testClient(t, func(c *config.Config) {
+		c.Servers = []string{addr}
+		c.RPCCompression = true
+	})
+	defer c1.Shutdown()
+
+	req := structs.NodeSpecificRequest{
+		NodeID:       c1.Node().ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var out structs.SingleNodeResponse
+	testutil.WaitForResult(func() (bool, error) {
+		if err := s1.RPC("Node.GetNode", &req, &out); err != nil {
+			return false, err
+		}
+		return out.Node != nil, fmt.Errorf("missing reg")
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// Update the registration with a delta
+	c1.configLock.Lock()
+	node := c1.configCopy.Node.Copy()
+	node.Attributes["unique.fpga"] = "1"
+	c1.configCopy.Node = node
+	c1.configLock.Unlock()
+	if err := c1.registerNode(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := s1.RPC("Node.GetNode", &req, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Node.Attributes["unique.fpga"] != "1" {
+		t.Fatalf("attribute not registered: %v", out.Node.Attributes)
+	}
+
+	// The node is registered again once the servers lost its registration
+	dereg := structs.NodeDeregisterRequest{
+		NodeID:       node.ID,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.NodeUpdateResponse
+	if err := s1.RPC("Node.Deregister", &dereg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := c1.registerNode(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := s1.RPC("Node.GetNode", &req, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.Node == nil {
+		t.Fatalf("node not registered")
+	}
+}
+
+func TestClient_Leave_Drain(t *testing.T) {
+	t.Parallel()
+	s1, _ := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	c1 := testClient(t, func(c *config.Config) {
+		c.RPCHandler = s1
+		c.DrainOnLeave = true
+		c.DrainDeadline = 2 * time.Second
+	})
+	defer c1.Shutdown()
+
+	req := structs.NodeSpecificRequest{
+		NodeID:       c1.Node().ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var out structs.SingleNodeResponse
+	testutil.WaitForResult(func() (bool, error) {
+		if err := s1.RPC("Node.GetNode", &req, &out); err != nil {
+			return false, err
+		}
+		return out.Node != nil, fmt.Errorf("missing reg")
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// An allocation that doesn't stop holds the drain until the deadline
+	_, ar := testAllocRunnerFromAlloc(mock.Alloc(), false)
+	c1.allocLock.Lock()
+	c1.allocs[ar.Alloc().ID] = ar
+	c1.allocLock.Unlock()
+	if err := c1.Leave(); err == nil || !strings.Contains(err.Error(), "deadline") {
+		t.Fatalf("expected deadline error, got: %v", err)
+	}
+
+	if err := s1.RPC("Node.GetNode", &req, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !out.Node.Drain {
+		t.Fatalf("node not drained")
+	}
+
+	// Once the allocations stopped the client leaves
+	c1.allocLock.Lock()
+	delete(c1.allocs, ar.Alloc().ID)
+	c1.allocLock.Unlock()
+	if err := c1.Leave(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestClient_Leave_Drain_Migrate(t *testing.T) {
+	t.Parallel()
+	s1, _ := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	c1 := testClient(t, func(c *config.Config) {
+		c.RPCHandler = s1
+		c.DrainOnLeave = true
+		c.DrainDeadline = time.Hour
+	})
+	defer c1.Shutdown()
+
+	req := structs.NodeSpecificRequest{
+		NodeID:       c1.Node().ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var out structs.SingleNodeResponse
+	testutil.WaitForResult(func() (bool, error) {
+		if err := s1.RPC("Node.GetNode", &req, &out); err != nil {
+			return false, err
+		}
+		return out.Node != nil, fmt.Errorf("missing reg")
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// An allocation whose group has a short deadline only holds the drain
+	// until its deadline, and one whose group ignores drains is killed
+	short := mock.Alloc()
+	short.Job.TaskGroups[0].Migrate = &structs.MigrateStrategy{Deadline: time.Second}
+	_, shortAR := testAllocRunnerFromAlloc(short, false)
+	ignored := mock.Alloc()
+	ignored.Job.TaskGroups[0].Migrate = &structs.MigrateStrategy{Ignore: true}
+	_, ignoredAR := testAllocRunnerFromAlloc(ignored, false)
+
+	c1.allocLock.Lock()
+	c1.allocs[short.ID] = shortAR
+	c1.allocs[ignored.ID] = ignoredAR
+	c1.allocLock.Unlock()
+
+	if d := c1.DrainDeadline(); d != time.Hour {
+		t.Fatalf("expected the deadline of the client, got: %v", d)
+	}
+
+	start := time.Now()
+	err := c1.Leave()
+	if err == nil || !strings.Contains(err.Error(), "1 allocations running") {
+		t.Fatalf("expected deadline error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("drain held for %v", elapsed)
+	}
+	if ignoredAR.ctx.Err() == nil {
+		t.Fatalf("ignored alloc not killed")
+	}
+
+	// A longer group deadline extends the drain
+	short.Job.TaskGroups[0].Migrate.Deadline = 2 * time.Hour
+	if d := c1.DrainDeadline(); d != 2*time.Hour {
+		t.Fatalf("expected the deadline of the group, got: %v", d)
+	}
+
+	// The runners aren't running, so they can't be waited for on shutdown
+	c1.allocLock.Lock()
+	delete(c1.allocs, short.ID)
+	delete(c1.allocs, ignored.ID)
+	c1.allocLock.Unlock()
+}
+
 func TestClient_Heartbeat(t *testing.T) {
 	t.Parallel()
 	s1, _ := testServer(t, func(c *nomad.Config) {
@@ -1121,6 +1307,78 @@ func TestClient_ReloadTLS_UpgradePlaintextToTLS(t *testing.T) {
 	}
 }
 
+func TestClient_Reload(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	s1, addr := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	c1 := testClient(t, func(c *config.Config) {
+		c.Servers = []string{addr}
+	})
+	defer c1.Shutdown()
+
+	c1.configLock.RLock()
+	oldCopy := c1.configCopy
+	newConfig := c1.config.Copy()
+	c1.configLock.RUnlock()
+
+	newConfig.ChrootEnv = map[string]string{"/bin": "/bin"}
+	newConfig.MaxKillTimeout = 42 * time.Second
+	newConfig.ArtifactCacheMaxMB = 10
+	newConfig.Node.Reserved.CPU = 123
+	newConfig.GloballyReservedPorts = []int{8888}
+	require.NoError(c1.Reload(newConfig))
+
+	// New allocations get the new configuration, running ones keep theirs
+	c1.configLock.RLock()
+	newCopy := c1.configCopy
+	c1.configLock.RUnlock()
+	require.Equal(newConfig.ChrootEnv, newCopy.ChrootEnv)
+	require.Equal(42*time.Second, newCopy.MaxKillTimeout)
+	require.Empty(oldCopy.ChrootEnv)
+	require.NotEqual(42*time.Second, oldCopy.MaxKillTimeout)
+	require.NotNil(c1.artifactCache)
+
+	// The ports are reserved on every network
+	for _, n := range newCopy.Node.Reserved.Networks {
+		require.Len(n.ReservedPorts, 1)
+		require.Equal(8888, n.ReservedPorts[0].Value)
+	}
+
+	// The node is updated with the reserved resources
+	testutil.WaitForResult(func() (bool, error) {
+		req := structs.NodeSpecificRequest{
+			NodeID:       c1.NodeID(),
+			QueryOptions: structs.QueryOptions{Region: "global"},
+		}
+		var out structs.SingleNodeResponse
+		if err := s1.RPC("Node.GetNode", &req, &out); err != nil {
+			return false, err
+		}
+		if out.Node == nil || out.Node.Reserved.CPU != 123 {
+			return false, fmt.Errorf("node not updated: %+v", out.Node)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// Disabling the cache and the reserved ports reloads them too
+	newConfig = newConfig.Copy()
+	newConfig.ArtifactCacheMaxMB = 0
+	newConfig.GloballyReservedPorts = nil
+	require.NoError(c1.Reload(newConfig))
+	require.Nil(c1.artifactCache)
+	c1.configLock.RLock()
+	for _, n := range c1.config.Node.Reserved.Networks {
+		require.Empty(n.ReservedPorts)
+	}
+	c1.configLock.RUnlock()
+}
+
 func TestClient_ReloadTLS_DowngradeTLSToPlaintext(t *testing.T) {
 	t.Parallel()
 	assert := assert.New(t)
@@ -41,6 +41,15 @@ var (
 		"java",
 	}, ",")
 
+	// DefaultDynamicUserDrivers is the set of drivers whose tasks are run as
+	// a dynamic user when "user.dynamic_range" is set and the task doesn't
+	// set a user.
+	DefaultDynamicUserDrivers = strings.Join([]string{
+		"exec",
+		"java",
+		"raw_exec",
+	}, ",")
+
 	// A mapping of directories on the host OS to attempt to embed inside each
 	// task's chroot.
 	DefaultChrootEnv = map[string]string{
@@ -55,6 +64,26 @@ var (
 	}
 )
 
+const (
+	// CgroupDriverCgroupfs and CgroupDriverSystemd are the supported values
+	// of CgroupDriver.
+	CgroupDriverCgroupfs = "cgroupfs"
+	CgroupDriverSystemd  = "systemd"
+
+	// MountDriverSyscall and MountDriverSystemd are the supported values of
+	// MountDriver.
+	MountDriverSyscall = "syscall"
+	MountDriverSystemd = "systemd"
+
+	// DefaultCgroupSlice is the slice task cgroups are created under when
+	// using the systemd cgroup driver.
+	DefaultCgroupSlice = "nomad.slice"
+
+	// DefaultArtifactCacheMaxMB is the default maximum size of the artifact
+	// cache
+	DefaultArtifactCacheMaxMB = 1024
+)
+
 // RPCHandler can be provided to the Client if there is a local server
 // to avoid going over the network. If not provided, the Client will
 // maintain a connection pool to the servers
@@ -172,6 +201,27 @@ type Config struct {
 	// before garbage collection is triggered.
 	GCMaxAllocs int
 
+	// GCPolicies collect the terminal allocations of the jobs they match
+	// more eagerly than the GC thresholds. The first matching policy of an
+	// allocation applies.
+	GCPolicies []*GCPolicy
+
+	// DrainOnLeave drains the node when the client leaves, before the agent
+	// exits.
+	DrainOnLeave bool
+
+	// DrainDeadline is how long the client waits for its allocations to stop
+	// when draining on leave.
+	DrainDeadline time.Duration
+
+	// FingerprintPlugins are external fingerprinters run after the builtin
+	// fingerprinters.
+	FingerprintPlugins []*config.FingerprintPluginConfig
+
+	// ExternalDNS configures the registration of the addresses of the
+	// allocations with an external DNS provider. It is nil if disabled.
+	ExternalDNS *config.ExternalDNSConfig
+
 	// LogLevel is the level of the logs to putout
 	LogLevel string
 
@@ -179,6 +229,34 @@ type Config struct {
 	// random UUID.
 	NoHostUUID bool
 
+	// CgroupDriver selects how task cgroups are managed. "cgroupfs" writes
+	// the cgroup filesystem directly while "systemd" creates each task's
+	// cgroup as a transient scope under CgroupSlice using the systemd D-Bus
+	// API.
+	CgroupDriver string
+
+	// CgroupSlice is the systemd slice task cgroups are created under when
+	// using the systemd cgroup driver.
+	CgroupSlice string
+
+	// MountDriver selects how the directories of the tasks are mounted.
+	// "syscall" mounts them from the client while "systemd" has systemd mount
+	// them as transient mount units through its D-Bus API, so the client
+	// doesn't need to be allowed to mount.
+	MountDriver string
+
+	// ArtifactCacheMaxMB is the maximum size of the cache of the artifacts
+	// downloaded by the tasks. The cache is disabled if it isn't positive.
+	ArtifactCacheMaxMB int
+
+	// WorkloadAPI serves the workload API socket in the allocation
+	// directories. When ACLs are enabled, requests made through the socket
+	// use the workload token of the allocation.
+	WorkloadAPI bool
+
+	// RPCCompression compresses the RPC connections to the servers
+	RPCCompression bool
+
 	// ACLEnabled controls if ACL enforcement and management is enabled.
 	ACLEnabled bool
 
@@ -195,6 +273,12 @@ type Config struct {
 	// BackwardsCompatibleMetrics determines whether to show methods of
 	// displaying metrics for older verions, or to only show the new format
 	BackwardsCompatibleMetrics bool
+
+	// MetricsNamespaceLabels and MetricsNodeClassLabels determine whether the
+	// allocation metrics are labeled with the namespace of the job and the
+	// class of the node
+	MetricsNamespaceLabels bool
+	MetricsNodeClassLabels bool
 }
 
 func (c *Config) Copy() *Config {
@@ -203,12 +287,72 @@ func (c *Config) Copy() *Config {
 	nc.Node = nc.Node.Copy()
 	nc.Servers = helper.CopySliceString(nc.Servers)
 	nc.Options = helper.CopyMapStringString(nc.Options)
+	nc.ChrootEnv = helper.CopyMapStringString(nc.ChrootEnv)
 	nc.GloballyReservedPorts = helper.CopySliceInt(c.GloballyReservedPorts)
 	nc.ConsulConfig = c.ConsulConfig.Copy()
 	nc.VaultConfig = c.VaultConfig.Copy()
+	if c.GCPolicies != nil {
+		nc.GCPolicies = make([]*GCPolicy, len(c.GCPolicies))
+		for i, p := range c.GCPolicies {
+			np := *p
+			nc.GCPolicies[i] = &np
+		}
+	}
+	if c.FingerprintPlugins != nil {
+		nc.FingerprintPlugins = make([]*config.FingerprintPluginConfig, len(c.FingerprintPlugins))
+		for i, p := range c.FingerprintPlugins {
+			nc.FingerprintPlugins[i] = p.Copy()
+		}
+	}
+	nc.ExternalDNS = c.ExternalDNS.Copy()
 	return nc
 }
 
+// GCPolicy collects the terminal allocations of the jobs it matches more
+// eagerly than the GC thresholds of the client, so that the allocations of
+// less critical jobs are reclaimed first.
+type GCPolicy struct {
+	// Namespace is the namespace of the jobs matched, any if empty
+	Namespace string
+
+	// MaxPriority is the highest priority of the jobs matched, any if zero
+	MaxPriority int
+
+	// MaxAge is how long the matched allocations are kept once marked for
+	// collection, without limit if zero
+	MaxAge time.Duration
+
+	// DiskUsageThreshold is the disk usage given as a percent beyond which
+	// the matched allocations are collected, unset if zero
+	DiskUsageThreshold float64
+}
+
+// Matches returns whether the policy applies to the allocation.
+func (p *GCPolicy) Matches(alloc *structs.Allocation) bool {
+	if p.Namespace != "" && p.Namespace != alloc.Namespace {
+		return false
+	}
+	if p.MaxPriority != 0 && (alloc.Job == nil || alloc.Job.Priority > p.MaxPriority) {
+		return false
+	}
+	return true
+}
+
+// Validate returns an error if the policy doesn't collect anything or has
+// an invalid threshold.
+func (p *GCPolicy) Validate() error {
+	if p.MaxAge < 0 {
+		return fmt.Errorf("max_age can't be negative")
+	}
+	if p.DiskUsageThreshold < 0 || p.DiskUsageThreshold > 100 {
+		return fmt.Errorf("disk_usage_threshold must be between 0 and 100")
+	}
+	if p.MaxAge == 0 && p.DiskUsageThreshold == 0 {
+		return fmt.Errorf("one of max_age or disk_usage_threshold must be set")
+	}
+	return nil
+}
+
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
@@ -225,12 +369,26 @@ func DefaultConfig() *Config {
 		GCDiskUsageThreshold:       80,
 		GCInodeUsageThreshold:      70,
 		GCMaxAllocs:                50,
+		DrainDeadline:              5 * time.Minute,
 		NoHostUUID:                 true,
+		CgroupDriver:               CgroupDriverCgroupfs,
+		CgroupSlice:                DefaultCgroupSlice,
+		MountDriver:                MountDriverSyscall,
+		ArtifactCacheMaxMB:         DefaultArtifactCacheMaxMB,
 		DisableTaggedMetrics:       false,
 		BackwardsCompatibleMetrics: false,
 	}
 }
 
+// CgroupParent returns the systemd slice task cgroups should be created
+// under, or "" if cgroups are managed through the cgroup filesystem.
+func (c *Config) CgroupParent() string {
+	if c.CgroupDriver != CgroupDriverSystemd {
+		return ""
+	}
+	return c.CgroupSlice
+}
+
 // Read returns the specified configuration value or "".
 func (c *Config) Read(id string) string {
 	return c.Options[id]
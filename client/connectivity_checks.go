@@ -0,0 +1,135 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// runConnectivityChecks runs the connectivity checks of the task once it is
+// started. Each check is retried at its interval until it passes and its
+// results are emitted as task events. The checks stop when stopCh is closed.
+func (r *TaskRunner) runConnectivityChecks(stopCh <-chan struct{}) {
+	taskEnv := r.envBuilder.Build()
+	for _, check := range r.task.ConnectivityChecks {
+		go r.runConnectivityCheck(taskEnv, check, stopCh)
+	}
+}
+
+// runConnectivityCheck runs a connectivity check until it passes. A failure
+// event is only emitted for the first failure so that retries don't flood the
+// task's events.
+func (r *TaskRunner) runConnectivityCheck(taskEnv *env.TaskEnv, check *structs.ConnectivityCheck, stopCh <-chan struct{}) {
+	failed := false
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-timer.C:
+		}
+
+		err := checkConnectivity(taskEnv, check)
+		if err == nil {
+			r.setState("", structs.NewTaskEvent(structs.TaskConnectivityPassed).
+				SetConnectivityCheck(check.Name), false)
+			return
+		}
+
+		r.logger.Printf("[DEBUG] client: connectivity check %q of task %q in alloc %q failed: %v",
+			check.Name, r.task.Name, r.alloc.ID, err)
+		if !failed {
+			failed = true
+			r.setState("", structs.NewTaskEvent(structs.TaskConnectivityFailed).
+				SetConnectivityCheck(check.Name).SetMessage(err.Error()), false)
+		}
+		timer.Reset(check.Interval)
+	}
+}
+
+// checkConnectivity runs a single attempt of the connectivity check from the
+// task's environment.
+func checkConnectivity(taskEnv *env.TaskEnv, check *structs.ConnectivityCheck) error {
+	address, err := connectivityCheckAddress(taskEnv, check)
+	if err != nil {
+		return err
+	}
+
+	switch check.Type {
+	case structs.ConnectivityCheckHTTP:
+		path := taskEnv.ReplaceEnv(check.Path)
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		url := fmt.Sprintf("http://%s%s", address, path)
+		client := &http.Client{Timeout: check.Timeout}
+		resp, err := client.Get(url)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("GET %s returned status %d", url, resp.StatusCode)
+		}
+		return nil
+	default:
+		conn, err := net.DialTimeout("tcp", address, check.Timeout)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+}
+
+// connectivityCheckAddress returns the host:port the connectivity check
+// connects to.
+func connectivityCheckAddress(taskEnv *env.TaskEnv, check *structs.ConnectivityCheck) (string, error) {
+	if check.PortLabel != "" {
+		address, ok := taskEnv.EnvMap[env.AddrPrefix+check.PortLabel]
+		if !ok {
+			return "", fmt.Errorf("unknown port %q", check.PortLabel)
+		}
+		return address, nil
+	}
+
+	address := taskEnv.ReplaceEnv(check.Address)
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return "", fmt.Errorf("invalid address %q: %v", address, err)
+	}
+	return address, nil
+}
+
+// failingConnectivityChecks returns the names of the connectivity checks of
+// the task that haven't passed since the task was last started.
+func failingConnectivityChecks(task *structs.Task, state *structs.TaskState) []string {
+	if len(task.ConnectivityChecks) == 0 {
+		return nil
+	}
+
+	passed := make(map[string]struct{}, len(task.ConnectivityChecks))
+	if state != nil {
+		for _, e := range state.Events {
+			switch e.Type {
+			case structs.TaskStarted:
+				passed = make(map[string]struct{}, len(task.ConnectivityChecks))
+			case structs.TaskConnectivityPassed:
+				passed[e.ConnectivityCheck] = struct{}{}
+			}
+		}
+	}
+
+	var failing []string
+	for _, check := range task.ConnectivityChecks {
+		if _, ok := passed[check.Name]; !ok {
+			failing = append(failing, check.Name)
+		}
+	}
+	return failing
+}
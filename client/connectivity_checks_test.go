@@ -0,0 +1,108 @@
+package client
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectivityCheck_TCP(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(err)
+	addr := l.Addr().String()
+
+	taskEnv := env.NewTaskEnv(map[string]string{
+		"DB_ADDR":       addr,
+		"NOMAD_ADDR_db": addr,
+	}, nil)
+	check := &structs.ConnectivityCheck{
+		Name:    "db",
+		Type:    structs.ConnectivityCheckTCP,
+		Address: "${DB_ADDR}",
+		Timeout: time.Second,
+	}
+	require.NoError(checkConnectivity(taskEnv, check))
+
+	// The port of the task can be checked instead of an address
+	check.Address = ""
+	check.PortLabel = "db"
+	require.NoError(checkConnectivity(taskEnv, check))
+
+	check.PortLabel = "http"
+	err = checkConnectivity(taskEnv, check)
+	require.Error(err)
+	require.Contains(err.Error(), "unknown port")
+
+	// Closed listeners fail the check
+	l.Close()
+	check.PortLabel = "db"
+	require.Error(checkConnectivity(taskEnv, check))
+}
+
+func TestConnectivityCheck_HTTP(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	taskEnv := env.NewTaskEnv(map[string]string{}, nil)
+	check := &structs.ConnectivityCheck{
+		Name:    "api",
+		Type:    structs.ConnectivityCheckHTTP,
+		Address: strings.TrimPrefix(ts.URL, "http://"),
+		Path:    "/health",
+		Timeout: time.Second,
+	}
+	require.NoError(checkConnectivity(taskEnv, check))
+
+	check.Path = "/other"
+	err := checkConnectivity(taskEnv, check)
+	require.Error(err)
+	require.Contains(err.Error(), "returned status 503")
+}
+
+func TestConnectivityCheck_Failing(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	task := &structs.Task{
+		Name: "web",
+		ConnectivityChecks: []*structs.ConnectivityCheck{
+			{Name: "db"},
+			{Name: "cache"},
+		},
+	}
+	state := &structs.TaskState{
+		Events: []*structs.TaskEvent{
+			structs.NewTaskEvent(structs.TaskStarted),
+			structs.NewTaskEvent(structs.TaskConnectivityPassed).SetConnectivityCheck("db"),
+			structs.NewTaskEvent(structs.TaskConnectivityFailed).SetConnectivityCheck("cache"),
+		},
+	}
+	require.Equal([]string{"cache"}, failingConnectivityChecks(task, state))
+
+	state.Events = append(state.Events,
+		structs.NewTaskEvent(structs.TaskConnectivityPassed).SetConnectivityCheck("cache"))
+	require.Empty(failingConnectivityChecks(task, state))
+
+	// Restarting the task requires the checks to pass again
+	state.Events = append(state.Events, structs.NewTaskEvent(structs.TaskStarted))
+	require.Equal([]string{"db", "cache"}, failingConnectivityChecks(task, state))
+
+	require.Equal([]string{"db", "cache"}, failingConnectivityChecks(task, nil))
+}
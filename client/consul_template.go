@@ -71,6 +71,10 @@ type TaskTemplateManager struct {
 	// runner is the consul-template runner
 	runner *manager.Runner
 
+	// nomad renders the templates reading Nomad variables and services. It
+	// is nil if there are none.
+	nomad *nomadTemplateRenderer
+
 	// signals is a lookup map from the string representation of a signal to its
 	// actual signal
 	signals map[string]os.Signal
@@ -108,6 +112,10 @@ type TaskTemplateManagerConfig struct {
 	// MaxTemplateEventRate is the maximum rate at which we should emit events.
 	MaxTemplateEventRate time.Duration
 
+	// NomadSource reads the Nomad variables and services of the templates.
+	// Templates reading them are rendered by Nomad only if it is set.
+	NomadSource nomadTemplateSource
+
 	// retryRate is only used for testing and is used to increase the retry rate
 	retryRate time.Duration
 }
@@ -160,8 +168,34 @@ func NewTaskTemplateManager(config *TaskTemplateManagerConfig) (*TaskTemplateMan
 		tm.signals[tmpl.ChangeSignal] = sig
 	}
 
+	// Templates reading Nomad variables and services are rendered by Nomad,
+	// the others by consul-template
+	ctConfig := config
+	if config.NomadSource != nil {
+		var consulTmpls, nomadTmpls []*structs.Template
+		for _, tmpl := range config.Templates {
+			if isNomadTemplate(config, tmpl) {
+				nomadTmpls = append(nomadTmpls, tmpl)
+			} else {
+				consulTmpls = append(consulTmpls, tmpl)
+			}
+		}
+
+		if len(nomadTmpls) != 0 {
+			renderer, err := newNomadTemplateRenderer(config, nomadTmpls)
+			if err != nil {
+				return nil, err
+			}
+			tm.nomad = renderer
+
+			copied := *config
+			copied.Templates = consulTmpls
+			ctConfig = &copied
+		}
+	}
+
 	// Build the consul-template runner
-	runner, lookup, err := templateRunner(config)
+	runner, lookup, err := templateRunner(ctConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -188,19 +222,27 @@ func (tm *TaskTemplateManager) Stop() {
 	if tm.runner != nil {
 		tm.runner.Stop()
 	}
+	if tm.nomad != nil {
+		tm.nomad.Stop()
+	}
 }
 
 // run is the long lived loop that handles errors and templates being rendered
 func (tm *TaskTemplateManager) run() {
-	// Runner is nil if there is no templates
-	if tm.runner == nil {
+	// Runners are nil if there is no templates
+	if tm.runner == nil && tm.nomad == nil {
 		// Unblock the start if there is nothing to do
 		tm.config.Hooks.UnblockStart(consulTemplateSourceName)
 		return
 	}
 
-	// Start the runner
-	go tm.runner.Start()
+	// Start the runners
+	if tm.runner != nil {
+		go tm.runner.Start()
+	}
+	if tm.nomad != nil {
+		go tm.nomad.Start()
+	}
 
 	// Block till all the templates have been rendered
 	tm.handleFirstRender()
@@ -248,74 +290,80 @@ func (tm *TaskTemplateManager) handleFirstRender() {
 	// be fired.
 	outstandingEvent := false
 
+	// updateMissing updates the set of missing dependencies from the render
+	// events of the runners
+	updateMissing := func() {
+		joinedSet := tm.missingDependencies()
+
+		// Check to see if the new joined set is the same as the old
+		different := len(joinedSet) != len(missingDependencies)
+		if !different {
+			for k := range joinedSet {
+				if _, ok := missingDependencies[k]; !ok {
+					different = true
+					break
+				}
+			}
+		}
+
+		// Nothing to do
+		if !different {
+			return
+		}
+
+		// Update the missing set
+		missingDependencies = joinedSet
+
+		// Update the event timer channel
+		if !outstandingEvent {
+			// We got new data so reset
+			outstandingEvent = true
+			eventTimer.Reset(tm.config.MaxTemplateEventRate)
+		}
+	}
+
+	// The channels of a runner that isn't used are left nil
+	var errCh, nomadErrCh <-chan error
+	var renderedCh, eventCh, nomadRenderedCh, nomadEventCh <-chan struct{}
+	consulRendered, nomadRendered := true, true
+	if tm.runner != nil {
+		errCh, renderedCh, eventCh = tm.runner.ErrCh, tm.runner.TemplateRenderedCh(), tm.runner.RenderEventCh()
+		consulRendered = false
+	}
+	if tm.nomad != nil {
+		nomadErrCh, nomadRenderedCh, nomadEventCh = tm.nomad.ErrCh, tm.nomad.TemplateRenderedCh(), tm.nomad.RenderEventCh()
+		nomadRendered = false
+	}
+
 	// Wait till all the templates have been rendered
 WAIT:
 	for {
 		select {
 		case <-tm.shutdownCh:
 			return
-		case err, ok := <-tm.runner.ErrCh:
+		case err, ok := <-errCh:
 			if !ok {
 				continue
 			}
 
 			tm.config.Hooks.Kill(consulTemplateSourceName, err.Error(), true)
-		case <-tm.runner.TemplateRenderedCh():
+		case err := <-nomadErrCh:
+			tm.config.Hooks.Kill(consulTemplateSourceName, err.Error(), true)
+		case <-renderedCh:
 			// A template has been rendered, figure out what to do
-			events := tm.runner.RenderEvents()
-
-			// Not all templates have been rendered yet
-			if len(events) < len(tm.lookup) {
-				continue
-			}
-
-			for _, event := range events {
-				// This template hasn't been rendered
-				if event.LastWouldRender.IsZero() {
-					continue WAIT
-				}
-			}
-
-			break WAIT
-		case <-tm.runner.RenderEventCh():
-			events := tm.runner.RenderEvents()
-			joinedSet := make(map[string]struct{})
-			for _, event := range events {
-				missing := event.MissingDeps
-				if missing == nil {
-					continue
-				}
-
-				for _, dep := range missing.List() {
-					joinedSet[dep.String()] = struct{}{}
-				}
-			}
-
-			// Check to see if the new joined set is the same as the old
-			different := len(joinedSet) != len(missingDependencies)
-			if !different {
-				for k := range joinedSet {
-					if _, ok := missingDependencies[k]; !ok {
-						different = true
-						break
-					}
-				}
+			consulRendered = tm.allConsulTemplatesRendered()
+			if consulRendered && nomadRendered {
+				break WAIT
 			}
-
-			// Nothing to do
-			if !different {
-				continue
-			}
-
-			// Update the missing set
-			missingDependencies = joinedSet
-
-			// Update the event timer channel
-			if !outstandingEvent {
-				// We got new data so reset
-				outstandingEvent = true
-				eventTimer.Reset(tm.config.MaxTemplateEventRate)
+		case <-nomadRenderedCh:
+			nomadRendered = tm.nomad.AllRendered()
+			if consulRendered && nomadRendered {
+				break WAIT
 			}
+		case <-eventCh:
+			updateMissing()
+		case <-nomadEventCh:
+			updateMissing()
 		case <-eventTimer.C:
 			if missingDependencies == nil {
 				continue
@@ -342,6 +390,52 @@ WAIT:
 	}
 }
 
+// allConsulTemplatesRendered returns whether consul-template has rendered all
+// of its templates.
+func (tm *TaskTemplateManager) allConsulTemplatesRendered() bool {
+	events := tm.runner.RenderEvents()
+
+	// Not all templates have been rendered yet
+	if len(events) < len(tm.lookup) {
+		return false
+	}
+
+	for _, event := range events {
+		// This template hasn't been rendered
+		if event.LastWouldRender.IsZero() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// missingDependencies returns the set of the dependencies the templates of
+// both runners are missing.
+func (tm *TaskTemplateManager) missingDependencies() map[string]struct{} {
+	joinedSet := make(map[string]struct{})
+	if tm.runner != nil {
+		for _, event := range tm.runner.RenderEvents() {
+			missing := event.MissingDeps
+			if missing == nil {
+				continue
+			}
+
+			for _, dep := range missing.List() {
+				joinedSet[dep.String()] = struct{}{}
+			}
+		}
+	}
+	if tm.nomad != nil {
+		for _, event := range tm.nomad.RenderEvents() {
+			for _, dep := range event.MissingDeps {
+				joinedSet[dep] = struct{}{}
+			}
+		}
+	}
+	return joinedSet
+}
+
 // handleTemplateRerenders is used to handle template render events after they
 // have all rendered. It takes action based on which set of templates re-render.
 // The passed allRenderedTime is the time at which all templates have rendered.
@@ -350,111 +444,149 @@ func (tm *TaskTemplateManager) handleTemplateRerenders(allRenderedTime time.Time
 	// A lookup for the last time the template was handled
 	handledRenders := make(map[string]time.Time, len(tm.config.Templates))
 
+	// The channels of a runner that isn't used are left nil
+	var errCh, nomadErrCh <-chan error
+	var renderedCh, nomadRenderedCh <-chan struct{}
+	if tm.runner != nil {
+		errCh, renderedCh = tm.runner.ErrCh, tm.runner.TemplateRenderedCh()
+	}
+	if tm.nomad != nil {
+		nomadErrCh, nomadRenderedCh = tm.nomad.ErrCh, tm.nomad.TemplateRenderedCh()
+	}
+
 	for {
 		select {
 		case <-tm.shutdownCh:
 			return
-		case err, ok := <-tm.runner.ErrCh:
+		case err, ok := <-errCh:
 			if !ok {
 				continue
 			}
 
 			tm.config.Hooks.Kill(consulTemplateSourceName, err.Error(), true)
-		case <-tm.runner.TemplateRenderedCh():
-			// A template has been rendered, figure out what to do
-			var handling []string
-			signals := make(map[string]struct{})
-			restart := false
-			var splay time.Duration
-
+		case err := <-nomadErrCh:
+			tm.config.Hooks.Kill(consulTemplateSourceName, err.Error(), true)
+		case <-renderedCh:
 			events := tm.runner.RenderEvents()
+			lastRenders := make(map[string]time.Time, len(events))
 			for id, event := range events {
+				lastRenders[id] = event.LastDidRender
+			}
+			if !tm.handleRerenders(lastRenders, tm.lookup, handledRenders, allRenderedTime) {
+				return
+			}
+		case <-nomadRenderedCh:
+			events := tm.nomad.RenderEvents()
+			lastRenders := make(map[string]time.Time, len(events))
+			for id, event := range events {
+				lastRenders[id] = event.LastDidRender
+			}
+			if !tm.handleRerenders(lastRenders, tm.nomad.lookup, handledRenders, allRenderedTime) {
+				return
+			}
+		}
+	}
+}
 
-				// First time through
-				if allRenderedTime.After(event.LastDidRender) || allRenderedTime.Equal(event.LastDidRender) {
-					handledRenders[id] = allRenderedTime
-					continue
-				}
+// handleRerenders takes action on the templates rendered again since they were
+// last handled, given the last time each template of a runner rendered and a
+// lookup of the templates by their ID. It returns false if the task was killed.
+func (tm *TaskTemplateManager) handleRerenders(lastRenders map[string]time.Time,
+	lookup map[string][]*structs.Template, handledRenders map[string]time.Time, allRenderedTime time.Time) bool {
 
-				// We have already handled this one
-				if htime := handledRenders[id]; htime.After(event.LastDidRender) || htime.Equal(event.LastDidRender) {
-					continue
-				}
+	// A template has been rendered, figure out what to do
+	var handling []string
+	signals := make(map[string]struct{})
+	restart := false
+	var splay time.Duration
 
-				// Lookup the template and determine what to do
-				tmpls, ok := tm.lookup[id]
-				if !ok {
-					tm.config.Hooks.Kill(consulTemplateSourceName, fmt.Sprintf("template runner returned unknown template id %q", id), true)
-					return
-				}
+	for id, lastDidRender := range lastRenders {
 
-				// Read environment variables from templates
-				envMap, err := loadTemplateEnv(tm.config.Templates, tm.config.TaskDir)
-				if err != nil {
-					tm.config.Hooks.Kill(consulTemplateSourceName, err.Error(), true)
-					return
-				}
-				tm.config.EnvBuilder.SetTemplateEnv(envMap)
-
-				for _, tmpl := range tmpls {
-					switch tmpl.ChangeMode {
-					case structs.TemplateChangeModeSignal:
-						signals[tmpl.ChangeSignal] = struct{}{}
-					case structs.TemplateChangeModeRestart:
-						restart = true
-					case structs.TemplateChangeModeNoop:
-						continue
-					}
-
-					if tmpl.Splay > splay {
-						splay = tmpl.Splay
-					}
-				}
+		// First time through
+		if allRenderedTime.After(lastDidRender) || allRenderedTime.Equal(lastDidRender) {
+			handledRenders[id] = allRenderedTime
+			continue
+		}
+
+		// We have already handled this one
+		if htime := handledRenders[id]; htime.After(lastDidRender) || htime.Equal(lastDidRender) {
+			continue
+		}
 
-				handling = append(handling, id)
+		// Lookup the template and determine what to do
+		tmpls, ok := lookup[id]
+		if !ok {
+			tm.config.Hooks.Kill(consulTemplateSourceName, fmt.Sprintf("template runner returned unknown template id %q", id), true)
+			return false
+		}
+
+		// Read environment variables from templates
+		envMap, err := loadTemplateEnv(tm.config.Templates, tm.config.TaskDir)
+		if err != nil {
+			tm.config.Hooks.Kill(consulTemplateSourceName, err.Error(), true)
+			return false
+		}
+		tm.config.EnvBuilder.SetTemplateEnv(envMap)
+
+		for _, tmpl := range tmpls {
+			switch tmpl.ChangeMode {
+			case structs.TemplateChangeModeSignal:
+				signals[tmpl.ChangeSignal] = struct{}{}
+			case structs.TemplateChangeModeRestart:
+				restart = true
+			case structs.TemplateChangeModeNoop:
+				continue
 			}
 
-			if restart || len(signals) != 0 {
-				if splay != 0 {
-					ns := splay.Nanoseconds()
-					offset := rand.Int63n(ns)
-					t := time.Duration(offset)
-
-					select {
-					case <-time.After(t):
-					case <-tm.shutdownCh:
-						return
-					}
-				}
+			if tmpl.Splay > splay {
+				splay = tmpl.Splay
+			}
+		}
+
+		handling = append(handling, id)
+	}
+
+	if restart || len(signals) != 0 {
+		if splay != 0 {
+			ns := splay.Nanoseconds()
+			offset := rand.Int63n(ns)
+			t := time.Duration(offset)
+
+			select {
+			case <-time.After(t):
+			case <-tm.shutdownCh:
+				return false
+			}
+		}
 
-				// Update handle time
-				for _, id := range handling {
-					handledRenders[id] = events[id].LastDidRender
+		// Update handle time
+		for _, id := range handling {
+			handledRenders[id] = lastRenders[id]
+		}
+
+		if restart {
+			const failure = false
+			tm.config.Hooks.Restart(consulTemplateSourceName, "template with change_mode restart re-rendered", failure)
+		} else if len(signals) != 0 {
+			var mErr multierror.Error
+			for signal := range signals {
+				err := tm.config.Hooks.Signal(consulTemplateSourceName, "template re-rendered", tm.signals[signal])
+				if err != nil {
+					multierror.Append(&mErr, err)
 				}
+			}
 
-				if restart {
-					const failure = false
-					tm.config.Hooks.Restart(consulTemplateSourceName, "template with change_mode restart re-rendered", failure)
-				} else if len(signals) != 0 {
-					var mErr multierror.Error
-					for signal := range signals {
-						err := tm.config.Hooks.Signal(consulTemplateSourceName, "template re-rendered", tm.signals[signal])
-						if err != nil {
-							multierror.Append(&mErr, err)
-						}
-					}
-
-					if err := mErr.ErrorOrNil(); err != nil {
-						flat := make([]os.Signal, 0, len(signals))
-						for signal := range signals {
-							flat = append(flat, tm.signals[signal])
-						}
-						tm.config.Hooks.Kill(consulTemplateSourceName, fmt.Sprintf("Sending signals %v failed: %v", flat, err), true)
-					}
+			if err := mErr.ErrorOrNil(); err != nil {
+				flat := make([]os.Signal, 0, len(signals))
+				for signal := range signals {
+					flat = append(flat, tm.signals[signal])
 				}
+				tm.config.Hooks.Kill(consulTemplateSourceName, fmt.Sprintf("Sending signals %v failed: %v", flat, err), true)
 			}
 		}
 	}
+
+	return true
 }
 
 // allTemplatesNoop returns whether all the managed templates have change mode noop.
@@ -515,25 +647,11 @@ func templateRunner(config *TaskTemplateManagerConfig) (
 // parseTemplateConfigs converts the tasks templates in the config into
 // consul-templates
 func parseTemplateConfigs(config *TaskTemplateManagerConfig) (map[ctconf.TemplateConfig]*structs.Template, error) {
-	allowAbs := config.ClientConfig.ReadBoolDefault(hostSrcOption, true)
-	taskEnv := config.EnvBuilder.Build()
-
 	ctmpls := make(map[ctconf.TemplateConfig]*structs.Template, len(config.Templates))
 	for _, tmpl := range config.Templates {
-		var src, dest string
-		if tmpl.SourcePath != "" {
-			if filepath.IsAbs(tmpl.SourcePath) {
-				if !allowAbs {
-					return nil, fmt.Errorf("Specifying absolute template paths disallowed by client config: %q", tmpl.SourcePath)
-				}
-
-				src = tmpl.SourcePath
-			} else {
-				src = filepath.Join(config.TaskDir, taskEnv.ReplaceEnv(tmpl.SourcePath))
-			}
-		}
-		if tmpl.DestPath != "" {
-			dest = filepath.Join(config.TaskDir, taskEnv.ReplaceEnv(tmpl.DestPath))
+		src, dest, err := templatePaths(config, tmpl)
+		if err != nil {
+			return nil, err
 		}
 
 		ct := ctconf.DefaultTemplateConfig()
@@ -560,6 +678,29 @@ func parseTemplateConfigs(config *TaskTemplateManagerConfig) (map[ctconf.Templat
 	return ctmpls, nil
 }
 
+// templatePaths returns the paths of the source file and destination of the
+// template, interpolated with the task's environment.
+func templatePaths(config *TaskTemplateManagerConfig, tmpl *structs.Template) (src, dest string, err error) {
+	allowAbs := config.ClientConfig.ReadBoolDefault(hostSrcOption, true)
+	taskEnv := config.EnvBuilder.Build()
+
+	if tmpl.SourcePath != "" {
+		if filepath.IsAbs(tmpl.SourcePath) {
+			if !allowAbs {
+				return "", "", fmt.Errorf("Specifying absolute template paths disallowed by client config: %q", tmpl.SourcePath)
+			}
+
+			src = tmpl.SourcePath
+		} else {
+			src = filepath.Join(config.TaskDir, taskEnv.ReplaceEnv(tmpl.SourcePath))
+		}
+	}
+	if tmpl.DestPath != "" {
+		dest = filepath.Join(config.TaskDir, taskEnv.ReplaceEnv(tmpl.DestPath))
+	}
+	return src, dest, nil
+}
+
 // newRunnerConfig returns a consul-template runner configuration, setting the
 // Vault and Consul configurations based on the clients configs.
 func newRunnerConfig(config *TaskTemplateManagerConfig,
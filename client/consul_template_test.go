@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -112,6 +113,7 @@ type testHarness struct {
 	taskDir    string
 	vault      *testutil.TestVault
 	consul     *ctestutil.TestServer
+	source     *testTemplateSource
 	emitRate   time.Duration
 }
 
@@ -166,8 +168,7 @@ func (h *testHarness) start(t *testing.T) {
 }
 
 func (h *testHarness) startWithErr() error {
-	var err error
-	h.manager, err = NewTaskTemplateManager(&TaskTemplateManagerConfig{
+	config := &TaskTemplateManagerConfig{
 		Hooks:                h.mockHooks,
 		Templates:            h.templates,
 		ClientConfig:         h.config,
@@ -176,8 +177,13 @@ func (h *testHarness) startWithErr() error {
 		EnvBuilder:           h.envBuilder,
 		MaxTemplateEventRate: h.emitRate,
 		retryRate:            10 * time.Millisecond,
-	})
+	}
+	if h.source != nil {
+		config.NomadSource = h.source
+	}
 
+	var err error
+	h.manager, err = NewTaskTemplateManager(config)
 	return err
 }
 
@@ -201,6 +207,85 @@ func (h *testHarness) stop() {
 	}
 }
 
+// testTemplateSource serves the Nomad variables and services set by tests.
+// Its blocking queries return when they change.
+type testTemplateSource struct {
+	index     uint64
+	variables map[string]*structs.Variable
+	services  []*structs.ServiceInstance
+	changeCh  chan struct{}
+	lock      sync.Mutex
+}
+
+func newTestTemplateSource() *testTemplateSource {
+	return &testTemplateSource{
+		index:     1,
+		variables: make(map[string]*structs.Variable),
+		changeCh:  make(chan struct{}),
+	}
+}
+
+// SetVariable stores the items of a variable
+func (s *testTemplateSource) SetVariable(path string, items map[string]string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.variables[path] = &structs.Variable{Path: path, Items: items}
+	s.changed()
+}
+
+// SetServices replaces the instances of the services
+func (s *testTemplateSource) SetServices(instances []*structs.ServiceInstance) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.services = instances
+	s.changed()
+}
+
+func (s *testTemplateSource) changed() {
+	s.index++
+	close(s.changeCh)
+	s.changeCh = make(chan struct{})
+}
+
+// wait blocks until the index is greater than minIndex or a timeout, and
+// returns the index with the lock held
+func (s *testTemplateSource) wait(minIndex uint64) uint64 {
+	timeout := time.After(time.Second)
+	for {
+		s.lock.Lock()
+		if s.index > minIndex {
+			return s.index
+		}
+		ch := s.changeCh
+		s.lock.Unlock()
+
+		select {
+		case <-ch:
+		case <-timeout:
+			s.lock.Lock()
+			return s.index
+		}
+	}
+}
+
+func (s *testTemplateSource) Variable(path string, minIndex uint64) (*structs.Variable, uint64, error) {
+	index := s.wait(minIndex)
+	defer s.lock.Unlock()
+	return s.variables[path], index, nil
+}
+
+func (s *testTemplateSource) Services(name string, minIndex uint64) ([]*structs.ServiceInstance, uint64, error) {
+	index := s.wait(minIndex)
+	defer s.lock.Unlock()
+	var instances []*structs.ServiceInstance
+	for _, instance := range s.services {
+		if name == "" || instance.Name == name {
+			instances = append(instances, instance)
+		}
+	}
+	return instances, index, nil
+}
+
 func TestTaskTemplateManager_InvalidConfig(t *testing.T) {
 	t.Parallel()
 	hooks := NewMockTaskHooks()
@@ -1316,3 +1401,135 @@ func TestTaskTemplateManager_BlockedEvents(t *testing.T) {
 		t.Fatalf("bad event: %q", event)
 	}
 }
+
+func TestTaskTemplateManager_Unblock_Nomad(t *testing.T) {
+	t.Parallel()
+	// Make a template that renders a Nomad variable and service, along with a
+	// static one rendered by consul-template
+	embedded := `{{ with nomadVar "nomad/jobs/web" }}{{ .password }}{{ end }}` +
+		`{{ range nomadService "db" }} {{ .Address }}:{{ .Port }}{{ end }}`
+	file := "my.tmpl"
+	static := "hello, world!"
+	staticFile := "static.tmpl"
+	templates := []*structs.Template{
+		{
+			EmbeddedTmpl: embedded,
+			DestPath:     file,
+			ChangeMode:   structs.TemplateChangeModeNoop,
+		},
+		{
+			EmbeddedTmpl: static,
+			DestPath:     staticFile,
+			ChangeMode:   structs.TemplateChangeModeNoop,
+		},
+	}
+
+	harness := newTestHarness(t, templates, false, false)
+	harness.source = newTestTemplateSource()
+	harness.source.SetServices([]*structs.ServiceInstance{
+		{Name: "db", Address: "10.0.0.1", Port: 5432},
+		{Name: "cache", Address: "10.0.0.2", Port: 6379},
+	})
+	harness.setEmitRate(100 * time.Millisecond)
+	harness.start(t)
+	defer harness.stop()
+
+	// Ensure no unblock while the variable is missing
+	select {
+	case <-harness.mockHooks.UnblockCh:
+		t.Fatalf("Task unblock should have not have been called")
+	case <-time.After(time.Duration(1*testutil.TestMultiplier()) * time.Second):
+	}
+
+	// The missing variable is reported
+	select {
+	case <-harness.mockHooks.EmitEventCh:
+	case <-time.After(time.Duration(1*testutil.TestMultiplier()) * time.Second):
+		t.Fatalf("Should have received an event")
+	}
+	if l := len(harness.mockHooks.Events); l == 0 || !strings.Contains(harness.mockHooks.Events[l-1], "nomad.var(nomad/jobs/web)") {
+		t.Fatalf("Unexpected events: %v", harness.mockHooks.Events)
+	}
+
+	// Store the variable
+	harness.source.SetVariable("nomad/jobs/web", map[string]string{"password": "foo"})
+
+	// Wait for the unblock
+	select {
+	case <-harness.mockHooks.UnblockCh:
+	case <-time.After(time.Duration(5*testutil.TestMultiplier()) * time.Second):
+		t.Fatalf("Task unblock should have been called")
+	}
+
+	// Check the files are there
+	for path, expected := range map[string]string{
+		file:       "foo 10.0.0.1:5432",
+		staticFile: static,
+	} {
+		raw, err := ioutil.ReadFile(filepath.Join(harness.taskDir, path))
+		if err != nil {
+			t.Fatalf("Failed to read rendered template from %q: %v", path, err)
+		}
+		if s := string(raw); s != expected {
+			t.Fatalf("Unexpected template data; got %q, want %q", s, expected)
+		}
+	}
+}
+
+func TestTaskTemplateManager_Rerender_Nomad_Restart(t *testing.T) {
+	t.Parallel()
+	// Make a template that renders the Nomad services and sends restart
+	embedded := `{{ range nomadServices }}{{ .Name }}{{ range nomadService .Name }} {{ .Address }}{{ end }};{{ end }}`
+	file := "my.tmpl"
+	template := &structs.Template{
+		EmbeddedTmpl: embedded,
+		DestPath:     file,
+		ChangeMode:   structs.TemplateChangeModeRestart,
+	}
+
+	harness := newTestHarness(t, []*structs.Template{template}, false, false)
+	harness.source = newTestTemplateSource()
+	harness.source.SetServices([]*structs.ServiceInstance{
+		{Name: "db", Address: "10.0.0.1", Port: 5432},
+	})
+	harness.start(t)
+	defer harness.stop()
+
+	// Wait for the unblock
+	select {
+	case <-harness.mockHooks.UnblockCh:
+	case <-time.After(time.Duration(5*testutil.TestMultiplier()) * time.Second):
+		t.Fatalf("Task unblock should have been called")
+	}
+
+	// Add an instance of the service
+	harness.source.SetServices([]*structs.ServiceInstance{
+		{Name: "db", Address: "10.0.0.1", Port: 5432},
+		{Name: "db", Address: "10.0.0.2", Port: 5432},
+	})
+
+	// Wait for restart
+	timeout := time.After(time.Duration(5*testutil.TestMultiplier()) * time.Second)
+OUTER:
+	for {
+		select {
+		case <-harness.mockHooks.RestartCh:
+			break OUTER
+		case <-harness.mockHooks.SignalCh:
+			t.Fatalf("Signal with restart policy: %+v", harness.mockHooks)
+		case <-timeout:
+			t.Fatalf("Should have received a restart: %+v", harness.mockHooks)
+		}
+	}
+
+	// Check the file has been updated
+	path := filepath.Join(harness.taskDir, file)
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read rendered template from %q: %v", path, err)
+	}
+
+	if s, expected := string(raw), "db 10.0.0.1 10.0.0.2;"; s != expected {
+		t.Fatalf("Unexpected template data; got %q, want %q", s, expected)
+	}
+}
@@ -0,0 +1,918 @@
+// +build linux
+
+package driver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/client/driver/executor"
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
+	"github.com/hashicorp/nomad/client/stats"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/helper/fields"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/mitchellh/mapstructure"
+)
+
+var (
+	reContainerdVersion = regexp.MustCompile(`(?s)Server:.*?Version:\s+v?(\d[.\d]*)`)
+
+	// ContainerdMeasuredMemStats are the memory stats measured for tasks on
+	// cgroup v1 hosts
+	ContainerdMeasuredMemStats = []string{"RSS", "Cache", "Max Usage"}
+
+	// ContainerdMeasuredMemStatsV2 are the memory stats measured for tasks
+	// on cgroup v2 hosts
+	ContainerdMeasuredMemStatsV2 = []string{"RSS", "Cache", "Swap"}
+
+	// ContainerdMeasuredCpuStats are the CPU stats measured for tasks
+	ContainerdMeasuredCpuStats = []string{"Throttled Periods", "Throttled Time", "Percent", "System Mode", "User Mode"}
+)
+
+const (
+	// The key populated in the Node Attributes to indicate the presence of the
+	// containerd driver
+	containerdDriverAttr = "driver.containerd"
+
+	// containerdAddressConfigOption is the key for setting the address of
+	// the containerd socket
+	containerdAddressConfigOption  = "containerd.address"
+	containerdAddressConfigDefault = "/run/containerd/containerd.sock"
+
+	// containerdNamespaceConfigOption is the key for setting the containerd
+	// namespace the containers are created in
+	containerdNamespaceConfigOption  = "containerd.namespace"
+	containerdNamespaceConfigDefault = "nomad"
+
+	// containerdSnapshotterConfigOption is the key for setting the default
+	// snapshotter of the containers. containerd's default is used when
+	// unset.
+	containerdSnapshotterConfigOption = "containerd.snapshotter"
+
+	// containerdRuntimeConfigOption is the key for setting the default
+	// runtime class of the containers, such as io.containerd.runc.v2.
+	// containerd's default is used when unset.
+	containerdRuntimeConfigOption = "containerd.runtime"
+
+	// containerdVolumesConfigOption is the key for enabling the use of
+	// custom bind volumes to arbitrary host paths.
+	containerdVolumesConfigOption  = "containerd.volumes.enabled"
+	containerdVolumesConfigDefault = true
+
+	// nerdctlCmd is the command the containers are managed with. It talks
+	// to containerd directly, without a daemon of its own.
+	nerdctlCmd = "nerdctl"
+
+	// ctrCmd is the containerd CLI, used to fingerprint containerd and read
+	// the cgroup metrics of the containers.
+	ctrCmd = "ctr"
+
+	// containerdStartDeadline is how long to wait for the container to be
+	// created once the task is launched
+	containerdStartDeadline = 10 * time.Second
+
+	// containerdStatsTimeout is how long reading the metrics of a container
+	// may take
+	containerdStatsTimeout = 5 * time.Second
+)
+
+// ContainerdDriver is a driver for running OCI images with containerd,
+// without the Docker daemon. The containers are run with nerdctl under an
+// executor collecting their output.
+type ContainerdDriver struct {
+	DriverContext
+
+	// driverConfig is the task's driver config, parsed by Prestart
+	driverConfig *ContainerdDriverConfig
+
+	// A tri-state boolean to know if the fingerprinting has happened and
+	// whether it has been successful
+	fingerprintSuccess *bool
+}
+
+type ContainerdDriverConfig struct {
+	ImageName   string              `mapstructure:"image"`        // Image to run
+	Command     string              `mapstructure:"command"`      // The command to run
+	Args        []string            `mapstructure:"args"`         // The args to pass to the command
+	ForcePull   bool                `mapstructure:"force_pull"`   // Always pull the image before running it
+	Snapshotter string              `mapstructure:"snapshotter"`  // Snapshotter of the container's filesystem
+	Runtime     string              `mapstructure:"runtime"`      // Runtime class of the container
+	NetworkMode string              `mapstructure:"network_mode"` // The CNI network, host or none
+	PortMapRaw  []map[string]string `mapstructure:"port_map"`     //
+	PortMap     map[string]int      `mapstructure:"-"`            // A map of host port labels and the ports exposed on the container
+	Volumes     []string            `mapstructure:"volumes"`      // Host-Volumes to mount in, syntax: /path/to/host/directory:/destination/path/in/container[:ro]
+}
+
+// NewContainerdDriverConfig returns the containerd driver config of the task
+func NewContainerdDriverConfig(task *structs.Task, env *env.TaskEnv) (*ContainerdDriverConfig, error) {
+	var conf ContainerdDriverConfig
+	if err := mapstructure.WeakDecode(task.Config, &conf); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(conf.ImageName) == "" {
+		return nil, fmt.Errorf("containerd driver requires an image name")
+	}
+
+	conf.ImageName = env.ReplaceEnv(conf.ImageName)
+	conf.Command = env.ReplaceEnv(conf.Command)
+	conf.NetworkMode = env.ReplaceEnv(conf.NetworkMode)
+
+	portMap := make(map[string]int)
+	for _, m := range conf.PortMapRaw {
+		for k, v := range m {
+			ki, vi := env.ReplaceEnv(k), env.ReplaceEnv(v)
+			p, err := strconv.Atoi(vi)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse port map value %v to %v: %v", ki, vi, err)
+			}
+			portMap[ki] = p
+		}
+	}
+	conf.PortMap = portMap
+	return &conf, nil
+}
+
+// containerdHandle is returned from Start/Open as a handle to the container
+type containerdHandle struct {
+	name           string
+	containerID    string
+	globalArgs     []string
+	env            *env.TaskEnv
+	taskDir        *allocdir.TaskDir
+	pluginClient   *plugin.Client
+	executorPid    int
+	executor       executor.Executor
+	logger         *log.Logger
+	killTimeout    time.Duration
+	maxKillTimeout time.Duration
+	cpuStatsTotal  *stats.CpuStats
+	cpuStatsUser   *stats.CpuStats
+	cpuStatsSys    *stats.CpuStats
+	waitCh         chan *dstructs.WaitResult
+	doneCh         chan struct{}
+}
+
+// containerdPID is a struct to map the executor running nerdctl to the
+// container
+type containerdPID struct {
+	Name           string
+	ContainerID    string
+	GlobalArgs     []string
+	PluginConfig   *PluginReattachConfig
+	ExecutorPid    int
+	KillTimeout    time.Duration
+	MaxKillTimeout time.Duration
+}
+
+// containerdContainer is the subset of the container returned by `nerdctl
+// inspect` used by the driver
+type containerdContainer struct {
+	ID    string `json:"Id"`
+	State struct {
+		Running bool
+	}
+	NetworkSettings struct {
+		IPAddress string
+	}
+}
+
+// NewContainerdDriver is used to create a new containerd driver
+func NewContainerdDriver(ctx *DriverContext) Driver {
+	return &ContainerdDriver{DriverContext: *ctx}
+}
+
+func (d *ContainerdDriver) FSIsolation() cstructs.FSIsolation {
+	return cstructs.FSIsolationImage
+}
+
+// Validate is used to validate the driver configuration
+func (d *ContainerdDriver) Validate(config map[string]interface{}) error {
+	fd := &fields.FieldData{
+		Raw: config,
+		Schema: map[string]*fields.FieldSchema{
+			"image": {
+				Type:     fields.TypeString,
+				Required: true,
+			},
+			"command": {
+				Type: fields.TypeString,
+			},
+			"args": {
+				Type: fields.TypeArray,
+			},
+			"force_pull": {
+				Type: fields.TypeBool,
+			},
+			"snapshotter": {
+				Type: fields.TypeString,
+			},
+			"runtime": {
+				Type: fields.TypeString,
+			},
+			"network_mode": {
+				Type: fields.TypeString,
+			},
+			"port_map": {
+				Type: fields.TypeArray,
+			},
+			"volumes": {
+				Type: fields.TypeArray,
+			},
+		},
+	}
+
+	if err := fd.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (d *ContainerdDriver) Abilities() DriverAbilities {
+	return DriverAbilities{
+		SendSignals: true,
+		Exec:        true,
+	}
+}
+
+func (d *ContainerdDriver) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	// containerd is only accessible to root
+	if syscall.Geteuid() != 0 {
+		if d.fingerprintSuccess == nil || *d.fingerprintSuccess {
+			d.logger.Printf("[DEBUG] driver.containerd: must run as root user, disabling")
+		}
+		d.fingerprintSuccess = helper.BoolToPtr(false)
+		resp.RemoveAttribute(containerdDriverAttr)
+		return nil
+	}
+
+	if _, err := exec.LookPath(nerdctlCmd); err != nil {
+		if d.fingerprintSuccess == nil || *d.fingerprintSuccess {
+			d.logger.Printf("[DEBUG] driver.containerd: %s not found, disabling", nerdctlCmd)
+		}
+		d.fingerprintSuccess = helper.BoolToPtr(false)
+		resp.RemoveAttribute(containerdDriverAttr)
+		return nil
+	}
+
+	// Reading the version of the server checks containerd is reachable
+	address := d.config.ReadDefault(containerdAddressConfigOption, containerdAddressConfigDefault)
+	outBytes, err := exec.Command(ctrCmd, "--address", address, "version").Output()
+	if err != nil {
+		if d.fingerprintSuccess == nil || *d.fingerprintSuccess {
+			d.logger.Printf("[DEBUG] driver.containerd: could not connect to containerd at %s: %v", address, err)
+		}
+		d.fingerprintSuccess = helper.BoolToPtr(false)
+		resp.RemoveAttribute(containerdDriverAttr)
+		return nil
+	}
+
+	matches := reContainerdVersion.FindStringSubmatch(string(outBytes))
+	if len(matches) != 2 {
+		d.fingerprintSuccess = helper.BoolToPtr(false)
+		resp.RemoveAttribute(containerdDriverAttr)
+		return fmt.Errorf("Unable to parse containerd version string: %q", strings.TrimSpace(string(outBytes)))
+	}
+
+	resp.AddAttribute(containerdDriverAttr, "1")
+	resp.AddAttribute("driver.containerd.version", matches[1])
+	resp.Detected = true
+
+	// Advertise if this node supports containerd volumes
+	if d.config.ReadBoolDefault(containerdVolumesConfigOption, containerdVolumesConfigDefault) {
+		resp.AddAttribute("driver."+containerdVolumesConfigOption, "1")
+	}
+	d.fingerprintSuccess = helper.BoolToPtr(true)
+	return nil
+}
+
+func (d *ContainerdDriver) Periodic() (bool, time.Duration) {
+	return true, 15 * time.Second
+}
+
+// globalArgs returns the nerdctl arguments selecting the containerd socket,
+// namespace and snapshotter of the task
+func (d *ContainerdDriver) globalArgs(driverConfig *ContainerdDriverConfig) []string {
+	args := []string{
+		"--address", d.config.ReadDefault(containerdAddressConfigOption, containerdAddressConfigDefault),
+		"--namespace", d.config.ReadDefault(containerdNamespaceConfigOption, containerdNamespaceConfigDefault),
+	}
+	snapshotter := driverConfig.Snapshotter
+	if snapshotter == "" {
+		snapshotter = d.config.Read(containerdSnapshotterConfigOption)
+	}
+	if snapshotter != "" {
+		args = append(args, "--snapshotter", snapshotter)
+	}
+	return args
+}
+
+// Prestart pulls the image of the task if it is missing or a pull is forced
+func (d *ContainerdDriver) Prestart(ctx *ExecContext, task *structs.Task) (*PrestartResponse, error) {
+	driverConfig, err := NewContainerdDriverConfig(task, ctx.TaskEnv)
+	if err != nil {
+		return nil, err
+	}
+	d.driverConfig = driverConfig
+
+	globalArgs := d.globalArgs(driverConfig)
+	image := driverConfig.ImageName
+	if driverConfig.ForcePull || nerdctl(globalArgs, "image", "inspect", image) != nil {
+		d.logger.Printf("[DEBUG] driver.containerd: pulling image %s", image)
+		d.emitEvent("Downloading image %s", image)
+		if err := nerdctl(globalArgs, "pull", "--quiet", image); err != nil {
+			return nil, structs.NewRecoverableError(fmt.Errorf("Failed to pull %s: %v", image, err), true)
+		}
+		d.logger.Printf("[DEBUG] driver.containerd: pulled image %s", image)
+	}
+
+	resp := NewPrestartResponse()
+
+	// Return the PortMap if it's set
+	if len(driverConfig.PortMap) > 0 {
+		resp.Network = &cstructs.DriverNetwork{
+			PortMap: driverConfig.PortMap,
+		}
+	}
+	return resp, nil
+}
+
+// runArgs returns the nerdctl run arguments of the task, except for the
+// global arguments. The environment of the task is passed in envFile.
+func (d *ContainerdDriver) runArgs(ctx *ExecContext, task *structs.Task, driverConfig *ContainerdDriverConfig,
+	name, envFile string) ([]string, error) {
+
+	args := []string{
+		"run",
+		"--rm",
+		"--pull", "never",
+		"--name", name,
+		"--env-file", envFile,
+	}
+
+	runtime := driverConfig.Runtime
+	if runtime == "" {
+		runtime = d.config.Read(containerdRuntimeConfigOption)
+	}
+	if runtime != "" {
+		args = append(args, "--runtime", runtime)
+	}
+
+	network := driverConfig.NetworkMode
+	if network != "" {
+		args = append(args, "--network", network)
+	}
+
+	// Add the resource limits
+	args = append(args, "--memory", fmt.Sprintf("%dm", task.Resources.MemoryMB))
+	args = append(args, "--cpu-shares", strconv.Itoa(task.Resources.CPU))
+
+	binds, err := d.containerBinds(driverConfig, ctx.TaskDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range binds {
+		args = append(args, "--volume", b)
+	}
+
+	// Setup port mapping
+	if len(task.Resources.Networks) == 0 {
+		d.logger.Println("[DEBUG] driver.containerd: No network interfaces are available")
+		if len(driverConfig.PortMap) > 0 {
+			return nil, fmt.Errorf("Trying to map ports but no network interface is available")
+		}
+	} else if network == "host" {
+		// Port mapping is skipped when host networking is used.
+		d.logger.Println("[DEBUG] driver.containerd: Ignoring port_map when using host networking")
+	} else {
+		// TODO add support for more than one network
+		n := task.Resources.Networks[0]
+		ports := make([]structs.Port, 0, len(n.ReservedPorts)+len(n.DynamicPorts))
+		ports = append(ports, n.ReservedPorts...)
+		ports = append(ports, n.DynamicPorts...)
+		for _, port := range ports {
+			// By default we will map the allocated port 1:1 to the container
+			containerPort := port.Value
+
+			// If the user has mapped a port using port_map we'll change it here
+			if mapped, ok := driverConfig.PortMap[port.Label]; ok {
+				containerPort = mapped
+			}
+
+			for _, proto := range []string{"tcp", "udp"} {
+				args = append(args, "--publish", fmt.Sprintf("%s:%d:%d/%s", n.IP, port.Value, containerPort, proto))
+			}
+			d.logger.Printf("[DEBUG] driver.containerd: allocated port %s:%d -> %d", n.IP, port.Value, containerPort)
+		}
+	}
+
+	// If a user has been specified for the task, pass it through to the user
+	if task.User != "" {
+		args = append(args, "--user", task.User)
+	}
+
+	args = append(args, driverConfig.ImageName)
+
+	// If the user specified a custom command to run, it replaces the command
+	// of the image
+	parsedArgs := ctx.TaskEnv.ParseAndReplace(driverConfig.Args)
+	if driverConfig.Command != "" {
+		if err := validateCommand(driverConfig.Command, "args"); err != nil {
+			return nil, err
+		}
+		d.logger.Printf("[DEBUG] driver.containerd: setting container startup command to: %s", strings.Join(append([]string{driverConfig.Command}, parsedArgs...), " "))
+		args = append(args, driverConfig.Command)
+		args = append(args, parsedArgs...)
+	} else if len(parsedArgs) != 0 {
+		args = append(args, parsedArgs...)
+	}
+	return args, nil
+}
+
+// containerBinds returns the bind mounts of the container. The task
+// directories are always mounted, and relative volume paths are mounted from
+// the task directory.
+func (d *ContainerdDriver) containerBinds(driverConfig *ContainerdDriverConfig, taskDir *allocdir.TaskDir) ([]string, error) {
+	binds := []string{
+		fmt.Sprintf("%s:%s", taskDir.SharedAllocDir, allocdir.SharedAllocContainerPath),
+		fmt.Sprintf("%s:%s", taskDir.LocalDir, allocdir.TaskLocalContainerPath),
+		fmt.Sprintf("%s:%s", taskDir.SecretsDir, allocdir.TaskSecretsContainerPath),
+	}
+
+	volumesEnabled := d.config.ReadBoolDefault(containerdVolumesConfigOption, containerdVolumesConfigDefault)
+	for _, userbind := range driverConfig.Volumes {
+		parts := strings.Split(userbind, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid containerd volume: %q", userbind)
+		}
+
+		// Resolve dotted path segments
+		parts[0] = filepath.Clean(parts[0])
+
+		if filepath.IsAbs(parts[0]) {
+			if !volumesEnabled {
+				// Disallow mounting arbitrary absolute paths
+				return nil, fmt.Errorf("%s is false; cannot mount host paths: %+q", containerdVolumesConfigOption, userbind)
+			}
+		} else {
+			// Relative paths are always allowed as they mount within the
+			// task directory
+			parts[0] = filepath.Join(taskDir.Dir, parts[0])
+		}
+		binds = append(binds, strings.Join(parts, ":"))
+	}
+	return binds, nil
+}
+
+// writeEnvFile writes the environment of the task to a file readable only by
+// the client, so that its secrets don't appear in the arguments of nerdctl.
+func writeEnvFile(path string, taskEnv *env.TaskEnv) error {
+	var buf bytes.Buffer
+	for k, v := range taskEnv.Map() {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("environment variable %q can not contain newlines", k)
+		}
+		fmt.Fprintf(&buf, "%s=%s\n", k, v)
+	}
+	return ioutil.WriteFile(path, buf.Bytes(), 0600)
+}
+
+// hostEnv returns the environment nerdctl runs with. The task's environment is
+// passed through the env file, but nerdctl itself needs an environment with
+// PATH set to find the CNI plugins and iptables.
+func (d *ContainerdDriver) hostEnv() *env.TaskEnv {
+	filter := strings.Split(d.config.ReadDefault("env.blacklist", config.DefaultEnvBlacklist), ",")
+	return env.NewEmptyBuilder().SetHostEnvvars(filter).Build()
+}
+
+// Start runs the container of the task
+func (d *ContainerdDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse, error) {
+	driverConfig := d.driverConfig
+	if driverConfig == nil {
+		var err error
+		if driverConfig, err = NewContainerdDriverConfig(task, ctx.TaskEnv); err != nil {
+			return nil, err
+		}
+	}
+
+	name := fmt.Sprintf("%s-%s", task.Name, d.DriverContext.allocID)
+	envFile := filepath.Join(ctx.TaskDir.Dir, "containerd.env")
+	if err := writeEnvFile(envFile, ctx.TaskEnv); err != nil {
+		return nil, fmt.Errorf("failed to write task environment: %v", err)
+	}
+
+	runArgs, err := d.runArgs(ctx, task, driverConfig, name, envFile)
+	if err != nil {
+		return nil, err
+	}
+	globalArgs := d.globalArgs(driverConfig)
+	args := append(append([]string{}, globalArgs...), runArgs...)
+
+	absPath, err := GetAbsolutePath(nerdctlCmd)
+	if err != nil {
+		return nil, err
+	}
+
+	pluginLogFile := filepath.Join(ctx.TaskDir.Dir, fmt.Sprintf("%s-executor.out", task.Name))
+	executorConfig := &dstructs.ExecutorConfig{
+		LogFile:  pluginLogFile,
+		LogLevel: d.config.LogLevel,
+	}
+
+	execIntf, pluginClient, err := createExecutor(d.config.LogOutput, d.config, executorConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	hostEnv := d.hostEnv()
+	executorCtx := &executor.ExecutorContext{
+		TaskEnv: hostEnv,
+		Driver:  "containerd",
+		Task:    task,
+		TaskDir: ctx.TaskDir.Dir,
+		LogDir:  ctx.TaskDir.LogDir,
+	}
+	if err := execIntf.SetContext(executorCtx); err != nil {
+		pluginClient.Kill()
+		return nil, fmt.Errorf("failed to set executor context: %v", err)
+	}
+
+	execCmd := &executor.ExecCommand{
+		Cmd:  absPath,
+		Args: args,
+	}
+	ps, err := execIntf.LaunchCmd(execCmd)
+	if err != nil {
+		pluginClient.Kill()
+		return nil, err
+	}
+	d.logger.Printf("[DEBUG] driver.containerd: started container %s for task %q with: %v", name, d.taskName, args)
+
+	// Wait for the container to be created to learn its ID and address
+	container, err := containerdWaitContainer(globalArgs, name, pluginClient)
+	if err != nil {
+		d.logger.Printf("[ERR] driver.containerd: %v", err)
+		execIntf.Exit()
+		pluginClient.Kill()
+		nerdctl(globalArgs, "rm", "--force", name)
+		return nil, structs.NewRecoverableError(err, true)
+	}
+
+	maxKill := d.DriverContext.config.MaxKillTimeout
+	h := &containerdHandle{
+		name:           name,
+		containerID:    container.ID,
+		globalArgs:     globalArgs,
+		env:            hostEnv,
+		taskDir:        ctx.TaskDir,
+		pluginClient:   pluginClient,
+		executor:       execIntf,
+		executorPid:    ps.Pid,
+		logger:         d.logger,
+		killTimeout:    GetKillTimeout(task.KillTimeout, maxKill),
+		maxKillTimeout: maxKill,
+		cpuStatsTotal:  stats.NewCpuStats(),
+		cpuStatsUser:   stats.NewCpuStats(),
+		cpuStatsSys:    stats.NewCpuStats(),
+		doneCh:         make(chan struct{}),
+		waitCh:         make(chan *dstructs.WaitResult, 1),
+	}
+	go h.run()
+
+	// Only return a driver network if *not* using host networking
+	resp := &StartResponse{Handle: h}
+	if ip := container.NetworkSettings.IPAddress; ip != "" && driverConfig.NetworkMode != "host" {
+		resp.Network = &cstructs.DriverNetwork{
+			PortMap: driverConfig.PortMap,
+			IP:      ip,
+		}
+	}
+	return resp, nil
+}
+
+// containerdWaitContainer waits for the container launched by nerdctl to be
+// created and running, unless nerdctl exits first
+func containerdWaitContainer(globalArgs []string, name string, pluginClient *plugin.Client) (*containerdContainer, error) {
+	deadline := time.Now().Add(containerdStartDeadline)
+	var lastErr error
+	for time.Now().Before(deadline) {
+		c, err := containerdInspect(globalArgs, name)
+		if err == nil && c.State.Running {
+			return c, nil
+		} else if err == nil {
+			lastErr = fmt.Errorf("container %s not running", name)
+		} else {
+			lastErr = err
+		}
+		if pluginClient.Exited() {
+			break
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return nil, fmt.Errorf("failed to start container %s: %v", name, lastErr)
+}
+
+// containerdInspect returns the container with the given name
+func containerdInspect(globalArgs []string, name string) (*containerdContainer, error) {
+	var outBuf, errBuf bytes.Buffer
+	cmd := exec.Command(nerdctlCmd, append(append([]string{}, globalArgs...), "inspect", name)...)
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %v: %s", name, err, strings.TrimSpace(errBuf.String()))
+	}
+	var containers []*containerdContainer
+	if err := json.Unmarshal(outBuf.Bytes(), &containers); err != nil {
+		return nil, fmt.Errorf("failed to parse container %s: %v", name, err)
+	}
+	if len(containers) != 1 {
+		return nil, fmt.Errorf("failed to find container %s", name)
+	}
+	return containers[0], nil
+}
+
+// nerdctl runs a nerdctl command and returns its error output on failure
+func nerdctl(globalArgs []string, args ...string) error {
+	var errBuf bytes.Buffer
+	cmd := exec.Command(nerdctlCmd, append(append([]string{}, globalArgs...), args...)...)
+	cmd.Stdout = ioutil.Discard
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(errBuf.String()); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+func (d *ContainerdDriver) Cleanup(*ExecContext, *CreatedResources) error { return nil }
+
+func (d *ContainerdDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, error) {
+	// Parse the handle
+	pidBytes := []byte(strings.TrimPrefix(handleID, "containerd:"))
+	id := &containerdPID{}
+	if err := json.Unmarshal(pidBytes, id); err != nil {
+		return nil, fmt.Errorf("failed to parse containerd handle '%s': %v", handleID, err)
+	}
+
+	pluginConfig := &plugin.ClientConfig{
+		Reattach: id.PluginConfig.PluginConfig(),
+	}
+	exec, pluginClient, err := createExecutorWithConfig(pluginConfig, d.config.LogOutput)
+	if err != nil {
+		d.logger.Println("[ERR] driver.containerd: error connecting to plugin so destroying plugin pid and user pid")
+		if e := destroyPlugin(id.PluginConfig.Pid, id.ExecutorPid); e != nil {
+			d.logger.Printf("[ERR] driver.containerd: error destroying plugin and executor pid: %v", e)
+		}
+		if e := nerdctl(id.GlobalArgs, "rm", "--force", id.Name); e != nil {
+			d.logger.Printf("[DEBUG] driver.containerd: couldn't remove container %s: %v", id.Name, e)
+		}
+		return nil, fmt.Errorf("error connecting to plugin: %v", err)
+	}
+
+	ver, _ := exec.Version()
+	d.logger.Printf("[DEBUG] driver.containerd: version of executor: %v", ver.Version)
+
+	// Return a driver handle
+	h := &containerdHandle{
+		name:           id.Name,
+		containerID:    id.ContainerID,
+		globalArgs:     id.GlobalArgs,
+		env:            d.hostEnv(),
+		taskDir:        ctx.TaskDir,
+		pluginClient:   pluginClient,
+		executorPid:    id.ExecutorPid,
+		executor:       exec,
+		logger:         d.logger,
+		killTimeout:    id.KillTimeout,
+		maxKillTimeout: id.MaxKillTimeout,
+		cpuStatsTotal:  stats.NewCpuStats(),
+		cpuStatsUser:   stats.NewCpuStats(),
+		cpuStatsSys:    stats.NewCpuStats(),
+		doneCh:         make(chan struct{}),
+		waitCh:         make(chan *dstructs.WaitResult, 1),
+	}
+	go h.run()
+	return h, nil
+}
+
+func (h *containerdHandle) ID() string {
+	// Return a handle to the PID
+	pid := &containerdPID{
+		Name:           h.name,
+		ContainerID:    h.containerID,
+		GlobalArgs:     h.globalArgs,
+		PluginConfig:   NewPluginReattachConfig(h.pluginClient.ReattachConfig()),
+		KillTimeout:    h.killTimeout,
+		MaxKillTimeout: h.maxKillTimeout,
+		ExecutorPid:    h.executorPid,
+	}
+	data, err := json.Marshal(pid)
+	if err != nil {
+		h.logger.Printf("[ERR] driver.containerd: failed to marshal containerd PID to JSON: %s", err)
+	}
+	return fmt.Sprintf("containerd:%s", string(data))
+}
+
+func (h *containerdHandle) WaitCh() chan *dstructs.WaitResult {
+	return h.waitCh
+}
+
+func (h *containerdHandle) Update(task *structs.Task) error {
+	// Store the updated kill timeout.
+	h.killTimeout = GetKillTimeout(task.KillTimeout, h.maxKillTimeout)
+	h.executor.UpdateTask(task)
+
+	// Update is not possible
+	return nil
+}
+
+func (h *containerdHandle) Exec(ctx context.Context, cmd string, args []string) ([]byte, int, error) {
+	execArgs := append(append([]string{}, h.globalArgs...), "exec", h.name, cmd)
+	execArgs = append(execArgs, args...)
+	return executor.ExecScript(ctx, h.taskDir.Dir, h.env, nil, nerdctlCmd, execArgs)
+}
+
+func (h *containerdHandle) Signal(s os.Signal) error {
+	sig, ok := s.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("Failed to determine signal number")
+	}
+	return nerdctl(h.globalArgs, "kill", "--signal", strconv.Itoa(int(sig)), h.name)
+}
+
+// Kill is used to terminate the task. The container is stopped with the kill
+// timeout as grace period before the executor is killed.
+func (h *containerdHandle) Kill() error {
+	timeout := int(math.Ceil(h.killTimeout.Seconds()))
+	if err := nerdctl(h.globalArgs, "stop", "--time", strconv.Itoa(timeout), h.name); err != nil {
+		h.logger.Printf("[ERR] driver.containerd: failed to stop container %s: %v", h.name, err)
+	}
+	select {
+	case <-h.doneCh:
+		return nil
+	case <-time.After(h.killTimeout):
+		return h.executor.Exit()
+	}
+}
+
+// Stats returns the resource usage of the container read from its cgroup
+// metrics
+func (h *containerdHandle) Stats() (*cstructs.TaskResourceUsage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), containerdStatsTimeout)
+	defer cancel()
+
+	// ctr only understands the address and namespace of the global args
+	var args []string
+	for i := 0; i+1 < len(h.globalArgs); i += 2 {
+		if h.globalArgs[i] == "--address" || h.globalArgs[i] == "--namespace" {
+			args = append(args, h.globalArgs[i], h.globalArgs[i+1])
+		}
+	}
+	args = append(args, "task", "metrics", "--format", "json", h.containerID)
+
+	var outBuf, errBuf bytes.Buffer
+	cmd := exec.CommandContext(ctx, ctrCmd, args...)
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to read metrics of container %s: %v: %s", h.name, err, strings.TrimSpace(errBuf.String()))
+	}
+
+	var m containerdMetrics
+	if err := json.Unmarshal(outBuf.Bytes(), &m); err != nil {
+		return nil, fmt.Errorf("failed to parse metrics of container %s: %v", h.name, err)
+	}
+	return &cstructs.TaskResourceUsage{
+		ResourceUsage: m.resourceUsage(h.cpuStatsTotal, h.cpuStatsUser, h.cpuStatsSys),
+		Timestamp:     time.Now().UTC().UnixNano(),
+	}, nil
+}
+
+func (h *containerdHandle) run() {
+	ps, werr := h.executor.Wait()
+	close(h.doneCh)
+	if ps.ExitCode == 0 && werr != nil {
+		if e := killProcess(h.executorPid); e != nil {
+			h.logger.Printf("[ERR] driver.containerd: error killing user process: %v", e)
+		}
+	}
+
+	// Exit the executor
+	if err := h.executor.Exit(); err != nil {
+		h.logger.Printf("[ERR] driver.containerd: error killing executor: %v", err)
+	}
+	h.pluginClient.Kill()
+
+	// Remove the container in case nerdctl exited before it
+	if err := nerdctl(h.globalArgs, "rm", "--force", h.name); err != nil {
+		h.logger.Printf("[DEBUG] driver.containerd: removing container %s: %v", h.name, err)
+	}
+
+	// Send the results
+	h.waitCh <- dstructs.NewWaitResult(ps.ExitCode, 0, werr)
+	close(h.waitCh)
+}
+
+// containerdMetrics are the cgroup metrics of a container printed by `ctr task
+// metrics --format json`. cgroup v1 hosts report the CPU usage in nanoseconds
+// and the memory usage as an object, and cgroup v2 hosts report the CPU usage
+// in microseconds and the memory usage as a number.
+type containerdMetrics struct {
+	CPU struct {
+		// cgroup v1
+		Usage *struct {
+			Total  uint64 `json:"total"`
+			Kernel uint64 `json:"kernel"`
+			User   uint64 `json:"user"`
+		} `json:"usage"`
+		Throttling *struct {
+			ThrottledPeriods uint64 `json:"throttled_periods"`
+			ThrottledTime    uint64 `json:"throttled_time"`
+		} `json:"throttling"`
+
+		// cgroup v2
+		UsageUsec     uint64 `json:"usage_usec"`
+		UserUsec      uint64 `json:"user_usec"`
+		SystemUsec    uint64 `json:"system_usec"`
+		NrThrottled   uint64 `json:"nr_throttled"`
+		ThrottledUsec uint64 `json:"throttled_usec"`
+	} `json:"cpu"`
+	Memory struct {
+		Usage json.RawMessage `json:"usage"`
+
+		// cgroup v1
+		Cache uint64 `json:"cache"`
+		RSS   uint64 `json:"rss"`
+
+		// cgroup v2
+		Anon      uint64 `json:"anon"`
+		File      uint64 `json:"file"`
+		SwapUsage uint64 `json:"swap_usage"`
+	} `json:"memory"`
+}
+
+// resourceUsage converts the metrics, computing the CPU percentages from the
+// previous metrics of the container
+func (m *containerdMetrics) resourceUsage(total, user, sys *stats.CpuStats) *cstructs.ResourceUsage {
+	ms := &cstructs.MemoryStats{}
+	var v1Usage struct {
+		Max uint64 `json:"max"`
+	}
+	if u := bytes.TrimSpace(m.Memory.Usage); len(u) > 0 && u[0] == '{' && json.Unmarshal(u, &v1Usage) == nil {
+		ms.RSS = m.Memory.RSS
+		ms.Cache = m.Memory.Cache
+		ms.MaxUsage = v1Usage.Max
+		ms.Measured = ContainerdMeasuredMemStats
+	} else {
+		ms.RSS = m.Memory.Anon
+		ms.Cache = m.Memory.File
+		ms.Swap = m.Memory.SwapUsage
+		ms.Measured = ContainerdMeasuredMemStatsV2
+	}
+
+	cs := &cstructs.CpuStats{Measured: ContainerdMeasuredCpuStats}
+	var totalNs, userNs, sysNs float64
+	if u := m.CPU.Usage; u != nil {
+		totalNs, userNs, sysNs = float64(u.Total), float64(u.User), float64(u.Kernel)
+		if t := m.CPU.Throttling; t != nil {
+			cs.ThrottledPeriods = t.ThrottledPeriods
+			cs.ThrottledTime = t.ThrottledTime
+		}
+	} else {
+		totalNs = float64(m.CPU.UsageUsec * 1000)
+		userNs = float64(m.CPU.UserUsec * 1000)
+		sysNs = float64(m.CPU.SystemUsec * 1000)
+		cs.ThrottledPeriods = m.CPU.NrThrottled
+		cs.ThrottledTime = m.CPU.ThrottledUsec * 1000
+	}
+	cs.Percent = total.Percent(totalNs)
+	cs.UserMode = user.Percent(userNs)
+	cs.SystemMode = sys.Percent(sysNs)
+	cs.TotalTicks = total.TicksConsumed(cs.Percent)
+
+	return &cstructs.ResourceUsage{
+		MemoryStats: ms,
+		CpuStats:    cs,
+	}
+}
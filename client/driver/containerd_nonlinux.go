@@ -0,0 +1,54 @@
+// +build !linux
+
+package driver
+
+import (
+	"time"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// NewContainerdDriver returns an unimplemented driver that returns false during
+// fingerprinting.
+func NewContainerdDriver(*DriverContext) Driver {
+	return ContainerdDriver{}
+}
+
+type ContainerdDriver struct{}
+
+func (ContainerdDriver) Prestart(*ExecContext, *structs.Task) (*PrestartResponse, error) {
+	panic("not implemented")
+}
+
+func (ContainerdDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse, error) {
+	panic("not implemented")
+}
+
+func (ContainerdDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, error) {
+	panic("not implemented")
+}
+
+func (ContainerdDriver) Cleanup(*ExecContext, *CreatedResources) error {
+	panic("not implemented")
+}
+
+func (ContainerdDriver) Validate(map[string]interface{}) error {
+	panic("not implemented")
+}
+
+func (ContainerdDriver) Abilities() DriverAbilities {
+	panic("not implemented")
+}
+
+func (ContainerdDriver) FSIsolation() cstructs.FSIsolation {
+	panic("not implemented")
+}
+
+func (ContainerdDriver) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	return nil
+}
+
+func (ContainerdDriver) Periodic() (bool, time.Duration) {
+	return false, 0
+}
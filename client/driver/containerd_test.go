@@ -0,0 +1,213 @@
+// +build linux
+
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/client/stats"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	ctestutils "github.com/hashicorp/nomad/client/testutil"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerdVersionRegex(t *testing.T) {
+	t.Parallel()
+
+	out := `Client:
+  Version:  v1.7.2
+  Revision: 7f7fdf5fed64eb6a7caf99b3e12efcf9d60e311c
+  Go version: go1.20.13
+
+Server:
+  Version:  1.6.28
+  Revision: ae07eda36dd25f8a1b98dfbf587313b99c0190bb
+  UUID: 3ba2e3a4-0f8c-4b5e-8e5e-0e0c4d1b5a3e
+`
+	matches := reContainerdVersion.FindStringSubmatch(out)
+	require.Len(t, matches, 2)
+	require.Equal(t, "1.6.28", matches[1])
+}
+
+func containerdTestTask() *structs.Task {
+	return &structs.Task{
+		Name:   "web",
+		Driver: "containerd",
+		Config: map[string]interface{}{
+			"image":   "docker.io/library/busybox:1.36",
+			"command": "/bin/sh",
+			"args":    []string{"-c", "echo ${NOMAD_TASK_NAME}"},
+			"port_map": []map[string]string{
+				{"http": "8080"},
+			},
+			"volumes": []string{"data:/data", "/etc/ssl:/etc/ssl:ro"},
+		},
+		LogConfig: &structs.LogConfig{
+			MaxFiles:      10,
+			MaxFileSizeMB: 10,
+		},
+		Resources: &structs.Resources{
+			CPU:      250,
+			MemoryMB: 128,
+			Networks: []*structs.NetworkResource{
+				{
+					IP:            "10.0.0.1",
+					ReservedPorts: []structs.Port{{Label: "admin", Value: 9000}},
+					DynamicPorts:  []structs.Port{{Label: "http", Value: 23456}},
+				},
+			},
+		},
+	}
+}
+
+func TestContainerdDriver_RunArgs(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	task := containerdTestTask()
+	ctx := testDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	ctx.DriverCtx.config.Options = map[string]string{
+		containerdNamespaceConfigOption:   "jobs",
+		containerdSnapshotterConfigOption: "native",
+		containerdRuntimeConfigOption:     "io.containerd.runc.v2",
+	}
+	d := NewContainerdDriver(ctx.DriverCtx).(*ContainerdDriver)
+
+	conf, err := NewContainerdDriverConfig(task, ctx.ExecCtx.TaskEnv)
+	require.Nil(err)
+	require.Equal(map[string]int{"http": 8080}, conf.PortMap)
+
+	require.Equal([]string{
+		"--address", containerdAddressConfigDefault,
+		"--namespace", "jobs",
+		"--snapshotter", "native",
+	}, d.globalArgs(conf))
+
+	// The task config overrides the client's snapshotter
+	conf.Snapshotter = "overlayfs"
+	require.Contains(strings.Join(d.globalArgs(conf), " "), "--snapshotter overlayfs")
+
+	args, err := d.runArgs(ctx.ExecCtx, task, conf, "web-1", "/tmp/web.env")
+	require.Nil(err)
+	joined := strings.Join(args, " ")
+	td := ctx.ExecCtx.TaskDir
+	for _, expected := range []string{
+		"run --rm --pull never --name web-1 --env-file /tmp/web.env",
+		"--runtime io.containerd.runc.v2",
+		"--memory 128m --cpu-shares 250",
+		fmt.Sprintf("--volume %s:/alloc", td.SharedAllocDir),
+		fmt.Sprintf("--volume %s:/data", filepath.Join(td.Dir, "data")),
+		"--volume /etc/ssl:/etc/ssl:ro",
+		"--publish 10.0.0.1:9000:9000/tcp --publish 10.0.0.1:9000:9000/udp",
+		"--publish 10.0.0.1:23456:8080/tcp",
+	} {
+		require.Contains(joined, expected)
+	}
+	require.Equal([]string{"docker.io/library/busybox:1.36", "/bin/sh", "-c", "echo web"}, args[len(args)-4:])
+
+	// Host networking publishes no ports
+	conf.NetworkMode = "host"
+	args, err = d.runArgs(ctx.ExecCtx, task, conf, "web-1", "/tmp/web.env")
+	require.Nil(err)
+	require.NotContains(strings.Join(args, " "), "--publish")
+
+	// Host paths can be disabled
+	ctx.DriverCtx.config.Options[containerdVolumesConfigOption] = "false"
+	_, err = d.runArgs(ctx.ExecCtx, task, conf, "web-1", "/tmp/web.env")
+	require.Error(err)
+	require.Contains(err.Error(), "cannot mount host paths")
+}
+
+func TestContainerdDriver_Metrics(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	v1 := `{
+  "cpu": {"usage": {"total": 2000000, "kernel": 500000, "user": 1500000}, "throttling": {"throttled_periods": 3, "throttled_time": 400}},
+  "memory": {"cache": 2048, "rss": 1024, "usage": {"usage": 4096, "max": 8192}}
+}`
+	var m containerdMetrics
+	assert.Nil(json.Unmarshal([]byte(v1), &m))
+	ru := m.resourceUsage(stats.NewCpuStats(), stats.NewCpuStats(), stats.NewCpuStats())
+	assert.EqualValues(1024, ru.MemoryStats.RSS)
+	assert.EqualValues(2048, ru.MemoryStats.Cache)
+	assert.EqualValues(8192, ru.MemoryStats.MaxUsage)
+	assert.Equal(ContainerdMeasuredMemStats, ru.MemoryStats.Measured)
+	assert.EqualValues(3, ru.CpuStats.ThrottledPeriods)
+	assert.EqualValues(400, ru.CpuStats.ThrottledTime)
+
+	v2 := `{
+  "cpu": {"usage_usec": 2000, "user_usec": 1500, "system_usec": 500, "nr_throttled": 2, "throttled_usec": 7},
+  "memory": {"usage": 4096, "anon": 1024, "file": 2048, "swap_usage": 512}
+}`
+	m = containerdMetrics{}
+	assert.Nil(json.Unmarshal([]byte(v2), &m))
+	total := stats.NewCpuStats()
+	ru = m.resourceUsage(total, stats.NewCpuStats(), stats.NewCpuStats())
+	assert.EqualValues(1024, ru.MemoryStats.RSS)
+	assert.EqualValues(2048, ru.MemoryStats.Cache)
+	assert.EqualValues(512, ru.MemoryStats.Swap)
+	assert.Equal(ContainerdMeasuredMemStatsV2, ru.MemoryStats.Measured)
+	assert.EqualValues(2, ru.CpuStats.ThrottledPeriods)
+	assert.EqualValues(7000, ru.CpuStats.ThrottledTime)
+
+	// The CPU percentage is computed from the previous metrics
+	time.Sleep(10 * time.Millisecond)
+	m.CPU.UsageUsec += 5000
+	ru = m.resourceUsage(total, stats.NewCpuStats(), stats.NewCpuStats())
+	assert.True(ru.CpuStats.Percent > 0)
+}
+
+func TestContainerdDriver_Start_Wait(t *testing.T) {
+	if os.Getenv("NOMAD_TEST_CONTAINERD") == "" {
+		t.Skip("skipping containerd tests")
+	}
+	ctestutils.ContainerdCompatible(t)
+	require := require.New(t)
+
+	task := containerdTestTask()
+	task.Config["args"] = []string{"-c", "echo hello from ${NOMAD_TASK_NAME}; sleep 1"}
+	task.Config["volumes"] = []string{"data:/data"}
+	ctx := testDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	d := NewContainerdDriver(ctx.DriverCtx)
+
+	var fp cstructs.FingerprintResponse
+	require.Nil(d.Fingerprint(&cstructs.FingerprintRequest{Config: &config.Config{}, Node: ctx.DriverCtx.node}, &fp))
+	require.True(fp.Detected)
+	require.NotEmpty(fp.Attributes["driver.containerd.version"])
+
+	_, err := d.Prestart(ctx.ExecCtx, task)
+	require.Nil(err)
+	resp, err := d.Start(ctx.ExecCtx, task)
+	require.Nil(err)
+	defer resp.Handle.Kill()
+
+	out, code, err := resp.Handle.Exec(context.Background(), "/bin/echo", []string{"exec"})
+	require.Nil(err)
+	require.Zero(code)
+	require.Equal("exec\n", string(out))
+
+	select {
+	case res := <-resp.Handle.WaitCh():
+		require.True(res.Successful(), "%v", res)
+	case <-time.After(30 * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	logs, err := ioutil.ReadFile(filepath.Join(ctx.ExecCtx.TaskDir.LogDir, "web.stdout.0"))
+	require.Nil(err)
+	require.Equal("hello from web\n", string(logs))
+}
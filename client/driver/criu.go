@@ -0,0 +1,64 @@
+package driver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/nomad/client/allocdir"
+)
+
+const (
+	// criuPidFile is the file of the task dir the pid of restored tasks is
+	// written to
+	criuPidFile = "restore.pid"
+
+	// checkpointConfigOption is the driver config option opting tasks into
+	// being checkpointed when migrated
+	checkpointConfigOption = "checkpoint"
+)
+
+// criuOptions are the options of both the dumps and restores of tasks. The
+// cgroups of the tasks are managed by their executor.
+var criuOptions = []string{
+	"--shell-job",
+	"--tcp-established",
+	"--file-locks",
+	"--ext-unix-sk",
+	"--manage-cgroups=ignore",
+}
+
+// linkCheckpointTaskDir links the task dir a task was checkpointed from to
+// the task dir it is restored into, since the files opened by the task refer
+// to the former. It returns the link created, if any.
+func linkCheckpointTaskDir(c *allocdir.Checkpoint, taskDir string) (string, error) {
+	if c.TaskDir == "" || c.TaskDir == taskDir {
+		return "", nil
+	}
+	if _, err := os.Lstat(c.TaskDir); err == nil {
+		return "", fmt.Errorf("checkpointed task dir %q already exists", c.TaskDir)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.TaskDir), 0711); err != nil {
+		return "", err
+	}
+	if err := os.Symlink(taskDir, c.TaskDir); err != nil {
+		return "", err
+	}
+	return c.TaskDir, nil
+}
+
+// readPidFile returns the pid written to a pid file
+func readPidFile(path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid pid file %q: %v", path, err)
+	}
+	return pid, nil
+}
@@ -0,0 +1,18 @@
+// +build !linux
+
+package driver
+
+import "fmt"
+
+// criuAvailable returns false as CRIU is only available on Linux
+func criuAvailable() (string, bool) {
+	return "", false
+}
+
+func criuDump(pid int, dir string) error {
+	return fmt.Errorf("CRIU is only available on Linux")
+}
+
+func criuRestoreCommand(dir, pidFile string) (string, []string, error) {
+	return "", nil, fmt.Errorf("CRIU is only available on Linux")
+}
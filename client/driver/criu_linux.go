@@ -0,0 +1,94 @@
+package driver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// criuStdioFile is the file of the checkpoint dir listing the pipes the
+// standard output and error of the dumped task were written to. The pipes
+// are read by the executor of the task so they must be replaced by those of
+// the new executor on restore.
+const criuStdioFile = "stdio"
+
+var (
+	// criuOnce guards the detection of CRIU, which forks a check of the
+	// kernel features it requires
+	criuOnce sync.Once
+
+	// criuPath is the path of the criu binary if CRIU works on the host
+	criuPath string
+)
+
+// criuAvailable returns the path of the criu binary and whether CRIU can
+// checkpoint processes on the host.
+func criuAvailable() (string, bool) {
+	criuOnce.Do(func() {
+		path, err := exec.LookPath("criu")
+		if err != nil {
+			return
+		}
+		if err := exec.Command(path, "check").Run(); err != nil {
+			return
+		}
+		criuPath = path
+	})
+	return criuPath, criuPath != ""
+}
+
+// criuDump dumps the process tree rooted at pid into dir, stopping it
+func criuDump(pid int, dir string) error {
+	path, ok := criuAvailable()
+	if !ok {
+		return fmt.Errorf("CRIU is unavailable")
+	}
+
+	var pipes []string
+	for fd := 1; fd <= 2; fd++ {
+		target, err := os.Readlink(fmt.Sprintf("/proc/%d/fd/%d", pid, fd))
+		if err != nil {
+			return fmt.Errorf("failed to read fd %d of pid %d: %v", fd, pid, err)
+		}
+		if strings.HasPrefix(target, "pipe:") {
+			pipes = append(pipes, fmt.Sprintf("fd[%d]:%s", fd, target))
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, criuStdioFile), []byte(strings.Join(pipes, "\n")), 0600); err != nil {
+		return err
+	}
+
+	args := []string{"dump", "--tree", strconv.Itoa(pid), "--images-dir", dir, "--log-file", "dump.log"}
+	args = append(args, criuOptions...)
+	if out, err := exec.Command(path, args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("criu dump failed: %v: %s", err, out)
+	}
+	return nil
+}
+
+// criuRestoreCommand returns the command restoring the process tree dumped in
+// dir. The command waits for the restored process, whose pid is written to
+// pidFile, and exits with its status.
+func criuRestoreCommand(dir, pidFile string) (string, []string, error) {
+	path, ok := criuAvailable()
+	if !ok {
+		return "", nil, fmt.Errorf("CRIU is unavailable")
+	}
+
+	args := []string{"restore", "--images-dir", dir, "--log-file", "restore.log", "--pidfile", pidFile}
+
+	// Write the output of the restored task to the pipes of the executor
+	data, err := ioutil.ReadFile(filepath.Join(dir, criuStdioFile))
+	if err != nil && !os.IsNotExist(err) {
+		return "", nil, err
+	}
+	for _, pipe := range strings.Fields(string(data)) {
+		args = append(args, "--inherit-fd", pipe)
+	}
+	return path, append(args, criuOptions...), nil
+}
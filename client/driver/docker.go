@@ -1,6 +1,7 @@
 package driver
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,9 +31,11 @@ import (
 	"github.com/hashicorp/nomad/client/driver/env"
 	"github.com/hashicorp/nomad/client/driver/executor"
 	dstructs "github.com/hashicorp/nomad/client/driver/structs"
+	"github.com/hashicorp/nomad/client/stats"
 	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/helper/fields"
+	"github.com/hashicorp/nomad/helper/numa"
 	shelpers "github.com/hashicorp/nomad/helper/stats"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/mitchellh/mapstructure"
@@ -51,8 +55,13 @@ var (
 	waitClient *docker.Client
 
 	// The statistics the Docker driver exposes
-	DockerMeasuredMemStats = []string{"RSS", "Cache", "Swap", "Max Usage"}
-	DockerMeasuredCpuStats = []string{"Throttled Periods", "Throttled Time", "Percent"}
+	DockerMeasuredMemStats  = []string{"RSS", "Cache", "Swap", "Max Usage"}
+	DockerMeasuredCpuStats  = []string{"Throttled Periods", "Throttled Time", "Percent"}
+	DockerMeasuredDiskStats = []string{"Read Bytes", "Write Bytes", "Read Rate", "Write Rate"}
+
+	// The memory statistics the Docker driver exposes on cgroup v2 hosts,
+	// which report no swap or maximum usage
+	DockerMeasuredMemStatsV2 = []string{"RSS", "Cache"}
 
 	// recoverableErrTimeouts returns a recoverable error if the error was due
 	// to timeouts
@@ -107,6 +116,10 @@ const (
 	// it is timed out.
 	dockerTimeout = 5 * time.Minute
 
+	// dockerHealthPollInterval is the interval at which the health status of
+	// containers with a HEALTHCHECK is polled
+	dockerHealthPollInterval = 5 * time.Second
+
 	// dockerImageResKey is the CreatedResources key for docker images
 	dockerImageResKey = "image"
 
@@ -137,6 +150,8 @@ type DockerDriverAuth struct {
 	Password      string `mapstructure:"password"`       // password to access the registry
 	Email         string `mapstructure:"email"`          // email address of the user who is allowed to access the registry
 	ServerAddress string `mapstructure:"server_address"` // server address of the registry
+	VaultPath     string `mapstructure:"vault_path"`     // path of the Vault secret holding the credentials
+	Helper        string `mapstructure:"helper"`         // credential helper resolving the credentials
 }
 
 type DockerLoggingOpts struct {
@@ -152,6 +167,14 @@ type DockerMount struct {
 	VolumeOptions []*DockerVolumeOptions `mapstructure:"volume_options"`
 }
 
+// DockerBuild is the build of the image of the task from a Dockerfile
+type DockerBuild struct {
+	Context    string              `mapstructure:"context"`    // Path of the build context, relative to the task dir
+	Dockerfile string              `mapstructure:"dockerfile"` // Path of the Dockerfile, relative to the context
+	ArgsRaw    []map[string]string `mapstructure:"args"`       //
+	Args       map[string]string   `mapstructure:"-"`          // The build args of the Dockerfile
+}
+
 type DockerDevice struct {
 	HostPath          string `mapstructure:"host_path"`
 	ContainerPath     string `mapstructure:"container_path"`
@@ -174,6 +197,7 @@ type DockerVolumeDriverConfig struct {
 type DockerDriverConfig struct {
 	ImageName            string              `mapstructure:"image"`                  // Container's Image Name
 	LoadImage            string              `mapstructure:"load"`                   // LoadImage is a path to an image archive file
+	Build                []DockerBuild       `mapstructure:"build"`                  // Build the image from a Dockerfile
 	Command              string              `mapstructure:"command"`                // The Command to run when the container starts up
 	Args                 []string            `mapstructure:"args"`                   // The arguments to the Command
 	Entrypoint           []string            `mapstructure:"entrypoint"`             // Override the containers entrypoint
@@ -217,6 +241,8 @@ type DockerDriverConfig struct {
 	CapDrop              []string            `mapstructure:"cap_drop"`               // Flags to pass directly to cap-drop
 	ReadonlyRootfs       bool                `mapstructure:"readonly_rootfs"`        // Mount the container’s root filesystem as read only
 	AdvertiseIPv6Address bool                `mapstructure:"advertise_ipv6_address"` // Flag to use the GlobalIPv6Address from the container as the detected IP
+	Checkpoint           bool                `mapstructure:"checkpoint"`             // Checkpoint the container when migrated
+	UseContainerHealth   bool                `mapstructure:"use_container_health"`   // Gate the health of the allocation on the HEALTHCHECK of the container
 }
 
 func sliceMergeUlimit(ulimitsRaw map[string]string) ([]docker.ULimit, error) {
@@ -259,6 +285,37 @@ func (c *DockerDriverConfig) Validate() error {
 	if c.ImageName == "" {
 		return fmt.Errorf("Docker Driver needs an image name")
 	}
+	if len(c.Build) > 1 {
+		return fmt.Errorf("Only one build block is allowed in the docker driver config")
+	}
+	if len(c.Build) == 1 {
+		if c.LoadImage != "" {
+			return fmt.Errorf("build and load are mutually exclusive")
+		}
+		b := c.Build[0]
+		if b.Context == "" {
+			return fmt.Errorf("build requires a context")
+		}
+		for _, path := range []string{b.Context, filepath.Join(b.Context, b.Dockerfile)} {
+			escapes, err := structs.PathEscapesAllocDir("task", path)
+			if err != nil {
+				return err
+			}
+			if escapes {
+				return fmt.Errorf("build path %q escapes the allocation directory", path)
+			}
+		}
+		c.Build[0].Args = mapMergeStrStr(b.ArgsRaw...)
+	}
+	if len(c.Auth) == 1 {
+		a := c.Auth[0]
+		if a.VaultPath != "" && a.Helper != "" {
+			return fmt.Errorf("auth vault_path and helper are mutually exclusive")
+		}
+		if a.Helper != "" && !reDockerAuthHelper.MatchString(a.Helper) {
+			return fmt.Errorf("invalid auth helper name %q", a.Helper)
+		}
+	}
 	if len(c.Devices) > 0 {
 		for _, dev := range c.Devices {
 			if dev.HostPath == "" {
@@ -350,6 +407,19 @@ func NewDockerDriverConfig(task *structs.Task, env *env.TaskEnv) (*DockerDriverC
 		dconf.Auth[i].Password = env.ReplaceEnv(a.Password)
 		dconf.Auth[i].Email = env.ReplaceEnv(a.Email)
 		dconf.Auth[i].ServerAddress = env.ReplaceEnv(a.ServerAddress)
+		dconf.Auth[i].VaultPath = env.ReplaceEnv(a.VaultPath)
+		dconf.Auth[i].Helper = env.ReplaceEnv(a.Helper)
+	}
+
+	for i, b := range dconf.Build {
+		dconf.Build[i].Context = env.ReplaceEnv(b.Context)
+		dconf.Build[i].Dockerfile = env.ReplaceEnv(b.Dockerfile)
+		for _, m := range b.ArgsRaw {
+			for k, v := range m {
+				delete(m, k)
+				m[env.ReplaceEnv(k)] = env.ReplaceEnv(v)
+			}
+		}
 	}
 
 	for i, l := range dconf.Logging {
@@ -453,6 +523,8 @@ type dockerPID struct {
 	KillTimeout    time.Duration
 	MaxKillTimeout time.Duration
 	PluginConfig   *PluginReattachConfig
+	Checkpoint     bool
+	UseHealth      bool
 }
 
 type DockerHandle struct {
@@ -471,6 +543,9 @@ type DockerHandle struct {
 	resourceUsage     *cstructs.TaskResourceUsage
 	waitCh            chan *dstructs.WaitResult
 	doneCh            chan bool
+	checkpoint        bool
+	useHealth         bool
+	healthCh          chan *TaskHealth
 }
 
 func NewDockerDriver(ctx *DriverContext) Driver {
@@ -505,6 +580,14 @@ func (d *DockerDriver) Fingerprint(req *cstructs.FingerprintRequest, resp *cstru
 	resp.AddAttribute("driver.docker.version", env.Get("Version"))
 	resp.Detected = true
 
+	// Advertise if containers can be checkpointed, which requires the
+	// experimental features of the daemon
+	if _, ok := criuAvailable(); ok && env.GetBool("Experimental") {
+		resp.AddAttribute(dockerDriverAttr+"."+checkpointConfigOption, "1")
+	} else {
+		resp.RemoveAttribute(dockerDriverAttr + "." + checkpointConfigOption)
+	}
+
 	privileged := d.config.ReadBoolDefault(dockerPrivilegedConfigOption, false)
 	if privileged {
 		resp.AddAttribute(dockerPrivilegedConfigOption, "1")
@@ -556,6 +639,9 @@ func (d *DockerDriver) Validate(config map[string]interface{}) error {
 			"load": {
 				Type: fields.TypeString,
 			},
+			"build": {
+				Type: fields.TypeArray,
+			},
 			"command": {
 				Type: fields.TypeString,
 			},
@@ -677,6 +763,12 @@ func (d *DockerDriver) Validate(config map[string]interface{}) error {
 			"advertise_ipv6_address": {
 				Type: fields.TypeBool,
 			},
+			"checkpoint": {
+				Type: fields.TypeBool,
+			},
+			"use_container_health": {
+				Type: fields.TypeBool,
+			},
 		},
 	}
 
@@ -806,9 +898,24 @@ func (d *DockerDriver) Start(ctx *ExecContext, task *structs.Task) (*StartRespon
 	// We don't need to start the container if the container is already running
 	// since we don't create containers which are already present on the host
 	// and are running
+	restored := false
 	if !container.State.Running {
-		// Start the container
-		if err := d.startContainer(container); err != nil {
+		checkpoint, err := ctx.TaskDir.ReadCheckpoint()
+		if err != nil {
+			pluginClient.Kill()
+			return nil, fmt.Errorf("failed to read checkpoint: %v", err)
+		}
+
+		if d.driverConfig.Checkpoint && checkpoint != nil && checkpoint.Driver == "docker" {
+			// Restore the container from its checkpoint
+			if err := restoreContainer(client, container.ID, ctx.TaskDir.CheckpointDir); err != nil {
+				d.logger.Printf("[ERR] driver.docker: failed to restore container %s: %s", container.ID, err)
+				pluginClient.Kill()
+				return nil, fmt.Errorf("Failed to restore container %s: %s", container.ID, err)
+			}
+			restored = true
+		} else if err := d.startContainer(container); err != nil {
+			// Start the container
 			d.logger.Printf("[ERR] driver.docker: failed to start container %s: %s", container.ID, err)
 			pluginClient.Kill()
 			return nil, structs.NewRecoverableError(fmt.Errorf("Failed to start container %s: %s", container.ID, err), structs.IsRecoverable(err))
@@ -826,6 +933,16 @@ func (d *DockerDriver) Start(ctx *ExecContext, task *structs.Task) (*StartRespon
 		}
 		container = runningContainer
 		d.logger.Printf("[INFO] driver.docker: started container %s", container.ID)
+
+		// Attach the SR-IOV virtual functions to the container's network
+		if err := attachSRIOVDevices(container.State.Pid, task.Resources); err != nil {
+			d.logger.Printf("[ERR] driver.docker: failed to attach devices to container %s: %v", container.ID, err)
+			if err := client.RemoveContainer(docker.RemoveContainerOptions{ID: container.ID, RemoveVolumes: true, Force: true}); err != nil {
+				d.logger.Printf("[ERR] driver.docker: failed to remove container %s: %v", container.ID, err)
+			}
+			pluginClient.Kill()
+			return nil, fmt.Errorf("failed to attach devices to container %s: %v", container.ID, err)
+		}
 	} else {
 		d.logger.Printf("[DEBUG] driver.docker: re-attaching to container %s with status %q",
 			container.ID, container.State.String())
@@ -847,6 +964,14 @@ func (d *DockerDriver) Start(ctx *ExecContext, task *structs.Task) (*StartRespon
 		maxKillTimeout: maxKill,
 		doneCh:         make(chan bool),
 		waitCh:         make(chan *dstructs.WaitResult, 1),
+		checkpoint:     d.driverConfig.Checkpoint,
+		useHealth:      d.driverConfig.UseContainerHealth,
+	}
+	if container.State.Health.Status != "" {
+		h.healthCh = make(chan *TaskHealth, 1)
+		go h.watchHealth()
+	} else if h.useHealth {
+		d.logger.Printf("[WARN] driver.docker: container %s has no HEALTHCHECK to gate its health on", container.ID)
 	}
 	go h.collectStats()
 	go h.run()
@@ -862,10 +987,43 @@ func (d *DockerDriver) Start(ctx *ExecContext, task *structs.Task) (*StartRespon
 			IP:            ip,
 			AutoAdvertise: autoUse,
 		},
+		ImageDigest: d.imageID,
+		Restored:    restored,
+	}
+
+	// Prefer the registry digest of the image as that is what vulnerability
+	// scanners and registries refer to.
+	if image, err := client.InspectImage(d.imageID); err != nil {
+		d.logger.Printf("[WARN] driver.docker: failed to inspect image %s for digest: %v", d.imageID, err)
+	} else {
+		resp.ImageDigest, resp.ImageSBOM = dockerImageDigest(image)
 	}
 	return resp, nil
 }
 
+// dockerImageSBOMLabel is the image label used to reference the software bill
+// of materials of an image.
+const dockerImageSBOMLabel = "io.nomadproject.sbom"
+
+// dockerImageDigest returns the content digest and SBOM reference of an
+// image. The repository digest is used when the image was pulled from a
+// registry, otherwise the image ID is returned.
+func dockerImageDigest(image *docker.Image) (string, string) {
+	digest := image.ID
+	for _, repoDigest := range image.RepoDigests {
+		if idx := strings.LastIndex(repoDigest, "@"); idx != -1 {
+			digest = repoDigest[idx+1:]
+			break
+		}
+	}
+
+	var sbom string
+	if image.Config != nil {
+		sbom = image.Config.Labels[dockerImageSBOMLabel]
+	}
+	return digest, sbom
+}
+
 // detectIP of Docker container. Returns the first IP found as well as true if
 // the IP should be advertised (bridge network IPs return false). Returns an
 // empty string and false if no IP could be found.
@@ -1146,6 +1304,39 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 	d.logger.Printf("[DEBUG] driver.docker: using %d cpu shares for %s", hostConfig.CPUShares, task.Name)
 	d.logger.Printf("[DEBUG] driver.docker: binding directories %#v for %s", hostConfig.Binds, task.Name)
 
+	// Pin the container to the cores assigned to the task
+	if len(task.Resources.CoreIDs) != 0 {
+		hostConfig.CPUSetCPUs = structs.CoreIDsString(task.Resources.CoreIDs)
+		d.logger.Printf("[DEBUG] driver.docker: pinning %s to cores %s", task.Name, hostConfig.CPUSetCPUs)
+
+		// Place the memory of the container on the NUMA nodes of its cores
+		if topology, err := numa.Topology(); err != nil {
+			d.logger.Printf("[WARN] driver.docker: failed to read NUMA topology: %v", err)
+		} else if mems := numa.Mems(topology, task.Resources.CoreIDs, task.Resources.MemoryMB); mems != "" {
+			hostConfig.CPUSetMEMs = mems
+			d.logger.Printf("[DEBUG] driver.docker: placing memory of %s on NUMA nodes %s", task.Name, mems)
+		}
+	}
+
+	// Limit the disk IO of the container, with the bandwidth limits applying to
+	// the disk of the task directory
+	if task.Resources.IOWeight != 0 {
+		hostConfig.BlkioWeight = int64(task.Resources.IOWeight)
+	}
+	if task.Resources.IOReadMBps != 0 || task.Resources.IOWriteMBps != 0 {
+		major, minor, err := executor.BlockDevice(ctx.TaskDir.Dir)
+		if err != nil {
+			return c, fmt.Errorf("failed to find the disk of the task directory: %v", err)
+		}
+		device := fmt.Sprintf("/dev/block/%d:%d", major, minor)
+		if mbps := task.Resources.IOReadMBps; mbps != 0 {
+			hostConfig.BlkioDeviceReadBps = []docker.BlockLimit{{Path: device, Rate: int64(mbps) * structs.BytesInMegabyte}}
+		}
+		if mbps := task.Resources.IOWriteMBps; mbps != 0 {
+			hostConfig.BlkioDeviceWriteBps = []docker.BlockLimit{{Path: device, Rate: int64(mbps) * structs.BytesInMegabyte}}
+		}
+	}
+
 	//  set privileged mode
 	hostPrivileged := d.config.ReadBoolDefault(dockerPrivilegedConfigOption, false)
 	if driverConfig.Privileged && !hostPrivileged {
@@ -1247,6 +1438,24 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 	hostConfig.DNSOptions = driverConfig.DNSOptions
 	hostConfig.ExtraHosts = driverConfig.ExtraHosts
 
+	// Fall back to the DNS config of the task for the DNS settings the
+	// driver config doesn't set
+	dns, err := taskDNSConfig(ctx.TaskEnv, task)
+	if err != nil {
+		return c, err
+	}
+	if dns != nil {
+		if len(hostConfig.DNS) == 0 {
+			hostConfig.DNS = dns.Servers
+		}
+		if len(hostConfig.DNSSearch) == 0 {
+			hostConfig.DNSSearch = dns.Searches
+		}
+		if len(hostConfig.DNSOptions) == 0 {
+			hostConfig.DNSOptions = dns.Options
+		}
+	}
+
 	hostConfig.IpcMode = driverConfig.IpcMode
 	hostConfig.PidMode = driverConfig.PidMode
 	hostConfig.UTSMode = driverConfig.UTSMode
@@ -1263,6 +1472,12 @@ func (d *DockerDriver) createContainerConfig(ctx *ExecContext, task *structs.Tas
 		hostConfig.NetworkMode = defaultNetworkMode
 	}
 
+	// SR-IOV virtual functions are moved into the container's network
+	// namespace, so the container needs one of its own
+	if len(task.Resources.DevicesOfType(structs.DeviceTypeSRIOV)) != 0 && hostConfig.NetworkMode == "host" {
+		return c, fmt.Errorf("SR-IOV devices can not be attached to containers using the host network")
+	}
+
 	// Setup port mapping and exposed ports
 	if len(task.Resources.Networks) == 0 {
 		d.logger.Println("[DEBUG] driver.docker: No network interfaces are available")
@@ -1420,13 +1635,20 @@ func (d *DockerDriver) createImage(driverConfig *DockerDriverConfig, client *doc
 		return d.loadImage(driverConfig, client, taskDir)
 	}
 
+	// Build the image if specified
+	if len(driverConfig.Build) == 1 {
+		return d.buildImage(driverConfig, taskDir)
+	}
+
 	// Download the image
-	return d.pullImage(driverConfig, client, repo, tag)
+	return d.pullImage(driverConfig, client, taskDir, repo, tag)
 }
 
 // pullImage creates an image by pulling it from a docker registry
-func (d *DockerDriver) pullImage(driverConfig *DockerDriverConfig, client *docker.Client, repo, tag string) (id string, err error) {
-	authOptions, err := d.resolveRegistryAuthentication(driverConfig, repo)
+func (d *DockerDriver) pullImage(driverConfig *DockerDriverConfig, client *docker.Client,
+	taskDir *allocdir.TaskDir, repo, tag string) (id string, err error) {
+
+	authOptions, err := d.resolveRegistryAuthentication(driverConfig, taskDir, repo)
 	if err != nil {
 		if d.driverConfig.AuthSoftFail {
 			d.logger.Printf("[WARN] Failed to find docker auth for repo %q: %v", repo, err)
@@ -1449,11 +1671,15 @@ type authBackend func(string) (*docker.AuthConfiguration, error)
 
 // resolveRegistryAuthentication attempts to retrieve auth credentials for the
 // repo, trying all authentication-backends possible.
-func (d *DockerDriver) resolveRegistryAuthentication(driverConfig *DockerDriverConfig, repo string) (*docker.AuthConfiguration, error) {
+func (d *DockerDriver) resolveRegistryAuthentication(driverConfig *DockerDriverConfig,
+	taskDir *allocdir.TaskDir, repo string) (*docker.AuthConfiguration, error) {
+
 	return firstValidAuth(repo, []authBackend{
 		authFromTaskConfig(driverConfig),
+		d.authFromVault(driverConfig, taskDir),
+		d.authFromTaskHelper(driverConfig),
 		authFromDockerConfig(d.config.Read("docker.auth.config")),
-		authFromHelper(d.config.Read("docker.auth.helper")),
+		d.authFromCachedHelper(d.config.Read("docker.auth.helper")),
 	})
 }
 
@@ -1484,6 +1710,74 @@ func (d *DockerDriver) loadImage(driverConfig *DockerDriverConfig, client *docke
 	return dockerImage.ID, nil
 }
 
+// buildImage creates an image by building it from a Dockerfile in the task
+// directory, usually downloaded as an artifact
+func (d *DockerDriver) buildImage(driverConfig *DockerDriverConfig, taskDir *allocdir.TaskDir) (id string, err error) {
+	build := driverConfig.Build[0]
+	contextDir := filepath.Join(taskDir.Dir, build.Context)
+	d.logger.Printf("[DEBUG] driver.docker: building image %s from: %v", driverConfig.ImageName, contextDir)
+	d.emitEvent("Building image %s", driverConfig.ImageName)
+
+	// Builds may take longer than the timeout of the client
+	_, waitClient, err := d.dockerClients()
+	if err != nil {
+		return "", err
+	}
+
+	var output bytes.Buffer
+	opts := docker.BuildImageOptions{
+		Name:                driverConfig.ImageName,
+		ContextDir:          contextDir,
+		Dockerfile:          build.Dockerfile,
+		BuildArgs:           dockerBuildArgs(build.Args),
+		RmTmpContainer:      true,
+		ForceRmTmpContainer: true,
+		OutputStream:        &output,
+	}
+	if err := waitClient.BuildImage(opts); err != nil {
+		return "", fmt.Errorf("failed to build image %s: %v: %s", driverConfig.ImageName, err,
+			dockerBuildOutputTail(output.String()))
+	}
+
+	dockerImage, err := waitClient.InspectImage(driverConfig.ImageName)
+	if err != nil {
+		return "", recoverableErrTimeouts(err)
+	}
+
+	coordinator, callerID := d.getDockerCoordinator(waitClient)
+	coordinator.IncrementImageReference(dockerImage.ID, driverConfig.ImageName, callerID)
+	return dockerImage.ID, nil
+}
+
+// dockerBuildArgs returns the build args sorted by name
+func dockerBuildArgs(args map[string]string) []docker.BuildArg {
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buildArgs := make([]docker.BuildArg, 0, len(names))
+	for _, name := range names {
+		buildArgs = append(buildArgs, docker.BuildArg{Name: name, Value: args[name]})
+	}
+	return buildArgs
+}
+
+// dockerBuildOutputTailLines is the number of lines of the output of failed
+// builds included in their error
+const dockerBuildOutputTailLines = 10
+
+// dockerBuildOutputTail returns the last lines of the output of a build,
+// which explain why it failed
+func dockerBuildOutputTail(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) > dockerBuildOutputTailLines {
+		lines = lines[len(lines)-dockerBuildOutputTailLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
 // createContainer creates the container given the passed configuration. It
 // attempts to handle any transient Docker errors.
 func (d *DockerDriver) createContainer(config docker.CreateContainerOptions) (*docker.Container, error) {
@@ -1664,6 +1958,14 @@ func (d *DockerDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, er
 		maxKillTimeout: pid.MaxKillTimeout,
 		doneCh:         make(chan bool),
 		waitCh:         make(chan *dstructs.WaitResult, 1),
+		checkpoint:     pid.Checkpoint,
+		useHealth:      pid.UseHealth,
+	}
+	if container, err := client.InspectContainer(pid.ContainerID); err != nil {
+		d.logger.Printf("[ERR] driver.docker: failed to inspect container %s: %v", pid.ContainerID, err)
+	} else if container.State.Health.Status != "" {
+		h.healthCh = make(chan *TaskHealth, 1)
+		go h.watchHealth()
 	}
 	go h.collectStats()
 	go h.run()
@@ -1680,6 +1982,8 @@ func (h *DockerHandle) ID() string {
 		KillTimeout:    h.killTimeout,
 		MaxKillTimeout: h.maxKillTimeout,
 		PluginConfig:   NewPluginReattachConfig(h.pluginClient.ReattachConfig()),
+		Checkpoint:     h.checkpoint,
+		UseHealth:      h.useHealth,
 	}
 	data, err := json.Marshal(pid)
 	if err != nil {
@@ -1783,6 +2087,87 @@ func (h *DockerHandle) Kill() error {
 	return nil
 }
 
+func (h *DockerHandle) Checkpointable() bool {
+	return h.checkpoint
+}
+
+// Checkpoint checkpoints the container with the checkpoint API of Docker
+func (h *DockerHandle) Checkpoint(dir string) error {
+	if err := checkpointContainer(h.client, h.containerID, dir); err != nil {
+		return fmt.Errorf("Failed to checkpoint container %s: %v", h.containerID, err)
+	}
+	h.logger.Printf("[INFO] driver.docker: checkpointed container %s", h.containerID)
+	return nil
+}
+
+// HealthCh returns the health of the container reported by its HEALTHCHECK,
+// or nil if it has none
+func (h *DockerHandle) HealthCh() <-chan *TaskHealth {
+	return h.healthCh
+}
+
+func (h *DockerHandle) HealthGated() bool {
+	return h.useHealth && h.healthCh != nil
+}
+
+// watchHealth polls the health status of the container and reports it each
+// time it changes until the container exits. The container isn't reported
+// while its status is starting.
+func (h *DockerHandle) watchHealth() {
+	defer close(h.healthCh)
+	ticker := time.NewTicker(dockerHealthPollInterval)
+	defer ticker.Stop()
+
+	last := ""
+	for {
+		select {
+		case <-h.doneCh:
+			return
+		case <-ticker.C:
+		}
+
+		container, err := h.client.InspectContainer(h.containerID)
+		if err != nil {
+			h.logger.Printf("[DEBUG] driver.docker: failed to inspect health of container %s: %v", h.containerID, err)
+			continue
+		}
+		status := container.State.Health.Status
+		if status == last {
+			continue
+		}
+		last = status
+
+		health := dockerTaskHealth(container.State.Health)
+		if health == nil {
+			continue
+		}
+		select {
+		case h.healthCh <- health:
+		case <-h.doneCh:
+			return
+		}
+	}
+}
+
+// dockerTaskHealth converts the health of a container, returning nil if it is
+// neither healthy nor unhealthy
+func dockerTaskHealth(health docker.Health) *TaskHealth {
+	var healthy bool
+	switch health.Status {
+	case "healthy":
+		healthy = true
+	case "unhealthy":
+	default:
+		return nil
+	}
+
+	th := &TaskHealth{Healthy: healthy}
+	if n := len(health.Log); n > 0 {
+		th.Output = strings.TrimSpace(health.Log[n-1].Output)
+	}
+	return th
+}
+
 func (h *DockerHandle) Stats() (*cstructs.TaskResourceUsage, error) {
 	h.resourceUsageLock.RLock()
 	defer h.resourceUsageLock.RUnlock()
@@ -1804,11 +2189,13 @@ func (h *DockerHandle) run() {
 		werr = fmt.Errorf("Docker container exited with non-zero exit code: %d", exitCode)
 	}
 
+	oomKilled := false
 	container, ierr := h.waitClient.InspectContainer(h.containerID)
 	if ierr != nil {
 		h.logger.Printf("[ERR] driver.docker: failed to inspect container %s: %v", h.containerID, ierr)
 	} else if container.State.OOMKilled {
 		werr = fmt.Errorf("OOM Killed")
+		oomKilled = true
 	}
 
 	close(h.doneCh)
@@ -1835,10 +2222,57 @@ func (h *DockerHandle) run() {
 	}
 
 	// Send the results
-	h.waitCh <- dstructs.NewWaitResult(exitCode, 0, werr)
+	res := dstructs.NewWaitResult(exitCode, 0, werr)
+	res.OOMKilled = oomKilled
+	h.waitCh <- res
 	close(h.waitCh)
 }
 
+// dockerMemoryStats converts the memory usage of a container. The memory
+// stats of cgroup v2 hosts have no rss or cache, so the usage is derived from
+// the unified hierarchy's anonymous and file memory. Only cgroup v1 reports a
+// hierarchical limit, which identifies the version.
+func dockerMemoryStats(s *docker.Stats) *cstructs.MemoryStats {
+	stats := s.MemoryStats.Stats
+	if stats.HierarchicalMemoryLimit == 0 && s.MemoryStats.Usage != 0 {
+		return &cstructs.MemoryStats{
+			RSS:      stats.ActiveAnon + stats.InactiveAnon,
+			Cache:    stats.ActiveFile + stats.InactiveFile,
+			Measured: DockerMeasuredMemStatsV2,
+		}
+	}
+
+	return &cstructs.MemoryStats{
+		RSS:      stats.Rss,
+		Cache:    stats.Cache,
+		Swap:     stats.Swap,
+		MaxUsage: s.MemoryStats.MaxUsage,
+		Measured: DockerMeasuredMemStats,
+	}
+}
+
+// dockerDiskStats converts the block IO usage of a container. cgroup v1
+// hosts name the operations "Read" and "Write", and cgroup v2 hosts "read"
+// and "write".
+func dockerDiskStats(s *docker.Stats, readRate, writeRate *stats.ByteRate) *cstructs.DiskStats {
+	var read, write uint64
+	for _, entry := range s.BlkioStats.IOServiceBytesRecursive {
+		switch strings.ToLower(entry.Op) {
+		case "read":
+			read += entry.Value
+		case "write":
+			write += entry.Value
+		}
+	}
+	return &cstructs.DiskStats{
+		ReadBytes:  read,
+		WriteBytes: write,
+		ReadRate:   readRate.Rate(read),
+		WriteRate:  writeRate.Rate(write),
+		Measured:   DockerMeasuredDiskStats,
+	}
+}
+
 // collectStats starts collecting resource usage stats of a docker container
 func (h *DockerHandle) collectStats() {
 	statsCh := make(chan *docker.Stats)
@@ -1850,17 +2284,13 @@ func (h *DockerHandle) collectStats() {
 		}
 	}()
 	numCores := runtime.NumCPU()
+	readRate, writeRate := stats.NewByteRate(), stats.NewByteRate()
 	for {
 		select {
 		case s := <-statsCh:
 			if s != nil {
-				ms := &cstructs.MemoryStats{
-					RSS:      s.MemoryStats.Stats.Rss,
-					Cache:    s.MemoryStats.Stats.Cache,
-					Swap:     s.MemoryStats.Stats.Swap,
-					MaxUsage: s.MemoryStats.MaxUsage,
-					Measured: DockerMeasuredMemStats,
-				}
+				ms := dockerMemoryStats(s)
+				ds := dockerDiskStats(s, readRate, writeRate)
 
 				cs := &cstructs.CpuStats{
 					ThrottledPeriods: s.CPUStats.ThrottlingData.ThrottledPeriods,
@@ -1885,6 +2315,7 @@ func (h *DockerHandle) collectStats() {
 					ResourceUsage: &cstructs.ResourceUsage{
 						MemoryStats: ms,
 						CpuStats:    cs,
+						DiskStats:   ds,
 					},
 					Timestamp: s.Read.UTC().UnixNano(),
 				}
@@ -1956,6 +2387,9 @@ func authFromTaskConfig(driverConfig *DockerDriverConfig) authBackend {
 			return nil, nil
 		}
 		auth := driverConfig.Auth[0]
+		if auth.VaultPath != "" || auth.Helper != "" {
+			return nil, nil
+		}
 		return &docker.AuthConfiguration{
 			Username:      auth.Username,
 			Password:      auth.Password,
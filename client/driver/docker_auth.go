@@ -0,0 +1,201 @@
+package driver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/client/config"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+const (
+	// dockerAuthCacheTTLConfigOption is the key for setting how long the
+	// registry credentials resolved from Vault and credential helpers are
+	// cached. Caching is disabled when zero.
+	dockerAuthCacheTTLConfigOption  = "docker.auth.cache_ttl"
+	dockerAuthCacheTTLConfigDefault = 5 * time.Minute
+
+	// dockerVaultTokenFile is the file of the secrets directory the Vault
+	// token of the task is written to
+	dockerVaultTokenFile = "vault_token"
+)
+
+// reDockerAuthHelper matches the names of the credential helpers tasks may
+// use, which are looked up with the dockerAuthHelperPrefix in the PATH
+var reDockerAuthHelper = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+
+// authCache caches the registry credentials across the tasks of the client
+var authCache = newDockerAuthCache()
+
+// dockerAuthCache caches registry credentials until they expire, so that
+// they aren't fetched for every pull. Expired credentials are fetched again
+// on the next pull, renewing short-lived credentials.
+type dockerAuthCache struct {
+	entries map[string]*dockerAuthCacheEntry
+	l       sync.Mutex
+}
+
+type dockerAuthCacheEntry struct {
+	auth    *docker.AuthConfiguration
+	expires time.Time
+}
+
+func newDockerAuthCache() *dockerAuthCache {
+	return &dockerAuthCache{entries: make(map[string]*dockerAuthCacheEntry)}
+}
+
+// get returns the credentials cached under key unless they expired
+func (c *dockerAuthCache) get(key string, now time.Time) (*docker.AuthConfiguration, bool) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !now.Before(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.auth, true
+}
+
+// set caches the credentials under key for ttl
+func (c *dockerAuthCache) set(key string, auth *docker.AuthConfiguration, ttl time.Duration, now time.Time) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.entries[key] = &dockerAuthCacheEntry{auth: auth, expires: now.Add(ttl)}
+}
+
+// authFetcher fetches registry credentials along with their lease, which is
+// zero when they don't expire
+type authFetcher func(repo string) (*docker.AuthConfiguration, time.Duration, error)
+
+// cachedAuth generates an authBackend caching the credentials fetched under
+// key for ttl, or half of their lease when shorter so that they are renewed
+// before they expire. Errors and missing credentials aren't cached.
+func cachedAuth(cache *dockerAuthCache, key string, ttl time.Duration, fetch authFetcher) authBackend {
+	return func(repo string) (*docker.AuthConfiguration, error) {
+		if auth, ok := cache.get(key, time.Now()); ok {
+			return auth, nil
+		}
+		auth, lease, err := fetch(repo)
+		if err != nil || auth == nil {
+			return auth, err
+		}
+		if lease > 0 && lease/2 < ttl {
+			ttl = lease / 2
+		}
+		if ttl > 0 {
+			cache.set(key, auth, ttl, time.Now())
+		}
+		return auth, nil
+	}
+}
+
+// authCacheTTL returns how long credentials are cached on the client
+func (d *DockerDriver) authCacheTTL() time.Duration {
+	return d.config.ReadDurationDefault(dockerAuthCacheTTLConfigOption, dockerAuthCacheTTLConfigDefault)
+}
+
+// authFromTaskHelper generates an authBackend for the credential helper set
+// in the task configuration
+func (d *DockerDriver) authFromTaskHelper(driverConfig *DockerDriverConfig) authBackend {
+	return func(repo string) (*docker.AuthConfiguration, error) {
+		if len(driverConfig.Auth) == 0 || driverConfig.Auth[0].Helper == "" {
+			return nil, nil
+		}
+		return d.authFromCachedHelper(driverConfig.Auth[0].Helper)(repo)
+	}
+}
+
+// authFromCachedHelper generates an authBackend for a credential helper whose
+// credentials are cached per repository
+func (d *DockerDriver) authFromCachedHelper(helperName string) authBackend {
+	return func(repo string) (*docker.AuthConfiguration, error) {
+		if helperName == "" {
+			return nil, nil
+		}
+		fetch := func(repo string) (*docker.AuthConfiguration, time.Duration, error) {
+			auth, err := authFromHelper(helperName)(repo)
+			return auth, 0, err
+		}
+		return cachedAuth(authCache, "helper:"+helperName+":"+repo, d.authCacheTTL(), fetch)(repo)
+	}
+}
+
+// authFromVault generates an authBackend reading the credentials set in the
+// task configuration from Vault with the Vault token of the task, so that
+// the policies of the task govern which credentials it can read. Credentials
+// are cached per token.
+func (d *DockerDriver) authFromVault(driverConfig *DockerDriverConfig, taskDir *allocdir.TaskDir) authBackend {
+	return func(repo string) (*docker.AuthConfiguration, error) {
+		if len(driverConfig.Auth) == 0 || driverConfig.Auth[0].VaultPath == "" {
+			return nil, nil
+		}
+		auth := driverConfig.Auth[0]
+
+		tokenBytes, err := ioutil.ReadFile(filepath.Join(taskDir.SecretsDir, dockerVaultTokenFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the Vault token of the task, which requires a vault stanza: %v", err)
+		}
+		token := strings.TrimSpace(string(tokenBytes))
+		sum := sha256.Sum256([]byte(token))
+		key := "vault:" + hex.EncodeToString(sum[:]) + ":" + auth.VaultPath
+
+		fetch := func(string) (*docker.AuthConfiguration, time.Duration, error) {
+			return readVaultAuth(d.config, token, auth.VaultPath, auth.ServerAddress)
+		}
+		return cachedAuth(authCache, key, d.authCacheTTL(), fetch)(repo)
+	}
+}
+
+// readVaultAuth reads the username and password of a registry from the
+// secret at path, which may be a KV version 1 or 2 secret
+func readVaultAuth(conf *config.Config, token, path, serverAddress string) (*docker.AuthConfiguration, time.Duration, error) {
+	if conf.VaultConfig == nil || !conf.VaultConfig.IsEnabled() {
+		return nil, 0, fmt.Errorf("Vault is not enabled on the client")
+	}
+	apiConf, err := conf.VaultConfig.ApiConfig()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create Vault API config: %v", err)
+	}
+	client, err := vaultapi.NewClient(apiConf)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create Vault client: %v", err)
+	}
+	client.SetToken(token)
+
+	secret, err := client.Logical().Read(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read registry credentials from Vault: %v", err)
+	}
+	if secret == nil {
+		return nil, 0, fmt.Errorf("no registry credentials found in Vault at %q", path)
+	}
+
+	data := secret.Data
+	if kv2, ok := data["data"].(map[string]interface{}); ok {
+		data = kv2
+	}
+	username, _ := data["username"].(string)
+	password, _ := data["password"].(string)
+	if username == "" && password == "" {
+		return nil, 0, fmt.Errorf("Vault secret %q has no username nor password", path)
+	}
+
+	auth := &docker.AuthConfiguration{
+		Username:      username,
+		Password:      password,
+		ServerAddress: serverAddress,
+	}
+	return auth, time.Duration(secret.LeaseDuration) * time.Second, nil
+}
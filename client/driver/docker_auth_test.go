@@ -0,0 +1,167 @@
+package driver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/nomad/structs"
+	sconfig "github.com/hashicorp/nomad/nomad/structs/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerAuthCache(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	cache := newDockerAuthCache()
+	fetches := 0
+	lease := time.Duration(0)
+	fetch := func(repo string) (*docker.AuthConfiguration, time.Duration, error) {
+		fetches++
+		return &docker.AuthConfiguration{Username: repo}, lease, nil
+	}
+
+	// Credentials are fetched once until they expire
+	backend := cachedAuth(cache, "a", time.Hour, fetch)
+	for i := 0; i < 3; i++ {
+		auth, err := backend("redis")
+		require.Nil(err)
+		require.Equal("redis", auth.Username)
+	}
+	require.Equal(1, fetches)
+
+	now := time.Now()
+	cache.set("a", &docker.AuthConfiguration{}, time.Minute, now)
+	_, ok := cache.get("a", now.Add(time.Minute))
+	require.False(ok)
+
+	// Leases shorter than the TTL expire the credentials at half their lease
+	lease = 2 * time.Millisecond
+	backend = cachedAuth(cache, "b", time.Hour, fetch)
+	_, err := backend("redis")
+	require.Nil(err)
+	time.Sleep(5 * time.Millisecond)
+	_, err = backend("redis")
+	require.Nil(err)
+	require.Equal(3, fetches)
+
+	// Errors and zero TTLs aren't cached
+	backend = cachedAuth(cache, "c", 0, fetch)
+	backend("redis")
+	backend("redis")
+	require.Equal(5, fetches)
+
+	failing := func(string) (*docker.AuthConfiguration, time.Duration, error) {
+		fetches++
+		return nil, 0, fmt.Errorf("failed")
+	}
+	backend = cachedAuth(cache, "d", time.Hour, failing)
+	backend("redis")
+	_, err = backend("redis")
+	require.Error(err)
+	require.Equal(7, fetches)
+}
+
+func TestDockerDriver_AuthFromVault(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	reads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "task-token" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"errors":["permission denied"]}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/registry":
+			reads++
+			fmt.Fprint(w, `{"lease_duration":3600,"data":{"username":"v1user","password":"v1pass"}}`)
+		case "/v1/kv/data/registry":
+			fmt.Fprint(w, `{"data":{"data":{"username":"v2user","password":"v2pass"},"metadata":{"version":1}}}`)
+		case "/v1/secret/empty":
+			fmt.Fprint(w, `{"data":{"token":"foo"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"errors":[]}`)
+		}
+	}))
+	defer srv.Close()
+
+	task := &structs.Task{
+		Name:      "redis-demo",
+		Driver:    "docker",
+		Config:    map[string]interface{}{"image": "busybox"},
+		Resources: basicResources,
+	}
+	ctx := testDockerDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	d := NewDockerDriver(ctx.DriverCtx).(*DockerDriver)
+	d.config.VaultConfig = &sconfig.VaultConfig{
+		Enabled: helper.BoolToPtr(true),
+		Addr:    srv.URL,
+	}
+	taskDir := ctx.ExecCtx.TaskDir
+
+	conf := &DockerDriverConfig{
+		Auth: []DockerDriverAuth{{VaultPath: "secret/registry", ServerAddress: "registry.example.com"}},
+	}
+
+	// The task needs a Vault token
+	_, err := d.resolveRegistryAuthentication(conf, taskDir, "registry.example.com/redis")
+	require.Error(err)
+	require.Contains(err.Error(), "Vault token")
+
+	tokenPath := filepath.Join(taskDir.SecretsDir, dockerVaultTokenFile)
+	require.Nil(ioutil.WriteFile(tokenPath, []byte("task-token\n"), 0600))
+	for i := 0; i < 2; i++ {
+		auth, err := d.resolveRegistryAuthentication(conf, taskDir, "registry.example.com/redis")
+		require.Nil(err)
+		require.Equal(&docker.AuthConfiguration{
+			Username:      "v1user",
+			Password:      "v1pass",
+			ServerAddress: "registry.example.com",
+		}, auth)
+	}
+	require.Equal(1, reads)
+
+	auth, _, err := readVaultAuth(d.config, "task-token", "kv/data/registry", "")
+	require.Nil(err)
+	require.Equal("v2user", auth.Username)
+	require.Equal("v2pass", auth.Password)
+
+	_, _, err = readVaultAuth(d.config, "task-token", "secret/empty", "")
+	require.Error(err)
+	_, _, err = readVaultAuth(d.config, "other-token", "secret/registry", "")
+	require.Error(err)
+
+	d.config.VaultConfig = &sconfig.VaultConfig{}
+	_, _, err = readVaultAuth(d.config, "task-token", "secret/registry", "")
+	require.Error(err)
+	require.Contains(err.Error(), "not enabled")
+}
+
+func TestDockerDriverConfig_AuthValidate(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	conf := &DockerDriverConfig{
+		ImageName: "busybox",
+		Auth:      []DockerDriverAuth{{Helper: "ecr-login"}},
+	}
+	require.Nil(conf.Validate())
+
+	conf.Auth[0].Helper = "../../bin/sh"
+	require.Error(conf.Validate())
+
+	conf.Auth[0].Helper = "ecr-login"
+	conf.Auth[0].VaultPath = "secret/registry"
+	require.Error(conf.Validate())
+}
@@ -0,0 +1,83 @@
+package driver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// dockerCheckpointID is the name of the checkpoints of containers in their
+// checkpoint dir
+const dockerCheckpointID = "nomad"
+
+// dockerCheckpointRequest makes a request to the checkpoint endpoints of the
+// Docker API, which the Docker client doesn't support. Checkpoints require the
+// experimental features of the daemon.
+func dockerCheckpointRequest(c *docker.Client, method, path string, query url.Values, body interface{}) error {
+	endpoint, err := url.Parse(c.Endpoint())
+	if err != nil {
+		return err
+	}
+
+	// The transport of the client dials the daemon's socket or pipe itself
+	base := "http://docker"
+	switch endpoint.Scheme {
+	case "tcp", "http", "https":
+		scheme := "http"
+		if c.TLSConfig != nil {
+			scheme = "https"
+		}
+		base = scheme + "://" + endpoint.Host
+	}
+
+	u := base + path
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, u, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("API error (%d): %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+// checkpointContainer checkpoints the container into dir, stopping it
+func checkpointContainer(c *docker.Client, id, dir string) error {
+	body := map[string]interface{}{
+		"CheckpointID":  dockerCheckpointID,
+		"CheckpointDir": dir,
+		"Exit":          true,
+	}
+	return dockerCheckpointRequest(c, "POST", fmt.Sprintf("/containers/%s/checkpoints", id), nil, body)
+}
+
+// restoreContainer starts the container from its checkpoint in dir
+func restoreContainer(c *docker.Client, id, dir string) error {
+	query := url.Values{}
+	query.Set("checkpoint", dockerCheckpointID)
+	query.Set("checkpoint-dir", dir)
+	return dockerCheckpointRequest(c, "POST", fmt.Sprintf("/containers/%s/start", id), query, nil)
+}
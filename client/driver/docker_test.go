@@ -21,6 +21,7 @@ import (
 	"github.com/hashicorp/nomad/client/allocdir"
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/client/stats"
 	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/client/testutil"
 	"github.com/hashicorp/nomad/helper/uuid"
@@ -2378,3 +2379,220 @@ func TestDockerDriver_AdvertiseIPv6Address(t *testing.T) {
 		t.Fatalf("Got GlobalIPv6address %s want GlobalIPv6address with prefix %s", expectedPrefix, container.NetworkSettings.GlobalIPv6Address)
 	}
 }
+
+func TestDockerDriver_ImageDigest(t *testing.T) {
+	t.Parallel()
+	image := &docker.Image{
+		ID:          "sha256:1111",
+		RepoDigests: []string{"redis@sha256:2222"},
+		Config: &docker.Config{
+			Labels: map[string]string{
+				dockerImageSBOMLabel: "https://sbom.example.com/redis",
+			},
+		},
+	}
+
+	digest, sbom := dockerImageDigest(image)
+	if digest != "sha256:2222" {
+		t.Fatalf("expected repo digest, got %q", digest)
+	}
+	if sbom != "https://sbom.example.com/redis" {
+		t.Fatalf("expected sbom reference, got %q", sbom)
+	}
+
+	// Locally built images fall back to the image ID
+	image.RepoDigests = nil
+	image.Config = nil
+	digest, sbom = dockerImageDigest(image)
+	if digest != "sha256:1111" || sbom != "" {
+		t.Fatalf("unexpected digest %q and sbom %q", digest, sbom)
+	}
+}
+
+func TestDockerDriver_MemoryStats(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	// cgroup v1
+	var s docker.Stats
+	s.MemoryStats.Usage = 4096
+	s.MemoryStats.MaxUsage = 8192
+	s.MemoryStats.Stats.Rss = 1024
+	s.MemoryStats.Stats.Cache = 2048
+	s.MemoryStats.Stats.ActiveAnon = 1024
+	s.MemoryStats.Stats.HierarchicalMemoryLimit = 1 << 30
+	ms := dockerMemoryStats(&s)
+	assert.EqualValues(1024, ms.RSS)
+	assert.EqualValues(2048, ms.Cache)
+	assert.EqualValues(8192, ms.MaxUsage)
+	assert.Equal(DockerMeasuredMemStats, ms.Measured)
+
+	// cgroup v2
+	s = docker.Stats{}
+	s.MemoryStats.Usage = 4096
+	s.MemoryStats.Stats.ActiveAnon = 512
+	s.MemoryStats.Stats.InactiveAnon = 512
+	s.MemoryStats.Stats.ActiveFile = 1024
+	s.MemoryStats.Stats.InactiveFile = 1024
+	ms = dockerMemoryStats(&s)
+	assert.EqualValues(1024, ms.RSS)
+	assert.EqualValues(2048, ms.Cache)
+	assert.Zero(ms.MaxUsage)
+	assert.Equal(DockerMeasuredMemStatsV2, ms.Measured)
+}
+
+func TestDockerDriver_DiskStats(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	var s docker.Stats
+	s.BlkioStats.IOServiceBytesRecursive = []docker.BlkioStatsEntry{
+		{Major: 8, Minor: 0, Op: "Read", Value: 1024},
+		{Major: 8, Minor: 0, Op: "Write", Value: 2048},
+		{Major: 8, Minor: 0, Op: "Total", Value: 3072},
+		{Major: 8, Minor: 16, Op: "read", Value: 512},
+	}
+	ds := dockerDiskStats(&s, stats.NewByteRate(), stats.NewByteRate())
+	assert.EqualValues(1536, ds.ReadBytes)
+	assert.EqualValues(2048, ds.WriteBytes)
+	assert.Zero(ds.ReadRate)
+	assert.Equal(DockerMeasuredDiskStats, ds.Measured)
+}
+
+func TestDockerDriver_TaskHealth(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	assert.Nil(dockerTaskHealth(docker.Health{}))
+	assert.Nil(dockerTaskHealth(docker.Health{Status: "starting"}))
+	assert.Equal(&TaskHealth{Healthy: true}, dockerTaskHealth(docker.Health{Status: "healthy"}))
+
+	h := docker.Health{
+		Status:        "unhealthy",
+		FailingStreak: 3,
+		Log: []docker.HealthCheck{
+			{ExitCode: 0, Output: "ok\n"},
+			{ExitCode: 1, Output: "connection refused\n"},
+		},
+	}
+	assert.Equal(&TaskHealth{Output: "connection refused"}, dockerTaskHealth(h))
+}
+
+func TestDockerDriverConfig_Build(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	task := &structs.Task{
+		Name:   "web",
+		Driver: "docker",
+		Config: map[string]interface{}{
+			"image": "web:${NOMAD_META_version}",
+			"build": []map[string]interface{}{{
+				"context":    "local/src",
+				"dockerfile": "docker/Dockerfile",
+				"args": []map[string]string{{
+					"VERSION": "${NOMAD_META_version}",
+					"TASK":    "${NOMAD_TASK_NAME}",
+				}},
+			}},
+		},
+	}
+	taskEnv := env.NewEmptyBuilder().SetTemplateEnv(map[string]string{
+		"NOMAD_META_version": "1.2.3",
+		"NOMAD_TASK_NAME":    "web",
+	}).Build()
+
+	conf, err := NewDockerDriverConfig(task, taskEnv)
+	require.Nil(err)
+	require.Equal("web:1.2.3", conf.ImageName)
+	require.Len(conf.Build, 1)
+	require.Equal("local/src", conf.Build[0].Context)
+	require.Equal("docker/Dockerfile", conf.Build[0].Dockerfile)
+	require.Equal([]docker.BuildArg{
+		{Name: "TASK", Value: "web"},
+		{Name: "VERSION", Value: "1.2.3"},
+	}, dockerBuildArgs(conf.Build[0].Args))
+
+	// Builds can't load an image nor escape the task directory
+	task.Config["load"] = "web.tar"
+	_, err = NewDockerDriverConfig(task, taskEnv)
+	require.NotNil(err)
+	delete(task.Config, "load")
+
+	task.Config["build"] = []map[string]interface{}{{"context": "../../src"}}
+	_, err = NewDockerDriverConfig(task, taskEnv)
+	require.NotNil(err)
+
+	task.Config["build"] = []map[string]interface{}{{"dockerfile": "Dockerfile"}}
+	_, err = NewDockerDriverConfig(task, taskEnv)
+	require.NotNil(err)
+}
+
+func TestDockerDriver_Start_BuildImage(t *testing.T) {
+	if !tu.IsTravis() {
+		t.Parallel()
+	}
+	if !testutil.DockerIsConnected(t) {
+		t.Skip("Docker not connected")
+	}
+	task := &structs.Task{
+		Name:   "busybox-build",
+		Driver: "docker",
+		Config: map[string]interface{}{
+			"image": "nomad-build-test:1",
+			"build": []map[string]interface{}{{
+				"context": "local/build",
+				"args": []map[string]string{{
+					"GREETING": "hello ${NOMAD_TASK_NAME}",
+				}},
+			}},
+			"command": "/bin/sh",
+			"args": []string{
+				"-c",
+				"cat /greeting > $NOMAD_TASK_DIR/output",
+			},
+		},
+		LogConfig: &structs.LogConfig{
+			MaxFiles:      10,
+			MaxFileSizeMB: 10,
+		},
+		Resources: &structs.Resources{
+			MemoryMB: 256,
+			CPU:      512,
+		},
+	}
+
+	ctx := testDockerDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	d := NewDockerDriver(ctx.DriverCtx)
+
+	// The base image is loaded as the build can't pull it
+	client := newTestDockerClient(t)
+	copyImage(t, ctx.ExecCtx.TaskDir, "busybox.tar")
+	f, err := os.Open(filepath.Join(ctx.ExecCtx.TaskDir.LocalDir, "busybox.tar"))
+	require.Nil(t, err)
+	require.Nil(t, client.LoadImage(docker.LoadImageOptions{InputStream: f}))
+	f.Close()
+
+	buildDir := filepath.Join(ctx.ExecCtx.TaskDir.LocalDir, "build")
+	require.Nil(t, os.MkdirAll(buildDir, 0755))
+	dockerfile := "FROM busybox\nARG GREETING\nRUN echo \"$GREETING\" > /greeting\n"
+	require.Nil(t, ioutil.WriteFile(filepath.Join(buildDir, "Dockerfile"), []byte(dockerfile), 0644))
+
+	_, err = d.Prestart(ctx.ExecCtx, task)
+	require.Nil(t, err)
+	resp, err := d.Start(ctx.ExecCtx, task)
+	require.Nil(t, err)
+	defer resp.Handle.Kill()
+
+	select {
+	case res := <-resp.Handle.WaitCh():
+		require.True(t, res.Successful(), "%v", res)
+	case <-time.After(time.Duration(tu.TestMultiplier()*5) * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	act, err := ioutil.ReadFile(filepath.Join(ctx.ExecCtx.TaskDir.LocalDir, "output"))
+	require.Nil(t, err)
+	require.Equal(t, "hello busybox-build", strings.TrimSpace(string(act)))
+}
@@ -23,12 +23,15 @@ var (
 	// BuiltinDrivers contains the built in registered drivers
 	// which are available for allocation handling
 	BuiltinDrivers = map[string]Factory{
-		"docker":   NewDockerDriver,
-		"exec":     NewExecDriver,
-		"raw_exec": NewRawExecDriver,
-		"java":     NewJavaDriver,
-		"qemu":     NewQemuDriver,
-		"rkt":      NewRktDriver,
+		"docker":      NewDockerDriver,
+		"exec":        NewExecDriver,
+		"raw_exec":    NewRawExecDriver,
+		"java":        NewJavaDriver,
+		"qemu":        NewQemuDriver,
+		"rkt":         NewRktDriver,
+		"containerd":  NewContainerdDriver,
+		"firecracker": NewFirecrackerDriver,
+		"wasm":        NewWasmDriver,
 	}
 
 	// DriverStatsNotImplemented is the error to be returned if a driver doesn't
@@ -199,6 +202,18 @@ type StartResponse struct {
 	// Network may be nil as not all drivers or configurations create
 	// networks.
 	Network *cstructs.DriverNetwork
+
+	// ImageDigest is the resolved digest of the image the task was started
+	// from. It may be empty for drivers that do not run images.
+	ImageDigest string
+
+	// ImageSBOM is an optional reference to the software bill of materials
+	// of the image the task was started from.
+	ImageSBOM string
+
+	// Restored is set when the task was restored from the checkpoint in its
+	// task dir rather than started anew.
+	Restored bool
 }
 
 // Driver is used for execution of tasks. This allows Nomad
@@ -317,6 +332,39 @@ type DriverHandle interface {
 	ScriptExecutor
 }
 
+// CheckpointHandle is implemented by the handles of drivers able to checkpoint
+// their task with CRIU. Drivers restore tasks started with a checkpoint from
+// the checkpoint dir of their task dir.
+type CheckpointHandle interface {
+	// Checkpointable returns whether the task can be checkpointed
+	Checkpointable() bool
+
+	// Checkpoint dumps the task into the directory and stops it
+	Checkpoint(dir string) error
+}
+
+// HealthHandle is implemented by the handles of drivers able to report the
+// health of their task as determined by the task itself, such as with the
+// HEALTHCHECK of a Docker image.
+type HealthHandle interface {
+	// HealthCh returns a channel receiving the health of the task each time
+	// it changes, which is closed once the task exits. It is nil if the task
+	// doesn't report its health.
+	HealthCh() <-chan *TaskHealth
+
+	// HealthGated returns whether the health of the allocation depends on
+	// the health reported by the task
+	HealthGated() bool
+}
+
+// TaskHealth is the health of a task reported by its driver
+type TaskHealth struct {
+	Healthy bool
+
+	// Output is the output of the last health check of the task
+	Output string
+}
+
 // ScriptExecutor is an interface that supports Exec()ing commands in the
 // driver's context. Split out of DriverHandle to ease testing.
 type ScriptExecutor interface {
@@ -35,6 +35,19 @@ const (
 	// CpuLimit is the environment variable with the tasks CPU limit in MHz.
 	CpuLimit = "NOMAD_CPU_LIMIT"
 
+	// CpuCores is the environment variable with the cores the task is pinned
+	// to.
+	CpuCores = "NOMAD_CPU_CORES"
+
+	// SRIOVDevices is the environment variable with the PCI addresses of the
+	// SR-IOV virtual functions assigned to the task.
+	SRIOVDevices = "NOMAD_SRIOV_VFS"
+
+	// SRIOVLocalCPUs is the environment variable with the CPUs local to the
+	// SR-IOV virtual functions of the task, if they are all local to the
+	// same CPUs.
+	SRIOVLocalCPUs = "NOMAD_SRIOV_LOCAL_CPUS"
+
 	// AllocID is the environment variable for passing the allocation ID.
 	AllocID = "NOMAD_ALLOC_ID"
 
@@ -82,6 +95,10 @@ const (
 	// MetaPrefix is the prefix for passing task meta data.
 	MetaPrefix = "NOMAD_META_"
 
+	// VariablePrefix is the prefix for passing the items of the variables
+	// the task can read.
+	VariablePrefix = "NOMAD_VAR_"
+
 	// VaultToken is the environment variable for passing the Vault token
 	VaultToken = "VAULT_TOKEN"
 )
@@ -189,6 +206,9 @@ type Builder struct {
 	// templateEnv are env vars set from templates
 	templateEnv map[string]string
 
+	// variables are the items of the variables the task can read
+	variables map[string]string
+
 	// hostEnv are environment variables filtered from the host
 	hostEnv map[string]string
 
@@ -209,6 +229,8 @@ type Builder struct {
 
 	cpuLimit         int
 	memLimit         int
+	cpuCores         []int
+	sriovDevices     []*structs.DeviceInstance
 	taskName         string
 	allocIndex       int
 	datacenter       string
@@ -277,6 +299,25 @@ func (b *Builder) Build() *TaskEnv {
 	if b.cpuLimit != 0 {
 		envMap[CpuLimit] = strconv.Itoa(b.cpuLimit)
 	}
+	if len(b.cpuCores) != 0 {
+		envMap[CpuCores] = structs.CoreIDsString(b.cpuCores)
+	}
+
+	// Add the assigned devices
+	if len(b.sriovDevices) != 0 {
+		ids := make([]string, len(b.sriovDevices))
+		localCPUs := b.sriovDevices[0].LocalCPUs
+		for i, d := range b.sriovDevices {
+			ids[i] = d.ID
+			if d.LocalCPUs != localCPUs {
+				localCPUs = ""
+			}
+		}
+		envMap[SRIOVDevices] = strings.Join(ids, ",")
+		if localCPUs != "" {
+			envMap[SRIOVLocalCPUs] = localCPUs
+		}
+	}
 
 	// Add the task metadata
 	if b.allocId != "" {
@@ -325,6 +366,11 @@ func (b *Builder) Build() *TaskEnv {
 		envMap[k] = v
 	}
 
+	// Copy the items of the variables
+	for k, v := range b.variables {
+		envMap[VariablePrefix+k] = v
+	}
+
 	// Copy node attributes
 	for k, v := range b.nodeAttrs {
 		nodeAttrs[k] = v
@@ -373,10 +419,14 @@ func (b *Builder) setTask(task *structs.Task) *Builder {
 	if task.Resources == nil {
 		b.memLimit = 0
 		b.cpuLimit = 0
+		b.cpuCores = nil
 		b.networks = []*structs.NetworkResource{}
+		b.sriovDevices = nil
 	} else {
 		b.memLimit = task.Resources.MemoryMB
 		b.cpuLimit = task.Resources.CPU
+		b.cpuCores = task.Resources.CoreIDs
+		b.sriovDevices = task.Resources.DevicesOfType(structs.DeviceTypeSRIOV)
 		// Copy networks to prevent sharing
 		b.networks = make([]*structs.NetworkResource, len(task.Resources.Networks))
 		for i, n := range task.Resources.Networks {
@@ -543,6 +593,15 @@ func (b *Builder) SetTemplateEnv(m map[string]string) *Builder {
 	return b
 }
 
+// SetVariables sets the items of the variables the task can read. They are
+// set in the environment prefixed by NOMAD_VAR_.
+func (b *Builder) SetVariables(items map[string]string) *Builder {
+	b.mu.Lock()
+	b.variables = items
+	b.mu.Unlock()
+	return b
+}
+
 func (b *Builder) SetVaultToken(token string, inject bool) *Builder {
 	b.mu.Lock()
 	b.vaultToken = token
@@ -554,7 +613,7 @@ func (b *Builder) SetVaultToken(token string, inject bool) *Builder {
 // addPort keys and values for other tasks to an env var map
 func addPort(m map[string]string, taskName, ip, portLabel string, port int) {
 	key := fmt.Sprintf("%s%s_%s", AddrPrefix, taskName, portLabel)
-	m[key] = fmt.Sprintf("%s:%d", ip, port)
+	m[key] = net.JoinHostPort(ip, strconv.Itoa(port))
 	key = fmt.Sprintf("%s%s_%s", IpPrefix, taskName, portLabel)
 	m[key] = ip
 	key = fmt.Sprintf("%s%s_%s", PortPrefix, taskName, portLabel)
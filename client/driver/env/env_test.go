@@ -248,6 +248,26 @@ func TestEnvironment_VaultToken(t *testing.T) {
 	}
 }
 
+func TestEnvironment_Variables(t *testing.T) {
+	n := mock.Node()
+	a := mock.Alloc()
+	task := a.Job.TaskGroups[0].Tasks[0]
+	task.Env = map[string]string{"password_hint": "${NOMAD_VAR_user}"}
+	env := NewBuilder(n, a, task, "global")
+	env.SetVariables(map[string]string{"user": "web", "db-password": "hunter2"})
+
+	act := env.Build().All()
+	if v := act["NOMAD_VAR_user"]; v != "web" {
+		t.Fatalf("expected NOMAD_VAR_user=web but found %q", v)
+	}
+	if v := act["NOMAD_VAR_db_password"]; v != "hunter2" {
+		t.Fatalf("expected NOMAD_VAR_db_password=hunter2 but found %q", v)
+	}
+	if v := act["password_hint"]; v != "web" {
+		t.Fatalf("expected the items to be interpolated but found %q", v)
+	}
+}
+
 func TestEnvironment_Envvars(t *testing.T) {
 	envMap := map[string]string{"foo": "baz", "bar": "bang"}
 	n := mock.Node()
@@ -325,6 +345,78 @@ func TestEnvironment_DashesInTaskName(t *testing.T) {
 	}
 }
 
+func TestEnvironment_IPv6Addrs(t *testing.T) {
+	a := mock.Alloc()
+	a.TaskResources["web"].Networks[0].IP = "2001:db8::1"
+	a.TaskResources["sidecar"] = &structs.Resources{
+		Networks: []*structs.NetworkResource{
+			{
+				IP:            "2001:db8::2",
+				ReservedPorts: []structs.Port{{Label: "admin", Value: 9000}},
+			},
+		},
+	}
+	task := a.Job.TaskGroups[0].Tasks[0]
+	task.Resources.Networks = a.TaskResources["web"].Networks
+	envMap := NewBuilder(mock.Node(), a, task, "global").Build().Map()
+
+	if act := envMap["NOMAD_ADDR_sidecar_admin"]; act != "[2001:db8::2]:9000" {
+		t.Fatalf("expected bracketed address for other task; found %q", act)
+	}
+	if act := envMap["NOMAD_IP_sidecar_admin"]; act != "2001:db8::2" {
+		t.Fatalf("expected unbracketed IP for other task; found %q", act)
+	}
+	for k, v := range envMap {
+		if strings.HasPrefix(k, AddrPrefix) && !strings.HasPrefix(k, AddrPrefix+"sidecar") && !strings.HasPrefix(v, "[2001:db8::1]:") {
+			t.Fatalf("expected bracketed address for %s; found %q", k, v)
+		}
+	}
+}
+
+func TestEnvironment_SRIOVDevices(t *testing.T) {
+	a := mock.Alloc()
+	task := a.Job.TaskGroups[0].Tasks[0]
+	task.Resources.Devices = []*structs.DeviceResource{
+		{
+			Type:  structs.DeviceTypeSRIOV,
+			Count: 2,
+			Instances: []*structs.DeviceInstance{
+				{ID: "0000:3b:02.0", LocalCPUs: "0-15"},
+				{ID: "0000:3b:02.1", LocalCPUs: "0-15"},
+			},
+		},
+	}
+	envMap := NewBuilder(mock.Node(), a, task, "global").Build().Map()
+	if v := envMap[SRIOVDevices]; v != "0000:3b:02.0,0000:3b:02.1" {
+		t.Fatalf("bad %s: %q", SRIOVDevices, v)
+	}
+	if v := envMap[SRIOVLocalCPUs]; v != "0-15" {
+		t.Fatalf("bad %s: %q", SRIOVLocalCPUs, v)
+	}
+
+	// The local CPUs are only set if they are the same for all devices
+	task.Resources.Devices[0].Instances[1].LocalCPUs = "16-31"
+	envMap = NewBuilder(mock.Node(), a, task, "global").Build().Map()
+	if v, ok := envMap[SRIOVLocalCPUs]; ok {
+		t.Fatalf("unexpected %s: %q", SRIOVLocalCPUs, v)
+	}
+}
+
+func TestEnvironment_CpuCores(t *testing.T) {
+	a := mock.Alloc()
+	task := a.Job.TaskGroups[0].Tasks[0]
+	envMap := NewBuilder(mock.Node(), a, task, "global").Build().Map()
+	if v, ok := envMap[CpuCores]; ok {
+		t.Fatalf("unexpected %s: %q", CpuCores, v)
+	}
+
+	task.Resources.CoreIDs = []int{2, 3}
+	envMap = NewBuilder(mock.Node(), a, task, "global").Build().Map()
+	if v := envMap[CpuCores]; v != "2,3" {
+		t.Fatalf("bad %s: %q", CpuCores, v)
+	}
+}
+
 // TestEnvironment_UpdateTask asserts env vars and task meta are updated when a
 // task is updated.
 func TestEnvironment_UpdateTask(t *testing.T) {
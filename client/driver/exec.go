@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -15,11 +17,24 @@ import (
 	"github.com/hashicorp/nomad/client/driver/executor"
 	dstructs "github.com/hashicorp/nomad/client/driver/structs"
 	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper/capabilities"
 	"github.com/hashicorp/nomad/helper/fields"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/mitchellh/mapstructure"
 )
 
+const (
+	// execCapsWhitelistConfigOption is the key for setting the list of Linux
+	// capabilities exec tasks may be bounded to. Tasks not declaring their
+	// capabilities are bounded to the whitelist.
+	execCapsWhitelistConfigOption  = "exec.caps.whitelist"
+	execCapsWhitelistConfigDefault = "ALL"
+
+	// execSeccompProfileConfigOption is the key for setting the path of the
+	// seccomp profile of exec tasks without one of their own
+	execSeccompProfileConfigOption = "exec.seccomp.profile"
+)
+
 // ExecDriver fork/execs tasks using as many of the underlying OS's isolation
 // features.
 type ExecDriver struct {
@@ -31,8 +46,12 @@ type ExecDriver struct {
 }
 
 type ExecDriverConfig struct {
-	Command string   `mapstructure:"command"`
-	Args    []string `mapstructure:"args"`
+	Command              string   `mapstructure:"command"`
+	Args                 []string `mapstructure:"args"`
+	Checkpoint           bool     `mapstructure:"checkpoint"`
+	Capabilities         []string `mapstructure:"capabilities"`
+	SeccompProfile       string   `mapstructure:"seccomp_profile"`
+	SeccompProfileInline string   `mapstructure:"seccomp_profile_inline"`
 }
 
 // execHandle is returned from Start/Open as a handle to the PID
@@ -48,6 +67,20 @@ type execHandle struct {
 	waitCh          chan *dstructs.WaitResult
 	doneCh          chan struct{}
 	version         string
+
+	// checkpoint is set if the task is checkpointed when migrated
+	checkpoint bool
+
+	// restored is set if the task was restored from a checkpoint. The user
+	// pid is then of the CRIU process waiting for the restored task.
+	restored bool
+
+	// killSignal is sent to restored tasks to stop them
+	killSignal string
+
+	// checkpointLink is the link to the task dir created to restore the
+	// task, removed once it exits
+	checkpointLink string
 }
 
 // NewExecDriver is used to create a new exec driver
@@ -67,6 +100,18 @@ func (d *ExecDriver) Validate(config map[string]interface{}) error {
 			"args": {
 				Type: fields.TypeArray,
 			},
+			"checkpoint": {
+				Type: fields.TypeBool,
+			},
+			"capabilities": {
+				Type: fields.TypeArray,
+			},
+			"seccomp_profile": {
+				Type: fields.TypeString,
+			},
+			"seccomp_profile_inline": {
+				Type: fields.TypeString,
+			},
 		},
 	}
 
@@ -74,6 +119,31 @@ func (d *ExecDriver) Validate(config map[string]interface{}) error {
 		return err
 	}
 
+	var driverConfig ExecDriverConfig
+	if err := mapstructure.WeakDecode(config, &driverConfig); err != nil {
+		return err
+	}
+	if _, err := capabilities.ParseNames(driverConfig.Capabilities); err != nil {
+		return err
+	}
+	if driverConfig.SeccompProfile != "" && driverConfig.SeccompProfileInline != "" {
+		return fmt.Errorf("only one of seccomp_profile and seccomp_profile_inline may be set")
+	}
+	if driverConfig.SeccompProfile != "" {
+		escapes, err := structs.PathEscapesAllocDir("task", driverConfig.SeccompProfile)
+		if err != nil {
+			return err
+		}
+		if escapes {
+			return fmt.Errorf("seccomp_profile escapes the allocation directory")
+		}
+	}
+	if driverConfig.SeccompProfileInline != "" {
+		if _, err := executor.ParseSeccompProfile([]byte(driverConfig.SeccompProfileInline)); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -108,6 +178,10 @@ func (d *ExecDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 		return nil, err
 	}
 
+	if err := writeResolvConf(ctx, task); err != nil {
+		return nil, err
+	}
+
 	pluginLogFile := filepath.Join(ctx.TaskDir.Dir, "executor.out")
 	executorConfig := &dstructs.ExecutorConfig{
 		LogFile:  pluginLogFile,
@@ -141,16 +215,70 @@ func (d *ExecDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 		FSIsolation:    true,
 		ResourceLimits: true,
 		User:           getExecutorUser(task),
+		CgroupParent:   d.config.CgroupParent(),
+
+		// Tasks assigned SR-IOV virtual functions get a network namespace
+		// of their own to attach them to
+		NetworkIsolation: len(task.Resources.DevicesOfType(structs.DeviceTypeSRIOV)) != 0,
+	}
+	execCmd.DropCapabilities, execCmd.SeccompProfile, err = d.taskRestrictions(ctx, task, &driverConfig)
+	if err != nil {
+		pluginClient.Kill()
+		return nil, err
+	}
+
+	// Restore the task if it was checkpointed. CRIU runs as root outside of
+	// the chroot, which it restores for the task.
+	checkpointLink := ""
+	checkpoint, err := ctx.TaskDir.ReadCheckpoint()
+	if err != nil {
+		pluginClient.Kill()
+		return nil, fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	restored := driverConfig.Checkpoint && checkpoint != nil && checkpoint.Driver == "exec"
+	if restored {
+		pidFile := filepath.Join(ctx.TaskDir.Dir, criuPidFile)
+		os.Remove(pidFile)
+		execCmd.Cmd, execCmd.Args, err = criuRestoreCommand(ctx.TaskDir.CheckpointDir, pidFile)
+		if err != nil {
+			pluginClient.Kill()
+			return nil, err
+		}
+		if checkpointLink, err = linkCheckpointTaskDir(checkpoint, ctx.TaskDir.Dir); err != nil {
+			pluginClient.Kill()
+			return nil, fmt.Errorf("failed to link checkpointed task dir: %v", err)
+		}
+		execCmd.FSIsolation = false
+		execCmd.User = ""
+
+		// The capabilities and seccomp filter of the task are restored
+		// with it, while CRIU needs its own
+		execCmd.DropCapabilities = 0
+		execCmd.SeccompProfile = nil
+		d.logger.Printf("[DEBUG] driver.exec: restoring task from checkpoint of %v", checkpoint.Time)
 	}
 
 	ps, err := exec.LaunchCmd(execCmd)
 	if err != nil {
+		if checkpointLink != "" {
+			os.Remove(checkpointLink)
+		}
 		pluginClient.Kill()
 		return nil, err
 	}
 
 	d.logger.Printf("[DEBUG] driver.exec: started process via plugin with pid: %v", ps.Pid)
 
+	// Attach the SR-IOV virtual functions to the task's network
+	if err := attachSRIOVDevices(ps.Pid, task.Resources); err != nil {
+		d.logger.Printf("[ERR] driver.exec: failed to attach devices to task: %v", err)
+		if err := exec.Exit(); err != nil {
+			d.logger.Printf("[ERR] driver.exec: failed to stop task: %v", err)
+		}
+		pluginClient.Kill()
+		return nil, fmt.Errorf("failed to attach devices to task: %v", err)
+	}
+
 	// Return a driver handle
 	maxKill := d.DriverContext.config.MaxKillTimeout
 	h := &execHandle{
@@ -165,13 +293,69 @@ func (d *ExecDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 		doneCh:          make(chan struct{}),
 		waitCh:          make(chan *dstructs.WaitResult, 1),
 		taskDir:         ctx.TaskDir,
+		checkpoint:      driverConfig.Checkpoint,
+		restored:        restored,
+		killSignal:      task.KillSignal,
+		checkpointLink:  checkpointLink,
 	}
 	go h.run()
-	return &StartResponse{Handle: h}, nil
+	return &StartResponse{Handle: h, Restored: restored}, nil
 }
 
 func (d *ExecDriver) Cleanup(*ExecContext, *CreatedResources) error { return nil }
 
+// taskRestrictions returns the capabilities dropped from the bounding set of
+// the task and the seccomp profile restricting its syscalls, if any.
+func (d *ExecDriver) taskRestrictions(ctx *ExecContext, task *structs.Task, driverConfig *ExecDriverConfig) (capabilities.Set, *executor.SeccompProfile, error) {
+	var whitelistNames []string
+	for _, name := range strings.Split(d.config.ReadDefault(execCapsWhitelistConfigOption, execCapsWhitelistConfigDefault), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			whitelistNames = append(whitelistNames, name)
+		}
+	}
+	whitelist, err := capabilities.ParseNames(whitelistNames)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid %s: %v", execCapsWhitelistConfigOption, err)
+	}
+
+	// An empty list of capabilities drops all of them
+	allowed := whitelist
+	if _, ok := task.Config["capabilities"]; ok {
+		if allowed, err = capabilities.ParseNames(driverConfig.Capabilities); err != nil {
+			return 0, nil, err
+		}
+		if missing := whitelist.Missing(allowed & capabilities.Known); len(missing) != 0 {
+			return 0, nil, fmt.Errorf("exec driver doesn't have the following caps whitelisted on this Nomad agent: %v", missing)
+		}
+	}
+
+	var profile []byte
+	switch {
+	case driverConfig.SeccompProfileInline != "":
+		profile = []byte(driverConfig.SeccompProfileInline)
+	case driverConfig.SeccompProfile != "":
+		path := filepath.Join(ctx.TaskDir.Dir, driverConfig.SeccompProfile)
+		if profile, err = ioutil.ReadFile(path); err != nil {
+			return 0, nil, fmt.Errorf("failed to read seccomp profile: %v", err)
+		}
+	default:
+		if path := d.config.Read(execSeccompProfileConfigOption); path != "" {
+			if profile, err = ioutil.ReadFile(path); err != nil {
+				return 0, nil, fmt.Errorf("failed to read seccomp profile %s: %v", execSeccompProfileConfigOption, err)
+			}
+		}
+	}
+	if profile == nil {
+		return capabilities.All &^ allowed, nil, nil
+	}
+
+	p, err := executor.ParseSeccompProfile(profile)
+	if err != nil {
+		return 0, nil, err
+	}
+	return capabilities.All &^ allowed, p, nil
+}
+
 type execId struct {
 	Version         string
 	KillTimeout     time.Duration
@@ -179,6 +363,10 @@ type execId struct {
 	UserPid         int
 	IsolationConfig *dstructs.IsolationConfig
 	PluginConfig    *PluginReattachConfig
+	Checkpoint      bool
+	Restored        bool
+	KillSignal      string
+	CheckpointLink  string
 }
 
 func (d *ExecDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, error) {
@@ -222,6 +410,10 @@ func (d *ExecDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, erro
 		doneCh:          make(chan struct{}),
 		waitCh:          make(chan *dstructs.WaitResult, 1),
 		taskDir:         ctx.TaskDir,
+		checkpoint:      id.Checkpoint,
+		restored:        id.Restored,
+		killSignal:      id.KillSignal,
+		checkpointLink:  id.CheckpointLink,
 	}
 	go h.run()
 	return h, nil
@@ -235,6 +427,10 @@ func (h *execHandle) ID() string {
 		PluginConfig:    NewPluginReattachConfig(h.pluginClient.ReattachConfig()),
 		UserPid:         h.userPid,
 		IsolationConfig: h.isolationConfig,
+		Checkpoint:      h.checkpoint,
+		Restored:        h.restored,
+		KillSignal:      h.killSignal,
+		CheckpointLink:  h.checkpointLink,
 	}
 
 	data, err := json.Marshal(id)
@@ -267,10 +463,66 @@ func (h *execHandle) Exec(ctx context.Context, cmd string, args []string) ([]byt
 }
 
 func (h *execHandle) Signal(s os.Signal) error {
+	if h.restored {
+		return h.signalRestored(s)
+	}
 	return h.executor.Signal(s)
 }
 
+// signalRestored signals a restored task directly as signals sent to the
+// CRIU process waiting for it aren't forwarded.
+func (h *execHandle) signalRestored(s os.Signal) error {
+	pid, err := h.taskPid()
+	if err != nil {
+		return err
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Signal(s)
+}
+
+// taskPid returns the pid of the task, which is the restored process for
+// restored tasks.
+func (h *execHandle) taskPid() (int, error) {
+	if !h.restored {
+		return h.userPid, nil
+	}
+	return readPidFile(filepath.Join(h.taskDir.Dir, criuPidFile))
+}
+
+func (h *execHandle) Checkpointable() bool {
+	_, ok := criuAvailable()
+	return h.checkpoint && ok
+}
+
+func (h *execHandle) Checkpoint(dir string) error {
+	pid, err := h.taskPid()
+	if err != nil {
+		return err
+	}
+	return criuDump(pid, dir)
+}
+
 func (h *execHandle) Kill() error {
+	if h.restored {
+		// The executor stops CRIU rather than the task so signal the task
+		// first
+		s, err := getTaskKillSignal(h.killSignal)
+		if err != nil {
+			return err
+		}
+		if err := h.signalRestored(s); err != nil {
+			h.logger.Printf("[DEBUG] driver.exec: failed to signal restored task: %v", err)
+		}
+		select {
+		case <-h.doneCh:
+			return nil
+		case <-time.After(h.killTimeout):
+		}
+	}
+
 	if err := h.executor.ShutDown(); err != nil {
 		if h.pluginClient.Exited() {
 			return nil
@@ -319,6 +571,12 @@ func (h *execHandle) run() {
 	}
 	h.pluginClient.Kill()
 
+	if h.checkpointLink != "" {
+		if err := os.Remove(h.checkpointLink); err != nil && !os.IsNotExist(err) {
+			h.logger.Printf("[ERR] driver.exec: error removing checkpointed task dir link: %v", err)
+		}
+	}
+
 	// Send the results
 	h.waitCh <- dstructs.NewWaitResult(ps.ExitCode, ps.Signal, werr)
 	close(h.waitCh)
@@ -3,7 +3,7 @@ package driver
 import (
 	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/helper"
-	"golang.org/x/sys/unix"
+	"github.com/hashicorp/nomad/helper/capabilities"
 )
 
 const (
@@ -24,9 +24,10 @@ func (d *ExecDriver) Fingerprint(req *cstructs.FingerprintRequest, resp *cstruct
 		d.fingerprintSuccess = helper.BoolToPtr(false)
 		resp.RemoveAttribute(execDriverAttr)
 		return nil
-	} else if unix.Geteuid() != 0 {
+	} else if !hasIsolationCapabilities(d.config) {
 		if d.fingerprintSuccess == nil || *d.fingerprintSuccess {
-			d.logger.Printf("[DEBUG] driver.exec: must run as root user, disabling")
+			d.logger.Printf("[DEBUG] driver.exec: must run as root user or with the %s capabilities, disabling",
+				capabilities.NewSet(isolationCapabilities(d.config)...))
 		}
 		d.fingerprintSuccess = helper.BoolToPtr(false)
 		resp.RemoveAttribute(execDriverAttr)
@@ -37,6 +38,13 @@ func (d *ExecDriver) Fingerprint(req *cstructs.FingerprintRequest, resp *cstruct
 		d.logger.Printf("[DEBUG] driver.exec: exec driver is enabled")
 	}
 	resp.AddAttribute(execDriverAttr, "1")
+
+	// Advertise if tasks can be checkpointed
+	if _, ok := criuAvailable(); ok {
+		resp.AddAttribute(execDriverAttr+"."+checkpointConfigOption, "1")
+	} else {
+		resp.RemoveAttribute(execDriverAttr + "." + checkpointConfigOption)
+	}
 	d.fingerprintSuccess = helper.BoolToPtr(true)
 	return nil
 }
@@ -13,9 +13,12 @@ import (
 
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/client/driver/executor"
 	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper/capabilities"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
+	"github.com/mitchellh/mapstructure"
 
 	ctestutils "github.com/hashicorp/nomad/client/testutil"
 )
@@ -398,3 +401,95 @@ func TestExecDriver_HandlerExec(t *testing.T) {
 		t.Fatalf("error killing exec handle: %v", err)
 	}
 }
+
+func TestExecDriver_TaskRestrictions(t *testing.T) {
+	if !testutil.IsTravis() {
+		t.Parallel()
+	}
+	ctestutils.ExecCompatible(t)
+	task := &structs.Task{
+		Name:   "sleep",
+		Driver: "exec",
+		Config: map[string]interface{}{
+			"command":         "/bin/sleep",
+			"capabilities":    []string{"chown"},
+			"seccomp_profile": "local/seccomp.json",
+		},
+		LogConfig: &structs.LogConfig{
+			MaxFiles:      10,
+			MaxFileSizeMB: 10,
+		},
+		Resources: basicResources,
+	}
+
+	ctx := testDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	ctx.DriverCtx.config.Options = map[string]string{execCapsWhitelistConfigOption: "CHOWN, KILL"}
+	d := NewExecDriver(ctx.DriverCtx).(*ExecDriver)
+
+	profile := `{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "mkdir", "action": "SCMP_ACT_ERRNO"}]}`
+	if err := ioutil.WriteFile(filepath.Join(ctx.ExecCtx.TaskDir.LocalDir, "seccomp.json"), []byte(profile), 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	restrictions := func() (capabilities.Set, *executor.SeccompProfile, error) {
+		var driverConfig ExecDriverConfig
+		if err := mapstructure.WeakDecode(task.Config, &driverConfig); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return d.taskRestrictions(ctx.ExecCtx, task, &driverConfig)
+	}
+
+	drop, p, err := restrictions()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if drop != capabilities.All&^capabilities.NewSet(capabilities.Chown) {
+		t.Fatalf("unexpected dropped capabilities: %v", drop)
+	}
+	if p == nil || len(p.Syscalls) != 1 || p.Syscalls[0].Name != "mkdir" {
+		t.Fatalf("unexpected seccomp profile: %#v", p)
+	}
+
+	// Tasks not declaring capabilities are bounded to the whitelist
+	delete(task.Config, "capabilities")
+	delete(task.Config, "seccomp_profile")
+	if drop, p, err = restrictions(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if drop != capabilities.All&^capabilities.NewSet(capabilities.Chown, capabilities.Kill) || p != nil {
+		t.Fatalf("unexpected restrictions: %v %#v", drop, p)
+	}
+
+	// Capabilities outside of the whitelist are refused
+	task.Config["capabilities"] = []string{"SYS_ADMIN"}
+	if _, _, err := restrictions(); err == nil || !strings.Contains(err.Error(), "CAP_SYS_ADMIN") {
+		t.Fatalf("expected whitelist error, got: %v", err)
+	}
+}
+
+func TestExecDriver_Validate_Seccomp(t *testing.T) {
+	t.Parallel()
+	d := NewExecDriver(NewEmptyDriverContext())
+	cases := []struct {
+		config map[string]interface{}
+		valid  bool
+	}{
+		{map[string]interface{}{"command": "/bin/sleep", "capabilities": []string{"CAP_NET_BIND_SERVICE"}}, true},
+		{map[string]interface{}{"command": "/bin/sleep", "capabilities": []string{"CAP_FLY"}}, false},
+		{map[string]interface{}{"command": "/bin/sleep", "seccomp_profile": "local/seccomp.json"}, true},
+		{map[string]interface{}{"command": "/bin/sleep", "seccomp_profile": "../../../etc/seccomp.json"}, false},
+		{map[string]interface{}{"command": "/bin/sleep", "seccomp_profile_inline": `{"defaultAction": "SCMP_ACT_ALLOW"}`}, true},
+		{map[string]interface{}{"command": "/bin/sleep", "seccomp_profile_inline": `{"defaultAction": "allow"}`}, false},
+		{map[string]interface{}{
+			"command":                "/bin/sleep",
+			"seccomp_profile":        "local/seccomp.json",
+			"seccomp_profile_inline": `{"defaultAction": "SCMP_ACT_ALLOW"}`,
+		}, false},
+	}
+	for i, c := range cases {
+		if err := d.Validate(c.config); (err == nil) != c.valid {
+			t.Errorf("case %d: unexpected validation result: %v", i, err)
+		}
+	}
+}
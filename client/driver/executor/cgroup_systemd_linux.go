@@ -0,0 +1,462 @@
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad/helper/cgroupv2"
+	"github.com/hashicorp/nomad/helper/systemd"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	cgroupFs "github.com/opencontainers/runc/libcontainer/cgroups/fs"
+	cgroupConfig "github.com/opencontainers/runc/libcontainer/configs"
+)
+
+const (
+	// cgroupUnifiedMountpoint is where the cgroup v2 unified hierarchy is
+	// mounted by systemd.
+	cgroupUnifiedMountpoint = cgroupv2.UnifiedMountpoint
+
+	// systemdScopePrefix is the prefix of the transient scope units that
+	// tasks are placed in.
+	systemdScopePrefix = "nomad"
+
+	// systemdScopeTimeout is how long to wait for systemd to move a process
+	// into a newly started scope.
+	systemdScopeTimeout = 5 * time.Second
+
+	// unifiedKey is the key under which the cgroup v2 path is stored in the
+	// paths map of the manager.
+	unifiedKey = "unified"
+)
+
+// systemdManager implements the libcontainer cgroup manager interface by
+// placing the task in a transient scope under a systemd slice. The scope and
+// its resource limits are managed through the systemd D-Bus API so that
+// systemd remains the single writer of the cgroup tree. Stats are read
+// directly from the cgroup filesystem.
+type systemdManager struct {
+	cgroups *cgroupConfig.Cgroup
+	paths   map[string]string
+	unified bool
+	mu      sync.Mutex
+}
+
+// newSystemdManager returns a manager for the cgroup. paths may be nil if the
+// scope has not been created yet.
+func newSystemdManager(groups *cgroupConfig.Cgroup, paths map[string]string) *systemdManager {
+	return &systemdManager{
+		cgroups: groups,
+		paths:   paths,
+		unified: cgroupv2.IsUnifiedMode(),
+	}
+}
+
+// unitName returns the name of the scope unit for the cgroup.
+func (m *systemdManager) unitName() string {
+	prefix := m.cgroups.ScopePrefix
+	if prefix == "" {
+		prefix = systemdScopePrefix
+	}
+	return fmt.Sprintf("%s-%s.scope", prefix, m.cgroups.Name)
+}
+
+// fs returns a cgroupfs manager for reading the v1 hierarchies of the scope.
+func (m *systemdManager) fs() *cgroupFs.Manager {
+	return &cgroupFs.Manager{Cgroups: m.cgroups, Paths: m.paths}
+}
+
+// Apply starts a transient scope under the configured slice containing pid.
+func (m *systemdManager) Apply(pid int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	accounting := "BlockIOAccounting"
+	if m.unified {
+		accounting = "IOAccounting"
+	}
+
+	args := []string{
+		"call", "org.freedesktop.systemd1", "/org/freedesktop/systemd1",
+		"org.freedesktop.systemd1.Manager", "StartTransientUnit", "ssa(sv)a(sa(sv))",
+		m.unitName(), "fail", "7",
+		"Description", "s", fmt.Sprintf("Nomad task %s", m.cgroups.Name),
+		"Slice", "s", m.cgroups.Parent,
+		"PIDs", "au", "1", strconv.Itoa(pid),
+		"Delegate", "b", "true",
+		"CPUAccounting", "b", "true",
+		"MemoryAccounting", "b", "true",
+		accounting, "b", "true",
+		"0",
+	}
+	if err := busctl(args...); err != nil {
+		return fmt.Errorf("failed to start scope %q: %v", m.unitName(), err)
+	}
+
+	// The process is moved asynchronously once the start job runs
+	deadline := time.Now().Add(systemdScopeTimeout)
+	for {
+		paths, err := m.scopePaths(pid)
+		if err == nil {
+			m.paths = paths
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// scopePaths returns the paths of the cgroups pid is a member of, once it
+// has been moved into the scope.
+func (m *systemdManager) scopePaths(pid int) (map[string]string, error) {
+	groups, err := cgroups.ParseCgroupFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string)
+	for subsystem, rel := range groups {
+		if !strings.HasSuffix(rel, m.unitName()) {
+			continue
+		}
+
+		if m.unified {
+			if subsystem == "" {
+				paths[unifiedKey] = filepath.Join(cgroupUnifiedMountpoint, rel)
+			}
+			continue
+		}
+
+		if subsystem == "" || strings.HasPrefix(subsystem, "name=") {
+			continue
+		}
+		mount, err := cgroups.FindCgroupMountpoint(subsystem)
+		if err != nil {
+			continue
+		}
+		paths[subsystem] = filepath.Join(mount, rel)
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("pid %d not moved into scope %q", pid, m.unitName())
+	}
+	return paths, nil
+}
+
+// Set applies the resource limits of the cgroup to the scope.
+func (m *systemdManager) Set(container *cgroupConfig.Config) error {
+	r := container.Cgroups.Resources
+	if r == nil {
+		return nil
+	}
+
+	props := systemdResourceProperties(r, m.unified)
+	if len(props) == 0 {
+		return nil
+	}
+
+	args := []string{
+		"call", "org.freedesktop.systemd1", "/org/freedesktop/systemd1",
+		"org.freedesktop.systemd1.Manager", "SetUnitProperties", "sba(sv)",
+		m.unitName(), "true", strconv.Itoa(len(props)),
+	}
+	for _, p := range props {
+		args = append(args, p...)
+	}
+	if err := busctl(args...); err != nil {
+		return fmt.Errorf("failed to set resources of scope %q: %v", m.unitName(), err)
+	}
+	return nil
+}
+
+// systemdResourceProperties returns the busctl arguments of the unit
+// properties setting the resource limits, one slice per property.
+func systemdResourceProperties(r *cgroupConfig.Resources, unified bool) [][]string {
+	var props [][]string
+	add := func(name, signature string, values ...string) {
+		props = append(props, append([]string{name, signature}, values...))
+	}
+
+	// Bandwidth limits are set per device node
+	bandwidth := func(name string, devices []*cgroupConfig.ThrottleDevice) {
+		if len(devices) == 0 {
+			return
+		}
+		values := []string{strconv.Itoa(len(devices))}
+		for _, d := range devices {
+			values = append(values, fmt.Sprintf("/dev/block/%d:%d", d.Major, d.Minor), strconv.FormatUint(d.Rate, 10))
+		}
+		add(name, "a(st)", values...)
+	}
+
+	if unified {
+		if r.CpuShares != 0 {
+			add("CPUWeight", "t", strconv.FormatUint(cpuSharesToWeight(uint64(r.CpuShares)), 10))
+		}
+		if r.Memory > 0 {
+			add("MemoryMax", "t", strconv.FormatInt(r.Memory, 10))
+		}
+		if r.BlkioWeight != 0 {
+			add("IOWeight", "t", strconv.FormatUint(blkioWeightToIOWeight(uint64(r.BlkioWeight)), 10))
+		}
+		bandwidth("IOReadBandwidthMax", r.BlkioThrottleReadBpsDevice)
+		bandwidth("IOWriteBandwidthMax", r.BlkioThrottleWriteBpsDevice)
+	} else {
+		if r.CpuShares != 0 {
+			add("CPUShares", "t", strconv.FormatInt(r.CpuShares, 10))
+		}
+		if r.Memory > 0 {
+			add("MemoryLimit", "t", strconv.FormatInt(r.Memory, 10))
+		}
+		if r.BlkioWeight != 0 {
+			add("BlockIOWeight", "t", strconv.FormatUint(uint64(r.BlkioWeight), 10))
+		}
+		bandwidth("BlockIOReadBandwidth", r.BlkioThrottleReadBpsDevice)
+		bandwidth("BlockIOWriteBandwidth", r.BlkioThrottleWriteBpsDevice)
+	}
+	return props
+}
+
+// pidsPath returns the path of a cgroup of the scope whose member processes
+// can be listed.
+func (m *systemdManager) pidsPath() (string, error) {
+	keys := []string{unifiedKey, "pids", "cpu", "memory"}
+	for _, key := range keys {
+		if path, ok := m.paths[key]; ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no cgroup paths known for scope %q", m.unitName())
+}
+
+// GetPids returns the pids in the scope.
+func (m *systemdManager) GetPids() ([]int, error) {
+	path, err := m.pidsPath()
+	if err != nil {
+		return nil, err
+	}
+	return cgroups.GetPids(path)
+}
+
+// GetAllPids returns the pids in the scope and any sub-cgroups delegated to
+// the task.
+func (m *systemdManager) GetAllPids() ([]int, error) {
+	path, err := m.pidsPath()
+	if err != nil {
+		return nil, err
+	}
+	return cgroups.GetAllPids(path)
+}
+
+// GetStats returns the resource usage of the scope.
+func (m *systemdManager) GetStats() (*cgroups.Stats, error) {
+	if m.unified {
+		return unifiedStats(m.paths[unifiedKey])
+	}
+	return m.fs().GetStats()
+}
+
+// Freeze freezes or thaws the processes in the scope. systemd does not manage
+// the v1 freezer hierarchy so only the v2 freezer is supported. Stopping the
+// scope kills its processes regardless, so an unavailable freezer is not an
+// error.
+func (m *systemdManager) Freeze(state cgroupConfig.FreezerState) error {
+	if !m.unified {
+		return nil
+	}
+
+	value := "0"
+	if state == cgroupConfig.Frozen {
+		value = "1"
+	}
+
+	// The v2 freezer is only available on kernels >= 5.2, and the scope is
+	// only writable by root as it is created by systemd
+	err := ioutil.WriteFile(filepath.Join(m.paths[unifiedKey], "cgroup.freeze"), []byte(value), 0644)
+	if err != nil && !os.IsNotExist(err) && !os.IsPermission(err) {
+		return err
+	}
+	return nil
+}
+
+// Destroy stops the scope, which kills any remaining processes and removes
+// its cgroups.
+func (m *systemdManager) Destroy() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	err := busctl("call", "org.freedesktop.systemd1", "/org/freedesktop/systemd1",
+		"org.freedesktop.systemd1.Manager", "StopUnit", "ss", m.unitName(), "replace")
+	if err != nil && !strings.Contains(err.Error(), "not loaded") {
+		return fmt.Errorf("failed to stop scope %q: %v", m.unitName(), err)
+	}
+
+	m.paths = make(map[string]string)
+	return nil
+}
+
+// GetPaths returns the cgroup paths of the scope.
+func (m *systemdManager) GetPaths() map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.paths
+}
+
+// busctl invokes a method on the system bus.
+func busctl(args ...string) error {
+	_, err := systemd.Busctl(args...)
+	return err
+}
+
+// cpuSharesToWeight converts cgroup v1 CPU shares, [2, 262144], to the cgroup
+// v2 CPU weight range, [1, 10000].
+func cpuSharesToWeight(shares uint64) uint64 {
+	if shares < 2 {
+		shares = 2
+	} else if shares > 262144 {
+		shares = 262144
+	}
+	return 1 + ((shares-2)*9999)/262142
+}
+
+// blkioWeightToIOWeight converts a cgroup v1 block IO weight, [10, 1000], to
+// the cgroup v2 IO weight range, [1, 10000].
+func blkioWeightToIOWeight(weight uint64) uint64 {
+	if weight < 10 {
+		weight = 10
+	} else if weight > 1000 {
+		weight = 1000
+	}
+	return 1 + ((weight-10)*9999)/990
+}
+
+// unifiedStats reads the resource usage of a cgroup v2 cgroup into the
+// libcontainer stats structure.
+func unifiedStats(path string) (*cgroups.Stats, error) {
+	stats := cgroups.NewStats()
+
+	memStat, err := readKeyValueFile(filepath.Join(path, "memory.stat"))
+	if err != nil {
+		return nil, err
+	}
+	stats.MemoryStats.Stats["rss"] = memStat["anon"]
+	stats.MemoryStats.Stats["cache"] = memStat["file"]
+	stats.MemoryStats.Cache = memStat["file"]
+
+	if v, err := readUintFile(filepath.Join(path, "memory.current")); err == nil {
+		stats.MemoryStats.Usage.Usage = v
+	}
+	if v, err := readUintFile(filepath.Join(path, "memory.peak")); err == nil {
+		stats.MemoryStats.Usage.MaxUsage = v
+	}
+	if v, err := readUintFile(filepath.Join(path, "memory.swap.current")); err == nil {
+		stats.MemoryStats.SwapUsage.Usage = v
+	}
+
+	cpuStat, err := readKeyValueFile(filepath.Join(path, "cpu.stat"))
+	if err != nil {
+		return nil, err
+	}
+
+	// cgroup v2 reports CPU time in microseconds
+	stats.CpuStats.CpuUsage.TotalUsage = cpuStat["usage_usec"] * 1000
+	stats.CpuStats.CpuUsage.UsageInUsermode = cpuStat["user_usec"] * 1000
+	stats.CpuStats.CpuUsage.UsageInKernelmode = cpuStat["system_usec"] * 1000
+	stats.CpuStats.ThrottlingData.Periods = cpuStat["nr_periods"]
+	stats.CpuStats.ThrottlingData.ThrottledPeriods = cpuStat["nr_throttled"]
+	stats.CpuStats.ThrottlingData.ThrottledTime = cpuStat["throttled_usec"] * 1000
+
+	// The io controller may not be enabled for the cgroup
+	if entries, err := readIOStat(filepath.Join(path, "io.stat")); err == nil {
+		stats.BlkioStats.IoServiceBytesRecursive = entries
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// readIOStat parses the bytes read and written per device of a cgroup v2
+// io.stat file into the format of the cgroup v1 block IO stats.
+func readIOStat(path string) ([]cgroups.BlkioStatEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []cgroups.BlkioStatEntry
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var major, minor uint64
+		if _, err := fmt.Sscanf(fields[0], "%d:%d", &major, &minor); err != nil {
+			continue
+		}
+		for _, kv := range fields[1:] {
+			parts := strings.SplitN(kv, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			var op string
+			switch parts[0] {
+			case "rbytes":
+				op = "Read"
+			case "wbytes":
+				op = "Write"
+			default:
+				continue
+			}
+			v, err := strconv.ParseUint(parts[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			entries = append(entries, cgroups.BlkioStatEntry{Major: major, Minor: minor, Op: op, Value: v})
+		}
+	}
+	return entries, s.Err()
+}
+
+// readKeyValueFile parses a flat keyed cgroup file of "key value" lines.
+func readKeyValueFile(path string) (map[string]uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]uint64)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		v, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		values[fields[0]] = v
+	}
+	return values, s.Err()
+}
+
+// readUintFile parses a cgroup file holding a single value.
+func readUintFile(path string) (uint64, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(contents)), 10, 64)
+}
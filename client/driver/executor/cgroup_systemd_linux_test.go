@@ -0,0 +1,95 @@
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	cgroupConfig "github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemdManager_UnitName(t *testing.T) {
+	t.Parallel()
+	m := newSystemdManager(&cgroupConfig.Cgroup{
+		Parent:      "nomad.slice",
+		ScopePrefix: "nomad",
+		Name:        "abc",
+	}, nil)
+	assert.Equal(t, "nomad-abc.scope", m.unitName())
+}
+
+func TestSystemdManager_WeightConversion(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	assert.EqualValues(1, cpuSharesToWeight(2))
+	assert.EqualValues(39, cpuSharesToWeight(1024))
+	assert.EqualValues(10000, cpuSharesToWeight(262144))
+	assert.EqualValues(10000, cpuSharesToWeight(1<<30))
+
+	assert.EqualValues(1, blkioWeightToIOWeight(10))
+	assert.EqualValues(5000, blkioWeightToIOWeight(505))
+	assert.EqualValues(10000, blkioWeightToIOWeight(1000))
+}
+
+func TestSystemdManager_UnifiedStats(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"memory.stat":         "anon 4096\nfile 8192\nkernel_stack 16384\n",
+		"memory.current":      "12288\n",
+		"memory.peak":         "20480\n",
+		"memory.swap.current": "0\n",
+		"cpu.stat":            "usage_usec 300\nuser_usec 200\nsystem_usec 100\nnr_periods 10\nnr_throttled 2\nthrottled_usec 50\n",
+		"io.stat":             "8:0 rbytes=1024 wbytes=2048 rios=1 wios=2 dbytes=0 dios=0\n8:16 rbytes=512 wbytes=0 rios=1 wios=0 dbytes=0 dios=0\n",
+	}
+	for name, contents := range files {
+		assert.Nil(ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644))
+	}
+
+	stats, err := unifiedStats(dir)
+	assert.Nil(err)
+	assert.EqualValues(4096, stats.MemoryStats.Stats["rss"])
+	assert.EqualValues(8192, stats.MemoryStats.Stats["cache"])
+	assert.EqualValues(12288, stats.MemoryStats.Usage.Usage)
+	assert.EqualValues(20480, stats.MemoryStats.Usage.MaxUsage)
+	assert.EqualValues(300000, stats.CpuStats.CpuUsage.TotalUsage)
+	assert.EqualValues(200000, stats.CpuStats.CpuUsage.UsageInUsermode)
+	assert.EqualValues(100000, stats.CpuStats.CpuUsage.UsageInKernelmode)
+	assert.EqualValues(2, stats.CpuStats.ThrottlingData.ThrottledPeriods)
+	assert.EqualValues(50000, stats.CpuStats.ThrottlingData.ThrottledTime)
+	assert.Equal([]cgroups.BlkioStatEntry{
+		{Major: 8, Minor: 0, Op: "Read", Value: 1024},
+		{Major: 8, Minor: 0, Op: "Write", Value: 2048},
+		{Major: 8, Minor: 16, Op: "Read", Value: 512},
+		{Major: 8, Minor: 16, Op: "Write", Value: 0},
+	}, stats.BlkioStats.IoServiceBytesRecursive)
+}
+
+func TestSystemdManager_ResourceProperties(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	r := &cgroupConfig.Resources{
+		Memory: 1024,
+		BlkioThrottleWriteBpsDevice: []*cgroupConfig.ThrottleDevice{
+			cgroupConfig.NewThrottleDevice(8, 0, 1<<20),
+		},
+	}
+	assert.Equal([][]string{
+		{"MemoryMax", "t", "1024"},
+		{"IOWriteBandwidthMax", "a(st)", "1", "/dev/block/8:0", "1048576"},
+	}, systemdResourceProperties(r, true))
+	assert.Equal([][]string{
+		{"MemoryLimit", "t", "1024"},
+		{"BlockIOWriteBandwidth", "a(st)", "1", "/dev/block/8:0", "1048576"},
+	}, systemdResourceProperties(r, false))
+}
@@ -0,0 +1,239 @@
+package executor
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	cgroupConfig "github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// unifiedControllers are the cgroup v2 controllers enabled for the task
+// cgroups, if the kernel has them.
+var unifiedControllers = []string{"cpu", "cpuset", "io", "memory", "pids"}
+
+// unifiedManager implements the libcontainer cgroup manager interface for
+// hosts that only mount the cgroup v2 unified hierarchy, by writing the
+// cgroup filesystem directly. It is used when the cgroups are not managed
+// through systemd.
+type unifiedManager struct {
+	cgroups *cgroupConfig.Cgroup
+	path    string
+	mu      sync.Mutex
+}
+
+// newUnifiedManager returns a manager for the cgroup. paths may be nil if the
+// cgroup has not been created yet.
+func newUnifiedManager(groups *cgroupConfig.Cgroup, paths map[string]string) *unifiedManager {
+	path := paths[unifiedKey]
+	if path == "" {
+		path = filepath.Join(cgroupUnifiedMountpoint, groups.Path)
+	}
+	return &unifiedManager{
+		cgroups: groups,
+		path:    path,
+	}
+}
+
+// Apply creates the cgroup and moves pid into it. The controllers are
+// enabled in the parents of the cgroup, as cgroup v2 only lets a cgroup use
+// the controllers its parent delegates to it.
+func (m *unifiedManager) Apply(pid int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.path != cgroupUnifiedMountpoint {
+		if err := enableUnifiedControllers(cgroupUnifiedMountpoint, filepath.Dir(m.path)); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(m.path, 0755); err != nil {
+			return err
+		}
+	}
+	return writeCgroupFile(m.path, "cgroup.procs", strconv.Itoa(pid))
+}
+
+// enableUnifiedControllers creates the cgroups from root down to dir and
+// enables the available controllers for the children of each.
+func enableUnifiedControllers(root, dir string) error {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return err
+	}
+
+	path := root
+	parts := []string{""}
+	if rel != "." {
+		parts = append(parts, strings.Split(rel, string(filepath.Separator))...)
+	}
+	for _, part := range parts {
+		path = filepath.Join(path, part)
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return err
+		}
+
+		available, err := ioutil.ReadFile(filepath.Join(path, "cgroup.controllers"))
+		if err != nil {
+			return err
+		}
+		var enable []string
+		for _, c := range strings.Fields(string(available)) {
+			for _, want := range unifiedControllers {
+				if c == want {
+					enable = append(enable, "+"+c)
+				}
+			}
+		}
+		if len(enable) == 0 {
+			continue
+		}
+		if err := writeCgroupFile(path, "cgroup.subtree_control", strings.Join(enable, " ")); err != nil {
+			return fmt.Errorf("failed to enable controllers of %q: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// Set writes the resource limits of the cgroup.
+func (m *unifiedManager) Set(container *cgroupConfig.Config) error {
+	r := container.Cgroups.Resources
+	if r == nil {
+		return nil
+	}
+
+	if r.CpuShares != 0 {
+		if err := writeCgroupFile(m.path, "cpu.weight", strconv.FormatUint(cpuSharesToWeight(uint64(r.CpuShares)), 10)); err != nil {
+			return err
+		}
+	}
+	if r.Memory > 0 {
+		if err := writeCgroupFile(m.path, "memory.max", strconv.FormatInt(r.Memory, 10)); err != nil {
+			return err
+		}
+	}
+	if r.BlkioWeight != 0 {
+		weight := strconv.FormatUint(blkioWeightToIOWeight(uint64(r.BlkioWeight)), 10)
+		if err := writeCgroupFile(m.path, "io.weight", "default "+weight); err != nil {
+			return err
+		}
+	}
+	for _, limit := range unifiedIOMax(r) {
+		if err := writeCgroupFile(m.path, "io.max", limit); err != nil {
+			return err
+		}
+	}
+	if r.CpusetCpus != "" {
+		if err := writeCgroupFile(m.path, "cpuset.cpus", r.CpusetCpus); err != nil {
+			return err
+		}
+	}
+	if r.CpusetMems != "" {
+		if err := writeCgroupFile(m.path, "cpuset.mems", r.CpusetMems); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unifiedIOMax returns the io.max lines limiting the bandwidth of each
+// throttled device.
+func unifiedIOMax(r *cgroupConfig.Resources) []string {
+	limits := make(map[string][]string)
+	var devices []string
+	add := func(key string, throttled []*cgroupConfig.ThrottleDevice) {
+		for _, d := range throttled {
+			dev := fmt.Sprintf("%d:%d", d.Major, d.Minor)
+			if _, ok := limits[dev]; !ok {
+				devices = append(devices, dev)
+			}
+			limits[dev] = append(limits[dev], fmt.Sprintf("%s=%d", key, d.Rate))
+		}
+	}
+	add("rbps", r.BlkioThrottleReadBpsDevice)
+	add("wbps", r.BlkioThrottleWriteBpsDevice)
+
+	lines := make([]string, len(devices))
+	for i, dev := range devices {
+		lines[i] = dev + " " + strings.Join(limits[dev], " ")
+	}
+	return lines
+}
+
+// GetPids returns the pids in the cgroup.
+func (m *unifiedManager) GetPids() ([]int, error) {
+	return cgroups.GetPids(m.path)
+}
+
+// GetAllPids returns the pids in the cgroup and its sub-cgroups.
+func (m *unifiedManager) GetAllPids() ([]int, error) {
+	return cgroups.GetAllPids(m.path)
+}
+
+// GetStats returns the resource usage of the cgroup.
+func (m *unifiedManager) GetStats() (*cgroups.Stats, error) {
+	return unifiedStats(m.path)
+}
+
+// Freeze freezes or thaws the processes in the cgroup. The v2 freezer is
+// only available on kernels >= 5.2, so an unavailable freezer is not an
+// error.
+func (m *unifiedManager) Freeze(state cgroupConfig.FreezerState) error {
+	value := "0"
+	if state == cgroupConfig.Frozen {
+		value = "1"
+	}
+
+	err := writeCgroupFile(m.path, "cgroup.freeze", value)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Destroy removes the cgroup, including any sub-cgroups created by the task.
+// The processes in the cgroup must have exited.
+func (m *unifiedManager) Destroy() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.path == cgroupUnifiedMountpoint {
+		return nil
+	}
+
+	var dirs []string
+	err := filepath.Walk(m.path, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	// Remove the deepest cgroups first
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Remove(dirs[i]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPaths returns the path of the cgroup.
+func (m *unifiedManager) GetPaths() map[string]string {
+	return map[string]string{unifiedKey: m.path}
+}
+
+// writeCgroupFile writes the value to a file of the cgroup at path.
+func writeCgroupFile(path, file, value string) error {
+	return ioutil.WriteFile(filepath.Join(path, file), []byte(value), 0644)
+}
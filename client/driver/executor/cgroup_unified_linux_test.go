@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cgroupConfig "github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedManager_EnableControllers(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	root, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(root)
+
+	// The controllers available are listed by the kernel for each cgroup
+	assert.Nil(ioutil.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpuset cpu io memory hugetlb pids rdma\n"), 0644))
+	assert.Nil(os.MkdirAll(filepath.Join(root, "nomad"), 0755))
+	assert.Nil(ioutil.WriteFile(filepath.Join(root, "nomad", "cgroup.controllers"), []byte("cpu memory\n"), 0644))
+
+	assert.Nil(enableUnifiedControllers(root, filepath.Join(root, "nomad")))
+
+	buf, err := ioutil.ReadFile(filepath.Join(root, "cgroup.subtree_control"))
+	assert.Nil(err)
+	assert.Equal("+cpuset +cpu +io +memory +pids", string(buf))
+	buf, err = ioutil.ReadFile(filepath.Join(root, "nomad", "cgroup.subtree_control"))
+	assert.Nil(err)
+	assert.Equal("+cpu +memory", string(buf))
+}
+
+func TestUnifiedManager_SetDestroy(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	root, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(root)
+
+	path := filepath.Join(root, "nomad", "abc")
+	assert.Nil(os.MkdirAll(filepath.Join(path, "sub"), 0755))
+
+	groups := &cgroupConfig.Cgroup{
+		Path: "/nomad/abc",
+		Resources: &cgroupConfig.Resources{
+			CpuShares:   1024,
+			Memory:      256 * 1024 * 1024,
+			BlkioWeight: 505,
+			CpusetCpus:  "2,3",
+			CpusetMems:  "1",
+			BlkioThrottleReadBpsDevice: []*cgroupConfig.ThrottleDevice{
+				cgroupConfig.NewThrottleDevice(8, 0, 1<<20),
+			},
+			BlkioThrottleWriteBpsDevice: []*cgroupConfig.ThrottleDevice{
+				cgroupConfig.NewThrottleDevice(8, 0, 2<<20),
+			},
+		},
+	}
+	m := newUnifiedManager(groups, map[string]string{unifiedKey: path})
+	assert.Equal(map[string]string{unifiedKey: path}, m.GetPaths())
+	assert.Nil(m.Set(&cgroupConfig.Config{Cgroups: groups}))
+
+	expected := map[string]string{
+		"cpu.weight":  "39",
+		"memory.max":  "268435456",
+		"io.weight":   "default 5000",
+		"cpuset.cpus": "2,3",
+		"cpuset.mems": "1",
+		"io.max":      "8:0 rbps=1048576 wbps=2097152",
+	}
+	for file, value := range expected {
+		buf, err := ioutil.ReadFile(filepath.Join(path, file))
+		assert.Nil(err)
+		assert.Equal(value, string(buf), file)
+	}
+
+	// Destroy only removes directories, so empty the test cgroup first
+	for file := range expected {
+		assert.Nil(os.Remove(filepath.Join(path, file)))
+	}
+	assert.Nil(m.Destroy())
+	_, err = os.Stat(path)
+	assert.True(os.IsNotExist(err))
+}
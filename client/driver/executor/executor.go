@@ -3,6 +3,7 @@ package executor
 import (
 	"context"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net"
@@ -25,6 +26,7 @@ import (
 	"github.com/hashicorp/nomad/client/driver/env"
 	"github.com/hashicorp/nomad/client/driver/logging"
 	"github.com/hashicorp/nomad/client/stats"
+	"github.com/hashicorp/nomad/helper/capabilities"
 	shelpers "github.com/hashicorp/nomad/helper/stats"
 	"github.com/hashicorp/nomad/nomad/structs"
 
@@ -110,6 +112,21 @@ type ExecCommand struct {
 	// ResourceLimits determines whether resource limits are enforced by the
 	// executor.
 	ResourceLimits bool
+
+	// CgroupParent is the systemd slice to create the task's cgroup under. If
+	// empty the cgroup is managed directly through the cgroup filesystem.
+	CgroupParent string
+
+	// NetworkIsolation determines whether the command is run in a network
+	// namespace of its own, so that devices can be attached to it.
+	NetworkIsolation bool
+
+	// DropCapabilities are the capabilities dropped from the bounding set of
+	// the task, which limits those its processes can gain.
+	DropCapabilities capabilities.Set
+
+	// SeccompProfile restricts the syscalls of the task if set.
+	SeccompProfile *SeccompProfile
 }
 
 // ProcessState holds information about the state of a user process.
@@ -163,14 +180,24 @@ type UniversalExecutor struct {
 	lro         *logging.FileRotator
 	rotatorLock sync.Mutex
 
+	// lse and lso ship the stderr and stdout of the task to its log sinks
+	lse *logging.LogShipper
+	lso *logging.LogShipper
+
 	syslogServer *logging.SyslogServer
 	syslogChan   chan *logging.SyslogMessage
 
 	resConCtx resourceContainerContext
 
+	// restrictions are the capability and syscall restrictions of the task,
+	// nil if it has none
+	restrictions *taskRestrictions
+
 	totalCpuStats  *stats.CpuStats
 	userCpuStats   *stats.CpuStats
 	systemCpuStats *stats.CpuStats
+	diskReadRate   *stats.ByteRate
+	diskWriteRate  *stats.ByteRate
 	logger         *log.Logger
 }
 
@@ -186,6 +213,8 @@ func NewExecutor(logger *log.Logger) Executor {
 		totalCpuStats:  stats.NewCpuStats(),
 		userCpuStats:   stats.NewCpuStats(),
 		systemCpuStats: stats.NewCpuStats(),
+		diskReadRate:   stats.NewByteRate(),
+		diskWriteRate:  stats.NewByteRate(),
 		pids:           make(map[int]*nomadPid),
 	}
 
@@ -244,8 +273,8 @@ func (e *UniversalExecutor) LaunchCmd(command *ExecCommand) (*ProcessState, erro
 	if err := e.configureLoggers(); err != nil {
 		return nil, err
 	}
-	e.cmd.Stdout = e.lro
-	e.cmd.Stderr = e.lre
+	e.cmd.Stdout = io.MultiWriter(e.lro, e.lso)
+	e.cmd.Stderr = io.MultiWriter(e.lre, e.lse)
 
 	// Look up the binary path and make it executable
 	absPath, err := e.lookupBin(e.ctx.TaskEnv.ReplaceEnv(command.Cmd))
@@ -274,7 +303,7 @@ func (e *UniversalExecutor) LaunchCmd(command *ExecCommand) (*ProcessState, erro
 	e.cmd.Env = e.ctx.TaskEnv.List()
 
 	// Start the process
-	if err := e.cmd.Start(); err != nil {
+	if err := e.startCmd(&e.cmd); err != nil {
 		return nil, fmt.Errorf("failed to start command path=%q --- args=%q: %v", path, e.cmd.Args, err)
 	}
 	go e.collectPids()
@@ -287,13 +316,19 @@ func (e *UniversalExecutor) LaunchCmd(command *ExecCommand) (*ProcessState, erro
 func (e *UniversalExecutor) Exec(deadline time.Time, name string, args []string) ([]byte, int, error) {
 	ctx, cancel := context.WithDeadline(context.Background(), deadline)
 	defer cancel()
-	return ExecScript(ctx, e.cmd.Dir, e.ctx.TaskEnv, e.cmd.SysProcAttr, name, args)
+	return execScript(ctx, e.cmd.Dir, e.ctx.TaskEnv, e.execAttrs(), e.startCmd, name, args)
 }
 
 // ExecScript executes cmd with args and returns the output, exit code, and
 // error. Output is truncated to client/driver/structs.CheckBufSize
 func ExecScript(ctx context.Context, dir string, env *env.TaskEnv, attrs *syscall.SysProcAttr,
 	name string, args []string) ([]byte, int, error) {
+	return execScript(ctx, dir, env, attrs, (*exec.Cmd).Start, name, args)
+}
+
+// execScript executes cmd with args as ExecScript, starting it with start.
+func execScript(ctx context.Context, dir string, env *env.TaskEnv, attrs *syscall.SysProcAttr,
+	start func(*exec.Cmd) error, name string, args []string) ([]byte, int, error) {
 	name = env.ReplaceEnv(name)
 	cmd := exec.CommandContext(ctx, name, env.ParseAndReplace(args)...)
 
@@ -307,7 +342,11 @@ func ExecScript(ctx context.Context, dir string, env *env.TaskEnv, attrs *syscal
 	cmd.Stdout = buf
 	cmd.Stderr = buf
 
-	if err := cmd.Run(); err != nil {
+	err := start(cmd)
+	if err == nil {
+		err = cmd.Wait()
+	}
+	if err != nil {
 		exitErr, ok := err.(*exec.ExitError)
 		if !ok {
 			// Non-exit error, return it and let the caller treat
@@ -351,7 +390,40 @@ func (e *UniversalExecutor) configureLoggers() error {
 		}
 		e.lre = lre
 	}
-	return nil
+
+	if e.lso == nil {
+		e.lso = logging.NewLogShipper(e.streamMeta("stdout"), e.logger)
+	}
+	if e.lse == nil {
+		e.lse = logging.NewLogShipper(e.streamMeta("stderr"), e.logger)
+	}
+	return e.setLogSinks(e.ctx.Task.LogConfig.Sinks)
+}
+
+// streamMeta returns the metadata of a log stream of the task shipped to the
+// log sinks.
+func (e *UniversalExecutor) streamMeta(stream string) logging.StreamMeta {
+	meta := logging.StreamMeta{
+		TaskName: e.ctx.Task.Name,
+		Stream:   stream,
+	}
+	if e.ctx.TaskEnv != nil {
+		envMap := e.ctx.TaskEnv.EnvMap
+		meta.AllocID = envMap[env.AllocID]
+		meta.JobName = envMap[env.JobName]
+	}
+	return meta
+}
+
+// setLogSinks sets the sinks the output of the task is shipped to.
+func (e *UniversalExecutor) setLogSinks(sinks []*structs.LogSink) error {
+	if e.lso == nil || e.lse == nil {
+		return fmt.Errorf("log shippers don't exist")
+	}
+	if err := e.lso.SetSinks(sinks); err != nil {
+		return err
+	}
+	return e.lse.SetSinks(sinks)
 }
 
 // Wait waits until a process has exited and returns it's exitcode and errors
@@ -375,7 +447,7 @@ func (e *UniversalExecutor) UpdateLogConfig(logConfig *structs.LogConfig) error
 	}
 	e.lre.MaxFiles = logConfig.MaxFiles
 	e.lre.FileSize = int64(logConfig.MaxFileSizeMB * 1024 * 1024)
-	return nil
+	return e.setLogSinks(logConfig.Sinks)
 }
 
 func (e *UniversalExecutor) UpdateTask(task *structs.Task) error {
@@ -391,12 +463,21 @@ func (e *UniversalExecutor) UpdateTask(task *structs.Task) error {
 		e.lre.FileSize = fileSize
 	}
 	e.rotatorLock.Unlock()
+
+	// Updating the log sinks
+	if e.lso != nil && e.lse != nil {
+		return e.setLogSinks(task.LogConfig.Sinks)
+	}
 	return nil
 }
 
 func (e *UniversalExecutor) wait() {
 	defer close(e.processExited)
 	err := e.cmd.Wait()
+
+	// Ship the output left before the exit is reported
+	e.lso.Close()
+	e.lse.Close()
 	ic := e.resConCtx.getIsolationConfig()
 	if err == nil {
 		e.exitState = &ProcessState{Pid: 0, ExitCode: 0, IsolationConfig: ic, Time: time.Now()}
@@ -459,6 +540,14 @@ func (e *UniversalExecutor) Exit() error {
 		e.lro.Close()
 	}
 
+	if e.lse != nil {
+		e.lse.Close()
+	}
+
+	if e.lso != nil {
+		e.lso.Close()
+	}
+
 	// If the executor did not launch a process, return.
 	if e.command == nil {
 		return nil
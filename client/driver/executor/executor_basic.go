@@ -1,9 +1,12 @@
-// +build darwin dragonfly freebsd netbsd openbsd solaris windows
+// +build darwin dragonfly freebsd netbsd openbsd solaris
 
 package executor
 
 import (
+	"fmt"
 	"os"
+	"os/exec"
+	"syscall"
 
 	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/mitchellh/go-ps"
@@ -25,10 +28,34 @@ func (e *UniversalExecutor) applyLimits(pid int) error {
 	return nil
 }
 
+// BlockDevice returns the device number of the disk the path is on. Disk IO
+// is only limited on Linux.
+func BlockDevice(path string) (major, minor int64, err error) {
+	return 0, 0, fmt.Errorf("disk IO limits are only supported on Linux")
+}
+
 func (e *UniversalExecutor) configureIsolation() error {
+	if e.command.NetworkIsolation {
+		return fmt.Errorf("network isolation is only supported on Linux")
+	}
+	if e.command.DropCapabilities != 0 || e.command.SeccompProfile != nil {
+		return fmt.Errorf("capability and seccomp restrictions are only supported on Linux")
+	}
 	return nil
 }
 
+// taskRestrictions are the restrictions of the capabilities and syscalls of
+// the processes of a task, which are only supported on Linux.
+type taskRestrictions struct{}
+
+func (e *UniversalExecutor) startCmd(cmd *exec.Cmd) error {
+	return cmd.Start()
+}
+
+func (e *UniversalExecutor) execAttrs() *syscall.SysProcAttr {
+	return e.cmd.SysProcAttr
+}
+
 func (e *UniversalExecutor) Stats() (*cstructs.TaskResourceUsage, error) {
 	pidStats, err := e.pidStats()
 	if err != nil {
@@ -2,9 +2,12 @@ package executor
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"os/user"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"syscall"
@@ -15,17 +18,22 @@ import (
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	cgroupFs "github.com/opencontainers/runc/libcontainer/cgroups/fs"
 	cgroupConfig "github.com/opencontainers/runc/libcontainer/configs"
+	"golang.org/x/sys/unix"
 
 	"github.com/hashicorp/nomad/client/stats"
 	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper/capabilities"
+	"github.com/hashicorp/nomad/helper/cgroupv2"
+	"github.com/hashicorp/nomad/helper/numa"
 	"github.com/hashicorp/nomad/helper/uuid"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
 var (
 	// The statistics the executor exposes when using cgroups
-	ExecutorCgroupMeasuredMemStats = []string{"RSS", "Cache", "Swap", "Max Usage", "Kernel Usage", "Kernel Max Usage"}
-	ExecutorCgroupMeasuredCpuStats = []string{"System Mode", "User Mode", "Throttled Periods", "Throttled Time", "Percent"}
+	ExecutorCgroupMeasuredMemStats  = []string{"RSS", "Cache", "Swap", "Max Usage", "Kernel Usage", "Kernel Max Usage"}
+	ExecutorCgroupMeasuredCpuStats  = []string{"System Mode", "User Mode", "Throttled Periods", "Throttled Time", "Percent"}
+	ExecutorCgroupMeasuredDiskStats = []string{"Read Bytes", "Write Bytes", "Read Rate", "Write Rate"}
 )
 
 // configureIsolation configures chroot and creates cgroups
@@ -41,9 +49,103 @@ func (e *UniversalExecutor) configureIsolation() error {
 			return fmt.Errorf("error creating cgroups: %v", err)
 		}
 	}
+
+	if e.command.NetworkIsolation {
+		if e.cmd.SysProcAttr == nil {
+			e.cmd.SysProcAttr = &syscall.SysProcAttr{}
+		}
+		e.cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+
+	if err := e.configureRestrictions(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// taskRestrictions are the restrictions of the capabilities and syscalls of
+// the processes of a task.
+type taskRestrictions struct {
+	// caps is the capability bounding set of the task
+	caps capabilities.Set
+
+	// seccompFilter is the seccomp filter of the task, if it has a profile
+	seccompFilter []unix.SockFilter
+}
+
+// configureRestrictions compiles the capability bounding set and seccomp
+// profile of the task.
+func (e *UniversalExecutor) configureRestrictions() error {
+	if e.command.DropCapabilities == 0 && e.command.SeccompProfile == nil {
+		return nil
+	}
+
+	r := &taskRestrictions{caps: capabilities.All &^ e.command.DropCapabilities}
+	if r.caps != capabilities.All {
+		e.logger.Printf("[DEBUG] executor: bounding capabilities of the task to %v", r.caps&capabilities.Known)
+	}
+
+	if p := e.command.SeccompProfile; p != nil {
+		filter, unknown, err := compileSeccomp(p, r.caps)
+		if err != nil {
+			return fmt.Errorf("failed to compile seccomp profile: %v", err)
+		}
+		if len(unknown) != 0 {
+			e.logger.Printf("[DEBUG] executor: skipping syscalls of the seccomp profile unknown on %s: %s",
+				runtime.GOARCH, strings.Join(unknown, ", "))
+		}
+		r.seccompFilter = filter
+	}
+
+	e.restrictions = r
 	return nil
 }
 
+// startCmd starts a process of the task. The capability bounding set and
+// seccomp filter are attributes of the thread forking the process, so it is
+// forked from a thread which is restricted for the task and then terminated
+// rather than returned to the runtime. The seccomp profile must allow the
+// syscalls starting the process, such as those changing its root and user.
+func (e *UniversalExecutor) startCmd(cmd *exec.Cmd) error {
+	r := e.restrictions
+	if r == nil {
+		return cmd.Start()
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		// The thread isn't unlocked so it exits with the goroutine
+		runtime.LockOSThread()
+
+		if r.caps != capabilities.All {
+			if err := dropBoundingCaps(r.caps); err != nil {
+				errCh <- err
+				return
+			}
+		}
+		if r.seccompFilter != nil {
+			if err := installSeccomp(r.seccompFilter); err != nil {
+				errCh <- err
+				return
+			}
+		}
+		errCh <- cmd.Start()
+	}()
+	return <-errCh
+}
+
+// execAttrs returns the process attributes of commands executed in the
+// task's context. The namespaces of the task are not entered, so the commands
+// run in the host's network namespace.
+func (e *UniversalExecutor) execAttrs() *syscall.SysProcAttr {
+	if e.cmd.SysProcAttr == nil {
+		return nil
+	}
+	attrs := *e.cmd.SysProcAttr
+	attrs.Cloneflags = 0
+	return &attrs
+}
+
 // applyLimits puts a process in a pre-configured cgroup
 func (e *UniversalExecutor) applyLimits(pid int) error {
 	if !e.command.ResourceLimits {
@@ -74,7 +176,14 @@ func (e *UniversalExecutor) configureCgroups(resources *structs.Resources) error
 	e.resConCtx.groups = &cgroupConfig.Cgroup{}
 	e.resConCtx.groups.Resources = &cgroupConfig.Resources{}
 	cgroupName := uuid.Generate()
-	e.resConCtx.groups.Path = filepath.Join("/nomad", cgroupName)
+	if slice := e.command.CgroupParent; slice != "" {
+		// Let systemd create the cgroup as a scope under the slice
+		e.resConCtx.groups.Parent = slice
+		e.resConCtx.groups.ScopePrefix = systemdScopePrefix
+		e.resConCtx.groups.Name = cgroupName
+	} else {
+		e.resConCtx.groups.Path = filepath.Join("/nomad", cgroupName)
+	}
 
 	// TODO: verify this is needed for things like network access
 	e.resConCtx.groups.Resources.AllowAllDevices = true
@@ -93,7 +202,20 @@ func (e *UniversalExecutor) configureCgroups(resources *structs.Resources) error
 	// Set the relative CPU shares for this cgroup.
 	e.resConCtx.groups.Resources.CpuShares = int64(resources.CPU)
 
-	if resources.IOPS != 0 {
+	// Pin the task to the cores assigned to it, and its memory to their NUMA
+	// nodes
+	if len(resources.CoreIDs) != 0 {
+		e.resConCtx.groups.Resources.CpusetCpus = structs.CoreIDsString(resources.CoreIDs)
+		if topology, err := numa.Topology(); err != nil {
+			e.logger.Printf("[WARN] executor: failed to read NUMA topology: %v", err)
+		} else {
+			e.resConCtx.groups.Resources.CpusetMems = numa.Mems(topology, resources.CoreIDs, resources.MemoryMB)
+		}
+	}
+
+	if resources.IOWeight != 0 {
+		e.resConCtx.groups.Resources.BlkioWeight = uint16(resources.IOWeight)
+	} else if resources.IOPS != 0 {
 		// Validate it is in an acceptable range.
 		if resources.IOPS < 10 || resources.IOPS > 1000 {
 			return fmt.Errorf("resources.IOPS must be between 10 and 1000: %d", resources.IOPS)
@@ -102,9 +224,51 @@ func (e *UniversalExecutor) configureCgroups(resources *structs.Resources) error
 		e.resConCtx.groups.Resources.BlkioWeight = uint16(resources.IOPS)
 	}
 
+	// Limit the bandwidth of the disk the task directory is on
+	if resources.IOReadMBps != 0 || resources.IOWriteMBps != 0 {
+		major, minor, err := BlockDevice(e.ctx.TaskDir)
+		if err != nil {
+			return fmt.Errorf("failed to find the disk of the task directory: %v", err)
+		}
+		if mbps := resources.IOReadMBps; mbps != 0 {
+			e.resConCtx.groups.Resources.BlkioThrottleReadBpsDevice = []*cgroupConfig.ThrottleDevice{
+				cgroupConfig.NewThrottleDevice(major, minor, uint64(mbps)*structs.BytesInMegabyte),
+			}
+		}
+		if mbps := resources.IOWriteMBps; mbps != 0 {
+			e.resConCtx.groups.Resources.BlkioThrottleWriteBpsDevice = []*cgroupConfig.ThrottleDevice{
+				cgroupConfig.NewThrottleDevice(major, minor, uint64(mbps)*structs.BytesInMegabyte),
+			}
+		}
+	}
+
 	return nil
 }
 
+// BlockDevice returns the device number of the disk the path is on. The
+// disk of a partition is returned, as IO is only throttled on whole disks.
+func BlockDevice(path string) (major, minor int64, err error) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return 0, 0, err
+	}
+	dev := fmt.Sprintf("%d:%d", unix.Major(uint64(st.Dev)), unix.Minor(uint64(st.Dev)))
+
+	sysfs := filepath.Join("/sys/dev/block", dev)
+	if _, err := os.Stat(filepath.Join(sysfs, "partition")); err == nil {
+		buf, err := ioutil.ReadFile(filepath.Join(sysfs, "..", "dev"))
+		if err != nil {
+			return 0, 0, err
+		}
+		dev = strings.TrimSpace(string(buf))
+	}
+
+	if _, err := fmt.Sscanf(dev, "%d:%d", &major, &minor); err != nil {
+		return 0, 0, fmt.Errorf("invalid device %q: %v", dev, err)
+	}
+	return major, minor, nil
+}
+
 // Stats reports the resource utilization of the cgroup. If there is no resource
 // isolation we aggregate the resource utilization of all the pids launched by
 // the executor.
@@ -138,6 +302,24 @@ func (e *UniversalExecutor) Stats() (*cstructs.TaskResourceUsage, error) {
 		Measured:       ExecutorCgroupMeasuredMemStats,
 	}
 
+	// Disk Related Stats
+	var read, write uint64
+	for _, entry := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch entry.Op {
+		case "Read":
+			read += entry.Value
+		case "Write":
+			write += entry.Value
+		}
+	}
+	ds := &cstructs.DiskStats{
+		ReadBytes:  read,
+		WriteBytes: write,
+		ReadRate:   e.diskReadRate.Rate(read),
+		WriteRate:  e.diskWriteRate.Rate(write),
+		Measured:   ExecutorCgroupMeasuredDiskStats,
+	}
+
 	// CPU Related Stats
 	totalProcessCPUUsage := float64(stats.CpuStats.CpuUsage.TotalUsage)
 	userModeTime := float64(stats.CpuStats.CpuUsage.UsageInUsermode)
@@ -157,6 +339,7 @@ func (e *UniversalExecutor) Stats() (*cstructs.TaskResourceUsage, error) {
 		ResourceUsage: &cstructs.ResourceUsage{
 			MemoryStats: ms,
 			CpuStats:    cs,
+			DiskStats:   ds,
 		},
 		Timestamp: ts.UTC().UnixNano(),
 	}
@@ -171,7 +354,15 @@ func (e *UniversalExecutor) Stats() (*cstructs.TaskResourceUsage, error) {
 func (e *UniversalExecutor) runAs(userid string) error {
 	u, err := user.Lookup(userid)
 	if err != nil {
-		return fmt.Errorf("Failed to identify user %v: %v", userid, err)
+		// Dynamic users are given by UID and aren't in the user database
+		uid, perr := strconv.ParseUint(userid, 10, 32)
+		if perr != nil {
+			return fmt.Errorf("Failed to identify user %v: %v", userid, err)
+		}
+		if u, err = user.LookupId(userid); err != nil {
+			e.setCredential(uint32(uid), uint32(uid), nil)
+			return nil
+		}
 	}
 
 	// Get the groups the user is a part of
@@ -200,20 +391,23 @@ func (e *UniversalExecutor) runAs(userid string) error {
 		return fmt.Errorf("Unable to convert groupid to uint32: %s", err)
 	}
 
-	// Set the command to run as that user and group.
+	e.setCredential(uint32(uid), uint32(gid), gids)
+	return nil
+}
+
+// setCredential sets the command to run as the user and group.
+func (e *UniversalExecutor) setCredential(uid, gid uint32, gids []uint32) {
 	if e.cmd.SysProcAttr == nil {
 		e.cmd.SysProcAttr = &syscall.SysProcAttr{}
 	}
 	if e.cmd.SysProcAttr.Credential == nil {
 		e.cmd.SysProcAttr.Credential = &syscall.Credential{}
 	}
-	e.cmd.SysProcAttr.Credential.Uid = uint32(uid)
-	e.cmd.SysProcAttr.Credential.Gid = uint32(gid)
+	e.cmd.SysProcAttr.Credential.Uid = uid
+	e.cmd.SysProcAttr.Credential.Gid = gid
 	e.cmd.SysProcAttr.Credential.Groups = gids
 
 	e.logger.Printf("[DEBUG] executor: running as user:group %d:%d with group membership in %v", uid, gid, gids)
-
-	return nil
 }
 
 // configureChroot configures a chroot
@@ -266,19 +460,22 @@ func DestroyCgroup(groups *cgroupConfig.Cgroup, cgPaths map[string]string, execu
 	}
 
 	// Move the executor into the global cgroup so that the task specific
-	// cgroup can be destroyed.
-	nilGroup := &cgroupConfig.Cgroup{}
-	nilGroup.Path = "/"
-	nilGroup.Resources = groups.Resources
-	nilManager := getCgroupManager(nilGroup, nil)
-	err := nilManager.Apply(executorPid)
-	if err != nil && !strings.Contains(err.Error(), "no such process") {
-		return fmt.Errorf("failed to remove executor pid %d: %v", executorPid, err)
+	// cgroup can be destroyed. Only the task is placed in systemd scopes so
+	// there is nothing to move.
+	if groups.Parent == "" {
+		nilGroup := &cgroupConfig.Cgroup{}
+		nilGroup.Path = "/"
+		nilGroup.Resources = groups.Resources
+		nilManager := getCgroupManager(nilGroup, nil)
+		err := nilManager.Apply(executorPid)
+		if err != nil && !strings.Contains(err.Error(), "no such process") {
+			return fmt.Errorf("failed to remove executor pid %d: %v", executorPid, err)
+		}
 	}
 
 	// Freeze the Cgroup so that it can not continue to fork/exec.
 	manager := getCgroupManager(groups, cgPaths)
-	err = manager.Freeze(cgroupConfig.Frozen)
+	err := manager.Freeze(cgroupConfig.Frozen)
 	if err != nil && !strings.Contains(err.Error(), "no such file or directory") {
 		return fmt.Errorf("failed to freeze cgroup: %v", err)
 	}
@@ -330,7 +527,15 @@ func DestroyCgroup(groups *cgroupConfig.Cgroup, cgPaths map[string]string, execu
 	return mErrs.ErrorOrNil()
 }
 
-// getCgroupManager returns the correct libcontainer cgroup manager.
+// getCgroupManager returns the correct libcontainer cgroup manager. Cgroups
+// with a parent slice are managed through systemd, and the others through the
+// cgroup filesystem of the hierarchy the host mounts.
 func getCgroupManager(groups *cgroupConfig.Cgroup, paths map[string]string) cgroups.Manager {
+	if groups.Parent != "" {
+		return newSystemdManager(groups, paths)
+	}
+	if cgroupv2.IsUnifiedMode() {
+		return newUnifiedManager(groups, paths)
+	}
 	return &cgroupFs.Manager{Cgroups: groups, Paths: paths}
 }
@@ -15,6 +15,7 @@ import (
 	dstructs "github.com/hashicorp/nomad/client/driver/structs"
 	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/client/testutil"
+	"github.com/hashicorp/nomad/helper/capabilities"
 	"github.com/hashicorp/nomad/nomad/mock"
 )
 
@@ -190,3 +191,64 @@ func TestExecutor_ClientCleanup(t *testing.T) {
 		t.Fatalf("Expected size: %v, actual: %v", finfo.Size(), finfo1.Size())
 	}
 }
+
+func TestExecutor_Restrictions(t *testing.T) {
+	t.Parallel()
+	testutil.ExecCompatible(t)
+
+	ctx, allocDir := testExecutorContext(t)
+	defer allocDir.Destroy()
+
+	profile, err := ParseSeccompProfile([]byte(`{
+		"defaultAction": "SCMP_ACT_ALLOW",
+		"syscalls": [{"names": ["mkdir", "mkdirat"], "action": "SCMP_ACT_ERRNO"}]
+	}`))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	execCmd := ExecCommand{
+		Cmd:              "/bin/sh",
+		Args:             []string{"-c", "grep CapBnd /proc/self/status; mkdir denied"},
+		DropCapabilities: capabilities.All &^ capabilities.NewSet(capabilities.Chown, capabilities.Kill),
+		SeccompProfile:   profile,
+	}
+
+	executor := NewExecutor(log.New(os.Stdout, "", log.LstdFlags))
+	if err := executor.SetContext(ctx); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := executor.LaunchCmd(&execCmd); err != nil {
+		t.Fatalf("error in launching command: %v", err)
+	}
+	ps, err := executor.Wait()
+	if err != nil {
+		t.Fatalf("error in waiting for command: %v", err)
+	}
+	if ps.ExitCode == 0 {
+		t.Fatalf("expected mkdir to fail")
+	}
+
+	output, err := ioutil.ReadFile(filepath.Join(ctx.LogDir, "web.stdout.0"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if act := strings.TrimSpace(string(output)); act != "CapBnd:\t0000000000000021" {
+		t.Fatalf("unexpected bounding set: %q", act)
+	}
+	if _, err := os.Stat(filepath.Join(ctx.TaskDir, "denied")); !os.IsNotExist(err) {
+		t.Fatalf("directory created despite the seccomp profile: %v", err)
+	}
+
+	// Commands executed in the task's context are restricted
+	out, code, err := executor.Exec(time.Now().Add(5*time.Second), "/bin/mkdir", []string{"denied"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if code == 0 || !strings.Contains(string(out), "not permitted") {
+		t.Fatalf("unexpected exec result %d: %s", code, out)
+	}
+
+	if err := executor.Exit(); err != nil {
+		t.Fatalf("error: %v", err)
+	}
+}
@@ -29,7 +29,7 @@ func (e *UniversalExecutor) LaunchSyslogServer() (*SyslogServerState, error) {
 
 	e.syslogServer = logging.NewSyslogServer(l, e.syslogChan, e.logger)
 	go e.syslogServer.Start()
-	go e.collectLogs(e.lre, e.lro)
+	go e.collectLogs(io.MultiWriter(e.lre, e.lse), io.MultiWriter(e.lro, e.lso))
 	syslogAddr := fmt.Sprintf("%s://%s", l.Addr().Network(), l.Addr().String())
 	return &SyslogServerState{Addr: syslogAddr}, nil
 }
@@ -39,11 +39,11 @@ func (e *UniversalExecutor) collectLogs(we io.Writer, wo io.Writer) {
 		// If the severity of the log line is err then we write to stderr
 		// otherwise all messages go to stdout
 		if logParts.Severity == syslog.LOG_ERR {
-			e.lre.Write(logParts.Message)
-			e.lre.Write([]byte{'\n'})
+			we.Write(logParts.Message)
+			we.Write([]byte{'\n'})
 		} else {
-			e.lro.Write(logParts.Message)
-			e.lro.Write([]byte{'\n'})
+			wo.Write(logParts.Message)
+			wo.Write([]byte{'\n'})
 		}
 	}
 }
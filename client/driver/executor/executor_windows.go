@@ -0,0 +1,191 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/hashicorp/nomad/client/stats"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	shelpers "github.com/hashicorp/nomad/helper/stats"
+	"github.com/mitchellh/go-ps"
+)
+
+// JobObjectMeasuredMemStats are the memory stats measured for the tasks run in
+// a job object
+var JobObjectMeasuredMemStats = []string{"RSS", "Swap", "Max Usage"}
+
+func (e *UniversalExecutor) configureChroot() error {
+	return nil
+}
+
+func (e *UniversalExecutor) removeChrootMounts() error {
+	return nil
+}
+
+func (e *UniversalExecutor) runAs(userid string) error {
+	return nil
+}
+
+// applyLimits doesn't apply the limits to the executor, which is left out of
+// the job object of the task so that its memory and CPU aren't accounted to
+// the task. The task is assigned to the job object on start instead.
+func (e *UniversalExecutor) applyLimits(pid int) error {
+	return nil
+}
+
+// BlockDevice returns the device number of the disk the path is on. Disk IO
+// is only limited on Linux.
+func BlockDevice(path string) (major, minor int64, err error) {
+	return 0, 0, fmt.Errorf("disk IO limits are only supported on Linux")
+}
+
+// configureIsolation creates the job object enforcing the memory and CPU of
+// the task when resource limits are enabled
+func (e *UniversalExecutor) configureIsolation() error {
+	if e.command.NetworkIsolation {
+		return fmt.Errorf("network isolation is only supported on Linux")
+	}
+	if e.command.DropCapabilities != 0 || e.command.SeccompProfile != nil {
+		return fmt.Errorf("capability and seccomp restrictions are only supported on Linux")
+	}
+	if !e.command.ResourceLimits {
+		return nil
+	}
+
+	var memory uint64
+	var cpuRate uint32
+	if resources := e.ctx.Task.Resources; resources != nil {
+		memory = uint64(resources.MemoryMB) * 1024 * 1024
+
+		// The CPU rate is the share of all the CPUs of the host in
+		// hundredths of a percent
+		if total := shelpers.TotalTicksAvailable(); total > 0 && resources.CPU > 0 {
+			cpuRate = uint32(float64(resources.CPU) / total * jobObjectCPURateMax)
+			if cpuRate == 0 {
+				cpuRate = 1
+			}
+		}
+	}
+
+	job, err := newJobObject(memory, cpuRate)
+	if err != nil {
+		return err
+	}
+	e.resConCtx.jobLock.Lock()
+	e.resConCtx.job = job
+	e.resConCtx.cpuStatsTotal = stats.NewCpuStats()
+	e.resConCtx.cpuStatsUser = stats.NewCpuStats()
+	e.resConCtx.cpuStatsSys = stats.NewCpuStats()
+	e.resConCtx.jobLock.Unlock()
+	return nil
+}
+
+// taskRestrictions are the restrictions of the capabilities and syscalls of
+// the processes of a task, which are only supported on Linux.
+type taskRestrictions struct{}
+
+// startCmd starts the command suspended when the task has a job object, and
+// resumes it once assigned to the job object so that none of its children
+// escape it.
+func (e *UniversalExecutor) startCmd(cmd *exec.Cmd) error {
+	e.resConCtx.jobLock.Lock()
+	job := e.resConCtx.job
+	e.resConCtx.jobLock.Unlock()
+	if job == nil {
+		return cmd.Start()
+	}
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= createSuspended
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if err := job.Assign(cmd.Process.Pid); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	return nil
+}
+
+// execAttrs returns the attributes of the processes executed in the task,
+// without the suspended flag set by startCmd when assigning them to the job
+// object.
+func (e *UniversalExecutor) execAttrs() *syscall.SysProcAttr {
+	if e.cmd.SysProcAttr == nil {
+		return nil
+	}
+	attrs := *e.cmd.SysProcAttr
+	attrs.CreationFlags &^= createSuspended
+	return &attrs
+}
+
+// Stats returns the resource usage of the task. When the task has a job
+// object, its processes are those of the job object and its CPU usage and
+// peak memory include the processes that exited.
+func (e *UniversalExecutor) Stats() (*cstructs.TaskResourceUsage, error) {
+	pidStats, err := e.pidStats()
+	if err != nil {
+		return nil, err
+	}
+	usage := e.aggregatedResourceUsage(pidStats)
+
+	rc := &e.resConCtx
+	rc.jobLock.Lock()
+	defer rc.jobLock.Unlock()
+	if rc.job == nil {
+		return usage, nil
+	}
+
+	if user, kernel, err := rc.job.CPUTimes(); err == nil {
+		cs := usage.ResourceUsage.CpuStats
+		cs.UserMode = rc.cpuStatsUser.Percent(user)
+		cs.SystemMode = rc.cpuStatsSys.Percent(kernel)
+		cs.Percent = rc.cpuStatsTotal.Percent(user + kernel)
+		cs.TotalTicks = e.systemCpuStats.TicksConsumed(cs.Percent)
+	} else {
+		e.logger.Printf("[DEBUG] executor: %v", err)
+	}
+	if peak, err := rc.job.PeakMemory(); err == nil {
+		ms := usage.ResourceUsage.MemoryStats
+		ms.MaxUsage = peak
+		ms.Measured = JobObjectMeasuredMemStats
+	} else {
+		e.logger.Printf("[DEBUG] executor: %v", err)
+	}
+	return usage, nil
+}
+
+// getAllPids returns the processes of the job object of the task, or else the
+// processes descending from the executor
+func (e *UniversalExecutor) getAllPids() (map[int]*nomadPid, error) {
+	e.resConCtx.jobLock.Lock()
+	job := e.resConCtx.job
+	e.resConCtx.jobLock.Unlock()
+	if job == nil {
+		allProcesses, err := ps.Processes()
+		if err != nil {
+			return nil, err
+		}
+		return e.scanPids(os.Getpid(), allProcesses)
+	}
+
+	pids, err := job.Pids()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int]*nomadPid, len(pids))
+	for _, pid := range pids {
+		res[pid] = &nomadPid{
+			pid:           pid,
+			cpuStatsTotal: stats.NewCpuStats(),
+			cpuStatsUser:  stats.NewCpuStats(),
+			cpuStatsSys:   stats.NewCpuStats(),
+		}
+	}
+	return res, nil
+}
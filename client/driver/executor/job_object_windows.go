@@ -0,0 +1,220 @@
+package executor
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	modkernel32 = windows.NewLazySystemDLL("kernel32.dll")
+	modntdll    = windows.NewLazySystemDLL("ntdll.dll")
+
+	procCreateJobObjectW          = modkernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject   = modkernel32.NewProc("SetInformationJobObject")
+	procQueryInformationJobObject = modkernel32.NewProc("QueryInformationJobObject")
+	procAssignProcessToJobObject  = modkernel32.NewProc("AssignProcessToJobObject")
+	procTerminateJobObject        = modkernel32.NewProc("TerminateJobObject")
+	procNtResumeProcess           = modntdll.NewProc("NtResumeProcess")
+)
+
+const (
+	// Job object information classes
+	jobObjectInfoBasicAccounting = 1
+	jobObjectInfoProcessIDList   = 3
+	jobObjectInfoExtendedLimit   = 9
+	jobObjectInfoCPURateControl  = 15
+
+	jobObjectLimitJobMemory    = 0x00000200
+	jobObjectLimitKillOnClose  = 0x00002000
+	jobObjectCPURateEnable     = 0x1
+	jobObjectCPURateHardCap    = 0x4
+	jobObjectCPURateMax        = 10000
+	processSuspendResume       = 0x0800
+	processSetQuota            = 0x0100
+	createSuspended            = 0x00000004
+	errorMoreData              = syscall.Errno(234)
+	jobObjectProcessListLength = 256
+)
+
+type jobObjectBasicLimitInformation struct {
+	PerProcessUserTimeLimit int64
+	PerJobUserTimeLimit     int64
+	LimitFlags              uint32
+	MinimumWorkingSetSize   uintptr
+	MaximumWorkingSetSize   uintptr
+	ActiveProcessLimit      uint32
+	Affinity                uintptr
+	PriorityClass           uint32
+	SchedulingClass         uint32
+}
+
+type ioCounters struct {
+	ReadOperationCount  uint64
+	WriteOperationCount uint64
+	OtherOperationCount uint64
+	ReadTransferCount   uint64
+	WriteTransferCount  uint64
+	OtherTransferCount  uint64
+}
+
+type jobObjectExtendedLimitInformation struct {
+	BasicLimitInformation jobObjectBasicLimitInformation
+	IoInfo                ioCounters
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+type jobObjectCPURateControlInformation struct {
+	ControlFlags uint32
+	CPURate      uint32
+}
+
+type jobObjectBasicAccountingInformation struct {
+	TotalUserTime             int64
+	TotalKernelTime           int64
+	ThisPeriodTotalUserTime   int64
+	ThisPeriodTotalKernelTime int64
+	TotalPageFaultCount       uint32
+	TotalProcesses            uint32
+	ActiveProcesses           uint32
+	TotalTerminatedProcesses  uint32
+}
+
+// jobObject is a Windows job object holding the processes of a task. The
+// processes are killed when the job object is closed, so that they don't
+// outlive the executor.
+type jobObject struct {
+	handle windows.Handle
+}
+
+// newJobObject creates a job object limiting the memory of its processes to
+// memoryBytes and their CPU to cpuRate, in hundredths of a percent of all the
+// CPUs of the host. Limits that are zero aren't enforced.
+func newJobObject(memoryBytes uint64, cpuRate uint32) (*jobObject, error) {
+	h, _, err := procCreateJobObjectW.Call(0, 0)
+	if h == 0 {
+		return nil, fmt.Errorf("failed to create job object: %v", err)
+	}
+	j := &jobObject{handle: windows.Handle(h)}
+
+	limits := jobObjectExtendedLimitInformation{}
+	limits.BasicLimitInformation.LimitFlags = jobObjectLimitKillOnClose
+	if memoryBytes > 0 {
+		limits.BasicLimitInformation.LimitFlags |= jobObjectLimitJobMemory
+		limits.JobMemoryLimit = uintptr(memoryBytes)
+	}
+	if err := j.setInformation(jobObjectInfoExtendedLimit, unsafe.Pointer(&limits), unsafe.Sizeof(limits)); err != nil {
+		j.Close()
+		return nil, fmt.Errorf("failed to set job object limits: %v", err)
+	}
+
+	if cpuRate > 0 {
+		if cpuRate > jobObjectCPURateMax {
+			cpuRate = jobObjectCPURateMax
+		}
+		rate := jobObjectCPURateControlInformation{
+			ControlFlags: jobObjectCPURateEnable | jobObjectCPURateHardCap,
+			CPURate:      cpuRate,
+		}
+		if err := j.setInformation(jobObjectInfoCPURateControl, unsafe.Pointer(&rate), unsafe.Sizeof(rate)); err != nil {
+			j.Close()
+			return nil, fmt.Errorf("failed to set job object CPU rate: %v", err)
+		}
+	}
+	return j, nil
+}
+
+func (j *jobObject) setInformation(class uint32, info unsafe.Pointer, size uintptr) error {
+	r, _, err := procSetInformationJobObject.Call(uintptr(j.handle), uintptr(class), uintptr(info), size)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func (j *jobObject) queryInformation(class uint32, info unsafe.Pointer, size uintptr) error {
+	r, _, err := procQueryInformationJobObject.Call(uintptr(j.handle), uintptr(class), uintptr(info), size, 0)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+// Assign adds the suspended process to the job object and resumes it, so that
+// the process can't start children outside of the job object.
+func (j *jobObject) Assign(pid int) error {
+	h, err := windows.OpenProcess(processSetQuota|windows.PROCESS_TERMINATE|processSuspendResume, false, uint32(pid))
+	if err != nil {
+		return fmt.Errorf("failed to open process %d: %v", pid, err)
+	}
+	defer windows.CloseHandle(h)
+
+	if r, _, err := procAssignProcessToJobObject.Call(uintptr(j.handle), uintptr(h)); r == 0 {
+		return fmt.Errorf("failed to assign process %d to job object: %v", pid, err)
+	}
+	if status, _, _ := procNtResumeProcess.Call(uintptr(h)); status != 0 {
+		return fmt.Errorf("failed to resume process %d: NTSTATUS 0x%x", pid, status)
+	}
+	return nil
+}
+
+// Pids returns the processes of the job object
+func (j *jobObject) Pids() ([]int, error) {
+	// The list starts with two DWORDs, the number of assigned processes and
+	// the number of processes in the list, followed by their IDs
+	header := int(8 / unsafe.Sizeof(uintptr(0)))
+	for n := jobObjectProcessListLength; ; n *= 2 {
+		buf := make([]uintptr, header+n)
+		err := j.queryInformation(jobObjectInfoProcessIDList, unsafe.Pointer(&buf[0]), uintptr(len(buf))*unsafe.Sizeof(buf[0]))
+		if err == errorMoreData {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to list job object processes: %v", err)
+		}
+
+		count := *(*uint32)(unsafe.Pointer(uintptr(unsafe.Pointer(&buf[0])) + 4))
+		pids := make([]int, 0, count)
+		for _, pid := range buf[header : header+int(count)] {
+			pids = append(pids, int(pid))
+		}
+		return pids, nil
+	}
+}
+
+// PeakMemory returns the peak memory used by the processes of the job object
+func (j *jobObject) PeakMemory() (uint64, error) {
+	var limits jobObjectExtendedLimitInformation
+	if err := j.queryInformation(jobObjectInfoExtendedLimit, unsafe.Pointer(&limits), unsafe.Sizeof(limits)); err != nil {
+		return 0, fmt.Errorf("failed to query job object memory: %v", err)
+	}
+	return uint64(limits.PeakJobMemoryUsed), nil
+}
+
+// CPUTimes returns the user and kernel CPU time in nanoseconds used by the
+// processes of the job object, including the processes that exited
+func (j *jobObject) CPUTimes() (user, kernel float64, err error) {
+	var acct jobObjectBasicAccountingInformation
+	if err := j.queryInformation(jobObjectInfoBasicAccounting, unsafe.Pointer(&acct), unsafe.Sizeof(acct)); err != nil {
+		return 0, 0, fmt.Errorf("failed to query job object accounting: %v", err)
+	}
+	// The times are in 100ns intervals
+	return float64(acct.TotalUserTime * 100), float64(acct.TotalKernelTime * 100), nil
+}
+
+// Terminate kills the processes of the job object
+func (j *jobObject) Terminate() error {
+	if r, _, err := procTerminateJobObject.Call(uintptr(j.handle), 1); r == 0 {
+		return fmt.Errorf("failed to terminate job object: %v", err)
+	}
+	return nil
+}
+
+// Close closes the job object, killing its remaining processes
+func (j *jobObject) Close() error {
+	return windows.CloseHandle(j.handle)
+}
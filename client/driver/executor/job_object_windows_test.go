@@ -0,0 +1,53 @@
+package executor
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestJobObject(t *testing.T) {
+	job, err := newJobObject(64*1024*1024, jobObjectCPURateMax/2)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer job.Close()
+
+	cmd := exec.Command("cmd.exe", "/c", "ping", "-n", "30", "127.0.0.1")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: createSuspended}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := job.Assign(cmd.Process.Pid); err != nil {
+		cmd.Process.Kill()
+		t.Fatalf("err: %v", err)
+	}
+
+	pids, err := job.Pids()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	found := false
+	for _, pid := range pids {
+		found = found || pid == cmd.Process.Pid
+	}
+	if !found {
+		t.Fatalf("pid %d not in job object: %v", cmd.Process.Pid, pids)
+	}
+	if _, _, err := job.CPUTimes(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Terminating the job object kills the process
+	if err := job.Terminate(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	waitCh := make(chan error, 1)
+	go func() { waitCh <- cmd.Wait() }()
+	select {
+	case <-waitCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("process not killed")
+	}
+}
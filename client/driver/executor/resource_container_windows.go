@@ -0,0 +1,45 @@
+package executor
+
+import (
+	"sync"
+
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
+	"github.com/hashicorp/nomad/client/stats"
+)
+
+// resourceContainerContext is a platform-specific struct for managing a
+// resource container. In the case of Windows, this is a job object.
+type resourceContainerContext struct {
+	job     *jobObject
+	jobLock sync.Mutex
+
+	// The CPU usage of the job object is calculated from its accounting
+	cpuStatsTotal *stats.CpuStats
+	cpuStatsUser  *stats.CpuStats
+	cpuStatsSys   *stats.CpuStats
+}
+
+// clientCleanup has nothing to clean up, as the processes of the job object
+// are killed when the executor holding it exits.
+func clientCleanup(ic *dstructs.IsolationConfig, pid int) error {
+	return nil
+}
+
+// executorCleanup kills the processes of the job object and closes it
+func (rc *resourceContainerContext) executorCleanup() error {
+	rc.jobLock.Lock()
+	defer rc.jobLock.Unlock()
+	if rc.job == nil {
+		return nil
+	}
+	err := rc.job.Terminate()
+	if cerr := rc.job.Close(); err == nil {
+		err = cerr
+	}
+	rc.job = nil
+	return err
+}
+
+func (rc *resourceContainerContext) getIsolationConfig() *dstructs.IsolationConfig {
+	return nil
+}
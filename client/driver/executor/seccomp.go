@@ -0,0 +1,134 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The actions of seccomp profiles
+const (
+	SeccompActAllow       = "SCMP_ACT_ALLOW"
+	SeccompActErrno       = "SCMP_ACT_ERRNO"
+	SeccompActKill        = "SCMP_ACT_KILL"
+	SeccompActKillThread  = "SCMP_ACT_KILL_THREAD"
+	SeccompActKillProcess = "SCMP_ACT_KILL_PROCESS"
+	SeccompActTrap        = "SCMP_ACT_TRAP"
+	SeccompActLog         = "SCMP_ACT_LOG"
+)
+
+// The operators comparing the arguments of syscalls in seccomp profiles
+const (
+	SeccompCmpNotEqual     = "SCMP_CMP_NE"
+	SeccompCmpLessThan     = "SCMP_CMP_LT"
+	SeccompCmpLessEqual    = "SCMP_CMP_LE"
+	SeccompCmpEqual        = "SCMP_CMP_EQ"
+	SeccompCmpGreaterEqual = "SCMP_CMP_GE"
+	SeccompCmpGreaterThan  = "SCMP_CMP_GT"
+	SeccompCmpMaskedEqual  = "SCMP_CMP_MASKED_EQ"
+)
+
+// SeccompProfile restricts the syscalls a task can make. Its format is that of
+// the seccomp profiles of Docker, so their default profile can be used as is.
+// The minimum kernel versions of rules are ignored.
+type SeccompProfile struct {
+	// DefaultAction is the action taken on syscalls matching no rule
+	DefaultAction string `json:"defaultAction"`
+
+	// DefaultErrnoRet is the errno returned by the default action if it is
+	// SCMP_ACT_ERRNO. It defaults to EPERM.
+	DefaultErrnoRet *uint `json:"defaultErrnoRet"`
+
+	Syscalls []*SeccompSyscall `json:"syscalls"`
+}
+
+// SeccompSyscall is a rule of a seccomp profile taking an action on syscalls.
+type SeccompSyscall struct {
+	// Name and Names are the names of the syscalls the rule matches
+	Name  string   `json:"name"`
+	Names []string `json:"names"`
+
+	// Action is the action taken on the matching syscalls
+	Action string `json:"action"`
+
+	// ErrnoRet is the errno returned if the action is SCMP_ACT_ERRNO. It
+	// defaults to EPERM.
+	ErrnoRet *uint `json:"errnoRet"`
+
+	// Args are the conditions on the arguments of the syscalls, all of which
+	// must hold for the rule to match
+	Args []*SeccompArg `json:"args"`
+
+	// Includes and Excludes are the conditions on the task for the rule to
+	// apply to it
+	Includes SeccompCondition `json:"includes"`
+	Excludes SeccompCondition `json:"excludes"`
+}
+
+// SeccompArg is a condition on an argument of a syscall.
+type SeccompArg struct {
+	Index    uint   `json:"index"`
+	Value    uint64 `json:"value"`
+	ValueTwo uint64 `json:"valueTwo"`
+	Op       string `json:"op"`
+}
+
+// SeccompCondition is a condition on the architecture and capabilities of a
+// task. A rule including a condition applies if the task runs on one of the
+// architectures and has all of the capabilities, while a rule excluding it
+// doesn't apply if the task runs on one of the architectures or has any of the
+// capabilities.
+type SeccompCondition struct {
+	Arches []string `json:"arches"`
+	Caps   []string `json:"caps"`
+}
+
+// ParseSeccompProfile parses and validates a JSON seccomp profile.
+func ParseSeccompProfile(data []byte) (*SeccompProfile, error) {
+	var p SeccompProfile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse seccomp profile: %v", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate returns an error if the profile is invalid.
+func (p *SeccompProfile) Validate() error {
+	if err := validateSeccompAction(p.DefaultAction); err != nil {
+		return fmt.Errorf("invalid default action: %v", err)
+	}
+	for i, rule := range p.Syscalls {
+		if rule.Name == "" && len(rule.Names) == 0 {
+			return fmt.Errorf("syscall rule %d matches no syscalls", i)
+		}
+		if err := validateSeccompAction(rule.Action); err != nil {
+			return fmt.Errorf("invalid action of syscall rule %d: %v", i, err)
+		}
+		for _, arg := range rule.Args {
+			if arg.Index > 5 {
+				return fmt.Errorf("syscall rule %d compares argument %d but syscalls have 6 arguments", i, arg.Index)
+			}
+			switch arg.Op {
+			case SeccompCmpNotEqual, SeccompCmpLessThan, SeccompCmpLessEqual, SeccompCmpEqual,
+				SeccompCmpGreaterEqual, SeccompCmpGreaterThan, SeccompCmpMaskedEqual:
+			default:
+				return fmt.Errorf("syscall rule %d has unknown operator %q", i, arg.Op)
+			}
+		}
+	}
+	return nil
+}
+
+func validateSeccompAction(action string) error {
+	switch action {
+	case SeccompActAllow, SeccompActErrno, SeccompActKill, SeccompActKillThread,
+		SeccompActKillProcess, SeccompActTrap, SeccompActLog:
+		return nil
+	case "":
+		return fmt.Errorf("missing action")
+	default:
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
@@ -0,0 +1,279 @@
+package executor
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/hashicorp/nomad/helper/capabilities"
+)
+
+// The offsets of the fields of struct seccomp_data. The arguments are 64 bit
+// little endian words.
+const (
+	seccompDataNr   = 0
+	seccompDataArch = 4
+	seccompDataArgs = 16
+)
+
+// The return values of seccomp filters
+const (
+	seccompRetKillProcess = 0x80000000
+	seccompRetKillThread  = 0x00000000
+	seccompRetTrap        = 0x00030000
+	seccompRetErrno       = 0x00050000
+	seccompRetLog         = 0x7ffc0000
+	seccompRetAllow       = 0x7fff0000
+)
+
+// seccompMaxInstructions is the maximum length of a seccomp filter
+const seccompMaxInstructions = 4096
+
+// seccompNoMatch is the placeholder offset of the jumps taken when a syscall
+// doesn't match a rule, to the first instruction of the next rule. Rules are
+// far shorter than the largest offset.
+const seccompNoMatch = 0xff
+
+// compileSeccomp compiles the profile into a seccomp filter for a task with
+// the given capabilities. The names of the syscalls unknown on the
+// architecture are returned; they are skipped as by Docker.
+func compileSeccomp(p *SeccompProfile, caps capabilities.Set) ([]unix.SockFilter, []string, error) {
+	if seccompArch == 0 {
+		return nil, nil, fmt.Errorf("seccomp profiles are not supported on %s", runtime.GOARCH)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	defaultAction := seccompAction(p.DefaultAction, p.DefaultErrnoRet)
+
+	// Kill the task on syscalls of other architectures, whose numbers differ
+	filter := []unix.SockFilter{
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataArch),
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, seccompArch, 1, 0),
+		bpfStmt(unix.BPF_RET|unix.BPF_K, seccompRetKillProcess),
+	}
+	if seccompSyscallLimit != 0 {
+		filter = append(filter,
+			bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataNr),
+			bpfJump(unix.BPF_JMP|unix.BPF_JGE|unix.BPF_K, seccompSyscallLimit, 0, 1),
+			bpfStmt(unix.BPF_RET|unix.BPF_K, seccompRetKillProcess),
+		)
+	}
+
+	var unknown []string
+	for _, rule := range p.Syscalls {
+		if !seccompRuleApplies(rule, caps) {
+			continue
+		}
+
+		action := seccompAction(rule.Action, rule.ErrnoRet)
+		names := rule.Names
+		if rule.Name != "" {
+			names = append([]string{rule.Name}, names...)
+		}
+		for _, name := range names {
+			nr, ok := seccompSyscalls[name]
+			if !ok {
+				unknown = append(unknown, name)
+				continue
+			}
+			filter = append(filter, seccompRule(nr, rule.Args, action)...)
+		}
+	}
+	filter = append(filter, bpfStmt(unix.BPF_RET|unix.BPF_K, defaultAction))
+
+	if len(filter) > seccompMaxInstructions {
+		return nil, nil, fmt.Errorf("seccomp profile compiles to %d instructions, more than the maximum of %d",
+			len(filter), seccompMaxInstructions)
+	}
+	return filter, unknown, nil
+}
+
+// seccompRule returns the instructions taking the action on the syscall if
+// all of the conditions on its arguments hold.
+func seccompRule(nr uint32, args []*SeccompArg, action uint32) []unix.SockFilter {
+	rule := []unix.SockFilter{
+		bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, seccompDataNr),
+		bpfJump(unix.BPF_JMP|unix.BPF_JEQ|unix.BPF_K, nr, 0, seccompNoMatch),
+	}
+	for _, arg := range args {
+		rule = append(rule, seccompArgCheck(arg)...)
+	}
+	rule = append(rule, bpfStmt(unix.BPF_RET|unix.BPF_K, action))
+
+	// Resolve the jumps past the end of the rule
+	for i := range rule {
+		if rule[i].Code&0x07 != unix.BPF_JMP {
+			continue
+		}
+		next := uint8(len(rule) - i - 1)
+		if rule[i].Jt == seccompNoMatch {
+			rule[i].Jt = next
+		}
+		if rule[i].Jf == seccompNoMatch {
+			rule[i].Jf = next
+		}
+	}
+	return rule
+}
+
+// seccompArgCheck returns the instructions comparing a 64 bit argument, as
+// two 32 bit words, which fall through if the condition holds.
+func seccompArgCheck(arg *SeccompArg) []unix.SockFilter {
+	lo := uint32(seccompDataArgs + 8*arg.Index)
+	hi := lo + 4
+	loadLo := bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, lo)
+	loadHi := bpfStmt(unix.BPF_LD|unix.BPF_W|unix.BPF_ABS, hi)
+	valueLo, valueHi := uint32(arg.Value), uint32(arg.Value>>32)
+
+	jeq := unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K
+	jgt := unix.BPF_JMP | unix.BPF_JGT | unix.BPF_K
+	jge := unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K
+
+	switch arg.Op {
+	case SeccompCmpEqual:
+		return []unix.SockFilter{
+			loadHi, bpfJump(jeq, valueHi, 0, seccompNoMatch),
+			loadLo, bpfJump(jeq, valueLo, 0, seccompNoMatch),
+		}
+	case SeccompCmpNotEqual:
+		return []unix.SockFilter{
+			loadHi, bpfJump(jeq, valueHi, 0, 2),
+			loadLo, bpfJump(jeq, valueLo, seccompNoMatch, 0),
+		}
+	case SeccompCmpMaskedEqual:
+		maskLo, maskHi := valueLo, valueHi
+		valueLo, valueHi = uint32(arg.ValueTwo), uint32(arg.ValueTwo>>32)
+		and := unix.BPF_ALU | unix.BPF_AND | unix.BPF_K
+		return []unix.SockFilter{
+			loadHi, bpfStmt(and, maskHi), bpfJump(jeq, valueHi, 0, seccompNoMatch),
+			loadLo, bpfStmt(and, maskLo), bpfJump(jeq, valueLo, 0, seccompNoMatch),
+		}
+	case SeccompCmpGreaterThan, SeccompCmpGreaterEqual:
+		// Holds if the high word is greater, or equal and the low word
+		// compares
+		last := jgt
+		if arg.Op == SeccompCmpGreaterEqual {
+			last = jge
+		}
+		return []unix.SockFilter{
+			loadHi, bpfJump(jgt, valueHi, 3, 0), bpfJump(jeq, valueHi, 0, seccompNoMatch),
+			loadLo, bpfJump(last, valueLo, 0, seccompNoMatch),
+		}
+	default:
+		// SCMP_CMP_LT and SCMP_CMP_LE hold if the high word is less, or
+		// equal and the low word compares
+		last := jge
+		if arg.Op == SeccompCmpLessEqual {
+			last = jgt
+		}
+		return []unix.SockFilter{
+			loadHi, bpfJump(jgt, valueHi, seccompNoMatch, 0), bpfJump(jeq, valueHi, 0, 2),
+			loadLo, bpfJump(last, valueLo, seccompNoMatch, 0),
+		}
+	}
+}
+
+// seccompRuleApplies returns whether the conditions of the rule hold for a
+// task with the given capabilities.
+func seccompRuleApplies(rule *SeccompSyscall, caps capabilities.Set) bool {
+	if arches := rule.Includes.Arches; len(arches) != 0 && !containsString(arches, runtime.GOARCH) {
+		return false
+	}
+	for _, name := range rule.Includes.Caps {
+		c, err := capabilities.Parse(name)
+		if err != nil || !caps.Has(c) {
+			return false
+		}
+	}
+	if containsString(rule.Excludes.Arches, runtime.GOARCH) {
+		return false
+	}
+	for _, name := range rule.Excludes.Caps {
+		if c, err := capabilities.Parse(name); err == nil && caps.Has(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// seccompAction returns the return value of the action.
+func seccompAction(action string, errnoRet *uint) uint32 {
+	switch action {
+	case SeccompActAllow:
+		return seccompRetAllow
+	case SeccompActErrno:
+		errno := uint32(unix.EPERM)
+		if errnoRet != nil {
+			errno = uint32(*errnoRet)
+		}
+		return seccompRetErrno | errno&0xffff
+	case SeccompActKillProcess:
+		return seccompRetKillProcess
+	case SeccompActTrap:
+		return seccompRetTrap
+	case SeccompActLog:
+		return seccompRetLog
+	default:
+		return seccompRetKillThread
+	}
+}
+
+// installSeccomp installs the seccomp filter on the calling thread, which the
+// processes it forks inherit. Without CAP_SYS_ADMIN the thread first has to
+// give up gaining privileges through execve.
+func installSeccomp(filter []unix.SockFilter) error {
+	if !capabilities.Has(capabilities.SysAdmin) {
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			return fmt.Errorf("failed to set no new privileges: %v", err)
+		}
+	}
+
+	prog := unix.SockFprog{
+		Len:    uint16(len(filter)),
+		Filter: &filter[0],
+	}
+	if err := unix.Prctl(unix.PR_SET_SECCOMP, unix.SECCOMP_MODE_FILTER, uintptr(unsafe.Pointer(&prog)), 0, 0); err != nil {
+		return fmt.Errorf("failed to install seccomp filter: %v", err)
+	}
+	return nil
+}
+
+// dropBoundingCaps drops the capabilities not in keep from the bounding set
+// of the calling thread, which limits the capabilities the processes it forks
+// gain on execve.
+func dropBoundingCaps(keep capabilities.Set) error {
+	for c := capabilities.Cap(0); c < 64; c++ {
+		if keep.Has(c) {
+			continue
+		}
+		if err := unix.Prctl(unix.PR_CAPBSET_DROP, uintptr(c), 0, 0, 0); err != nil {
+			// Capabilities past the last one of the kernel are invalid
+			if err == unix.EINVAL {
+				return nil
+			}
+			return fmt.Errorf("failed to drop %v from the capability bounding set: %v", c, err)
+		}
+	}
+	return nil
+}
+
+func bpfStmt(code int, k uint32) unix.SockFilter {
+	return unix.SockFilter{Code: uint16(code), K: k}
+}
+
+func bpfJump(code int, k uint32, jt, jf uint8) unix.SockFilter {
+	return unix.SockFilter{Code: uint16(code), Jt: jt, Jf: jf, K: k}
+}
+
+func containsString(l []string, s string) bool {
+	for _, v := range l {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
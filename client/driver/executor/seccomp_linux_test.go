@@ -0,0 +1,131 @@
+package executor
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/hashicorp/nomad/helper/capabilities"
+)
+
+// runSeccompFilter evaluates the classic BPF instructions of seccomp filters
+// for the syscall and arguments.
+func runSeccompFilter(t *testing.T, filter []unix.SockFilter, arch, nr uint32, args ...uint64) uint32 {
+	data := make([]uint32, 16)
+	data[seccompDataNr/4] = nr
+	data[seccompDataArch/4] = arch
+	for i, arg := range args {
+		data[seccompDataArgs/4+2*i] = uint32(arg)
+		data[seccompDataArgs/4+2*i+1] = uint32(arg >> 32)
+	}
+
+	var a uint32
+	for pc := 0; pc < len(filter); pc++ {
+		ins := filter[pc]
+		switch ins.Code {
+		case unix.BPF_LD | unix.BPF_W | unix.BPF_ABS:
+			a = data[ins.K/4]
+		case unix.BPF_ALU | unix.BPF_AND | unix.BPF_K:
+			a &= ins.K
+		case unix.BPF_RET | unix.BPF_K:
+			return ins.K
+		case unix.BPF_JMP | unix.BPF_JEQ | unix.BPF_K, unix.BPF_JMP | unix.BPF_JGT | unix.BPF_K, unix.BPF_JMP | unix.BPF_JGE | unix.BPF_K:
+			var taken bool
+			switch ins.Code &^ (unix.BPF_JMP | unix.BPF_K) {
+			case unix.BPF_JEQ:
+				taken = a == ins.K
+			case unix.BPF_JGT:
+				taken = a > ins.K
+			default:
+				taken = a >= ins.K
+			}
+			if taken {
+				pc += int(ins.Jt)
+			} else {
+				pc += int(ins.Jf)
+			}
+		default:
+			t.Fatalf("unexpected instruction %+v", ins)
+		}
+	}
+	t.Fatalf("filter didn't return")
+	return 0
+}
+
+func TestCompileSeccomp(t *testing.T) {
+	t.Parallel()
+	if seccompArch == 0 {
+		t.Skip("seccomp profiles are not supported on this architecture")
+	}
+	p, err := ParseSeccompProfile([]byte(`{
+		"defaultAction": "SCMP_ACT_ERRNO",
+		"defaultErrnoRet": 38,
+		"syscalls": [
+			{"names": ["read", "write", "not_a_syscall"], "action": "SCMP_ACT_ALLOW"},
+			{"name": "kill", "action": "SCMP_ACT_ALLOW", "args": [{"index": 1, "value": 9, "op": "SCMP_CMP_NE"}]},
+			{"name": "fcntl", "action": "SCMP_ACT_ALLOW", "args": [{"index": 0, "value": 4294967296, "op": "SCMP_CMP_GT"}]},
+			{"name": "lseek", "action": "SCMP_ACT_ALLOW", "args": [{"index": 2, "value": 4294967296, "op": "SCMP_CMP_LE"}]},
+			{"name": "mmap", "action": "SCMP_ACT_ALLOW", "args": [{"index": 3, "value": 3, "valueTwo": 1, "op": "SCMP_CMP_MASKED_EQ"}]},
+			{"name": "chroot", "action": "SCMP_ACT_ALLOW", "includes": {"caps": ["CAP_SYS_CHROOT"]}},
+			{"name": "getpid", "action": "SCMP_ACT_KILL_PROCESS", "excludes": {"caps": ["CAP_KILL"]}}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	filter, unknown, err := compileSeccomp(p, capabilities.NewSet(capabilities.Kill))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(unknown) != 1 || unknown[0] != "not_a_syscall" {
+		t.Fatalf("unexpected unknown syscalls: %v", unknown)
+	}
+
+	denied := uint32(seccompRetErrno | 38)
+	cases := []struct {
+		name     string
+		arch     uint32
+		nr       uint32
+		args     []uint64
+		expected uint32
+	}{
+		{"read", seccompArch, unix.SYS_READ, nil, seccompRetAllow},
+		{"other arch", seccompArch + 1, unix.SYS_READ, nil, seccompRetKillProcess},
+		{"unlisted", seccompArch, unix.SYS_GETUID, nil, denied},
+		{"kill sigterm", seccompArch, unix.SYS_KILL, []uint64{1, 15}, seccompRetAllow},
+		{"kill sigkill", seccompArch, unix.SYS_KILL, []uint64{1, 9}, denied},
+		{"kill high word", seccompArch, unix.SYS_KILL, []uint64{1, 1<<32 | 9}, seccompRetAllow},
+		{"fcntl greater", seccompArch, unix.SYS_FCNTL, []uint64{1<<32 + 1}, seccompRetAllow},
+		{"fcntl greater high word", seccompArch, unix.SYS_FCNTL, []uint64{2 << 32}, seccompRetAllow},
+		{"fcntl equal", seccompArch, unix.SYS_FCNTL, []uint64{1 << 32}, denied},
+		{"fcntl less", seccompArch, unix.SYS_FCNTL, []uint64{5}, denied},
+		{"lseek equal", seccompArch, unix.SYS_LSEEK, []uint64{0, 0, 1 << 32}, seccompRetAllow},
+		{"lseek less", seccompArch, unix.SYS_LSEEK, []uint64{0, 0, 1<<32 - 1}, seccompRetAllow},
+		{"lseek greater", seccompArch, unix.SYS_LSEEK, []uint64{0, 0, 1<<32 + 1}, denied},
+		{"mmap masked", seccompArch, unix.SYS_MMAP, []uint64{0, 0, 0, 5}, seccompRetAllow},
+		{"mmap unmasked", seccompArch, unix.SYS_MMAP, []uint64{0, 0, 0, 3}, denied},
+		{"chroot without cap", seccompArch, unix.SYS_CHROOT, nil, denied},
+		{"getpid with cap", seccompArch, unix.SYS_GETPID, nil, denied},
+	}
+	for _, c := range cases {
+		if actual := runSeccompFilter(t, filter, c.arch, c.nr, c.args...); actual != c.expected {
+			t.Errorf("%s: got %#x; want %#x", c.name, actual, c.expected)
+		}
+	}
+}
+
+func TestParseSeccompProfile_Invalid(t *testing.T) {
+	t.Parallel()
+	for _, profile := range []string{
+		`{"syscalls": []}`,
+		`{"defaultAction": "SCMP_ACT_FLY"}`,
+		`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"action": "SCMP_ACT_ERRNO"}]}`,
+		`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "kill", "action": "SCMP_ACT_ERRNO", "args": [{"index": 6, "op": "SCMP_CMP_EQ"}]}]}`,
+		`{"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"name": "kill", "action": "SCMP_ACT_ERRNO", "args": [{"index": 0, "op": "SCMP_CMP_LIKE"}]}]}`,
+	} {
+		if _, err := ParseSeccompProfile([]byte(profile)); err == nil {
+			t.Errorf("expected error parsing %s", profile)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+// +build linux,!amd64,!arm64
+
+package executor
+
+// Seccomp profiles are only compiled for amd64 and arm64.
+const (
+	seccompArch         = 0
+	seccompSyscallLimit = 0
+)
+
+var seccompSyscalls map[string]uint32
@@ -0,0 +1,927 @@
+// +build linux
+
+package driver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/client/driver/executor"
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/helper/fields"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/mitchellh/mapstructure"
+)
+
+var reFirecrackerVersion = regexp.MustCompile(`Firecracker v(\d+\.\d+\.\d+)`)
+
+const (
+	// minFirecrackerVersion is the earliest supported version of
+	// Firecracker, which can boot VMs from a config file without its API
+	minFirecrackerVersion = "1.0.0"
+
+	// The key populated in the Node Attributes to indicate the presence of the
+	// firecracker driver
+	firecrackerDriverAttr = "driver.firecracker"
+
+	// firecrackerConfigOption is the key for setting the path of the
+	// firecracker binary. It is looked up in the PATH by default.
+	firecrackerConfigOption  = "firecracker.path"
+	firecrackerConfigDefault = "firecracker"
+
+	// firecrackerBridgeConfigOption is the key for setting the bridge the
+	// VMs are attached to. The bridge must exist and have an IPv4 address,
+	// which is the gateway of the VMs. VMs have no network when unset.
+	firecrackerBridgeConfigOption = "firecracker.bridge"
+
+	// firecrackerTapResKey and firecrackerLeaseResKey are the keys of the TAP
+	// devices and leased IPs of the tasks in their CreatedResources
+	firecrackerTapResKey   = "tap"
+	firecrackerLeaseResKey = "lease"
+
+	// firecrackerDefaultBootArgs are the kernel arguments of VMs which don't
+	// set their own. The VM exits when the guest reboots.
+	firecrackerDefaultBootArgs = "console=ttyS0 reboot=k panic=1 pci=off"
+
+	// firecrackerDefaultRootfsSize is the size in MB of the root filesystems
+	// built from images
+	firecrackerDefaultRootfsSize = 1024
+
+	// firecrackerVMMOverheadMB is the memory of the task left to the VMM
+	// rather than the guest, so that the VMM isn't killed when the guest uses
+	// all of its memory
+	firecrackerVMMOverheadMB = 32
+
+	// firecrackerMaxVCPUs is the maximum number of vCPUs of a VM
+	firecrackerMaxVCPUs = 32
+
+	// kvmDevice must be accessible for VMs to run
+	kvmDevice = "/dev/kvm"
+)
+
+// FirecrackerDriver is a driver for running tasks as Firecracker microVMs,
+// which isolate tasks with their own kernel. VMs are run under an executor
+// collecting their serial console.
+type FirecrackerDriver struct {
+	DriverContext
+
+	// driverConfig is the task's driver config, parsed by Prestart
+	driverConfig *FirecrackerDriverConfig
+
+	// rootfs is the path of the root filesystem of the VM, and tap and guest
+	// its TAP device and address on the bridge when it has a network. They
+	// are set by Prestart.
+	rootfs  string
+	tap     string
+	guest   *net.IPNet
+	gateway net.IP
+	network *cstructs.DriverNetwork
+
+	// A tri-state boolean to know if the fingerprinting has happened and
+	// whether it has been successful
+	fingerprintSuccess *bool
+}
+
+type FirecrackerDriverConfig struct {
+	Kernel     string              `mapstructure:"kernel"`      // Path of the kernel, relative to the task dir
+	Rootfs     string              `mapstructure:"rootfs"`      // Path of the root filesystem, relative to the task dir
+	ImageName  string              `mapstructure:"image"`       // Image the root filesystem is built from
+	RootfsSize int                 `mapstructure:"rootfs_size"` // Size in MB of the root filesystem built from the image
+	BootArgs   string              `mapstructure:"boot_args"`   // Arguments of the kernel
+	Init       string              `mapstructure:"init"`        // Init process of the guest
+	PortMapRaw []map[string]string `mapstructure:"port_map"`    //
+	PortMap    map[string]int      `mapstructure:"-"`           // A map of host port labels and the ports the guest listens on
+}
+
+// NewFirecrackerDriverConfig returns the firecracker driver config of the task
+func NewFirecrackerDriverConfig(task *structs.Task, env *env.TaskEnv) (*FirecrackerDriverConfig, error) {
+	var conf FirecrackerDriverConfig
+	if err := mapstructure.WeakDecode(task.Config, &conf); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(conf.Kernel) == "" {
+		return nil, fmt.Errorf("firecracker driver requires a kernel")
+	}
+	if (conf.Rootfs == "") == (conf.ImageName == "") {
+		return nil, fmt.Errorf("firecracker driver requires either a rootfs or an image")
+	}
+
+	conf.Kernel = env.ReplaceEnv(conf.Kernel)
+	conf.Rootfs = env.ReplaceEnv(conf.Rootfs)
+	conf.ImageName = env.ReplaceEnv(conf.ImageName)
+	conf.BootArgs = env.ReplaceEnv(conf.BootArgs)
+	conf.Init = env.ReplaceEnv(conf.Init)
+	if conf.RootfsSize == 0 {
+		conf.RootfsSize = firecrackerDefaultRootfsSize
+	}
+
+	portMap := make(map[string]int)
+	for _, m := range conf.PortMapRaw {
+		for k, v := range m {
+			ki, vi := env.ReplaceEnv(k), env.ReplaceEnv(v)
+			p, err := strconv.Atoi(vi)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse port map value %v to %v: %v", ki, vi, err)
+			}
+			portMap[ki] = p
+		}
+	}
+	conf.PortMap = portMap
+	return &conf, nil
+}
+
+// firecrackerHandle is returned from Start/Open as a handle to the VM
+type firecrackerHandle struct {
+	pluginClient    *plugin.Client
+	executor        executor.Executor
+	isolationConfig *dstructs.IsolationConfig
+	userPid         int
+	killTimeout     time.Duration
+	maxKillTimeout  time.Duration
+	logger          *log.Logger
+	waitCh          chan *dstructs.WaitResult
+	doneCh          chan struct{}
+	version         string
+}
+
+// firecrackerVMConfig is the config file of a VM
+type firecrackerVMConfig struct {
+	BootSource        firecrackerBootSource    `json:"boot-source"`
+	Drives            []firecrackerDrive       `json:"drives"`
+	MachineConfig     firecrackerMachineConfig `json:"machine-config"`
+	NetworkInterfaces []firecrackerInterface   `json:"network-interfaces,omitempty"`
+}
+
+type firecrackerBootSource struct {
+	KernelImagePath string `json:"kernel_image_path"`
+	BootArgs        string `json:"boot_args"`
+}
+
+type firecrackerDrive struct {
+	DriveID      string `json:"drive_id"`
+	PathOnHost   string `json:"path_on_host"`
+	IsRootDevice bool   `json:"is_root_device"`
+	IsReadOnly   bool   `json:"is_read_only"`
+}
+
+type firecrackerMachineConfig struct {
+	VCPUCount  int `json:"vcpu_count"`
+	MemSizeMib int `json:"mem_size_mib"`
+}
+
+type firecrackerInterface struct {
+	IfaceID     string `json:"iface_id"`
+	GuestMAC    string `json:"guest_mac"`
+	HostDevName string `json:"host_dev_name"`
+}
+
+// NewFirecrackerDriver is used to create a new firecracker driver
+func NewFirecrackerDriver(ctx *DriverContext) Driver {
+	return &FirecrackerDriver{DriverContext: *ctx}
+}
+
+// Validate is used to validate the driver configuration
+func (d *FirecrackerDriver) Validate(config map[string]interface{}) error {
+	fd := &fields.FieldData{
+		Raw: config,
+		Schema: map[string]*fields.FieldSchema{
+			"kernel": {
+				Type:     fields.TypeString,
+				Required: true,
+			},
+			"rootfs": {
+				Type: fields.TypeString,
+			},
+			"image": {
+				Type: fields.TypeString,
+			},
+			"rootfs_size": {
+				Type: fields.TypeInt,
+			},
+			"boot_args": {
+				Type: fields.TypeString,
+			},
+			"init": {
+				Type: fields.TypeString,
+			},
+			"port_map": {
+				Type: fields.TypeArray,
+			},
+		},
+	}
+
+	if err := fd.Validate(); err != nil {
+		return err
+	}
+
+	var conf FirecrackerDriverConfig
+	if err := mapstructure.WeakDecode(config, &conf); err != nil {
+		return err
+	}
+	if (conf.Rootfs == "") == (conf.ImageName == "") {
+		return fmt.Errorf("exactly one of rootfs or image must be set")
+	}
+	for _, path := range []string{conf.Kernel, conf.Rootfs} {
+		escapes, err := structs.PathEscapesAllocDir("task", path)
+		if err != nil {
+			return err
+		}
+		if escapes {
+			return fmt.Errorf("%q escapes the allocation directory", path)
+		}
+	}
+	return nil
+}
+
+func (d *FirecrackerDriver) Abilities() DriverAbilities {
+	return DriverAbilities{
+		SendSignals: false,
+		Exec:        false,
+	}
+}
+
+func (d *FirecrackerDriver) FSIsolation() cstructs.FSIsolation {
+	return cstructs.FSIsolationImage
+}
+
+func (d *FirecrackerDriver) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	// TAP devices and cgroups can only be managed by root
+	if syscall.Geteuid() != 0 {
+		if d.fingerprintSuccess == nil || *d.fingerprintSuccess {
+			d.logger.Printf("[DEBUG] driver.firecracker: must run as root user, disabling")
+		}
+		d.fingerprintSuccess = helper.BoolToPtr(false)
+		resp.RemoveAttribute(firecrackerDriverAttr)
+		return nil
+	}
+
+	if _, err := os.Stat(kvmDevice); err != nil {
+		if d.fingerprintSuccess == nil || *d.fingerprintSuccess {
+			d.logger.Printf("[DEBUG] driver.firecracker: %s not found, disabling", kvmDevice)
+		}
+		d.fingerprintSuccess = helper.BoolToPtr(false)
+		resp.RemoveAttribute(firecrackerDriverAttr)
+		return nil
+	}
+
+	bin := req.Config.ReadDefault(firecrackerConfigOption, firecrackerConfigDefault)
+	outBytes, err := exec.Command(bin, "--version").Output()
+	if err != nil {
+		if d.fingerprintSuccess == nil || *d.fingerprintSuccess {
+			d.logger.Printf("[DEBUG] driver.firecracker: firecracker not found, disabling")
+		}
+		d.fingerprintSuccess = helper.BoolToPtr(false)
+		resp.RemoveAttribute(firecrackerDriverAttr)
+		return nil
+	}
+
+	matches := reFirecrackerVersion.FindStringSubmatch(string(outBytes))
+	if len(matches) != 2 {
+		d.fingerprintSuccess = helper.BoolToPtr(false)
+		resp.RemoveAttribute(firecrackerDriverAttr)
+		return fmt.Errorf("Unable to parse firecracker version string: %q", strings.TrimSpace(string(outBytes)))
+	}
+
+	minVersion, _ := version.NewVersion(minFirecrackerVersion)
+	currentVersion, err := version.NewVersion(matches[1])
+	if err != nil || currentVersion.LessThan(minVersion) {
+		if d.fingerprintSuccess == nil || *d.fingerprintSuccess {
+			d.logger.Printf("[WARN] driver.firecracker: unsupported firecracker version %s; please upgrade to >= %s",
+				matches[1], minVersion)
+		}
+		d.fingerprintSuccess = helper.BoolToPtr(false)
+		resp.RemoveAttribute(firecrackerDriverAttr)
+		return nil
+	}
+
+	resp.AddAttribute(firecrackerDriverAttr, "1")
+	resp.AddAttribute("driver.firecracker.version", matches[1])
+	resp.Detected = true
+
+	// Advertise if this node can build root filesystems from images
+	oci := true
+	for _, cmd := range []string{nerdctlCmd, "tar", "mkfs.ext4"} {
+		if _, err := exec.LookPath(cmd); err != nil {
+			oci = false
+			break
+		}
+	}
+	if oci {
+		resp.AddAttribute("driver.firecracker.oci", "1")
+	} else {
+		resp.RemoveAttribute("driver.firecracker.oci")
+	}
+	d.fingerprintSuccess = helper.BoolToPtr(true)
+	return nil
+}
+
+func (d *FirecrackerDriver) Periodic() (bool, time.Duration) {
+	return true, 15 * time.Second
+}
+
+// Prestart builds the root filesystem of the task from its image and attaches
+// a TAP device to the bridge for the VM
+func (d *FirecrackerDriver) Prestart(ctx *ExecContext, task *structs.Task) (*PrestartResponse, error) {
+	driverConfig, err := NewFirecrackerDriverConfig(task, ctx.TaskEnv)
+	if err != nil {
+		return nil, err
+	}
+	d.driverConfig = driverConfig
+
+	resp := NewPrestartResponse()
+	if driverConfig.ImageName != "" {
+		rootfs, err := d.buildRootfs(ctx, driverConfig)
+		if err != nil {
+			return nil, err
+		}
+		d.rootfs = rootfs
+	} else {
+		d.rootfs = filepath.Join(ctx.TaskDir.Dir, driverConfig.Rootfs)
+	}
+
+	bridge := d.config.Read(firecrackerBridgeConfigOption)
+	if bridge == "" {
+		// Return the PortMap if it's set
+		if len(driverConfig.PortMap) > 0 {
+			d.network = &cstructs.DriverNetwork{
+				PortMap: driverConfig.PortMap,
+			}
+			resp.Network = d.network
+		}
+		return resp, nil
+	}
+
+	gateway, subnet, err := bridgeAddr(bridge)
+	if err != nil {
+		return nil, err
+	}
+	leaseDir := filepath.Join(d.config.StateDir, "firecracker", "leases")
+	ip, err := acquireLease(leaseDir, subnet, gateway, d.allocID+"/"+d.taskName)
+	if err != nil {
+		return nil, err
+	}
+	resp.CreatedResources.Add(firecrackerLeaseResKey, ip.String())
+
+	tap := firecrackerTapName(d.allocID, d.taskName)
+	if err := createTap(tap, bridge); err != nil {
+		return resp, err
+	}
+	resp.CreatedResources.Add(firecrackerTapResKey, tap)
+
+	d.tap = tap
+	d.guest = &net.IPNet{IP: ip, Mask: subnet.Mask}
+	d.gateway = gateway
+	d.network = &cstructs.DriverNetwork{
+		PortMap:       driverConfig.PortMap,
+		IP:            ip.String(),
+		AutoAdvertise: true,
+	}
+	resp.Network = d.network
+	return resp, nil
+}
+
+// buildRootfs builds an ext4 root filesystem from the image of the task. The
+// filesystem is built once per task, so that it persists across restarts.
+func (d *FirecrackerDriver) buildRootfs(ctx *ExecContext, driverConfig *FirecrackerDriverConfig) (string, error) {
+	path := filepath.Join(ctx.TaskDir.Dir, "rootfs.ext4")
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	image := driverConfig.ImageName
+	globalArgs := (&ContainerdDriver{DriverContext: d.DriverContext}).globalArgs(&ContainerdDriverConfig{})
+	if nerdctl(globalArgs, "image", "inspect", image) != nil {
+		d.logger.Printf("[DEBUG] driver.firecracker: pulling image %s", image)
+		d.emitEvent("Downloading image %s", image)
+		if err := nerdctl(globalArgs, "pull", "--quiet", image); err != nil {
+			return "", structs.NewRecoverableError(fmt.Errorf("Failed to pull %s: %v", image, err), true)
+		}
+	}
+
+	d.emitEvent("Building root filesystem from image %s", image)
+	tmp, err := ioutil.TempDir(ctx.TaskDir.Dir, "rootfs")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	// The filesystem of the image is that of a container created from it
+	name := "nomad-" + firecrackerTapName(d.allocID, d.taskName)
+	if err := nerdctl(globalArgs, "create", "--name", name, image); err != nil {
+		return "", fmt.Errorf("failed to create container from %s: %v", image, err)
+	}
+	defer nerdctl(globalArgs, "rm", "--force", name)
+
+	archive := filepath.Join(tmp, "rootfs.tar")
+	root := filepath.Join(tmp, "rootfs")
+	if err := nerdctl(globalArgs, "export", "--output", archive, name); err != nil {
+		return "", fmt.Errorf("failed to export the filesystem of %s: %v", image, err)
+	}
+	if err := os.Mkdir(root, 0755); err != nil {
+		return "", err
+	}
+	if err := runHostCommand("tar", "-xf", archive, "-C", root); err != nil {
+		return "", fmt.Errorf("failed to extract the filesystem of %s: %v", image, err)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	err = f.Truncate(int64(driverConfig.RootfsSize) * 1024 * 1024)
+	f.Close()
+	if err == nil {
+		err = runHostCommand("mkfs.ext4", "-F", "-q", "-d", root, path)
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("failed to build root filesystem: %v", err)
+	}
+	return path, nil
+}
+
+func (d *FirecrackerDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse, error) {
+	driverConfig := d.driverConfig
+	kernel := filepath.Join(ctx.TaskDir.Dir, driverConfig.Kernel)
+	for _, path := range []string{kernel, d.rootfs} {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("failed to find %q: %v", path, err)
+		}
+	}
+
+	vmConfig, err := d.vmConfig(task, kernel)
+	if err != nil {
+		return nil, err
+	}
+	vmConfigPath := filepath.Join(ctx.TaskDir.Dir, "firecracker.json")
+	data, err := json.Marshal(vmConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(vmConfigPath, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write VM config: %v", err)
+	}
+
+	bin, err := GetAbsolutePath(d.config.ReadDefault(firecrackerConfigOption, firecrackerConfigDefault))
+	if err != nil {
+		return nil, err
+	}
+
+	pluginLogFile := filepath.Join(ctx.TaskDir.Dir, "executor.out")
+	executorConfig := &dstructs.ExecutorConfig{
+		LogFile:  pluginLogFile,
+		LogLevel: d.config.LogLevel,
+	}
+	exec, pluginClient, err := createExecutor(d.config.LogOutput, d.config, executorConfig)
+	if err != nil {
+		return nil, err
+	}
+	executorCtx := &executor.ExecutorContext{
+		TaskEnv: ctx.TaskEnv,
+		Driver:  "firecracker",
+		Task:    task,
+		TaskDir: ctx.TaskDir.Dir,
+		LogDir:  ctx.TaskDir.LogDir,
+	}
+	if err := exec.SetContext(executorCtx); err != nil {
+		pluginClient.Kill()
+		return nil, fmt.Errorf("failed to set executor context: %v", err)
+	}
+
+	taskKillSignal, err := getTaskKillSignal(task.KillSignal)
+	if err != nil {
+		pluginClient.Kill()
+		return nil, err
+	}
+
+	// The serial console of the guest is the output of the VMM
+	execCmd := &executor.ExecCommand{
+		Cmd:            bin,
+		Args:           []string{"--no-api", "--config-file", vmConfigPath},
+		TaskKillSignal: taskKillSignal,
+		ResourceLimits: true,
+		CgroupParent:   d.config.CgroupParent(),
+	}
+	ps, err := exec.LaunchCmd(execCmd)
+	if err != nil {
+		pluginClient.Kill()
+		return nil, err
+	}
+	d.logger.Printf("[DEBUG] driver.firecracker: started VM with %d vCPUs and %d MiB with pid: %v",
+		vmConfig.MachineConfig.VCPUCount, vmConfig.MachineConfig.MemSizeMib, ps.Pid)
+
+	// Return a driver handle
+	maxKill := d.DriverContext.config.MaxKillTimeout
+	h := &firecrackerHandle{
+		pluginClient:    pluginClient,
+		executor:        exec,
+		isolationConfig: ps.IsolationConfig,
+		userPid:         ps.Pid,
+		killTimeout:     GetKillTimeout(task.KillTimeout, maxKill),
+		maxKillTimeout:  maxKill,
+		version:         d.config.Version.VersionNumber(),
+		logger:          d.logger,
+		doneCh:          make(chan struct{}),
+		waitCh:          make(chan *dstructs.WaitResult, 1),
+	}
+	go h.run()
+	return &StartResponse{Handle: h, Network: d.network}, nil
+}
+
+// vmConfig returns the config of the VM of the task. The guest gets the
+// memory of the task but the overhead of the VMM, and a vCPU per core of the
+// node its CPU spans.
+func (d *FirecrackerDriver) vmConfig(task *structs.Task, kernel string) (*firecrackerVMConfig, error) {
+	if task.Resources == nil || task.Resources.MemoryMB <= firecrackerVMMOverheadMB {
+		return nil, fmt.Errorf("firecracker tasks require more than %d MB of memory", firecrackerVMMOverheadMB)
+	}
+
+	var mhzPerCore, cores int
+	if d.node != nil {
+		mhzPerCore, _ = strconv.Atoi(d.node.Attributes["cpu.frequency"])
+		cores, _ = strconv.Atoi(d.node.Attributes["cpu.numcores"])
+	}
+
+	conf := &firecrackerVMConfig{
+		BootSource: firecrackerBootSource{
+			KernelImagePath: kernel,
+			BootArgs:        firecrackerBootArgs(d.driverConfig, d.guest, d.gateway),
+		},
+		Drives: []firecrackerDrive{{
+			DriveID:      "rootfs",
+			PathOnHost:   d.rootfs,
+			IsRootDevice: true,
+		}},
+		MachineConfig: firecrackerMachineConfig{
+			VCPUCount:  firecrackerVCPUs(task.Resources.CPU, mhzPerCore, cores),
+			MemSizeMib: task.Resources.MemoryMB - firecrackerVMMOverheadMB,
+		},
+	}
+	if d.tap != "" {
+		conf.NetworkInterfaces = []firecrackerInterface{{
+			IfaceID:     "eth0",
+			GuestMAC:    firecrackerMAC(d.guest.IP),
+			HostDevName: d.tap,
+		}}
+	}
+	return conf, nil
+}
+
+// firecrackerVCPUs returns the number of vCPUs needed for cpuMHz on a node
+// with cores of mhzPerCore
+func firecrackerVCPUs(cpuMHz, mhzPerCore, cores int) int {
+	if mhzPerCore <= 0 {
+		return 1
+	}
+	vcpus := (cpuMHz + mhzPerCore - 1) / mhzPerCore
+	if cores > 0 && vcpus > cores {
+		vcpus = cores
+	}
+	if vcpus > firecrackerMaxVCPUs {
+		vcpus = firecrackerMaxVCPUs
+	}
+	if vcpus < 1 {
+		vcpus = 1
+	}
+	return vcpus
+}
+
+// firecrackerBootArgs returns the kernel arguments of the VM, configuring the
+// address of the guest on the bridge when it has one
+func firecrackerBootArgs(driverConfig *FirecrackerDriverConfig, guest *net.IPNet, gateway net.IP) string {
+	args := driverConfig.BootArgs
+	if args == "" {
+		args = firecrackerDefaultBootArgs
+	}
+	if driverConfig.Init != "" {
+		args += " init=" + driverConfig.Init
+	}
+	if guest != nil {
+		args += fmt.Sprintf(" ip=%s::%s:%s::eth0:off", guest.IP, gateway, net.IP(guest.Mask))
+	}
+	return args
+}
+
+// firecrackerTapName returns the name of the TAP device of a task, which is
+// limited to the 15 characters of network interface names
+func firecrackerTapName(allocID, task string) string {
+	sum := sha1.Sum([]byte(allocID + "/" + task))
+	return "fc" + hex.EncodeToString(sum[:])[:12]
+}
+
+// firecrackerMAC returns the MAC address of the guest, which is derived from
+// its IPv4 address so that it is unique on the bridge
+func firecrackerMAC(ip net.IP) string {
+	ip = ip.To4()
+	return fmt.Sprintf("06:00:%02x:%02x:%02x:%02x", ip[0], ip[1], ip[2], ip[3])
+}
+
+// bridgeAddr returns the IPv4 address and subnet of the bridge
+func bridgeAddr(bridge string) (net.IP, *net.IPNet, error) {
+	iface, err := net.InterfaceByName(bridge)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find bridge %q: %v", bridge, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the addresses of bridge %q: %v", bridge, err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+			_, subnet, _ := net.ParseCIDR(ipNet.String())
+			return ipNet.IP.To4(), subnet, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("bridge %q has no IPv4 address", bridge)
+}
+
+// acquireLease leases an address of the subnet to owner, returning the
+// address already leased to owner if any. Leases are files named after their
+// address in dir, so that they persist across restarts of the client.
+func acquireLease(dir string, subnet *net.IPNet, gateway net.IP, owner string) (net.IP, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create lease dir: %v", err)
+	}
+	leases, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, lease := range leases {
+		data, err := ioutil.ReadFile(filepath.Join(dir, lease.Name()))
+		if err == nil && string(data) == owner {
+			if ip := net.ParseIP(lease.Name()).To4(); ip != nil && subnet.Contains(ip) {
+				return ip, nil
+			}
+		}
+	}
+
+	base := binary.BigEndian.Uint32(subnet.IP.To4())
+	ones, bits := subnet.Mask.Size()
+	size := uint32(1) << uint(bits-ones)
+
+	// Skip the network and broadcast addresses
+	for i := uint32(1); i+1 < size; i++ {
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, base+i)
+		if ip.Equal(gateway) {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join(dir, ip.String()), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to lease %s: %v", ip, err)
+		}
+		_, err = f.WriteString(owner)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			return nil, fmt.Errorf("failed to lease %s: %v", ip, err)
+		}
+		return ip, nil
+	}
+	return nil, fmt.Errorf("no addresses left in %s", subnet)
+}
+
+// releaseLease releases the lease of the address. No error is returned if
+// the address isn't leased.
+func releaseLease(dir, ip string) error {
+	if err := os.Remove(filepath.Join(dir, ip)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// createTap creates the TAP device attached to the bridge, unless it exists
+func createTap(tap, bridge string) error {
+	if _, err := net.InterfaceByName(tap); err == nil {
+		return nil
+	}
+	if err := runHostCommand("ip", "tuntap", "add", "dev", tap, "mode", "tap"); err != nil {
+		return fmt.Errorf("failed to create TAP device %s: %v", tap, err)
+	}
+	if err := runHostCommand("ip", "link", "set", tap, "master", bridge, "up"); err != nil {
+		runHostCommand("ip", "link", "del", tap)
+		return fmt.Errorf("failed to attach TAP device %s to %s: %v", tap, bridge, err)
+	}
+	return nil
+}
+
+// deleteTap deletes the TAP device. No error is returned if it doesn't exist.
+func deleteTap(tap string) error {
+	if _, err := net.InterfaceByName(tap); err != nil {
+		return nil
+	}
+	return runHostCommand("ip", "link", "del", tap)
+}
+
+// runHostCommand runs a command and returns its error output on failure
+func runHostCommand(name string, args ...string) error {
+	var errBuf bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = ioutil.Discard
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(errBuf.String()); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// Cleanup deletes the TAP device of the task and releases its address
+func (d *FirecrackerDriver) Cleanup(_ *ExecContext, res *CreatedResources) error {
+	retry := false
+	var merr multierror.Error
+	leaseDir := filepath.Join(d.config.StateDir, "firecracker", "leases")
+	for key, resources := range res.Resources {
+		for _, value := range resources {
+			var err error
+			switch key {
+			case firecrackerTapResKey:
+				err = deleteTap(value)
+			case firecrackerLeaseResKey:
+				err = releaseLease(leaseDir, value)
+			default:
+				d.logger.Printf("[ERR] driver.firecracker: unknown resource to cleanup: %q", key)
+				continue
+			}
+			if err != nil {
+				retry = true
+				merr.Errors = append(merr.Errors, err)
+				continue
+			}
+			res.Remove(key, value)
+		}
+	}
+	return structs.NewRecoverableError(merr.ErrorOrNil(), retry)
+}
+
+type firecrackerId struct {
+	Version         string
+	KillTimeout     time.Duration
+	MaxKillTimeout  time.Duration
+	UserPid         int
+	IsolationConfig *dstructs.IsolationConfig
+	PluginConfig    *PluginReattachConfig
+}
+
+func (d *FirecrackerDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, error) {
+	id := &firecrackerId{}
+	if err := json.Unmarshal([]byte(handleID), id); err != nil {
+		return nil, fmt.Errorf("Failed to parse handle '%s': %v", handleID, err)
+	}
+
+	pluginConfig := &plugin.ClientConfig{
+		Reattach: id.PluginConfig.PluginConfig(),
+	}
+	exec, pluginClient, err := createExecutorWithConfig(pluginConfig, d.config.LogOutput)
+	if err != nil {
+		merrs := new(multierror.Error)
+		merrs.Errors = append(merrs.Errors, err)
+		d.logger.Println("[ERR] driver.firecracker: error connecting to plugin so destroying plugin pid and user pid")
+		if e := destroyPlugin(id.PluginConfig.Pid, id.UserPid); e != nil {
+			merrs.Errors = append(merrs.Errors, fmt.Errorf("error destroying plugin and userpid: %v", e))
+		}
+		if id.IsolationConfig != nil {
+			ePid := pluginConfig.Reattach.Pid
+			if e := executor.ClientCleanup(id.IsolationConfig, ePid); e != nil {
+				merrs.Errors = append(merrs.Errors, fmt.Errorf("destroying resource container failed: %v", e))
+			}
+		}
+		return nil, fmt.Errorf("error connecting to plugin: %v", merrs.ErrorOrNil())
+	}
+
+	ver, _ := exec.Version()
+	d.logger.Printf("[DEBUG] driver.firecracker: version of executor: %v", ver.Version)
+
+	// Return a driver handle
+	h := &firecrackerHandle{
+		pluginClient:    pluginClient,
+		executor:        exec,
+		isolationConfig: id.IsolationConfig,
+		userPid:         id.UserPid,
+		logger:          d.logger,
+		killTimeout:     id.KillTimeout,
+		maxKillTimeout:  id.MaxKillTimeout,
+		version:         id.Version,
+		doneCh:          make(chan struct{}),
+		waitCh:          make(chan *dstructs.WaitResult, 1),
+	}
+	go h.run()
+	return h, nil
+}
+
+func (h *firecrackerHandle) ID() string {
+	id := firecrackerId{
+		Version:         h.version,
+		KillTimeout:     h.killTimeout,
+		MaxKillTimeout:  h.maxKillTimeout,
+		PluginConfig:    NewPluginReattachConfig(h.pluginClient.ReattachConfig()),
+		UserPid:         h.userPid,
+		IsolationConfig: h.isolationConfig,
+	}
+
+	data, err := json.Marshal(id)
+	if err != nil {
+		h.logger.Printf("[ERR] driver.firecracker: failed to marshal ID to JSON: %s", err)
+	}
+	return string(data)
+}
+
+func (h *firecrackerHandle) WaitCh() chan *dstructs.WaitResult {
+	return h.waitCh
+}
+
+func (h *firecrackerHandle) Update(task *structs.Task) error {
+	// Store the updated kill timeout.
+	h.killTimeout = GetKillTimeout(task.KillTimeout, h.maxKillTimeout)
+	h.executor.UpdateTask(task)
+
+	// Update is not possible
+	return nil
+}
+
+func (h *firecrackerHandle) Exec(ctx context.Context, cmd string, args []string) ([]byte, int, error) {
+	return nil, 0, fmt.Errorf("Firecracker VMs do not support exec")
+}
+
+func (h *firecrackerHandle) Signal(s os.Signal) error {
+	return fmt.Errorf("Firecracker VMs do not support signals")
+}
+
+func (h *firecrackerHandle) Kill() error {
+	if err := h.executor.ShutDown(); err != nil {
+		if h.pluginClient.Exited() {
+			return nil
+		}
+		return fmt.Errorf("executor Shutdown failed: %v", err)
+	}
+
+	select {
+	case <-h.doneCh:
+		return nil
+	case <-time.After(h.killTimeout):
+		if h.pluginClient.Exited() {
+			return nil
+		}
+		if err := h.executor.Exit(); err != nil {
+			return fmt.Errorf("executor Exit failed: %v", err)
+		}
+		return nil
+	}
+}
+
+func (h *firecrackerHandle) Stats() (*cstructs.TaskResourceUsage, error) {
+	return h.executor.Stats()
+}
+
+func (h *firecrackerHandle) run() {
+	ps, werr := h.executor.Wait()
+	close(h.doneCh)
+	if ps.ExitCode == 0 && werr != nil {
+		if h.isolationConfig != nil {
+			ePid := h.pluginClient.ReattachConfig().Pid
+			if e := executor.ClientCleanup(h.isolationConfig, ePid); e != nil {
+				h.logger.Printf("[ERR] driver.firecracker: destroying resource container failed: %v", e)
+			}
+		} else if e := killProcess(h.userPid); e != nil {
+			h.logger.Printf("[ERR] driver.firecracker: error killing user process: %v", e)
+		}
+	}
+
+	// Exit the executor
+	if err := h.executor.Exit(); err != nil {
+		h.logger.Printf("[ERR] driver.firecracker: error killing executor: %v", err)
+	}
+	h.pluginClient.Kill()
+
+	// Send the results
+	h.waitCh <- dstructs.NewWaitResult(ps.ExitCode, ps.Signal, werr)
+	close(h.waitCh)
+}
@@ -0,0 +1,54 @@
+// +build !linux
+
+package driver
+
+import (
+	"time"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// NewFirecrackerDriver returns an unimplemented driver that returns false during
+// fingerprinting.
+func NewFirecrackerDriver(*DriverContext) Driver {
+	return FirecrackerDriver{}
+}
+
+type FirecrackerDriver struct{}
+
+func (FirecrackerDriver) Prestart(*ExecContext, *structs.Task) (*PrestartResponse, error) {
+	panic("not implemented")
+}
+
+func (FirecrackerDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse, error) {
+	panic("not implemented")
+}
+
+func (FirecrackerDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, error) {
+	panic("not implemented")
+}
+
+func (FirecrackerDriver) Cleanup(*ExecContext, *CreatedResources) error {
+	panic("not implemented")
+}
+
+func (FirecrackerDriver) Validate(map[string]interface{}) error {
+	panic("not implemented")
+}
+
+func (FirecrackerDriver) Abilities() DriverAbilities {
+	panic("not implemented")
+}
+
+func (FirecrackerDriver) FSIsolation() cstructs.FSIsolation {
+	panic("not implemented")
+}
+
+func (FirecrackerDriver) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	return nil
+}
+
+func (FirecrackerDriver) Periodic() (bool, time.Duration) {
+	return false, 0
+}
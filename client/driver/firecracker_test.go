@@ -0,0 +1,170 @@
+// +build linux
+
+package driver
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirecrackerVersionRegex(t *testing.T) {
+	t.Parallel()
+
+	matches := reFirecrackerVersion.FindStringSubmatch("Firecracker v1.7.0\n\n")
+	require.Len(t, matches, 2)
+	require.Equal(t, "1.7.0", matches[1])
+}
+
+func firecrackerTestTask() *structs.Task {
+	return &structs.Task{
+		Name:   "vm",
+		Driver: "firecracker",
+		Config: map[string]interface{}{
+			"kernel": "local/vmlinux",
+			"rootfs": "local/rootfs.ext4",
+			"init":   "/sbin/${NOMAD_TASK_NAME}-init",
+			"port_map": []map[string]string{
+				{"http": "8080"},
+			},
+		},
+		LogConfig: &structs.LogConfig{
+			MaxFiles:      10,
+			MaxFileSizeMB: 10,
+		},
+		Resources: &structs.Resources{
+			CPU:      3000,
+			MemoryMB: 256,
+		},
+	}
+}
+
+func TestFirecrackerDriver_Validate(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	task := firecrackerTestTask()
+	ctx := testDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	d := NewFirecrackerDriver(ctx.DriverCtx)
+
+	require.Nil(d.Validate(task.Config))
+	require.NotNil(d.Validate(map[string]interface{}{"rootfs": "local/rootfs.ext4"}))
+	require.NotNil(d.Validate(map[string]interface{}{"kernel": "local/vmlinux"}))
+	require.NotNil(d.Validate(map[string]interface{}{
+		"kernel": "local/vmlinux",
+		"rootfs": "local/rootfs.ext4",
+		"image":  "docker.io/library/alpine:3",
+	}))
+	require.NotNil(d.Validate(map[string]interface{}{
+		"kernel": "../../vmlinux",
+		"image":  "docker.io/library/alpine:3",
+	}))
+}
+
+func TestFirecrackerDriver_VMConfig(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	task := firecrackerTestTask()
+	ctx := testDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	ctx.DriverCtx.node = &structs.Node{
+		Attributes: map[string]string{
+			"cpu.frequency": "2000",
+			"cpu.numcores":  "4",
+		},
+	}
+	d := NewFirecrackerDriver(ctx.DriverCtx).(*FirecrackerDriver)
+
+	resp, err := d.Prestart(ctx.ExecCtx, task)
+	require.Nil(err)
+	require.Equal(map[string]int{"http": 8080}, resp.Network.PortMap)
+
+	conf, err := d.vmConfig(task, "/tmp/vmlinux")
+	require.Nil(err)
+	require.Equal(2, conf.MachineConfig.VCPUCount)
+	require.Equal(256-firecrackerVMMOverheadMB, conf.MachineConfig.MemSizeMib)
+	require.Equal(firecrackerDefaultBootArgs+" init=/sbin/vm-init", conf.BootSource.BootArgs)
+	require.Equal(filepath.Join(ctx.ExecCtx.TaskDir.Dir, "local", "rootfs.ext4"), conf.Drives[0].PathOnHost)
+	require.True(conf.Drives[0].IsRootDevice)
+	require.Empty(conf.NetworkInterfaces)
+
+	// VMs on a bridge get an interface and are configured by the kernel
+	d.tap = firecrackerTapName(ctx.DriverCtx.allocID, task.Name)
+	d.guest = &net.IPNet{IP: net.IPv4(10, 0, 0, 2).To4(), Mask: net.CIDRMask(24, 32)}
+	d.gateway = net.IPv4(10, 0, 0, 1).To4()
+	conf, err = d.vmConfig(task, "/tmp/vmlinux")
+	require.Nil(err)
+	require.Contains(conf.BootSource.BootArgs, " ip=10.0.0.2::10.0.0.1:255.255.255.0::eth0:off")
+	require.Equal([]firecrackerInterface{{
+		IfaceID:     "eth0",
+		GuestMAC:    "06:00:0a:00:00:02",
+		HostDevName: d.tap,
+	}}, conf.NetworkInterfaces)
+
+	data, err := json.Marshal(conf)
+	require.Nil(err)
+	require.Contains(string(data), `"machine-config":{"vcpu_count":2,"mem_size_mib":224}`)
+
+	task.Resources.MemoryMB = firecrackerVMMOverheadMB
+	_, err = d.vmConfig(task, "/tmp/vmlinux")
+	require.NotNil(err)
+}
+
+func TestFirecrackerVCPUs(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, 1, firecrackerVCPUs(500, 2000, 4))
+	require.Equal(t, 2, firecrackerVCPUs(2001, 2000, 4))
+	require.Equal(t, 4, firecrackerVCPUs(20000, 2000, 4))
+	require.Equal(t, 1, firecrackerVCPUs(20000, 0, 0))
+	require.Equal(t, firecrackerMaxVCPUs, firecrackerVCPUs(200000, 1000, 0))
+}
+
+func TestFirecrackerTapName(t *testing.T) {
+	t.Parallel()
+
+	name := firecrackerTapName("e0b2a7d6-4a3c-9a1e-5f0b-3e1c8e7a2d41", "web")
+	require.Len(t, name, 14)
+	require.Equal(t, name, firecrackerTapName("e0b2a7d6-4a3c-9a1e-5f0b-3e1c8e7a2d41", "web"))
+	require.NotEqual(t, name, firecrackerTapName("e0b2a7d6-4a3c-9a1e-5f0b-3e1c8e7a2d41", "db"))
+}
+
+func TestFirecrackerLeases(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "leases")
+	require.Nil(err)
+	defer os.RemoveAll(dir)
+	leaseDir := filepath.Join(dir, "leases")
+
+	_, subnet, _ := net.ParseCIDR("10.0.0.0/30")
+	gateway := net.IPv4(10, 0, 0, 1).To4()
+
+	// The gateway, network and broadcast addresses are never leased
+	ip, err := acquireLease(leaseDir, subnet, gateway, "a/web")
+	require.Nil(err)
+	require.Equal("10.0.0.2", ip.String())
+
+	// Owners get their existing lease back
+	ip, err = acquireLease(leaseDir, subnet, gateway, "a/web")
+	require.Nil(err)
+	require.Equal("10.0.0.2", ip.String())
+
+	_, err = acquireLease(leaseDir, subnet, gateway, "b/web")
+	require.NotNil(err)
+
+	require.Nil(releaseLease(leaseDir, "10.0.0.2"))
+	require.Nil(releaseLease(leaseDir, "10.0.0.2"))
+	ip, err = acquireLease(leaseDir, subnet, gateway, "b/web")
+	require.Nil(err)
+	require.Equal("10.0.0.2", ip.String())
+}
@@ -11,7 +11,6 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/hashicorp/go-multierror"
@@ -24,6 +23,7 @@ import (
 	"github.com/hashicorp/nomad/client/fingerprint"
 	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/helper/capabilities"
 	"github.com/hashicorp/nomad/helper/fields"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -112,10 +112,12 @@ func (d *JavaDriver) Abilities() DriverAbilities {
 }
 
 func (d *JavaDriver) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
-	// Only enable if we are root and cgroups are mounted when running on linux systems.
-	if runtime.GOOS == "linux" && (syscall.Geteuid() != 0 || !cgroupsMounted(req.Node)) {
+	// Only enable if we can isolate tasks and cgroups are mounted when running
+	// on linux systems.
+	if runtime.GOOS == "linux" && (!hasIsolationCapabilities(d.config) || !cgroupsMounted(req.Node)) {
 		if d.fingerprintSuccess == nil || *d.fingerprintSuccess {
-			d.logger.Printf("[INFO] driver.java: root privileges and mounted cgroups required on linux, disabling")
+			d.logger.Printf("[INFO] driver.java: root privileges or the %s capabilities and mounted cgroups required on linux, disabling",
+				capabilities.NewSet(isolationCapabilities(d.config)...))
 		}
 		d.fingerprintSuccess = helper.BoolToPtr(false)
 		resp.RemoveAttribute(javaDriverAttr)
@@ -237,6 +239,12 @@ func (d *JavaDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 		args = append(args, driverConfig.Args...)
 	}
 
+	if d.FSIsolation() == cstructs.FSIsolationChroot {
+		if err := writeResolvConf(ctx, task); err != nil {
+			return nil, err
+		}
+	}
+
 	pluginLogFile := filepath.Join(ctx.TaskDir.Dir, "executor.out")
 	executorConfig := &dstructs.ExecutorConfig{
 		LogFile:  pluginLogFile,
@@ -277,6 +285,7 @@ func (d *JavaDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 		FSIsolation:    true,
 		ResourceLimits: true,
 		User:           getExecutorUser(task),
+		CgroupParent:   d.config.CgroupParent(),
 		TaskKillSignal: taskKillSignal,
 	}
 	ps, err := execIntf.LaunchCmd(execCmd)
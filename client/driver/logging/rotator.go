@@ -3,6 +3,7 @@ package logging
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -288,3 +289,71 @@ func (f *FileRotator) createOrResetBuffer() {
 		f.bufw.Reset(f.currentFile)
 	}
 }
+
+// LastLines returns up to the last n lines of the rotated set of files of
+// baseFile in path, reading back through the rotated files as needed. At most
+// maxBytes are read, so the first line may be truncated.
+func LastLines(path, baseFile string, n int, maxBytes int64) ([]string, error) {
+	finfos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes []int
+	prefix := fmt.Sprintf("%s.", baseFile)
+	for _, fi := range finfos {
+		if fi.IsDir() || !strings.HasPrefix(fi.Name(), prefix) {
+			continue
+		}
+		if idx, err := strconv.Atoi(strings.TrimPrefix(fi.Name(), prefix)); err == nil {
+			indexes = append(indexes, idx)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+
+	// Lines may span rotated files, so the files are read from the newest
+	// until enough lines are read
+	var tail []byte
+	for _, idx := range indexes {
+		data, err := readTail(filepath.Join(path, fmt.Sprintf("%s.%d", baseFile, idx)), maxBytes-int64(len(tail)))
+		if err != nil {
+			return nil, err
+		}
+		tail = append(data, tail...)
+		if int64(len(tail)) >= maxBytes || strings.Count(strings.TrimRight(string(tail), "\n"), "\n") >= n {
+			break
+		}
+	}
+
+	text := strings.TrimRight(string(tail), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// readTail reads up to the last max bytes of a file.
+func readTail(path string, max int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := fi.Size() - max
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(f)
+}
@@ -289,3 +289,44 @@ func TestFileRotator_PurgeOldFiles(t *testing.T) {
 		t.Fatalf("%v", lastErr)
 	})
 }
+
+func TestLastLines(t *testing.T) {
+	t.Parallel()
+	path, err := ioutil.TempDir("", pathPrefix)
+	if err != nil {
+		t.Fatalf("test setup err: %v", err)
+	}
+	defer os.RemoveAll(path)
+
+	// A line spans the rotated files
+	files := map[string]string{
+		"redis.stderr.0": "one\ntwo\nthr",
+		"redis.stderr.1": "ee\nfour\n",
+		"redis.stdout.2": "not stderr\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(path, name), []byte(content), 0644); err != nil {
+			t.Fatalf("test setup err: %v", err)
+		}
+	}
+
+	lines, err := LastLines(path, "redis.stderr", 3, 1024)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fmt.Sprint(lines) != "[two three four]" {
+		t.Fatalf("unexpected lines: %q", lines)
+	}
+
+	// The bytes read are bounded
+	if lines, err = LastLines(path, "redis.stderr", 3, 10); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if fmt.Sprint(lines) != "[hree four]" {
+		t.Fatalf("unexpected lines: %q", lines)
+	}
+
+	if lines, err = LastLines(path, "redis.stdout", 3, 1024); err != nil || fmt.Sprint(lines) != "[not stderr]" {
+		t.Fatalf("unexpected lines %q: %v", lines, err)
+	}
+}
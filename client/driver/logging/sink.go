@@ -0,0 +1,459 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	syslog "github.com/RackSec/srslog"
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// sinkBufferLines is the number of lines buffered for each sink. Lines
+	// are dropped once the buffer is full, so that a slow sink never blocks
+	// the task.
+	sinkBufferLines = 4096
+
+	// sinkBatchLines is the maximum number of lines sent to a sink at once
+	sinkBatchLines = 256
+
+	// sinkFlushIntv is the interval at which the buffered lines are sent
+	sinkFlushIntv = time.Second
+
+	// sinkCloseTimeout is how long closing a shipper waits for the buffered
+	// lines to be sent
+	sinkCloseTimeout = 5 * time.Second
+
+	// sinkHTTPTimeout is the timeout of the requests made to HTTP sinks
+	sinkHTTPTimeout = 10 * time.Second
+
+	// maxLineSize is the size at which a line without a newline is shipped
+	maxLineSize = 64 * 1024
+)
+
+// LogLine is a line of a log stream of a task.
+type LogLine struct {
+	Time time.Time
+	Line []byte
+}
+
+// StreamMeta identifies the log stream of a task shipped to the sinks.
+type StreamMeta struct {
+	AllocID  string
+	JobName  string
+	TaskName string
+
+	// Stream is either stdout or stderr
+	Stream string
+}
+
+// labels returns the labels of the stream merged with the labels of the sink.
+func (m *StreamMeta) labels(sink *structs.LogSink) map[string]string {
+	labels := map[string]string{
+		"alloc_id": m.AllocID,
+		"job":      m.JobName,
+		"task":     m.TaskName,
+		"stream":   m.Stream,
+	}
+	for k, v := range sink.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// Sink ships batches of log lines to a remote destination.
+type Sink interface {
+	// Send ships the lines, returning an error if they were not delivered
+	Send(lines []*LogLine) error
+
+	// Close releases the resources of the sink
+	Close() error
+}
+
+// SinkFactory returns a sink shipping the lines of the stream to the
+// destination described by sink.
+type SinkFactory func(sink *structs.LogSink, meta *StreamMeta) (Sink, error)
+
+// sinkFactories are the factories of each type of sink
+var sinkFactories = map[string]SinkFactory{
+	structs.LogSinkSyslog: newSyslogSink,
+	structs.LogSinkHTTP:   newHTTPSink,
+	structs.LogSinkLoki:   newLokiSink,
+}
+
+// LogShipper is a writer shipping the lines written to it to the sinks of a
+// log stream. Writes never block on the sinks and never fail.
+type LogShipper struct {
+	meta    StreamMeta
+	logger  *log.Logger
+	confs   []*structs.LogSink
+	workers []*sinkWorker
+	partial []byte
+	l       sync.Mutex
+}
+
+// NewLogShipper returns a shipper of the stream without any sink.
+func NewLogShipper(meta StreamMeta, logger *log.Logger) *LogShipper {
+	return &LogShipper{
+		meta:   meta,
+		logger: logger,
+	}
+}
+
+// SetSinks replaces the sinks of the shipper, unless they are unchanged. The
+// lines buffered for the replaced sinks are still sent.
+func (s *LogShipper) SetSinks(confs []*structs.LogSink) error {
+	s.l.Lock()
+	if reflect.DeepEqual(confs, s.confs) {
+		s.l.Unlock()
+		return nil
+	}
+
+	workers := make([]*sinkWorker, 0, len(confs))
+	for _, conf := range confs {
+		factory, ok := sinkFactories[conf.Type]
+		if !ok {
+			s.l.Unlock()
+			closeWorkers(workers)
+			return fmt.Errorf("unknown log sink type %q", conf.Type)
+		}
+		sink, err := factory(conf, &s.meta)
+		if err != nil {
+			s.l.Unlock()
+			closeWorkers(workers)
+			return fmt.Errorf("failed to create %s log sink: %v", conf.Type, err)
+		}
+		workers = append(workers, newSinkWorker(conf, sink, s.logger))
+	}
+
+	old := s.workers
+	s.confs = confs
+	s.workers = workers
+	s.l.Unlock()
+
+	closeWorkers(old)
+	return nil
+}
+
+// Write splits p into lines and queues them for each sink.
+func (s *LogShipper) Write(p []byte) (int, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if len(s.workers) == 0 {
+		return len(p), nil
+	}
+
+	now := time.Now()
+	data := p
+	for len(data) > 0 {
+		idx := bytes.IndexByte(data, '\n')
+		if idx == -1 {
+			s.partial = append(s.partial, data...)
+			if len(s.partial) >= maxLineSize {
+				s.ship(now, s.partial)
+				s.partial = nil
+			}
+			break
+		}
+
+		line := data[:idx]
+		if len(s.partial) > 0 {
+			line = append(s.partial, line...)
+			s.partial = nil
+		}
+		s.ship(now, line)
+		data = data[idx+1:]
+	}
+	return len(p), nil
+}
+
+// ship queues a copy of the line for each sink. The lock must be held.
+func (s *LogShipper) ship(t time.Time, line []byte) {
+	l := &LogLine{Time: t, Line: append([]byte(nil), line...)}
+	for _, w := range s.workers {
+		w.queue(l)
+	}
+}
+
+// Close ships the partial line left, then waits for the buffered lines to be
+// sent and closes the sinks.
+func (s *LogShipper) Close() error {
+	s.l.Lock()
+	if len(s.partial) > 0 {
+		s.ship(time.Now(), s.partial)
+		s.partial = nil
+	}
+	workers := s.workers
+	s.workers = nil
+	s.confs = nil
+	s.l.Unlock()
+
+	closeWorkers(workers)
+	return nil
+}
+
+// closeWorkers closes the workers concurrently and waits for them.
+func closeWorkers(workers []*sinkWorker) {
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w *sinkWorker) {
+			defer wg.Done()
+			w.close()
+		}(w)
+	}
+	wg.Wait()
+}
+
+// sinkWorker buffers the lines of a sink and sends them in batches.
+type sinkWorker struct {
+	conf    *structs.LogSink
+	sink    Sink
+	logger  *log.Logger
+	lines   chan *LogLine
+	doneCh  chan struct{}
+	dropped int
+	l       sync.Mutex
+}
+
+func newSinkWorker(conf *structs.LogSink, sink Sink, logger *log.Logger) *sinkWorker {
+	w := &sinkWorker{
+		conf:   conf,
+		sink:   sink,
+		logger: logger,
+		lines:  make(chan *LogLine, sinkBufferLines),
+		doneCh: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// queue buffers the line, dropping it if the buffer is full.
+func (w *sinkWorker) queue(line *LogLine) {
+	select {
+	case w.lines <- line:
+	default:
+		w.l.Lock()
+		w.dropped++
+		w.l.Unlock()
+	}
+}
+
+func (w *sinkWorker) run() {
+	defer close(w.doneCh)
+	ticker := time.NewTicker(sinkFlushIntv)
+	defer ticker.Stop()
+
+	var batch []*LogLine
+	for {
+		select {
+		case line, ok := <-w.lines:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, line)
+			if len(batch) < sinkBatchLines {
+				continue
+			}
+		case <-ticker.C:
+		}
+
+		w.flush(batch)
+		batch = nil
+	}
+}
+
+// flush sends the batch. Batches that fail are dropped, as the lines are
+// still in the rotated log files.
+func (w *sinkWorker) flush(batch []*LogLine) {
+	w.l.Lock()
+	dropped := w.dropped
+	w.dropped = 0
+	w.l.Unlock()
+	if dropped > 0 {
+		w.logger.Printf("[WARN] logging: dropped %d log lines for %s sink %q as it is too slow", dropped, w.conf.Type, w.conf.Address)
+	}
+
+	if len(batch) == 0 {
+		return
+	}
+	if err := w.sink.Send(batch); err != nil {
+		w.logger.Printf("[WARN] logging: failed to ship %d log lines to %s sink %q: %v", len(batch), w.conf.Type, w.conf.Address, err)
+	}
+}
+
+// close waits for the buffered lines to be sent and closes the sink.
+func (w *sinkWorker) close() {
+	close(w.lines)
+	select {
+	case <-w.doneCh:
+	case <-time.After(sinkCloseTimeout):
+		w.logger.Printf("[WARN] logging: timed out shipping the log lines left to %s sink %q", w.conf.Type, w.conf.Address)
+	}
+	w.sink.Close()
+}
+
+// syslogSink ships the lines as syslog messages. The lines of stderr are sent
+// with the error severity.
+type syslogSink struct {
+	network  string
+	raddr    string
+	tag      string
+	priority syslog.Priority
+	w        *syslog.Writer
+}
+
+func newSyslogSink(sink *structs.LogSink, meta *StreamMeta) (Sink, error) {
+	u, err := url.Parse(sink.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &syslogSink{
+		network:  u.Scheme,
+		raddr:    u.Host,
+		tag:      meta.TaskName,
+		priority: syslog.LOG_USER | syslog.LOG_INFO,
+	}
+	if u.Scheme == "unix" {
+		s.raddr = u.Path
+	}
+	if tag := sink.Labels["tag"]; tag != "" {
+		s.tag = tag
+	}
+	if meta.Stream == "stderr" {
+		s.priority = syslog.LOG_USER | syslog.LOG_ERR
+	}
+	return s, nil
+}
+
+func (s *syslogSink) Send(lines []*LogLine) error {
+	// Dial lazily so that an unreachable server doesn't fail the task
+	if s.w == nil {
+		w, err := syslog.Dial(s.network, s.raddr, s.priority, s.tag)
+		if err != nil {
+			return err
+		}
+		w.SetFormatter(syslog.RFC5424Formatter)
+		s.w = w
+	}
+
+	for _, l := range lines {
+		if _, err := s.w.Write(l.Line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syslogSink) Close() error {
+	if s.w == nil {
+		return nil
+	}
+	return s.w.Close()
+}
+
+// httpSink posts the lines as newline delimited JSON objects.
+type httpSink struct {
+	address string
+	labels  map[string]string
+	client  *http.Client
+}
+
+func newHTTPSink(sink *structs.LogSink, meta *StreamMeta) (Sink, error) {
+	client := cleanhttp.DefaultClient()
+	client.Timeout = sinkHTTPTimeout
+	return &httpSink{
+		address: sink.Address,
+		labels:  meta.labels(sink),
+		client:  client,
+	}, nil
+}
+
+func (s *httpSink) Send(lines []*LogLine) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, l := range lines {
+		entry := map[string]string{
+			"time":    l.Time.UTC().Format(time.RFC3339Nano),
+			"message": string(l.Line),
+		}
+		for k, v := range s.labels {
+			if _, ok := entry[k]; !ok {
+				entry[k] = v
+			}
+		}
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return postSink(s.client, s.address, "application/x-ndjson", &buf)
+}
+
+func (s *httpSink) Close() error {
+	return nil
+}
+
+// lokiSink pushes the lines to the push API of Loki, as a single stream
+// labeled with the labels of the sink.
+type lokiSink struct {
+	address string
+	labels  map[string]string
+	client  *http.Client
+}
+
+func newLokiSink(sink *structs.LogSink, meta *StreamMeta) (Sink, error) {
+	client := cleanhttp.DefaultClient()
+	client.Timeout = sinkHTTPTimeout
+	return &lokiSink{
+		address: sink.Address,
+		labels:  meta.labels(sink),
+		client:  client,
+	}, nil
+}
+
+func (s *lokiSink) Send(lines []*LogLine) error {
+	type lokiStream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	stream := lokiStream{Stream: s.labels, Values: make([][2]string, len(lines))}
+	for i, l := range lines {
+		stream.Values[i] = [2]string{strconv.FormatInt(l.Time.UnixNano(), 10), string(l.Line)}
+	}
+
+	body, err := json.Marshal(map[string][]lokiStream{"streams": {stream}})
+	if err != nil {
+		return err
+	}
+	return postSink(s.client, s.address, "application/json", bytes.NewReader(body))
+}
+
+func (s *lokiSink) Close() error {
+	return nil
+}
+
+// postSink posts the body to the address, expecting a successful response.
+func postSink(client *http.Client, address, contentType string, body io.Reader) error {
+	resp, err := client.Post(address, contentType, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+	return nil
+}
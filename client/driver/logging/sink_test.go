@@ -0,0 +1,163 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+var testMeta = StreamMeta{
+	AllocID:  "alloc",
+	JobName:  "job",
+	TaskName: "web",
+	Stream:   "stdout",
+}
+
+// testCollector is an HTTP server recording the bodies posted to it
+type testCollector struct {
+	bodies []string
+	l      sync.Mutex
+}
+
+func (c *testCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	c.l.Lock()
+	c.bodies = append(c.bodies, string(body))
+	c.l.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *testCollector) Bodies() []string {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return append([]string(nil), c.bodies...)
+}
+
+func TestLogShipper_HTTP(t *testing.T) {
+	c := &testCollector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	s := NewLogShipper(testMeta, logger)
+	sinks := []*structs.LogSink{
+		{
+			Type:    structs.LogSinkHTTP,
+			Address: srv.URL,
+			Labels:  map[string]string{"env": "prod"},
+		},
+	}
+	if err := s.SetSinks(sinks); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lines may be split across writes
+	s.Write([]byte("hello\nwor"))
+	s.Write([]byte("ld\npartial"))
+	if err := s.Close(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	var messages []string
+	for _, body := range c.Bodies() {
+		scanner := bufio.NewScanner(strings.NewReader(body))
+		for scanner.Scan() {
+			var entry map[string]string
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Fatalf("invalid entry %q: %v", scanner.Text(), err)
+			}
+			if entry["env"] != "prod" || entry["task"] != "web" || entry["alloc_id"] != "alloc" || entry["stream"] != "stdout" {
+				t.Fatalf("unexpected labels: %v", entry)
+			}
+			messages = append(messages, entry["message"])
+		}
+	}
+	if expected := "hello,world,partial"; strings.Join(messages, ",") != expected {
+		t.Fatalf("got messages %v; want %s", messages, expected)
+	}
+}
+
+func TestLogShipper_Loki(t *testing.T) {
+	c := &testCollector{}
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	s := NewLogShipper(testMeta, logger)
+	if err := s.SetSinks([]*structs.LogSink{{Type: structs.LogSinkLoki, Address: srv.URL}}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s.Write([]byte("one\ntwo\n"))
+	s.Close()
+
+	bodies := c.Bodies()
+	if len(bodies) != 1 {
+		t.Fatalf("expected a single push, got %v", bodies)
+	}
+	var push struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal([]byte(bodies[0]), &push); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(push.Streams) != 1 || push.Streams[0].Stream["job"] != "job" {
+		t.Fatalf("unexpected streams: %+v", push.Streams)
+	}
+	values := push.Streams[0].Values
+	if len(values) != 2 || values[0][1] != "one" || values[1][1] != "two" {
+		t.Fatalf("unexpected values: %v", values)
+	}
+}
+
+func TestLogShipper_Syslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer conn.Close()
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	s := NewLogShipper(testMeta, logger)
+	sinks := []*structs.LogSink{{Type: structs.LogSinkSyslog, Address: "udp://" + conn.LocalAddr().String()}}
+	if err := s.SetSinks(sinks); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	s.Write([]byte("syslog line\n"))
+	s.Close()
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if msg := string(buf[:n]); !strings.Contains(msg, "web") || !strings.HasSuffix(strings.TrimSpace(msg), "syslog line") {
+		t.Fatalf("unexpected message %q", msg)
+	}
+}
+
+func TestLogShipper_NoSinks(t *testing.T) {
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	s := NewLogShipper(testMeta, logger)
+	if n, err := s.Write([]byte("dropped\n")); err != nil || n != 8 {
+		t.Fatalf("unexpected write result %d: %v", n, err)
+	}
+	if err := s.SetSinks([]*structs.LogSink{{Type: "kafka", Address: "tcp://127.0.0.1:9092"}}); err == nil {
+		t.Fatalf("expected error setting an unknown sink")
+	}
+	s.Close()
+}
@@ -7,8 +7,10 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -83,6 +85,9 @@ type MockDriverConfig struct {
 	// DriverPortMap will parse a label:number pair and return it in
 	// DriverNetwork.PortMap from Start().
 	DriverPortMap string `mapstructure:"driver_port_map"`
+
+	// Checkpoint lets the task be checkpointed and restored
+	Checkpoint bool `mapstructure:"checkpoint"`
 }
 
 // MockDriver is a driver which is used for testing purposes
@@ -178,6 +183,7 @@ func (m *MockDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 		logger:      m.logger,
 		doneCh:      make(chan struct{}),
 		waitCh:      make(chan *dstructs.WaitResult, 1),
+		checkpoint:  driverConfig.Checkpoint,
 	}
 	if driverConfig.ExitErrMsg != "" {
 		h.exitErr = errors.New(driverConfig.ExitErrMsg)
@@ -185,10 +191,20 @@ func (m *MockDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 	if driverConfig.SignalErr != "" {
 		h.signalErr = fmt.Errorf(driverConfig.SignalErr)
 	}
+	// Restore the task if it was checkpointed
+	restored := false
+	if driverConfig.Checkpoint && ctx.TaskDir != nil {
+		checkpoint, err := ctx.TaskDir.ReadCheckpoint()
+		if err != nil {
+			return nil, err
+		}
+		restored = checkpoint != nil && checkpoint.Driver == "mock_driver"
+	}
+
 	m.logger.Printf("[DEBUG] driver.mock: starting task %q", task.Name)
 	go h.run()
 
-	return &StartResponse{Handle: &h, Network: net}, nil
+	return &StartResponse{Handle: &h, Network: net, Restored: restored}, nil
 }
 
 // Cleanup deletes all keys except for Config.Options["cleanup_fail_on"] for
@@ -246,6 +262,7 @@ type mockDriverHandle struct {
 	logger      *log.Logger
 	waitCh      chan *dstructs.WaitResult
 	doneCh      chan struct{}
+	checkpoint  bool
 }
 
 type mockDriverID struct {
@@ -257,6 +274,7 @@ type mockDriverID struct {
 	ExitSignal  int
 	ExitErr     error
 	SignalErr   error
+	Checkpoint  bool
 }
 
 func (h *mockDriverHandle) ID() string {
@@ -269,6 +287,7 @@ func (h *mockDriverHandle) ID() string {
 		ExitSignal:  h.exitSignal,
 		ExitErr:     h.exitErr,
 		SignalErr:   h.signalErr,
+		Checkpoint:  h.checkpoint,
 	}
 
 	data, err := json.Marshal(id)
@@ -297,6 +316,7 @@ func (m *MockDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, erro
 		logger:      m.logger,
 		doneCh:      make(chan struct{}),
 		waitCh:      make(chan *dstructs.WaitResult, 1),
+		checkpoint:  id.Checkpoint,
 	}
 
 	go h.run()
@@ -347,6 +367,23 @@ func (h *mockDriverHandle) Kill() error {
 	return nil
 }
 
+func (h *mockDriverHandle) Checkpointable() bool {
+	return h.checkpoint
+}
+
+// Checkpoint writes a fake image into the directory and stops the task
+func (h *mockDriverHandle) Checkpoint(dir string) error {
+	if err := ioutil.WriteFile(filepath.Join(dir, "mock.img"), []byte(h.taskName), 0600); err != nil {
+		return err
+	}
+	select {
+	case <-h.doneCh:
+	default:
+		close(h.doneCh)
+	}
+	return nil
+}
+
 // TODO Implement when we need it.
 func (h *mockDriverHandle) Stats() (*cstructs.TaskResourceUsage, error) {
 	return nil, nil
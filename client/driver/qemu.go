@@ -49,6 +49,17 @@ const (
 	qemuMonitorSocketName   = "qemu-monitor.sock"
 	// Maximum socket path length prior to qemu 2.10.1
 	qemuLegacyMaxMonitorPathLen = 108
+
+	// qemuVolumesConfigOption is the key for enabling the use of custom
+	// virtio-fs volumes of arbitrary host paths.
+	qemuVolumesConfigOption  = "qemu.volumes.enabled"
+	qemuVolumesConfigDefault = true
+
+	// qemuVirtiofsdConfigOption is the key for setting the path of the
+	// virtiofsd daemon sharing volumes with VMs. It is looked up in the PATH
+	// by default.
+	qemuVirtiofsdConfigOption  = "qemu.virtiofsd"
+	qemuVirtiofsdConfigDefault = "virtiofsd"
 )
 
 // QemuDriver is a driver for running images via Qemu
@@ -59,14 +70,21 @@ type QemuDriver struct {
 	fingerprint.StaticFingerprinter
 
 	driverConfig *QemuDriverConfig
+
+	// cloudInitISO is the path of the cloud-init seed of the VM and volumes
+	// the host paths shared with it. They are set by Prestart.
+	cloudInitISO string
+	volumes      []qemuVolume
 }
 
 type QemuDriverConfig struct {
-	ImagePath        string           `mapstructure:"image_path"`
-	Accelerator      string           `mapstructure:"accelerator"`
-	GracefulShutdown bool             `mapstructure:"graceful_shutdown"`
-	PortMap          []map[string]int `mapstructure:"port_map"` // A map of host port labels and to guest ports.
-	Args             []string         `mapstructure:"args"`     // extra arguments to qemu executable
+	ImagePath        string              `mapstructure:"image_path"`
+	Accelerator      string              `mapstructure:"accelerator"`
+	GracefulShutdown bool                `mapstructure:"graceful_shutdown"`
+	PortMap          []map[string]int    `mapstructure:"port_map"`   // A map of host port labels and to guest ports.
+	Args             []string            `mapstructure:"args"`       // extra arguments to qemu executable
+	CloudInit        []map[string]string `mapstructure:"cloud_init"` // Files of the cloud-init seed of the VM
+	Volumes          []string            `mapstructure:"volumes"`    // Host paths shared with the VM, syntax: /path/to/host/directory:tag[:ro]
 }
 
 // qemuHandle is returned from Start/Open as a handle to the PID
@@ -75,6 +93,7 @@ type qemuHandle struct {
 	userPid        int
 	executor       executor.Executor
 	monitorPath    string
+	virtiofsd      []*os.Process
 	killTimeout    time.Duration
 	maxKillTimeout time.Duration
 	logger         *log.Logger
@@ -133,6 +152,12 @@ func (d *QemuDriver) Validate(config map[string]interface{}) error {
 			"args": {
 				Type: fields.TypeArray,
 			},
+			"cloud_init": {
+				Type: fields.TypeArray,
+			},
+			"volumes": {
+				Type: fields.TypeArray,
+			},
 		},
 	}
 
@@ -140,6 +165,18 @@ func (d *QemuDriver) Validate(config map[string]interface{}) error {
 		return err
 	}
 
+	var driverConfig QemuDriverConfig
+	if err := mapstructure.WeakDecode(config, &driverConfig); err != nil {
+		return err
+	}
+	if len(driverConfig.CloudInit) > 1 {
+		return fmt.Errorf("Only one cloud_init block is allowed in the qemu driver config")
+	}
+	for _, ci := range driverConfig.CloudInit {
+		if err := validateCloudInit(ci); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -180,10 +217,17 @@ func (d *QemuDriver) Fingerprint(req *cstructs.FingerprintRequest, resp *cstruct
 	resp.AddAttribute(qemuDriverVersionAttr, currentQemuVersion)
 	resp.Detected = true
 
+	// Advertise if this node can build cloud-init seeds
+	if _, err := cloudInitISOTool(); err == nil {
+		resp.AddAttribute("driver.qemu.cloud_init", "1")
+	}
+
 	return nil
 }
 
-func (d *QemuDriver) Prestart(_ *ExecContext, task *structs.Task) (*PrestartResponse, error) {
+// Prestart builds the cloud-init seed of the VM, so that it is rebuilt from
+// the latest templates each time the task starts
+func (d *QemuDriver) Prestart(ctx *ExecContext, task *structs.Task) (*PrestartResponse, error) {
 	var driverConfig QemuDriverConfig
 	if err := mapstructure.WeakDecode(task.Config, &driverConfig); err != nil {
 		return nil, err
@@ -192,9 +236,27 @@ func (d *QemuDriver) Prestart(_ *ExecContext, task *structs.Task) (*PrestartResp
 	if len(driverConfig.PortMap) > 1 {
 		return nil, fmt.Errorf("Only one port_map block is allowed in the qemu driver config")
 	}
+	if len(driverConfig.CloudInit) > 1 {
+		return nil, fmt.Errorf("Only one cloud_init block is allowed in the qemu driver config")
+	}
 
 	d.driverConfig = &driverConfig
 
+	if len(driverConfig.CloudInit) == 1 {
+		iso, err := buildCloudInitISO(ctx.TaskDir.Dir, ctx.TaskEnv, driverConfig.CloudInit[0], d.allocID, d.taskName)
+		if err != nil {
+			return nil, err
+		}
+		d.cloudInitISO = iso
+	}
+
+	volumesEnabled := d.config.ReadBoolDefault(qemuVolumesConfigOption, qemuVolumesConfigDefault)
+	volumes, err := parseQemuVolumes(driverConfig.Volumes, ctx.TaskDir.Dir, volumesEnabled)
+	if err != nil {
+		return nil, err
+	}
+	d.volumes = volumes
+
 	r := NewPrestartResponse()
 	if len(driverConfig.PortMap) == 1 {
 		r.Network = &cstructs.DriverNetwork{
@@ -263,6 +325,11 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 	// This will allow a VM with embedded configuration to boot successfully.
 	args = append(args, d.driverConfig.Args...)
 
+	// The seed is found by cloud-init from its cidata label
+	if d.cloudInitISO != "" {
+		args = append(args, "-drive", fmt.Sprintf("file=%s,media=cdrom", d.cloudInitISO))
+	}
+
 	// Check the Resources required Networks to add port mappings. If no resources
 	// are required, we assume the VM is a purely compute job and does not require
 	// the outside world to be able to reach it. VMs ran without port mappings can
@@ -308,6 +375,23 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 		)
 	}
 
+	var virtiofsd []*os.Process
+	if len(d.volumes) > 0 {
+		if runtime.GOOS != "linux" {
+			return nil, errors.New("QEMU volumes are only supported on Linux")
+		}
+		bin, err := GetAbsolutePath(d.config.ReadDefault(qemuVirtiofsdConfigOption, qemuVirtiofsdConfigDefault))
+		if err != nil {
+			return nil, err
+		}
+		sockets, procs, err := startVirtiofsd(d.logger, bin, ctx.TaskDir.Dir, d.volumes)
+		if err != nil {
+			return nil, err
+		}
+		virtiofsd = procs
+		args = append(args, virtiofsArgs(d.volumes, sockets, mem)...)
+	}
+
 	d.logger.Printf("[DEBUG] driver.qemu: starting QemuVM command: %q", strings.Join(args, " "))
 	pluginLogFile := filepath.Join(ctx.TaskDir.Dir, "executor.out")
 	executorConfig := &dstructs.ExecutorConfig{
@@ -317,6 +401,7 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 
 	exec, pluginClient, err := createExecutor(d.config.LogOutput, d.config, executorConfig)
 	if err != nil {
+		killVirtiofsd(virtiofsd)
 		return nil, err
 	}
 	executorCtx := &executor.ExecutorContext{
@@ -328,6 +413,7 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 	}
 	if err := exec.SetContext(executorCtx); err != nil {
 		pluginClient.Kill()
+		killVirtiofsd(virtiofsd)
 		return nil, fmt.Errorf("failed to set executor context: %v", err)
 	}
 
@@ -339,6 +425,7 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 	ps, err := exec.LaunchCmd(execCmd)
 	if err != nil {
 		pluginClient.Kill()
+		killVirtiofsd(virtiofsd)
 		return nil, err
 	}
 	d.logger.Printf("[INFO] driver.qemu: started new QemuVM: %s", vmID)
@@ -352,6 +439,7 @@ func (d *QemuDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse
 		killTimeout:    GetKillTimeout(task.KillTimeout, maxKill),
 		maxKillTimeout: maxKill,
 		monitorPath:    monitorPath,
+		virtiofsd:      virtiofsd,
 		version:        d.config.Version.VersionNumber(),
 		logger:         d.logger,
 		doneCh:         make(chan struct{}),
@@ -506,6 +594,9 @@ func (h *qemuHandle) run() {
 	}
 	close(h.doneCh)
 
+	// virtiofsd exits once the VM disconnects, unless the VM never connected
+	killVirtiofsd(h.virtiofsd)
+
 	// Exit the executor
 	h.executor.Exit()
 	h.pluginClient.Kill()
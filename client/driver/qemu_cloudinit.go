@@ -0,0 +1,125 @@
+package driver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+
+	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// qemuCloudInitISOName is the name of the cloud-init seed of the VM in
+	// the task directory
+	qemuCloudInitISOName = "cloud-init.iso"
+
+	// cloudInitVolumeLabel is the label of the seeds of the NoCloud
+	// datasource of cloud-init
+	cloudInitVolumeLabel = "cidata"
+)
+
+var (
+	// cloudInitFiles maps the keys of the cloud_init block to the files of
+	// the seed
+	cloudInitFiles = map[string]string{
+		"user_data":      "user-data",
+		"meta_data":      "meta-data",
+		"network_config": "network-config",
+	}
+
+	// cloudInitISOTools are the tools able to build seeds, in order of
+	// preference. They share the same arguments.
+	cloudInitISOTools = []string{"genisoimage", "mkisofs", "xorrisofs"}
+)
+
+// validateCloudInit validates the cloud_init block of the task, whose values
+// are the paths of files relative to the task directory
+func validateCloudInit(ci map[string]string) error {
+	for k, path := range ci {
+		if _, ok := cloudInitFiles[k]; !ok {
+			return fmt.Errorf("invalid cloud_init key %q", k)
+		}
+		escapes, err := structs.PathEscapesAllocDir("task", path)
+		if err != nil {
+			return err
+		}
+		if escapes {
+			return fmt.Errorf("cloud_init %s %q escapes the allocation directory", k, path)
+		}
+	}
+	return nil
+}
+
+// cloudInitISOTool returns the path of the tool used to build seeds
+func cloudInitISOTool() (string, error) {
+	for _, tool := range cloudInitISOTools {
+		if path, err := exec.LookPath(tool); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("none of %v found to build the cloud-init seed", cloudInitISOTools)
+}
+
+// cloudInitMetaData returns the meta-data of VMs which don't set their own.
+// The instance is the allocation, so that cloud-init configures each
+// allocation of the task once.
+func cloudInitMetaData(allocID, taskName string) string {
+	return fmt.Sprintf("instance-id: %q\nlocal-hostname: %q\n", allocID, taskName)
+}
+
+// buildCloudInitISO builds the cloud-init seed of the VM in the task
+// directory from the files of the cloud_init block, which are usually
+// rendered by templates. The user-data is empty and the meta-data generated
+// when unset.
+func buildCloudInitISO(taskDir string, taskEnv *env.TaskEnv, ci map[string]string, allocID, taskName string) (string, error) {
+	if err := validateCloudInit(ci); err != nil {
+		return "", err
+	}
+	tool, err := cloudInitISOTool()
+	if err != nil {
+		return "", err
+	}
+
+	seedDir, err := ioutil.TempDir(taskDir, "cloud-init")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(seedDir)
+
+	contents := map[string][]byte{
+		"user-data": nil,
+		"meta-data": []byte(cloudInitMetaData(allocID, taskName)),
+	}
+	for k, path := range ci {
+		data, err := ioutil.ReadFile(filepath.Join(taskDir, taskEnv.ReplaceEnv(path)))
+		if err != nil {
+			return "", fmt.Errorf("failed to read cloud_init %s: %v", k, err)
+		}
+		contents[cloudInitFiles[k]] = data
+	}
+
+	names := make([]string, 0, len(contents))
+	for name := range contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	iso := filepath.Join(taskDir, qemuCloudInitISOName)
+	args := []string{"-output", iso, "-volid", cloudInitVolumeLabel, "-joliet", "-rock", "-quiet"}
+	for _, name := range names {
+		path := filepath.Join(seedDir, name)
+		if err := ioutil.WriteFile(path, contents[name], 0600); err != nil {
+			return "", err
+		}
+		args = append(args, path)
+	}
+
+	if out, err := exec.Command(tool, args...).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to build cloud-init seed: %v: %s", err, out)
+	}
+	return iso, nil
+}
@@ -2,8 +2,10 @@ package driver
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"syscall"
 	"testing"
@@ -441,3 +443,114 @@ func TestQemuDriverGetMonitorPathNewQemu(t *testing.T) {
 		t.Fatal("Should not have returned an error")
 	}
 }
+
+func TestQemuDriver_ValidateCloudInit(t *testing.T) {
+	t.Parallel()
+	d := NewQemuDriver(NewEmptyDriverContext())
+
+	config := map[string]interface{}{
+		"image_path": "linux-0.2.img",
+		"cloud_init": []map[string]string{{
+			"user_data": "local/user-data",
+			"meta_data": "local/meta-data",
+		}},
+	}
+	if err := d.Validate(config); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	config["cloud_init"] = []map[string]string{{"vendor_data": "local/vendor-data"}}
+	if err := d.Validate(config); err == nil {
+		t.Fatalf("expected an error for an invalid key")
+	}
+
+	config["cloud_init"] = []map[string]string{{"user_data": "../../user-data"}}
+	if err := d.Validate(config); err == nil {
+		t.Fatalf("expected an error for a path escaping the alloc dir")
+	}
+}
+
+func TestQemuDriver_CloudInit(t *testing.T) {
+	if !testutil.IsTravis() {
+		t.Parallel()
+	}
+	if _, err := cloudInitISOTool(); err != nil {
+		t.Skip(err)
+	}
+	task := &structs.Task{
+		Name:   "linux",
+		Driver: "qemu",
+		Config: map[string]interface{}{
+			"image_path": "linux-0.2.img",
+			"cloud_init": []map[string]string{{
+				"user_data": "local/user-data",
+			}},
+		},
+		Resources: structs.DefaultResources(),
+	}
+	ctx := testDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	d := NewQemuDriver(ctx.DriverCtx).(*QemuDriver)
+
+	userData := filepath.Join(ctx.ExecCtx.TaskDir.LocalDir, "user-data")
+	if err := ioutil.WriteFile(userData, []byte("#cloud-config\n"), 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := d.Prestart(ctx.ExecCtx, task); err != nil {
+		t.Fatalf("Prestart failed: %v", err)
+	}
+	if d.cloudInitISO != filepath.Join(ctx.ExecCtx.TaskDir.Dir, qemuCloudInitISOName) {
+		t.Fatalf("unexpected seed %q", d.cloudInitISO)
+	}
+	if _, err := os.Stat(d.cloudInitISO); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestQemuCloudInitMetaData(t *testing.T) {
+	t.Parallel()
+	expected := "instance-id: \"5a1c\"\nlocal-hostname: \"web\"\n"
+	if md := cloudInitMetaData("5a1c", "web"); md != expected {
+		t.Fatalf("expected %q; got %q", expected, md)
+	}
+}
+
+func TestQemuDriver_Volumes(t *testing.T) {
+	t.Parallel()
+
+	volumes, err := parseQemuVolumes([]string{"data:data", "/srv/config:config:ro"}, "/tmp/task", true)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := []qemuVolume{
+		{HostPath: "/tmp/task/data", Tag: "data"},
+		{HostPath: "/srv/config", Tag: "config", ReadOnly: true},
+	}
+	if !reflect.DeepEqual(volumes, expected) {
+		t.Fatalf("expected %#v; got %#v", expected, volumes)
+	}
+
+	args := strings.Join(virtiofsArgs(volumes, []string{"/tmp/task/virtiofs-0.sock", "/tmp/task/virtiofs-1.sock"}, "512M"), " ")
+	for _, arg := range []string{
+		"-object memory-backend-memfd,id=mem,size=512M,share=on -numa node,memdev=mem",
+		"-chardev socket,id=virtiofs0,path=/tmp/task/virtiofs-0.sock -device vhost-user-fs-pci,queue-size=1024,chardev=virtiofs0,tag=data",
+		"-chardev socket,id=virtiofs1,path=/tmp/task/virtiofs-1.sock -device vhost-user-fs-pci,queue-size=1024,chardev=virtiofs1,tag=config",
+	} {
+		if !strings.Contains(args, arg) {
+			t.Fatalf("expected %q in %q", arg, args)
+		}
+	}
+
+	// Absolute host paths are only shared when enabled
+	if _, err := parseQemuVolumes([]string{"/srv/config:config"}, "/tmp/task", false); err == nil {
+		t.Fatalf("expected an error sharing a host path")
+	}
+	for _, invalid := range []string{"data", "data:", "data:data:rw", "a:" + strings.Repeat("x", virtiofsMaxTagLen+1)} {
+		if _, err := parseQemuVolumes([]string{invalid}, "/tmp/task", true); err == nil {
+			t.Fatalf("expected an error for %q", invalid)
+		}
+	}
+	if _, err := parseQemuVolumes([]string{"a:data", "b:data"}, "/tmp/task", true); err == nil {
+		t.Fatalf("expected an error for duplicate tags")
+	}
+}
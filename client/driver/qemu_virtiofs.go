@@ -0,0 +1,158 @@
+package driver
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// virtiofsMaxTagLen is the maximum length of the mount tags of virtio-fs
+	virtiofsMaxTagLen = 36
+
+	// virtiofsdStartTimeout is how long virtiofsd may take to listen on its
+	// socket
+	virtiofsdStartTimeout = 5 * time.Second
+
+	// maxUnixSocketPathLen is the maximum length of the path of unix sockets
+	maxUnixSocketPathLen = 108
+)
+
+// qemuVolume is a host path shared with the VM over virtio-fs, which the
+// guest mounts by its tag
+type qemuVolume struct {
+	HostPath string
+	Tag      string
+	ReadOnly bool
+}
+
+// parseQemuVolumes parses the host_path:tag[:ro] volumes of the task.
+// Relative host paths are shared from the task directory, and absolute ones
+// only when enabled.
+func parseQemuVolumes(volumes []string, taskDir string, enabled bool) ([]qemuVolume, error) {
+	parsed := make([]qemuVolume, 0, len(volumes))
+	tags := make(map[string]struct{}, len(volumes))
+	for _, volume := range volumes {
+		parts := strings.Split(volume, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[1] == "" || (len(parts) == 3 && parts[2] != "ro") {
+			return nil, fmt.Errorf("invalid qemu volume: %q", volume)
+		}
+		if len(parts[1]) > virtiofsMaxTagLen {
+			return nil, fmt.Errorf("qemu volume tag %q is longer than %d bytes", parts[1], virtiofsMaxTagLen)
+		}
+		if _, ok := tags[parts[1]]; ok {
+			return nil, fmt.Errorf("duplicate qemu volume tag %q", parts[1])
+		}
+		tags[parts[1]] = struct{}{}
+
+		// Resolve dotted path segments
+		hostPath := filepath.Clean(parts[0])
+		if filepath.IsAbs(hostPath) {
+			if !enabled {
+				// Disallow sharing arbitrary absolute paths
+				return nil, fmt.Errorf("%s is false; cannot share host paths: %+q", qemuVolumesConfigOption, volume)
+			}
+		} else {
+			// Relative paths are always allowed as they are within the task
+			// directory
+			hostPath = filepath.Join(taskDir, hostPath)
+		}
+		parsed = append(parsed, qemuVolume{
+			HostPath: hostPath,
+			Tag:      parts[1],
+			ReadOnly: len(parts) == 3,
+		})
+	}
+	return parsed, nil
+}
+
+// startVirtiofsd starts a virtiofsd daemon sharing each volume and returns
+// the sockets the VM connects to. The daemons exit once the VM disconnects.
+func startVirtiofsd(logger *log.Logger, bin, taskDir string, volumes []qemuVolume) ([]string, []*os.Process, error) {
+	out, err := os.OpenFile(filepath.Join(taskDir, "virtiofsd.out"), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer out.Close()
+
+	var sockets []string
+	var procs []*os.Process
+	for i, volume := range volumes {
+		socket := filepath.Join(taskDir, fmt.Sprintf("virtiofs-%d.sock", i))
+		if len(socket) > maxUnixSocketPathLen {
+			killVirtiofsd(procs)
+			return nil, nil, fmt.Errorf("virtiofsd socket path %q is too long", socket)
+		}
+		os.Remove(socket)
+
+		args := []string{"--socket-path=" + socket, "--shared-dir=" + volume.HostPath}
+		if volume.ReadOnly {
+			args = append(args, "--readonly")
+		}
+		cmd := exec.Command(bin, args...)
+		cmd.Stdout = out
+		cmd.Stderr = out
+		if err := cmd.Start(); err != nil {
+			killVirtiofsd(procs)
+			return nil, nil, fmt.Errorf("failed to start virtiofsd for volume %q: %v", volume.Tag, err)
+		}
+		procs = append(procs, cmd.Process)
+
+		exited := make(chan error, 1)
+		go func() {
+			exited <- cmd.Wait()
+		}()
+		if err := waitForSocket(socket, exited); err != nil {
+			killVirtiofsd(procs)
+			return nil, nil, fmt.Errorf("virtiofsd for volume %q failed: %v", volume.Tag, err)
+		}
+		logger.Printf("[DEBUG] driver.qemu: sharing %q with tag %q from virtiofsd pid %d", volume.HostPath, volume.Tag, cmd.Process.Pid)
+		sockets = append(sockets, socket)
+	}
+	return sockets, procs, nil
+}
+
+// waitForSocket waits for virtiofsd to create its socket, unless it exits
+func waitForSocket(socket string, exited <-chan error) error {
+	deadline := time.After(virtiofsdStartTimeout)
+	for {
+		if _, err := os.Stat(socket); err == nil {
+			return nil
+		}
+		select {
+		case err := <-exited:
+			return fmt.Errorf("exited before listening: %v", err)
+		case <-deadline:
+			return fmt.Errorf("not listening after %s", virtiofsdStartTimeout)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// killVirtiofsd kills the virtiofsd daemons, which may have exited
+func killVirtiofsd(procs []*os.Process) {
+	for _, p := range procs {
+		p.Kill()
+	}
+}
+
+// virtiofsArgs returns the qemu arguments connecting the VM to the virtiofsd
+// daemons of its volumes. virtio-fs requires the memory of the VM to be
+// shared with the daemons.
+func virtiofsArgs(volumes []qemuVolume, sockets []string, mem string) []string {
+	args := []string{
+		"-object", fmt.Sprintf("memory-backend-memfd,id=mem,size=%s,share=on", mem),
+		"-numa", "node,memdev=mem",
+	}
+	for i, volume := range volumes {
+		args = append(args,
+			"-chardev", fmt.Sprintf("socket,id=virtiofs%d,path=%s", i, sockets[i]),
+			"-device", fmt.Sprintf("vhost-user-fs-pci,queue-size=1024,chardev=virtiofs%d,tag=%s", i, volume.Tag),
+		)
+	}
+	return args
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
 	"time"
 
 	"github.com/hashicorp/go-plugin"
@@ -25,14 +26,19 @@ const (
 	// The option that enables this driver in the Config.Options map.
 	rawExecConfigOption = "driver.raw_exec.enable"
 
+	// rawExecJobObjectOption is the option that enables the job objects
+	// enforcing the resources of the tasks on Windows
+	rawExecJobObjectOption = "driver.raw_exec.job_object"
+
 	// The key populated in Node Attributes to indicate presence of the Raw Exec
 	// driver
 	rawExecDriverAttr = "driver.raw_exec"
 )
 
 // The RawExecDriver is a privileged version of the exec driver. It provides no
-// resource isolation and just fork/execs. The Exec driver should be preferred
-// and this should only be used when explicitly needed.
+// isolation and just fork/execs, though its tasks are run in job objects
+// enforcing their memory and CPU on Windows. The Exec driver should be
+// preferred and this should only be used when explicitly needed.
 type RawExecDriver struct {
 	DriverContext
 	fingerprint.StaticFingerprinter
@@ -154,6 +160,7 @@ func (d *RawExecDriver) Start(ctx *ExecContext, task *structs.Task) (*StartRespo
 		Args:           driverConfig.Args,
 		User:           task.User,
 		TaskKillSignal: taskKillSignal,
+		ResourceLimits: runtime.GOOS == "windows" && d.config.ReadBoolDefault(rawExecJobObjectOption, true),
 	}
 	ps, err := exec.LaunchCmd(execCmd)
 	if err != nil {
@@ -0,0 +1,18 @@
+// +build !linux
+
+package driver
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// attachSRIOVDevices returns an error if the task is assigned SR-IOV virtual
+// functions, since they can only be attached on Linux.
+func attachSRIOVDevices(pid int, resources *structs.Resources) error {
+	if resources != nil && len(resources.DevicesOfType(structs.DeviceTypeSRIOV)) != 0 {
+		return fmt.Errorf("SR-IOV devices are only supported on Linux")
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package driver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// pciDevicesPath is the sysfs directory of the PCI devices
+const pciDevicesPath = "/sys/bus/pci/devices"
+
+// sriovNetdev returns the network interface of the SR-IOV virtual function
+// with the PCI address.
+func sriovNetdev(addr string) (string, error) {
+	entries, err := ioutil.ReadDir(filepath.Join(pciDevicesPath, addr, "net"))
+	if err != nil || len(entries) == 0 {
+		return "", fmt.Errorf("virtual function %s has no network interface; it may be bound to a userspace driver", addr)
+	}
+	return entries[0].Name(), nil
+}
+
+// attachSRIOVDevices moves the network interfaces of the SR-IOV virtual
+// functions assigned to the task into the network namespace of the process.
+// The kernel returns the interfaces to the host's namespace when the task's
+// namespace is destroyed, so they need not be detached.
+func attachSRIOVDevices(pid int, resources *structs.Resources) error {
+	if resources == nil {
+		return nil
+	}
+
+	for _, vf := range resources.DevicesOfType(structs.DeviceTypeSRIOV) {
+		netdev, err := sriovNetdev(vf.ID)
+		if err != nil {
+			return err
+		}
+
+		out, err := exec.Command("ip", "link", "set", "dev", netdev, "netns", strconv.Itoa(pid)).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("failed to move %s (%s) into the network namespace of the task: %v: %s",
+				netdev, vf.ID, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
@@ -18,6 +18,9 @@ type WaitResult struct {
 	ExitCode int
 	Signal   int
 	Err      error
+
+	// OOMKilled is set if the task was killed for running out of memory
+	OOMKilled bool
 }
 
 func NewWaitResult(code, signal int, err error) *WaitResult {
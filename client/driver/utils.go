@@ -1,9 +1,12 @@
 package driver
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -19,10 +22,32 @@ import (
 	"github.com/hashicorp/nomad/client/driver/executor"
 	dstructs "github.com/hashicorp/nomad/client/driver/structs"
 	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper/capabilities"
 	"github.com/hashicorp/nomad/helper/discover"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
+// isolationCapabilities returns the capabilities the executor requires to
+// isolate tasks in a chroot and run them as another user. The chroot is only
+// mounted by the client when its mounts aren't delegated to systemd.
+func isolationCapabilities(conf *config.Config) []capabilities.Cap {
+	caps := []capabilities.Cap{
+		capabilities.SysChroot,
+		capabilities.Setuid,
+		capabilities.Setgid,
+	}
+	if conf.MountDriver != config.MountDriverSystemd {
+		caps = append(caps, capabilities.SysAdmin)
+	}
+	return caps
+}
+
+// hasIsolationCapabilities returns true if the agent has the capabilities
+// required to isolate tasks.
+func hasIsolationCapabilities(conf *config.Config) bool {
+	return capabilities.Has(isolationCapabilities(conf)...)
+}
+
 // cgroupsMounted returns true if the cgroups are mounted on a system otherwise
 // returns false
 func cgroupsMounted(node *structs.Node) bool {
@@ -220,3 +245,69 @@ func getTaskKillSignal(signal string) (os.Signal, error) {
 
 	return taskKillSignal, nil
 }
+
+// taskDNSConfig returns the DNS config of the task with its node attributes
+// and environment variables interpolated, or nil if it has none.
+func taskDNSConfig(env *env.TaskEnv, task *structs.Task) (*structs.DNSConfig, error) {
+	if task.DNS == nil {
+		return nil, nil
+	}
+
+	dns := &structs.DNSConfig{
+		Servers:  env.ParseAndReplace(task.DNS.Servers),
+		Searches: env.ParseAndReplace(task.DNS.Searches),
+		Options:  env.ParseAndReplace(task.DNS.Options),
+	}
+	if err := dns.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid DNS config: %v", err)
+	}
+
+	// Servers that failed to interpolate aren't caught by validation
+	for _, server := range dns.Servers {
+		if net.ParseIP(server) == nil {
+			return nil, fmt.Errorf("invalid DNS server %q: must be an IP address", server)
+		}
+	}
+	return dns, nil
+}
+
+// resolvConf returns the content of a resolv.conf file given a DNS config
+func resolvConf(dns *structs.DNSConfig) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by Nomad from the dns block of the task\n")
+	for _, server := range dns.Servers {
+		fmt.Fprintf(&buf, "nameserver %s\n", server)
+	}
+	if len(dns.Searches) != 0 {
+		fmt.Fprintf(&buf, "search %s\n", strings.Join(dns.Searches, " "))
+	}
+	if len(dns.Options) != 0 {
+		fmt.Fprintf(&buf, "options %s\n", strings.Join(dns.Options, " "))
+	}
+	return buf.Bytes()
+}
+
+// writeResolvConf generates the resolv.conf of a task running in a chroot
+// from its DNS config. Nothing is written if the task has no DNS config.
+func writeResolvConf(ctx *ExecContext, task *structs.Task) error {
+	dns, err := taskDNSConfig(ctx.TaskEnv, task)
+	if err != nil || dns == nil {
+		return err
+	}
+
+	etc := filepath.Join(ctx.TaskDir.Dir, "etc")
+	if err := os.MkdirAll(etc, 0755); err != nil {
+		return fmt.Errorf("failed to create %q: %v", etc, err)
+	}
+
+	// The resolv.conf of the chroot may be a hard link to the one of the
+	// host, so it is replaced rather than written to
+	path := filepath.Join(etc, "resolv.conf")
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %q: %v", path, err)
+	}
+	if err := ioutil.WriteFile(path, resolvConf(dns), 0644); err != nil {
+		return fmt.Errorf("failed to write %q: %v", path, err)
+	}
+	return nil
+}
@@ -1,13 +1,19 @@
 package driver
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDriver_KillTimeout(t *testing.T) {
@@ -56,3 +62,79 @@ func TestDriver_getTaskKillSignal(t *testing.T) {
 		assert.Equal(sig, syscall.SIGKILL)
 	}
 }
+
+func TestDriver_taskDNSConfig(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	taskEnv := env.NewTaskEnv(nil, map[string]string{
+		"node.datacenter":  "dc1",
+		"attr.dns.address": "10.0.0.53",
+	})
+
+	// Tasks without a DNS config have none
+	dns, err := taskDNSConfig(taskEnv, &structs.Task{})
+	require.NoError(err)
+	require.Nil(dns)
+
+	task := &structs.Task{
+		DNS: &structs.DNSConfig{
+			Servers:  []string{"${attr.dns.address}"},
+			Searches: []string{"${node.datacenter}.consul"},
+			Options:  []string{"ndots:2"},
+		},
+	}
+	dns, err = taskDNSConfig(taskEnv, task)
+	require.NoError(err)
+	require.Equal([]string{"10.0.0.53"}, dns.Servers)
+	require.Equal([]string{"dc1.consul"}, dns.Searches)
+	require.Equal([]string{"ndots:2"}, dns.Options)
+
+	// Servers must interpolate to IP addresses
+	task.DNS.Servers = []string{"${attr.missing}"}
+	_, err = taskDNSConfig(taskEnv, task)
+	require.Error(err)
+	require.Contains(err.Error(), "invalid DNS server")
+}
+
+func TestDriver_writeResolvConf(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// Hard link the resolv.conf of the host into the chroot
+	host := filepath.Join(dir, "host-resolv.conf")
+	hostContent := []byte("nameserver 127.0.0.1\n")
+	require.NoError(ioutil.WriteFile(host, hostContent, 0644))
+	taskDir := filepath.Join(dir, "task")
+	require.NoError(os.MkdirAll(filepath.Join(taskDir, "etc"), 0755))
+	path := filepath.Join(taskDir, "etc", "resolv.conf")
+	require.NoError(os.Link(host, path))
+
+	task := &structs.Task{
+		DNS: &structs.DNSConfig{
+			Servers:  []string{"10.0.0.53", "10.0.0.54"},
+			Searches: []string{"service.consul", "example.com"},
+			Options:  []string{"ndots:2", "timeout:1"},
+		},
+	}
+	ctx := NewExecContext(&allocdir.TaskDir{Dir: taskDir}, env.NewTaskEnv(nil, nil))
+	require.NoError(writeResolvConf(ctx, task))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(err)
+	require.Equal(`# Generated by Nomad from the dns block of the task
+nameserver 10.0.0.53
+nameserver 10.0.0.54
+search service.consul example.com
+options ndots:2 timeout:1
+`, string(data))
+
+	// The resolv.conf of the host is left untouched
+	data, err = ioutil.ReadFile(host)
+	require.NoError(err)
+	require.Equal(hostContent, data)
+}
@@ -0,0 +1,479 @@
+package driver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/go-plugin"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/client/driver/executor"
+	dstructs "github.com/hashicorp/nomad/client/driver/structs"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/helper/fields"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/mitchellh/mapstructure"
+)
+
+var reWasmtimeVersion = regexp.MustCompile(`wasmtime(?:-cli)? (\d+\.\d+\.\d+)`)
+
+const (
+	// minWasmtimeVersion is the earliest supported version of wasmtime, which
+	// introduced the -W options used to limit the fuel and memory of modules
+	minWasmtimeVersion = "14.0.0"
+
+	// The key populated in the Node Attributes to indicate the presence of the
+	// wasm driver
+	wasmDriverAttr = "driver.wasm"
+
+	// wasmtimeConfigOption is the key for setting the path of wasmtime. It is
+	// looked up in the PATH by default.
+	wasmtimeConfigOption  = "wasm.wasmtime"
+	wasmtimeConfigDefault = "wasmtime"
+
+	// wasmFuelPerMHzConfigOption is the key for setting the fuel given to
+	// modules per MHz of CPU of their task, when the task doesn't set its own
+	// fuel. Modules have unlimited fuel when unset.
+	wasmFuelPerMHzConfigOption = "wasm.fuel_per_mhz"
+)
+
+// WasmDriver runs WebAssembly modules with the WASI interface of wasmtime.
+// Modules are distributed as artifacts and can only access the task's
+// directories.
+type WasmDriver struct {
+	DriverContext
+
+	// A tri-state boolean to know if the fingerprinting has happened and
+	// whether it has been successful
+	fingerprintSuccess *bool
+}
+
+type WasmDriverConfig struct {
+	Module string   `mapstructure:"module"` // Path of the module, relative to the task dir
+	Args   []string `mapstructure:"args"`   // The args passed to the module
+	Fuel   uint64   `mapstructure:"fuel"`   // Fuel the module may consume
+	Invoke string   `mapstructure:"invoke"` // Function to invoke instead of the WASI command's entrypoint
+}
+
+// wasmHandle is returned from Start/Open as a handle to the PID
+type wasmHandle struct {
+	pluginClient    *plugin.Client
+	executor        executor.Executor
+	isolationConfig *dstructs.IsolationConfig
+	userPid         int
+	killTimeout     time.Duration
+	maxKillTimeout  time.Duration
+	logger          *log.Logger
+	waitCh          chan *dstructs.WaitResult
+	doneCh          chan struct{}
+	version         string
+}
+
+// NewWasmDriver is used to create a new wasm driver
+func NewWasmDriver(ctx *DriverContext) Driver {
+	return &WasmDriver{DriverContext: *ctx}
+}
+
+// Validate is used to validate the driver configuration
+func (d *WasmDriver) Validate(config map[string]interface{}) error {
+	fd := &fields.FieldData{
+		Raw: config,
+		Schema: map[string]*fields.FieldSchema{
+			"module": {
+				Type:     fields.TypeString,
+				Required: true,
+			},
+			"args": {
+				Type: fields.TypeArray,
+			},
+			"fuel": {
+				Type: fields.TypeInt,
+			},
+			"invoke": {
+				Type: fields.TypeString,
+			},
+		},
+	}
+
+	if err := fd.Validate(); err != nil {
+		return err
+	}
+
+	var driverConfig WasmDriverConfig
+	if err := mapstructure.WeakDecode(config, &driverConfig); err != nil {
+		return err
+	}
+	escapes, err := structs.PathEscapesAllocDir("task", driverConfig.Module)
+	if err != nil {
+		return err
+	}
+	if escapes {
+		return fmt.Errorf("module escapes the allocation directory")
+	}
+	return nil
+}
+
+func (d *WasmDriver) Abilities() DriverAbilities {
+	return DriverAbilities{
+		SendSignals: false,
+		Exec:        false,
+	}
+}
+
+// FSIsolation is that of images as modules only access the task's
+// directories, which are mounted at the paths they have in containers
+func (d *WasmDriver) FSIsolation() cstructs.FSIsolation {
+	return cstructs.FSIsolationImage
+}
+
+func (d *WasmDriver) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	bin := req.Config.ReadDefault(wasmtimeConfigOption, wasmtimeConfigDefault)
+	outBytes, err := exec.Command(bin, "--version").Output()
+	if err != nil {
+		if d.fingerprintSuccess == nil || *d.fingerprintSuccess {
+			d.logger.Printf("[DEBUG] driver.wasm: wasmtime not found, disabling")
+		}
+		d.fingerprintSuccess = helper.BoolToPtr(false)
+		resp.RemoveAttribute(wasmDriverAttr)
+		return nil
+	}
+
+	matches := reWasmtimeVersion.FindStringSubmatch(string(outBytes))
+	if len(matches) != 2 {
+		d.fingerprintSuccess = helper.BoolToPtr(false)
+		resp.RemoveAttribute(wasmDriverAttr)
+		return fmt.Errorf("Unable to parse wasmtime version string: %q", string(outBytes))
+	}
+
+	minVersion, _ := version.NewVersion(minWasmtimeVersion)
+	currentVersion, err := version.NewVersion(matches[1])
+	if err != nil || currentVersion.LessThan(minVersion) {
+		if d.fingerprintSuccess == nil || *d.fingerprintSuccess {
+			d.logger.Printf("[WARN] driver.wasm: unsupported wasmtime version %s; please upgrade to >= %s",
+				matches[1], minVersion)
+		}
+		d.fingerprintSuccess = helper.BoolToPtr(false)
+		resp.RemoveAttribute(wasmDriverAttr)
+		return nil
+	}
+
+	resp.AddAttribute(wasmDriverAttr, "1")
+	resp.AddAttribute("driver.wasm.wasmtime.version", matches[1])
+	resp.Detected = true
+	d.fingerprintSuccess = helper.BoolToPtr(true)
+	return nil
+}
+
+func (d *WasmDriver) Periodic() (bool, time.Duration) {
+	return true, 15 * time.Second
+}
+
+func (d *WasmDriver) Prestart(*ExecContext, *structs.Task) (*PrestartResponse, error) {
+	return nil, nil
+}
+
+func (d *WasmDriver) Start(ctx *ExecContext, task *structs.Task) (*StartResponse, error) {
+	var driverConfig WasmDriverConfig
+	if err := mapstructure.WeakDecode(task.Config, &driverConfig); err != nil {
+		return nil, err
+	}
+
+	// Modules are expected to be downloaded as artifacts
+	module := filepath.Join(ctx.TaskDir.Dir, ctx.TaskEnv.ReplaceEnv(driverConfig.Module))
+	if _, err := os.Stat(module); err != nil {
+		return nil, fmt.Errorf("failed to find module %q: %v", driverConfig.Module, err)
+	}
+
+	fuelPerMHz := uint64(0)
+	if v := d.config.Read(wasmFuelPerMHzConfigOption); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", wasmFuelPerMHzConfigOption, v, err)
+		}
+		fuelPerMHz = n
+	}
+	args := wasmtimeArgs(task, &driverConfig, ctx.TaskDir, ctx.TaskEnv, module, fuelPerMHz)
+
+	bin, err := GetAbsolutePath(d.config.ReadDefault(wasmtimeConfigOption, wasmtimeConfigDefault))
+	if err != nil {
+		return nil, err
+	}
+
+	pluginLogFile := filepath.Join(ctx.TaskDir.Dir, "executor.out")
+	executorConfig := &dstructs.ExecutorConfig{
+		LogFile:  pluginLogFile,
+		LogLevel: d.config.LogLevel,
+	}
+	exec, pluginClient, err := createExecutor(d.config.LogOutput, d.config, executorConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// wasmtime inherits the task's environment, which it passes to the module
+	// by name so that secrets don't appear in its arguments
+	executorCtx := &executor.ExecutorContext{
+		TaskEnv: ctx.TaskEnv,
+		Driver:  "wasm",
+		Task:    task,
+		TaskDir: ctx.TaskDir.Dir,
+		LogDir:  ctx.TaskDir.LogDir,
+	}
+	if err := exec.SetContext(executorCtx); err != nil {
+		pluginClient.Kill()
+		return nil, fmt.Errorf("failed to set executor context: %v", err)
+	}
+
+	taskKillSignal, err := getTaskKillSignal(task.KillSignal)
+	if err != nil {
+		pluginClient.Kill()
+		return nil, err
+	}
+
+	execCmd := &executor.ExecCommand{
+		Cmd:            bin,
+		Args:           args,
+		User:           task.User,
+		TaskKillSignal: taskKillSignal,
+		ResourceLimits: wasmResourceLimits(),
+		CgroupParent:   d.config.CgroupParent(),
+	}
+	ps, err := exec.LaunchCmd(execCmd)
+	if err != nil {
+		pluginClient.Kill()
+		return nil, err
+	}
+	d.logger.Printf("[DEBUG] driver.wasm: started module %q with pid: %v", driverConfig.Module, ps.Pid)
+
+	// Return a driver handle
+	maxKill := d.DriverContext.config.MaxKillTimeout
+	h := &wasmHandle{
+		pluginClient:    pluginClient,
+		executor:        exec,
+		isolationConfig: ps.IsolationConfig,
+		userPid:         ps.Pid,
+		killTimeout:     GetKillTimeout(task.KillTimeout, maxKill),
+		maxKillTimeout:  maxKill,
+		version:         d.config.Version.VersionNumber(),
+		logger:          d.logger,
+		doneCh:          make(chan struct{}),
+		waitCh:          make(chan *dstructs.WaitResult, 1),
+	}
+	go h.run()
+	return &StartResponse{Handle: h}, nil
+}
+
+// wasmResourceLimits returns whether the executor can enforce the CPU and
+// memory of the task on wasmtime, with cgroups when running as root on Linux
+// or job objects on Windows
+func wasmResourceLimits() bool {
+	switch runtime.GOOS {
+	case "linux":
+		return syscall.Geteuid() == 0
+	case "windows":
+		return true
+	}
+	return false
+}
+
+// wasmtimeArgs returns the arguments of wasmtime running the module. The
+// linear memory of the module is limited to the memory of the task, and its
+// fuel to that of its config or else fuelPerMHz for each MHz of its CPU. The
+// task's directories are the only ones the module can access.
+func wasmtimeArgs(task *structs.Task, driverConfig *WasmDriverConfig, taskDir *allocdir.TaskDir,
+	taskEnv *env.TaskEnv, module string, fuelPerMHz uint64) []string {
+
+	args := []string{"run"}
+	if task.Resources != nil && task.Resources.MemoryMB > 0 {
+		args = append(args, "-W", fmt.Sprintf("max-memory-size=%d", uint64(task.Resources.MemoryMB)*1024*1024))
+	}
+	fuel := driverConfig.Fuel
+	if fuel == 0 && fuelPerMHz != 0 && task.Resources != nil {
+		fuel = uint64(task.Resources.CPU) * fuelPerMHz
+	}
+	if fuel != 0 {
+		args = append(args, "-W", fmt.Sprintf("fuel=%d", fuel))
+	}
+
+	args = append(args,
+		"--dir", fmt.Sprintf("%s::%s", taskDir.SharedAllocDir, allocdir.SharedAllocContainerPath),
+		"--dir", fmt.Sprintf("%s::%s", taskDir.LocalDir, allocdir.TaskLocalContainerPath),
+		"--dir", fmt.Sprintf("%s::%s", taskDir.SecretsDir, allocdir.TaskSecretsContainerPath),
+	)
+
+	envMap := taskEnv.Map()
+	names := make([]string, 0, len(envMap))
+	for k := range envMap {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		args = append(args, "--env", k)
+	}
+
+	if driverConfig.Invoke != "" {
+		args = append(args, "--invoke", driverConfig.Invoke)
+	}
+
+	args = append(args, module)
+	return append(args, taskEnv.ParseAndReplace(driverConfig.Args)...)
+}
+
+func (d *WasmDriver) Cleanup(*ExecContext, *CreatedResources) error { return nil }
+
+type wasmId struct {
+	Version         string
+	KillTimeout     time.Duration
+	MaxKillTimeout  time.Duration
+	UserPid         int
+	IsolationConfig *dstructs.IsolationConfig
+	PluginConfig    *PluginReattachConfig
+}
+
+func (d *WasmDriver) Open(ctx *ExecContext, handleID string) (DriverHandle, error) {
+	id := &wasmId{}
+	if err := json.Unmarshal([]byte(handleID), id); err != nil {
+		return nil, fmt.Errorf("Failed to parse handle '%s': %v", handleID, err)
+	}
+
+	pluginConfig := &plugin.ClientConfig{
+		Reattach: id.PluginConfig.PluginConfig(),
+	}
+	exec, pluginClient, err := createExecutorWithConfig(pluginConfig, d.config.LogOutput)
+	if err != nil {
+		merrs := new(multierror.Error)
+		merrs.Errors = append(merrs.Errors, err)
+		d.logger.Println("[ERR] driver.wasm: error connecting to plugin so destroying plugin pid and user pid")
+		if e := destroyPlugin(id.PluginConfig.Pid, id.UserPid); e != nil {
+			merrs.Errors = append(merrs.Errors, fmt.Errorf("error destroying plugin and userpid: %v", e))
+		}
+		if id.IsolationConfig != nil {
+			ePid := pluginConfig.Reattach.Pid
+			if e := executor.ClientCleanup(id.IsolationConfig, ePid); e != nil {
+				merrs.Errors = append(merrs.Errors, fmt.Errorf("destroying resource container failed: %v", e))
+			}
+		}
+		return nil, fmt.Errorf("error connecting to plugin: %v", merrs.ErrorOrNil())
+	}
+
+	ver, _ := exec.Version()
+	d.logger.Printf("[DEBUG] driver.wasm: version of executor: %v", ver.Version)
+
+	// Return a driver handle
+	h := &wasmHandle{
+		pluginClient:    pluginClient,
+		executor:        exec,
+		isolationConfig: id.IsolationConfig,
+		userPid:         id.UserPid,
+		logger:          d.logger,
+		killTimeout:     id.KillTimeout,
+		maxKillTimeout:  id.MaxKillTimeout,
+		version:         id.Version,
+		doneCh:          make(chan struct{}),
+		waitCh:          make(chan *dstructs.WaitResult, 1),
+	}
+	go h.run()
+	return h, nil
+}
+
+func (h *wasmHandle) ID() string {
+	id := wasmId{
+		Version:         h.version,
+		KillTimeout:     h.killTimeout,
+		MaxKillTimeout:  h.maxKillTimeout,
+		PluginConfig:    NewPluginReattachConfig(h.pluginClient.ReattachConfig()),
+		UserPid:         h.userPid,
+		IsolationConfig: h.isolationConfig,
+	}
+
+	data, err := json.Marshal(id)
+	if err != nil {
+		h.logger.Printf("[ERR] driver.wasm: failed to marshal ID to JSON: %s", err)
+	}
+	return string(data)
+}
+
+func (h *wasmHandle) WaitCh() chan *dstructs.WaitResult {
+	return h.waitCh
+}
+
+func (h *wasmHandle) Update(task *structs.Task) error {
+	// Store the updated kill timeout.
+	h.killTimeout = GetKillTimeout(task.KillTimeout, h.maxKillTimeout)
+	h.executor.UpdateTask(task)
+
+	// Update is not possible
+	return nil
+}
+
+func (h *wasmHandle) Exec(ctx context.Context, cmd string, args []string) ([]byte, int, error) {
+	return nil, 0, fmt.Errorf("WebAssembly modules do not support exec")
+}
+
+func (h *wasmHandle) Signal(s os.Signal) error {
+	return fmt.Errorf("WebAssembly modules do not support signals")
+}
+
+func (h *wasmHandle) Kill() error {
+	if err := h.executor.ShutDown(); err != nil {
+		if h.pluginClient.Exited() {
+			return nil
+		}
+		return fmt.Errorf("executor Shutdown failed: %v", err)
+	}
+
+	select {
+	case <-h.doneCh:
+		return nil
+	case <-time.After(h.killTimeout):
+		if h.pluginClient.Exited() {
+			return nil
+		}
+		if err := h.executor.Exit(); err != nil {
+			return fmt.Errorf("executor Exit failed: %v", err)
+		}
+		return nil
+	}
+}
+
+func (h *wasmHandle) Stats() (*cstructs.TaskResourceUsage, error) {
+	return h.executor.Stats()
+}
+
+func (h *wasmHandle) run() {
+	ps, werr := h.executor.Wait()
+	close(h.doneCh)
+	if ps.ExitCode == 0 && werr != nil {
+		if h.isolationConfig != nil {
+			ePid := h.pluginClient.ReattachConfig().Pid
+			if e := executor.ClientCleanup(h.isolationConfig, ePid); e != nil {
+				h.logger.Printf("[ERR] driver.wasm: destroying resource container failed: %v", e)
+			}
+		} else if e := killProcess(h.userPid); e != nil {
+			h.logger.Printf("[ERR] driver.wasm: error killing user process: %v", e)
+		}
+	}
+
+	// Exit the executor
+	if err := h.executor.Exit(); err != nil {
+		h.logger.Printf("[ERR] driver.wasm: error killing executor: %v", err)
+	}
+	h.pluginClient.Kill()
+
+	// Send the results
+	h.waitCh <- dstructs.NewWaitResult(ps.ExitCode, ps.Signal, werr)
+	close(h.waitCh)
+}
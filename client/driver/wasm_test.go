@@ -0,0 +1,134 @@
+package driver
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ctestutils "github.com/hashicorp/nomad/client/testutil"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWasmtimeVersionRegex(t *testing.T) {
+	t.Parallel()
+
+	for out, expected := range map[string]string{
+		"wasmtime-cli 14.0.4\n":                        "14.0.4",
+		"wasmtime-cli 21.0.1 (cedf9aa0f 2024-05-22)\n": "21.0.1",
+		"wasmtime 25.0.0 (0ff5f9ef4 2024-09-20)\n":     "25.0.0",
+	} {
+		matches := reWasmtimeVersion.FindStringSubmatch(out)
+		require.Len(t, matches, 2, out)
+		require.Equal(t, expected, matches[1])
+	}
+}
+
+func wasmTestTask() *structs.Task {
+	return &structs.Task{
+		Name:   "sleep",
+		Driver: "wasm",
+		Config: map[string]interface{}{
+			"module": "local/app.wasm",
+			"args":   []string{"--name", "${NOMAD_TASK_NAME}"},
+		},
+		LogConfig: &structs.LogConfig{
+			MaxFiles:      10,
+			MaxFileSizeMB: 10,
+		},
+		Resources: &structs.Resources{
+			CPU:      100,
+			MemoryMB: 64,
+		},
+	}
+}
+
+func TestWasmDriver_Validate(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	task := wasmTestTask()
+	ctx := testDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	d := NewWasmDriver(ctx.DriverCtx)
+
+	require.Nil(d.Validate(task.Config))
+	require.NotNil(d.Validate(map[string]interface{}{"args": []string{"foo"}}))
+	require.NotNil(d.Validate(map[string]interface{}{"module": "../../other/app.wasm"}))
+}
+
+func TestWasmDriver_Args(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	task := wasmTestTask()
+	ctx := testDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	conf := &WasmDriverConfig{
+		Module: "local/app.wasm",
+		Args:   []string{"--name", "${NOMAD_TASK_NAME}"},
+	}
+	td := ctx.ExecCtx.TaskDir
+	module := filepath.Join(td.Dir, conf.Module)
+
+	args := wasmtimeArgs(task, conf, td, ctx.ExecCtx.TaskEnv, module, 0)
+	joined := strings.Join(args, " ")
+	for _, expected := range []string{
+		fmt.Sprintf("run -W max-memory-size=%d --dir", 64*1024*1024),
+		fmt.Sprintf("--dir %s::/alloc", td.SharedAllocDir),
+		fmt.Sprintf("--dir %s::/local", td.LocalDir),
+		fmt.Sprintf("--dir %s::/secrets", td.SecretsDir),
+		"--env NOMAD_TASK_NAME",
+	} {
+		require.Contains(joined, expected)
+	}
+	require.NotContains(joined, "fuel=")
+	require.NotContains(joined, "--invoke")
+	require.Equal([]string{module, "--name", "sleep"}, args[len(args)-3:])
+
+	// The fuel is derived from the CPU unless set by the task
+	args = wasmtimeArgs(task, conf, td, ctx.ExecCtx.TaskEnv, module, 1000)
+	require.Contains(strings.Join(args, " "), "-W fuel=100000 ")
+
+	conf.Fuel = 42
+	conf.Invoke = "main"
+	args = wasmtimeArgs(task, conf, td, ctx.ExecCtx.TaskEnv, module, 1000)
+	joined = strings.Join(args, " ")
+	require.Contains(joined, "-W fuel=42 ")
+	require.Contains(joined, fmt.Sprintf("--invoke main %s", module))
+}
+
+func TestWasmDriver_Start_Wait(t *testing.T) {
+	if !testutil.IsTravis() {
+		t.Parallel()
+	}
+	ctestutils.WasmCompatible(t)
+	require := require.New(t)
+
+	task := wasmTestTask()
+	ctx := testDriverContexts(t, task)
+	defer ctx.AllocDir.Destroy()
+	d := NewWasmDriver(ctx.DriverCtx)
+
+	// wasmtime runs modules in the text format too
+	module := `(module (func (export "_start")))`
+	path := filepath.Join(ctx.ExecCtx.TaskDir.Dir, "local", "app.wasm")
+	require.Nil(ioutil.WriteFile(path, []byte(module), 0644))
+
+	_, err := d.Prestart(ctx.ExecCtx, task)
+	require.Nil(err)
+	resp, err := d.Start(ctx.ExecCtx, task)
+	require.Nil(err)
+	defer resp.Handle.Kill()
+
+	select {
+	case res := <-resp.Handle.WaitCh():
+		require.True(res.Successful(), "%v", res)
+	case <-time.After(time.Duration(testutil.TestMultiplier()*5) * time.Second):
+		t.Fatalf("timeout")
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"github.com/hashicorp/nomad/client/driver"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// watchDriverHealth emits a task event each time the health of the task
+// reported by its driver changes, until the task exits.
+func (r *TaskRunner) watchDriverHealth(handle driver.DriverHandle) {
+	h, ok := handle.(driver.HealthHandle)
+	if !ok {
+		return
+	}
+	healthCh := h.HealthCh()
+	if healthCh == nil {
+		return
+	}
+
+	for health := range healthCh {
+		if health.Healthy {
+			r.setState("", structs.NewTaskEvent(structs.TaskDriverHealthy), false)
+			continue
+		}
+		r.logger.Printf("[DEBUG] client: driver reported task %q in alloc %q unhealthy: %s",
+			r.task.Name, r.alloc.ID, health.Output)
+		r.setState("", structs.NewTaskEvent(structs.TaskDriverUnhealthy).SetMessage(health.Output), false)
+	}
+}
+
+// driverHealthGated returns whether the health of the allocation depends on
+// the health of the task reported by its driver.
+func (r *TaskRunner) driverHealthGated() bool {
+	h, ok := r.getHandle().(driver.HealthHandle)
+	return ok && h.HealthGated()
+}
+
+// driverHealthGated returns whether the health of the allocation depends on
+// the health of the task reported by its driver.
+func (r *AllocRunner) driverHealthGated(task string) bool {
+	r.taskLock.RLock()
+	tr, ok := r.tasks[task]
+	r.taskLock.RUnlock()
+	return ok && tr.driverHealthGated()
+}
+
+// driverHealthy returns whether the driver reported the task healthy more
+// recently than unhealthy since the task was last started.
+func driverHealthy(state *structs.TaskState) bool {
+	if state == nil {
+		return false
+	}
+
+	healthy := false
+	for _, e := range state.Events {
+		switch e.Type {
+		case structs.TaskStarted, structs.TaskDriverUnhealthy:
+			healthy = false
+		case structs.TaskDriverHealthy:
+			healthy = true
+		}
+	}
+	return healthy
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDriverHealthy(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	require.False(driverHealthy(nil))
+
+	state := &structs.TaskState{
+		Events: []*structs.TaskEvent{
+			structs.NewTaskEvent(structs.TaskStarted),
+		},
+	}
+	require.False(driverHealthy(state))
+
+	state.Events = append(state.Events, structs.NewTaskEvent(structs.TaskDriverHealthy))
+	require.True(driverHealthy(state))
+
+	state.Events = append(state.Events, structs.NewTaskEvent(structs.TaskDriverUnhealthy).SetMessage("exit 1"))
+	require.False(driverHealthy(state))
+
+	state.Events = append(state.Events, structs.NewTaskEvent(structs.TaskDriverHealthy))
+	require.True(driverHealthy(state))
+
+	// Restarting the task requires it to be reported healthy again
+	state.Events = append(state.Events, structs.NewTaskEvent(structs.TaskStarted))
+	require.False(driverHealthy(state))
+}
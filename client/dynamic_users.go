@@ -0,0 +1,115 @@
+package client
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/boltdb/bolt"
+)
+
+// dynamicUserPool is the range of UIDs given to the tasks run as a dynamic
+// user. Each task gets a UID no other task uses, whose GID is the same. The
+// UIDs are held until the allocation directory is destroyed, so that another
+// task can't access the files left behind. A nil pool gives no users.
+type dynamicUserPool struct {
+	min, max int
+
+	// next is where the search for a free UID starts, so that the UIDs are
+	// reused as late as possible
+	next int
+
+	// used maps the UIDs in use to the ID of the allocation holding them
+	used map[int]string
+	l    sync.Mutex
+}
+
+// newDynamicUserPool returns a pool of the UIDs of a range given as
+// "<min>-<max>", both inclusive.
+func newDynamicUserPool(spec string) (*dynamicUserPool, error) {
+	parts := strings.SplitN(spec, "-", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid UID range %q: must be of the form <min>-<max>", spec)
+	}
+	min, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid UID range %q: %v", spec, err)
+	}
+	max, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid UID range %q: %v", spec, err)
+	}
+	if min <= 0 || max < min || max > 1<<31-1 {
+		return nil, fmt.Errorf("invalid UID range %q: must be a non empty range of positive UIDs", spec)
+	}
+
+	return &dynamicUserPool{
+		min:  min,
+		max:  max,
+		next: min,
+		used: make(map[int]string),
+	}, nil
+}
+
+// acquire returns a free UID for a task of the allocation.
+func (p *dynamicUserPool) acquire(allocID string) (int, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	size := p.max - p.min + 1
+	for i := 0; i < size; i++ {
+		uid := p.min + (p.next-p.min+i)%size
+		if _, ok := p.used[uid]; ok {
+			continue
+		}
+		p.used[uid] = allocID
+		p.next = uid + 1
+		return uid, nil
+	}
+	return 0, fmt.Errorf("all %d dynamic users are in use", size)
+}
+
+// restore marks the UID of a restored task as used by its allocation.
+func (p *dynamicUserPool) restore(uid int, allocID string) {
+	if p == nil || uid < p.min || uid > p.max {
+		return
+	}
+	p.l.Lock()
+	p.used[uid] = allocID
+	p.l.Unlock()
+}
+
+// release frees the UIDs held by the allocation.
+func (p *dynamicUserPool) release(allocID string) {
+	if p == nil {
+		return
+	}
+	p.l.Lock()
+	defer p.l.Unlock()
+	for uid, id := range p.used {
+		if id == allocID {
+			delete(p.used, uid)
+		}
+	}
+}
+
+// restoreDynamicUser marks the dynamic user of a task that is not restored as
+// used by its allocation.
+func restoreDynamicUser(stateDB *bolt.DB, pool *dynamicUserPool, allocID, taskName string) {
+	if pool == nil {
+		return
+	}
+
+	var snap taskRunnerState
+	err := stateDB.View(func(tx *bolt.Tx) error {
+		bkt, err := getTaskBucket(tx, allocID, taskName)
+		if err != nil {
+			return err
+		}
+		return getObject(bkt, taskRunnerStateAllKey, &snap)
+	})
+	if err == nil {
+		pool.restore(snap.DynamicUser, allocID)
+	}
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"testing"
+)
+
+func TestDynamicUserPool(t *testing.T) {
+	t.Parallel()
+	for _, spec := range []string{"", "100", "a-b", "0-10", "20-10"} {
+		if _, err := newDynamicUserPool(spec); err == nil {
+			t.Errorf("expected error parsing %q", spec)
+		}
+	}
+
+	p, err := newDynamicUserPool("70000-70002")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A restored UID isn't given again
+	p.restore(70000, "alloc1")
+	uid, err := p.acquire("alloc2")
+	if err != nil || uid != 70001 {
+		t.Fatalf("got uid %d, err %v; want 70001", uid, err)
+	}
+	if uid, err = p.acquire("alloc2"); err != nil || uid != 70002 {
+		t.Fatalf("got uid %d, err %v; want 70002", uid, err)
+	}
+	if _, err := p.acquire("alloc3"); err == nil {
+		t.Fatalf("expected error acquiring from an exhausted pool")
+	}
+
+	// Releasing an alloc frees all of its UIDs
+	p.release("alloc2")
+	if uid, err = p.acquire("alloc3"); err != nil || uid != 70001 {
+		t.Fatalf("got uid %d, err %v; want 70001", uid, err)
+	}
+
+	// A nil pool ignores restores and releases
+	var nilPool *dynamicUserPool
+	nilPool.restore(70000, "alloc1")
+	nilPool.release("alloc1")
+}
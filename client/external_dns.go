@@ -0,0 +1,291 @@
+package client
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/nomad/structs"
+	nconfig "github.com/hashicorp/nomad/nomad/structs/config"
+)
+
+const (
+	// externalDNSRetryIntv is the interval at which failed registrations
+	// with the external DNS provider are retried
+	externalDNSRetryIntv = 30 * time.Second
+
+	// externalDNSNameMeta is the job or task group meta key holding the name
+	// the allocations are registered under
+	externalDNSNameMeta = "external_dns.name"
+
+	// externalDNSTTLMeta is the job or task group meta key overriding the
+	// TTL of the records
+	externalDNSTTLMeta = "external_dns.ttl"
+
+	// externalDNSTaskMeta is the job or task group meta key restricting the
+	// registered addresses to those of a task
+	externalDNSTaskMeta = "external_dns.task"
+)
+
+// dnsRecord is the registration of the addresses of an allocation under a
+// name.
+type dnsRecord struct {
+	// Name is the fully qualified name of the record without trailing dot
+	Name string
+
+	// AllocID identifies the addresses of the allocation among those
+	// registered under the name
+	AllocID string
+
+	// IPs are the sorted addresses of the allocation
+	IPs []string
+
+	TTL time.Duration
+}
+
+// equal returns whether both records register the same addresses
+func (r *dnsRecord) equal(o *dnsRecord) bool {
+	if r.Name != o.Name || r.AllocID != o.AllocID || r.TTL != o.TTL || len(r.IPs) != len(o.IPs) {
+		return false
+	}
+	for i, ip := range r.IPs {
+		if o.IPs[i] != ip {
+			return false
+		}
+	}
+	return true
+}
+
+// dnsProvider registers the addresses of allocations with an external DNS
+// service. Registering a record replaces the addresses previously registered
+// for its allocation.
+type dnsProvider interface {
+	Register(r *dnsRecord) error
+	Deregister(r *dnsRecord) error
+}
+
+// dnsProviderFactory returns the provider for the client's configuration
+type dnsProviderFactory func(conf *nconfig.ExternalDNSConfig) (dnsProvider, error)
+
+// dnsProviders are the factories of the supported providers by name
+var dnsProviders = map[string]dnsProviderFactory{
+	nconfig.ExternalDNSProviderRoute53: newRoute53Provider,
+	nconfig.ExternalDNSProviderCoreDNS: newCoreDNSProvider,
+}
+
+// externalDNSRegistrar keeps the addresses of the running allocations of the
+// node registered with an external DNS provider. The registrations are
+// reconciled each time an allocation changes status or is removed.
+type externalDNSRegistrar struct {
+	provider dnsProvider
+	ttl      time.Duration
+
+	// updateCh is used to trigger a reconciliation
+	updateCh chan struct{}
+
+	// registered are the records registered with the provider by
+	// allocation. It is only accessed by the reconciliation loop.
+	registered map[string]*dnsRecord
+}
+
+// newExternalDNSRegistrar returns a registrar for the configured provider.
+// A reconciliation is triggered so that allocations restored on startup are
+// registered.
+func newExternalDNSRegistrar(conf *nconfig.ExternalDNSConfig) (*externalDNSRegistrar, error) {
+	factory, ok := dnsProviders[conf.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unsupported external DNS provider %q", conf.Provider)
+	}
+	provider, err := factory(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create external DNS provider %q: %v", conf.Provider, err)
+	}
+
+	ttl := conf.TTL
+	if ttl == 0 {
+		ttl = nconfig.DefaultExternalDNSTTL
+	}
+	d := &externalDNSRegistrar{
+		provider:   provider,
+		ttl:        ttl,
+		updateCh:   make(chan struct{}, 1),
+		registered: make(map[string]*dnsRecord),
+	}
+	d.trigger()
+	return d, nil
+}
+
+// trigger causes the registrations to be reconciled. It may be called on a nil
+// registrar.
+func (d *externalDNSRegistrar) trigger() {
+	if d == nil {
+		return
+	}
+	select {
+	case d.updateCh <- struct{}{}:
+	default:
+	}
+}
+
+// sync reconciles the registered records with the desired records by
+// allocation. Changed records are deregistered before their new addresses are
+// registered, and records failing to deregister are retried on the next sync,
+// before their new addresses are registered.
+func (d *externalDNSRegistrar) sync(desired map[string]*dnsRecord) error {
+	var mErr multierror.Error
+	for id, old := range d.registered {
+		if r, ok := desired[id]; ok && r.equal(old) {
+			continue
+		}
+		if err := d.provider.Deregister(old); err != nil {
+			multierror.Append(&mErr, fmt.Errorf("failed to deregister alloc %q from %q: %v", id, old.Name, err))
+			continue
+		}
+		delete(d.registered, id)
+	}
+
+	for id, r := range desired {
+		if _, ok := d.registered[id]; ok {
+			continue
+		}
+		if err := d.provider.Register(r); err != nil {
+			multierror.Append(&mErr, fmt.Errorf("failed to register alloc %q as %q: %v", id, r.Name, err))
+			continue
+		}
+		d.registered[id] = r
+	}
+	return mErr.ErrorOrNil()
+}
+
+// registerExternalDNS reconciles the registrations of the allocations with
+// the external DNS provider each time a reconciliation is triggered, and
+// retries failed registrations periodically.
+func (c *Client) registerExternalDNS() {
+	d := c.externalDNS
+	var retry <-chan time.Time
+	for {
+		select {
+		case <-d.updateCh:
+		case <-retry:
+		case <-c.shutdownCh:
+			return
+		}
+
+		retry = nil
+		if err := d.sync(c.externalDNSRecords(d.ttl)); err != nil {
+			c.logger.Printf("[ERR] client: failed to sync external DNS records: %v", err)
+			retry = time.After(c.retryIntv(externalDNSRetryIntv))
+		}
+	}
+}
+
+// externalDNSRecords returns the records of the running allocations of the
+// node by allocation
+func (c *Client) externalDNSRecords(ttl time.Duration) map[string]*dnsRecord {
+	c.allocLock.RLock()
+	defer c.allocLock.RUnlock()
+
+	records := make(map[string]*dnsRecord)
+	for _, ar := range c.allocs {
+		alloc := ar.Alloc()
+		if alloc.TerminalStatus() || alloc.ClientStatus != structs.AllocClientStatusRunning {
+			continue
+		}
+
+		driverIPs := make(map[string]string)
+		ar.taskLock.RLock()
+		for name, tr := range ar.tasks {
+			tr.driverNetLock.Lock()
+			if tr.driverNet != nil && tr.driverNet.IP != "" {
+				driverIPs[name] = tr.driverNet.IP
+			}
+			tr.driverNetLock.Unlock()
+		}
+		ar.taskLock.RUnlock()
+
+		r, err := allocDNSRecord(alloc, driverIPs, ttl)
+		if err != nil {
+			c.logger.Printf("[ERR] client: failed to register alloc %q with external DNS: %v", alloc.ID, err)
+			continue
+		}
+		if r != nil {
+			records[alloc.ID] = r
+		}
+	}
+	return records
+}
+
+// allocDNSRecord returns the record of the allocation or nil if neither its
+// job nor its task group set the external DNS name. The meta of the task group
+// overrides that of the job. The addresses of the tasks are those set by their
+// driver, or else those of their network resources.
+func allocDNSRecord(alloc *structs.Allocation, driverIPs map[string]string, ttl time.Duration) (*dnsRecord, error) {
+	if alloc.Job == nil {
+		return nil, nil
+	}
+	meta := make(map[string]string)
+	for k, v := range alloc.Job.Meta {
+		meta[k] = v
+	}
+	if tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup); tg != nil {
+		for k, v := range tg.Meta {
+			meta[k] = v
+		}
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(meta[externalDNSNameMeta], "."))
+	if name == "" {
+		return nil, nil
+	}
+	if v, ok := meta[externalDNSTTLMeta]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s meta %q", externalDNSTTLMeta, v)
+		}
+		ttl = d
+	}
+	only := meta[externalDNSTaskMeta]
+
+	seen := make(map[string]struct{})
+	var ips []string
+	add := func(s string) {
+		ip := net.ParseIP(s)
+		if ip == nil || ip.IsUnspecified() {
+			return
+		}
+		s = ip.String()
+		if _, ok := seen[s]; !ok {
+			seen[s] = struct{}{}
+			ips = append(ips, s)
+		}
+	}
+	for task, res := range alloc.TaskResources {
+		if only != "" && task != only {
+			continue
+		}
+		if ip, ok := driverIPs[task]; ok {
+			add(ip)
+			continue
+		}
+		if res == nil {
+			continue
+		}
+		for _, n := range res.Networks {
+			add(n.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, nil
+	}
+	sort.Strings(ips)
+
+	return &dnsRecord{
+		Name:    name,
+		AllocID: alloc.ID,
+		IPs:     ips,
+		TTL:     ttl,
+	}, nil
+}
@@ -0,0 +1,282 @@
+package client
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/client/metadata"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/private/protocol/restxml"
+	"github.com/aws/aws-sdk-go/private/signer/v4"
+	nconfig "github.com/hashicorp/nomad/nomad/structs/config"
+)
+
+const (
+	// route53APIVersion is the version of the Route 53 API the changes are
+	// sent to
+	route53APIVersion = "2013-04-01"
+
+	// route53Namespace is the XML namespace of the Route 53 API
+	route53Namespace = "https://route53.amazonaws.com/doc/2013-04-01/"
+)
+
+// route53Provider registers the addresses of each allocation as multivalue
+// answer A and AAAA record sets identified by the allocation ID in a Route 53
+// hosted zone. Credentials are found through the default AWS credential
+// chain.
+type route53Provider struct {
+	client *client.Client
+	zoneID string
+}
+
+// newRoute53Provider returns a provider for the configured hosted zone. The
+// endpoint may be overridden for testing.
+func newRoute53Provider(conf *nconfig.ExternalDNSConfig) (dnsProvider, error) {
+	return newRoute53ProviderEndpoint(conf.HostedZoneID, "")
+}
+
+func newRoute53ProviderEndpoint(zoneID, endpoint string) (*route53Provider, error) {
+	// Route 53 is a global service signed in us-east-1
+	awsConf := aws.NewConfig().WithRegion("us-east-1")
+	if endpoint != "" {
+		awsConf = awsConf.WithEndpoint(endpoint)
+	}
+	c := session.New(awsConf).ClientConfig("route53")
+	cl := client.New(*c.Config,
+		metadata.ClientInfo{
+			ServiceName:   "route53",
+			SigningRegion: c.SigningRegion,
+			Endpoint:      c.Endpoint,
+			APIVersion:    route53APIVersion,
+		},
+		c.Handlers)
+
+	cl.Handlers.Sign.PushBack(v4.Sign)
+	cl.Handlers.Build.PushBack(buildRoute53Request)
+	cl.Handlers.Unmarshal.PushBack(discardRoute53Response)
+	cl.Handlers.UnmarshalMeta.PushBack(restxml.UnmarshalMeta)
+	cl.Handlers.UnmarshalError.PushBack(restxml.UnmarshalError)
+
+	return &route53Provider{
+		client: cl,
+		zoneID: strings.TrimPrefix(zoneID, "/hostedzone/"),
+	}, nil
+}
+
+// route53ChangeRequest is the body of a ChangeResourceRecordSets request
+type route53ChangeRequest struct {
+	XMLName xml.Name        `xml:"ChangeResourceRecordSetsRequest"`
+	Xmlns   string          `xml:"xmlns,attr"`
+	Changes []route53Change `xml:"ChangeBatch>Changes>Change"`
+}
+
+type route53Change struct {
+	Action           string
+	Name             string                 `xml:"ResourceRecordSet>Name"`
+	Type             string                 `xml:"ResourceRecordSet>Type"`
+	SetIdentifier    string                 `xml:"ResourceRecordSet>SetIdentifier"`
+	MultiValueAnswer bool                   `xml:"ResourceRecordSet>MultiValueAnswer"`
+	TTL              int64                  `xml:"ResourceRecordSet>TTL"`
+	Records          []route53ResourceValue `xml:"ResourceRecordSet>ResourceRecords>ResourceRecord"`
+}
+
+type route53ResourceValue struct {
+	Value string
+}
+
+// buildRoute53Request encodes the change request parameters of the request
+func buildRoute53Request(r *request.Request) {
+	body, err := xml.Marshal(r.Params)
+	if err != nil {
+		r.Error = awserr.New("SerializationError", "failed to encode route53 request", err)
+		return
+	}
+	r.HTTPRequest.Header.Set("Content-Type", "application/xml")
+	r.SetBufferBody(append([]byte(xml.Header), body...))
+}
+
+// discardRoute53Response discards the change info of a successful response
+func discardRoute53Response(r *request.Request) {
+	defer r.HTTPResponse.Body.Close()
+	io.Copy(ioutil.Discard, r.HTTPResponse.Body)
+}
+
+// changes returns the changes applying the action to the A and AAAA record
+// sets of the record
+func (p *route53Provider) changes(action string, rec *dnsRecord) []route53Change {
+	byType := make(map[string][]route53ResourceValue)
+	var types []string
+	for _, s := range rec.IPs {
+		t := "AAAA"
+		if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+			t = "A"
+		}
+		if _, ok := byType[t]; !ok {
+			types = append(types, t)
+		}
+		byType[t] = append(byType[t], route53ResourceValue{Value: s})
+	}
+
+	changes := make([]route53Change, 0, len(types))
+	for _, t := range types {
+		changes = append(changes, route53Change{
+			Action:           action,
+			Name:             rec.Name + ".",
+			Type:             t,
+			SetIdentifier:    rec.AllocID,
+			MultiValueAnswer: true,
+			TTL:              int64(rec.TTL / time.Second),
+			Records:          byType[t],
+		})
+	}
+	return changes
+}
+
+// change sends the changes in a single batch so they are applied atomically
+func (p *route53Provider) change(changes []route53Change) error {
+	op := &request.Operation{
+		Name:       "ChangeResourceRecordSets",
+		HTTPMethod: "POST",
+		HTTPPath:   fmt.Sprintf("/%s/hostedzone/%s/rrset/", route53APIVersion, p.zoneID),
+	}
+	params := &route53ChangeRequest{
+		Xmlns:   route53Namespace,
+		Changes: changes,
+	}
+	return p.client.NewRequest(op, params, nil).Send()
+}
+
+func (p *route53Provider) Register(rec *dnsRecord) error {
+	return p.change(p.changes("UPSERT", rec))
+}
+
+func (p *route53Provider) Deregister(rec *dnsRecord) error {
+	err := p.change(p.changes("DELETE", rec))
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "InvalidChangeBatch" &&
+		strings.Contains(aerr.Message(), "not found") {
+		// The record sets were already deleted
+		return nil
+	}
+	return err
+}
+
+// coreDNSProvider registers the addresses of each allocation in the etcd
+// backend of CoreDNS, using the JSON gateway of etcd v3. Each address is a key
+// below the path of the name, prefixed by the allocation ID so that the
+// addresses of an allocation can be deleted at once.
+type coreDNSProvider struct {
+	endpoints []string
+	prefix    string
+	client    *http.Client
+}
+
+func newCoreDNSProvider(conf *nconfig.ExternalDNSConfig) (dnsProvider, error) {
+	prefix := conf.Prefix
+	if prefix == "" {
+		prefix = nconfig.DefaultExternalDNSPrefix
+	}
+	endpoints := make([]string, len(conf.Endpoints))
+	for i, e := range conf.Endpoints {
+		endpoints[i] = strings.TrimSuffix(e, "/")
+	}
+	return &coreDNSProvider{
+		endpoints: endpoints,
+		prefix:    "/" + strings.Trim(prefix, "/"),
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// coreDNSRecord is the value of a key of the CoreDNS etcd backend
+type coreDNSRecord struct {
+	Host string `json:"host"`
+	TTL  int64  `json:"ttl"`
+}
+
+// keyPrefix returns the prefix of the keys of the addresses of the record.
+// The labels of the name are reversed, so web.example.com is stored below
+// /skydns/com/example/web.
+func (p *coreDNSProvider) keyPrefix(rec *dnsRecord) string {
+	labels := strings.Split(rec.Name, ".")
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+	return fmt.Sprintf("%s/%s/%s-", p.prefix, strings.Join(labels, "/"), rec.AllocID)
+}
+
+func (p *coreDNSProvider) Register(rec *dnsRecord) error {
+	// Remove the addresses previously registered for the allocation
+	if err := p.Deregister(rec); err != nil {
+		return err
+	}
+
+	prefix := p.keyPrefix(rec)
+	for i, ip := range rec.IPs {
+		value, err := json.Marshal(&coreDNSRecord{
+			Host: ip,
+			TTL:  int64(rec.TTL / time.Second),
+		})
+		if err != nil {
+			return err
+		}
+		req := map[string]string{
+			"key":   base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s%d", prefix, i))),
+			"value": base64.StdEncoding.EncodeToString(value),
+		}
+		if err := p.call("/v3/kv/put", req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *coreDNSProvider) Deregister(rec *dnsRecord) error {
+	prefix := []byte(p.keyPrefix(rec))
+
+	// The range end of a prefix is the prefix with its last byte incremented
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	end[len(end)-1]++
+
+	req := map[string]string{
+		"key":       base64.StdEncoding.EncodeToString(prefix),
+		"range_end": base64.StdEncoding.EncodeToString(end),
+	}
+	return p.call("/v3/kv/deleterange", req)
+}
+
+// call sends the request to the endpoints in order until one succeeds
+func (p *coreDNSProvider) call(path string, req interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for _, e := range p.endpoints {
+		resp, err := p.client.Post(e+path, "application/json", bytes.NewReader(body))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		msg, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			return nil
+		}
+		lastErr = fmt.Errorf("unexpected response code %d from %s: %s", resp.StatusCode, e, bytes.TrimSpace(msg))
+	}
+	return lastErr
+}
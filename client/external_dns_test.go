@@ -0,0 +1,230 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	nconfig "github.com/hashicorp/nomad/nomad/structs/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocDNSRecord(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	alloc := mock.Alloc()
+	alloc.TaskResources["sidecar"] = &structs.Resources{
+		Networks: []*structs.NetworkResource{{IP: "192.168.0.100"}},
+	}
+
+	// No name
+	r, err := allocDNSRecord(alloc, nil, time.Minute)
+	assert.Nil(err)
+	assert.Nil(r)
+
+	// Job meta overridden by the task group meta
+	alloc.Job.Meta = map[string]string{
+		externalDNSNameMeta: "other.example.com",
+		externalDNSTTLMeta:  "10s",
+	}
+	alloc.Job.TaskGroups[0].Meta = map[string]string{
+		externalDNSNameMeta: "Web.Example.com.",
+	}
+	r, err = allocDNSRecord(alloc, map[string]string{"web": "172.17.0.2"}, time.Minute)
+	assert.Nil(err)
+	assert.Equal(&dnsRecord{
+		Name:    "web.example.com",
+		AllocID: alloc.ID,
+		IPs:     []string{"172.17.0.2", "192.168.0.100"},
+		TTL:     10 * time.Second,
+	}, r)
+
+	// Restricted to a task
+	alloc.Job.TaskGroups[0].Meta[externalDNSTaskMeta] = "sidecar"
+	r, err = allocDNSRecord(alloc, map[string]string{"web": "172.17.0.2"}, time.Minute)
+	assert.Nil(err)
+	assert.Equal([]string{"192.168.0.100"}, r.IPs)
+
+	// Invalid TTL
+	alloc.Job.Meta[externalDNSTTLMeta] = "soon"
+	_, err = allocDNSRecord(alloc, nil, time.Minute)
+	assert.NotNil(err)
+}
+
+// testDNSProvider records the registered addresses by allocation
+type testDNSProvider struct {
+	records map[string]*dnsRecord
+	err     error
+	calls   int
+}
+
+func (p *testDNSProvider) Register(r *dnsRecord) error {
+	p.calls++
+	if p.err != nil {
+		return p.err
+	}
+	p.records[r.AllocID] = r
+	return nil
+}
+
+func (p *testDNSProvider) Deregister(r *dnsRecord) error {
+	p.calls++
+	if p.err != nil {
+		return p.err
+	}
+	delete(p.records, r.AllocID)
+	return nil
+}
+
+func TestExternalDNSRegistrar_Sync(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	p := &testDNSProvider{records: make(map[string]*dnsRecord)}
+	d := &externalDNSRegistrar{
+		provider:   p,
+		registered: make(map[string]*dnsRecord),
+	}
+
+	a := &dnsRecord{Name: "web.example.com", AllocID: "a", IPs: []string{"10.0.0.1"}, TTL: time.Minute}
+	b := &dnsRecord{Name: "web.example.com", AllocID: "b", IPs: []string{"10.0.0.2"}, TTL: time.Minute}
+	assert.Nil(d.sync(map[string]*dnsRecord{"a": a, "b": b}))
+	assert.Len(p.records, 2)
+	assert.Equal(2, p.calls)
+
+	// Unchanged records aren't registered again
+	assert.Nil(d.sync(map[string]*dnsRecord{"a": a, "b": b}))
+	assert.Equal(2, p.calls)
+
+	// Failed changes are retried
+	moved := &dnsRecord{Name: "web.example.com", AllocID: "a", IPs: []string{"10.0.0.3"}, TTL: time.Minute}
+	p.err = fmt.Errorf("unavailable")
+	assert.NotNil(d.sync(map[string]*dnsRecord{"a": moved}))
+	assert.Equal(a, p.records["a"])
+
+	p.err = nil
+	assert.Nil(d.sync(map[string]*dnsRecord{"a": moved}))
+	assert.Equal(map[string]*dnsRecord{"a": moved}, p.records)
+	assert.Equal(map[string]*dnsRecord{"a": moved}, d.registered)
+}
+
+func TestRoute53Provider(t *testing.T) {
+	require := require.New(t)
+
+	for k, v := range map[string]string{"AWS_ACCESS_KEY_ID": "id", "AWS_SECRET_ACCESS_KEY": "secret"} {
+		old, ok := os.LookupEnv(k)
+		os.Setenv(k, v)
+		if ok {
+			defer os.Setenv(k, old)
+		} else {
+			defer os.Unsetenv(k)
+		}
+	}
+
+	var l sync.Mutex
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		l.Lock()
+		bodies = append(bodies, string(body))
+		l.Unlock()
+
+		if r.URL.Path != "/2013-04-01/hostedzone/Z1234/rrset/" || !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if strings.Contains(string(body), "<Action>DELETE</Action>") {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<ErrorResponse><Error><Code>InvalidChangeBatch</Code><Message>Tried to delete resource record set but it was not found</Message></Error></ErrorResponse>`)
+			return
+		}
+		fmt.Fprint(w, `<ChangeResourceRecordSetsResponse><ChangeInfo><Id>/change/C1</Id></ChangeInfo></ChangeResourceRecordSetsResponse>`)
+	}))
+	defer srv.Close()
+
+	p, err := newRoute53ProviderEndpoint("/hostedzone/Z1234", srv.URL)
+	require.Nil(err)
+
+	r := &dnsRecord{Name: "web.example.com", AllocID: "a", IPs: []string{"10.0.0.1", "fd00::1"}, TTL: time.Minute}
+	require.Nil(p.Register(r))
+
+	// Record sets already deleted are deregistered
+	require.Nil(p.Deregister(r))
+
+	require.Len(bodies, 2)
+	require.Contains(bodies[0], `<ChangeResourceRecordSetsRequest xmlns="https://route53.amazonaws.com/doc/2013-04-01/">`)
+	require.Contains(bodies[0], `<Change><Action>UPSERT</Action><ResourceRecordSet><Name>web.example.com.</Name><Type>A</Type><SetIdentifier>a</SetIdentifier><MultiValueAnswer>true</MultiValueAnswer><TTL>60</TTL><ResourceRecords><ResourceRecord><Value>10.0.0.1</Value></ResourceRecord></ResourceRecords></ResourceRecordSet></Change>`)
+	require.Contains(bodies[0], `<Type>AAAA</Type>`)
+}
+
+func TestCoreDNSProvider(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	var l sync.Mutex
+	kv := make(map[string]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		decode := func(k string) string {
+			b, _ := base64.StdEncoding.DecodeString(req[k])
+			return string(b)
+		}
+
+		l.Lock()
+		defer l.Unlock()
+		switch r.URL.Path {
+		case "/v3/kv/put":
+			kv[decode("key")] = decode("value")
+		case "/v3/kv/deleterange":
+			for k := range kv {
+				if k >= decode("key") && k < decode("range_end") {
+					delete(kv, k)
+				}
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "{}")
+	}))
+	defer srv.Close()
+
+	// The first endpoint is down
+	p, err := newCoreDNSProvider(&nconfig.ExternalDNSConfig{
+		Endpoints: []string{"http://127.0.0.1:1", srv.URL + "/"},
+	})
+	require.Nil(err)
+
+	r := &dnsRecord{Name: "web.example.com", AllocID: "a", IPs: []string{"10.0.0.1", "10.0.0.2"}, TTL: time.Minute}
+	require.Nil(p.Register(r))
+	require.Equal(map[string]string{
+		"/skydns/com/example/web/a-0": `{"host":"10.0.0.1","ttl":60}`,
+		"/skydns/com/example/web/a-1": `{"host":"10.0.0.2","ttl":60}`,
+	}, kv)
+
+	// Registering replaces the previous addresses
+	r.IPs = []string{"10.0.0.3"}
+	require.Nil(p.Register(r))
+	require.Equal(map[string]string{
+		"/skydns/com/example/web/a-0": `{"host":"10.0.0.3","ttl":60}`,
+	}, kv)
+
+	require.Nil(p.Deregister(r))
+	require.Empty(kv)
+}
@@ -3,9 +3,13 @@
 package fingerprint
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"strings"
 
 	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper/cgroupv2"
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 )
 
@@ -16,8 +20,9 @@ func FindCgroupMountpointDir() (string, error) {
 	if err != nil {
 		switch e := err.(type) {
 		case *cgroups.NotFoundError:
-			// It's okay if the mount point is not discovered
-			return "", nil
+			// Hosts using only the cgroup v2 unified hierarchy have no v1
+			// mounts. It's okay if neither mount point is discovered.
+			return findCgroup2Mountpoint()
 		default:
 			// All other errors are passed back as is
 			return "", e
@@ -26,6 +31,41 @@ func FindCgroupMountpointDir() (string, error) {
 	return mount, nil
 }
 
+// findCgroup2Mountpoint returns the mount point of the cgroup v2 unified
+// hierarchy or "" if it is not mounted.
+func findCgroup2Mountpoint() (string, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		text := scanner.Text()
+		index := strings.Index(text, " - ")
+		if index < 0 {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		postSeparatorFields := strings.Fields(text[index+3:])
+		if len(fields) > 4 && len(postSeparatorFields) > 0 && postSeparatorFields[0] == "cgroup2" {
+			return fields[4], nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// cgroupVersion returns the version of the cgroup hierarchy mounted at the
+// mount point, "v2" for the unified hierarchy and "v1" otherwise.
+func cgroupVersion(mount string) string {
+	if cgroupv2.IsMount(mount) {
+		return "v2"
+	}
+	return "v1"
+}
+
 // Fingerprint tries to find a valid cgroup moint point
 func (f *CGroupFingerprint) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
 	mount, err := f.mountPointDetector.MountPoint()
@@ -47,6 +87,7 @@ func (f *CGroupFingerprint) Fingerprint(req *cstructs.FingerprintRequest, resp *
 	}
 
 	resp.AddAttribute("unique.cgroup.mountpoint", mount)
+	resp.AddAttribute("unique.cgroup.version", cgroupVersion(mount))
 	resp.Detected = true
 
 	if f.lastState == cgroupUnavailable {
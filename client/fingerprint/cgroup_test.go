@@ -83,6 +83,9 @@ func TestCGroupFingerprint(t *testing.T) {
 		if a, ok := response.Attributes["unique.cgroup.mountpoint"]; !ok {
 			t.Fatalf("unable to find attribute: %s", a)
 		}
+		if a := response.Attributes["unique.cgroup.version"]; a != "v1" && a != "v2" {
+			t.Fatalf("unexpected cgroup version: %q", a)
+		}
 	}
 
 	{
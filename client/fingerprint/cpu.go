@@ -27,6 +27,11 @@ func (f *CPUFingerprint) Fingerprint(req *cstructs.FingerprintRequest, resp *cst
 		resp.Resources = &structs.Resources{
 			CPU: totalCompute,
 		}
+
+		// The logical CPUs tasks can be pinned to
+		for i := 0; i < stats.CPUNumCores(); i++ {
+			resp.Resources.CoreIDs = append(resp.Resources.CoreIDs, i)
+		}
 	}
 
 	if err := stats.Init(); err != nil {
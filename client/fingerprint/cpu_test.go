@@ -1,6 +1,7 @@
 package fingerprint
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/hashicorp/nomad/client/config"
@@ -47,6 +48,9 @@ func TestCPUFingerprint(t *testing.T) {
 	if response.Resources == nil || response.Resources.CPU == 0 {
 		t.Fatalf("Expected to find CPU Resources")
 	}
+	if n := len(response.Resources.CoreIDs); n == 0 || attributes["cpu.numcores"] != strconv.Itoa(n) {
+		t.Fatalf("Expected a core ID per core: %v", response.Resources.CoreIDs)
+	}
 }
 
 // TestCPUFingerprint_OverrideCompute asserts that setting cpu_total_compute in
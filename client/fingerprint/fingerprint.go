@@ -36,6 +36,7 @@ var (
 		"memory":  NewMemoryFingerprint,
 		"network": NewNetworkFingerprint,
 		"nomad":   NewNomadFingerprint,
+		"power":   NewPowerFingerprint,
 		"signal":  NewSignalFingerprint,
 		"storage": NewStorageFingerprint,
 		"vault":   NewVaultFingerprint,
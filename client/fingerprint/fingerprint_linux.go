@@ -2,4 +2,5 @@ package fingerprint
 
 func initPlatformFingerprints(fps map[string]Factory) {
 	fps["cgroup"] = NewCGroupFingerprint
+	fps["sriov"] = NewSRIOVFingerprint
 }
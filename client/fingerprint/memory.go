@@ -3,8 +3,10 @@ package fingerprint
 import (
 	"fmt"
 	"log"
+	"strconv"
 
 	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper/numa"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/shirou/gopsutil/mem"
 )
@@ -38,5 +40,19 @@ func (f *MemoryFingerprint) Fingerprint(req *cstructs.FingerprintRequest, resp *
 		}
 	}
 
+	// Fingerprint the memory and cores of each NUMA node
+	topology, err := numa.Topology()
+	if err != nil {
+		f.logger.Printf("[WARN] fingerprint.memory: failed to read NUMA topology: %v", err)
+		return nil
+	}
+	if len(topology) != 0 {
+		resp.AddAttribute("numa.nodes", strconv.Itoa(len(topology)))
+	}
+	for _, n := range topology {
+		resp.AddAttribute(fmt.Sprintf("numa.%d.memory.totalbytes", n.ID), strconv.FormatInt(int64(n.MemoryMB)*1024*1024, 10))
+		resp.AddAttribute(fmt.Sprintf("numa.%d.cores", n.ID), structs.CoreIDsString(n.Cores))
+	}
+
 	return nil
 }
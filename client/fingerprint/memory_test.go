@@ -29,4 +29,10 @@ func TestMemoryFingerprint(t *testing.T) {
 	if response.Resources.MemoryMB == 0 {
 		t.Fatalf("Expected node.Resources.MemoryMB to be non-zero")
 	}
+
+	// Hosts exposing their NUMA topology have their nodes fingerprinted
+	if response.Attributes["numa.nodes"] != "" {
+		assertNodeAttributeContains(t, response.Attributes, "numa.0.memory.totalbytes")
+		assertNodeAttributeContains(t, response.Attributes, "numa.0.cores")
+	}
 }
@@ -0,0 +1,226 @@
+package fingerprint
+
+import (
+	"fmt"
+	"log"
+	"net/rpc"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-plugin"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+	nconfig "github.com/hashicorp/nomad/nomad/structs/config"
+)
+
+const (
+	// pluginName is the name the fingerprinter is dispensed under by plugins
+	pluginName = "fingerprint"
+
+	// pluginAttributePrefix is the prefix of the attributes and links set by
+	// external fingerprinters. It is followed by the name of the plugin.
+	pluginAttributePrefix = "plugins."
+)
+
+// PluginHandshake is the handshake configuration of fingerprinter plugins
+var PluginHandshake = plugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "NOMAD_FINGERPRINT_PLUGIN_MAGIC_COOKIE",
+	MagicCookieValue: "0e5b0c8b034ec098b693c194fa9f5a0b6ea3a8b0d1c6f3f84e3ba3a5e3ed0f9a",
+}
+
+// PluginRequest is the request sent to fingerprinter plugins
+type PluginRequest struct {
+	// Config is the configuration of the plugin in the client
+	Config map[string]string
+
+	// Attributes are the attributes of the node
+	Attributes map[string]string
+}
+
+// Plugin is implemented by external fingerprinters. The attributes and links
+// added to the response are set on the node under "plugins.<name>." and the
+// devices replace those of the same type on the node.
+type Plugin interface {
+	Fingerprint(*PluginRequest, *cstructs.FingerprintResponse) error
+}
+
+// ServePlugin serves the fingerprinter. It is called by the main function of
+// plugin binaries and doesn't return.
+func ServePlugin(p Plugin) {
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: PluginHandshake,
+		Plugins: map[string]plugin.Plugin{
+			pluginName: &fingerprintPlugin{impl: p},
+		},
+	})
+}
+
+// fingerprintPlugin implements plugin.Plugin for fingerprinters
+type fingerprintPlugin struct {
+	impl Plugin
+}
+
+func (p *fingerprintPlugin) Server(*plugin.MuxBroker) (interface{}, error) {
+	return &fingerprintRPCServer{impl: p.impl}, nil
+}
+
+func (p *fingerprintPlugin) Client(b *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+	return &fingerprintRPC{client: c}, nil
+}
+
+type fingerprintRPC struct {
+	client *rpc.Client
+}
+
+func (f *fingerprintRPC) Fingerprint(req *PluginRequest, resp *cstructs.FingerprintResponse) error {
+	return f.client.Call("Plugin.Fingerprint", req, resp)
+}
+
+type fingerprintRPCServer struct {
+	impl Plugin
+}
+
+func (f *fingerprintRPCServer) Fingerprint(req *PluginRequest, resp *cstructs.FingerprintResponse) error {
+	return f.impl.Fingerprint(req, resp)
+}
+
+// ExternalFingerprint fingerprints the node by launching a fingerprinter
+// plugin
+type ExternalFingerprint struct {
+	conf   *nconfig.FingerprintPluginConfig
+	logger *log.Logger
+
+	// attributes, links and deviceTypes are the names reported by the last
+	// fingerprint, so that the ones no longer reported are removed
+	attributes  map[string]struct{}
+	links       map[string]struct{}
+	deviceTypes map[string]struct{}
+}
+
+// NewExternalFingerprint returns a fingerprinter running the given plugin
+func NewExternalFingerprint(conf *nconfig.FingerprintPluginConfig, logger *log.Logger) *ExternalFingerprint {
+	return &ExternalFingerprint{
+		conf:   conf,
+		logger: logger,
+	}
+}
+
+// Name returns the name of the plugin
+func (f *ExternalFingerprint) Name() string {
+	return f.conf.Name
+}
+
+func (f *ExternalFingerprint) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	var presp cstructs.FingerprintResponse
+	if err := f.run(req, &presp); err != nil {
+		// A failing plugin shouldn't prevent the client from starting
+		f.logger.Printf("[WARN] fingerprint.plugin: fingerprinting with plugin %q failed: %v", f.conf.Name, err)
+		return nil
+	}
+
+	prefix := pluginAttributePrefix + f.conf.Name + "."
+	attributes := make(map[string]struct{}, len(presp.Attributes))
+	for name, value := range presp.Attributes {
+		resp.AddAttribute(prefix+name, value)
+		attributes[prefix+name] = struct{}{}
+	}
+	for name := range f.attributes {
+		if _, ok := attributes[name]; !ok {
+			resp.RemoveAttribute(name)
+		}
+	}
+	f.attributes = attributes
+
+	links := make(map[string]struct{}, len(presp.Links))
+	for name, value := range presp.Links {
+		resp.AddLink(prefix+name, value)
+		links[prefix+name] = struct{}{}
+	}
+	for name := range f.links {
+		if _, ok := links[name]; !ok {
+			resp.RemoveLink(name)
+		}
+	}
+	f.links = links
+
+	if presp.Resources != nil {
+		resp.Resources = presp.Resources.Copy()
+	}
+	resp.Resources = f.mergeDevices(req.Node, resp.Resources)
+	resp.Detected = presp.Detected
+	return nil
+}
+
+// run launches the plugin and fingerprints the node with it
+func (f *ExternalFingerprint) run(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	client := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig: PluginHandshake,
+		Plugins: map[string]plugin.Plugin{
+			pluginName: new(fingerprintPlugin),
+		},
+		Cmd:     exec.Command(f.conf.Command, f.conf.Args...),
+		Managed: true,
+	})
+	defer client.Kill()
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		return fmt.Errorf("error creating rpc client: %v", err)
+	}
+	raw, err := rpcClient.Dispense(pluginName)
+	if err != nil {
+		return fmt.Errorf("unable to dispense the plugin: %v", err)
+	}
+
+	preq := &PluginRequest{
+		Config:     f.conf.Config,
+		Attributes: make(map[string]string),
+	}
+	if req.Node != nil {
+		for name, value := range req.Node.Attributes {
+			// Don't leak the attributes of other plugins
+			if !strings.HasPrefix(name, pluginAttributePrefix) {
+				preq.Attributes[name] = value
+			}
+		}
+	}
+	return raw.(Plugin).Fingerprint(preq, resp)
+}
+
+// mergeDevices returns the resources with the devices of the node added to the
+// devices reported by the plugin, minus the devices of the types the plugin
+// reported before. The node's devices are replaced when the resources are
+// merged so they would otherwise be lost.
+func (f *ExternalFingerprint) mergeDevices(node *structs.Node, resources *structs.Resources) *structs.Resources {
+	deviceTypes := make(map[string]struct{})
+	if resources != nil {
+		for _, d := range resources.Devices {
+			deviceTypes[d.Type] = struct{}{}
+		}
+	}
+	previous := f.deviceTypes
+	f.deviceTypes = deviceTypes
+	if (len(deviceTypes) == 0 && len(previous) == 0) || node == nil || node.Resources == nil {
+		return resources
+	}
+	if resources == nil {
+		resources = &structs.Resources{}
+	}
+
+	for _, d := range node.Resources.Devices {
+		if _, ok := deviceTypes[d.Type]; ok {
+			continue
+		}
+		if _, ok := previous[d.Type]; ok {
+			continue
+		}
+		resources.Devices = append(resources.Devices, d.Copy())
+	}
+	return resources
+}
+
+func (f *ExternalFingerprint) Periodic() (bool, time.Duration) {
+	return f.conf.Interval > 0, f.conf.Interval
+}
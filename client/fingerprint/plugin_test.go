@@ -0,0 +1,131 @@
+package fingerprint
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+	nconfig "github.com/hashicorp/nomad/nomad/structs/config"
+)
+
+// testPluginEnv is set to serve the test plugin from the test binary
+const testPluginEnv = "NOMAD_TEST_FINGERPRINT_PLUGIN"
+
+// testPlugin reports an FPGA device and the kernel of the node unless its
+// config disables it
+type testPlugin struct{}
+
+func (testPlugin) Fingerprint(req *PluginRequest, resp *cstructs.FingerprintResponse) error {
+	if req.Config["enabled"] != "true" {
+		return nil
+	}
+	resp.AddAttribute("kernel", req.Attributes["kernel.name"])
+	resp.AddAttribute("leaked", req.Attributes["plugins.other.secret"])
+	resp.AddLink("inventory", "fpga-01")
+	resp.Resources = &structs.Resources{
+		Devices: []*structs.DeviceResource{
+			{
+				Type:      "fpga",
+				Name:      req.Config["vendor"],
+				Count:     1,
+				Instances: []*structs.DeviceInstance{{ID: "fpga0"}},
+			},
+		},
+	}
+	resp.Detected = true
+	return nil
+}
+
+// TestFingerprintPlugin_Helper serves the test plugin when run by the tests
+// below rather than by go test
+func TestFingerprintPlugin_Helper(t *testing.T) {
+	if os.Getenv(testPluginEnv) != "1" {
+		return
+	}
+	ServePlugin(testPlugin{})
+}
+
+func TestExternalFingerprint(t *testing.T) {
+	os.Setenv(testPluginEnv, "1")
+	defer os.Unsetenv(testPluginEnv)
+
+	conf := &nconfig.FingerprintPluginConfig{
+		Name:     "fpga",
+		Command:  os.Args[0],
+		Args:     []string{"-test.run=TestFingerprintPlugin_Helper"},
+		Interval: time.Minute,
+		Config:   map[string]string{"enabled": "true", "vendor": "xilinx"},
+	}
+	f := NewExternalFingerprint(conf, testLogger())
+	if p, d := f.Periodic(); !p || d != time.Minute {
+		t.Fatalf("unexpected periodic %v %v", p, d)
+	}
+
+	node := &structs.Node{
+		Attributes: map[string]string{
+			"kernel.name":          "linux",
+			"plugins.other.secret": "hunter2",
+		},
+		Resources: &structs.Resources{
+			Devices: []*structs.DeviceResource{{Type: "sriov", Name: "eth0", Count: 2}},
+		},
+	}
+	request := &cstructs.FingerprintRequest{Node: node}
+	var response cstructs.FingerprintResponse
+	if err := f.Fingerprint(request, &response); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !response.Detected {
+		t.Fatalf("expected plugin to be detected")
+	}
+	assertNodeAttributeEquals(t, response.Attributes, "plugins.fpga.kernel", "linux")
+	assertNodeAttributeEquals(t, response.Attributes, "plugins.fpga.leaked", "")
+	if response.Links["plugins.fpga.inventory"] != "fpga-01" {
+		t.Fatalf("unexpected links: %v", response.Links)
+	}
+
+	// The node's devices are kept along with the plugin's
+	devices := response.Resources.Devices
+	if len(devices) != 2 || devices[0].Type != "fpga" || devices[0].Name != "xilinx" || devices[1].Type != "sriov" {
+		t.Fatalf("unexpected devices: %v", devices)
+	}
+
+	// The attributes and devices no longer reported are removed
+	node.Resources.Merge(response.Resources)
+	conf.Config["enabled"] = "false"
+	response = cstructs.FingerprintResponse{}
+	if err := f.Fingerprint(request, &response); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if v, ok := response.Attributes["plugins.fpga.kernel"]; !ok || v != "" {
+		t.Fatalf("expected attribute to be removed: %v", response.Attributes)
+	}
+	if v, ok := response.Links["plugins.fpga.inventory"]; !ok || v != "" {
+		t.Fatalf("expected link to be removed: %v", response.Links)
+	}
+	devices = response.Resources.Devices
+	if len(devices) != 1 || devices[0].Type != "sriov" {
+		t.Fatalf("unexpected devices: %v", devices)
+	}
+}
+
+func TestExternalFingerprint_Failure(t *testing.T) {
+	conf := &nconfig.FingerprintPluginConfig{
+		Name:    "missing",
+		Command: "/nonexistent/fingerprint-plugin",
+	}
+	f := NewExternalFingerprint(conf, testLogger())
+	if p, _ := f.Periodic(); p {
+		t.Fatalf("expected plugin without interval not to be periodic")
+	}
+
+	var response cstructs.FingerprintResponse
+	if err := f.Fingerprint(&cstructs.FingerprintRequest{Node: &structs.Node{}}, &response); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if response.Detected || len(response.Attributes) != 0 {
+		t.Fatalf("unexpected response: %+v", response)
+	}
+}
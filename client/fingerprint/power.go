@@ -0,0 +1,180 @@
+package fingerprint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cleanhttp"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+)
+
+const (
+	// powerTDPConfig is the client option setting the thermal design power
+	// of the node in watts. It overrides the power limits detected with the
+	// powercap framework.
+	powerTDPConfig = "fingerprint.power.tdp_watts"
+
+	// powerCarbonIntensityConfig is the client option setting a static
+	// carbon intensity of the electricity consumed by the node in grams of
+	// CO2 equivalent per kWh.
+	powerCarbonIntensityConfig = "fingerprint.power.carbon_intensity"
+
+	// powerCarbonProviderConfig is the client option setting the URL of a
+	// carbon intensity provider. The provider must respond to GET requests
+	// with a JSON object whose "carbon_intensity" field is the current
+	// carbon intensity in grams of CO2 equivalent per kWh. It takes
+	// precedence over the static carbon intensity.
+	powerCarbonProviderConfig = "fingerprint.power.carbon_intensity_provider"
+
+	// powerRefreshConfig is the client option setting the interval at which
+	// the carbon intensity is refreshed.
+	powerRefreshConfig = "fingerprint.power.refresh_interval"
+
+	// defaultPowerRefresh is the default interval at which the power profile
+	// is fingerprinted
+	defaultPowerRefresh = 5 * time.Minute
+
+	// carbonProviderTimeout is the timeout used when contacting the carbon
+	// intensity provider
+	carbonProviderTimeout = 5 * time.Second
+)
+
+// PowerFingerprint is used to fingerprint the power profile of the node: its
+// thermal design power and the carbon intensity of the electricity it
+// consumes. The carbon intensity changes over time so the fingerprint is
+// periodic.
+type PowerFingerprint struct {
+	logger *log.Logger
+	client *http.Client
+
+	// sysfs is the mount point of sysfs
+	sysfs string
+
+	// refresh is the interval at which the fingerprint runs. It is set from
+	// the client configuration on the first fingerprint.
+	refresh time.Duration
+}
+
+// NewPowerFingerprint is used to create a power profile fingerprint
+func NewPowerFingerprint(logger *log.Logger) Fingerprint {
+	return &PowerFingerprint{
+		logger: logger,
+		client: &http.Client{
+			Timeout:   carbonProviderTimeout,
+			Transport: cleanhttp.DefaultTransport(),
+		},
+		sysfs:   "/sys",
+		refresh: defaultPowerRefresh,
+	}
+}
+
+func (f *PowerFingerprint) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	cfg := req.Config
+	f.refresh = cfg.ReadDurationDefault(powerRefreshConfig, defaultPowerRefresh)
+
+	tdp := cfg.ReadIntDefault(powerTDPConfig, 0)
+	if tdp <= 0 {
+		tdp = f.powercapWatts()
+	}
+	if tdp > 0 {
+		resp.AddAttribute("power.tdp_watts", strconv.Itoa(tdp))
+		resp.Detected = true
+	} else {
+		resp.RemoveAttribute("power.tdp_watts")
+	}
+
+	var intensity float64
+	var err error
+	if provider := cfg.Read(powerCarbonProviderConfig); provider != "" {
+		intensity, err = f.queryCarbonIntensity(provider)
+		if err != nil {
+			// Keep the last known carbon intensity rather than flapping
+			// the attribute while the provider is unavailable
+			f.logger.Printf("[WARN] fingerprint.power: failed to query carbon intensity provider: %v", err)
+			return nil
+		}
+	} else if static := cfg.Read(powerCarbonIntensityConfig); static != "" {
+		intensity, err = strconv.ParseFloat(static, 64)
+		if err != nil || intensity < 0 {
+			return fmt.Errorf("invalid %s %q", powerCarbonIntensityConfig, static)
+		}
+	} else {
+		resp.RemoveAttribute("power.carbon_intensity")
+		return nil
+	}
+
+	resp.AddAttribute("power.carbon_intensity", strconv.FormatFloat(intensity, 'f', -1, 64))
+	resp.Detected = true
+	return nil
+}
+
+func (f *PowerFingerprint) Periodic() (bool, time.Duration) {
+	return true, f.refresh
+}
+
+// powercapWatts returns the sum of the long term power limits of the
+// top-level zones of the powercap framework, which correspond to the CPU
+// packages. It returns zero if the limits are not exposed.
+func (f *PowerFingerprint) powercapWatts() int {
+	zones, err := filepath.Glob(filepath.Join(f.sysfs, "class", "powercap", "intel-rapl:*"))
+	if err != nil {
+		return 0
+	}
+
+	var microwatts uint64
+	for _, zone := range zones {
+		// Sub-zones, such as the DRAM of a package, are named after their
+		// parent and are already accounted for by the package limit
+		if strings.Count(filepath.Base(zone), ":") != 1 {
+			continue
+		}
+
+		raw, err := ioutil.ReadFile(filepath.Join(zone, "constraint_0_max_power_uw"))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				f.logger.Printf("[DEBUG] fingerprint.power: failed to read power limit of %s: %v", zone, err)
+			}
+			continue
+		}
+		v, err := strconv.ParseUint(strings.TrimSpace(string(raw)), 10, 64)
+		if err != nil {
+			continue
+		}
+		microwatts += v
+	}
+
+	return int(microwatts / 1000000)
+}
+
+// queryCarbonIntensity returns the current carbon intensity reported by the
+// provider.
+func (f *PowerFingerprint) queryCarbonIntensity(provider string) (float64, error) {
+	res, err := f.client.Get(provider)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected response code %d", res.StatusCode)
+	}
+
+	var out struct {
+		CarbonIntensity *float64 `json:"carbon_intensity"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %v", err)
+	}
+	if out.CarbonIntensity == nil || *out.CarbonIntensity < 0 {
+		return 0, fmt.Errorf("response has no valid carbon_intensity")
+	}
+	return *out.CarbonIntensity, nil
+}
@@ -0,0 +1,87 @@
+package fingerprint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/client/config"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPowerFingerprint(t *testing.T) {
+	require := require.New(t)
+
+	// Create a fake powercap framework with two packages
+	sysfs, err := ioutil.TempDir("", "nomad-sysfs")
+	require.NoError(err)
+	defer os.RemoveAll(sysfs)
+	for zone, uw := range map[string]string{
+		"intel-rapl:0":   "125000000",
+		"intel-rapl:1":   "125000000",
+		"intel-rapl:0:0": "30000000",
+	} {
+		dir := filepath.Join(sysfs, "class", "powercap", zone)
+		require.NoError(os.MkdirAll(dir, 0755))
+		require.NoError(ioutil.WriteFile(filepath.Join(dir, "constraint_0_max_power_uw"), []byte(uw+"\n"), 0644))
+	}
+
+	intensity := "412.5"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if intensity == "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, `{"carbon_intensity": %s}`, intensity)
+	}))
+	defer ts.Close()
+
+	fp := NewPowerFingerprint(testLogger())
+	fp.(*PowerFingerprint).sysfs = sysfs
+
+	conf := config.DefaultConfig()
+	conf.Options = map[string]string{
+		powerCarbonProviderConfig:  ts.URL,
+		powerCarbonIntensityConfig: "100",
+		powerRefreshConfig:         "1m",
+	}
+	request := &cstructs.FingerprintRequest{Config: conf, Node: &structs.Node{}}
+
+	// The provider takes precedence over the static carbon intensity
+	var response cstructs.FingerprintResponse
+	require.NoError(fp.Fingerprint(request, &response))
+	require.True(response.Detected)
+	require.Equal("250", response.Attributes["power.tdp_watts"])
+	require.Equal("412.5", response.Attributes["power.carbon_intensity"])
+
+	periodic, interval := fp.Periodic()
+	require.True(periodic)
+	require.Equal(time.Minute, interval)
+
+	// The last carbon intensity is retained while the provider is unavailable
+	intensity = ""
+	response = cstructs.FingerprintResponse{}
+	require.NoError(fp.Fingerprint(request, &response))
+	_, ok := response.Attributes["power.carbon_intensity"]
+	require.False(ok)
+
+	// The configured TDP overrides the detected limits
+	delete(conf.Options, powerCarbonProviderConfig)
+	conf.Options[powerTDPConfig] = "95"
+	response = cstructs.FingerprintResponse{}
+	require.NoError(fp.Fingerprint(request, &response))
+	require.Equal("95", response.Attributes["power.tdp_watts"])
+	require.Equal("100", response.Attributes["power.carbon_intensity"])
+
+	// Invalid static carbon intensities are rejected
+	conf.Options[powerCarbonIntensityConfig] = "-1"
+	response = cstructs.FingerprintResponse{}
+	require.Error(fp.Fingerprint(request, &response))
+}
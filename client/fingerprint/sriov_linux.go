@@ -0,0 +1,138 @@
+package fingerprint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper/capabilities"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// SRIOVFingerprint is used to fingerprint the SR-IOV virtual functions of the
+// network interfaces, so that they can be assigned to tasks as devices.
+type SRIOVFingerprint struct {
+	StaticFingerprinter
+	logger *log.Logger
+
+	// sysfs is the mount point of sysfs
+	sysfs string
+}
+
+// NewSRIOVFingerprint returns a new SR-IOV fingerprinter
+func NewSRIOVFingerprint(logger *log.Logger) Fingerprint {
+	return &SRIOVFingerprint{
+		logger: logger,
+		sysfs:  "/sys",
+	}
+}
+
+func (f *SRIOVFingerprint) Fingerprint(req *cstructs.FingerprintRequest, resp *cstructs.FingerprintResponse) error {
+	devices, err := f.devices()
+	if err != nil {
+		f.logger.Printf("[WARN] fingerprint.sriov: failed to detect SR-IOV devices: %v", err)
+		return nil
+	}
+	if len(devices) == 0 {
+		return nil
+	}
+
+	// Attaching a virtual function moves its interface into the task's
+	// network namespace with iproute2
+	if !capabilities.Has(capabilities.NetAdmin) {
+		f.logger.Printf("[WARN] fingerprint.sriov: SR-IOV devices require the %s capability, disabling", capabilities.NetAdmin)
+		return nil
+	}
+	if _, err := exec.LookPath("ip"); err != nil {
+		f.logger.Printf("[WARN] fingerprint.sriov: SR-IOV devices require the ip command of iproute2, disabling")
+		return nil
+	}
+
+	for _, d := range devices {
+		resp.AddAttribute(fmt.Sprintf("sriov.%s.vfs", d.Name), strconv.Itoa(len(d.Instances)))
+		resp.AddAttribute(fmt.Sprintf("sriov.%s.numa_node", d.Name), strconv.Itoa(d.Instances[0].NUMANode))
+		if d.MBits > 0 {
+			resp.AddAttribute(fmt.Sprintf("sriov.%s.mbits", d.Name), strconv.Itoa(d.MBits))
+		}
+	}
+	resp.Resources = &structs.Resources{Devices: devices}
+	resp.Detected = true
+	return nil
+}
+
+// devices returns the enabled virtual functions of each physical function, in
+// the order of their interface names.
+func (f *SRIOVFingerprint) devices() ([]*structs.DeviceResource, error) {
+	netDir := filepath.Join(f.sysfs, "class", "net")
+	ifaces, err := ioutil.ReadDir(netDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var devices []*structs.DeviceResource
+	for _, iface := range ifaces {
+		name := iface.Name()
+		deviceDir := filepath.Join(netDir, name, "device")
+		numVFs, err := readSysfsInt(filepath.Join(deviceDir, "sriov_numvfs"))
+		if err != nil || numVFs <= 0 {
+			// Not a physical function, or no virtual functions are enabled
+			continue
+		}
+
+		numaNode, err := readSysfsInt(filepath.Join(deviceDir, "numa_node"))
+		if err != nil || numaNode < 0 {
+			// Hosts without NUMA report -1
+			numaNode = 0
+		}
+		localCPUs, _ := ioutil.ReadFile(filepath.Join(deviceDir, "local_cpulist"))
+
+		// The link speed can't be read while the link is down
+		mbits, err := readSysfsInt(filepath.Join(netDir, name, "speed"))
+		if err != nil || mbits < 0 {
+			mbits = 0
+		}
+
+		d := &structs.DeviceResource{
+			Type:  structs.DeviceTypeSRIOV,
+			Name:  name,
+			MBits: mbits,
+		}
+		for i := 0; i < numVFs; i++ {
+			link, err := os.Readlink(filepath.Join(deviceDir, fmt.Sprintf("virtfn%d", i)))
+			if err != nil {
+				f.logger.Printf("[DEBUG] fingerprint.sriov: failed to read virtual function %d of %s: %v", i, name, err)
+				continue
+			}
+			d.Instances = append(d.Instances, &structs.DeviceInstance{
+				ID:        filepath.Base(link),
+				NUMANode:  numaNode,
+				LocalCPUs: strings.TrimSpace(string(localCPUs)),
+			})
+		}
+		if len(d.Instances) != 0 {
+			devices = append(devices, d)
+		}
+	}
+
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Name < devices[j].Name })
+	return devices, nil
+}
+
+// readSysfsInt reads a file of sysfs holding an integer
+func readSysfsInt(path string) (int, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(raw)))
+}
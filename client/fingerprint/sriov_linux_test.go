@@ -0,0 +1,83 @@
+package fingerprint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeSRIOVSysfs creates the sysfs entries of a network interface with the
+// virtual functions at the PCI addresses
+func writeSRIOVSysfs(t *testing.T, sysfs, iface, numaNode, speed string, vfs ...string) {
+	deviceDir := filepath.Join(sysfs, "class", "net", iface, "device")
+	if err := os.MkdirAll(deviceDir, 0755); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	files := map[string]string{
+		filepath.Join(deviceDir, "numa_node"):                numaNode + "\n",
+		filepath.Join(deviceDir, "local_cpulist"):            "0-7\n",
+		filepath.Join(sysfs, "class", "net", iface, "speed"): speed + "\n",
+	}
+	if vfs != nil {
+		files[filepath.Join(deviceDir, "sriov_numvfs")] = "2\n"
+	}
+	for path, contents := range files {
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	for i, vf := range vfs {
+		link := filepath.Join(deviceDir, fmt.Sprintf("virtfn%d", i))
+		if err := os.Symlink(filepath.Join("..", vf), link); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+}
+
+func TestSRIOVFingerprint_Devices(t *testing.T) {
+	assert := assert.New(t)
+	sysfs, err := ioutil.TempDir("", "sriov")
+	assert.Nil(err)
+	defer os.RemoveAll(sysfs)
+
+	writeSRIOVSysfs(t, sysfs, "eth1", "1", "25000", "0000:af:02.0", "0000:af:02.1")
+	writeSRIOVSysfs(t, sysfs, "eth0", "-1", "-1", "0000:3b:02.0", "0000:3b:02.1")
+	writeSRIOVSysfs(t, sysfs, "lo", "-1", "10")
+
+	f := &SRIOVFingerprint{logger: testLogger(), sysfs: sysfs}
+	devices, err := f.devices()
+	assert.Nil(err)
+	assert.Equal([]*structs.DeviceResource{
+		{
+			Type: structs.DeviceTypeSRIOV,
+			Name: "eth0",
+			Instances: []*structs.DeviceInstance{
+				{ID: "0000:3b:02.0", LocalCPUs: "0-7"},
+				{ID: "0000:3b:02.1", LocalCPUs: "0-7"},
+			},
+		},
+		{
+			Type:  structs.DeviceTypeSRIOV,
+			Name:  "eth1",
+			MBits: 25000,
+			Instances: []*structs.DeviceInstance{
+				{ID: "0000:af:02.0", NUMANode: 1, LocalCPUs: "0-7"},
+				{ID: "0000:af:02.1", NUMANode: 1, LocalCPUs: "0-7"},
+			},
+		},
+	}, devices)
+}
+
+func TestSRIOVFingerprint_NoSysfs(t *testing.T) {
+	f := &SRIOVFingerprint{logger: testLogger(), sysfs: "/does/not/exist"}
+	devices, err := f.devices()
+	assert.Nil(t, err)
+	assert.Empty(t, devices)
+}
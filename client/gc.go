@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/client/stats"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -26,6 +27,10 @@ type GCConfig struct {
 	Interval            time.Duration
 	ReservedDiskMB      int
 	ParallelDestroys    int
+
+	// Policies collect the allocations they match before the thresholds
+	// are reached
+	Policies []*config.GCPolicy
 }
 
 // AllocCounter is used by AllocGarbageCollector to discover how many un-GC'd
@@ -96,6 +101,9 @@ func (a *AllocGarbageCollector) Run() {
 			return
 		}
 
+		if err := a.collectByPolicy(); err != nil {
+			a.logger.Printf("[ERR] client.gc: error garbage collecting allocation by policy: %v", err)
+		}
 		if err := a.keepUsageBelowThreshold(); err != nil {
 			a.logger.Printf("[ERR] client.gc: error garbage collecting allocation: %v", err)
 		}
@@ -166,6 +174,77 @@ func (a *AllocGarbageCollector) keepUsageBelowThreshold() error {
 	return nil
 }
 
+// policyFor returns the first GC policy matching the allocation, nil if none
+// does.
+func (a *AllocGarbageCollector) policyFor(alloc *structs.Allocation) *config.GCPolicy {
+	if alloc == nil {
+		return nil
+	}
+	for _, p := range a.config.Policies {
+		if p.Matches(alloc) {
+			return p
+		}
+	}
+	return nil
+}
+
+// collectByPolicy garbage collects the allocations of each policy that are
+// older than its maximum age, then the oldest allocations of the policy while
+// the disk usage is over its threshold.
+func (a *AllocGarbageCollector) collectByPolicy() error {
+	for i, p := range a.config.Policies {
+		matches := func(gcAlloc *GCAlloc) bool {
+			return a.policyFor(gcAlloc.allocRunner.Alloc()) == p
+		}
+
+		if p.MaxAge > 0 {
+			cutoff := time.Now().Add(-p.MaxAge)
+			reason := fmt.Sprintf("age over the max of %s of gc policy %d", p.MaxAge, i+1)
+			for {
+				select {
+				case <-a.shutdownCh:
+					return nil
+				default:
+				}
+
+				gcAlloc := a.allocRunners.PopMatching(func(gcAlloc *GCAlloc) bool {
+					return gcAlloc.timeStamp.Before(cutoff) && matches(gcAlloc)
+				})
+				if gcAlloc == nil {
+					break
+				}
+				a.destroyAllocRunner(gcAlloc.allocRunner, reason)
+			}
+		}
+
+		if p.DiskUsageThreshold > 0 {
+			for {
+				select {
+				case <-a.shutdownCh:
+					return nil
+				default:
+				}
+
+				if err := a.statsCollector.Collect(); err != nil {
+					return err
+				}
+				diskStats := a.statsCollector.Stats().AllocDirStats
+				if diskStats.UsedPercent <= p.DiskUsageThreshold {
+					break
+				}
+
+				gcAlloc := a.allocRunners.PopMatching(matches)
+				if gcAlloc == nil {
+					break
+				}
+				a.destroyAllocRunner(gcAlloc.allocRunner, fmt.Sprintf("disk usage of %.0f is over threshold of %.0f of gc policy %d",
+					diskStats.UsedPercent, p.DiskUsageThreshold, i+1))
+			}
+		}
+	}
+	return nil
+}
+
 // destroyAllocRunner is used to destroy an allocation runner. It will acquire a
 // lock to restrict parallelism and then destroy the alloc runner, returning
 // once the allocation has been destroyed.
@@ -427,6 +506,27 @@ func (i *IndexedGCAllocPQ) Pop() *GCAlloc {
 	return gcAlloc
 }
 
+// PopMatching removes and returns the oldest alloc runner matching the
+// function. Returns nil if none matches.
+func (i *IndexedGCAllocPQ) PopMatching(match func(*GCAlloc) bool) *GCAlloc {
+	i.pqLock.Lock()
+	defer i.pqLock.Unlock()
+
+	var oldest *GCAlloc
+	for _, gcAlloc := range i.heap {
+		if (oldest == nil || gcAlloc.timeStamp.Before(oldest.timeStamp)) && match(gcAlloc) {
+			oldest = gcAlloc
+		}
+	}
+	if oldest == nil {
+		return nil
+	}
+
+	heap.Remove(&i.heap, oldest.index)
+	delete(i.index, oldest.allocRunner.Alloc().ID)
+	return oldest
+}
+
 // Remove alloc from GC. Returns nil if alloc doesn't exist.
 func (i *IndexedGCAllocPQ) Remove(allocID string) *GCAlloc {
 	i.pqLock.Lock()
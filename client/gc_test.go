@@ -482,3 +482,104 @@ func TestAllocGarbageCollector_UsedPercentThreshold(t *testing.T) {
 		t.Fatalf("gcAlloc: %v", gcAlloc)
 	}
 }
+
+func TestIndexedGCAllocPQ_PopMatching(t *testing.T) {
+	t.Parallel()
+	pq := NewIndexedGCAllocPQ()
+
+	_, ar1 := testAllocRunnerFromAlloc(mock.Alloc(), false)
+	_, ar2 := testAllocRunnerFromAlloc(mock.Alloc(), false)
+	_, ar3 := testAllocRunnerFromAlloc(mock.Alloc(), false)
+	pq.Push(ar1)
+	pq.Push(ar2)
+	pq.Push(ar3)
+
+	notFirst := func(gcAlloc *GCAlloc) bool {
+		return gcAlloc.allocRunner != ar1
+	}
+	if gcAlloc := pq.PopMatching(notFirst); gcAlloc == nil || gcAlloc.allocRunner != ar2 {
+		t.Fatalf("expected alloc %v, got %v", ar2.Alloc().ID, gcAlloc)
+	}
+	if gcAlloc := pq.PopMatching(notFirst); gcAlloc == nil || gcAlloc.allocRunner != ar3 {
+		t.Fatalf("expected alloc %v, got %v", ar3.Alloc().ID, gcAlloc)
+	}
+	if gcAlloc := pq.PopMatching(notFirst); gcAlloc != nil {
+		t.Fatalf("expected nil, got %v", gcAlloc)
+	}
+
+	// The remaining alloc is still indexed
+	if gcAlloc := pq.Remove(ar1.Alloc().ID); gcAlloc == nil {
+		t.Fatalf("expected alloc %v to be removed", ar1.Alloc().ID)
+	}
+}
+
+func TestAllocGarbageCollector_PolicyMaxAge(t *testing.T) {
+	t.Parallel()
+	logger := testLogger()
+	conf := gcConfig()
+	conf.Policies = []*config.GCPolicy{{Namespace: "batch", MaxAge: time.Millisecond}}
+	gc := NewAllocGarbageCollector(logger, &MockStatsCollector{}, &MockAllocCounter{}, conf)
+
+	batch := mock.Alloc()
+	batch.Namespace = "batch"
+	_, ar1 := testAllocRunnerFromAlloc(batch, false)
+	close(ar1.waitCh)
+	_, ar2 := testAllocRunnerFromAlloc(mock.Alloc(), false)
+	close(ar2.waitCh)
+	gc.MarkForCollection(ar1)
+	gc.MarkForCollection(ar2)
+
+	time.Sleep(10 * time.Millisecond)
+	if err := gc.collectByPolicy(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Only the alloc of the batch namespace should be collected
+	if gcAlloc := gc.allocRunners.Pop(); gcAlloc == nil || gcAlloc.allocRunner != ar2 {
+		t.Fatalf("expected alloc %v, got %v", ar2.Alloc().ID, gcAlloc)
+	}
+	if gcAlloc := gc.allocRunners.Pop(); gcAlloc != nil {
+		t.Fatalf("gcAlloc: %v", gcAlloc)
+	}
+}
+
+func TestAllocGarbageCollector_PolicyDiskUsageThreshold(t *testing.T) {
+	t.Parallel()
+	logger := testLogger()
+	statsCollector := &MockStatsCollector{}
+	conf := gcConfig()
+	conf.Policies = []*config.GCPolicy{{MaxPriority: 30, DiskUsageThreshold: 50}}
+	gc := NewAllocGarbageCollector(logger, statsCollector, &MockAllocCounter{}, conf)
+
+	low1 := mock.Alloc()
+	low1.Job.Priority = 20
+	_, ar1 := testAllocRunnerFromAlloc(low1, false)
+	close(ar1.waitCh)
+	_, ar2 := testAllocRunnerFromAlloc(mock.Alloc(), false)
+	close(ar2.waitCh)
+	low2 := mock.Alloc()
+	low2.Job.Priority = 30
+	_, ar3 := testAllocRunnerFromAlloc(low2, false)
+	close(ar3.waitCh)
+	gc.MarkForCollection(ar1)
+	gc.MarkForCollection(ar2)
+	gc.MarkForCollection(ar3)
+
+	// The usage stays over the threshold of the policy but below the
+	// threshold of the client
+	statsCollector.availableValues = []uint64{1000}
+	statsCollector.usedPercents = []float64{60}
+	statsCollector.inodePercents = []float64{10}
+
+	if err := gc.collectByPolicy(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Both low priority allocs should be collected
+	if gcAlloc := gc.allocRunners.Pop(); gcAlloc == nil || gcAlloc.allocRunner != ar2 {
+		t.Fatalf("expected alloc %v, got %v", ar2.Alloc().ID, gcAlloc)
+	}
+	if gcAlloc := gc.allocRunners.Pop(); gcAlloc != nil {
+		t.Fatalf("gcAlloc: %v", gcAlloc)
+	}
+}
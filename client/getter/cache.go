@@ -0,0 +1,223 @@
+package getter
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gg "github.com/hashicorp/go-getter"
+)
+
+// Cache is a content-addressed cache of the downloaded artifact files, shared
+// by the tasks of a client. Files are keyed by the checksum of their
+// artifact, so only artifacts with a checksum are cached. The least recently
+// used files are evicted once the cache grows larger than its maximum size.
+type Cache struct {
+	dir     string
+	maxSize int64
+	l       sync.Mutex
+}
+
+// NewCache returns a cache storing the files in dir, up to maxSize bytes.
+func NewCache(dir string, maxSize int64) *Cache {
+	return &Cache{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+}
+
+// SetMaxSize changes the maximum size of the cache, evicting the least
+// recently used files if the cache no longer fits.
+func (c *Cache) SetMaxSize(maxSize int64) error {
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.maxSize = maxSize
+	if _, err := os.Stat(c.dir); os.IsNotExist(err) {
+		return nil
+	}
+	return c.evict()
+}
+
+// cacheKey returns the key of a checksum of the form "type:value", or an
+// empty key if the checksum is invalid.
+func cacheKey(checksum string) string {
+	idx := strings.Index(checksum, ":")
+	if idx == -1 || newChecksumHash(checksum[:idx]) == nil {
+		return ""
+	}
+	value := strings.ToLower(checksum[idx+1:])
+	if _, err := hex.DecodeString(value); err != nil || value == "" {
+		return ""
+	}
+	return checksum[:idx] + "-" + value
+}
+
+// newChecksumHash returns the hash of the checksum type, nil if unsupported.
+func newChecksumHash(checksumType string) hash.Hash {
+	switch checksumType {
+	case "md5":
+		return md5.New()
+	case "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	case "sha512":
+		return sha512.New()
+	}
+	return nil
+}
+
+// fetch copies the file cached under key to dst, returning false if it isn't
+// cached.
+func (c *Cache) fetch(key, dst string) (bool, error) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	path := filepath.Join(c.dir, key)
+	src, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer src.Close()
+
+	// Tasks may modify their files so the cached file is copied
+	if err := copyFile(dst, src); err != nil {
+		return false, err
+	}
+
+	// Mark the file as recently used
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return true, nil
+}
+
+// store caches the file at path under key if its content matches the key,
+// then evicts the least recently used files if the cache is too large.
+func (c *Cache) store(key, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(c.dir, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	// Verify the content while copying it so that the cache can't hold a
+	// file that doesn't match its checksum
+	idx := strings.Index(key, "-")
+	h := newChecksumHash(key[:idx])
+	if _, err := io.Copy(io.MultiWriter(tmp, h), src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != key[idx+1:] {
+		return fmt.Errorf("checksum of %q does not match", path)
+	}
+
+	c.l.Lock()
+	defer c.l.Unlock()
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, key)); err != nil {
+		return err
+	}
+	return c.evict()
+}
+
+// evict removes the least recently used files until the cache fits in its
+// maximum size. The lock must be held.
+func (c *Cache) evict() error {
+	infos, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	var size int64
+	var files []os.FileInfo
+	for _, info := range infos {
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".tmp-") {
+			continue
+		}
+		size += info.Size()
+		files = append(files, info)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, info := range files {
+		if size <= c.maxSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, info.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		size -= info.Size()
+	}
+	return nil
+}
+
+// copyFile copies src to a new file at dst.
+func copyFile(dst string, src io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// cachedGetter wraps a getter to serve the file downloads of an artifact from
+// the cache.
+type cachedGetter struct {
+	gg.Getter
+	cache *Cache
+	key   string
+}
+
+// GetFile copies the cached file to dst, downloading and caching it on a miss.
+// go-getter still verifies the checksum of the file afterwards.
+func (g *cachedGetter) GetFile(dst string, u *url.URL) error {
+	if ok, err := g.cache.fetch(g.key, dst); err != nil {
+		return err
+	} else if ok {
+		return nil
+	}
+
+	if err := g.Getter.GetFile(dst, u); err != nil {
+		return err
+	}
+
+	// A file that can't be cached is still a successful download
+	g.cache.store(g.key, dst)
+	return nil
+}
@@ -0,0 +1,90 @@
+package getter
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCacheKey(t *testing.T) {
+	cases := map[string]string{
+		"md5:BCE963762AA2DBFED13CAF492A45FB72": "md5-bce963762aa2dbfed13caf492a45fb72",
+		"sha256:abcd":                          "sha256-abcd",
+		"":                                     "",
+		"abcd":                                 "",
+		"crc32:abcd":                           "",
+		"sha1:not-hex":                         "",
+		"sha1:":                                "",
+	}
+	for checksum, expected := range cases {
+		if key := cacheKey(checksum); key != expected {
+			t.Fatalf("cacheKey(%q) = %q; want %q", checksum, key, expected)
+		}
+	}
+}
+
+func TestCache_StoreEvict(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("failed to make temp directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The cache fits two files
+	cache := NewCache(filepath.Join(dir, "cache"), 10)
+	store := func(content string) string {
+		sum := md5.Sum([]byte(content))
+		key := "md5-" + hex.EncodeToString(sum[:])
+		path := filepath.Join(dir, content)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		if err := cache.store(key, path); err != nil {
+			t.Fatalf("store failed: %v", err)
+		}
+		return key
+	}
+	fetch := func(key string) bool {
+		ok, err := cache.fetch(key, filepath.Join(dir, "fetched"))
+		if err != nil {
+			t.Fatalf("fetch failed: %v", err)
+		}
+		return ok
+	}
+
+	// Files not matching their key are refused
+	path := filepath.Join(dir, "bad")
+	if err := ioutil.WriteFile(path, []byte("bad"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if err := cache.store("md5-bce963762aa2dbfed13caf492a45fb72", path); err == nil {
+		t.Fatalf("expected a checksum error")
+	}
+
+	first := store("first")
+	past := time.Now().Add(-time.Hour)
+	os.Chtimes(filepath.Join(dir, "cache", first), past, past)
+	second := store("secnd")
+	os.Chtimes(filepath.Join(dir, "cache", second), past.Add(time.Minute), past.Add(time.Minute))
+
+	// Using the first file makes the second one the least recently used
+	if !fetch(first) {
+		t.Fatalf("expected %q to be cached", first)
+	}
+	buf, err := ioutil.ReadFile(filepath.Join(dir, "fetched"))
+	if err != nil || string(buf) != "first" {
+		t.Fatalf("unexpected fetched file %q: %v", buf, err)
+	}
+
+	third := store("third")
+	if fetch(second) {
+		t.Fatalf("expected %q to be evicted", second)
+	}
+	if !fetch(first) || !fetch(third) {
+		t.Fatalf("expected %q and %q to be cached", first, third)
+	}
+}
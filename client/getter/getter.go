@@ -17,13 +17,18 @@ var (
 	getters map[string]gg.Getter
 	lock    sync.Mutex
 
-	// supported is the set of download schemes supported by Nomad
+	// supported is the set of go-getter download schemes supported by Nomad,
+	// in addition to the OCI registries
 	supported = []string{"http", "https", "s3", "hg", "git"}
 )
 
 const (
 	// gitSSHPrefix is the prefix for dowwnloading via git using ssh
 	gitSSHPrefix = "git@github.com:"
+
+	// maxParallelDownloads is the maximum number of artifacts of a task
+	// downloaded at once
+	maxParallelDownloads = 4
 )
 
 // EnvReplacer is an interface which can interpolate environment variables and
@@ -33,7 +38,9 @@ type EnvReplacer interface {
 }
 
 // getClient returns a client that is suitable for Nomad downloading artifacts.
-func getClient(src string, mode gg.ClientMode, dst string) *gg.Client {
+// The file downloads are served from the cache under key, unless the key is
+// empty. OCI artifacts are pulled with auth if it isn't nil.
+func getClient(src string, mode gg.ClientMode, dst string, cache *Cache, key string, auth *RegistryAuth) *gg.Client {
 	lock.Lock()
 	defer lock.Unlock()
 
@@ -45,13 +52,28 @@ func getClient(src string, mode gg.ClientMode, dst string) *gg.Client {
 				getters[getter] = impl
 			}
 		}
+		getters["oci"] = new(ociGetter)
+	}
+
+	clientGetters := getters
+	if auth != nil || (cache != nil && key != "") {
+		clientGetters = make(map[string]gg.Getter, len(getters))
+		for scheme, getter := range getters {
+			if scheme == "oci" && auth != nil {
+				getter = &ociGetter{auth: auth}
+			}
+			if cache != nil && key != "" {
+				getter = &cachedGetter{Getter: getter, cache: cache, key: key}
+			}
+			clientGetters[scheme] = getter
+		}
 	}
 
 	return &gg.Client{
 		Src:     src,
 		Dst:     dst,
 		Mode:    mode,
-		Getters: getters,
+		Getters: clientGetters,
 	}
 }
 
@@ -90,6 +112,61 @@ func getGetterUrl(taskEnv EnvReplacer, artifact *structs.TaskArtifact) (string,
 
 // GetArtifact downloads an artifact into the specified task directory.
 func GetArtifact(taskEnv EnvReplacer, artifact *structs.TaskArtifact, taskDir string) error {
+	return getArtifact(taskEnv, artifact, taskDir, nil, nil)
+}
+
+// GetArtifacts downloads the artifacts into the specified task directory
+// concurrently, using the cache for the artifacts with a checksum if it isn't
+// nil. auths holds the registry credentials of the OCI artifacts at the same
+// index, and may be nil. Artifacts with the same destination are downloaded in
+// order, as they may overwrite each other. The first artifact that failed is
+// returned with its error.
+func GetArtifacts(taskEnv EnvReplacer, artifacts []*structs.TaskArtifact, taskDir string, cache *Cache, auths []*RegistryAuth) (*structs.TaskArtifact, error) {
+	// Group the artifacts by destination
+	var dests []string
+	byDest := make(map[string][]int)
+	for i, artifact := range artifacts {
+		dest := filepath.Clean(artifact.RelativeDest)
+		if _, ok := byDest[dest]; !ok {
+			dests = append(dests, dest)
+		}
+		byDest[dest] = append(byDest[dest], i)
+	}
+
+	errs := make([]error, len(artifacts))
+	sem := make(chan struct{}, maxParallelDownloads)
+	var wg sync.WaitGroup
+	for _, dest := range dests {
+		wg.Add(1)
+		go func(indexes []int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			for _, i := range indexes {
+				var auth *RegistryAuth
+				if i < len(auths) {
+					auth = auths[i]
+				}
+				if errs[i] = getArtifact(taskEnv, artifacts[i], taskDir, cache, auth); errs[i] != nil {
+					return
+				}
+			}
+		}(byDest[dest])
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return artifacts[i], err
+		}
+	}
+	return nil, nil
+}
+
+// getArtifact downloads an artifact into the specified task directory, using
+// the cache and the registry credentials if they aren't nil.
+func getArtifact(taskEnv EnvReplacer, artifact *structs.TaskArtifact, taskDir string, cache *Cache, auth *RegistryAuth) error {
 	url, err := getGetterUrl(taskEnv, artifact)
 	if err != nil {
 		return newGetError(artifact.GetterSource, err, false)
@@ -107,7 +184,8 @@ func GetArtifact(taskEnv EnvReplacer, artifact *structs.TaskArtifact, taskDir st
 		mode = gg.ClientModeDir
 	}
 
-	if err := getClient(url, mode, dest).Get(); err != nil {
+	key := cacheKey(taskEnv.ReplaceEnv(artifact.GetterOptions["checksum"]))
+	if err := getClient(url, mode, dest, cache, key, auth).Get(); err != nil {
 		return newGetError(url, err, true)
 	}
 
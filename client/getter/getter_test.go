@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/hashicorp/nomad/client/driver/env"
@@ -91,6 +93,106 @@ func TestGetArtifact_File_RelativeDest(t *testing.T) {
 	}
 }
 
+func TestGetArtifacts_Parallel(t *testing.T) {
+	// Serve the files once both are requested, so that the downloads only
+	// complete if they are concurrent
+	var wg sync.WaitGroup
+	wg.Add(2)
+	fs := http.FileServer(http.Dir("./test-fixtures/"))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/missing" {
+			wg.Done()
+			wg.Wait()
+		}
+		fs.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	taskDir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("failed to make temp directory: %v", err)
+	}
+	defer os.RemoveAll(taskDir)
+
+	artifacts := []*structs.TaskArtifact{
+		{
+			GetterSource: fmt.Sprintf("%s/test.sh", ts.URL),
+			RelativeDest: "foo/",
+		},
+		{
+			GetterSource: fmt.Sprintf("%s/archive.tar.gz", ts.URL),
+			RelativeDest: "bar/",
+		},
+	}
+	if failed, err := GetArtifacts(taskEnv, artifacts, taskDir, nil, nil); err != nil {
+		t.Fatalf("GetArtifacts failed for %v: %v", failed, err)
+	}
+	for _, file := range []string{"foo/test.sh", "bar/exist/my.config"} {
+		if _, err := os.Stat(filepath.Join(taskDir, file)); err != nil {
+			t.Fatalf("file not found: %s", err)
+		}
+	}
+
+	// The failed artifact is returned
+	missing := &structs.TaskArtifact{
+		GetterSource: fmt.Sprintf("%s/missing", ts.URL),
+		RelativeDest: "foo/",
+	}
+	failed, err := GetArtifacts(taskEnv, []*structs.TaskArtifact{missing}, taskDir, nil, nil)
+	if err == nil || failed != missing {
+		t.Fatalf("expected the missing artifact to fail: %v %v", failed, err)
+	}
+}
+
+func TestGetArtifacts_Cache(t *testing.T) {
+	// Count the downloads
+	var requests int32
+	fs := http.FileServer(http.Dir("./test-fixtures/"))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fs.ServeHTTP(w, r)
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("failed to make temp directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	cache := NewCache(filepath.Join(dir, "cache"), 1024*1024)
+
+	artifact := &structs.TaskArtifact{
+		GetterSource: fmt.Sprintf("%s/test.sh", ts.URL),
+		GetterOptions: map[string]string{
+			"checksum": "md5:bce963762aa2dbfed13caf492a45fb72",
+		},
+		RelativeDest: "local/",
+	}
+
+	// Download the artifact for two tasks
+	for _, task := range []string{"task1", "task2"} {
+		taskDir := filepath.Join(dir, task)
+		if failed, err := GetArtifacts(taskEnv, []*structs.TaskArtifact{artifact}, taskDir, cache, nil); err != nil {
+			t.Fatalf("GetArtifacts failed for %v: %v", failed, err)
+		}
+		if _, err := os.Stat(filepath.Join(taskDir, "local", "test.sh")); err != nil {
+			t.Fatalf("file not found: %s", err)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Fatalf("expected the artifact to be downloaded once; got %d", n)
+	}
+
+	// Artifacts without a checksum are not cached
+	artifact.GetterOptions = nil
+	if failed, err := GetArtifacts(taskEnv, []*structs.TaskArtifact{artifact}, filepath.Join(dir, "task3"), cache, nil); err != nil {
+		t.Fatalf("GetArtifacts failed for %v: %v", failed, err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Fatalf("expected the artifact to be downloaded again; got %d", n)
+	}
+}
+
 func TestGetGetterUrl_Interprolation(t *testing.T) {
 	// Create the artifact
 	artifact := &structs.TaskArtifact{
@@ -0,0 +1,394 @@
+package getter
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	gg "github.com/hashicorp/go-getter"
+)
+
+const (
+	// ociTitleAnnotation is the annotation of a layer holding the name of
+	// its file
+	ociTitleAnnotation = "org.opencontainers.image.title"
+
+	// ociManifestTypes are the media types of the manifests accepted from
+	// the registries
+	ociManifestTypes = "application/vnd.oci.image.manifest.v1+json, application/vnd.docker.distribution.manifest.v2+json"
+)
+
+var (
+	// registryAuthConfig is the path of the Docker style configuration file
+	// holding the credentials of the OCI registries. It is guarded by the
+	// lock.
+	registryAuthConfig string
+
+	// challengeParamRe matches the parameters of a WWW-Authenticate header
+	challengeParamRe = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// RegistryAuth is the credentials used to pull artifacts from an OCI registry.
+type RegistryAuth struct {
+	Username string
+	Password string
+}
+
+// SetRegistryAuthConfig sets the path of the Docker style configuration file
+// holding the credentials of the OCI registries. The file is read on each
+// download, so updates don't require a restart.
+func SetRegistryAuthConfig(path string) {
+	lock.Lock()
+	defer lock.Unlock()
+	registryAuthConfig = path
+}
+
+// registryAuthFromConfig returns the credentials of the registry found in the
+// registry auth configuration file, nil if there are none.
+func registryAuthFromConfig(registry string) (*RegistryAuth, error) {
+	lock.Lock()
+	path := registryAuthConfig
+	lock.Unlock()
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry auth config: %v", err)
+	}
+
+	var conf struct {
+		Auths map[string]struct {
+			Auth     string `json:"auth"`
+			Username string `json:"username"`
+			Password string `json:"password"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return nil, fmt.Errorf("failed to parse registry auth config: %v", err)
+	}
+
+	for host, entry := range conf.Auths {
+		// Entries may be keyed by the URL of the registry
+		if u, err := url.Parse(host); err == nil && u.Host != "" {
+			host = u.Host
+		}
+		if host != registry {
+			continue
+		}
+
+		if entry.Auth == "" {
+			return &RegistryAuth{Username: entry.Username, Password: entry.Password}, nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid auth of registry %q: %v", registry, err)
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid auth of registry %q: must be username:password", registry)
+		}
+		return &RegistryAuth{Username: parts[0], Password: parts[1]}, nil
+	}
+	return nil, nil
+}
+
+// ociManifest is the part of an OCI image manifest used to pull artifacts.
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// ociDescriptor describes a layer of an OCI manifest.
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// ociGetter pulls artifacts from OCI registries, for sources of the form
+// oci://registry/repository:tag or oci://registry/repository@digest. Following
+// the ORAS conventions, each layer of the manifest is a file named by its title
+// annotation.
+type ociGetter struct {
+	// auth is the credentials of the registry. The registry auth
+	// configuration file is used if it is nil.
+	auth *RegistryAuth
+
+	// client is the HTTP client used to reach the registry and scheme the
+	// scheme of its URLs. They default to a clean client over HTTPS.
+	client *http.Client
+	scheme string
+}
+
+// ClientMode returns the directory mode, as an artifact may hold several files.
+func (g *ociGetter) ClientMode(u *url.URL) (gg.ClientMode, error) {
+	return gg.ClientModeDir, nil
+}
+
+// Get downloads the titled layers of the artifact into the dst directory.
+func (g *ociGetter) Get(dst string, u *url.URL) error {
+	r, manifest, err := g.manifest(u)
+	if err != nil {
+		return err
+	}
+
+	pulled := 0
+	for _, layer := range manifest.Layers {
+		title := layer.Annotations[ociTitleAnnotation]
+		if title == "" {
+			continue
+		}
+
+		// Titles are chosen by whoever pushed the artifact, so they must not
+		// escape the destination
+		name := filepath.Clean(filepath.FromSlash(title))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("layer title %q escapes the destination", title)
+		}
+		if err := r.blob(layer, filepath.Join(dst, name)); err != nil {
+			return err
+		}
+		pulled++
+	}
+
+	if pulled == 0 {
+		return fmt.Errorf("artifact %q has no titled layers", u.Host+u.Path)
+	}
+	return nil
+}
+
+// GetFile downloads the single layer of the artifact to dst.
+func (g *ociGetter) GetFile(dst string, u *url.URL) error {
+	r, manifest, err := g.manifest(u)
+	if err != nil {
+		return err
+	}
+	if n := len(manifest.Layers); n != 1 {
+		return fmt.Errorf("artifact %q must have a single layer to be downloaded as a file; has %d", u.Host+u.Path, n)
+	}
+	return r.blob(manifest.Layers[0], dst)
+}
+
+// manifest returns the manifest of the artifact along with the registry
+// client used to pull its layers.
+func (g *ociGetter) manifest(u *url.URL) (*ociRegistry, *ociManifest, error) {
+	repo, ref, err := parseOCIReference(u)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	auth := g.auth
+	if auth == nil {
+		if auth, err = registryAuthFromConfig(u.Host); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	r := &ociRegistry{
+		client: g.client,
+		base:   "https://" + u.Host,
+		repo:   repo,
+		auth:   auth,
+	}
+	if r.client == nil {
+		r.client = cleanhttp.DefaultClient()
+	}
+	if g.scheme != "" {
+		r.base = g.scheme + "://" + u.Host
+	}
+
+	resp, err := r.get("/manifests/"+ref, ociManifestTypes)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	var manifest ociManifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode manifest: %v", err)
+	}
+	return r, &manifest, nil
+}
+
+// parseOCIReference returns the repository and the tag or digest of the
+// artifact referenced by the URL. The tag defaults to latest.
+func parseOCIReference(u *url.URL) (string, string, error) {
+	repo := strings.TrimPrefix(u.Path, "/")
+	ref := "latest"
+	if idx := strings.Index(repo, "@"); idx != -1 {
+		repo, ref = repo[:idx], repo[idx+1:]
+	} else if idx := strings.LastIndex(repo, ":"); idx > strings.LastIndex(repo, "/") {
+		repo, ref = repo[:idx], repo[idx+1:]
+	}
+
+	if u.Host == "" || repo == "" || ref == "" {
+		return "", "", fmt.Errorf("invalid OCI reference %q: must be oci://registry/repository:tag", u.Host+u.Path)
+	}
+	return repo, ref, nil
+}
+
+// ociRegistry is a client of the repository of an artifact, following the
+// token authentication of the distribution API.
+type ociRegistry struct {
+	client *http.Client
+	base   string
+	repo   string
+	auth   *RegistryAuth
+	token  string
+}
+
+// get returns the successful response to a GET request on the path of the
+// repository, authenticating if the registry asks for it.
+func (r *ociRegistry) get(path, accept string) (*http.Response, error) {
+	resp, err := r.do(path, accept)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized && r.token == "" {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err := r.authenticate(challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = r.do(path, accept); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected response from registry for %q: %s", r.repo+path, resp.Status)
+	}
+	return resp, nil
+}
+
+// do makes a GET request on the path of the repository.
+func (r *ociRegistry) do(path, accept string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", r.base+"/v2/"+r.repo+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	} else if r.auth != nil {
+		req.SetBasicAuth(r.auth.Username, r.auth.Password)
+	}
+	return r.client.Do(req)
+}
+
+// authenticate answers the challenge of the registry. Bearer challenges are
+// answered by requesting a pull token, with the credentials if there are any.
+func (r *ociRegistry) authenticate(challenge string) error {
+	idx := strings.Index(challenge, " ")
+	if idx == -1 || !strings.EqualFold(challenge[:idx], "bearer") {
+		if r.auth == nil {
+			return fmt.Errorf("registry requires credentials to pull %q", r.repo)
+		}
+		return fmt.Errorf("registry rejected the credentials to pull %q", r.repo)
+	}
+
+	params := make(map[string]string)
+	for _, m := range challengeParamRe.FindAllStringSubmatch(challenge[idx+1:], -1) {
+		params[strings.ToLower(m[1])] = m[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("invalid authentication realm %q", params["realm"])
+	}
+
+	q := realm.Query()
+	if service := params["service"]; service != "" {
+		q.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", r.repo)
+	}
+	q.Set("scope", scope)
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequest("GET", realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if r.auth != nil {
+		req.SetBasicAuth(r.auth.Username, r.auth.Password)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get a token to pull %q: %s", r.repo, resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("failed to decode token: %v", err)
+	}
+	if r.token = token.Token; r.token == "" {
+		r.token = token.AccessToken
+	}
+	if r.token == "" {
+		return fmt.Errorf("no token to pull %q", r.repo)
+	}
+	return nil
+}
+
+// blob downloads the layer to dst, verifying its digest.
+func (r *ociRegistry) blob(layer ociDescriptor, dst string) error {
+	idx := strings.Index(layer.Digest, ":")
+	if idx == -1 || layer.Digest[:idx] != "sha256" {
+		return fmt.Errorf("unsupported layer digest %q", layer.Digest)
+	}
+
+	resp, err := r.get("/blobs/"+layer.Digest, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(f, h), resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if hex.EncodeToString(h.Sum(nil)) != layer.Digest[idx+1:] {
+		os.Remove(dst)
+		return fmt.Errorf("layer %q does not match its digest", layer.Digest)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package getter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testRegistry serves an artifact of the given files at
+// /v2/app/manifests/1.0, with bearer token authentication if auth is set.
+func testRegistry(t *testing.T, files map[string]string, auth *RegistryAuth) *httptest.Server {
+	blobs := make(map[string]string)
+	var manifest ociManifest
+	for name, content := range files {
+		sum := sha256.Sum256([]byte(content))
+		digest := "sha256:" + hex.EncodeToString(sum[:])
+		blobs[digest] = content
+		manifest.Layers = append(manifest.Layers, ociDescriptor{
+			MediaType:   "application/octet-stream",
+			Digest:      digest,
+			Size:        int64(len(content)),
+			Annotations: map[string]string{ociTitleAnnotation: name},
+		})
+	}
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			user, pass, _ := r.BasicAuth()
+			if auth == nil || user != auth.Username || pass != auth.Password {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if scope := r.URL.Query().Get("scope"); scope != "repository:app:pull" {
+				t.Errorf("unexpected scope %q", scope)
+			}
+			fmt.Fprint(w, `{"token": "secret-token"}`)
+			return
+		}
+
+		if auth != nil && r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.URL.Path == "/v2/app/manifests/1.0":
+			json.NewEncoder(w).Encode(manifest)
+		case strings.HasPrefix(r.URL.Path, "/v2/app/blobs/"):
+			content, ok := blobs[strings.TrimPrefix(r.URL.Path, "/v2/app/blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, content)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return srv
+}
+
+func testOCIURL(t *testing.T, srv *httptest.Server, ref string) *url.URL {
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return &url.URL{Scheme: "oci", Host: u.Host, Path: "/" + ref}
+}
+
+func TestParseOCIReference(t *testing.T) {
+	cases := []struct {
+		source string
+		repo   string
+		ref    string
+		err    bool
+	}{
+		{"oci://registry.example.com/app:1.0", "app", "1.0", false},
+		{"oci://registry.example.com/org/app", "org/app", "latest", false},
+		{"oci://localhost:5000/org/app@sha256:abcd", "org/app", "sha256:abcd", false},
+		{"oci://registry.example.com/", "", "", true},
+	}
+
+	for _, c := range cases {
+		u, err := url.Parse(c.source)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		repo, ref, err := parseOCIReference(u)
+		if c.err {
+			if err == nil {
+				t.Errorf("%s: expected error", c.source)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.source, err)
+		} else if repo != c.repo || ref != c.ref {
+			t.Errorf("%s: got %q %q; want %q %q", c.source, repo, ref, c.repo, c.ref)
+		}
+	}
+}
+
+func TestOCIGetter_Get(t *testing.T) {
+	auth := &RegistryAuth{Username: "nomad", Password: "hunter2"}
+	srv := testRegistry(t, map[string]string{
+		"config.json":   `{"port": 8080}`,
+		"bin/server.sh": "#!/bin/sh",
+	}, auth)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Pulling without credentials is refused
+	g := &ociGetter{scheme: "http"}
+	if err := g.Get(dir, testOCIURL(t, srv, "app:1.0")); err == nil {
+		t.Fatalf("expected error without credentials")
+	}
+
+	g.auth = auth
+	if err := g.Get(dir, testOCIURL(t, srv, "app:1.0")); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for name, expected := range map[string]string{"config.json": `{"port": 8080}`, "bin/server.sh": "#!/bin/sh"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if string(data) != expected {
+			t.Errorf("%s: got %q; want %q", name, data, expected)
+		}
+	}
+
+	// A multi layer artifact can't be downloaded as a file
+	if err := g.GetFile(filepath.Join(dir, "file"), testOCIURL(t, srv, "app:1.0")); err == nil {
+		t.Fatalf("expected error downloading a multi layer artifact as a file")
+	}
+}
+
+func TestOCIGetter_GetFile_AuthConfig(t *testing.T) {
+	srv := testRegistry(t, map[string]string{"app.tar": "content"}, &RegistryAuth{Username: "nomad", Password: "hunter2"})
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	u := testOCIURL(t, srv, "app:1.0")
+	conf := filepath.Join(dir, "config.json")
+	auths := fmt.Sprintf(`{"auths": {"http://%s": {"auth": "bm9tYWQ6aHVudGVyMg=="}}}`, u.Host)
+	if err := ioutil.WriteFile(conf, []byte(auths), 0600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	SetRegistryAuthConfig(conf)
+	defer SetRegistryAuthConfig("")
+
+	dst := filepath.Join(dir, "app.tar")
+	g := &ociGetter{scheme: "http"}
+	if err := g.GetFile(dst, u); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if data, err := ioutil.ReadFile(dst); err != nil || string(data) != "content" {
+		t.Fatalf("unexpected file content %q: %v", data, err)
+	}
+}
+
+func TestOCIGetter_Get_Escape(t *testing.T) {
+	srv := testRegistry(t, map[string]string{"../escaped": "content"}, nil)
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "nomad-test")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	dst := filepath.Join(dir, "dst")
+	g := &ociGetter{scheme: "http"}
+	if err := g.Get(dst, testOCIURL(t, srv, "app:1.0")); err == nil || !strings.Contains(err.Error(), "escapes") {
+		t.Fatalf("expected escape error, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped")); !os.IsNotExist(err) {
+		t.Fatalf("layer written outside of the destination: %v", err)
+	}
+}
@@ -0,0 +1,379 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// networkPolicyRetryIntv is the interval at which the network policies
+	// are refetched after an error
+	networkPolicyRetryIntv = 5 * time.Second
+
+	// networkPolicyTable is the nftables table holding the rules compiled
+	// from the network policies
+	networkPolicyTable = "inet nomad"
+)
+
+// networkEndpoint is the address of a task on the bridge network of the node
+type networkEndpoint struct {
+	Namespace string
+	Services  []string
+	IP        net.IP
+}
+
+// family returns the nftables family of the address of the endpoint
+func (e *networkEndpoint) family() string {
+	if e.IP.To4() != nil {
+		return "ip"
+	}
+	return "ip6"
+}
+
+// networkPolicyEnforcer compiles the network policies and the addresses of the
+// tasks running on the node into nftables rules. The rules are recompiled when
+// the policies change or a task starts, and only applied when they differ from
+// the rules in place.
+type networkPolicyEnforcer struct {
+	policies []*structs.NetworkPolicy
+	l        sync.Mutex
+
+	// updateCh is used to trigger an enforcement
+	updateCh chan struct{}
+
+	// applied is the last ruleset applied
+	applied string
+}
+
+// newNetworkPolicyEnforcer returns an enforcer without policies
+func newNetworkPolicyEnforcer() *networkPolicyEnforcer {
+	return &networkPolicyEnforcer{
+		updateCh: make(chan struct{}, 1),
+	}
+}
+
+// setPolicies replaces the policies and triggers an enforcement
+func (n *networkPolicyEnforcer) setPolicies(policies []*structs.NetworkPolicy) {
+	n.l.Lock()
+	n.policies = policies
+	n.l.Unlock()
+	n.trigger()
+}
+
+// trigger causes the rules to be recompiled. It may be called on a nil
+// enforcer.
+func (n *networkPolicyEnforcer) trigger() {
+	if n == nil {
+		return
+	}
+	select {
+	case n.updateCh <- struct{}{}:
+	default:
+	}
+}
+
+// watchNetworkPolicies keeps the client's network policies in sync with the
+// servers using blocking queries.
+func (c *Client) watchNetworkPolicies() {
+	req := structs.NetworkPolicyListRequest{
+		NodeID:   c.NodeID(),
+		SecretID: c.secretNodeID(),
+		QueryOptions: structs.QueryOptions{
+			Region:     c.Region(),
+			AllowStale: true,
+		},
+	}
+	var resp structs.NetworkPolicyListResponse
+
+	for {
+		resp = structs.NetworkPolicyListResponse{}
+		if err := c.RPC("NetworkPolicy.List", &req, &resp); err != nil {
+			// Shutdown often causes EOF errors, so check for shutdown first
+			select {
+			case <-c.shutdownCh:
+				return
+			default:
+			}
+
+			if err != noServersErr {
+				c.logger.Printf("[ERR] client: failed to query network policies: %v", err)
+			}
+			retry := c.retryIntv(networkPolicyRetryIntv)
+			select {
+			case <-c.serversDiscoveredCh:
+				continue
+			case <-time.After(retry):
+				continue
+			case <-c.shutdownCh:
+				return
+			}
+		}
+
+		// Check for shutdown
+		select {
+		case <-c.shutdownCh:
+			return
+		default:
+		}
+
+		// Filter all updates until the index changes
+		if resp.Index <= req.MinQueryIndex {
+			continue
+		}
+		req.MinQueryIndex = resp.Index
+
+		c.netPolicies.setPolicies(resp.Policies)
+		c.logger.Printf("[DEBUG] client: updated network policies with %d policies", len(resp.Policies))
+	}
+}
+
+// enforceNetworkPolicies applies the rules compiled from the network policies
+// each time an enforcement is triggered.
+func (c *Client) enforceNetworkPolicies() {
+	n := c.netPolicies
+	for {
+		select {
+		case <-n.updateCh:
+		case <-c.shutdownCh:
+			return
+		}
+
+		n.l.Lock()
+		policies := n.policies
+		n.l.Unlock()
+
+		rules := compileNetworkPolicies(policies, c.networkEndpoints())
+		if rules == n.applied {
+			continue
+		}
+
+		script := rules
+		if script == "" {
+			// No policy applies to the tasks of the node anymore
+			script = fmt.Sprintf("table %s\ndelete table %s\n", networkPolicyTable, networkPolicyTable)
+		}
+		if err := applyNetworkRules(script); err != nil {
+			c.logger.Printf("[ERR] client: failed to apply network policies: %v", err)
+			continue
+		}
+		n.applied = rules
+		c.logger.Printf("[DEBUG] client: applied network policies")
+	}
+}
+
+// networkEndpoints returns the addresses on the bridge network of the tasks
+// of the non-terminal allocations of the node
+func (c *Client) networkEndpoints() []*networkEndpoint {
+	c.allocLock.RLock()
+	defer c.allocLock.RUnlock()
+
+	var endpoints []*networkEndpoint
+	for _, ar := range c.allocs {
+		alloc := ar.Alloc()
+		if alloc.TerminalStatus() {
+			continue
+		}
+		tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+		if tg == nil {
+			continue
+		}
+
+		ar.taskLock.RLock()
+		for name, tr := range ar.tasks {
+			tr.driverNetLock.Lock()
+			driverNet := tr.driverNet
+			tr.driverNetLock.Unlock()
+			if driverNet == nil {
+				continue
+			}
+			ip := net.ParseIP(driverNet.IP)
+			if ip == nil {
+				continue
+			}
+
+			var services []string
+			if task := tg.LookupTask(name); task != nil {
+				for _, s := range task.Services {
+					services = append(services, s.Name)
+				}
+			}
+			endpoints = append(endpoints, &networkEndpoint{
+				Namespace: alloc.Namespace,
+				Services:  services,
+				IP:        ip,
+			})
+		}
+		ar.taskLock.RUnlock()
+	}
+	return endpoints
+}
+
+// compileNetworkPolicies returns the nftables script enforcing the policies
+// on the traffic between the endpoints, or an empty string if no policy
+// applies to them. Only traffic between endpoints is filtered, so traffic
+// from outside the node and replies of established connections are allowed.
+// Deny rules are applied before allow rules, and traffic to an endpoint
+// selected by a policy denying by default is dropped if no rule allows it.
+func compileNetworkPolicies(policies []*structs.NetworkPolicy, endpoints []*networkEndpoint) string {
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].IP.String() < endpoints[j].IP.String()
+	})
+
+	// Group the sources by family
+	byFamily := make(map[string][]*networkEndpoint)
+	for _, e := range endpoints {
+		byFamily[e.family()] = append(byFamily[e.family()], e)
+	}
+
+	var jumps, chains bytes.Buffer
+	for i, dst := range endpoints {
+		var denies, allows []*structs.NetworkPolicyRule
+		defaultDeny := false
+		for _, p := range policies {
+			if !p.Selects(dst.Namespace, dst.Services) {
+				continue
+			}
+			defaultDeny = defaultDeny || p.DefaultDeny
+			for _, r := range p.Rules {
+				if r.Action == structs.NetworkPolicyActionDeny {
+					denies = append(denies, r)
+				} else {
+					allows = append(allows, r)
+				}
+			}
+		}
+		if len(denies) == 0 && len(allows) == 0 && !defaultDeny {
+			continue
+		}
+
+		family := dst.family()
+		chain := fmt.Sprintf("endpoint_%d", i)
+		cidrs := ruleCIDRs(family, denies, allows)
+		fmt.Fprintf(&jumps, "\t\t%s saddr %s %s daddr %s jump %s\n",
+			family, nftSet(byFamily[family], cidrs...), family, dst.IP, chain)
+
+		fmt.Fprintf(&chains, "\tchain %s {\n", chain)
+		for _, r := range denies {
+			writeNetworkPolicyRule(&chains, family, r, byFamily[family], "drop")
+		}
+		for _, r := range allows {
+			writeNetworkPolicyRule(&chains, family, r, byFamily[family], "accept")
+		}
+		if defaultDeny {
+			fmt.Fprintf(&chains, "\t\tdrop\n")
+		}
+		fmt.Fprintf(&chains, "\t}\n")
+	}
+	if jumps.Len() == 0 {
+		return ""
+	}
+
+	// Declaring the table before deleting it ensures the script succeeds
+	// when the table does not exist yet, and the whole script is applied
+	// atomically.
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "table %s\ndelete table %s\n", networkPolicyTable, networkPolicyTable)
+	fmt.Fprintf(&out, "table %s {\n", networkPolicyTable)
+	fmt.Fprintf(&out, "\tchain forward {\n")
+	fmt.Fprintf(&out, "\t\ttype filter hook forward priority 0; policy accept;\n")
+	fmt.Fprintf(&out, "\t\tct state established,related accept\n")
+	out.Write(jumps.Bytes())
+	fmt.Fprintf(&out, "\t}\n")
+	out.Write(chains.Bytes())
+	fmt.Fprintf(&out, "}\n")
+	return out.String()
+}
+
+// writeNetworkPolicyRule writes the nftables rules matching the traffic of a
+// policy rule from the sources, skipping it if it matches none
+func writeNetworkPolicyRule(buf *bytes.Buffer, family string, r *structs.NetworkPolicyRule,
+	sources []*networkEndpoint, verdict string) {
+	var saddr string
+	if r.CIDR != "" {
+		_, ipnet, err := net.ParseCIDR(r.CIDR)
+		if err != nil || cidrFamily(ipnet) != family {
+			return
+		}
+		saddr = fmt.Sprintf("%s saddr %s", family, ipnet)
+	} else {
+		var matched []*networkEndpoint
+		for _, src := range sources {
+			if r.Matches(src.Namespace, src.Services) {
+				matched = append(matched, src)
+			}
+		}
+		if len(matched) == 0 {
+			return
+		}
+		saddr = fmt.Sprintf("%s saddr %s", family, nftSet(matched))
+	}
+
+	if len(r.Ports) == 0 {
+		fmt.Fprintf(buf, "\t\t%s %s\n", saddr, verdict)
+		return
+	}
+
+	ports := make([]string, len(r.Ports))
+	for i, p := range r.Ports {
+		ports[i] = strconv.Itoa(p)
+	}
+	dport := "{ " + strings.Join(ports, ", ") + " }"
+	fmt.Fprintf(buf, "\t\t%s tcp dport %s %s\n", saddr, dport, verdict)
+	fmt.Fprintf(buf, "\t\t%s udp dport %s %s\n", saddr, dport, verdict)
+}
+
+// nftSet returns the anonymous set of the addresses of the endpoints and of
+// the CIDR blocks, omitting duplicates
+func nftSet(endpoints []*networkEndpoint, cidrs ...string) string {
+	seen := make(map[string]struct{}, len(endpoints)+len(cidrs))
+	var addrs []string
+	add := func(addr string) {
+		if _, ok := seen[addr]; ok {
+			return
+		}
+		seen[addr] = struct{}{}
+		addrs = append(addrs, addr)
+	}
+	for _, e := range endpoints {
+		add(e.IP.String())
+	}
+	for _, cidr := range cidrs {
+		add(cidr)
+	}
+	return "{ " + strings.Join(addrs, ", ") + " }"
+}
+
+// ruleCIDRs returns the CIDR blocks of the family matched by the rules, in
+// their canonical form
+func ruleCIDRs(family string, rules ...[]*structs.NetworkPolicyRule) []string {
+	var cidrs []string
+	for _, rs := range rules {
+		for _, r := range rs {
+			if r.CIDR == "" {
+				continue
+			}
+			_, ipnet, err := net.ParseCIDR(r.CIDR)
+			if err != nil || cidrFamily(ipnet) != family {
+				continue
+			}
+			cidrs = append(cidrs, ipnet.String())
+		}
+	}
+	return cidrs
+}
+
+// cidrFamily returns the nftables family of the CIDR block
+func cidrFamily(ipnet *net.IPNet) string {
+	if ipnet.IP.To4() != nil {
+		return "ip"
+	}
+	return "ip6"
+}
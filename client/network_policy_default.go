@@ -0,0 +1,11 @@
+// +build !linux
+
+package client
+
+import "fmt"
+
+// applyNetworkRules returns an error since network policies are enforced with
+// nftables
+func applyNetworkRules(script string) error {
+	return fmt.Errorf("network policies are only supported on Linux")
+}
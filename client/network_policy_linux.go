@@ -0,0 +1,20 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// applyNetworkRules atomically applies the nftables script
+func applyNetworkRules(script string) error {
+	cmd := exec.Command("nft", "-f", "-")
+	cmd.Stdin = strings.NewReader(script)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("nft failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"net"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileNetworkPolicies(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	endpoints := []*networkEndpoint{
+		{Namespace: "default", Services: []string{"db"}, IP: net.ParseIP("172.17.0.4")},
+		{Namespace: "default", Services: []string{"web"}, IP: net.ParseIP("172.17.0.2")},
+		{Namespace: "other", Services: []string{"web"}, IP: net.ParseIP("172.17.0.3")},
+	}
+	policies := []*structs.NetworkPolicy{
+		{
+			Name:        "db",
+			Namespace:   "default",
+			Services:    []string{"db"},
+			DefaultDeny: true,
+			Rules: []*structs.NetworkPolicyRule{
+				{Action: structs.NetworkPolicyActionAllow, Namespace: "*", Service: "web", Ports: []int{5432}},
+				{Action: structs.NetworkPolicyActionDeny, Namespace: "other"},
+			},
+		},
+	}
+
+	expected := `table inet nomad
+delete table inet nomad
+table inet nomad {
+	chain forward {
+		type filter hook forward priority 0; policy accept;
+		ct state established,related accept
+		ip saddr { 172.17.0.2, 172.17.0.3, 172.17.0.4 } ip daddr 172.17.0.4 jump endpoint_2
+	}
+	chain endpoint_2 {
+		ip saddr { 172.17.0.3 } drop
+		ip saddr { 172.17.0.2, 172.17.0.3 } tcp dport { 5432 } accept
+		ip saddr { 172.17.0.2, 172.17.0.3 } udp dport { 5432 } accept
+		drop
+	}
+}
+`
+	assert.Equal(expected, compileNetworkPolicies(policies, endpoints))
+}
+
+func TestCompileNetworkPolicies_None(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	endpoints := []*networkEndpoint{
+		{Namespace: "default", Services: []string{"web"}, IP: net.ParseIP("172.17.0.2")},
+		{Namespace: "default", IP: net.ParseIP("fd00::2")},
+	}
+
+	// No policy
+	assert.Empty(compileNetworkPolicies(nil, endpoints))
+
+	// A policy selecting no endpoint
+	policies := []*structs.NetworkPolicy{
+		{Name: "db", Namespace: "default", Services: []string{"db"}, DefaultDeny: true},
+	}
+	assert.Empty(compileNetworkPolicies(policies, endpoints))
+
+	// Addresses of other families are not sources
+	policies[0].Services = nil
+	policies[0].Rules = []*structs.NetworkPolicyRule{
+		{Action: structs.NetworkPolicyActionAllow, Namespace: "default"},
+	}
+	out := compileNetworkPolicies(policies, endpoints)
+	assert.Contains(out, "ip6 saddr { fd00::2 } ip6 daddr fd00::2 jump endpoint_1")
+	assert.Contains(out, "ip saddr { 172.17.0.2 } ip daddr 172.17.0.2 jump endpoint_0")
+}
+
+func TestCompileNetworkPolicies_CIDR(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	endpoints := []*networkEndpoint{
+		{Namespace: "default", Services: []string{"db"}, IP: net.ParseIP("fd00::4")},
+		{Namespace: "default", Services: []string{"web"}, IP: net.ParseIP("fd00::2")},
+	}
+	policies := []*structs.NetworkPolicy{
+		{
+			Name:        "db",
+			Namespace:   "default",
+			Services:    []string{"db"},
+			DefaultDeny: true,
+			Rules: []*structs.NetworkPolicyRule{
+				{Action: structs.NetworkPolicyActionAllow, CIDR: "2001:db8::/32", Ports: []int{5432}},
+				{Action: structs.NetworkPolicyActionAllow, CIDR: "10.0.0.0/8"},
+			},
+		},
+	}
+
+	out := compileNetworkPolicies(policies, endpoints)
+	assert.Contains(out, "ip6 saddr { fd00::2, fd00::4, 2001:db8::/32 } ip6 daddr fd00::4 jump endpoint_1")
+	assert.Contains(out, "ip6 saddr 2001:db8::/32 tcp dport { 5432 } accept")
+
+	// Blocks of other families are ignored
+	assert.NotContains(out, "10.0.0.0/8")
+}
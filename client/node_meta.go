@@ -0,0 +1,132 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper"
+)
+
+const (
+	// nodeMetaFile is the file in the state directory the dynamic node
+	// metadata is persisted to.
+	nodeMetaFile = "node-meta.json"
+)
+
+// restoreNodeMeta applies the persisted dynamic metadata over the metadata of
+// the client's configuration.
+func (c *Client) restoreNodeMeta() error {
+	c.dynamicMeta = make(map[string]*string)
+	if c.config.DevMode {
+		return nil
+	}
+
+	buf, err := ioutil.ReadFile(filepath.Join(c.config.StateDir, nodeMetaFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(buf, &c.dynamicMeta); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", nodeMetaFile, err)
+	}
+
+	applyNodeMeta(c.config.Node.Meta, c.dynamicMeta)
+	return nil
+}
+
+// NodeMeta returns the metadata of the node.
+func (c *Client) NodeMeta() *cstructs.NodeMetaResponse {
+	c.configLock.RLock()
+	defer c.configLock.RUnlock()
+	return &cstructs.NodeMetaResponse{
+		Meta:    helper.CopyMapStringString(c.config.Node.Meta),
+		Dynamic: copyNodeMeta(c.dynamicMeta),
+	}
+}
+
+// ApplyNodeMeta updates the metadata of the node at runtime. A nil value
+// unsets the key. The changes are persisted so that they survive restarts and
+// the node is re-registered so schedulers take them into account.
+func (c *Client) ApplyNodeMeta(meta map[string]*string) (*cstructs.NodeMetaResponse, error) {
+	for k := range meta {
+		if k == "" {
+			return nil, fmt.Errorf("metadata keys must not be empty")
+		}
+	}
+
+	c.configLock.Lock()
+	dynamic := copyNodeMeta(c.dynamicMeta)
+	for k, v := range meta {
+		dynamic[k] = v
+	}
+	if err := c.persistNodeMeta(dynamic); err != nil {
+		c.configLock.Unlock()
+		return nil, fmt.Errorf("failed to persist node metadata: %v", err)
+	}
+	c.dynamicMeta = dynamic
+
+	if c.config.Node.Meta == nil {
+		c.config.Node.Meta = make(map[string]string)
+	}
+	applyNodeMeta(c.config.Node.Meta, meta)
+	c.configLock.Unlock()
+
+	c.triggerNodeUpdate()
+	return c.NodeMeta(), nil
+}
+
+// persistNodeMeta writes the dynamic metadata to the state directory. It must
+// be called with the configLock held.
+func (c *Client) persistNodeMeta(dynamic map[string]*string) error {
+	if c.config.DevMode {
+		return nil
+	}
+
+	buf, err := json.Marshal(dynamic)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash doesn't corrupt the
+	// persisted metadata
+	path := filepath.Join(c.config.StateDir, nodeMetaFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// triggerNodeUpdate causes an immediate check for node changes (if one isn't
+// already pending)
+func (c *Client) triggerNodeUpdate() {
+	select {
+	case c.triggerNodeUpdateCh <- struct{}{}:
+	default:
+	}
+}
+
+// applyNodeMeta sets or unsets the keys of the dynamic metadata in meta.
+func applyNodeMeta(meta map[string]string, dynamic map[string]*string) {
+	for k, v := range dynamic {
+		if v == nil {
+			delete(meta, k)
+		} else {
+			meta[k] = *v
+		}
+	}
+}
+
+func copyNodeMeta(meta map[string]*string) map[string]*string {
+	c := make(map[string]*string, len(meta))
+	for k, v := range meta {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"testing"
+
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/command/agent/consul"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_ApplyNodeMeta(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s1, _ := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	c1 := testClient(t, func(c *config.Config) {
+		c.DevMode = false
+		c.RPCHandler = s1
+		c.Node.Meta = map[string]string{"rack": "r1", "owner": "ops"}
+	})
+	defer c1.Shutdown()
+	waitTilNodeReady(c1, t)
+
+	// Invalid keys are rejected
+	_, err := c1.ApplyNodeMeta(map[string]*string{"": helper.StringToPtr("a")})
+	require.Error(err)
+
+	// Set a key and unset a key of the configuration
+	resp, err := c1.ApplyNodeMeta(map[string]*string{
+		"rack":  helper.StringToPtr("r2"),
+		"owner": nil,
+	})
+	require.NoError(err)
+	require.Equal(map[string]string{"rack": "r2"}, resp.Meta)
+	require.Nil(resp.Dynamic["owner"])
+	require.Equal("r2", *resp.Dynamic["rack"])
+
+	// The node is re-registered with the new metadata
+	testutil.WaitForResult(func() (bool, error) {
+		node, err := s1.State().NodeByID(memdb.NewWatchSet(), c1.Node().ID)
+		if err != nil {
+			return false, err
+		}
+		if node.Meta["rack"] != "r2" {
+			return false, fmt.Errorf("node meta not updated: %v", node.Meta)
+		}
+		if _, ok := node.Meta["owner"]; ok {
+			return false, fmt.Errorf("node meta not unset: %v", node.Meta)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+
+	// The metadata survives a restart with the original configuration
+	require.NoError(c1.Shutdown())
+	c1.config.Node.Meta = map[string]string{"rack": "r1", "owner": "ops", "os": "linux"}
+
+	logger := log.New(c1.config.LogOutput, "", log.LstdFlags)
+	catalog := consul.NewMockCatalog(logger)
+	mockService := newMockConsulServiceClient()
+	mockService.logger = logger
+	c2, err := NewClient(c1.config, catalog, mockService, logger)
+	require.NoError(err)
+	defer c2.Shutdown()
+
+	require.Equal(map[string]string{"rack": "r2", "os": "linux"}, c2.NodeMeta().Meta)
+}
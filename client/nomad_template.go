@@ -0,0 +1,604 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// nomadTemplateIDPrefix prefixes the IDs of the templates rendered by
+	// Nomad so they don't collide with those of consul-template
+	nomadTemplateIDPrefix = "nomad:"
+
+	// nomadTemplateRetryAttempts is the number of consecutive failures to read
+	// the data of a template after which the task is killed
+	nomadTemplateRetryAttempts = 12
+
+	// nomadTemplateRetryRate is the initial and nomadTemplateMaxRetryRate the
+	// maximum wait between the attempts to read the data of a template
+	nomadTemplateRetryRate    = 250 * time.Millisecond
+	nomadTemplateMaxRetryRate = time.Minute
+)
+
+// nomadTemplateFuncs matches the functions of the templates that are rendered
+// by Nomad rather than consul-template.
+var nomadTemplateFuncs = regexp.MustCompile(`\bnomad(Var|Service|Services)\b`)
+
+// nomadTemplateSource reads the Nomad variables and services rendered by the
+// templates of a task. The reads are blocking queries returning once the index
+// of the data is greater than minIndex.
+type nomadTemplateSource interface {
+	// Variable returns the variable at the path, or nil if it doesn't exist
+	Variable(path string, minIndex uint64) (*structs.Variable, uint64, error)
+
+	// Services returns the instances of the named service, or of every
+	// service if the name is empty
+	Services(name string, minIndex uint64) ([]*structs.ServiceInstance, uint64, error)
+}
+
+// nomadDep is a variable or service a template rendered by Nomad reads.
+type nomadDep struct {
+	// variable is whether the dependency is a variable or a service
+	variable bool
+
+	// name is the path of the variable or the name of the service
+	name string
+}
+
+func (d nomadDep) String() string {
+	switch {
+	case d.variable:
+		return fmt.Sprintf("nomad.var(%s)", d.name)
+	case d.name == "":
+		return "nomad.services"
+	default:
+		return fmt.Sprintf("nomad.service(%s)", d.name)
+	}
+}
+
+// nomadData is the data of a dependency as of its index.
+type nomadData struct {
+	index    uint64
+	variable *structs.Variable
+	services []*structs.ServiceInstance
+}
+
+// nomadServiceSummary is a service returned by the nomadServices function.
+type nomadServiceSummary struct {
+	Name string
+	Tags []string
+}
+
+// nomadRenderEvent tracks the renders of a template rendered by Nomad.
+type nomadRenderEvent struct {
+	// LastWouldRender is the last time the template was rendered with all of
+	// its data
+	LastWouldRender time.Time
+
+	// LastDidRender is the last time the rendered template changed and was
+	// written to its destination
+	LastDidRender time.Time
+
+	// MissingDeps are the dependencies the template is waiting for
+	MissingDeps []string
+}
+
+// nomadTemplate is a parsed template rendered by Nomad.
+type nomadTemplate struct {
+	id    string
+	text  *template.Template
+	dest  string
+	perms os.FileMode
+}
+
+// nomadTemplateRenderer renders the templates of a task that read Nomad
+// variables and services. Their data is watched with blocking queries and the
+// templates are rendered again when it changes.
+type nomadTemplateRenderer struct {
+	source    nomadTemplateSource
+	templates []*nomadTemplate
+	env       map[string]string
+	retryRate time.Duration
+
+	// lookup is the templates of the task by the ID of the template
+	lookup map[string][]*structs.Template
+
+	// watching is the set of dependencies being watched. It is only
+	// accessed by the render loop.
+	watching map[nomadDep]struct{}
+
+	// data is the data of the dependencies that have been read, and events
+	// the render events of the templates by ID
+	data   map[nomadDep]*nomadData
+	events map[string]*nomadRenderEvent
+	lock   sync.Mutex
+
+	// ErrCh is sent the errors rendering the templates or reading their data
+	ErrCh chan error
+
+	updateCh   chan struct{}
+	renderedCh chan struct{}
+	eventCh    chan struct{}
+	stopCh     chan struct{}
+	stopOnce   sync.Once
+}
+
+// isNomadTemplate returns whether the template reads Nomad variables or
+// services. Templates from source files that can't be read are left to
+// consul-template to report the error.
+func isNomadTemplate(config *TaskTemplateManagerConfig, tmpl *structs.Template) bool {
+	contents, err := templateContents(config, tmpl)
+	return err == nil && nomadTemplateFuncs.MatchString(contents)
+}
+
+// templateContents returns the contents of the template, reading them from its
+// source file if it has one.
+func templateContents(config *TaskTemplateManagerConfig, tmpl *structs.Template) (string, error) {
+	if tmpl.SourcePath == "" {
+		return tmpl.EmbeddedTmpl, nil
+	}
+	src, _, err := templatePaths(config, tmpl)
+	if err != nil {
+		return "", err
+	}
+	raw, err := ioutil.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// newNomadTemplateRenderer parses the templates to be rendered by Nomad and
+// returns their renderer.
+func newNomadTemplateRenderer(config *TaskTemplateManagerConfig, tmpls []*structs.Template) (*nomadTemplateRenderer, error) {
+	r := &nomadTemplateRenderer{
+		source:     config.NomadSource,
+		env:        config.EnvBuilder.Build().All(),
+		retryRate:  nomadTemplateRetryRate,
+		lookup:     make(map[string][]*structs.Template, len(tmpls)),
+		watching:   make(map[nomadDep]struct{}),
+		data:       make(map[nomadDep]*nomadData),
+		events:     make(map[string]*nomadRenderEvent, len(tmpls)),
+		ErrCh:      make(chan error),
+		updateCh:   make(chan struct{}, 1),
+		renderedCh: make(chan struct{}, 1),
+		eventCh:    make(chan struct{}, 1),
+		stopCh:     make(chan struct{}),
+	}
+	if config.retryRate != 0 {
+		r.retryRate = config.retryRate
+	}
+
+	// The functions are bound to the data of each render, so these are only
+	// used to parse the templates
+	funcs := r.funcs(nil, nil)
+	for _, tmpl := range tmpls {
+		_, dest, err := templatePaths(config, tmpl)
+		if err != nil {
+			return nil, err
+		}
+		contents, err := templateContents(config, tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read template %q: %v", tmpl.SourcePath, err)
+		}
+
+		id := nomadTemplateIDPrefix + dest
+		text, err := template.New(id).Delims(tmpl.LeftDelim, tmpl.RightDelim).Funcs(funcs).Parse(contents)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse template %q: %v", tmpl.DestPath, err)
+		}
+
+		perms := os.FileMode(0644)
+		if tmpl.Perms != "" {
+			v, err := strconv.ParseUint(tmpl.Perms, 8, 12)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to parse %q as octal: %v", tmpl.Perms, err)
+			}
+			perms = os.FileMode(v)
+		}
+
+		// Templates sharing a destination are rendered once
+		if _, ok := r.lookup[id]; !ok {
+			r.templates = append(r.templates, &nomadTemplate{id: id, text: text, dest: dest, perms: perms})
+		}
+		r.lookup[id] = append(r.lookup[id], tmpl)
+	}
+
+	return r, nil
+}
+
+// Start renders the templates until the renderer is stopped.
+func (r *nomadTemplateRenderer) Start() {
+	for {
+		if err := r.render(); err != nil {
+			r.sendErr(err)
+		}
+
+		select {
+		case <-r.stopCh:
+			return
+		case <-r.updateCh:
+		}
+	}
+}
+
+// Stop stops rendering the templates and watching their data.
+func (r *nomadTemplateRenderer) Stop() {
+	r.stopOnce.Do(func() { close(r.stopCh) })
+}
+
+// TemplateRenderedCh is notified when templates are rendered.
+func (r *nomadTemplateRenderer) TemplateRenderedCh() <-chan struct{} {
+	return r.renderedCh
+}
+
+// RenderEventCh is notified when the missing dependencies of the templates
+// change.
+func (r *nomadTemplateRenderer) RenderEventCh() <-chan struct{} {
+	return r.eventCh
+}
+
+// RenderEvents returns a copy of the render events of the templates by ID.
+func (r *nomadTemplateRenderer) RenderEvents() map[string]*nomadRenderEvent {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	events := make(map[string]*nomadRenderEvent, len(r.events))
+	for id, event := range r.events {
+		copied := *event
+		events[id] = &copied
+	}
+	return events
+}
+
+// AllRendered returns whether all of the templates have been rendered.
+func (r *nomadTemplateRenderer) AllRendered() bool {
+	events := r.RenderEvents()
+	for _, t := range r.templates {
+		if event, ok := events[t.id]; !ok || event.LastWouldRender.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// render renders the templates whose data has all been read, writing those
+// whose contents changed.
+func (r *nomadTemplateRenderer) render() error {
+	r.lock.Lock()
+	data := make(map[nomadDep]*nomadData, len(r.data))
+	for dep, d := range r.data {
+		data[dep] = d
+	}
+	r.lock.Unlock()
+
+	now := time.Now()
+	rendered, missingChanged := false, false
+	for _, t := range r.templates {
+		used := make(map[nomadDep]struct{})
+		missing := make(map[string]struct{})
+
+		var buf bytes.Buffer
+		err := t.text.Funcs(r.funcs(data, func(dep nomadDep, found bool) {
+			used[dep] = struct{}{}
+			if !found {
+				missing[dep.String()] = struct{}{}
+			}
+		})).Execute(&buf, nil)
+
+		for dep := range used {
+			r.watch(dep)
+		}
+
+		missingList := make([]string, 0, len(missing))
+		for dep := range missing {
+			missingList = append(missingList, dep)
+		}
+		sort.Strings(missingList)
+
+		r.lock.Lock()
+		event, ok := r.events[t.id]
+		if !ok {
+			event = &nomadRenderEvent{}
+			r.events[t.id] = event
+		}
+		if strings.Join(event.MissingDeps, ",") != strings.Join(missingList, ",") {
+			missingChanged = true
+		}
+		event.MissingDeps = missingList
+		r.lock.Unlock()
+
+		// Wait for the missing data, whose zero values may fail the render
+		if len(missingList) != 0 {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to render template %q: %v", t.dest, err)
+		}
+
+		written, err := writeRenderedTemplate(t.dest, buf.Bytes(), t.perms)
+		if err != nil {
+			return err
+		}
+
+		r.lock.Lock()
+		event.LastWouldRender = now
+		if written {
+			event.LastDidRender = now
+		}
+		r.lock.Unlock()
+		rendered = true
+	}
+
+	if rendered {
+		notify(r.renderedCh)
+	}
+	if missingChanged {
+		notify(r.eventCh)
+	}
+	return nil
+}
+
+// funcs returns the functions of the templates, reading the given data. Each
+// dependency read is passed to use along with whether its data was found.
+func (r *nomadTemplateRenderer) funcs(data map[nomadDep]*nomadData, use func(nomadDep, bool)) template.FuncMap {
+	lookup := func(dep nomadDep) *nomadData {
+		d, ok := data[dep]
+		if use != nil {
+			use(dep, ok && (!dep.variable || d.variable != nil))
+		}
+		return d
+	}
+
+	return template.FuncMap{
+		"nomadVar": func(path string) map[string]string {
+			d := lookup(nomadDep{variable: true, name: path})
+			if d == nil || d.variable == nil {
+				return map[string]string{}
+			}
+			return d.variable.Items
+		},
+		"nomadService": func(name string) []*structs.ServiceInstance {
+			if d := lookup(nomadDep{name: name}); d != nil {
+				return d.services
+			}
+			return nil
+		},
+		"nomadServices": func() []*nomadServiceSummary {
+			d := lookup(nomadDep{})
+			if d == nil {
+				return nil
+			}
+			return summarizeServices(d.services)
+		},
+		"env": func(key string) string {
+			return r.env[key]
+		},
+		"toJSON": func(v interface{}) (string, error) {
+			out, err := json.Marshal(v)
+			return string(out), err
+		},
+		"join": func(sep string, l []string) string {
+			return strings.Join(l, sep)
+		},
+		"split": func(sep, s string) []string {
+			return strings.Split(s, sep)
+		},
+		"toLower":   strings.ToLower,
+		"toUpper":   strings.ToUpper,
+		"trimSpace": strings.TrimSpace,
+	}
+}
+
+// watch starts watching the data of the dependency if it isn't already.
+func (r *nomadTemplateRenderer) watch(dep nomadDep) {
+	if _, ok := r.watching[dep]; ok {
+		return
+	}
+	r.watching[dep] = struct{}{}
+	go r.watchDep(dep)
+}
+
+// watchDep reads the data of the dependency with blocking queries, triggering
+// a render each time it changes.
+func (r *nomadTemplateRenderer) watchDep(dep nomadDep) {
+	var index uint64
+	failures := 0
+	backoff := r.retryRate
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		default:
+		}
+
+		d := &nomadData{}
+		var err error
+		if dep.variable {
+			d.variable, d.index, err = r.source.Variable(dep.name, index)
+		} else {
+			d.services, d.index, err = r.source.Services(dep.name, index)
+		}
+		if err != nil {
+			// Reading data the task isn't allowed to won't succeed later
+			failures++
+			if failures >= nomadTemplateRetryAttempts || strings.Contains(err.Error(), structs.ErrPermissionDenied.Error()) {
+				r.sendErr(fmt.Errorf("failed to read %s: %v", dep, err))
+				return
+			}
+
+			select {
+			case <-time.After(backoff):
+			case <-r.stopCh:
+				return
+			}
+			if backoff *= 2; backoff > nomadTemplateMaxRetryRate {
+				backoff = nomadTemplateMaxRetryRate
+			}
+			continue
+		}
+		failures = 0
+		backoff = r.retryRate
+
+		// The blocking query timed out without changes
+		if index != 0 && d.index == index {
+			continue
+		}
+		index = d.index
+
+		r.lock.Lock()
+		r.data[dep] = d
+		r.lock.Unlock()
+		notify(r.updateCh)
+	}
+}
+
+// sendErr sends the error on the error channel unless the renderer is
+// stopped.
+func (r *nomadTemplateRenderer) sendErr(err error) {
+	select {
+	case r.ErrCh <- err:
+	case <-r.stopCh:
+	}
+}
+
+// summarizeServices returns the names of the services of the instances, with
+// the tags of all of their instances, sorted by name.
+func summarizeServices(instances []*structs.ServiceInstance) []*nomadServiceSummary {
+	byName := make(map[string]map[string]struct{})
+	for _, instance := range instances {
+		tags, ok := byName[instance.Name]
+		if !ok {
+			tags = make(map[string]struct{})
+			byName[instance.Name] = tags
+		}
+		for _, tag := range instance.Tags {
+			tags[tag] = struct{}{}
+		}
+	}
+
+	summaries := make([]*nomadServiceSummary, 0, len(byName))
+	for name, tags := range byName {
+		summary := &nomadServiceSummary{Name: name, Tags: make([]string, 0, len(tags))}
+		for tag := range tags {
+			summary.Tags = append(summary.Tags, tag)
+		}
+		sort.Strings(summary.Tags)
+		summaries = append(summaries, summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries
+}
+
+// writeRenderedTemplate atomically writes the contents of a rendered template
+// to its destination, returning whether they differed from those already
+// there.
+func writeRenderedTemplate(dest string, contents []byte, perms os.FileMode) (bool, error) {
+	if existing, err := ioutil.ReadFile(dest); err == nil && bytes.Equal(existing, contents) {
+		return false, nil
+	}
+
+	dir := filepath.Dir(dest)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, fmt.Errorf("failed to create directory of template %q: %v", dest, err)
+	}
+	f, err := ioutil.TempFile(dir, filepath.Base(dest))
+	if err != nil {
+		return false, fmt.Errorf("failed to write template %q: %v", dest, err)
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(contents)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), perms)
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), dest)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to write template %q: %v", dest, err)
+	}
+	return true, nil
+}
+
+// notify sends on the channel unless a notification is already pending.
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// taskTemplateSourceFn returns the source of the Nomad variables and services
+// rendered by the templates of a task of an allocation
+type taskTemplateSourceFn func(alloc *structs.Allocation, task string) nomadTemplateSource
+
+// taskTemplateSource returns the source of the Nomad variables and services
+// rendered by the templates of the task, read from the servers.
+func (c *Client) taskTemplateSource(alloc *structs.Allocation, task string) nomadTemplateSource {
+	return &rpcTemplateSource{client: c, allocID: alloc.ID, task: task}
+}
+
+// rpcTemplateSource reads the variables and services rendered by the templates
+// of a task from the servers, on behalf of the task.
+type rpcTemplateSource struct {
+	client  *Client
+	allocID string
+	task    string
+}
+
+func (s *rpcTemplateSource) Variable(path string, minIndex uint64) (*structs.Variable, uint64, error) {
+	req := structs.VariableTaskReadRequest{
+		NodeID:   s.client.NodeID(),
+		SecretID: s.client.secretNodeID(),
+		AllocID:  s.allocID,
+		Task:     s.task,
+		Path:     path,
+		QueryOptions: structs.QueryOptions{
+			Region:        s.client.Region(),
+			AllowStale:    true,
+			MinQueryIndex: minIndex,
+		},
+	}
+
+	var resp structs.SingleVariableResponse
+	if err := s.client.RPC("Variables.TaskRead", &req, &resp); err != nil {
+		return nil, 0, err
+	}
+	return resp.Variable, resp.Index, nil
+}
+
+func (s *rpcTemplateSource) Services(name string, minIndex uint64) ([]*structs.ServiceInstance, uint64, error) {
+	req := structs.ServiceInstancesRequest{
+		NodeID:   s.client.NodeID(),
+		SecretID: s.client.secretNodeID(),
+		AllocID:  s.allocID,
+		Service:  name,
+		QueryOptions: structs.QueryOptions{
+			Region:        s.client.Region(),
+			AllowStale:    true,
+			MinQueryIndex: minIndex,
+		},
+	}
+
+	var resp structs.ServiceInstancesResponse
+	if err := s.client.RPC("ServiceDiscovery.Instances", &req, &resp); err != nil {
+		return nil, 0, err
+	}
+	return resp.Instances, resp.Index, nil
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// quarantineRetryIntv is the interval at which the quarantine list is
+	// refetched after an error
+	quarantineRetryIntv = 5 * time.Second
+)
+
+// quarantineList is the client's copy of the cluster-wide list of image
+// digests and artifact checksums that must not be run. A nil list denies
+// nothing.
+type quarantineList struct {
+	entries map[string]*structs.QuarantineEntry
+	l       sync.RWMutex
+}
+
+// newQuarantineList returns an empty quarantine list
+func newQuarantineList() *quarantineList {
+	return &quarantineList{
+		entries: make(map[string]*structs.QuarantineEntry),
+	}
+}
+
+// set replaces the entries of the list
+func (q *quarantineList) set(entries []*structs.QuarantineEntry) {
+	m := make(map[string]*structs.QuarantineEntry, len(entries))
+	for _, e := range entries {
+		m[e.Digest] = e
+	}
+
+	q.l.Lock()
+	q.entries = m
+	q.l.Unlock()
+}
+
+// lookup returns the quarantine entry for the digest or nil if it is not
+// quarantined
+func (q *quarantineList) lookup(digest string) *structs.QuarantineEntry {
+	if q == nil || digest == "" {
+		return nil
+	}
+
+	q.l.RLock()
+	defer q.l.RUnlock()
+	return q.entries[structs.NormalizeQuarantineDigest(digest)]
+}
+
+// checkImage returns an error if the image digest is quarantined
+func (q *quarantineList) checkImage(digest string) error {
+	if e := q.lookup(digest); e != nil {
+		return fmt.Errorf("image %q is quarantined: %s", e.Digest, e.Reason)
+	}
+	return nil
+}
+
+// checkArtifacts returns an error if the checksum of any of the artifacts is
+// quarantined
+func (q *quarantineList) checkArtifacts(artifacts []*structs.TaskArtifact) error {
+	for _, artifact := range artifacts {
+		if e := q.lookup(artifact.GetterOptions["checksum"]); e != nil {
+			return fmt.Errorf("artifact %q with checksum %q is quarantined: %s",
+				artifact.GetterSource, e.Digest, e.Reason)
+		}
+	}
+	return nil
+}
+
+// watchQuarantine keeps the client's quarantine list in sync with the
+// servers using blocking queries.
+func (c *Client) watchQuarantine() {
+	req := structs.QuarantineListRequest{
+		NodeID:   c.NodeID(),
+		SecretID: c.secretNodeID(),
+		QueryOptions: structs.QueryOptions{
+			Region:     c.Region(),
+			AllowStale: true,
+		},
+	}
+	var resp structs.QuarantineListResponse
+
+	for {
+		resp = structs.QuarantineListResponse{}
+		if err := c.RPC("Quarantine.List", &req, &resp); err != nil {
+			// Shutdown often causes EOF errors, so check for shutdown first
+			select {
+			case <-c.shutdownCh:
+				return
+			default:
+			}
+
+			if err != noServersErr {
+				c.logger.Printf("[ERR] client: failed to query quarantine list: %v", err)
+			}
+			retry := c.retryIntv(quarantineRetryIntv)
+			select {
+			case <-c.serversDiscoveredCh:
+				continue
+			case <-time.After(retry):
+				continue
+			case <-c.shutdownCh:
+				return
+			}
+		}
+
+		// Check for shutdown
+		select {
+		case <-c.shutdownCh:
+			return
+		default:
+		}
+
+		// Filter all updates until the index changes
+		if resp.Index <= req.MinQueryIndex {
+			continue
+		}
+		req.MinQueryIndex = resp.Index
+
+		c.quarantine.set(resp.Entries)
+		c.logger.Printf("[DEBUG] client: updated quarantine list with %d entries", len(resp.Entries))
+	}
+}
@@ -0,0 +1,39 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantineList(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	// A nil list denies nothing
+	var q *quarantineList
+	assert.Nil(q.checkImage("sha256:abc"))
+
+	q = newQuarantineList()
+	q.set([]*structs.QuarantineEntry{
+		{Digest: "sha256:abc", Type: structs.QuarantineTypeImage, Reason: "CVE"},
+		{Digest: "md5:def", Type: structs.QuarantineTypeArtifact},
+	})
+
+	assert.NotNil(q.checkImage("sha256:abc"))
+	assert.NotNil(q.checkImage("SHA256:ABC"))
+	assert.Nil(q.checkImage("sha256:123"))
+	assert.Nil(q.checkImage(""))
+
+	artifacts := []*structs.TaskArtifact{
+		{GetterSource: "http://example.com/a.tgz"},
+		{GetterSource: "http://example.com/b.tgz", GetterOptions: map[string]string{"checksum": "md5:def"}},
+	}
+	assert.NotNil(q.checkArtifacts(artifacts))
+	assert.Nil(q.checkArtifacts(artifacts[:1]))
+
+	// Replacing the entries lifts the quarantine
+	q.set(nil)
+	assert.Nil(q.checkImage("sha256:abc"))
+}
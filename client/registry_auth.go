@@ -0,0 +1,80 @@
+package client
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/nomad/client/getter"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+// registryAuths reads the registry credentials of the artifacts that name a
+// Vault secret with the task's Vault token. It returns the artifacts to
+// download, with the Vault option removed, along with their credentials at
+// the same index. The credentials are kept out of the artifact options so
+// that they don't end up in the source URL.
+func registryAuths(vaultConf *config.VaultConfig, token string, taskEnv getter.EnvReplacer,
+	artifacts []*structs.TaskArtifact) ([]*structs.TaskArtifact, []*getter.RegistryAuth, error) {
+
+	var vclient *vaultapi.Client
+	var auths []*getter.RegistryAuth
+	for i, artifact := range artifacts {
+		path, ok := artifact.GetterOptions[structs.ArtifactOptionVaultPath]
+		if !ok {
+			continue
+		}
+
+		if vclient == nil {
+			apiConf, err := vaultConf.ApiConfig()
+			if err != nil {
+				return nil, nil, err
+			}
+			if vclient, err = vaultapi.NewClient(apiConf); err != nil {
+				return nil, nil, err
+			}
+			vclient.SetToken(token)
+
+			// Copy the artifacts before removing their option
+			copied := make([]*structs.TaskArtifact, len(artifacts))
+			for j, a := range artifacts {
+				copied[j] = a.Copy()
+			}
+			artifacts = copied
+			auths = make([]*getter.RegistryAuth, len(artifacts))
+		}
+
+		path = taskEnv.ReplaceEnv(path)
+		auth, err := readRegistryAuth(vclient, path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read registry credentials of artifact %q from %q: %v",
+				artifact.GetterSource, path, err)
+		}
+		delete(artifacts[i].GetterOptions, structs.ArtifactOptionVaultPath)
+		auths[i] = auth
+	}
+	return artifacts, auths, nil
+}
+
+// readRegistryAuth reads the username and password keys of the Vault secret
+// at path. Secrets of version 2 of the KV secrets engine are supported.
+func readRegistryAuth(vclient *vaultapi.Client, path string) (*getter.RegistryAuth, error) {
+	secret, err := vclient.Logical().Read(path)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, fmt.Errorf("secret not found")
+	}
+
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	username, _ := data["username"].(string)
+	password, _ := data["password"].(string)
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("secret must have username and password keys")
+	}
+	return &getter.RegistryAuth{Username: username, Password: password}, nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
+)
+
+type testEnvReplacer struct{}
+
+func (testEnvReplacer) ReplaceEnv(s string) string { return s }
+
+func TestRegistryAuths(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "task-token" || r.URL.Path != "/v1/secret/data/registry" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, `{"data": {"data": {"username": "nomad", "password": "hunter2"}}}`)
+	}))
+	defer srv.Close()
+
+	public := &structs.TaskArtifact{GetterSource: "oci://registry.example.com/public:1.0"}
+	private := &structs.TaskArtifact{
+		GetterSource:  "oci://registry.example.com/private:1.0",
+		GetterOptions: map[string]string{structs.ArtifactOptionVaultPath: "secret/data/registry"},
+	}
+	vaultConf := &config.VaultConfig{Addr: srv.URL}
+
+	artifacts, auths, err := registryAuths(vaultConf, "task-token", testEnvReplacer{}, []*structs.TaskArtifact{public, private})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(artifacts) != 2 || len(auths) != 2 {
+		t.Fatalf("unexpected artifacts %v and auths %v", artifacts, auths)
+	}
+	if auths[0] != nil {
+		t.Fatalf("unexpected auth of public artifact: %v", auths[0])
+	}
+	if auths[1] == nil || auths[1].Username != "nomad" || auths[1].Password != "hunter2" {
+		t.Fatalf("unexpected auth of private artifact: %v", auths[1])
+	}
+
+	// The option is removed from the downloaded copy only
+	if _, ok := artifacts[1].GetterOptions[structs.ArtifactOptionVaultPath]; ok {
+		t.Fatalf("vault option not removed: %v", artifacts[1].GetterOptions)
+	}
+	if _, ok := private.GetterOptions[structs.ArtifactOptionVaultPath]; !ok {
+		t.Fatalf("vault option removed from the task's artifact")
+	}
+
+	// A token without access fails
+	if _, _, err := registryAuths(vaultConf, "other-token", testEnvReplacer{}, []*structs.TaskArtifact{private}); err == nil {
+		t.Fatalf("expected error reading the secret without access")
+	}
+}
@@ -0,0 +1,36 @@
+package stats
+
+import (
+	"time"
+)
+
+// ByteRate calculates the rate at which a byte counter, such as the bytes
+// read from disk, grows
+type ByteRate struct {
+	prevBytes uint64
+	prevTime  time.Time
+}
+
+// NewByteRate returns a byte rate calculator
+func NewByteRate() *ByteRate {
+	return &ByteRate{}
+}
+
+// Rate returns the bytes per second the counter grew by since the previous
+// call. The first call returns 0.
+func (b *ByteRate) Rate(bytes uint64) float64 {
+	return b.rateAt(bytes, time.Now())
+}
+
+func (b *ByteRate) rateAt(bytes uint64, now time.Time) float64 {
+	prevBytes, prevTime := b.prevBytes, b.prevTime
+	b.prevBytes = bytes
+	b.prevTime = now
+
+	// The counter restarts if the task's cgroup was recreated
+	delta := now.Sub(prevTime).Seconds()
+	if prevTime.IsZero() || delta <= 0 || bytes < prevBytes {
+		return 0.0
+	}
+	return float64(bytes-prevBytes) / delta
+}
@@ -0,0 +1,26 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestByteRate(t *testing.T) {
+	b := NewByteRate()
+	now := time.Now()
+
+	if r := b.rateAt(1000, now); r != 0 {
+		t.Fatalf("expected no rate on the first sample: %v", r)
+	}
+	if r := b.rateAt(3000, now.Add(2*time.Second)); r != 1000 {
+		t.Fatalf("bad rate: %v", r)
+	}
+
+	// A counter going backwards was reset
+	if r := b.rateAt(500, now.Add(3*time.Second)); r != 0 {
+		t.Fatalf("bad rate: %v", r)
+	}
+	if r := b.rateAt(1000, now.Add(4*time.Second)); r != 500 {
+		t.Fatalf("bad rate: %v", r)
+	}
+}
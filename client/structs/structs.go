@@ -4,6 +4,7 @@ import (
 	"crypto/md5"
 	"io"
 	"strconv"
+	"time"
 
 	"github.com/hashicorp/nomad/client/config"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -55,15 +56,43 @@ func (cs *CpuStats) Add(other *CpuStats) {
 	cs.Measured = joinStringSet(cs.Measured, other.Measured)
 }
 
-// ResourceUsage holds information related to cpu and memory stats
+// DiskStats holds the block IO stats of a task. The rates are the bytes per
+// second since the previous sample.
+type DiskStats struct {
+	ReadBytes  uint64
+	WriteBytes uint64
+	ReadRate   float64
+	WriteRate  float64
+
+	// A list of fields whose values were actually sampled
+	Measured []string
+}
+
+func (ds *DiskStats) Add(other *DiskStats) {
+	ds.ReadBytes += other.ReadBytes
+	ds.WriteBytes += other.WriteBytes
+	ds.ReadRate += other.ReadRate
+	ds.WriteRate += other.WriteRate
+	ds.Measured = joinStringSet(ds.Measured, other.Measured)
+}
+
+// ResourceUsage holds information related to cpu, memory and disk stats. The
+// disk stats are nil if the driver doesn't measure them.
 type ResourceUsage struct {
 	MemoryStats *MemoryStats
 	CpuStats    *CpuStats
+	DiskStats   *DiskStats
 }
 
 func (ru *ResourceUsage) Add(other *ResourceUsage) {
 	ru.MemoryStats.Add(other.MemoryStats)
 	ru.CpuStats.Add(other.CpuStats)
+	if other.DiskStats != nil {
+		if ru.DiskStats == nil {
+			ru.DiskStats = &DiskStats{}
+		}
+		ru.DiskStats.Add(other.DiskStats)
+	}
 }
 
 // TaskResourceUsage holds aggregated resource usage of all processes in a Task
@@ -87,6 +116,86 @@ type AllocResourceUsage struct {
 	Timestamp int64
 }
 
+const (
+	// CheckProviderConsul is the provider of the checks run by Consul
+	CheckProviderConsul = "consul"
+
+	// CheckProviderNomad is the provider of the script checks run by Nomad
+	CheckProviderNomad = "nomad"
+)
+
+// CheckStatus is the status of a service check of an allocation.
+type CheckStatus struct {
+	Task    string
+	Service string
+	Name    string
+	CheckID string
+	Type    string
+
+	// Provider is who runs the check: Consul or Nomad
+	Provider string
+
+	// Status and Output are the health status and output of the check as
+	// registered in Consul
+	Status string
+	Output string
+
+	Interval time.Duration
+	Timeout  time.Duration
+
+	// LastRun and LastDuration are the time and duration of the last run of
+	// checks run by Nomad. They are zero when unknown.
+	LastRun      time.Time
+	LastDuration time.Duration
+}
+
+// NodeMetaApplyRequest is used to update the dynamic metadata of the node. A
+// nil value unsets the key.
+type NodeMetaApplyRequest struct {
+	Meta map[string]*string
+}
+
+// NodeMetaResponse is the metadata of the node.
+type NodeMetaResponse struct {
+	// Meta is the effective metadata of the node.
+	Meta map[string]string
+
+	// Dynamic is the metadata set or unset at runtime, overriding the
+	// metadata of the client's configuration.
+	Dynamic map[string]*string
+}
+
+const (
+	// AllocMigrationStatusPending is the status of a migration which hasn't
+	// started waiting on the previous allocation yet.
+	AllocMigrationStatusPending = "pending"
+
+	// AllocMigrationStatusWaiting is the status of a migration waiting on the
+	// previous allocation to terminate.
+	AllocMigrationStatusWaiting = "waiting"
+
+	// AllocMigrationStatusMigrating is the status of a migration moving or
+	// downloading the data of the previous allocation.
+	AllocMigrationStatusMigrating = "migrating"
+)
+
+// AllocMigration is a pending migration of the sticky ephemeral disk of an
+// allocation from its previous allocation.
+type AllocMigration struct {
+	// AllocID is the ID of the allocation the data is migrated to.
+	AllocID string
+
+	// PreviousAllocID is the ID of the allocation the data is migrated from.
+	PreviousAllocID string
+
+	// Local is true if the previous allocation is on this node, in which
+	// case its data is handed over in place rather than copied.
+	Local bool
+
+	// Status is the status of the migration.
+	Status string
+}
+
 // joinStringSet takes two slices of strings and joins them
 func joinStringSet(s1, s2 []string) []string {
 	lookup := make(map[string]struct{}, len(s1))
@@ -10,6 +10,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -29,6 +30,7 @@ import (
 	"github.com/ugorji/go/codec"
 
 	"github.com/hashicorp/nomad/client/driver/env"
+	"github.com/hashicorp/nomad/client/driver/logging"
 	dstructs "github.com/hashicorp/nomad/client/driver/structs"
 	cstructs "github.com/hashicorp/nomad/client/structs"
 )
@@ -57,6 +59,11 @@ const (
 	// vaultTokenFile is the name of the file holding the Vault token inside the
 	// task's secret directory
 	vaultTokenFile = "vault_token"
+
+	// stderrTailLines and stderrTailBytes bound the tail of the stderr of
+	// unsuccessful tasks recorded in their termination event
+	stderrTailLines = 10
+	stderrTailBytes = 4096
 )
 
 var (
@@ -109,6 +116,38 @@ type TaskRunner struct {
 	driverNet     *cstructs.DriverNetwork
 	driverNetLock sync.Mutex
 
+	// quarantine is used to refuse starting tasks from quarantined images
+	// and artifacts. It may be nil.
+	quarantine *quarantineList
+
+	// variables reads the items of the variables the task can read, which
+	// are set in its environment. It may be nil.
+	variables taskVariablesFn
+
+	// templateSource returns the source of the Nomad variables and services
+	// rendered by the templates of the task. It may be nil.
+	templateSource taskTemplateSourceFn
+
+	// netPolicies is triggered to enforce the network policies on the
+	// address of the task once it starts. It may be nil.
+	netPolicies *networkPolicyEnforcer
+
+	// artifactCache caches the artifacts the task downloads. It may be nil.
+	artifactCache *getter.Cache
+
+	// dynamicUsers gives the task a dynamic user to run as. It may be nil.
+	dynamicUsers *dynamicUserPool
+
+	// dynamicUser is the UID of the dynamic user the task runs as, zero if
+	// none. It is persisted.
+	dynamicUser int
+
+	// imageDigest and imageSBOM describe the image the task was last started
+	// from as reported by the driver. They are only accessed from the run
+	// loop.
+	imageDigest string
+	imageSBOM   string
+
 	// updateCh is used to receive updated versions of the allocation
 	updateCh chan *structs.Allocation
 
@@ -166,6 +205,10 @@ type TaskRunner struct {
 	destroyLock  sync.Mutex
 	destroyEvent *structs.TaskEvent
 
+	// checkpoint is set when the task should be checkpointed rather than
+	// killed when destroyed, as its allocation is migrated
+	checkpoint bool
+
 	// waitCh closing marks the run loop as having exited
 	waitCh chan struct{}
 
@@ -193,6 +236,7 @@ type taskRunnerState struct {
 	PayloadRendered    bool
 	CreatedResources   *driver.CreatedResources
 	DriverNetwork      *cstructs.DriverNetwork
+	DynamicUser        int
 }
 
 func (s *taskRunnerState) Hash() []byte {
@@ -205,6 +249,7 @@ func (s *taskRunnerState) Hash() []byte {
 	io.WriteString(h, fmt.Sprintf("%v", s.PayloadRendered))
 	h.Write(s.CreatedResources.Hash())
 	h.Write(s.DriverNetwork.Hash())
+	io.WriteString(h, fmt.Sprintf("%v", s.DynamicUser))
 
 	return h.Sum(nil)
 }
@@ -242,6 +287,11 @@ func NewTaskRunner(logger *log.Logger, config *config.Config,
 	}
 	restartTracker := newRestartTracker(tg.RestartPolicy, alloc.Job.Type)
 
+	// Tasks without a DNS config use the one of their group
+	if task.DNS == nil {
+		task.DNS = tg.DNS.Copy()
+	}
+
 	// Initialize the environment builder
 	envBuilder := env.NewBuilder(config.Node, alloc, task, config.Region)
 
@@ -286,6 +336,7 @@ func NewTaskRunner(logger *log.Logger, config *config.Config,
 			Value: tc.task.Name,
 		},
 	}
+	tc.baseLabels = append(tc.baseLabels, tenantMetricLabels(config, alloc)...)
 
 	return tc
 }
@@ -370,6 +421,8 @@ func (r *TaskRunner) RestoreState() (string, error) {
 	r.payloadRendered = snap.PayloadRendered
 	r.setCreatedResources(snap.CreatedResources)
 	r.driverNet = snap.DriverNetwork
+	r.dynamicUser = snap.DynamicUser
+	r.dynamicUsers.restore(snap.DynamicUser, r.alloc.ID)
 
 	if r.task.Vault != nil {
 		// Read the token from the secret directory
@@ -482,6 +535,7 @@ func (r *TaskRunner) SaveState() error {
 		TaskDirBuilt:       r.taskDirBuilt,
 		PayloadRendered:    r.payloadRendered,
 		CreatedResources:   r.getCreatedResources(),
+		DynamicUser:        r.dynamicUser,
 	}
 
 	r.handleLock.Lock()
@@ -882,6 +936,15 @@ func (r *TaskRunner) deriveVaultToken() (token string, exit bool) {
 }
 
 // writeToken writes the given token to disk
+// nomadTemplateSource returns the source of the Nomad variables and services
+// rendered by the task's templates, or nil if the runner has none.
+func (r *TaskRunner) nomadTemplateSource() nomadTemplateSource {
+	if r.templateSource == nil {
+		return nil
+	}
+	return r.templateSource(r.alloc, r.task.Name)
+}
+
 func (r *TaskRunner) writeToken(token string) error {
 	tokenPath := filepath.Join(r.taskDir.SecretsDir, vaultTokenFile)
 	if err := ioutil.WriteFile(tokenPath, []byte(token), 0777); err != nil {
@@ -911,6 +974,7 @@ func (r *TaskRunner) updatedTokenHandler() {
 			TaskDir:              r.taskDir.Dir,
 			EnvBuilder:           r.envBuilder,
 			MaxTemplateEventRate: DefaultMaxTemplateEventRate,
+			NomadSource:          r.nomadTemplateSource(),
 		})
 
 		if err != nil {
@@ -979,24 +1043,55 @@ func (r *TaskRunner) prestart(alloc *structs.Allocation, task *structs.Task, res
 		r.payloadRendered = true
 	}
 
+	// Refuse to download quarantined artifacts
+	if err := r.quarantine.checkArtifacts(task.Artifacts); err != nil {
+		r.setState(
+			structs.TaskStateDead,
+			structs.NewTaskEvent(structs.TaskSetupFailure).SetSetupError(err).SetFailsTask(),
+			false)
+		resultCh <- false
+		return
+	}
+
 	for {
 		r.persistLock.Lock()
 		downloaded := r.artifactsDownloaded
 		r.persistLock.Unlock()
 
+		// Read the variables of the task before its artifacts and templates
+		// are interpolated
+		if r.variables != nil {
+			items, err := r.variables(alloc, task.Name)
+			if err != nil {
+				wrapped := fmt.Errorf("failed to read variables: %v", err)
+				r.logger.Printf("[DEBUG] client: %v", wrapped)
+				r.setState(structs.TaskStatePending,
+					structs.NewTaskEvent(structs.TaskSetupFailure).SetSetupError(wrapped), false)
+				r.restartTracker.SetStartError(structs.NewRecoverableError(wrapped, true))
+				goto RESTART
+			}
+			r.envBuilder.SetVariables(items)
+		}
+
 		// Download the task's artifacts
 		if !downloaded && len(task.Artifacts) > 0 {
 			r.setState(structs.TaskStatePending, structs.NewTaskEvent(structs.TaskDownloadingArtifacts), false)
 			taskEnv := r.envBuilder.Build()
-			for _, artifact := range task.Artifacts {
-				if err := getter.GetArtifact(taskEnv, artifact, r.taskDir.Dir); err != nil {
-					wrapped := fmt.Errorf("failed to download artifact %q: %v", artifact.GetterSource, err)
-					r.logger.Printf("[DEBUG] client: %v", wrapped)
-					r.setState(structs.TaskStatePending,
-						structs.NewTaskEvent(structs.TaskArtifactDownloadFailed).SetDownloadError(wrapped), false)
-					r.restartTracker.SetStartError(structs.WrapRecoverable(wrapped.Error(), err))
-					goto RESTART
-				}
+			artifacts, auths, err := registryAuths(r.config.VaultConfig, r.vaultFuture.Get(), taskEnv, task.Artifacts)
+			if err != nil {
+				r.logger.Printf("[DEBUG] client: %v", err)
+				r.setState(structs.TaskStatePending,
+					structs.NewTaskEvent(structs.TaskArtifactDownloadFailed).SetDownloadError(err), false)
+				r.restartTracker.SetStartError(structs.NewRecoverableError(err, true))
+				goto RESTART
+			}
+			if artifact, err := getter.GetArtifacts(taskEnv, artifacts, r.taskDir.Dir, r.artifactCache, auths); err != nil {
+				wrapped := fmt.Errorf("failed to download artifact %q: %v", artifact.GetterSource, err)
+				r.logger.Printf("[DEBUG] client: %v", wrapped)
+				r.setState(structs.TaskStatePending,
+					structs.NewTaskEvent(structs.TaskArtifactDownloadFailed).SetDownloadError(wrapped), false)
+				r.restartTracker.SetStartError(structs.WrapRecoverable(wrapped.Error(), err))
+				goto RESTART
 			}
 
 			r.persistLock.Lock()
@@ -1027,6 +1122,7 @@ func (r *TaskRunner) prestart(alloc *structs.Allocation, task *structs.Task, res
 				TaskDir:              r.taskDir.Dir,
 				EnvBuilder:           r.envBuilder,
 				MaxTemplateEventRate: DefaultMaxTemplateEventRate,
+				NomadSource:          r.nomadTemplateSource(),
 			})
 			if err != nil {
 				err := fmt.Errorf("failed to build task's template manager: %v", err)
@@ -1087,6 +1183,8 @@ func (r *TaskRunner) run() {
 	if !handleEmpty {
 		stopCollection = make(chan struct{})
 		go r.collectResourceUsageStats(stopCollection)
+		go r.runConnectivityChecks(stopCollection)
+		go r.watchDriverHealth(r.handle)
 		handleWaitCh = r.handle.WaitCh()
 	}
 
@@ -1118,7 +1216,8 @@ func (r *TaskRunner) run() {
 					}
 
 					// Mark the task as started
-					r.setState(structs.TaskStateRunning, structs.NewTaskEvent(structs.TaskStarted), false)
+					r.setState(structs.TaskStateRunning,
+						structs.NewTaskEvent(structs.TaskStarted).SetImage(r.imageDigest, r.imageSBOM), false)
 					r.runningLock.Lock()
 					r.running = true
 					r.runningLock.Unlock()
@@ -1128,6 +1227,12 @@ func (r *TaskRunner) run() {
 						go r.collectResourceUsageStats(stopCollection)
 					}
 
+					// Verify the addresses the task depends on are reachable
+					go r.runConnectivityChecks(stopCollection)
+
+					// Report the health of the task reported by its driver
+					go r.watchDriverHealth(r.handle)
+
 					handleWaitCh = r.handle.WaitCh()
 				}
 
@@ -1233,7 +1338,9 @@ func (r *TaskRunner) run() {
 					}
 				}
 
-				r.killTask(killEvent)
+				if !r.checkpointTask() {
+					r.killTask(killEvent)
+				}
 				close(stopCollection)
 
 				// Wait for handler to exit before calling cleanup
@@ -1397,6 +1504,51 @@ func (r *TaskRunner) killTask(killingEvent *structs.TaskEvent) {
 	r.setState("", structs.NewTaskEvent(structs.TaskKilled).SetKillError(err), true)
 }
 
+// checkpointTask checkpoints the running task into the checkpoint dir of its
+// task dir when its allocation is migrated, which stops it. It returns whether
+// the task was checkpointed, otherwise it should be killed.
+func (r *TaskRunner) checkpointTask() bool {
+	r.destroyLock.Lock()
+	checkpoint := r.checkpoint
+	r.destroyLock.Unlock()
+	if !checkpoint {
+		return false
+	}
+
+	r.runningLock.Lock()
+	running := r.running
+	r.runningLock.Unlock()
+	if !running {
+		return false
+	}
+
+	handle, ok := r.getHandle().(driver.CheckpointHandle)
+	if !ok || !handle.Checkpointable() {
+		return false
+	}
+
+	r.logger.Printf("[DEBUG] client: checkpointing task %q for alloc %q", r.task.Name, r.alloc.ID)
+	err := r.taskDir.BuildCheckpointDir()
+	if err == nil {
+		err = handle.Checkpoint(r.taskDir.CheckpointDir)
+	}
+	if err == nil {
+		err = r.taskDir.CommitCheckpoint(r.task.Driver)
+	}
+	if err != nil {
+		r.logger.Printf("[WARN] client: failed to checkpoint task %q for alloc %q, killing it: %v",
+			r.task.Name, r.alloc.ID, err)
+		return false
+	}
+
+	r.runningLock.Lock()
+	r.running = false
+	r.runningLock.Unlock()
+
+	r.setState("", structs.NewTaskEvent(structs.TaskCheckpointed), true)
+	return true
+}
+
 // startTask creates the driver, task dir, and starts the task.
 func (r *TaskRunner) startTask() error {
 	// Create a driver
@@ -1406,9 +1558,18 @@ func (r *TaskRunner) startTask() error {
 			r.task.Name, r.alloc.ID, err)
 	}
 
+	// Run the task as a dynamic user if it gets one
+	task, err := r.setupDynamicUser()
+	if err != nil {
+		wrapped := fmt.Errorf("failed to set up dynamic user of task %q for alloc %q: %v",
+			r.task.Name, r.alloc.ID, err)
+		r.logger.Printf("[WARN] client: %v", wrapped)
+		return structs.NewRecoverableError(wrapped, true)
+	}
+
 	// Run prestart
 	ctx := driver.NewExecContext(r.taskDir, r.envBuilder.Build())
-	presp, err := drv.Prestart(ctx, r.task)
+	presp, err := drv.Prestart(ctx, task)
 
 	// Merge newly created resources into previously created resources
 	if presp != nil {
@@ -1431,7 +1592,14 @@ func (r *TaskRunner) startTask() error {
 	ctx = driver.NewExecContext(r.taskDir, r.envBuilder.Build())
 
 	// Start the job
-	sresp, err := drv.Start(ctx, r.task)
+	sresp, err := drv.Start(ctx, task)
+
+	// Tasks are only restored from a checkpoint once, the next starts are
+	// anew
+	if rerr := r.taskDir.RemoveCheckpoint(); rerr != nil {
+		r.logger.Printf("[WARN] client: failed to remove checkpoint of task %q for alloc %q: %v",
+			r.task.Name, r.alloc.ID, rerr)
+	}
 	if err != nil {
 		wrapped := fmt.Sprintf("failed to start task %q for alloc %q: %v",
 			r.task.Name, r.alloc.ID, err)
@@ -1439,6 +1607,20 @@ func (r *TaskRunner) startTask() error {
 		return structs.WrapRecoverable(wrapped, err)
 
 	}
+	if sresp.Restored {
+		r.setState(structs.TaskStateRunning, structs.NewTaskEvent(structs.TaskRestored), false)
+	}
+
+	// Kill tasks started from a quarantined image. The digest is only known
+	// once the driver has resolved the image.
+	if err := r.quarantine.checkImage(sresp.ImageDigest); err != nil {
+		r.logger.Printf("[ERR] client: refusing to run task %q alloc %q: %v", r.task.Name, r.alloc.ID, err)
+		if destroyed, err := r.handleDestroy(sresp.Handle); !destroyed {
+			r.logger.Printf("[ERR] client: failed to kill task %q alloc %q. Resources may be leaked: %v",
+				r.task.Name, r.alloc.ID, err)
+		}
+		return structs.NewRecoverableError(err, false)
+	}
 
 	// Log driver network information
 	if sresp.Network != nil && sresp.Network.IP != "" {
@@ -1479,6 +1661,10 @@ func (r *TaskRunner) startTask() error {
 	r.driverNetLock.Lock()
 	r.driverNet = sresp.Network
 	r.driverNetLock.Unlock()
+	r.netPolicies.trigger()
+
+	r.imageDigest = sresp.ImageDigest
+	r.imageSBOM = sresp.ImageSBOM
 
 	return nil
 }
@@ -1528,6 +1714,39 @@ func interpolateServices(taskEnv *env.TaskEnv, task *structs.Task) *structs.Task
 	return taskCopy
 }
 
+// setupDynamicUser acquires a dynamic user for the task if it doesn't set a
+// user and its driver runs tasks as dynamic users, giving it the task
+// directory. It returns the task to start, which runs as the dynamic user.
+func (r *TaskRunner) setupDynamicUser() (*structs.Task, error) {
+	if r.dynamicUsers == nil || r.task.User != "" {
+		return r.task, nil
+	}
+	drivers := r.config.ReadStringListToMapDefault("user.dynamic_drivers", config.DefaultDynamicUserDrivers)
+	if _, ok := drivers[r.task.Driver]; !ok {
+		return r.task, nil
+	}
+
+	r.persistLock.Lock()
+	uid := r.dynamicUser
+	r.persistLock.Unlock()
+	if uid == 0 {
+		var err error
+		if uid, err = r.dynamicUsers.acquire(r.alloc.ID); err != nil {
+			return nil, err
+		}
+		r.persistLock.Lock()
+		r.dynamicUser = uid
+		r.persistLock.Unlock()
+	}
+
+	if err := r.taskDir.SetOwner(uid, uid); err != nil {
+		return nil, err
+	}
+	task := r.task.Copy()
+	task.User = strconv.Itoa(uid)
+	return task, nil
+}
+
 // buildTaskDir creates the task directory before driver.Prestart. It is safe
 // to call multiple times as its state is persisted.
 func (r *TaskRunner) buildTaskDir(fsi cstructs.FSIsolation) error {
@@ -1645,6 +1864,9 @@ func (r *TaskRunner) handleUpdate(update *structs.Allocation) error {
 
 	// Merge in the task resources
 	updatedTask.Resources = update.TaskResources[updatedTask.Name]
+	if updatedTask.DNS == nil {
+		updatedTask.DNS = tg.DNS.Copy()
+	}
 
 	// Interpolate the old task with the old env before updating the env as
 	// updating services in Consul need both the old and new interpolations
@@ -1794,11 +2016,27 @@ func (r *TaskRunner) UnblockStart(source string) {
 }
 
 // Helper function for converting a WaitResult into a TaskTerminated event.
+// The event records the resource limits of the task and, if it failed, the
+// tail of its stderr to explain why it terminated.
 func (r *TaskRunner) waitErrorToEvent(res *dstructs.WaitResult) *structs.TaskEvent {
-	return structs.NewTaskEvent(structs.TaskTerminated).
+	event := structs.NewTaskEvent(structs.TaskTerminated).
 		SetExitCode(res.ExitCode).
 		SetSignal(res.Signal).
+		SetOOMKilled(res.OOMKilled).
 		SetExitMessage(res.Err)
+
+	if resources := r.task.Resources; resources != nil {
+		event.SetResourceLimits(resources.MemoryMB, resources.CPU)
+	}
+
+	if !res.Successful() && r.taskDir != nil {
+		tail, err := logging.LastLines(r.taskDir.LogDir, fmt.Sprintf("%s.stderr", r.task.Name), stderrTailLines, stderrTailBytes)
+		if err != nil {
+			r.logger.Printf("[DEBUG] client: failed to read stderr of task %q for alloc %q: %v", r.task.Name, r.alloc.ID, err)
+		}
+		event.SetStderrTail(tail)
+	}
+	return event
 }
 
 // Update is used to update the task of the context
@@ -1811,6 +2049,14 @@ func (r *TaskRunner) Update(update *structs.Allocation) {
 	}
 }
 
+// CheckpointOnDestroy checkpoints the task rather than killing it when it is
+// destroyed, if its driver supports it.
+func (r *TaskRunner) CheckpointOnDestroy() {
+	r.destroyLock.Lock()
+	defer r.destroyLock.Unlock()
+	r.checkpoint = true
+}
+
 // Destroy is used to indicate that the task context should be destroyed. The
 // event parameter provides a context for the destroy.
 func (r *TaskRunner) Destroy(event *structs.TaskEvent) {
@@ -1903,6 +2149,26 @@ func (r *TaskRunner) setGaugeForCPU(ru *cstructs.TaskResourceUsage) {
 	}
 }
 
+func (r *TaskRunner) setGaugeForDisk(ru *cstructs.TaskResourceUsage) {
+	if !r.config.DisableTaggedMetrics {
+		metrics.SetGaugeWithLabels([]string{"client", "allocs", "disk", "read_bytes"},
+			float32(ru.ResourceUsage.DiskStats.ReadBytes), r.baseLabels)
+		metrics.SetGaugeWithLabels([]string{"client", "allocs", "disk", "write_bytes"},
+			float32(ru.ResourceUsage.DiskStats.WriteBytes), r.baseLabels)
+		metrics.SetGaugeWithLabels([]string{"client", "allocs", "disk", "read_rate"},
+			float32(ru.ResourceUsage.DiskStats.ReadRate), r.baseLabels)
+		metrics.SetGaugeWithLabels([]string{"client", "allocs", "disk", "write_rate"},
+			float32(ru.ResourceUsage.DiskStats.WriteRate), r.baseLabels)
+	}
+
+	if r.config.BackwardsCompatibleMetrics {
+		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "disk", "read_bytes"}, float32(ru.ResourceUsage.DiskStats.ReadBytes))
+		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "disk", "write_bytes"}, float32(ru.ResourceUsage.DiskStats.WriteBytes))
+		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "disk", "read_rate"}, float32(ru.ResourceUsage.DiskStats.ReadRate))
+		metrics.SetGauge([]string{"client", "allocs", r.alloc.Job.Name, r.alloc.TaskGroup, r.alloc.ID, r.task.Name, "disk", "write_rate"}, float32(ru.ResourceUsage.DiskStats.WriteRate))
+	}
+}
+
 // emitStats emits resource usage stats of tasks to remote metrics collector
 // sinks
 func (r *TaskRunner) emitStats(ru *cstructs.TaskResourceUsage) {
@@ -1925,4 +2191,8 @@ func (r *TaskRunner) emitStats(ru *cstructs.TaskResourceUsage) {
 	if ru.ResourceUsage.CpuStats != nil {
 		r.setGaugeForCPU(ru)
 	}
+
+	if ru.ResourceUsage.DiskStats != nil {
+		r.setGaugeForDisk(ru)
+	}
 }
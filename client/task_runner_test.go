@@ -251,6 +251,47 @@ func TestTaskRunner_SimpleRun(t *testing.T) {
 
 }
 
+// TestTaskRunner_TerminatedDiagnostics asserts the event of a failed task
+// records its resource limits and the last lines it wrote to stderr.
+func TestTaskRunner_TerminatedDiagnostics(t *testing.T) {
+	t.Parallel()
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{
+		"exit_code": "1",
+		"run_for":   "1ns",
+	}
+
+	ctx := testTaskRunnerFromAlloc(t, false, alloc)
+	defer ctx.Cleanup()
+
+	// Write the stderr of the task as its log rotator would
+	stderr := filepath.Join(ctx.tr.taskDir.LogDir, task.Name+".stderr.0")
+	if err := ioutil.WriteFile(stderr, []byte("starting\npanic: out of cheese\n"), 0666); err != nil {
+		t.Fatalf("error writing stderr: %v", err)
+	}
+
+	ctx.tr.MarkReceived()
+	ctx.tr.Run()
+
+	var event *structs.TaskEvent
+	for _, e := range ctx.upd.events {
+		if e.Type == structs.TaskTerminated {
+			event = e
+		}
+	}
+	if event == nil {
+		t.Fatalf("no terminated event: %#v", ctx.upd.events)
+	}
+	if event.MemoryLimitMB != task.Resources.MemoryMB || event.CPULimit != task.Resources.CPU {
+		t.Fatalf("bad limits: %d MB, %d MHz", event.MemoryLimitMB, event.CPULimit)
+	}
+	if expected := []string{"starting", "panic: out of cheese"}; !reflect.DeepEqual(event.StderrTail, expected) {
+		t.Fatalf("bad stderr tail: got %q; want %q", event.StderrTail, expected)
+	}
+}
+
 func TestTaskRunner_Run_RecoverableStartError(t *testing.T) {
 	t.Parallel()
 	alloc := mock.Alloc()
@@ -337,6 +378,70 @@ func TestTaskRunner_Destroy(t *testing.T) {
 	}
 }
 
+func TestTaskRunner_Checkpoint(t *testing.T) {
+	t.Parallel()
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.Config = map[string]interface{}{
+		"run_for":    "1000s",
+		"checkpoint": true,
+	}
+
+	ctx := testTaskRunnerFromAlloc(t, true, alloc)
+	ctx.tr.MarkReceived()
+	go ctx.tr.Run()
+	defer ctx.Cleanup()
+	testWaitForTaskToStart(t, ctx)
+
+	// The task is checkpointed rather than killed
+	ctx.tr.CheckpointOnDestroy()
+	ctx.tr.Destroy(structs.NewTaskEvent(structs.TaskKilled))
+	select {
+	case <-ctx.tr.WaitCh():
+	case <-time.After(time.Duration(testutil.TestMultiplier()*15) * time.Second):
+		t.Fatalf("timeout")
+	}
+
+	last := ctx.upd.events[len(ctx.upd.events)-1]
+	if last.Type != structs.TaskCheckpointed {
+		t.Fatalf("Last event was %v; want %v", last.Type, structs.TaskCheckpointed)
+	}
+	checkpoint, err := ctx.tr.taskDir.ReadCheckpoint()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if checkpoint == nil || checkpoint.Driver != "mock_driver" || checkpoint.TaskDir != ctx.tr.taskDir.Dir {
+		t.Fatalf("unexpected checkpoint: %+v", checkpoint)
+	}
+
+	// A task started with the checkpoint is restored, once
+	ctx2 := testTaskRunnerFromAlloc(t, true, alloc.Copy())
+	defer ctx2.Cleanup()
+	if err := ctx2.tr.taskDir.BuildCheckpointDir(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := ctx2.tr.taskDir.CommitCheckpoint("mock_driver"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ctx2.tr.MarkReceived()
+	go ctx2.tr.Run()
+
+	testutil.WaitForResult(func() (bool, error) {
+		for _, e := range ctx2.upd.events {
+			if e.Type == structs.TaskRestored {
+				return true, nil
+			}
+		}
+		return false, fmt.Errorf("task not restored: %v", ctx2.upd)
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+	if checkpoint, err := ctx2.tr.taskDir.ReadCheckpoint(); err != nil || checkpoint != nil {
+		t.Fatalf("checkpoint not removed: %+v %v", checkpoint, err)
+	}
+}
+
 func TestTaskRunner_Update(t *testing.T) {
 	t.Parallel()
 	alloc := mock.Alloc()
@@ -667,6 +772,59 @@ func TestTaskRunner_UnregisterConsul_Retries(t *testing.T) {
 	}
 }
 
+func TestTaskRunner_DynamicUser(t *testing.T) {
+	t.Parallel()
+	if os.Geteuid() != 0 {
+		t.Skip("Must be run as root")
+	}
+	alloc := mock.Alloc()
+	task := alloc.Job.TaskGroups[0].Tasks[0]
+	task.Driver = "mock_driver"
+	task.User = ""
+	task.Config = map[string]interface{}{
+		"exit_code": "0",
+		"run_for":   "5s",
+	}
+
+	ctx := testTaskRunnerFromAlloc(t, false, alloc)
+	ctx.tr.config.Options = map[string]string{"user.dynamic_drivers": "mock_driver"}
+	pool, err := newDynamicUserPool("70000-70009")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ctx.tr.dynamicUsers = pool
+	ctx.tr.MarkReceived()
+	go ctx.tr.Run()
+	defer ctx.Cleanup()
+
+	testWaitForTaskToStart(t, ctx)
+	if ctx.tr.dynamicUser != 70000 {
+		t.Fatalf("got dynamic user %d; want 70000", ctx.tr.dynamicUser)
+	}
+	if ctx.tr.task.User != "" {
+		t.Fatalf("dynamic user set on the task: %q", ctx.tr.task.User)
+	}
+	if err := ctx.tr.SaveState(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The user is held by a restored task
+	restored, _ := newDynamicUserPool("70000-70009")
+	tr2 := NewTaskRunner(ctx.tr.logger, ctx.tr.config, ctx.tr.stateDB, ctx.upd.Update,
+		ctx.tr.taskDir, ctx.tr.alloc, ctx.tr.task.Copy(), ctx.tr.vaultClient, ctx.tr.consul)
+	tr2.dynamicUsers = restored
+	if _, err := tr2.RestoreState(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer tr2.Destroy(structs.NewTaskEvent(structs.TaskKilled))
+	if tr2.dynamicUser != 70000 {
+		t.Fatalf("got restored dynamic user %d; want 70000", tr2.dynamicUser)
+	}
+	if uid, err := restored.acquire("other"); err != nil || uid == 70000 {
+		t.Fatalf("got uid %d, err %v; want another uid", uid, err)
+	}
+}
+
 func TestTaskRunner_Validate_UserEnforcement(t *testing.T) {
 	t.Parallel()
 	ctx := testTaskRunner(t, false)
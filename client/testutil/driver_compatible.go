@@ -49,6 +49,19 @@ func RktCompatible(t *testing.T) {
 	}
 }
 
+func ContainerdCompatible(t *testing.T) {
+	if runtime.GOOS != "linux" || syscall.Geteuid() != 0 {
+		t.Skip("Must be root on Linux to run test")
+	}
+	// else see if containerd and nerdctl exist
+	if _, err := exec.Command("ctr", "version").CombinedOutput(); err != nil {
+		t.Skip("Must have containerd running for containerd specific tests to run")
+	}
+	if _, err := exec.LookPath("nerdctl"); err != nil {
+		t.Skip("Must have nerdctl installed for containerd specific tests to run")
+	}
+}
+
 func MountCompatible(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("Windows does not support mount")
@@ -58,3 +71,9 @@ func MountCompatible(t *testing.T) {
 		t.Skip("Must be root to run test")
 	}
 }
+
+func WasmCompatible(t *testing.T) {
+	if _, err := exec.Command("wasmtime", "--version").CombinedOutput(); err != nil {
+		t.Skip("Must have wasmtime installed for wasm specific tests to run")
+	}
+}
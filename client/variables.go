@@ -0,0 +1,30 @@
+package client
+
+import (
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// taskVariablesFn returns the items of the variables a task of an allocation
+// can read
+type taskVariablesFn func(alloc *structs.Allocation, task string) (map[string]string, error)
+
+// taskVariables reads the items of the variables the task of the allocation
+// can read from the servers.
+func (c *Client) taskVariables(alloc *structs.Allocation, task string) (map[string]string, error) {
+	req := structs.VariableTaskRequest{
+		NodeID:   c.NodeID(),
+		SecretID: c.secretNodeID(),
+		AllocID:  alloc.ID,
+		Task:     task,
+		QueryOptions: structs.QueryOptions{
+			Region:     c.Region(),
+			AllowStale: true,
+		},
+	}
+
+	var resp structs.VariableTaskResponse
+	if err := c.RPC("Variables.Task", &req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Items, nil
+}
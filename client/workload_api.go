@@ -0,0 +1,151 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	cleanhttp "github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// workloadAPISocket is the name of the workload API socket in the shared
+// allocation directory
+const workloadAPISocket = "api.sock"
+
+// workloadAPIPaths are the prefixes of the HTTP API paths tasks can read
+// through the workload API socket
+var workloadAPIPaths = []string{
+	"/v1/allocation/",
+	"/v1/job/",
+	"/v1/service-discovery/",
+	"/v1/var/",
+	"/v1/vars",
+}
+
+// workloadAPIFn serves the workload API of an allocation on a unix socket at
+// path until the returned server is closed
+type workloadAPIFn func(alloc *structs.Allocation, path string) (io.Closer, error)
+
+// serveWorkloadAPI serves the workload API of the allocation by proxying the
+// requests made on the socket to the HTTP API of the agent. When ACLs are
+// enabled the requests are made with the workload token of the allocation.
+func (c *Client) serveWorkloadAPI(alloc *structs.Allocation, socket string) (io.Closer, error) {
+	var token string
+	if c.config.ACLEnabled {
+		var err error
+		if token, err = c.deriveWorkloadToken(alloc); err != nil {
+			return nil, fmt.Errorf("failed to derive workload token: %v", err)
+		}
+	}
+
+	target, transport, err := c.workloadAPITarget()
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove the socket left by a previous run of the client
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tasks may run as any user
+	if err := os.Chmod(socket, 0666); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	srv := &http.Server{Handler: newWorkloadAPIHandler(target, transport, token)}
+	go srv.Serve(l)
+	return srv, nil
+}
+
+// deriveWorkloadToken returns the secret ID of the workload token of the
+// allocation, created by the servers on the first request.
+func (c *Client) deriveWorkloadToken(alloc *structs.Allocation) (string, error) {
+	req := structs.DeriveWorkloadTokenRequest{
+		NodeID:   c.NodeID(),
+		SecretID: c.secretNodeID(),
+		AllocID:  alloc.ID,
+		WriteRequest: structs.WriteRequest{
+			Region: c.Region(),
+		},
+	}
+
+	var resp structs.DeriveWorkloadTokenResponse
+	if err := c.RPC("Node.DeriveWorkloadToken", &req, &resp); err != nil {
+		return "", err
+	}
+	return resp.SecretID, nil
+}
+
+// workloadAPITarget returns the address of the HTTP API of the agent and the
+// transport to reach it with.
+func (c *Client) workloadAPITarget() (*url.URL, http.RoundTripper, error) {
+	c.configLock.RLock()
+	defer c.configLock.RUnlock()
+
+	transport := cleanhttp.DefaultTransport()
+	target := &url.URL{Scheme: "http", Host: c.config.Node.HTTPAddr}
+	if c.config.TLSConfig.EnableHTTP {
+		tlsConf, err := c.config.TLSConfiguration().OutgoingTLSConfig()
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsConf.ServerName = fmt.Sprintf("client.%s.nomad", c.config.Region)
+		transport.TLSClientConfig = tlsConf
+		target.Scheme = "https"
+	}
+	return target, transport, nil
+}
+
+// newWorkloadAPIHandler returns a handler proxying the read requests to the
+// workload API paths to the target. The requests are made with the token,
+// replacing any token set by the task.
+func newWorkloadAPIHandler(target *url.URL, transport http.RoundTripper, token string) http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.Transport = transport
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Header.Del("X-Nomad-Token")
+		if token != "" {
+			req.Header.Set("X-Nomad-Token", token)
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			http.Error(w, "the workload API is read-only", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Clean the path so that it can't escape the allowed prefixes
+		p := path.Clean(req.URL.Path)
+		allowed := false
+		for _, prefix := range workloadAPIPaths {
+			if strings.HasPrefix(p, prefix) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			http.Error(w, fmt.Sprintf("path %q is not served by the workload API", p), http.StatusForbidden)
+			return
+		}
+
+		req.URL.Path = p
+		req.URL.RawPath = ""
+		proxy.ServeHTTP(w, req)
+	})
+}
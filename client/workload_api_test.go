@@ -0,0 +1,62 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkloadAPIHandler(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	var gotPath, gotToken string
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotPath = req.URL.Path
+		gotToken = req.Header.Get("X-Nomad-Token")
+		w.Write([]byte("ok"))
+	}))
+	defer agent.Close()
+
+	target, err := url.Parse(agent.URL)
+	require.NoError(err)
+	proxy := httptest.NewServer(newWorkloadAPIHandler(target, http.DefaultTransport, "workload-secret"))
+	defer proxy.Close()
+
+	// The token of the task is replaced by the workload token
+	req, err := http.NewRequest("GET", proxy.URL+"/v1/var/nomad/jobs/web", nil)
+	require.NoError(err)
+	req.Header.Set("X-Nomad-Token", "task-secret")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(err)
+	require.Equal(http.StatusOK, resp.StatusCode)
+	require.Equal("ok", string(body))
+	require.Equal("/v1/var/nomad/jobs/web", gotPath)
+	require.Equal("workload-secret", gotToken)
+
+	// Writes are refused
+	resp, err = http.Post(proxy.URL+"/v1/var/nomad/jobs/web", "application/json", nil)
+	require.NoError(err)
+	resp.Body.Close()
+	require.Equal(http.StatusMethodNotAllowed, resp.StatusCode)
+
+	// Only the workload paths are served, even when escaping them
+	gotPath = ""
+	for _, p := range []string{"/v1/agent/self", "/v1/allocation/../agent/self"} {
+		req, err := http.NewRequest("GET", proxy.URL, nil)
+		require.NoError(err)
+		req.URL.Opaque = p
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(err)
+		resp.Body.Close()
+		require.Equal(http.StatusForbidden, resp.StatusCode, p)
+	}
+	require.Empty(gotPath)
+}
@@ -102,6 +102,14 @@ func formatKVACLToken(token *api.ACLToken) string {
 		output = append(output, fmt.Sprintf("Policies|%v", token.Policies))
 	}
 
+	// Tokens issued by auth methods expire
+	if token.AuthMethod != "" {
+		output = append(output, fmt.Sprintf("Auth Method|%s", token.AuthMethod))
+	}
+	if token.ExpirationTime != nil {
+		output = append(output, fmt.Sprintf("Expiration Time|%v", *token.ExpirationTime))
+	}
+
 	// Add the generic output
 	output = append(output,
 		fmt.Sprintf("Create Time|%v", token.CreateTime),
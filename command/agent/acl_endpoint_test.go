@@ -448,3 +448,61 @@ func TestHTTP_ACLTokenDelete(t *testing.T) {
 		assert.Nil(t, out)
 	})
 }
+
+func TestHTTP_ACLAuthMethodCRUD(t *testing.T) {
+	t.Parallel()
+	httpACLTest(t, nil, func(s *TestAgent) {
+		m1 := &structs.ACLAuthMethod{
+			Name: "okta",
+			Type: structs.ACLAuthMethodTypeOIDC,
+			Config: &structs.ACLAuthMethodConfig{
+				OIDCDiscoveryURL:    "https://example.com",
+				OIDCClientID:        "nomad",
+				AllowedRedirectURIs: []string{"http://localhost:4649/oidc/callback"},
+			},
+		}
+
+		// Create the auth method
+		req, err := http.NewRequest("PUT", "/v1/acl/auth-method/"+m1.Name, encodeReq(m1))
+		assert.Nil(t, err)
+		respW := httptest.NewRecorder()
+		setToken(req, s.RootToken)
+		obj, err := s.Server.ACLAuthMethodSpecificRequest(respW, req)
+		assert.Nil(t, err)
+		assert.Nil(t, obj)
+		assert.NotEqual(t, "", respW.HeaderMap.Get("X-Nomad-Index"))
+
+		// The name must match the path
+		req, err = http.NewRequest("PUT", "/v1/acl/auth-method/other", encodeReq(m1))
+		assert.Nil(t, err)
+		setToken(req, s.RootToken)
+		_, err = s.Server.ACLAuthMethodSpecificRequest(httptest.NewRecorder(), req)
+		assert.NotNil(t, err)
+
+		// List the auth methods
+		req, err = http.NewRequest("GET", "/v1/acl/auth-methods", nil)
+		assert.Nil(t, err)
+		obj, err = s.Server.ACLAuthMethodsRequest(httptest.NewRecorder(), req)
+		assert.Nil(t, err)
+		assert.Len(t, obj.([]*structs.ACLAuthMethodListStub), 1)
+
+		// Read the auth method
+		req, err = http.NewRequest("GET", "/v1/acl/auth-method/"+m1.Name, nil)
+		assert.Nil(t, err)
+		setToken(req, s.RootToken)
+		obj, err = s.Server.ACLAuthMethodSpecificRequest(httptest.NewRecorder(), req)
+		assert.Nil(t, err)
+		assert.Equal(t, m1.Config, obj.(*structs.ACLAuthMethod).Config)
+
+		// Delete the auth method
+		req, err = http.NewRequest("DELETE", "/v1/acl/auth-method/"+m1.Name, nil)
+		assert.Nil(t, err)
+		setToken(req, s.RootToken)
+		_, err = s.Server.ACLAuthMethodSpecificRequest(httptest.NewRecorder(), req)
+		assert.Nil(t, err)
+
+		out, err := s.Agent.server.State().ACLAuthMethodByName(nil, m1.Name)
+		assert.Nil(t, err)
+		assert.Nil(t, out)
+	})
+}
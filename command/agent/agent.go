@@ -57,6 +57,9 @@ type Agent struct {
 	// consulCatalog is the subset of Consul's Catalog API Nomad uses.
 	consulCatalog consul.CatalogAPI
 
+	// consulKV is the subset of Consul's KV API Nomad uses.
+	consulKV consul.KVAPI
+
 	// consulSupportsTLSSkipVerify flags whether or not Nomad can register
 	// checks with TLSSkipVerify
 	consulSupportsTLSSkipVerify bool
@@ -151,6 +154,8 @@ func convertServerConfig(agentConfig *Config, logOutput io.Writer) (*nomad.Confi
 	if len(agentConfig.Server.EnabledSchedulers) != 0 {
 		conf.EnabledSchedulers = agentConfig.Server.EnabledSchedulers
 	}
+	conf.Scorers = agentConfig.Server.Scorers
+	conf.NodeHooks = agentConfig.Server.NodeHooks
 	if agentConfig.ACL.Enabled {
 		conf.ACLEnabled = true
 	}
@@ -259,6 +264,50 @@ func convertServerConfig(agentConfig *Config, logOutput io.Writer) (*nomad.Confi
 	if maxHPS := agentConfig.Server.MaxHeartbeatsPerSecond; maxHPS != 0 {
 		conf.MaxHeartbeatsPerSecond = maxHPS
 	}
+	if retention := agentConfig.Server.StateHistoryRetention; retention != 0 {
+		conf.StateHistoryRetention = retention
+	}
+	if interval := agentConfig.Server.StateHistoryInterval; interval != 0 {
+		conf.StateHistoryInterval = interval
+	}
+	if size := agentConfig.Server.EventBufferSize; size < 0 {
+		return nil, fmt.Errorf("event_buffer_size must be non-negative: %v", size)
+	} else if size != 0 {
+		conf.EventBufferSize = size
+	}
+	if size := agentConfig.Server.EventDiskBufferSize; size < 0 {
+		return nil, fmt.Errorf("event_disk_buffer_size must be non-negative: %v", size)
+	} else if size != 0 {
+		conf.EventDiskBufferSize = size
+	}
+	if limit := agentConfig.Server.RPCReadRateLimit; limit < 0 {
+		return nil, fmt.Errorf("rpc_read_rate_limit must be non-negative: %v", limit)
+	}
+	if limit := agentConfig.Server.RPCWriteRateLimit; limit < 0 {
+		return nil, fmt.Errorf("rpc_write_rate_limit must be non-negative: %v", limit)
+	}
+	conf.RPCReadRateLimit = agentConfig.Server.RPCReadRateLimit
+	conf.RPCReadRateBurst = agentConfig.Server.RPCReadRateBurst
+	conf.RPCWriteRateLimit = agentConfig.Server.RPCWriteRateLimit
+	conf.RPCWriteRateBurst = agentConfig.Server.RPCWriteRateBurst
+	if max := agentConfig.Server.MaxJobSize; max != nil {
+		if *max < 0 {
+			return nil, fmt.Errorf("max_job_size must be non-negative: %v", *max)
+		}
+		conf.MaxJobSize = *max
+	}
+	if max := agentConfig.Server.MaxDispatchPayloadSize; max != nil {
+		if *max < 0 {
+			return nil, fmt.Errorf("max_dispatch_payload_size must be non-negative: %v", *max)
+		}
+		conf.MaxDispatchPayloadSize = *max
+	}
+	if max := agentConfig.Server.MaxTemplateSize; max != nil {
+		if *max < 0 {
+			return nil, fmt.Errorf("max_template_size must be non-negative: %v", *max)
+		}
+		conf.MaxTemplateSize = *max
+	}
 
 	if *agentConfig.Consul.AutoAdvertise && agentConfig.Consul.ServerServiceName == "" {
 		return nil, fmt.Errorf("server_service_name must be set when auto_advertise is enabled")
@@ -268,6 +317,16 @@ func convertServerConfig(agentConfig *Config, logOutput io.Writer) (*nomad.Confi
 	conf.ConsulConfig = agentConfig.Consul
 	conf.VaultConfig = agentConfig.Vault
 
+	// Add the state export config
+	if agentConfig.StateExport != nil {
+		conf.StateExportConfig = agentConfig.StateExport
+	}
+
+	// Add the allocation archive config
+	if agentConfig.AllocArchive != nil {
+		conf.AllocArchiveConfig = agentConfig.AllocArchive
+	}
+
 	// Set the TLS config
 	conf.TLSConfig = agentConfig.TLSConfig
 
@@ -275,6 +334,7 @@ func convertServerConfig(agentConfig *Config, logOutput io.Writer) (*nomad.Confi
 	conf.StatsCollectionInterval = agentConfig.Telemetry.collectionInterval
 	conf.DisableTaggedMetrics = agentConfig.Telemetry.DisableTaggedMetrics
 	conf.BackwardsCompatibleMetrics = agentConfig.Telemetry.BackwardsCompatibleMetrics
+	conf.MetricsNamespaceLabels = agentConfig.Telemetry.NamespaceLabels
 
 	return conf, nil
 }
@@ -386,6 +446,8 @@ func (a *Agent) clientConfig() (*clientconfig.Config, error) {
 	conf.PublishAllocationMetrics = a.config.Telemetry.PublishAllocationMetrics
 	conf.DisableTaggedMetrics = a.config.Telemetry.DisableTaggedMetrics
 	conf.BackwardsCompatibleMetrics = a.config.Telemetry.BackwardsCompatibleMetrics
+	conf.MetricsNamespaceLabels = a.config.Telemetry.NamespaceLabels
+	conf.MetricsNodeClassLabels = a.config.Telemetry.NodeClassLabels
 
 	// Set the TLS related configs
 	conf.TLSConfig = a.config.TLSConfig
@@ -404,6 +466,63 @@ func (a *Agent) clientConfig() (*clientconfig.Config, error) {
 		conf.NoHostUUID = true
 	}
 
+	// Set the cgroup management configs
+	switch a.config.Client.CgroupDriver {
+	case "":
+	case clientconfig.CgroupDriverCgroupfs, clientconfig.CgroupDriverSystemd:
+		conf.CgroupDriver = a.config.Client.CgroupDriver
+	default:
+		return nil, fmt.Errorf("invalid cgroup_driver %q: must be %q or %q", a.config.Client.CgroupDriver,
+			clientconfig.CgroupDriverCgroupfs, clientconfig.CgroupDriverSystemd)
+	}
+	switch a.config.Client.MountDriver {
+	case "":
+	case clientconfig.MountDriverSyscall, clientconfig.MountDriverSystemd:
+		conf.MountDriver = a.config.Client.MountDriver
+	default:
+		return nil, fmt.Errorf("invalid mount_driver %q: must be %q or %q", a.config.Client.MountDriver,
+			clientconfig.MountDriverSyscall, clientconfig.MountDriverSystemd)
+	}
+	if a.config.Client.CgroupSlice != "" {
+		if !strings.HasSuffix(a.config.Client.CgroupSlice, ".slice") {
+			return nil, fmt.Errorf("invalid cgroup_slice %q: must end in \".slice\"", a.config.Client.CgroupSlice)
+		}
+		conf.CgroupSlice = a.config.Client.CgroupSlice
+	}
+	conf.WorkloadAPI = a.config.Client.WorkloadAPI
+	conf.RPCCompression = a.config.Client.RPCCompression
+	if a.config.Client.ArtifactCacheMaxMB != 0 {
+		conf.ArtifactCacheMaxMB = a.config.Client.ArtifactCacheMaxMB
+	}
+	for i, p := range a.config.Client.GCPolicies {
+		policy := &clientconfig.GCPolicy{
+			Namespace:          p.Namespace,
+			MaxPriority:        p.MaxPriority,
+			MaxAge:             p.MaxAge,
+			DiskUsageThreshold: p.DiskUsageThreshold,
+		}
+		if err := policy.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid gc_policy %d: %v", i+1, err)
+		}
+		conf.GCPolicies = append(conf.GCPolicies, policy)
+	}
+	for _, p := range a.config.Client.FingerprintPlugins {
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid fingerprint_plugin %q: %v", p.Name, err)
+		}
+		conf.FingerprintPlugins = append(conf.FingerprintPlugins, p.Copy())
+	}
+	if d := a.config.Client.ExternalDNS; d != nil {
+		if err := d.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid external_dns: %v", err)
+		}
+		conf.ExternalDNS = d.Copy()
+	}
+	conf.DrainOnLeave = a.config.Client.DrainOnLeave
+	if a.config.Client.DrainDeadline != 0 {
+		conf.DrainDeadline = a.config.Client.DrainDeadline
+	}
+
 	// Setup the ACLs
 	conf.ACLEnabled = a.config.ACL.Enabled
 	conf.ACLTokenTTL = a.config.ACL.TokenTTL
@@ -430,7 +549,7 @@ func (a *Agent) setupServer() error {
 	}
 
 	// Create the server
-	server, err := nomad.NewServer(conf, a.consulCatalog, a.logger)
+	server, err := nomad.NewServer(conf, a.consulCatalog, a.consulKV, a.logger)
 	if err != nil {
 		return fmt.Errorf("server setup failed: %v", err)
 	}
@@ -767,11 +886,15 @@ func (a *Agent) Stats() map[string]map[string]string {
 func (a *Agent) ShouldReload(newConfig *Config) (bool, bool) {
 	a.configLock.Lock()
 	defer a.configLock.Unlock()
-	if a.config.TLSConfig.Equals(newConfig.TLSConfig) {
-		return false, false
+	if !a.config.TLSConfig.Equals(newConfig.TLSConfig) {
+		return true, true // requires a reload of both agent and http server
 	}
 
-	return true, true // requires a reload of both agent and http server
+	// Changes to the reloadable client settings only reload the agent
+	if a.client != nil && !a.config.Client.reloadableEqual(newConfig.Client) {
+		return true, false
+	}
+	return false, false
 }
 
 // Reload handles configuration changes for the agent. Provides a method that
@@ -784,6 +907,14 @@ func (a *Agent) Reload(newConfig *Config) error {
 		return fmt.Errorf("cannot reload agent with nil configuration")
 	}
 
+	// Update the client settings which are reloaded without restarting it
+	if a.config.Client != nil && newConfig.Client != nil {
+		a.config.Client.applyReloadable(newConfig.Client)
+	}
+	if a.config.TLSConfig.Equals(newConfig.TLSConfig) {
+		return nil
+	}
+
 	// This is just a TLS configuration reload, we don't need to refresh
 	// existing network connections
 	if !a.config.TLSConfig.IsEmpty() && !newConfig.TLSConfig.IsEmpty() {
@@ -844,6 +975,9 @@ func (a *Agent) setupConsul(consulConfig *config.ConsulConfig) error {
 	// Create Consul Catalog client for service discovery.
 	a.consulCatalog = client.Catalog()
 
+	// Create Consul KV client for verifying the keys jobs depend on.
+	a.consulKV = client.KV()
+
 	// Create Consul Service client for service advertisement and checks.
 	a.consulService = consul.NewServiceClient(client.Agent(), a.consulSupportsTLSSkipVerify, a.logger)
 
@@ -2,12 +2,15 @@ package agent
 
 import (
 	"encoding/json"
+	"fmt"
 	"net"
 	"net/http"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/serf/serf"
 	"github.com/mitchellh/copystructure"
@@ -367,6 +370,26 @@ func (s *HTTPServer) HealthRequest(resp http.ResponseWriter, req *http.Request)
 			health.Server.Ok = false
 			health.Server.Message = "no leader"
 		}
+
+		// Only raft and the state store make the server unhealthy; the other
+		// subsystems are reported so monitoring can alert on them
+		health.Server.Subsystems = make(map[string]*healthResponseSubsystem)
+		for name, sub := range server.SubsystemHealth() {
+			h := &healthResponseSubsystem{
+				Ok:        sub.Ok,
+				Message:   sub.Message,
+				Latency:   sub.Latency,
+				LastError: sub.LastError,
+			}
+			if sub.LastError != "" {
+				h.LastErrorTime = &sub.LastErrorTime
+			}
+			health.Server.Subsystems[name] = h
+			if !sub.Ok && health.Server.Ok && (name == nomad.HealthRaft || name == nomad.HealthStateStore) {
+				health.Server.Ok = false
+				health.Server.Message = fmt.Sprintf("%s: %s", name, sub.Message)
+			}
+		}
 	}
 
 	if health.ok() {
@@ -397,6 +420,15 @@ func (h healthResponse) ok() bool {
 }
 
 type healthResponseAgent struct {
-	Ok      bool   `json:"ok"`
-	Message string `json:"message,omitempty"`
+	Ok         bool                                `json:"ok"`
+	Message    string                              `json:"message,omitempty"`
+	Subsystems map[string]*healthResponseSubsystem `json:"subsystems,omitempty"`
+}
+
+type healthResponseSubsystem struct {
+	Ok            bool          `json:"ok"`
+	Message       string        `json:"message,omitempty"`
+	Latency       time.Duration `json:"latency"`
+	LastError     string        `json:"last_error,omitempty"`
+	LastErrorTime *time.Time    `json:"last_error_time,omitempty"`
 }
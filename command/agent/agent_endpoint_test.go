@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/stretchr/testify/assert"
@@ -690,6 +691,18 @@ func TestHTTP_AgentHealth_Ok(t *testing.T) {
 			assert.True(health.Server.Ok)
 			assert.Equal("ok", health.Server.Message)
 			assert.Nil(health.Client)
+
+			// The subsystems of the leader are healthy
+			for _, name := range []string{nomad.HealthRaft, nomad.HealthStateStore, nomad.HealthEvalBroker, nomad.HealthPlanQueue} {
+				if assert.Contains(health.Server.Subsystems, name) {
+					sub := health.Server.Subsystems[name]
+					assert.True(sub.Ok, name)
+					assert.Empty(sub.LastError, name)
+					assert.Nil(sub.LastErrorTime, name)
+				}
+			}
+			assert.Equal("leader", health.Server.Subsystems[nomad.HealthRaft].Message)
+			assert.NotContains(health.Server.Subsystems, nomad.HealthVault)
 		}
 
 		// type=client&type=server
@@ -10,8 +10,10 @@ import (
 	"time"
 
 	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/nomad"
 	sconfig "github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func tmpDir(t testing.TB) string {
@@ -256,6 +258,26 @@ func TestAgent_ServerConfig(t *testing.T) {
 	if out.BootstrapExpect != 3 {
 		t.Fatalf("should have bootstrap-expect = 3")
 	}
+
+	// Size limits keep their defaults unless set, and zero disables them
+	if out.MaxJobSize != nomad.DefaultMaxJobSize {
+		t.Fatalf("expect default max job size, got: %d", out.MaxJobSize)
+	}
+	conf.Server.MaxJobSize = helper.IntToPtr(0)
+	conf.Server.MaxDispatchPayloadSize = helper.IntToPtr(0)
+	conf.Server.MaxTemplateSize = helper.IntToPtr(0)
+	out, err = a.serverConfig()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if out.MaxJobSize != 0 || out.MaxDispatchPayloadSize != 0 || out.MaxTemplateSize != 0 {
+		t.Fatalf("size limits should be disabled: %d %d %d",
+			out.MaxJobSize, out.MaxDispatchPayloadSize, out.MaxTemplateSize)
+	}
+	conf.Server.MaxJobSize = helper.IntToPtr(-1)
+	if _, err := a.serverConfig(); err == nil {
+		t.Fatalf("expected error for negative max job size")
+	}
 }
 
 func TestAgent_ClientConfig(t *testing.T) {
@@ -940,3 +962,31 @@ func TestServer_ShouldReload_ReturnTrueForConfigChanges(t *testing.T) {
 	assert.True(shouldReloadAgent)
 	assert.True(shouldReloadHTTPServer)
 }
+
+func TestServer_ShouldReload_ClientChanges(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	agent := NewTestAgent(t, t.Name(), nil)
+	defer agent.Shutdown()
+	require.NotNil(agent.Client())
+
+	newConfig := *agent.GetConfig()
+	client := *newConfig.Client
+	newConfig.Client = &client
+	newConfig.Client.ChrootEnv = map[string]string{"/bin": "/bin"}
+	newConfig.Client.Options = map[string]string{"artifact.oci.auth.config": "/etc/docker/config.json"}
+
+	// Only the agent reloads
+	shouldReloadAgent, shouldReloadHTTPServer := agent.ShouldReload(&newConfig)
+	require.True(shouldReloadAgent)
+	require.False(shouldReloadHTTPServer)
+
+	require.NoError(agent.Reload(&newConfig))
+	conf := agent.GetConfig()
+	require.Equal(newConfig.Client.ChrootEnv, conf.Client.ChrootEnv)
+	require.Equal("/etc/docker/config.json", conf.Client.Options["artifact.oci.auth.config"])
+
+	shouldReloadAgent, _ = agent.ShouldReload(&newConfig)
+	require.False(shouldReloadAgent)
+}
@@ -3,11 +3,17 @@ package agent
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/docker/docker/pkg/ioutils"
 	"github.com/golang/snappy"
 	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/client"
+	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/ugorji/go/codec"
 )
 
 const (
@@ -40,6 +46,27 @@ func (s *HTTPServer) AllocsRequest(resp http.ResponseWriter, req *http.Request)
 	return out.Allocations, nil
 }
 
+// AllocsStopRequest stops a batch of the running allocations that match the
+// filter of the request body.
+func (s *HTTPServer) AllocsStopRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.AllocBatchStopRequest{}
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	var out structs.AllocBatchStopResponse
+	if err := s.agent.RPC("Alloc.StopBatch", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
 func (s *HTTPServer) AllocSpecificRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	allocID := strings.TrimPrefix(req.URL.Path, "/v1/allocation/")
 	if req.Method != "GET" {
@@ -95,6 +122,8 @@ func (s *HTTPServer) ClientAllocRequest(resp http.ResponseWriter, req *http.Requ
 	switch tokens[1] {
 	case "stats":
 		return s.allocStats(allocID, resp, req)
+	case "checks":
+		return s.allocChecks(allocID, resp, req)
 	case "snapshot":
 		return s.allocSnapshot(allocID, resp, req)
 	case "gc":
@@ -123,6 +152,31 @@ func (s *HTTPServer) ClientGCRequest(resp http.ResponseWriter, req *http.Request
 	return nil, nil
 }
 
+func (s *HTTPServer) ClientMigrationsRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.agent.client == nil {
+		return nil, clientNotRunning
+	}
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var secret string
+	s.parseToken(req, &secret)
+
+	// Check node read permissions
+	if aclObj, err := s.agent.Client().ResolveToken(secret); err != nil {
+		return nil, err
+	} else if aclObj != nil && !aclObj.AllowNodeRead() {
+		return nil, structs.ErrPermissionDenied
+	}
+
+	migrations := s.agent.Client().PendingMigrations()
+	if migrations == nil {
+		migrations = make([]*cstructs.AllocMigration, 0)
+	}
+	return migrations, nil
+}
+
 func (s *HTTPServer) allocGC(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	var secret string
 	s.parseToken(req, &secret)
@@ -155,8 +209,12 @@ func (s *HTTPServer) allocSnapshot(allocID string, resp http.ResponseWriter, req
 	if err != nil {
 		return nil, fmt.Errorf(allocNotFoundErr)
 	}
+
+	// The snapshot is streamed as it is archived. Errors are written into the
+	// archive so they can't be returned once it started.
+	resp.Header().Set("Content-Type", "application/x-tar")
 	if err := allocFS.Snapshot(resp); err != nil {
-		return nil, fmt.Errorf("error making snapshot: %v", err)
+		s.logger.Printf("[ERR] http: error making snapshot of alloc %q: %v", allocID, err)
 	}
 	return nil, nil
 }
@@ -181,6 +239,94 @@ func (s *HTTPServer) allocStats(allocID string, resp http.ResponseWriter, req *h
 		return nil, err
 	}
 
-	task := req.URL.Query().Get("task")
-	return aStats.LatestAllocStats(task)
+	q := req.URL.Query()
+	task := q.Get("task")
+	var follow bool
+	if followStr := q.Get("follow"); followStr != "" {
+		if follow, err = strconv.ParseBool(followStr); err != nil {
+			return nil, fmt.Errorf("Failed to parse follow field to boolean: %v", err)
+		}
+	}
+	if !follow {
+		return aStats.LatestAllocStats(task)
+	}
+
+	// Samples aren't collected more often than the collection interval
+	interval := s.agent.client.GetConfig().StatsCollectionInterval
+	if intervalStr := q.Get("interval"); intervalStr != "" {
+		d, err := time.ParseDuration(intervalStr)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse interval field to duration: %v", err)
+		}
+		if d > interval {
+			interval = d
+		}
+	}
+
+	// The first sample is taken before streaming so its errors are returned
+	// with a status code
+	usage, err := aStats.LatestAllocStats(task)
+	if err != nil {
+		return nil, err
+	}
+	s.streamAllocStats(aStats, task, usage, interval, resp, req)
+	return nil, nil
+}
+
+// streamAllocStats streams the resource usage samples of the allocation as
+// newline delimited JSON at the given interval, until the request is canceled
+// or the allocation is removed. Samples which weren't collected since the
+// last one are skipped.
+func (s *HTTPServer) streamAllocStats(aStats client.AllocStatsReporter, task string, usage *cstructs.AllocResourceUsage,
+	interval time.Duration, resp http.ResponseWriter, req *http.Request) {
+
+	resp.Header().Set("Content-Type", "application/json")
+	output := ioutils.NewWriteFlusher(resp)
+	enc := codec.NewEncoder(output, structs.JsonHandle)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var last int64
+	for {
+		if usage.Timestamp != last {
+			if err := enc.Encode(usage); err != nil {
+				// The subscriber went away
+				return
+			}
+			if _, err := output.Write([]byte("\n")); err != nil {
+				return
+			}
+			last = usage.Timestamp
+		}
+
+		select {
+		case <-req.Context().Done():
+			return
+		case <-ticker.C:
+		}
+
+		var err error
+		if usage, err = aStats.LatestAllocStats(task); err != nil {
+			s.logger.Printf("[DEBUG] http: alloc stats stream ended: %v", err)
+			return
+		}
+	}
+}
+
+func (s *HTTPServer) allocChecks(allocID string, resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var secret string
+	s.parseToken(req, &secret)
+
+	var namespace string
+	parseNamespace(req, &namespace)
+
+	// Check namespace read-job permissions
+	if aclObj, err := s.agent.Client().ResolveToken(secret); err != nil {
+		return nil, err
+	} else if aclObj != nil && !aclObj.AllowNsOp(namespace, acl.NamespaceCapabilityReadJob) {
+		return nil, structs.ErrPermissionDenied
+	}
+
+	return s.agent.Client().AllocChecks(allocID)
 }
@@ -2,6 +2,8 @@ package agent
 
 import (
 	"archive/tar"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -11,10 +13,12 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/snappy"
 	"github.com/hashicorp/nomad/acl"
 	"github.com/hashicorp/nomad/client/allocdir"
+	cstructs "github.com/hashicorp/nomad/client/structs"
 	"github.com/hashicorp/nomad/nomad"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -278,6 +282,58 @@ func TestHTTP_AllocStats(t *testing.T) {
 	})
 }
 
+// testAllocStats returns a new sample on every call but the second, and
+// cancels the stream on the fourth.
+type testAllocStats struct {
+	calls  int
+	cancel func()
+}
+
+func (a *testAllocStats) LatestAllocStats(task string) (*cstructs.AllocResourceUsage, error) {
+	a.calls++
+	if a.calls == 4 {
+		a.cancel()
+	}
+	ts := int64(a.calls)
+	if a.calls == 2 {
+		ts = 1
+	}
+	return &cstructs.AllocResourceUsage{Timestamp: ts}, nil
+}
+
+func TestHTTP_AllocStats_Follow(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	httpTest(t, nil, func(s *TestAgent) {
+		req, err := http.NewRequest("GET", "/v1/client/allocation/123/stats?follow=true", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+		req = req.WithContext(ctx)
+
+		stats := &testAllocStats{cancel: cancel}
+		usage, _ := stats.LatestAllocStats("")
+		respW := httptest.NewRecorder()
+		s.Server.streamAllocStats(stats, "", usage, 10*time.Millisecond, respW, req)
+
+		// The repeated sample is skipped
+		var timestamps []int64
+		dec := json.NewDecoder(respW.Body)
+		for {
+			var sample cstructs.AllocResourceUsage
+			if err := dec.Decode(&sample); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			timestamps = append(timestamps, sample.Timestamp)
+		}
+		assert.Equal([]int64{1, 3, 4}, timestamps)
+	})
+}
+
 func TestHTTP_AllocStats_ACL(t *testing.T) {
 	t.Parallel()
 	assert := assert.New(t)
@@ -333,6 +389,40 @@ func TestHTTP_AllocStats_ACL(t *testing.T) {
 	})
 }
 
+func TestHTTP_AllocChecks_ACL(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	httpACLTest(t, nil, func(s *TestAgent) {
+		state := s.Agent.server.State()
+
+		req, err := http.NewRequest("GET", "/v1/client/allocation/123/checks", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Try request without a token and expect failure
+		{
+			respW := httptest.NewRecorder()
+			_, err := s.Server.ClientAllocRequest(respW, req)
+			assert.NotNil(err)
+			assert.Equal(err.Error(), structs.ErrPermissionDenied.Error())
+		}
+
+		// Try request with a valid token
+		// Still returns an error because the alloc does not exist
+		{
+			respW := httptest.NewRecorder()
+			policy := mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilityReadJob})
+			token := mock.CreatePolicyAndToken(t, state, 1007, "valid", policy)
+			setToken(req, token)
+			_, err := s.Server.ClientAllocRequest(respW, req)
+			assert.NotNil(err)
+			assert.Contains(err.Error(), "unknown allocation ID")
+		}
+	})
+}
+
 func TestHTTP_AllocSnapshot(t *testing.T) {
 	t.Parallel()
 	httpTest(t, nil, func(s *TestAgent) {
@@ -583,6 +673,34 @@ func TestHTTP_AllocAllGC(t *testing.T) {
 
 }
 
+func TestHTTP_ClientMigrations(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		req, err := http.NewRequest("GET", "/v1/client/migrations", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		obj, err := s.Server.ClientMigrationsRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if migrations := obj.([]*cstructs.AllocMigration); len(migrations) != 0 {
+			t.Fatalf("unexpected migrations: %v", migrations)
+		}
+
+		// Only reads are allowed
+		req, err = http.NewRequest("PUT", "/v1/client/migrations", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if _, err := s.Server.ClientMigrationsRequest(httptest.NewRecorder(), req); err == nil {
+			t.Fatalf("expected error")
+		}
+	})
+}
+
 func TestHTTP_AllocAllGC_ACL(t *testing.T) {
 	t.Parallel()
 	assert := assert.New(t)
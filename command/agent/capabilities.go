@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"fmt"
+
+	clientconfig "github.com/hashicorp/nomad/client/config"
+	"github.com/hashicorp/nomad/helper/capabilities"
+)
+
+// capabilityRequirement is a client feature and the capabilities the agent
+// requires to provide it.
+type capabilityRequirement struct {
+	Feature string
+	Caps    []capabilities.Cap
+}
+
+// clientCapabilityRequirements returns the capabilities required by the
+// features of the client. The agent does not need to run as root as long as it
+// holds these capabilities, for example by starting it from a systemd unit
+// with them set as AmbientCapabilities. The mounts and cgroups of the tasks
+// can be delegated to systemd instead through the mount and cgroup drivers.
+func clientCapabilityRequirements(config *ClientConfig) []capabilityRequirement {
+	// The systemd mount driver delegates the mounts of the task directories
+	// to systemd, so only the syscall driver mounts them itself.
+	mounts := config.MountDriver != clientconfig.MountDriverSystemd
+	isolation := []capabilities.Cap{
+		capabilities.SysChroot,
+		capabilities.Setuid,
+		capabilities.Setgid,
+	}
+	if mounts {
+		isolation = append(isolation, capabilities.SysAdmin)
+	}
+
+	reqs := []capabilityRequirement{
+		{
+			Feature: "task isolation for the exec and java drivers",
+			Caps:    isolation,
+		},
+		{
+			Feature: "allocation directory ownership",
+			Caps: []capabilities.Cap{
+				capabilities.Chown,
+				capabilities.Fowner,
+				capabilities.DACOverride,
+			},
+		},
+		{
+			Feature: "signalling tasks run as other users",
+			Caps:    []capabilities.Cap{capabilities.Kill},
+		},
+	}
+	if mounts {
+		reqs = append(reqs, capabilityRequirement{
+			Feature: "secrets directory tmpfs mounts",
+			Caps:    []capabilities.Cap{capabilities.SysAdmin},
+		})
+	}
+
+	// The systemd cgroup driver delegates cgroup management to systemd, so
+	// only the cgroupfs driver writes to the cgroup hierarchy itself.
+	if config.CgroupDriver != clientconfig.CgroupDriverSystemd {
+		reqs = append(reqs, capabilityRequirement{
+			Feature: "task cgroups managed through cgroupfs",
+			Caps:    []capabilities.Cap{capabilities.DACOverride},
+		})
+	}
+
+	// Tasks run as dynamic users get their task directory and are started
+	// as the user whatever their driver
+	if config.Options["user.dynamic_range"] != "" {
+		reqs = append(reqs, capabilityRequirement{
+			Feature: "dynamic task users",
+			Caps: []capabilities.Cap{
+				capabilities.Chown,
+				capabilities.Setuid,
+				capabilities.Setgid,
+			},
+		})
+	}
+
+	return reqs
+}
+
+// capabilityReport checks the capabilities of the agent against those required
+// by its enabled features. It returns the lines of the report, the warnings and
+// whether any required capability is missing.
+func capabilityReport(config *Config, state *capabilities.State, euid int) (report, warnings []string, missing bool) {
+	report = append(report, fmt.Sprintf("Effective capabilities: %s", state.Effective))
+
+	if !config.Client.Enabled {
+		// Servers only need to bind their ports and write to the data dir
+		if euid == 0 {
+			warnings = append(warnings,
+				"Server agents do not require any privileges; set the server user or run the agent as an unprivileged user")
+		} else if state.Effective != 0 {
+			warnings = append(warnings,
+				"Server agents do not require any capabilities; consider dropping them")
+		}
+		return report, warnings, false
+	}
+
+	for _, req := range clientCapabilityRequirements(config.Client) {
+		absent := state.Effective.Missing(capabilities.NewSet(req.Caps...))
+		if len(absent) == 0 {
+			report = append(report, fmt.Sprintf("%s: ok", req.Feature))
+			continue
+		}
+
+		missing = true
+		warnings = append(warnings, fmt.Sprintf("%s: missing %s",
+			req.Feature, capabilities.NewSet(absent...)))
+	}
+
+	return report, warnings, missing
+}
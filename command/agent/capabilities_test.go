@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/helper/capabilities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapabilityReport_Server(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	config := DefaultConfig()
+	config.Server.Enabled = true
+
+	// Unprivileged servers have nothing to report
+	_, warnings, missing := capabilityReport(config, &capabilities.State{}, 1000)
+	assert.Empty(warnings)
+	assert.False(missing)
+
+	// Servers run as root are warned but never missing capabilities
+	_, warnings, missing = capabilityReport(config, &capabilities.State{Effective: capabilities.All}, 0)
+	assert.Len(warnings, 1)
+	assert.False(missing)
+}
+
+func TestCapabilityReport_Client(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	config := DefaultConfig()
+	config.Client.Enabled = true
+
+	// Root has every capability
+	_, warnings, missing := capabilityReport(config, &capabilities.State{Effective: capabilities.All}, 0)
+	assert.Empty(warnings)
+	assert.False(missing)
+
+	// Without CAP_KILL tasks run as other users can't be signalled
+	state := &capabilities.State{
+		Effective: capabilities.NewSet(
+			capabilities.SysAdmin,
+			capabilities.SysChroot,
+			capabilities.Setuid,
+			capabilities.Setgid,
+			capabilities.Chown,
+			capabilities.Fowner,
+			capabilities.DACOverride,
+		),
+	}
+	_, warnings, missing = capabilityReport(config, state, 1000)
+	assert.True(missing)
+	if assert.Len(warnings, 1) {
+		assert.True(strings.Contains(warnings[0], "CAP_KILL"), warnings[0])
+	}
+}
+
+func TestClientCapabilityRequirements_CgroupDriver(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	config := DefaultConfig()
+	cgroupfs := len(clientCapabilityRequirements(config.Client))
+
+	// The systemd driver delegates cgroup management
+	config.Client.CgroupDriver = "systemd"
+	assert.Equal(cgroupfs-1, len(clientCapabilityRequirements(config.Client)))
+}
+
+func TestClientCapabilityRequirements_MountDriver(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	config := DefaultConfig()
+	config.Client.CgroupDriver = "systemd"
+	config.Client.MountDriver = "systemd"
+
+	// The systemd driver delegates the mounts, so only the executor's
+	// isolation of tasks requires capabilities
+	for _, req := range clientCapabilityRequirements(config.Client) {
+		assert.NotContains(req.Caps, capabilities.SysAdmin, req.Feature)
+	}
+
+	state := &capabilities.State{
+		Effective: capabilities.NewSet(
+			capabilities.SysChroot,
+			capabilities.Setuid,
+			capabilities.Setgid,
+			capabilities.Chown,
+			capabilities.Fowner,
+			capabilities.DACOverride,
+			capabilities.Kill,
+		),
+	}
+	config.Client.Enabled = true
+	_, warnings, missing := capabilityReport(config, state, 1000)
+	assert.Empty(warnings)
+	assert.False(missing)
+}
@@ -23,6 +23,7 @@ import (
 	checkpoint "github.com/hashicorp/go-checkpoint"
 	gsyslog "github.com/hashicorp/go-syslog"
 	"github.com/hashicorp/logutils"
+	"github.com/hashicorp/nomad/helper/capabilities"
 	flaghelper "github.com/hashicorp/nomad/helper/flag-helpers"
 	gatedwriter "github.com/hashicorp/nomad/helper/gated-writer"
 	"github.com/hashicorp/nomad/nomad/structs/config"
@@ -313,9 +314,47 @@ func (c *Command) readConfig() *Config {
 		c.Ui.Error("WARNING: Bootstrap mode enabled! Potentially unsafe operation.")
 	}
 
+	// Clients need their privileges so only server agents can drop them
+	if config.Server.User != "" && config.Client.Enabled {
+		c.Ui.Error("The server user can not be set for agents running a client")
+		return nil
+	}
+
 	return config
 }
 
+// checkCapabilities reports the capabilities of the agent and whether they are
+// sufficient for the enabled features. It returns false if capabilities are
+// enforced and some are missing.
+func (c *Command) checkCapabilities(config *Config) bool {
+	enforce := config.Client.Enabled && config.Client.EnforceCapabilities
+
+	state, err := capabilities.Current()
+	if err != nil {
+		if enforce {
+			c.Ui.Error(fmt.Sprintf("Error checking agent capabilities: %v", err))
+			return false
+		}
+		c.Ui.Warn(fmt.Sprintf("WARNING: Unable to check agent capabilities: %v", err))
+		return true
+	}
+
+	report, warnings, missing := capabilityReport(config, state, os.Geteuid())
+	c.Ui.Output("Capability check:")
+	for _, line := range report {
+		c.Ui.Info(line)
+	}
+	for _, line := range warnings {
+		c.Ui.Warn(fmt.Sprintf("    WARNING: %s", line))
+	}
+
+	if missing && enforce {
+		c.Ui.Error("Agent is missing required capabilities and enforce_capabilities is set")
+		return false
+	}
+	return true
+}
+
 // setupLoggers is used to setup the logGate, logWriter, and our logOutput
 func (c *Command) setupLoggers(config *Config) (*gatedwriter.Writer, *logWriter, io.Writer) {
 	// Setup logging. First create the gated log writer, which will
@@ -452,6 +491,11 @@ func (c *Command) Run(args []string) int {
 		return 1
 	}
 
+	// Servers started as root run as their unprivileged user
+	if config.Server.User != "" && os.Geteuid() == 0 {
+		return c.runAsUser(config)
+	}
+
 	// Setup the log outputs
 	logGate, _, logOutput := c.setupLoggers(config)
 	if logGate == nil {
@@ -465,6 +509,11 @@ func (c *Command) Run(args []string) int {
 		c.Ui.Info("No configuration files loaded")
 	}
 
+	// Check the agent has the privileges its features require
+	if !c.checkCapabilities(config) {
+		return 1
+	}
+
 	// Initialize the telemetry
 	inmem, err := c.setupTelemetry(config)
 	if err != nil {
@@ -576,6 +625,12 @@ WAIT:
 		return 1
 	}
 
+	// Give the client time to drain its allocations
+	timeout := gracefulTimeout
+	if client := c.agent.Client(); client != nil && c.agent.GetConfig().Client.DrainOnLeave {
+		timeout += client.DrainDeadline()
+	}
+
 	// Attempt a graceful leave
 	gracefulCh := make(chan struct{})
 	c.Ui.Output("Gracefully shutting down agent...")
@@ -591,7 +646,7 @@ WAIT:
 	select {
 	case <-signalCh:
 		return 1
-	case <-time.After(gracefulTimeout):
+	case <-time.After(timeout):
 		return 1
 	case <-gracefulCh:
 		return 0
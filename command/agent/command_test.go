@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -25,6 +26,11 @@ func TestCommand_Args(t *testing.T) {
 	}
 	defer os.RemoveAll(tmpDir)
 
+	serverUserConfig := filepath.Join(tmpDir, "server_user.hcl")
+	if err := ioutil.WriteFile(serverUserConfig, []byte(`server { user = "nomad" }`), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
 	type tcase struct {
 		args   []string
 		errOut string
@@ -50,6 +56,10 @@ func TestCommand_Args(t *testing.T) {
 			[]string{"-client", "-alloc-dir="},
 			"Must specify both the state and alloc dir if data-dir is omitted.",
 		},
+		{
+			[]string{"-client", "-server", "-data-dir=" + tmpDir, "-config=" + serverUserConfig},
+			"The server user can not be set for agents running a client",
+		},
 	}
 	for _, tc := range tcases {
 		// Make a new command. We pre-emptively close the shutdownCh
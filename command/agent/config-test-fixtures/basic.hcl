@@ -60,6 +60,35 @@ client {
     gc_inode_usage_threshold = 91
     gc_max_allocs = 50
     no_host_uuid = false
+    cgroup_driver = "systemd"
+    cgroup_slice = "batch.slice"
+    mount_driver = "systemd"
+    enforce_capabilities = true
+    workload_api = true
+    artifact_cache_max_mb = 2048
+    gc_policy {
+        namespace = "batch"
+        max_priority = 30
+        max_age = "1h"
+        disk_usage_threshold = 60
+    }
+    drain_on_leave = true
+    drain_deadline = "10m"
+    rpc_compression = true
+    fingerprint_plugin "fpga" {
+        command = "/usr/local/bin/nomad-fpga-fingerprint"
+        args = ["-v"]
+        interval = "1m"
+        config {
+            vendor = "xilinx"
+        }
+    }
+    external_dns {
+        provider = "coredns"
+        ttl = "30s"
+        endpoints = ["http://127.0.0.1:2379"]
+        prefix = "/dns"
+    }
 }
 server {
 	enabled = true
@@ -77,6 +106,13 @@ server {
 	heartbeat_grace   = "30s"
 	min_heartbeat_ttl = "33s"
 	max_heartbeats_per_second = 11.0
+	rpc_read_rate_limit = 100.5
+	rpc_read_rate_burst = 200
+	rpc_write_rate_limit = 10
+	rpc_write_rate_burst = 20
+	event_buffer_size = 500
+	event_disk_buffer_size = 5000
+	max_job_size = 0
 	retry_join = [ "1.1.1.1", "2.2.2.2" ]
 	start_join = [ "1.1.1.1", "2.2.2.2" ]
 	retry_max = 3
@@ -86,6 +122,23 @@ server {
 	redundancy_zone = "foo"
 	upgrade_version = "0.8.0"
 	encrypt = "abc"
+	user = "nomad"
+	scorer "energy" {
+		weight = 2.5
+		options {
+			max_watts = "400"
+		}
+	}
+	node_hook "cmdb" {
+		events = ["registered", "purged"]
+		webhook = "https://cmdb.example.com/nomad"
+		headers {
+			Authorization = "Bearer abc"
+		}
+		retries = 5
+		retry_interval = "5s"
+		timeout = "10s"
+	}
 }
 acl {
     enabled = true
@@ -103,6 +156,8 @@ telemetry {
     publish_node_metrics = true
     disable_tagged_metrics = true
     backwards_compatible_metrics = true
+    namespace_labels = true
+    node_class_labels = true
 }
 leave_on_interrupt = true
 leave_on_terminate = true
@@ -172,3 +227,19 @@ autopilot {
     server_stabilization_time = "23057s"
     enable_custom_upgrades = true
 }
+state_export {
+    enabled = true
+    tables = ["jobs", "allocs"]
+    interval = "15s"
+    sink = "s3"
+    bucket = "nomad-state"
+    prefix = "prod/"
+    region = "us-east-1"
+}
+alloc_archive {
+    enabled = true
+    store = "s3"
+    bucket = "nomad-archive"
+    prefix = "prod/"
+    region = "us-east-1"
+}
@@ -8,6 +8,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"sort"
 	"strconv"
@@ -133,6 +134,14 @@ type Config struct {
 
 	// Autopilot contains the configuration for Autopilot behavior.
 	Autopilot *config.AutopilotConfig `mapstructure:"autopilot"`
+
+	// StateExport configures the export of the cluster state to external
+	// storage for analytics.
+	StateExport *config.StateExportConfig `mapstructure:"state_export"`
+
+	// AllocArchive configures the archive terminal allocations are written
+	// to before they are garbage collected.
+	AllocArchive *config.AllocArchiveConfig `mapstructure:"alloc_archive"`
 }
 
 // ClientConfig is configuration specific to the client mode
@@ -214,6 +223,62 @@ type ClientConfig struct {
 	// NoHostUUID disables using the host's UUID and will force generation of a
 	// random UUID.
 	NoHostUUID *bool `mapstructure:"no_host_uuid"`
+
+	// CgroupDriver selects how task cgroups are managed, either "cgroupfs"
+	// or "systemd".
+	CgroupDriver string `mapstructure:"cgroup_driver"`
+
+	// CgroupSlice is the systemd slice task cgroups are created under when
+	// using the systemd cgroup driver.
+	CgroupSlice string `mapstructure:"cgroup_slice"`
+
+	// MountDriver selects how the directories of the tasks are mounted,
+	// either "syscall" or "systemd".
+	MountDriver string `mapstructure:"mount_driver"`
+
+	// EnforceCapabilities fails agent startup if the agent is missing any of
+	// the capabilities required by the client's features.
+	EnforceCapabilities bool `mapstructure:"enforce_capabilities"`
+
+	// WorkloadAPI serves the workload API socket in the allocation
+	// directories, giving tasks read access to their namespace.
+	WorkloadAPI bool `mapstructure:"workload_api"`
+
+	// ArtifactCacheMaxMB is the maximum size of the cache of the artifacts
+	// downloaded by the tasks. A negative value disables the cache.
+	ArtifactCacheMaxMB int `mapstructure:"artifact_cache_max_mb"`
+
+	// GCPolicies collect the terminal allocations of the jobs they match
+	// more eagerly than the GC thresholds.
+	GCPolicies []*GCPolicy `mapstructure:"gc_policy"`
+
+	// DrainOnLeave drains the node when the agent gracefully leaves, waiting
+	// for its allocations to be migrated or stopped before exiting.
+	DrainOnLeave bool `mapstructure:"drain_on_leave"`
+
+	// DrainDeadline is how long the client waits for its allocations to stop
+	// when draining on leave.
+	DrainDeadline time.Duration `mapstructure:"drain_deadline"`
+
+	// RPCCompression compresses the RPC connections to the servers, which
+	// must support it.
+	RPCCompression bool `mapstructure:"rpc_compression"`
+
+	// FingerprintPlugins are the external fingerprinters run by the client
+	FingerprintPlugins []*config.FingerprintPluginConfig `mapstructure:"-"`
+
+	// ExternalDNS configures the registration of the allocations with an
+	// external DNS provider
+	ExternalDNS *config.ExternalDNSConfig `mapstructure:"-"`
+}
+
+// GCPolicy configures the garbage collection of the terminal allocations of
+// the jobs of a namespace or up to a priority.
+type GCPolicy struct {
+	Namespace          string        `mapstructure:"namespace"`
+	MaxPriority        int           `mapstructure:"max_priority"`
+	MaxAge             time.Duration `mapstructure:"max_age"`
+	DiskUsageThreshold float64       `mapstructure:"disk_usage_threshold"`
 }
 
 // ACLConfig is configuration specific to the ACL system
@@ -272,6 +337,14 @@ type ServerConfig struct {
 	// that the workers dequeue for processing.
 	EnabledSchedulers []string `mapstructure:"enabled_schedulers"`
 
+	// Scorers enables scorer extensions compiled into the binary to rank
+	// nodes when placing allocations.
+	Scorers []*config.ScorerConfig `mapstructure:"-"`
+
+	// NodeHooks are fired by the leader on node lifecycle transitions to call
+	// webhooks or run scripts.
+	NodeHooks []*config.NodeHookConfig `mapstructure:"-"`
+
 	// NodeGCThreshold controls how "old" a node must be to be collected by GC.
 	// Age is not the only requirement for a node to be GCed but the threshold
 	// can be used to filter by age.
@@ -305,6 +378,50 @@ type ServerConfig struct {
 	// to meet the target rate.
 	MaxHeartbeatsPerSecond float64 `mapstructure:"max_heartbeats_per_second"`
 
+	// StateHistoryRetention is how long snapshots of the state are retained
+	// to serve reads as of a past index. Zero disables the state history.
+	StateHistoryRetention time.Duration `mapstructure:"state_history_retention"`
+
+	// StateHistoryInterval is how often the state is snapshotted into the
+	// state history.
+	StateHistoryInterval time.Duration `mapstructure:"state_history_interval"`
+
+	// EventBufferSize is the number of the most recent events retained for
+	// event stream subscribers.
+	EventBufferSize int `mapstructure:"event_buffer_size"`
+
+	// EventDiskBufferSize is the number of events retained on disk for event
+	// stream subscribers resuming from events evicted from memory.
+	EventDiskBufferSize int `mapstructure:"event_disk_buffer_size"`
+
+	// RPCReadRateLimit is the maximum rate, in requests per second, of read
+	// RPCs made with a single ACL token. Zero disables the limit.
+	RPCReadRateLimit float64 `mapstructure:"rpc_read_rate_limit"`
+
+	// RPCReadRateBurst is the number of read requests a token may burst above
+	// its rate.
+	RPCReadRateBurst int `mapstructure:"rpc_read_rate_burst"`
+
+	// RPCWriteRateLimit is the maximum rate, in requests per second, of write
+	// RPCs made with a single ACL token. Zero disables the limit.
+	RPCWriteRateLimit float64 `mapstructure:"rpc_write_rate_limit"`
+
+	// RPCWriteRateBurst is the number of write requests a token may burst
+	// above its rate.
+	RPCWriteRateBurst int `mapstructure:"rpc_write_rate_burst"`
+
+	// MaxJobSize is the maximum encoded size in bytes of a job that may be
+	// submitted. Zero disables the check, while nil keeps the default.
+	MaxJobSize *int `mapstructure:"max_job_size"`
+
+	// MaxDispatchPayloadSize is the maximum size in bytes of a dispatch
+	// payload. Zero disables the check, while nil keeps the default.
+	MaxDispatchPayloadSize *int `mapstructure:"max_dispatch_payload_size"`
+
+	// MaxTemplateSize is the maximum size in bytes of a template's embedded
+	// content. Zero disables the check, while nil keeps the default.
+	MaxTemplateSize *int `mapstructure:"max_template_size"`
+
 	// StartJoin is a list of addresses to attempt to join when the
 	// agent starts. If Serf is unable to communicate with any of these
 	// addresses, then the agent will error and exit.
@@ -343,6 +460,9 @@ type ServerConfig struct {
 
 	// Encryption key to use for the Serf communication
 	EncryptKey string `mapstructure:"encrypt" json:"-"`
+
+	// User is the unprivileged user server agents started as root run as.
+	User string `mapstructure:"user"`
 }
 
 // EncryptBytes returns the encryption key configured.
@@ -372,6 +492,14 @@ type Telemetry struct {
 	// key/value structure as done in older versions of Nomad
 	BackwardsCompatibleMetrics bool `mapstructure:"backwards_compatible_metrics"`
 
+	// NamespaceLabels adds the namespace of the job as a label of the
+	// allocation and job summary metrics
+	NamespaceLabels bool `mapstructure:"namespace_labels"`
+
+	// NodeClassLabels adds the class of the node as a label of the
+	// allocation metrics
+	NodeClassLabels bool `mapstructure:"node_class_labels"`
+
 	// Circonus: see https://github.com/circonus-labs/circonus-gometrics
 	// for more details on the various configuration options.
 	// Valid configuration combinations:
@@ -598,6 +726,7 @@ func DefaultConfig() *Config {
 			GCInodeUsageThreshold: 70,
 			GCMaxAllocs:           50,
 			NoHostUUID:            helper.BoolToPtr(true),
+			DrainDeadline:         5 * time.Minute,
 		},
 		Server: &ServerConfig{
 			Enabled:          false,
@@ -616,10 +745,12 @@ func DefaultConfig() *Config {
 			CollectionInterval: "1s",
 			collectionInterval: 1 * time.Second,
 		},
-		TLSConfig: &config.TLSConfig{},
-		Sentinel:  &config.SentinelConfig{},
-		Version:   version.GetVersion(),
-		Autopilot: config.DefaultAutopilotConfig(),
+		TLSConfig:    &config.TLSConfig{},
+		Sentinel:     &config.SentinelConfig{},
+		Version:      version.GetVersion(),
+		Autopilot:    config.DefaultAutopilotConfig(),
+		StateExport:  config.DefaultStateExportConfig(),
+		AllocArchive: config.DefaultAllocArchiveConfig(),
 	}
 }
 
@@ -785,6 +916,20 @@ func (c *Config) Merge(b *Config) *Config {
 		result.Autopilot = result.Autopilot.Merge(b.Autopilot)
 	}
 
+	if result.StateExport == nil && b.StateExport != nil {
+		stateExport := *b.StateExport
+		result.StateExport = &stateExport
+	} else if b.StateExport != nil {
+		result.StateExport = result.StateExport.Merge(b.StateExport)
+	}
+
+	if result.AllocArchive == nil && b.AllocArchive != nil {
+		allocArchive := *b.AllocArchive
+		result.AllocArchive = &allocArchive
+	} else if b.AllocArchive != nil {
+		result.AllocArchive = result.AllocArchive.Merge(b.AllocArchive)
+	}
+
 	// Merge config files lists
 	result.Files = append(result.Files, b.Files...)
 
@@ -914,10 +1059,10 @@ func normalizeAdvertise(addr string, bind string, defport int, dev bool) (string
 			}
 
 			// missing port, append the default
-			return net.JoinHostPort(addr, strconv.Itoa(defport)), nil
+			return net.JoinHostPort(helper.NormalizeIP(addr), strconv.Itoa(defport)), nil
 		}
 
-		return addr, nil
+		return helper.NormalizeAddr(addr), nil
 	}
 
 	// Fallback to bind address first, and then try resolving the local hostname
@@ -1029,6 +1174,39 @@ func (a *ServerConfig) Merge(b *ServerConfig) *ServerConfig {
 	if b.MaxHeartbeatsPerSecond != 0.0 {
 		result.MaxHeartbeatsPerSecond = b.MaxHeartbeatsPerSecond
 	}
+	if b.StateHistoryRetention != 0 {
+		result.StateHistoryRetention = b.StateHistoryRetention
+	}
+	if b.StateHistoryInterval != 0 {
+		result.StateHistoryInterval = b.StateHistoryInterval
+	}
+	if b.EventBufferSize != 0 {
+		result.EventBufferSize = b.EventBufferSize
+	}
+	if b.EventDiskBufferSize != 0 {
+		result.EventDiskBufferSize = b.EventDiskBufferSize
+	}
+	if b.RPCReadRateLimit != 0 {
+		result.RPCReadRateLimit = b.RPCReadRateLimit
+	}
+	if b.RPCReadRateBurst != 0 {
+		result.RPCReadRateBurst = b.RPCReadRateBurst
+	}
+	if b.RPCWriteRateLimit != 0 {
+		result.RPCWriteRateLimit = b.RPCWriteRateLimit
+	}
+	if b.RPCWriteRateBurst != 0 {
+		result.RPCWriteRateBurst = b.RPCWriteRateBurst
+	}
+	if b.MaxJobSize != nil {
+		result.MaxJobSize = helper.IntToPtr(*b.MaxJobSize)
+	}
+	if b.MaxDispatchPayloadSize != nil {
+		result.MaxDispatchPayloadSize = helper.IntToPtr(*b.MaxDispatchPayloadSize)
+	}
+	if b.MaxTemplateSize != nil {
+		result.MaxTemplateSize = helper.IntToPtr(*b.MaxTemplateSize)
+	}
 	if b.RetryMaxAttempts != 0 {
 		result.RetryMaxAttempts = b.RetryMaxAttempts
 	}
@@ -1051,10 +1229,57 @@ func (a *ServerConfig) Merge(b *ServerConfig) *ServerConfig {
 	if b.EncryptKey != "" {
 		result.EncryptKey = b.EncryptKey
 	}
+	if b.User != "" {
+		result.User = b.User
+	}
 
 	// Add the schedulers
 	result.EnabledSchedulers = append(result.EnabledSchedulers, b.EnabledSchedulers...)
 
+	// Merge the scorers, replacing the configuration of scorers enabled in
+	// both
+	if len(b.Scorers) != 0 {
+		scorers := make([]*config.ScorerConfig, 0, len(a.Scorers)+len(b.Scorers))
+		for _, s := range a.Scorers {
+			replaced := false
+			for _, o := range b.Scorers {
+				if o.Name == s.Name {
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				scorers = append(scorers, s)
+			}
+		}
+		for _, s := range b.Scorers {
+			scorers = append(scorers, s.Copy())
+		}
+		result.Scorers = scorers
+	}
+
+	// Merge the node hooks, replacing the configuration of hooks defined in
+	// both
+	if len(b.NodeHooks) != 0 {
+		hooks := make([]*config.NodeHookConfig, 0, len(a.NodeHooks)+len(b.NodeHooks))
+		for _, h := range a.NodeHooks {
+			replaced := false
+			for _, o := range b.NodeHooks {
+				if o.Name == h.Name {
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				hooks = append(hooks, h)
+			}
+		}
+		for _, h := range b.NodeHooks {
+			hooks = append(hooks, h.Copy())
+		}
+		result.NodeHooks = hooks
+	}
+
 	// Copy the start join addresses
 	result.StartJoin = make([]string, 0, len(a.StartJoin)+len(b.StartJoin))
 	result.StartJoin = append(result.StartJoin, a.StartJoin...)
@@ -1068,6 +1293,43 @@ func (a *ServerConfig) Merge(b *ServerConfig) *ServerConfig {
 	return &result
 }
 
+// artifactOCIAuthConfigOption is the client option naming the Docker config
+// file holding the credentials of OCI registries, which is reloadable.
+const artifactOCIAuthConfigOption = "artifact.oci.auth.config"
+
+// reloadableEqual returns whether the settings of two client configs which
+// are reloaded without restarting the client are equal.
+func (a *ClientConfig) reloadableEqual(b *ClientConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return reflect.DeepEqual(a.ChrootEnv, b.ChrootEnv) &&
+		reflect.DeepEqual(a.Reserved, b.Reserved) &&
+		a.MaxKillTimeout == b.MaxKillTimeout &&
+		a.ArtifactCacheMaxMB == b.ArtifactCacheMaxMB &&
+		a.Options[artifactOCIAuthConfigOption] == b.Options[artifactOCIAuthConfigOption]
+}
+
+// applyReloadable sets the settings of the client config which are reloaded
+// without restarting the client to those of b.
+func (a *ClientConfig) applyReloadable(b *ClientConfig) {
+	a.ChrootEnv = helper.CopyMapStringString(b.ChrootEnv)
+	a.Reserved = b.Reserved
+	a.MaxKillTimeout = b.MaxKillTimeout
+	a.ArtifactCacheMaxMB = b.ArtifactCacheMaxMB
+
+	options := helper.CopyMapStringString(a.Options)
+	if options == nil {
+		options = make(map[string]string)
+	}
+	if v, ok := b.Options[artifactOCIAuthConfigOption]; ok {
+		options[artifactOCIAuthConfigOption] = v
+	} else {
+		delete(options, artifactOCIAuthConfigOption)
+	}
+	a.Options = options
+}
+
 // Merge is used to merge two client configs together
 func (a *ClientConfig) Merge(b *ClientConfig) *ClientConfig {
 	result := *a
@@ -1127,6 +1389,61 @@ func (a *ClientConfig) Merge(b *ClientConfig) *ClientConfig {
 	if b.NoHostUUID != nil {
 		result.NoHostUUID = b.NoHostUUID
 	}
+	if b.CgroupDriver != "" {
+		result.CgroupDriver = b.CgroupDriver
+	}
+	if b.CgroupSlice != "" {
+		result.CgroupSlice = b.CgroupSlice
+	}
+	if b.MountDriver != "" {
+		result.MountDriver = b.MountDriver
+	}
+	if b.EnforceCapabilities {
+		result.EnforceCapabilities = true
+	}
+	if b.WorkloadAPI {
+		result.WorkloadAPI = true
+	}
+	if b.ArtifactCacheMaxMB != 0 {
+		result.ArtifactCacheMaxMB = b.ArtifactCacheMaxMB
+	}
+	if len(b.GCPolicies) != 0 {
+		result.GCPolicies = b.GCPolicies
+	}
+	if b.DrainOnLeave {
+		result.DrainOnLeave = true
+	}
+	if b.DrainDeadline != 0 {
+		result.DrainDeadline = b.DrainDeadline
+	}
+	if b.RPCCompression {
+		result.RPCCompression = true
+	}
+
+	// Merge the fingerprinter plugins, replacing the configuration of plugins
+	// defined in both
+	if len(b.FingerprintPlugins) != 0 {
+		plugins := make([]*config.FingerprintPluginConfig, 0, len(a.FingerprintPlugins)+len(b.FingerprintPlugins))
+		for _, p := range a.FingerprintPlugins {
+			replaced := false
+			for _, o := range b.FingerprintPlugins {
+				if o.Name == p.Name {
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				plugins = append(plugins, p)
+			}
+		}
+		for _, p := range b.FingerprintPlugins {
+			plugins = append(plugins, p.Copy())
+		}
+		result.FingerprintPlugins = plugins
+	}
+	if b.ExternalDNS != nil {
+		result.ExternalDNS = b.ExternalDNS.Copy()
+	}
 
 	// Add the servers
 	result.Servers = append(result.Servers, b.Servers...)
@@ -1244,6 +1561,14 @@ func (a *Telemetry) Merge(b *Telemetry) *Telemetry {
 		result.BackwardsCompatibleMetrics = b.BackwardsCompatibleMetrics
 	}
 
+	if b.NamespaceLabels {
+		result.NamespaceLabels = true
+	}
+
+	if b.NodeClassLabels {
+		result.NodeClassLabels = true
+	}
+
 	return &result
 }
 
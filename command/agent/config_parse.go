@@ -100,6 +100,8 @@ func parseConfig(result *Config, list *ast.ObjectList) error {
 		"acl",
 		"sentinel",
 		"autopilot",
+		"state_export",
+		"alloc_archive",
 	}
 	if err := helper.CheckHCLKeys(list, valid); err != nil {
 		return multierror.Prefix(err, "config:")
@@ -124,6 +126,8 @@ func parseConfig(result *Config, list *ast.ObjectList) error {
 	delete(m, "acl")
 	delete(m, "sentinel")
 	delete(m, "autopilot")
+	delete(m, "state_export")
+	delete(m, "alloc_archive")
 
 	// Decode the rest
 	if err := mapstructure.WeakDecode(m, result); err != nil {
@@ -214,6 +218,20 @@ func parseConfig(result *Config, list *ast.ObjectList) error {
 		}
 	}
 
+	// Parse state export config
+	if o := list.Filter("state_export"); len(o.Items) > 0 {
+		if err := parseStateExport(&result.StateExport, o); err != nil {
+			return multierror.Prefix(err, "state_export->")
+		}
+	}
+
+	// Parse allocation archive config
+	if o := list.Filter("alloc_archive"); len(o.Items) > 0 {
+		if err := parseAllocArchive(&result.AllocArchive, o); err != nil {
+			return multierror.Prefix(err, "alloc_archive->")
+		}
+	}
+
 	// Parse out http_api_response_headers fields. These are in HCL as a list so
 	// we need to iterate over them and merge them.
 	if headersO := list.Filter("http_api_response_headers"); len(headersO.Items) > 0 {
@@ -368,6 +386,18 @@ func parseClient(result **ClientConfig, list *ast.ObjectList) error {
 		"gc_parallel_destroys",
 		"gc_max_allocs",
 		"no_host_uuid",
+		"cgroup_driver",
+		"cgroup_slice",
+		"mount_driver",
+		"enforce_capabilities",
+		"workload_api",
+		"artifact_cache_max_mb",
+		"gc_policy",
+		"drain_on_leave",
+		"drain_deadline",
+		"rpc_compression",
+		"fingerprint_plugin",
+		"external_dns",
 	}
 	if err := helper.CheckHCLKeys(listVal, valid); err != nil {
 		return err
@@ -383,6 +413,9 @@ func parseClient(result **ClientConfig, list *ast.ObjectList) error {
 	delete(m, "chroot_env")
 	delete(m, "reserved")
 	delete(m, "stats")
+	delete(m, "gc_policy")
+	delete(m, "fingerprint_plugin")
+	delete(m, "external_dns")
 
 	var config ClientConfig
 	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
@@ -446,10 +479,178 @@ func parseClient(result **ClientConfig, list *ast.ObjectList) error {
 		}
 	}
 
+	// Parse the GC policies
+	if o := listVal.Filter("gc_policy"); len(o.Items) > 0 {
+		if err := parseGCPolicies(&config.GCPolicies, o); err != nil {
+			return multierror.Prefix(err, "gc_policy ->")
+		}
+	}
+
+	// Parse the fingerprinter plugins
+	if o := listVal.Filter("fingerprint_plugin"); len(o.Items) > 0 {
+		if err := parseFingerprintPlugins(&config.FingerprintPlugins, o); err != nil {
+			return multierror.Prefix(err, "fingerprint_plugin ->")
+		}
+	}
+
+	// Parse the external DNS config
+	if o := listVal.Filter("external_dns"); len(o.Items) > 0 {
+		if err := parseExternalDNS(&config.ExternalDNS, o); err != nil {
+			return multierror.Prefix(err, "external_dns ->")
+		}
+	}
+
 	*result = &config
 	return nil
 }
 
+func parseGCPolicies(result *[]*GCPolicy, list *ast.ObjectList) error {
+	for _, obj := range list.Elem().Items {
+		// Check for invalid keys
+		valid := []string{
+			"namespace",
+			"max_priority",
+			"max_age",
+			"disk_usage_threshold",
+		}
+		if err := helper.CheckHCLKeys(obj.Val, valid); err != nil {
+			return err
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, obj.Val); err != nil {
+			return err
+		}
+
+		var policy GCPolicy
+		dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+			WeaklyTypedInput: true,
+			Result:           &policy,
+		})
+		if err != nil {
+			return err
+		}
+		if err := dec.Decode(m); err != nil {
+			return err
+		}
+		*result = append(*result, &policy)
+	}
+	return nil
+}
+
+// parseFingerprintPlugins parses the named fingerprint_plugin blocks of the
+// client stanza
+func parseFingerprintPlugins(result *[]*config.FingerprintPluginConfig, list *ast.ObjectList) error {
+	for _, o := range list.Items {
+		if len(o.Keys) != 1 {
+			return fmt.Errorf("fingerprint_plugin block must be named")
+		}
+		name := o.Keys[0].Token.Value().(string)
+
+		// Check for invalid keys
+		valid := []string{
+			"command",
+			"args",
+			"interval",
+			"config",
+		}
+		if err := helper.CheckHCLKeys(o.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("%s ->", name))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+		delete(m, "config")
+
+		plugin := &config.FingerprintPluginConfig{Name: name}
+		dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+			WeaklyTypedInput: true,
+			Result:           plugin,
+		})
+		if err != nil {
+			return err
+		}
+		if err := dec.Decode(m); err != nil {
+			return err
+		}
+
+		// Parse out the config. These are in HCL as a list so we need to
+		// iterate over them and merge them.
+		if ot, ok := o.Val.(*ast.ObjectType); ok {
+			if configO := ot.List.Filter("config"); len(configO.Items) > 0 {
+				for _, co := range configO.Elem().Items {
+					var cm map[string]interface{}
+					if err := hcl.DecodeObject(&cm, co.Val); err != nil {
+						return err
+					}
+					if err := mapstructure.WeakDecode(cm, &plugin.Config); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		*result = append(*result, plugin)
+	}
+	return nil
+}
+
+// parseExternalDNS parses the external_dns block of the client stanza
+func parseExternalDNS(result **config.ExternalDNSConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'external_dns' block allowed")
+	}
+
+	// Get our external_dns object
+	obj := list.Items[0]
+
+	// Value should be an object
+	var listVal *ast.ObjectList
+	if ot, ok := obj.Val.(*ast.ObjectType); ok {
+		listVal = ot.List
+	} else {
+		return fmt.Errorf("external_dns value: should be an object")
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"provider",
+		"ttl",
+		"hosted_zone_id",
+		"endpoints",
+		"prefix",
+	}
+	if err := helper.CheckHCLKeys(listVal, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, listVal); err != nil {
+		return err
+	}
+
+	var externalDNS config.ExternalDNSConfig
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &externalDNS,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return err
+	}
+
+	*result = &externalDNS
+	return nil
+}
+
 func parseReserved(result **Resources, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
@@ -529,6 +730,17 @@ func parseServer(result **ServerConfig, list *ast.ObjectList) error {
 		"heartbeat_grace",
 		"min_heartbeat_ttl",
 		"max_heartbeats_per_second",
+		"max_job_size",
+		"state_history_retention",
+		"state_history_interval",
+		"rpc_read_rate_limit",
+		"rpc_read_rate_burst",
+		"rpc_write_rate_limit",
+		"rpc_write_rate_burst",
+		"event_buffer_size",
+		"event_disk_buffer_size",
+		"max_dispatch_payload_size",
+		"max_template_size",
 		"start_join",
 		"retry_join",
 		"retry_max",
@@ -539,6 +751,9 @@ func parseServer(result **ServerConfig, list *ast.ObjectList) error {
 		"non_voting_server",
 		"redundancy_zone",
 		"upgrade_version",
+		"user",
+		"scorer",
+		"node_hook",
 	}
 	if err := helper.CheckHCLKeys(listVal, valid); err != nil {
 		return err
@@ -548,6 +763,8 @@ func parseServer(result **ServerConfig, list *ast.ObjectList) error {
 	if err := hcl.DecodeObject(&m, listVal); err != nil {
 		return err
 	}
+	delete(m, "scorer")
+	delete(m, "node_hook")
 
 	var config ServerConfig
 	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
@@ -568,10 +785,136 @@ func parseServer(result **ServerConfig, list *ast.ObjectList) error {
 		}
 	}
 
+	// Parse the scorers
+	if o := listVal.Filter("scorer"); len(o.Items) > 0 {
+		if err := parseScorers(&config.Scorers, o); err != nil {
+			return multierror.Prefix(err, "scorer ->")
+		}
+	}
+
+	// Parse the node hooks
+	if o := listVal.Filter("node_hook"); len(o.Items) > 0 {
+		if err := parseNodeHooks(&config.NodeHooks, o); err != nil {
+			return multierror.Prefix(err, "node_hook ->")
+		}
+	}
+
 	*result = &config
 	return nil
 }
 
+// parseScorers parses the named scorer blocks of the server stanza
+func parseScorers(result *[]*config.ScorerConfig, list *ast.ObjectList) error {
+	for _, o := range list.Items {
+		if len(o.Keys) != 1 {
+			return fmt.Errorf("scorer block must be named")
+		}
+		name := o.Keys[0].Token.Value().(string)
+
+		// Check for invalid keys
+		valid := []string{
+			"weight",
+			"options",
+		}
+		if err := helper.CheckHCLKeys(o.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("%s ->", name))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+		delete(m, "options")
+
+		scorer := &config.ScorerConfig{Name: name}
+		if err := mapstructure.WeakDecode(m, scorer); err != nil {
+			return err
+		}
+
+		// Parse out the options. These are in HCL as a list so we need to
+		// iterate over them and merge them.
+		if ot, ok := o.Val.(*ast.ObjectType); ok {
+			if optionsO := ot.List.Filter("options"); len(optionsO.Items) > 0 {
+				for _, oo := range optionsO.Elem().Items {
+					var om map[string]interface{}
+					if err := hcl.DecodeObject(&om, oo.Val); err != nil {
+						return err
+					}
+					if err := mapstructure.WeakDecode(om, &scorer.Options); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		*result = append(*result, scorer)
+	}
+	return nil
+}
+
+// parseNodeHooks parses the named node_hook blocks of the server stanza
+func parseNodeHooks(result *[]*config.NodeHookConfig, list *ast.ObjectList) error {
+	for _, o := range list.Items {
+		if len(o.Keys) != 1 {
+			return fmt.Errorf("node_hook block must be named")
+		}
+		name := o.Keys[0].Token.Value().(string)
+
+		// Check for invalid keys
+		valid := []string{
+			"events",
+			"webhook",
+			"headers",
+			"script",
+			"args",
+			"retries",
+			"retry_interval",
+			"timeout",
+		}
+		if err := helper.CheckHCLKeys(o.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("%s ->", name))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+		delete(m, "headers")
+
+		hook := &config.NodeHookConfig{Name: name}
+		dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+			WeaklyTypedInput: true,
+			Result:           hook,
+		})
+		if err != nil {
+			return err
+		}
+		if err := dec.Decode(m); err != nil {
+			return err
+		}
+
+		// Parse out the headers. These are in HCL as a list so we need to
+		// iterate over them and merge them.
+		if ot, ok := o.Val.(*ast.ObjectType); ok {
+			if headersO := ot.List.Filter("headers"); len(headersO.Items) > 0 {
+				for _, ho := range headersO.Elem().Items {
+					var hm map[string]interface{}
+					if err := hcl.DecodeObject(&hm, ho.Val); err != nil {
+						return err
+					}
+					if err := mapstructure.WeakDecode(hm, &hook.Headers); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		*result = append(*result, hook)
+	}
+	return nil
+}
+
 func parseACL(result **ACLConfig, list *ast.ObjectList) error {
 	list = list.Elem()
 	if len(list.Items) > 1 {
@@ -658,6 +1001,8 @@ func parseTelemetry(result **Telemetry, list *ast.ObjectList) error {
 		"circonus_broker_select_tag",
 		"disable_tagged_metrics",
 		"backwards_compatible_metrics",
+		"namespace_labels",
+		"node_class_labels",
 	}
 	if err := helper.CheckHCLKeys(listVal, valid); err != nil {
 		return err
@@ -905,3 +1250,96 @@ func parseAutopilot(result **config.AutopilotConfig, list *ast.ObjectList) error
 	*result = autopilotConfig
 	return nil
 }
+
+func parseStateExport(result **config.StateExportConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'state_export' block allowed")
+	}
+
+	// Get our state export object
+	listVal := list.Items[0].Val
+
+	// Check for invalid keys
+	valid := []string{
+		"enabled",
+		"tables",
+		"interval",
+		"sink",
+		"path",
+		"bucket",
+		"prefix",
+		"region",
+		"endpoint",
+	}
+
+	if err := helper.CheckHCLKeys(listVal, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, listVal); err != nil {
+		return err
+	}
+
+	var stateExportConfig config.StateExportConfig
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &stateExportConfig,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return err
+	}
+
+	*result = &stateExportConfig
+	return nil
+}
+
+func parseAllocArchive(result **config.AllocArchiveConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'alloc_archive' block allowed")
+	}
+
+	// Get our allocation archive object
+	listVal := list.Items[0].Val
+
+	// Check for invalid keys
+	valid := []string{
+		"enabled",
+		"store",
+		"path",
+		"bucket",
+		"prefix",
+		"region",
+		"endpoint",
+	}
+
+	if err := helper.CheckHCLKeys(listVal, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, listVal); err != nil {
+		return err
+	}
+
+	var allocArchiveConfig config.AllocArchiveConfig
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		WeaklyTypedInput: true,
+		Result:           &allocArchiveConfig,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return err
+	}
+
+	*result = &allocArchiveConfig
+	return nil
+}
@@ -81,6 +81,38 @@ func TestConfig_Parse(t *testing.T) {
 					GCInodeUsageThreshold: 91,
 					GCMaxAllocs:           50,
 					NoHostUUID:            helper.BoolToPtr(false),
+					CgroupDriver:          "systemd",
+					CgroupSlice:           "batch.slice",
+					MountDriver:           "systemd",
+					EnforceCapabilities:   true,
+					WorkloadAPI:           true,
+					ArtifactCacheMaxMB:    2048,
+					GCPolicies: []*GCPolicy{
+						{
+							Namespace:          "batch",
+							MaxPriority:        30,
+							MaxAge:             time.Hour,
+							DiskUsageThreshold: 60,
+						},
+					},
+					DrainOnLeave:   true,
+					DrainDeadline:  10 * time.Minute,
+					RPCCompression: true,
+					FingerprintPlugins: []*config.FingerprintPluginConfig{
+						{
+							Name:     "fpga",
+							Command:  "/usr/local/bin/nomad-fpga-fingerprint",
+							Args:     []string{"-v"},
+							Interval: time.Minute,
+							Config:   map[string]string{"vendor": "xilinx"},
+						},
+					},
+					ExternalDNS: &config.ExternalDNSConfig{
+						Provider:  "coredns",
+						TTL:       30 * time.Second,
+						Endpoints: []string{"http://127.0.0.1:2379"},
+						Prefix:    "/dns",
+					},
 				},
 				Server: &ServerConfig{
 					Enabled:                true,
@@ -98,6 +130,13 @@ func TestConfig_Parse(t *testing.T) {
 					HeartbeatGrace:         30 * time.Second,
 					MinHeartbeatTTL:        33 * time.Second,
 					MaxHeartbeatsPerSecond: 11.0,
+					RPCReadRateLimit:       100.5,
+					RPCReadRateBurst:       200,
+					RPCWriteRateLimit:      10,
+					RPCWriteRateBurst:      20,
+					EventBufferSize:        500,
+					EventDiskBufferSize:    5000,
+					MaxJobSize:             helper.IntToPtr(0),
 					RetryJoin:              []string{"1.1.1.1", "2.2.2.2"},
 					StartJoin:              []string{"1.1.1.1", "2.2.2.2"},
 					RetryInterval:          "15s",
@@ -107,6 +146,25 @@ func TestConfig_Parse(t *testing.T) {
 					RedundancyZone:         "foo",
 					UpgradeVersion:         "0.8.0",
 					EncryptKey:             "abc",
+					User:                   "nomad",
+					Scorers: []*config.ScorerConfig{
+						{
+							Name:    "energy",
+							Weight:  2.5,
+							Options: map[string]string{"max_watts": "400"},
+						},
+					},
+					NodeHooks: []*config.NodeHookConfig{
+						{
+							Name:          "cmdb",
+							Events:        []string{"registered", "purged"},
+							Webhook:       "https://cmdb.example.com/nomad",
+							Headers:       map[string]string{"Authorization": "Bearer abc"},
+							Retries:       helper.IntToPtr(5),
+							RetryInterval: 5 * time.Second,
+							Timeout:       10 * time.Second,
+						},
+					},
 				},
 				ACL: &ACLConfig{
 					Enabled:          true,
@@ -126,6 +184,8 @@ func TestConfig_Parse(t *testing.T) {
 					PublishNodeMetrics:         true,
 					DisableTaggedMetrics:       true,
 					BackwardsCompatibleMetrics: true,
+					NamespaceLabels:            true,
+					NodeClassLabels:            true,
 				},
 				LeaveOnInt:                true,
 				LeaveOnTerm:               true,
@@ -199,6 +259,22 @@ func TestConfig_Parse(t *testing.T) {
 					DisableUpgradeMigration: &trueValue,
 					EnableCustomUpgrades:    &trueValue,
 				},
+				StateExport: &config.StateExportConfig{
+					Enabled:  &trueValue,
+					Tables:   []string{"jobs", "allocs"},
+					Interval: 15 * time.Second,
+					Sink:     "s3",
+					Bucket:   "nomad-state",
+					Prefix:   "prod/",
+					Region:   "us-east-1",
+				},
+				AllocArchive: &config.AllocArchiveConfig{
+					Enabled: &trueValue,
+					Store:   "s3",
+					Bucket:  "nomad-archive",
+					Prefix:  "prod/",
+					Region:  "us-east-1",
+				},
 			},
 			false,
 		},
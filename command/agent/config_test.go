@@ -36,6 +36,8 @@ func TestConfig_Merge(t *testing.T) {
 		Consul:         &config.ConsulConfig{},
 		Sentinel:       &config.SentinelConfig{},
 		Autopilot:      &config.AutopilotConfig{},
+		StateExport:    &config.StateExportConfig{},
+		AllocArchive:   &config.AllocArchiveConfig{},
 	}
 
 	c2 := &Config{
@@ -172,6 +174,18 @@ func TestConfig_Merge(t *testing.T) {
 			DisableUpgradeMigration: &falseValue,
 			EnableCustomUpgrades:    &falseValue,
 		},
+		StateExport: &config.StateExportConfig{
+			Enabled:  &falseValue,
+			Tables:   []string{"jobs"},
+			Interval: 1 * time.Second,
+			Sink:     "file",
+			Path:     "/tmp/export1",
+		},
+		AllocArchive: &config.AllocArchiveConfig{
+			Enabled: &falseValue,
+			Store:   "file",
+			Path:    "/tmp/archive1",
+		},
 	}
 
 	c3 := &Config{
@@ -199,6 +213,8 @@ func TestConfig_Merge(t *testing.T) {
 			PublishAllocationMetrics:           true,
 			DisableTaggedMetrics:               true,
 			BackwardsCompatibleMetrics:         true,
+			NamespaceLabels:                    true,
+			NodeClassLabels:                    true,
 			CirconusAPIToken:                   "1",
 			CirconusAPIApp:                     "nomad",
 			CirconusAPIURL:                     "https://api.circonus.com/v2",
@@ -244,6 +260,32 @@ func TestConfig_Merge(t *testing.T) {
 			GCParallelDestroys:    6,
 			GCDiskUsageThreshold:  71,
 			GCInodeUsageThreshold: 86,
+			CgroupDriver:          "systemd",
+			CgroupSlice:           "batch.slice",
+			MountDriver:           "systemd",
+			EnforceCapabilities:   true,
+			WorkloadAPI:           true,
+			ArtifactCacheMaxMB:    2048,
+			GCPolicies: []*GCPolicy{
+				{
+					Namespace:   "batch",
+					MaxPriority: 30,
+					MaxAge:      time.Hour,
+				},
+			},
+			DrainOnLeave:   true,
+			DrainDeadline:  10 * time.Minute,
+			RPCCompression: true,
+			FingerprintPlugins: []*config.FingerprintPluginConfig{
+				{
+					Name:    "fpga",
+					Command: "/usr/local/bin/nomad-fpga-fingerprint",
+				},
+			},
+			ExternalDNS: &config.ExternalDNSConfig{
+				Provider:     "route53",
+				HostedZoneID: "Z1234",
+			},
 		},
 		Server: &ServerConfig{
 			Enabled:                true,
@@ -258,6 +300,12 @@ func TestConfig_Merge(t *testing.T) {
 			HeartbeatGrace:         2 * time.Minute,
 			MinHeartbeatTTL:        2 * time.Minute,
 			MaxHeartbeatsPerSecond: 200.0,
+			RPCReadRateLimit:       50,
+			RPCReadRateBurst:       100,
+			RPCWriteRateLimit:      5,
+			RPCWriteRateBurst:      10,
+			EventBufferSize:        200,
+			EventDiskBufferSize:    2000,
 			RejoinAfterLeave:       true,
 			StartJoin:              []string{"1.1.1.1"},
 			RetryJoin:              []string{"1.1.1.1"},
@@ -266,6 +314,7 @@ func TestConfig_Merge(t *testing.T) {
 			NonVotingServer:        true,
 			RedundancyZone:         "bar",
 			UpgradeVersion:         "bar",
+			User:                   "nomad",
 		},
 		ACL: &ACLConfig{
 			Enabled:          true,
@@ -338,6 +387,26 @@ func TestConfig_Merge(t *testing.T) {
 			DisableUpgradeMigration: &trueValue,
 			EnableCustomUpgrades:    &trueValue,
 		},
+		StateExport: &config.StateExportConfig{
+			Enabled:  &trueValue,
+			Tables:   []string{"jobs", "allocs"},
+			Interval: 2 * time.Second,
+			Sink:     "s3",
+			Path:     "/tmp/export2",
+			Bucket:   "bucket",
+			Prefix:   "prefix/",
+			Region:   "us-east-1",
+			Endpoint: "http://127.0.0.1:9000",
+		},
+		AllocArchive: &config.AllocArchiveConfig{
+			Enabled:  &trueValue,
+			Store:    "s3",
+			Path:     "/tmp/archive2",
+			Bucket:   "bucket",
+			Prefix:   "prefix/",
+			Region:   "us-east-1",
+			Endpoint: "http://127.0.0.1:9000",
+		},
 	}
 
 	result := c0.Merge(c1)
@@ -735,6 +804,37 @@ func TestConfig_normalizeAddrs_IPv6Loopback(t *testing.T) {
 	}
 }
 
+func TestConfig_normalizeAddrs_IPv6Normalized(t *testing.T) {
+	c := &Config{
+		BindAddr: "::",
+		Ports: &Ports{
+			HTTP: 4646,
+			RPC:  4647,
+			Serf: 4648,
+		},
+		Addresses: &Addresses{},
+		AdvertiseAddrs: &AdvertiseAddrs{
+			HTTP: "2001:DB8:0:0:0:0:0:1",
+			RPC:  "[2001:db8:0::1]:4647",
+			Serf: "[2001:db8::1]:4648",
+		},
+	}
+
+	if err := c.normalizeAddrs(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if c.AdvertiseAddrs.HTTP != "[2001:db8::1]:4646" {
+		t.Errorf("expected normalized HTTP advertise address, got %s", c.AdvertiseAddrs.HTTP)
+	}
+	if c.AdvertiseAddrs.RPC != "[2001:db8::1]:4647" {
+		t.Errorf("expected normalized RPC advertise address, got %s", c.AdvertiseAddrs.RPC)
+	}
+	if c.AdvertiseAddrs.Serf != "[2001:db8::1]:4648" {
+		t.Errorf("expected normalized Serf advertise address, got %s", c.AdvertiseAddrs.Serf)
+	}
+}
+
 func TestConfig_normalizeAddrs(t *testing.T) {
 	c := &Config{
 		BindAddr: "169.254.1.5",
@@ -28,6 +28,34 @@ func (m *MockCatalog) Service(service, tag string, q *api.QueryOptions) ([]*api.
 	return nil, nil, nil
 }
 
+// MockKV is a fake in-memory Consul KV store that can be used for testing
+// where the KVAPI is needed.
+type MockKV struct {
+	pairs map[string][]byte
+	mu    sync.Mutex
+}
+
+func NewMockKV() *MockKV {
+	return &MockKV{pairs: make(map[string][]byte)}
+}
+
+// Put stores the value at the key
+func (m *MockKV) Put(key string, value []byte) {
+	m.mu.Lock()
+	m.pairs[key] = value
+	m.mu.Unlock()
+}
+
+func (m *MockKV) Get(key string, q *api.QueryOptions) (*api.KVPair, *api.QueryMeta, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.pairs[key]
+	if !ok {
+		return nil, &api.QueryMeta{}, nil
+	}
+	return &api.KVPair{Key: key, Value: value}, &api.QueryMeta{}, nil
+}
+
 // MockAgent is a fake in-memory Consul backend for ServiceClient.
 type MockAgent struct {
 	// maps of what services and checks have been registered
@@ -64,6 +64,11 @@ type CatalogAPI interface {
 	Service(service, tag string, q *api.QueryOptions) ([]*api.CatalogService, *api.QueryMeta, error)
 }
 
+// KVAPI is the consul/api.KV API used by Nomad.
+type KVAPI interface {
+	Get(key string, q *api.QueryOptions) (*api.KVPair, *api.QueryMeta, error)
+}
+
 // AgentAPI is the consul/api.Agent API used by Nomad.
 type AgentAPI interface {
 	Services() (map[string]*api.AgentService, error)
@@ -166,11 +171,22 @@ type ServiceRegistration struct {
 	serviceID string
 	checkIDs  map[string]struct{}
 
+	// checkDefs are the job's definitions of the checks by check ID
+	checkDefs map[string]*structs.ServiceCheck
+
 	// Service is the AgentService registered in Consul.
 	Service *api.AgentService
 
 	// Checks is the status of the registered checks.
 	Checks []*api.AgentCheck
+
+	// CheckDefinitions are the job's definitions of the registered checks by
+	// check ID.
+	CheckDefinitions map[string]*structs.ServiceCheck
+
+	// CheckRuns are the last runs of the script checks executed by Nomad by
+	// check ID.
+	CheckRuns map[string]*CheckRun
 }
 
 func (s *ServiceRegistration) copy() *ServiceRegistration {
@@ -180,6 +196,18 @@ func (s *ServiceRegistration) copy() *ServiceRegistration {
 	return &ServiceRegistration{
 		serviceID: s.serviceID,
 		checkIDs:  helper.CopyMapStringStruct(s.checkIDs),
+		checkDefs: s.checkDefs,
+	}
+}
+
+// setCheckDefs records the definitions of the registered checks among the
+// given checks of the service.
+func (s *ServiceRegistration) setCheckDefs(checks []*structs.ServiceCheck) {
+	for _, check := range checks {
+		cid := makeCheckID(s.serviceID, check)
+		if _, ok := s.checkIDs[cid]; ok {
+			s.checkDefs[cid] = check
+		}
 	}
 }
 
@@ -210,6 +238,9 @@ type ServiceClient struct {
 	scripts        map[string]*scriptCheck
 	runningScripts map[string]*scriptHandle
 
+	// checkRuns records the last runs of script checks
+	checkRuns *checkRuns
+
 	// allocRegistrations stores the services and checks that are registered
 	// with Consul by allocation ID.
 	allocRegistrations     map[string]*AllocRegistration
@@ -246,6 +277,7 @@ func NewServiceClient(consulClient AgentAPI, skipVerifySupport bool, logger *log
 		checks:             make(map[string]*api.AgentCheckRegistration),
 		scripts:            make(map[string]*scriptCheck),
 		runningScripts:     make(map[string]*scriptHandle),
+		checkRuns:          newCheckRuns(),
 		allocRegistrations: make(map[string]*AllocRegistration),
 		agentServices:      make(map[string]struct{}),
 		agentChecks:        make(map[string]struct{}),
@@ -364,6 +396,7 @@ func (c *ServiceClient) merge(ops *operations) {
 			script.cancel()
 			delete(c.scripts, cid)
 			delete(c.runningScripts, cid)
+			c.checkRuns.remove(cid)
 		}
 		delete(c.checks, cid)
 	}
@@ -574,6 +607,7 @@ func (c *ServiceClient) serviceRegs(ops *operations, allocID string, service *st
 	sreg := &ServiceRegistration{
 		serviceID: id,
 		checkIDs:  make(map[string]struct{}, len(service.Checks)),
+		checkDefs: make(map[string]*structs.ServiceCheck, len(service.Checks)),
 	}
 
 	// Service address modes default to auto
@@ -587,6 +621,7 @@ func (c *ServiceClient) serviceRegs(ops *operations, allocID string, service *st
 	if err != nil {
 		return nil, fmt.Errorf("unable to get address for service %q: %v", service.Name, err)
 	}
+	ip = helper.NormalizeIP(ip)
 
 	// Build the Consul Service registration request
 	serviceReg := &api.AgentServiceRegistration{
@@ -609,6 +644,7 @@ func (c *ServiceClient) serviceRegs(ops *operations, allocID string, service *st
 	for _, cid := range checkIDs {
 		sreg.checkIDs[cid] = struct{}{}
 	}
+	sreg.setCheckDefs(service.Checks)
 	return sreg, nil
 }
 
@@ -636,8 +672,9 @@ func (c *ServiceClient) checkRegs(ops *operations, allocID, serviceID string, se
 			if exec == nil {
 				return nil, fmt.Errorf("driver doesn't support script checks")
 			}
-			ops.scripts = append(ops.scripts, newScriptCheck(
-				allocID, task.Name, checkID, check, exec, c.client, c.logger, c.shutdownCh))
+			sc := newScriptCheck(allocID, task.Name, checkID, check, exec, c.client, c.logger, c.shutdownCh)
+			sc.runs = c.checkRuns
+			ops.scripts = append(ops.scripts, sc)
 
 			// Skip getAddress for script checks
 			checkReg, err := createCheckReg(serviceID, checkID, check, "", 0)
@@ -665,6 +702,7 @@ func (c *ServiceClient) checkRegs(ops *operations, allocID, serviceID string, se
 		if err != nil {
 			return nil, fmt.Errorf("error getting address for check %q: %v", check.Name, err)
 		}
+		ip = helper.NormalizeIP(ip)
 
 		checkReg, err := createCheckReg(serviceID, checkID, check, ip, port)
 		if err != nil {
@@ -765,6 +803,7 @@ func (c *ServiceClient) UpdateTask(allocID string, existing, newTask *structs.Ta
 		sreg := &ServiceRegistration{
 			serviceID: existingID,
 			checkIDs:  make(map[string]struct{}, len(newSvc.Checks)),
+			checkDefs: make(map[string]*structs.ServiceCheck, len(newSvc.Checks)),
 		}
 		taskReg.Services[existingID] = sreg
 
@@ -800,6 +839,8 @@ func (c *ServiceClient) UpdateTask(allocID string, existing, newTask *structs.Ta
 			}
 		}
 
+		sreg.setCheckDefs(newSvc.Checks)
+
 		// Remove existing checks not in updated service
 		for cid, check := range existingChecks {
 			ops.deregChecks = append(ops.deregChecks, cid)
@@ -897,10 +938,18 @@ func (c *ServiceClient) AllocRegistrations(allocID string) (*AllocRegistration,
 	for _, treg := range reg.Tasks {
 		for serviceID, sreg := range treg.Services {
 			sreg.Service = services[serviceID]
+			sreg.CheckDefinitions = make(map[string]*structs.ServiceCheck, len(sreg.checkIDs))
+			sreg.CheckRuns = make(map[string]*CheckRun)
 			for checkID := range sreg.checkIDs {
 				if check, ok := checks[checkID]; ok {
 					sreg.Checks = append(sreg.Checks, check)
 				}
+				if def, ok := sreg.checkDefs[checkID]; ok {
+					sreg.CheckDefinitions[checkID] = def
+				}
+				if run := c.checkRuns.get(checkID); run != nil {
+					sreg.CheckRuns[checkID] = run
+				}
 			}
 		}
 	}
@@ -3,6 +3,7 @@ package consul
 import (
 	"context"
 	"log"
+	"sync"
 	"time"
 
 	metrics "github.com/armon/go-metrics"
@@ -30,6 +31,57 @@ func (s *scriptHandle) wait() <-chan struct{} {
 	return s.exitCh
 }
 
+// CheckRun is the outcome of the last run of a check executed by Nomad.
+type CheckRun struct {
+	// Time is when the check was last run
+	Time time.Time
+
+	// Duration is how long the last run took
+	Duration time.Duration
+
+	// Status and Output are the health status and output reported for the
+	// last run
+	Status string
+	Output string
+}
+
+// checkRuns records the last run of script checks by check ID so it can be
+// queried while the checks are running.
+type checkRuns struct {
+	runs map[string]*CheckRun
+	l    sync.RWMutex
+}
+
+func newCheckRuns() *checkRuns {
+	return &checkRuns{
+		runs: make(map[string]*CheckRun),
+	}
+}
+
+// set records the last run of a check. It is a no-op on a nil checkRuns.
+func (c *checkRuns) set(checkID string, run *CheckRun) {
+	if c == nil {
+		return
+	}
+	c.l.Lock()
+	defer c.l.Unlock()
+	c.runs[checkID] = run
+}
+
+// get returns the last run of a check or nil if it hasn't run.
+func (c *checkRuns) get(checkID string) *CheckRun {
+	c.l.RLock()
+	defer c.l.RUnlock()
+	return c.runs[checkID]
+}
+
+// remove forgets the runs of a removed check.
+func (c *checkRuns) remove(checkID string) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	delete(c.runs, checkID)
+}
+
 // scriptCheck runs script checks via a ScriptExecutor and updates the
 // appropriate check's TTL when the script succeeds.
 type scriptCheck struct {
@@ -44,6 +96,9 @@ type scriptCheck struct {
 	// lastCheckOk is true if the last check was ok; otherwise false
 	lastCheckOk bool
 
+	// runs records the last run of the check if set
+	runs *checkRuns
+
 	logger     *log.Logger
 	shutdownCh <-chan struct{}
 }
@@ -91,8 +146,10 @@ func (s *scriptCheck) run() *scriptHandle {
 			metrics.IncrCounter([]string{"client", "consul", "script_runs"}, 1)
 
 			// Execute check script with timeout
+			start := time.Now()
 			execctx, cancel := context.WithTimeout(ctx, s.check.Timeout)
 			output, code, err := s.exec.Exec(execctx, s.check.Command, s.check.Args)
+			duration := time.Since(start)
 			switch execctx.Err() {
 			case context.Canceled:
 				// check removed during execution; exit
@@ -131,6 +188,13 @@ func (s *scriptCheck) run() *scriptHandle {
 				outputMsg = string(output)
 			}
 
+			s.runs.set(s.id, &CheckRun{
+				Time:     start,
+				Duration: duration,
+				Status:   state,
+				Output:   outputMsg,
+			})
+
 			// Actually heartbeat the check
 			err = s.agent.UpdateTTL(s.id, outputMsg, state)
 			select {
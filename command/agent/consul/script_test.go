@@ -112,6 +112,7 @@ func TestConsulScript_Exec_Timeout(t *testing.T) {
 
 	hb := newFakeHeartbeater()
 	check := newScriptCheck("allocid", "testtask", "checkid", &serviceCheck, exec, hb, testLogger(), nil)
+	check.runs = newCheckRuns()
 	handle := check.run()
 	defer handle.cancel() // just-in-case cleanup
 	<-exec.running
@@ -129,6 +130,15 @@ func TestConsulScript_Exec_Timeout(t *testing.T) {
 		t.Errorf("expected script executor to run and exit but it has not")
 	}
 
+	// The run is recorded before heartbeating
+	run := check.runs.get("checkid")
+	if run == nil {
+		t.Fatalf("expected the run of the check to be recorded")
+	}
+	if run.Status != api.HealthCritical || run.Duration < serviceCheck.Timeout {
+		t.Errorf("expected a critical run lasting at least %s but found %#v", serviceCheck.Timeout, run)
+	}
+
 	// Cancel and watch for exit
 	handle.cancel()
 	select {
@@ -8,15 +8,27 @@ import (
 )
 
 func (s *HTTPServer) EvalsRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
-	if req.Method != "GET" {
+	switch req.Method {
+	case "GET":
+		return s.evalsList(resp, req)
+	case "DELETE":
+		return s.evalsDelete(resp, req)
+	default:
 		return nil, CodedError(405, ErrInvalidMethod)
 	}
+}
 
+func (s *HTTPServer) evalsList(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	args := structs.EvalListRequest{}
 	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
 		return nil, nil
 	}
 
+	query := req.URL.Query()
+	args.FilterJobID = query.Get("job")
+	args.FilterEvalStatus = query.Get("status")
+	args.FilterTriggeredBy = query.Get("triggered_by")
+
 	var out structs.EvalListResponse
 	if err := s.agent.RPC("Eval.List", &args, &out); err != nil {
 		return nil, err
@@ -29,6 +41,46 @@ func (s *HTTPServer) EvalsRequest(resp http.ResponseWriter, req *http.Request) (
 	return out.Evaluations, nil
 }
 
+// evalsDelete deletes the evaluations of the request body by ID.
+func (s *HTTPServer) evalsDelete(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := structs.EvalDeleteByIDRequest{}
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if len(args.EvalIDs) == 0 {
+		return nil, CodedError(400, "Must specify at least one evaluation ID")
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	var out structs.EvalDeleteByIDResponse
+	if err := s.agent.RPC("Eval.Delete", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
+// EvalsDeleteRequest deletes a batch of the evaluations that match the filter of
+// the request body.
+func (s *HTTPServer) EvalsDeleteRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.EvalBatchDeleteRequest{}
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	var out structs.EvalBatchDeleteResponse
+	if err := s.agent.RPC("Eval.DeleteBatch", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
 func (s *HTTPServer) EvalSpecificRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	path := strings.TrimPrefix(req.URL.Path, "/v1/evaluation/")
 	switch {
@@ -197,3 +197,79 @@ func TestHTTP_EvalQuery(t *testing.T) {
 		}
 	})
 }
+
+func TestHTTP_EvalList_Filters(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		eval1 := mock.Eval()
+		eval1.Status = structs.EvalStatusBlocked
+		eval2 := mock.Eval()
+		err := state.UpsertEvals(1000,
+			[]*structs.Evaluation{eval1, eval2})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		req, err := http.NewRequest("GET", "/v1/evaluations?status=blocked&job="+eval1.JobID, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.EvalsRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the eval
+		e := obj.([]*structs.Evaluation)
+		if len(e) != 1 || e[0].ID != eval1.ID {
+			t.Fatalf("bad: %#v", e)
+		}
+	})
+}
+
+func TestHTTP_EvalsDelete(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		// Directly manipulate the state
+		state := s.Agent.server.State()
+		eval1 := mock.Eval()
+		eval1.Status = structs.EvalStatusBlocked
+		err := state.UpsertEvals(1000, []*structs.Evaluation{eval1})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the HTTP request
+		args := structs.EvalDeleteByIDRequest{EvalIDs: []string{eval1.ID}}
+		req, err := http.NewRequest("DELETE", "/v1/evaluations", encodeReq(args))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.EvalsRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+
+		// Check the eval was deleted
+		resp := obj.(structs.EvalDeleteByIDResponse)
+		if len(resp.Deleted) != 1 || resp.Deleted[0] != eval1.ID {
+			t.Fatalf("bad: %#v", resp)
+		}
+		out, err := state.EvalByID(nil, eval1.ID)
+		if err != nil || out != nil {
+			t.Fatalf("eval not deleted: %v %v", out, err)
+		}
+	})
+}
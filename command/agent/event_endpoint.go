@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	// eventStreamHeartbeat is how long the stream waits for events before
+	// sending a heartbeat, unless the request sets a wait time.
+	eventStreamHeartbeat = 10 * time.Second
+)
+
+// EventStreamFrame is a batch of events written to the event stream. Frames
+// without events are heartbeats.
+type EventStreamFrame struct {
+	Index  uint64           `json:",omitempty"`
+	Events []*structs.Event `json:",omitempty"`
+}
+
+// EventStream streams the events of the state store as newline delimited JSON
+// frames. The events can be filtered by topic, namespace and key prefix, and
+// the stream starts after the given index.
+func (s *HTTPServer) EventStream(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.EventStreamRequest
+	if done := s.parse(resp, req, &args.Region, &args.QueryOptions); done {
+		return nil, nil
+	}
+	args.Topics = req.URL.Query()["topic"]
+	if args.MaxQueryTime == 0 {
+		args.MaxQueryTime = eventStreamHeartbeat
+	}
+
+	// The first request is made before streaming so its errors are returned
+	// with a status code
+	var reply structs.EventStreamResponse
+	if err := s.agent.RPC("Event.List", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	resp.Header().Set("Content-Type", "application/json")
+	output := ioutils.NewWriteFlusher(resp)
+	enc := codec.NewEncoder(output, structs.JsonHandle)
+	for {
+		frame := EventStreamFrame{}
+		if len(reply.Events) != 0 {
+			frame.Index = reply.Index
+			frame.Events = reply.Events
+		}
+		if err := enc.Encode(frame); err != nil {
+			// The subscriber went away
+			return nil, nil
+		}
+		if _, err := output.Write([]byte("\n")); err != nil {
+			return nil, nil
+		}
+
+		args.MinQueryIndex = reply.Index
+		reply = structs.EventStreamResponse{}
+		if err := s.agent.RPC("Event.List", &args, &reply); err != nil {
+			s.logger.Printf("[ERR] http: event stream failed: %v", err)
+			return nil, nil
+		}
+	}
+}
+
+// EventRetentionRequest returns the range of events retained by the servers.
+func (s *HTTPServer) EventRetentionRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.EventRetentionRequest
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.EventRetentionResponse
+	if err := s.agent.RPC("Event.Retention", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	return out.Retention, nil
+}
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP_EventStream_Errors(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	httpTest(t, func(c *Config) {
+		c.Server.EventBufferSize = 100
+	}, func(s *TestAgent) {
+		// Only GET is allowed
+		req, err := http.NewRequest("PUT", "/v1/event/stream", nil)
+		assert.Nil(err)
+		_, err = s.Server.EventStream(httptest.NewRecorder(), req)
+		if assert.NotNil(err) {
+			assert.Contains(err.Error(), ErrInvalidMethod)
+		}
+
+		// Errors of the first request are returned before streaming
+		req, err = http.NewRequest("GET", "/v1/event/stream?topic=foo", nil)
+		assert.Nil(err)
+		_, err = s.Server.EventStream(httptest.NewRecorder(), req)
+		if assert.NotNil(err) {
+			assert.Contains(err.Error(), `unknown topic "foo"`)
+		}
+	})
+}
+
+func TestHTTP_EventRetention(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	httpTest(t, func(c *Config) {
+		c.Server.EventBufferSize = 100
+	}, func(s *TestAgent) {
+		req, err := http.NewRequest("GET", "/v1/event/retention", nil)
+		assert.Nil(err)
+		obj, err := s.Server.EventRetentionRequest(httptest.NewRecorder(), req)
+		assert.Nil(err)
+		retention := obj.(*structs.EventRetention)
+		assert.False(retention.Durable)
+	})
+}
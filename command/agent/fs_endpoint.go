@@ -119,6 +119,16 @@ func (s *HTTPServer) FsRequest(resp http.ResponseWriter, req *http.Request) (int
 			}
 		}
 		return s.Logs(resp, req)
+	case strings.HasPrefix(path, "logs-multiplexed"):
+		// Logs can be accessed with ReadFS or ReadLogs caps
+		if aclObj != nil {
+			readfs := aclObj.AllowNsOp(namespace, acl.NamespaceCapabilityReadFS)
+			logs := aclObj.AllowNsOp(namespace, acl.NamespaceCapabilityReadLogs)
+			if !readfs && !logs {
+				return nil, structs.ErrPermissionDenied
+			}
+		}
+		return s.MultiplexedLogs(resp, req)
 	default:
 		return nil, CodedError(404, ErrInvalidMethod)
 	}
@@ -0,0 +1,313 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/docker/docker/pkg/ioutils"
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/ugorji/go/codec"
+)
+
+var (
+	allocOrJobNotPresentErr = fmt.Errorf("must provide a valid alloc id or job")
+	logTypeInvalidErr       = fmt.Errorf("log type must be stdout, stderr or empty for both")
+)
+
+// MultiplexedStreamFrame wraps a StreamFrame of a single log stream with the
+// allocation and task it was read from so that the logs of many tasks can be
+// interleaved on a single connection. A frame without a StreamFrame or an
+// Error is a heartbeat.
+type MultiplexedStreamFrame struct {
+	// AllocID is the allocation the frame was read from
+	AllocID string `json:",omitempty"`
+
+	// Task is the task the frame was read from
+	Task string `json:",omitempty"`
+
+	// Type is the log type, stdout or stderr
+	Type string `json:",omitempty"`
+
+	// Frame is the framed log data
+	Frame *StreamFrame `json:",omitempty"`
+
+	// Error is set if streaming the logs of the task failed. No further
+	// frames will be sent for the task.
+	Error string `json:",omitempty"`
+}
+
+// IsHeartbeat returns if the frame is a heartbeat frame
+func (m *MultiplexedStreamFrame) IsHeartbeat() bool {
+	return m.Frame == nil && m.Error == ""
+}
+
+// logStream identifies a single log of a task that is part of a multiplexed
+// stream.
+type logStream struct {
+	allocID string
+	task    string
+	logType string
+	fs      allocdir.AllocDirFS
+}
+
+// MultiplexedLogs streams the logs of every task of an allocation, or of every
+// allocation of a job running on this client, over a single connection. The
+// parameters are:
+//   - job: stream the allocations of the job instead of a single allocation.
+//   - task: optionally restrict the stream to a single task.
+//   - type: stdout/stderr to stream. Defaults to both.
+//   - follow: A boolean of whether to follow the logs.
+//   - offset: The offset to start streaming data at, defaults to zero.
+//   - origin: Either "start" or "end" and defines from where the offset is
+//     applied. Defaults to "start".
+func (s *HTTPServer) MultiplexedLogs(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var allocID, jobID, task, namespace string
+	var follow bool
+	var err error
+
+	q := req.URL.Query()
+
+	allocID = strings.TrimPrefix(req.URL.Path, "/v1/client/fs/logs-multiplexed")
+	allocID = strings.TrimPrefix(allocID, "/")
+	jobID = q.Get("job")
+	if (allocID == "") == (jobID == "") {
+		return nil, allocOrJobNotPresentErr
+	}
+
+	task = q.Get("task")
+	parseNamespace(req, &namespace)
+
+	if followStr := q.Get("follow"); followStr != "" {
+		if follow, err = strconv.ParseBool(followStr); err != nil {
+			return nil, fmt.Errorf("Failed to parse follow field to boolean: %v", err)
+		}
+	}
+
+	var logTypes []string
+	switch logType := q.Get("type"); logType {
+	case "stdout", "stderr":
+		logTypes = []string{logType}
+	case "":
+		logTypes = []string{"stdout", "stderr"}
+	default:
+		return nil, logTypeInvalidErr
+	}
+
+	var offset int64
+	if offsetString := q.Get("offset"); offsetString != "" {
+		if offset, err = strconv.ParseInt(offsetString, 10, 64); err != nil {
+			return nil, fmt.Errorf("error parsing offset: %v", err)
+		}
+	}
+
+	origin := q.Get("origin")
+	switch origin {
+	case "start", "end":
+	case "":
+		origin = "start"
+	default:
+		return nil, invalidOrigin
+	}
+
+	// Determine the allocations to stream
+	var allocs []*structs.Allocation
+	if allocID != "" {
+		alloc, err := s.agent.client.GetClientAlloc(allocID)
+		if err != nil {
+			return nil, err
+		}
+		allocs = append(allocs, alloc)
+	} else {
+		for _, alloc := range s.agent.client.GetClientAllocs() {
+			if alloc.Namespace == namespace && alloc.JobID == jobID {
+				allocs = append(allocs, alloc)
+			}
+		}
+		if len(allocs) == 0 {
+			return nil, CodedError(404, fmt.Sprintf("no allocations of job %q on this client", jobID))
+		}
+	}
+	sort.Slice(allocs, func(i, j int) bool { return allocs[i].ID < allocs[j].ID })
+
+	var streams []*logStream
+	for _, alloc := range allocs {
+		fs, err := s.agent.client.GetAllocFS(alloc.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+		if tg == nil {
+			return nil, fmt.Errorf("Failed to lookup task group for allocation")
+		}
+
+		for _, t := range tg.Tasks {
+			if task != "" && t.Name != task {
+				continue
+			}
+
+			// Tasks that have not started yet have no logs
+			if state, ok := alloc.TaskStates[t.Name]; !ok || state.StartedAt.IsZero() {
+				continue
+			}
+
+			for _, logType := range logTypes {
+				streams = append(streams, &logStream{
+					allocID: alloc.ID,
+					task:    t.Name,
+					logType: logType,
+					fs:      fs,
+				})
+			}
+		}
+	}
+
+	if len(streams) == 0 {
+		return nil, CodedError(404, "no started tasks found. No logs available")
+	}
+
+	// Create an output that gets flushed on every write
+	output := ioutils.NewWriteFlusher(resp)
+
+	return nil, s.multiplexLogs(follow, offset, origin, streams, output)
+}
+
+// logMultiplexer serializes the frames of many log streams onto a single
+// output.
+type logMultiplexer struct {
+	enc *codec.Encoder
+
+	// pipes are the readers of the individual streams. They are closed if
+	// writing to the output fails so that the streams stop.
+	pipes []*io.PipeReader
+
+	l      sync.Mutex
+	err    error
+	failed bool
+}
+
+// send writes the frame to the output. Once a write has failed the error is
+// returned for every subsequent call.
+func (m *logMultiplexer) send(f *MultiplexedStreamFrame) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if m.failed {
+		return m.err
+	}
+
+	if err := m.enc.Encode(f); err != nil {
+		m.failed = true
+		m.err = err
+		for _, p := range m.pipes {
+			p.CloseWithError(io.ErrClosedPipe)
+		}
+		return err
+	}
+	return nil
+}
+
+// multiplexLogs streams every log stream using the regular logs machinery and
+// re-frames the output of each with the originating allocation and task before
+// writing it to the output. The output is closed once all streams finish.
+func (s *HTTPServer) multiplexLogs(follow bool, offset int64, origin string,
+	streams []*logStream, output io.WriteCloser) error {
+
+	mux := &logMultiplexer{
+		enc: codec.NewEncoder(output, structs.JsonHandle),
+	}
+
+	writers := make([]*io.PipeWriter, len(streams))
+	for i := range streams {
+		r, w := io.Pipe()
+		mux.pipes = append(mux.pipes, r)
+		writers[i] = w
+	}
+
+	// Heartbeat on the shared connection. The heartbeats of the individual
+	// streams are discarded so this is what detects a closed connection.
+	stopCh := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(streamHeartbeatRate)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := mux.send(&MultiplexedStreamFrame{}); err != nil {
+					return
+				}
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i, stream := range streams {
+		wg.Add(1)
+		go func(stream *logStream, r *io.PipeReader, w *io.PipeWriter) {
+			defer wg.Done()
+
+			// Re-frame everything the stream writes until it closes its end
+			// of the pipe
+			doneCh := make(chan struct{})
+			go func() {
+				defer close(doneCh)
+				dec := codec.NewDecoder(r, structs.JsonHandle)
+				for {
+					var frame StreamFrame
+					if err := dec.Decode(&frame); err != nil {
+						return
+					}
+
+					if frame.IsHeartbeat() {
+						continue
+					}
+
+					err := mux.send(&MultiplexedStreamFrame{
+						AllocID: stream.allocID,
+						Task:    stream.task,
+						Type:    stream.logType,
+						Frame:   &frame,
+					})
+					if err != nil {
+						return
+					}
+				}
+			}()
+
+			err := s.logs(follow, false, offset, origin, stream.task, stream.logType, stream.fs, w)
+			<-doneCh
+			r.Close()
+
+			if err != nil && parseFramerErr(err) != syscall.EPIPE {
+				mux.send(&MultiplexedStreamFrame{
+					AllocID: stream.allocID,
+					Task:    stream.task,
+					Type:    stream.logType,
+					Error:   err.Error(),
+				})
+			}
+		}(stream, mux.pipes[i], writers[i])
+	}
+
+	wg.Wait()
+	close(stopCh)
+
+	// Stop any in flight heartbeat from writing to the closed output
+	mux.l.Lock()
+	mux.failed = true
+	mux.err = io.ErrClosedPipe
+	mux.l.Unlock()
+
+	output.Close()
+	return nil
+}
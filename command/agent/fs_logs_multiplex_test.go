@@ -0,0 +1,123 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/client/allocdir"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+)
+
+func TestHTTP_MultiplexedLogs_MissingParams(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	httpTest(t, nil, func(s *TestAgent) {
+		// Neither an alloc nor a job
+		req, err := http.NewRequest("GET", "/v1/client/fs/logs-multiplexed/", nil)
+		assert.Nil(err)
+		_, err = s.Server.MultiplexedLogs(httptest.NewRecorder(), req)
+		assert.Equal(allocOrJobNotPresentErr, err)
+
+		// Both an alloc and a job
+		req, err = http.NewRequest("GET", "/v1/client/fs/logs-multiplexed/foo?job=bar", nil)
+		assert.Nil(err)
+		_, err = s.Server.MultiplexedLogs(httptest.NewRecorder(), req)
+		assert.Equal(allocOrJobNotPresentErr, err)
+
+		// Bad log type
+		req, err = http.NewRequest("GET", "/v1/client/fs/logs-multiplexed/foo?type=bar", nil)
+		assert.Nil(err)
+		_, err = s.Server.MultiplexedLogs(httptest.NewRecorder(), req)
+		assert.Equal(logTypeInvalidErr, err)
+	})
+}
+
+func TestHTTP_MultiplexedLogs_NoFollow(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	httpTest(t, nil, func(s *TestAgent) {
+		// Get a temp alloc dir and create the log dir
+		ad := tempAllocDir(t)
+		defer os.RemoveAll(ad.AllocDir)
+
+		logDir := filepath.Join(ad.SharedDir, allocdir.LogDirName)
+		if err := os.MkdirAll(logDir, 0777); err != nil {
+			t.Fatalf("Failed to make log dir: %v", err)
+		}
+
+		// Create log files for two tasks
+		expected := map[string]string{
+			"web.stdout":     "web out",
+			"web.stderr":     "web err",
+			"sidecar.stdout": "sidecar out",
+		}
+		for name, contents := range expected {
+			logFilePath := filepath.Join(logDir, fmt.Sprintf("%s.0", name))
+			if err := ioutil.WriteFile(logFilePath, []byte(contents), 0777); err != nil {
+				t.Fatalf("Failed to create file: %v", err)
+			}
+		}
+
+		streams := []*logStream{
+			{allocID: "a1", task: "web", logType: "stdout", fs: ad},
+			{allocID: "a1", task: "web", logType: "stderr", fs: ad},
+			{allocID: "a1", task: "sidecar", logType: "stdout", fs: ad},
+		}
+
+		// Create a decoder
+		r, w := io.Pipe()
+		wrappedW := &WriteCloseChecker{WriteCloser: w}
+		defer r.Close()
+		defer w.Close()
+		dec := codec.NewDecoder(r, structs.JsonHandle)
+
+		received := make(map[string]string)
+		resultCh := make(chan struct{})
+		go func() {
+			defer close(resultCh)
+			for {
+				var frame MultiplexedStreamFrame
+				if err := dec.Decode(&frame); err != nil {
+					return
+				}
+
+				if frame.IsHeartbeat() {
+					continue
+				}
+
+				if frame.Error != "" {
+					t.Errorf("unexpected error for %s/%s: %s", frame.Task, frame.Type, frame.Error)
+					continue
+				}
+
+				if frame.AllocID != "a1" {
+					t.Errorf("unexpected alloc id %q", frame.AllocID)
+				}
+				key := fmt.Sprintf("%s.%s", frame.Task, frame.Type)
+				received[key] += string(frame.Frame.Data)
+			}
+		}()
+
+		if err := s.Server.multiplexLogs(false, 0, OriginStart, streams, wrappedW); err != nil {
+			t.Fatalf("multiplexLogs() failed: %v", err)
+		}
+		assert.True(wrappedW.Closed)
+
+		select {
+		case <-resultCh:
+		case <-time.After(10 * time.Duration(testutil.TestMultiplier()) * streamBatchWindow):
+			t.Fatalf("did not receive all data: got %v", received)
+		}
+		assert.Equal(expected, received)
+	})
+}
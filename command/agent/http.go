@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/tls"
 	"encoding/json"
@@ -16,6 +17,7 @@ import (
 
 	"github.com/NYTimes/gziphandler"
 	assetfs "github.com/elazarl/go-bindata-assetfs"
+	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/helper/tlsutil"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/rs/cors"
@@ -29,6 +31,16 @@ const (
 	// ErrEntOnly is the error returned if accessing an enterprise only
 	// endpoint
 	ErrEntOnly = "Nomad Enterprise only endpoint"
+
+	// maxBufferedResponseSize is the size up to which an encoded response is
+	// buffered so an encoding error can still be returned with an error
+	// status. Larger responses are streamed to the client using chunked
+	// transfer encoding.
+	maxBufferedResponseSize = 1024 * 1024
+
+	// streamedResponseChunkSize is the size of the chunks a streamed
+	// response is written in
+	streamedResponseChunkSize = 32 * 1024
 )
 
 var (
@@ -143,15 +155,19 @@ func (s *HTTPServer) Shutdown() {
 // registerHandlers is used to attach our handlers to the mux
 func (s *HTTPServer) registerHandlers(enableDebug bool) {
 	s.mux.HandleFunc("/v1/jobs", s.wrap(s.JobsRequest))
+	s.mux.HandleFunc("/v1/jobs/render", s.wrap(s.JobsRenderRequest))
+	s.mux.HandleFunc("/v1/jobs/evaluate", s.wrap(s.JobsEvaluateRequest))
 	s.mux.HandleFunc("/v1/job/", s.wrap(s.JobSpecificRequest))
 
 	s.mux.HandleFunc("/v1/nodes", s.wrap(s.NodesRequest))
 	s.mux.HandleFunc("/v1/node/", s.wrap(s.NodeSpecificRequest))
 
 	s.mux.HandleFunc("/v1/allocations", s.wrap(s.AllocsRequest))
+	s.mux.HandleFunc("/v1/allocations/stop", s.wrap(s.AllocsStopRequest))
 	s.mux.HandleFunc("/v1/allocation/", s.wrap(s.AllocSpecificRequest))
 
 	s.mux.HandleFunc("/v1/evaluations", s.wrap(s.EvalsRequest))
+	s.mux.HandleFunc("/v1/evaluations/delete", s.wrap(s.EvalsDeleteRequest))
 	s.mux.HandleFunc("/v1/evaluation/", s.wrap(s.EvalSpecificRequest))
 
 	s.mux.HandleFunc("/v1/deployments", s.wrap(s.DeploymentsRequest))
@@ -160,6 +176,16 @@ func (s *HTTPServer) registerHandlers(enableDebug bool) {
 	s.mux.HandleFunc("/v1/acl/policies", s.wrap(s.ACLPoliciesRequest))
 	s.mux.HandleFunc("/v1/acl/policy/", s.wrap(s.ACLPolicySpecificRequest))
 
+	s.mux.HandleFunc("/v1/network-policies", s.wrap(s.NetworkPoliciesRequest))
+	s.mux.HandleFunc("/v1/network-policy/", s.wrap(s.NetworkPolicySpecificRequest))
+
+	s.mux.HandleFunc("/v1/vars", s.wrap(s.VariablesRequest))
+	s.mux.HandleFunc("/v1/var/", s.wrap(s.VariableSpecificRequest))
+
+	s.mux.HandleFunc("/v1/acl/auth-methods", s.wrap(s.ACLAuthMethodsRequest))
+	s.mux.HandleFunc("/v1/acl/auth-method/", s.wrap(s.ACLAuthMethodSpecificRequest))
+	s.mux.HandleFunc("/v1/acl/oidc/auth-url", s.wrap(s.ACLOIDCAuthURLRequest))
+	s.mux.HandleFunc("/v1/acl/oidc/complete-auth", s.wrap(s.ACLOIDCCompleteAuthRequest))
 	s.mux.HandleFunc("/v1/acl/bootstrap", s.wrap(s.ACLTokenBootstrap))
 	s.mux.HandleFunc("/v1/acl/tokens", s.wrap(s.ACLTokensRequest))
 	s.mux.HandleFunc("/v1/acl/token", s.wrap(s.ACLTokenSpecificRequest))
@@ -167,6 +193,8 @@ func (s *HTTPServer) registerHandlers(enableDebug bool) {
 
 	s.mux.Handle("/v1/client/fs/", wrapCORS(s.wrap(s.FsRequest)))
 	s.mux.HandleFunc("/v1/client/gc", s.wrap(s.ClientGCRequest))
+	s.mux.HandleFunc("/v1/client/metadata", s.wrap(s.ClientMetaRequest))
+	s.mux.HandleFunc("/v1/client/migrations", s.wrap(s.ClientMigrationsRequest))
 	s.mux.Handle("/v1/client/stats", wrapCORS(s.wrap(s.ClientStatsRequest)))
 	s.mux.Handle("/v1/client/allocation/", wrapCORS(s.wrap(s.ClientAllocRequest)))
 
@@ -189,13 +217,26 @@ func (s *HTTPServer) registerHandlers(enableDebug bool) {
 
 	s.mux.HandleFunc("/v1/search", s.wrap(s.SearchRequest))
 
+	s.mux.HandleFunc("/v1/event/stream", s.wrap(s.EventStream))
+	s.mux.HandleFunc("/v1/event/retention", s.wrap(s.EventRetentionRequest))
+
+	s.mux.HandleFunc("/v1/service-discovery/prometheus", s.wrap(s.PrometheusSDRequest))
+
 	s.mux.HandleFunc("/v1/operator/raft/", s.wrap(s.OperatorRequest))
 	s.mux.HandleFunc("/v1/operator/autopilot/configuration", s.wrap(s.OperatorAutopilotConfiguration))
 	s.mux.HandleFunc("/v1/operator/autopilot/health", s.wrap(s.OperatorServerHealth))
+	s.mux.HandleFunc("/v1/operator/inventory", s.wrap(s.OperatorInventoryRequest))
+	s.mux.HandleFunc("/v1/operator/utilization", s.wrap(s.OperatorUtilizationRequest))
+	s.mux.HandleFunc("/v1/operator/query", s.wrap(s.OperatorImageQueryRequest))
+	s.mux.HandleFunc("/v1/operator/deployment-concurrency", s.wrap(s.OperatorDeploymentConcurrencyRequest))
+	s.mux.HandleFunc("/v1/operator/quarantine", s.wrap(s.OperatorQuarantineRequest))
+	s.mux.HandleFunc("/v1/operator/quarantine/", s.wrap(s.OperatorQuarantineSpecificRequest))
 
 	s.mux.HandleFunc("/v1/system/gc", s.wrap(s.GarbageCollectRequest))
 	s.mux.HandleFunc("/v1/system/reconcile/summaries", s.wrap(s.ReconcileJobSummaries))
 
+	s.mux.HandleFunc("/v1/openapi.json", s.wrap(s.OpenAPIRequest))
+
 	if uiEnabled {
 		s.mux.Handle("/ui/", http.StripPrefix("/ui/", handleUI(http.FileServer(&UIAssetWrapper{FileSystem: assetFS()}))))
 	} else {
@@ -301,6 +342,9 @@ func (s *HTTPServer) wrap(handler func(resp http.ResponseWriter, req *http.Reque
 				} else if strings.HasSuffix(errMsg, structs.ErrTokenNotFound.Error()) {
 					errMsg = structs.ErrTokenNotFound.Error()
 					code = 403
+				} else if strings.HasSuffix(errMsg, structs.ErrTooManyRequests.Error()) {
+					errMsg = structs.ErrTooManyRequests.Error()
+					code = 429
 				}
 			}
 
@@ -318,27 +362,90 @@ func (s *HTTPServer) wrap(handler func(resp http.ResponseWriter, req *http.Reque
 
 		// Write out the JSON object
 		if obj != nil {
-			var buf bytes.Buffer
+			buf := newResponseBuffer(resp, "application/json", maxBufferedResponseSize)
 			if prettyPrint {
-				enc := codec.NewEncoder(&buf, structs.JsonHandlePretty)
+				enc := codec.NewEncoder(buf, structs.JsonHandlePretty)
 				err = enc.Encode(obj)
 				if err == nil {
-					buf.Write([]byte("\n"))
+					_, err = buf.Write([]byte("\n"))
 				}
 			} else {
-				enc := codec.NewEncoder(&buf, structs.JsonHandle)
+				enc := codec.NewEncoder(buf, structs.JsonHandle)
 				err = enc.Encode(obj)
 			}
-			if err != nil {
+			if err != nil && !buf.Streaming() {
 				goto HAS_ERR
 			}
-			resp.Header().Set("Content-Type", "application/json")
-			resp.Write(buf.Bytes())
+			if err == nil {
+				err = buf.Close()
+			}
+			if err != nil {
+				// Part of the response may have been sent already, so abort
+				// it to let the client know it is truncated.
+				s.logger.Printf("[WARN] http: Request %v, error writing response: %v", reqURL, err)
+				panic(http.ErrAbortHandler)
+			}
 		}
 	}
 	return f
 }
 
+// responseBuffer buffers a response up to a maximum size. Responses under the
+// size are written out when the buffer is closed, and larger ones are
+// streamed to the client in chunks as they are written. The Content-Type
+// header is only set once the response is written out, so an error can be
+// returned instead as long as the response is buffered.
+type responseBuffer struct {
+	resp        http.ResponseWriter
+	contentType string
+	max         int
+	buf         bytes.Buffer
+	w           *bufio.Writer
+}
+
+// newResponseBuffer returns a buffer of the response with the given content
+// type and maximum buffered size
+func newResponseBuffer(resp http.ResponseWriter, contentType string, max int) *responseBuffer {
+	return &responseBuffer{
+		resp:        resp,
+		contentType: contentType,
+		max:         max,
+	}
+}
+
+// Streaming returns whether the response is being streamed
+func (r *responseBuffer) Streaming() bool {
+	return r.w != nil
+}
+
+func (r *responseBuffer) Write(p []byte) (int, error) {
+	if r.w != nil {
+		return r.w.Write(p)
+	}
+	if r.buf.Len()+len(p) <= r.max {
+		return r.buf.Write(p)
+	}
+
+	// Start streaming the response beginning with what is buffered
+	r.resp.Header().Set("Content-Type", r.contentType)
+	r.w = bufio.NewWriterSize(r.resp, streamedResponseChunkSize)
+	if _, err := r.w.Write(r.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	r.buf.Reset()
+	return r.w.Write(p)
+}
+
+// Close writes out the rest of the response
+func (r *responseBuffer) Close() error {
+	if r.w != nil {
+		return r.w.Flush()
+	}
+	r.resp.Header().Set("Content-Type", r.contentType)
+	_, err := r.resp.Write(r.buf.Bytes())
+	return err
+}
+
 // decodeBody is used to decode a JSON request body
 func decodeBody(req *http.Request, out interface{}) error {
 	dec := json.NewDecoder(req.Body)
@@ -404,6 +511,21 @@ func parseWait(resp http.ResponseWriter, req *http.Request, b *structs.QueryOpti
 	return false
 }
 
+// parseIndexAt is used to parse the ?index_at query param
+// Returns true on error
+func parseIndexAt(resp http.ResponseWriter, req *http.Request, b *structs.QueryOptions) bool {
+	if idx := req.URL.Query().Get("index_at"); idx != "" {
+		index, err := strconv.ParseUint(idx, 10, 64)
+		if err != nil || index == 0 {
+			resp.WriteHeader(400)
+			resp.Write([]byte("Invalid index_at"))
+			return true
+		}
+		b.IndexAt = index
+	}
+	return false
+}
+
 // parseConsistency is used to parse the ?stale query params.
 func parseConsistency(req *http.Request, b *structs.QueryOptions) {
 	query := req.URL.Query()
@@ -453,7 +575,38 @@ func (s *HTTPServer) parse(resp http.ResponseWriter, req *http.Request, r *strin
 	parseConsistency(req, b)
 	parsePrefix(req, b)
 	parseNamespace(req, &b.Namespace)
-	return parseWait(resp, req, b)
+	if parseWait(resp, req, b) {
+		return true
+	}
+	return parseIndexAt(resp, req, b)
+}
+
+// parseCAS is used to parse the check-and-set index of a write request. The
+// index may be passed using the ?cas query parameter or the If-Match header.
+// The returned bool is true if an index was given.
+func parseCAS(req *http.Request) (uint64, bool, error) {
+	cas := req.URL.Query().Get("cas")
+	if cas == "" {
+		cas = strings.Trim(req.Header.Get("If-Match"), `"`)
+	}
+	if cas == "" {
+		return 0, false, nil
+	}
+
+	index, err := strconv.ParseUint(cas, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("Invalid check-and-set index %q: %v", cas, err)
+	}
+	return index, true, nil
+}
+
+// requestSourceIP returns the IP address of the client that made the request.
+func requestSourceIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return helper.NormalizeIP(req.RemoteAddr)
+	}
+	return helper.NormalizeIP(host)
 }
 
 // parseWriteRequest is a convience method for endpoints that need to parse a
@@ -12,6 +12,7 @@ func (s *HTTPServer) registerEnterpriseHandlers() {
 
 	s.mux.HandleFunc("/v1/sentinel/policies", s.wrap(s.entOnly))
 	s.mux.HandleFunc("/v1/sentinel/policy/", s.wrap(s.entOnly))
+	s.mux.HandleFunc("/v1/sentinel/test", s.wrap(s.entOnly))
 
 	s.mux.HandleFunc("/v1/quotas", s.wrap(s.entOnly))
 	s.mux.HandleFunc("/v1/quota-usages", s.wrap(s.entOnly))
@@ -13,6 +13,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -219,6 +220,70 @@ func testPrettyPrint(pretty string, prettyFmt bool, t *testing.T) {
 	}
 }
 
+func TestResponseBuffer(t *testing.T) {
+	t.Parallel()
+
+	// Responses under the maximum size are buffered until closed
+	resp := httptest.NewRecorder()
+	buf := newResponseBuffer(resp, "application/json", 8)
+	buf.Write([]byte("1234"))
+	buf.Write([]byte("5678"))
+	if buf.Streaming() || resp.Body.Len() != 0 || resp.Header().Get("Content-Type") != "" {
+		t.Fatalf("expected response to be buffered")
+	}
+	if err := buf.Close(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Body.String() != "12345678" || resp.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("bad: %q %v", resp.Body.String(), resp.Header())
+	}
+
+	// Larger responses are streamed
+	resp = httptest.NewRecorder()
+	buf = newResponseBuffer(resp, "application/json", 8)
+	buf.Write([]byte("1234"))
+	buf.Write([]byte("56789"))
+	if !buf.Streaming() || resp.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("expected response to be streamed")
+	}
+	if err := buf.Close(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Body.String() != "123456789" {
+		t.Fatalf("bad: %q", resp.Body.String())
+	}
+}
+
+func TestStreamedResponse(t *testing.T) {
+	t.Parallel()
+	s := makeHTTPServer(t, nil)
+	defer s.Shutdown()
+
+	// Return a list encoding to more than the maximum buffered size
+	var jobs []*structs.Job
+	for i := 0; i < maxBufferedResponseSize/1024; i++ {
+		jobs = append(jobs, &structs.Job{Name: strings.Repeat("a", 1024)})
+	}
+	handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+		return jobs, nil
+	}
+
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/jobs", nil)
+	s.Server.wrap(handler)(resp, req)
+
+	if contentType := resp.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("bad: %q", contentType)
+	}
+	var out []*structs.Job
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out) != len(jobs) {
+		t.Fatalf("bad: %d", len(out))
+	}
+}
+
 func TestPermissionDenied(t *testing.T) {
 	s := makeHTTPServer(t, func(c *Config) {
 		c.ACL.Enabled = true
@@ -266,6 +331,20 @@ func TestTokenNotFound(t *testing.T) {
 	assert.Equal(t, resp.Code, 403)
 }
 
+func TestTooManyRequests(t *testing.T) {
+	s := makeHTTPServer(t, nil)
+	defer s.Shutdown()
+
+	resp := httptest.NewRecorder()
+	handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+		return nil, fmt.Errorf("rpc error: %v", structs.ErrTooManyRequests)
+	}
+
+	req, _ := http.NewRequest("GET", "/v1/jobs", nil)
+	s.Server.wrap(handler)(resp, req)
+	assert.Equal(t, resp.Code, 429)
+}
+
 func TestParseWait(t *testing.T) {
 	t.Parallel()
 	resp := httptest.NewRecorder()
@@ -289,6 +368,35 @@ func TestParseWait(t *testing.T) {
 	}
 }
 
+func TestParseIndexAt(t *testing.T) {
+	t.Parallel()
+	resp := httptest.NewRecorder()
+	var b structs.QueryOptions
+
+	req, err := http.NewRequest("GET", "/v1/allocations?index_at=1000", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if d := parseIndexAt(resp, req, &b); d {
+		t.Fatalf("unexpected done")
+	}
+	if b.IndexAt != 1000 {
+		t.Fatalf("Bad: %v", b)
+	}
+
+	req, err = http.NewRequest("GET", "/v1/allocations?index_at=foo", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if d := parseIndexAt(resp, req, &b); !d {
+		t.Fatalf("expected done")
+	}
+	if resp.Code != 400 {
+		t.Fatalf("bad code: %v", resp.Code)
+	}
+}
+
 func TestParseWait_InvalidTime(t *testing.T) {
 	t.Parallel()
 	resp := httptest.NewRecorder()
@@ -404,6 +512,49 @@ func TestParseToken(t *testing.T) {
 	}
 }
 
+func TestParseCAS(t *testing.T) {
+	t.Parallel()
+
+	// Query parameter
+	req, err := http.NewRequest("PUT", "/v1/jobs?cas=10", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	index, ok, err := parseCAS(req)
+	if err != nil || !ok || index != 10 {
+		t.Fatalf("bad: %d %v %v", index, ok, err)
+	}
+
+	// Header
+	req, err = http.NewRequest("PUT", "/v1/jobs", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	req.Header.Add("If-Match", `"20"`)
+	index, ok, err = parseCAS(req)
+	if err != nil || !ok || index != 20 {
+		t.Fatalf("bad: %d %v %v", index, ok, err)
+	}
+
+	// Not set
+	req, err = http.NewRequest("PUT", "/v1/jobs", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok, err := parseCAS(req); err != nil || ok {
+		t.Fatalf("bad: %v %v", ok, err)
+	}
+
+	// Invalid
+	req, err = http.NewRequest("PUT", "/v1/jobs?cas=foo", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, _, err := parseCAS(req); err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
 // TestHTTP_VerifyHTTPSClient asserts that a client certificate signed by the
 // appropriate CA is required when VerifyHTTPSClient=true.
 func TestHTTP_VerifyHTTPSClient(t *testing.T) {
@@ -2,12 +2,15 @@ package agent
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/golang/snappy"
 	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/jobspec"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -46,6 +49,9 @@ func (s *HTTPServer) JobSpecificRequest(resp http.ResponseWriter, req *http.Requ
 	case strings.HasSuffix(path, "/evaluate"):
 		jobName := strings.TrimSuffix(path, "/evaluate")
 		return s.jobForceEvaluate(resp, req, jobName)
+	case strings.HasSuffix(path, "/allocations/history"):
+		jobName := strings.TrimSuffix(path, "/allocations/history")
+		return s.jobAllocationHistory(resp, req, jobName)
 	case strings.HasSuffix(path, "/allocations"):
 		jobName := strings.TrimSuffix(path, "/allocations")
 		return s.jobAllocations(resp, req, jobName)
@@ -64,12 +70,24 @@ func (s *HTTPServer) JobSpecificRequest(resp http.ResponseWriter, req *http.Requ
 	case strings.HasSuffix(path, "/dispatch"):
 		jobName := strings.TrimSuffix(path, "/dispatch")
 		return s.jobDispatchRequest(resp, req, jobName)
+	case strings.HasSuffix(path, "/dispatch-batch"):
+		jobName := strings.TrimSuffix(path, "/dispatch-batch")
+		return s.jobDispatchBatchRequest(resp, req, jobName)
 	case strings.HasSuffix(path, "/versions"):
 		jobName := strings.TrimSuffix(path, "/versions")
 		return s.jobVersions(resp, req, jobName)
+	case strings.HasSuffix(path, "/submission"):
+		jobName := strings.TrimSuffix(path, "/submission")
+		return s.jobSubmission(resp, req, jobName)
+	case strings.HasSuffix(path, "/diff"):
+		jobName := strings.TrimSuffix(path, "/diff")
+		return s.jobDiffVersions(resp, req, jobName)
 	case strings.HasSuffix(path, "/revert"):
 		jobName := strings.TrimSuffix(path, "/revert")
 		return s.jobRevert(resp, req, jobName)
+	case strings.HasSuffix(path, "/restart"):
+		jobName := strings.TrimSuffix(path, "/restart")
+		return s.jobRestart(resp, req, jobName)
 	case strings.HasSuffix(path, "/deployments"):
 		jobName := strings.TrimSuffix(path, "/deployments")
 		return s.jobDeployments(resp, req, jobName)
@@ -156,9 +174,19 @@ func (s *HTTPServer) ValidateJobRequest(resp http.ResponseWriter, req *http.Requ
 		return nil, CodedError(400, "Job must be specified")
 	}
 
+	enforcement := validateRequest.Enforcement
+	if enforcementStr := req.URL.Query().Get("enforcement"); enforcementStr != "" {
+		var err error
+		enforcement, err = strconv.ParseBool(enforcementStr)
+		if err != nil {
+			return nil, CodedError(400, fmt.Sprintf("Failed to parse value of %q (%v) as a bool: %v", "enforcement", enforcementStr, err))
+		}
+	}
+
 	job := ApiJobToStructJob(validateRequest.Job)
 	args := structs.JobValidateRequest{
-		Job: job,
+		Job:         job,
+		Enforcement: enforcement,
 		WriteRequest: structs.WriteRequest{
 			Region: validateRequest.Region,
 		},
@@ -174,6 +202,107 @@ func (s *HTTPServer) ValidateJobRequest(resp http.ResponseWriter, req *http.Requ
 	return out, nil
 }
 
+// JobsRenderRequest parses a job specification with variables, then validates
+// it and runs the enforcement checks a registration would be subject to. It
+// returns the job as it would be registered along with the diagnostics of the
+// specification, without registering it.
+func (s *HTTPServer) JobsRenderRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if !(req.Method == "POST" || req.Method == "PUT") {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var renderRequest api.JobRenderRequest
+	if err := decodeBody(req, &renderRequest); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if renderRequest.JobHCL == "" {
+		return nil, CodedError(400, "JobHCL must be specified")
+	}
+
+	var out structs.JobRenderResponse
+	job, err := jobspec.ParseWithVariables(strings.NewReader(renderRequest.JobHCL), renderRequest.Variables)
+	if err != nil {
+		for _, d := range jobspec.Diagnostics(err) {
+			out.Diagnostics = append(out.Diagnostics, &structs.JobDiagnostic{
+				Severity: structs.JobDiagnosticError,
+				Summary:  d.Summary,
+				Filename: renderRequest.Filename,
+				Line:     d.Line,
+				Column:   d.Column,
+			})
+		}
+		return out, nil
+	}
+
+	sJob := ApiJobToStructJob(job)
+	args := structs.JobValidateRequest{
+		Job:         sJob,
+		Enforcement: true,
+		WriteRequest: structs.WriteRequest{
+			Region: renderRequest.Region,
+		},
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+	args.Namespace = sJob.Namespace
+
+	var validateResp structs.JobValidateResponse
+	if err := s.agent.RPC("Job.Validate", &args, &validateResp); err != nil {
+		return nil, err
+	}
+
+	out.Job = validateResp.Job
+	out.EnforcementResults = validateResp.EnforcementResults
+	for _, e := range validateResp.ValidationErrors {
+		out.Diagnostics = append(out.Diagnostics, &structs.JobDiagnostic{
+			Severity: structs.JobDiagnosticError,
+			Summary:  e,
+			Filename: renderRequest.Filename,
+		})
+	}
+	for _, w := range splitWarnings(validateResp.Warnings) {
+		out.Diagnostics = append(out.Diagnostics, &structs.JobDiagnostic{
+			Severity: structs.JobDiagnosticWarning,
+			Summary:  w,
+			Filename: renderRequest.Filename,
+		})
+	}
+	return out, nil
+}
+
+// JobsEvaluateRequest forces the evaluation of a batch of the jobs of the
+// namespace.
+func (s *HTTPServer) JobsEvaluateRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.JobBatchEvaluateRequest{}
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	var out structs.JobBatchEvaluateResponse
+	if err := s.agent.RPC("Job.EvaluateBatch", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
+// splitWarnings splits the warnings formatted by
+// structs.MergeMultierrorWarnings into the individual warnings.
+func splitWarnings(warnings string) []string {
+	var out []string
+	for _, line := range strings.Split(warnings, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "* ") {
+			out = append(out, strings.TrimPrefix(line, "* "))
+		}
+	}
+	return out
+}
+
 func (s *HTTPServer) periodicForceRequest(resp http.ResponseWriter, req *http.Request,
 	jobName string) (interface{}, error) {
 	if req.Method != "PUT" && req.Method != "POST" {
@@ -223,6 +352,38 @@ func (s *HTTPServer) jobAllocations(resp http.ResponseWriter, req *http.Request,
 	return out.Allocations, nil
 }
 
+func (s *HTTPServer) jobAllocationHistory(resp http.ResponseWriter, req *http.Request,
+	jobName string) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.AllocHistoryRequest{
+		JobID: jobName,
+	}
+	if raw := req.URL.Query().Get("since"); raw != "" {
+		since, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, CodedError(400, fmt.Sprintf("Invalid since %q: must be a RFC 3339 timestamp", raw))
+		}
+		args.Since = since.UnixNano()
+	}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.AllocHistoryResponse
+	if err := s.agent.RPC("Alloc.History", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Allocations == nil {
+		out.Allocations = make([]*structs.ArchivedAllocation, 0)
+	}
+	return out.Allocations, nil
+}
+
 func (s *HTTPServer) jobEvaluations(resp http.ResponseWriter, req *http.Request,
 	jobName string) (interface{}, error) {
 	if req.Method != "GET" {
@@ -299,6 +460,8 @@ func (s *HTTPServer) jobCRUD(resp http.ResponseWriter, req *http.Request,
 		return s.jobQuery(resp, req, jobName)
 	case "PUT", "POST":
 		return s.jobUpdate(resp, req, jobName)
+	case "PATCH":
+		return s.jobPatch(resp, req, jobName)
 	case "DELETE":
 		return s.jobDelete(resp, req, jobName)
 	default:
@@ -345,6 +508,22 @@ func (s *HTTPServer) jobUpdate(resp http.ResponseWriter, req *http.Request,
 	if err := decodeBody(req, &args); err != nil {
 		return nil, CodedError(400, err.Error())
 	}
+
+	// Parse the job from the submitted source if only the source was given
+	var submission *structs.JobSubmission
+	if args.Submission != nil {
+		submission = ApiJobSubmissionToStructs(args.Submission)
+		if args.Job == nil {
+			if submission.Format != structs.JobSubmissionFormatHCL {
+				return nil, CodedError(400, fmt.Sprintf("unsupported submission format %q", submission.Format))
+			}
+			job, err := jobspec.ParseWithVariables(strings.NewReader(submission.Source), submission.Variables)
+			if err != nil {
+				return nil, CodedError(400, fmt.Sprintf("failed to parse submission: %v", err))
+			}
+			args.Job = job
+		}
+	}
 	if args.Job == nil {
 		return nil, CodedError(400, "Job must be specified")
 	}
@@ -356,13 +535,26 @@ func (s *HTTPServer) jobUpdate(resp http.ResponseWriter, req *http.Request,
 		return nil, CodedError(400, "Job ID does not match name")
 	}
 
+	// A check-and-set index passed as a query parameter or header overrides
+	// the one in the body
+	if index, ok, err := parseCAS(req); err != nil {
+		return nil, CodedError(400, err.Error())
+	} else if ok {
+		args.EnforceIndex = true
+		args.JobModifyIndex = index
+	}
+
 	sJob := ApiJobToStructJob(args.Job)
 
 	regReq := structs.JobRegisterRequest{
-		Job:            sJob,
-		EnforceIndex:   args.EnforceIndex,
-		JobModifyIndex: args.JobModifyIndex,
-		PolicyOverride: args.PolicyOverride,
+		Job:              sJob,
+		EnforceIndex:     args.EnforceIndex,
+		JobModifyIndex:   args.JobModifyIndex,
+		PolicyOverride:   args.PolicyOverride,
+		IdempotencyToken: args.IdempotencyToken,
+		Submission:       submission,
+		Source:           args.Source,
+		SourceIP:         requestSourceIP(req),
 		WriteRequest: structs.WriteRequest{
 			Region:    args.WriteRequest.Region,
 			AuthToken: args.WriteRequest.SecretID,
@@ -373,6 +565,49 @@ func (s *HTTPServer) jobUpdate(resp http.ResponseWriter, req *http.Request,
 
 	var out structs.JobRegisterResponse
 	if err := s.agent.RPC("Job.Register", &regReq, &out); err != nil {
+		if strings.Contains(err.Error(), api.RegisterEnforceIndexErrPrefix) {
+			return nil, CodedError(409, err.Error())
+		}
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
+// jobPatch applies the JSON merge patch of the request body to the job. Arrays
+// of named objects are merged by name, and an element with "$delete": true
+// removes the element of its name.
+func (s *HTTPServer) jobPatch(resp http.ResponseWriter, req *http.Request,
+	jobName string) (interface{}, error) {
+	if req.Body == nil {
+		return nil, CodedError(400, "Patch must be specified")
+	}
+	patch, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if len(patch) == 0 {
+		return nil, CodedError(400, "Patch must be specified")
+	}
+
+	args := structs.JobPatchRequest{
+		JobID:    jobName,
+		Patch:    patch,
+		SourceIP: requestSourceIP(req),
+	}
+	if index, ok, err := parseCAS(req); err != nil {
+		return nil, CodedError(400, err.Error())
+	} else if ok {
+		args.EnforceIndex = true
+		args.JobModifyIndex = index
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	var out structs.JobRegisterResponse
+	if err := s.agent.RPC("Job.Patch", &args, &out); err != nil {
+		if strings.Contains(err.Error(), api.RegisterEnforceIndexErrPrefix) {
+			return nil, CodedError(409, err.Error())
+		}
 		return nil, err
 	}
 	setIndex(resp, out.Index)
@@ -440,6 +675,77 @@ func (s *HTTPServer) jobVersions(resp http.ResponseWriter, req *http.Request,
 	return out, nil
 }
 
+func (s *HTTPServer) jobSubmission(resp http.ResponseWriter, req *http.Request,
+	jobName string) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.JobSubmissionRequest{
+		JobID: jobName,
+	}
+	if versionStr := req.URL.Query().Get("version"); versionStr != "" {
+		version, err := strconv.ParseUint(versionStr, 10, 64)
+		if err != nil {
+			return nil, CodedError(400, fmt.Sprintf("Failed to parse value of %q (%v) as a uint64: %v", "version", versionStr, err))
+		}
+		args.Version = &version
+	}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.JobSubmissionResponse
+	if err := s.agent.RPC("Job.GetJobSubmission", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Submission == nil {
+		return nil, CodedError(404, "job submission not found")
+	}
+	return out.Submission, nil
+}
+
+func (s *HTTPServer) jobDiffVersions(resp http.ResponseWriter, req *http.Request,
+	jobName string) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.JobDiffVersionsRequest{
+		JobID: jobName,
+	}
+
+	query := req.URL.Query()
+	for _, v := range []struct {
+		param string
+		out   *uint64
+	}{{"old", &args.OldVersion}, {"new", &args.NewVersion}} {
+		str := query.Get(v.param)
+		if str == "" {
+			return nil, CodedError(400, fmt.Sprintf("missing %q version", v.param))
+		}
+		version, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			return nil, CodedError(400, fmt.Sprintf("Failed to parse value of %q (%v) as a version: %v", v.param, str, err))
+		}
+		*v.out = version
+	}
+
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.JobDiffVersionsResponse
+	if err := s.agent.RPC("Job.DiffVersions", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	return out.Diff, nil
+}
+
 func (s *HTTPServer) jobRevert(resp http.ResponseWriter, req *http.Request,
 	jobName string) (interface{}, error) {
 
@@ -469,6 +775,35 @@ func (s *HTTPServer) jobRevert(resp http.ResponseWriter, req *http.Request,
 	return out, nil
 }
 
+func (s *HTTPServer) jobRestart(resp http.ResponseWriter, req *http.Request,
+	jobName string) (interface{}, error) {
+
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var restartRequest structs.JobRestartRequest
+	if err := decodeBody(req, &restartRequest); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if restartRequest.JobID == "" {
+		return nil, CodedError(400, "JobID must be specified")
+	}
+	if restartRequest.JobID != jobName {
+		return nil, CodedError(400, "Job ID does not match")
+	}
+
+	s.parseWriteRequest(req, &restartRequest.WriteRequest)
+
+	var out structs.JobRegisterResponse
+	if err := s.agent.RPC("Job.Restart", &restartRequest, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	return out, nil
+}
+
 func (s *HTTPServer) jobStable(resp http.ResponseWriter, req *http.Request,
 	jobName string) (interface{}, error) {
 
@@ -544,6 +879,31 @@ func (s *HTTPServer) jobDispatchRequest(resp http.ResponseWriter, req *http.Requ
 	return out, nil
 }
 
+func (s *HTTPServer) jobDispatchBatchRequest(resp http.ResponseWriter, req *http.Request, name string) (interface{}, error) {
+	if req.Method != "PUT" && req.Method != "POST" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+	args := structs.JobDispatchBatchRequest{}
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if args.JobID != "" && args.JobID != name {
+		return nil, CodedError(400, "Job ID does not match")
+	}
+	if args.JobID == "" {
+		args.JobID = name
+	}
+
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	var out structs.JobDispatchBatchResponse
+	if err := s.agent.RPC("Job.DispatchBatch", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return out, nil
+}
+
 func ApiJobToStructJob(job *api.Job) *structs.Job {
 	job.Canonicalize()
 
@@ -605,6 +965,14 @@ func ApiJobToStructJob(job *api.Job) *structs.Job {
 		}
 	}
 
+	if job.Secrets != nil {
+		j.Secrets = &structs.SecretDependencies{
+			Vault:     job.Secrets.Vault,
+			Variables: job.Secrets.Variables,
+			Consul:    job.Secrets.Consul,
+		}
+	}
+
 	if l := len(job.TaskGroups); l != 0 {
 		j.TaskGroups = make([]*structs.TaskGroup, l)
 		for i, taskGroup := range job.TaskGroups {
@@ -656,6 +1024,15 @@ func ApiTgToStructsTG(taskGroup *api.TaskGroup, tg *structs.TaskGroup) {
 		}
 	}
 
+	tg.DNS = ApiDNSConfigToStructs(taskGroup.DNS)
+
+	if taskGroup.Migrate != nil {
+		tg.Migrate = &structs.MigrateStrategy{
+			Deadline: taskGroup.Migrate.Deadline,
+			Ignore:   taskGroup.Migrate.Ignore,
+		}
+	}
+
 	if l := len(taskGroup.Tasks); l != 0 {
 		tg.Tasks = make([]*structs.Task, l)
 		for l, task := range taskGroup.Tasks {
@@ -767,10 +1144,45 @@ func ApiTaskToStructsTask(apiTask *api.Task, structsTask *structs.Task) {
 		}
 	}
 
+	if l := len(apiTask.Resources.Devices); l != 0 {
+		structsTask.Resources.Devices = make([]*structs.DeviceResource, l)
+		for i, d := range apiTask.Resources.Devices {
+			structsTask.Resources.Devices[i] = &structs.DeviceResource{
+				Type:  d.Type,
+				Name:  d.Name,
+				Count: *d.Count,
+				MBits: d.MBits,
+			}
+		}
+	}
+
+	if apiTask.Resources.Cores != nil {
+		structsTask.Resources.Cores = *apiTask.Resources.Cores
+	}
+	if apiTask.Resources.IOWeight != nil {
+		structsTask.Resources.IOWeight = *apiTask.Resources.IOWeight
+	}
+	if apiTask.Resources.IOReadMBps != nil {
+		structsTask.Resources.IOReadMBps = *apiTask.Resources.IOReadMBps
+	}
+	if apiTask.Resources.IOWriteMBps != nil {
+		structsTask.Resources.IOWriteMBps = *apiTask.Resources.IOWriteMBps
+	}
+
 	structsTask.LogConfig = &structs.LogConfig{
 		MaxFiles:      *apiTask.LogConfig.MaxFiles,
 		MaxFileSizeMB: *apiTask.LogConfig.MaxFileSizeMB,
 	}
+	if l := len(apiTask.LogConfig.Sinks); l != 0 {
+		structsTask.LogConfig.Sinks = make([]*structs.LogSink, l)
+		for i, sink := range apiTask.LogConfig.Sinks {
+			structsTask.LogConfig.Sinks[i] = &structs.LogSink{
+				Type:    sink.Type,
+				Address: sink.Address,
+				Labels:  sink.Labels,
+			}
+		}
+	}
 
 	if l := len(apiTask.Artifacts); l != 0 {
 		structsTask.Artifacts = make([]*structs.TaskArtifact, l)
@@ -812,11 +1224,54 @@ func ApiTaskToStructsTask(apiTask *api.Task, structsTask *structs.Task) {
 		}
 	}
 
+	if l := len(apiTask.ConnectivityChecks); l != 0 {
+		structsTask.ConnectivityChecks = make([]*structs.ConnectivityCheck, l)
+		for i, check := range apiTask.ConnectivityChecks {
+			structsTask.ConnectivityChecks[i] = &structs.ConnectivityCheck{
+				Name:      check.Name,
+				Type:      check.Type,
+				Address:   check.Address,
+				PortLabel: check.PortLabel,
+				Path:      check.Path,
+				Interval:  check.Interval,
+				Timeout:   check.Timeout,
+			}
+		}
+	}
+
 	if apiTask.DispatchPayload != nil {
 		structsTask.DispatchPayload = &structs.DispatchPayloadConfig{
 			File: apiTask.DispatchPayload.File,
 		}
 	}
+
+	structsTask.DNS = ApiDNSConfigToStructs(apiTask.DNS)
+}
+
+// ApiDNSConfigToStructs converts a DNS config, returning nil if it isn't set
+func ApiDNSConfigToStructs(dns *api.DNSConfig) *structs.DNSConfig {
+	if dns == nil {
+		return nil
+	}
+	return &structs.DNSConfig{
+		Servers:  dns.Servers,
+		Searches: dns.Searches,
+		Options:  dns.Options,
+	}
+}
+
+// ApiJobSubmissionToStructs converts the submitted source of a job. The format
+// defaults to HCL.
+func ApiJobSubmissionToStructs(sub *api.JobSubmission) *structs.JobSubmission {
+	format := sub.Format
+	if format == "" {
+		format = structs.JobSubmissionFormatHCL
+	}
+	return &structs.JobSubmission{
+		Source:    sub.Source,
+		Format:    format,
+		Variables: sub.Variables,
+	}
 }
 
 func ApiConstraintToStructs(c1 *api.Constraint, c2 *structs.Constraint) {
@@ -1,8 +1,12 @@
 package agent
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
@@ -13,6 +17,7 @@ import (
 	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/kr/pretty"
 	"github.com/stretchr/testify/assert"
 )
@@ -432,6 +437,144 @@ func TestHTTP_JobUpdate(t *testing.T) {
 	})
 }
 
+func TestHTTP_JobUpdate_Provenance(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		assert := assert.New(t)
+
+		// Register the job with a source
+		job := api.MockJob()
+		args := api.JobRegisterRequest{
+			Job:    job,
+			Source: "git:4b2e82d",
+			WriteRequest: api.WriteRequest{
+				Region:    "global",
+				Namespace: api.DefaultNamespace,
+			},
+		}
+		req, err := http.NewRequest("PUT", "/v1/job/"+*job.ID, encodeReq(args))
+		assert.Nil(err)
+		req.RemoteAddr = "10.1.2.3:51234"
+		respW := httptest.NewRecorder()
+		_, err = s.Server.JobSpecificRequest(respW, req)
+		assert.Nil(err)
+
+		// The source and the address of the client are recorded
+		getReq := structs.JobSpecificRequest{
+			JobID: *job.ID,
+			QueryOptions: structs.QueryOptions{
+				Region:    "global",
+				Namespace: structs.DefaultNamespace,
+			},
+		}
+		var getResp structs.SingleJobResponse
+		assert.Nil(s.Agent.RPC("Job.GetJob", &getReq, &getResp))
+		if assert.NotNil(getResp.Job) && assert.NotNil(getResp.Job.Provenance) {
+			assert.Equal("git:4b2e82d", getResp.Job.Provenance.Source)
+			assert.Equal("10.1.2.3", getResp.Job.Provenance.SourceIP)
+			assert.Empty(getResp.Job.Provenance.TokenAccessor)
+		}
+	})
+}
+
+func TestHTTP_JobPatch(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		assert := assert.New(t)
+
+		// Create the job
+		job := mock.Job()
+		args := structs.JobRegisterRequest{
+			Job: job,
+			WriteRequest: structs.WriteRequest{
+				Region:    "global",
+				Namespace: structs.DefaultNamespace,
+			},
+		}
+		var resp structs.JobRegisterResponse
+		assert.Nil(s.Agent.RPC("Job.Register", &args, &resp))
+
+		// Patch the job
+		body := bytes.NewBufferString(`{"Priority": 75}`)
+		req, err := http.NewRequest("PATCH", "/v1/job/"+job.ID, body)
+		assert.Nil(err)
+		respW := httptest.NewRecorder()
+
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		assert.Nil(err)
+		patchResp := obj.(structs.JobRegisterResponse)
+		assert.NotEmpty(patchResp.EvalID)
+		assert.NotEmpty(respW.HeaderMap.Get("X-Nomad-Index"))
+
+		// Check the job was updated
+		getReq := structs.JobSpecificRequest{
+			JobID: job.ID,
+			QueryOptions: structs.QueryOptions{
+				Region:    "global",
+				Namespace: structs.DefaultNamespace,
+			},
+		}
+		var getResp structs.SingleJobResponse
+		assert.Nil(s.Agent.RPC("Job.GetJob", &getReq, &getResp))
+		if assert.NotNil(getResp.Job) {
+			assert.Equal(75, getResp.Job.Priority)
+			assert.EqualValues(1, getResp.Job.Version)
+		}
+
+		// A stale check-and-set index conflicts
+		body = bytes.NewBufferString(`{"Priority": 80}`)
+		req, err = http.NewRequest("PATCH", fmt.Sprintf("/v1/job/%s?cas=%d", job.ID, resp.JobModifyIndex), body)
+		assert.Nil(err)
+		_, err = s.Server.JobSpecificRequest(respW, req)
+		if assert.NotNil(err) {
+			codedErr, ok := err.(HTTPCodedError)
+			if assert.True(ok) {
+				assert.Equal(409, codedErr.Code())
+			}
+		}
+	})
+}
+
+func TestHTTP_JobAllocationHistory(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cb := func(c *Config) {
+		c.AllocArchive = &config.AllocArchiveConfig{
+			Enabled: helper.BoolToPtr(true),
+			Store:   "file",
+			Path:    dir,
+		}
+	}
+	httpTest(t, cb, func(s *TestAgent) {
+		assert := assert.New(t)
+
+		// A job without archived allocations has an empty history
+		req, err := http.NewRequest("GET", "/v1/job/example/allocations/history?since=2017-10-01T00:00:00Z", nil)
+		assert.Nil(err)
+		respW := httptest.NewRecorder()
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		assert.Nil(err)
+		assert.Equal([]*structs.ArchivedAllocation{}, obj)
+		assert.NotEmpty(respW.HeaderMap.Get("X-Nomad-Index"))
+
+		// Invalid timestamps are rejected
+		req, err = http.NewRequest("GET", "/v1/job/example/allocations/history?since=30d", nil)
+		assert.Nil(err)
+		_, err = s.Server.JobSpecificRequest(httptest.NewRecorder(), req)
+		if assert.NotNil(err) {
+			codedErr, ok := err.(HTTPCodedError)
+			if assert.True(ok) {
+				assert.Equal(400, codedErr.Code())
+			}
+		}
+	})
+}
+
 func TestHTTP_JobDelete(t *testing.T) {
 	t.Parallel()
 	httpTest(t, nil, func(s *TestAgent) {
@@ -855,6 +998,139 @@ func TestHTTP_JobVersions(t *testing.T) {
 	})
 }
 
+func TestHTTP_JobSubmission(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		assert := assert.New(t)
+
+		// Register a job from its source
+		source := `
+job "${var.name}" {
+  datacenters = ["dc1"]
+  group "web" {
+    task "web" {
+      driver = "exec"
+      config {
+        command = "/bin/date"
+      }
+    }
+  }
+}
+`
+		args := api.JobRegisterRequest{
+			Submission: &api.JobSubmission{
+				Source:    source,
+				Variables: map[string]string{"name": "example"},
+			},
+			WriteRequest: api.WriteRequest{Region: "global"},
+		}
+		req, err := http.NewRequest("PUT", "/v1/jobs", encodeReq(args))
+		assert.Nil(err)
+		_, err = s.Server.JobsRequest(httptest.NewRecorder(), req)
+		assert.Nil(err)
+
+		// Read the source back
+		req, err = http.NewRequest("GET", "/v1/job/example/submission?version=0", nil)
+		assert.Nil(err)
+		respW := httptest.NewRecorder()
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		assert.Nil(err)
+		assert.NotEmpty(respW.HeaderMap.Get("X-Nomad-Index"))
+
+		sub := obj.(*structs.JobSubmission)
+		assert.Equal(source, sub.Source)
+		assert.Equal(structs.JobSubmissionFormatHCL, sub.Format)
+		assert.Equal("example", sub.Variables["name"])
+		assert.EqualValues(0, sub.Version)
+
+		// Undefined variables are rejected
+		args.Submission.Variables = nil
+		req, err = http.NewRequest("PUT", "/v1/jobs", encodeReq(args))
+		assert.Nil(err)
+		_, err = s.Server.JobsRequest(httptest.NewRecorder(), req)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), `variable "name" is not set`)
+	})
+}
+
+func TestHTTP_JobsRender(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		assert := assert.New(t)
+
+		source := `
+job "${var.name}" {
+  datacenters = ["dc1"]
+  group "web" {
+    task "web" {
+      driver = "exec"
+      config {
+        command = "/bin/date"
+      }
+    }
+  }
+}
+`
+		args := api.JobRenderRequest{
+			JobHCL:       source,
+			Variables:    map[string]string{"name": "example"},
+			Filename:     "example.nomad",
+			WriteRequest: api.WriteRequest{Region: "global"},
+		}
+		req, err := http.NewRequest("POST", "/v1/jobs/render", encodeReq(args))
+		assert.Nil(err)
+		obj, err := s.Server.JobsRenderRequest(httptest.NewRecorder(), req)
+		assert.Nil(err)
+
+		// The canonical job is returned without being registered
+		out := obj.(structs.JobRenderResponse)
+		if assert.NotNil(out.Job) {
+			assert.Equal("example", out.Job.ID)
+			assert.Equal(structs.JobTypeService, out.Job.Type)
+		}
+		for _, d := range out.Diagnostics {
+			assert.NotEqual(structs.JobDiagnosticError, d.Severity, d.Summary)
+		}
+		assert.NotEmpty(out.EnforcementResults)
+
+		job, err := s.Agent.server.State().JobByID(nil, structs.DefaultNamespace, "example")
+		assert.Nil(err)
+		assert.Nil(job)
+
+		// Parse errors are positioned in the source
+		args.JobHCL = strings.Replace(source, "driver", "drvier", 1)
+		req, err = http.NewRequest("POST", "/v1/jobs/render", encodeReq(args))
+		assert.Nil(err)
+		obj, err = s.Server.JobsRenderRequest(httptest.NewRecorder(), req)
+		assert.Nil(err)
+
+		out = obj.(structs.JobRenderResponse)
+		assert.Nil(out.Job)
+		if assert.Len(out.Diagnostics, 1) {
+			d := out.Diagnostics[0]
+			assert.Equal(structs.JobDiagnosticError, d.Severity)
+			assert.Contains(d.Summary, "invalid key: drvier")
+			assert.Equal("example.nomad", d.Filename)
+			assert.Equal(6, d.Line)
+			assert.Equal(7, d.Column)
+		}
+
+		// Validation errors are returned as diagnostics
+		args.JobHCL = strings.Replace(source, `datacenters = ["dc1"]`, "", 1)
+		req, err = http.NewRequest("POST", "/v1/jobs/render", encodeReq(args))
+		assert.Nil(err)
+		obj, err = s.Server.JobsRenderRequest(httptest.NewRecorder(), req)
+		assert.Nil(err)
+
+		out = obj.(structs.JobRenderResponse)
+		assert.NotNil(out.Job)
+		assert.Empty(out.EnforcementResults)
+		if assert.NotEmpty(out.Diagnostics) {
+			assert.Contains(out.Diagnostics[0].Summary, "datacenters")
+		}
+	})
+}
+
 func TestHTTP_PeriodicForce(t *testing.T) {
 	t.Parallel()
 	httpTest(t, nil, func(s *TestAgent) {
@@ -993,6 +1269,66 @@ func TestHTTP_JobDispatch(t *testing.T) {
 	})
 }
 
+func TestHTTP_JobDispatchBatch(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		// Create the parameterized job
+		job := mock.Job()
+		job.Type = "batch"
+		job.ParameterizedJob = &structs.ParameterizedJobConfig{}
+
+		args := structs.JobRegisterRequest{
+			Job: job,
+			WriteRequest: structs.WriteRequest{
+				Region:    "global",
+				Namespace: structs.DefaultNamespace,
+			},
+		}
+		var resp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &args, &resp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Make the request
+		respW := httptest.NewRecorder()
+		args2 := structs.JobDispatchBatchRequest{
+			Items: []*structs.JobDispatchItem{{}, {}},
+			WriteRequest: structs.WriteRequest{
+				Region:    "global",
+				Namespace: structs.DefaultNamespace,
+			},
+		}
+		buf := encodeReq(args2)
+
+		// Make the HTTP request
+		req2, err := http.NewRequest("PUT", "/v1/job/"+job.ID+"/dispatch-batch", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW.Flush()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req2)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		dispatch := obj.(structs.JobDispatchBatchResponse)
+		if len(dispatch.Results) != 2 {
+			t.Fatalf("bad: %v", dispatch)
+		}
+		for _, result := range dispatch.Results {
+			if result.Error != "" || result.DispatchedJobID == "" || result.EvalID == "" {
+				t.Fatalf("bad: %#v", result)
+			}
+		}
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+	})
+}
+
 func TestHTTP_JobRevert(t *testing.T) {
 	t.Parallel()
 	httpTest(t, nil, func(s *TestAgent) {
@@ -1052,6 +1388,58 @@ func TestHTTP_JobRevert(t *testing.T) {
 	})
 }
 
+func TestHTTP_JobRestart(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		// Create the job
+		job := mock.Job()
+		regReq := structs.JobRegisterRequest{
+			Job: job,
+			WriteRequest: structs.WriteRequest{
+				Region:    "global",
+				Namespace: structs.DefaultNamespace,
+			},
+		}
+		var regResp structs.JobRegisterResponse
+		if err := s.Agent.RPC("Job.Register", &regReq, &regResp); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		args := structs.JobRestartRequest{
+			JobID: job.ID,
+			WriteRequest: structs.WriteRequest{
+				Region:    "global",
+				Namespace: structs.DefaultNamespace,
+			},
+		}
+		buf := encodeReq(args)
+
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/job/"+job.ID+"/restart", buf)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.JobSpecificRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// Check the response
+		restartResp := obj.(structs.JobRegisterResponse)
+		if restartResp.EvalID == "" {
+			t.Fatalf("bad: %v", restartResp)
+		}
+
+		// Check for the index
+		if respW.HeaderMap.Get("X-Nomad-Index") == "" {
+			t.Fatalf("missing index")
+		}
+	})
+}
+
 func TestHTTP_JobStable(t *testing.T) {
 	t.Parallel()
 	httpTest(t, nil, func(s *TestAgent) {
@@ -1149,7 +1537,12 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 			Payload:      "payload",
 			MetaRequired: []string{"a", "b"},
 			MetaOptional: []string{"c", "d"},
+		}, Secrets: &api.SecretDependencies{
+			Vault:     []string{"secret/data/foo"},
+			Variables: []string{"nomad/jobs/foo"},
+			Consul:    []string{"config/foo"},
 		},
+
 		Payload: []byte("payload"),
 		Meta: map[string]string{
 			"foo": "bar",
@@ -1171,6 +1564,14 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 					Delay:    helper.TimeToPtr(10 * time.Second),
 					Mode:     helper.StringToPtr("delay"),
 				},
+				DNS: &api.DNSConfig{
+					Servers:  []string{"10.0.0.53"},
+					Searches: []string{"example.com"},
+					Options:  []string{"ndots:2"},
+				},
+				Migrate: &api.MigrateStrategy{
+					Deadline: 30 * time.Minute,
+				},
 				EphemeralDisk: &api.EphemeralDisk{
 					SizeMB:  helper.IntToPtr(100),
 					Sticky:  helper.BoolToPtr(true),
@@ -1276,6 +1677,13 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 						LogConfig: &api.LogConfig{
 							MaxFiles:      helper.IntToPtr(10),
 							MaxFileSizeMB: helper.IntToPtr(100),
+							Sinks: []*api.LogSink{
+								{
+									Type:    "syslog",
+									Address: "udp://127.0.0.1:514",
+									Labels:  map[string]string{"tag": "web"},
+								},
+							},
 						},
 						Artifacts: []*api.TaskArtifact{
 							{
@@ -1311,6 +1719,9 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 						DispatchPayload: &api.DispatchPayloadConfig{
 							File: "fileA",
 						},
+						DNS: &api.DNSConfig{
+							Servers: []string{"10.0.0.54"},
+						},
 					},
 				},
 			},
@@ -1357,7 +1768,12 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 			Payload:      "payload",
 			MetaRequired: []string{"a", "b"},
 			MetaOptional: []string{"c", "d"},
+		}, Secrets: &structs.SecretDependencies{
+			Vault:     []string{"secret/data/foo"},
+			Variables: []string{"nomad/jobs/foo"},
+			Consul:    []string{"config/foo"},
 		},
+
 		Payload: []byte("payload"),
 		Meta: map[string]string{
 			"foo": "bar",
@@ -1379,6 +1795,14 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 					Delay:    10 * time.Second,
 					Mode:     "delay",
 				},
+				DNS: &structs.DNSConfig{
+					Servers:  []string{"10.0.0.53"},
+					Searches: []string{"example.com"},
+					Options:  []string{"ndots:2"},
+				},
+				Migrate: &structs.MigrateStrategy{
+					Deadline: 30 * time.Minute,
+				},
 				EphemeralDisk: &structs.EphemeralDisk{
 					SizeMB:  100,
 					Sticky:  true,
@@ -1484,6 +1908,13 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 						LogConfig: &structs.LogConfig{
 							MaxFiles:      10,
 							MaxFileSizeMB: 100,
+							Sinks: []*structs.LogSink{
+								{
+									Type:    "syslog",
+									Address: "udp://127.0.0.1:514",
+									Labels:  map[string]string{"tag": "web"},
+								},
+							},
 						},
 						Artifacts: []*structs.TaskArtifact{
 							{
@@ -1519,6 +1950,9 @@ func TestJobs_ApiJobToStructsJob(t *testing.T) {
 						DispatchPayload: &structs.DispatchPayloadConfig{
 							File: "fileA",
 						},
+						DNS: &structs.DNSConfig{
+							Servers: []string{"10.0.0.54"},
+						},
 					},
 				},
 			},
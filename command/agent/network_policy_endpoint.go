@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func (s *HTTPServer) NetworkPoliciesRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.NetworkPolicyListRequest{}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.NetworkPolicyListResponse
+	if err := s.agent.RPC("NetworkPolicy.List", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Policies == nil {
+		out.Policies = make([]*structs.NetworkPolicy, 0)
+	}
+	return out.Policies, nil
+}
+
+func (s *HTTPServer) NetworkPolicySpecificRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	name := strings.TrimPrefix(req.URL.Path, "/v1/network-policy/")
+	if len(name) == 0 {
+		return nil, CodedError(400, "Missing Policy Name")
+	}
+	switch req.Method {
+	case "GET":
+		return s.networkPolicyQuery(resp, req, name)
+	case "PUT", "POST":
+		return s.networkPolicyUpdate(resp, req, name)
+	case "DELETE":
+		return s.networkPolicyDelete(resp, req, name)
+	default:
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+}
+
+func (s *HTTPServer) networkPolicyQuery(resp http.ResponseWriter, req *http.Request,
+	policyName string) (interface{}, error) {
+	args := structs.NetworkPolicySpecificRequest{
+		Name: policyName,
+	}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.SingleNetworkPolicyResponse
+	if err := s.agent.RPC("NetworkPolicy.GetPolicy", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.Policy == nil {
+		return nil, CodedError(404, "network policy not found")
+	}
+	return out.Policy, nil
+}
+
+func (s *HTTPServer) networkPolicyUpdate(resp http.ResponseWriter, req *http.Request,
+	policyName string) (interface{}, error) {
+	// Parse the policy
+	var policy structs.NetworkPolicy
+	if err := decodeBody(req, &policy); err != nil {
+		return nil, CodedError(500, err.Error())
+	}
+
+	// Ensure the policy name matches
+	if policy.Name != policyName {
+		return nil, CodedError(400, "Network policy name does not match request path")
+	}
+
+	// Format the request
+	args := structs.NetworkPolicyUpsertRequest{
+		Policies: []*structs.NetworkPolicy{&policy},
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	// The policy defaults to the namespace of the request
+	if policy.Namespace == "" {
+		policy.Namespace = args.RequestNamespace()
+	}
+
+	var out structs.GenericResponse
+	if err := s.agent.RPC("NetworkPolicy.UpsertPolicies", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return nil, nil
+}
+
+func (s *HTTPServer) networkPolicyDelete(resp http.ResponseWriter, req *http.Request,
+	policyName string) (interface{}, error) {
+
+	args := structs.NetworkPolicyDeleteRequest{
+		Names: []string{policyName},
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	var out structs.GenericResponse
+	if err := s.agent.RPC("NetworkPolicy.DeletePolicies", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return nil, nil
+}
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP_NetworkPolicyCRUD(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		assert := assert.New(t)
+
+		// Upsert a policy into the namespace of the request
+		policy := &structs.NetworkPolicy{
+			Name:        "db",
+			Services:    []string{"db"},
+			DefaultDeny: true,
+			Rules: []*structs.NetworkPolicyRule{
+				{Action: structs.NetworkPolicyActionAllow, Service: "web", Ports: []int{5432}},
+			},
+		}
+		req, err := http.NewRequest("PUT", "/v1/network-policy/db", encodeReq(policy))
+		assert.Nil(err)
+		respW := httptest.NewRecorder()
+		_, err = s.Server.NetworkPolicySpecificRequest(respW, req)
+		assert.Nil(err)
+		assert.NotEmpty(respW.HeaderMap.Get("X-Nomad-Index"))
+
+		// The name must match the path
+		req, err = http.NewRequest("PUT", "/v1/network-policy/other", encodeReq(policy))
+		assert.Nil(err)
+		_, err = s.Server.NetworkPolicySpecificRequest(httptest.NewRecorder(), req)
+		assert.NotNil(err)
+
+		// Read it back
+		req, err = http.NewRequest("GET", "/v1/network-policy/db", nil)
+		assert.Nil(err)
+		obj, err := s.Server.NetworkPolicySpecificRequest(httptest.NewRecorder(), req)
+		assert.Nil(err)
+		out := obj.(*structs.NetworkPolicy)
+		assert.Equal(structs.DefaultNamespace, out.Namespace)
+		assert.True(out.DefaultDeny)
+
+		// List the policies
+		req, err = http.NewRequest("GET", "/v1/network-policies", nil)
+		assert.Nil(err)
+		obj, err = s.Server.NetworkPoliciesRequest(httptest.NewRecorder(), req)
+		assert.Nil(err)
+		assert.Len(obj.([]*structs.NetworkPolicy), 1)
+
+		// Delete it
+		req, err = http.NewRequest("DELETE", "/v1/network-policy/db", nil)
+		assert.Nil(err)
+		_, err = s.Server.NetworkPolicySpecificRequest(httptest.NewRecorder(), req)
+		assert.Nil(err)
+
+		req, err = http.NewRequest("GET", "/v1/network-policy/db", nil)
+		assert.Nil(err)
+		_, err = s.Server.NetworkPolicySpecificRequest(httptest.NewRecorder(), req)
+		if assert.NotNil(err) {
+			assert.Contains(err.Error(), "not found")
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"net/http"
+
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func (s *HTTPServer) ClientMetaRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if s.agent.client == nil {
+		return nil, clientNotRunning
+	}
+
+	switch req.Method {
+	case "GET":
+		return s.clientMetaRead(resp, req)
+	case "PUT", "POST":
+		return s.clientMetaApply(resp, req)
+	default:
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+}
+
+func (s *HTTPServer) clientMetaRead(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var secret string
+	s.parseToken(req, &secret)
+
+	// Check node read permissions
+	if aclObj, err := s.agent.Client().ResolveToken(secret); err != nil {
+		return nil, err
+	} else if aclObj != nil && !aclObj.AllowNodeRead() {
+		return nil, structs.ErrPermissionDenied
+	}
+
+	return s.agent.Client().NodeMeta(), nil
+}
+
+func (s *HTTPServer) clientMetaApply(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var secret string
+	s.parseToken(req, &secret)
+
+	// Check node write permissions
+	if aclObj, err := s.agent.Client().ResolveToken(secret); err != nil {
+		return nil, err
+	} else if aclObj != nil && !aclObj.AllowNodeWrite() {
+		return nil, structs.ErrPermissionDenied
+	}
+
+	var args cstructs.NodeMetaApplyRequest
+	if err := decodeBody(req, &args); err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	if len(args.Meta) == 0 {
+		return nil, CodedError(400, "missing metadata to apply")
+	}
+
+	out, err := s.agent.Client().ApplyNodeMeta(args.Meta)
+	if err != nil {
+		return nil, CodedError(400, err.Error())
+	}
+	return out, nil
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/acl"
+	cstructs "github.com/hashicorp/nomad/client/structs"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientMetaRequest(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	httpTest(t, nil, func(s *TestAgent) {
+		// Apply the metadata
+		args := cstructs.NodeMetaApplyRequest{
+			Meta: map[string]*string{"rack": helper.StringToPtr("r2")},
+		}
+		buf, err := json.Marshal(args)
+		require.NoError(err)
+		req, err := http.NewRequest("PUT", "/v1/client/metadata", bytes.NewReader(buf))
+		require.NoError(err)
+		respW := httptest.NewRecorder()
+		obj, err := s.Server.ClientMetaRequest(respW, req)
+		require.NoError(err)
+		require.Equal("r2", obj.(*cstructs.NodeMetaResponse).Meta["rack"])
+
+		// Read it back
+		req, err = http.NewRequest("GET", "/v1/client/metadata", nil)
+		require.NoError(err)
+		respW = httptest.NewRecorder()
+		obj, err = s.Server.ClientMetaRequest(respW, req)
+		require.NoError(err)
+		meta := obj.(*cstructs.NodeMetaResponse)
+		require.Equal("r2", meta.Meta["rack"])
+		require.Equal("r2", *meta.Dynamic["rack"])
+
+		// Applying no metadata fails
+		req, err = http.NewRequest("PUT", "/v1/client/metadata", bytes.NewReader([]byte("{}")))
+		require.NoError(err)
+		respW = httptest.NewRecorder()
+		_, err = s.Server.ClientMetaRequest(respW, req)
+		require.Error(err)
+	})
+}
+
+func TestClientMetaRequest_ACL(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	httpACLTest(t, nil, func(s *TestAgent) {
+		state := s.Agent.server.State()
+		body := []byte(`{"Meta": {"rack": "r2"}}`)
+
+		// Try request without a token and expect failure
+		{
+			req, err := http.NewRequest("PUT", "/v1/client/metadata", bytes.NewReader(body))
+			require.NoError(err)
+			respW := httptest.NewRecorder()
+			_, err = s.Server.ClientMetaRequest(respW, req)
+			require.Equal(structs.ErrPermissionDenied.Error(), err.Error())
+		}
+
+		// Try request with a read token and expect failure
+		{
+			req, err := http.NewRequest("PUT", "/v1/client/metadata", bytes.NewReader(body))
+			require.NoError(err)
+			respW := httptest.NewRecorder()
+			token := mock.CreatePolicyAndToken(t, state, 1005, "read", mock.NodePolicy(acl.PolicyRead))
+			setToken(req, token)
+			_, err = s.Server.ClientMetaRequest(respW, req)
+			require.Equal(structs.ErrPermissionDenied.Error(), err.Error())
+		}
+
+		// Try request with a write token
+		{
+			req, err := http.NewRequest("PUT", "/v1/client/metadata", bytes.NewReader(body))
+			require.NoError(err)
+			respW := httptest.NewRecorder()
+			token := mock.CreatePolicyAndToken(t, state, 1007, "write", mock.NodePolicy(acl.PolicyWrite))
+			setToken(req, token)
+			_, err = s.Server.ClientMetaRequest(respW, req)
+			require.NoError(err)
+		}
+	})
+}
@@ -0,0 +1,441 @@
+package agent
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/version"
+)
+
+// openAPIRoute describes a single HTTP operation exposed by the agent. The
+// request and response bodies are described using the api package structs,
+// which mirror the JSON the agent reads and writes.
+type openAPIRoute struct {
+	Method   string
+	Path     string
+	Tag      string
+	Summary  string
+	Request  interface{}
+	Response interface{}
+	Blocking bool
+}
+
+// openAPIRoutes is the route table used to generate the OpenAPI document. It
+// must be kept in sync with registerHandlers and the per endpoint path
+// dispatchers.
+var openAPIRoutes = []openAPIRoute{
+	{"GET", "/v1/jobs", "jobs", "List jobs", nil, []*api.JobListStub{}, true},
+	{"PUT", "/v1/jobs", "jobs", "Register a job", &api.RegisterJobRequest{}, &api.JobRegisterResponse{}, false},
+	{"PUT", "/v1/jobs/evaluate", "jobs", "Create evaluations for a batch of jobs", &api.JobBatchEvaluateRequest{}, &api.JobBatchEvaluateResponse{}, false},
+	{"POST", "/v1/jobs/render", "jobs", "Render a job file", &api.JobRenderRequest{}, &api.JobRenderResponse{}, false},
+	{"GET", "/v1/job/{job_id}", "jobs", "Read a job", nil, &api.Job{}, true},
+	{"PUT", "/v1/job/{job_id}", "jobs", "Update a job", &api.RegisterJobRequest{}, &api.JobRegisterResponse{}, false},
+	{"PATCH", "/v1/job/{job_id}", "jobs", "Patch a job", map[string]interface{}{}, &api.JobRegisterResponse{}, false},
+	{"DELETE", "/v1/job/{job_id}", "jobs", "Deregister a job", nil, &api.JobDeregisterResponse{}, false},
+	{"GET", "/v1/job/{job_id}/versions", "jobs", "List job versions", nil, &api.JobVersionsResponse{}, true},
+	{"GET", "/v1/job/{job_id}/submission", "jobs", "Read the source a job version was submitted with", nil, &api.JobSubmission{}, true},
+	{"GET", "/v1/job/{job_id}/diff", "jobs", "Diff two job versions", nil, &api.JobDiff{}, true},
+	{"GET", "/v1/job/{job_id}/allocations", "jobs", "List job allocations", nil, []*api.AllocationListStub{}, true},
+	{"GET", "/v1/job/{job_id}/allocations/history", "jobs", "List archived job allocations", nil, []*api.ArchivedAllocation{}, true},
+	{"GET", "/v1/job/{job_id}/evaluations", "jobs", "List job evaluations", nil, []*api.Evaluation{}, true},
+	{"GET", "/v1/job/{job_id}/deployments", "jobs", "List job deployments", nil, []*api.Deployment{}, true},
+	{"GET", "/v1/job/{job_id}/deployment", "jobs", "Read the most recent job deployment", nil, &api.Deployment{}, true},
+	{"GET", "/v1/job/{job_id}/summary", "jobs", "Read a job summary", nil, &api.JobSummary{}, true},
+	{"PUT", "/v1/job/{job_id}/evaluate", "jobs", "Create a job evaluation", nil, &api.JobRegisterResponse{}, false},
+	{"PUT", "/v1/job/{job_id}/plan", "jobs", "Plan a job", &api.JobPlanRequest{}, &api.JobPlanResponse{}, false},
+	{"PUT", "/v1/job/{job_id}/dispatch", "jobs", "Dispatch a parameterized job", &api.JobDispatchRequest{}, &api.JobDispatchResponse{}, false},
+	{"PUT", "/v1/job/{job_id}/dispatch-batch", "jobs", "Dispatch a parameterized job many times", &api.JobDispatchBatchRequest{}, &api.JobDispatchBatchResponse{}, false},
+	{"PUT", "/v1/job/{job_id}/revert", "jobs", "Revert a job to an older version", &api.JobRevertRequest{}, &api.JobRegisterResponse{}, false},
+	{"PUT", "/v1/job/{job_id}/restart", "jobs", "Restart the allocations of a job", &api.JobRestartRequest{}, &api.JobRegisterResponse{}, false},
+	{"PUT", "/v1/job/{job_id}/stable", "jobs", "Set job stability", &api.JobStabilityRequest{}, &api.JobStabilityResponse{}, false},
+	{"PUT", "/v1/job/{job_id}/periodic/force", "jobs", "Force a new periodic instance", nil, &api.JobRegisterResponse{}, false},
+	{"PUT", "/v1/validate/job", "jobs", "Validate a job", &api.JobValidateRequest{}, &api.JobValidateResponse{}, false},
+
+	{"GET", "/v1/nodes", "nodes", "List nodes", nil, []*api.NodeListStub{}, true},
+	{"GET", "/v1/node/{node_id}", "nodes", "Read a node", nil, &api.Node{}, true},
+	{"GET", "/v1/node/{node_id}/allocations", "nodes", "List node allocations", nil, []*api.Allocation{}, true},
+	{"PUT", "/v1/node/{node_id}/evaluate", "nodes", "Create a node evaluation", nil, nil, false},
+	{"PUT", "/v1/node/{node_id}/drain", "nodes", "Toggle node drain mode", nil, nil, false},
+	{"PUT", "/v1/node/{node_id}/purge", "nodes", "Purge a node", nil, nil, false},
+
+	{"GET", "/v1/allocations", "allocations", "List allocations", nil, []*api.AllocationListStub{}, true},
+	{"PUT", "/v1/allocations/stop", "allocations", "Stop a batch of allocations", &api.AllocBatchStopRequest{}, &api.AllocBatchStopResponse{}, false},
+	{"GET", "/v1/allocation/{alloc_id}", "allocations", "Read an allocation", nil, &api.Allocation{}, true},
+
+	{"GET", "/v1/evaluations", "evaluations", "List evaluations", nil, []*api.Evaluation{}, true},
+	{"DELETE", "/v1/evaluations", "evaluations", "Delete evaluations by ID", &api.EvalDeleteRequest{}, &api.EvalDeleteResponse{}, false},
+	{"PUT", "/v1/evaluations/delete", "evaluations", "Delete a batch of evaluations", &api.EvalBatchDeleteRequest{}, &api.EvalBatchDeleteResponse{}, false},
+	{"GET", "/v1/evaluation/{eval_id}", "evaluations", "Read an evaluation", nil, &api.Evaluation{}, true},
+	{"GET", "/v1/evaluation/{eval_id}/allocations", "evaluations", "List evaluation allocations", nil, []*api.AllocationListStub{}, true},
+
+	{"GET", "/v1/deployments", "deployments", "List deployments", nil, []*api.Deployment{}, true},
+	{"GET", "/v1/deployment/{deployment_id}", "deployments", "Read a deployment", nil, &api.Deployment{}, true},
+	{"GET", "/v1/deployment/allocations/{deployment_id}", "deployments", "List deployment allocations", nil, []*api.AllocationListStub{}, true},
+	{"PUT", "/v1/deployment/fail/{deployment_id}", "deployments", "Fail a deployment", &api.DeploymentFailRequest{}, &api.DeploymentUpdateResponse{}, false},
+	{"PUT", "/v1/deployment/pause/{deployment_id}", "deployments", "Pause a deployment", &api.DeploymentPauseRequest{}, &api.DeploymentUpdateResponse{}, false},
+	{"PUT", "/v1/deployment/promote/{deployment_id}", "deployments", "Promote a deployment", &api.DeploymentPromoteRequest{}, &api.DeploymentUpdateResponse{}, false},
+	{"PUT", "/v1/deployment/allocation-health/{deployment_id}", "deployments", "Set allocation health", &api.DeploymentAllocHealthRequest{}, &api.DeploymentUpdateResponse{}, false},
+
+	{"GET", "/v1/acl/policies", "acl", "List ACL policies", nil, []*api.ACLPolicyListStub{}, true},
+	{"GET", "/v1/acl/policy/{policy_name}", "acl", "Read an ACL policy", nil, &api.ACLPolicy{}, true},
+	{"PUT", "/v1/acl/policy/{policy_name}", "acl", "Upsert an ACL policy", &api.ACLPolicy{}, nil, false},
+	{"DELETE", "/v1/acl/policy/{policy_name}", "acl", "Delete an ACL policy", nil, nil, false},
+	{"GET", "/v1/acl/auth-methods", "acl", "List ACL auth methods", nil, []*api.ACLAuthMethodListStub{}, true},
+	{"GET", "/v1/acl/auth-method/{method_name}", "acl", "Read an ACL auth method", nil, &api.ACLAuthMethod{}, true},
+	{"PUT", "/v1/acl/auth-method/{method_name}", "acl", "Upsert an ACL auth method", &api.ACLAuthMethod{}, nil, false},
+	{"DELETE", "/v1/acl/auth-method/{method_name}", "acl", "Delete an ACL auth method", nil, nil, false},
+	{"PUT", "/v1/acl/oidc/auth-url", "acl", "Start the OIDC flow of an auth method", &api.ACLOIDCAuthURLRequest{}, &api.ACLOIDCAuthURLResponse{}, false},
+	{"PUT", "/v1/acl/oidc/complete-auth", "acl", "Complete the OIDC flow of an auth method", &api.ACLOIDCCompleteAuthRequest{}, &api.ACLToken{}, false},
+	{"PUT", "/v1/acl/bootstrap", "acl", "Bootstrap the ACL system", nil, &api.ACLToken{}, false},
+	{"GET", "/v1/acl/tokens", "acl", "List ACL tokens", nil, []*api.ACLTokenListStub{}, true},
+	{"PUT", "/v1/acl/token", "acl", "Create an ACL token", &api.ACLToken{}, &api.ACLToken{}, false},
+	{"GET", "/v1/acl/token/self", "acl", "Read the ACL token in use", nil, &api.ACLToken{}, true},
+	{"GET", "/v1/acl/token/{accessor_id}", "acl", "Read an ACL token", nil, &api.ACLToken{}, true},
+	{"PUT", "/v1/acl/token/{accessor_id}", "acl", "Update an ACL token", &api.ACLToken{}, &api.ACLToken{}, false},
+	{"DELETE", "/v1/acl/token/{accessor_id}", "acl", "Delete an ACL token", nil, nil, false},
+
+	{"GET", "/v1/network-policies", "network-policies", "List network policies", nil, []*api.NetworkPolicy{}, true},
+	{"GET", "/v1/network-policy/{policy_name}", "network-policies", "Read a network policy", nil, &api.NetworkPolicy{}, true},
+	{"PUT", "/v1/network-policy/{policy_name}", "network-policies", "Upsert a network policy", &api.NetworkPolicy{}, nil, false},
+	{"DELETE", "/v1/network-policy/{policy_name}", "network-policies", "Delete a network policy", nil, nil, false},
+
+	{"GET", "/v1/vars", "variables", "List variables", nil, []*api.VariableMetadata{}, true},
+	{"GET", "/v1/var/{path}", "variables", "Read a variable", nil, &api.Variable{}, true},
+	{"PUT", "/v1/var/{path}", "variables", "Create or update a variable", &api.Variable{}, nil, false},
+	{"DELETE", "/v1/var/{path}", "variables", "Delete a variable", nil, nil, false},
+
+	{"GET", "/v1/client/stats", "client", "Read client host statistics", nil, &api.HostStats{}, false},
+	{"GET", "/v1/client/allocation/{alloc_id}/stats", "client", "Read allocation resource usage", nil, &api.AllocResourceUsage{}, false},
+	{"GET", "/v1/client/allocation/{alloc_id}/checks", "client", "Read allocation check statuses", nil, []*api.AllocCheckStatus{}, false},
+	{"GET", "/v1/client/fs/ls/{alloc_id}", "client", "List allocation files", nil, []*api.AllocFileInfo{}, false},
+	{"GET", "/v1/client/fs/stat/{alloc_id}", "client", "Stat an allocation file", nil, &api.AllocFileInfo{}, false},
+	{"PUT", "/v1/client/gc", "client", "Garbage collect allocations", nil, nil, false},
+	{"GET", "/v1/client/metadata", "client", "Read the node metadata", nil, &api.NodeMetaResponse{}, false},
+	{"PUT", "/v1/client/metadata", "client", "Apply dynamic node metadata", &api.NodeMetaApplyRequest{}, &api.NodeMetaResponse{}, false},
+	{"GET", "/v1/client/migrations", "client", "List allocation migrations of the node", nil, []*api.AllocMigration{}, false},
+
+	{"GET", "/v1/agent/self", "agent", "Read the agent configuration", nil, &api.AgentSelf{}, false},
+	{"PUT", "/v1/agent/join", "agent", "Join the agent to a gossip pool", nil, nil, false},
+	{"GET", "/v1/agent/members", "agent", "List gossip pool members", nil, &api.ServerMembers{}, false},
+	{"PUT", "/v1/agent/force-leave", "agent", "Force a member to leave", nil, nil, false},
+	{"GET", "/v1/agent/servers", "agent", "List known servers", nil, []string{}, false},
+	{"GET", "/v1/agent/health", "agent", "Read agent health", nil, &api.AgentHealthResponse{}, false},
+	{"GET", "/v1/agent/keyring/list", "agent", "List gossip encryption keys", nil, &api.KeyringResponse{}, false},
+	{"PUT", "/v1/agent/keyring/install", "agent", "Install a gossip encryption key", &api.KeyringRequest{}, &api.KeyringResponse{}, false},
+	{"PUT", "/v1/agent/keyring/use", "agent", "Use a gossip encryption key", &api.KeyringRequest{}, &api.KeyringResponse{}, false},
+	{"PUT", "/v1/agent/keyring/remove", "agent", "Remove a gossip encryption key", &api.KeyringRequest{}, &api.KeyringResponse{}, false},
+	{"GET", "/v1/metrics", "agent", "Read agent metrics", nil, nil, false},
+
+	{"GET", "/v1/regions", "status", "List regions", nil, []string{}, false},
+	{"GET", "/v1/status/leader", "status", "Read the Raft leader", nil, "", false},
+	{"GET", "/v1/status/peers", "status", "List Raft peers", nil, []string{}, false},
+
+	{"PUT", "/v1/search", "search", "Search by prefix", &api.SearchRequest{}, &api.SearchResponse{}, false},
+
+	{"GET", "/v1/service-discovery/prometheus", "service-discovery", "List Prometheus targets", nil, []*api.PrometheusTargetGroup{}, true},
+
+	{"GET", "/v1/operator/raft/configuration", "operator", "Read the Raft configuration", nil, &api.RaftConfiguration{}, false},
+	{"DELETE", "/v1/operator/raft/peer", "operator", "Remove a Raft peer", nil, nil, false},
+	{"GET", "/v1/operator/autopilot/configuration", "operator", "Read the autopilot configuration", nil, &api.AutopilotConfiguration{}, false},
+	{"PUT", "/v1/operator/autopilot/configuration", "operator", "Update the autopilot configuration", &api.AutopilotConfiguration{}, nil, false},
+	{"GET", "/v1/operator/autopilot/health", "operator", "Read server health", nil, &api.OperatorHealthReply{}, false},
+	{"GET", "/v1/operator/inventory", "operator", "Export the cluster inventory", nil, []*api.InventoryNode{}, false},
+	{"GET", "/v1/operator/utilization", "operator", "Read cluster resource utilization", nil, []*api.UtilizationGroup{}, true},
+	{"GET", "/v1/operator/query", "operator", "Find allocations by image digest", nil, []*api.AllocationListStub{}, true},
+	{"GET", "/v1/operator/deployment-concurrency", "operator", "Read the deployment concurrency limits", nil, &api.DeploymentConcurrencyConfig{}, true},
+	{"PUT", "/v1/operator/deployment-concurrency", "operator", "Update the deployment concurrency limits", &api.DeploymentConcurrencyConfig{}, nil, false},
+	{"GET", "/v1/operator/quarantine", "operator", "List quarantined digests", nil, []*api.QuarantineEntry{}, true},
+	{"PUT", "/v1/operator/quarantine", "operator", "Quarantine digests", &api.QuarantineUpsertRequest{}, nil, false},
+	{"DELETE", "/v1/operator/quarantine/{digest}", "operator", "Remove a quarantined digest", nil, nil, false},
+
+	{"GET", "/v1/event/stream", "event", "Stream events of the cluster state", nil, &api.Events{}, false},
+	{"GET", "/v1/event/retention", "event", "Read the range of retained events", nil, &api.EventRetention{}, false},
+
+	{"PUT", "/v1/system/gc", "system", "Force a garbage collection", nil, &api.GCResponse{}, false},
+	{"PUT", "/v1/system/reconcile/summaries", "system", "Reconcile job summaries", nil, nil, false},
+
+	{"GET", "/v1/openapi.json", "meta", "Read the OpenAPI specification", nil, nil, false},
+}
+
+// openAPIPathParam matches the templated parameters of a route path
+var openAPIPathParam = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+var (
+	openAPIDoc     *openAPIDocument
+	openAPIDocOnce sync.Once
+)
+
+// OpenAPIRequest serves the OpenAPI v3 document describing the HTTP API
+func (s *HTTPServer) OpenAPIRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	openAPIDocOnce.Do(func() {
+		openAPIDoc = generateOpenAPI(openAPIRoutes)
+	})
+	return openAPIDoc, nil
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       openAPIInfo                      `json:"info"`
+	Paths      map[string]map[string]*openAPIOp `json:"paths"`
+	Components openAPIComponents                `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*openAPISchema `json:"schemas"`
+}
+
+type openAPIOp struct {
+	Summary     string                      `json:"summary"`
+	OperationID string                      `json:"operationId"`
+	Tags        []string                    `json:"tags"`
+	Parameters  []*openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIBody                `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+type openAPIBody struct {
+	Required bool                         `json:"required,omitempty"`
+	Content  map[string]*openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// generateOpenAPI builds the OpenAPI document for the given routes. Schemas
+// are derived from the request and response structs by reflection.
+func generateOpenAPI(routes []openAPIRoute) *openAPIDocument {
+	doc := &openAPIDocument{
+		OpenAPI: "3.0.0",
+		Info: openAPIInfo{
+			Title:   "Nomad",
+			Version: version.GetVersion().VersionNumber(),
+		},
+		Paths: make(map[string]map[string]*openAPIOp),
+		Components: openAPIComponents{
+			Schemas: make(map[string]*openAPISchema),
+		},
+	}
+
+	for _, r := range routes {
+		op := &openAPIOp{
+			Summary:     r.Summary,
+			OperationID: openAPIOperationID(r),
+			Tags:        []string{r.Tag},
+			Responses:   make(map[string]*openAPIResponse),
+		}
+
+		for _, m := range openAPIPathParam.FindAllStringSubmatch(r.Path, -1) {
+			op.Parameters = append(op.Parameters, &openAPIParameter{
+				Name:     m[1],
+				In:       "path",
+				Required: true,
+				Schema:   &openAPISchema{Type: "string"},
+			})
+		}
+		op.Parameters = append(op.Parameters, openAPICommonParams(r)...)
+
+		if r.Request != nil {
+			op.RequestBody = &openAPIBody{
+				Required: true,
+				Content: map[string]*openAPIMediaType{
+					"application/json": {Schema: doc.schemaFor(reflect.TypeOf(r.Request))},
+				},
+			}
+		}
+
+		ok := &openAPIResponse{Description: "OK"}
+		if r.Response != nil {
+			ok.Content = map[string]*openAPIMediaType{
+				"application/json": {Schema: doc.schemaFor(reflect.TypeOf(r.Response))},
+			}
+		}
+		op.Responses["200"] = ok
+		op.Responses["default"] = &openAPIResponse{Description: "Error"}
+
+		if _, ok := doc.Paths[r.Path]; !ok {
+			doc.Paths[r.Path] = make(map[string]*openAPIOp)
+		}
+		doc.Paths[r.Path][strings.ToLower(r.Method)] = op
+	}
+
+	return doc
+}
+
+// openAPICommonParams returns the query parameters shared by every endpoint
+// of the given kind.
+func openAPICommonParams(r openAPIRoute) []*openAPIParameter {
+	str := &openAPISchema{Type: "string"}
+	params := []*openAPIParameter{
+		{Name: "region", In: "query", Schema: str},
+		{Name: "namespace", In: "query", Schema: str},
+		{Name: "X-Nomad-Token", In: "header", Schema: str},
+	}
+
+	if r.Blocking {
+		params = append(params,
+			&openAPIParameter{Name: "index", In: "query", Schema: &openAPISchema{Type: "integer", Format: "uint64"}},
+			&openAPIParameter{Name: "wait", In: "query", Schema: str},
+			&openAPIParameter{Name: "stale", In: "query", Schema: &openAPISchema{Type: "boolean"}},
+			&openAPIParameter{Name: "prefix", In: "query", Schema: str},
+			&openAPIParameter{Name: "index_at", In: "query", Schema: &openAPISchema{Type: "integer", Format: "uint64"}},
+		)
+	}
+	return params
+}
+
+// openAPIOperationID returns a unique identifier for the route, such as
+// "getJobVersions" for "GET /v1/job/{job_id}/versions". A trailing path
+// parameter is named, such as in "putAclTokenByAccessorId" for
+// "PUT /v1/acl/token/{accessor_id}", so that the route doesn't collide with
+// the one without the parameter.
+func openAPIOperationID(r openAPIRoute) string {
+	var buf bytes.Buffer
+	buf.WriteString(strings.ToLower(r.Method))
+	segments := strings.Split(strings.TrimPrefix(r.Path, "/v1/"), "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") {
+			if i != len(segments)-1 {
+				continue
+			}
+			buf.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		for _, part := range strings.FieldsFunc(segment, func(c rune) bool {
+			return c == '-' || c == '.' || c == '_'
+		}) {
+			buf.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return buf.String()
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// schemaFor returns the schema for the given type. Named structs are stored
+// in the components section and referenced so recursive types terminate.
+func (d *openAPIDocument) schemaFor(t reflect.Type) *openAPISchema {
+	switch t {
+	case timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &openAPISchema{Type: "integer", Format: "int64"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := d.schemaFor(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &openAPISchema{Type: "integer", Format: t.Kind().String()}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		// Byte slices are encoded as base64 strings
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: d.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: d.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := d.Components.Schemas[name]; !ok {
+			// Reserve the name before descending to handle recursive types
+			d.Components.Schemas[name] = &openAPISchema{}
+			*d.Components.Schemas[name] = *d.structSchema(t)
+		}
+		return &openAPISchema{Ref: "#/components/schemas/" + name}
+	default:
+		// Interfaces and other dynamic values accept any JSON value
+		return &openAPISchema{}
+	}
+}
+
+// structSchema returns the object schema for the exported fields of a struct,
+// flattening embedded structs the way the JSON encoder does.
+func (d *openAPIDocument) structSchema(t reflect.Type) *openAPISchema {
+	s := &openAPISchema{
+		Type:       "object",
+		Properties: make(map[string]*openAPISchema),
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			for k, v := range d.structSchema(f.Type).Properties {
+				s.Properties[k] = v
+			}
+			continue
+		}
+
+		s.Properties[name] = d.schemaFor(f.Type)
+	}
+
+	return s
+}
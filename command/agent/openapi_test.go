@@ -0,0 +1,226 @@
+package agent
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP_OpenAPI(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		assert := assert.New(t)
+
+		req, err := http.NewRequest("GET", "/v1/openapi.json", nil)
+		assert.Nil(err)
+		respW := httptest.NewRecorder()
+
+		obj, err := s.Server.OpenAPIRequest(respW, req)
+		assert.Nil(err)
+
+		doc := obj.(*openAPIDocument)
+		assert.Equal("3.0.0", doc.OpenAPI)
+
+		// Every route in the table is described
+		for _, r := range openAPIRoutes {
+			ops, ok := doc.Paths[r.Path]
+			if assert.True(ok, r.Path) {
+				assert.Contains(ops, strings.ToLower(r.Method), r.Path)
+			}
+		}
+
+		// Path parameters are extracted from the route
+		op := doc.Paths["/v1/job/{job_id}/versions"]["get"]
+		assert.Equal("getJobVersions", op.OperationID)
+		if assert.NotEmpty(op.Parameters) {
+			assert.Equal("job_id", op.Parameters[0].Name)
+			assert.Equal("path", op.Parameters[0].In)
+		}
+
+		// Trailing path parameters are named in the operation ID
+		op = doc.Paths["/v1/acl/token/{accessor_id}"]["put"]
+		assert.Equal("putAclTokenByAccessorId", op.OperationID)
+
+		// Operation IDs are unique
+		ids := make(map[string]string)
+		for path, ops := range doc.Paths {
+			for _, op := range ops {
+				if other, ok := ids[op.OperationID]; ok {
+					t.Errorf("operation ID %q of %q is also used by %q", op.OperationID, path, other)
+				}
+				ids[op.OperationID] = path
+			}
+		}
+
+		// Schemas are generated from the api structs
+		job, ok := doc.Components.Schemas["Job"]
+		if assert.True(ok) {
+			assert.Equal("object", job.Type)
+			assert.Equal("#/components/schemas/TaskGroup", job.Properties["TaskGroups"].Items.Ref)
+			assert.Equal("object", job.Properties["Meta"].Type)
+		}
+
+		// Only GET is allowed
+		req, err = http.NewRequest("PUT", "/v1/openapi.json", nil)
+		assert.Nil(err)
+		_, err = s.Server.OpenAPIRequest(respW, req)
+		assert.NotNil(err)
+	})
+}
+
+func TestOpenAPI_RecursiveSchema(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	type node struct {
+		Name     string `json:"name"`
+		Ignored  string `json:"-"`
+		Children []*node
+	}
+
+	doc := generateOpenAPI([]openAPIRoute{
+		{"GET", "/v1/tree", "tree", "Read a tree", nil, &node{}, false},
+	})
+
+	s := doc.Components.Schemas["node"]
+	if assert.NotNil(s) {
+		assert.Contains(s.Properties, "name")
+		assert.NotContains(s.Properties, "Ignored")
+		assert.Equal("#/components/schemas/node", s.Properties["Children"].Items.Ref)
+	}
+}
+
+// TestOpenAPI_RoutesRegistered fails when a route registered on the HTTP mux,
+// or dispatched by the suffix of its path, is missing from openAPIRoutes.
+func TestOpenAPI_RoutesRegistered(t *testing.T) {
+	t.Parallel()
+
+	// Routes that aren't part of the HTTP API
+	ignored := map[string]bool{
+		"/":    true,
+		"/ui/": true,
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatalf("failed to parse the agent package: %v", err)
+	}
+	funcs := make(map[string]*ast.FuncDecl)
+	for _, f := range pkgs["agent"].Files {
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil {
+				funcs[fn.Name.Name] = fn
+			}
+		}
+	}
+
+	// stringArgs returns the string literal arguments of the calls to the
+	// function named name within node
+	stringArgs := func(node ast.Node, name string) [][]string {
+		var out [][]string
+		ast.Inspect(node, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != name {
+				return true
+			}
+			var args []string
+			for _, arg := range call.Args {
+				if lit, ok := arg.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+					args = append(args, strings.Trim(lit.Value, `"`))
+				}
+			}
+			out = append(out, args)
+			return true
+		})
+		return out
+	}
+
+	// handlerName returns the name of the HTTPServer method a handler wraps
+	handlerName := func(call *ast.CallExpr) string {
+		var name string
+		ast.Inspect(call, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if _, ok := funcs[sel.Sel.Name]; ok && sel.Sel.Name != "wrap" {
+					name = sel.Sel.Name
+				}
+			}
+			return name == ""
+		})
+		return name
+	}
+
+	hasRoute := func(prefix, suffix string, exact bool) bool {
+		for _, r := range openAPIRoutes {
+			if exact && r.Path == prefix {
+				return true
+			}
+			if !exact && strings.HasPrefix(r.Path, prefix) && strings.HasSuffix(r.Path, suffix) {
+				return true
+			}
+		}
+		return false
+	}
+
+	register := funcs["registerHandlers"]
+	if register == nil {
+		t.Fatalf("registerHandlers not found")
+	}
+	checked := 0
+	ast.Inspect(register, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 {
+			return true
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok || (sel.Sel.Name != "HandleFunc" && sel.Sel.Name != "Handle") {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		pattern := strings.Trim(lit.Value, `"`)
+		if ignored[pattern] || strings.HasPrefix(pattern, "/debug/") {
+			return false
+		}
+		checked++
+
+		if !strings.HasSuffix(pattern, "/") {
+			if !hasRoute(pattern, "", true) {
+				t.Errorf("route %q is missing from openAPIRoutes", pattern)
+			}
+			return false
+		}
+
+		// Prefix patterns must have a route for every suffix their handler
+		// dispatches on
+		if !hasRoute(pattern, "", false) {
+			t.Errorf("routes under %q are missing from openAPIRoutes", pattern)
+		}
+		if handler := funcs[handlerName(call.Args[1].(*ast.CallExpr))]; handler != nil {
+			for _, args := range stringArgs(handler, "HasSuffix") {
+				if len(args) == 1 && !hasRoute(pattern, args[0], false) {
+					t.Errorf("route %q is missing from openAPIRoutes", pattern+"{...}"+args[0])
+				}
+			}
+		}
+		return false
+	})
+	if checked == 0 {
+		t.Fatalf("no routes found in registerHandlers")
+	}
+}
@@ -220,3 +220,187 @@ func (s *HTTPServer) OperatorServerHealth(resp http.ResponseWriter, req *http.Re
 
 	return out, nil
 }
+
+// OperatorInventoryRequest returns a point-in-time inventory of the nodes in
+// the cluster and the allocations placed on them.
+func (s *HTTPServer) OperatorInventoryRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.InventoryRequest
+	if done := s.parse(resp, req, &args.Region, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply structs.InventoryResponse
+	if err := s.agent.RPC("Operator.Inventory", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &reply.QueryMeta)
+	if reply.Nodes == nil {
+		reply.Nodes = make([]*structs.InventoryNode, 0)
+	}
+	return reply.Nodes, nil
+}
+
+// OperatorUtilizationRequest returns the resource utilization of the cluster
+// grouped by datacenter and node class.
+func (s *HTTPServer) OperatorUtilizationRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	var args structs.UtilizationRequest
+	if done := s.parse(resp, req, &args.Region, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply structs.UtilizationResponse
+	if err := s.agent.RPC("Operator.Utilization", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &reply.QueryMeta)
+	if reply.Groups == nil {
+		reply.Groups = make([]*structs.UtilizationGroup, 0)
+	}
+	return reply.Groups, nil
+}
+
+// OperatorImageQueryRequest lists the allocations running tasks started from
+// the image with the given digest.
+func (s *HTTPServer) OperatorImageQueryRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.ImageQueryRequest{
+		ImageDigest: req.URL.Query().Get("image_digest"),
+	}
+	if args.ImageDigest == "" {
+		return nil, CodedError(400, "missing image_digest")
+	}
+	if done := s.parse(resp, req, &args.Region, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply structs.ImageQueryResponse
+	if err := s.agent.RPC("Operator.ImageQuery", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &reply.QueryMeta)
+	if reply.Allocations == nil {
+		reply.Allocations = make([]*structs.AllocListStub, 0)
+	}
+	return reply.Allocations, nil
+}
+
+// OperatorDeploymentConcurrencyRequest is used to read and set the limits on
+// the number of running deployments.
+func (s *HTTPServer) OperatorDeploymentConcurrencyRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case "GET":
+		var args structs.GenericRequest
+		if done := s.parse(resp, req, &args.Region, &args.QueryOptions); done {
+			return nil, nil
+		}
+
+		var reply structs.DeploymentConcurrencyConfigResponse
+		if err := s.agent.RPC("Operator.DeploymentConcurrencyGetConfiguration", &args, &reply); err != nil {
+			return nil, err
+		}
+
+		setMeta(resp, &reply.QueryMeta)
+		if reply.Config == nil {
+			reply.Config = &structs.DeploymentConcurrencyConfig{}
+		}
+		return reply.Config, nil
+
+	case "PUT", "POST":
+		var args structs.DeploymentConcurrencySetConfigRequest
+		if err := decodeBody(req, &args.Config); err != nil {
+			return nil, CodedError(400, err.Error())
+		}
+		s.parseWriteRequest(req, &args.WriteRequest)
+
+		var out structs.GenericResponse
+		if err := s.agent.RPC("Operator.DeploymentConcurrencySetConfiguration", &args, &out); err != nil {
+			return nil, err
+		}
+		setIndex(resp, out.Index)
+		return nil, nil
+
+	default:
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+}
+
+// OperatorQuarantineRequest is used to list and add entries to the cluster
+// wide quarantine list.
+func (s *HTTPServer) OperatorQuarantineRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case "GET":
+		args := structs.QuarantineListRequest{}
+		if done := s.parse(resp, req, &args.Region, &args.QueryOptions); done {
+			return nil, nil
+		}
+
+		var reply structs.QuarantineListResponse
+		if err := s.agent.RPC("Quarantine.List", &args, &reply); err != nil {
+			return nil, err
+		}
+
+		setMeta(resp, &reply.QueryMeta)
+		if reply.Entries == nil {
+			reply.Entries = make([]*structs.QuarantineEntry, 0)
+		}
+		return reply.Entries, nil
+
+	case "PUT", "POST":
+		var args structs.QuarantineUpsertRequest
+		if err := decodeBody(req, &args); err != nil {
+			return nil, CodedError(400, err.Error())
+		}
+		if len(args.Entries) == 0 {
+			return nil, CodedError(400, "must specify at least one entry")
+		}
+		s.parseWriteRequest(req, &args.WriteRequest)
+
+		var out structs.GenericResponse
+		if err := s.agent.RPC("Quarantine.UpsertEntries", &args, &out); err != nil {
+			return nil, err
+		}
+		setIndex(resp, out.Index)
+		return nil, nil
+
+	default:
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+}
+
+// OperatorQuarantineSpecificRequest is used to remove an entry from the
+// quarantine list.
+func (s *HTTPServer) OperatorQuarantineSpecificRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	digest := strings.TrimPrefix(req.URL.Path, "/v1/operator/quarantine/")
+	if digest == "" {
+		return nil, CodedError(400, "missing digest")
+	}
+	if req.Method != "DELETE" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.QuarantineDeleteRequest{
+		Digests: []string{digest},
+	}
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	var out structs.GenericResponse
+	if err := s.agent.RPC("Quarantine.DeleteEntries", &args, &out); err != nil {
+		return nil, err
+	}
+	setIndex(resp, out.Index)
+	return nil, nil
+}
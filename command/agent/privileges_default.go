@@ -0,0 +1,12 @@
+// +build !linux
+
+package agent
+
+import "fmt"
+
+// runAsUser runs the agent as the unprivileged server user, which is only
+// supported on Linux.
+func (c *Command) runAsUser(config *Config) int {
+	c.Ui.Error(fmt.Sprintf("Running the agent as server user %q is only supported on Linux", config.Server.User))
+	return 1
+}
@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// runAsUser runs the agent as the unprivileged server user, handing the data
+// dir over to the user. Go can't change the user of a running process, so the
+// agent is started again as the user and the root process only waits for it,
+// forwarding the signals it receives.
+func (c *Command) runAsUser(config *Config) int {
+	cred, err := lookupCredential(config.Server.User)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error looking up server user %q: %v", config.Server.User, err))
+		return 1
+	}
+	if cred.Uid == 0 {
+		c.Ui.Error(fmt.Sprintf("Server user %q must not be root", config.Server.User))
+		return 1
+	}
+
+	if err := chownDataDir(config.DataDir, int(cred.Uid), int(cred.Gid)); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error giving the data dir to server user %q: %v", config.Server.User, err))
+		return 1
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error finding the Nomad executable: %v", err))
+		return 1
+	}
+	cmd := exec.Command(exe, append([]string{"agent"}, c.args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Credential: cred,
+
+		// The agent gets its own process group so the signals of the
+		// terminal are only forwarded once, and is stopped if the root
+		// process dies
+		Setpgid:   true,
+		Pdeathsig: syscall.SIGTERM,
+	}
+
+	signalCh := make(chan os.Signal, 4)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGPIPE)
+	defer signal.Stop(signalCh)
+
+	c.Ui.Output(fmt.Sprintf("Running the agent as server user %q", config.Server.User))
+	if err := cmd.Start(); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error starting the agent as server user %q: %v", config.Server.User, err))
+		return 1
+	}
+	go func() {
+		for sig := range signalCh {
+			if sig != syscall.SIGPIPE {
+				cmd.Process.Signal(sig)
+			}
+		}
+	}()
+
+	if err := cmd.Wait(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Exited() {
+				return status.ExitStatus()
+			}
+		}
+		c.Ui.Error(fmt.Sprintf("Error running the agent as server user %q: %v", config.Server.User, err))
+		return 1
+	}
+	return 0
+}
+
+// lookupCredential returns the credential of a user and its groups.
+func lookupCredential(name string) (*syscall.Credential, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID %q: %v", u.Uid, err)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid group ID %q: %v", u.Gid, err)
+	}
+
+	gidStrings, err := u.GroupIds()
+	if err != nil {
+		return nil, fmt.Errorf("unable to lookup the groups of the user: %v", err)
+	}
+	groups := make([]uint32, 0, len(gidStrings))
+	for _, s := range gidStrings {
+		g, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid group ID %q: %v", s, err)
+		}
+		groups = append(groups, uint32(g))
+	}
+
+	return &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid), Groups: groups}, nil
+}
+
+// chownDataDir creates the data dir if it doesn't exist and gives it and its
+// content to the user, so that servers previously run as root can start as
+// the user.
+func chownDataDir(dir string, uid, gid int) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(path, uid, gid)
+	})
+}
@@ -0,0 +1,31 @@
+package agent
+
+import (
+	"net/http"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// PrometheusSDRequest returns the services of running allocations in the
+// Prometheus HTTP service discovery format.
+func (s *HTTPServer) PrometheusSDRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, ErrInvalidMethod)
+	}
+
+	args := structs.PrometheusTargetsRequest{}
+	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
+		return nil, nil
+	}
+
+	var out structs.PrometheusTargetsResponse
+	if err := s.agent.RPC("ServiceDiscovery.Prometheus", &args, &out); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &out.QueryMeta)
+	if out.TargetGroups == nil {
+		out.TargetGroups = make([]*structs.PrometheusTargetGroup, 0)
+	}
+	return out.TargetGroups, nil
+}
@@ -1,7 +1,10 @@
 package agent
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -11,16 +14,33 @@ func (s *HTTPServer) GarbageCollectRequest(resp http.ResponseWriter, req *http.R
 		return nil, CodedError(405, ErrInvalidMethod)
 	}
 
-	var args structs.GenericRequest
-	if s.parse(resp, req, &args.Region, &args.QueryOptions) {
-		return nil, nil
+	var args structs.SystemGCRequest
+	s.parseWriteRequest(req, &args.WriteRequest)
+
+	// The garbage collection is only restricted to a namespace if it is given
+	query := req.URL.Query()
+	if query.Get("namespace") == "" {
+		args.Namespace = structs.AllNamespacesSentinel
+	}
+	if collections := query.Get("collections"); collections != "" {
+		args.Collections = strings.Split(collections, ",")
+	}
+	if dryRun := query.Get("dry_run"); dryRun != "" {
+		var err error
+		if args.DryRun, err = strconv.ParseBool(dryRun); err != nil {
+			return nil, CodedError(400, fmt.Sprintf("Failed to parse value of %q (%v) as a bool: %v", "dry_run", dryRun, err))
+		}
 	}
 
-	var gResp structs.GenericResponse
-	if err := s.agent.RPC("System.GarbageCollect", &args, &gResp); err != nil {
+	var out structs.SystemGCResponse
+	if err := s.agent.RPC("System.GarbageCollect", &args, &out); err != nil {
 		return nil, err
 	}
-	return nil, nil
+	if !args.Scoped() {
+		return nil, nil
+	}
+	setIndex(resp, out.Index)
+	return out, nil
 }
 
 func (s *HTTPServer) ReconcileJobSummaries(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
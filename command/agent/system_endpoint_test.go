@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
 )
 
 func TestHTTP_SystemGarbageCollect(t *testing.T) {
@@ -23,6 +25,36 @@ func TestHTTP_SystemGarbageCollect(t *testing.T) {
 	})
 }
 
+func TestHTTP_SystemGarbageCollect_DryRun(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		// Make the HTTP request
+		req, err := http.NewRequest("PUT", "/v1/system/gc?collections=evals,nodes&dry_run=true", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		respW := httptest.NewRecorder()
+
+		// Make the request
+		obj, err := s.Server.GarbageCollectRequest(respW, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if _, ok := obj.(structs.SystemGCResponse); !ok {
+			t.Fatalf("unexpected response: %#v", obj)
+		}
+
+		// Fails on an invalid dry run
+		req, err = http.NewRequest("PUT", "/v1/system/gc?dry_run=maybe", nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if _, err := s.Server.GarbageCollectRequest(respW, req); err == nil {
+			t.Fatalf("expected error")
+		}
+	})
+}
+
 func TestHTTP_ReconcileJobSummaries(t *testing.T) {
 	t.Parallel()
 	httpTest(t, nil, func(s *TestAgent) {
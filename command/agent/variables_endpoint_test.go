@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTP_VariableCRUD(t *testing.T) {
+	t.Parallel()
+	httpTest(t, nil, func(s *TestAgent) {
+		assert := assert.New(t)
+
+		// Put a variable, its path defaults to the request's
+		variable := &structs.Variable{
+			Items: map[string]string{"password": "hunter2"},
+		}
+		req, err := http.NewRequest("PUT", "/v1/var/nomad/jobs/web", encodeReq(variable))
+		assert.Nil(err)
+		respW := httptest.NewRecorder()
+		_, err = s.Server.VariableSpecificRequest(respW, req)
+		assert.Nil(err)
+		assert.NotEmpty(respW.HeaderMap.Get("X-Nomad-Index"))
+
+		// The path must match the request's
+		variable.Path = "other"
+		req, err = http.NewRequest("PUT", "/v1/var/nomad/jobs/web", encodeReq(variable))
+		assert.Nil(err)
+		_, err = s.Server.VariableSpecificRequest(httptest.NewRecorder(), req)
+		assert.NotNil(err)
+
+		// Read it back
+		req, err = http.NewRequest("GET", "/v1/var/nomad/jobs/web", nil)
+		assert.Nil(err)
+		obj, err := s.Server.VariableSpecificRequest(httptest.NewRecorder(), req)
+		assert.Nil(err)
+		out := obj.(*structs.Variable)
+		assert.Equal(structs.DefaultNamespace, out.Namespace)
+		assert.Equal("hunter2", out.Items["password"])
+
+		// List the variables by prefix
+		req, err = http.NewRequest("GET", "/v1/vars?prefix=nomad/jobs", nil)
+		assert.Nil(err)
+		obj, err = s.Server.VariablesRequest(httptest.NewRecorder(), req)
+		assert.Nil(err)
+		assert.Len(obj.([]*structs.VariableStub), 1)
+
+		req, err = http.NewRequest("GET", "/v1/vars?prefix=other", nil)
+		assert.Nil(err)
+		obj, err = s.Server.VariablesRequest(httptest.NewRecorder(), req)
+		assert.Nil(err)
+		assert.Len(obj.([]*structs.VariableStub), 0)
+
+		// Delete it
+		req, err = http.NewRequest("DELETE", "/v1/var/nomad/jobs/web", nil)
+		assert.Nil(err)
+		_, err = s.Server.VariableSpecificRequest(httptest.NewRecorder(), req)
+		assert.Nil(err)
+
+		req, err = http.NewRequest("GET", "/v1/var/nomad/jobs/web", nil)
+		assert.Nil(err)
+		_, err = s.Server.VariableSpecificRequest(httptest.NewRecorder(), req)
+		if assert.NotNil(err) {
+			assert.Contains(err.Error(), "not found")
+		}
+	})
+}
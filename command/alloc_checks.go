@@ -0,0 +1,205 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/api/contexts"
+	"github.com/posener/complete"
+)
+
+type AllocChecksCommand struct {
+	Meta
+}
+
+func (c *AllocChecksCommand) Help() string {
+	helpText := `
+Usage: nomad alloc checks [options] <allocation>
+
+  Checks displays the current status, output and timing of the service checks
+  of an allocation's tasks. The status is queried from the client running the
+  allocation. It covers the checks run by Consul as well as the script checks
+  run by Nomad, whose last run time and duration are also displayed.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Checks Options:
+
+  -json
+    Output the checks in a JSON format.
+
+  -t
+    Format and display the checks using a Go template.
+
+  -verbose
+    Display full information, including the check IDs.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AllocChecksCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-json":    complete.PredictNothing,
+			"-t":       complete.PredictAnything,
+			"-verbose": complete.PredictNothing,
+		})
+}
+
+func (c *AllocChecksCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		client, err := c.Meta.Client()
+		if err != nil {
+			return nil
+		}
+
+		resp, _, err := client.Search().PrefixSearch(a.Last, contexts.Allocs, nil)
+		if err != nil {
+			return []string{}
+		}
+		return resp.Matches[contexts.Allocs]
+	})
+}
+
+func (c *AllocChecksCommand) Synopsis() string {
+	return "Display the status of the service checks of an allocation"
+}
+
+func (c *AllocChecksCommand) Run(args []string) int {
+	var json, verbose bool
+	var tmpl string
+
+	flags := c.Meta.FlagSet("alloc checks", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one allocation ID
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+	allocID := args[0]
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	if len(allocID) == 1 {
+		c.Ui.Error(fmt.Sprintf("Identifier must contain at least two characters."))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	allocID = sanatizeUUIDPrefix(allocID)
+	allocs, _, err := client.Allocations().PrefixList(allocID)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying allocation: %v", err))
+		return 1
+	}
+	if len(allocs) == 0 {
+		c.Ui.Error(fmt.Sprintf("No allocation(s) with prefix or id %q found", allocID))
+		return 1
+	}
+	if len(allocs) > 1 {
+		out := formatAllocListStubs(allocs, verbose, length)
+		c.Ui.Output(fmt.Sprintf("Prefix matched multiple allocations\n\n%s", out))
+		return 0
+	}
+	alloc, _, err := client.Allocations().Info(allocs[0].ID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying allocation: %s", err))
+		return 1
+	}
+
+	checks, err := client.Allocations().Checks(alloc, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying allocation checks: %s", err))
+		return 1
+	}
+
+	if json || len(tmpl) > 0 {
+		out, err := Format(json, tmpl, checks)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		c.Ui.Output(out)
+		return 0
+	}
+
+	if len(checks) == 0 {
+		c.Ui.Output(fmt.Sprintf("No checks registered for allocation %q", limit(alloc.ID, length)))
+		return 0
+	}
+
+	c.Ui.Output(formatAllocChecks(checks, verbose))
+	if outputs := formatAllocCheckOutputs(checks); outputs != "" {
+		c.Ui.Output(c.Colorize().Color("\n[bold]Check Outputs[reset]"))
+		c.Ui.Output(outputs)
+	}
+	return 0
+}
+
+func formatAllocChecks(checks []*api.AllocCheckStatus, verbose bool) string {
+	rows := make([]string, len(checks)+1)
+	rows[0] = "Task|Service|Name|Type|Provider|Status|Interval|Timeout|Last Run|Duration"
+	if verbose {
+		rows[0] += "|Check ID"
+	}
+	for i, check := range checks {
+		lastRun, duration := "<none>", "<none>"
+		if !check.LastRun.IsZero() {
+			lastRun = formatTime(check.LastRun)
+			duration = check.LastDuration.String()
+		}
+		rows[i+1] = fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s|%s|%s|%s",
+			check.Task,
+			check.Service,
+			check.Name,
+			check.Type,
+			check.Provider,
+			check.Status,
+			check.Interval,
+			check.Timeout,
+			lastRun,
+			duration)
+		if verbose {
+			rows[i+1] += "|" + check.CheckID
+		}
+	}
+	return formatList(rows)
+}
+
+// formatAllocCheckOutputs returns the output of the checks that have one,
+// indented below the task and check name.
+func formatAllocCheckOutputs(checks []*api.AllocCheckStatus) string {
+	var outputs []string
+	for _, check := range checks {
+		output := strings.TrimSpace(check.Output)
+		if output == "" {
+			continue
+		}
+		lines := strings.Split(output, "\n")
+		outputs = append(outputs, fmt.Sprintf("%s/%s:\n  %s", check.Task, check.Name, strings.Join(lines, "\n  ")))
+	}
+	return strings.Join(outputs, "\n")
+}
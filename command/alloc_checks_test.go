@@ -0,0 +1,98 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocChecksCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &AllocChecksCommand{}
+}
+
+func TestAllocChecksCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &AllocChecksCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on a too short identifier
+	if code := cmd.Run([]string{"a"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "at least two characters") {
+		t.Fatalf("expected identifier error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "foobar"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying allocation") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestAllocChecksCommand_Format(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	lastRun := time.Date(2018, 3, 1, 12, 0, 0, 0, time.UTC)
+	checks := []*api.AllocCheckStatus{
+		{
+			Task:     "web",
+			Service:  "web",
+			Name:     "alive",
+			CheckID:  "_nomad-check-1",
+			Type:     "http",
+			Provider: "consul",
+			Status:   "passing",
+			Output:   "HTTP GET: 200 OK",
+			Interval: 10 * time.Second,
+			Timeout:  2 * time.Second,
+		},
+		{
+			Task:         "web",
+			Service:      "web",
+			Name:         "ready",
+			CheckID:      "_nomad-check-2",
+			Type:         "script",
+			Provider:     "nomad",
+			Status:       "critical",
+			Output:       "not ready\nretrying",
+			Interval:     30 * time.Second,
+			Timeout:      5 * time.Second,
+			LastRun:      lastRun,
+			LastDuration: 1500 * time.Millisecond,
+		},
+	}
+
+	out := formatAllocChecks(checks, false)
+	lines := strings.Split(out, "\n")
+	require.Len(lines, 3)
+	require.NotContains(lines[0], "Check ID")
+	require.Contains(lines[1], "<none>")
+	require.Contains(lines[2], "2018-03-01T12:00:00Z")
+	require.Contains(lines[2], "1.5s")
+
+	out = formatAllocChecks(checks, true)
+	require.Contains(out, "Check ID")
+	require.Contains(out, "_nomad-check-2")
+
+	require.Equal("web/alive:\n  HTTP GET: 200 OK\nweb/ready:\n  not ready\n  retrying",
+		formatAllocCheckOutputs(checks))
+}
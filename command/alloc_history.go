@@ -0,0 +1,210 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+type AllocHistoryCommand struct {
+	Meta
+}
+
+func (c *AllocHistoryCommand) Help() string {
+	helpText := `
+Usage: nomad alloc history [options] -job <job>
+
+  History is used to list the archived allocations of a job. Terminal
+  allocations are archived by the servers before they are garbage collected, so
+  the history covers allocations that "nomad job status" no longer shows. The
+  allocation archive must be enabled on the servers.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+History Options:
+
+  -job
+    The ID of the job whose allocations are listed. Required.
+
+  -since
+    Only lists the allocations that stopped within the given duration, such as
+    "12h" or "30d". By default all the archived allocations are listed.
+
+  -json
+    Output the allocations in a JSON format.
+
+  -t
+    Format and display the allocations using a Go template.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AllocHistoryCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-job":     complete.PredictAnything,
+			"-since":   complete.PredictAnything,
+			"-json":    complete.PredictNothing,
+			"-t":       complete.PredictAnything,
+			"-verbose": complete.PredictNothing,
+		})
+}
+
+func (c *AllocHistoryCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *AllocHistoryCommand) Synopsis() string {
+	return "List the archived allocations of a job"
+}
+
+func (c *AllocHistoryCommand) Run(args []string) int {
+	var json, verbose bool
+	var jobID, sinceStr, tmpl string
+
+	flags := c.Meta.FlagSet("alloc history", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&jobID, "job", "", "")
+	flags.StringVar(&sinceStr, "since", "", "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments and a job
+	args = flags.Args()
+	if l := len(args); l != 0 || jobID == "" {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	var since time.Time
+	if sinceStr != "" {
+		d, err := parseSinceDuration(sinceStr)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error parsing since value %q: %v", sinceStr, err))
+			return 1
+		}
+		since = time.Now().Add(-d)
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	allocs, _, err := client.Jobs().AllocationHistory(jobID, since, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving allocation history: %s", err))
+		return 1
+	}
+
+	if json || len(tmpl) > 0 {
+		out, err := Format(json, tmpl, allocs)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		c.Ui.Output(out)
+		return 0
+	}
+
+	c.Ui.Output(formatArchivedAllocs(allocs, length))
+	return 0
+}
+
+// parseSinceDuration parses a duration that may also be specified in days,
+// such as "30d".
+func parseSinceDuration(s string) (time.Duration, error) {
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid number of days")
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration must not be negative")
+	}
+	return d, nil
+}
+
+func formatArchivedAllocs(allocs []*api.ArchivedAllocation, uuidLength int) string {
+	if len(allocs) == 0 {
+		return "No archived allocations found"
+	}
+
+	rows := make([]string, len(allocs)+1)
+	rows[0] = "ID|Node|Task Group|Version|Status|Created|Stopped|Exit Codes"
+	for i, a := range allocs {
+		node := a.NodeName
+		if node == "" {
+			node = limit(a.NodeID, uuidLength)
+		}
+		rows[i+1] = fmt.Sprintf("%s|%s|%s|%d|%s|%s|%s|%s",
+			limit(a.ID, uuidLength),
+			node,
+			a.TaskGroup,
+			a.JobVersion,
+			a.ClientStatus,
+			formatUnixNanoTime(a.CreateTime),
+			formatUnixNanoTime(a.ModifyTime),
+			formatArchivedExits(a.TaskStates))
+	}
+	return formatList(rows)
+}
+
+// formatArchivedExits returns the exit code, or terminating signal, of each
+// task that ran.
+func formatArchivedExits(states map[string]*api.ArchivedTaskState) string {
+	tasks := make([]string, 0, len(states))
+	for task := range states {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+
+	exits := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ts := states[task]
+		switch {
+		case ts.StartedAt.IsZero():
+			continue
+		case ts.Signal != 0:
+			exits = append(exits, fmt.Sprintf("%s=signal %d", task, ts.Signal))
+		default:
+			exits = append(exits, fmt.Sprintf("%s=%d", task, ts.ExitCode))
+		}
+	}
+
+	if len(exits) == 0 {
+		return "<none>"
+	}
+	return strings.Join(exits, ", ")
+}
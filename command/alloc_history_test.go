@@ -0,0 +1,99 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocHistoryCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &AllocHistoryCommand{}
+}
+
+func TestAllocHistoryCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &AllocHistoryCommand{Meta: Meta{Ui: ui}}
+
+	// Fails without a job
+	if code := cmd.Run([]string{"-since=30d"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on an invalid since
+	if code := cmd.Run([]string{"-job=example", "-since=a month"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error parsing since") {
+		t.Fatalf("expected since error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "-job=example"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error retrieving allocation history") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestAllocHistoryCommand_ParseSince(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	d, err := parseSinceDuration("30d")
+	require.NoError(err)
+	require.Equal(30*24*time.Hour, d)
+
+	d, err = parseSinceDuration("12h")
+	require.NoError(err)
+	require.Equal(12*time.Hour, d)
+
+	for _, invalid := range []string{"d", "1.5d", "-1h", "soon"} {
+		_, err = parseSinceDuration(invalid)
+		require.Error(err, invalid)
+	}
+}
+
+func TestAllocHistoryCommand_Format(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	require.Equal("No archived allocations found", formatArchivedAllocs(nil, shortId))
+
+	started := time.Now()
+	allocs := []*api.ArchivedAllocation{
+		{
+			ID:           "11111111-2222-3333-4444-555555555555",
+			NodeID:       "66666666-7777-8888-9999-000000000000",
+			TaskGroup:    "web",
+			JobVersion:   3,
+			ClientStatus: "failed",
+			TaskStates: map[string]*api.ArchivedTaskState{
+				"web":     {StartedAt: started, ExitCode: 1},
+				"sidecar": {StartedAt: started, Signal: 9},
+				"init":    {},
+			},
+		},
+	}
+	out := formatArchivedAllocs(allocs, shortId)
+	require.Contains(out, "11111111")
+	require.Contains(out, "66666666")
+	require.NotContains(out, "66666666-7777")
+	require.Contains(out, "sidecar=signal 9, web=1")
+	require.NotContains(out, "init")
+
+	// Node names are preferred over IDs
+	allocs[0].NodeName = "worker-1"
+	require.Contains(formatArchivedAllocs(allocs, shortId), "worker-1")
+}
@@ -2,10 +2,14 @@ package command
 
 import (
 	"fmt"
+	"io"
 	"math"
+	"os"
+	"os/signal"
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
@@ -41,6 +45,10 @@ Alloc Status Options:
   -stats
     Display detailed resource usage statistics.
 
+  -follow
+    Keep printing the resource usage of the tasks as it is streamed by the
+    client of the allocation, until interrupted.
+
   -verbose
     Show full information.
 
@@ -62,6 +70,8 @@ func (c *AllocStatusCommand) AutocompleteFlags() complete.Flags {
 	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
 		complete.Flags{
 			"-short":   complete.PredictNothing,
+			"-stats":   complete.PredictNothing,
+			"-follow":  complete.PredictNothing,
 			"-verbose": complete.PredictNothing,
 			"-json":    complete.PredictNothing,
 			"-t":       complete.PredictAnything,
@@ -84,7 +94,7 @@ func (c *AllocStatusCommand) AutocompleteArgs() complete.Predictor {
 }
 
 func (c *AllocStatusCommand) Run(args []string) int {
-	var short, displayStats, verbose, json bool
+	var short, displayStats, follow, verbose, json bool
 	var tmpl string
 
 	flags := c.Meta.FlagSet("alloc-status", FlagSetClient)
@@ -92,6 +102,7 @@ func (c *AllocStatusCommand) Run(args []string) int {
 	flags.BoolVar(&short, "short", false, "")
 	flags.BoolVar(&verbose, "verbose", false, "")
 	flags.BoolVar(&displayStats, "stats", false, "")
+	flags.BoolVar(&follow, "follow", false, "")
 	flags.BoolVar(&json, "json", false, "")
 	flags.StringVar(&tmpl, "t", "", "")
 
@@ -133,6 +144,11 @@ func (c *AllocStatusCommand) Run(args []string) int {
 	}
 	allocID := args[0]
 
+	if follow && (short || json || len(tmpl) > 0) {
+		c.Ui.Error("The -follow flag can't be used with -short, -json or -t")
+		return 1
+	}
+
 	// Truncate the id unless full length is requested
 	length := shortId
 	if verbose {
@@ -210,9 +226,77 @@ func (c *AllocStatusCommand) Run(args []string) int {
 		c.Ui.Output(formatAllocMetrics(alloc.Metrics, true, "  "))
 	}
 
+	if follow {
+		return c.followStats(client, alloc)
+	}
 	return 0
 }
 
+// followStats prints the resource usage of the tasks of the allocation as it
+// is streamed by its client, until interrupted.
+func (c *AllocStatusCommand) followStats(client *api.Client, alloc *api.Allocation) int {
+	cancel := make(chan struct{})
+	defer close(cancel)
+	samples, errCh := client.Allocations().StatsStream(alloc, 0, cancel, nil)
+
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+
+	c.Ui.Output(c.Colorize().Color("\n[bold]Resource Usage[reset]"))
+	for {
+		select {
+		case <-signalCh:
+			return 0
+		case err := <-errCh:
+			if err == io.EOF {
+				// The client of the allocation ended the stream
+				return 0
+			}
+			c.Ui.Error(fmt.Sprintf("Error streaming resource usage: %v", err))
+			return 1
+		case usage, ok := <-samples:
+			if !ok {
+				samples = nil
+				continue
+			}
+			c.Ui.Output(formatResourceUsageSample(usage))
+		}
+	}
+}
+
+// formatResourceUsageSample formats the resource usage of the tasks in a
+// sample of the stats of an allocation.
+func formatResourceUsageSample(usage *api.AllocResourceUsage) string {
+	tasks := make([]string, 0, len(usage.Tasks))
+	for task := range usage.Tasks {
+		tasks = append(tasks, task)
+	}
+	sort.Strings(tasks)
+
+	out := []string{"Time|Task|CPU|Memory|Disk Read|Disk Write"}
+	for _, task := range tasks {
+		ru := usage.Tasks[task]
+		if ru == nil || ru.ResourceUsage == nil {
+			continue
+		}
+		cpu, memory, read, write := "-", "-", "-", "-"
+		if cs := ru.ResourceUsage.CpuStats; cs != nil {
+			cpu = fmt.Sprintf("%v MHz", math.Floor(cs.TotalTicks))
+		}
+		if ms := ru.ResourceUsage.MemoryStats; ms != nil {
+			memory = humanize.IBytes(ms.RSS)
+		}
+		if ds := ru.ResourceUsage.DiskStats; ds != nil {
+			read = humanize.IBytes(uint64(ds.ReadRate)) + "/s"
+			write = humanize.IBytes(uint64(ds.WriteRate)) + "/s"
+		}
+		out = append(out, fmt.Sprintf("%s|%s|%s|%s|%s|%s",
+			formatUnixNanoTime(ru.Timestamp), task, cpu, memory, read, write))
+	}
+	return formatList(out)
+}
+
 func formatAllocBasicInfo(alloc *api.Allocation, client *api.Client, uuidLength int, verbose bool) (string, error) {
 	var formattedCreateTime, formattedModifyTime string
 
@@ -239,6 +323,10 @@ func formatAllocBasicInfo(alloc *api.Allocation, client *api.Client, uuidLength
 		fmt.Sprintf("Modified|%s", formattedModifyTime),
 	}
 
+	if alloc.StopReason != "" {
+		basic = append(basic, fmt.Sprintf("Stop Reason|%s", alloc.StopReason))
+	}
+
 	if alloc.DeploymentID != "" {
 		health := "unset"
 		if alloc.DeploymentStatus != nil && alloc.DeploymentStatus.Healthy != nil {
@@ -334,6 +422,26 @@ func (c *AllocStatusCommand) outputTaskStatus(state *api.TaskState) {
 		// Reverse order so we are sorted by time
 	}
 	c.Ui.Output(formatList(events))
+
+	// Explain the last termination of the task with what it wrote to stderr
+	if event := lastTerminatedEvent(state.Events); event != nil && len(event.StderrTail) != 0 {
+		c.Ui.Output("")
+		c.Ui.Output(fmt.Sprintf("Stderr Before Termination (%s):", formatUnixNanoTime(event.Time)))
+		for _, line := range event.StderrTail {
+			c.Ui.Output("  " + line)
+		}
+	}
+}
+
+// lastTerminatedEvent returns the most recent TaskTerminated event, or nil if
+// the task hasn't terminated.
+func lastTerminatedEvent(events []*api.TaskEvent) *api.TaskEvent {
+	for i := len(events) - 1; i >= 0; i-- {
+		if events[i].Type == api.TaskTerminated {
+			return events[i]
+		}
+	}
+	return nil
 }
 
 func buildDisplayMessage(event *api.TaskEvent) string {
@@ -394,6 +502,14 @@ func buildDisplayMessage(event *api.TaskEvent) string {
 			parts = append(parts, fmt.Sprintf("Signal: %d", event.Signal))
 		}
 
+		if event.OOMKilled {
+			if event.MemoryLimitMB != 0 {
+				parts = append(parts, fmt.Sprintf("OOM Killed (Memory Limit: %d MB)", event.MemoryLimitMB))
+			} else {
+				parts = append(parts, "OOM Killed")
+			}
+		}
+
 		if event.Message != "" {
 			parts = append(parts, fmt.Sprintf("Exit Message: %q", event.Message))
 		}
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/testutil"
@@ -193,3 +194,78 @@ func TestAllocStatusCommand_AutocompleteArgs(t *testing.T) {
 	assert.Equal(1, len(res))
 	assert.Equal(a.ID, res[0])
 }
+
+func TestAllocStatusCommand_TaskStatus_Diagnostics(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &AllocStatusCommand{Meta: Meta{Ui: ui}}
+
+	state := &api.TaskState{
+		State: structs.TaskStateDead,
+		Events: []*api.TaskEvent{
+			{Type: api.TaskStarted, Time: 1},
+			{
+				Type:          api.TaskTerminated,
+				Time:          2,
+				ExitCode:      137,
+				Signal:        9,
+				OOMKilled:     true,
+				MemoryLimitMB: 256,
+				StderrTail:    []string{"allocating buffer", "fatal: out of memory"},
+			},
+		},
+	}
+	cmd.outputTaskStatus(state)
+
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "Exit Code: 137, Signal: 9, OOM Killed (Memory Limit: 256 MB)") {
+		t.Fatalf("expected OOM description, got: %s", out)
+	}
+	if !strings.Contains(out, "Stderr Before Termination") || !strings.Contains(out, "  fatal: out of memory") {
+		t.Fatalf("expected stderr tail, got: %s", out)
+	}
+}
+
+func TestAllocStatusCommand_Follow(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &AllocStatusCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on incompatible flags
+	if code := cmd.Run([]string{"-address=nope", "-follow", "-json", "foobar"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "-follow flag can't be used") {
+		t.Fatalf("expected flag error, got: %s", out)
+	}
+
+	usage := &api.AllocResourceUsage{
+		Tasks: map[string]*api.TaskResourceUsage{
+			"web": {
+				Timestamp: 1,
+				ResourceUsage: &api.ResourceUsage{
+					CpuStats:    &api.CpuStats{TotalTicks: 312.4},
+					MemoryStats: &api.MemoryStats{RSS: 45 * 1024 * 1024},
+					DiskStats:   &api.DiskStats{WriteRate: 2048},
+				},
+			},
+			"sidecar": {
+				Timestamp:     1,
+				ResourceUsage: &api.ResourceUsage{},
+			},
+		},
+	}
+	out := formatResourceUsageSample(usage)
+	lines := strings.Split(out, "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and two tasks, got: %s", out)
+	}
+	if !strings.Contains(lines[1], "sidecar") || !strings.Contains(lines[1], "-") {
+		t.Fatalf("expected sidecar without stats first, got: %s", out)
+	}
+	for _, s := range []string{"web", "312 MHz", "45 MiB", "0 B/s", "2.0 KiB/s"} {
+		if !strings.Contains(lines[2], s) {
+			t.Fatalf("expected %q in %q", s, lines[2])
+		}
+	}
+}
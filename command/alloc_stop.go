@@ -0,0 +1,164 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/api/contexts"
+	"github.com/posener/complete"
+)
+
+type AllocStopCommand struct {
+	Meta
+}
+
+func (c *AllocStopCommand) Help() string {
+	helpText := `
+Usage: nomad alloc stop [options] [<allocation>]
+
+  Stop is used to stop running allocations so that they are replaced by the
+  schedulers. Either a single allocation ID or prefix is given, or the
+  allocations of the namespace that match the filter expression are stopped.
+  The allocations are stopped by the servers in batches and the progress is
+  displayed after each batch.
+
+  The filter expression compares fields of the allocations, joined by "and":
+
+      NodeID == "f1b2..." and TaskGroup == "cache"
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Stop Options:
+
+  -filter
+    The expression selecting the allocations to stop.
+
+  -batch-size
+    The number of allocations to stop per request. Defaults to 100.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AllocStopCommand) Synopsis() string {
+	return "Stop and replace allocations"
+}
+
+func (c *AllocStopCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-filter":     complete.PredictAnything,
+			"-batch-size": complete.PredictAnything,
+			"-verbose":    complete.PredictNothing,
+		})
+}
+
+func (c *AllocStopCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		client, err := c.Meta.Client()
+		if err != nil {
+			return nil
+		}
+
+		resp, _, err := client.Search().PrefixSearch(a.Last, contexts.Allocs, nil)
+		if err != nil {
+			return []string{}
+		}
+		return resp.Matches[contexts.Allocs]
+	})
+}
+
+func (c *AllocStopCommand) Run(args []string) int {
+	var filter string
+	var batchSize int
+	var verbose bool
+
+	flags := c.Meta.FlagSet("alloc stop", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&filter, "filter", "", "")
+	flags.IntVar(&batchSize, "batch-size", 0, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got either an allocation or a filter
+	args = flags.Args()
+	if len(args) > 1 || (len(args) == 1) == (filter != "") {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Lookup a single allocation by its prefix
+	if len(args) == 1 {
+		allocID := args[0]
+		if len(allocID) == 1 {
+			c.Ui.Error(fmt.Sprintf("Identifier must contain at least two characters."))
+			return 1
+		}
+
+		allocID = sanatizeUUIDPrefix(allocID)
+		allocs, _, err := client.Allocations().PrefixList(allocID)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error querying allocation: %v", err))
+			return 1
+		}
+		if len(allocs) == 0 {
+			c.Ui.Error(fmt.Sprintf("No allocation(s) with prefix or id %q found", allocID))
+			return 1
+		}
+		if len(allocs) > 1 {
+			out := formatAllocListStubs(allocs, verbose, length)
+			c.Ui.Error(fmt.Sprintf("Prefix matched multiple allocations\n\n%s", out))
+			return 1
+		}
+		filter = fmt.Sprintf("ID == %q", allocs[0].ID)
+	}
+
+	req := &api.AllocBatchStopRequest{
+		Filter:    filter,
+		BatchSize: batchSize,
+	}
+	stopped := 0
+	var evalIDs []string
+	for {
+		resp, _, err := client.Allocations().StopBatch(req, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error stopping allocations: %s", err))
+			return 1
+		}
+		stopped += resp.Stopped
+		evalIDs = append(evalIDs, resp.EvalIDs...)
+
+		if resp.NextToken == "" {
+			break
+		}
+		c.Ui.Output(fmt.Sprintf("==> Stopped %d allocations so far", stopped))
+		req.NextToken = resp.NextToken
+	}
+
+	c.Ui.Output(fmt.Sprintf("Stopped %d allocations", stopped))
+	for _, id := range evalIDs {
+		c.Ui.Output(fmt.Sprintf("Created evaluation %q to replace them", limit(id, length)))
+	}
+	return 0
+}
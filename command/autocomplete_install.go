@@ -0,0 +1,227 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	multierror "github.com/hashicorp/go-multierror"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/posener/complete/cmd/install"
+)
+
+// autocompleteShell installs the autocompletion of a command in a shell. The
+// shells complete the command by running its binary with the COMP_LINE
+// environment variable set to the line being completed, so the arguments,
+// such as job and allocation IDs, are completed dynamically by the commands.
+type autocompleteShell interface {
+	Install(cmd, bin string) error
+	Uninstall(cmd, bin string) error
+}
+
+// AutocompleteInstall installs the autocompletion of the command in the shells
+// of the user: bash, zsh, fish and PowerShell.
+func AutocompleteInstall(cmd string) error {
+	return autocompleteRun(cmd, true)
+}
+
+// AutocompleteUninstall uninstalls the autocompletion of the command from the
+// shells of the user.
+func AutocompleteUninstall(cmd string) error {
+	return autocompleteRun(cmd, false)
+}
+
+func autocompleteRun(cmd string, add bool) error {
+	home, err := homedir.Dir()
+	if err != nil {
+		return fmt.Errorf("failed to find the home directory: %v", err)
+	}
+	bin, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if bin, err = filepath.Abs(bin); err != nil {
+		return err
+	}
+
+	shells := autocompleteShells(home)
+	if len(shells) == 0 {
+		return fmt.Errorf("no shells found to install the autocompletion in")
+	}
+
+	var mErr multierror.Error
+	for _, s := range shells {
+		if add {
+			err = s.Install(cmd, bin)
+		} else {
+			err = s.Uninstall(cmd, bin)
+		}
+		if err != nil {
+			multierror.Append(&mErr, err)
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// autocompleteShells returns the shells the user has configured in the home
+// directory.
+func autocompleteShells(home string) []autocompleteShell {
+	var shells []autocompleteShell
+
+	// Bash and zsh are installed by the completion library, which looks the
+	// shells up itself
+	for _, rc := range []string{".bashrc", ".bash_profile", ".zshrc"} {
+		if fileExists(filepath.Join(home, rc)) {
+			shells = append(shells, posenerShells{})
+			break
+		}
+	}
+
+	if dir := filepath.Join(home, ".config", "fish"); fileExists(dir) {
+		shells = append(shells, fishShell{dir: filepath.Join(dir, "completions")})
+	}
+
+	for _, profile := range powershellProfiles(home) {
+		if fileExists(profile) {
+			shells = append(shells, powershellShell{profile: profile})
+		}
+	}
+	return shells
+}
+
+// posenerShells installs the completion in bash and zsh.
+type posenerShells struct{}
+
+func (posenerShells) Install(cmd, _ string) error   { return install.Install(cmd) }
+func (posenerShells) Uninstall(cmd, _ string) error { return install.Uninstall(cmd) }
+
+// fishShell installs the completion as a file of the fish completions
+// directory.
+type fishShell struct {
+	dir string
+}
+
+func (f fishShell) path(cmd string) string {
+	return filepath.Join(f.dir, cmd+".fish")
+}
+
+func (f fishShell) Install(cmd, bin string) error {
+	path := f.path(cmd)
+	if fileExists(path) {
+		return fmt.Errorf("already installed in %s", path)
+	}
+	if err := os.MkdirAll(f.dir, 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(fishCompletionScript(cmd, bin)), 0644)
+}
+
+func (f fishShell) Uninstall(cmd, _ string) error {
+	path := f.path(cmd)
+	if !fileExists(path) {
+		return fmt.Errorf("not installed in %s", path)
+	}
+	return os.Remove(path)
+}
+
+// fishCompletionScript returns the fish script completing the command with
+// the binary.
+func fishCompletionScript(cmd, bin string) string {
+	return fmt.Sprintf(`function __complete_%[1]s
+    set -lx COMP_LINE (commandline -cp)
+    test -z (commandline -ct)
+    and set COMP_LINE "$COMP_LINE "
+    '%[2]s'
+end
+complete -f -c %[1]s -a "(__complete_%[1]s)"
+`, cmd, bin)
+}
+
+// powershellShell installs the completion as a line of a PowerShell profile.
+type powershellShell struct {
+	profile string
+}
+
+func (p powershellShell) Install(cmd, bin string) error {
+	line := powershellCompletionScript(cmd, bin)
+	lines, err := readLines(p.profile)
+	if err != nil {
+		return err
+	}
+	for _, l := range lines {
+		if l == line {
+			return fmt.Errorf("already installed in %s", p.profile)
+		}
+	}
+
+	f, err := os.OpenFile(p.profile, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "\n%s\n", line)
+	return err
+}
+
+func (p powershellShell) Uninstall(cmd, bin string) error {
+	line := powershellCompletionScript(cmd, bin)
+	lines, err := readLines(p.profile)
+	if err != nil {
+		return err
+	}
+
+	kept := make([]string, 0, len(lines))
+	for _, l := range lines {
+		if l != line {
+			kept = append(kept, l)
+		}
+	}
+	if len(kept) == len(lines) {
+		return fmt.Errorf("not installed in %s", p.profile)
+	}
+	return ioutil.WriteFile(p.profile, []byte(strings.Join(kept, "\n")+"\n"), 0644)
+}
+
+// powershellCompletionScript returns the PowerShell line registering the
+// completion of the command with the binary. The line is truncated at the
+// cursor and a space is appended when completing a new word, as the shells
+// supported by the completion library do.
+func powershellCompletionScript(cmd, bin string) string {
+	return fmt.Sprintf(`Register-ArgumentCompleter -Native -CommandName '%s' -ScriptBlock { `+
+		`param($word, $ast, $pos) `+
+		`$line = $ast.Extent.Text; $end = $pos - $ast.Extent.StartOffset; `+
+		`if ($end -lt $line.Length) { $line = $line.Substring(0, $end) }; `+
+		`if ($word -eq '') { $line += ' ' }; `+
+		`$env:COMP_LINE = $line; `+
+		`& '%s' | ForEach-Object { [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_) }; `+
+		`Remove-Item Env:\COMP_LINE }`,
+		cmd, strings.Replace(bin, "'", "''", -1))
+}
+
+// powershellProfiles returns the paths the PowerShell profile of the user may
+// be at.
+func powershellProfiles(home string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{
+			filepath.Join(home, "Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1"),
+			filepath.Join(home, "Documents", "WindowsPowerShell", "Microsoft.PowerShell_profile.ps1"),
+		}
+	}
+	return []string{filepath.Join(home, ".config", "powershell", "Microsoft.PowerShell_profile.ps1")}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func readLines(path string) ([]string, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(buf), "\n"), "\n"), nil
+}
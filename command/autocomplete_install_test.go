@@ -0,0 +1,95 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutocompleteShells(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	home, err := ioutil.TempDir("", "nomad-autocomplete")
+	require.NoError(err)
+	defer os.RemoveAll(home)
+
+	require.Empty(autocompleteShells(home))
+
+	require.NoError(os.MkdirAll(filepath.Join(home, ".config", "fish"), 0755))
+	profile := powershellProfiles(home)[0]
+	require.NoError(os.MkdirAll(filepath.Dir(profile), 0755))
+	require.NoError(ioutil.WriteFile(profile, []byte("Set-PSReadLineOption -EditMode Emacs\n"), 0644))
+
+	shells := autocompleteShells(home)
+	require.Len(shells, 2)
+	require.Equal(fishShell{dir: filepath.Join(home, ".config", "fish", "completions")}, shells[0])
+	require.Equal(powershellShell{profile: profile}, shells[1])
+}
+
+func TestAutocompleteShells_Fish(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad-autocomplete")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	s := fishShell{dir: filepath.Join(dir, "completions")}
+	require.NoError(s.Install("nomad", "/usr/bin/nomad"))
+
+	buf, err := ioutil.ReadFile(filepath.Join(dir, "completions", "nomad.fish"))
+	require.NoError(err)
+	require.Equal(fishCompletionScript("nomad", "/usr/bin/nomad"), string(buf))
+	require.Contains(string(buf), `complete -f -c nomad -a "(__complete_nomad)"`)
+
+	err = s.Install("nomad", "/usr/bin/nomad")
+	require.Error(err)
+	require.Contains(err.Error(), "already installed")
+
+	require.NoError(s.Uninstall("nomad", "/usr/bin/nomad"))
+	_, err = os.Stat(filepath.Join(dir, "completions", "nomad.fish"))
+	require.True(os.IsNotExist(err))
+	require.Error(s.Uninstall("nomad", "/usr/bin/nomad"))
+}
+
+func TestAutocompleteShells_PowerShell(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	if runtime.GOOS == "windows" {
+		t.Skip("paths differ on Windows")
+	}
+
+	dir, err := ioutil.TempDir("", "nomad-autocomplete")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	profile := filepath.Join(dir, "profile.ps1")
+	require.NoError(ioutil.WriteFile(profile, []byte("Set-PSReadLineOption -EditMode Emacs\n"), 0644))
+
+	s := powershellShell{profile: profile}
+	bin := "/opt/it's/nomad"
+	require.NoError(s.Install("nomad", bin))
+
+	buf, err := ioutil.ReadFile(profile)
+	require.NoError(err)
+	line := powershellCompletionScript("nomad", bin)
+	require.Equal("Set-PSReadLineOption -EditMode Emacs\n\n"+line+"\n", string(buf))
+	require.False(strings.Contains(line, "\n"))
+	require.Contains(line, `& '/opt/it''s/nomad'`)
+
+	err = s.Install("nomad", bin)
+	require.Error(err)
+	require.Contains(err.Error(), "already installed")
+
+	require.NoError(s.Uninstall("nomad", bin))
+	buf, err = ioutil.ReadFile(profile)
+	require.NoError(err)
+	require.Equal("Set-PSReadLineOption -EditMode Emacs\n\n", string(buf))
+	require.Error(s.Uninstall("nomad", bin))
+}
@@ -0,0 +1,276 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/api/contexts"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/posener/complete"
+)
+
+const (
+	// deploymentWatchRefresh is the maximum duration to wait for a change of
+	// the deployment before redisplaying a pending auto-revert countdown.
+	deploymentWatchRefresh = 10 * time.Second
+)
+
+type DeploymentWatchCommand struct {
+	Meta
+}
+
+func (c *DeploymentWatchCommand) Help() string {
+	helpText := `
+Usage: nomad deployment watch [options] <deployment id>
+
+  Watch is used to stream the progress of a deployment until it completes. The
+  status of the deployment is displayed each time it changes, including the
+  health of the canaries and the time left before the allocations of task
+  groups with auto revert enabled must be healthy.
+
+  The exit status is 0 if the deployment is successful and 2 if it fails or
+  is cancelled.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Watch Options:
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DeploymentWatchCommand) Synopsis() string {
+	return "Watch the progress of a deployment"
+}
+
+func (c *DeploymentWatchCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-verbose": complete.PredictNothing,
+		})
+}
+
+func (c *DeploymentWatchCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		client, err := c.Meta.Client()
+		if err != nil {
+			return nil
+		}
+
+		resp, _, err := client.Search().PrefixSearch(a.Last, contexts.Deployments, nil)
+		if err != nil {
+			return []string{}
+		}
+		return resp.Matches[contexts.Deployments]
+	})
+}
+
+func (c *DeploymentWatchCommand) Run(args []string) int {
+	var verbose bool
+
+	flags := c.Meta.FlagSet("deployment watch", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&verbose, "verbose", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	dID := args[0]
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Do a prefix lookup
+	deploy, possible, err := getDeployment(client.Deployments(), dID)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving deployment: %s", err))
+		return 1
+	}
+
+	if len(possible) != 0 {
+		c.Ui.Error(fmt.Sprintf("Prefix matched multiple deployments\n\n%s", formatDeployments(possible, length)))
+		return 1
+	}
+
+	// Lookup the healthy deadlines of the deployed job version to display the
+	// auto-revert countdowns
+	deadlines, err := deploymentHealthyDeadlines(client, deploy)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error retrieving job versions: %s", err))
+		return 1
+	}
+
+	q := &api.QueryOptions{Namespace: deploy.Namespace}
+	var lastIndex uint64
+	for {
+		allocs, _, err := client.Deployments().Allocations(deploy.ID, &api.QueryOptions{Namespace: deploy.Namespace})
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error retrieving deployment allocations: %s", err))
+			return 1
+		}
+		// Only redisplay an unchanged deployment to refresh its countdowns
+		countdowns := deploymentRevertCountdowns(deploy, allocs, deadlines, time.Now())
+		if deploy.ModifyIndex != lastIndex || len(countdowns) != 0 {
+			c.Ui.Output(c.Colorize().Color(formatDeploymentWatch(deploy, allocs, countdowns, length)))
+		}
+		lastIndex = deploy.ModifyIndex
+
+		switch deploy.Status {
+		case structs.DeploymentStatusRunning, structs.DeploymentStatusPaused, structs.DeploymentStatusQueued:
+		case structs.DeploymentStatusSuccessful:
+			return 0
+		default:
+			return 2
+		}
+
+		// Block until the deployment changes
+		q.WaitIndex = deploy.ModifyIndex
+		q.WaitTime = deploymentWatchRefresh
+		deploy, _, err = client.Deployments().Info(deploy.ID, q)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error retrieving deployment: %s", err))
+			return 1
+		}
+	}
+}
+
+// deploymentHealthyDeadlines returns the healthy deadlines of the task groups
+// with auto revert enabled of the job version being deployed.
+func deploymentHealthyDeadlines(client *api.Client, d *api.Deployment) (map[string]time.Duration, error) {
+	autorevert := false
+	for _, state := range d.TaskGroups {
+		autorevert = autorevert || state.AutoRevert
+	}
+	if !autorevert {
+		return nil, nil
+	}
+
+	versions, _, _, err := client.Jobs().Versions(d.JobID, false, &api.QueryOptions{Namespace: d.Namespace})
+	if err != nil {
+		return nil, err
+	}
+
+	deadlines := make(map[string]time.Duration)
+	for _, job := range versions {
+		if job.Version == nil || *job.Version != d.JobVersion {
+			continue
+		}
+		for _, tg := range job.TaskGroups {
+			state, ok := d.TaskGroups[*tg.Name]
+			if !ok || !state.AutoRevert || tg.Update == nil || tg.Update.HealthyDeadline == nil {
+				continue
+			}
+			deadlines[*tg.Name] = *tg.Update.HealthyDeadline
+		}
+	}
+	return deadlines, nil
+}
+
+// deploymentRevertCountdowns returns the time left per task group with auto
+// revert enabled before its earliest placed allocation without a health
+// status must be healthy. The healthy deadline starts once the allocation is
+// running on its client, so the countdown is measured from its creation as an
+// approximation.
+func deploymentRevertCountdowns(d *api.Deployment, allocs []*api.AllocationListStub,
+	deadlines map[string]time.Duration, now time.Time) map[string]time.Duration {
+
+	countdowns := make(map[string]time.Duration)
+	if d.Status != structs.DeploymentStatusRunning {
+		return countdowns
+	}
+
+	for _, alloc := range allocs {
+		deadline, ok := deadlines[alloc.TaskGroup]
+		if !ok || alloc.DesiredStatus != structs.AllocDesiredStatusRun {
+			continue
+		}
+		if alloc.DeploymentStatus != nil && alloc.DeploymentStatus.Healthy != nil {
+			continue
+		}
+
+		left := time.Unix(0, alloc.CreateTime).Add(deadline).Sub(now)
+		if left < 0 {
+			left = 0
+		}
+		if current, ok := countdowns[alloc.TaskGroup]; !ok || left < current {
+			countdowns[alloc.TaskGroup] = left
+		}
+	}
+	return countdowns
+}
+
+func formatDeploymentWatch(d *api.Deployment, allocs []*api.AllocationListStub,
+	countdowns map[string]time.Duration, uuidLength int) string {
+
+	base := fmt.Sprintf("[bold]%s: Deployment %q is %s[reset]",
+		formatTime(time.Now()), limit(d.ID, uuidLength), d.Status)
+	if d.StatusDescription != "" {
+		base += fmt.Sprintf("\n    %s", d.StatusDescription)
+	}
+	if len(d.TaskGroups) == 0 {
+		return base
+	}
+	base += "\n" + formatDeploymentGroups(d, uuidLength)
+
+	// Summarize the health of the canaries
+	healthy := make(map[string]bool, len(allocs))
+	for _, alloc := range allocs {
+		if alloc.DeploymentStatus != nil && alloc.DeploymentStatus.Healthy != nil {
+			healthy[alloc.ID] = *alloc.DeploymentStatus.Healthy
+		}
+	}
+
+	tgNames := make([]string, 0, len(d.TaskGroups))
+	for name := range d.TaskGroups {
+		tgNames = append(tgNames, name)
+	}
+	sort.Strings(tgNames)
+
+	var rows []string
+	for _, tg := range tgNames {
+		state := d.TaskGroups[tg]
+		if state.DesiredCanaries > 0 {
+			healthyCanaries := 0
+			for _, id := range state.PlacedCanaries {
+				if healthy[id] {
+					healthyCanaries++
+				}
+			}
+			rows = append(rows, fmt.Sprintf("Canaries of %q|%d of %d healthy",
+				tg, healthyCanaries, state.DesiredCanaries))
+		}
+		if left, ok := countdowns[tg]; ok {
+			rows = append(rows, fmt.Sprintf("Auto revert of %q|in %s unless healthy",
+				tg, left.Truncate(time.Second)))
+		}
+	}
+	if len(rows) != 0 {
+		base += "\n" + formatKV(rows)
+	}
+	return base + "\n"
+}
@@ -0,0 +1,118 @@
+package command
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeploymentWatchCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &DeploymentWatchCommand{}
+}
+
+func TestDeploymentWatchCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &DeploymentWatchCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	if code := cmd.Run([]string{"-address=nope", "12"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error retrieving deployment") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+}
+
+func TestDeploymentWatchCommand_Run(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	srv, _, url := testServer(t, true, nil)
+	defer srv.Shutdown()
+
+	// Create a running deployment that completes while it is watched
+	state := srv.Agent.Server().State()
+	d := mock.Deployment()
+	d.TaskGroups["web"].DesiredCanaries = 1
+	require.Nil(state.UpsertDeployment(1000, d))
+
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		update := &structs.DeploymentStatusUpdate{
+			DeploymentID:      d.ID,
+			Status:            structs.DeploymentStatusSuccessful,
+			StatusDescription: structs.DeploymentStatusDescriptionSuccessful,
+		}
+		state.UpdateDeploymentStatus(1001, &structs.DeploymentStatusUpdateRequest{DeploymentUpdate: update})
+	}()
+
+	ui := new(cli.MockUi)
+	cmd := &DeploymentWatchCommand{Meta: Meta{Ui: ui}}
+	code := cmd.Run([]string{"-address=" + url, d.ID})
+	require.Equal(0, code, ui.ErrorWriter.String())
+
+	out := ui.OutputWriter.String()
+	require.Contains(out, "is running")
+	require.Contains(out, "is successful")
+	require.Contains(out, `Canaries of "web"`)
+}
+
+func TestDeploymentWatch_RevertCountdowns(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	now := time.Now()
+	d := &api.Deployment{
+		Status: structs.DeploymentStatusRunning,
+		TaskGroups: map[string]*api.DeploymentState{
+			"web": {AutoRevert: true},
+		},
+	}
+	allocs := []*api.AllocationListStub{
+		{
+			TaskGroup:     "web",
+			DesiredStatus: structs.AllocDesiredStatusRun,
+			CreateTime:    now.Add(-time.Minute).UnixNano(),
+		},
+		{
+			TaskGroup:     "web",
+			DesiredStatus: structs.AllocDesiredStatusRun,
+			CreateTime:    now.Add(-time.Minute).UnixNano(),
+			DeploymentStatus: &api.AllocDeploymentStatus{
+				Healthy: helper.BoolToPtr(true),
+			},
+		},
+		{
+			TaskGroup:     "web",
+			DesiredStatus: structs.AllocDesiredStatusRun,
+			CreateTime:    now.Add(-2 * time.Minute).UnixNano(),
+		},
+	}
+	deadlines := map[string]time.Duration{"web": 5 * time.Minute}
+
+	// The earliest allocation without a health status determines the countdown
+	countdowns := deploymentRevertCountdowns(d, allocs, deadlines, now)
+	assert.Equal(map[string]time.Duration{"web": 3 * time.Minute}, countdowns)
+
+	d.Status = structs.DeploymentStatusPaused
+	assert.Empty(deploymentRevertCountdowns(d, allocs, deadlines, now))
+}
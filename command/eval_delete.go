@@ -0,0 +1,245 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/api/contexts"
+	"github.com/posener/complete"
+)
+
+// defaultEvalDeleteBatchSize is the number of evaluations deleted by ID per
+// request.
+const defaultEvalDeleteBatchSize = 100
+
+type EvalDeleteCommand struct {
+	Meta
+}
+
+func (c *EvalDeleteCommand) Help() string {
+	helpText := `
+Usage: nomad eval delete [options] [<eval_id>...]
+
+  Delete is used to delete evaluations. The evaluations are selected in one of
+  the following ways:
+
+  * By ID, or unique ID prefix, given as arguments.
+
+  * By the -job, -status and -triggered-by filters of "nomad eval list". This
+    is used to remove the blocked evaluations that are stuck, for example
+    after an outage:
+
+      nomad eval delete -status blocked
+
+  * By the -filter expression, which compares fields of the evaluations
+    joined by "and":
+
+      Status == "failed" and JobID contains "batch-"
+
+  The evaluations are deleted by the servers in batches and the progress is
+  displayed after each batch. Blocked evaluations are deleted and no longer
+  tracked by the leader, while evaluations that are still pending are never
+  deleted.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Delete Options:
+
+  -job
+    Delete the evaluations of the job with the given ID.
+
+  -status
+    Delete the evaluations with the given status, such as "blocked".
+
+  -triggered-by
+    Delete the evaluations triggered by the given reason.
+
+  -filter
+    The expression selecting the evaluations to delete.
+
+  -batch-size
+    The number of evaluations to delete per request. Defaults to 100.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *EvalDeleteCommand) Synopsis() string {
+	return "Delete the evaluations matching a filter"
+}
+
+func (c *EvalDeleteCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-job":          complete.PredictAnything,
+			"-status":       complete.PredictAnything,
+			"-triggered-by": complete.PredictAnything,
+			"-filter":       complete.PredictAnything,
+			"-batch-size":   complete.PredictAnything,
+		})
+}
+
+func (c *EvalDeleteCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		client, err := c.Meta.Client()
+		if err != nil {
+			return nil
+		}
+
+		resp, _, err := client.Search().PrefixSearch(a.Last, contexts.Evals, nil)
+		if err != nil {
+			return []string{}
+		}
+		return resp.Matches[contexts.Evals]
+	})
+}
+
+func (c *EvalDeleteCommand) Run(args []string) int {
+	var filters evalListFilters
+	var filter string
+	var batchSize int
+
+	flags := c.Meta.FlagSet("eval delete", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	filters.addFlags(flags)
+	flags.StringVar(&filter, "filter", "", "")
+	flags.IntVar(&batchSize, "batch-size", 0, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that the evaluations are selected in exactly one way
+	args = flags.Args()
+	selectors := 0
+	for _, set := range []bool{len(args) != 0, filters.set(), filter != ""} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if filter != "" {
+		return c.deleteMatching(client, filter, batchSize)
+	}
+
+	var evalIDs []string
+	if len(args) != 0 {
+		for _, prefix := range args {
+			id, err := c.lookupEval(client, prefix)
+			if err != nil {
+				c.Ui.Error(err.Error())
+				return 1
+			}
+			evalIDs = append(evalIDs, id)
+		}
+	} else {
+		evals, _, err := client.Evaluations().List(filters.queryOptions())
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error querying evaluations: %s", err))
+			return 1
+		}
+		for _, eval := range evals {
+			evalIDs = append(evalIDs, eval.ID)
+		}
+	}
+	return c.deleteByID(client, evalIDs, batchSize)
+}
+
+// lookupEval returns the ID of the evaluation with the ID prefix.
+func (c *EvalDeleteCommand) lookupEval(client *api.Client, prefix string) (string, error) {
+	if len(prefix) == 1 {
+		return "", fmt.Errorf("Identifier must contain at least two characters.")
+	}
+
+	prefix = sanatizeUUIDPrefix(prefix)
+	evals, _, err := client.Evaluations().PrefixList(prefix)
+	if err != nil {
+		return "", fmt.Errorf("Error querying evaluation: %v", err)
+	}
+	switch len(evals) {
+	case 0:
+		return "", fmt.Errorf("No evaluation(s) with prefix or id %q found", prefix)
+	case 1:
+		return evals[0].ID, nil
+	default:
+		return "", fmt.Errorf("Prefix %q matched multiple evaluations", prefix)
+	}
+}
+
+// deleteByID deletes the evaluations by ID in batches.
+func (c *EvalDeleteCommand) deleteByID(client *api.Client, evalIDs []string, batchSize int) int {
+	if batchSize <= 0 {
+		batchSize = defaultEvalDeleteBatchSize
+	}
+
+	deleted, skipped := 0, 0
+	for len(evalIDs) != 0 {
+		batch := evalIDs
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+		evalIDs = evalIDs[len(batch):]
+
+		resp, _, err := client.Evaluations().Delete(batch, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error deleting evaluations: %s", err))
+			return 1
+		}
+		deleted += len(resp.Deleted)
+		skipped += len(resp.Skipped)
+
+		if len(evalIDs) != 0 {
+			c.Ui.Output(fmt.Sprintf("==> Deleted %d evaluations so far", deleted))
+		}
+	}
+
+	c.Ui.Output(fmt.Sprintf("Deleted %d evaluations", deleted))
+	if skipped != 0 {
+		c.Ui.Output(fmt.Sprintf("Skipped %d pending evaluations", skipped))
+	}
+	return 0
+}
+
+// deleteMatching deletes the evaluations matching the filter
+// expression in batches.
+func (c *EvalDeleteCommand) deleteMatching(client *api.Client, filter string, batchSize int) int {
+	req := &api.EvalBatchDeleteRequest{
+		Filter:    filter,
+		BatchSize: batchSize,
+	}
+	deleted, skipped := 0, 0
+	for {
+		resp, _, err := client.Evaluations().DeleteBatch(req, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error deleting evaluations: %s", err))
+			return 1
+		}
+		deleted += resp.Deleted
+		skipped += resp.Skipped
+
+		if resp.NextToken == "" {
+			break
+		}
+		c.Ui.Output(fmt.Sprintf("==> Deleted %d evaluations so far", deleted))
+		req.NextToken = resp.NextToken
+	}
+
+	c.Ui.Output(fmt.Sprintf("Deleted %d evaluations", deleted))
+	if skipped != 0 {
+		c.Ui.Output(fmt.Sprintf("Skipped %d pending evaluations", skipped))
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalDeleteCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &EvalDeleteCommand{}
+}
+
+func TestEvalDeleteCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &EvalDeleteCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{`-filter=Status == "failed"`, "some"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", `-filter=Status == "failed"`}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error deleting evaluations") {
+		t.Fatalf("expected failed delete error, got: %s", out)
+	}
+}
+
+func TestEvalDeleteCommand_Run(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	srv, _, url := testServer(t, true, nil)
+	defer srv.Shutdown()
+
+	// Create failed evaluations
+	state := srv.Agent.Server().State()
+	var evals []*structs.Evaluation
+	for i := 0; i < 3; i++ {
+		eval := mock.Eval()
+		eval.Status = structs.EvalStatusFailed
+		evals = append(evals, eval)
+	}
+	require.Nil(state.UpsertEvals(1000, evals))
+
+	ui := new(cli.MockUi)
+	cmd := &EvalDeleteCommand{Meta: Meta{Ui: ui}}
+	code := cmd.Run([]string{"-address=" + url, "-batch-size=2", `-filter=Status == "failed"`})
+	require.Equal(0, code, ui.ErrorWriter.String())
+
+	out := ui.OutputWriter.String()
+	require.Contains(out, "Deleted 2 evaluations so far")
+	require.Contains(out, "Deleted 3 evaluations")
+}
+
+func TestEvalDeleteCommand_Blocked(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	srv, _, url := testServer(t, true, nil)
+	defer srv.Shutdown()
+
+	// Create blocked, pending and failed evaluations
+	state := srv.Agent.Server().State()
+	var evals []*structs.Evaluation
+	for _, status := range []string{structs.EvalStatusBlocked, structs.EvalStatusBlocked, structs.EvalStatusFailed} {
+		eval := mock.Eval()
+		eval.Status = status
+		evals = append(evals, eval)
+	}
+	require.Nil(state.UpsertEvals(1000, evals))
+
+	// Delete the blocked evaluations
+	ui := new(cli.MockUi)
+	cmd := &EvalDeleteCommand{Meta: Meta{Ui: ui}}
+	code := cmd.Run([]string{"-address=" + url, "-status=blocked"})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Contains(ui.OutputWriter.String(), "Deleted 2 evaluations")
+	ui.OutputWriter.Reset()
+
+	// Delete the failed evaluation by ID prefix
+	code = cmd.Run([]string{"-address=" + url, evals[2].ID[:8]})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Contains(ui.OutputWriter.String(), "Deleted 1 evaluations")
+
+	for _, eval := range evals {
+		out, err := state.EvalByID(nil, eval.ID)
+		require.Nil(err)
+		require.Nil(out)
+	}
+}
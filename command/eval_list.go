@@ -0,0 +1,185 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/posener/complete"
+)
+
+type EvalListCommand struct {
+	Meta
+}
+
+func (c *EvalListCommand) Help() string {
+	helpText := `
+Usage: nomad eval list [options]
+
+  List is used to list the evaluations of the namespace, optionally filtered by
+  their job, status and trigger.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+List Options:
+
+  -job
+    Only list the evaluations of the job with the given ID.
+
+  -status
+    Only list the evaluations with the given status, such as "blocked".
+
+  -triggered-by
+    Only list the evaluations triggered by the given reason, such as
+    "node-update".
+
+  -verbose
+    Display full information.
+
+  -json
+    Output the evaluations in JSON format.
+
+  -t
+    Format and display the evaluations using a Go template.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *EvalListCommand) Synopsis() string {
+	return "List evaluations"
+}
+
+func (c *EvalListCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-job": complete.PredictAnything,
+			"-status": complete.PredictSet(
+				structs.EvalStatusBlocked,
+				structs.EvalStatusPending,
+				structs.EvalStatusComplete,
+				structs.EvalStatusFailed,
+				structs.EvalStatusCancelled,
+			),
+			"-triggered-by": complete.PredictAnything,
+			"-verbose":      complete.PredictNothing,
+			"-json":         complete.PredictNothing,
+			"-t":            complete.PredictAnything,
+		})
+}
+
+func (c *EvalListCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *EvalListCommand) Run(args []string) int {
+	var filters evalListFilters
+	var verbose, json bool
+	var tmpl string
+
+	flags := c.Meta.FlagSet("eval list", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	filters.addFlags(flags)
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	evals, _, err := client.Evaluations().List(filters.queryOptions())
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying evaluations: %v", err))
+		return 1
+	}
+
+	// If output format is specified, format and output the data
+	if json || len(tmpl) > 0 {
+		out, err := Format(json, tmpl, evals)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		c.Ui.Output(out)
+		return 0
+	}
+
+	if len(evals) == 0 {
+		c.Ui.Output("No evaluations found")
+		return 0
+	}
+
+	out := make([]string, len(evals)+1)
+	out[0] = "ID|Priority|Triggered By|Job ID|Status|Placement Failures"
+	for i, eval := range evals {
+		failures, _ := evalFailureStatus(eval)
+		out[i+1] = fmt.Sprintf("%s|%d|%s|%s|%s|%s",
+			limit(eval.ID, length),
+			eval.Priority,
+			eval.TriggeredBy,
+			eval.JobID,
+			eval.Status,
+			failures,
+		)
+	}
+	c.Ui.Output(formatList(out))
+	return 0
+}
+
+// evalListFilters are the flags filtering the evaluations listed.
+type evalListFilters struct {
+	job         string
+	status      string
+	triggeredBy string
+}
+
+// addFlags adds the filter flags to the flag set.
+func (f *evalListFilters) addFlags(flags *flag.FlagSet) {
+	flags.StringVar(&f.job, "job", "", "")
+	flags.StringVar(&f.status, "status", "", "")
+	flags.StringVar(&f.triggeredBy, "triggered-by", "", "")
+}
+
+// set returns whether any filter is set.
+func (f *evalListFilters) set() bool {
+	return f.job != "" || f.status != "" || f.triggeredBy != ""
+}
+
+// queryOptions returns the query options of the evaluation list request.
+func (f *evalListFilters) queryOptions() *api.QueryOptions {
+	q := &api.QueryOptions{Params: make(map[string]string)}
+	if f.job != "" {
+		q.Params["job"] = f.job
+	}
+	if f.status != "" {
+		q.Params["status"] = f.status
+	}
+	if f.triggeredBy != "" {
+		q.Params["triggered_by"] = f.triggeredBy
+	}
+	return q
+}
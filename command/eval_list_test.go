@@ -0,0 +1,83 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalListCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &EvalListCommand{}
+}
+
+func TestEvalListCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &EvalListCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying evaluations") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestEvalListCommand_Run(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	srv, _, url := testServer(t, true, nil)
+	defer srv.Shutdown()
+
+	// Create evaluations of two jobs, one of them blocked
+	state := srv.Agent.Server().State()
+	e1, e2, e3 := mock.Eval(), mock.Eval(), mock.Eval()
+	e1.JobID, e2.JobID, e3.JobID = "web", "web", "batch"
+	e1.Status = structs.EvalStatusBlocked
+	e2.TriggeredBy = structs.EvalTriggerNodeUpdate
+	require.Nil(state.UpsertEvals(1000, []*structs.Evaluation{e1, e2, e3}))
+
+	ui := new(cli.MockUi)
+	cmd := &EvalListCommand{Meta: Meta{Ui: ui}}
+
+	run := func(args ...string) string {
+		code := cmd.Run(append([]string{"-address=" + url, "-verbose"}, args...))
+		require.Equal(0, code, ui.ErrorWriter.String())
+		out := ui.OutputWriter.String()
+		ui.OutputWriter.Reset()
+		return out
+	}
+
+	out := run()
+	require.Contains(out, e1.ID)
+	require.Contains(out, e2.ID)
+	require.Contains(out, e3.ID)
+
+	out = run("-job=web", "-status=blocked")
+	require.Contains(out, e1.ID)
+	require.NotContains(out, e2.ID)
+	require.NotContains(out, e3.ID)
+
+	out = run("-triggered-by=" + structs.EvalTriggerNodeUpdate)
+	require.NotContains(out, e1.ID)
+	require.Contains(out, e2.ID)
+	require.NotContains(out, e3.ID)
+
+	out = run("-job=missing")
+	require.Contains(out, "No evaluations found")
+}
@@ -0,0 +1,185 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/nomad/jobspec"
+	"github.com/posener/complete"
+)
+
+type FmtCommand struct {
+	Meta
+
+	// testStdin can be overwritten for tests
+	testStdin io.Reader
+}
+
+func (c *FmtCommand) Help() string {
+	helpText := `
+Usage: nomad fmt [options] [path ...]
+
+  Fmt rewrites HCL job files to their canonical format. Blocks are indented by
+  two spaces, the equal signs of consecutive assignments are aligned and
+  consecutive blank lines are collapsed. Comments are preserved.
+
+  The paths may be files or directories, in which case the ".nomad" and ".hcl"
+  files in them are formatted. The current directory is formatted if no path
+  is given. If the path is "-", the job file is read from stdin and the
+  formatted job file is written to stdout.
+
+Fmt Options:
+
+  -check
+    Check that the files are formatted without modifying them. The exit code
+    is 1 if any file is not formatted, which is useful in CI. Implies
+    -write=false.
+
+  -list
+    List the files whose formatting was changed. Defaults to true.
+
+  -write
+    Write the formatted files in place. Defaults to true.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *FmtCommand) Synopsis() string {
+	return "Rewrite job files to their canonical format"
+}
+
+func (c *FmtCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-check": complete.PredictNothing,
+		"-list":  complete.PredictNothing,
+		"-write": complete.PredictNothing,
+	}
+}
+
+func (c *FmtCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictOr(complete.PredictFiles("*.nomad"), complete.PredictFiles("*.hcl"))
+}
+
+func (c *FmtCommand) Run(args []string) int {
+	var check, list, write bool
+
+	flags := c.Meta.FlagSet("fmt", FlagSetNone)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&check, "check", false, "")
+	flags.BoolVar(&list, "list", true, "")
+	flags.BoolVar(&write, "write", true, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+	if check {
+		write = false
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	// Format stdin to stdout
+	if len(paths) == 1 && paths[0] == "-" {
+		var stdin io.Reader = os.Stdin
+		if c.testStdin != nil {
+			stdin = c.testStdin
+		}
+		src, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error reading stdin: %s", err))
+			return 1
+		}
+		out, err := jobspec.Format(src)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error formatting stdin: %s", err))
+			return 1
+		}
+		if check {
+			if !bytes.Equal(src, out) {
+				return 1
+			}
+			return 0
+		}
+		c.Ui.Output(strings.TrimSuffix(string(out), "\n"))
+		return 0
+	}
+
+	files, err := fmtFiles(paths)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	failed, unformatted := false, false
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error reading %q: %s", file, err))
+			failed = true
+			continue
+		}
+		out, err := jobspec.Format(src)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error formatting %q: %s", file, err))
+			failed = true
+			continue
+		}
+		if bytes.Equal(src, out) {
+			continue
+		}
+
+		unformatted = true
+		if list {
+			c.Ui.Output(file)
+		}
+		if write {
+			if err := ioutil.WriteFile(file, out, 0644); err != nil {
+				c.Ui.Error(fmt.Sprintf("Error writing %q: %s", file, err))
+				failed = true
+			}
+		}
+	}
+
+	if failed || (check && unformatted) {
+		return 1
+	}
+	return 0
+}
+
+// fmtFiles returns the files to format given the paths of files and
+// directories. The ".nomad" and ".hcl" files of directories are returned in
+// lexical order.
+func fmtFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading %q: %s", path, err)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		var dirFiles []string
+		for _, ext := range []string{"*.nomad", "*.hcl"} {
+			matches, err := filepath.Glob(filepath.Join(path, ext))
+			if err != nil {
+				return nil, fmt.Errorf("Error listing %q: %s", path, err)
+			}
+			dirFiles = append(dirFiles, matches...)
+		}
+		sort.Strings(dirFiles)
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
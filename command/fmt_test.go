@@ -0,0 +1,119 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	fmtUnformatted = "job \"example\" {\ndatacenters = [\"dc1\"]\n    type = \"batch\"\n}\n"
+	fmtFormatted   = "job \"example\" {\n  datacenters = [\"dc1\"]\n  type        = \"batch\"\n}\n"
+)
+
+func TestFmtCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &FmtCommand{}
+}
+
+func TestFmtCommand_Write(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad-fmt")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	unformatted := filepath.Join(dir, "a.nomad")
+	formatted := filepath.Join(dir, "b.nomad")
+	ignored := filepath.Join(dir, "c.txt")
+	require.NoError(ioutil.WriteFile(unformatted, []byte(fmtUnformatted), 0644))
+	require.NoError(ioutil.WriteFile(formatted, []byte(fmtFormatted), 0644))
+	require.NoError(ioutil.WriteFile(ignored, []byte(fmtUnformatted), 0644))
+
+	ui := new(cli.MockUi)
+	cmd := &FmtCommand{Meta: Meta{Ui: ui}}
+
+	// Only the unformatted file is listed and rewritten
+	require.Equal(0, cmd.Run([]string{dir}))
+	require.Equal(unformatted+"\n", ui.OutputWriter.String())
+
+	for _, path := range []string{unformatted, formatted} {
+		data, err := ioutil.ReadFile(path)
+		require.NoError(err)
+		require.Equal(fmtFormatted, string(data))
+	}
+	data, err := ioutil.ReadFile(ignored)
+	require.NoError(err)
+	require.Equal(fmtUnformatted, string(data))
+}
+
+func TestFmtCommand_Check(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad-fmt")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "example.nomad")
+	require.NoError(ioutil.WriteFile(path, []byte(fmtUnformatted), 0644))
+
+	// Unformatted files fail the check and aren't modified
+	ui := new(cli.MockUi)
+	cmd := &FmtCommand{Meta: Meta{Ui: ui}}
+	require.Equal(1, cmd.Run([]string{"-check", path}))
+	require.Equal(path+"\n", ui.OutputWriter.String())
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(err)
+	require.Equal(fmtUnformatted, string(data))
+
+	// Formatted files pass it
+	require.NoError(ioutil.WriteFile(path, []byte(fmtFormatted), 0644))
+	ui = new(cli.MockUi)
+	cmd = &FmtCommand{Meta: Meta{Ui: ui}}
+	require.Equal(0, cmd.Run([]string{"-check", path}))
+}
+
+func TestFmtCommand_Stdin(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	ui := new(cli.MockUi)
+	cmd := &FmtCommand{
+		Meta:      Meta{Ui: ui},
+		testStdin: strings.NewReader(fmtUnformatted),
+	}
+	require.Equal(0, cmd.Run([]string{"-"}))
+	require.Equal(fmtFormatted, ui.OutputWriter.String())
+
+	cmd.testStdin = strings.NewReader(fmtUnformatted)
+	require.Equal(1, cmd.Run([]string{"-check", "-"}))
+}
+
+func TestFmtCommand_Fails(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad-fmt")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "invalid.nomad")
+	require.NoError(ioutil.WriteFile(path, []byte("job \"example\" {"), 0644))
+
+	ui := new(cli.MockUi)
+	cmd := &FmtCommand{Meta: Meta{Ui: ui}}
+	require.Equal(1, cmd.Run([]string{path}))
+	require.Contains(ui.ErrorWriter.String(), "Error formatting")
+
+	ui.ErrorWriter.Reset()
+	require.Equal(1, cmd.Run([]string{filepath.Join(dir, "missing.nomad")}))
+	require.Contains(ui.ErrorWriter.String(), "Error reading")
+}
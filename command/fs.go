@@ -33,12 +33,17 @@ type FSCommand struct {
 
 func (f *FSCommand) Help() string {
 	helpText := `
-Usage: nomad fs [options] <allocation> <path>
+Usage: nomad alloc fs [options] <allocation> <path>
+Alias: nomad fs
 
   fs displays either the contents of an allocation directory for the passed allocation,
   or displays the file at the given path. The path is relative to the root of the alloc
   dir and defaults to root if unspecified.
 
+  When following a directory, new files and the data appended to the files of
+  the directory are displayed, each preceded by a header with the path of the
+  file, similar to "tail -F" on all of its files.
+
 General Options:
 
   ` + generalOptionsUsage() + `
@@ -57,9 +62,14 @@ FS Specific Options:
   -stat
     Show file stat information instead of displaying the file, or listing the directory.
 
+  -recursive
+    List, stat or follow the files of the subdirectories too, with their path
+    relative to the directory.
+
   -f
     Causes the output to not stop when the end of the file is reached, but rather to
-    wait for additional output.
+    wait for additional output. On a directory, the files of the directory are
+    followed from their end and new files from their start.
 
   -tail
     Show the files contents with offsets relative to the end of the file. If no
@@ -82,14 +92,15 @@ func (f *FSCommand) Synopsis() string {
 func (c *FSCommand) AutocompleteFlags() complete.Flags {
 	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
 		complete.Flags{
-			"-H":       complete.PredictNothing,
-			"-verbose": complete.PredictNothing,
-			"-job":     complete.PredictAnything,
-			"-stat":    complete.PredictNothing,
-			"-f":       complete.PredictNothing,
-			"-tail":    complete.PredictNothing,
-			"-n":       complete.PredictAnything,
-			"-c":       complete.PredictAnything,
+			"-H":         complete.PredictNothing,
+			"-verbose":   complete.PredictNothing,
+			"-job":       complete.PredictAnything,
+			"-stat":      complete.PredictNothing,
+			"-recursive": complete.PredictNothing,
+			"-f":         complete.PredictNothing,
+			"-tail":      complete.PredictNothing,
+			"-n":         complete.PredictAnything,
+			"-c":         complete.PredictAnything,
 		})
 }
 
@@ -109,7 +120,7 @@ func (f *FSCommand) AutocompleteArgs() complete.Predictor {
 }
 
 func (f *FSCommand) Run(args []string) int {
-	var verbose, machine, job, stat, tail, follow bool
+	var verbose, machine, job, stat, recursive, tail, follow bool
 	var numLines, numBytes int64
 
 	flags := f.Meta.FlagSet("fs", FlagSetClient)
@@ -118,6 +129,7 @@ func (f *FSCommand) Run(args []string) int {
 	flags.BoolVar(&machine, "H", false, "")
 	flags.BoolVar(&job, "job", false, "")
 	flags.BoolVar(&stat, "stat", false, "")
+	flags.BoolVar(&recursive, "recursive", false, "")
 	flags.BoolVar(&follow, "f", false, "")
 	flags.BoolVar(&tail, "tail", false, "")
 	flags.Int64Var(&numLines, "n", -1, "")
@@ -204,24 +216,13 @@ func (f *FSCommand) Run(args []string) int {
 		return 1
 	}
 
-	// If we want file stats, print those and exit.
-	if stat {
+	// If we want file stats of a single file, print those and exit.
+	if stat && !(file.IsDir && recursive) {
 		// Display the file information
 		out := make([]string, 2)
 		out[0] = "Mode|Size|Modified Time|Name"
 		if file != nil {
-			fn := file.Name
-			if file.IsDir {
-				fn = fmt.Sprintf("%s/", fn)
-			}
-			var size string
-			if machine {
-				size = fmt.Sprintf("%d", file.Size)
-			} else {
-				size = humanize.IBytes(uint64(file.Size))
-			}
-			out[1] = fmt.Sprintf("%s|%s|%s|%s", file.FileMode, size,
-				formatTime(file.ModTime), fn)
+			out[1] = formatAllocFileInfo(file, file.Name, machine)
 		}
 		f.Ui.Output(formatList(out))
 		return 0
@@ -229,8 +230,22 @@ func (f *FSCommand) Run(args []string) int {
 
 	// Determine if the path is a file or a directory.
 	if file.IsDir {
+		// Follow the files of the directory
+		if follow {
+			var offset int64 = -1
+			if tail {
+				offset = defaultTailLines * bytesToLines
+				if numLines > 0 {
+					offset = numLines * bytesToLines
+				} else if numBytes > 0 {
+					offset = numBytes
+				}
+			}
+			return f.followDir(client, alloc, path, recursive, offset)
+		}
+
 		// We have a directory, list it.
-		files, _, err := client.AllocFS().List(alloc, path, nil)
+		files, err := walkAllocDir(client.AllocFS(), alloc, path, recursive)
 		if err != nil {
 			f.Ui.Error(fmt.Sprintf("Error listing alloc dir: %s", err))
 			return 1
@@ -239,22 +254,7 @@ func (f *FSCommand) Run(args []string) int {
 		out := make([]string, len(files)+1)
 		out[0] = "Mode|Size|Modified Time|Name"
 		for i, file := range files {
-			fn := file.Name
-			if file.IsDir {
-				fn = fmt.Sprintf("%s/", fn)
-			}
-			var size string
-			if machine {
-				size = fmt.Sprintf("%d", file.Size)
-			} else {
-				size = humanize.IBytes(uint64(file.Size))
-			}
-			out[i+1] = fmt.Sprintf("%s|%s|%s|%s",
-				file.FileMode,
-				size,
-				formatTime(file.ModTime),
-				fn,
-			)
+			out[i+1] = formatAllocFileInfo(file.AllocFileInfo, file.Path, machine)
 		}
 		f.Ui.Output(formatList(out))
 		return 0
@@ -367,6 +367,62 @@ func (f *FSCommand) followFile(client *api.Client, alloc *api.Allocation,
 	return r, nil
 }
 
+// followDir outputs the data appended to the files of the directory and the
+// new files until interrupted. Existing files are followed from offset bytes
+// before their end, or from their end if offset is negative.
+func (f *FSCommand) followDir(client *api.Client, alloc *api.Allocation, path string, recursive bool, offset int64) int {
+	follower := &allocDirFollower{
+		fs:        client.AllocFS(),
+		alloc:     alloc,
+		dir:       path,
+		recursive: recursive,
+		out:       os.Stdout,
+	}
+	if err := follower.init(offset); err != nil {
+		f.Ui.Error(fmt.Sprintf("Error listing alloc dir: %s", err))
+		return 1
+	}
+
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+
+	ticker := time.NewTicker(fsFollowDirInterval)
+	defer ticker.Stop()
+	for {
+		if err := follower.poll(); err != nil {
+			f.Ui.Error(fmt.Sprintf("Error following alloc dir: %s", err))
+			return 1
+		}
+
+		select {
+		case <-signalCh:
+			return 0
+		case <-ticker.C:
+		}
+	}
+}
+
+// formatAllocFileInfo formats the stat information of the file as a row of
+// the file list.
+func formatAllocFileInfo(file *api.AllocFileInfo, name string, machine bool) string {
+	if file.IsDir {
+		name = fmt.Sprintf("%s/", name)
+	}
+	var size string
+	if machine {
+		size = fmt.Sprintf("%d", file.Size)
+	} else {
+		size = humanize.IBytes(uint64(file.Size))
+	}
+	return fmt.Sprintf("%s|%s|%s|%s",
+		file.FileMode,
+		size,
+		formatTime(file.ModTime),
+		name,
+	)
+}
+
 // Get Random Allocation ID from a known jobID. Prefer to use a running allocation,
 // but use a dead allocation if no running allocations are found
 func getRandomJobAlloc(client *api.Client, jobID string) (string, error) {
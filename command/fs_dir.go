@@ -0,0 +1,162 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// fsFollowDirInterval is the interval at which the files of a followed
+// directory are checked for new data.
+const fsFollowDirInterval = time.Second
+
+// allocFS is the part of the allocation filesystem API used to walk and follow
+// directories.
+type allocFS interface {
+	List(alloc *api.Allocation, path string, q *api.QueryOptions) ([]*api.AllocFileInfo, *api.QueryMeta, error)
+	ReadAt(alloc *api.Allocation, path string, offset int64, limit int64, q *api.QueryOptions) (io.ReadCloser, error)
+}
+
+// allocDirEntry is a file of a walked directory.
+type allocDirEntry struct {
+	*api.AllocFileInfo
+
+	// Path is the path of the file relative to the walked directory.
+	Path string
+}
+
+// walkAllocDir returns the files of the directory, sorted by path. The files
+// of the subdirectories are returned if recursive is set.
+func walkAllocDir(fs allocFS, alloc *api.Allocation, dir string, recursive bool) ([]*allocDirEntry, error) {
+	var entries []*allocDirEntry
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		files, _, err := fs.List(alloc, path.Join(dir, rel), nil)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			entry := &allocDirEntry{AllocFileInfo: file, Path: path.Join(rel, file.Name)}
+			entries = append(entries, entry)
+			if file.IsDir && recursive {
+				if err := walk(entry.Path); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// allocDirFollower outputs the data appended to the files of a directory and
+// the new files, similar to "tail -F" on all of its files.
+type allocDirFollower struct {
+	fs        allocFS
+	alloc     *api.Allocation
+	dir       string
+	recursive bool
+	out       io.Writer
+
+	// offsets is the offset up to which each file was output.
+	offsets map[string]int64
+
+	// last is the file the data was last output of, which is preceded by a
+	// header when it changes.
+	last string
+}
+
+// init records the files of the directory, to be followed from offset bytes
+// before their end, or from their end if offset is negative.
+func (d *allocDirFollower) init(offset int64) error {
+	entries, err := walkAllocDir(d.fs, d.alloc, d.dir, d.recursive)
+	if err != nil {
+		return err
+	}
+
+	d.offsets = make(map[string]int64, len(entries))
+	for _, e := range entries {
+		if e.IsDir {
+			continue
+		}
+		start := e.Size
+		if offset >= 0 {
+			start -= offset
+			if start < 0 {
+				start = 0
+			}
+		}
+		d.offsets[e.Path] = start
+	}
+	return nil
+}
+
+// poll outputs the data appended to the files since the last poll. New files
+// are output from their start, as are the files that were truncated.
+func (d *allocDirFollower) poll() error {
+	entries, err := walkAllocDir(d.fs, d.alloc, d.dir, d.recursive)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]struct{}, len(entries))
+	for _, e := range entries {
+		if e.IsDir {
+			continue
+		}
+		seen[e.Path] = struct{}{}
+
+		offset := d.offsets[e.Path]
+		if e.Size < offset {
+			d.header(e.Path, " truncated")
+			offset = 0
+		}
+		d.offsets[e.Path] = offset
+		if e.Size == offset {
+			continue
+		}
+
+		r, err := d.fs.ReadAt(d.alloc, path.Join(d.dir, e.Path), offset, e.Size-offset, nil)
+		if err != nil {
+			return fmt.Errorf("failed to read %q: %v", e.Path, err)
+		}
+		d.header(e.Path, "")
+		n, err := io.Copy(d.out, r)
+		r.Close()
+		d.offsets[e.Path] = offset + n
+		if err != nil {
+			return fmt.Errorf("failed to read %q: %v", e.Path, err)
+		}
+	}
+
+	// Forget the removed files so they are output from their start if they
+	// are created again
+	for p := range d.offsets {
+		if _, ok := seen[p]; !ok {
+			delete(d.offsets, p)
+		}
+	}
+	return nil
+}
+
+// header outputs the path of the file before its data if the data last output
+// is of another file, or always if there is a note about the file.
+func (d *allocDirFollower) header(p, note string) {
+	if d.last == p && note == "" {
+		return
+	}
+	if d.last != "" {
+		fmt.Fprintln(d.out)
+	}
+	fmt.Fprintf(d.out, "==> %s%s <==\n", p, note)
+	d.last = p
+}
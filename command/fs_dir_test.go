@@ -0,0 +1,126 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/stretchr/testify/require"
+)
+
+// testAllocFS is an in-memory allocation filesystem. Directories are the
+// parents of the files.
+type testAllocFS struct {
+	files map[string]string
+}
+
+func (fs *testAllocFS) List(alloc *api.Allocation, dir string, q *api.QueryOptions) ([]*api.AllocFileInfo, *api.QueryMeta, error) {
+	dir = path.Clean("/" + dir)
+	seen := make(map[string]*api.AllocFileInfo)
+	for p, data := range fs.files {
+		rel := strings.TrimPrefix(p, dir)
+		if rel == p && dir != "/" {
+			continue
+		}
+		parts := strings.Split(strings.TrimPrefix(rel, "/"), "/")
+		if len(parts) == 1 {
+			seen[parts[0]] = &api.AllocFileInfo{Name: parts[0], Size: int64(len(data))}
+		} else {
+			seen[parts[0]] = &api.AllocFileInfo{Name: parts[0], IsDir: true}
+		}
+	}
+
+	var out []*api.AllocFileInfo
+	for _, f := range seen {
+		out = append(out, f)
+	}
+	return out, nil, nil
+}
+
+func (fs *testAllocFS) ReadAt(alloc *api.Allocation, p string, offset int64, limit int64, q *api.QueryOptions) (io.ReadCloser, error) {
+	data, ok := fs.files[path.Clean("/"+p)]
+	if !ok {
+		return nil, fmt.Errorf("file %q not found", p)
+	}
+	return ioutil.NopCloser(strings.NewReader(data[offset : offset+limit])), nil
+}
+
+func TestFS_WalkAllocDir(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	fs := &testAllocFS{files: map[string]string{
+		"/alloc/logs/web.stdout.0":   "foo",
+		"/alloc/logs/web.stderr.0":   "bar",
+		"/alloc/logs/sidecar/out.0":  "baz",
+		"/alloc/data/ignored-by-dir": "",
+	}}
+
+	entries, err := walkAllocDir(fs, nil, "/alloc/logs", false)
+	require.NoError(err)
+	var paths []string
+	for _, e := range entries {
+		paths = append(paths, e.Path)
+	}
+	require.Equal([]string{"sidecar", "web.stderr.0", "web.stdout.0"}, paths)
+
+	entries, err = walkAllocDir(fs, nil, "/alloc/logs", true)
+	require.NoError(err)
+	paths = nil
+	for _, e := range entries {
+		paths = append(paths, e.Path)
+	}
+	require.Equal([]string{"sidecar", "sidecar/out.0", "web.stderr.0", "web.stdout.0"}, paths)
+}
+
+func TestFS_AllocDirFollower(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	fs := &testAllocFS{files: map[string]string{
+		"/alloc/logs/a.stdout.0":      "old a\n",
+		"/alloc/logs/sidecar/b.out.0": "old b\n",
+	}}
+
+	var out bytes.Buffer
+	d := &allocDirFollower{
+		fs:        fs,
+		dir:       "/alloc/logs",
+		recursive: true,
+		out:       &out,
+	}
+
+	// Existing files are followed from their end
+	require.NoError(d.init(-1))
+	require.NoError(d.poll())
+	require.Empty(out.String())
+
+	// Appends and new files are output with a header
+	fs.files["/alloc/logs/a.stdout.0"] += "new a\n"
+	fs.files["/alloc/logs/c.stdout.0"] = "new c\n"
+	require.NoError(d.poll())
+	require.Equal("==> a.stdout.0 <==\nnew a\n\n==> c.stdout.0 <==\nnew c\n", out.String())
+	out.Reset()
+
+	// Appends to the last file don't repeat its header
+	fs.files["/alloc/logs/c.stdout.0"] += "more c\n"
+	require.NoError(d.poll())
+	require.Equal("more c\n", out.String())
+	out.Reset()
+
+	// Truncated files are output from their start
+	fs.files["/alloc/logs/sidecar/b.out.0"] = "b\n"
+	require.NoError(d.poll())
+	require.Equal("\n==> sidecar/b.out.0 truncated <==\nb\n", out.String())
+	out.Reset()
+
+	// Existing files are followed from the tail offset
+	fs.files["/alloc/logs/c.stdout.0"] = "old c\nnew c\n"
+	d = &allocDirFollower{fs: fs, dir: "/alloc/logs", out: &out}
+	require.NoError(d.init(6))
+	require.NoError(d.poll())
+	require.Equal("==> a.stdout.0 <==\nnew a\n\n==> c.stdout.0 <==\nnew c\n", out.String())
+}
@@ -354,6 +354,22 @@ type JobGetter struct {
 
 // StructJob returns the Job struct from jobfile.
 func (j *JobGetter) ApiJob(jpath string) (*api.Job, error) {
+	src, err := j.Source(jpath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the JobFile
+	jobStruct, err := jobspec.Parse(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing job file from %s: %v", jpath, err)
+	}
+
+	return jobStruct, nil
+}
+
+// Source returns the contents of the jobfile.
+func (j *JobGetter) Source(jpath string) ([]byte, error) {
 	var jobfile io.Reader
 	switch jpath {
 	case "-":
@@ -401,13 +417,11 @@ func (j *JobGetter) ApiJob(jpath string) (*api.Job, error) {
 		}
 	}
 
-	// Parse the JobFile
-	jobStruct, err := jobspec.Parse(jobfile)
+	src, err := ioutil.ReadAll(jobfile)
 	if err != nil {
-		return nil, fmt.Errorf("Error parsing job file from %s: %v", jpath, err)
+		return nil, fmt.Errorf("Error reading job file from %s: %v", jpath, err)
 	}
-
-	return jobStruct, nil
+	return src, nil
 }
 
 // COMPAT: Remove in 0.7.0
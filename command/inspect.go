@@ -31,6 +31,10 @@ Inspect Options:
   -json
     Output the job in its JSON format.
 
+  -hcl
+    Output the source the job was submitted with. Only jobs submitted with
+    their source have one.
+
   -t
     Format and display job using a Go template.
 `
@@ -46,6 +50,7 @@ func (c *InspectCommand) AutocompleteFlags() complete.Flags {
 		complete.Flags{
 			"-version": complete.PredictAnything,
 			"-json":    complete.PredictNothing,
+			"-hcl":     complete.PredictNothing,
 			"-t":       complete.PredictAnything,
 		})
 }
@@ -66,12 +71,13 @@ func (c *InspectCommand) AutocompleteArgs() complete.Predictor {
 }
 
 func (c *InspectCommand) Run(args []string) int {
-	var json bool
+	var json, hcl bool
 	var tmpl, versionStr string
 
 	flags := c.Meta.FlagSet("inspect", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&json, "json", false, "")
+	flags.BoolVar(&hcl, "hcl", false, "")
 	flags.StringVar(&tmpl, "t", "", "")
 	flags.StringVar(&versionStr, "version", "", "")
 
@@ -138,6 +144,22 @@ func (c *InspectCommand) Run(args []string) int {
 		version = &v
 	}
 
+	// Output the submitted source
+	if hcl {
+		if json || len(tmpl) > 0 {
+			c.Ui.Error("The -hcl flag can not be used with -json or -t")
+			return 1
+		}
+
+		sub, _, err := client.Jobs().Submission(jobs[0].ID, version, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error retrieving job source: %s", err))
+			return 1
+		}
+		c.Ui.Output(strings.TrimSpace(sub.Source))
+		return 0
+	}
+
 	// Prefix lookup matched a single job
 	job, err := getJob(client, jobs[0].ID, version)
 	if err != nil {
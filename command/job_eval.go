@@ -0,0 +1,147 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/api/contexts"
+	"github.com/posener/complete"
+)
+
+type JobEvalCommand struct {
+	Meta
+}
+
+func (c *JobEvalCommand) Help() string {
+	helpText := `
+Usage: nomad job eval [options] <job id>
+       nomad job eval [options] -all
+
+  Eval is used to force the evaluation of a job, so that the schedulers
+  reconcile its allocations. With -all, all the jobs of the namespace are
+  evaluated by the servers in batches and the progress is displayed after each
+  batch.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Eval Options:
+
+  -all
+    Evaluate all the jobs of the namespace. Periodic, parameterized and
+    stopped jobs are skipped.
+
+  -batch-size
+    The number of jobs to evaluate per request with -all. Defaults to 100.
+
+  -verbose
+    Display full information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *JobEvalCommand) Synopsis() string {
+	return "Force the evaluation of jobs"
+}
+
+func (c *JobEvalCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-all":        complete.PredictNothing,
+			"-batch-size": complete.PredictAnything,
+			"-verbose":    complete.PredictNothing,
+		})
+}
+
+func (c *JobEvalCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		client, err := c.Meta.Client()
+		if err != nil {
+			return nil
+		}
+
+		resp, _, err := client.Search().PrefixSearch(a.Last, contexts.Jobs, nil)
+		if err != nil {
+			return []string{}
+		}
+		return resp.Matches[contexts.Jobs]
+	})
+}
+
+func (c *JobEvalCommand) Run(args []string) int {
+	var all, verbose bool
+	var batchSize int
+
+	flags := c.Meta.FlagSet("job eval", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&all, "all", false, "")
+	flags.IntVar(&batchSize, "batch-size", 0, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got either a job or -all
+	args = flags.Args()
+	if len(args) > 1 || (len(args) == 1) == all {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if !all {
+		evalID, _, err := client.Jobs().ForceEvaluate(args[0], nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error evaluating job: %s", err))
+			return 1
+		}
+		c.Ui.Output(fmt.Sprintf("Created evaluation %q", limit(evalID, length)))
+		return 0
+	}
+
+	req := &api.JobBatchEvaluateRequest{
+		BatchSize: batchSize,
+	}
+	evaluated, skipped := 0, 0
+	for {
+		resp, _, err := client.Jobs().EvaluateBatch(req, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error evaluating jobs: %s", err))
+			return 1
+		}
+		evaluated += len(resp.EvalIDs)
+		skipped += resp.Skipped
+		if verbose {
+			for _, id := range resp.EvalIDs {
+				c.Ui.Output(fmt.Sprintf("Created evaluation %q", id))
+			}
+		}
+
+		if resp.NextToken == "" {
+			break
+		}
+		c.Ui.Output(fmt.Sprintf("==> Evaluated %d jobs so far", evaluated))
+		req.NextToken = resp.NextToken
+	}
+
+	c.Ui.Output(fmt.Sprintf("Evaluated %d jobs", evaluated))
+	if skipped != 0 {
+		c.Ui.Output(fmt.Sprintf("Skipped %d periodic, parameterized or stopped jobs", skipped))
+	}
+	return 0
+}
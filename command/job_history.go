@@ -32,18 +32,34 @@ General Options:
 
 History Options:
 
-  -p
+  -p, -diff
     Display the difference between each job and its predecessor.
 
+  -versions <old>,<new>
+    Display the difference between two arbitrary job versions.
+
+  -from <version>, -to <version>
+    Display the difference between two arbitrary job versions, from the old
+    version up to the new one. The new version defaults to the latest version
+    of the job. Exclusive with -versions.
+
   -full
     Display the full job definition for each version.
 
+  -verbose
+    Display who submitted each version and from where.
+
   -version <job version>
     Display only the history for the given job version.
 
   -json
     Output the job versions in a JSON format.
 
+  -format <format>
+    The format of the output, either "text" or "json". The "json" format is the
+    same as -json and outputs the structured diff when diffing two versions.
+    Defaults to "text".
+
   -t
     Format and display the job versions using a Go template.
 `
@@ -57,11 +73,17 @@ func (c *JobHistoryCommand) Synopsis() string {
 func (c *JobHistoryCommand) Autocompleteflags() complete.Flags {
 	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
 		complete.Flags{
-			"-p":       complete.PredictNothing,
-			"-full":    complete.PredictNothing,
-			"-version": complete.PredictAnything,
-			"-json":    complete.PredictNothing,
-			"-t":       complete.PredictAnything,
+			"-p":        complete.PredictNothing,
+			"-diff":     complete.PredictNothing,
+			"-versions": complete.PredictAnything,
+			"-from":     complete.PredictAnything,
+			"-to":       complete.PredictAnything,
+			"-full":     complete.PredictNothing,
+			"-verbose":  complete.PredictNothing,
+			"-version":  complete.PredictAnything,
+			"-json":     complete.PredictNothing,
+			"-format":   complete.PredictSet("text", "json"),
+			"-t":        complete.PredictAnything,
 		})
 }
 
@@ -81,14 +103,20 @@ func (c *JobHistoryCommand) AutocompleteArgs() complete.Predictor {
 }
 
 func (c *JobHistoryCommand) Run(args []string) int {
-	var json, diff, full bool
-	var tmpl, versionStr string
+	var json, diff, full, verbose bool
+	var tmpl, versionStr, versionsStr, fromStr, toStr, format string
 
 	flags := c.Meta.FlagSet("job history", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&diff, "p", false, "")
+	flags.BoolVar(&diff, "diff", false, "")
+	flags.StringVar(&versionsStr, "versions", "", "")
+	flags.StringVar(&fromStr, "from", "", "")
+	flags.StringVar(&toStr, "to", "", "")
 	flags.BoolVar(&full, "full", false, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
 	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&format, "format", "text", "")
 	flags.StringVar(&versionStr, "version", "", "")
 	flags.StringVar(&tmpl, "t", "", "")
 
@@ -103,11 +131,38 @@ func (c *JobHistoryCommand) Run(args []string) int {
 		return 1
 	}
 
-	if (json || len(tmpl) != 0) && (diff || full) {
+	switch format {
+	case "text":
+	case "json":
+		json = true
+	default:
+		c.Ui.Error(fmt.Sprintf("Unsupported format %q; must be one of text or json", format))
+		return 1
+	}
+
+	// The diff between two versions is a single structured diff, which can be
+	// output as JSON
+	pairDiff := versionsStr != "" || fromStr != "" || toStr != ""
+	if (json || len(tmpl) != 0) && (diff || full) && !pairDiff {
 		c.Ui.Error("-json and -t are exclusive with -p and -full")
 		return 1
 	}
 
+	if versionsStr != "" && (fromStr != "" || toStr != "") {
+		c.Ui.Error("-versions is exclusive with -from and -to")
+		return 1
+	}
+
+	if toStr != "" && fromStr == "" {
+		c.Ui.Error("-to requires -from")
+		return 1
+	}
+
+	if pairDiff && versionStr != "" {
+		c.Ui.Error("-versions, -from and -to are exclusive with -version")
+		return 1
+	}
+
 	// Get the HTTP client
 	client, err := c.Meta.Client()
 	if err != nil {
@@ -132,6 +187,61 @@ func (c *JobHistoryCommand) Run(args []string) int {
 		return 1
 	}
 
+	// Diff two arbitrary versions
+	if pairDiff {
+		var oldVersion, newVersion uint64
+		if versionsStr != "" {
+			oldVersion, newVersion, err = parseVersionPair(versionsStr)
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf("Error parsing versions value %q: %v", versionsStr, err))
+				return 1
+			}
+		} else {
+			oldVersion, _, err = parseVersion(fromStr)
+			if err != nil {
+				c.Ui.Error(fmt.Sprintf("Error parsing from value %q: %v", fromStr, err))
+				return 1
+			}
+
+			if toStr != "" {
+				newVersion, _, err = parseVersion(toStr)
+				if err != nil {
+					c.Ui.Error(fmt.Sprintf("Error parsing to value %q: %v", toStr, err))
+					return 1
+				}
+			} else {
+				// Default to the latest version of the job
+				job, _, err := client.Jobs().Info(jobs[0].ID, nil)
+				if err != nil {
+					c.Ui.Error(fmt.Sprintf("Error retrieving job: %s", err))
+					return 1
+				}
+				newVersion = *job.Version
+			}
+		}
+
+		jobDiff, _, err := client.Jobs().DiffVersions(jobs[0].ID, oldVersion, newVersion, nil)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error retrieving job diff: %s", err))
+			return 1
+		}
+
+		if json || len(tmpl) > 0 {
+			out, err := Format(json, tmpl, jobDiff)
+			if err != nil {
+				c.Ui.Error(err.Error())
+				return 1
+			}
+
+			c.Ui.Output(out)
+			return 0
+		}
+
+		c.Ui.Output(c.Colorize().Color(fmt.Sprintf("[bold]Diff between version %d and %d:[reset]\n%s",
+			oldVersion, newVersion, strings.TrimSpace(formatJobDiff(jobDiff, full)))))
+		return 0
+	}
+
 	// Prefix lookup matched a single job
 	versions, diffs, _, err := client.Jobs().Versions(jobs[0].ID, diff, nil)
 	if err != nil {
@@ -179,7 +289,7 @@ func (c *JobHistoryCommand) Run(args []string) int {
 			return 0
 		}
 
-		if err := c.formatJobVersion(job, diff, nextVersion, full); err != nil {
+		if err := c.formatJobVersion(job, diff, nextVersion, full, verbose); err != nil {
 			c.Ui.Error(err.Error())
 			return 1
 		}
@@ -196,7 +306,7 @@ func (c *JobHistoryCommand) Run(args []string) int {
 			return 0
 		}
 
-		if err := c.formatJobVersions(versions, diffs, full); err != nil {
+		if err := c.formatJobVersions(versions, diffs, full, verbose); err != nil {
 			c.Ui.Error(err.Error())
 			return 1
 		}
@@ -216,7 +326,25 @@ func parseVersion(input string) (uint64, bool, error) {
 	return u, true, err
 }
 
-func (c *JobHistoryCommand) formatJobVersions(versions []*api.Job, diffs []*api.JobDiff, full bool) error {
+// parseVersionPair parses the versions flag of the form "<old>,<new>".
+func parseVersionPair(input string) (uint64, uint64, error) {
+	parts := strings.Split(input, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected two comma separated versions")
+	}
+
+	oldVersion, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	newVersion, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return oldVersion, newVersion, nil
+}
+
+func (c *JobHistoryCommand) formatJobVersions(versions []*api.Job, diffs []*api.JobDiff, full, verbose bool) error {
 	vLen := len(versions)
 	dLen := len(diffs)
 	if dLen != 0 && vLen != dLen+1 {
@@ -231,7 +359,7 @@ func (c *JobHistoryCommand) formatJobVersions(versions []*api.Job, diffs []*api.
 			nextVersion = *versions[i+1].Version
 		}
 
-		if err := c.formatJobVersion(version, diff, nextVersion, full); err != nil {
+		if err := c.formatJobVersion(version, diff, nextVersion, full, verbose); err != nil {
 			return err
 		}
 
@@ -244,13 +372,24 @@ func (c *JobHistoryCommand) formatJobVersions(versions []*api.Job, diffs []*api.
 	return nil
 }
 
-func (c *JobHistoryCommand) formatJobVersion(job *api.Job, diff *api.JobDiff, nextVersion uint64, full bool) error {
+func (c *JobHistoryCommand) formatJobVersion(job *api.Job, diff *api.JobDiff, nextVersion uint64, full, verbose bool) error {
 	basic := []string{
 		fmt.Sprintf("Version|%d", *job.Version),
 		fmt.Sprintf("Stable|%v", *job.Stable),
 		fmt.Sprintf("Submit Date|%v", formatTime(time.Unix(0, *job.SubmitTime))),
 	}
 
+	if verbose {
+		p := job.Provenance
+		if p == nil {
+			p = &api.JobProvenance{}
+		}
+		basic = append(basic,
+			fmt.Sprintf("Token Accessor|%s", p.TokenAccessor),
+			fmt.Sprintf("Source IP|%s", p.SourceIP),
+			fmt.Sprintf("Source|%s", p.Source))
+	}
+
 	if diff != nil {
 		//diffStr := fmt.Sprintf("Difference between version %d and %d:", *job.Version, nextVersion)
 		basic = append(basic, fmt.Sprintf("Diff|\n%s", strings.TrimSpace(formatJobDiff(diff, false))))
@@ -1,13 +1,16 @@
 package command
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestJobHistoryCommand_Implements(t *testing.T) {
@@ -38,6 +41,21 @@ func TestJobHistoryCommand_Fails(t *testing.T) {
 	ui.ErrorWriter.Reset()
 }
 
+func TestJobHistoryCommand_ParseVersionPair(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	oldVersion, newVersion, err := parseVersionPair("3,7")
+	assert.Nil(err)
+	assert.EqualValues(3, oldVersion)
+	assert.EqualValues(7, newVersion)
+
+	for _, input := range []string{"", "3", "3,7,9", "a,7", "3,-1"} {
+		_, _, err := parseVersionPair(input)
+		assert.NotNil(err, input)
+	}
+}
+
 func TestJobHistoryCommand_AutocompleteArgs(t *testing.T) {
 	assert := assert.New(t)
 	t.Parallel()
@@ -61,3 +79,73 @@ func TestJobHistoryCommand_AutocompleteArgs(t *testing.T) {
 	assert.Equal(1, len(res))
 	assert.Equal(j.ID, res[0])
 }
+
+func TestJobHistoryCommand_DiffFromTo(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	srv, _, url := testServer(t, true, nil)
+	defer srv.Shutdown()
+
+	// Create three versions of a job
+	state := srv.Agent.Server().State()
+	j := mock.Job()
+	require.NoError(state.UpsertJob(1000, j))
+	j = j.Copy()
+	j.TaskGroups[0].Count = 3
+	require.NoError(state.UpsertJob(1001, j))
+	j = j.Copy()
+	j.TaskGroups[0].Tasks[0].Resources.MemoryMB = 512
+	require.NoError(state.UpsertJob(1002, j))
+
+	ui := new(cli.MockUi)
+	cmd := &JobHistoryCommand{Meta: Meta{Ui: ui}}
+
+	// The structured diff defaults to the latest version
+	code := cmd.Run([]string{"-address=" + url, "-diff", "-from=0", "-format=json", j.ID})
+	require.Equal(0, code, ui.ErrorWriter.String())
+
+	var diff api.JobDiff
+	require.NoError(json.Unmarshal(ui.OutputWriter.Bytes(), &diff))
+	require.Equal("Edited", diff.Type)
+	require.Len(diff.TaskGroups, 1)
+	require.Len(diff.TaskGroups[0].Fields, 1)
+	require.Equal("Count", diff.TaskGroups[0].Fields[0].Name)
+	require.Len(diff.TaskGroups[0].Tasks, 1)
+	require.Equal("Edited", diff.TaskGroups[0].Tasks[0].Type)
+	ui.OutputWriter.Reset()
+
+	// An explicit range only includes the changes between the versions
+	code = cmd.Run([]string{"-address=" + url, "-diff", "-from=1", "-to=2", j.ID})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	out := ui.OutputWriter.String()
+	require.Contains(out, "Diff between version 1 and 2")
+	require.Contains(out, "MemoryMB")
+	require.NotContains(out, "Count")
+}
+
+func TestJobHistoryCommand_DiffFromTo_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &JobHistoryCommand{Meta: Meta{Ui: ui}}
+
+	cases := []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-to=2", "foo"}, "-to requires -from"},
+		{[]string{"-from=1", "-versions=1,2", "foo"}, "exclusive with -from and -to"},
+		{[]string{"-from=1", "-version=1", "foo"}, "exclusive with -version"},
+		{[]string{"-format=yaml", "foo"}, "Unsupported format"},
+		{[]string{"-diff", "-format=json", "foo"}, "exclusive with -p and -full"},
+	}
+	for _, tc := range cases {
+		if code := cmd.Run(tc.args); code != 1 {
+			t.Fatalf("%v: expected exit code 1, got: %d", tc.args, code)
+		}
+		if out := ui.ErrorWriter.String(); !strings.Contains(out, tc.err) {
+			t.Fatalf("%v: expected %q error, got: %s", tc.args, tc.err, out)
+		}
+		ui.ErrorWriter.Reset()
+	}
+}
@@ -0,0 +1,125 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/nomad/jobspec"
+	"github.com/posener/complete"
+)
+
+type JobUpgradeSpecCommand struct {
+	Meta
+}
+
+func (c *JobUpgradeSpecCommand) Help() string {
+	helpText := `
+Usage: nomad job upgrade-spec [options] <path> [<path>...]
+
+  Upgrade-spec rewrites the deprecated stanzas and fields of HCL job files to
+  their current equivalents, adding a comment about the change in behavior
+  before each rewritten field:
+
+    - The "stagger" of update stanzas becomes "min_healthy_time".
+    - Update stanzas of batch jobs are removed.
+    - The "disk" of task resources moves to the "ephemeral_disk" of the group.
+    - The "ssl" option of the docker driver is removed.
+
+  The upgraded job file is written to stdout unless -write is given. The paths
+  may be directories when -write is given, in which case the ".nomad" and
+  ".hcl" files in them are upgraded.
+
+Upgrade Spec Options:
+
+  -write
+    Write the upgraded files in place and list the changes made to them.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *JobUpgradeSpecCommand) Synopsis() string {
+	return "Rewrite deprecated fields of job files"
+}
+
+func (c *JobUpgradeSpecCommand) AutocompleteFlags() complete.Flags {
+	return complete.Flags{
+		"-write": complete.PredictNothing,
+	}
+}
+
+func (c *JobUpgradeSpecCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictOr(complete.PredictFiles("*.nomad"), complete.PredictFiles("*.hcl"))
+}
+
+func (c *JobUpgradeSpecCommand) Run(args []string) int {
+	var write bool
+
+	flags := c.Meta.FlagSet("job upgrade-spec", FlagSetNone)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&write, "write", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got a single file unless writing in place
+	paths := flags.Args()
+	if len(paths) == 0 || !write && len(paths) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	if !write {
+		if info, err := os.Stat(paths[0]); err == nil && info.IsDir() {
+			c.Ui.Error("Upgrading a directory requires -write")
+			return 1
+		}
+	}
+
+	files, err := fmtFiles(paths)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	failed := false
+	for _, file := range files {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error reading %q: %s", file, err))
+			failed = true
+			continue
+		}
+		out, changes, err := jobspec.Upgrade(src)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error upgrading %q: %s", file, err))
+			failed = true
+			continue
+		}
+
+		if !write {
+			c.Ui.Output(strings.TrimSuffix(string(out), "\n"))
+			continue
+		}
+		if bytes.Equal(src, out) {
+			continue
+		}
+		if err := ioutil.WriteFile(file, out, 0644); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error writing %q: %s", file, err))
+			failed = true
+			continue
+		}
+		c.Ui.Output(fmt.Sprintf("Upgraded %s", file))
+		for _, change := range changes {
+			c.Ui.Output("  " + change)
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
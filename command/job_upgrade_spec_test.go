@@ -0,0 +1,110 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	upgradeSpecLegacy   = "job \"example\" {\n  update {\n    stagger = \"30s\"\n  }\n}\n"
+	upgradeSpecUpgraded = "job \"example\" {\n  update {\n" +
+		"    # upgrade-spec: stagger was replaced by min_healthy_time in Nomad 0.6.0:\n" +
+		"    # allocations are now replaced once the previous ones are healthy rather\n" +
+		"    # than at a fixed interval.\n" +
+		"    min_healthy_time = \"30s\"\n  }\n}\n"
+)
+
+func TestJobUpgradeSpecCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &JobUpgradeSpecCommand{}
+}
+
+func TestJobUpgradeSpecCommand_Stdout(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad-upgrade-spec")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "a.nomad")
+	require.NoError(ioutil.WriteFile(path, []byte(upgradeSpecLegacy), 0644))
+
+	ui := new(cli.MockUi)
+	cmd := &JobUpgradeSpecCommand{Meta: Meta{Ui: ui}}
+	require.Equal(0, cmd.Run([]string{path}), ui.ErrorWriter.String())
+	require.Equal(upgradeSpecUpgraded, ui.OutputWriter.String())
+
+	// The file is left as is
+	data, err := ioutil.ReadFile(path)
+	require.NoError(err)
+	require.Equal(upgradeSpecLegacy, string(data))
+}
+
+func TestJobUpgradeSpecCommand_Write(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad-upgrade-spec")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	legacy := filepath.Join(dir, "a.nomad")
+	current := filepath.Join(dir, "b.nomad")
+	require.NoError(ioutil.WriteFile(legacy, []byte(upgradeSpecLegacy), 0644))
+	require.NoError(ioutil.WriteFile(current, []byte(upgradeSpecUpgraded), 0644))
+
+	ui := new(cli.MockUi)
+	cmd := &JobUpgradeSpecCommand{Meta: Meta{Ui: ui}}
+	require.Equal(0, cmd.Run([]string{"-write", dir}), ui.ErrorWriter.String())
+	require.Equal("Upgraded "+legacy+"\n  line 3: renamed stagger to min_healthy_time\n", ui.OutputWriter.String())
+
+	for _, path := range []string{legacy, current} {
+		data, err := ioutil.ReadFile(path)
+		require.NoError(err)
+		require.Equal(upgradeSpecUpgraded, string(data))
+	}
+}
+
+func TestJobUpgradeSpecCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &JobUpgradeSpecCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"a.nomad", "b.nomad"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on non-existent files
+	if code := cmd.Run([]string{"/unicorns/leprechauns"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error reading") {
+		t.Fatalf("expected read error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on directories without -write
+	dir, err := ioutil.TempDir("", "nomad-upgrade-spec")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	if code := cmd.Run([]string{dir}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "requires -write") {
+		t.Fatalf("expected -write error, got: %s", out)
+	}
+}
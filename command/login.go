@@ -0,0 +1,287 @@
+package command
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/posener/complete"
+)
+
+const (
+	// defaultOIDCCallbackAddr is the address the callback server listens on
+	// for the OIDC provider to redirect the user to.
+	defaultOIDCCallbackAddr = "localhost:4649"
+
+	// oidcCallbackPath is the path of the callback server's redirect URI.
+	oidcCallbackPath = "/oidc/callback"
+)
+
+type LoginCommand struct {
+	Meta
+
+	// openBrowser opens the URL in the browser of the user. It is overridden
+	// in tests.
+	openBrowser func(string) error
+}
+
+func (c *LoginCommand) Help() string {
+	helpText := `
+Usage: nomad login [options]
+
+  Login authenticates the user with an auth method and stores the ACL token
+  issued for the identity with the CLI token helper. The stored token is used
+  by the commands that aren't given a token with the -token flag or the
+  NOMAD_TOKEN environment variable.
+
+  For OIDC auth methods, the URL of the provider is opened in the browser and
+  a local server waits for the provider to redirect the user back once
+  authenticated. The redirect URI, http://<oidc-callback-addr>/oidc/callback,
+  must be allowed by the auth method.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Login Options:
+
+  -method=<name>
+    The name of the auth method to login with. Required.
+
+  -type=<type>
+    The type of the auth method. Only "oidc" is supported. Defaults to "oidc".
+
+  -oidc-callback-addr=<addr>
+    The address the callback server listens on. Defaults to "localhost:4649".
+
+  -no-store
+    Print the ACL token instead of storing it with the token helper.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *LoginCommand) Synopsis() string {
+	return "Login to Nomad using an auth method"
+}
+
+func (c *LoginCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-method":             complete.PredictAnything,
+			"-type":               complete.PredictSet(structs.ACLAuthMethodTypeOIDC),
+			"-oidc-callback-addr": complete.PredictAnything,
+			"-no-store":           complete.PredictNothing,
+		})
+}
+
+func (c *LoginCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *LoginCommand) Run(args []string) int {
+	var method, methodType, callbackAddr string
+	var noStore bool
+
+	flags := c.Meta.FlagSet("login", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&method, "method", "", "")
+	flags.StringVar(&methodType, "type", structs.ACLAuthMethodTypeOIDC, "")
+	flags.StringVar(&callbackAddr, "oidc-callback-addr", defaultOIDCCallbackAddr, "")
+	flags.BoolVar(&noStore, "no-store", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	if method == "" {
+		c.Ui.Error("The -method flag is required")
+		return 1
+	}
+	if methodType != structs.ACLAuthMethodTypeOIDC {
+		c.Ui.Error(fmt.Sprintf("Unsupported auth method type %q", methodType))
+		return 1
+	}
+
+	var helper *tokenHelper
+	if !noStore {
+		var err error
+		if helper, err = defaultTokenHelper(); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error initializing token helper: %s", err))
+			return 1
+		}
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Start the callback server before the flow so the provider can't
+	// redirect the user before it listens
+	ln, err := net.Listen("tcp", callbackAddr)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error starting the OIDC callback server: %s", err))
+		return 1
+	}
+	defer ln.Close()
+
+	callbackCh := make(chan *oidcCallback, 1)
+	srv := &http.Server{Handler: oidcCallbackHandler(callbackCh)}
+	go srv.Serve(ln)
+
+	redirectURI := "http://" + callbackAddr + oidcCallbackPath
+	clientNonce := uuid.Generate()
+	authURL, _, err := client.ACLAuthMethods().GetAuthURL(&api.ACLOIDCAuthURLRequest{
+		AuthMethod:  method,
+		RedirectURI: redirectURI,
+		ClientNonce: clientNonce,
+	}, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error starting the OIDC flow: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Complete the login at the following URL in your browser:\n\n    %s\n", authURL.AuthURL))
+	open := c.openBrowser
+	if open == nil {
+		open = openBrowser
+	}
+	if err := open(authURL.AuthURL); err != nil {
+		c.Ui.Warn(fmt.Sprintf("Failed to open the browser: %s", err))
+	}
+	c.Ui.Output("Waiting for the OIDC provider to redirect back...")
+
+	// Wait for the callback or an interrupt
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+
+	var cb *oidcCallback
+	select {
+	case cb = <-callbackCh:
+	case <-signalCh:
+		c.Ui.Error("Login interrupted")
+		return 1
+	}
+	if cb.err != "" {
+		c.Ui.Error(fmt.Sprintf("OIDC provider returned an error: %s", cb.err))
+		return 1
+	}
+
+	token, _, err := client.ACLAuthMethods().CompleteAuth(&api.ACLOIDCCompleteAuthRequest{
+		AuthMethod:  method,
+		ClientNonce: clientNonce,
+		State:       cb.state,
+		Code:        cb.code,
+		RedirectURI: redirectURI,
+	}, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error completing the OIDC flow: %s", err))
+		return 1
+	}
+
+	if helper != nil {
+		if err := helper.Store(token.SecretID); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error storing the ACL token: %s", err))
+			return 1
+		}
+	}
+
+	c.Ui.Output(fmt.Sprintf("\nSuccessfully logged in via %s\n", method))
+	c.Ui.Output(formatLoginToken(token, helper == nil))
+	return 0
+}
+
+// formatLoginToken formats the token issued by a login, including its secret
+// only if it isn't stored by the token helper.
+func formatLoginToken(token *api.ACLToken, secret bool) string {
+	expiry := "<none>"
+	if token.ExpirationTime != nil {
+		expiry = formatTime(*token.ExpirationTime)
+	}
+	rows := []string{
+		fmt.Sprintf("Accessor ID|%s", token.AccessorID),
+	}
+	if secret {
+		rows = append(rows, fmt.Sprintf("Secret ID|%s", token.SecretID))
+	}
+	rows = append(rows,
+		fmt.Sprintf("Name|%s", token.Name),
+		fmt.Sprintf("Auth Method|%s", token.AuthMethod),
+		fmt.Sprintf("Expiration Time|%s", expiry),
+		fmt.Sprintf("Policies|%v", token.Policies),
+	)
+	return formatKV(rows)
+}
+
+// oidcCallback is the redirect of the OIDC provider.
+type oidcCallback struct {
+	state string
+	code  string
+	err   string
+}
+
+// oidcCallbackHandler returns the handler of the callback server, sending the
+// first redirect of the provider on the channel.
+func oidcCallbackHandler(ch chan<- *oidcCallback) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(oidcCallbackPath, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		cb := &oidcCallback{
+			state: q.Get("state"),
+			code:  q.Get("code"),
+			err:   q.Get("error"),
+		}
+		if desc := q.Get("error_description"); desc != "" {
+			cb.err = fmt.Sprintf("%s: %s", cb.err, desc)
+		}
+		if cb.err == "" && (cb.state == "" || cb.code == "") {
+			http.Error(w, "Missing state or code", http.StatusBadRequest)
+			return
+		}
+
+		select {
+		case ch <- cb:
+		default:
+		}
+		fmt.Fprintln(w, "Login complete, you can close this window and return to the terminal.")
+	})
+	return mux
+}
+
+// openBrowser opens the URL with the default browser of the platform.
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// Reap the process without blocking the login
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,105 @@
+package command
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &LoginCommand{}
+}
+
+func TestLoginCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &LoginCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails without a method
+	if code := cmd.Run([]string{}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "-method flag is required") {
+		t.Fatalf("expected missing method error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on unsupported types
+	if code := cmd.Run([]string{"-method=okta", "-type=ldap"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Unsupported auth method type") {
+		t.Fatalf("expected unsupported type error, got: %s", out)
+	}
+}
+
+func TestLoginCommand_OIDCCallbackHandler(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	ch := make(chan *oidcCallback, 1)
+	srv := httptest.NewServer(oidcCallbackHandler(ch))
+	defer srv.Close()
+
+	// Redirects without a state or code are rejected
+	resp, err := http.Get(srv.URL + oidcCallbackPath + "?code=foo")
+	require.NoError(err)
+	resp.Body.Close()
+	require.Equal(http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL + oidcCallbackPath + "?code=foo&state=bar")
+	require.NoError(err)
+	resp.Body.Close()
+	require.Equal(http.StatusOK, resp.StatusCode)
+
+	cb := <-ch
+	require.Equal("foo", cb.code)
+	require.Equal("bar", cb.state)
+	require.Empty(cb.err)
+}
+
+func TestTokenHelper(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	dir, err := ioutil.TempDir("", "nomad")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	helper := &tokenHelper{path: filepath.Join(dir, tokenHelperFile)}
+
+	// Nothing is stored yet
+	token, err := helper.Get()
+	require.NoError(err)
+	require.Empty(token)
+
+	// The stored token is only readable by the user
+	require.NoError(helper.Store("foo"))
+	fi, err := os.Stat(helper.path)
+	require.NoError(err)
+	require.Equal(os.FileMode(0600), fi.Mode().Perm())
+
+	token, err = helper.Get()
+	require.NoError(err)
+	require.Equal("foo", token)
+
+	require.NoError(helper.Erase())
+	token, err = helper.Get()
+	require.NoError(err)
+	require.Empty(token)
+}
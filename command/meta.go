@@ -149,6 +149,13 @@ func (m *Meta) Client() (*api.Client, error) {
 
 	if m.token != "" {
 		config.SecretID = m.token
+	} else if config.SecretID == "" {
+		// Fallback to the token stored by "nomad login"
+		if helper, err := defaultTokenHelper(); err == nil {
+			if token, err := helper.Get(); err == nil {
+				config.SecretID = token
+			}
+		}
 	}
 
 	return api.NewClient(config)
@@ -210,7 +217,8 @@ func generalOptionsUsage() string {
 
   -token
     The SecretID of an ACL token to use to authenticate API requests with.
-    Overrides the NOMAD_TOKEN environment variable if set.
+    Overrides the NOMAD_TOKEN environment variable if set. Defaults to the
+    token stored by "nomad login".
 `
 	return strings.TrimSpace(helpText)
 }
@@ -84,7 +84,7 @@ func (c *NamespaceStatusCommand) Run(args []string) int {
 		}
 
 		// Get the quota usages
-		usages, failures := quotaUsages(spec, quotas)
+		usages, failures := quotaUsages(spec, quotas, false)
 
 		// Format the limits
 		c.Ui.Output(c.Colorize().Color("\n[bold]Quota Limits[reset]"))
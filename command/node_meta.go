@@ -0,0 +1,69 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+type NodeMetaCommand struct {
+	Meta
+}
+
+func (c *NodeMetaCommand) Help() string {
+	helpText := `
+Usage: nomad node meta <subcommand> [options]
+
+  The meta command is used to read and update the metadata of client nodes at
+  runtime without editing the client configuration. Metadata applied at runtime
+  is persisted by the client and survives restarts.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NodeMetaCommand) Synopsis() string {
+	return "Interact with node metadata"
+}
+
+func (c *NodeMetaCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+// resolveNodeMetaID returns the ID of the node the metadata commands target:
+// the node matching the given ID prefix or the local node if none is given.
+func resolveNodeMetaID(client *api.Client, nodeID string) (string, error) {
+	if nodeID == "" {
+		return getLocalNodeID(client)
+	}
+
+	if len(nodeID) == 1 {
+		return "", fmt.Errorf("Identifier must contain at least two characters.")
+	}
+
+	nodeID = sanatizeUUIDPrefix(nodeID)
+	nodes, _, err := client.Nodes().PrefixList(nodeID)
+	if err != nil {
+		return "", fmt.Errorf("Error querying node: %s", err)
+	}
+	switch len(nodes) {
+	case 0:
+		return "", fmt.Errorf("No node(s) with prefix or id %q found", nodeID)
+	case 1:
+		return nodes[0].ID, nil
+	default:
+		out := make([]string, len(nodes)+1)
+		out[0] = "ID|Datacenter|Name|Class|Drain|Status"
+		for i, node := range nodes {
+			out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%v|%s",
+				node.ID,
+				node.Datacenter,
+				node.Name,
+				node.NodeClass,
+				node.Drain,
+				node.Status)
+		}
+		return "", fmt.Errorf("Prefix matched multiple nodes\n\n%s", formatList(out))
+	}
+}
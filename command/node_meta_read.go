@@ -0,0 +1,128 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/nomad/api/contexts"
+	"github.com/posener/complete"
+)
+
+type NodeMetaReadCommand struct {
+	Meta
+}
+
+func (c *NodeMetaReadCommand) Help() string {
+	helpText := `
+Usage: nomad node meta read [options]
+
+  Read is used to display the metadata of a client node. Metadata set or unset
+  at runtime is marked as dynamic.
+
+  If ACLs are enabled, this command requires a token with the 'node:read'
+  capability.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Node Meta Read Options:
+
+  -node-id
+    The ID of the node to read. Defaults to the local node.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *NodeMetaReadCommand) Synopsis() string {
+	return "Read node metadata"
+}
+
+func (c *NodeMetaReadCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-node-id": complete.PredictFunc(func(a complete.Args) []string {
+				client, err := c.Meta.Client()
+				if err != nil {
+					return nil
+				}
+
+				resp, _, err := client.Search().PrefixSearch(a.Last, contexts.Nodes, nil)
+				if err != nil {
+					return []string{}
+				}
+				return resp.Matches[contexts.Nodes]
+			}),
+		})
+}
+
+func (c *NodeMetaReadCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *NodeMetaReadCommand) Run(args []string) int {
+	var nodeID string
+
+	flags := c.Meta.FlagSet("node meta read", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&nodeID, "node-id", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if nodeID, err = resolveNodeMetaID(client, nodeID); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	meta, err := client.Nodes().Meta(nodeID, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading node metadata: %s", err))
+		return 1
+	}
+
+	keys := make([]string, 0, len(meta.Meta)+len(meta.Dynamic))
+	for k := range meta.Meta {
+		keys = append(keys, k)
+	}
+	for k, v := range meta.Dynamic {
+		if v == nil {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		c.Ui.Output("No metadata found")
+		return 0
+	}
+	sort.Strings(keys)
+
+	rows := make([]string, len(keys)+1)
+	rows[0] = "Key|Value|Dynamic"
+	for i, k := range keys {
+		value, dynamic := meta.Meta[k], false
+		if v, ok := meta.Dynamic[k]; ok {
+			dynamic = true
+			if v == nil {
+				value = "<unset>"
+			}
+		}
+		rows[i+1] = fmt.Sprintf("%s|%s|%v", k, value, dynamic)
+	}
+	c.Ui.Output(formatList(rows))
+	return 0
+}
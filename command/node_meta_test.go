@@ -0,0 +1,88 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeMetaCommands(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	srv, client, url := testServer(t, true, nil)
+	defer srv.Shutdown()
+
+	// Wait for the node to be ready
+	var nodeID string
+	testutil.WaitForResult(func() (bool, error) {
+		nodes, _, err := client.Nodes().List(nil)
+		if err != nil {
+			return false, err
+		}
+		if len(nodes) == 0 {
+			return false, fmt.Errorf("missing node")
+		}
+		nodeID = nodes[0].ID
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+
+	ui := new(cli.MockUi)
+	meta := Meta{Ui: ui}
+
+	// Apply metadata to the node by its ID prefix
+	apply := &NodeMetaApplyCommand{Meta: meta}
+	code := apply.Run([]string{"-address=" + url, "-node-id=" + nodeID[:8], "rack=r2", "zone=a"})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Contains(ui.OutputWriter.String(), "Successfully applied metadata")
+	ui.OutputWriter.Reset()
+
+	code = apply.Run([]string{"-address=" + url, "-node-id=" + nodeID, "-unset=zone"})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	ui.OutputWriter.Reset()
+
+	// Read the metadata of the local node
+	read := &NodeMetaReadCommand{Meta: meta}
+	code = read.Run([]string{"-address=" + url})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	out := ui.OutputWriter.String()
+	require.Regexp(`rack +r2 +true`, out)
+	require.Regexp(`zone +<unset> +true`, out)
+}
+
+func TestNodeMetaApplyCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &NodeMetaApplyCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run(nil); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on invalid metadata
+	if code := cmd.Run([]string{"rack"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Invalid metadata") {
+		t.Fatalf("expected invalid metadata error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "-node-id=12345678", "rack=r2"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying node") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
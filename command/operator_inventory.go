@@ -0,0 +1,29 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type OperatorInventoryCommand struct {
+	Meta
+}
+
+func (c *OperatorInventoryCommand) Help() string {
+	helpText := `
+Usage: nomad operator inventory <subcommand> [options]
+
+  The inventory operator command is used to report on the nodes of the cluster
+  and the allocations, images and artifacts running on them.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorInventoryCommand) Synopsis() string {
+	return "Provides access to the cluster inventory"
+}
+
+func (c *OperatorInventoryCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
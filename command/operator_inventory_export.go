@@ -0,0 +1,203 @@
+package command
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+// inventoryCSVHeader is the header row of the CSV inventory export. Each row
+// describes a single task, or a node without allocations.
+var inventoryCSVHeader = []string{
+	"node_id", "node_name", "datacenter", "node_class", "node_status",
+	"alloc_id", "namespace", "job_id", "job_version", "task_group",
+	"desired_status", "client_status", "task", "driver", "image", "artifacts",
+	"cpu", "memory_mb",
+}
+
+type OperatorInventoryExportCommand struct {
+	Meta
+}
+
+func (c *OperatorInventoryExportCommand) Help() string {
+	helpText := `
+Usage: nomad operator inventory export [options]
+
+  Export a point-in-time inventory of the cluster. The inventory lists every
+  node along with the allocations placed on it and, for each task, the driver,
+  image, artifacts and resources. The inventory is built by the servers from a
+  single snapshot of the cluster state, so it is consistent across nodes.
+
+  Allocations in namespaces the token can not read are omitted.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Export Options:
+
+  -format (default: json)
+    The output format. Must be one of json or csv. The csv format writes one
+    row per task.
+
+  -output
+    Write the inventory to the given file instead of stdout.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorInventoryExportCommand) Synopsis() string {
+	return "Export an inventory of the cluster"
+}
+
+func (c *OperatorInventoryExportCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-format": complete.PredictSet("json", "csv"),
+			"-output": complete.PredictFiles("*"),
+		})
+}
+
+func (c *OperatorInventoryExportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorInventoryExportCommand) Run(args []string) int {
+	var format, output string
+
+	flags := c.Meta.FlagSet("operator inventory export", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&format, "format", "json", "")
+	flags.StringVar(&output, "output", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	if format != "json" && format != "csv" {
+		c.Ui.Error(fmt.Sprintf("Unsupported format %q; must be one of json or csv", format))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	nodes, _, err := client.Operator().Inventory(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying inventory: %s", err))
+		return 1
+	}
+
+	var buf bytes.Buffer
+	switch format {
+	case "csv":
+		err = writeInventoryCSV(&buf, nodes)
+	default:
+		var s string
+		s, err = Format(true, "", nodes)
+		buf.WriteString(s)
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error formatting inventory: %s", err))
+		return 1
+	}
+
+	if output == "" {
+		c.Ui.Output(strings.TrimSpace(buf.String()))
+		return 0
+	}
+
+	if err := ioutil.WriteFile(output, buf.Bytes(), 0644); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error writing inventory: %s", err))
+		return 1
+	}
+	c.Ui.Output(fmt.Sprintf("Wrote inventory of %d nodes to %q", len(nodes), output))
+	return 0
+}
+
+// writeInventoryCSV writes the inventory with a row per task. Nodes without
+// allocations and allocations without tasks are written as a single row with
+// the missing columns left empty.
+func writeInventoryCSV(w io.Writer, nodes []*api.InventoryNode) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(inventoryCSVHeader); err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		nodeCols := []string{node.ID, node.Name, node.Datacenter, node.NodeClass, node.Status}
+		if len(node.Allocations) == 0 {
+			if err := cw.Write(inventoryRow(nodeCols, nil, nil)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, alloc := range node.Allocations {
+			allocCols := []string{
+				alloc.ID, alloc.Namespace, alloc.JobID,
+				strconv.FormatUint(alloc.JobVersion, 10), alloc.TaskGroup,
+				alloc.DesiredStatus, alloc.ClientStatus,
+			}
+			if len(alloc.Tasks) == 0 {
+				if err := cw.Write(inventoryRow(nodeCols, allocCols, nil)); err != nil {
+					return err
+				}
+				continue
+			}
+
+			for _, task := range alloc.Tasks {
+				taskCols := []string{
+					task.Name, task.Driver, task.Image,
+					strings.Join(task.Artifacts, ";"), "", "",
+				}
+				if r := task.Resources; r != nil {
+					if r.CPU != nil {
+						taskCols[4] = strconv.Itoa(*r.CPU)
+					}
+					if r.MemoryMB != nil {
+						taskCols[5] = strconv.Itoa(*r.MemoryMB)
+					}
+				}
+				if err := cw.Write(inventoryRow(nodeCols, allocCols, taskCols)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// inventoryRow joins the node, allocation and task columns of a CSV row,
+// padding missing columns so every row matches the header.
+func inventoryRow(node, alloc, task []string) []string {
+	row := make([]string, 0, len(inventoryCSVHeader))
+	row = append(row, node...)
+	if alloc == nil {
+		alloc = make([]string, 7)
+	}
+	row = append(row, alloc...)
+	if task == nil {
+		task = make([]string, 6)
+	}
+	return append(row, task...)
+}
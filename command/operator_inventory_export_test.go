@@ -0,0 +1,95 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorInventoryExportCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &OperatorInventoryExportCommand{}
+}
+
+func TestOperatorInventoryExportCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &OperatorInventoryExportCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on an unknown format
+	if code := cmd.Run([]string{"-format=xml"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Unsupported format") {
+		t.Fatalf("expected format error, got: %s", out)
+	}
+}
+
+func TestOperatorInventoryExport_CSV(t *testing.T) {
+	t.Parallel()
+	nodes := []*api.InventoryNode{
+		{
+			ID:         "node1",
+			Name:       "foo",
+			Datacenter: "dc1",
+			Status:     "ready",
+			Allocations: []*api.InventoryAllocation{
+				{
+					ID:           "alloc1",
+					Namespace:    "default",
+					JobID:        "example",
+					JobVersion:   3,
+					TaskGroup:    "cache",
+					ClientStatus: "running",
+					Tasks: []*api.InventoryTask{
+						{
+							Name:      "redis",
+							Driver:    "docker",
+							Image:     "redis:3.2",
+							Artifacts: []string{"https://example.com/a", "https://example.com/b"},
+							Resources: &api.Resources{CPU: helper.IntToPtr(500), MemoryMB: helper.IntToPtr(256)},
+						},
+					},
+				},
+			},
+		},
+		{
+			ID:     "node2",
+			Name:   "bar",
+			Status: "down",
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeInventoryCSV(&buf, nodes); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got: %q", lines)
+	}
+	if !strings.HasPrefix(lines[0], "node_id,node_name") {
+		t.Fatalf("bad header: %q", lines[0])
+	}
+	expected := "node1,foo,dc1,,ready,alloc1,default,example,3,cache,,running,redis,docker,redis:3.2,https://example.com/a;https://example.com/b,500,256"
+	if lines[1] != expected {
+		t.Fatalf("bad row: %q", lines[1])
+	}
+	if lines[2] != "node2,bar,,,down,,,,,,,,,,,,," {
+		t.Fatalf("bad row: %q", lines[2])
+	}
+}
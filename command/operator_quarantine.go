@@ -0,0 +1,31 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type OperatorQuarantineCommand struct {
+	Meta
+}
+
+func (c *OperatorQuarantineCommand) Help() string {
+	helpText := `
+Usage: nomad operator quarantine <subcommand> [options]
+
+  The quarantine operator command is used to manage the cluster-wide list of
+  denied image digests and artifact checksums. Jobs referencing a quarantined
+  digest are rejected at registration, and clients refuse to start tasks whose
+  image or artifacts resolve to one.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorQuarantineCommand) Synopsis() string {
+	return "Manage quarantined images and artifacts"
+}
+
+func (c *OperatorQuarantineCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
@@ -0,0 +1,100 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+type OperatorQuarantineAddCommand struct {
+	Meta
+}
+
+func (c *OperatorQuarantineAddCommand) Help() string {
+	helpText := `
+Usage: nomad operator quarantine add [options] <digest>
+
+  Add an image digest or artifact checksum to the quarantine list. The digest
+  must be of the form "<algorithm>:<hex>", such as "sha256:e9ed...".
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Add Options:
+
+  -type
+    The kind of object the digest identifies; either "image" or "artifact".
+    Defaults to "image".
+
+  -reason
+    A human readable explanation of why the digest is quarantined.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorQuarantineAddCommand) Synopsis() string {
+	return "Quarantine an image or artifact"
+}
+
+func (c *OperatorQuarantineAddCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-type":   complete.PredictSet(api.QuarantineTypeImage, api.QuarantineTypeArtifact),
+			"-reason": complete.PredictAnything,
+		})
+}
+
+func (c *OperatorQuarantineAddCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorQuarantineAddCommand) Run(args []string) int {
+	var typ, reason string
+
+	flags := c.Meta.FlagSet("operator quarantine add", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&typ, "type", api.QuarantineTypeImage, "")
+	flags.StringVar(&reason, "reason", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one digest
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	switch typ {
+	case api.QuarantineTypeImage, api.QuarantineTypeArtifact:
+	default:
+		c.Ui.Error(fmt.Sprintf("Invalid type %q: must be %q or %q", typ,
+			api.QuarantineTypeImage, api.QuarantineTypeArtifact))
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	entry := &api.QuarantineEntry{
+		Digest: args[0],
+		Type:   typ,
+		Reason: reason,
+	}
+	if _, err := client.Operator().QuarantineUpsert([]*api.QuarantineEntry{entry}, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error quarantining digest: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Quarantined %s %q", typ, args[0]))
+	return 0
+}
@@ -0,0 +1,112 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+type OperatorQuarantineListCommand struct {
+	Meta
+}
+
+func (c *OperatorQuarantineListCommand) Help() string {
+	helpText := `
+Usage: nomad operator quarantine list [options]
+
+  List the image digests and artifact checksums in the quarantine list.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+List Options:
+
+  -json
+    Output the entries in JSON format.
+
+  -t
+    Format and display the entries using a Go template.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorQuarantineListCommand) Synopsis() string {
+	return "List quarantined images and artifacts"
+}
+
+func (c *OperatorQuarantineListCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-json": complete.PredictNothing,
+			"-t":    complete.PredictAnything,
+		})
+}
+
+func (c *OperatorQuarantineListCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorQuarantineListCommand) Run(args []string) int {
+	var json bool
+	var tmpl string
+
+	flags := c.Meta.FlagSet("operator quarantine list", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	entries, _, err := client.Operator().QuarantineList(nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error listing quarantine entries: %s", err))
+		return 1
+	}
+
+	if json || len(tmpl) > 0 {
+		out, err := Format(json, tmpl, entries)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		c.Ui.Output(out)
+		return 0
+	}
+
+	if len(entries) == 0 {
+		c.Ui.Output("No quarantine entries")
+		return 0
+	}
+
+	c.Ui.Output(formatQuarantineEntries(entries))
+	return 0
+}
+
+// formatQuarantineEntries formats the quarantine entries as a table.
+func formatQuarantineEntries(entries []*api.QuarantineEntry) string {
+	out := make([]string, len(entries)+1)
+	out[0] = "Digest|Type|Reason"
+	for i, e := range entries {
+		out[i+1] = fmt.Sprintf("%s|%s|%s", e.Digest, e.Type, e.Reason)
+	}
+	return formatList(out)
+}
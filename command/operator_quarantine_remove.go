@@ -0,0 +1,67 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/posener/complete"
+)
+
+type OperatorQuarantineRemoveCommand struct {
+	Meta
+}
+
+func (c *OperatorQuarantineRemoveCommand) Help() string {
+	helpText := `
+Usage: nomad operator quarantine remove [options] <digest>
+
+  Remove an image digest or artifact checksum from the quarantine list.
+
+General Options:
+
+  ` + generalOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorQuarantineRemoveCommand) Synopsis() string {
+	return "Remove an image or artifact from quarantine"
+}
+
+func (c *OperatorQuarantineRemoveCommand) AutocompleteFlags() complete.Flags {
+	return c.Meta.AutocompleteFlags(FlagSetClient)
+}
+
+func (c *OperatorQuarantineRemoveCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorQuarantineRemoveCommand) Run(args []string) int {
+	flags := c.Meta.FlagSet("operator quarantine remove", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one digest
+	args = flags.Args()
+	if len(args) != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if _, err := client.Operator().QuarantineDelete(args[0], nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error removing digest from quarantine: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Removed %q from quarantine", args[0]))
+	return 0
+}
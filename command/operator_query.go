@@ -0,0 +1,150 @@
+package command
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+type OperatorQueryCommand struct {
+	Meta
+}
+
+func (c *OperatorQueryCommand) Help() string {
+	helpText := `
+Usage: nomad operator query [options]
+
+  Query the cluster for the allocations currently running a given workload.
+  This is useful during incident response to answer where a vulnerable image
+  is running right now.
+
+  Allocations in namespaces the token can not read are omitted.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Query Options:
+
+  -image-digest
+    Find the running allocations with a task that was started from the image
+    with the given digest, such as "sha256:...".
+
+  -verbose
+    Display full information.
+
+  -json
+    Output the allocations in JSON format.
+
+  -t
+    Format and display the allocations using a Go template.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorQueryCommand) Synopsis() string {
+	return "Find the allocations running a given image"
+}
+
+func (c *OperatorQueryCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-image-digest": complete.PredictAnything,
+			"-verbose":      complete.PredictNothing,
+			"-json":         complete.PredictNothing,
+			"-t":            complete.PredictAnything,
+		})
+}
+
+func (c *OperatorQueryCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorQueryCommand) Run(args []string) int {
+	var digest, tmpl string
+	var verbose, json bool
+
+	flags := c.Meta.FlagSet("operator query", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&digest, "image-digest", "", "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.BoolVar(&json, "json", false, "")
+	flags.StringVar(&tmpl, "t", "", "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments and a digest to query
+	if len(flags.Args()) != 0 || digest == "" {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	allocs, _, err := client.Operator().ImageQuery(digest, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error querying allocations: %s", err))
+		return 1
+	}
+
+	if json || len(tmpl) > 0 {
+		out, err := Format(json, tmpl, allocs)
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+
+		c.Ui.Output(out)
+		return 0
+	}
+
+	if len(allocs) == 0 {
+		c.Ui.Output(fmt.Sprintf("No running allocations found for image %q", digest))
+		return 0
+	}
+
+	c.Ui.Output(formatImageQuery(allocs, digest, length))
+	return 0
+}
+
+// formatImageQuery formats the allocations running the image with the given
+// digest, listing the tasks started from it.
+func formatImageQuery(allocs []*api.AllocationListStub, digest string, length int) string {
+	out := make([]string, len(allocs)+1)
+	out[0] = "ID|Node ID|Namespace|Job ID|Task Group|Tasks|Status"
+	for i, alloc := range allocs {
+		var tasks []string
+		for name, state := range alloc.TaskStates {
+			if state != nil && state.ImageDigest == digest {
+				tasks = append(tasks, name)
+			}
+		}
+		sort.Strings(tasks)
+
+		out[i+1] = fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s",
+			limit(alloc.ID, length),
+			limit(alloc.NodeID, length),
+			alloc.Namespace,
+			alloc.JobID,
+			alloc.TaskGroup,
+			strings.Join(tasks, ","),
+			alloc.ClientStatus)
+	}
+	return formatList(out)
+}
@@ -0,0 +1,72 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorQueryCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &OperatorQueryCommand{}
+}
+
+func TestOperatorQueryCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &OperatorQueryCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails without a digest
+	if code := cmd.Run([]string{}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "-image-digest=sha256:aaa"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error querying allocations") {
+		t.Fatalf("expected failed query error, got: %s", out)
+	}
+}
+
+func TestOperatorQueryCommand_Format(t *testing.T) {
+	t.Parallel()
+	allocs := []*api.AllocationListStub{
+		{
+			ID:           "11111111-2222-3333-4444-555555555555",
+			NodeID:       "66666666-7777-8888-9999-000000000000",
+			Namespace:    "default",
+			JobID:        "example",
+			TaskGroup:    "cache",
+			ClientStatus: "running",
+			TaskStates: map[string]*api.TaskState{
+				"redis":   {ImageDigest: "sha256:aaa"},
+				"sidecar": {ImageDigest: "sha256:bbb"},
+			},
+		},
+	}
+
+	out := formatImageQuery(allocs, "sha256:aaa", shortId)
+	if !strings.Contains(out, "11111111") || !strings.Contains(out, "redis") {
+		t.Fatalf("expected alloc and task in output, got: %s", out)
+	}
+	if strings.Contains(out, "sidecar") {
+		t.Fatalf("unexpected task in output: %s", out)
+	}
+}
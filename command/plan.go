@@ -1,12 +1,15 @@
 package command
 
 import (
+	"encoding/base64"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/scheduler"
 	"github.com/posener/complete"
 )
@@ -51,6 +54,10 @@ Usage: nomad plan [options] <path>
   If the job has specified the region, the -region flag and NOMAD_REGION
   environment variable are overridden and the job's region is used.
 
+  The secrets declared in the job's secrets block are verified to exist and be
+  accessible. Vault paths are verified with the Vault token given by the
+  -vault-token flag or the $VAULT_TOKEN environment variable.
+
   Plan will return one of the following exit codes:
     * 0: No allocations created or destroyed.
     * 1: Allocations created or destroyed.
@@ -69,6 +76,17 @@ Plan Options:
   -policy-override
     Sets the flag to force override any soft mandatory Sentinel policies.
 
+  -quota
+    Display the impact of the job on the quota attached to its namespace. The
+    current usage, the change in usage once the job is fully placed and the
+    remaining headroom are displayed for each limited resource of the job's
+    region.
+
+  -vault-token
+    If set, the passed Vault token is used to verify that the Vault secrets
+    declared by the job can be read. This overrides the token found in the
+    $VAULT_TOKEN environment variable and the job file.
+
   -verbose
     Increase diff verbosity.
 `
@@ -84,6 +102,8 @@ func (c *PlanCommand) AutocompleteFlags() complete.Flags {
 		complete.Flags{
 			"-diff":            complete.PredictNothing,
 			"-policy-override": complete.PredictNothing,
+			"-quota":           complete.PredictNothing,
+			"-vault-token":     complete.PredictAnything,
 			"-verbose":         complete.PredictNothing,
 		})
 }
@@ -93,13 +113,16 @@ func (c *PlanCommand) AutocompleteArgs() complete.Predictor {
 }
 
 func (c *PlanCommand) Run(args []string) int {
-	var diff, policyOverride, verbose bool
+	var diff, policyOverride, quota, verbose bool
+	var vaultToken string
 
 	flags := c.Meta.FlagSet("plan", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&diff, "diff", true, "")
 	flags.BoolVar(&policyOverride, "policy-override", false, "")
+	flags.BoolVar(&quota, "quota", false, "")
 	flags.BoolVar(&verbose, "verbose", false, "")
+	flags.StringVar(&vaultToken, "vault-token", "", "")
 
 	if err := flags.Parse(args); err != nil {
 		return 255
@@ -137,6 +160,14 @@ func (c *PlanCommand) Run(args []string) int {
 		client.SetNamespace(*n)
 	}
 
+	// Parse the Vault token used to verify the job's Vault secrets
+	if vaultToken == "" {
+		vaultToken = os.Getenv("VAULT_TOKEN")
+	}
+	if vaultToken != "" {
+		job.VaultToken = helper.StringToPtr(vaultToken)
+	}
+
 	// Setup the options
 	opts := &api.PlanOptions{}
 	if diff {
@@ -157,6 +188,13 @@ func (c *PlanCommand) Run(args []string) int {
 	if diff {
 		c.Ui.Output(fmt.Sprintf("%s\n",
 			c.Colorize().Color(strings.TrimSpace(formatJobDiff(resp.Diff, verbose)))))
+
+		// Call out the changes that affect traffic to the tasks
+		if resp.Diff != nil && len(resp.Diff.RoutingChanges) != 0 {
+			c.Ui.Output(c.Colorize().Color("[bold]Routing changes:[reset]"))
+			c.Ui.Output(c.Colorize().Color(formatRoutingChanges(resp.Diff.RoutingChanges)))
+			c.Ui.Output("")
+		}
 	}
 
 	// Print the scheduler dry-run output
@@ -164,6 +202,18 @@ func (c *PlanCommand) Run(args []string) int {
 	c.Ui.Output(c.Colorize().Color(formatDryRun(resp, job)))
 	c.Ui.Output("")
 
+	// Print the quota impact of the job
+	if quota {
+		impact, err := quotaImpact(client, job)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error determining quota impact: %s", err))
+			return 255
+		}
+		c.Ui.Output(c.Colorize().Color("[bold]Quota impact:[reset]"))
+		c.Ui.Output(c.Colorize().Color(impact))
+		c.Ui.Output("")
+	}
+
 	// Print any warnings if there are any
 	if resp.Warnings != "" {
 		c.Ui.Output(
@@ -251,6 +301,174 @@ func formatDryRun(resp *api.JobPlanResponse, job *api.Job) string {
 	return out
 }
 
+// quotaImpact looks up the quota attached to the namespace of the job and
+// returns the impact of registering the job on the quota limit of the job's
+// region.
+func quotaImpact(client *api.Client, job *api.Job) (string, error) {
+	job.Canonicalize()
+
+	ns, _, err := client.Namespaces().Info(*job.Namespace, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve namespace %q: %v", *job.Namespace, err)
+	}
+	if ns.Quota == "" {
+		return fmt.Sprintf("[green]- Namespace %q has no quota attached.", ns.Name), nil
+	}
+
+	quotas := client.Quotas()
+	spec, _, err := quotas.Info(ns.Quota, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve quota %q: %v", ns.Quota, err)
+	}
+	exemptType, _ := helper.SliceStringIsSubset(spec.ExemptJobTypes, []string{*job.Type})
+	exemptJob, _ := helper.SliceStringIsSubset(spec.ExemptJobs, []string{*job.ID})
+	if exemptType || exemptJob {
+		return fmt.Sprintf("[green]- Job is exempt from quota %q.", spec.Name), nil
+	}
+	if *job.Type == "system" {
+		return "[yellow]- The usage of system jobs depends on the number of feasible nodes and is not estimated.", nil
+	}
+
+	var limit *api.QuotaLimit
+	for _, l := range spec.Limits {
+		if l.Region == *job.Region {
+			limit = l
+			break
+		}
+	}
+	if limit == nil || limit.RegionLimit == nil {
+		return fmt.Sprintf("[green]- Quota %q has no limit for region %q.", spec.Name, *job.Region), nil
+	}
+
+	usage, _, err := quotas.Usage(spec.Name, &api.QueryOptions{Region: *job.Region})
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve usage of quota %q: %v", spec.Name, err)
+	}
+	used := usage.Used[base64.StdEncoding.EncodeToString(limit.Hash)]
+
+	// Subtract the usage of the currently registered version of the job
+	var existing *api.Job
+	if *job.ID != "" {
+		existing, _, err = client.Jobs().Info(*job.ID, &api.QueryOptions{Namespace: *job.Namespace})
+		if err != nil && !strings.Contains(err.Error(), "404") {
+			return "", fmt.Errorf("failed to retrieve job %q: %v", *job.ID, err)
+		}
+	}
+	delta := jobQuotaUsage(job)
+	current := jobQuotaUsage(existing)
+	delta.CPU = helper.IntToPtr(*delta.CPU - *current.CPU)
+	delta.MemoryMB = helper.IntToPtr(*delta.MemoryMB - *current.MemoryMB)
+	delta.DiskMB = helper.IntToPtr(*delta.DiskMB - *current.DiskMB)
+
+	return formatQuotaImpact(spec.Name, limit, used, delta), nil
+}
+
+// jobQuotaUsage returns the resources counted against a quota once all the
+// allocations of the job are placed. Stopped or missing jobs use no
+// resources. The job must be canonicalized.
+func jobQuotaUsage(job *api.Job) *api.Resources {
+	cpu, memory, disk := 0, 0, 0
+	if job != nil && (job.Stop == nil || !*job.Stop) {
+		for _, tg := range job.TaskGroups {
+			count := *tg.Count
+			for _, task := range tg.Tasks {
+				if task.Resources == nil {
+					continue
+				}
+				cpu += count * *task.Resources.CPU
+				memory += count * *task.Resources.MemoryMB
+			}
+			if tg.EphemeralDisk != nil {
+				disk += count * *tg.EphemeralDisk.SizeMB
+			}
+		}
+	}
+
+	return &api.Resources{
+		CPU:      helper.IntToPtr(cpu),
+		MemoryMB: helper.IntToPtr(memory),
+		DiskMB:   helper.IntToPtr(disk),
+	}
+}
+
+// formatQuotaImpact produces a table of the current usage of the quota limit,
+// the change in usage and the remaining headroom per limited resource,
+// followed by whether the change fits within the limit. The disk usage is
+// only displayed if the limit specifies it. Used may be nil if nothing was
+// consumed yet.
+func formatQuotaImpact(quota string, limit *api.QuotaLimit, used *api.QuotaLimit, delta *api.Resources) string {
+	usedRes := &api.Resources{}
+	if used != nil && used.RegionLimit != nil {
+		usedRes = used.RegionLimit
+	}
+
+	type resource struct {
+		name        string
+		used, limit *int
+		delta       int
+	}
+	resources := []resource{
+		{"CPU", usedRes.CPU, limit.RegionLimit.CPU, *delta.CPU},
+		{"Memory", usedRes.MemoryMB, limit.RegionLimit.MemoryMB, *delta.MemoryMB},
+	}
+	if limit.RegionLimit.DiskMB != nil {
+		resources = append(resources, resource{"Disk", usedRes.DiskMB, limit.RegionLimit.DiskMB, *delta.DiskMB})
+	}
+
+	rows := []string{"Resource|Used|Delta|Limit|Remaining"}
+	var exceeded []string
+	for _, r := range resources {
+		current := 0
+		if r.used != nil {
+			current = *r.used
+		}
+
+		remaining := "inf"
+		if r.limit != nil && *r.limit != 0 {
+			left := -(current + r.delta)
+			if *r.limit > 0 {
+				left += *r.limit
+			}
+			remaining = fmt.Sprintf("%d", left)
+			if left < 0 && r.delta > 0 {
+				exceeded = append(exceeded, strings.ToLower(r.name))
+			}
+		}
+
+		rows = append(rows, fmt.Sprintf("%s|%d|%+d|%s|%s",
+			r.name, current, r.delta, formatQuotaLimitInt(r.limit), remaining))
+	}
+
+	out := formatList(rows) + "\n"
+	if len(exceeded) != 0 {
+		out += fmt.Sprintf("[bold][red]- WARNING: Registering the job would exceed the %s limit of quota %q in region %q.[reset]",
+			strings.Join(exceeded, ", "), quota, limit.Region)
+	} else {
+		out += fmt.Sprintf("[green]- Job fits within quota %q in region %q.[reset]", quota, limit.Region)
+	}
+	return out
+}
+
+// formatRoutingChanges produces a line per routing change, naming the task
+// group and task it belongs to.
+func formatRoutingChanges(changes []*api.RoutingChange) string {
+	lines := make([]string, 0, len(changes))
+	for _, change := range changes {
+		marker, _ := getDiffString(change.Type)
+		line := fmt.Sprintf("%s%s %q (Task Group %q, Task %q)", marker, change.Kind, change.Name, change.TaskGroup, change.Task)
+		switch change.Type {
+		case "Added":
+			line += fmt.Sprintf(": %q", change.New)
+		case "Deleted":
+			line += fmt.Sprintf(": %q", change.Old)
+		default:
+			line += fmt.Sprintf(": %q => %q", change.Old, change.New)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
 // formatJobDiff produces an annoted diff of the job. If verbose mode is
 // set, added or deleted task groups and tasks are expanded.
 func formatJobDiff(job *api.JobDiff, verbose bool) string {
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/mitchellh/cli"
 )
@@ -169,3 +171,76 @@ func TestPlanCommand_From_URL(t *testing.T) {
 		t.Fatalf("expected error getting jobfile, got: %s", out)
 	}
 }
+
+func TestPlanCommand_FormatRoutingChanges(t *testing.T) {
+	t.Parallel()
+	changes := []*api.RoutingChange{
+		{Type: "Edited", Kind: "Port", TaskGroup: "web", Task: "frontend", Name: "http", Old: "80", New: "8080"},
+		{Type: "Deleted", Kind: "Service", TaskGroup: "web", Task: "frontend", Name: "admin", Old: "port admin"},
+	}
+	out := formatRoutingChanges(changes)
+	expected := `[light_yellow]+/-[reset] Port "http" (Task Group "web", Task "frontend"): "80" => "8080"
+[red]-[reset] Service "admin" (Task Group "web", Task "frontend"): "port admin"`
+	if out != expected {
+		t.Fatalf("got:\n%s\nwant:\n%s", out, expected)
+	}
+}
+
+func TestPlanCommand_FormatQuotaImpact(t *testing.T) {
+	t.Parallel()
+	limit := &api.QuotaLimit{
+		Region: "global",
+		RegionLimit: &api.Resources{
+			CPU:      helper.IntToPtr(2500),
+			MemoryMB: helper.IntToPtr(0),
+			DiskMB:   helper.IntToPtr(1000),
+		},
+	}
+	used := &api.QuotaLimit{
+		RegionLimit: &api.Resources{
+			CPU:      helper.IntToPtr(2000),
+			MemoryMB: helper.IntToPtr(1024),
+		},
+	}
+
+	// Job counted against the quota
+	job := &api.Job{
+		TaskGroups: []*api.TaskGroup{
+			{
+				Name:          helper.StringToPtr("web"),
+				Count:         helper.IntToPtr(2),
+				EphemeralDisk: &api.EphemeralDisk{SizeMB: helper.IntToPtr(300)},
+				Tasks: []*api.Task{
+					{Resources: &api.Resources{CPU: helper.IntToPtr(500), MemoryMB: helper.IntToPtr(256)}},
+				},
+			},
+		},
+	}
+	delta := jobQuotaUsage(job)
+	if *delta.CPU != 1000 || *delta.MemoryMB != 512 || *delta.DiskMB != 600 {
+		t.Fatalf("unexpected usage: %d CPU, %d memory, %d disk", *delta.CPU, *delta.MemoryMB, *delta.DiskMB)
+	}
+
+	out := formatQuotaImpact("shared", limit, used, delta)
+	for _, expected := range []string{
+		"CPU       2000  +1000  2500   -500",
+		"Memory    1024  +512   inf    inf",
+		"Disk      0     +600   1000   400",
+		`exceed the cpu limit of quota "shared" in region "global"`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expected %q in output:\n%s", expected, out)
+		}
+	}
+
+	// Stopped jobs use no resources
+	job.Stop = helper.BoolToPtr(true)
+	delta = jobQuotaUsage(job)
+	if *delta.CPU != 0 || *delta.MemoryMB != 0 || *delta.DiskMB != 0 {
+		t.Fatalf("unexpected usage of stopped job: %d CPU, %d memory, %d disk", *delta.CPU, *delta.MemoryMB, *delta.DiskMB)
+	}
+	out = formatQuotaImpact("shared", limit, used, delta)
+	if !strings.Contains(out, `fits within quota "shared"`) {
+		t.Fatalf("expected job to fit within the quota:\n%s", out)
+	}
+}
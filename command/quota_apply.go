@@ -35,6 +35,12 @@ General Options:
 
 Apply Options:
 
+  -check-index
+    If set, the quota specification is only created or updated if the passed
+    modify index matches the server side version. If a check-index value of
+    zero is passed, the specification is only created if it does not yet
+    exist. The index is checked by the Nomad Enterprise servers.
+
   -json
     Parse the input as a JSON quota specification. 
 `
@@ -45,7 +51,8 @@ Apply Options:
 func (c *QuotaApplyCommand) AutocompleteFlags() complete.Flags {
 	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
 		complete.Flags{
-			"-json": complete.PredictNothing,
+			"-check-index": complete.PredictNothing,
+			"-json":        complete.PredictNothing,
 		})
 }
 
@@ -59,14 +66,23 @@ func (c *QuotaApplyCommand) Synopsis() string {
 
 func (c *QuotaApplyCommand) Run(args []string) int {
 	var jsonInput bool
+	var checkIndexStr string
 	flags := c.Meta.FlagSet("quota apply", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.BoolVar(&jsonInput, "json", false, "")
+	flags.StringVar(&checkIndexStr, "check-index", "", "")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
 	}
 
+	// Parse the check-index
+	checkIndex, enforce, err := parseCheckIndex(checkIndexStr)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing check-index value %q: %v", checkIndexStr, err))
+		return 1
+	}
+
 	// Check that we get exactly one argument
 	args = flags.Args()
 	if l := len(args); l != 1 {
@@ -74,58 +90,64 @@ func (c *QuotaApplyCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Read the file contents
-	file := args[0]
+	spec, err := readQuotaSpec(args[0], jsonInput)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	if enforce {
+		_, err = client.Quotas().RegisterCAS(spec, checkIndex, nil)
+	} else {
+		_, err = client.Quotas().Register(spec, nil)
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error applying quota specification: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Successfully applied quota specification %q!", spec.Name))
+	return 0
+}
+
+// readQuotaSpec reads the quota specification from the file, or from stdin if
+// the file is "-", and parses it from HCL or JSON.
+func readQuotaSpec(file string, jsonInput bool) (*api.QuotaSpec, error) {
 	var rawQuota []byte
 	var err error
 	if file == "-" {
 		rawQuota, err = ioutil.ReadAll(os.Stdin)
 		if err != nil {
-			c.Ui.Error(fmt.Sprintf("Failed to read stdin: %v", err))
-			return 1
+			return nil, fmt.Errorf("Failed to read stdin: %v", err)
 		}
 	} else {
 		rawQuota, err = ioutil.ReadFile(file)
 		if err != nil {
-			c.Ui.Error(fmt.Sprintf("Failed to read file: %v", err))
-			return 1
+			return nil, fmt.Errorf("Failed to read file: %v", err)
 		}
 	}
 
-	var spec *api.QuotaSpec
 	if jsonInput {
-		var jsonSpec api.QuotaSpec
+		var spec api.QuotaSpec
 		dec := json.NewDecoder(bytes.NewBuffer(rawQuota))
-		if err := dec.Decode(&jsonSpec); err != nil {
-			c.Ui.Error(fmt.Sprintf("Failed to parse quota: %v", err))
-			return 1
+		if err := dec.Decode(&spec); err != nil {
+			return nil, fmt.Errorf("Failed to parse quota: %v", err)
 		}
-		spec = &jsonSpec
-	} else {
-		hclSpec, err := parseQuotaSpec(rawQuota)
-		if err != nil {
-			c.Ui.Error(fmt.Sprintf("Error parsing quota specification: %s", err))
-			return 1
-		}
-
-		spec = hclSpec
+		return &spec, nil
 	}
 
-	// Get the HTTP client
-	client, err := c.Meta.Client()
+	spec, err := parseQuotaSpec(rawQuota)
 	if err != nil {
-		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
-		return 1
-	}
-
-	_, err = client.Quotas().Register(spec, nil)
-	if err != nil {
-		c.Ui.Error(fmt.Sprintf("Error applying quota specification: %s", err))
-		return 1
+		return nil, fmt.Errorf("Error parsing quota specification: %s", err)
 	}
-
-	c.Ui.Output(fmt.Sprintf("Successfully applied quota specification %q!", spec.Name))
-	return 0
+	return spec, nil
 }
 
 // parseQuotaSpec is used to parse the quota specification from HCL
@@ -156,6 +178,8 @@ func parseQuotaSpecImpl(result *api.QuotaSpec, list *ast.ObjectList) error {
 		"name",
 		"description",
 		"limit",
+		"exempt_job_types",
+		"exempt_jobs",
 	}
 	if err := helper.CheckHCLKeys(list, valid); err != nil {
 		return err
@@ -192,6 +216,7 @@ func parseQuotaLimits(result *[]*api.QuotaLimit, list *ast.ObjectList) error {
 		valid := []string{
 			"region",
 			"region_limit",
+			"device_seconds",
 		}
 		if err := helper.CheckHCLKeys(o.Val, valid); err != nil {
 			return err
@@ -258,6 +283,7 @@ func parseQuotaResource(result *api.Resources, list *ast.ObjectList) error {
 	valid := []string{
 		"cpu",
 		"memory",
+		"disk",
 	}
 	if err := helper.CheckHCLKeys(listVal, valid); err != nil {
 		return multierror.Prefix(err, "resources ->")
@@ -97,3 +97,31 @@ func TestQuotaApplyCommand_Good_JSON(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Len(t, quotas, 1)
 }
+
+func TestQuotaApplyCommand_ParseSpec(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	input := `
+name = "tenant-quota"
+exempt_job_types = ["system"]
+exempt_jobs = ["log-shipper*"]
+
+limit {
+    region = "global"
+    region_limit {
+        cpu = 2500
+        disk = 20000
+    }
+    device_seconds = 360000
+}
+`
+	spec, err := parseQuotaSpec([]byte(input))
+	assert.Nil(err)
+	assert.Equal([]string{"system"}, spec.ExemptJobTypes)
+	assert.Equal([]string{"log-shipper*"}, spec.ExemptJobs)
+	if assert.Len(spec.Limits, 1) {
+		assert.Equal(20000, *spec.Limits[0].RegionLimit.DiskMB)
+		assert.Equal(360000, *spec.Limits[0].DeviceSeconds)
+	}
+}
@@ -102,17 +102,37 @@ func (c *QuotaInitCommand) Run(args []string) int {
 }
 
 var defaultHclQuotaSpec = strings.TrimSpace(`
+# The name of the quota specification, which namespaces are attached to with
+# "nomad namespace apply -quota".
 name = "default-quota"
 description = "Limit the shared default namespace"
 
+# Allocations of jobs of the given types, and of jobs whose name matches one
+# of the glob patterns, are not accounted toward the limits.
+# exempt_job_types = ["system"]
+# exempt_jobs = ["log-shipper*"]
+
 # Create a limit for the global region. Additional limits may
 # be specified in-order to limit other regions.
 limit {
     region = "global"
+
+    # The resources all the allocations of the namespaces attached to the
+    # quota may use in the region. A limit of zero is unlimited and a negative
+    # limit disallows the resource.
     region_limit {
+        # CPU in MHz
         cpu = 2500
+
+        # Memory in MB
         memory = 1000
+
+        # Ephemeral disk in MB
+        # disk = 10000
     }
+
+    # The device-seconds, such as GPU-seconds, the allocations may accumulate.
+    # device_seconds = 36000
 }
 `)
 
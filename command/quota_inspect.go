@@ -92,7 +92,7 @@ func (c *QuotaInspectCommand) Run(args []string) int {
 	}
 
 	// Get the quota usages
-	usages, failures := quotaUsages(spec, quotas)
+	usages, failures := quotaUsages(spec, quotas, false)
 
 	failuresConverted := make(map[string]string, len(failures))
 	for r, e := range failures {
@@ -81,12 +81,18 @@ func (c *QuotaStatusCommand) Run(args []string) int {
 	c.Ui.Output(formatQuotaSpecBasics(spec))
 
 	// Get the quota usages
-	usages, failures := quotaUsages(spec, quotas)
+	usages, failures := quotaUsages(spec, quotas, true)
 
 	// Format the limits
 	c.Ui.Output(c.Colorize().Color("\n[bold]Quota Limits[reset]"))
 	c.Ui.Output(formatQuotaLimits(spec, usages))
 
+	// Format the contribution of the namespaces
+	if consumers := formatQuotaConsumers(spec, usages); consumers != "" {
+		c.Ui.Output(c.Colorize().Color("\n[bold]Namespace Usage[reset]"))
+		c.Ui.Output(consumers)
+	}
+
 	// Display any failures
 	if len(failures) != 0 {
 		c.Ui.Error(c.Colorize().Color("\n[bold][red]Lookup Failures[reset]"))
@@ -101,8 +107,9 @@ func (c *QuotaStatusCommand) Run(args []string) int {
 
 // quotaUsages returns the quota usages for the limits described by the spec. It
 // will make a request to each referenced Nomad region. If the region couldn't
-// be contacted, the error will be stored in the failures map
-func quotaUsages(spec *api.QuotaSpec, client *api.Quotas) (usages map[string]*api.QuotaUsage, failures map[string]error) {
+// be contacted, the error will be stored in the failures map. Verbose usages
+// include the contribution of each namespace.
+func quotaUsages(spec *api.QuotaSpec, client *api.Quotas, verbose bool) (usages map[string]*api.QuotaUsage, failures map[string]error) {
 	// Determine the regions we have limits for
 	regions := make(map[string]struct{})
 	for _, limit := range spec.Limits {
@@ -116,7 +123,13 @@ func quotaUsages(spec *api.QuotaSpec, client *api.Quotas) (usages map[string]*ap
 	// Retrieve the usage per region
 	for region := range regions {
 		q.Region = region
-		usage, _, err := client.Usage(spec.Name, &q)
+		var usage *api.QuotaUsage
+		var err error
+		if verbose {
+			usage, _, err = client.UsageVerbose(spec.Name, &q)
+		} else {
+			usage, _, err = client.Usage(spec.Name, &q)
+		}
 		if err != nil {
 			failures[region] = err
 			continue
@@ -136,13 +149,20 @@ func formatQuotaSpecBasics(spec *api.QuotaSpec) string {
 		fmt.Sprintf("Description|%s", spec.Description),
 		fmt.Sprintf("Limits|%d", len(spec.Limits)),
 	}
+	if len(spec.ExemptJobTypes) != 0 {
+		basic = append(basic, fmt.Sprintf("Exempt Job Types|%s", strings.Join(spec.ExemptJobTypes, ",")))
+	}
+	if len(spec.ExemptJobs) != 0 {
+		basic = append(basic, fmt.Sprintf("Exempt Jobs|%s", strings.Join(spec.ExemptJobs, ",")))
+	}
 
 	return formatKV(basic)
 }
 
 // formatQuotaLimits formats the limits to display the quota usage versus the
 // limit per quota limit. It takes as input the specification as well as quota
-// usage by region. The formatter handles missing usages.
+// usage by region. The formatter handles missing usages. The disk and device
+// usages are only displayed if a limit specifies them.
 func formatQuotaLimits(spec *api.QuotaSpec, usages map[string]*api.QuotaUsage) string {
 	if len(spec.Limits) == 0 {
 		return "No quota limits defined"
@@ -151,8 +171,25 @@ func formatQuotaLimits(spec *api.QuotaSpec, usages map[string]*api.QuotaUsage) s
 	// Sort the limits
 	sort.Sort(api.QuotaLimitSort(spec.Limits))
 
+	// Determine the optional dimensions to display
+	showDisk, showDevices := false, false
+	for _, specLimit := range spec.Limits {
+		if specLimit.RegionLimit != nil && specLimit.RegionLimit.DiskMB != nil {
+			showDisk = true
+		}
+		if specLimit.DeviceSeconds != nil {
+			showDevices = true
+		}
+	}
+
 	limits := make([]string, len(spec.Limits)+1)
 	limits[0] = "Region|CPU Usage|Memory Usage"
+	if showDisk {
+		limits[0] += "|Disk Usage"
+	}
+	if showDevices {
+		limits[0] += "|Device Hours"
+	}
 	i := 0
 	for _, specLimit := range spec.Limits {
 		i++
@@ -173,17 +210,94 @@ func formatQuotaLimits(spec *api.QuotaSpec, usages map[string]*api.QuotaUsage) s
 			cpu := fmt.Sprintf("- / %s", formatQuotaLimitInt(specLimit.RegionLimit.CPU))
 			memory := fmt.Sprintf("- / %s", formatQuotaLimitInt(specLimit.RegionLimit.MemoryMB))
 			limits[i] = fmt.Sprintf("%s|%s|%s", specLimit.Region, cpu, memory)
+			if showDisk {
+				limits[i] += fmt.Sprintf("|- / %s", formatQuotaLimitInt(specLimit.RegionLimit.DiskMB))
+			}
+			if showDevices {
+				limits[i] += fmt.Sprintf("|- / %s", formatQuotaDeviceHours(specLimit.DeviceSeconds))
+			}
 			continue
 		}
 
 		cpu := fmt.Sprintf("%d / %s", *used.RegionLimit.CPU, formatQuotaLimitInt(specLimit.RegionLimit.CPU))
 		memory := fmt.Sprintf("%d / %s", *used.RegionLimit.MemoryMB, formatQuotaLimitInt(specLimit.RegionLimit.MemoryMB))
 		limits[i] = fmt.Sprintf("%s|%s|%s", specLimit.Region, cpu, memory)
+		if showDisk {
+			limits[i] += fmt.Sprintf("|%s / %s", formatQuotaUsedInt(used.RegionLimit.DiskMB), formatQuotaLimitInt(specLimit.RegionLimit.DiskMB))
+		}
+		if showDevices {
+			limits[i] += fmt.Sprintf("|%s / %s", formatQuotaUsedDeviceHours(used.DeviceSeconds), formatQuotaDeviceHours(specLimit.DeviceSeconds))
+		}
 	}
 
 	return formatList(limits)
 }
 
+// formatQuotaConsumers formats the contribution of each namespace to the usage
+// of the quota per region. It returns an empty string if the usages do not
+// include namespaces.
+func formatQuotaConsumers(spec *api.QuotaSpec, usages map[string]*api.QuotaUsage) string {
+	// Sort the limits
+	sort.Sort(api.QuotaLimitSort(spec.Limits))
+
+	rows := []string{"Namespace|Region|Allocs|CPU Usage|Memory Usage"}
+	for _, specLimit := range spec.Limits {
+		usage, ok := usages[specLimit.Region]
+		if !ok {
+			continue
+		}
+
+		namespaces := make([]*api.QuotaNamespaceUsage, len(usage.Namespaces))
+		copy(namespaces, usage.Namespaces)
+		sort.Slice(namespaces, func(i, j int) bool {
+			return namespaces[i].Namespace < namespaces[j].Namespace
+		})
+
+		key := base64.StdEncoding.EncodeToString(specLimit.Hash)
+		for _, ns := range namespaces {
+			cpu, memory := "-", "-"
+			if used, ok := ns.Used[key]; ok && used.RegionLimit != nil {
+				cpu = formatQuotaUsedInt(used.RegionLimit.CPU)
+				memory = formatQuotaUsedInt(used.RegionLimit.MemoryMB)
+			}
+			rows = append(rows, fmt.Sprintf("%s|%s|%d|%s|%s",
+				ns.Namespace, specLimit.Region, ns.Allocs, cpu, memory))
+		}
+	}
+
+	if len(rows) == 1 {
+		return ""
+	}
+	return formatList(rows)
+}
+
+// formatQuotaUsedInt returns the string for the usage of an integer
+// resource, which may not be reported by older servers.
+func formatQuotaUsedInt(value *int) string {
+	if value == nil {
+		return "-"
+	}
+	return strconv.Itoa(*value)
+}
+
+// formatQuotaUsedDeviceHours returns the string for the device-seconds
+// accumulated in hours.
+func formatQuotaUsedDeviceHours(value *int) string {
+	if value == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(float64(*value)/3600, 'f', 1, 64)
+}
+
+// formatQuotaDeviceHours takes a device-seconds limit and returns the
+// appropriate string, in hours, for output.
+func formatQuotaDeviceHours(value *int) string {
+	if value == nil || *value <= 0 {
+		return formatQuotaLimitInt(value)
+	}
+	return formatQuotaUsedDeviceHours(value)
+}
+
 // formatQuotaLimitInt takes a integer resource value and returns the
 // appropriate string for output.
 func formatQuotaLimitInt(value *int) string {
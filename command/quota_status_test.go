@@ -3,9 +3,12 @@
 package command
 
 import (
+	"encoding/base64"
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper"
 	"github.com/mitchellh/cli"
 	"github.com/posener/complete"
 	"github.com/stretchr/testify/assert"
@@ -93,3 +96,102 @@ func TestQuotaStatusCommand_AutocompleteArgs(t *testing.T) {
 	assert.Equal(1, len(res))
 	assert.Equal(qs.Name, res[0])
 }
+
+func TestQuotaStatusCommand_FormatLimits_DiskAndDevices(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	spec := &api.QuotaSpec{
+		Name: "gpu",
+		Limits: []*api.QuotaLimit{
+			{
+				Region: "global",
+				RegionLimit: &api.Resources{
+					CPU:      helper.IntToPtr(2500),
+					MemoryMB: helper.IntToPtr(1000),
+					DiskMB:   helper.IntToPtr(20000),
+				},
+				DeviceSeconds: helper.IntToPtr(360000),
+				Hash:          []byte("hash"),
+			},
+		},
+	}
+	usages := map[string]*api.QuotaUsage{
+		"global": {
+			Name: "gpu",
+			Used: map[string]*api.QuotaLimit{
+				base64.StdEncoding.EncodeToString([]byte("hash")): {
+					RegionLimit: &api.Resources{
+						CPU:      helper.IntToPtr(500),
+						MemoryMB: helper.IntToPtr(256),
+						DiskMB:   helper.IntToPtr(300),
+					},
+					DeviceSeconds: helper.IntToPtr(5400),
+				},
+			},
+		},
+	}
+
+	out := formatQuotaLimits(spec, usages)
+	assert.Contains(out, "Disk Usage")
+	assert.Contains(out, "Device Hours")
+	assert.Contains(out, "300 / 20000")
+	assert.Contains(out, "1.5 / 100.0")
+
+	// The optional dimensions are hidden if no limit specifies them
+	spec.Limits[0].RegionLimit.DiskMB = nil
+	spec.Limits[0].DeviceSeconds = nil
+	out = formatQuotaLimits(spec, usages)
+	assert.NotContains(out, "Disk Usage")
+	assert.NotContains(out, "Device Hours")
+}
+
+func TestQuotaStatusCommand_FormatConsumers(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	spec := &api.QuotaSpec{
+		Name: "shared",
+		Limits: []*api.QuotaLimit{
+			{
+				Region:      "global",
+				RegionLimit: &api.Resources{CPU: helper.IntToPtr(2500), MemoryMB: helper.IntToPtr(1000)},
+				Hash:        []byte("hash"),
+			},
+		},
+	}
+	key := base64.StdEncoding.EncodeToString([]byte("hash"))
+	usages := map[string]*api.QuotaUsage{
+		"global": {
+			Name: "shared",
+			Namespaces: []*api.QuotaNamespaceUsage{
+				{
+					Namespace: "web",
+					Allocs:    3,
+					Used: map[string]*api.QuotaLimit{
+						key: {RegionLimit: &api.Resources{CPU: helper.IntToPtr(300), MemoryMB: helper.IntToPtr(768)}},
+					},
+				},
+				{
+					Namespace: "batch",
+					Allocs:    1,
+					Used: map[string]*api.QuotaLimit{
+						key: {RegionLimit: &api.Resources{CPU: helper.IntToPtr(200), MemoryMB: helper.IntToPtr(128)}},
+					},
+				},
+			},
+		},
+	}
+
+	out := formatQuotaConsumers(spec, usages)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if assert.Len(lines, 3) {
+		assert.Contains(lines[0], "Namespace")
+		assert.Regexp(`^batch\s+global\s+1\s+200\s+128$`, lines[1])
+		assert.Regexp(`^web\s+global\s+3\s+300\s+768$`, lines[2])
+	}
+
+	// Usages without namespaces are not displayed
+	usages["global"].Namespaces = nil
+	assert.Empty(formatQuotaConsumers(spec, usages))
+}
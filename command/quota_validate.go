@@ -0,0 +1,197 @@
+package command
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+var (
+	// validQuotaName is the pattern quota names must match
+	validQuotaName = regexp.MustCompile("^[a-zA-Z0-9-]{1,128}$")
+
+	// quotaExemptJobTypes is the set of job types allocations can be exempt
+	// from the quota limits by
+	quotaExemptJobTypes = []string{"service", "batch", "system"}
+)
+
+const (
+	// maxQuotaDescriptionLength is the maximum length of the description of a
+	// quota specification
+	maxQuotaDescriptionLength = 256
+)
+
+type QuotaValidateCommand struct {
+	Meta
+}
+
+func (c *QuotaValidateCommand) Help() string {
+	helpText := `
+Usage: nomad quota validate [options] <input>
+
+  Validate is used to check a quota specification for errors without applying
+  it. The specification file will be read from stdin by specifying "-",
+  otherwise a path to the file is expected.
+
+  The name, the description, the exemptions and the limits are validated. The
+  regions of the limits are checked against the regions known to the Nomad
+  agent, unless it can't be reached.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Validate Options:
+
+  -json
+    Parse the input as a JSON quota specification.
+`
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *QuotaValidateCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-json": complete.PredictNothing,
+		})
+}
+
+func (c *QuotaValidateCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *QuotaValidateCommand) Synopsis() string {
+	return "Check a quota specification for errors"
+}
+
+func (c *QuotaValidateCommand) Run(args []string) int {
+	var jsonInput bool
+	flags := c.Meta.FlagSet("quota validate", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&jsonInput, "json", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we get exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	spec, err := readQuotaSpec(args[0], jsonInput)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// The regions are only checked if the agent can be reached
+	regions, err := client.Regions().List()
+	if err != nil {
+		c.Ui.Output(
+			c.Colorize().Color("[bold][yellow]Regions not validated since connection to Nomad agent couldn't be established.[reset]\n"))
+	}
+
+	if err := validateQuotaSpec(spec, regions); err != nil {
+		c.Ui.Error(
+			c.Colorize().Color("[bold][red]Quota specification validation errors:[reset]"))
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	c.Ui.Output(
+		c.Colorize().Color("[bold][green]Quota specification validation successful[reset]"))
+	return 0
+}
+
+// validateQuotaSpec validates the quota specification as the servers do when
+// it is applied. The regions of the limits are checked against the given
+// regions unless they are nil.
+func validateQuotaSpec(spec *api.QuotaSpec, regions []string) error {
+	var mErr multierror.Error
+	if !validQuotaName.MatchString(spec.Name) {
+		multierror.Append(&mErr, fmt.Errorf("invalid name %q. Must match regex %s", spec.Name, validQuotaName))
+	}
+	if len(spec.Description) > maxQuotaDescriptionLength {
+		multierror.Append(&mErr, fmt.Errorf("description longer than %d", maxQuotaDescriptionLength))
+	}
+
+	for _, t := range spec.ExemptJobTypes {
+		valid := false
+		for _, v := range quotaExemptJobTypes {
+			if t == v {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			multierror.Append(&mErr, fmt.Errorf("invalid exempt job type %q. Must be one of %s", t, strings.Join(quotaExemptJobTypes, ", ")))
+		}
+	}
+	for _, p := range spec.ExemptJobs {
+		if strings.TrimSpace(p) == "" {
+			multierror.Append(&mErr, fmt.Errorf("exempt job pattern can't be empty"))
+		}
+	}
+
+	if len(spec.Limits) == 0 {
+		multierror.Append(&mErr, fmt.Errorf("must provide at least one limit"))
+	}
+
+	known := make(map[string]struct{}, len(regions))
+	for _, r := range regions {
+		known[r] = struct{}{}
+	}
+	seen := make(map[string]struct{}, len(spec.Limits))
+	for i, l := range spec.Limits {
+		if err := validateQuotaLimit(l, known, regions != nil); err != nil {
+			multierror.Append(&mErr, multierror.Prefix(err, fmt.Sprintf("limit %d ->", i+1)))
+		}
+		if _, ok := seen[l.Region]; ok && l.Region != "" {
+			multierror.Append(&mErr, fmt.Errorf("limit %d -> multiple limits for region %q", i+1, l.Region))
+		}
+		seen[l.Region] = struct{}{}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// validateQuotaLimit validates a limit of a quota specification. The region
+// is checked against the known regions if checkRegion is set.
+func validateQuotaLimit(l *api.QuotaLimit, known map[string]struct{}, checkRegion bool) error {
+	var mErr multierror.Error
+	if l.Region == "" {
+		multierror.Append(&mErr, fmt.Errorf("must provide a region"))
+	} else if _, ok := known[l.Region]; checkRegion && !ok {
+		multierror.Append(&mErr, fmt.Errorf("unknown region %q", l.Region))
+	}
+
+	// A limit must limit something. Zero values are unlimited and negative ones
+	// disallow the resource
+	r := l.RegionLimit
+	if (r == nil || (isZeroInt(r.CPU) && isZeroInt(r.MemoryMB) && isZeroInt(r.DiskMB))) && isZeroInt(l.DeviceSeconds) {
+		multierror.Append(&mErr, fmt.Errorf("must provide a non-zero region_limit or device_seconds"))
+	}
+	if r != nil && (r.IOPS != nil || len(r.Networks) != 0 || len(r.Devices) != 0) {
+		multierror.Append(&mErr, fmt.Errorf("region_limit only supports cpu, memory and disk"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// isZeroInt returns whether the optional value is unset or zero.
+func isZeroInt(i *int) bool {
+	return i == nil || *i == 0
+}
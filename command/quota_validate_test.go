@@ -0,0 +1,102 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaValidateCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &QuotaValidateCommand{}
+}
+
+func TestQuotaValidateCommand_Run(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	srv, _, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	ui := new(cli.MockUi)
+	cmd := &QuotaValidateCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	require.Equal(1, cmd.Run([]string{"some", "bad", "args"}))
+	require.Contains(ui.ErrorWriter.String(), cmd.Help())
+	ui.ErrorWriter.Reset()
+
+	fh, err := ioutil.TempFile("", "nomad")
+	require.NoError(err)
+	defer os.Remove(fh.Name())
+	_, err = fh.WriteString(defaultHclQuotaSpec)
+	require.NoError(err)
+
+	// The example specification is valid
+	code := cmd.Run([]string{"-address=" + url, fh.Name()})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Contains(ui.OutputWriter.String(), "Quota specification validation successful")
+	ui.OutputWriter.Reset()
+
+	// Unknown regions are rejected
+	spec := strings.Replace(defaultHclQuotaSpec, `region = "global"`, `region = "mars"`, 1)
+	require.NoError(ioutil.WriteFile(fh.Name(), []byte(spec), 0600))
+	require.Equal(1, cmd.Run([]string{"-address=" + url, fh.Name()}))
+	require.Contains(ui.ErrorWriter.String(), `unknown region "mars"`)
+	ui.ErrorWriter.Reset()
+
+	// The regions aren't checked without an agent
+	require.Equal(0, cmd.Run([]string{"-address=http://127.0.0.1:1", fh.Name()}))
+	require.Contains(ui.OutputWriter.String(), "Regions not validated")
+}
+
+func TestQuotaValidateCommand_ValidateSpec(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	valid := func() *api.QuotaSpec {
+		return &api.QuotaSpec{
+			Name: "default-quota",
+			Limits: []*api.QuotaLimit{{
+				Region:      "global",
+				RegionLimit: &api.Resources{CPU: helper.IntToPtr(2500)},
+			}},
+		}
+	}
+	require.NoError(validateQuotaSpec(valid(), []string{"global"}))
+
+	spec := valid()
+	spec.Limits[0].RegionLimit = nil
+	spec.Limits[0].DeviceSeconds = helper.IntToPtr(3600)
+	require.NoError(validateQuotaSpec(spec, nil))
+
+	cases := []struct {
+		name   string
+		modify func(*api.QuotaSpec)
+		err    string
+	}{
+		{"name", func(s *api.QuotaSpec) { s.Name = "bad name" }, "invalid name"},
+		{"description", func(s *api.QuotaSpec) { s.Description = strings.Repeat("a", 257) }, "description longer than 256"},
+		{"exempt job type", func(s *api.QuotaSpec) { s.ExemptJobTypes = []string{"daemon"} }, `invalid exempt job type "daemon"`},
+		{"exempt job", func(s *api.QuotaSpec) { s.ExemptJobs = []string{" "} }, "exempt job pattern can't be empty"},
+		{"no limits", func(s *api.QuotaSpec) { s.Limits = nil }, "at least one limit"},
+		{"no region", func(s *api.QuotaSpec) { s.Limits[0].Region = "" }, "limit 1 -> must provide a region"},
+		{"unknown region", func(s *api.QuotaSpec) { s.Limits[0].Region = "mars" }, `limit 1 -> unknown region "mars"`},
+		{"duplicate region", func(s *api.QuotaSpec) { s.Limits = append(s.Limits, s.Limits[0]) }, `limit 2 -> multiple limits for region "global"`},
+		{"empty limit", func(s *api.QuotaSpec) { s.Limits[0].RegionLimit = &api.Resources{} }, "non-zero region_limit or device_seconds"},
+		{"iops", func(s *api.QuotaSpec) { s.Limits[0].RegionLimit.IOPS = helper.IntToPtr(10) }, "only supports cpu, memory and disk"},
+	}
+	for _, tc := range cases {
+		spec := valid()
+		tc.modify(spec)
+		err := validateQuotaSpec(spec, []string{"global"})
+		require.Error(err, tc.name)
+		require.Contains(err.Error(), tc.err, tc.name)
+	}
+}
@@ -74,6 +74,12 @@ Run Options:
     the evaluation ID will be printed to the screen, which can be used to
     examine the evaluation using the eval-status command.
 
+  -idempotency-token
+    If set, the token is stored with the registered job version and
+    evaluation. Submitting the job again with the same token returns the
+    original evaluation instead of creating a new job version, making retries
+    safe. Submitting a different job with the same token is an error.
+
   -output
     Output the JSON that would be submitted to the HTTP API without submitting
     the job.
@@ -81,6 +87,11 @@ Run Options:
   -policy-override
     Sets the flag to force override any soft mandatory Sentinel policies.
 
+  -source
+    If set, the description of where the job comes from, such as a git SHA,
+    is recorded with the registered job version and shown by
+    "nomad job history -verbose".
+
   -vault-token
     If set, the passed Vault token is stored in the job before sending to the
     Nomad servers. This allows passing the Vault token without storing it in
@@ -100,12 +111,14 @@ func (c *RunCommand) Synopsis() string {
 func (c *RunCommand) AutocompleteFlags() complete.Flags {
 	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
 		complete.Flags{
-			"-check-index":     complete.PredictNothing,
-			"-detach":          complete.PredictNothing,
-			"-verbose":         complete.PredictNothing,
-			"-vault-token":     complete.PredictAnything,
-			"-output":          complete.PredictNothing,
-			"-policy-override": complete.PredictNothing,
+			"-check-index":       complete.PredictNothing,
+			"-detach":            complete.PredictNothing,
+			"-idempotency-token": complete.PredictAnything,
+			"-verbose":           complete.PredictNothing,
+			"-vault-token":       complete.PredictAnything,
+			"-output":            complete.PredictNothing,
+			"-policy-override":   complete.PredictNothing,
+			"-source":            complete.PredictAnything,
 		})
 }
 
@@ -115,7 +128,7 @@ func (c *RunCommand) AutocompleteArgs() complete.Predictor {
 
 func (c *RunCommand) Run(args []string) int {
 	var detach, verbose, output, override bool
-	var checkIndexStr, vaultToken string
+	var checkIndexStr, vaultToken, idempotencyToken, source string
 
 	flags := c.Meta.FlagSet("run", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
@@ -125,6 +138,8 @@ func (c *RunCommand) Run(args []string) int {
 	flags.BoolVar(&override, "policy-override", false, "")
 	flags.StringVar(&checkIndexStr, "check-index", "", "")
 	flags.StringVar(&vaultToken, "vault-token", "", "")
+	flags.StringVar(&idempotencyToken, "idempotency-token", "", "")
+	flags.StringVar(&source, "source", "", "")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
@@ -216,6 +231,8 @@ func (c *RunCommand) Run(args []string) int {
 	if override {
 		opts.PolicyOverride = true
 	}
+	opts.IdempotencyToken = idempotencyToken
+	opts.Source = source
 
 	// Submit the job
 	resp, _, err := client.Jobs().RegisterOpts(job, opts, nil)
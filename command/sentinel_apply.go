@@ -28,6 +28,12 @@ General Options:
 
 Apply Options:
 
+  -check-index
+    If set, the policy is only created or updated if the passed modify index
+    matches the server side version. If a check-index value of zero is passed,
+    the policy is only created if it does not yet exist. The index is
+    checked by the Nomad Enterprise servers.
+
   -description
     Sets a human readable description for the policy.
 
@@ -45,6 +51,7 @@ Apply Options:
 func (c *SentinelApplyCommand) AutocompleteFlags() complete.Flags {
 	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
 		complete.Flags{
+			"-check-index": complete.PredictNothing,
 			"-description": complete.PredictAnything,
 			"-scope":       complete.PredictAnything,
 			"-level":       complete.PredictAnything,
@@ -60,17 +67,25 @@ func (c *SentinelApplyCommand) Synopsis() string {
 }
 
 func (c *SentinelApplyCommand) Run(args []string) int {
-	var description, scope, enfLevel string
+	var description, scope, enfLevel, checkIndexStr string
 	var err error
 	flags := c.Meta.FlagSet("sentinel apply", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
 	flags.StringVar(&description, "description", "", "")
 	flags.StringVar(&scope, "scope", "submit-job", "")
 	flags.StringVar(&enfLevel, "level", "advisory", "")
+	flags.StringVar(&checkIndexStr, "check-index", "", "")
 	if err := flags.Parse(args); err != nil {
 		return 1
 	}
 
+	// Parse the check-index
+	checkIndex, enforce, err := parseCheckIndex(checkIndexStr)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error parsing check-index value %q: %v", checkIndexStr, err))
+		return 1
+	}
+
 	// Check that we got exactly two arguments
 	args = flags.Args()
 	if l := len(args); l != 2 {
@@ -114,8 +129,12 @@ func (c *SentinelApplyCommand) Run(args []string) int {
 		return 1
 	}
 
-	// Get the list of policies
-	_, err = client.SentinelPolicies().Upsert(sp, nil)
+	// Write the policy
+	if enforce {
+		_, err = client.SentinelPolicies().UpsertCAS(sp, checkIndex, nil)
+	} else {
+		_, err = client.SentinelPolicies().Upsert(sp, nil)
+	}
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Error writing Sentinel policy: %s", err))
 		return 1
@@ -0,0 +1,102 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+type SentinelEnforceCommand struct {
+	Meta
+}
+
+func (c *SentinelEnforceCommand) Help() string {
+	helpText := `
+Usage: nomad sentinel enforce [options] <name>
+
+  Enforce is used to change the enforcement level or scope of an existing
+  Sentinel policy without rewriting the policy itself. The change is recorded
+  as a new version of the policy.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Enforce Options:
+
+  -scope
+    Sets the scope of the policy and when it should be enforced.
+
+  -level
+    Sets the enforcment level of the policy. Must be one of advisory,
+    soft-mandatory, hard-mandatory.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SentinelEnforceCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-scope": complete.PredictAnything,
+			"-level": complete.PredictSet("advisory", "soft-mandatory", "hard-mandatory"),
+		})
+}
+
+func (c *SentinelEnforceCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *SentinelEnforceCommand) Synopsis() string {
+	return "Change the enforcement level or scope of a Sentinel policy"
+}
+
+func (c *SentinelEnforceCommand) Run(args []string) int {
+	var scope, enfLevel string
+	flags := c.Meta.FlagSet("sentinel enforce", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.StringVar(&scope, "scope", "", "")
+	flags.StringVar(&enfLevel, "level", "", "")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got exactly one argument
+	args = flags.Args()
+	if l := len(args); l != 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Check that something is changed
+	if scope == "" && enfLevel == "" {
+		c.Ui.Error("Either -level or -scope must be specified")
+		return 1
+	}
+
+	// Get the name
+	policyName := args[0]
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Update the policy
+	update := &api.SentinelPolicyEnforcement{
+		EnforcementLevel: enfLevel,
+		Scope:            scope,
+	}
+	if _, err := client.SentinelPolicies().UpdateEnforcement(policyName, update, nil); err != nil {
+		c.Ui.Error(fmt.Sprintf("Error updating Sentinel policy: %s", err))
+		return 1
+	}
+
+	c.Ui.Output(fmt.Sprintf("Successfully updated enforcement of %q Sentinel policy!",
+		policyName))
+	return 0
+}
@@ -89,6 +89,7 @@ func (c *SentinelReadCommand) Run(args []string) int {
 		fmt.Sprintf("Name|%s", policy.Name),
 		fmt.Sprintf("Scope|%s", policy.Scope),
 		fmt.Sprintf("Enforcement Level|%s", policy.EnforcementLevel),
+		fmt.Sprintf("Version|%d", policy.Version),
 		fmt.Sprintf("Description|%s", policy.Description),
 	}
 	c.Ui.Output(formatKV(info))
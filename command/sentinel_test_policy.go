@@ -0,0 +1,169 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/posener/complete"
+)
+
+type SentinelTestCommand struct {
+	Meta
+	JobGetter
+}
+
+func (c *SentinelTestCommand) Help() string {
+	helpText := `
+Usage: nomad sentinel test [options] <policy file> <job file> [<job file>...]
+
+  Test is used to evaluate a Sentinel policy against job files without writing
+  the policy or registering the jobs. The result of the policy and the trace of
+  its rules are printed for each job. The command exits with a non-zero code if
+  the policy fails for any job, which allows repositories of policies to be
+  tested in CI.
+
+  By default the policy is evaluated by the servers, with the same imports and
+  data the policy would have once written. A job file is read from stdin by
+  specifying "-", and may also be downloaded from a URL.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Test Options:
+
+  -local
+    Evaluates the policy with the embedded Sentinel evaluator instead of
+    sending it to the servers. No agent is contacted.
+
+  -trace
+    Prints the trace of the policy rules for every job. By default the trace
+    is only printed for the jobs the policy fails for.
+
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SentinelTestCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-local": complete.PredictNothing,
+			"-trace": complete.PredictNothing,
+		})
+}
+
+func (c *SentinelTestCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictOr(
+		complete.PredictFiles("*.sentinel"),
+		complete.PredictFiles("*.nomad"),
+		complete.PredictFiles("*.hcl"))
+}
+
+func (c *SentinelTestCommand) Synopsis() string {
+	return "Evaluate a Sentinel policy against job files"
+}
+
+func (c *SentinelTestCommand) Run(args []string) int {
+	var local, trace bool
+	flags := c.Meta.FlagSet("sentinel test", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&local, "local", false, "")
+	flags.BoolVar(&trace, "trace", false, "")
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got a policy and at least one job
+	args = flags.Args()
+	if l := len(args); l < 2 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Read the policy
+	rawPolicy, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Failed to read policy file: %v", err))
+		return 1
+	}
+
+	// Read the jobs
+	files := args[1:]
+	jobs := make([]*api.Job, 0, len(files))
+	for _, file := range files {
+		job, err := c.JobGetter.ApiJob(file)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error getting job struct from %q: %s", file, err))
+			return 1
+		}
+		jobs = append(jobs, job)
+	}
+
+	req := &api.SentinelTestRequest{
+		Policy: string(rawPolicy),
+		Jobs:   jobs,
+	}
+
+	var resp *api.SentinelTestResponse
+	if local {
+		resp, err = c.testLocal(req)
+	} else {
+		var client *api.Client
+		client, err = c.Meta.Client()
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+			return 1
+		}
+		resp, _, err = client.SentinelPolicies().Test(req, nil)
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error testing Sentinel policy: %s", err))
+		return 1
+	}
+	if len(resp.Results) != len(files) {
+		c.Ui.Error(fmt.Sprintf("Error testing Sentinel policy: expected %d results, got %d",
+			len(files), len(resp.Results)))
+		return 1
+	}
+
+	c.Ui.Output(c.Colorize().Color(formatSentinelTestResults(files, resp.Results, trace)))
+
+	for _, r := range resp.Results {
+		if !r.Passed {
+			return 1
+		}
+	}
+	return 0
+}
+
+// formatSentinelTestResults returns the results of a policy, matched with the
+// job files in order. Traces are printed for failures or if all is set.
+func formatSentinelTestResults(files []string, results []*api.SentinelTestResult, all bool) string {
+	var b bytes.Buffer
+	passed := 0
+	for i, r := range results {
+		status := "[bold][red]FAIL[reset]"
+		if r.Passed {
+			status = "[bold][green]PASS[reset]"
+			passed++
+		}
+		fmt.Fprintf(&b, "%s - %s (job %q)\n", status, files[i], r.JobID)
+
+		if r.Error != "" {
+			fmt.Fprintf(&b, "  Error: %s\n", r.Error)
+		}
+		if trace := strings.TrimSpace(r.Trace); trace != "" && (all || !r.Passed) {
+			b.WriteString("\n")
+			for _, line := range strings.Split(trace, "\n") {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	fmt.Fprintf(&b, "\n%d of %d job(s) passed", passed, len(results))
+	return b.String()
+}
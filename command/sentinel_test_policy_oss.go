@@ -0,0 +1,15 @@
+// +build !ent
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/nomad/api"
+)
+
+// testLocal evaluates a Sentinel policy with the embedded evaluator, which is
+// only available in Nomad Enterprise
+func (c *SentinelTestCommand) testLocal(req *api.SentinelTestRequest) (*api.SentinelTestResponse, error) {
+	return nil, fmt.Errorf("local evaluation of Sentinel policies is only available in Nomad Enterprise")
+}
@@ -0,0 +1,60 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSentinelTestCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &SentinelTestCommand{}
+}
+
+func TestSentinelTestCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &SentinelTestCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"policy.sentinel"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on a missing policy file
+	if code := cmd.Run([]string{"/unicorns/leprechauns", "example.nomad"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Failed to read policy file") {
+		t.Fatalf("expected policy file error, got: %s", out)
+	}
+}
+
+func TestSentinelTestCommand_FormatResults(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	files := []string{"pass.nomad", "fail.nomad"}
+	results := []*api.SentinelTestResult{
+		{JobID: "pass", Passed: true, Trace: "Rule \"main\" (byte offset 0) = true"},
+		{JobID: "fail", Passed: false, Trace: "Rule \"main\" (byte offset 0) = false"},
+	}
+
+	// Traces are only printed for failures by default
+	out := formatSentinelTestResults(files, results, false)
+	require.Contains(out, `PASS[reset] - pass.nomad (job "pass")`)
+	require.Contains(out, `FAIL[reset] - fail.nomad (job "fail")`)
+	require.Contains(out, `  Rule "main" (byte offset 0) = false`)
+	require.NotContains(out, "= true")
+	require.Contains(out, "1 of 2 job(s) passed")
+
+	out = formatSentinelTestResults(files, results, true)
+	require.Contains(out, `  Rule "main" (byte offset 0) = true`)
+}
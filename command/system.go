@@ -0,0 +1,29 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+type SystemCommand struct {
+	Meta
+}
+
+func (c *SystemCommand) Help() string {
+	helpText := `
+Usage: nomad system <subcommand> [options]
+
+  This command groups subcommands for interacting with the system API. Users
+  can force the garbage collection of the cluster's objects.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SystemCommand) Synopsis() string {
+	return "Interact with the system API"
+}
+
+func (c *SystemCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
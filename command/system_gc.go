@@ -0,0 +1,188 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/posener/complete"
+)
+
+type SystemGCCommand struct {
+	Meta
+}
+
+func (c *SystemGCCommand) Help() string {
+	helpText := `
+Usage: nomad system gc [options]
+
+  Initializes a garbage collection of jobs, evaluations, allocations, nodes and
+  deployments that are eligible for it. The garbage collection can be
+  restricted to some of the collections with the flags below, and to a
+  namespace with the -namespace option. Nodes aren't namespaced so they are
+  not collected when a namespace is given.
+
+  A restricted garbage collection or a dry run is done before the command
+  returns and the objects collected are reported. Otherwise the garbage
+  collection is done asynchronously by the servers.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+GC Options:
+
+  -jobs
+    Garbage collect jobs, along with their evaluations and allocations.
+
+  -evals
+    Garbage collect evaluations, along with their allocations.
+
+  -allocs
+    Garbage collect allocations.
+
+  -nodes
+    Garbage collect nodes.
+
+  -deployments
+    Garbage collect deployments.
+
+  -dry-run
+    Report the objects that would be garbage collected without collecting
+    them.
+
+  -verbose
+    Display full IDs.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *SystemGCCommand) Synopsis() string {
+	return "Run the system garbage collection process"
+}
+
+func (c *SystemGCCommand) AutocompleteFlags() complete.Flags {
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-jobs":        complete.PredictNothing,
+			"-evals":       complete.PredictNothing,
+			"-allocs":      complete.PredictNothing,
+			"-nodes":       complete.PredictNothing,
+			"-deployments": complete.PredictNothing,
+			"-dry-run":     complete.PredictNothing,
+			"-verbose":     complete.PredictNothing,
+		})
+}
+
+func (c *SystemGCCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *SystemGCCommand) Run(args []string) int {
+	var jobs, evals, allocs, nodes, deployments, dryRun, verbose bool
+
+	flags := c.Meta.FlagSet("system gc", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&jobs, "jobs", false, "")
+	flags.BoolVar(&evals, "evals", false, "")
+	flags.BoolVar(&allocs, "allocs", false, "")
+	flags.BoolVar(&nodes, "nodes", false, "")
+	flags.BoolVar(&deployments, "deployments", false, "")
+	flags.BoolVar(&dryRun, "dry-run", false, "")
+	flags.BoolVar(&verbose, "verbose", false, "")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Check that we got no arguments
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	// Truncate the id unless full length is requested
+	length := shortId
+	if verbose {
+		length = fullId
+	}
+
+	// Get the HTTP client
+	client, err := c.Meta.Client()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	opts := &api.GCOptions{DryRun: dryRun}
+	for collection, set := range map[string]bool{
+		structs.GCCollectionJobs:        jobs,
+		structs.GCCollectionEvals:       evals,
+		structs.GCCollectionAllocs:      allocs,
+		structs.GCCollectionNodes:       nodes,
+		structs.GCCollectionDeployments: deployments,
+	} {
+		if set {
+			opts.Collections = append(opts.Collections, collection)
+		}
+	}
+	sort.Strings(opts.Collections)
+
+	// An unrestricted garbage collection is done asynchronously. The client
+	// restricts it to the namespace of the flag or the environment.
+	namespaced := c.Meta.namespace != "" || os.Getenv("NOMAD_NAMESPACE") != ""
+	if len(opts.Collections) == 0 && !dryRun && !namespaced {
+		if err := client.System().GarbageCollect(); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error running system garbage collection: %s", err))
+			return 1
+		}
+		c.Ui.Output("Triggered the garbage collection of all the eligible objects")
+		return 0
+	}
+
+	resp, _, err := client.System().GarbageCollectScoped(opts, nil)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error running system garbage collection: %s", err))
+		return 1
+	}
+
+	verb := "Garbage collected"
+	if dryRun {
+		verb = "Would garbage collect"
+	}
+	c.Ui.Output(fmt.Sprintf("%s %d jobs, %d evaluations, %d allocations, %d nodes and %d deployments",
+		verb, len(resp.Jobs), len(resp.Evals), len(resp.Allocs), len(resp.Nodes), len(resp.Deployments)))
+	if !dryRun {
+		return 0
+	}
+
+	if len(resp.Jobs) != 0 {
+		rows := []string{"Namespace|ID"}
+		for _, job := range resp.Jobs {
+			rows = append(rows, fmt.Sprintf("%s|%s", job.Namespace, job.ID))
+		}
+		c.Ui.Output(c.Colorize().Color(fmt.Sprintf("\n[bold]Jobs[reset]\n%s", formatList(rows))))
+	}
+	for _, section := range []struct {
+		title string
+		ids   []string
+	}{
+		{"Evaluations", resp.Evals},
+		{"Allocations", resp.Allocs},
+		{"Nodes", resp.Nodes},
+		{"Deployments", resp.Deployments},
+	} {
+		if len(section.ids) == 0 {
+			continue
+		}
+		rows := []string{"ID"}
+		for _, id := range section.ids {
+			rows = append(rows, limit(id, length))
+		}
+		c.Ui.Output(c.Colorize().Color(fmt.Sprintf("\n[bold]%s[reset]\n%s", section.title, formatList(rows))))
+	}
+	return 0
+}
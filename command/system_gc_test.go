@@ -0,0 +1,71 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+)
+
+func TestSystemGCCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &SystemGCCommand{}
+}
+
+func TestSystemGCCommand_Run(t *testing.T) {
+	t.Parallel()
+	srv, _, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	ui := new(cli.MockUi)
+	cmd := &SystemGCCommand{Meta: Meta{Ui: ui}}
+
+	// An unrestricted garbage collection is triggered
+	if code := cmd.Run([]string{"-address=" + url}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Triggered the garbage collection") {
+		t.Fatalf("expected triggered garbage collection, got: %s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// A dry run reports what would be collected
+	if code := cmd.Run([]string{"-address=" + url, "-evals", "-allocs", "-dry-run"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Would garbage collect 0 jobs, 0 evaluations") {
+		t.Fatalf("expected dry run report, got: %s", out)
+	}
+	ui.OutputWriter.Reset()
+
+	// A garbage collection restricted to a namespace reports what was collected
+	if code := cmd.Run([]string{"-address=" + url, "-namespace=default", "-deployments"}); code != 0 {
+		t.Fatalf("expected exit 0, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Garbage collected 0 jobs") {
+		t.Fatalf("expected garbage collection report, got: %s", out)
+	}
+}
+
+func TestSystemGCCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &SystemGCCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "-dry-run"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error running system garbage collection") {
+		t.Fatalf("expected failed garbage collection error, got: %s", out)
+	}
+}
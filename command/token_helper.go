@@ -0,0 +1,64 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// tokenHelperFile is the name of the file in the home directory of the user
+// the token helper stores the ACL token in.
+const tokenHelperFile = ".nomad-token"
+
+// tokenHelper stores the ACL token of the user, such as the token issued by
+// "nomad login", so that it is used by the commands that aren't given one with
+// the -token flag or the NOMAD_TOKEN environment variable.
+type tokenHelper struct {
+	path string
+}
+
+// defaultTokenHelper returns the token helper storing the token in the home
+// directory of the user.
+func defaultTokenHelper() (*tokenHelper, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the home directory: %v", err)
+	}
+	return &tokenHelper{path: filepath.Join(home, tokenHelperFile)}, nil
+}
+
+// Get returns the stored token, or an empty string if there is none.
+func (h *tokenHelper) Get() (string, error) {
+	buf, err := ioutil.ReadFile(h.path)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(buf)), nil
+}
+
+// Store stores the token, readable only by the user.
+func (h *tokenHelper) Store(token string) error {
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(token); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Erase removes the stored token.
+func (h *tokenHelper) Erase() error {
+	if err := os.Remove(h.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -2,9 +2,17 @@ package command
 
 import (
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
 	"net/url"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/api/contexts"
 	"github.com/posener/complete"
 	"github.com/skratchdot/open-golang/open"
@@ -15,6 +23,16 @@ var (
 	uiContexts = []contexts.Context{contexts.Jobs, contexts.Allocs, contexts.Nodes}
 )
 
+const (
+	// defaultUITunnelAddr is the address the UI tunnel listens on. The port is
+	// chosen by the OS.
+	defaultUITunnelAddr = "127.0.0.1:0"
+
+	// uiTunnelFlushInterval is the interval at which the tunnel flushes the
+	// responses of the agent, which are streamed for logs and blocking queries.
+	uiTunnelFlushInterval = 100 * time.Millisecond
+)
+
 type UiCommand struct {
 	Meta
 }
@@ -27,15 +45,39 @@ Open the Nomad Web UI in the default browser. An optional identifier may be
 provided, in which case the UI will be opened to view the details for that
 object. Supported identifiers are jobs, allocations and nodes.
 
+With -tunnel, the UI is opened through a local proxy to the agent, which uses
+the address and the TLS configuration of the CLI. This allows reaching the UI
+of an agent whose HTTP port is only reachable with client certificates or
+from a jump host, without exposing it. The proxy runs until interrupted.
+
 General Options:
 
-  ` + generalOptionsUsage()
+  ` + generalOptionsUsage() + `
+
+UI Options:
+
+  -tunnel
+    Open the UI through a local proxy to the agent.
+
+  -tunnel-addr=<addr>
+    The address the proxy listens on. Defaults to a random port of 127.0.0.1.
+
+  -authenticate
+    Authenticate the requests of the UI with the ACL token of the CLI, as given
+    by -token, NOMAD_TOKEN or "nomad login". The token is added by the proxy
+    and never reaches the browser. Requires -tunnel.
+`
 
 	return strings.TrimSpace(helpText)
 }
 
 func (c *UiCommand) AutocompleteFlags() complete.Flags {
-	return c.Meta.AutocompleteFlags(FlagSetClient)
+	return mergeAutocompleteFlags(c.Meta.AutocompleteFlags(FlagSetClient),
+		complete.Flags{
+			"-tunnel":       complete.PredictNothing,
+			"-tunnel-addr":  complete.PredictAnything,
+			"-authenticate": complete.PredictNothing,
+		})
 }
 
 func (c *UiCommand) AutocompleteArgs() complete.Predictor {
@@ -73,13 +115,24 @@ func (c *UiCommand) Synopsis() string {
 }
 
 func (c *UiCommand) Run(args []string) int {
-	flags := c.Meta.FlagSet("deployment list", FlagSetClient)
+	var tunnel, authenticate bool
+	var tunnelAddr string
+
+	flags := c.Meta.FlagSet("ui", FlagSetClient)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&tunnel, "tunnel", false, "")
+	flags.StringVar(&tunnelAddr, "tunnel-addr", defaultUITunnelAddr, "")
+	flags.BoolVar(&authenticate, "authenticate", false, "")
 
 	if err := flags.Parse(args); err != nil {
 		return 1
 	}
 
+	if authenticate && !tunnel {
+		c.Ui.Error("-authenticate requires -tunnel")
+		return 1
+	}
+
 	// Check that we got no more than one argument
 	args = flags.Args()
 	if l := len(args); l > 1 {
@@ -156,13 +209,69 @@ func (c *UiCommand) Run(args []string) int {
 		}
 	}
 
-	c.Ui.Output(fmt.Sprintf("Opening URL %q", url.String()))
+	if !tunnel {
+		c.Ui.Output(fmt.Sprintf("Opening URL %q", url.String()))
+		if err := open.Start(url.String()); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error opening URL: %s", err))
+			return 1
+		}
+		return 0
+	}
+
+	// Only add the token of the CLI to the requests proxied by the tunnel if
+	// asked to
+	if !authenticate {
+		client.SetSecretID("")
+	}
+
+	ln, err := net.Listen("tcp", tunnelAddr)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error starting the UI tunnel: %s", err))
+		return 1
+	}
+	defer ln.Close()
+
+	srv := &http.Server{Handler: uiTunnelHandler(client)}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	url.Scheme = "http"
+	url.Host = ln.Addr().String()
+	url.User = nil
+	if url.Path == "" {
+		url.Path = "ui/"
+	}
+	c.Ui.Output(fmt.Sprintf("Tunneling the UI of %q at %q, press Ctrl-C to stop", client.Address(), url.String()))
 	if err := open.Start(url.String()); err != nil {
-		c.Ui.Error(fmt.Sprintf("Error opening URL: %s", err))
+		c.Ui.Warn(fmt.Sprintf("Error opening URL: %s", err))
+	}
+
+	// Serve the UI until interrupted
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+
+	select {
+	case <-signalCh:
+		return 0
+	case err := <-errCh:
+		c.Ui.Error(fmt.Sprintf("Error serving the UI tunnel: %s", err))
 		return 1
 	}
+}
 
-	return 0
+// uiTunnelHandler returns the handler of the UI tunnel, proxying the requests
+// to the agent of the client.
+func uiTunnelHandler(client *api.Client) http.Handler {
+	return &httputil.ReverseProxy{
+		// The client sends the requests to the agent, so they are passed
+		// through unchanged
+		Director:      func(*http.Request) {},
+		Transport:     client,
+		FlushInterval: uiTunnelFlushInterval,
+	}
 }
 
 // logMultiMatchError is used to log an error message when multiple matches are
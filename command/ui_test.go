@@ -0,0 +1,72 @@
+package command
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUiCommand_Implements(t *testing.T) {
+	t.Parallel()
+	var _ cli.Command = &UiCommand{}
+}
+
+func TestUiCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &UiCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"some", "bad", "args"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	if code := cmd.Run([]string{"-authenticate"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "-authenticate requires -tunnel") {
+		t.Fatalf("expected -tunnel error, got: %s", out)
+	}
+}
+
+func TestUiCommand_TunnelHandler(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.RequestURI() + " " + r.Header.Get("X-Nomad-Token")))
+	}))
+	defer agent.Close()
+
+	client, err := api.NewClient(&api.Config{Address: agent.URL, SecretID: "secret"})
+	require.NoError(err)
+
+	tunnel := httptest.NewServer(uiTunnelHandler(client))
+	defer tunnel.Close()
+
+	get := func(path string) string {
+		resp, err := http.Get(tunnel.URL + path)
+		require.NoError(err)
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(err)
+		return string(body)
+	}
+
+	// Requests are authenticated with the token of the CLI
+	require.Equal("/v1/jobs?prefix=ex secret", get("/v1/jobs?prefix=ex"))
+
+	// Without a token the requests are passed through unauthenticated
+	client.SetSecretID("")
+	require.Equal("/ui/jobs ", get("/ui/jobs"))
+}
@@ -1,16 +1,25 @@
 package command
 
 import (
+	"bytes"
 	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/command/agent"
+	flaghelper "github.com/hashicorp/nomad/helper/flag-helpers"
+	"github.com/hashicorp/nomad/jobspec"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/posener/complete"
 )
 
+// reNodeConstraintTarget matches the constraint targets referencing node
+// attributes or metadata.
+var reNodeConstraintTarget = regexp.MustCompile(`^\$\{(attr|meta)\.(.+)\}$`)
+
 type ValidateCommand struct {
 	Meta
 	JobGetter
@@ -26,6 +35,18 @@ Usage: nomad validate [options] <path>
   If the supplied path is "-", the jobfile is read from stdin. Otherwise
   it is read from the file at the supplied path or downloaded and
   read from URL specified.
+
+Validate Options:
+
+  -strict
+    Additionally fail the validation on deprecated fields, variables set
+    with -var that are not referenced by the job, driver configuration that
+    could not be validated by a Nomad agent and constraints on node
+    attributes or metadata that none of the current nodes have.
+
+  -var 'name=value'
+    Sets the value of a variable referenced by the job as "${var.name}". May
+    be specified multiple times.
 `
 	return strings.TrimSpace(helpText)
 }
@@ -35,7 +56,10 @@ func (c *ValidateCommand) Synopsis() string {
 }
 
 func (c *ValidateCommand) AutocompleteFlags() complete.Flags {
-	return nil
+	return complete.Flags{
+		"-strict": complete.PredictNothing,
+		"-var":    complete.PredictAnything,
+	}
 }
 
 func (c *ValidateCommand) AutocompleteArgs() complete.Predictor {
@@ -43,8 +67,13 @@ func (c *ValidateCommand) AutocompleteArgs() complete.Predictor {
 }
 
 func (c *ValidateCommand) Run(args []string) int {
+	var strict bool
+	var varArgs []string
+
 	flags := c.Meta.FlagSet("validate", FlagSetNone)
 	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&strict, "strict", false, "")
+	flags.Var((*flaghelper.StringFlag)(&varArgs), "var", "")
 	if err := flags.Parse(args); err != nil {
 		return 1
 	}
@@ -56,13 +85,35 @@ func (c *ValidateCommand) Run(args []string) int {
 		return 1
 	}
 
+	// Parse the variables
+	vars := make(map[string]string, len(varArgs))
+	for _, v := range varArgs {
+		parts := strings.SplitN(v, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			c.Ui.Error(fmt.Sprintf("Error parsing variable %q: must be of the form name=value", v))
+			return 1
+		}
+		vars[parts[0]] = parts[1]
+	}
+
 	// Get Job struct from Jobfile
-	job, err := c.JobGetter.ApiJob(args[0])
+	src, err := c.JobGetter.Source(args[0])
 	if err != nil {
 		c.Ui.Error(fmt.Sprintf("Error getting job struct: %s", err))
 		return 1
 	}
 
+	var job *api.Job
+	if strict || len(vars) != 0 {
+		job, err = jobspec.ParseWithVariables(bytes.NewReader(src), vars)
+	} else {
+		job, err = jobspec.Parse(bytes.NewReader(src))
+	}
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error getting job struct: Error parsing job file from %s: %v", args[0], err))
+		return 1
+	}
+
 	// Get the HTTP client
 	client, err := c.Meta.Client()
 	if err != nil {
@@ -85,7 +136,7 @@ func (c *ValidateCommand) Run(args []string) int {
 		return 1
 	}
 
-	if jr != nil && !jr.DriverConfigValidated {
+	if jr != nil && !jr.DriverConfigValidated && !strict {
 		c.Ui.Output(
 			c.Colorize().Color("[bold][yellow]Driver configuration not validated since connection to Nomad agent couldn't be established.[reset]\n"))
 	}
@@ -103,6 +154,17 @@ func (c *ValidateCommand) Run(args []string) int {
 			c.Colorize().Color(fmt.Sprintf("[bold][yellow]Job Warnings:\n%s[reset]\n", jr.Warnings)))
 	}
 
+	if strict {
+		if errs := c.validateStrict(client, src, vars, job, jr); len(errs) != 0 {
+			c.Ui.Error(
+				c.Colorize().Color("[bold][red]Strict validation errors:[reset]"))
+			for _, err := range errs {
+				c.Ui.Error(fmt.Sprintf("* %s", err))
+			}
+			return 1
+		}
+	}
+
 	// Done!
 	c.Ui.Output(
 		c.Colorize().Color("[bold][green]Job validation successful[reset]"))
@@ -132,3 +194,109 @@ func (c *ValidateCommand) validateLocal(aj *api.Job) (*api.JobValidateResponse,
 	out.Warnings = structs.MergeMultierrorWarnings(warnings, canonicalizeWarnings)
 	return &out, nil
 }
+
+// validateStrict returns the errors of the strict validation of the job
+// parsed from src.
+func (c *ValidateCommand) validateStrict(client *api.Client, src []byte, vars map[string]string,
+	job *api.Job, jr *api.JobValidateResponse) []string {
+
+	var errs []string
+
+	// Check for variables that aren't used by the job
+	referenced := make(map[string]struct{})
+	for _, name := range jobspec.Variables(src) {
+		referenced[name] = struct{}{}
+	}
+	var unused []string
+	for name := range vars {
+		if _, ok := referenced[name]; !ok {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	for _, name := range unused {
+		errs = append(errs, fmt.Sprintf("Variable %q is set but not referenced by the job", name))
+	}
+
+	// Check for deprecated fields
+	deprecations, err := jobspec.Deprecations(src)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("Error checking for deprecated fields: %v", err))
+	}
+	for _, d := range deprecations {
+		errs = append(errs, fmt.Sprintf("%s (run \"nomad job upgrade-spec\" to upgrade the job)", d))
+	}
+
+	// Unknown driver configuration fields are only detected by the agent
+	if !jr.DriverConfigValidated {
+		errs = append(errs, "Driver configuration not validated since connection to Nomad agent couldn't be established")
+		return errs
+	}
+
+	missing, err := missingConstraintTargets(client, job)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("Error checking constraint targets against the nodes: %v", err))
+	}
+	for _, target := range missing {
+		errs = append(errs, fmt.Sprintf("Constraint target %q is not set on any node", target))
+	}
+	return errs
+}
+
+// missingConstraintTargets returns the node attributes and metadata targeted
+// by the constraints of the job that none of the nodes have.
+func missingConstraintTargets(client *api.Client, job *api.Job) ([]string, error) {
+	constraints := append([]*api.Constraint{}, job.Constraints...)
+	for _, tg := range job.TaskGroups {
+		constraints = append(constraints, tg.Constraints...)
+		for _, task := range tg.Tasks {
+			constraints = append(constraints, task.Constraints...)
+		}
+	}
+
+	var targets []string
+	seen := make(map[string]struct{})
+	for _, c := range constraints {
+		if c == nil || !reNodeConstraintTarget.MatchString(c.LTarget) {
+			continue
+		}
+		if _, ok := seen[c.LTarget]; !ok {
+			seen[c.LTarget] = struct{}{}
+			targets = append(targets, c.LTarget)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	stubs, _, err := client.Nodes().List(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]struct{})
+	for _, stub := range stubs {
+		node, _, err := client.Nodes().Info(stub.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range targets {
+			m := reNodeConstraintTarget.FindStringSubmatch(target)
+			values := node.Attributes
+			if m[1] == "meta" {
+				values = node.Meta
+			}
+			if _, ok := values[m[2]]; ok {
+				found[target] = struct{}{}
+			}
+		}
+	}
+
+	var missing []string
+	for _, target := range targets {
+		if _, ok := found[target]; !ok {
+			missing = append(missing, target)
+		}
+	}
+	return missing, nil
+}
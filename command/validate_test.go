@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -177,3 +178,111 @@ func TestValidateCommand_From_URL(t *testing.T) {
 		t.Fatalf("expected error getting jobfile, got: %s", out)
 	}
 }
+
+func TestValidateCommand_Strict(t *testing.T) {
+	t.Parallel()
+	srv, client, url := testServer(t, true, nil)
+	defer srv.Shutdown()
+
+	// Wait for the node to be ready
+	testutil.WaitForResult(func() (bool, error) {
+		nodes, _, err := client.Nodes().List(nil)
+		if err != nil {
+			return false, err
+		}
+		if len(nodes) == 0 {
+			return false, fmt.Errorf("missing node")
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %s", err)
+	})
+
+	dir, err := ioutil.TempDir("", "nomad")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeJob := func(name, src string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return path
+	}
+
+	valid := writeJob("valid.nomad", `
+job "job1" {
+	datacenters = ["dc1"]
+	constraint {
+		attribute = "${attr.kernel.name}"
+		value = "linux"
+	}
+	group "group1" {
+		task "task1" {
+			driver = "mock_driver"
+			config {
+				run_for = "${var.run_for}"
+			}
+		}
+	}
+}`)
+	invalid := writeJob("invalid.nomad", `
+job "job1" {
+	datacenters = ["dc1"]
+	update {
+		stagger = "10s"
+	}
+	constraint {
+		attribute = "${attr.kernal.name}"
+		value = "linux"
+	}
+	group "group1" {
+		task "task1" {
+			driver = "mock_driver"
+			config {
+				run_for = "1s"
+			}
+		}
+	}
+}`)
+
+	ui := new(cli.MockUi)
+	cmd := &ValidateCommand{Meta: Meta{Ui: ui, flagAddress: url}}
+	if code := cmd.Run([]string{"-strict", "-var", "run_for=1s", valid}); code != 0 {
+		t.Fatalf("expect exit 0, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on unset variables
+	if code := cmd.Run([]string{"-strict", valid}); code != 1 {
+		t.Fatalf("expect exit 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, `variable "run_for" is not set`) {
+		t.Fatalf("expect unset variable error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on unused variables, deprecated fields and missing attributes
+	if code := cmd.Run([]string{"-strict", "-var", "foo=bar", invalid}); code != 1 {
+		t.Fatalf("expect exit 1, got: %d", code)
+	}
+	out := ui.ErrorWriter.String()
+	for _, expected := range []string{
+		"Strict validation errors",
+		`Variable "foo" is set but not referenced by the job`,
+		"line 5: stagger is deprecated",
+		`Constraint target "${attr.kernal.name}" is not set on any node`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expect %q, got: %s", expected, out)
+		}
+	}
+	ui.ErrorWriter.Reset()
+
+	// Passes without -strict
+	if code := cmd.Run([]string{invalid}); code != 0 {
+		t.Fatalf("expect exit 0, got: %d: %s", code, ui.ErrorWriter.String())
+	}
+}
@@ -0,0 +1,72 @@
+package command
+
+import (
+	"strings"
+
+	"github.com/hashicorp/nomad/api"
+	"github.com/mitchellh/cli"
+	"github.com/posener/complete"
+)
+
+type VarCommand struct {
+	Meta
+}
+
+func (c *VarCommand) Help() string {
+	helpText := `
+Usage: nomad var <subcommand> [options] [args]
+
+  This command groups subcommands for interacting with variables. Variables
+  are sets of secret items stored at a path of a namespace. They are encrypted
+  by the servers and can be read by the tasks of the jobs they belong to.
+
+  The tasks of a job can read the variables at the paths "nomad/jobs/<job>",
+  "nomad/jobs/<job>/<group>" and "nomad/jobs/<job>/<group>/<task>". Their items
+  are set in the environment of the task as NOMAD_VAR_<key> and can be used by
+  templates with the env function.
+
+  Create or update a variable:
+
+      $ nomad var put nomad/jobs/example password=hunter2
+
+  Read a variable:
+
+      $ nomad var get nomad/jobs/example
+
+  List the variables under a prefix:
+
+      $ nomad var list nomad/jobs
+
+  Please see the individual subcommand help for detailed usage information.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *VarCommand) Synopsis() string {
+	return "Interact with variables"
+}
+
+func (c *VarCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+// VariablePathPredictor returns a predictor of the paths of the variables
+func VariablePathPredictor(factory ApiClientFactory) complete.Predictor {
+	return complete.PredictFunc(func(a complete.Args) []string {
+		client, err := factory()
+		if err != nil {
+			return nil
+		}
+
+		vars, _, err := client.Variables().List(&api.QueryOptions{Prefix: a.Last})
+		if err != nil {
+			return []string{}
+		}
+
+		paths := make([]string, len(vars))
+		for i, v := range vars {
+			paths[i] = v.Path
+		}
+		return paths
+	})
+}
@@ -0,0 +1,94 @@
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarCommands(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	srv, _, url := testServer(t, false, nil)
+	defer srv.Shutdown()
+
+	ui := new(cli.MockUi)
+	meta := Meta{Ui: ui}
+
+	// Put a variable
+	put := &VarPutCommand{Meta: meta}
+	code := put.Run([]string{"-address=" + url, "nomad/jobs/web", "user=web", "password=a=b"})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Contains(ui.OutputWriter.String(), `Successfully wrote variable "nomad/jobs/web"`)
+	ui.OutputWriter.Reset()
+
+	// Get its items
+	get := &VarGetCommand{Meta: meta}
+	code = get.Run([]string{"-address=" + url, "nomad/jobs/web"})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	out := ui.OutputWriter.String()
+	require.Contains(out, "nomad/jobs/web")
+	require.Contains(out, "password")
+	require.Contains(out, "a=b")
+	ui.OutputWriter.Reset()
+
+	code = get.Run([]string{"-address=" + url, "-item=password", "nomad/jobs/web"})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Equal("a=b\n", ui.OutputWriter.String())
+	ui.OutputWriter.Reset()
+
+	// List the variables by prefix
+	list := &VarListCommand{Meta: meta}
+	code = list.Run([]string{"-address=" + url, "nomad/"})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Contains(ui.OutputWriter.String(), "nomad/jobs/web")
+	ui.OutputWriter.Reset()
+
+	code = list.Run([]string{"-address=" + url, "other/"})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Contains(ui.OutputWriter.String(), "No variables found")
+	ui.OutputWriter.Reset()
+
+	// Delete the variable
+	del := &VarDeleteCommand{Meta: meta}
+	code = del.Run([]string{"-address=" + url, "nomad/jobs/web"})
+	require.Equal(0, code, ui.ErrorWriter.String())
+
+	code = get.Run([]string{"-address=" + url, "nomad/jobs/web"})
+	require.Equal(1, code)
+	require.Contains(ui.ErrorWriter.String(), "Error reading variable")
+}
+
+func TestVarPutCommand_Fails(t *testing.T) {
+	t.Parallel()
+	ui := new(cli.MockUi)
+	cmd := &VarPutCommand{Meta: Meta{Ui: ui}}
+
+	// Fails on misuse
+	if code := cmd.Run([]string{"nomad/jobs/web"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, cmd.Help()) {
+		t.Fatalf("expected help output, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on invalid items
+	if code := cmd.Run([]string{"nomad/jobs/web", "password"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Invalid item") {
+		t.Fatalf("expected invalid item error, got: %s", out)
+	}
+	ui.ErrorWriter.Reset()
+
+	// Fails on connection failure
+	if code := cmd.Run([]string{"-address=nope", "nomad/jobs/web", "a=b"}); code != 1 {
+		t.Fatalf("expected exit code 1, got: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "Error writing variable") {
+		t.Fatalf("expected failed write error, got: %s", out)
+	}
+}
@@ -91,6 +91,31 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"alloc": func() (cli.Command, error) {
+			return &command.AllocCommand{
+				Meta: meta,
+			}, nil
+		},
+		"alloc checks": func() (cli.Command, error) {
+			return &command.AllocChecksCommand{
+				Meta: meta,
+			}, nil
+		},
+		"alloc fs": func() (cli.Command, error) {
+			return &command.FSCommand{
+				Meta: meta,
+			}, nil
+		},
+		"alloc history": func() (cli.Command, error) {
+			return &command.AllocHistoryCommand{
+				Meta: meta,
+			}, nil
+		},
+		"alloc stop": func() (cli.Command, error) {
+			return &command.AllocStopCommand{
+				Meta: meta,
+			}, nil
+		},
 		"alloc-status": func() (cli.Command, error) {
 			return &command.AllocStatusCommand{
 				Meta: meta,
@@ -153,6 +178,26 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"deployment watch": func() (cli.Command, error) {
+			return &command.DeploymentWatchCommand{
+				Meta: meta,
+			}, nil
+		},
+		"eval": func() (cli.Command, error) {
+			return &command.EvalCommand{
+				Meta: meta,
+			}, nil
+		},
+		"eval delete": func() (cli.Command, error) {
+			return &command.EvalDeleteCommand{
+				Meta: meta,
+			}, nil
+		},
+		"eval list": func() (cli.Command, error) {
+			return &command.EvalListCommand{
+				Meta: meta,
+			}, nil
+		},
 		"eval-status": func() (cli.Command, error) {
 			return &command.EvalStatusCommand{
 				Meta: meta,
@@ -163,6 +208,11 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"fmt": func() (cli.Command, error) {
+			return &command.FmtCommand{
+				Meta: meta,
+			}, nil
+		},
 		"fs": func() (cli.Command, error) {
 			return &command.FSCommand{
 				Meta: meta,
@@ -203,6 +253,11 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"job eval": func() (cli.Command, error) {
+			return &command.JobEvalCommand{
+				Meta: meta,
+			}, nil
+		},
 		"job history": func() (cli.Command, error) {
 			return &command.JobHistoryCommand{
 				Meta: meta,
@@ -213,6 +268,11 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"job restart": func() (cli.Command, error) {
+			return &command.JobRestartCommand{
+				Meta: meta,
+			}, nil
+		},
 		"job revert": func() (cli.Command, error) {
 			return &command.JobRevertCommand{
 				Meta: meta,
@@ -223,6 +283,21 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"job upgrade-spec": func() (cli.Command, error) {
+			return &command.JobUpgradeSpecCommand{
+				Meta: meta,
+			}, nil
+		},
+		"job validate": func() (cli.Command, error) {
+			return &command.ValidateCommand{
+				Meta: meta,
+			}, nil
+		},
+		"login": func() (cli.Command, error) {
+			return &command.LoginCommand{
+				Meta: meta,
+			}, nil
+		},
 		"logs": func() (cli.Command, error) {
 			return &command.LogsCommand{
 				Meta: meta,
@@ -258,6 +333,26 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"node": func() (cli.Command, error) {
+			return &command.NodeCommand{
+				Meta: meta,
+			}, nil
+		},
+		"node meta": func() (cli.Command, error) {
+			return &command.NodeMetaCommand{
+				Meta: meta,
+			}, nil
+		},
+		"node meta apply": func() (cli.Command, error) {
+			return &command.NodeMetaApplyCommand{
+				Meta: meta,
+			}, nil
+		},
+		"node meta read": func() (cli.Command, error) {
+			return &command.NodeMetaReadCommand{
+				Meta: meta,
+			}, nil
+		},
 		"node-drain": func() (cli.Command, error) {
 			return &command.NodeDrainCommand{
 				Meta: meta,
@@ -293,6 +388,48 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"operator inventory": func() (cli.Command, error) {
+			return &command.OperatorInventoryCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"operator inventory export": func() (cli.Command, error) {
+			return &command.OperatorInventoryExportCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"operator quarantine": func() (cli.Command, error) {
+			return &command.OperatorQuarantineCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"operator quarantine add": func() (cli.Command, error) {
+			return &command.OperatorQuarantineAddCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"operator quarantine list": func() (cli.Command, error) {
+			return &command.OperatorQuarantineListCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"operator quarantine remove": func() (cli.Command, error) {
+			return &command.OperatorQuarantineRemoveCommand{
+				Meta: meta,
+			}, nil
+		},
+
+		"operator query": func() (cli.Command, error) {
+			return &command.OperatorQueryCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"operator raft": func() (cli.Command, error) {
 			return &command.OperatorRaftCommand{
 				Meta: meta,
@@ -359,6 +496,12 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 			}, nil
 		},
 
+		"quota validate": func() (cli.Command, error) {
+			return &command.QuotaValidateCommand{
+				Meta: meta,
+			}, nil
+		},
+
 		"run": func() (cli.Command, error) {
 			return &command.RunCommand{
 				Meta: meta,
@@ -379,6 +522,11 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"sentinel enforce": func() (cli.Command, error) {
+			return &command.SentinelEnforceCommand{
+				Meta: meta,
+			}, nil
+		},
 		"sentinel delete": func() (cli.Command, error) {
 			return &command.SentinelDeleteCommand{
 				Meta: meta,
@@ -389,6 +537,11 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"sentinel test": func() (cli.Command, error) {
+			return &command.SentinelTestCommand{
+				Meta: meta,
+			}, nil
+		},
 		"server-force-leave": func() (cli.Command, error) {
 			return &command.ServerForceLeaveCommand{
 				Meta: meta,
@@ -414,6 +567,16 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"system": func() (cli.Command, error) {
+			return &command.SystemCommand{
+				Meta: meta,
+			}, nil
+		},
+		"system gc": func() (cli.Command, error) {
+			return &command.SystemGCCommand{
+				Meta: meta,
+			}, nil
+		},
 		"ui": func() (cli.Command, error) {
 			return &command.UiCommand{
 				Meta: meta,
@@ -424,6 +587,31 @@ func Commands(metaPtr *command.Meta) map[string]cli.CommandFactory {
 				Meta: meta,
 			}, nil
 		},
+		"var": func() (cli.Command, error) {
+			return &command.VarCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var delete": func() (cli.Command, error) {
+			return &command.VarDeleteCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var get": func() (cli.Command, error) {
+			return &command.VarGetCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var list": func() (cli.Command, error) {
+			return &command.VarListCommand{
+				Meta: meta,
+			}, nil
+		},
+		"var put": func() (cli.Command, error) {
+			return &command.VarPutCommand{
+				Meta: meta,
+			}, nil
+		},
 		"version": func() (cli.Command, error) {
 			return &command.VersionCommand{
 				Version: version.GetVersion(),
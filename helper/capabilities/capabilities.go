@@ -0,0 +1,229 @@
+// Package capabilities reports the Linux capabilities of the Nomad process so
+// the agent can run with the minimal set of privileges its features require
+// rather than as root.
+package capabilities
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Cap is a Linux capability number.
+type Cap uint
+
+// The Linux capabilities. The values are those of linux/capability.h.
+const (
+	Chown             Cap = 0
+	DACOverride       Cap = 1
+	DACReadSearch     Cap = 2
+	Fowner            Cap = 3
+	Fsetid            Cap = 4
+	Kill              Cap = 5
+	Setgid            Cap = 6
+	Setuid            Cap = 7
+	Setpcap           Cap = 8
+	LinuxImmutable    Cap = 9
+	NetBindService    Cap = 10
+	NetBroadcast      Cap = 11
+	NetAdmin          Cap = 12
+	NetRaw            Cap = 13
+	IPCLock           Cap = 14
+	IPCOwner          Cap = 15
+	SysModule         Cap = 16
+	SysRawio          Cap = 17
+	SysChroot         Cap = 18
+	SysPtrace         Cap = 19
+	SysPacct          Cap = 20
+	SysAdmin          Cap = 21
+	SysBoot           Cap = 22
+	SysNice           Cap = 23
+	SysResource       Cap = 24
+	SysTime           Cap = 25
+	SysTTYConfig      Cap = 26
+	Mknod             Cap = 27
+	Lease             Cap = 28
+	AuditWrite        Cap = 29
+	AuditControl      Cap = 30
+	Setfcap           Cap = 31
+	MacOverride       Cap = 32
+	MacAdmin          Cap = 33
+	Syslog            Cap = 34
+	WakeAlarm         Cap = 35
+	BlockSuspend      Cap = 36
+	AuditRead         Cap = 37
+	Perfmon           Cap = 38
+	BPF               Cap = 39
+	CheckpointRestore Cap = 40
+)
+
+var capNames = map[Cap]string{
+	Chown:             "CAP_CHOWN",
+	DACOverride:       "CAP_DAC_OVERRIDE",
+	DACReadSearch:     "CAP_DAC_READ_SEARCH",
+	Fowner:            "CAP_FOWNER",
+	Fsetid:            "CAP_FSETID",
+	Kill:              "CAP_KILL",
+	Setgid:            "CAP_SETGID",
+	Setuid:            "CAP_SETUID",
+	Setpcap:           "CAP_SETPCAP",
+	LinuxImmutable:    "CAP_LINUX_IMMUTABLE",
+	NetBindService:    "CAP_NET_BIND_SERVICE",
+	NetBroadcast:      "CAP_NET_BROADCAST",
+	NetAdmin:          "CAP_NET_ADMIN",
+	NetRaw:            "CAP_NET_RAW",
+	IPCLock:           "CAP_IPC_LOCK",
+	IPCOwner:          "CAP_IPC_OWNER",
+	SysModule:         "CAP_SYS_MODULE",
+	SysRawio:          "CAP_SYS_RAWIO",
+	SysChroot:         "CAP_SYS_CHROOT",
+	SysPtrace:         "CAP_SYS_PTRACE",
+	SysPacct:          "CAP_SYS_PACCT",
+	SysAdmin:          "CAP_SYS_ADMIN",
+	SysBoot:           "CAP_SYS_BOOT",
+	SysNice:           "CAP_SYS_NICE",
+	SysResource:       "CAP_SYS_RESOURCE",
+	SysTime:           "CAP_SYS_TIME",
+	SysTTYConfig:      "CAP_SYS_TTY_CONFIG",
+	Mknod:             "CAP_MKNOD",
+	Lease:             "CAP_LEASE",
+	AuditWrite:        "CAP_AUDIT_WRITE",
+	AuditControl:      "CAP_AUDIT_CONTROL",
+	Setfcap:           "CAP_SETFCAP",
+	MacOverride:       "CAP_MAC_OVERRIDE",
+	MacAdmin:          "CAP_MAC_ADMIN",
+	Syslog:            "CAP_SYSLOG",
+	WakeAlarm:         "CAP_WAKE_ALARM",
+	BlockSuspend:      "CAP_BLOCK_SUSPEND",
+	AuditRead:         "CAP_AUDIT_READ",
+	Perfmon:           "CAP_PERFMON",
+	BPF:               "CAP_BPF",
+	CheckpointRestore: "CAP_CHECKPOINT_RESTORE",
+}
+
+// String returns the name of the capability as used by capabilities(7).
+func (c Cap) String() string {
+	if name, ok := capNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("CAP_%d", uint(c))
+}
+
+// Parse returns the capability of the given name. The name is case
+// insensitive and the CAP_ prefix is optional.
+func Parse(name string) (Cap, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(name))
+	if !strings.HasPrefix(normalized, "CAP_") {
+		normalized = "CAP_" + normalized
+	}
+	for c, n := range capNames {
+		if n == normalized {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown capability %q", name)
+}
+
+// ParseNames returns the set of the named capabilities. The name "ALL" stands
+// for every capability.
+func ParseNames(names []string) (Set, error) {
+	var s Set
+	for _, name := range names {
+		if strings.EqualFold(strings.TrimSpace(name), "all") {
+			s = All
+			continue
+		}
+		c, err := Parse(name)
+		if err != nil {
+			return 0, err
+		}
+		s |= NewSet(c)
+	}
+	return s, nil
+}
+
+// Set is a set of capabilities as a bit mask.
+type Set uint64
+
+// All is the set of every capability.
+const All Set = ^Set(0)
+
+// Known is the set of the capabilities named by this package.
+var Known = func() Set {
+	var s Set
+	for c := range capNames {
+		s |= NewSet(c)
+	}
+	return s
+}()
+
+// NewSet returns the set of the given capabilities.
+func NewSet(caps ...Cap) Set {
+	var s Set
+	for _, c := range caps {
+		s |= 1 << c
+	}
+	return s
+}
+
+// ParseSet parses a capability mask as found in /proc/<pid>/status.
+func ParseSet(mask string) (Set, error) {
+	v, err := strconv.ParseUint(strings.TrimSpace(mask), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid capability mask %q: %v", mask, err)
+	}
+	return Set(v), nil
+}
+
+// Has returns whether all of the capabilities are in the set.
+func (s Set) Has(caps ...Cap) bool {
+	required := NewSet(caps...)
+	return s&required == required
+}
+
+// Missing returns the capabilities of the required set that are not in s.
+func (s Set) Missing(required Set) []Cap {
+	var missing []Cap
+	for c := Cap(0); c < 64; c++ {
+		if required&(1<<c) != 0 && s&(1<<c) == 0 {
+			missing = append(missing, c)
+		}
+	}
+	return missing
+}
+
+// String returns the sorted names of the capabilities in the set.
+func (s Set) String() string {
+	if s == 0 {
+		return "none"
+	}
+	var names []string
+	for c := Cap(0); c < 64; c++ {
+		if s&(1<<c) != 0 {
+			names = append(names, c.String())
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// State is the capability state of a process.
+type State struct {
+	// Effective is the set of capabilities the process can use
+	Effective Set
+
+	// Ambient is the set of capabilities preserved across the execve of
+	// unprivileged programs, such as the executor and task processes
+	Ambient Set
+}
+
+// Has returns whether the current process has all of the capabilities in its
+// effective set. If the capabilities can not be determined it returns false.
+func Has(caps ...Cap) bool {
+	state, err := Current()
+	if err != nil {
+		return false
+	}
+	return state.Effective.Has(caps...)
+}
@@ -0,0 +1,56 @@
+package capabilities
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Current returns the capability state of the current process.
+func Current() (*State, error) {
+	f, err := os.Open("/proc/self/status")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseStatus(f)
+}
+
+// parseStatus parses the capability sets out of /proc/<pid>/status.
+func parseStatus(r io.Reader) (*State, error) {
+	var state State
+	var found bool
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		var dst *Set
+		switch parts[0] {
+		case "CapEff":
+			dst = &state.Effective
+			found = true
+		case "CapAmb":
+			dst = &state.Ambient
+		default:
+			continue
+		}
+
+		set, err := ParseSet(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		*dst = set
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("no effective capabilities found in process status")
+	}
+	return &state, nil
+}
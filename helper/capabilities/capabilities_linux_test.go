@@ -0,0 +1,35 @@
+package capabilities
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStatus(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	status := `Name:	nomad
+Uid:	1000	1000	1000	1000
+CapInh:	0000000000000000
+CapPrm:	00000000002400c3
+CapEff:	00000000002400c3
+CapBnd:	0000003fffffffff
+CapAmb:	00000000000000c0
+`
+	state, err := parseStatus(strings.NewReader(status))
+	assert.Nil(err)
+	assert.True(state.Effective.Has(Chown, DACOverride, Setgid, Setuid, SysChroot, SysAdmin))
+	assert.False(state.Effective.Has(Kill))
+	assert.Equal(NewSet(Setgid, Setuid), state.Ambient)
+
+	// The effective set is required
+	_, err = parseStatus(strings.NewReader("Name:	nomad\n"))
+	assert.NotNil(err)
+
+	// Masks must be hex
+	_, err = parseStatus(strings.NewReader("CapEff:	foo\n"))
+	assert.NotNil(err)
+}
@@ -0,0 +1,35 @@
+package capabilities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet_Missing(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	s := NewSet(Chown, Setuid)
+	assert.Equal([]Cap{Setgid, SysAdmin}, s.Missing(NewSet(Chown, Setgid, SysAdmin)))
+	assert.Nil(s.Missing(NewSet(Chown)))
+	assert.Equal("CAP_CHOWN, CAP_SETUID", s.String())
+	assert.Equal("none", Set(0).String())
+}
+
+func TestParseNames(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	s, err := ParseNames([]string{"CAP_CHOWN", "net_bind_service", " Kill "})
+	require.NoError(err)
+	require.Equal(NewSet(Chown, NetBindService, Kill), s)
+
+	s, err = ParseNames([]string{"chown", "ALL"})
+	require.NoError(err)
+	require.Equal(All, s)
+
+	_, err = ParseNames([]string{"CAP_FLY"})
+	require.Error(err)
+}
@@ -0,0 +1,15 @@
+// Package cgroupv2 detects the cgroup v2 unified hierarchy, so that the
+// fingerprinters and the executor agree on the cgroup mode of the host.
+package cgroupv2
+
+const (
+	// UnifiedMountpoint is where the cgroup v2 unified hierarchy is mounted
+	// by systemd.
+	UnifiedMountpoint = "/sys/fs/cgroup"
+)
+
+// IsUnifiedMode returns whether the host only mounts the cgroup v2 unified
+// hierarchy.
+func IsUnifiedMode() bool {
+	return IsMount(UnifiedMountpoint)
+}
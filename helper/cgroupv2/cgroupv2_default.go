@@ -0,0 +1,9 @@
+// +build !linux
+
+package cgroupv2
+
+// IsMount returns whether a cgroup v2 hierarchy is mounted at the path.
+// cgroups are only supported on Linux.
+func IsMount(path string) bool {
+	return false
+}
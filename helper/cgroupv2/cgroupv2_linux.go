@@ -0,0 +1,15 @@
+package cgroupv2
+
+import "syscall"
+
+// superMagic is the filesystem magic of a cgroup v2 mount.
+const superMagic = 0x63677270
+
+// IsMount returns whether a cgroup v2 hierarchy is mounted at the path.
+func IsMount(path string) bool {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return false
+	}
+	return st.Type == superMagic
+}
@@ -0,0 +1,22 @@
+package cgroupv2
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestIsMount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cgroupv2")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if IsMount(dir) {
+		t.Fatalf("%q is not a cgroup v2 mount", dir)
+	}
+	if IsMount(dir + "/missing") {
+		t.Fatalf("missing paths are not cgroup v2 mounts")
+	}
+}
@@ -0,0 +1,171 @@
+// Package filter implements the filter expressions used to select the objects
+// of bulk operations, such as:
+//
+//	Status == "failed" and JobID contains "batch-"
+//
+// An expression is a set of terms joined by "and". Each term compares an
+// exported field of the object, the selector, against a value using "==",
+// "!=" or "contains". Values may be quoted and are compared against the
+// string representation of the field.
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	OpEqual    = "=="
+	OpNotEqual = "!="
+	OpContains = "contains"
+)
+
+// Filter is a parsed filter expression.
+type Filter struct {
+	terms []*term
+}
+
+type term struct {
+	selector string
+	op       string
+	value    string
+}
+
+// Parse parses the filter expression. The empty expression matches all
+// objects.
+func Parse(expr string) (*Filter, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Filter{}
+	for i := 0; i < len(tokens); {
+		if len(f.terms) != 0 {
+			if !strings.EqualFold(tokens[i], "and") {
+				return nil, fmt.Errorf("expected \"and\" but found %q", tokens[i])
+			}
+			i++
+		}
+
+		if len(tokens)-i < 3 {
+			return nil, fmt.Errorf("incomplete term %q", strings.Join(tokens[i:], " "))
+		}
+		t := &term{
+			selector: tokens[i],
+			op:       tokens[i+1],
+			value:    tokens[i+2],
+		}
+		switch t.op {
+		case OpEqual, OpNotEqual, OpContains:
+		default:
+			return nil, fmt.Errorf("invalid operator %q in term for %q", t.op, t.selector)
+		}
+		f.terms = append(f.terms, t)
+		i += 3
+	}
+	return f, nil
+}
+
+// Validate checks that the selectors of the filter are fields of obj.
+func (f *Filter) Validate(obj interface{}) error {
+	for _, t := range f.terms {
+		if _, err := field(obj, t.selector); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match returns whether obj matches all the terms of the filter.
+func (f *Filter) Match(obj interface{}) (bool, error) {
+	for _, t := range f.terms {
+		v, err := field(obj, t.selector)
+		if err != nil {
+			return false, err
+		}
+
+		var match bool
+		switch t.op {
+		case OpEqual:
+			match = v == t.value
+		case OpNotEqual:
+			match = v != t.value
+		case OpContains:
+			match = strings.Contains(v, t.value)
+		}
+		if !match {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// field returns the string representation of the exported field of obj.
+func field(obj interface{}, selector string) (string, error) {
+	v := reflect.Indirect(reflect.ValueOf(obj))
+	if v.Kind() != reflect.Struct {
+		return "", fmt.Errorf("can't filter objects of type %T", obj)
+	}
+
+	if selector == "" || !unicode.IsUpper([]rune(selector)[0]) {
+		return "", fmt.Errorf("unknown selector %q", selector)
+	}
+	fv := v.FieldByName(selector)
+	if !fv.IsValid() {
+		return "", fmt.Errorf("unknown selector %q", selector)
+	}
+
+	switch fv.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprint(fv.Interface()), nil
+	default:
+		return "", fmt.Errorf("selector %q can't be filtered on", selector)
+	}
+}
+
+// tokenize splits the expression into its words, operators and quoted
+// strings.
+func tokenize(expr string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		switch c := expr[i]; {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '"':
+			end := i + 1
+			for ; end < len(expr) && expr[end] != '"'; end++ {
+				if expr[end] == '\\' {
+					end++
+				}
+			}
+			if end >= len(expr) {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			s, err := strconv.Unquote(expr[i : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at offset %d: %v", i, err)
+			}
+			tokens = append(tokens, s)
+			i = end + 1
+		case c == '=' || c == '!':
+			if i+1 >= len(expr) || expr[i+1] != '=' {
+				return nil, fmt.Errorf("invalid operator at offset %d", i)
+			}
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		default:
+			end := i
+			for ; end < len(expr) && !strings.ContainsRune(" \t\n\"=!", rune(expr[end])); end++ {
+			}
+			tokens = append(tokens, expr[i:end])
+			i = end
+		}
+	}
+	return tokens, nil
+}
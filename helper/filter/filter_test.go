@@ -0,0 +1,84 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+)
+
+type testObject struct {
+	ID       string
+	Status   string
+	Priority int
+	Stop     bool
+	Meta     map[string]string
+	internal string
+}
+
+func TestFilter_Match(t *testing.T) {
+	obj := &testObject{
+		ID:       "batch-1234",
+		Status:   "failed",
+		Priority: 50,
+		Stop:     true,
+	}
+
+	cases := []struct {
+		expr  string
+		match bool
+	}{
+		{``, true},
+		{`Status == "failed"`, true},
+		{`Status == failed`, true},
+		{`Status != "failed"`, false},
+		{`Status=="failed"`, true},
+		{`ID contains "batch-"`, true},
+		{`ID contains "service-"`, false},
+		{`Priority == 50 and Stop == true`, true},
+		{`Status == "failed" AND Priority == 70`, false},
+	}
+
+	for _, c := range cases {
+		f, err := Parse(c.expr)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", c.expr, err)
+		}
+		match, err := f.Match(obj)
+		if err != nil {
+			t.Fatalf("failed to match %q: %v", c.expr, err)
+		}
+		if match != c.match {
+			t.Errorf("expected %q to match %v", c.expr, c.match)
+		}
+	}
+}
+
+func TestFilter_Invalid(t *testing.T) {
+	cases := []struct {
+		expr string
+		err  string
+	}{
+		{`Status = "failed"`, "invalid operator"},
+		{`Status < "failed"`, "invalid operator"},
+		{`Status ==`, "incomplete term"},
+		{`Status == "failed`, "unterminated string"},
+		{`Status == "failed" or ID == "a"`, `expected "and"`},
+	}
+
+	for _, c := range cases {
+		_, err := Parse(c.expr)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("expected error containing %q parsing %q, got: %v", c.err, c.expr, err)
+		}
+	}
+
+	// Selectors must be comparable exported fields
+	for _, selector := range []string{"Missing", "Meta", "internal"} {
+		f, err := Parse(selector + ` == "a"`)
+		if err != nil {
+			t.Fatalf("failed to parse: %v", err)
+		}
+		if err := f.Validate(&testObject{}); err == nil {
+			t.Errorf("expected selector %q to be invalid", selector)
+		}
+	}
+}
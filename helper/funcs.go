@@ -3,13 +3,38 @@ package helper
 import (
 	"crypto/sha512"
 	"fmt"
+	"net"
 	"regexp"
+	"strings"
 	"time"
 
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl/hcl/ast"
 )
 
+// NormalizeIP returns the IP address in its canonical text form, as
+// described by RFC 5952 for IPv6 addresses, so that equal addresses compare
+// equal. Brackets around the address are removed. Strings that are not IP
+// addresses are returned unchanged.
+func NormalizeIP(s string) string {
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(s, "["), "]"))
+	if ip == nil {
+		return s
+	}
+	return ip.String()
+}
+
+// NormalizeAddr returns the host:port address with its host normalized by
+// NormalizeIP. IPv6 hosts are bracketed. Addresses without a port are
+// normalized as hosts.
+func NormalizeAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return NormalizeIP(addr)
+	}
+	return net.JoinHostPort(NormalizeIP(host), port)
+}
+
 // validUUID is used to check if a given string looks like a UUID
 var validUUID = regexp.MustCompile(`(?i)^[\da-f]{8}-[\da-f]{4}-[\da-f]{4}-[\da-f]{4}-[\da-f]{12}$`)
 
@@ -275,6 +300,8 @@ func CleanEnvVar(s string, r byte) string {
 	return string(b)
 }
 
+// CheckHCLKeys returns an error for each key of the node that is not valid.
+// The errors include the position of the keys in the source when known.
 func CheckHCLKeys(node ast.Node, valid []string) error {
 	var list *ast.ObjectList
 	switch n := node.(type) {
@@ -295,8 +322,13 @@ func CheckHCLKeys(node ast.Node, valid []string) error {
 	for _, item := range list.Items {
 		key := item.Keys[0].Token.Value().(string)
 		if _, ok := validMap[key]; !ok {
-			result = multierror.Append(result, fmt.Errorf(
-				"invalid key: %s", key))
+			if pos := item.Pos(); pos.IsValid() {
+				result = multierror.Append(result, fmt.Errorf(
+					"invalid key: %s (line %d, column %d)", key, pos.Line, pos.Column))
+			} else {
+				result = multierror.Append(result, fmt.Errorf(
+					"invalid key: %s", key))
+			}
 		}
 	}
 
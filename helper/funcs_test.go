@@ -77,6 +77,38 @@ func TestClearEnvVar(t *testing.T) {
 	}
 }
 
+func TestNormalizeIP(t *testing.T) {
+	cases := map[string]string{
+		"2001:DB8:0:0:0:0:0:1": "2001:db8::1",
+		"[2001:db8:0::1]":      "2001:db8::1",
+		"2001:0db8::0001":      "2001:db8::1",
+		"10.0.0.1":             "10.0.0.1",
+		"node1.example.com":    "node1.example.com",
+		"2001:db8:0:1:1:1:1:1": "2001:db8:0:1:1:1:1:1",
+		"2001:db8:0:0:1:0:0:1": "2001:db8::1:0:0:1",
+		"":                     "",
+	}
+	for input, expected := range cases {
+		if output := NormalizeIP(input); output != expected {
+			t.Errorf("NormalizeIP(%q) -> %q != %q", input, output, expected)
+		}
+	}
+}
+
+func TestNormalizeAddr(t *testing.T) {
+	cases := map[string]string{
+		"[2001:DB8::0:1]:4646": "[2001:db8::1]:4646",
+		"2001:db8::1":          "2001:db8::1",
+		"10.0.0.1:4646":        "10.0.0.1:4646",
+		"node1:4646":           "node1:4646",
+	}
+	for input, expected := range cases {
+		if output := NormalizeAddr(input); output != expected {
+			t.Errorf("NormalizeAddr(%q) -> %q != %q", input, output, expected)
+		}
+	}
+}
+
 func BenchmarkCleanEnvVar(b *testing.B) {
 	in := "NOMAD_ADDR_redis-cache"
 	replacement := byte('_')
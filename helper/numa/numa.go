@@ -0,0 +1,95 @@
+// Package numa reads the NUMA topology of the host so that the memory of the
+// tasks pinned to cores can be placed on the NUMA nodes of those cores.
+package numa
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Node is a NUMA node of the host.
+type Node struct {
+	// ID is the number of the node
+	ID int
+
+	// Cores are the logical CPUs of the node
+	Cores []int
+
+	// MemoryMB is the total memory of the node
+	MemoryMB int
+}
+
+// Mems returns the NUMA nodes of the cores as a cpuset list, to be used as
+// the cpuset.mems of a task pinned to the cores. An empty list is returned if
+// the host has a single NUMA node, if a core is on none of the nodes, or if
+// the nodes of the cores don't have the memory asked for, in which case the
+// memory of the task has to be allowed on every node.
+func Mems(topology []*Node, cores []int, memoryMB int) string {
+	if len(topology) < 2 || len(cores) == 0 {
+		return ""
+	}
+
+	nodeOf := make(map[int]*Node)
+	for _, n := range topology {
+		for _, c := range n.Cores {
+			nodeOf[c] = n
+		}
+	}
+
+	used := make(map[int]*Node)
+	for _, c := range cores {
+		n, ok := nodeOf[c]
+		if !ok {
+			return ""
+		}
+		used[n.ID] = n
+	}
+
+	ids := make([]int, 0, len(used))
+	total := 0
+	for id, n := range used {
+		ids = append(ids, id)
+		total += n.MemoryMB
+	}
+	if total < memoryMB {
+		return ""
+	}
+
+	sort.Ints(ids)
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.Itoa(id)
+	}
+	return strings.Join(s, ",")
+}
+
+// ParseList parses a cpuset list such as "0-3,8,10-11".
+func ParseList(list string) ([]int, error) {
+	var ids []int
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return nil, nil
+	}
+	for _, part := range strings.Split(list, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpuset list %q: %v", list, err)
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid cpuset list %q: %v", list, err)
+			}
+		}
+		if end < start {
+			return nil, fmt.Errorf("invalid cpuset list %q: range %q is reversed", list, part)
+		}
+		for id := start; id <= end; id++ {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,9 @@
+// +build !linux
+
+package numa
+
+// Topology returns the NUMA nodes of the host. NUMA placement is only
+// supported on Linux, so no nodes are returned elsewhere.
+func Topology() ([]*Node, error) {
+	return nil, nil
+}
@@ -0,0 +1,79 @@
+package numa
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sysfsNodeDir is the directory of the NUMA nodes in sysfs
+var sysfsNodeDir = "/sys/devices/system/node"
+
+// Topology returns the NUMA nodes of the host, sorted by ID. No nodes are
+// returned if the kernel doesn't expose them.
+func Topology() ([]*Node, error) {
+	entries, err := ioutil.ReadDir(sysfsNodeDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var nodes []*Node
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), "node") {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimPrefix(e.Name(), "node"))
+		if err != nil {
+			continue
+		}
+		n, err := readNode(filepath.Join(sysfsNodeDir, e.Name()), id)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// readNode reads the cores and memory of a NUMA node from its sysfs
+// directory.
+func readNode(dir string, id int) (*Node, error) {
+	list, err := ioutil.ReadFile(filepath.Join(dir, "cpulist"))
+	if err != nil {
+		return nil, err
+	}
+	cores, err := ParseList(string(list))
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(dir, "meminfo"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// Lines are of the form "Node 0 MemTotal:       32768000 kB"
+	n := &Node{ID: id, Cores: cores}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[2] != "MemTotal:" {
+			continue
+		}
+		kb, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory of NUMA node %d: %v", id, err)
+		}
+		n.MemoryMB = kb / 1024
+	}
+	return n, scanner.Err()
+}
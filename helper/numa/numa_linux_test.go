@@ -0,0 +1,49 @@
+package numa
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopology(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "numa")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(node, cpulist, meminfo string) {
+		nodeDir := filepath.Join(dir, node)
+		if err := os.MkdirAll(nodeDir, 0755); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		ioutil.WriteFile(filepath.Join(nodeDir, "cpulist"), []byte(cpulist), 0644)
+		ioutil.WriteFile(filepath.Join(nodeDir, "meminfo"), []byte(meminfo), 0644)
+	}
+	write("node1", "4-7\n", "Node 1 MemTotal:        2097152 kB\nNode 1 MemFree:         1048576 kB\n")
+	write("node0", "0-3\n", "Node 0 MemTotal:        1048576 kB\n")
+	os.MkdirAll(filepath.Join(dir, "power"), 0755)
+
+	old := sysfsNodeDir
+	sysfsNodeDir = dir
+	defer func() { sysfsNodeDir = old }()
+
+	nodes, err := Topology()
+	assert.Nil(err)
+	assert.Equal([]*Node{
+		{ID: 0, Cores: []int{0, 1, 2, 3}, MemoryMB: 1024},
+		{ID: 1, Cores: []int{4, 5, 6, 7}, MemoryMB: 2048},
+	}, nodes)
+
+	// Hosts without NUMA nodes in sysfs have none
+	sysfsNodeDir = filepath.Join(dir, "missing")
+	nodes, err = Topology()
+	assert.Nil(err)
+	assert.Nil(nodes)
+}
@@ -0,0 +1,45 @@
+package numa
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseList(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	ids, err := ParseList("0-3,8,10-11\n")
+	assert.Nil(err)
+	assert.Equal([]int{0, 1, 2, 3, 8, 10, 11}, ids)
+
+	ids, err = ParseList("")
+	assert.Nil(err)
+	assert.Nil(ids)
+
+	_, err = ParseList("3-1")
+	assert.NotNil(err)
+	_, err = ParseList("a")
+	assert.NotNil(err)
+}
+
+func TestMems(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	topology := []*Node{
+		{ID: 0, Cores: []int{0, 1, 2, 3}, MemoryMB: 1024},
+		{ID: 1, Cores: []int{4, 5, 6, 7}, MemoryMB: 1024},
+	}
+	assert.Equal("0", Mems(topology, []int{1, 2}, 512))
+	assert.Equal("0,1", Mems(topology, []int{3, 4}, 2048))
+
+	// The memory must fit on the nodes of the cores
+	assert.Equal("", Mems(topology, []int{4}, 2048))
+
+	// Unknown cores and single node hosts aren't restricted
+	assert.Equal("", Mems(topology, []int{9}, 0))
+	assert.Equal("", Mems(topology[:1], []int{1}, 0))
+	assert.Equal("", Mems(topology, nil, 0))
+}
@@ -0,0 +1,62 @@
+// Package systemd calls the systemd D-Bus API through busctl, so that clients
+// can delegate the operations they aren't privileged for to systemd.
+package systemd
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Busctl invokes a method on the system bus and returns its output.
+func Busctl(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("busctl", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// EscapePath returns the name of the units of a path, such as its mount unit
+// without the suffix, as escaped by systemd-escape --path.
+func EscapePath(path string) string {
+	var parts []string
+	for _, part := range strings.Split(path, "/") {
+		if part != "" && part != "." {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+
+	var buf bytes.Buffer
+	for i, part := range parts {
+		if i > 0 {
+			buf.WriteByte('-')
+		}
+		for j := 0; j < len(part); j++ {
+			c := part[j]
+			if isUnitNameChar(c) && !(i == 0 && j == 0 && c == '.') {
+				buf.WriteByte(c)
+			} else {
+				fmt.Fprintf(&buf, `\x%02x`, c)
+			}
+		}
+	}
+	return buf.String()
+}
+
+// isUnitNameChar returns whether the character can appear unescaped in the
+// name of a unit.
+func isUnitNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == ':' || c == '_' || c == '.'
+}
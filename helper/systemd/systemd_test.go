@@ -0,0 +1,23 @@
+package systemd
+
+import "testing"
+
+func TestEscapePath(t *testing.T) {
+	t.Parallel()
+	cases := map[string]string{
+		"/":                              "-",
+		"/var/lib/nomad":                 "var-lib-nomad",
+		"//var/./lib/":                   "var-lib",
+		"/alloc/5fd0-3a/web server":      `alloc-5fd0\x2d3a-web\x20server`,
+		"/alloc/.hidden/task.1":          `alloc-.hidden-task.1`,
+		"/.alloc/a:b_c":                  `\x2ealloc-a:b_c`,
+		"/alloc/é":                       `alloc-\xc3\xa9`,
+		"/alloc/secrets\\dir":            `alloc-secrets\x5cdir`,
+		"/var/lib/nomad/alloc/x/secrets": "var-lib-nomad-alloc-x-secrets",
+	}
+	for path, expected := range cases {
+		if out := EscapePath(path); out != expected {
+			t.Errorf("EscapePath(%q) = %q, expected %q", path, out, expected)
+		}
+	}
+}
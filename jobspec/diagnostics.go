@@ -0,0 +1,87 @@
+package jobspec
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+var (
+	// reHCLPosition matches the position prefixed to syntax errors by the
+	// HCL parser
+	reHCLPosition = regexp.MustCompile(`At (\d+):(\d+): `)
+
+	// reKeyPosition matches the position suffixed to the errors of invalid
+	// keys and unset variables
+	reKeyPosition = regexp.MustCompile(` \(line (\d+), column (\d+)\)`)
+)
+
+// Diagnostic is an error about a job spec, positioned in its source when
+// the position is known.
+type Diagnostic struct {
+	// Summary describes the error
+	Summary string
+
+	// Line and Column are the position of the error in the source, starting
+	// at 1. They are zero if the position is unknown.
+	Line   int
+	Column int
+}
+
+// Diagnostics splits an error returned while parsing a job spec into
+// diagnostics. Each nested error reporting a position is its own
+// diagnostic. Errors without any position are returned as a single
+// diagnostic.
+func Diagnostics(err error) []*Diagnostic {
+	if err == nil {
+		return nil
+	}
+
+	var errs []error
+	if merr, ok := err.(*multierror.Error); ok {
+		errs = merr.Errors
+	} else {
+		errs = []error{err}
+	}
+
+	var diags []*Diagnostic
+	for _, err := range errs {
+		var positioned []*Diagnostic
+		for _, line := range strings.Split(err.Error(), "\n") {
+			if d := positionedDiagnostic(line); d != nil {
+				positioned = append(positioned, d)
+			}
+		}
+
+		if len(positioned) == 0 {
+			positioned = []*Diagnostic{{Summary: strings.TrimSpace(err.Error())}}
+		}
+		diags = append(diags, positioned...)
+	}
+	return diags
+}
+
+// positionedDiagnostic returns the diagnostic of an error message line if it
+// reports a position.
+func positionedDiagnostic(line string) *Diagnostic {
+	re := reHCLPosition
+	m := re.FindStringSubmatchIndex(line)
+	if m == nil {
+		re = reKeyPosition
+		if m = re.FindStringSubmatchIndex(line); m == nil {
+			return nil
+		}
+	}
+
+	l, _ := strconv.Atoi(line[m[2]:m[3]])
+	c, _ := strconv.Atoi(line[m[4]:m[5]])
+	summary := line[:m[0]] + line[m[1]:]
+	summary = strings.TrimPrefix(strings.TrimSpace(summary), "* ")
+	return &Diagnostic{
+		Summary: summary,
+		Line:    l,
+		Column:  c,
+	}
+}
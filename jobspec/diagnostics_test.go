@@ -0,0 +1,57 @@
+package jobspec
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-multierror"
+)
+
+func TestDiagnostics(t *testing.T) {
+	cases := []struct {
+		Err      error
+		Expected []*Diagnostic
+	}{
+		{
+			Err:      nil,
+			Expected: nil,
+		},
+		{
+			Err: fmt.Errorf("At 4:7: illegal char"),
+			Expected: []*Diagnostic{
+				{Summary: "illegal char", Line: 4, Column: 7},
+			},
+		},
+		{
+			Err: multierror.Append(nil,
+				fmt.Errorf("group: 'web', task: 'web', invalid key: drvier (line 6, column 7)"),
+				fmt.Errorf(`variable "dc" is not set (line 3, column 19)`),
+			),
+			Expected: []*Diagnostic{
+				{Summary: "group: 'web', task: 'web', invalid key: drvier", Line: 6, Column: 7},
+				{Summary: `variable "dc" is not set`, Line: 3, Column: 19},
+			},
+		},
+		{
+			Err: fmt.Errorf("job: 1 error occurred:\n\n* invalid key: foo (line 2, column 3)\n* invalid key: bar (line 5, column 3)"),
+			Expected: []*Diagnostic{
+				{Summary: "invalid key: foo", Line: 2, Column: 3},
+				{Summary: "invalid key: bar", Line: 5, Column: 3},
+			},
+		},
+		{
+			Err: fmt.Errorf("only one 'job' block allowed"),
+			Expected: []*Diagnostic{
+				{Summary: "only one 'job' block allowed"},
+			},
+		},
+	}
+
+	for i, tc := range cases {
+		actual := Diagnostics(tc.Err)
+		if !reflect.DeepEqual(actual, tc.Expected) {
+			t.Fatalf("case %d: expected %#v; got %#v", i, tc.Expected, actual)
+		}
+	}
+}
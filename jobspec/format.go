@@ -0,0 +1,314 @@
+package jobspec
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/parser"
+	"github.com/hashicorp/hcl/hcl/token"
+)
+
+// formatIndent is the indentation of each level of nesting
+const formatIndent = "  "
+
+// Format returns the canonical formatting of an HCL job specification.
+// Blocks are indented by two spaces, the equal signs of consecutive
+// assignments are aligned, consecutive blank lines are collapsed and lists
+// are kept on a single line only if they were written on one. Comments are
+// preserved where they were written. Formatting is idempotent.
+func Format(src []byte) ([]byte, error) {
+	file, err := parser.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &formatter{}
+	for _, group := range file.Comments {
+		f.comments = append(f.comments, group.List...)
+	}
+	if list, ok := file.Node.(*ast.ObjectList); ok {
+		f.items(list.Items)
+	}
+	f.flush(math.MaxInt32)
+
+	out := bytes.TrimSpace(f.buf.Bytes())
+	if len(out) != 0 {
+		out = append(out, '\n')
+	}
+
+	// Guard against formatting changing the meaning of the file
+	formatted, err := parser.Parse(out)
+	if err != nil {
+		return nil, fmt.Errorf("formatted output does not parse: %v", err)
+	}
+	var before, after interface{}
+	if err := hcl.DecodeObject(&before, file); err != nil {
+		return nil, err
+	}
+	if err := hcl.DecodeObject(&after, formatted); err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(before, after) {
+		return nil, fmt.Errorf("formatting changed the content of the file")
+	}
+	return out, nil
+}
+
+// formatter writes the canonical formatting of an HCL syntax tree. It tracks
+// the source line of the last output so that blank lines are preserved and
+// comments are written where they were.
+type formatter struct {
+	buf bytes.Buffer
+
+	// comments are the comments not written yet, in source order
+	comments []*ast.Comment
+
+	// depth is the nesting level of the output
+	depth int
+
+	// line is the source line of the last output, and blockStart whether it
+	// was the opening of a block or list
+	line       int
+	blockStart bool
+}
+
+// startLine indents a new line for a node starting at the source line,
+// preceded by a blank line if there was one in the source.
+func (f *formatter) startLine(line int) {
+	if f.line != 0 && line > f.line+1 && !f.blockStart {
+		f.buf.WriteByte('\n')
+	}
+	f.blockStart = false
+	f.buf.WriteString(strings.Repeat(formatIndent, f.depth))
+}
+
+// flush writes the comments before the offset on their own lines
+func (f *formatter) flush(offset int) {
+	for len(f.comments) != 0 && f.comments[0].Start.Offset < offset {
+		c := f.comments[0]
+		f.comments = f.comments[1:]
+		f.startLine(c.Start.Line)
+		f.buf.WriteString(c.Text)
+		f.buf.WriteByte('\n')
+		f.line = endLine(c.Start, c.Text)
+	}
+}
+
+// trailing writes the comments following the last output on its source line
+func (f *formatter) trailing() {
+	for len(f.comments) != 0 && f.comments[0].Start.Line == f.line {
+		c := f.comments[0]
+		f.comments = f.comments[1:]
+		f.buf.WriteByte(' ')
+		f.buf.WriteString(c.Text)
+		f.line = endLine(c.Start, c.Text)
+	}
+}
+
+// items writes the items of an object, aligning the equal signs of
+// consecutive single line assignments
+func (f *formatter) items(items []*ast.ObjectItem) {
+	widths := f.alignments(items)
+	for i, item := range items {
+		f.flush(item.Pos().Offset)
+		f.startLine(item.Pos().Line)
+
+		keys := make([]string, len(item.Keys))
+		for j, k := range item.Keys {
+			keys[j] = k.Token.Text
+		}
+		key := strings.Join(keys, " ")
+		f.buf.WriteString(key)
+		if item.Assign.IsValid() {
+			f.buf.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(key)))
+			f.buf.WriteString(" = ")
+		} else if len(keys) != 0 {
+			f.buf.WriteByte(' ')
+		}
+
+		f.value(item.Val)
+		f.trailing()
+		f.buf.WriteByte('\n')
+	}
+}
+
+// alignments returns the width the key of each item is padded to. The keys of
+// consecutive single line assignments, without blank lines or comments
+// between them, are padded to the longest of them.
+func (f *formatter) alignments(items []*ast.ObjectItem) []int {
+	widths := make([]int, len(items))
+	start := 0
+	for i := 0; i <= len(items); i++ {
+		if i < len(items) && i > start && f.aligned(items[i-1], items[i]) {
+			continue
+		}
+
+		// Pad the keys of the group ending before i
+		max := 0
+		for _, item := range items[start:i] {
+			if n := keyWidth(item); n > max {
+				max = n
+			}
+		}
+		for j := start; j < i; j++ {
+			widths[j] = max
+		}
+		start = i
+	}
+	return widths
+}
+
+// aligned returns whether two consecutive items are aligned
+func (f *formatter) aligned(prev, item *ast.ObjectItem) bool {
+	if !f.singleLineAssign(prev) || !f.singleLineAssign(item) {
+		return false
+	}
+	if item.Pos().Line != nodeEndLine(prev.Val)+1 {
+		return false
+	}
+	for _, c := range f.comments {
+		if c.Start.Offset > prev.Pos().Offset && c.Start.Offset < item.Pos().Offset && c.Start.Line != nodeEndLine(prev.Val) {
+			return false
+		}
+	}
+	return true
+}
+
+// singleLineAssign returns whether the item is an assignment written on a
+// single line
+func (f *formatter) singleLineAssign(item *ast.ObjectItem) bool {
+	if !item.Assign.IsValid() || len(item.Keys) != 1 {
+		return false
+	}
+	switch v := item.Val.(type) {
+	case *ast.LiteralType:
+		return v.Token.Type != token.HEREDOC
+	case *ast.ListType:
+		return f.inlineList(v)
+	}
+	return false
+}
+
+// inlineList returns whether a list is written on a single line: it was in the
+// source, holds no comments and only holds literals.
+func (f *formatter) inlineList(l *ast.ListType) bool {
+	if l.Lbrack.Line != l.Rbrack.Line {
+		return false
+	}
+	for _, n := range l.List {
+		lit, ok := n.(*ast.LiteralType)
+		if !ok || lit.Token.Type == token.HEREDOC {
+			return false
+		}
+	}
+	for _, c := range f.comments {
+		if c.Start.Offset > l.Lbrack.Offset && c.Start.Offset < l.Rbrack.Offset {
+			return false
+		}
+	}
+	return true
+}
+
+// value writes a value
+func (f *formatter) value(n ast.Node) {
+	switch v := n.(type) {
+	case *ast.LiteralType:
+		text := v.Token.Text
+		if v.Token.Type == token.HEREDOC {
+			text = strings.TrimSuffix(text, "\n")
+		}
+		f.buf.WriteString(text)
+		f.line = endLine(v.Token.Pos, text)
+
+	case *ast.ObjectType:
+		if len(v.List.Items) == 0 && !f.commentsBefore(v.Rbrace.Offset) {
+			f.buf.WriteString("{}")
+			f.line = v.Rbrace.Line
+			return
+		}
+
+		f.buf.WriteByte('{')
+		f.line = v.Lbrace.Line
+		f.trailing()
+		f.buf.WriteByte('\n')
+		f.depth++
+		f.blockStart = true
+		f.items(v.List.Items)
+		f.flush(v.Rbrace.Offset)
+		f.depth--
+		f.blockStart = false
+		f.buf.WriteString(strings.Repeat(formatIndent, f.depth))
+		f.buf.WriteByte('}')
+		f.line = v.Rbrace.Line
+
+	case *ast.ListType:
+		if f.inlineList(v) {
+			elems := make([]string, len(v.List))
+			for i, n := range v.List {
+				elems[i] = n.(*ast.LiteralType).Token.Text
+			}
+			f.buf.WriteString("[" + strings.Join(elems, ", ") + "]")
+			f.line = v.Rbrack.Line
+			return
+		}
+
+		f.buf.WriteByte('[')
+		f.line = v.Lbrack.Line
+		f.trailing()
+		f.buf.WriteByte('\n')
+		f.depth++
+		f.blockStart = true
+		for _, elem := range v.List {
+			f.flush(elem.Pos().Offset)
+			f.startLine(elem.Pos().Line)
+			f.value(elem)
+			f.buf.WriteByte(',')
+			f.trailing()
+			f.buf.WriteByte('\n')
+		}
+		f.flush(v.Rbrack.Offset)
+		f.depth--
+		f.blockStart = false
+		f.buf.WriteString(strings.Repeat(formatIndent, f.depth))
+		f.buf.WriteByte(']')
+		f.line = v.Rbrack.Line
+	}
+}
+
+// commentsBefore returns whether comments not written yet are before the
+// offset
+func (f *formatter) commentsBefore(offset int) bool {
+	return len(f.comments) != 0 && f.comments[0].Start.Offset < offset
+}
+
+// keyWidth returns the width of the key of an item
+func keyWidth(item *ast.ObjectItem) int {
+	if len(item.Keys) != 1 {
+		return 0
+	}
+	return utf8.RuneCountInString(item.Keys[0].Token.Text)
+}
+
+// nodeEndLine returns the source line a value ends on
+func nodeEndLine(n ast.Node) int {
+	switch v := n.(type) {
+	case *ast.LiteralType:
+		return endLine(v.Token.Pos, strings.TrimSuffix(v.Token.Text, "\n"))
+	case *ast.ObjectType:
+		return v.Rbrace.Line
+	case *ast.ListType:
+		return v.Rbrack.Line
+	}
+	return n.Pos().Line
+}
+
+// endLine returns the source line text starting at the position ends on
+func endLine(pos token.Pos, text string) int {
+	return pos.Line + strings.Count(text, "\n")
+}
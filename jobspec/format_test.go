@@ -0,0 +1,92 @@
+package jobspec
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	src, err := ioutil.ReadFile(filepath.Join("test-fixtures", "fmt", "unformatted.nomad"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected, err := ioutil.ReadFile(filepath.Join("test-fixtures", "fmt", "formatted.nomad"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := Format(src)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(out) != string(expected) {
+		t.Fatalf("bad formatting:\n%s\nexpected:\n%s", out, expected)
+	}
+}
+
+func TestFormat_Cases(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Src      string
+		Expected string
+	}{
+		{
+			Name:     "empty",
+			Src:      "\n\n",
+			Expected: "",
+		},
+		{
+			Name:     "alignment is broken by blank lines and blocks",
+			Src:      "a = 1\nlong_key = 2\n\nb = 3\nc {\n}\nlonger = 4\n",
+			Expected: "a        = 1\nlong_key = 2\n\nb = 3\nc {}\nlonger = 4\n",
+		},
+		{
+			Name:     "multi-line lists get trailing commas",
+			Src:      "l = [\n1,\n  2\n]\n",
+			Expected: "l = [\n  1,\n  2,\n]\n",
+		},
+		{
+			Name:     "lists of objects",
+			Src:      "l = [{a = 1}]\n",
+			Expected: "l = [\n  {\n    a = 1\n  },\n]\n",
+		},
+		{
+			Name:     "heredocs are kept verbatim",
+			Src:      "task \"t\" {\n        data = <<EOF\n   keep\nEOF\n}\n",
+			Expected: "task \"t\" {\n  data = <<EOF\n   keep\nEOF\n}\n",
+		},
+		{
+			Name:     "comments in empty blocks",
+			Src:      "a {\n# inside\n}\n",
+			Expected: "a {\n  # inside\n}\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			out, err := Format([]byte(c.Src))
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if string(out) != c.Expected {
+				t.Fatalf("got %q; want %q", out, c.Expected)
+			}
+
+			// Formatting is idempotent
+			again, err := Format(out)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if string(again) != string(out) {
+				t.Fatalf("not idempotent: got %q; want %q", again, out)
+			}
+		})
+	}
+}
+
+func TestFormat_Invalid(t *testing.T) {
+	if _, err := Format([]byte("job \"example\" {")); err == nil {
+		t.Fatalf("expected a parse error")
+	}
+}
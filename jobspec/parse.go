@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl"
 	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/token"
 	"github.com/hashicorp/nomad/api"
 	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -23,11 +24,101 @@ import (
 var reDynamicPorts = regexp.MustCompile("^[a-zA-Z0-9_]+$")
 var errPortLabel = fmt.Errorf("Port label does not conform to naming requirements %s", reDynamicPorts.String())
 
+// reVariable matches the references to variables in a job spec. A reference
+// preceded by another "$" is escaped.
+var reVariable = regexp.MustCompile(`\$?\$\{\s*var\.([a-zA-Z_][a-zA-Z0-9_-]*)\s*\}`)
+
 // Parse parses the job spec from the given io.Reader.
 //
 // Due to current internal limitations, the entire contents of the
 // io.Reader will be copied into memory first before parsing.
 func Parse(r io.Reader) (*api.Job, error) {
+	root, err := parseSource(r)
+	if err != nil {
+		return nil, err
+	}
+	return parseRoot(root)
+}
+
+// ParseWithVariables parses the job spec from the given io.Reader after
+// substituting the values of the variables it references as "${var.<name>}".
+// References are only substituted in the quoted strings and keys of the
+// parsed job spec, so the values can't change its structure, and aren't
+// substituted in comments and heredocs. Referencing a variable without a
+// value is an error.
+func ParseWithVariables(r io.Reader, vars map[string]string) (*api.Job, error) {
+	root, err := parseSource(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var mErr multierror.Error
+	walkStrings(root, func(tok *token.Token) {
+		value := tok.Value().(string)
+		var out bytes.Buffer
+		last := 0
+		for _, loc := range reVariable.FindAllStringSubmatchIndex(value, -1) {
+			out.WriteString(value[last:loc[0]])
+			last = loc[1]
+
+			ref := value[loc[0]:loc[1]]
+			if strings.HasPrefix(ref, "$$") {
+				out.WriteString(ref)
+				continue
+			}
+			name := value[loc[2]:loc[3]]
+			v, ok := vars[name]
+			if !ok {
+				multierror.Append(&mErr, fmt.Errorf("variable %q is not set (line %d, column %d)", name, tok.Pos.Line, tok.Pos.Column))
+				out.WriteString(ref)
+				continue
+			}
+			out.WriteString(v)
+		}
+		if last == 0 {
+			return
+		}
+		out.WriteString(value[last:])
+
+		// The substituted string is quoted as JSON so that it is decoded
+		// as is, without HCL unquoting any interpolation in the values
+		tok.Text = strconv.Quote(out.String())
+		tok.JSON = true
+	})
+	if err := mErr.ErrorOrNil(); err != nil {
+		return nil, err
+	}
+
+	return parseRoot(root)
+}
+
+// Variables returns the names of the variables referenced by the job spec as
+// "${var.<name>}", in the order they are first referenced. Nil is returned if
+// the job spec can't be parsed.
+func Variables(src []byte) []string {
+	root, err := parseSource(bytes.NewReader(src))
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	seen := make(map[string]struct{})
+	walkStrings(root, func(tok *token.Token) {
+		for _, m := range reVariable.FindAllStringSubmatch(tok.Value().(string), -1) {
+			if strings.HasPrefix(m[0], "$$") {
+				continue
+			}
+			if _, ok := seen[m[1]]; !ok {
+				seen[m[1]] = struct{}{}
+				names = append(names, m[1])
+			}
+		}
+	})
+	return names
+}
+
+// parseSource parses the HCL source of a job spec
+func parseSource(r io.Reader) (*ast.File, error) {
 	// Copy the reader into an in-memory buffer first since HCL requires it.
 	var buf bytes.Buffer
 	if _, err := io.Copy(&buf, r); err != nil {
@@ -39,8 +130,11 @@ func Parse(r io.Reader) (*api.Job, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error parsing: %s", err)
 	}
-	buf.Reset()
+	return root, nil
+}
 
+// parseRoot parses the job of a parsed job spec
+func parseRoot(root *ast.File) (*api.Job, error) {
 	// Top-level item should be a list
 	list, ok := root.Node.(*ast.ObjectList)
 	if !ok {
@@ -69,6 +163,24 @@ func Parse(r io.Reader) (*api.Job, error) {
 	return &job, nil
 }
 
+// walkStrings calls fn with the tokens of the quoted strings and keys of a
+// parsed job spec
+func walkStrings(root *ast.File, fn func(tok *token.Token)) {
+	ast.Walk(root, func(n ast.Node) (ast.Node, bool) {
+		switch n := n.(type) {
+		case *ast.ObjectKey:
+			if n.Token.Type == token.STRING {
+				fn(&n.Token)
+			}
+		case *ast.LiteralType:
+			if n.Token.Type == token.STRING {
+				fn(&n.Token)
+			}
+		}
+		return n, true
+	})
+}
+
 // ParseFile parses the given path as a job spec.
 func ParseFile(path string) (*api.Job, error) {
 	path, err := filepath.Abs(path)
@@ -108,6 +220,7 @@ func parseJob(result *api.Job, list *ast.ObjectList) error {
 	delete(m, "periodic")
 	delete(m, "vault")
 	delete(m, "parameterized")
+	delete(m, "secrets")
 
 	// Set the ID and name to the object key
 	result.ID = helper.StringToPtr(obj.Keys[0].Token.Value().(string))
@@ -140,6 +253,7 @@ func parseJob(result *api.Job, list *ast.ObjectList) error {
 		"periodic",
 		"priority",
 		"region",
+		"secrets",
 		"task",
 		"type",
 		"update",
@@ -178,6 +292,13 @@ func parseJob(result *api.Job, list *ast.ObjectList) error {
 		}
 	}
 
+	// If we have declared secrets, then parse them
+	if o := listVal.Filter("secrets"); len(o.Items) > 0 {
+		if err := parseSecrets(&result.Secrets, o); err != nil {
+			return multierror.Prefix(err, "secrets ->")
+		}
+	}
+
 	// Parse out meta fields. These are in HCL as a list so we need
 	// to iterate over them and merge them.
 	if metaO := listVal.Filter("meta"); len(metaO.Items) > 0 {
@@ -269,6 +390,8 @@ func parseGroups(result *api.Job, list *ast.ObjectList) error {
 		valid := []string{
 			"count",
 			"constraint",
+			"dns",
+			"migrate",
 			"restart",
 			"meta",
 			"task",
@@ -285,6 +408,8 @@ func parseGroups(result *api.Job, list *ast.ObjectList) error {
 			return err
 		}
 		delete(m, "constraint")
+		delete(m, "dns")
+		delete(m, "migrate")
 		delete(m, "meta")
 		delete(m, "task")
 		delete(m, "restart")
@@ -328,6 +453,20 @@ func parseGroups(result *api.Job, list *ast.ObjectList) error {
 			}
 		}
 
+		// Parse the DNS config
+		if o := listVal.Filter("dns"); len(o.Items) > 0 {
+			if err := parseDNS(&g.DNS, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', dns ->", n))
+			}
+		}
+
+		// Parse the migrate strategy
+		if o := listVal.Filter("migrate"); len(o.Items) > 0 {
+			if err := parseMigrate(&g.Migrate, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', migrate ->", n))
+			}
+		}
+
 		// Parse out meta fields. These are in HCL as a list so we need
 		// to iterate over them and merge them.
 		if metaO := listVal.Filter("meta"); len(metaO.Items) > 0 {
@@ -531,6 +670,79 @@ func parseEphemeralDisk(result **api.EphemeralDisk, list *ast.ObjectList) error
 	return nil
 }
 
+func parseDNS(result **api.DNSConfig, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'dns' block allowed")
+	}
+
+	// Get our dns object
+	obj := list.Items[0]
+
+	// Check for invalid keys
+	valid := []string{
+		"servers",
+		"searches",
+		"options",
+	}
+	if err := helper.CheckHCLKeys(obj.Val, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, obj.Val); err != nil {
+		return err
+	}
+
+	var dns api.DNSConfig
+	if err := mapstructure.WeakDecode(m, &dns); err != nil {
+		return err
+	}
+	*result = &dns
+
+	return nil
+}
+
+func parseMigrate(result **api.MigrateStrategy, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'migrate' block allowed")
+	}
+
+	// Get our migrate object
+	obj := list.Items[0]
+
+	// Check for invalid keys
+	valid := []string{
+		"deadline",
+		"ignore",
+	}
+	if err := helper.CheckHCLKeys(obj.Val, valid); err != nil {
+		return err
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, obj.Val); err != nil {
+		return err
+	}
+
+	var migrate api.MigrateStrategy
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+		WeaklyTypedInput: true,
+		Result:           &migrate,
+	})
+	if err != nil {
+		return err
+	}
+	if err := dec.Decode(m); err != nil {
+		return err
+	}
+	*result = &migrate
+
+	return nil
+}
+
 // parseBool takes an interface value and tries to convert it to a boolean and
 // returns an error if the type can't be converted.
 func parseBool(value interface{}) (bool, error) {
@@ -577,8 +789,10 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 		valid := []string{
 			"artifact",
 			"config",
+			"connectivity_check",
 			"constraint",
 			"dispatch_payload",
+			"dns",
 			"driver",
 			"env",
 			"kill_timeout",
@@ -603,8 +817,10 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 		}
 		delete(m, "artifact")
 		delete(m, "config")
+		delete(m, "connectivity_check")
 		delete(m, "constraint")
 		delete(m, "dispatch_payload")
+		delete(m, "dns")
 		delete(m, "env")
 		delete(m, "logs")
 		delete(m, "meta")
@@ -709,6 +925,7 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 			valid := []string{
 				"max_files",
 				"max_file_size",
+				"sink",
 			}
 			if err := helper.CheckHCLKeys(logsBlock.Val, valid); err != nil {
 				return multierror.Prefix(err, fmt.Sprintf("'%s', logs ->", n))
@@ -717,12 +934,22 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 			if err := hcl.DecodeObject(&m, logsBlock.Val); err != nil {
 				return err
 			}
+			delete(m, "sink")
 
 			var log api.LogConfig
 			if err := mapstructure.WeakDecode(m, &log); err != nil {
 				return err
 			}
 
+			// Parse the log sinks
+			if ot, ok := logsBlock.Val.(*ast.ObjectType); ok {
+				if o := ot.List.Filter("sink"); len(o.Items) > 0 {
+					if err := parseLogSinks(&log, o); err != nil {
+						return multierror.Prefix(err, fmt.Sprintf("'%s', logs ->", n))
+					}
+				}
+			}
+
 			t.LogConfig = &log
 		}
 
@@ -740,6 +967,13 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 			}
 		}
 
+		// Parse connectivity checks
+		if o := listVal.Filter("connectivity_check"); len(o.Items) > 0 {
+			if err := parseConnectivityChecks(&t.ConnectivityChecks, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', connectivity_check ->", n))
+			}
+		}
+
 		// If we have a vault block, then parse that
 		if o := listVal.Filter("vault"); len(o.Items) > 0 {
 			v := &api.Vault{
@@ -780,12 +1014,60 @@ func parseTasks(jobName string, taskGroupName string, result *[]*api.Task, list
 			}
 		}
 
+		// Parse the DNS config
+		if o := listVal.Filter("dns"); len(o.Items) > 0 {
+			if err := parseDNS(&t.DNS, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("'%s', dns ->", n))
+			}
+		}
+
 		*result = append(*result, &t)
 	}
 
 	return nil
 }
 
+func parseLogSinks(result *api.LogConfig, list *ast.ObjectList) error {
+	for _, item := range list.Items {
+		if len(item.Keys) != 1 {
+			return fmt.Errorf("sink should have a single type")
+		}
+		sinkType := item.Keys[0].Token.Value().(string)
+
+		// Check for invalid keys
+		valid := []string{
+			"address",
+			"labels",
+		}
+		if err := helper.CheckHCLKeys(item.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("sink '%s' ->", sinkType))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return err
+		}
+		delete(m, "labels")
+
+		sink := api.LogSink{Type: sinkType}
+		if err := mapstructure.WeakDecode(m, &sink); err != nil {
+			return err
+		}
+
+		if ot, ok := item.Val.(*ast.ObjectType); ok {
+			if o := ot.List.Filter("labels"); len(o.Items) > 0 {
+				labels := make(map[string]string)
+				if err := parseArtifactOption(labels, o); err != nil {
+					return multierror.Prefix(err, fmt.Sprintf("sink '%s', labels ->", sinkType))
+				}
+				sink.Labels = labels
+			}
+		}
+		result.Sinks = append(result.Sinks, &sink)
+	}
+	return nil
+}
+
 func parseArtifacts(result *[]*api.TaskArtifact, list *ast.ObjectList) error {
 	for _, o := range list.Elem().Items {
 		// Check for invalid keys
@@ -902,6 +1184,46 @@ func parseTemplates(result *[]*api.Template, list *ast.ObjectList) error {
 	return nil
 }
 
+func parseConnectivityChecks(result *[]*api.ConnectivityCheck, list *ast.ObjectList) error {
+	for _, o := range list.Elem().Items {
+		// Check for invalid keys
+		valid := []string{
+			"name",
+			"type",
+			"address",
+			"port",
+			"path",
+			"interval",
+			"timeout",
+		}
+		if err := helper.CheckHCLKeys(o.Val, valid); err != nil {
+			return err
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, o.Val); err != nil {
+			return err
+		}
+
+		var check api.ConnectivityCheck
+		dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+			WeaklyTypedInput: true,
+			Result:           &check,
+		})
+		if err != nil {
+			return err
+		}
+		if err := dec.Decode(m); err != nil {
+			return err
+		}
+
+		*result = append(*result, &check)
+	}
+
+	return nil
+}
+
 func parseServices(jobName string, taskGroupName string, task *api.Task, serviceObjs *ast.ObjectList) error {
 	task.Services = make([]*api.Service, len(serviceObjs.Items))
 	for idx, o := range serviceObjs.Items {
@@ -1122,6 +1444,11 @@ func parseResources(result *api.Resources, list *ast.ObjectList) error {
 		"disk",
 		"memory",
 		"network",
+		"device",
+		"cores",
+		"io_weight",
+		"io_read_mbps",
+		"io_write_mbps",
 	}
 	if err := helper.CheckHCLKeys(listVal, valid); err != nil {
 		return multierror.Prefix(err, "resources ->")
@@ -1132,6 +1459,7 @@ func parseResources(result *api.Resources, list *ast.ObjectList) error {
 		return err
 	}
 	delete(m, "network")
+	delete(m, "device")
 
 	if err := mapstructure.WeakDecode(m, result); err != nil {
 		return err
@@ -1174,6 +1502,44 @@ func parseResources(result *api.Resources, list *ast.ObjectList) error {
 		result.Networks = []*api.NetworkResource{&r}
 	}
 
+	// Parse the device resources
+	if o := listVal.Filter("device"); len(o.Items) > 0 {
+		if err := parseDevices(result, o); err != nil {
+			return multierror.Prefix(err, "resources ->")
+		}
+	}
+
+	return nil
+}
+
+func parseDevices(result *api.Resources, list *ast.ObjectList) error {
+	for _, item := range list.Items {
+		if len(item.Keys) != 1 {
+			return fmt.Errorf("device should have a single type")
+		}
+		deviceType := item.Keys[0].Token.Value().(string)
+
+		// Check for invalid keys
+		valid := []string{
+			"name",
+			"count",
+			"mbits",
+		}
+		if err := helper.CheckHCLKeys(item.Val, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("device '%s' ->", deviceType))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return err
+		}
+
+		d := api.DeviceResource{Type: deviceType}
+		if err := mapstructure.WeakDecode(m, &d); err != nil {
+			return err
+		}
+		result.Devices = append(result.Devices, &d)
+	}
 	return nil
 }
 
@@ -1384,3 +1750,37 @@ func parseParameterizedJob(result **api.ParameterizedJobConfig, list *ast.Object
 	*result = &d
 	return nil
 }
+
+func parseSecrets(result **api.SecretDependencies, list *ast.ObjectList) error {
+	list = list.Elem()
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'secrets' block allowed per job")
+	}
+
+	// Get our resource object
+	o := list.Items[0]
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, o.Val); err != nil {
+		return err
+	}
+
+	// Check for invalid keys
+	valid := []string{
+		"vault",
+		"variables",
+		"consul",
+	}
+	if err := helper.CheckHCLKeys(o.Val, valid); err != nil {
+		return err
+	}
+
+	// Build the secrets block
+	var d api.SecretDependencies
+	if err := mapstructure.WeakDecode(m, &d); err != nil {
+		return err
+	}
+
+	*result = &d
+	return nil
+}
@@ -159,6 +159,13 @@ func TestParse(t *testing.T) {
 								LogConfig: &api.LogConfig{
 									MaxFiles:      helper.IntToPtr(14),
 									MaxFileSizeMB: helper.IntToPtr(101),
+									Sinks: []*api.LogSink{
+										{
+											Type:    "loki",
+											Address: "http://loki.service.consul:3100/loki/api/v1/push",
+											Labels:  map[string]string{"env": "prod"},
+										},
+									},
 								},
 								Artifacts: []*api.TaskArtifact{
 									{
@@ -560,6 +567,65 @@ func TestParse(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"secrets.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("example"),
+				Name: helper.StringToPtr("example"),
+
+				Secrets: &api.SecretDependencies{
+					Vault:     []string{"secret/data/example"},
+					Variables: []string{"nomad/jobs/example"},
+					Consul:    []string{"config/example/db"},
+				},
+
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("cache"),
+						Tasks: []*api.Task{
+							{
+								Name:   "redis",
+								Driver: "docker",
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			"connectivity-check.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("connectivity"),
+				Name: helper.StringToPtr("connectivity"),
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("group"),
+						Tasks: []*api.Task{
+							{
+								Name:   "task",
+								Driver: "docker",
+								ConnectivityChecks: []*api.ConnectivityCheck{
+									{
+										Name:    "db",
+										Address: "${DB_ADDR}",
+										Timeout: time.Second,
+									},
+									{
+										Name:      "self",
+										Type:      "http",
+										PortLabel: "http",
+										Path:      "/health",
+										Interval:  10 * time.Second,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
 		{
 			"job-with-kill-signal.hcl",
 			&api.Job{
@@ -667,6 +733,153 @@ func TestParse(t *testing.T) {
 			},
 			false,
 		},
+
+		{
+			"devices.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("appliance"),
+				Name: helper.StringToPtr("appliance"),
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("router"),
+						Tasks: []*api.Task{
+							{
+								Name:   "router",
+								Driver: "exec",
+								Resources: &api.Resources{
+									Devices: []*api.DeviceResource{
+										{
+											Type:  "sriov",
+											Name:  "eth1",
+											Count: helper.IntToPtr(2),
+											MBits: 10000,
+										},
+										{
+											Type: "sriov",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"cores.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("trading"),
+				Name: helper.StringToPtr("trading"),
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("engine"),
+						Tasks: []*api.Task{
+							{
+								Name:   "engine",
+								Driver: "exec",
+								Resources: &api.Resources{
+									Cores:    helper.IntToPtr(2),
+									MemoryMB: helper.IntToPtr(1024),
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+
+		{
+			"disk-io.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("database"),
+				Name: helper.StringToPtr("database"),
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("db"),
+						Tasks: []*api.Task{
+							{
+								Name:   "db",
+								Driver: "exec",
+								Resources: &api.Resources{
+									IOWeight:    helper.IntToPtr(500),
+									IOReadMBps:  helper.IntToPtr(200),
+									IOWriteMBps: helper.IntToPtr(100),
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			"dns.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("dns"),
+				Name: helper.StringToPtr("dns"),
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("cache"),
+						DNS: &api.DNSConfig{
+							Servers:  []string{"10.0.0.53"},
+							Searches: []string{"${node.datacenter}.consul", "example.com"},
+							Options:  []string{"ndots:2"},
+						},
+						Tasks: []*api.Task{
+							{
+								Name:   "redis",
+								Driver: "exec",
+							},
+							{
+								Name:   "proxy",
+								Driver: "exec",
+								DNS: &api.DNSConfig{
+									Servers: []string{"${attr.unique.network.ip-address}"},
+								},
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
+		{
+			"migrate.hcl",
+			&api.Job{
+				ID:   helper.StringToPtr("migrate"),
+				Name: helper.StringToPtr("migrate"),
+				TaskGroups: []*api.TaskGroup{
+					{
+						Name: helper.StringToPtr("cache"),
+						Migrate: &api.MigrateStrategy{
+							Deadline: 30 * time.Minute,
+						},
+						Tasks: []*api.Task{
+							{
+								Name:   "redis",
+								Driver: "exec",
+							},
+						},
+					},
+					{
+						Name: helper.StringToPtr("scratch"),
+						Migrate: &api.MigrateStrategy{
+							Ignore: true,
+						},
+						Tasks: []*api.Task{
+							{
+								Name:   "worker",
+								Driver: "exec",
+							},
+						},
+					},
+				},
+			},
+			false,
+		},
 	}
 
 	for _, tc := range cases {
@@ -739,3 +952,83 @@ func TestIncorrectKey(t *testing.T) {
 		t.Fatalf("Expected key error; got %v", err)
 	}
 }
+
+func TestParseWithVariables(t *testing.T) {
+	src := `
+job "${var.name}" {
+  datacenters = ["${var.dc}"]
+  meta {
+    escaped = "$${var.dc}"
+  }
+}
+`
+	vars := map[string]string{
+		"name": "example",
+		"dc":   "dc1",
+	}
+
+	job, err := ParseWithVariables(strings.NewReader(src), vars)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if *job.ID != "example" {
+		t.Fatalf("bad id: %q", *job.ID)
+	}
+	if !reflect.DeepEqual(job.Datacenters, []string{"dc1"}) {
+		t.Fatalf("bad datacenters: %v", job.Datacenters)
+	}
+	if job.Meta["escaped"] != "$${var.dc}" {
+		t.Fatalf("bad meta: %v", job.Meta)
+	}
+
+	delete(vars, "dc")
+	_, err = ParseWithVariables(strings.NewReader(src), vars)
+	if err == nil || !strings.Contains(err.Error(), `variable "dc" is not set`) {
+		t.Fatalf("expected unset variable error; got %v", err)
+	}
+	if diags := Diagnostics(err); len(diags) != 1 || diags[0].Line == 0 {
+		t.Fatalf("expected positioned diagnostic; got %#v", diags)
+	}
+}
+
+func TestParseWithVariables_Escaping(t *testing.T) {
+	src := `
+# Comments referencing ${var.unset} are left alone
+job "example" {
+  meta {
+    value = "${var.value}"
+    heredoc = <<EOF
+${var.unset}
+EOF
+  }
+}
+`
+	value := "a \"quoted\" \\ value\n}\ntask \"evil\" {\n  driver = \"raw_exec\"\n}"
+	job, err := ParseWithVariables(strings.NewReader(src), map[string]string{"value": value})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if job.Meta["value"] != value {
+		t.Fatalf("bad value: %q", job.Meta["value"])
+	}
+	if job.Meta["heredoc"] != "${var.unset}\n" {
+		t.Fatalf("bad heredoc: %q", job.Meta["heredoc"])
+	}
+	if len(job.TaskGroups) != 0 {
+		t.Fatalf("variable injected task groups: %#v", job.TaskGroups)
+	}
+}
+
+func TestVariables(t *testing.T) {
+	src := []byte(`
+job "${var.name}" {
+  datacenters = ["${ var.dc }", "${var.name}-backup"]
+  meta {
+    escaped = "$${var.escaped}"
+  }
+}
+`)
+	if names := Variables(src); !reflect.DeepEqual(names, []string{"name", "dc"}) {
+		t.Fatalf("bad variables: %v", names)
+	}
+}
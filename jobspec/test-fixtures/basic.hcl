@@ -78,6 +78,14 @@ job "binstore-storagelocker" {
       logs {
         max_files     = 14
         max_file_size = 101
+
+        sink "loki" {
+          address = "http://loki.service.consul:3100/loki/api/v1/push"
+
+          labels {
+            env = "prod"
+          }
+        }
       }
 
       env {
@@ -0,0 +1,21 @@
+job "connectivity" {
+  group "group" {
+    task "task" {
+      driver = "docker"
+
+      connectivity_check {
+        name    = "db"
+        address = "${DB_ADDR}"
+        timeout = "1s"
+      }
+
+      connectivity_check {
+        name     = "self"
+        type     = "http"
+        port     = "http"
+        path     = "/health"
+        interval = "10s"
+      }
+    }
+  }
+}
@@ -0,0 +1,12 @@
+job "trading" {
+  group "engine" {
+    task "engine" {
+      driver = "exec"
+
+      resources {
+        cores  = 2
+        memory = 1024
+      }
+    }
+  }
+}
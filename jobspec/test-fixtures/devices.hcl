@@ -0,0 +1,17 @@
+job "appliance" {
+  group "router" {
+    task "router" {
+      driver = "exec"
+
+      resources {
+        device "sriov" {
+          name  = "eth1"
+          count = 2
+          mbits = 10000
+        }
+
+        device "sriov" {}
+      }
+    }
+  }
+}
@@ -0,0 +1,13 @@
+job "database" {
+  group "db" {
+    task "db" {
+      driver = "exec"
+
+      resources {
+        io_weight     = 500
+        io_read_mbps  = 200
+        io_write_mbps = 100
+      }
+    }
+  }
+}
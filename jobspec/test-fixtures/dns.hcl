@@ -0,0 +1,21 @@
+job "dns" {
+  group "cache" {
+    dns {
+      servers  = ["10.0.0.53"]
+      searches = ["${node.datacenter}.consul", "example.com"]
+      options  = ["ndots:2"]
+    }
+
+    task "redis" {
+      driver = "exec"
+    }
+
+    task "proxy" {
+      driver = "exec"
+
+      dns {
+        servers = ["${attr.unique.network.ip-address}"]
+      }
+    }
+  }
+}
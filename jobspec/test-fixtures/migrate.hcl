@@ -0,0 +1,21 @@
+job "migrate" {
+  group "cache" {
+    migrate {
+      deadline = "30m"
+    }
+
+    task "redis" {
+      driver = "exec"
+    }
+  }
+
+  group "scratch" {
+    migrate {
+      ignore = true
+    }
+
+    task "worker" {
+      driver = "exec"
+    }
+  }
+}
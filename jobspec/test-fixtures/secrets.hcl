@@ -0,0 +1,12 @@
+job "example" {
+    secrets {
+        vault = ["secret/data/example"]
+        variables = ["nomad/jobs/example"]
+        consul = ["config/example/db"]
+    }
+    group "cache" {
+        task "redis" {
+            driver = "docker"
+        }
+    }
+}
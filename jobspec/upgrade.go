@@ -0,0 +1,398 @@
+package jobspec
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/parser"
+	"github.com/hashicorp/hcl/hcl/token"
+)
+
+const (
+	// upgradeCommentPrefix starts the comments added by Upgrade
+	upgradeCommentPrefix = "# upgrade-spec:"
+
+	// upgradeCommentWidth is the width the comments are wrapped after
+	upgradeCommentWidth = 72
+)
+
+// Upgrade rewrites the deprecated stanzas and fields of an HCL job
+// specification to their current equivalents:
+//
+//   - The pre 0.6.0 "stagger" of update stanzas becomes "min_healthy_time".
+//   - Update stanzas, disallowed for batch jobs since 0.6.0, are removed.
+//   - The "disk" of task resources, deprecated in 0.5.0, moves to the
+//     "ephemeral_disk" of the task group.
+//   - The "ssl" option of the docker driver, unused since 0.6.0, is removed.
+//
+// A comment describing the change in behavior, if any, is added before each
+// rewritten field. The upgraded specification is returned in its canonical
+// format along with a description of each change. The source is returned
+// unmodified if there is nothing to upgrade.
+func Upgrade(src []byte) ([]byte, []string, error) {
+	u, err := newUpgrader(src)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(u.edits) == 0 {
+		return src, nil, nil
+	}
+
+	out, err := Format(u.apply())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to format the upgraded job: %v", err)
+	}
+
+	return out, upgradeLines(u.changes), nil
+}
+
+// Deprecations returns a description of each deprecated stanza and field of
+// an HCL job specification that Upgrade rewrites.
+func Deprecations(src []byte) ([]string, error) {
+	u, err := newUpgrader(src)
+	if err != nil {
+		return nil, err
+	}
+	return upgradeLines(u.deprecations), nil
+}
+
+// upgradeLines formats the changes in source order
+func upgradeLines(changes []upgradeChange) []string {
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].line < changes[j].line })
+	lines := make([]string, len(changes))
+	for i, c := range changes {
+		lines[i] = fmt.Sprintf("line %d: %s", c.line, c.desc)
+	}
+	return lines
+}
+
+// upgradeEdit replaces the source between two offsets
+type upgradeEdit struct {
+	start, end int
+	text       string
+}
+
+// upgrader collects the edits upgrading a job specification
+type upgrader struct {
+	src          []byte
+	edits        []upgradeEdit
+	changes      []upgradeChange
+	deprecations []upgradeChange
+}
+
+// upgradeChange describes a change made at a source line
+type upgradeChange struct {
+	line int
+	desc string
+}
+
+// newUpgrader returns the upgrader holding the edits upgrading the jobs of
+// the source
+func newUpgrader(src []byte) (*upgrader, error) {
+	file, err := parser.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &upgrader{src: src}
+	if list, ok := file.Node.(*ast.ObjectList); ok {
+		for _, job := range upgradeItems(list, "job") {
+			if obj, ok := job.Val.(*ast.ObjectType); ok {
+				u.job(obj)
+			}
+		}
+	}
+	return u, nil
+}
+
+func (u *upgrader) job(job *ast.ObjectType) {
+	batch := upgradeString(job.List, "type") == "batch"
+	groups := upgradeItems(job.List, "group")
+
+	for _, update := range upgradeItems(job.List, "update") {
+		if batch {
+			u.deprecated(update.Pos().Offset, "the update stanza is disallowed for batch jobs")
+			u.remove(update, "The update stanza is disallowed for batch jobs since Nomad 0.6.0 and was removed.")
+			continue
+		}
+		note := ""
+		if len(groups) > 1 {
+			note = "max_parallel now applies to each task group, where the automatic conversion used 10% of their count."
+		}
+		u.update(update, note)
+	}
+
+	for _, group := range groups {
+		obj, ok := group.Val.(*ast.ObjectType)
+		if !ok {
+			continue
+		}
+		for _, update := range upgradeItems(obj.List, "update") {
+			if batch {
+				u.deprecated(update.Pos().Offset, "the update stanza is disallowed for batch jobs")
+				u.remove(update, "The update stanza is disallowed for batch jobs since Nomad 0.6.0 and was removed.")
+				continue
+			}
+			u.update(update, "")
+		}
+		u.groupDisk(obj)
+		for _, task := range upgradeItems(obj.List, "task") {
+			u.task(task)
+		}
+	}
+
+	// Tasks outside of groups are placed in their own group
+	for _, task := range upgradeItems(job.List, "task") {
+		u.task(task)
+	}
+}
+
+// update replaces the deprecated stagger of an update stanza
+func (u *upgrader) update(update *ast.ObjectItem, note string) {
+	obj, ok := update.Val.(*ast.ObjectType)
+	if !ok {
+		return
+	}
+	for _, stagger := range upgradeItems(obj.List, "stagger") {
+		u.deprecated(stagger.Pos().Offset, "stagger is deprecated in favor of min_healthy_time")
+		if len(upgradeItems(obj.List, "min_healthy_time")) != 0 {
+			u.remove(stagger, "stagger was deprecated in Nomad 0.6.0 in favor of min_healthy_time and was removed.")
+			continue
+		}
+		u.rename(stagger, "min_healthy_time",
+			strings.TrimSpace("stagger was replaced by min_healthy_time in Nomad 0.6.0: allocations are now "+
+				"replaced once the previous ones are healthy rather than at a fixed interval. "+note))
+	}
+}
+
+// groupDisk moves the disk of the resources of the tasks of a group to its
+// ephemeral disk. The size of the ephemeral disk is the sum of their disk, as
+// it was computed for the deprecated field.
+func (u *upgrader) groupDisk(group *ast.ObjectType) {
+	var disks []*ast.ObjectItem
+	size := 0
+	for _, task := range upgradeItems(group.List, "task") {
+		obj, ok := task.Val.(*ast.ObjectType)
+		if !ok {
+			continue
+		}
+		for _, resources := range upgradeItems(obj.List, "resources") {
+			robj, ok := resources.Val.(*ast.ObjectType)
+			if !ok {
+				continue
+			}
+			for _, disk := range upgradeItems(robj.List, "disk") {
+				lit, ok := disk.Val.(*ast.LiteralType)
+				if !ok || lit.Token.Type != token.NUMBER {
+					// Leave disks that can't be summed alone
+					return
+				}
+				n, err := strconv.Atoi(lit.Token.Text)
+				if err != nil {
+					return
+				}
+				size += n
+				disks = append(disks, disk)
+			}
+		}
+	}
+	if len(disks) == 0 {
+		return
+	}
+
+	for _, disk := range disks {
+		u.deprecated(disk.Pos().Offset, "the resources disk is deprecated in favor of the ephemeral_disk of the group")
+		u.remove(disk, "The resources disk was deprecated in Nomad 0.5.0 and moved to the ephemeral_disk of the group.")
+	}
+
+	note := fmt.Sprintf("The ephemeral disk size is the sum of the resources disk of the tasks (%d MB).", size)
+	ephemeral := upgradeItems(group.List, "ephemeral_disk")
+	if len(ephemeral) == 0 {
+		// Add the ephemeral disk before the first task and its comments
+		task := upgradeItems(group.List, "task")[0]
+		offset := task.Pos().Offset
+		if task.LeadComment != nil {
+			offset = task.LeadComment.List[0].Start.Offset
+		}
+		offset = u.lineStart(offset)
+		u.insert(offset, upgradeComment(note)+fmt.Sprintf("ephemeral_disk {\nsize = %d\n}\n\n", size))
+		u.change(offset, "added ephemeral_disk")
+		return
+	}
+
+	obj, ok := ephemeral[0].Val.(*ast.ObjectType)
+	if !ok {
+		return
+	}
+	if sizes := upgradeItems(obj.List, "size"); len(sizes) != 0 {
+		u.comment(sizes[0].Pos().Offset, note)
+		u.replace(sizes[0].Val.Pos().Offset, upgradeEnd(sizes[0].Val), strconv.Itoa(size))
+		u.change(sizes[0].Pos().Offset, "set ephemeral_disk size")
+		return
+	}
+	u.insert(obj.Lbrace.Offset+1, "\n"+upgradeComment(note)+fmt.Sprintf("size = %d", size))
+	u.change(obj.Lbrace.Offset, "set ephemeral_disk size")
+}
+
+// task removes the deprecated driver options of a task
+func (u *upgrader) task(task *ast.ObjectItem) {
+	obj, ok := task.Val.(*ast.ObjectType)
+	if !ok || upgradeString(obj.List, "driver") != "docker" {
+		return
+	}
+	for _, config := range upgradeItems(obj.List, "config") {
+		cobj, ok := config.Val.(*ast.ObjectType)
+		if !ok {
+			continue
+		}
+		for _, ssl := range upgradeItems(cobj.List, "ssl") {
+			u.deprecated(ssl.Pos().Offset, "the ssl option of the docker driver is unused")
+			u.remove(ssl, "The docker ssl option is unused since Nomad 0.6.0 and was removed.")
+		}
+	}
+}
+
+// rename renames the key of an item
+func (u *upgrader) rename(item *ast.ObjectItem, key, note string) {
+	k := item.Keys[0].Token
+	u.comment(item.Pos().Offset, note)
+	u.replace(k.Pos.Offset, k.Pos.Offset+len(k.Text), key)
+	u.change(k.Pos.Offset, fmt.Sprintf("renamed %s to %s", k.Text, key))
+}
+
+// remove removes an item, along with its lines if it is alone on them
+func (u *upgrader) remove(item *ast.ObjectItem, note string) {
+	start, end := item.Pos().Offset, upgradeEnd(item.Val)
+	lineStart, lineEnd := u.lineStart(start), end
+	for lineEnd < len(u.src) && (u.src[lineEnd] == ' ' || u.src[lineEnd] == '\t') {
+		lineEnd++
+	}
+	if strings.TrimSpace(string(u.src[lineStart:start])) == "" &&
+		(lineEnd == len(u.src) || u.src[lineEnd] == '\n') {
+		start = lineStart
+		end = lineEnd
+		if end < len(u.src) {
+			end++
+		}
+	}
+
+	u.comment(item.Pos().Offset, note)
+	u.replace(start, end, "")
+	u.change(item.Pos().Offset, "removed "+item.Keys[0].Token.Text)
+}
+
+// comment adds a comment on its own lines before the line of the offset
+func (u *upgrader) comment(offset int, note string) {
+	u.insert(u.lineStart(offset), upgradeComment(note))
+}
+
+// change records a change at the offset
+func (u *upgrader) change(offset int, desc string) {
+	u.changes = append(u.changes, upgradeChange{line: u.line(offset), desc: desc})
+}
+
+// deprecated records the use of a deprecated field at the offset
+func (u *upgrader) deprecated(offset int, desc string) {
+	u.deprecations = append(u.deprecations, upgradeChange{line: u.line(offset), desc: desc})
+}
+
+func (u *upgrader) insert(offset int, text string) {
+	u.replace(offset, offset, text)
+}
+
+func (u *upgrader) replace(start, end int, text string) {
+	u.edits = append(u.edits, upgradeEdit{start: start, end: end, text: text})
+}
+
+// line returns the source line of the offset
+func (u *upgrader) line(offset int) int {
+	return strings.Count(string(u.src[:offset]), "\n") + 1
+}
+
+// lineStart returns the offset of the start of the line of the offset
+func (u *upgrader) lineStart(offset int) int {
+	for offset > 0 && u.src[offset-1] != '\n' {
+		offset--
+	}
+	return offset
+}
+
+// apply returns the source with the edits applied
+func (u *upgrader) apply() []byte {
+	// Apply the edits from the end of the source so that the offsets of the
+	// remaining ones stay valid. Of the edits starting at the same offset,
+	// replacements are applied before insertions so the inserted text isn't
+	// replaced.
+	sort.SliceStable(u.edits, func(i, j int) bool {
+		if u.edits[i].start != u.edits[j].start {
+			return u.edits[i].start > u.edits[j].start
+		}
+		return u.edits[i].end > u.edits[j].end
+	})
+
+	out := u.src
+	for _, e := range u.edits {
+		edited := make([]byte, 0, len(out)+len(e.text))
+		edited = append(edited, out[:e.start]...)
+		edited = append(edited, e.text...)
+		out = append(edited, out[e.end:]...)
+	}
+	return out
+}
+
+// upgradeComment returns the lines of a comment holding the note, wrapped
+// after upgradeCommentWidth columns
+func upgradeComment(note string) string {
+	var buf bytes.Buffer
+	line := upgradeCommentPrefix
+	for _, word := range strings.Fields(note) {
+		if len(line)+len(word) > upgradeCommentWidth && line != upgradeCommentPrefix && line != "#" {
+			buf.WriteString(line + "\n")
+			line = "#"
+		}
+		line += " " + word
+	}
+	buf.WriteString(line + "\n")
+	return buf.String()
+}
+
+// upgradeItems returns the items of the list whose first key is key
+func upgradeItems(list *ast.ObjectList, key string) []*ast.ObjectItem {
+	var items []*ast.ObjectItem
+	for _, item := range list.Items {
+		if len(item.Keys) == 0 {
+			continue
+		}
+		if k, ok := item.Keys[0].Token.Value().(string); ok && k == key {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// upgradeString returns the value of the string assignment to key in the list
+func upgradeString(list *ast.ObjectList, key string) string {
+	for _, item := range upgradeItems(list, key) {
+		if lit, ok := item.Val.(*ast.LiteralType); ok && lit.Token.Type == token.STRING {
+			return lit.Token.Value().(string)
+		}
+	}
+	return ""
+}
+
+// upgradeEnd returns the offset following a value
+func upgradeEnd(n ast.Node) int {
+	switch v := n.(type) {
+	case *ast.LiteralType:
+		return v.Token.Pos.Offset + len(v.Token.Text)
+	case *ast.ObjectType:
+		return v.Rbrace.Offset + 1
+	case *ast.ListType:
+		return v.Rbrack.Offset + 1
+	}
+	return n.Pos().Offset
+}
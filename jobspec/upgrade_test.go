@@ -0,0 +1,147 @@
+package jobspec
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUpgrade(t *testing.T) {
+	src, err := ioutil.ReadFile(filepath.Join("test-fixtures", "upgrade", "legacy.nomad"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected, err := ioutil.ReadFile(filepath.Join("test-fixtures", "upgrade", "upgraded.nomad"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, changes, err := Upgrade(src)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(out) != string(expected) {
+		t.Fatalf("bad upgrade:\n%s\nexpected:\n%s", out, expected)
+	}
+
+	expectedChanges := []string{
+		"line 6: renamed stagger to min_healthy_time",
+		"line 13: added ephemeral_disk",
+		"line 18: removed ssl",
+		"line 23: removed disk",
+		"line 36: removed disk",
+		"line 43: removed stagger",
+		"line 47: set ephemeral_disk size",
+		"line 55: removed disk",
+	}
+	if !reflect.DeepEqual(changes, expectedChanges) {
+		t.Fatalf("bad changes: %#v", changes)
+	}
+
+	// The upgraded job is parsed with the same disk sizes
+	job, err := Parse(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if size := *job.TaskGroups[0].EphemeralDisk.SizeMB; size != 400 {
+		t.Fatalf("bad ephemeral disk size: %d", size)
+	}
+	if size := *job.TaskGroups[1].EphemeralDisk.SizeMB; size != 500 {
+		t.Fatalf("bad ephemeral disk size: %d", size)
+	}
+	if job.Update.MinHealthyTime == nil || job.Update.Stagger != nil {
+		t.Fatalf("bad update: %#v", job.Update)
+	}
+
+	// Upgrading is idempotent
+	again, changes, err := Upgrade(out)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if string(again) != string(out) || len(changes) != 0 {
+		t.Fatalf("upgrade is not idempotent:\n%s\n%v", again, changes)
+	}
+}
+
+func TestUpgrade_Cases(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Src      string
+		Expected string
+	}{
+		{
+			Name:     "nothing to upgrade is left as is",
+			Src:      "job \"j\" {\n    update { max_parallel = 1 }\n}\n",
+			Expected: "job \"j\" {\n    update { max_parallel = 1 }\n}\n",
+		},
+		{
+			Name: "update stanzas of batch jobs are removed",
+			Src:  "job \"j\" {\n  type = \"batch\"\n  update {\n    stagger = \"10s\"\n  }\n  group \"g\" {\n    update {}\n  }\n}\n",
+			Expected: "job \"j\" {\n  type = \"batch\"\n" +
+				"  # upgrade-spec: The update stanza is disallowed for batch jobs since\n" +
+				"  # Nomad 0.6.0 and was removed.\n" +
+				"  group \"g\" {\n" +
+				"    # upgrade-spec: The update stanza is disallowed for batch jobs since\n" +
+				"    # Nomad 0.6.0 and was removed.\n" +
+				"  }\n}\n",
+		},
+		{
+			Name:     "disks that can't be summed are left as is",
+			Src:      "job \"j\" {\n  group \"g\" {\n    task \"t\" {\n      resources {\n        disk = \"${var}\"\n      }\n    }\n  }\n}\n",
+			Expected: "job \"j\" {\n  group \"g\" {\n    task \"t\" {\n      resources {\n        disk = \"${var}\"\n      }\n    }\n  }\n}\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			out, _, err := Upgrade([]byte(c.Src))
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if string(out) != c.Expected {
+				t.Fatalf("bad upgrade:\n%q\nexpected:\n%q", out, c.Expected)
+			}
+		})
+	}
+}
+
+func TestUpgrade_Invalid(t *testing.T) {
+	if _, _, err := Upgrade([]byte("job \"j\" {")); err == nil || !strings.Contains(err.Error(), "expected") {
+		t.Fatalf("expected parse error, got: %v", err)
+	}
+}
+
+func TestDeprecations(t *testing.T) {
+	src, err := ioutil.ReadFile(filepath.Join("test-fixtures", "upgrade", "legacy.nomad"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	deprecations, err := Deprecations(src)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := []string{
+		"line 6: stagger is deprecated in favor of min_healthy_time",
+		"line 18: the ssl option of the docker driver is unused",
+		"line 23: the resources disk is deprecated in favor of the ephemeral_disk of the group",
+		"line 36: the resources disk is deprecated in favor of the ephemeral_disk of the group",
+		"line 43: stagger is deprecated in favor of min_healthy_time",
+		"line 55: the resources disk is deprecated in favor of the ephemeral_disk of the group",
+	}
+	if !reflect.DeepEqual(deprecations, expected) {
+		t.Fatalf("bad deprecations: %#v", deprecations)
+	}
+
+	// The upgraded job has none
+	upgraded, err := ioutil.ReadFile(filepath.Join("test-fixtures", "upgrade", "upgraded.nomad"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if deprecations, err := Deprecations(upgraded); err != nil || len(deprecations) != 0 {
+		t.Fatalf("expected no deprecations; got %v, %v", deprecations, err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/hashicorp/nomad/command"
 	"github.com/hashicorp/nomad/version"
 	"github.com/mitchellh/cli"
 	"github.com/sean-/seed"
@@ -30,11 +31,18 @@ func RunCustom(args []string, commands map[string]cli.CommandFactory) int {
 	for k := range commands {
 		switch k {
 		case "deployment list", "deployment status", "deployment pause",
-			"deployment resume", "deployment fail", "deployment promote":
+			"deployment resume", "deployment fail", "deployment promote",
+			"deployment watch":
+		case "alloc checks", "alloc fs", "alloc history", "alloc stop":
+		case "eval delete", "eval list":
 		case "fs ls", "fs cat", "fs stat":
-		case "job deployments", "job dispatch", "job history", "job promote", "job revert":
+		case "job deployments", "job dispatch", "job eval", "job history", "job promote", "job restart", "job revert",
+			"job upgrade-spec", "job validate":
+		case "node meta", "node meta apply", "node meta read":
+		case "system gc":
 		case "namespace list", "namespace delete", "namespace apply", "namespace inspect", "namespace status":
-		case "quota list", "quota delete", "quota apply", "quota status", "quota inspect", "quota init":
+		case "quota list", "quota delete", "quota apply", "quota status", "quota inspect", "quota init",
+			"quota validate":
 		case "operator raft", "operator raft list-peers", "operator raft remove-peer":
 		case "acl policy", "acl policy apply", "acl token", "acl token create":
 		default:
@@ -48,6 +56,23 @@ func RunCustom(args []string, commands map[string]cli.CommandFactory) int {
 	// commands above.
 	hidden := []string{"check", "executor", "syslog"}
 
+	// The CLI library only installs the autocompletion in bash and zsh, so the
+	// flags are handled here to install it in fish and PowerShell too
+	switch autocompleteFlag(args) {
+	case "autocomplete-install":
+		if err := command.AutocompleteInstall("nomad"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error executing CLI: %s\n", err.Error())
+			return 1
+		}
+		return 0
+	case "autocomplete-uninstall":
+		if err := command.AutocompleteUninstall("nomad"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error executing CLI: %s\n", err.Error())
+			return 1
+		}
+		return 0
+	}
+
 	cli := &cli.CLI{
 		Name:           "nomad",
 		Version:        version.GetVersion().FullVersionNumber(true),
@@ -67,6 +92,21 @@ func RunCustom(args []string, commands map[string]cli.CommandFactory) int {
 	return exitCode
 }
 
+// autocompleteFlag returns the autocomplete install or uninstall flag if it is
+// given before the command.
+func autocompleteFlag(args []string) string {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return ""
+		}
+		switch flag := strings.TrimLeft(arg, "-"); flag {
+		case "autocomplete-install", "autocomplete-uninstall":
+			return flag
+		}
+	}
+	return ""
+}
+
 // helpFunc is a custom help function. At the moment it is essentially a copy of
 // the cli.BasicHelpFunc that includes flags demonstrating how to use the
 // autocomplete flags.
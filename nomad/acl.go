@@ -54,6 +54,9 @@ func resolveTokenFromSnapshotCache(snap *state.StateSnapshot, cache *lru.TwoQueu
 		if token == nil {
 			return nil, structs.ErrTokenNotFound
 		}
+		if token.IsExpired(time.Now()) {
+			return nil, structs.ErrTokenExpired
+		}
 	}
 
 	// Check if this is a management token
@@ -61,6 +64,19 @@ func resolveTokenFromSnapshotCache(snap *state.StateSnapshot, cache *lru.TwoQueu
 		return acl.ManagementACL, nil
 	}
 
+	// Workload tokens are scoped to the namespace of their allocation and only
+	// valid while it is running
+	if token.AllocID != "" {
+		alloc, err := snap.AllocByID(nil, token.AllocID)
+		if err != nil {
+			return nil, err
+		}
+		if alloc == nil || alloc.TerminalStatus() {
+			return nil, structs.ErrTokenNotFound
+		}
+		return structs.CompileACLObject(cache, []*structs.ACLPolicy{structs.WorkloadACLPolicy(alloc.Namespace)})
+	}
+
 	// Get all associated policies
 	policies := make([]*structs.ACLPolicy, 0, len(token.Policies))
 	for _, policyName := range token.Policies {
@@ -0,0 +1,322 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// UpsertAuthMethods is used to create or update a set of auth methods
+func (a *ACL) UpsertAuthMethods(args *structs.ACLAuthMethodUpsertRequest, reply *structs.GenericResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.UpsertAuthMethods", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "upsert_auth_methods"}, time.Now())
+
+	// Check management level permissions
+	if acl, err := a.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if acl == nil || !acl.IsManagement() {
+		return structs.ErrPermissionDenied
+	}
+
+	// Validate non-zero set of auth methods
+	if len(args.AuthMethods) == 0 {
+		return fmt.Errorf("must specify as least one auth method")
+	}
+	for idx, method := range args.AuthMethods {
+		if err := method.Validate(); err != nil {
+			return fmt.Errorf("auth method %d invalid: %v", idx, err)
+		}
+	}
+
+	// Update via Raft
+	_, index, err := a.srv.raftApply(structs.ACLAuthMethodUpsertRequestType, args)
+	if err != nil {
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// DeleteAuthMethods is used to delete auth methods
+func (a *ACL) DeleteAuthMethods(args *structs.ACLAuthMethodDeleteRequest, reply *structs.GenericResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.DeleteAuthMethods", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "delete_auth_methods"}, time.Now())
+
+	// Check management level permissions
+	if acl, err := a.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if acl == nil || !acl.IsManagement() {
+		return structs.ErrPermissionDenied
+	}
+
+	// Validate non-zero set of auth methods
+	if len(args.Names) == 0 {
+		return fmt.Errorf("must specify as least one auth method")
+	}
+
+	// Update via Raft
+	_, index, err := a.srv.raftApply(structs.ACLAuthMethodDeleteRequestType, args)
+	if err != nil {
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// ListAuthMethods is used to list the auth methods. No token is required since
+// users must be able to discover the methods they can authenticate with.
+func (a *ACL) ListAuthMethods(args *structs.ACLAuthMethodListRequest, reply *structs.ACLAuthMethodListResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.ListAuthMethods", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "list_auth_methods"}, time.Now())
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			iter, err := state.ACLAuthMethods(ws)
+			if err != nil {
+				return err
+			}
+
+			// Convert all the auth methods to a list stub
+			reply.AuthMethods = nil
+			for raw := iter.Next(); raw != nil; raw = iter.Next() {
+				reply.AuthMethods = append(reply.AuthMethods, raw.(*structs.ACLAuthMethod).Stub())
+			}
+
+			// Use the last index that affected the auth methods table
+			index, err := state.Index("acl_auth_methods")
+			if err != nil {
+				return err
+			}
+
+			// Ensure we never set the index to zero, otherwise a blocking query cannot be used.
+			if index == 0 {
+				index = 1
+			}
+			reply.Index = index
+			return nil
+		}}
+	return a.srv.blockingRPC(&opts)
+}
+
+// GetAuthMethod is used to get a specific auth method
+func (a *ACL) GetAuthMethod(args *structs.ACLAuthMethodSpecificRequest, reply *structs.SingleACLAuthMethodResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.GetAuthMethod", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "get_auth_method"}, time.Now())
+
+	// Check management level permissions since the config holds the client
+	// secret
+	if acl, err := a.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if acl == nil || !acl.IsManagement() {
+		return structs.ErrPermissionDenied
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			out, err := state.ACLAuthMethodByName(ws, args.Name)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.AuthMethod = out
+			if out != nil {
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the auth methods table
+				index, err := state.Index("acl_auth_methods")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+			return nil
+		}}
+	return a.srv.blockingRPC(&opts)
+}
+
+// OIDCAuthURL starts the OIDC flow of an auth method and returns the URL of the
+// provider the user authenticates at.
+func (a *ACL) OIDCAuthURL(args *structs.ACLOIDCAuthURLRequest, reply *structs.ACLOIDCAuthURLResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.OIDCAuthURL", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "oidc_auth_url"}, time.Now())
+
+	if args.ClientNonce == "" {
+		return fmt.Errorf("missing client nonce")
+	}
+	method, err := a.oidcAuthMethod(args.AuthMethod, args.RedirectURI)
+	if err != nil {
+		return err
+	}
+
+	provider, err := discoverOIDCProvider(method.Config)
+	if err != nil {
+		return err
+	}
+
+	req := &oidcRequest{
+		authMethod:  method.Name,
+		clientNonce: args.ClientNonce,
+		redirectURI: args.RedirectURI,
+		nonce:       uuid.Generate(),
+	}
+	state := a.srv.oidcRequests.add(req, time.Now())
+	reply.AuthURL = provider.authURL(method.Config, args.RedirectURI, state, req.nonce)
+	return nil
+}
+
+// OIDCCompleteAuth completes the OIDC flow of an auth method. The identity of
+// the user is exchanged for a local ACL token that expires after the token TTL
+// of the auth method.
+func (a *ACL) OIDCCompleteAuth(args *structs.ACLOIDCCompleteAuthRequest, reply *structs.ACLTokenUpsertResponse) error {
+	if !a.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := a.srv.forward("ACL.OIDCCompleteAuth", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "acl", "oidc_complete_auth"}, time.Now())
+
+	// Check the flow was started by this client
+	now := time.Now()
+	req := a.srv.oidcRequests.complete(args.State, now)
+	if req == nil {
+		return fmt.Errorf("unknown or expired OIDC state")
+	}
+	if req.authMethod != args.AuthMethod || req.clientNonce != args.ClientNonce || req.redirectURI != args.RedirectURI {
+		return fmt.Errorf("OIDC state doesn't match the request")
+	}
+
+	method, err := a.oidcAuthMethod(args.AuthMethod, args.RedirectURI)
+	if err != nil {
+		return err
+	}
+	provider, err := discoverOIDCProvider(method.Config)
+	if err != nil {
+		return err
+	}
+
+	// Exchange the code for the identity of the user
+	idToken, err := provider.exchange(method.Config, args.Code, args.RedirectURI)
+	if err != nil {
+		return err
+	}
+	claims, err := provider.verify(method.Config, idToken, req.nonce, now)
+	if err != nil {
+		return err
+	}
+
+	policies := oidcTokenPolicies(method.Config, claims)
+	if len(policies) == 0 {
+		return fmt.Errorf("no policies are associated with the identity")
+	}
+
+	subject, _ := claims["sub"].(string)
+	if email, ok := claims["email"].(string); ok && email != "" {
+		subject = email
+	}
+	expiration := now.Add(method.TokenTTL()).UTC()
+	token := &structs.ACLToken{
+		AccessorID:     uuid.Generate(),
+		SecretID:       uuid.Generate(),
+		Name:           fmt.Sprintf("%s: %s", method.Name, subject),
+		Type:           structs.ACLClientToken,
+		Policies:       policies,
+		CreateTime:     now.UTC(),
+		ExpirationTime: &expiration,
+		AuthMethod:     method.Name,
+	}
+	if err := token.Validate(); err != nil {
+		return err
+	}
+	token.SetHash()
+
+	// Update via Raft
+	upsert := &structs.ACLTokenUpsertRequest{
+		Tokens:       []*structs.ACLToken{token},
+		WriteRequest: args.WriteRequest,
+	}
+	_, index, err := a.srv.raftApply(structs.ACLTokenUpsertRequestType, upsert)
+	if err != nil {
+		return err
+	}
+
+	// Populate the response from the state to pickup the indexes
+	snap, err := a.srv.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	out, err := snap.ACLTokenByAccessorID(nil, token.AccessorID)
+	if err != nil {
+		return fmt.Errorf("token lookup failed: %v", err)
+	}
+	reply.Tokens = append(reply.Tokens, out)
+	reply.Index = index
+	return nil
+}
+
+// oidcAuthMethod returns the OIDC auth method with the given name, checking
+// that the redirect URI is allowed.
+func (a *ACL) oidcAuthMethod(name, redirectURI string) (*structs.ACLAuthMethod, error) {
+	snap, err := a.srv.State().Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	method, err := snap.ACLAuthMethodByName(nil, name)
+	if err != nil {
+		return nil, err
+	}
+	if method == nil {
+		return nil, fmt.Errorf("auth method %q not found", name)
+	}
+	if method.Type != structs.ACLAuthMethodTypeOIDC {
+		return nil, fmt.Errorf("auth method %q is not an OIDC auth method", name)
+	}
+
+	for _, uri := range method.Config.AllowedRedirectURIs {
+		if uri == redirectURI {
+			return method, nil
+		}
+	}
+	return nil, fmt.Errorf("redirect URI %q is not allowed by auth method %q", redirectURI, name)
+}
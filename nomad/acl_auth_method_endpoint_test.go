@@ -0,0 +1,300 @@
+package nomad
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// testOIDCProvider is a fake OpenID Connect provider issuing identity tokens
+// with the claims of the test.
+type testOIDCProvider struct {
+	*httptest.Server
+	t   *testing.T
+	key *rsa.PrivateKey
+
+	l      sync.Mutex
+	nonce  string
+	claims map[string]interface{}
+}
+
+func newTestOIDCProvider(t *testing.T) *testOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	p := &testOIDCProvider{t: t, key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/auth",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "nomad" || secret != "secret" || r.FormValue("code") != "code" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": p.idToken()})
+	})
+	p.Server = httptest.NewServer(mux)
+	return p
+}
+
+// start records the nonce of the auth URL and returns its state.
+func (p *testOIDCProvider) start(authURL string) string {
+	u, err := url.Parse(authURL)
+	require.NoError(p.t, err)
+	p.l.Lock()
+	p.nonce = u.Query().Get("nonce")
+	p.l.Unlock()
+	return u.Query().Get("state")
+}
+
+func (p *testOIDCProvider) idToken() string {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	claims := map[string]interface{}{
+		"iss":   p.URL,
+		"aud":   "nomad",
+		"sub":   "1234",
+		"nonce": p.nonce,
+		"exp":   time.Now().Add(time.Minute).Unix(),
+	}
+	for k, v := range p.claims {
+		claims[k] = v
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	require.NoError(p.t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func testOIDCAuthMethod(issuer string) *structs.ACLAuthMethod {
+	return &structs.ACLAuthMethod{
+		Name:        "okta",
+		Type:        structs.ACLAuthMethodTypeOIDC,
+		MaxTokenTTL: 30 * time.Minute,
+		Config: &structs.ACLAuthMethodConfig{
+			OIDCDiscoveryURL:    issuer,
+			OIDCClientID:        "nomad",
+			OIDCClientSecret:    "secret",
+			AllowedRedirectURIs: []string{"http://localhost:4649/oidc/callback"},
+			Policies:            []string{"readonly"},
+			GroupsClaim:         "groups",
+			GroupPolicies: map[string][]string{
+				"ops": {"ops"},
+			},
+		},
+	}
+}
+
+func TestACLEndpoint_AuthMethods(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	method := testOIDCAuthMethod("https://example.com")
+
+	// Upserting requires a management token
+	req := &structs.ACLAuthMethodUpsertRequest{
+		AuthMethods:  []*structs.ACLAuthMethod{method},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	err := msgpackrpc.CallWithCodec(codec, "ACL.UpsertAuthMethods", req, &resp)
+	require.EqualError(err, structs.ErrPermissionDenied.Error())
+
+	req.AuthToken = root.SecretID
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.UpsertAuthMethods", req, &resp))
+	require.NotZero(resp.Index)
+
+	// Invalid methods are rejected
+	invalid := testOIDCAuthMethod("")
+	req.AuthMethods = []*structs.ACLAuthMethod{invalid}
+	err = msgpackrpc.CallWithCodec(codec, "ACL.UpsertAuthMethods", req, &resp)
+	require.Error(err)
+	require.Contains(err.Error(), "invalid OIDC discovery URL")
+
+	// Listing doesn't require a token and hides the config
+	listReq := &structs.ACLAuthMethodListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var listResp structs.ACLAuthMethodListResponse
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.ListAuthMethods", listReq, &listResp))
+	require.Len(listResp.AuthMethods, 1)
+	require.Equal("okta", listResp.AuthMethods[0].Name)
+
+	// Reading requires a management token
+	getReq := &structs.ACLAuthMethodSpecificRequest{
+		Name:         "okta",
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var getResp structs.SingleACLAuthMethodResponse
+	err = msgpackrpc.CallWithCodec(codec, "ACL.GetAuthMethod", getReq, &getResp)
+	require.EqualError(err, structs.ErrPermissionDenied.Error())
+
+	getReq.AuthToken = root.SecretID
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.GetAuthMethod", getReq, &getResp))
+	require.NotNil(getResp.AuthMethod)
+	require.Equal("secret", getResp.AuthMethod.Config.OIDCClientSecret)
+
+	// Delete the method
+	delReq := &structs.ACLAuthMethodDeleteRequest{
+		Names: []string{"okta"},
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			AuthToken: root.SecretID,
+		},
+	}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.DeleteAuthMethods", delReq, &resp))
+	out, err := s1.fsm.State().ACLAuthMethodByName(nil, "okta")
+	require.NoError(err)
+	require.Nil(out)
+}
+
+func TestACLEndpoint_OIDCLogin(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	provider := newTestOIDCProvider(t)
+	defer provider.Close()
+	provider.claims = map[string]interface{}{
+		"email":  "alice@example.com",
+		"groups": []string{"ops", "dev"},
+	}
+
+	method := testOIDCAuthMethod(provider.URL)
+	req := &structs.ACLAuthMethodUpsertRequest{
+		AuthMethods: []*structs.ACLAuthMethod{method},
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			AuthToken: root.SecretID,
+		},
+	}
+	var resp structs.GenericResponse
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.UpsertAuthMethods", req, &resp))
+
+	redirectURI := method.Config.AllowedRedirectURIs[0]
+
+	// Redirect URIs that aren't allowed are rejected
+	urlReq := &structs.ACLOIDCAuthURLRequest{
+		AuthMethod:   "okta",
+		RedirectURI:  "http://evil.com/callback",
+		ClientNonce:  "client-nonce",
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var urlResp structs.ACLOIDCAuthURLResponse
+	err := msgpackrpc.CallWithCodec(codec, "ACL.OIDCAuthURL", urlReq, &urlResp)
+	require.Error(err)
+	require.Contains(err.Error(), "is not allowed")
+
+	urlReq.RedirectURI = redirectURI
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.OIDCAuthURL", urlReq, &urlResp))
+	state := provider.start(urlResp.AuthURL)
+	require.NotEmpty(state)
+
+	// A different client can't complete the flow, which also consumes it
+	completeReq := &structs.ACLOIDCCompleteAuthRequest{
+		AuthMethod:   "okta",
+		ClientNonce:  "other-nonce",
+		State:        state,
+		Code:         "code",
+		RedirectURI:  redirectURI,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var completeResp structs.ACLTokenUpsertResponse
+	err = msgpackrpc.CallWithCodec(codec, "ACL.OIDCCompleteAuth", completeReq, &completeResp)
+	require.Error(err)
+	require.Contains(err.Error(), "doesn't match")
+
+	// Restart the flow and complete it
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.OIDCAuthURL", urlReq, &urlResp))
+	completeReq.State = provider.start(urlResp.AuthURL)
+	completeReq.ClientNonce = "client-nonce"
+	before := time.Now()
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.OIDCCompleteAuth", completeReq, &completeResp))
+	require.Len(completeResp.Tokens, 1)
+
+	token := completeResp.Tokens[0]
+	require.Equal("okta: alice@example.com", token.Name)
+	require.Equal(structs.ACLClientToken, token.Type)
+	require.Equal([]string{"readonly", "ops"}, token.Policies)
+	require.Equal("okta", token.AuthMethod)
+	require.False(token.Global)
+	require.NotNil(token.ExpirationTime)
+	require.WithinDuration(before.Add(30*time.Minute), *token.ExpirationTime, 10*time.Second)
+
+	out, err := s1.fsm.State().ACLTokenBySecretID(nil, token.SecretID)
+	require.NoError(err)
+	require.NotNil(out)
+
+	// The flow can only be completed once
+	err = msgpackrpc.CallWithCodec(codec, "ACL.OIDCCompleteAuth", completeReq, &completeResp)
+	require.Error(err)
+	require.Contains(err.Error(), "unknown or expired")
+}
+
+func TestResolveACLToken_Expired(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s1, _ := testACLServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	expired := time.Now().Add(-time.Minute)
+	token := &structs.ACLToken{
+		AccessorID:     "b1a4ee6c-9769-4f82-9cf2-39cfe6e5e2fc",
+		SecretID:       "0a15e8b3-2cbb-4e0c-9c0b-4d6e2e4d1d3a",
+		Name:           "expired",
+		Type:           structs.ACLClientToken,
+		Policies:       []string{"readonly"},
+		CreateTime:     time.Now().Add(-time.Hour),
+		ExpirationTime: &expired,
+	}
+	token.SetHash()
+	require.NoError(s1.fsm.State().UpsertACLTokens(1000, []*structs.ACLToken{token}))
+
+	_, err := s1.ResolveToken(token.SecretID)
+	require.Equal(structs.ErrTokenExpired, err)
+}
@@ -494,6 +494,9 @@ func (a *ACL) UpsertTokens(args *structs.ACLTokenUpsertRequest, reply *structs.A
 		if err := token.Validate(); err != nil {
 			return fmt.Errorf("token %d invalid: %v", idx, err)
 		}
+		if token.AllocID != "" {
+			return fmt.Errorf("token %d invalid: workload tokens are created by clients", idx)
+		}
 
 		// Generate an accessor and secret ID if new
 		if token.AccessorID == "" {
@@ -515,6 +518,15 @@ func (a *ACL) UpsertTokens(args *structs.ACLTokenUpsertRequest, reply *structs.A
 			if token.Global != out.Global {
 				return fmt.Errorf("cannot toggle global mode of %s", token.AccessorID)
 			}
+
+			// Workload tokens are managed by the servers
+			if out.AllocID != "" {
+				return fmt.Errorf("cannot modify workload token %s", token.AccessorID)
+			}
+
+			// Tokens created by auth methods keep expiring
+			token.ExpirationTime = out.ExpirationTime
+			token.AuthMethod = out.AuthMethod
 		}
 
 		// Compute the token hash
@@ -1116,6 +1116,41 @@ func TestACLEndpoint_UpsertTokens_Invalid(t *testing.T) {
 	}
 }
 
+func TestACLEndpoint_UpsertTokens_Workload(t *testing.T) {
+	t.Parallel()
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Workload tokens can't be created
+	p1 := mock.ACLToken()
+	p1.AccessorID = ""
+	p1.AllocID = uuid.Generate()
+	req := &structs.ACLTokenUpsertRequest{
+		Tokens: []*structs.ACLToken{p1},
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			AuthToken: root.SecretID,
+		},
+	}
+	var resp structs.ACLTokenUpsertResponse
+	err := msgpackrpc.CallWithCodec(codec, "ACL.UpsertTokens", req, &resp)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "workload tokens are created by clients")
+
+	// Nor modified
+	p2 := mock.ACLToken()
+	p2.AllocID = uuid.Generate()
+	assert.Nil(t, s1.fsm.State().UpsertACLTokens(1000, []*structs.ACLToken{p2}))
+	update := *p2
+	update.AllocID = ""
+	req.Tokens = []*structs.ACLToken{&update}
+	err = msgpackrpc.CallWithCodec(codec, "ACL.UpsertTokens", req, &resp)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "cannot modify workload token")
+}
+
 func TestACLEndpoint_ResolveToken(t *testing.T) {
 	t.Parallel()
 	s1, _ := testACLServer(t, nil)
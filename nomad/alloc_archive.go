@@ -0,0 +1,194 @@
+package nomad
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
+	"github.com/ugorji/go/codec"
+)
+
+// allocArchiveStore is the storage terminal allocations are archived to. It
+// reuses the sinks of the state exporter.
+type allocArchiveStore interface {
+	stateExportSink
+
+	// List returns the keys of the objects below the key prefix.
+	List(prefix string) ([]string, error)
+}
+
+// List returns the keys of the objects below the key prefix, which must end
+// with a slash, implementing allocArchiveStore.
+func (f *fileExportSink) List(prefix string) ([]string, error) {
+	root := filepath.Join(f.dir, filepath.FromSlash(prefix))
+	var keys []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(f.dir, path)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, filepath.ToSlash(rel))
+		return nil
+	})
+	return keys, err
+}
+
+// List returns the keys of the objects below the key prefix, implementing
+// allocArchiveStore.
+func (s *s3ExportSink) List(prefix string) ([]string, error) {
+	var keys []string
+	input := &s3.ListObjectsInput{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(s.prefix + prefix),
+	}
+	err := s.client.ListObjectsPages(input, func(page *s3.ListObjectsOutput, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			keys = append(keys, strings.TrimPrefix(aws.StringValue(obj.Key), s.prefix))
+		}
+		return true
+	})
+	return keys, err
+}
+
+// newAllocArchiveStore returns the store configured by the allocation archive
+// config.
+func newAllocArchiveStore(c *config.AllocArchiveConfig) (allocArchiveStore, error) {
+	switch c.Store {
+	case "file":
+		if c.Path == "" {
+			return nil, fmt.Errorf("file store requires a path")
+		}
+		return &fileExportSink{dir: c.Path}, nil
+	case "s3":
+		if c.Bucket == "" {
+			return nil, fmt.Errorf("s3 store requires a bucket")
+		}
+		return newS3ExportSink(c.Bucket, c.Prefix, c.Region, c.Endpoint), nil
+	default:
+		return nil, fmt.Errorf("unsupported store %q: must be \"file\" or \"s3\"", c.Store)
+	}
+}
+
+// allocArchivePrefix returns the key prefix of the archived allocations of a
+// job. IDs are escaped so that the allocations of child jobs, whose IDs are
+// prefixed by the parent's, are kept apart.
+func allocArchivePrefix(namespace, jobID string) string {
+	return fmt.Sprintf("allocs/%s/%s/", url.PathEscape(namespace), url.PathEscape(jobID))
+}
+
+// allocArchiveKey returns the key of an archived allocation. Keys are ordered
+// by the time the allocation was last modified, which for terminal
+// allocations is when they stopped, so that the history of a job can be
+// filtered without reading every object.
+func allocArchiveKey(a *structs.ArchivedAllocation) string {
+	return fmt.Sprintf("%s%020d-%s.json", allocArchivePrefix(a.Namespace, a.JobID), a.ModifyTime, a.ID)
+}
+
+// archiveAllocs writes the terminal allocations among the given IDs to the
+// allocation archive. Allocations that no longer exist are skipped. It is a
+// no-op if the archive is disabled.
+func (s *Server) archiveAllocs(snap *state.StateSnapshot, ids []string) error {
+	if s.allocArchive == nil || len(ids) == 0 {
+		return nil
+	}
+
+	now := time.Now().UnixNano()
+	archived := 0
+	for _, id := range ids {
+		alloc, err := snap.AllocByID(nil, id)
+		if err != nil {
+			return err
+		}
+		if alloc == nil || !alloc.TerminalStatus() {
+			continue
+		}
+
+		summary := alloc.Archive()
+		summary.ArchiveTime = now
+		node, err := snap.NodeByID(nil, alloc.NodeID)
+		if err != nil {
+			return err
+		}
+		if node != nil {
+			summary.NodeName = node.Name
+		}
+
+		key := allocArchiveKey(summary)
+		var buf bytes.Buffer
+		if err := codec.NewEncoder(&buf, structs.JsonHandle).Encode(summary); err != nil {
+			return fmt.Errorf("failed to encode %q: %v", key, err)
+		}
+		if err := s.allocArchive.Put(key, buf.Bytes()); err != nil {
+			return fmt.Errorf("failed to write %q: %v", key, err)
+		}
+		archived++
+	}
+
+	metrics.IncrCounter([]string{"nomad", "alloc_archive", "archived"}, float32(archived))
+	return nil
+}
+
+// allocHistory returns the archived allocations of a job that were last
+// modified at or after the since Unix nanosecond timestamp, oldest first.
+func (s *Server) allocHistory(namespace, jobID string, since int64) ([]*structs.ArchivedAllocation, error) {
+	if s.allocArchive == nil {
+		return nil, fmt.Errorf("allocation archive is not enabled")
+	}
+
+	prefix := allocArchivePrefix(namespace, jobID)
+	keys, err := s.allocArchive.List(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived allocations: %v", err)
+	}
+	sort.Strings(keys)
+
+	out := make([]*structs.ArchivedAllocation, 0, len(keys))
+	for _, key := range keys {
+		// Skip objects that weren't written by the archive
+		name := strings.TrimPrefix(key, prefix)
+		i := strings.IndexByte(name, '-')
+		if i < 0 || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		modifyTime, err := strconv.ParseInt(name[:i], 10, 64)
+		if err != nil || modifyTime < since {
+			continue
+		}
+
+		data, err := s.allocArchive.Get(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %q: %v", key, err)
+		}
+		if data == nil {
+			continue
+		}
+
+		var alloc structs.ArchivedAllocation
+		if err := codec.NewDecoderBytes(data, structs.JsonHandle).Decode(&alloc); err != nil {
+			return nil, fmt.Errorf("failed to decode %q: %v", key, err)
+		}
+		out = append(out, &alloc)
+	}
+	return out, nil
+}
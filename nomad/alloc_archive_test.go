@@ -0,0 +1,97 @@
+package nomad
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllocArchive_History(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	s := &Server{allocArchive: &fileExportSink{dir: dir}}
+
+	state := state.TestStateStore(t)
+	node := mock.Node()
+	assert.Nil(state.UpsertNode(1000, node))
+
+	now := time.Now()
+	failed := mock.Alloc()
+	failed.NodeID = node.ID
+	failed.ClientStatus = structs.AllocClientStatusFailed
+	failed.ModifyTime = now.Add(-48 * time.Hour).UnixNano()
+	failed.TaskStates = map[string]*structs.TaskState{
+		"web": {
+			State:      structs.TaskStateDead,
+			Failed:     true,
+			StartedAt:  now.Add(-49 * time.Hour),
+			FinishedAt: now.Add(-48 * time.Hour),
+			Events: []*structs.TaskEvent{
+				{Type: structs.TaskStarted},
+				{Type: structs.TaskTerminated, ExitCode: 2, Message: "boom"},
+				{Type: structs.TaskNotRestarting},
+			},
+		},
+	}
+
+	complete := mock.Alloc()
+	complete.JobID = failed.JobID
+	complete.ClientStatus = structs.AllocClientStatusComplete
+	complete.ModifyTime = now.Add(-time.Hour).UnixNano()
+
+	// Running allocations and allocations of child jobs are kept apart
+	running := mock.Alloc()
+	running.JobID = failed.JobID
+	child := mock.Alloc()
+	child.JobID = failed.JobID + "/periodic-1"
+	child.ClientStatus = structs.AllocClientStatusComplete
+
+	allocs := []*structs.Allocation{failed, complete, running, child}
+	assert.Nil(state.UpsertAllocs(1001, allocs))
+	snap, err := state.Snapshot()
+	assert.Nil(err)
+	ids := []string{failed.ID, complete.ID, running.ID, child.ID, uuid.Generate()}
+	assert.Nil(s.archiveAllocs(snap, ids))
+
+	out, err := s.allocHistory(failed.Namespace, failed.JobID, 0)
+	assert.Nil(err)
+	if assert.Len(out, 2) {
+		assert.Equal(failed.ID, out[0].ID)
+		assert.Equal(node.Name, out[0].NodeName)
+		assert.Equal(failed.Resources.CPU, out[0].Resources.CPU)
+		assert.NotZero(out[0].ArchiveTime)
+		if assert.Contains(out[0].TaskStates, "web") {
+			web := out[0].TaskStates["web"]
+			assert.True(web.Failed)
+			assert.Equal(2, web.ExitCode)
+			assert.Equal("boom", web.ExitMessage)
+		}
+		assert.Equal(complete.ID, out[1].ID)
+	}
+
+	// Allocations that stopped before since are filtered out
+	out, err = s.allocHistory(failed.Namespace, failed.JobID, now.Add(-24*time.Hour).UnixNano())
+	assert.Nil(err)
+	if assert.Len(out, 1) {
+		assert.Equal(complete.ID, out[0].ID)
+	}
+
+	out, err = s.allocHistory(child.Namespace, child.JobID, 0)
+	assert.Nil(err)
+	assert.Len(out, 1)
+
+	// The history can't be queried without an archive
+	_, err = (&Server{}).allocHistory(failed.Namespace, failed.JobID, 0)
+	assert.NotNil(err)
+}
@@ -1,12 +1,14 @@
 package nomad
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-memdb"
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/helper/uuid"
 	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
@@ -72,6 +74,42 @@ func (a *Alloc) List(args *structs.AllocListRequest, reply *structs.AllocListRes
 	return a.srv.blockingRPC(&opts)
 }
 
+// History is used to list the archived allocations of a job, including
+// those that were garbage collected
+func (a *Alloc) History(args *structs.AllocHistoryRequest, reply *structs.AllocHistoryResponse) error {
+	if done, err := a.srv.forward("Alloc.History", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "alloc", "history"}, time.Now())
+
+	// Check namespace read-job permissions
+	if aclObj, err := a.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityReadJob) {
+		return structs.ErrPermissionDenied
+	}
+
+	if args.JobID == "" {
+		return fmt.Errorf("missing job ID")
+	}
+
+	allocs, err := a.srv.allocHistory(args.RequestNamespace(), args.JobID, args.Since)
+	if err != nil {
+		return err
+	}
+	reply.Allocations = allocs
+
+	// The archive is outside of the state store so use the index of the
+	// allocs table, which changes when allocations are garbage collected
+	index, err := a.srv.fsm.State().Index("allocs")
+	if err != nil {
+		return err
+	}
+	reply.Index = index
+	a.srv.setQueryMeta(&reply.QueryMeta)
+	return nil
+}
+
 // GetAlloc is used to lookup a particular allocation
 func (a *Alloc) GetAlloc(args *structs.AllocSpecificRequest,
 	reply *structs.SingleAllocResponse) error {
@@ -200,3 +238,109 @@ func (a *Alloc) GetAllocs(args *structs.AllocsGetRequest,
 	}
 	return a.srv.blockingRPC(&opts)
 }
+
+// StopBatch is used to stop the running allocations of the namespace that
+// match a filter. The stopped allocations are replaced by the evaluations
+// created for their jobs. A batch of allocations is stopped per request so that
+// large cleanups can report their progress.
+func (a *Alloc) StopBatch(args *structs.AllocBatchStopRequest, reply *structs.AllocBatchStopResponse) error {
+	if done, err := a.srv.forward("Alloc.StopBatch", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "alloc", "stop_batch"}, time.Now())
+
+	// Check for submit-job permissions
+	if aclObj, err := a.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilitySubmitJob) {
+		return structs.ErrPermissionDenied
+	}
+
+	f, err := parseBulkFilter(args.Filter, &structs.Allocation{})
+	if err != nil {
+		return err
+	}
+
+	snap, err := a.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	iter, err := snap.AllocsByNamespace(nil, args.RequestNamespace())
+	if err != nil {
+		return err
+	}
+
+	// Collect a batch of matching allocations and an evaluation per job to
+	// replace them
+	batchSize := bulkBatchSize(args.BatchSize)
+	req := &structs.AllocStopRequest{
+		ModifyTime:   time.Now().UTC().UnixNano(),
+		WriteRequest: args.WriteRequest,
+	}
+	jobs := make(map[string]struct{})
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		alloc := raw.(*structs.Allocation)
+		if alloc.ID <= args.NextToken || alloc.TerminalStatus() {
+			continue
+		}
+		match, err := f.Match(alloc)
+		if err != nil {
+			return err
+		}
+		if !match {
+			continue
+		}
+
+		// Stop once the batch is full, there are more allocations to check
+		if len(req.AllocIDs) == batchSize {
+			reply.NextToken = req.AllocIDs[len(req.AllocIDs)-1]
+			break
+		}
+		req.AllocIDs = append(req.AllocIDs, alloc.ID)
+
+		if _, ok := jobs[alloc.JobID]; ok {
+			continue
+		}
+		jobs[alloc.JobID] = struct{}{}
+
+		job, err := snap.JobByID(nil, alloc.Namespace, alloc.JobID)
+		if err != nil {
+			return err
+		}
+		if job == nil || job.Stopped() {
+			continue
+		}
+		eval := &structs.Evaluation{
+			ID:             uuid.Generate(),
+			Namespace:      alloc.Namespace,
+			Priority:       job.Priority,
+			Type:           job.Type,
+			TriggeredBy:    structs.EvalTriggerAllocStop,
+			JobID:          job.ID,
+			JobModifyIndex: job.ModifyIndex,
+			Status:         structs.EvalStatusPending,
+		}
+		req.Evals = append(req.Evals, eval)
+		reply.EvalIDs = append(reply.EvalIDs, eval.ID)
+	}
+
+	if len(req.AllocIDs) == 0 {
+		index, err := snap.Index("allocs")
+		if err != nil {
+			return err
+		}
+		reply.Index = index
+		return nil
+	}
+
+	// Commit the stops and evaluations via Raft
+	_, index, err := a.srv.raftApply(structs.AllocStopRequestType, req)
+	if err != nil {
+		a.srv.logger.Printf("[ERR] nomad.alloc: Alloc stop failed: %v", err)
+		return err
+	}
+
+	reply.Stopped = len(req.AllocIDs)
+	reply.Index = index
+	return nil
+}
@@ -1,14 +1,18 @@
 package nomad
 
 import (
+	"io/ioutil"
+	"os"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/net-rpc-msgpackrpc"
 	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/stretchr/testify/assert"
 )
@@ -210,6 +214,73 @@ func TestAllocEndpoint_List_Blocking(t *testing.T) {
 	}
 }
 
+func TestAllocEndpoint_History(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	s1, root := testACLServer(t, func(c *Config) {
+		c.AllocArchiveConfig = &config.AllocArchiveConfig{
+			Enabled: helper.BoolToPtr(true),
+			Store:   "file",
+			Path:    dir,
+		}
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Archive a terminal alloc
+	state := s1.fsm.State()
+	alloc := mock.Alloc()
+	alloc.ClientStatus = structs.AllocClientStatusComplete
+	alloc.ModifyTime = time.Now().UnixNano()
+	assert.Nil(state.UpsertJobSummary(999, mock.JobSummary(alloc.JobID)))
+	assert.Nil(state.UpsertAllocs(1000, []*structs.Allocation{alloc}))
+	snap, err := state.Snapshot()
+	assert.Nil(err)
+	assert.Nil(s1.archiveAllocs(snap, []string{alloc.ID}))
+
+	get := &structs.AllocHistoryRequest{
+		JobID: alloc.JobID,
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			Namespace: structs.DefaultNamespace,
+		},
+	}
+
+	// Lookup the history without a token
+	var resp structs.AllocHistoryResponse
+	err = msgpackrpc.CallWithCodec(codec, "Alloc.History", get, &resp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), structs.ErrPermissionDenied.Error())
+	}
+
+	// Lookup the history with a token that can read the job
+	token := mock.CreatePolicyAndToken(t, state, 1001, "test-valid",
+		mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilityReadJob}))
+	get.AuthToken = token.SecretID
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Alloc.History", get, &resp))
+	assert.EqualValues(1000, resp.Index)
+	if assert.Len(resp.Allocations, 1) {
+		assert.Equal(alloc.ID, resp.Allocations[0].ID)
+	}
+
+	// Allocations that stopped before since are filtered out
+	get.AuthToken = root.SecretID
+	get.Since = time.Now().Add(time.Minute).UnixNano()
+	var resp2 structs.AllocHistoryResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Alloc.History", get, &resp2))
+	assert.Empty(resp2.Allocations)
+
+	// The job is required
+	get.JobID = ""
+	assert.NotNil(msgpackrpc.CallWithCodec(codec, "Alloc.History", get, &resp))
+}
+
 func TestAllocEndpoint_GetAlloc(t *testing.T) {
 	t.Parallel()
 	s1 := testServer(t, nil)
@@ -474,3 +545,70 @@ func TestAllocEndpoint_GetAllocs_Blocking(t *testing.T) {
 		t.Fatalf("bad: %#v", resp.Allocs)
 	}
 }
+
+func TestAllocEndpoint_StopBatch(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Create a job with two running allocations and one that is stopped
+	state := s1.fsm.State()
+	job := mock.Job()
+	assert.Nil(state.UpsertJob(999, job))
+
+	var allocs []*structs.Allocation
+	for i := 0; i < 3; i++ {
+		alloc := mock.Alloc()
+		alloc.Job = job
+		alloc.JobID = job.ID
+		allocs = append(allocs, alloc)
+	}
+	allocs[2].DesiredStatus = structs.AllocDesiredStatusStop
+	other := mock.Alloc()
+	other.TaskGroup = "other"
+	allocs = append(allocs, other)
+	assert.Nil(state.UpsertJobSummary(1000, mock.JobSummary(other.JobID)))
+	assert.Nil(state.UpsertAllocs(1001, allocs))
+
+	// Stop the running allocations of the task group
+	req := &structs.AllocBatchStopRequest{
+		Filter:       `TaskGroup == "web"`,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.AllocBatchStopResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Alloc.StopBatch", req, &resp))
+	assert.Equal(2, resp.Stopped)
+	assert.Empty(resp.NextToken)
+
+	for i, alloc := range allocs {
+		out, err := state.AllocByID(nil, alloc.ID)
+		assert.Nil(err)
+		if i < 2 {
+			assert.Equal(structs.AllocDesiredStatusStop, out.DesiredStatus)
+			assert.Equal(structs.AllocStopReasonOperatorStopped, out.StopReason)
+		} else if i == 3 {
+			assert.Equal(structs.AllocDesiredStatusRun, out.DesiredStatus)
+		}
+	}
+
+	// A single evaluation replaces the allocations of the job
+	if assert.Len(resp.EvalIDs, 1) {
+		eval, err := state.EvalByID(nil, resp.EvalIDs[0])
+		assert.Nil(err)
+		if assert.NotNil(eval) {
+			assert.Equal(job.ID, eval.JobID)
+			assert.Equal(structs.EvalTriggerAllocStop, eval.TriggeredBy)
+		}
+	}
+
+	// Stopping again finds no running allocations
+	resp = structs.AllocBatchStopResponse{}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Alloc.StopBatch", req, &resp))
+	assert.Zero(resp.Stopped)
+	assert.Empty(resp.EvalIDs)
+}
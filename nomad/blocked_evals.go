@@ -307,6 +307,26 @@ func (b *BlockedEvals) Untrack(jobID string) {
 		return
 	}
 
+	b.untrackEvalLocked(evalID)
+}
+
+// UntrackEval causes the blocked evaluation to be no longer tracked. It is
+// called when the evaluation is deleted.
+func (b *BlockedEvals) UntrackEval(evalID string) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	// Do nothing if not enabled
+	if !b.enabled {
+		return
+	}
+
+	b.untrackEvalLocked(evalID)
+}
+
+// untrackEvalLocked stops tracking the blocked evaluation. The lock must be
+// held.
+func (b *BlockedEvals) untrackEvalLocked(evalID string) {
 	// Attempt to delete the evaluation
 	if w, ok := b.captured[evalID]; ok {
 		delete(b.jobs, w.eval.JobID)
@@ -137,6 +137,13 @@ type Config struct {
 	// that the workers dequeue for processing.
 	EnabledSchedulers []string
 
+	// Scorers are the scorer extensions compiled into the binary that the
+	// schedulers apply when ranking nodes.
+	Scorers []*config.ScorerConfig
+
+	// NodeHooks are fired by the leader on node lifecycle transitions
+	NodeHooks []*config.NodeHookConfig
+
 	// ReconcileInterval controls how often we reconcile the strongly
 	// consistent store with the Serf info. This is used to handle nodes
 	// that are force removed, as well as intermittent unavailability during
@@ -173,6 +180,10 @@ type Config struct {
 	// for GC. This gives users some time to view terminal deployments.
 	DeploymentGCThreshold time.Duration
 
+	// ExpiredACLTokenGCInterval is how often we dispatch a job to GC the
+	// expired ACL tokens created by auth methods.
+	ExpiredACLTokenGCInterval time.Duration
+
 	// EvalNackTimeout controls how long we allow a sub-scheduler to
 	// work on an evaluation before we consider it failed and Nack it.
 	// This allows that evaluation to be handed to another sub-scheduler
@@ -228,12 +239,64 @@ type Config struct {
 	// of all the heartbeats.
 	FailoverHeartbeatTTL time.Duration
 
+	// StateHistoryRetention is how long snapshots of the state store are
+	// retained to serve reads as of a past index. Zero disables the state
+	// history.
+	StateHistoryRetention time.Duration
+
+	// StateHistoryInterval is how often the state store is snapshotted into
+	// the state history.
+	StateHistoryInterval time.Duration
+
+	// EventBufferSize is the number of the most recent events retained for
+	// event stream subscribers. Zero disables the event stream, which is the
+	// default since every server diffs the modified tables of its state store
+	// after each batch of writes to publish the events.
+	EventBufferSize int
+
+	// EventDiskBufferSize is the number of events retained on disk in the
+	// data directory, so subscribers can resume from events evicted from
+	// memory. Zero, the default, or running in dev mode retains events only in
+	// memory.
+	EventDiskBufferSize int
+
+	// RPCReadRateLimit and RPCWriteRateLimit are the maximum rates, in
+	// requests per second, of read and write RPCs made with a single ACL
+	// token. Zero disables the limit.
+	RPCReadRateLimit  float64
+	RPCWriteRateLimit float64
+
+	// RPCReadRateBurst and RPCWriteRateBurst are the number of requests a
+	// token may burst above its rate. Zero defaults to the rate.
+	RPCReadRateBurst  int
+	RPCWriteRateBurst int
+
+	// MaxJobSize is the maximum encoded size in bytes of a job that may be
+	// registered. Zero disables the check.
+	MaxJobSize int
+
+	// MaxDispatchPayloadSize is the maximum size in bytes of the payload
+	// passed when dispatching a parameterized job. Zero disables the check.
+	MaxDispatchPayloadSize int
+
+	// MaxTemplateSize is the maximum size in bytes of the embedded content of
+	// a single template. Zero disables the check.
+	MaxTemplateSize int
+
 	// ConsulConfig is this Agent's Consul configuration
 	ConsulConfig *config.ConsulConfig
 
 	// VaultConfig is this Agent's Vault configuration
 	VaultConfig *config.VaultConfig
 
+	// StateExportConfig configures the export of the cluster state to
+	// external storage by the leader
+	StateExportConfig *config.StateExportConfig
+
+	// AllocArchiveConfig configures the archive terminal allocations are
+	// written to before they are garbage collected
+	AllocArchiveConfig *config.AllocArchiveConfig
+
 	// RPCHoldTimeout is how long an RPC can be "held" before it is errored.
 	// This is used to paper over a loss of leadership by instead holding RPCs,
 	// so that the caller experiences a slow response rather than an error.
@@ -273,6 +336,10 @@ type Config struct {
 	// displaying metrics for older verions, or to only show the new format
 	BackwardsCompatibleMetrics bool
 
+	// MetricsNamespaceLabels determines whether the job summary metrics are
+	// labeled with the namespace of the job
+	MetricsNamespaceLabels bool
+
 	// AutopilotConfig is used to apply the initial autopilot config when
 	// bootstrapping.
 	AutopilotConfig *structs.AutopilotConfig
@@ -328,6 +395,7 @@ func DefaultConfig() *Config {
 		NodeGCThreshold:                  24 * time.Hour,
 		DeploymentGCInterval:             5 * time.Minute,
 		DeploymentGCThreshold:            1 * time.Hour,
+		ExpiredACLTokenGCInterval:        5 * time.Minute,
 		EvalNackTimeout:                  60 * time.Second,
 		EvalDeliveryLimit:                3,
 		EvalNackInitialReenqueueDelay:    1 * time.Second,
@@ -338,8 +406,14 @@ func DefaultConfig() *Config {
 		MaxHeartbeatsPerSecond:           50.0,
 		HeartbeatGrace:                   10 * time.Second,
 		FailoverHeartbeatTTL:             300 * time.Second,
+		StateHistoryInterval:             1 * time.Minute,
+		MaxJobSize:                       DefaultMaxJobSize,
+		MaxDispatchPayloadSize:           DispatchPayloadSizeLimit,
+		MaxTemplateSize:                  DefaultMaxTemplateSize,
 		ConsulConfig:                     config.DefaultConsulConfig(),
 		VaultConfig:                      config.DefaultVaultConfig(),
+		StateExportConfig:                config.DefaultStateExportConfig(),
+		AllocArchiveConfig:               config.DefaultAllocArchiveConfig(),
 		RPCHoldTimeout:                   5 * time.Second,
 		StatsCollectionInterval:          1 * time.Minute,
 		TLSConfig:                        &config.TLSConfig{},
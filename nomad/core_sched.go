@@ -5,6 +5,7 @@ import (
 	"math"
 	"time"
 
+	"github.com/armon/go-metrics"
 	memdb "github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -24,6 +25,10 @@ var (
 type CoreScheduler struct {
 	srv  *Server
 	snap *state.StateSnapshot
+
+	// gc restricts a forced garbage collection and records the objects it
+	// collects. It is nil for unrestricted garbage collections.
+	gc *scopedGC
 }
 
 // NewCoreScheduler is used to return a new system scheduler instance
@@ -46,6 +51,8 @@ func (c *CoreScheduler) Process(eval *structs.Evaluation) error {
 		return c.jobGC(eval)
 	case structs.CoreJobDeploymentGC:
 		return c.deploymentGC(eval)
+	case structs.CoreJobExpiredACLTokenGC:
+		return c.expiredACLTokenGC(eval)
 	case structs.CoreJobForceGC:
 		return c.forceGC(eval)
 	default:
@@ -53,6 +60,80 @@ func (c *CoreScheduler) Process(eval *structs.Evaluation) error {
 	}
 }
 
+// scopedGC restricts a forced garbage collection to some collections and to a
+// namespace, and records the objects that are collected.
+type scopedGC struct {
+	collections map[string]struct{}
+	namespace   string
+	dryRun      bool
+
+	result *structs.SystemGCResponse
+	seen   map[string]struct{}
+}
+
+// newScopedGC returns the scope of the garbage collection request. The
+// collected objects are recorded in the response.
+func newScopedGC(args *structs.SystemGCRequest, reply *structs.SystemGCResponse) (*scopedGC, error) {
+	g := &scopedGC{
+		dryRun: args.DryRun,
+		result: reply,
+		seen:   make(map[string]struct{}),
+	}
+	if args.Namespace != structs.AllNamespacesSentinel {
+		g.namespace = args.Namespace
+	}
+	if len(args.Collections) != 0 {
+		g.collections = make(map[string]struct{}, len(args.Collections))
+		for _, c := range args.Collections {
+			switch c {
+			case structs.GCCollectionJobs, structs.GCCollectionEvals, structs.GCCollectionAllocs,
+				structs.GCCollectionNodes, structs.GCCollectionDeployments:
+				g.collections[c] = struct{}{}
+			default:
+				return nil, fmt.Errorf("unknown garbage collection %q", c)
+			}
+		}
+	}
+	return g, nil
+}
+
+// collects returns whether the objects of the collection are garbage
+// collected.
+func (g *scopedGC) collects(collection string) bool {
+	if g == nil {
+		return true
+	}
+	if collection == structs.GCCollectionNodes && g.namespace != "" {
+		return false
+	}
+	if g.collections == nil {
+		return true
+	}
+	_, ok := g.collections[collection]
+	return ok
+}
+
+// inNamespace returns whether the objects of the namespace are garbage
+// collected.
+func (g *scopedGC) inNamespace(namespace string) bool {
+	return g == nil || g.namespace == "" || g.namespace == namespace
+}
+
+// record records the garbage collected objects and returns whether they
+// should be reaped.
+func (g *scopedGC) record(ids *[]string, collected []string) bool {
+	if g == nil {
+		return true
+	}
+	for _, id := range collected {
+		if _, ok := g.seen[id]; !ok {
+			g.seen[id] = struct{}{}
+			*ids = append(*ids, id)
+		}
+	}
+	return !g.dryRun
+}
+
 // forceGC is used to garbage collect all eligible objects.
 func (c *CoreScheduler) forceGC(eval *structs.Evaluation) error {
 	if err := c.jobGC(eval); err != nil {
@@ -72,6 +153,10 @@ func (c *CoreScheduler) forceGC(eval *structs.Evaluation) error {
 
 // jobGC is used to garbage collect eligible jobs.
 func (c *CoreScheduler) jobGC(eval *structs.Evaluation) error {
+	if !c.gc.collects(structs.GCCollectionJobs) {
+		return nil
+	}
+
 	// Get all the jobs eligible for garbage collection.
 	ws := memdb.NewWatchSet()
 	iter, err := c.snap.JobsByGC(ws, true)
@@ -102,8 +187,8 @@ OUTER:
 	for i := iter.Next(); i != nil; i = iter.Next() {
 		job := i.(*structs.Job)
 
-		// Ignore new jobs.
-		if job.CreateIndex > oldThreshold {
+		// Ignore new jobs and jobs of other namespaces.
+		if job.CreateIndex > oldThreshold || !c.gc.inNamespace(job.Namespace) {
 			continue
 		}
 
@@ -146,6 +231,16 @@ OUTER:
 	c.srv.logger.Printf("[DEBUG] sched.core: job GC: %d jobs, %d evaluations, %d allocs eligible",
 		len(gcJob), len(gcEval), len(gcAlloc))
 
+	if c.gc != nil {
+		for _, job := range gcJob {
+			c.gc.result.Jobs = append(c.gc.result.Jobs, structs.NamespacedID{ID: job.ID, Namespace: job.Namespace})
+		}
+		c.gc.record(&c.gc.result.Evals, gcEval)
+		if !c.gc.record(&c.gc.result.Allocs, gcAlloc) {
+			return nil
+		}
+	}
+
 	// Reap the evals and allocs
 	if err := c.evalReap(gcEval, gcAlloc); err != nil {
 		return err
@@ -174,6 +269,12 @@ OUTER:
 
 // evalGC is used to garbage collect old evaluations
 func (c *CoreScheduler) evalGC(eval *structs.Evaluation) error {
+	collectEvals := c.gc.collects(structs.GCCollectionEvals)
+	collectAllocs := c.gc.collects(structs.GCCollectionAllocs)
+	if !collectEvals && !collectAllocs {
+		return nil
+	}
+
 	// Iterate over the evaluations
 	ws := memdb.NewWatchSet()
 	iter, err := c.snap.Evals(ws)
@@ -202,6 +303,9 @@ func (c *CoreScheduler) evalGC(eval *structs.Evaluation) error {
 	var gcAlloc, gcEval []string
 	for raw := iter.Next(); raw != nil; raw = iter.Next() {
 		eval := raw.(*structs.Evaluation)
+		if !c.gc.inNamespace(eval.Namespace) {
+			continue
+		}
 
 		// The Evaluation GC should not handle batch jobs since those need to be
 		// garbage collected in one shot
@@ -210,10 +314,14 @@ func (c *CoreScheduler) evalGC(eval *structs.Evaluation) error {
 			return err
 		}
 
-		if gc {
+		// The allocations of a collected evaluation are always collected
+		// since they would no longer be garbage collected otherwise.
+		if gc && collectEvals {
 			gcEval = append(gcEval, eval.ID)
+			gcAlloc = append(gcAlloc, allocs...)
+		} else if collectAllocs {
+			gcAlloc = append(gcAlloc, allocs...)
 		}
-		gcAlloc = append(gcAlloc, allocs...)
 	}
 
 	// Fast-path the nothing case
@@ -223,6 +331,13 @@ func (c *CoreScheduler) evalGC(eval *structs.Evaluation) error {
 	c.srv.logger.Printf("[DEBUG] sched.core: eval GC: %d evaluations, %d allocs eligible",
 		len(gcEval), len(gcAlloc))
 
+	if c.gc != nil {
+		c.gc.record(&c.gc.result.Evals, gcEval)
+		if !c.gc.record(&c.gc.result.Allocs, gcAlloc) {
+			return nil
+		}
+	}
+
 	return c.evalReap(gcEval, gcAlloc)
 }
 
@@ -302,6 +417,14 @@ func (c *CoreScheduler) gcEval(eval *structs.Evaluation, thresholdIndex uint64,
 // evalReap contacts the leader and issues a reap on the passed evals and
 // allocs.
 func (c *CoreScheduler) evalReap(evals, allocs []string) error {
+	// Archive the allocations before they are garbage collected. Nothing is
+	// reaped if archiving fails so that it is retried by the next GC.
+	if err := c.srv.archiveAllocs(c.snap, allocs); err != nil {
+		c.srv.logger.Printf("[ERR] sched.core: failed to archive allocations: %v", err)
+		metrics.IncrCounter([]string{"nomad", "alloc_archive", "errors"}, 1)
+		return err
+	}
+
 	// Call to the leader to issue the reap
 	for _, req := range c.partitionEvalReap(evals, allocs) {
 		var resp structs.GenericResponse
@@ -361,6 +484,10 @@ func (c *CoreScheduler) partitionEvalReap(evals, allocs []string) []*structs.Eva
 
 // nodeGC is used to garbage collect old nodes
 func (c *CoreScheduler) nodeGC(eval *structs.Evaluation) error {
+	if !c.gc.collects(structs.GCCollectionNodes) {
+		return nil
+	}
+
 	// Iterate over the evaluations
 	ws := memdb.NewWatchSet()
 	iter, err := c.snap.Nodes(ws)
@@ -429,6 +556,10 @@ OUTER:
 	}
 	c.srv.logger.Printf("[DEBUG] sched.core: node GC: %d nodes eligible", len(gcNode))
 
+	if c.gc != nil && !c.gc.record(&c.gc.result.Nodes, gcNode) {
+		return nil
+	}
+
 	// Call to the leader to issue the reap
 	for _, nodeID := range gcNode {
 		req := structs.NodeDeregisterRequest{
@@ -449,6 +580,10 @@ OUTER:
 
 // deploymentGC is used to garbage collect old deployments
 func (c *CoreScheduler) deploymentGC(eval *structs.Evaluation) error {
+	if !c.gc.collects(structs.GCCollectionDeployments) {
+		return nil
+	}
+
 	// Iterate over the deployments
 	ws := memdb.NewWatchSet()
 	iter, err := c.snap.Deployments(ws)
@@ -484,8 +619,9 @@ OUTER:
 		}
 		deploy := raw.(*structs.Deployment)
 
-		// Ignore non-terminal and new deployments
-		if deploy.Active() || deploy.ModifyIndex > oldThreshold {
+		// Ignore non-terminal and new deployments, and deployments of other
+		// namespaces
+		if deploy.Active() || deploy.ModifyIndex > oldThreshold || !c.gc.inNamespace(deploy.Namespace) {
 			continue
 		}
 
@@ -513,9 +649,69 @@ OUTER:
 		return nil
 	}
 	c.srv.logger.Printf("[DEBUG] sched.core: deployment GC: %d deployments eligible", len(gcDeployment))
+
+	if c.gc != nil && !c.gc.record(&c.gc.result.Deployments, gcDeployment) {
+		return nil
+	}
 	return c.deploymentReap(gcDeployment)
 }
 
+// expiredACLTokenGC is used to garbage collect the expired local ACL tokens
+// and the workload tokens of the allocations that stopped.
+func (c *CoreScheduler) expiredACLTokenGC(eval *structs.Evaluation) error {
+	if !c.srv.config.ACLEnabled {
+		return nil
+	}
+
+	ws := memdb.NewWatchSet()
+	iter, err := c.snap.ACLTokensByGlobal(ws, false)
+	if err != nil {
+		return err
+	}
+
+	// Collect the expired tokens
+	now := time.Now()
+	var gcToken []string
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		token := raw.(*structs.ACLToken)
+		if token.IsExpired(now) {
+			gcToken = append(gcToken, token.AccessorID)
+			continue
+		}
+
+		if token.AllocID != "" {
+			alloc, err := c.snap.AllocByID(ws, token.AllocID)
+			if err != nil {
+				return err
+			}
+			if alloc == nil || alloc.TerminalStatus() {
+				gcToken = append(gcToken, token.AccessorID)
+			}
+		}
+	}
+
+	// Fast-path the nothing case
+	if len(gcToken) == 0 {
+		return nil
+	}
+	c.srv.logger.Printf("[DEBUG] sched.core: expired ACL token GC: %d tokens eligible", len(gcToken))
+
+	// Call to the leader to delete the tokens
+	req := structs.ACLTokenDeleteRequest{
+		AccessorIDs: gcToken,
+		WriteRequest: structs.WriteRequest{
+			Region:    c.srv.config.Region,
+			AuthToken: eval.LeaderACL,
+		},
+	}
+	var resp structs.GenericResponse
+	if err := c.srv.RPC("ACL.DeleteTokens", &req, &resp); err != nil {
+		c.srv.logger.Printf("[ERR] sched.core: expired ACL token reap failed: %v", err)
+		return err
+	}
+	return nil
+}
+
 // deploymentReap contacts the leader and issues a reap on the passed
 // deployments.
 func (c *CoreScheduler) deploymentReap(deployments []string) error {
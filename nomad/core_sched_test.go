@@ -2,14 +2,20 @@ package nomad
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
 	"time"
 
 	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/helper/uuid"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCoreScheduler_EvalGC(t *testing.T) {
@@ -93,6 +99,65 @@ func TestCoreScheduler_EvalGC(t *testing.T) {
 	}
 }
 
+// An EvalGC should archive the allocations it reaps
+func TestCoreScheduler_EvalGC_ArchiveAllocs(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	s1 := testServer(t, func(c *Config) {
+		c.AllocArchiveConfig = &config.AllocArchiveConfig{
+			Enabled: helper.BoolToPtr(true),
+			Store:   "file",
+			Path:    dir,
+		}
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// COMPAT Remove in 0.6: Reset the FSM time table since we reconcile which sets index 0
+	s1.fsm.timetable.table = make([]TimeTableEntry, 1, 10)
+
+	// Insert "dead" eval and alloc
+	state := s1.fsm.State()
+	eval := mock.Eval()
+	eval.Status = structs.EvalStatusFailed
+	assert.Nil(state.UpsertJobSummary(999, mock.JobSummary(eval.JobID)))
+	assert.Nil(state.UpsertEvals(1000, []*structs.Evaluation{eval}))
+
+	alloc := mock.Alloc()
+	alloc.EvalID = eval.ID
+	alloc.DesiredStatus = structs.AllocDesiredStatusStop
+	alloc.JobID = eval.JobID
+	assert.Nil(state.UpsertAllocs(1001, []*structs.Allocation{alloc}))
+
+	// Update the time tables to make this work
+	tt := s1.fsm.TimeTable()
+	tt.Witness(2000, time.Now().UTC().Add(-1*s1.config.EvalGCThreshold))
+
+	// Attempt the GC
+	snap, err := state.Snapshot()
+	assert.Nil(err)
+	core := NewCoreScheduler(s1, snap)
+	gc := s1.coreJobEval(structs.CoreJobEvalGC, 2000)
+	assert.Nil(core.Process(gc))
+
+	// The alloc is gone from the state but archived
+	out, err := state.AllocByID(nil, alloc.ID)
+	assert.Nil(err)
+	assert.Nil(out)
+
+	history, err := s1.allocHistory(alloc.Namespace, alloc.JobID, 0)
+	assert.Nil(err)
+	if assert.Len(history, 1) {
+		assert.Equal(alloc.ID, history[0].ID)
+		assert.Equal(structs.AllocDesiredStatusStop, history[0].DesiredStatus)
+	}
+}
+
 // An EvalGC should never reap a batch job that has not been stopped
 func TestCoreScheduler_EvalGC_Batch(t *testing.T) {
 	t.Parallel()
@@ -1532,3 +1597,78 @@ func TestCoreScheduler_PartitionDeploymentReap(t *testing.T) {
 		t.Fatalf("Unexpected second request: %v", second)
 	}
 }
+
+func TestCoreScheduler_ExpiredACLTokenGC(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s1, _ := testACLServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Insert an expired and an unexpired token
+	state := s1.fsm.State()
+	expired, unexpired := mock.ACLToken(), mock.ACLToken()
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+	expired.ExpirationTime = &past
+	unexpired.ExpirationTime = &future
+	require.NoError(state.UpsertACLTokens(1000, []*structs.ACLToken{expired, unexpired}))
+
+	// Create a core scheduler
+	snap, err := state.Snapshot()
+	require.NoError(err)
+	core := NewCoreScheduler(s1, snap)
+
+	// Attempt the GC
+	gc := s1.coreJobEval(structs.CoreJobExpiredACLTokenGC, 2000)
+	require.NoError(core.Process(gc))
+
+	// Only the expired token should be gone
+	out, err := state.ACLTokenByAccessorID(nil, expired.AccessorID)
+	require.NoError(err)
+	require.Nil(out)
+
+	out, err = state.ACLTokenByAccessorID(nil, unexpired.AccessorID)
+	require.NoError(err)
+	require.NotNil(out)
+}
+
+func TestCoreScheduler_ExpiredACLTokenGC_Workload(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s1, _ := testACLServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Insert the workload tokens of a running and a stopped allocation
+	state := s1.fsm.State()
+	running, stopped := mock.Alloc(), mock.Alloc()
+	stopped.DesiredStatus = structs.AllocDesiredStatusStop
+	require.NoError(state.UpsertJobSummary(999, mock.JobSummary(running.JobID)))
+	require.NoError(state.UpsertJobSummary(999, mock.JobSummary(stopped.JobID)))
+	require.NoError(state.UpsertAllocs(1000, []*structs.Allocation{running, stopped}))
+
+	runningToken, stoppedToken, goneToken := mock.ACLToken(), mock.ACLToken(), mock.ACLToken()
+	runningToken.AllocID = running.ID
+	stoppedToken.AllocID = stopped.ID
+	goneToken.AllocID = uuid.Generate()
+	require.NoError(state.UpsertACLTokens(1001, []*structs.ACLToken{runningToken, stoppedToken, goneToken}))
+
+	// Create a core scheduler
+	snap, err := state.Snapshot()
+	require.NoError(err)
+	core := NewCoreScheduler(s1, snap)
+
+	// Attempt the GC
+	gc := s1.coreJobEval(structs.CoreJobExpiredACLTokenGC, 2000)
+	require.NoError(core.Process(gc))
+
+	// Only the token of the running allocation should be left
+	for _, token := range []*structs.ACLToken{stoppedToken, goneToken} {
+		out, err := state.ACLTokenByAccessorID(nil, token.AccessorID)
+		require.NoError(err)
+		require.Nil(out)
+	}
+	out, err := state.ACLTokenByAccessorID(nil, runningToken.AccessorID)
+	require.NoError(err)
+	require.NotNil(out)
+}
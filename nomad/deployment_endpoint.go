@@ -151,6 +151,15 @@ func (d *Deployment) Pause(args *structs.DeploymentPauseRequest, reply *structs.
 		return fmt.Errorf("can't resume terminal deployment")
 	}
 
+	// Queued deployments are started by the leader in order
+	if deploy.Status == structs.DeploymentStatusQueued {
+		if args.Pause {
+			return fmt.Errorf("can't pause queued deployment")
+		}
+
+		return fmt.Errorf("can't resume queued deployment")
+	}
+
 	// Call into the deployment watcher
 	return d.srv.deploymentWatcher.PauseDeployment(args, reply)
 }
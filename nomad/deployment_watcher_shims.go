@@ -56,3 +56,8 @@ func (d *deploymentWatcherRaftShim) UpdateDeploymentAllocHealth(req *structs.App
 	fsmErrIntf, index, raftErr := d.apply(structs.DeploymentAllocHealthRequestType, req)
 	return d.convertApplyErrors(fsmErrIntf, index, raftErr)
 }
+
+func (d *deploymentWatcherRaftShim) UpdateDeploymentCanaryAnalysis(req *structs.ApplyDeploymentCanaryAnalysisRequest) (uint64, error) {
+	fsmErrIntf, index, raftErr := d.apply(structs.DeploymentCanaryAnalysisRequestType, req)
+	return d.convertApplyErrors(fsmErrIntf, index, raftErr)
+}
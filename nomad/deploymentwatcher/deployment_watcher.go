@@ -40,6 +40,10 @@ type deploymentTriggers interface {
 	// upsertDeploymentAllocHealth is used to set the health of allocations in a
 	// deployment
 	upsertDeploymentAllocHealth(req *structs.ApplyDeploymentAllocHealthRequest) (uint64, error)
+
+	// upsertDeploymentCanaryAnalysis is used to append canary analysis
+	// snapshots to a deployment
+	upsertDeploymentCanaryAnalysis(req *structs.ApplyDeploymentCanaryAnalysisRequest) (uint64, error)
 }
 
 // deploymentWatcher is used to watch a single deployment and trigger the
@@ -70,6 +74,10 @@ type deploymentWatcher struct {
 	// by holding the lock or using the setter and getter methods.
 	latestEval uint64
 
+	// canaryAnalysisInterval is the interval at which canary analysis
+	// snapshots are collected
+	canaryAnalysisInterval time.Duration
+
 	logger *log.Logger
 	ctx    context.Context
 	exitFn context.CancelFunc
@@ -80,23 +88,30 @@ type deploymentWatcher struct {
 // deployments and trigger the scheduler as needed.
 func newDeploymentWatcher(parent context.Context, queryLimiter *rate.Limiter,
 	logger *log.Logger, state *state.StateStore, d *structs.Deployment,
-	j *structs.Job, triggers deploymentTriggers) *deploymentWatcher {
+	j *structs.Job, triggers deploymentTriggers,
+	canaryAnalysisInterval time.Duration) *deploymentWatcher {
 
 	ctx, exitFn := context.WithCancel(parent)
 	w := &deploymentWatcher{
-		queryLimiter:       queryLimiter,
-		d:                  d,
-		j:                  j,
-		state:              state,
-		deploymentTriggers: triggers,
-		logger:             logger,
-		ctx:                ctx,
-		exitFn:             exitFn,
+		queryLimiter:           queryLimiter,
+		d:                      d,
+		j:                      j,
+		state:                  state,
+		deploymentTriggers:     triggers,
+		canaryAnalysisInterval: canaryAnalysisInterval,
+		logger:                 logger,
+		ctx:                    ctx,
+		exitFn:                 exitFn,
 	}
 
 	// Start the long lived watcher that scans for allocation updates
 	go w.watch()
 
+	// Collect canary analysis snapshots if the deployment places canaries
+	if w.hasCanaries() {
+		go w.watchCanaryAnalysis()
+	}
+
 	return w
 }
 
@@ -256,6 +271,19 @@ func (w *deploymentWatcher) PauseDeployment(
 	return nil
 }
 
+// StartDeployment starts a queued deployment and creates an evaluation to
+// make its placements.
+func (w *deploymentWatcher) StartDeployment() error {
+	update := w.getDeploymentStatusUpdate(structs.DeploymentStatusRunning, structs.DeploymentStatusDescriptionRunning)
+	eval := w.getEval()
+	i, err := w.upsertDeploymentStatusUpdate(update, eval, nil)
+	if err != nil {
+		return err
+	}
+	w.setLatestEval(i)
+	return nil
+}
+
 func (w *deploymentWatcher) FailDeployment(
 	req *structs.DeploymentFailRequest,
 	resp *structs.DeploymentUpdateResponse) error {
@@ -408,6 +436,92 @@ func (w *deploymentWatcher) watch() {
 	}
 }
 
+// hasCanaries returns whether any task group of the deployment places
+// canaries
+func (w *deploymentWatcher) hasCanaries() bool {
+	for _, state := range w.d.TaskGroups {
+		if state.DesiredCanaries > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// watchCanaryAnalysis periodically collects canary analysis snapshots until
+// the deployment is no longer watched.
+func (w *deploymentWatcher) watchCanaryAnalysis() {
+	ticker := time.NewTicker(w.canaryAnalysisInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.ctx.Done():
+			return
+		}
+
+		if err := w.collectCanaryAnalysis(time.Now()); err != nil {
+			w.logger.Printf("[ERR] nomad.deployment_watcher: failed to collect canary analysis for deployment %q: %v", w.d.ID, err)
+		}
+	}
+}
+
+// collectCanaryAnalysis appends a snapshot comparing the canaries with the
+// stable allocations to each task group whose canaries await promotion.
+func (w *deploymentWatcher) collectCanaryAnalysis(now time.Time) error {
+	snap, err := w.state.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	d, err := snap.DeploymentByID(nil, w.d.ID)
+	if err != nil {
+		return err
+	}
+	if d == nil || d.Status != structs.DeploymentStatusRunning {
+		return nil
+	}
+
+	allocs, err := snap.AllocsByJob(nil, d.Namespace, d.JobID, false)
+	if err != nil {
+		return err
+	}
+
+	snapshots := make(map[string]*structs.CanaryAnalysisSnapshot)
+	for group, state := range d.TaskGroups {
+		if state.Promoted || len(state.PlacedCanaries) == 0 {
+			continue
+		}
+
+		placed := make(map[string]struct{}, len(state.PlacedCanaries))
+		for _, id := range state.PlacedCanaries {
+			placed[id] = struct{}{}
+		}
+
+		var canaries, stable []*structs.Allocation
+		for _, alloc := range allocs {
+			if alloc.TaskGroup != group || alloc.TerminalStatus() {
+				continue
+			}
+			if _, ok := placed[alloc.ID]; ok {
+				canaries = append(canaries, alloc)
+			} else if alloc.DeploymentID != d.ID {
+				stable = append(stable, alloc)
+			}
+		}
+		snapshots[group] = structs.NewCanaryAnalysisSnapshot(now, canaries, stable)
+	}
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	_, err = w.upsertDeploymentCanaryAnalysis(&structs.ApplyDeploymentCanaryAnalysisRequest{
+		DeploymentID: d.ID,
+		Snapshots:    snapshots,
+	})
+	return err
+}
+
 // latestStableJob returns the latest stable job. It may be nil if none exist
 func (w *deploymentWatcher) latestStableJob() (*structs.Job, error) {
 	snap, err := w.state.Snapshot()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
@@ -22,6 +23,10 @@ const (
 	// CrossDeploymentEvalBatchDuration is the duration in which evaluations are
 	// batched across all deployment watchers before committing to Raft.
 	CrossDeploymentEvalBatchDuration = 250 * time.Millisecond
+
+	// CanaryAnalysisInterval is the interval at which canary analysis
+	// snapshots are collected for deployments with unpromoted canaries.
+	CanaryAnalysisInterval = 1 * time.Minute
 )
 
 var (
@@ -49,6 +54,10 @@ type DeploymentRaftEndpoints interface {
 	// UpdateDeploymentAllocHealth is used to set the health of allocations in a
 	// deployment
 	UpdateDeploymentAllocHealth(req *structs.ApplyDeploymentAllocHealthRequest) (uint64, error)
+
+	// UpdateDeploymentCanaryAnalysis is used to append canary analysis
+	// snapshots to a deployment
+	UpdateDeploymentCanaryAnalysis(req *structs.ApplyDeploymentCanaryAnalysisRequest) (uint64, error)
 }
 
 // Watcher is used to watch deployments and their allocations created
@@ -65,6 +74,10 @@ type Watcher struct {
 	// deployment watchers
 	evalBatchDuration time.Duration
 
+	// canaryAnalysisInterval is the interval at which deployment watchers
+	// collect canary analysis snapshots
+	canaryAnalysisInterval time.Duration
+
 	// raft contains the set of Raft endpoints that can be used by the
 	// deployments watcher
 	raft DeploymentRaftEndpoints
@@ -92,10 +105,11 @@ func NewDeploymentsWatcher(logger *log.Logger,
 	evalBatchDuration time.Duration) *Watcher {
 
 	return &Watcher{
-		raft:              raft,
-		queryLimiter:      rate.NewLimiter(rate.Limit(stateQueriesPerSecond), 100),
-		evalBatchDuration: evalBatchDuration,
-		logger:            logger,
+		raft:                   raft,
+		queryLimiter:           rate.NewLimiter(rate.Limit(stateQueriesPerSecond), 100),
+		evalBatchDuration:      evalBatchDuration,
+		canaryAnalysisInterval: CanaryAnalysisInterval,
+		logger:                 logger,
 	}
 }
 
@@ -170,6 +184,58 @@ func (w *Watcher) watchDeployments(ctx context.Context) {
 				w.remove(d)
 			}
 		}
+
+		// Start the queued deployments the concurrency limits allow
+		_, config, err := w.state.DeploymentConcurrencyConfig(nil)
+		if err != nil {
+			w.logger.Printf("[ERR] nomad.deployments_watcher: failed to retrieve deployment concurrency config: %v", err)
+			continue
+		}
+		w.startQueued(deployments, config)
+	}
+}
+
+// startQueued starts the queued deployments in the order they were created
+// while the number of running deployments is below the limits of the
+// deployment concurrency configuration. Paused deployments do not count
+// against the limits. A deployment held back by the limit of its namespace
+// does not hold back the deployments of other namespaces.
+func (w *Watcher) startQueued(deployments []*structs.Deployment, config *structs.DeploymentConcurrencyConfig) {
+	running := 0
+	nsRunning := make(map[string]int)
+	var queued []*structs.Deployment
+	for _, d := range deployments {
+		switch d.Status {
+		case structs.DeploymentStatusRunning:
+			running++
+			nsRunning[d.Namespace]++
+		case structs.DeploymentStatusQueued:
+			queued = append(queued, d)
+		}
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].CreateIndex < queued[j].CreateIndex
+	})
+
+	for _, d := range queued {
+		if config.ClusterFull(running) {
+			return
+		}
+		if config.NamespaceFull(d.Namespace, nsRunning[d.Namespace]) {
+			continue
+		}
+
+		watcher, err := w.getOrCreateWatcher(d.ID)
+		if err != nil {
+			w.logger.Printf("[ERR] nomad.deployments_watcher: failed to track deployment %q: %v", d.ID, err)
+			continue
+		}
+		if err := watcher.StartDeployment(); err != nil {
+			w.logger.Printf("[ERR] nomad.deployments_watcher: failed to start deployment %q: %v", d.ID, err)
+			continue
+		}
+		running++
+		nsRunning[d.Namespace]++
 	}
 }
 
@@ -201,11 +267,25 @@ func (w *Watcher) getDeploysImpl(ws memdb.WatchSet, state *state.StateStore) (in
 		deploys = append(deploys, deploy)
 	}
 
-	// Use the last index that affected the deployment table
+	// Watch the deployment concurrency config so that queued deployments
+	// are started when the limits are raised
+	if _, _, err := state.DeploymentConcurrencyConfig(ws); err != nil {
+		return nil, 0, err
+	}
+
+	// Use the last index that affected the deployment table or the
+	// deployment concurrency config
 	index, err := state.Index("deployment")
 	if err != nil {
 		return nil, 0, err
 	}
+	configIndex, err := state.Index("deployment-concurrency-config")
+	if err != nil {
+		return nil, 0, err
+	}
+	if configIndex > index {
+		index = configIndex
+	}
 
 	return deploys, index, nil
 }
@@ -249,7 +329,7 @@ func (w *Watcher) addLocked(d *structs.Deployment) (*deploymentWatcher, error) {
 		return nil, fmt.Errorf("deployment %q references unknown job %q", d.ID, d.JobID)
 	}
 
-	watcher := newDeploymentWatcher(w.ctx, w.queryLimiter, w.logger, w.state, d, job, w)
+	watcher := newDeploymentWatcher(w.ctx, w.queryLimiter, w.logger, w.state, d, job, w, w.canaryAnalysisInterval)
 	w.watchers[d.ID] = watcher
 	return watcher, nil
 }
@@ -389,3 +469,9 @@ func (w *Watcher) upsertDeploymentPromotion(req *structs.ApplyDeploymentPromoteR
 func (w *Watcher) upsertDeploymentAllocHealth(req *structs.ApplyDeploymentAllocHealthRequest) (uint64, error) {
 	return w.raft.UpdateDeploymentAllocHealth(req)
 }
+
+// upsertDeploymentCanaryAnalysis commits the given canary analysis snapshots
+// to Raft
+func (w *Watcher) upsertDeploymentCanaryAnalysis(req *structs.ApplyDeploymentCanaryAnalysisRequest) (uint64, error) {
+	return w.raft.UpdateDeploymentCanaryAnalysis(req)
+}
@@ -991,3 +991,134 @@ func TestWatcher_BatchEvals(t *testing.T) {
 	testutil.WaitForResult(func() (bool, error) { return 2 == len(w.watchers), nil },
 		func(err error) { assert.Equal(2, len(w.watchers), "Should have 2 deployment") })
 }
+
+// Tests that queued deployments are started in order within the concurrency
+// limits
+func TestWatcher_StartQueued(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	w, m := defaultTestDeploymentWatcher(t)
+
+	// Allow two running deployments
+	config := &structs.DeploymentConcurrencyConfig{Limit: 2}
+	assert.Nil(m.state.DeploymentConcurrencySetConfig(m.nextIndex(), config))
+
+	// Create a running deployment and two queued deployments
+	j1, j2, j3 := mock.Job(), mock.Job(), mock.Job()
+	assert.Nil(m.state.UpsertJob(m.nextIndex(), j1))
+	assert.Nil(m.state.UpsertJob(m.nextIndex(), j2))
+	assert.Nil(m.state.UpsertJob(m.nextIndex(), j3))
+
+	d1, d2, d3 := mock.Deployment(), mock.Deployment(), mock.Deployment()
+	d1.JobID = j1.ID
+	d2.JobID = j2.ID
+	d2.Status = structs.DeploymentStatusQueued
+	d3.JobID = j3.ID
+	d3.Status = structs.DeploymentStatusQueued
+	assert.Nil(m.state.UpsertDeployment(m.nextIndex(), d1))
+	assert.Nil(m.state.UpsertDeployment(m.nextIndex(), d2))
+	assert.Nil(m.state.UpsertDeployment(m.nextIndex(), d3))
+
+	// Assert that only the first queued deployment is started
+	matchConfig := &matchDeploymentStatusUpdateConfig{
+		DeploymentID:      d2.ID,
+		Status:            structs.DeploymentStatusRunning,
+		StatusDescription: structs.DeploymentStatusDescriptionRunning,
+		Eval:              true,
+	}
+	matcher := matchDeploymentStatusUpdateRequest(matchConfig)
+	m.On("UpdateDeploymentStatus", mocker.MatchedBy(matcher)).Return(nil).Once()
+
+	w.SetEnabled(true, m.state)
+	testutil.WaitForResult(func() (bool, error) { return 3 == len(w.watchers), nil },
+		func(err error) { assert.Equal(3, len(w.watchers), "Should have 3 deployments") })
+	testutil.WaitForResult(func() (bool, error) {
+		d, err := m.state.DeploymentByID(nil, d2.ID)
+		if err != nil {
+			return false, err
+		}
+		return d.Status == structs.DeploymentStatusRunning, fmt.Errorf("deployment status %q", d.Status)
+	}, func(err error) { t.Fatal(err) })
+
+	out, err := m.state.DeploymentByID(nil, d3.ID)
+	assert.Nil(err)
+	assert.Equal(structs.DeploymentStatusQueued, out.Status)
+
+	// Raising the limit starts the last queued deployment
+	matchConfig = &matchDeploymentStatusUpdateConfig{
+		DeploymentID:      d3.ID,
+		Status:            structs.DeploymentStatusRunning,
+		StatusDescription: structs.DeploymentStatusDescriptionRunning,
+		Eval:              true,
+	}
+	matcher = matchDeploymentStatusUpdateRequest(matchConfig)
+	m.On("UpdateDeploymentStatus", mocker.MatchedBy(matcher)).Return(nil).Once()
+
+	config = &structs.DeploymentConcurrencyConfig{Limit: 3}
+	assert.Nil(m.state.DeploymentConcurrencySetConfig(m.nextIndex(), config))
+	testutil.WaitForResult(func() (bool, error) {
+		d, err := m.state.DeploymentByID(nil, d3.ID)
+		if err != nil {
+			return false, err
+		}
+		return d.Status == structs.DeploymentStatusRunning, fmt.Errorf("deployment status %q", d.Status)
+	}, func(err error) { t.Fatal(err) })
+}
+
+func TestWatcher_CanaryAnalysis(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	w, m := defaultTestDeploymentWatcher(t)
+	w.canaryAnalysisInterval = 10 * time.Millisecond
+
+	// Create a job, a canary and a stable alloc, and a deployment
+	j := mock.Job()
+	j.TaskGroups[0].Update = structs.DefaultUpdateStrategy.Copy()
+	j.TaskGroups[0].Update.Canary = 1
+	d := mock.Deployment()
+	d.JobID = j.ID
+	canary := mock.Alloc()
+	canary.Job = j
+	canary.JobID = j.ID
+	canary.DeploymentID = d.ID
+	canary.ClientStatus = structs.AllocClientStatusRunning
+	canary.TaskStates = map[string]*structs.TaskState{
+		"web": {
+			Restarts:      1,
+			ResourceUsage: &structs.TaskResourceUsageSummary{CPUTicks: 200, MemoryRSS: 100},
+		},
+	}
+	stable := mock.Alloc()
+	stable.Job = j
+	stable.JobID = j.ID
+	stable.ClientStatus = structs.AllocClientStatusRunning
+	stable.TaskStates = map[string]*structs.TaskState{
+		"web": {ResourceUsage: &structs.TaskResourceUsageSummary{CPUTicks: 150, MemoryRSS: 200}},
+	}
+	d.TaskGroups[canary.TaskGroup].DesiredCanaries = 1
+	d.TaskGroups[canary.TaskGroup].PlacedCanaries = []string{canary.ID}
+	assert.Nil(m.state.UpsertJob(m.nextIndex(), j), "UpsertJob")
+	assert.Nil(m.state.UpsertDeployment(m.nextIndex(), d), "UpsertDeployment")
+	assert.Nil(m.state.UpsertAllocs(m.nextIndex(), []*structs.Allocation{canary, stable}), "UpsertAllocs")
+
+	m.On("UpdateDeploymentCanaryAnalysis", mocker.Anything).Return(nil)
+
+	w.SetEnabled(true, m.state)
+	testutil.WaitForResult(func() (bool, error) {
+		out, err := m.state.DeploymentByID(nil, d.ID)
+		if err != nil {
+			return false, err
+		}
+		n := len(out.TaskGroups[canary.TaskGroup].CanaryAnalysis)
+		return n != 0, fmt.Errorf("got %d snapshots", n)
+	}, func(err error) { t.Fatal(err) })
+
+	out, err := m.state.DeploymentByID(nil, d.ID)
+	assert.Nil(err)
+	s := out.TaskGroups[canary.TaskGroup].CanaryAnalysis[0]
+	assert.Equal(1, s.Canary.Allocs)
+	assert.EqualValues(1, s.Canary.TaskRestarts)
+	assert.Equal(1, s.Stable.Allocs)
+	assert.Equal(50.0, s.CPUDelta)
+	assert.EqualValues(-100, s.MemoryRSSDelta)
+}
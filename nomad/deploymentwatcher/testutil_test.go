@@ -168,6 +168,12 @@ func (m *mockBackend) UpdateDeploymentAllocHealth(req *structs.ApplyDeploymentAl
 	return i, m.state.UpdateDeploymentAllocHealth(i, req)
 }
 
+func (m *mockBackend) UpdateDeploymentCanaryAnalysis(req *structs.ApplyDeploymentCanaryAnalysisRequest) (uint64, error) {
+	m.Called(req)
+	i := m.nextIndex()
+	return i, m.state.UpdateDeploymentCanaryAnalysis(i, req)
+}
+
 // matchDeploymentAllocHealthRequestConfig is used to configure the matching
 // function
 type matchDeploymentAllocHealthRequestConfig struct {
@@ -0,0 +1,143 @@
+package nomad
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// rootKeySize is the size of the AES-256 keys of the keyring
+const rootKeySize = 32
+
+// encryptVariable encrypts the items of a variable with the active key of the
+// keyring. The keyring is initialized with a new key if it is empty, so it
+// must only be called by the leader.
+func (s *Server) encryptVariable(v *structs.Variable) (*structs.VariableEncrypted, error) {
+	key, material, err := s.activeRootKey()
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := rootKeyCipher(key.KeyID, material)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(v.Items)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	return &structs.VariableEncrypted{
+		Namespace: v.Namespace,
+		Path:      v.Path,
+		KeyID:     key.KeyID,
+		Data:      aead.Seal(nonce, nonce, plaintext, variableAdditionalData(v.Namespace, v.Path)),
+	}, nil
+}
+
+// decryptVariable decrypts an encrypted variable with the key of the keyring
+// it was encrypted with.
+func (s *Server) decryptVariable(ev *structs.VariableEncrypted) (*structs.Variable, error) {
+	key, err := s.fsm.State().RootKeyByID(nil, ev.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, fmt.Errorf("root key %q of variable %q not found", ev.KeyID, ev.Path)
+	}
+	material, err := s.unwrapOwnRootKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := rootKeyCipher(key.KeyID, material)
+	if err != nil {
+		return nil, err
+	}
+	if len(ev.Data) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted data of variable %q is truncated", ev.Path)
+	}
+	nonce, ciphertext := ev.Data[:aead.NonceSize()], ev.Data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, variableAdditionalData(ev.Namespace, ev.Path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt variable %q: %v", ev.Path, err)
+	}
+
+	v := &structs.Variable{
+		Namespace:   ev.Namespace,
+		Path:        ev.Path,
+		CreateIndex: ev.CreateIndex,
+		ModifyIndex: ev.ModifyIndex,
+	}
+	if err := json.Unmarshal(plaintext, &v.Items); err != nil {
+		return nil, fmt.Errorf("failed to decode variable %q: %v", ev.Path, err)
+	}
+	return v, nil
+}
+
+// activeRootKey returns the key new variables are encrypted with along with
+// its key material, adding a new key to the keyring via Raft if it is empty.
+// New keys are wrapped for every alive server of the region, and for the
+// servers joining later by the leader reconciling the members.
+func (s *Server) activeRootKey() (*structs.RootKey, []byte, error) {
+	s.keyringLock.Lock()
+	defer s.keyringLock.Unlock()
+
+	key, err := s.fsm.State().ActiveRootKey(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if key != nil {
+		material, err := s.unwrapOwnRootKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		return key, material, nil
+	}
+
+	material := make([]byte, rootKeySize)
+	if _, err := rand.Read(material); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate root key: %v", err)
+	}
+	key = &structs.RootKey{
+		KeyID:       uuid.Generate(),
+		WrappedKeys: make(map[string][]byte),
+	}
+	for _, kek := range s.keyringKEKs() {
+		wrapped, err := wrapRootKey(kek, key.KeyID, material)
+		if err != nil {
+			return nil, nil, err
+		}
+		key.WrappedKeys[kek] = wrapped
+	}
+	if err := s.upsertRootKeys([]*structs.RootKey{key}); err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize the keyring: %v", err)
+	}
+	s.logger.Printf("[INFO] nomad: initialized the keyring with root key %q", key.KeyID)
+	return key, material, nil
+}
+
+// rootKeyCipher returns the AES-GCM cipher of the key material of a root key
+func rootKeyCipher(keyID string, material []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(material)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root key %q: %v", keyID, err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// variableAdditionalData binds the encrypted items of a variable to its
+// namespace and path so they can't be swapped with those of another
+// variable.
+func variableAdditionalData(namespace, path string) []byte {
+	return []byte(namespace + "\x00" + path)
+}
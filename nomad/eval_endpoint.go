@@ -317,6 +317,183 @@ func (e *Eval) Reap(args *structs.EvalDeleteRequest,
 	return nil
 }
 
+// DeleteBatch is used to delete the evaluations of the namespace that match a
+// filter, following the rules of Delete. A batch of evaluations is deleted per
+// request so that large cleanups can report their progress.
+func (e *Eval) DeleteBatch(args *structs.EvalBatchDeleteRequest, reply *structs.EvalBatchDeleteResponse) error {
+	if done, err := e.srv.forward("Eval.DeleteBatch", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "eval", "delete_batch"}, time.Now())
+
+	// Check for submit-job permissions
+	if aclObj, err := e.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilitySubmitJob) {
+		return structs.ErrPermissionDenied
+	}
+
+	f, err := parseBulkFilter(args.Filter, &structs.Evaluation{})
+	if err != nil {
+		return err
+	}
+
+	snap, err := e.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	iter, err := snap.EvalsByNamespace(nil, args.RequestNamespace())
+	if err != nil {
+		return err
+	}
+
+	// Collect a batch of matching evaluations
+	batchSize := bulkBatchSize(args.BatchSize)
+	var evals []*structs.Evaluation
+	var last string
+	processed := 0
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		eval := raw.(*structs.Evaluation)
+		if eval.ID <= args.NextToken {
+			continue
+		}
+		match, err := f.Match(eval)
+		if err != nil {
+			return err
+		}
+		if !match {
+			continue
+		}
+
+		// Stop once the batch is full, there are more evaluations to check
+		if processed == batchSize {
+			reply.NextToken = last
+			break
+		}
+		processed++
+		last = eval.ID
+
+		if !evalDeletable(eval) {
+			reply.Skipped++
+			continue
+		}
+		evals = append(evals, eval)
+	}
+
+	if len(evals) == 0 {
+		index, err := snap.Index("evals")
+		if err != nil {
+			return err
+		}
+		reply.Index = index
+		return nil
+	}
+
+	index, err := e.deleteEvals(evals, args.WriteRequest)
+	if err != nil {
+		return err
+	}
+
+	reply.Deleted = len(evals)
+	reply.Index = index
+	return nil
+}
+
+// Delete is used to delete evaluations by ID, including blocked evaluations
+// that are stuck. Pending evaluations are in the eval broker and are skipped.
+func (e *Eval) Delete(args *structs.EvalDeleteByIDRequest, reply *structs.EvalDeleteByIDResponse) error {
+	if done, err := e.srv.forward("Eval.Delete", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "eval", "delete"}, time.Now())
+
+	if len(args.EvalIDs) == 0 {
+		return fmt.Errorf("must specify at least one evaluation")
+	}
+
+	aclObj, err := e.srv.ResolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+
+	snap, err := e.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+
+	var evals []*structs.Evaluation
+	for _, id := range args.EvalIDs {
+		eval, err := snap.EvalByID(nil, id)
+		if err != nil {
+			return err
+		}
+		if eval == nil {
+			return fmt.Errorf("evaluation %q not found", id)
+		}
+
+		// Check for submit-job permissions in the namespace of the evaluation
+		if aclObj != nil && !aclObj.AllowNsOp(eval.Namespace, acl.NamespaceCapabilitySubmitJob) {
+			return structs.ErrPermissionDenied
+		}
+
+		if !evalDeletable(eval) {
+			reply.Skipped = append(reply.Skipped, eval.ID)
+			continue
+		}
+		evals = append(evals, eval)
+	}
+
+	if len(evals) == 0 {
+		index, err := snap.Index("evals")
+		if err != nil {
+			return err
+		}
+		reply.Index = index
+		return nil
+	}
+
+	index, err := e.deleteEvals(evals, args.WriteRequest)
+	if err != nil {
+		return err
+	}
+
+	for _, eval := range evals {
+		reply.Deleted = append(reply.Deleted, eval.ID)
+	}
+	reply.Index = index
+	return nil
+}
+
+// evalDeletable returns whether an evaluation may be deleted. Pending
+// evaluations are in the eval broker and are skipped, while blocked ones are
+// deleted and untracked by deleteEvals.
+func evalDeletable(eval *structs.Evaluation) bool {
+	return eval.Status != structs.EvalStatusPending
+}
+
+// deleteEvals commits the deletion of the evaluations via Raft and stops
+// tracking the deleted blocked evaluations so they are never unblocked.
+func (e *Eval) deleteEvals(evals []*structs.Evaluation, wr structs.WriteRequest) (uint64, error) {
+	req := &structs.EvalDeleteRequest{
+		WriteRequest: wr,
+	}
+	for _, eval := range evals {
+		req.Evals = append(req.Evals, eval.ID)
+	}
+	_, index, err := e.srv.raftApply(structs.EvalDeleteRequestType, req)
+	if err != nil {
+		e.srv.logger.Printf("[ERR] nomad.eval: Eval delete failed: %v", err)
+		return 0, err
+	}
+
+	for _, eval := range evals {
+		if eval.Status == structs.EvalStatusBlocked {
+			e.srv.blockedEvals.UntrackEval(eval.ID)
+		}
+	}
+	return index, nil
+}
+
 // List is used to get a list of the evaluations in the system
 func (e *Eval) List(args *structs.EvalListRequest,
 	reply *structs.EvalListResponse) error {
@@ -356,6 +533,9 @@ func (e *Eval) List(args *structs.EvalListRequest,
 					break
 				}
 				eval := raw.(*structs.Evaluation)
+				if args.ShouldBeFiltered(eval) {
+					continue
+				}
 				evals = append(evals, eval)
 			}
 			reply.Evaluations = evals
@@ -17,6 +17,7 @@ import (
 	"github.com/hashicorp/nomad/scheduler"
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestEvalEndpoint_GetEval(t *testing.T) {
@@ -1098,3 +1099,185 @@ func TestGenerateMigrateToken(t *testing.T) {
 	assert.False(CompareMigrateToken(allocID, nodeSecret, token2))
 	assert.True(CompareMigrateToken("x", nodeSecret, token2))
 }
+
+func TestEvalEndpoint_DeleteBatch(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Create terminal, pending and blocked evaluations of batch jobs and
+	// another job
+	state := s1.fsm.State()
+	var evals []*structs.Evaluation
+	for _, jobID := range []string{"batch-1", "batch-2", "batch-3", "batch-4", "service"} {
+		eval := mock.Eval()
+		eval.JobID = jobID
+		eval.Status = structs.EvalStatusComplete
+		evals = append(evals, eval)
+	}
+	evals[2].Status = structs.EvalStatusPending
+	evals[3].Status = structs.EvalStatusBlocked
+	assert.Nil(state.UpsertEvals(1000, evals))
+	s1.blockedEvals.Block(evals[3])
+	testutil.WaitForResult(func() (bool, error) {
+		return s1.blockedEvals.Stats().TotalBlocked == 1, nil
+	}, func(err error) {
+		t.Fatalf("eval not blocked")
+	})
+
+	// Invalid filters are rejected
+	req := &structs.EvalBatchDeleteRequest{
+		Filter:       `Missing == "a"`,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.EvalBatchDeleteResponse
+	err := msgpackrpc.CallWithCodec(codec, "Eval.DeleteBatch", req, &resp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "invalid filter")
+	}
+
+	// Delete the matching evaluations one at a time
+	req.Filter = `JobID contains "batch-"`
+	req.BatchSize = 1
+	deleted, skipped, requests := 0, 0, 0
+	for {
+		resp = structs.EvalBatchDeleteResponse{}
+		assert.Nil(msgpackrpc.CallWithCodec(codec, "Eval.DeleteBatch", req, &resp))
+		assert.NotZero(resp.Index)
+		deleted += resp.Deleted
+		skipped += resp.Skipped
+		requests++
+		if resp.NextToken == "" {
+			break
+		}
+		req.NextToken = resp.NextToken
+	}
+	assert.Equal(3, deleted)
+	assert.Equal(1, skipped)
+	assert.Equal(4, requests)
+
+	// The blocked evaluation is no longer tracked
+	assert.Equal(0, s1.blockedEvals.Stats().TotalBlocked)
+
+	// Only the pending and unmatched evaluations remain
+	for i, eval := range evals {
+		out, err := state.EvalByID(nil, eval.ID)
+		assert.Nil(err)
+		if i == 2 || i == 4 {
+			assert.NotNil(out, eval.JobID)
+		} else {
+			assert.Nil(out, eval.JobID)
+		}
+	}
+}
+
+func TestEvalEndpoint_List_Filters(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	require := require.New(t)
+
+	// Create evaluations of two jobs, one of them blocked
+	e1, e2, e3 := mock.Eval(), mock.Eval(), mock.Eval()
+	e1.JobID, e2.JobID, e3.JobID = "web", "web", "batch"
+	e1.Status = structs.EvalStatusBlocked
+	e3.TriggeredBy = structs.EvalTriggerNodeUpdate
+	require.Nil(s1.fsm.State().UpsertEvals(1000, []*structs.Evaluation{e1, e2, e3}))
+
+	cases := []struct {
+		req      structs.EvalListRequest
+		expected []string
+	}{
+		{structs.EvalListRequest{}, []string{e1.ID, e2.ID, e3.ID}},
+		{structs.EvalListRequest{FilterJobID: "web"}, []string{e1.ID, e2.ID}},
+		{structs.EvalListRequest{FilterJobID: "web", FilterEvalStatus: structs.EvalStatusPending}, []string{e2.ID}},
+		{structs.EvalListRequest{FilterTriggeredBy: structs.EvalTriggerNodeUpdate}, []string{e3.ID}},
+	}
+	for _, c := range cases {
+		c.req.Region = "global"
+		c.req.Namespace = structs.DefaultNamespace
+		var resp structs.EvalListResponse
+		require.Nil(msgpackrpc.CallWithCodec(codec, "Eval.List", &c.req, &resp))
+
+		var ids []string
+		for _, eval := range resp.Evaluations {
+			ids = append(ids, eval.ID)
+		}
+		require.Len(ids, len(c.expected))
+		for _, id := range c.expected {
+			require.Contains(ids, id)
+		}
+	}
+}
+
+func TestEvalEndpoint_Delete(t *testing.T) {
+	t.Parallel()
+	s1, root := testACLServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	require := require.New(t)
+
+	// Create a blocked, a pending and a failed evaluation
+	state := s1.fsm.State()
+	blocked, pending, failed := mock.Eval(), mock.Eval(), mock.Eval()
+	blocked.Status = structs.EvalStatusBlocked
+	failed.Status = structs.EvalStatusFailed
+	require.Nil(state.UpsertEvals(1000, []*structs.Evaluation{blocked, pending, failed}))
+	s1.blockedEvals.Block(blocked)
+	testutil.WaitForResult(func() (bool, error) {
+		return s1.blockedEvals.Stats().TotalBlocked == 1, nil
+	}, func(err error) {
+		t.Fatalf("eval not blocked")
+	})
+
+	req := &structs.EvalDeleteByIDRequest{
+		EvalIDs:      []string{blocked.ID, pending.ID, failed.ID},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.EvalDeleteByIDResponse
+
+	// Deleting requires the submit-job capability
+	invalidToken := mock.CreatePolicyAndToken(t, state, 1001, "test-invalid",
+		mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilityReadJob}))
+	req.AuthToken = invalidToken.SecretID
+	err := msgpackrpc.CallWithCodec(codec, "Eval.Delete", req, &resp)
+	require.EqualError(err, structs.ErrPermissionDenied.Error())
+
+	validToken := mock.CreatePolicyAndToken(t, state, 1003, "test-valid",
+		mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilitySubmitJob}))
+	req.AuthToken = validToken.SecretID
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Eval.Delete", req, &resp))
+	require.NotZero(resp.Index)
+	require.Len(resp.Deleted, 2)
+	require.Contains(resp.Deleted, blocked.ID)
+	require.Contains(resp.Deleted, failed.ID)
+	require.Equal([]string{pending.ID}, resp.Skipped)
+
+	// The blocked evaluation is no longer tracked
+	require.Equal(0, s1.blockedEvals.Stats().TotalBlocked)
+
+	// Only the pending evaluation remains
+	out, err := state.EvalByID(nil, pending.ID)
+	require.Nil(err)
+	require.NotNil(out)
+	for _, id := range resp.Deleted {
+		out, err := state.EvalByID(nil, id)
+		require.Nil(err)
+		require.Nil(out)
+	}
+
+	// Unknown evaluations are rejected
+	req.AuthToken = root.SecretID
+	req.EvalIDs = []string{blocked.ID}
+	err = msgpackrpc.CallWithCodec(codec, "Eval.Delete", req, &resp)
+	require.Error(err)
+	require.Contains(err.Error(), "not found")
+}
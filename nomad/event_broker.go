@@ -0,0 +1,345 @@
+package nomad
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// eventPublishInterval is the minimum interval between diffs of the
+	// state store, so bursts of writes are published as a single batch.
+	eventPublishInterval = 50 * time.Millisecond
+)
+
+// eventTopic describes how the events of a topic are generated from a table
+// of the state store.
+type eventTopic struct {
+	// table is the name of the table in the state store and in exportTables
+	table string
+
+	// watch adds the table to the watch set
+	watch func(s *state.StateStore, ws memdb.WatchSet) error
+
+	// key and namespace return the key and namespace of the event of an
+	// object
+	key       func(raw interface{}) string
+	namespace func(raw interface{}) string
+
+	// payload returns the payload of the event of a sanitized object
+	payload func(obj interface{}) *structs.EventPayload
+}
+
+// eventTopics are the topics of the event stream. Objects are diffed and
+// sanitized as they are for the state export.
+var eventTopics = map[string]*eventTopic{
+	structs.TopicJob: {
+		table: "jobs",
+		watch: func(s *state.StateStore, ws memdb.WatchSet) error {
+			_, err := s.Jobs(ws)
+			return err
+		},
+		key:       func(raw interface{}) string { return raw.(*structs.Job).ID },
+		namespace: func(raw interface{}) string { return raw.(*structs.Job).Namespace },
+		payload: func(obj interface{}) *structs.EventPayload {
+			return &structs.EventPayload{Job: obj.(*structs.Job)}
+		},
+	},
+	structs.TopicAllocation: {
+		table: "allocs",
+		watch: func(s *state.StateStore, ws memdb.WatchSet) error {
+			_, err := s.Allocs(ws)
+			return err
+		},
+		key:       func(raw interface{}) string { return raw.(*structs.Allocation).ID },
+		namespace: func(raw interface{}) string { return raw.(*structs.Allocation).Namespace },
+		payload: func(obj interface{}) *structs.EventPayload {
+			return &structs.EventPayload{Allocation: obj.(*structs.Allocation)}
+		},
+	},
+	structs.TopicDeployment: {
+		table: "deployments",
+		watch: func(s *state.StateStore, ws memdb.WatchSet) error {
+			_, err := s.Deployments(ws)
+			return err
+		},
+		key:       func(raw interface{}) string { return raw.(*structs.Deployment).ID },
+		namespace: func(raw interface{}) string { return raw.(*structs.Deployment).Namespace },
+		payload: func(obj interface{}) *structs.EventPayload {
+			return &structs.EventPayload{Deployment: obj.(*structs.Deployment)}
+		},
+	},
+	structs.TopicNode: {
+		table: "nodes",
+		watch: func(s *state.StateStore, ws memdb.WatchSet) error {
+			_, err := s.Nodes(ws)
+			return err
+		},
+		key:       func(raw interface{}) string { return raw.(*structs.Node).ID },
+		namespace: func(raw interface{}) string { return "" },
+		payload: func(obj interface{}) *structs.EventPayload {
+			return &structs.EventPayload{Node: obj.(*structs.Node)}
+		},
+	},
+}
+
+// eventBroker retains the most recent events and wakes up subscribers when
+// new events are published. The events evicted from memory can be retained
+// on disk by an event log.
+type eventBroker struct {
+	size int
+
+	// events are the retained events, oldest first
+	events []*structs.Event
+
+	// evicted is the index of the newest event evicted from events
+	evicted uint64
+
+	// log retains the events on disk if set
+	log *eventLog
+
+	// index is the index of the last published batch
+	index uint64
+
+	// notifyCh is closed when the next batch is published
+	notifyCh chan struct{}
+
+	l sync.RWMutex
+}
+
+// newEventBroker returns a broker retaining up to size events in memory and
+// the events of the log, if any, on disk.
+func newEventBroker(size int, log *eventLog) *eventBroker {
+	return &eventBroker{
+		size:     size,
+		log:      log,
+		notifyCh: make(chan struct{}),
+	}
+}
+
+// publish appends a batch of events observed at the index, evicting the oldest
+// events beyond the size of the broker. Events of the same index are evicted
+// together, so more events than the size are retained if the oldest index
+// retained would otherwise be incomplete. The batch is published even if it
+// could not be written to the event log, and the error is returned.
+func (b *eventBroker) publish(index uint64, events []*structs.Event) error {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	var err error
+	if b.log != nil {
+		err = b.log.append(events)
+	}
+
+	b.events = append(b.events, events...)
+	if over := len(b.events) - b.size; over > 0 {
+		// Keep the events of an index together, so the retained events of
+		// each index are complete
+		for over > 0 && b.events[over-1].Index == b.events[over].Index {
+			over--
+		}
+
+		if over > 0 {
+			b.evicted = b.events[over-1].Index
+
+			// Copy so the evicted events can be garbage collected
+			retained := make([]*structs.Event, len(b.events)-over)
+			copy(retained, b.events[over:])
+			b.events = retained
+		}
+	}
+
+	b.index = index
+	close(b.notifyCh)
+	b.notifyCh = make(chan struct{})
+	return err
+}
+
+// after returns the retained events after the index that match the filter,
+// the index up to which events were returned, and a channel closed when the
+// next batch is published. Events evicted from memory are read from the event
+// log, in which case fewer events may be returned and the index is the one of
+// the last returned event.
+func (b *eventBroker) after(index uint64, match func(*structs.Event) bool) ([]*structs.Event, uint64, <-chan struct{}, error) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	if b.log != nil && index < b.evicted {
+		out, truncated, err := b.log.after(index, match)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if truncated {
+			return out, out[len(out)-1].Index, b.notifyCh, nil
+		}
+		return out, b.index, b.notifyCh, nil
+	}
+
+	start := sort.Search(len(b.events), func(i int) bool {
+		return b.events[i].Index > index
+	})
+
+	var out []*structs.Event
+	for _, e := range b.events[start:] {
+		if match(e) {
+			out = append(out, e)
+		}
+	}
+	return out, b.index, b.notifyCh, nil
+}
+
+// retained returns the index of the oldest retained event, or zero if no
+// events are retained, and the index of the last published batch.
+func (b *eventBroker) retained() (oldest, latest uint64) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	if b.log != nil {
+		return b.log.oldest, b.index
+	}
+	if len(b.events) != 0 {
+		oldest = b.events[0].Index
+	}
+	return oldest, b.index
+}
+
+// close closes the event log of the broker, if any
+func (b *eventBroker) close() error {
+	if b.log == nil {
+		return nil
+	}
+
+	b.l.Lock()
+	defer b.l.Unlock()
+	return b.log.close()
+}
+
+// eventDiff returns the events of the changes between the previous snapshot,
+// taken at prevIndex, and the current one. Only tables modified since the
+// previous snapshot are diffed.
+func eventDiff(prev *state.StateSnapshot, prevIndex uint64, snap *state.StateSnapshot, index uint64) ([]*structs.Event, error) {
+	var events []*structs.Event
+	for name, topic := range eventTopics {
+		tableIndex, err := snap.Index(topic.table)
+		if err != nil {
+			return nil, err
+		}
+		if tableIndex <= prevIndex {
+			continue
+		}
+
+		t := exportTables[topic.table]
+		iter, err := t.list(&snap.StateStore)
+		if err != nil {
+			return nil, err
+		}
+		for raw := iter.Next(); raw != nil; raw = iter.Next() {
+			if modify := t.modifyIndex(raw); modify > prevIndex {
+				events = append(events, &structs.Event{
+					Topic:     name,
+					Type:      structs.EventTypeUpserted,
+					Key:       topic.key(raw),
+					Namespace: topic.namespace(raw),
+					Index:     modify,
+					Payload:   topic.payload(t.sanitize(raw)),
+				})
+			}
+		}
+
+		// Objects of the previous snapshot that no longer exist were deleted
+		iter, err = t.list(&prev.StateStore)
+		if err != nil {
+			return nil, err
+		}
+		for raw := iter.Next(); raw != nil; raw = iter.Next() {
+			exists, err := t.exists(&snap.StateStore, raw)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				events = append(events, &structs.Event{
+					Topic:     name,
+					Type:      structs.EventTypeDeleted,
+					Key:       topic.key(raw),
+					Namespace: topic.namespace(raw),
+					Index:     index,
+					Payload:   topic.payload(t.sanitize(raw)),
+				})
+			}
+		}
+	}
+
+	// Subscribers read the events after an index, so they must be ordered
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Index < events[j].Index
+	})
+	return events, nil
+}
+
+// publishEvents diffs the state store each time it changes and publishes the
+// changes to the event broker until the server shuts down. Every server
+// publishes the events of its own state store, so subscribers can be served
+// by any server.
+func (s *Server) publishEvents() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.shutdownCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	var prev *state.StateSnapshot
+	var prevIndex uint64
+	for {
+		// Watch the tables before taking the snapshot so that no change is
+		// missed
+		store := s.fsm.State()
+		ws := memdb.NewWatchSet()
+		ws.Add(store.AbandonCh())
+		for _, topic := range eventTopics {
+			if err := topic.watch(store, ws); err != nil {
+				s.logger.Printf("[ERR] nomad.events: failed to watch table %q: %v", topic.table, err)
+			}
+		}
+
+		snap, err := store.Snapshot()
+		if err != nil {
+			s.logger.Printf("[ERR] nomad.events: failed to snapshot state: %v", err)
+		} else if index, err := snap.LatestIndex(); err != nil {
+			s.logger.Printf("[ERR] nomad.events: failed to determine state index: %v", err)
+		} else if prev == nil {
+			// Events are only published for changes after the server
+			// started
+			prev, prevIndex = snap, index
+		} else if index > prevIndex {
+			events, err := eventDiff(prev, prevIndex, snap, index)
+			if err != nil {
+				s.logger.Printf("[ERR] nomad.events: failed to diff state: %v", err)
+			} else {
+				if err := s.eventBroker.publish(index, events); err != nil {
+					s.logger.Printf("[ERR] nomad.events: failed to write events to the event log: %v", err)
+				}
+				prev, prevIndex = snap, index
+			}
+		}
+
+		// A failure is retried on the next change
+		if err := ws.WatchCtx(ctx); err != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventPublishInterval):
+		}
+	}
+}
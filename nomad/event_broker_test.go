@@ -0,0 +1,140 @@
+package nomad
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBroker_Publish(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	b := newEventBroker(2, nil)
+	all := func(*structs.Event) bool { return true }
+
+	_, index, notifyCh, err := b.after(0, all)
+	assert.Nil(err)
+	assert.EqualValues(0, index)
+
+	assert.Nil(b.publish(10, []*structs.Event{{Key: "a", Index: 9}, {Key: "b", Index: 10}}))
+	select {
+	case <-notifyCh:
+	default:
+		t.Fatalf("subscriber not notified")
+	}
+
+	// The oldest events are evicted
+	assert.Nil(b.publish(12, []*structs.Event{{Key: "c", Index: 12}}))
+	events, index, _, err := b.after(0, all)
+	assert.Nil(err)
+	assert.EqualValues(12, index)
+	if assert.Len(events, 2) {
+		assert.Equal("b", events[0].Key)
+		assert.Equal("c", events[1].Key)
+	}
+
+	// Only the events after the index are returned
+	events, _, _, _ = b.after(10, all)
+	if assert.Len(events, 1) {
+		assert.Equal("c", events[0].Key)
+	}
+
+	// Events are filtered
+	events, _, _, _ = b.after(0, func(e *structs.Event) bool { return e.Key == "b" })
+	assert.Len(events, 1)
+
+	// The events of an index are evicted together
+	assert.Nil(b.publish(13, []*structs.Event{{Key: "d", Index: 13}, {Key: "e", Index: 13}}))
+	oldest, latest := b.retained()
+	assert.EqualValues(13, oldest)
+	assert.EqualValues(13, latest)
+}
+
+func TestEventBroker_Log(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	log, err := newEventLog(filepath.Join(dir, eventLogPath), 3)
+	assert.Nil(err)
+	b := newEventBroker(1, log)
+	defer b.close()
+	all := func(*structs.Event) bool { return true }
+
+	assert.Nil(b.publish(10, []*structs.Event{{Key: "a", Index: 9}, {Key: "b", Index: 10}}))
+	assert.Nil(b.publish(12, []*structs.Event{{Key: "c", Index: 12}, {Key: "d", Index: 12}}))
+
+	// Events evicted from memory are read from disk, and the log evicts the
+	// oldest index
+	events, index, _, err := b.after(0, all)
+	assert.Nil(err)
+	assert.EqualValues(12, index)
+	if assert.Len(events, 3) {
+		assert.Equal("b", events[0].Key)
+		assert.Equal("c", events[1].Key)
+		assert.Equal("d", events[2].Key)
+	}
+
+	oldest, latest := b.retained()
+	assert.EqualValues(10, oldest)
+	assert.EqualValues(12, latest)
+
+	// Events still in memory are read from memory
+	events, _, _, err = b.after(12, all)
+	assert.Nil(err)
+	assert.Len(events, 0)
+
+	// Events of a previous run are discarded
+	assert.Nil(b.close())
+	log, err = newEventLog(filepath.Join(dir, eventLogPath), 3)
+	assert.Nil(err)
+	defer log.close()
+	events, _, err = log.after(0, all)
+	assert.Nil(err)
+	assert.Len(events, 0)
+}
+
+func TestEventDiff(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	s := state.TestStateStore(t)
+	job := mock.Job()
+	node := mock.Node()
+	assert.Nil(s.UpsertJob(1000, job))
+	assert.Nil(s.UpsertNode(1001, node))
+	prev, err := s.Snapshot()
+	assert.Nil(err)
+
+	// Update the node and delete the job
+	assert.Nil(s.UpdateNodeDrain(1002, node.ID, true))
+	assert.Nil(s.DeleteJob(1003, job.Namespace, job.ID))
+	snap, err := s.Snapshot()
+	assert.Nil(err)
+
+	events, err := eventDiff(prev, 1001, snap, 1003)
+	assert.Nil(err)
+	if assert.Len(events, 2) {
+		assert.Equal(structs.TopicNode, events[0].Topic)
+		assert.Equal(structs.EventTypeUpserted, events[0].Type)
+		assert.Equal(node.ID, events[0].Key)
+		assert.EqualValues(1002, events[0].Index)
+		assert.Empty(events[0].Payload.Node.SecretID)
+
+		assert.Equal(structs.TopicJob, events[1].Topic)
+		assert.Equal(structs.EventTypeDeleted, events[1].Type)
+		assert.Equal(job.ID, events[1].Key)
+		assert.Equal(job.Namespace, events[1].Namespace)
+		assert.EqualValues(1003, events[1].Index)
+	}
+}
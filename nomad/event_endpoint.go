@@ -0,0 +1,143 @@
+package nomad
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// Event endpoint is used to subscribe to the changes of the state store
+type Event struct {
+	srv *Server
+}
+
+// List returns the events after the query's MinQueryIndex that match the
+// request's topics, namespace and key prefix, blocking until there are some or
+// the query times out. Events of namespaces the token can not read, and node
+// events if it can not read nodes, are omitted.
+func (e *Event) List(args *structs.EventStreamRequest, reply *structs.EventStreamResponse) error {
+	if done, err := e.srv.forward("Event.List", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "event", "list"}, time.Now())
+
+	broker := e.srv.eventBroker
+	if broker == nil {
+		return fmt.Errorf("event stream is disabled")
+	}
+
+	for _, topic := range args.Topics {
+		if _, ok := eventTopics[topic]; !ok {
+			return fmt.Errorf("unknown topic %q", topic)
+		}
+	}
+
+	// Check namespace read-job permissions unless reading all namespaces, in
+	// which case each event is checked
+	namespace := args.RequestNamespace()
+	aclObj, err := e.srv.ResolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	} else if aclObj != nil && namespace != structs.AllNamespacesSentinel &&
+		!aclObj.AllowNsOp(namespace, acl.NamespaceCapabilityReadJob) {
+		return structs.ErrPermissionDenied
+	}
+
+	filter := &structs.EventFilter{
+		Topics:    args.Topics,
+		Namespace: namespace,
+		Prefix:    args.Prefix,
+	}
+	match := func(ev *structs.Event) bool {
+		if !filter.Matches(ev) {
+			return false
+		}
+		if aclObj == nil {
+			return true
+		}
+		if ev.Topic == structs.TopicNode {
+			return aclObj.AllowNodeRead()
+		}
+		return aclObj.AllowNsOp(ev.Namespace, acl.NamespaceCapabilityReadJob)
+	}
+
+	// Restrict the max query time, and ensure there is always one
+	if args.MaxQueryTime > maxQueryTime {
+		args.MaxQueryTime = maxQueryTime
+	} else if args.MaxQueryTime <= 0 {
+		args.MaxQueryTime = defaultQueryTime
+	}
+	args.MaxQueryTime += lib.RandomStagger(args.MaxQueryTime / jitterFraction)
+	ctx, cancel := context.WithTimeout(context.Background(), args.MaxQueryTime)
+	defer cancel()
+
+	index := args.MinQueryIndex
+	for {
+		events, latest, notifyCh, err := broker.after(index, match)
+		if err != nil {
+			return err
+		}
+		reply.Events = events
+		reply.Index = latest
+		if reply.Index < args.MinQueryIndex {
+			reply.Index = args.MinQueryIndex
+		}
+
+		// Return immediately for a new subscriber, otherwise block until
+		// there are matching events
+		if len(events) != 0 || args.MinQueryIndex == 0 {
+			break
+		}
+		if latest > index {
+			index = latest
+		}
+
+		select {
+		case <-notifyCh:
+			continue
+		case <-ctx.Done():
+		case <-e.srv.shutdownCh:
+		}
+		break
+	}
+
+	e.srv.setQueryMeta(&reply.QueryMeta)
+	return nil
+}
+
+// Retention returns the range of events retained by the server, so
+// subscribers can determine whether they can resume from an index.
+func (e *Event) Retention(args *structs.EventRetentionRequest, reply *structs.EventRetentionResponse) error {
+	if done, err := e.srv.forward("Event.Retention", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "event", "retention"}, time.Now())
+
+	broker := e.srv.eventBroker
+	if broker == nil {
+		return fmt.Errorf("event stream is disabled")
+	}
+
+	namespace := args.RequestNamespace()
+	if aclObj, err := e.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && namespace != structs.AllNamespacesSentinel &&
+		!aclObj.AllowNsOp(namespace, acl.NamespaceCapabilityReadJob) {
+		return structs.ErrPermissionDenied
+	}
+
+	oldest, latest := broker.retained()
+	reply.Retention = &structs.EventRetention{
+		OldestIndex: oldest,
+		LatestIndex: latest,
+		Durable:     broker.log != nil,
+	}
+	reply.Index = latest
+	e.srv.setQueryMeta(&reply.QueryMeta)
+	return nil
+}
@@ -0,0 +1,190 @@
+package nomad
+
+import (
+	"fmt"
+	"testing"
+
+	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// The IDs of the allocations of the tests. They share prefixes to test
+// filtering events by key prefix.
+const (
+	eventWebPrefix = "aaaaaaaa"
+	eventWeb1      = eventWebPrefix + "-0000-0000-0000-000000000001"
+	eventWeb2      = eventWebPrefix + "-0000-0000-0000-000000000002"
+	eventAPI1      = "bbbbbbbb-0000-0000-0000-000000000001"
+)
+
+// eventKeys returns the keys of the events after waiting for the events up to
+// the index to be published.
+func eventKeys(t *testing.T, s *Server, args *structs.EventStreamRequest, index uint64) []string {
+	codec := rpcClient(t, s)
+	var keys []string
+	testutil.WaitForResult(func() (bool, error) {
+		var resp structs.EventStreamResponse
+		if err := msgpackrpc.CallWithCodec(codec, "Event.List", args, &resp); err != nil {
+			return false, err
+		}
+		if resp.Index < index {
+			return false, fmt.Errorf("events published up to %d; want %d", resp.Index, index)
+		}
+		keys = nil
+		for _, e := range resp.Events {
+			keys = append(keys, e.Key)
+		}
+		return true, nil
+	}, func(err error) {
+		t.Fatalf("err: %v", err)
+	})
+	return keys
+}
+
+// eventAlloc returns an allocation with the given ID in the namespace, along
+// with its job summary.
+func eventAlloc(id, namespace string) (*structs.Allocation, *structs.JobSummary) {
+	alloc := mock.Alloc()
+	alloc.ID = id
+	alloc.Namespace = namespace
+	alloc.Job.Namespace = namespace
+	summary := mock.JobSummary(alloc.JobID)
+	summary.Namespace = namespace
+	return alloc, summary
+}
+
+func TestEvent_List_Filter(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	s1 := testServer(t, func(c *Config) {
+		c.EventBufferSize = 100
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create allocations in two namespaces
+	state := s1.fsm.State()
+	web, webSummary := eventAlloc(eventWeb1, structs.DefaultNamespace)
+	api, apiSummary := eventAlloc(eventAPI1, structs.DefaultNamespace)
+	other, otherSummary := eventAlloc(eventWeb2, "other")
+	assert.Nil(state.UpsertJobSummary(1000, webSummary))
+	assert.Nil(state.UpsertJobSummary(1001, apiSummary))
+	assert.Nil(state.UpsertJobSummary(1002, otherSummary))
+	assert.Nil(state.UpsertAllocs(1003, []*structs.Allocation{web}))
+	assert.Nil(state.UpsertAllocs(1004, []*structs.Allocation{api}))
+	assert.Nil(state.UpsertAllocs(1005, []*structs.Allocation{other}))
+
+	// Filter by namespace
+	args := &structs.EventStreamRequest{
+		Topics: []string{structs.TopicAllocation},
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			Namespace: structs.DefaultNamespace,
+		},
+	}
+	assert.Equal([]string{eventWeb1, eventAPI1}, eventKeys(t, s1, args, 1005))
+
+	// Filter by namespace and key prefix
+	args.Prefix = eventWebPrefix
+	assert.Equal([]string{eventWeb1}, eventKeys(t, s1, args, 1005))
+
+	// Every namespace
+	args.Namespace = structs.AllNamespacesSentinel
+	assert.Equal([]string{eventWeb1, eventWeb2}, eventKeys(t, s1, args, 1005))
+
+	// Other topics
+	args.Topics = []string{structs.TopicNode}
+	assert.Empty(eventKeys(t, s1, args, 1005))
+
+	// Unknown topics are rejected
+	args.Topics = []string{"foo"}
+	var resp structs.EventStreamResponse
+	assert.NotNil(msgpackrpc.CallWithCodec(rpcClient(t, s1), "Event.List", args, &resp))
+}
+
+func TestEvent_List_ACL(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	s1, _ := testACLServer(t, func(c *Config) {
+		c.EventBufferSize = 100
+	})
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	state := s1.fsm.State()
+	token := mock.CreatePolicyAndToken(t, state, 1000, "test-valid",
+		mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilityReadJob}))
+
+	// Create an allocation in each namespace and a node
+	alloc, summary := eventAlloc(eventWeb1, structs.DefaultNamespace)
+	other, otherSummary := eventAlloc(eventWeb2, "other")
+	node := mock.Node()
+	assert.Nil(state.UpsertJobSummary(1001, summary))
+	assert.Nil(state.UpsertJobSummary(1002, otherSummary))
+	assert.Nil(state.UpsertAllocs(1003, []*structs.Allocation{alloc, other}))
+	assert.Nil(state.UpsertNode(1004, node))
+
+	// Only the events of the namespaces the token can read are returned
+	args := &structs.EventStreamRequest{
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			Namespace: structs.AllNamespacesSentinel,
+			AuthToken: token.SecretID,
+		},
+	}
+	assert.Equal([]string{alloc.ID}, eventKeys(t, s1, args, 1004))
+
+	// Namespaces the token can not read are denied
+	args.Namespace = "other"
+	var resp structs.EventStreamResponse
+	err := msgpackrpc.CallWithCodec(rpcClient(t, s1), "Event.List", args, &resp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), structs.ErrPermissionDenied.Error())
+	}
+}
+
+func TestEvent_Retention(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	s1 := testServer(t, func(c *Config) {
+		c.EventBufferSize = 2
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create more allocations than the buffer retains
+	state := s1.fsm.State()
+	for i := 0; i < 3; i++ {
+		alloc, summary := eventAlloc(fmt.Sprintf("%s-0000-0000-0000-00000000000%d", eventWebPrefix, i), structs.DefaultNamespace)
+		assert.Nil(state.UpsertJobSummary(uint64(1000+2*i), summary))
+		assert.Nil(state.UpsertAllocs(uint64(1001+2*i), []*structs.Allocation{alloc}))
+	}
+
+	args := &structs.EventStreamRequest{
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			Namespace: structs.DefaultNamespace,
+		},
+	}
+	assert.Equal([]string{eventWeb1, eventWeb2}, eventKeys(t, s1, args, 1005))
+
+	get := &structs.EventRetentionRequest{
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			Namespace: structs.DefaultNamespace,
+		},
+	}
+	var resp structs.EventRetentionResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Event.Retention", get, &resp))
+	if assert.NotNil(resp.Retention) {
+		assert.EqualValues(1003, resp.Retention.OldestIndex)
+		assert.EqualValues(1005, resp.Retention.LatestIndex)
+		assert.False(resp.Retention.Durable)
+	}
+	assert.EqualValues(1005, resp.Index)
+}
@@ -0,0 +1,161 @@
+package nomad
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"github.com/boltdb/bolt"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	// eventLogPath is the path of the event log in the data directory
+	eventLogPath = "events/events.db"
+
+	// eventLogReadLimit is the maximum number of events returned by a read of
+	// the event log, so subscribers far behind catch up in several requests.
+	// Reads are only truncated between indexes.
+	eventLogReadLimit = 1000
+)
+
+var (
+	// eventLogBucket is the bolt bucket of the events
+	eventLogBucket = []byte("events")
+)
+
+// eventLog is a bounded on-disk buffer of the published events. The events
+// are keyed by their index followed by a sequence number, so they are read
+// back in the order they were published.
+type eventLog struct {
+	db   *bolt.DB
+	size int
+
+	// count is the number of retained events and seq the sequence number of
+	// the last appended event
+	count int
+	seq   uint64
+
+	// oldest is the index of the oldest retained event
+	oldest uint64
+}
+
+// newEventLog opens the event log at the path, retaining up to size events.
+// Events of a previous run are discarded, since the changes made while the
+// server was down can not be diffed.
+func newEventLog(path string, size int) (*eventLog, error) {
+	if err := ensurePath(path, false); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove previous event log: %v", err)
+	}
+
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log: %v", err)
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(eventLogBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create event log bucket: %v", err)
+	}
+
+	return &eventLog{
+		db:   db,
+		size: size,
+	}, nil
+}
+
+// eventLogKey returns the key of an event
+func eventLogKey(index, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, index)
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+// append writes the events and evicts the oldest events beyond the size of
+// the log. Events are evicted an index at a time, so the retained events of
+// each index are complete.
+func (l *eventLog) append(events []*structs.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	seq, count := l.seq, l.count
+	var oldest uint64
+	err := l.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket(eventLogBucket)
+		for _, e := range events {
+			var buf bytes.Buffer
+			if err := codec.NewEncoder(&buf, structs.MsgpackHandle).Encode(e); err != nil {
+				return fmt.Errorf("failed to encode event: %v", err)
+			}
+			seq++
+			if err := bkt.Put(eventLogKey(e.Index, seq), buf.Bytes()); err != nil {
+				return err
+			}
+			count++
+		}
+
+		// The cursor is repositioned after each delete, since moving it
+		// after a delete may skip keys
+		c := bkt.Cursor()
+		var evicted uint64
+		for k, _ := c.First(); k != nil; k, _ = c.First() {
+			index := binary.BigEndian.Uint64(k)
+			if count <= l.size && index != evicted {
+				oldest = index
+				break
+			}
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			evicted = index
+			count--
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	l.seq, l.count, l.oldest = seq, count, oldest
+	return nil
+}
+
+// after returns the retained events after the index that match the filter,
+// up to eventLogReadLimit events, and whether the read was truncated.
+func (l *eventLog) after(index uint64, match func(*structs.Event) bool) ([]*structs.Event, bool, error) {
+	var out []*structs.Event
+	truncated := false
+	err := l.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(eventLogBucket).Cursor()
+		for k, v := c.Seek(eventLogKey(index+1, 0)); k != nil; k, v = c.Next() {
+			if len(out) >= eventLogReadLimit && binary.BigEndian.Uint64(k) != out[len(out)-1].Index {
+				truncated = true
+				break
+			}
+
+			var e structs.Event
+			if err := codec.NewDecoder(bytes.NewReader(v), structs.MsgpackHandle).Decode(&e); err != nil {
+				return fmt.Errorf("failed to decode event: %v", err)
+			}
+			if match(&e) {
+				out = append(out, &e)
+			}
+		}
+		return nil
+	})
+	return out, truncated, err
+}
+
+// close closes the event log
+func (l *eventLog) close() error {
+	return l.db.Close()
+}
@@ -44,6 +44,13 @@ const (
 	DeploymentSnapshot
 	ACLPolicySnapshot
 	ACLTokenSnapshot
+	QuarantineSnapshot
+	JobSubmissionSnapshot
+	NetworkPolicySnapshot
+	DeploymentConcurrencyConfigSnapshot
+	VariableSnapshot
+	RootKeySnapshot
+	ACLAuthMethodSnapshot
 )
 
 // LogApplier is the definition of a function that can apply a Raft log
@@ -236,6 +243,32 @@ func (n *nomadFSM) Apply(log *raft.Log) interface{} {
 		return n.applyACLTokenBootstrap(buf[1:], log.Index)
 	case structs.AutopilotRequestType:
 		return n.applyAutopilotUpdate(buf[1:], log.Index)
+	case structs.QuarantineUpsertRequestType:
+		return n.applyQuarantineUpsert(buf[1:], log.Index)
+	case structs.QuarantineDeleteRequestType:
+		return n.applyQuarantineDelete(buf[1:], log.Index)
+	case structs.JobBatchDispatchRequestType:
+		return n.applyBatchDispatchJobs(buf[1:], log.Index)
+	case structs.NetworkPolicyUpsertRequestType:
+		return n.applyNetworkPolicyUpsert(buf[1:], log.Index)
+	case structs.NetworkPolicyDeleteRequestType:
+		return n.applyNetworkPolicyDelete(buf[1:], log.Index)
+	case structs.DeploymentConcurrencyRequestType:
+		return n.applyDeploymentConcurrencyUpdate(buf[1:], log.Index)
+	case structs.DeploymentCanaryAnalysisRequestType:
+		return n.applyDeploymentCanaryAnalysis(buf[1:], log.Index)
+	case structs.VariableUpsertRequestType:
+		return n.applyVariableUpsert(buf[1:], log.Index)
+	case structs.VariableDeleteRequestType:
+		return n.applyVariableDelete(buf[1:], log.Index)
+	case structs.RootKeyUpsertRequestType:
+		return n.applyRootKeyUpsert(buf[1:], log.Index)
+	case structs.AllocStopRequestType:
+		return n.applyAllocStop(buf[1:], log.Index)
+	case structs.ACLAuthMethodUpsertRequestType:
+		return n.applyACLAuthMethodUpsert(buf[1:], log.Index)
+	case structs.ACLAuthMethodDeleteRequestType:
+		return n.applyACLAuthMethodDelete(buf[1:], log.Index)
 	}
 
 	// Check enterprise only message types.
@@ -350,10 +383,31 @@ func (n *nomadFSM) applyUpsertJob(buf []byte, index uint64) interface{} {
 		return err
 	}
 
+	// Store the source of the job alongside the version it was registered as
+	if sub := req.Submission; sub != nil {
+		sub.Namespace = req.Job.Namespace
+		sub.JobID = req.Job.ID
+		sub.Version = req.Job.Version
+		if err := n.state.UpsertJobSubmission(index, sub); err != nil {
+			n.logger.Printf("[ERR] nomad.fsm: UpsertJobSubmission failed: %v", err)
+			return err
+		}
+	}
+
+	if err := n.trackUpsertedJob(index, req.Namespace, req.Job); err != nil {
+		return err
+	}
+	return nil
+}
+
+// trackUpsertedJob updates the periodic dispatcher and periodic launch table
+// after the job has been upserted.
+func (n *nomadFSM) trackUpsertedJob(index uint64, namespace string, job *structs.Job) error {
+
 	// We always add the job to the periodic dispatcher because there is the
 	// possibility that the periodic spec was removed and then we should stop
 	// tracking it.
-	if err := n.periodicDispatcher.Add(req.Job); err != nil {
+	if err := n.periodicDispatcher.Add(job); err != nil {
 		n.logger.Printf("[ERR] nomad.fsm: periodicDispatcher.Add failed: %v", err)
 		return err
 	}
@@ -366,8 +420,8 @@ func (n *nomadFSM) applyUpsertJob(buf []byte, index uint64) interface{} {
 	// the time it is added to when it was suppose to launch, leader election
 	// occurs and the job was not launched. In this case, we use the insertion
 	// time to determine if a launch was missed.
-	if req.Job.IsPeriodicActive() {
-		prevLaunch, err := n.state.PeriodicLaunchByID(ws, req.Namespace, req.Job.ID)
+	if job.IsPeriodicActive() {
+		prevLaunch, err := n.state.PeriodicLaunchByID(ws, namespace, job.ID)
 		if err != nil {
 			n.logger.Printf("[ERR] nomad.fsm: PeriodicLaunchByID failed: %v", err)
 			return err
@@ -377,8 +431,8 @@ func (n *nomadFSM) applyUpsertJob(buf []byte, index uint64) interface{} {
 		// such that the first entry is the insertion time.
 		if prevLaunch == nil {
 			launch := &structs.PeriodicLaunch{
-				ID:        req.Job.ID,
-				Namespace: req.Namespace,
+				ID:        job.ID,
+				Namespace: namespace,
 				Launch:    time.Now(),
 			}
 			if err := n.state.UpsertPeriodicLaunch(index, launch); err != nil {
@@ -389,9 +443,9 @@ func (n *nomadFSM) applyUpsertJob(buf []byte, index uint64) interface{} {
 	}
 
 	// Check if the parent job is periodic and mark the launch time.
-	parentID := req.Job.ParentID
+	parentID := job.ParentID
 	if parentID != "" {
-		parent, err := n.state.JobByID(ws, req.Namespace, parentID)
+		parent, err := n.state.JobByID(ws, namespace, parentID)
 		if err != nil {
 			n.logger.Printf("[ERR] nomad.fsm: JobByID(%v) lookup for parent failed: %v", parentID, err)
 			return err
@@ -401,15 +455,15 @@ func (n *nomadFSM) applyUpsertJob(buf []byte, index uint64) interface{} {
 		}
 
 		if parent.IsPeriodic() && !parent.IsParameterized() {
-			t, err := n.periodicDispatcher.LaunchTime(req.Job.ID)
+			t, err := n.periodicDispatcher.LaunchTime(job.ID)
 			if err != nil {
-				n.logger.Printf("[ERR] nomad.fsm: LaunchTime(%v) failed: %v", req.Job.ID, err)
+				n.logger.Printf("[ERR] nomad.fsm: LaunchTime(%v) failed: %v", job.ID, err)
 				return err
 			}
 
 			launch := &structs.PeriodicLaunch{
 				ID:        parentID,
-				Namespace: req.Namespace,
+				Namespace: namespace,
 				Launch:    t,
 			}
 			if err := n.state.UpsertPeriodicLaunch(index, launch); err != nil {
@@ -477,12 +531,20 @@ func (n *nomadFSM) applyUpdateEval(buf []byte, index uint64) interface{} {
 		panic(fmt.Errorf("failed to decode request: %v", err))
 	}
 
-	if err := n.state.UpsertEvals(index, req.Evals); err != nil {
+	if err := n.upsertEvals(index, req.Evals); err != nil {
+		return err
+	}
+	return nil
+}
+
+// upsertEvals upserts the evaluations and enqueues or blocks them as needed.
+func (n *nomadFSM) upsertEvals(index uint64, evals []*structs.Evaluation) error {
+	if err := n.state.UpsertEvals(index, evals); err != nil {
 		n.logger.Printf("[ERR] nomad.fsm: UpsertEvals failed: %v", err)
 		return err
 	}
 
-	for _, eval := range req.Evals {
+	for _, eval := range evals {
 		if eval.ShouldEnqueue() {
 			n.evalBroker.Enqueue(eval)
 		} else if eval.ShouldBlock() {
@@ -497,6 +559,40 @@ func (n *nomadFSM) applyUpdateEval(buf []byte, index uint64) interface{} {
 	return nil
 }
 
+// applyBatchDispatchJobs registers the child jobs and evaluations of a batch
+// dispatch.
+func (n *nomadFSM) applyBatchDispatchJobs(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "batch_dispatch_jobs"}, time.Now())
+	var req structs.JobBatchDispatchRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	for _, job := range req.Jobs {
+		job.Canonicalize()
+	}
+	if err := n.state.UpsertJobs(index, req.Jobs); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertJobs failed: %v", err)
+		return err
+	}
+
+	for _, job := range req.Jobs {
+		if err := n.trackUpsertedJob(index, job.Namespace, job); err != nil {
+			return err
+		}
+	}
+
+	// The evaluations are created for the jobs registered at this index
+	for _, eval := range req.Evals {
+		eval.JobModifyIndex = index
+	}
+
+	if err := n.upsertEvals(index, req.Evals); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (n *nomadFSM) applyDeleteEval(buf []byte, index uint64) interface{} {
 	defer metrics.MeasureSince([]string{"nomad", "fsm", "delete_eval"}, time.Now())
 	var req structs.EvalDeleteRequest
@@ -511,6 +607,26 @@ func (n *nomadFSM) applyDeleteEval(buf []byte, index uint64) interface{} {
 	return nil
 }
 
+// applyAllocStop stops the allocations and creates the evaluations replacing
+// them.
+func (n *nomadFSM) applyAllocStop(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "alloc_stop"}, time.Now())
+	var req structs.AllocStopRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.StopAllocs(index, req.AllocIDs, req.ModifyTime); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: StopAllocs failed: %v", err)
+		return err
+	}
+
+	if err := n.upsertEvals(index, req.Evals); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (n *nomadFSM) applyAllocUpdate(buf []byte, index uint64) interface{} {
 	defer metrics.MeasureSince([]string{"nomad", "fsm", "alloc_update"}, time.Now())
 	var req structs.AllocUpdateRequest
@@ -728,6 +844,23 @@ func (n *nomadFSM) applyDeploymentAllocHealth(buf []byte, index uint64) interfac
 	return nil
 }
 
+// applyDeploymentCanaryAnalysis is used to append canary analysis snapshots to
+// a deployment
+func (n *nomadFSM) applyDeploymentCanaryAnalysis(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_deployment_canary_analysis"}, time.Now())
+	var req structs.ApplyDeploymentCanaryAnalysisRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpdateDeploymentCanaryAnalysis(index, &req); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpdateDeploymentCanaryAnalysis failed: %v", err)
+		return err
+	}
+
+	return nil
+}
+
 // applyDeploymentDelete is used to delete a set of deployments
 func (n *nomadFSM) applyDeploymentDelete(buf []byte, index uint64) interface{} {
 	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_deployment_delete"}, time.Now())
@@ -790,6 +923,157 @@ func (n *nomadFSM) applyACLPolicyDelete(buf []byte, index uint64) interface{} {
 	return nil
 }
 
+// applyQuarantineUpsert is used to upsert a set of quarantine entries
+func (n *nomadFSM) applyQuarantineUpsert(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_quarantine_upsert"}, time.Now())
+	var req structs.QuarantineUpsertRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpsertQuarantineEntries(index, req.Entries); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertQuarantineEntries failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyQuarantineDelete is used to delete a set of quarantine entries
+func (n *nomadFSM) applyQuarantineDelete(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_quarantine_delete"}, time.Now())
+	var req structs.QuarantineDeleteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.DeleteQuarantineEntries(index, req.Digests); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: DeleteQuarantineEntries failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyNetworkPolicyUpsert is used to upsert a set of network policies
+func (n *nomadFSM) applyNetworkPolicyUpsert(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_network_policy_upsert"}, time.Now())
+	var req structs.NetworkPolicyUpsertRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpsertNetworkPolicies(index, req.Policies); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertNetworkPolicies failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyNetworkPolicyDelete is used to delete a set of network policies
+func (n *nomadFSM) applyNetworkPolicyDelete(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_network_policy_delete"}, time.Now())
+	var req structs.NetworkPolicyDeleteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.DeleteNetworkPolicies(index, req.RequestNamespace(), req.Names); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: DeleteNetworkPolicies failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyVariableUpsert is used to upsert a set of encrypted variables
+func (n *nomadFSM) applyVariableUpsert(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_variable_upsert"}, time.Now())
+	var req structs.VariableEncryptedUpsertRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpsertVariables(index, req.Variables); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertVariables failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyVariableDelete is used to delete a variable
+func (n *nomadFSM) applyVariableDelete(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_variable_delete"}, time.Now())
+	var req structs.VariableDeleteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.DeleteVariables(index, req.RequestNamespace(), []string{req.Path}); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: DeleteVariables failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyRootKeyUpsert is used to add a set of keys to the keyring
+func (n *nomadFSM) applyRootKeyUpsert(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_root_key_upsert"}, time.Now())
+	var req structs.RootKeyUpsertRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpsertRootKeys(index, req.RootKeys); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertRootKeys failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyDeploymentConcurrencyUpdate is used to set the deployment concurrency
+// configuration
+func (n *nomadFSM) applyDeploymentConcurrencyUpdate(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "deployment_concurrency"}, time.Now())
+	var req structs.DeploymentConcurrencySetConfigRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.DeploymentConcurrencySetConfig(index, &req.Config); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: DeploymentConcurrencySetConfig failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyACLAuthMethodUpsert is used to upsert a set of auth methods
+func (n *nomadFSM) applyACLAuthMethodUpsert(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_acl_auth_method_upsert"}, time.Now())
+	var req structs.ACLAuthMethodUpsertRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.UpsertACLAuthMethods(index, req.AuthMethods); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: UpsertACLAuthMethods failed: %v", err)
+		return err
+	}
+	return nil
+}
+
+// applyACLAuthMethodDelete is used to delete a set of auth methods
+func (n *nomadFSM) applyACLAuthMethodDelete(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_acl_auth_method_delete"}, time.Now())
+	var req structs.ACLAuthMethodDeleteRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	if err := n.state.DeleteACLAuthMethods(index, req.Names); err != nil {
+		n.logger.Printf("[ERR] nomad.fsm: DeleteACLAuthMethods failed: %v", err)
+		return err
+	}
+	return nil
+}
+
 // applyACLTokenUpsert is used to upsert a set of policies
 func (n *nomadFSM) applyACLTokenUpsert(buf []byte, index uint64) interface{} {
 	defer metrics.MeasureSince([]string{"nomad", "fsm", "apply_acl_token_upsert"}, time.Now())
@@ -1068,6 +1352,69 @@ func (n *nomadFSM) Restore(old io.ReadCloser) error {
 				return err
 			}
 
+		case QuarantineSnapshot:
+			entry := new(structs.QuarantineEntry)
+			if err := dec.Decode(entry); err != nil {
+				return err
+			}
+			if err := restore.QuarantineEntryRestore(entry); err != nil {
+				return err
+			}
+
+		case JobSubmissionSnapshot:
+			sub := new(structs.JobSubmission)
+			if err := dec.Decode(sub); err != nil {
+				return err
+			}
+			if err := restore.JobSubmissionRestore(sub); err != nil {
+				return err
+			}
+
+		case NetworkPolicySnapshot:
+			policy := new(structs.NetworkPolicy)
+			if err := dec.Decode(policy); err != nil {
+				return err
+			}
+			if err := restore.NetworkPolicyRestore(policy); err != nil {
+				return err
+			}
+
+		case DeploymentConcurrencyConfigSnapshot:
+			config := new(structs.DeploymentConcurrencyConfig)
+			if err := dec.Decode(config); err != nil {
+				return err
+			}
+			if err := restore.DeploymentConcurrencyConfigRestore(config); err != nil {
+				return err
+			}
+
+		case VariableSnapshot:
+			v := new(structs.VariableEncrypted)
+			if err := dec.Decode(v); err != nil {
+				return err
+			}
+			if err := restore.VariableRestore(v); err != nil {
+				return err
+			}
+
+		case RootKeySnapshot:
+			key := new(structs.RootKey)
+			if err := dec.Decode(key); err != nil {
+				return err
+			}
+			if err := restore.RootKeyRestore(key); err != nil {
+				return err
+			}
+
+		case ACLAuthMethodSnapshot:
+			method := new(structs.ACLAuthMethod)
+			if err := dec.Decode(method); err != nil {
+				return err
+			}
+			if err := restore.ACLAuthMethodRestore(method); err != nil {
+				return err
+			}
+
 		default:
 			// Check if this is an enterprise only object being restored
 			restorer, ok := n.enterpriseRestorers[snapType]
@@ -1292,6 +1639,34 @@ func (s *nomadSnapshot) Persist(sink raft.SnapshotSink) error {
 		sink.Cancel()
 		return err
 	}
+	if err := s.persistQuarantineEntries(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistJobSubmissions(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistNetworkPolicies(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistDeploymentConcurrencyConfig(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistVariables(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistRootKeys(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
+	if err := s.persistACLAuthMethods(sink, encoder); err != nil {
+		sink.Cancel()
+		return err
+	}
 	if err := s.persistEnterpriseTables(sink, encoder); err != nil {
 		sink.Cancel()
 		return err
@@ -1628,6 +2003,193 @@ func (s *nomadSnapshot) persistACLTokens(sink raft.SnapshotSink,
 	return nil
 }
 
+func (s *nomadSnapshot) persistQuarantineEntries(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get all the quarantine entries
+	ws := memdb.NewWatchSet()
+	entries, err := s.snap.QuarantineEntries(ws)
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Get the next item
+		raw := entries.Next()
+		if raw == nil {
+			break
+		}
+
+		// Prepare the request struct
+		entry := raw.(*structs.QuarantineEntry)
+
+		// Write out a quarantine entry registration
+		sink.Write([]byte{byte(QuarantineSnapshot)})
+		if err := encoder.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// persistJobSubmissions is used to persist the sources of the job versions
+func (s *nomadSnapshot) persistJobSubmissions(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get all the job submissions
+	ws := memdb.NewWatchSet()
+	subs, err := s.snap.JobSubmissions(ws)
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Get the next item
+		raw := subs.Next()
+		if raw == nil {
+			break
+		}
+
+		// Prepare the request struct
+		sub := raw.(*structs.JobSubmission)
+
+		// Write out a job submission registration
+		sink.Write([]byte{byte(JobSubmissionSnapshot)})
+		if err := encoder.Encode(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// persistNetworkPolicies is used to persist the network policies
+func (s *nomadSnapshot) persistNetworkPolicies(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get all the network policies
+	ws := memdb.NewWatchSet()
+	policies, err := s.snap.NetworkPolicies(ws)
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Get the next item
+		raw := policies.Next()
+		if raw == nil {
+			break
+		}
+
+		// Prepare the request struct
+		policy := raw.(*structs.NetworkPolicy)
+
+		// Write out a network policy registration
+		sink.Write([]byte{byte(NetworkPolicySnapshot)})
+		if err := encoder.Encode(policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// persistVariables is used to persist the encrypted variables
+func (s *nomadSnapshot) persistVariables(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get all the variables
+	ws := memdb.NewWatchSet()
+	variables, err := s.snap.Variables(ws)
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Get the next item
+		raw := variables.Next()
+		if raw == nil {
+			break
+		}
+
+		// Write out the variable
+		v := raw.(*structs.VariableEncrypted)
+		sink.Write([]byte{byte(VariableSnapshot)})
+		if err := encoder.Encode(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// persistRootKeys is used to persist the keyring
+func (s *nomadSnapshot) persistRootKeys(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get all the keys
+	ws := memdb.NewWatchSet()
+	keys, err := s.snap.RootKeys(ws)
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Get the next item
+		raw := keys.Next()
+		if raw == nil {
+			break
+		}
+
+		// Write out the key
+		key := raw.(*structs.RootKey)
+		sink.Write([]byte{byte(RootKeySnapshot)})
+		if err := encoder.Encode(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// persistACLAuthMethods is used to persist the auth methods
+func (s *nomadSnapshot) persistACLAuthMethods(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	// Get all the auth methods
+	ws := memdb.NewWatchSet()
+	methods, err := s.snap.ACLAuthMethods(ws)
+	if err != nil {
+		return err
+	}
+
+	for {
+		// Get the next item
+		raw := methods.Next()
+		if raw == nil {
+			break
+		}
+
+		// Write out the auth method
+		method := raw.(*structs.ACLAuthMethod)
+		sink.Write([]byte{byte(ACLAuthMethodSnapshot)})
+		if err := encoder.Encode(method); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// persistDeploymentConcurrencyConfig is used to persist the deployment
+// concurrency configuration
+func (s *nomadSnapshot) persistDeploymentConcurrencyConfig(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	_, config, err := s.snap.DeploymentConcurrencyConfig(nil)
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		return nil
+	}
+
+	// Write out the configuration
+	sink.Write([]byte{byte(DeploymentConcurrencyConfigSnapshot)})
+	if err := encoder.Encode(config); err != nil {
+		return err
+	}
+	return nil
+}
+
 // Release is a no-op, as we just need to GC the pointer
 // to the state store snapshot. There is nothing to explicitly
 // cleanup.
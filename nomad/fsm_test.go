@@ -12,6 +12,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	memdb "github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/helper/uuid"
 	"github.com/hashicorp/nomad/nomad/mock"
 	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
@@ -331,6 +332,40 @@ func TestFSM_RegisterJob(t *testing.T) {
 	}
 }
 
+func TestFSM_BatchDispatchJobs(t *testing.T) {
+	t.Parallel()
+	fsm := testFSM(t)
+	assert := assert.New(t)
+
+	job1 := mock.Job()
+	job2 := mock.Job()
+	eval := mock.Eval()
+	eval.JobID = job1.ID
+	req := structs.JobBatchDispatchRequest{
+		Jobs:  []*structs.Job{job1, job2},
+		Evals: []*structs.Evaluation{eval},
+	}
+	buf, err := structs.Encode(structs.JobBatchDispatchRequestType, req)
+	assert.Nil(err)
+	assert.Nil(fsm.Apply(makeLog(buf)))
+
+	// Verify the jobs and eval were registered at the same index
+	for _, job := range req.Jobs {
+		out, err := fsm.State().JobByID(nil, job.Namespace, job.ID)
+		assert.Nil(err)
+		if assert.NotNil(out) {
+			assert.EqualValues(1, out.CreateIndex)
+		}
+	}
+
+	evalOut, err := fsm.State().EvalByID(nil, eval.ID)
+	assert.Nil(err)
+	if assert.NotNil(evalOut) {
+		assert.EqualValues(1, evalOut.CreateIndex)
+		assert.EqualValues(1, evalOut.JobModifyIndex)
+	}
+}
+
 func TestFSM_RegisterPeriodicJob_NonLeader(t *testing.T) {
 	t.Parallel()
 	fsm := testFSM(t)
@@ -2134,6 +2169,76 @@ func TestFSM_SnapshotRestore_JobVersions(t *testing.T) {
 	}
 }
 
+func TestFSM_SnapshotRestore_JobSubmissions(t *testing.T) {
+	t.Parallel()
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	job := mock.Job()
+	state.UpsertJob(1000, job)
+	sub := &structs.JobSubmission{
+		Source:    "job \"example\" {}",
+		Format:    structs.JobSubmissionFormatHCL,
+		Variables: map[string]string{"foo": "bar"},
+		Namespace: job.Namespace,
+		JobID:     job.ID,
+		Version:   job.Version,
+	}
+	state.UpsertJobSubmission(1001, sub)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out, _ := state2.JobSubmission(nil, job.Namespace, job.ID, job.Version)
+	assert.Equal(t, sub, out)
+}
+
+func TestFSM_SnapshotRestore_NetworkPolicies(t *testing.T) {
+	t.Parallel()
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	policy := &structs.NetworkPolicy{
+		Name:        "db",
+		Namespace:   structs.DefaultNamespace,
+		Services:    []string{"db"},
+		DefaultDeny: true,
+		Rules: []*structs.NetworkPolicyRule{
+			{
+				Action:    structs.NetworkPolicyActionAllow,
+				Namespace: structs.DefaultNamespace,
+				Service:   "web",
+				Ports:     []int{5432},
+			},
+		},
+	}
+	state.UpsertNetworkPolicies(1000, []*structs.NetworkPolicy{policy})
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out, _ := state2.NetworkPolicyByName(nil, policy.Namespace, policy.Name)
+	assert.Equal(t, policy, out)
+}
+
+func TestFSM_SnapshotRestore_DeploymentConcurrencyConfig(t *testing.T) {
+	t.Parallel()
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	config := &structs.DeploymentConcurrencyConfig{
+		Limit:           10,
+		NamespaceLimits: map[string]int{"web": 3},
+	}
+	state.DeploymentConcurrencySetConfig(1000, config)
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	_, out, _ := state2.DeploymentConcurrencyConfig(nil)
+	assert.Equal(t, config, out)
+}
+
 func TestFSM_SnapshotRestore_Deployments(t *testing.T) {
 	t.Parallel()
 	// Add some state
@@ -2177,6 +2282,21 @@ func TestFSM_SnapshotRestore_ACLPolicy(t *testing.T) {
 	assert.Equal(t, p2, out2)
 }
 
+func TestFSM_SnapshotRestore_ACLAuthMethods(t *testing.T) {
+	t.Parallel()
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	m1 := testOIDCAuthMethod("https://example.com")
+	state.UpsertACLAuthMethods(1000, []*structs.ACLAuthMethod{m1})
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	out1, _ := state2.ACLAuthMethodByName(nil, m1.Name)
+	assert.Equal(t, m1, out1)
+}
+
 func TestFSM_SnapshotRestore_ACLTokens(t *testing.T) {
 	t.Parallel()
 	// Add some state
@@ -2369,3 +2489,30 @@ func TestFSM_Autopilot(t *testing.T) {
 		t.Fatalf("bad: %v", config.CleanupDeadServers)
 	}
 }
+
+func TestFSM_SnapshotRestore_Variables(t *testing.T) {
+	t.Parallel()
+	// Add some state
+	fsm := testFSM(t)
+	state := fsm.State()
+	key := &structs.RootKey{
+		KeyID:       uuid.Generate(),
+		WrappedKeys: map[string][]byte{"kek": []byte("wrapped")},
+	}
+	state.UpsertRootKeys(1000, []*structs.RootKey{key})
+	v := &structs.VariableEncrypted{
+		Namespace: structs.DefaultNamespace,
+		Path:      "nomad/jobs/example",
+		KeyID:     key.KeyID,
+		Data:      []byte("encrypted"),
+	}
+	state.UpsertVariables(1001, []*structs.VariableEncrypted{v})
+
+	// Verify the contents
+	fsm2 := testSnapshotRestore(t, fsm)
+	state2 := fsm2.State()
+	outKey, _ := state2.RootKeyByID(nil, key.KeyID)
+	assert.Equal(t, key, outKey)
+	out, _ := state2.VariableByPath(nil, v.Namespace, v.Path)
+	assert.Equal(t, v, out)
+}
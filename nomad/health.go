@@ -0,0 +1,173 @@
+package nomad
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// The subsystems whose health is reported by the server
+	HealthRaft       = "raft"
+	HealthStateStore = "state_store"
+	HealthEvalBroker = "eval_broker"
+	HealthPlanQueue  = "plan_queue"
+	HealthVault      = "vault"
+	HealthConsul     = "consul"
+)
+
+// SubsystemHealth is the health of a subsystem of the server
+type SubsystemHealth struct {
+	// Ok is false if the subsystem is unhealthy
+	Ok bool
+
+	// Message describes the state of the subsystem, or why it is unhealthy
+	Message string
+
+	// Latency is the time taken to check the subsystem
+	Latency time.Duration
+
+	// LastError is the last error of a check of the subsystem, and
+	// LastErrorTime when it occurred. The last error is retained once the
+	// subsystem is healthy again.
+	LastError     string
+	LastErrorTime time.Time
+}
+
+// healthErrors tracks the last error of each subsystem
+type healthErrors struct {
+	errors map[string]healthError
+	l      sync.Mutex
+}
+
+// healthError is the last error of a subsystem
+type healthError struct {
+	err  string
+	time time.Time
+}
+
+func newHealthErrors() *healthErrors {
+	return &healthErrors{
+		errors: make(map[string]healthError),
+	}
+}
+
+// record stores the error of the check of a subsystem, if any, and sets the
+// last error of the health
+func (h *healthErrors) record(subsystem string, health *SubsystemHealth, err error) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	if err != nil {
+		health.Ok = false
+		health.Message = err.Error()
+		h.errors[subsystem] = healthError{err: err.Error(), time: time.Now().UTC()}
+	}
+	if last, ok := h.errors[subsystem]; ok {
+		health.LastError = last.err
+		health.LastErrorTime = last.time
+	}
+}
+
+// SubsystemHealth checks the subsystems of the server and returns their
+// health by subsystem. Vault and Consul are only included when the server uses
+// them.
+func (s *Server) SubsystemHealth() map[string]*SubsystemHealth {
+	checks := map[string]func() (string, error){
+		HealthRaft:       s.checkRaftHealth,
+		HealthStateStore: s.checkStateStoreHealth,
+		HealthEvalBroker: s.checkEvalBrokerHealth,
+		HealthPlanQueue:  s.checkPlanQueueHealth,
+	}
+	if s.config.VaultConfig.IsEnabled() {
+		checks[HealthVault] = s.checkVaultHealth
+	}
+	if s.consulCatalog != nil {
+		checks[HealthConsul] = s.checkConsulHealth
+	}
+
+	out := make(map[string]*SubsystemHealth, len(checks))
+	for subsystem, check := range checks {
+		start := time.Now()
+		msg, err := check()
+		health := &SubsystemHealth{
+			Ok:      true,
+			Message: msg,
+			Latency: time.Since(start),
+		}
+		s.healthErrors.record(subsystem, health, err)
+		out[subsystem] = health
+	}
+	return out
+}
+
+// checkRaftHealth checks that the cluster has a leader. The leader verifies
+// it is still the leader by contacting a quorum of its peers.
+func (s *Server) checkRaftHealth() (string, error) {
+	if s.IsLeader() {
+		if err := s.raft.VerifyLeader().Error(); err != nil {
+			return "", fmt.Errorf("failed to verify leadership: %v", err)
+		}
+		return "leader", nil
+	}
+
+	if s.raft.Leader() == "" {
+		return "", fmt.Errorf("no leader")
+	}
+	return fmt.Sprintf("follower, last contact %v ago", time.Since(s.raft.LastContact())), nil
+}
+
+// checkStateStoreHealth checks that the state store can be snapshotted and
+// read
+func (s *Server) checkStateStoreHealth() (string, error) {
+	snap, err := s.fsm.State().Snapshot()
+	if err != nil {
+		return "", fmt.Errorf("failed to snapshot state: %v", err)
+	}
+	index, err := snap.LatestIndex()
+	if err != nil {
+		return "", fmt.Errorf("failed to read latest index: %v", err)
+	}
+	return fmt.Sprintf("index %d", index), nil
+}
+
+// checkEvalBrokerHealth checks that the eval broker is enabled on the leader
+func (s *Server) checkEvalBrokerHealth() (string, error) {
+	enabled := s.evalBroker.Enabled()
+	if !s.IsLeader() {
+		return "disabled on follower", nil
+	}
+	if !enabled {
+		return "", fmt.Errorf("disabled on leader")
+	}
+	stats := s.evalBroker.Stats()
+	return fmt.Sprintf("%d ready, %d unacked", stats.TotalReady, stats.TotalUnacked), nil
+}
+
+// checkPlanQueueHealth checks that the plan queue is enabled on the leader
+func (s *Server) checkPlanQueueHealth() (string, error) {
+	enabled := s.planQueue.Enabled()
+	if !s.IsLeader() {
+		return "disabled on follower", nil
+	}
+	if !enabled {
+		return "", fmt.Errorf("disabled on leader")
+	}
+	return fmt.Sprintf("%d pending", s.planQueue.Stats().Depth), nil
+}
+
+// checkVaultHealth checks that Vault can be reached
+func (s *Server) checkVaultHealth() (string, error) {
+	if err := s.vault.Health(); err != nil {
+		return "", err
+	}
+	return "ok", nil
+}
+
+// checkConsulHealth checks that the Consul catalog can be queried
+func (s *Server) checkConsulHealth() (string, error) {
+	if _, err := s.consulCatalog.Datacenters(); err != nil {
+		return "", fmt.Errorf("failed to query datacenters: %v", err)
+	}
+	return "ok", nil
+}
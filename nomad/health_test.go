@@ -0,0 +1,58 @@
+package nomad
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_SubsystemHealth(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	health := s1.SubsystemHealth()
+	for _, name := range []string{HealthRaft, HealthStateStore, HealthEvalBroker, HealthPlanQueue, HealthConsul} {
+		if assert.Contains(health, name) {
+			assert.True(health[name].Ok, name)
+			assert.Empty(health[name].LastError, name)
+		}
+	}
+	assert.Equal("leader", health[HealthRaft].Message)
+
+	// Vault is disabled by the test server
+	assert.NotContains(health, HealthVault)
+}
+
+func TestHealthErrors_Record(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	h := newHealthErrors()
+
+	// An error makes the subsystem unhealthy
+	health := &SubsystemHealth{Ok: true}
+	h.record(HealthConsul, health, fmt.Errorf("connection refused"))
+	assert.False(health.Ok)
+	assert.Equal("connection refused", health.Message)
+	assert.Equal("connection refused", health.LastError)
+	assert.False(health.LastErrorTime.IsZero())
+	failed := health.LastErrorTime
+
+	// The last error is retained once healthy
+	health = &SubsystemHealth{Ok: true, Message: "ok"}
+	h.record(HealthConsul, health, nil)
+	assert.True(health.Ok)
+	assert.Equal("ok", health.Message)
+	assert.Equal("connection refused", health.LastError)
+	assert.Equal(failed, health.LastErrorTime)
+
+	// Other subsystems are unaffected
+	health = &SubsystemHealth{Ok: true}
+	h.record(HealthRaft, health, nil)
+	assert.True(health.Ok)
+	assert.Empty(health.LastError)
+}
@@ -2,6 +2,7 @@ package nomad
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/armon/go-metrics"
 	"github.com/golang/snappy"
+	consulapi "github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
 	memdb "github.com/hashicorp/go-memdb"
 	"github.com/hashicorp/go-multierror"
@@ -26,9 +28,16 @@ const (
 	// enforcing the job modify index during registers.
 	RegisterEnforceIndexErrPrefix = "Enforcing job modify index"
 
-	// DispatchPayloadSizeLimit is the maximum size of the uncompressed input
-	// data payload.
+	// DispatchPayloadSizeLimit is the default maximum size of the uncompressed
+	// input data payload.
 	DispatchPayloadSizeLimit = 16 * 1024
+
+	// DefaultMaxJobSize is the default maximum encoded size of a job.
+	DefaultMaxJobSize = 8 * 1024 * 1024
+
+	// DefaultMaxTemplateSize is the default maximum size of a template's
+	// embedded content.
+	DefaultMaxTemplateSize = 1024 * 1024
 )
 
 var (
@@ -58,6 +67,23 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		return fmt.Errorf("missing job for registration")
 	}
 
+	// Check job submission permissions
+	if err := j.checkSubmitJobACL(args.AuthToken, args.RequestNamespace(), args.Job.ID, args.PolicyOverride); err != nil {
+		return err
+	}
+
+	// Ensure that the job has permissions for the requested Vault tokens
+	if err := j.checkVaultPolicies(args.Job); err != nil {
+		return err
+	}
+
+	return j.register(args, reply)
+}
+
+// register registers a job whose submission permissions and Vault policies
+// were checked by the caller, creating a new version of the job if its spec
+// changed and an evaluation for it.
+func (j *Job) register(args *structs.JobRegisterRequest, reply *structs.JobRegisterResponse) error {
 	// Initialize the job fields (sets defaults and any necessary init work).
 	canonicalizeWarnings := args.Job.Canonicalize()
 
@@ -70,26 +96,17 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		return err
 	}
 
-	// Set the warning message
-	reply.Warnings = structs.MergeMultierrorWarnings(warnings, canonicalizeWarnings)
-
-	// Check job submission permissions
-	if aclObj, err := j.srv.ResolveToken(args.AuthToken); err != nil {
+	// Reject jobs that are too large to safely commit through Raft
+	if err := j.validateJobSize(args.Job); err != nil {
+		return err
+	}
+	if err := j.validateJobSubmission(args.Submission); err != nil {
 		return err
-	} else if aclObj != nil {
-		if !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilitySubmitJob) {
-			return structs.ErrPermissionDenied
-		}
-		// Check if override is set and we do not have permissions
-		if args.PolicyOverride {
-			if !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilitySentinelOverride) {
-				j.srv.logger.Printf("[WARN] nomad.job: policy override attempted without permissions for Job %q", args.Job.ID)
-				return structs.ErrPermissionDenied
-			}
-			j.srv.logger.Printf("[WARN] nomad.job: policy override set for Job %q", args.Job.ID)
-		}
 	}
 
+	// Set the warning message
+	reply.Warnings = structs.MergeMultierrorWarnings(warnings, canonicalizeWarnings)
+
 	// Lookup the job
 	snap, err := j.srv.State().Snapshot()
 	if err != nil {
@@ -101,6 +118,23 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		return err
 	}
 
+	// Reject jobs pinned to quarantined images or artifacts
+	if err := checkQuarantine(snap, args.Job); err != nil {
+		return err
+	}
+
+	// If the registration is a replay of a previous request, return the
+	// original result rather than creating a new version and evaluation.
+	if args.IdempotencyToken != "" && existingJob != nil {
+		replayed, err := j.idempotentRegisterReply(snap, args, reply)
+		if err != nil {
+			return err
+		}
+		if replayed {
+			return nil
+		}
+	}
+
 	// If EnforceIndex set, check it before trying to apply
 	if args.EnforceIndex {
 		jmi := args.JobModifyIndex
@@ -121,43 +155,7 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		if err := validateJobUpdate(existingJob, args.Job); err != nil {
 			return err
 		}
-	}
-
-	// Ensure that the job has permissions for the requested Vault tokens
-	policies := args.Job.VaultPolicies()
-	if len(policies) != 0 {
-		vconf := j.srv.config.VaultConfig
-		if !vconf.IsEnabled() {
-			return fmt.Errorf("Vault not enabled and Vault policies requested")
-		}
-
-		// Have to check if the user has permissions
-		if !vconf.AllowsUnauthenticated() {
-			if args.Job.VaultToken == "" {
-				return fmt.Errorf("Vault policies requested but missing Vault Token")
-			}
-
-			vault := j.srv.vault
-			s, err := vault.LookupToken(context.Background(), args.Job.VaultToken)
-			if err != nil {
-				return err
-			}
-
-			allowedPolicies, err := PoliciesFrom(s)
-			if err != nil {
-				return err
-			}
-
-			// If we are given a root token it can access all policies
-			if !lib.StrContains(allowedPolicies, "root") {
-				flatPolicies := structs.VaultPoliciesSet(policies)
-				subset, offending := helper.SliceStringIsSubset(allowedPolicies, flatPolicies)
-				if !subset {
-					return fmt.Errorf("Passed Vault Token doesn't allow access to the following policies: %s",
-						strings.Join(offending, ", "))
-				}
-			}
-		}
+		carryJobRestarts(existingJob, args.Job)
 	}
 
 	// Enforce Sentinel policies
@@ -178,6 +176,16 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		// Set the submit time
 		args.Job.SetSubmitTime()
 
+		// Store the idempotency token with the new version
+		args.Job.IdempotencyToken = args.IdempotencyToken
+
+		// Record who submitted the new version
+		provenance, err := j.jobProvenance(args)
+		if err != nil {
+			return err
+		}
+		args.Job.Provenance = provenance
+
 		// Commit this update via Raft
 		fsmErr, index, err := j.srv.raftApply(structs.JobRegisterRequestType, args)
 		if err, ok := fsmErr.(error); ok && err != nil {
@@ -210,6 +218,10 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 		JobID:          args.Job.ID,
 		JobModifyIndex: reply.JobModifyIndex,
 		Status:         structs.EvalStatusPending,
+
+		// Record the token even if the job didn't change, so that a retry
+		// returns this evaluation rather than creating another one
+		IdempotencyToken: args.IdempotencyToken,
 	}
 	update := &structs.EvalUpdateRequest{
 		Evals:        []*structs.Evaluation{eval},
@@ -232,6 +244,303 @@ func (j *Job) Register(args *structs.JobRegisterRequest, reply *structs.JobRegis
 	return nil
 }
 
+// checkSubmitJobACL checks that the token may submit jobs to the namespace,
+// and override Sentinel policies if requested.
+func (j *Job) checkSubmitJobACL(token, namespace, jobID string, override bool) error {
+	aclObj, err := j.srv.ResolveToken(token)
+	if err != nil {
+		return err
+	} else if aclObj == nil {
+		return nil
+	}
+	if !aclObj.AllowNsOp(namespace, acl.NamespaceCapabilitySubmitJob) {
+		return structs.ErrPermissionDenied
+	}
+	// Check if override is set and we do not have permissions
+	if override {
+		if !aclObj.AllowNsOp(namespace, acl.NamespaceCapabilitySentinelOverride) {
+			j.srv.logger.Printf("[WARN] nomad.job: policy override attempted without permissions for Job %q", jobID)
+			return structs.ErrPermissionDenied
+		}
+		j.srv.logger.Printf("[WARN] nomad.job: policy override set for Job %q", jobID)
+	}
+	return nil
+}
+
+// checkVaultPolicies checks that the Vault token of the job allows access to
+// the Vault policies it requests.
+func (j *Job) checkVaultPolicies(job *structs.Job) error {
+	policies := job.VaultPolicies()
+	if len(policies) == 0 {
+		return nil
+	}
+	vconf := j.srv.config.VaultConfig
+	if !vconf.IsEnabled() {
+		return fmt.Errorf("Vault not enabled and Vault policies requested")
+	}
+
+	// Have to check if the user has permissions
+	if vconf.AllowsUnauthenticated() {
+		return nil
+	}
+	if job.VaultToken == "" {
+		return fmt.Errorf("Vault policies requested but missing Vault Token")
+	}
+
+	vault := j.srv.vault
+	s, err := vault.LookupToken(context.Background(), job.VaultToken)
+	if err != nil {
+		return err
+	}
+
+	allowedPolicies, err := PoliciesFrom(s)
+	if err != nil {
+		return err
+	}
+
+	// If we are given a root token it can access all policies
+	if !lib.StrContains(allowedPolicies, "root") {
+		flatPolicies := structs.VaultPoliciesSet(policies)
+		subset, offending := helper.SliceStringIsSubset(allowedPolicies, flatPolicies)
+		if !subset {
+			return fmt.Errorf("Passed Vault Token doesn't allow access to the following policies: %s",
+				strings.Join(offending, ", "))
+		}
+	}
+	return nil
+}
+
+// validateJobSize returns an error if the job or any of its embedded templates
+// exceed the sizes allowed by the server's configuration.
+func (j *Job) validateJobSize(job *structs.Job) error {
+	if max := j.srv.config.MaxTemplateSize; max > 0 {
+		for _, tg := range job.TaskGroups {
+			for _, task := range tg.Tasks {
+				for i, tmpl := range task.Templates {
+					if l := len(tmpl.EmbeddedTmpl); l > max {
+						return fmt.Errorf("Task %q in group %q has template %d with embedded content exceeding the maximum size; %d > %d bytes. "+
+							"Consider sourcing the template from an artifact or raising the server's max_template_size",
+							task.Name, tg.Name, i+1, l, max)
+					}
+				}
+			}
+		}
+	}
+
+	if max := j.srv.config.MaxJobSize; max > 0 {
+		buf, err := structs.Encode(structs.JobRegisterRequestType, job)
+		if err != nil {
+			return fmt.Errorf("failed to encode job: %v", err)
+		}
+		if l := len(buf); l > max {
+			return fmt.Errorf("Job %q exceeds the maximum size; %d > %d bytes. "+
+				"Large jobs are usually caused by embedded templates or payloads; consider moving them to artifacts or raising the server's max_job_size",
+				job.ID, l, max)
+		}
+	}
+
+	return nil
+}
+
+// jobProvenance returns the provenance of the job version being registered.
+// The token accessor is resolved from the request's token, so it cannot be
+// supplied by the client.
+func (j *Job) jobProvenance(args *structs.JobRegisterRequest) (*structs.JobProvenance, error) {
+	p := &structs.JobProvenance{
+		SourceIP: args.SourceIP,
+		Source:   args.Source,
+	}
+
+	if j.srv.config.ACLEnabled {
+		if args.AuthToken == "" {
+			p.TokenAccessor = structs.AnonymousACLToken.AccessorID
+		} else {
+			token, err := j.srv.State().ACLTokenBySecretID(nil, args.AuthToken)
+			if err != nil {
+				return nil, err
+			}
+			if token != nil {
+				p.TokenAccessor = token.AccessorID
+			}
+		}
+	}
+
+	if p.TokenAccessor == "" && p.SourceIP == "" && p.Source == "" {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// verifySecrets verifies the secrets the job declares it depends on. It
+// returns an error listing the secrets that don't exist or can't be accessed,
+// and warnings for the secrets that can't be verified.
+func (j *Job) verifySecrets(job *structs.Job, snap *state.StateSnapshot) (error, error) {
+	secrets := job.Secrets
+	if secrets.Empty() {
+		return nil, nil
+	}
+
+	var mErr, warnings multierror.Error
+
+	// Variables must exist in the job's namespace and be at a path the tasks
+	// of the job can read
+	readable := make(map[string]struct{})
+	for _, tg := range job.TaskGroups {
+		for _, task := range tg.Tasks {
+			for _, p := range structs.VariableTaskPaths(job.ID, tg.Name, task.Name) {
+				readable[p] = struct{}{}
+			}
+		}
+	}
+	for _, p := range secrets.Variables {
+		if _, ok := readable[p]; !ok {
+			multierror.Append(&mErr, fmt.Errorf("Variable %q can't be read by the tasks of the job: tasks can only read the variables at %q, %q and %q",
+				p, structs.VariablesJobsPrefix+"/"+job.ID, structs.VariablesJobsPrefix+"/"+job.ID+"/<group>",
+				structs.VariablesJobsPrefix+"/"+job.ID+"/<group>/<task>"))
+			continue
+		}
+		v, err := snap.VariableByPath(nil, job.Namespace, p)
+		if err != nil {
+			return err, nil
+		}
+		if v == nil {
+			multierror.Append(&mErr, fmt.Errorf("Variable %q does not exist in namespace %q: create it with \"nomad var put %s <key>=<value>\"",
+				p, job.Namespace, p))
+		}
+	}
+
+	// Vault paths must be readable with the Vault token of the submitter. The
+	// paths are not read since reading dynamic secrets would create them.
+	if len(secrets.Vault) != 0 {
+		if !j.srv.config.VaultConfig.IsEnabled() {
+			multierror.Append(&mErr, fmt.Errorf("Vault not enabled and Vault secrets declared"))
+		} else if job.VaultToken == "" {
+			multierror.Append(&warnings, fmt.Errorf("Vault secrets can't be verified without a Vault token"))
+		} else {
+			for _, p := range secrets.Vault {
+				caps, err := j.srv.vault.LookupCapabilities(context.Background(), job.VaultToken, p)
+				if err != nil {
+					multierror.Append(&mErr, fmt.Errorf("Failed to verify Vault secret %q: %v", p, err))
+					continue
+				}
+				if !lib.StrContains(caps, "read") && !lib.StrContains(caps, "root") {
+					multierror.Append(&mErr, fmt.Errorf("Vault secret %q is not readable with the Vault token: it has the capabilities %v; grant \"read\" on the path to the Vault policies of the tasks",
+						p, caps))
+				}
+			}
+		}
+	}
+
+	// Consul keys must exist and be readable with the servers' Consul token
+	if len(secrets.Consul) != 0 {
+		if j.srv.consulKV == nil {
+			multierror.Append(&warnings, fmt.Errorf("Consul keys can't be verified without a Consul connection"))
+		} else {
+			for _, k := range secrets.Consul {
+				pair, _, err := j.srv.consulKV.Get(k, &consulapi.QueryOptions{AllowStale: true})
+				if err != nil {
+					multierror.Append(&mErr, fmt.Errorf("Failed to verify Consul key %q: %v", k, err))
+				} else if pair == nil {
+					multierror.Append(&mErr, fmt.Errorf("Consul key %q does not exist", k))
+				}
+			}
+		}
+	}
+
+	if err := mErr.ErrorOrNil(); err != nil {
+		return multierror.Prefix(err, "Secrets:"), nil
+	}
+	return nil, warnings.ErrorOrNil()
+}
+
+// validateJobSubmission returns an error if the source submitted with a job is
+// of an unknown format or too large to safely commit through Raft.
+func (j *Job) validateJobSubmission(sub *structs.JobSubmission) error {
+	if sub == nil {
+		return nil
+	}
+	if sub.Format != structs.JobSubmissionFormatHCL {
+		return fmt.Errorf("unsupported job source format %q", sub.Format)
+	}
+	if max := j.srv.config.MaxJobSize; max > 0 && len(sub.Source) > max {
+		return fmt.Errorf("Job source exceeds the maximum size; %d > %d bytes", len(sub.Source), max)
+	}
+	return nil
+}
+
+// idempotentRegisterReply looks for a registration of the job made with the
+// request's idempotency token, either the version of the job it created or,
+// if the job didn't change, the evaluation it created. If one is found the
+// reply is populated with the result of the original registration and true is
+// returned. Replaying a token with a job that differs from the version it
+// registered is an error.
+func (j *Job) idempotentRegisterReply(snap *state.StateSnapshot,
+	args *structs.JobRegisterRequest, reply *structs.JobRegisterResponse) (bool, error) {
+
+	ws := memdb.NewWatchSet()
+	versions, err := snap.JobVersionsByID(ws, args.RequestNamespace(), args.Job.ID)
+	if err != nil {
+		return false, err
+	}
+	evals, err := snap.EvalsByJob(ws, args.RequestNamespace(), args.Job.ID)
+	if err != nil {
+		return false, err
+	}
+
+	var eval *structs.Evaluation
+	for _, e := range evals {
+		if e.TriggeredBy == structs.EvalTriggerJobRegister && e.IdempotencyToken == args.IdempotencyToken {
+			eval = e
+			break
+		}
+	}
+
+	var match *structs.Job
+	for _, v := range versions {
+		if eval != nil && v.JobModifyIndex == eval.JobModifyIndex ||
+			eval == nil && v.IdempotencyToken == args.IdempotencyToken {
+			match = v
+			break
+		}
+	}
+	if match == nil && eval == nil {
+		return false, nil
+	}
+
+	// The Vault token is cleared and the restarts are carried over when the
+	// job is registered, so they aren't part of the replayed spec
+	if match != nil {
+		replayed := args.Job.Copy()
+		replayed.VaultToken = match.VaultToken
+		replayed.Restarts = match.Restarts
+		if match.SpecChanged(replayed) {
+			return false, fmt.Errorf("idempotency token %q was used to register a different version of job %q",
+				args.IdempotencyToken, args.Job.ID)
+		}
+		reply.JobModifyIndex = match.JobModifyIndex
+		reply.Index = match.JobModifyIndex
+	}
+
+	if eval == nil && !match.IsPeriodic() && !match.IsParameterized() {
+		for _, e := range evals {
+			if e.TriggeredBy == structs.EvalTriggerJobRegister && e.JobModifyIndex == match.JobModifyIndex {
+				eval = e
+				break
+			}
+		}
+	}
+	if eval != nil {
+		reply.JobModifyIndex = eval.JobModifyIndex
+		reply.EvalID = eval.ID
+		reply.EvalCreateIndex = eval.CreateIndex
+		reply.Index = eval.CreateIndex
+	}
+
+	j.srv.logger.Printf("[DEBUG] nomad.job: replayed registration of job %q at job modify index %d for idempotency token",
+		args.Job.ID, reply.JobModifyIndex)
+	return true, nil
+}
+
 // setImplicitConstraints adds implicit constraints to the job based on the
 // features it is requesting.
 func setImplicitConstraints(j *structs.Job) {
@@ -354,11 +663,17 @@ func (j *Job) Summary(args *structs.JobSummaryRequest,
 func (j *Job) Validate(args *structs.JobValidateRequest, reply *structs.JobValidateResponse) error {
 	defer metrics.MeasureSince([]string{"nomad", "job", "validate"}, time.Now())
 
-	// Check for read-job permissions
+	// Check for read-job permissions. Enforcement checks what a registration
+	// would, so it requires submit-job permissions.
 	if aclObj, err := j.srv.ResolveToken(args.AuthToken); err != nil {
 		return err
-	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityReadJob) {
-		return structs.ErrPermissionDenied
+	} else if aclObj != nil {
+		if !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityReadJob) {
+			return structs.ErrPermissionDenied
+		}
+		if args.Enforcement && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilitySubmitJob) {
+			return structs.ErrPermissionDenied
+		}
 	}
 
 	// Initialize the job fields (sets defaults and any necessary init work).
@@ -384,9 +699,62 @@ func (j *Job) Validate(args *structs.JobValidateRequest, reply *structs.JobValid
 	// Set the warning message
 	reply.Warnings = structs.MergeMultierrorWarnings(warnings, canonicalizeWarnings)
 	reply.DriverConfigValidated = true
+	reply.Job = args.Job
+
+	// Run the enforcement checks without creating an evaluation
+	if args.Enforcement && err == nil {
+		results, err := j.enforcementResults(args.Job)
+		if err != nil {
+			return err
+		}
+		reply.EnforcementResults = results
+	}
 	return nil
 }
 
+// enforcementResults runs the checks a registration of the job is subject to
+// after the job is validated and returns the result of each.
+func (j *Job) enforcementResults(job *structs.Job) ([]*structs.JobEnforcementResult, error) {
+	snap, err := j.srv.State().Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	existingJob, err := snap.JobByID(nil, job.Namespace, job.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Admission checks the job is accepted by the cluster
+	admission := &structs.JobEnforcementResult{Check: structs.JobEnforcementCheckAdmission}
+	if err := j.validateJobSize(job); err != nil {
+		admission.Error = err.Error()
+	} else if err := checkQuarantine(snap, job); err != nil {
+		admission.Error = err.Error()
+	} else if existingJob != nil {
+		if err := validateJobUpdate(existingJob, job); err != nil {
+			admission.Error = err.Error()
+		}
+	}
+	admission.Passed = admission.Error == ""
+
+	// Sentinel policies are enforced without override
+	sentinel := &structs.JobEnforcementResult{Check: structs.JobEnforcementCheckSentinel}
+	policyWarnings, err := j.enforceSubmitJob(false, job)
+	if err != nil {
+		sentinel.Error = err.Error()
+	}
+	sentinel.Passed = sentinel.Error == ""
+	sentinel.Warnings = structs.MergeMultierrorWarnings(policyWarnings)
+
+	quota := &structs.JobEnforcementResult{Check: structs.JobEnforcementCheckQuota}
+	if err := j.checkQuota(job); err != nil {
+		quota.Error = err.Error()
+	}
+	quota.Passed = quota.Error == ""
+
+	return []*structs.JobEnforcementResult{admission, sentinel, quota}, nil
+}
+
 // Revert is used to revert the job to a prior version
 func (j *Job) Revert(args *structs.JobRevertRequest, reply *structs.JobRegisterResponse) error {
 	if done, err := j.srv.forward("Job.Revert", args, args, reply); done {
@@ -432,24 +800,287 @@ func (j *Job) Revert(args *structs.JobRevertRequest, reply *structs.JobRegisterR
 		return fmt.Errorf("job %q in namespace %q at version %d not found", args.JobID, args.RequestNamespace(), args.JobVersion)
 	}
 
-	// Build the register request
-	reg := &structs.JobRegisterRequest{
-		Job:          jobV.Copy(),
-		WriteRequest: args.WriteRequest,
+	// Build the register request
+	reg := &structs.JobRegisterRequest{
+		Job:          jobV.Copy(),
+		WriteRequest: args.WriteRequest,
+	}
+
+	// If the request is enforcing the existing version do a check.
+	if args.EnforcePriorVersion != nil {
+		if cur.Version != *args.EnforcePriorVersion {
+			return fmt.Errorf("Current job has version %d; enforcing version %d", cur.Version, *args.EnforcePriorVersion)
+		}
+
+		reg.EnforceIndex = true
+		reg.JobModifyIndex = cur.JobModifyIndex
+	}
+
+	// Register the version.
+	return j.Register(reg, reply)
+}
+
+// Restart is used to replace the allocations of a job without changing its
+// specification. A new version of the job is registered so that the
+// allocations are replaced following the update strategy of the job.
+func (j *Job) Restart(args *structs.JobRestartRequest, reply *structs.JobRegisterResponse) error {
+	if done, err := j.srv.forward("Job.Restart", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "restart"}, time.Now())
+
+	// Check for submit-job permissions
+	if aclObj, err := j.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilitySubmitJob) {
+		return structs.ErrPermissionDenied
+	}
+
+	// Validate the arguments
+	if args.JobID == "" {
+		return fmt.Errorf("missing job ID for restart")
+	}
+
+	// Lookup the job
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+
+	ws := memdb.NewWatchSet()
+	cur, err := snap.JobByID(ws, args.RequestNamespace(), args.JobID)
+	if err != nil {
+		return err
+	}
+	if cur == nil {
+		return fmt.Errorf("job %q not found", args.JobID)
+	}
+	if cur.Stop {
+		return fmt.Errorf("can't restart stopped job %q", args.JobID)
+	}
+	if cur.IsPeriodic() || cur.IsParameterized() {
+		return fmt.Errorf("can't restart periodic or parameterized job %q", args.JobID)
+	}
+
+	// Build the register request
+	job := cur.Copy()
+	job.Restarts++
+	reg := &structs.JobRegisterRequest{
+		Job:          job,
+		WriteRequest: args.WriteRequest,
+	}
+
+	// If the request is enforcing the existing version do a check.
+	if args.EnforcePriorVersion != nil {
+		if cur.Version != *args.EnforcePriorVersion {
+			return fmt.Errorf("Current job has version %d; enforcing version %d", cur.Version, *args.EnforcePriorVersion)
+		}
+
+		reg.EnforceIndex = true
+		reg.JobModifyIndex = cur.JobModifyIndex
+	}
+
+	// Register the version.
+	return j.Register(reg, reply)
+}
+
+// carryJobRestarts carries the restarts of the existing job over to the job
+// being registered. Restarts are managed by the servers so submitted jobs
+// don't set them, and registering them must not replace the allocations
+// again.
+func carryJobRestarts(existing, job *structs.Job) {
+	if job.Restarts < existing.Restarts {
+		job.Restarts = existing.Restarts
+	}
+}
+
+// Patch is used to update a job by applying a JSON merge patch to its current
+// version
+func (j *Job) Patch(args *structs.JobPatchRequest, reply *structs.JobRegisterResponse) error {
+	if done, err := j.srv.forward("Job.Patch", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "patch"}, time.Now())
+
+	// Check for submit-job permissions
+	if err := j.checkSubmitJobACL(args.AuthToken, args.RequestNamespace(), args.JobID, args.PolicyOverride); err != nil {
+		return err
+	}
+
+	// Validate the arguments
+	if args.JobID == "" {
+		return fmt.Errorf("missing job ID for patch")
+	}
+	if len(args.Patch) == 0 {
+		return fmt.Errorf("missing patch")
+	}
+
+	// Lookup the current job
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	cur, err := snap.JobByID(nil, args.RequestNamespace(), args.JobID)
+	if err != nil {
+		return err
+	}
+	if cur == nil {
+		return fmt.Errorf("job %q not found", args.JobID)
+	}
+	if args.EnforceIndex && cur.JobModifyIndex != args.JobModifyIndex {
+		return fmt.Errorf("%s %d: job exists with conflicting job modify index: %d",
+			RegisterEnforceIndexErrPrefix, args.JobModifyIndex, cur.JobModifyIndex)
+	}
+
+	patched, err := patchJob(cur, args.Patch)
+	if err != nil {
+		return err
+	}
+	if patched.ID != cur.ID || patched.Namespace != cur.Namespace {
+		return fmt.Errorf("patch can not change the job ID or namespace")
+	}
+
+	// The Vault policies of the current version were checked when it was
+	// registered, and patches can't pass a Vault token to check new ones
+	curPolicies := structs.VaultPoliciesSet(cur.VaultPolicies())
+	patchedPolicies := structs.VaultPoliciesSet(patched.VaultPolicies())
+	if subset, _ := helper.SliceStringIsSubset(curPolicies, patchedPolicies); !subset {
+		if err := j.checkVaultPolicies(patched); err != nil {
+			return fmt.Errorf("patch changes the Vault policies of the job: %v", err)
+		}
+	}
+
+	// Build the register request. The index of the patched version is
+	// enforced so concurrent updates are not lost.
+	reg := &structs.JobRegisterRequest{
+		Job:            patched,
+		EnforceIndex:   true,
+		JobModifyIndex: cur.JobModifyIndex,
+		PolicyOverride: args.PolicyOverride,
+		SourceIP:       args.SourceIP,
+		WriteRequest:   args.WriteRequest,
+	}
+
+	// Register the patched version. The permissions were checked above and
+	// the request was already forwarded and rate limited.
+	return j.register(reg, reply)
+}
+
+// patchJob returns a copy of the job with the JSON merge patch (RFC 7396)
+// applied. As an extension, arrays of objects that all have a Name, such as
+// task groups and tasks, are merged element-wise by name rather than
+// replaced, so a single group or task can be patched in isolation. An element
+// with the mergePatchDeleteKey set to true removes the element of that name.
+func patchJob(job *structs.Job, patch []byte) (*structs.Job, error) {
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("failed to decode patch: %v", err)
+	}
+	if _, ok := p.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("patch must be a JSON object")
+	}
+
+	orig, err := json.Marshal(job)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job: %v", err)
+	}
+	var target interface{}
+	if err := json.Unmarshal(orig, &target); err != nil {
+		return nil, fmt.Errorf("failed to decode job: %v", err)
+	}
+
+	merged, err := json.Marshal(mergePatch(target, p))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode patched job: %v", err)
+	}
+	var out structs.Job
+	if err := json.Unmarshal(merged, &out); err != nil {
+		return nil, fmt.Errorf("failed to decode patched job: %v", err)
+	}
+	return &out, nil
+}
+
+// mergePatchDeleteKey is the key marking the elements of a patch array of
+// named objects that remove the target element of the same name
+const mergePatchDeleteKey = "$delete"
+
+// mergePatch applies the patch to the target following the JSON merge patch
+// rules, merging arrays of named objects by name.
+func mergePatch(target, patch interface{}) interface{} {
+	switch p := patch.(type) {
+	case map[string]interface{}:
+		t, ok := target.(map[string]interface{})
+		if !ok {
+			t = make(map[string]interface{}, len(p))
+		}
+		for k, v := range p {
+			if v == nil {
+				delete(t, k)
+				continue
+			}
+			t[k] = mergePatch(t[k], v)
+		}
+		return t
+	case []interface{}:
+		if !namedObjects(p) {
+			return p
+		}
+		t, _ := target.([]interface{})
+		if !namedObjects(t) {
+			t = nil
+		}
+
+		idx := make(map[string]int, len(t))
+		for i, e := range t {
+			idx[e.(map[string]interface{})["Name"].(string)] = i
+		}
+		deleted := make(map[int]bool)
+		for _, e := range p {
+			obj := e.(map[string]interface{})
+			name := obj["Name"].(string)
+			i, ok := idx[name]
+			if del, _ := obj[mergePatchDeleteKey].(bool); del {
+				if ok {
+					deleted[i] = true
+				}
+				continue
+			}
+			if ok {
+				t[i] = mergePatch(t[i], e)
+			} else {
+				idx[name] = len(t)
+				t = append(t, mergePatch(nil, e))
+			}
+		}
+
+		out := make([]interface{}, 0, len(t))
+		for i, e := range t {
+			if !deleted[i] {
+				out = append(out, e)
+			}
+		}
+		return out
+	default:
+		return patch
 	}
+}
 
-	// If the request is enforcing the existing version do a check.
-	if args.EnforcePriorVersion != nil {
-		if cur.Version != *args.EnforcePriorVersion {
-			return fmt.Errorf("Current job has version %d; enforcing version %d", cur.Version, *args.EnforcePriorVersion)
+// namedObjects returns whether every element of the array is an object with a
+// string Name.
+func namedObjects(a []interface{}) bool {
+	if len(a) == 0 {
+		return false
+	}
+	for _, e := range a {
+		obj, ok := e.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := obj["Name"].(string); !ok {
+			return false
 		}
-
-		reg.EnforceIndex = true
-		reg.JobModifyIndex = cur.JobModifyIndex
 	}
-
-	// Register the version.
-	return j.Register(reg, reply)
+	return true
 }
 
 // Stable is used to mark the job version as stable
@@ -568,6 +1199,94 @@ func (j *Job) Evaluate(args *structs.JobEvaluateRequest, reply *structs.JobRegis
 	return nil
 }
 
+// EvaluateBatch is used to force the evaluation of all the jobs of the
+// namespace. A batch of jobs is evaluated per request so that large namespaces
+// can report their progress.
+func (j *Job) EvaluateBatch(args *structs.JobBatchEvaluateRequest, reply *structs.JobBatchEvaluateResponse) error {
+	if done, err := j.srv.forward("Job.EvaluateBatch", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "evaluate_batch"}, time.Now())
+
+	// Check for read-job permissions
+	if aclObj, err := j.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityReadJob) {
+		return structs.ErrPermissionDenied
+	}
+
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	iter, err := snap.JobsByNamespace(nil, args.RequestNamespace())
+	if err != nil {
+		return err
+	}
+
+	// Create an evaluation per job of the batch. Periodic, parameterized and
+	// stopped jobs can't be evaluated and are skipped.
+	batchSize := bulkBatchSize(args.BatchSize)
+	var evals []*structs.Evaluation
+	var last string
+	processed := 0
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		job := raw.(*structs.Job)
+		if job.ID <= args.NextToken {
+			continue
+		}
+
+		// Stop once the batch is full, there are more jobs to evaluate
+		if processed == batchSize {
+			reply.NextToken = last
+			break
+		}
+		processed++
+		last = job.ID
+
+		if job.IsPeriodic() || job.IsParameterized() || job.Stopped() {
+			reply.Skipped++
+			continue
+		}
+		evals = append(evals, &structs.Evaluation{
+			ID:             uuid.Generate(),
+			Namespace:      job.Namespace,
+			Priority:       job.Priority,
+			Type:           job.Type,
+			TriggeredBy:    structs.EvalTriggerJobRegister,
+			JobID:          job.ID,
+			JobModifyIndex: job.ModifyIndex,
+			Status:         structs.EvalStatusPending,
+		})
+	}
+
+	if len(evals) == 0 {
+		index, err := snap.Index("evals")
+		if err != nil {
+			return err
+		}
+		reply.Index = index
+		return nil
+	}
+
+	// Commit the evaluations via Raft
+	update := &structs.EvalUpdateRequest{
+		Evals:        evals,
+		WriteRequest: structs.WriteRequest{Region: args.Region},
+	}
+	_, index, err := j.srv.raftApply(structs.EvalUpdateRequestType, update)
+	if err != nil {
+		j.srv.logger.Printf("[ERR] nomad.job: Eval create failed: %v", err)
+		return err
+	}
+
+	for _, eval := range evals {
+		reply.EvalIDs = append(reply.EvalIDs, eval.ID)
+	}
+	reply.Index = index
+	return nil
+}
+
 // Deregister is used to remove a job the cluster.
 func (j *Job) Deregister(args *structs.JobDeregisterRequest, reply *structs.JobDeregisterResponse) error {
 	if done, err := j.srv.forward("Job.Deregister", args, args, reply); done {
@@ -750,6 +1469,126 @@ func (j *Job) GetJobVersions(args *structs.JobVersionsRequest,
 	return j.srv.blockingRPC(&opts)
 }
 
+// GetJobSubmission is used to retrieve the source a job version was submitted
+// with.
+func (j *Job) GetJobSubmission(args *structs.JobSubmissionRequest,
+	reply *structs.JobSubmissionResponse) error {
+	if done, err := j.srv.forward("Job.GetJobSubmission", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "get_job_submission"}, time.Now())
+
+	// Check for read-job permissions
+	if aclObj, err := j.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityReadJob) {
+		return structs.ErrPermissionDenied
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			reply.Submission = nil
+
+			// Default to the current version of the job
+			var out *structs.JobSubmission
+			version := args.Version
+			if version == nil {
+				job, err := state.JobByID(ws, args.RequestNamespace(), args.JobID)
+				if err != nil {
+					return err
+				}
+				if job != nil {
+					version = &job.Version
+				}
+			}
+			if version != nil {
+				var err error
+				out, err = state.JobSubmission(ws, args.RequestNamespace(), args.JobID, *version)
+				if err != nil {
+					return err
+				}
+			}
+
+			// Setup the output
+			reply.Submission = out
+			if out != nil {
+				reply.Index = out.CreateIndex
+			} else {
+				// Use the last index that affected the job submission table
+				index, err := state.Index("job_submission")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			j.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return j.srv.blockingRPC(&opts)
+}
+
+// DiffVersions is used to retrieve the structured diff between two tracked
+// versions of a job
+func (j *Job) DiffVersions(args *structs.JobDiffVersionsRequest,
+	reply *structs.JobDiffVersionsResponse) error {
+	if done, err := j.srv.forward("Job.DiffVersions", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "diff_versions"}, time.Now())
+
+	// Check for read-job permissions
+	if aclObj, err := j.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityReadJob) {
+		return structs.ErrPermissionDenied
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			old, err := state.JobByIDAndVersion(ws, args.RequestNamespace(), args.JobID, args.OldVersion)
+			if err != nil {
+				return err
+			}
+			if old == nil {
+				return fmt.Errorf("job %q at version %d not found", args.JobID, args.OldVersion)
+			}
+
+			new, err := state.JobByIDAndVersion(ws, args.RequestNamespace(), args.JobID, args.NewVersion)
+			if err != nil {
+				return err
+			}
+			if new == nil {
+				return fmt.Errorf("job %q at version %d not found", args.JobID, args.NewVersion)
+			}
+
+			d, err := old.Diff(new, true)
+			if err != nil {
+				return fmt.Errorf("failed to create job diff: %v", err)
+			}
+			reply.Diff = d
+
+			// Use the last index that affected the job versions table
+			index, err := state.Index("job_version")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			j.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return j.srv.blockingRPC(&opts)
+}
+
 // List is used to list the jobs registered in the system
 func (j *Job) List(args *structs.JobListRequest,
 	reply *structs.JobListResponse) error {
@@ -1016,6 +1855,11 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 		return err
 	}
 
+	// Reject jobs that are too large to safely commit through Raft
+	if err := j.validateJobSize(args.Job); err != nil {
+		return err
+	}
+
 	// Set the warning message
 	reply.Warnings = structs.MergeMultierrorWarnings(warnings, canonicalizeWarnings)
 
@@ -1050,6 +1894,19 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 		return err
 	}
 
+	// Verify the secrets the job depends on exist and can be accessed
+	err, secretWarnings := j.verifySecrets(args.Job, snap)
+	if err != nil {
+		return err
+	}
+	if secretWarnings != nil {
+		reply.Warnings = structs.MergeMultierrorWarnings(warnings,
+			canonicalizeWarnings, policyWarnings, secretWarnings)
+	}
+
+	// Clear the Vault token
+	args.Job.VaultToken = ""
+
 	// Get the original job
 	ws := memdb.NewWatchSet()
 	oldJob, err := snap.JobByID(ws, args.RequestNamespace(), args.Job.ID)
@@ -1062,6 +1919,7 @@ func (j *Job) Plan(args *structs.JobPlanRequest, reply *structs.JobPlanResponse)
 
 	if oldJob != nil {
 		index = oldJob.JobModifyIndex
+		carryJobRestarts(oldJob, args.Job)
 
 		// We want to reuse deployments where possible, so only insert the job if
 		// it has changed or the job didn't exist
@@ -1273,28 +2131,12 @@ func (j *Job) Dispatch(args *structs.JobDispatchRequest, reply *structs.JobDispa
 	}
 
 	// Validate the arguments
-	if err := validateDispatchRequest(args, parameterizedJob); err != nil {
+	if err := validateDispatchRequest(args, parameterizedJob, j.srv.config.MaxDispatchPayloadSize); err != nil {
 		return err
 	}
 
 	// Derive the child job and commit it via Raft
-	dispatchJob := parameterizedJob.Copy()
-	dispatchJob.ParameterizedJob = nil
-	dispatchJob.ID = structs.DispatchedID(parameterizedJob.ID, time.Now())
-	dispatchJob.ParentID = parameterizedJob.ID
-	dispatchJob.Name = dispatchJob.ID
-	dispatchJob.SetSubmitTime()
-
-	// Merge in the meta data
-	for k, v := range args.Meta {
-		if dispatchJob.Meta == nil {
-			dispatchJob.Meta = make(map[string]string, len(args.Meta))
-		}
-		dispatchJob.Meta[k] = v
-	}
-
-	// Compress the payload
-	dispatchJob.Payload = snappy.Encode(nil, args.Payload)
+	dispatchJob := deriveDispatchJob(parameterizedJob, args.Meta, args.Payload)
 
 	regReq := &structs.JobRegisterRequest{
 		Job:          dispatchJob,
@@ -1350,9 +2192,145 @@ func (j *Job) Dispatch(args *structs.JobDispatchRequest, reply *structs.JobDispa
 	return nil
 }
 
+// DispatchBatch is used to dispatch many instances of a parameterized job.
+// The child jobs and their evaluations are committed in a single Raft apply.
+// Items that fail validation are reported in their result and do not prevent
+// the others from being dispatched.
+func (j *Job) DispatchBatch(args *structs.JobDispatchBatchRequest, reply *structs.JobDispatchBatchResponse) error {
+	if done, err := j.srv.forward("Job.DispatchBatch", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "job", "dispatch_batch"}, time.Now())
+
+	// Check for submit-job permissions
+	if aclObj, err := j.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityDispatchJob) {
+		return structs.ErrPermissionDenied
+	}
+
+	// Lookup the parameterized job
+	if args.JobID == "" {
+		return fmt.Errorf("missing parameterized job ID")
+	}
+	if len(args.Items) == 0 {
+		return fmt.Errorf("must specify at least one item to dispatch")
+	}
+
+	snap, err := j.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	parameterizedJob, err := snap.JobByID(nil, args.RequestNamespace(), args.JobID)
+	if err != nil {
+		return err
+	}
+	if parameterizedJob == nil {
+		return fmt.Errorf("parameterized job not found")
+	}
+
+	if !parameterizedJob.IsParameterized() {
+		return fmt.Errorf("Specified job %q is not a parameterized job", args.JobID)
+	}
+
+	if parameterizedJob.Stop {
+		return fmt.Errorf("Specified job %q is stopped", args.JobID)
+	}
+
+	// Validate each item and derive its child job and evaluation
+	batch := &structs.JobBatchDispatchRequest{
+		WriteRequest: args.WriteRequest,
+	}
+	reply.Results = make([]*structs.JobDispatchItemResult, len(args.Items))
+	for i, item := range args.Items {
+		result := &structs.JobDispatchItemResult{}
+		reply.Results[i] = result
+		if item == nil {
+			result.Error = "missing dispatch item"
+			continue
+		}
+
+		req := &structs.JobDispatchRequest{
+			JobID:   args.JobID,
+			Payload: item.Payload,
+			Meta:    item.Meta,
+		}
+		if err := validateDispatchRequest(req, parameterizedJob, j.srv.config.MaxDispatchPayloadSize); err != nil {
+			result.Error = err.Error()
+			continue
+		}
+
+		dispatchJob := deriveDispatchJob(parameterizedJob, item.Meta, item.Payload)
+		batch.Jobs = append(batch.Jobs, dispatchJob)
+		result.DispatchedJobID = dispatchJob.ID
+
+		// If the job is periodic, we don't create an eval.
+		if !dispatchJob.IsPeriodic() {
+			eval := &structs.Evaluation{
+				ID:          uuid.Generate(),
+				Namespace:   args.RequestNamespace(),
+				Priority:    dispatchJob.Priority,
+				Type:        dispatchJob.Type,
+				TriggeredBy: structs.EvalTriggerJobRegister,
+				JobID:       dispatchJob.ID,
+				Status:      structs.EvalStatusPending,
+			}
+			batch.Evals = append(batch.Evals, eval)
+			result.EvalID = eval.ID
+		}
+	}
+
+	// Nothing to commit if every item was rejected
+	if len(batch.Jobs) == 0 {
+		return nil
+	}
+
+	// Commit the jobs and evaluations in a single Raft apply. The FSM sets the
+	// job modify index of the evaluations since it is not known until then.
+	fsmErr, index, err := j.srv.raftApply(structs.JobBatchDispatchRequestType, batch)
+	if err, ok := fsmErr.(error); ok && err != nil {
+		j.srv.logger.Printf("[ERR] nomad.job: Batch dispatch failed: %v", err)
+		return err
+	}
+	if err != nil {
+		j.srv.logger.Printf("[ERR] nomad.job: Batch dispatch failed: %v", err)
+		return err
+	}
+
+	reply.JobCreateIndex = index
+	if len(batch.Evals) != 0 {
+		reply.EvalCreateIndex = index
+	}
+	reply.Index = index
+	return nil
+}
+
+// deriveDispatchJob returns the child job dispatched from the parameterized
+// job with the given meta data and payload.
+func deriveDispatchJob(parameterizedJob *structs.Job, meta map[string]string, payload []byte) *structs.Job {
+	dispatchJob := parameterizedJob.Copy()
+	dispatchJob.ParameterizedJob = nil
+	dispatchJob.ID = structs.DispatchedID(parameterizedJob.ID, time.Now())
+	dispatchJob.ParentID = parameterizedJob.ID
+	dispatchJob.Name = dispatchJob.ID
+	dispatchJob.SetSubmitTime()
+
+	// Merge in the meta data
+	for k, v := range meta {
+		if dispatchJob.Meta == nil {
+			dispatchJob.Meta = make(map[string]string, len(meta))
+		}
+		dispatchJob.Meta[k] = v
+	}
+
+	// Compress the payload
+	dispatchJob.Payload = snappy.Encode(nil, payload)
+	return dispatchJob
+}
+
 // validateDispatchRequest returns whether the request is valid given the
 // parameterized job.
-func validateDispatchRequest(req *structs.JobDispatchRequest, job *structs.Job) error {
+func validateDispatchRequest(req *structs.JobDispatchRequest, job *structs.Job, maxPayloadSize int) error {
 	// Check the payload constraint is met
 	hasInputData := len(req.Payload) != 0
 	if job.ParameterizedJob.Payload == structs.DispatchPayloadRequired && !hasInputData {
@@ -1362,8 +2340,8 @@ func validateDispatchRequest(req *structs.JobDispatchRequest, job *structs.Job)
 	}
 
 	// Check the payload doesn't exceed the size limit
-	if l := len(req.Payload); l > DispatchPayloadSizeLimit {
-		return fmt.Errorf("Payload exceeds maximum size; %d > %d", l, DispatchPayloadSizeLimit)
+	if l := len(req.Payload); maxPayloadSize > 0 && l > maxPayloadSize {
+		return fmt.Errorf("Payload exceeds maximum size; %d > %d. The limit is set by the server's max_dispatch_payload_size; consider passing large inputs as an artifact instead", l, maxPayloadSize)
 	}
 
 	// Check if the metadata is a set
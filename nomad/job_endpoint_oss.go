@@ -8,3 +8,9 @@ import "github.com/hashicorp/nomad/nomad/structs"
 func (j *Job) enforceSubmitJob(override bool, job *structs.Job) (error, error) {
 	return nil, nil
 }
+
+// checkQuota is used to check that the namespace of the job has quota
+// headroom for the job
+func (j *Job) checkQuota(job *structs.Job) error {
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	memdb "github.com/hashicorp/go-memdb"
 	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
 	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/command/agent/consul"
 	"github.com/hashicorp/nomad/helper"
 	"github.com/hashicorp/nomad/helper/uuid"
 	"github.com/hashicorp/nomad/nomad/mock"
@@ -17,6 +18,7 @@ import (
 	"github.com/hashicorp/nomad/testutil"
 	"github.com/kr/pretty"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestJobEndpoint_Register(t *testing.T) {
@@ -588,6 +590,186 @@ func TestJobEndpoint_Register_EnforceIndex(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Register_IdempotencyToken(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Register the job with a token
+	job := mock.Job()
+	req := &structs.JobRegisterRequest{
+		Job:              job,
+		IdempotencyToken: "deploy-1",
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	var resp structs.JobRegisterResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp))
+	assert.NotEmpty(resp.EvalID)
+
+	// Replay the request with the same token
+	var resp2 structs.JobRegisterResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp2))
+	assert.Equal(resp.EvalID, resp2.EvalID)
+	assert.Equal(resp.JobModifyIndex, resp2.JobModifyIndex)
+
+	// Replaying the token with a modified job is rejected
+	job2 := job.Copy()
+	job2.Priority = 100
+	req.Job = job2
+	err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp2)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "different version")
+	}
+	req.Job = job
+
+	// Check that no new version was created
+	state := s1.fsm.State()
+	ws := memdb.NewWatchSet()
+	out, err := state.JobByID(ws, job.Namespace, job.ID)
+	assert.Nil(err)
+	assert.NotNil(out)
+	assert.EqualValues(0, out.Version)
+	assert.Equal("deploy-1", out.IdempotencyToken)
+
+	// Retries of a registration that doesn't change the job return the
+	// evaluation of the first attempt
+	req.IdempotencyToken = "deploy-unchanged"
+	var first, retry structs.JobRegisterResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", req, &first))
+	assert.NotEqual(resp.EvalID, first.EvalID)
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", req, &retry))
+	assert.Equal(first.EvalID, retry.EvalID)
+	assert.Equal(first.JobModifyIndex, retry.JobModifyIndex)
+
+	evals, err := state.EvalsByJob(ws, job.Namespace, job.ID)
+	assert.Nil(err)
+	assert.Len(evals, 2)
+
+	// A new token registers a new version
+	req.Job = job2
+	req.IdempotencyToken = "deploy-2"
+	var resp3 structs.JobRegisterResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp3))
+	assert.NotEqual(resp.EvalID, resp3.EvalID)
+
+	out, err = state.JobByID(ws, job.Namespace, job.ID)
+	assert.Nil(err)
+	assert.EqualValues(1, out.Version)
+	assert.Equal("deploy-2", out.IdempotencyToken)
+}
+
+func TestJobEndpoint_Register_Provenance(t *testing.T) {
+	t.Parallel()
+	s1, root := testACLServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Register the job with a source
+	job := mock.Job()
+	req := &structs.JobRegisterRequest{
+		Job:      job,
+		Source:   "git:4b2e82d",
+		SourceIP: "10.0.0.1",
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+			AuthToken: root.SecretID,
+		},
+	}
+	var resp structs.JobRegisterResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp))
+
+	// The provenance is recorded and the accessor resolved from the token
+	state := s1.fsm.State()
+	out, err := state.JobByIDAndVersion(nil, job.Namespace, job.ID, 0)
+	assert.Nil(err)
+	if assert.NotNil(out) {
+		assert.Equal(&structs.JobProvenance{
+			TokenAccessor: root.AccessorID,
+			SourceIP:      "10.0.0.1",
+			Source:        "git:4b2e82d",
+		}, out.Provenance)
+	}
+
+	// A client supplied accessor is ignored
+	job2 := job.Copy()
+	job2.Priority = 100
+	job2.Provenance = &structs.JobProvenance{TokenAccessor: "forged"}
+	req.Job = job2
+	req.Source = ""
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp))
+
+	out, err = state.JobByIDAndVersion(nil, job.Namespace, job.ID, 1)
+	assert.Nil(err)
+	if assert.NotNil(out) {
+		assert.Equal(root.AccessorID, out.Provenance.TokenAccessor)
+		assert.Empty(out.Provenance.Source)
+	}
+
+	// The provenance of the first version is kept
+	out, err = state.JobByIDAndVersion(nil, job.Namespace, job.ID, 0)
+	assert.Nil(err)
+	assert.Equal("git:4b2e82d", out.Provenance.Source)
+}
+
+func TestJobEndpoint_Register_SizeLimits(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+		c.MaxJobSize = 64 * 1024
+		c.MaxTemplateSize = 1024
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// A template over the limit is rejected
+	job := mock.Job()
+	job.TaskGroups[0].Tasks[0].Templates = []*structs.Template{
+		{
+			EmbeddedTmpl: strings.Repeat("a", 2048),
+			DestPath:     "local/file",
+			ChangeMode:   structs.TemplateChangeModeNoop,
+		},
+	}
+	req := &structs.JobRegisterRequest{
+		Job: job,
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	var resp structs.JobRegisterResponse
+	err := msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "max_template_size")
+
+	// A job over the limit is rejected
+	job = mock.Job()
+	job.Meta["large"] = strings.Repeat("a", 128*1024)
+	req.Job = job
+	err = msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "max_job_size")
+
+	// A job under the limits is accepted
+	req.Job = mock.Job()
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp))
+}
+
 func TestJobEndpoint_Register_Vault_Disabled(t *testing.T) {
 	t.Parallel()
 	s1 := testServer(t, func(c *Config) {
@@ -855,6 +1037,213 @@ func TestJobEndpoint_Register_Vault_Policies(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Patch(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Create the initial register request
+	job := mock.Job()
+	reg := &structs.JobRegisterRequest{
+		Job: job,
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	var resp structs.JobRegisterResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp))
+
+	// Patch the count of the task group and the job meta
+	patch := &structs.JobPatchRequest{
+		JobID: job.ID,
+		Patch: []byte(`{"Meta": {"owner": "ops"}, "TaskGroups": [{"Name": "web", "Count": 3}]}`),
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	var patchResp structs.JobRegisterResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Patch", patch, &patchResp))
+	assert.NotEmpty(patchResp.EvalID)
+
+	state := s1.fsm.State()
+	out, err := state.JobByID(nil, job.Namespace, job.ID)
+	assert.Nil(err)
+	if assert.NotNil(out) {
+		assert.EqualValues(1, out.Version)
+		assert.Equal("ops", out.Meta["owner"])
+		if assert.Len(out.TaskGroups, 1) {
+			assert.Equal(3, out.TaskGroups[0].Count)
+			assert.Equal(job.TaskGroups[0].Tasks[0].Driver, out.TaskGroups[0].Tasks[0].Driver)
+		}
+	}
+
+	// Patching with a stale index fails
+	patch.EnforceIndex = true
+	patch.JobModifyIndex = resp.JobModifyIndex
+	err = msgpackrpc.CallWithCodec(codec, "Job.Patch", patch, &patchResp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), RegisterEnforceIndexErrPrefix)
+	}
+
+	// Patching the job ID fails
+	patch.EnforceIndex = false
+	patch.Patch = []byte(`{"ID": "other"}`)
+	assert.NotNil(msgpackrpc.CallWithCodec(codec, "Job.Patch", patch, &patchResp))
+
+	// Patching a missing job fails
+	patch.JobID = "missing"
+	assert.NotNil(msgpackrpc.CallWithCodec(codec, "Job.Patch", patch, &patchResp))
+}
+
+func TestJobEndpoint_Patch_Vault(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Enable vault
+	tr, f := true, false
+	s1.config.VaultConfig.Enabled = &tr
+	s1.config.VaultConfig.AllowUnauthenticated = &f
+
+	// Replace the Vault Client on the server
+	tvc := &TestVaultClient{}
+	s1.vault = tvc
+
+	goodToken := uuid.Generate()
+	tvc.SetLookupTokenAllowedPolicies(goodToken, []string{"foo"})
+
+	// Register a job asking for a vault policy with a token allowing it
+	job := mock.Job()
+	job.VaultToken = goodToken
+	job.TaskGroups[0].Tasks[0].Vault = &structs.Vault{
+		Policies:   []string{"foo"},
+		ChangeMode: structs.VaultChangeModeRestart,
+	}
+	reg := &structs.JobRegisterRequest{
+		Job: job,
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	var resp structs.JobRegisterResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp))
+
+	// Patching a field unrelated to Vault doesn't need a Vault token
+	patch := &structs.JobPatchRequest{
+		JobID: job.ID,
+		Patch: []byte(`{"Priority": 80}`),
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	var patchResp structs.JobRegisterResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Patch", patch, &patchResp))
+	assert.NotEmpty(patchResp.EvalID)
+
+	out, err := s1.fsm.State().JobByID(nil, job.Namespace, job.ID)
+	assert.Nil(err)
+	if assert.NotNil(out) {
+		assert.Equal(80, out.Priority)
+		assert.Equal([]string{"foo"}, out.TaskGroups[0].Tasks[0].Vault.Policies)
+		assert.Empty(out.VaultToken)
+	}
+
+	// Patching in a new Vault policy can't be authorized without a token
+	patch.Patch = []byte(`{"TaskGroups": [{"Name": "web", "Tasks": [{"Name": "web", "Vault": {"Policies": ["foo", "bar"]}}]}]}`)
+	err = msgpackrpc.CallWithCodec(codec, "Job.Patch", patch, &patchResp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "missing Vault Token")
+	}
+}
+
+func TestJobEndpoint_MergePatch(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	target := map[string]interface{}{
+		"a":    "b",
+		"c":    map[string]interface{}{"d": "e", "f": "g"},
+		"list": []interface{}{"x", "y"},
+		"named": []interface{}{
+			map[string]interface{}{"Name": "one", "Count": 1.0},
+			map[string]interface{}{"Name": "two", "Count": 2.0},
+		},
+		"removed": []interface{}{
+			map[string]interface{}{"Name": "one", "Count": 1.0},
+			map[string]interface{}{"Name": "two", "Count": 2.0},
+		},
+	}
+	patch := map[string]interface{}{
+		"a":    "z",
+		"c":    map[string]interface{}{"f": nil},
+		"list": []interface{}{"q"},
+		"named": []interface{}{
+			map[string]interface{}{"Name": "two", "Count": 5.0},
+			map[string]interface{}{"Name": "three"},
+		},
+		"removed": []interface{}{
+			map[string]interface{}{"Name": "one", mergePatchDeleteKey: true},
+			map[string]interface{}{"Name": "missing", mergePatchDeleteKey: true},
+		},
+		"added": []interface{}{
+			map[string]interface{}{"Name": "new", "Meta": nil},
+			map[string]interface{}{"Name": "gone", mergePatchDeleteKey: true},
+		},
+	}
+
+	expected := map[string]interface{}{
+		"a":    "z",
+		"c":    map[string]interface{}{"d": "e"},
+		"list": []interface{}{"q"},
+		"named": []interface{}{
+			map[string]interface{}{"Name": "one", "Count": 1.0},
+			map[string]interface{}{"Name": "two", "Count": 5.0},
+			map[string]interface{}{"Name": "three"},
+		},
+		"removed": []interface{}{
+			map[string]interface{}{"Name": "two", "Count": 2.0},
+		},
+		"added": []interface{}{
+			map[string]interface{}{"Name": "new"},
+		},
+	}
+	assert.Equal(expected, mergePatch(target, patch))
+}
+
+func TestJobEndpoint_PatchJob_Delete(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	job := mock.Job()
+	cache := job.TaskGroups[0].Copy()
+	cache.Name = "cache"
+	job.TaskGroups = append(job.TaskGroups, cache)
+
+	// Named elements are removed with the delete key
+	out, err := patchJob(job, []byte(`{"TaskGroups": [{"Name": "cache", "$delete": true}]}`))
+	require.Nil(err)
+	require.Len(out.TaskGroups, 1)
+	require.Equal("web", out.TaskGroups[0].Name)
+
+	out, err = patchJob(job, []byte(`{"TaskGroups": [{"Name": "web", "Tasks": [{"Name": "web", "$delete": true}]}]}`))
+	require.Nil(err)
+	require.Len(out.TaskGroups, 2)
+	require.Empty(out.TaskGroups[0].Tasks)
+	require.Len(out.TaskGroups[1].Tasks, 1)
+}
+
 func TestJobEndpoint_Revert(t *testing.T) {
 	t.Parallel()
 	s1 := testServer(t, func(c *Config) {
@@ -1024,6 +1413,112 @@ func TestJobEndpoint_Revert(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Restart(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the initial register request
+	job := mock.Job()
+	req := &structs.JobRegisterRequest{
+		Job: job,
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	var resp structs.JobRegisterResponse
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp))
+
+	// Restarting a missing job fails
+	restartReq := &structs.JobRestartRequest{
+		JobID: "missing",
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	err := msgpackrpc.CallWithCodec(codec, "Job.Restart", restartReq, &resp)
+	require.NotNil(err)
+	require.Contains(err.Error(), "not found")
+
+	// Restart enforcing an incorrect version
+	restartReq.JobID = job.ID
+	restartReq.EnforcePriorVersion = helper.Uint64ToPtr(10)
+	err = msgpackrpc.CallWithCodec(codec, "Job.Restart", restartReq, &resp)
+	require.NotNil(err)
+	require.Contains(err.Error(), "enforcing version 10")
+
+	// Restart the job
+	restartReq.EnforcePriorVersion = helper.Uint64ToPtr(0)
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Job.Restart", restartReq, &resp))
+	require.NotEmpty(resp.EvalID)
+	require.NotZero(resp.EvalCreateIndex)
+
+	state := s1.fsm.State()
+	out, err := state.JobByID(nil, job.Namespace, job.ID)
+	require.Nil(err)
+	require.EqualValues(1, out.Version)
+	require.EqualValues(1, out.Restarts)
+
+	// Registering the job spec again keeps the restarts, so the allocations
+	// are not replaced again
+	req.Job = job.Copy()
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp))
+	out, err = state.JobByID(nil, job.Namespace, job.ID)
+	require.Nil(err)
+	require.EqualValues(1, out.Version)
+	require.EqualValues(1, out.Restarts)
+}
+
+func TestJobEndpoint_Restart_ACL(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	s1, root := testACLServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	// Create the job
+	job := mock.Job()
+	require.Nil(state.UpsertJob(300, job))
+
+	restartReq := &structs.JobRestartRequest{
+		JobID: job.ID,
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+
+	// Attempt to restart without a token
+	var resp structs.JobRegisterResponse
+	err := msgpackrpc.CallWithCodec(codec, "Job.Restart", restartReq, &resp)
+	require.NotNil(err)
+	require.Contains(err.Error(), structs.ErrPermissionDenied.Error())
+
+	// Attempt to restart with an invalid token
+	invalidToken := mock.CreatePolicyAndToken(t, state, 1003, "test-invalid",
+		mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilityListJobs}))
+	restartReq.AuthToken = invalidToken.SecretID
+	err = msgpackrpc.CallWithCodec(codec, "Job.Restart", restartReq, &resp)
+	require.NotNil(err)
+	require.Contains(err.Error(), structs.ErrPermissionDenied.Error())
+
+	// Restart with a management token
+	restartReq.AuthToken = root.SecretID
+	require.Nil(msgpackrpc.CallWithCodec(codec, "Job.Restart", restartReq, &resp))
+}
+
 func TestJobEndpoint_Revert_ACL(t *testing.T) {
 	t.Parallel()
 	assert := assert.New(t)
@@ -2073,6 +2568,68 @@ func TestJobEndpoint_GetJob_Blocking(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_GetJobSubmission(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register the job with its source
+	job := mock.Job()
+	reg := &structs.JobRegisterRequest{
+		Job: job,
+		Submission: &structs.JobSubmission{
+			Source:    "job \"${var.name}\" {}",
+			Format:    structs.JobSubmissionFormatHCL,
+			Variables: map[string]string{"name": job.ID},
+		},
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	var resp structs.JobRegisterResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp))
+
+	// Register a new version without a source
+	job.Priority = 100
+	reg.Submission = nil
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp))
+
+	// The current version has no source
+	get := &structs.JobSubmissionRequest{
+		JobID: job.ID,
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	var subResp structs.JobSubmissionResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.GetJobSubmission", get, &subResp))
+	assert.Nil(subResp.Submission)
+
+	// The first version has one
+	version := uint64(0)
+	get.Version = &version
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.GetJobSubmission", get, &subResp))
+	sub := subResp.Submission
+	if assert.NotNil(sub) {
+		assert.Equal(`job "${var.name}" {}`, sub.Source)
+		assert.Equal(job.ID, sub.Variables["name"])
+		assert.Equal(job.ID, sub.JobID)
+		assert.EqualValues(0, sub.Version)
+		assert.Equal(sub.CreateIndex, subResp.Index)
+	}
+
+	// Unknown formats are rejected
+	reg.Submission = &structs.JobSubmission{Source: "{}", Format: "yaml"}
+	err := msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "unsupported job source format")
+}
+
 func TestJobEndpoint_GetJobVersions(t *testing.T) {
 	t.Parallel()
 	s1 := testServer(t, nil)
@@ -2308,6 +2865,58 @@ func TestJobEndpoint_GetJobVersions_Diff(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_DiffVersions(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Register three versions of the job
+	job := mock.Job()
+	reg := &structs.JobRegisterRequest{
+		Job: job,
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	var resp structs.JobRegisterResponse
+	for _, p := range []int{88, 90, 100} {
+		job.Priority = p
+		assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &resp))
+	}
+
+	// Diff the first and last version
+	get := &structs.JobDiffVersionsRequest{
+		JobID:      job.ID,
+		OldVersion: 0,
+		NewVersion: 2,
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	var diffResp structs.JobDiffVersionsResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.DiffVersions", get, &diffResp))
+	assert.EqualValues(resp.JobModifyIndex, diffResp.Index)
+
+	d := diffResp.Diff
+	if assert.NotNil(d) && assert.Len(d.Fields, 1) {
+		assert.Equal("Priority", d.Fields[0].Name)
+		assert.Equal("88", d.Fields[0].Old)
+		assert.Equal("100", d.Fields[0].New)
+	}
+
+	// Diffing against an unknown version fails
+	get.NewVersion = 10
+	err := msgpackrpc.CallWithCodec(codec, "Job.DiffVersions", get, &diffResp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "not found")
+	}
+}
+
 func TestJobEndpoint_GetJobVersions_Blocking(t *testing.T) {
 	t.Parallel()
 	s1 := testServer(t, nil)
@@ -3578,6 +4187,84 @@ func TestJobEndpoint_Plan_NoDiff(t *testing.T) {
 	}
 }
 
+func TestJobEndpoint_Plan_Secrets(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	require := require.New(t)
+
+	// Enable vault and replace the Vault and Consul clients of the server
+	tr := true
+	s1.config.VaultConfig.Enabled = &tr
+	tvc := &TestVaultClient{}
+	s1.vault = tvc
+	kv := consul.NewMockKV()
+	s1.consulKV = kv
+
+	job := mock.Job()
+	tg := job.TaskGroups[0]
+	job.Secrets = &structs.SecretDependencies{
+		Vault:     []string{"secret/data/web"},
+		Variables: []string{structs.VariableTaskPaths(job.ID, tg.Name, tg.Tasks[0].Name)[2]},
+		Consul:    []string{"config/web"},
+	}
+	planReq := &structs.JobPlanRequest{
+		Job: job,
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+
+	// None of the secrets exist so the plan fails with all of them
+	var planResp structs.JobPlanResponse
+	err := msgpackrpc.CallWithCodec(codec, "Job.Plan", planReq, &planResp)
+	require.Error(err)
+	require.Contains(err.Error(), "Variable")
+	require.Contains(err.Error(), "nomad var put")
+	require.Contains(err.Error(), `Consul key "config/web" does not exist`)
+	require.NotContains(err.Error(), "Vault")
+
+	// Without a Vault token the Vault secrets are only warned about
+	var resp structs.GenericResponse
+	upsert := &structs.VariableUpsertRequest{
+		Variable: &structs.Variable{
+			Path:  job.Secrets.Variables[0],
+			Items: map[string]string{"password": "hunter2"},
+		},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Variables.Upsert", upsert, &resp))
+	kv.Put("config/web", []byte("db.example.com"))
+
+	planResp = structs.JobPlanResponse{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Job.Plan", planReq, &planResp))
+	require.Contains(planResp.Warnings, "Vault secrets can't be verified")
+
+	// The Vault token must be able to read the Vault paths
+	token := uuid.Generate()
+	planReq.Job.VaultToken = token
+	err = msgpackrpc.CallWithCodec(codec, "Job.Plan", planReq, &planResp)
+	require.Error(err)
+	require.Contains(err.Error(), `Vault secret "secret/data/web" is not readable`)
+
+	tvc.SetLookupCapabilities(token, "secret/data/web", []string{"read"})
+	planReq.Job.VaultToken = token
+	planResp = structs.JobPlanResponse{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Job.Plan", planReq, &planResp))
+	require.Empty(planResp.Warnings)
+
+	// Variables must be at a path the tasks of the job can read
+	planReq.Job.Secrets.Variables = []string{"nomad/jobs/other"}
+	err = msgpackrpc.CallWithCodec(codec, "Job.Plan", planReq, &planResp)
+	require.Error(err)
+	require.Contains(err.Error(), "can't be read by the tasks of the job")
+}
+
 func TestJobEndpoint_ImplicitConstraints_Vault(t *testing.T) {
 	t.Parallel()
 	s1 := testServer(t, func(c *Config) {
@@ -3846,6 +4533,71 @@ func TestJobEndpoint_ValidateJobUpdate_ACL(t *testing.T) {
 	assert.Equal("", validResp.Warnings)
 }
 
+func TestJobEndpoint_ValidateJob_Enforcement(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	s1, root := testACLServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	state := s1.fsm.State()
+
+	entries := []*structs.QuarantineEntry{
+		{Digest: "sha256:abc", Type: structs.QuarantineTypeImage, Reason: "CVE"},
+	}
+	assert.Nil(state.UpsertQuarantineEntries(1000, entries))
+
+	job := mock.Job()
+	job.TaskGroups[0].Tasks[0].Driver = "docker"
+	job.TaskGroups[0].Tasks[0].Config["image"] = "redis@sha256:abc"
+	req := &structs.JobValidateRequest{
+		Job:         job,
+		Enforcement: true,
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+
+	// Enforcement requires submit-job permissions
+	readToken := mock.CreatePolicyAndToken(t, state, 1001, "test-read",
+		mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilityReadJob}))
+	req.AuthToken = readToken.SecretID
+	var resp structs.JobValidateResponse
+	err := msgpackrpc.CallWithCodec(codec, "Job.Validate", req, &resp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "Permission denied")
+	}
+
+	// The quarantined image fails the admission check
+	req.AuthToken = root.SecretID
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Validate", req, &resp))
+	assert.Empty(resp.Error)
+	if assert.Len(resp.EnforcementResults, 3) {
+		admission := resp.EnforcementResults[0]
+		assert.Equal(structs.JobEnforcementCheckAdmission, admission.Check)
+		assert.False(admission.Passed)
+		assert.Contains(admission.Error, "quarantined")
+		assert.True(resp.EnforcementResults[1].Passed)
+		assert.True(resp.EnforcementResults[2].Passed)
+	}
+
+	// No evaluation is created
+	evals, err := state.EvalsByJob(nil, job.Namespace, job.ID)
+	assert.Nil(err)
+	assert.Empty(evals)
+
+	// Enforcement is skipped for invalid jobs
+	job.TaskGroups[0].Tasks[0].Resources.CPU = 1
+	resp = structs.JobValidateResponse{}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Validate", req, &resp))
+	assert.NotEmpty(resp.Error)
+	assert.Nil(resp.EnforcementResults)
+}
+
 func TestJobEndpoint_Dispatch_ACL(t *testing.T) {
 	t.Parallel()
 	assert := assert.New(t)
@@ -4197,3 +4949,157 @@ func TestJobEndpoint_Dispatch(t *testing.T) {
 		})
 	}
 }
+
+func TestJobEndpoint_Dispatch_PayloadLimit(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	job := mock.Job()
+	job.Type = structs.JobTypeBatch
+	job.ParameterizedJob = &structs.ParameterizedJobConfig{}
+	req := &structs.JobDispatchRequest{Payload: make([]byte, 100)}
+
+	err := validateDispatchRequest(req, job, 10)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "max_dispatch_payload_size")
+	}
+
+	// Zero disables the limit
+	assert.Nil(validateDispatchRequest(req, job, 0))
+}
+
+func TestJobEndpoint_DispatchBatch(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Register a parameterized job requiring meta
+	job := mock.Job()
+	job.Type = structs.JobTypeBatch
+	job.ParameterizedJob = &structs.ParameterizedJobConfig{
+		MetaRequired: []string{"foo"},
+	}
+	reg := &structs.JobRegisterRequest{
+		Job: job,
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	var regResp structs.JobRegisterResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.Register", reg, &regResp))
+
+	// Dispatch a batch with one invalid item
+	req := &structs.JobDispatchBatchRequest{
+		JobID: job.ID,
+		Items: []*structs.JobDispatchItem{
+			{Meta: map[string]string{"foo": "1"}},
+			{},
+			{Meta: map[string]string{"foo": "2"}},
+		},
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+	var resp structs.JobDispatchBatchResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.DispatchBatch", req, &resp))
+	assert.NotZero(resp.Index)
+	assert.Equal(resp.Index, resp.JobCreateIndex)
+	assert.Equal(resp.Index, resp.EvalCreateIndex)
+	if !assert.Len(resp.Results, 3) {
+		return
+	}
+	assert.NotEmpty(resp.Results[1].Error)
+	assert.Empty(resp.Results[1].DispatchedJobID)
+
+	// The valid items were dispatched at the same index
+	state := s1.fsm.State()
+	for i, meta := range []string{"1", "2"} {
+		result := resp.Results[i*2]
+		assert.Empty(result.Error)
+
+		out, err := state.JobByID(nil, job.Namespace, result.DispatchedJobID)
+		assert.Nil(err)
+		if assert.NotNil(out) {
+			assert.Equal(job.ID, out.ParentID)
+			assert.Equal(meta, out.Meta["foo"])
+			assert.Equal(resp.Index, out.CreateIndex)
+		}
+
+		eval, err := state.EvalByID(nil, result.EvalID)
+		assert.Nil(err)
+		if assert.NotNil(eval) {
+			assert.Equal(result.DispatchedJobID, eval.JobID)
+			assert.Equal(resp.Index, eval.JobModifyIndex)
+		}
+	}
+
+	// A batch where every item is rejected commits nothing
+	req.Items = []*structs.JobDispatchItem{{}}
+	resp = structs.JobDispatchBatchResponse{}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.DispatchBatch", req, &resp))
+	assert.Zero(resp.Index)
+	if assert.Len(resp.Results, 1) {
+		assert.NotEmpty(resp.Results[0].Error)
+	}
+
+	// Dispatching a job that is not parameterized fails
+	req.JobID = "foo"
+	err := msgpackrpc.CallWithCodec(codec, "Job.DispatchBatch", req, &resp)
+	assert.NotNil(err)
+}
+
+func TestJobEndpoint_EvaluateBatch(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, func(c *Config) {
+		c.NumSchedulers = 0 // Prevent automatic dequeue
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	require := require.New(t)
+
+	// Create two jobs that can be evaluated and a periodic one
+	state := s1.fsm.State()
+	jobs := []*structs.Job{mock.Job(), mock.Job(), mock.PeriodicJob()}
+	for i, job := range jobs {
+		require.Nil(state.UpsertJob(uint64(1000+i), job))
+	}
+
+	// Evaluate the jobs two at a time
+	req := &structs.JobBatchEvaluateRequest{
+		BatchSize:    2,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var evalIDs []string
+	skipped := 0
+	for {
+		var resp structs.JobBatchEvaluateResponse
+		require.Nil(msgpackrpc.CallWithCodec(codec, "Job.EvaluateBatch", req, &resp))
+		evalIDs = append(evalIDs, resp.EvalIDs...)
+		skipped += resp.Skipped
+		if resp.NextToken == "" {
+			break
+		}
+		req.NextToken = resp.NextToken
+	}
+	require.Len(evalIDs, 2)
+	require.Equal(1, skipped)
+
+	evaluated := make(map[string]struct{})
+	for _, id := range evalIDs {
+		eval, err := state.EvalByID(nil, id)
+		require.Nil(err)
+		require.NotNil(eval)
+		require.Equal(structs.EvalTriggerJobRegister, eval.TriggeredBy)
+		evaluated[eval.JobID] = struct{}{}
+	}
+	require.Contains(evaluated, jobs[0].ID)
+	require.Contains(evaluated, jobs[1].ID)
+}
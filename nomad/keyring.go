@@ -0,0 +1,273 @@
+package nomad
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/serf/serf"
+	"golang.org/x/crypto/curve25519"
+)
+
+const (
+	// keyringKEKPath is the path of the key-encryption key of the server in
+	// the data directory
+	keyringKEKPath = "keyring/kek"
+
+	// keyringKEKTag is the serf tag the servers advertise the public part of
+	// their key-encryption key with
+	keyringKEKTag = "keyring_kek"
+
+	// keyringWrapInfo separates the keys derived to wrap root keys from any
+	// other use of the key-encryption keys
+	keyringWrapInfo = "nomad-keyring-wrap"
+)
+
+// loadKeyringKEK loads the private key-encryption key the root keys of the
+// keyring are wrapped with for this server, generating it on the first
+// start. The key lives in the data directory, outside of Raft, so that
+// snapshots and Raft logs only ever hold wrapped root keys. The key is kept
+// in memory only in dev mode.
+func loadKeyringKEK(config *Config) ([32]byte, error) {
+	var kek [32]byte
+	if config.DevMode || config.DataDir == "" {
+		return generateKeyringKEK()
+	}
+
+	path := filepath.Join(config.DataDir, keyringKEKPath)
+	raw, err := ioutil.ReadFile(path)
+	if err == nil {
+		if len(raw) != len(kek) {
+			return kek, fmt.Errorf("invalid key-encryption key %q: expected %d bytes, got %d", path, len(kek), len(raw))
+		}
+		copy(kek[:], raw)
+		return kek, nil
+	}
+	if !os.IsNotExist(err) {
+		return kek, err
+	}
+
+	kek, err = generateKeyringKEK()
+	if err != nil {
+		return kek, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return kek, err
+	}
+	if err := ioutil.WriteFile(path, kek[:], 0600); err != nil {
+		return kek, fmt.Errorf("failed to write key-encryption key: %v", err)
+	}
+	return kek, nil
+}
+
+// generateKeyringKEK generates a private X25519 key-encryption key
+func generateKeyringKEK() ([32]byte, error) {
+	var kek [32]byte
+	if _, err := rand.Read(kek[:]); err != nil {
+		return kek, fmt.Errorf("failed to generate key-encryption key: %v", err)
+	}
+	return kek, nil
+}
+
+// keyringKEKID returns the encoded public key-encryption key of a private
+// key-encryption key, which the root keys wrapped for a server are keyed by
+func keyringKEKID(kek [32]byte) string {
+	var pub [32]byte
+	curve25519.ScalarBaseMult(&pub, &kek)
+	return base64.StdEncoding.EncodeToString(pub[:])
+}
+
+// wrapRootKey wraps the key material of a root key for the server whose
+// public key-encryption key is kekID. The key is sealed with a key agreed
+// between an ephemeral key and the one of the server, whose public part is
+// prepended to the wrapped key along with the nonce.
+func wrapRootKey(kekID string, keyID string, key []byte) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(kekID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key-encryption key: %v", err)
+	}
+	var pub [32]byte
+	if len(raw) != len(pub) {
+		return nil, fmt.Errorf("invalid key-encryption key: expected %d bytes, got %d", len(pub), len(raw))
+	}
+	copy(pub[:], raw)
+
+	ephemeral, err := generateKeyringKEK()
+	if err != nil {
+		return nil, err
+	}
+	var ephemeralPub [32]byte
+	curve25519.ScalarBaseMult(&ephemeralPub, &ephemeral)
+	aead, err := keyringWrapCipher(ephemeral, pub, ephemeralPub)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+
+	wrapped := append(ephemeralPub[:], nonce...)
+	return aead.Seal(wrapped, nonce, key, []byte(keyID)), nil
+}
+
+// unwrapRootKey unwraps the key material of a root key wrapped for the
+// server owning kek
+func unwrapRootKey(kek [32]byte, keyID string, wrapped []byte) ([]byte, error) {
+	var ephemeralPub [32]byte
+	if len(wrapped) < len(ephemeralPub) {
+		return nil, fmt.Errorf("wrapped root key %q is truncated", keyID)
+	}
+	copy(ephemeralPub[:], wrapped)
+	aead, err := keyringWrapCipher(kek, ephemeralPub, ephemeralPub)
+	if err != nil {
+		return nil, err
+	}
+	wrapped = wrapped[len(ephemeralPub):]
+	if len(wrapped) < aead.NonceSize() {
+		return nil, fmt.Errorf("wrapped root key %q is truncated", keyID)
+	}
+	nonce, ciphertext := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	key, err := aead.Open(nil, nonce, ciphertext, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap root key %q: %v", keyID, err)
+	}
+	return key, nil
+}
+
+// keyringWrapCipher returns the AES-GCM cipher root keys are wrapped with,
+// keyed by the key agreed between priv and peer and bound to the public
+// ephemeral key of the wrapped key
+func keyringWrapCipher(priv, peer, ephemeralPub [32]byte) (cipher.AEAD, error) {
+	var shared, zero [32]byte
+	curve25519.ScalarMult(&shared, &priv, &peer)
+	if subtle.ConstantTimeCompare(shared[:], zero[:]) == 1 {
+		return nil, fmt.Errorf("invalid key-encryption key: low order point")
+	}
+	h := sha256.New()
+	h.Write([]byte(keyringWrapInfo))
+	h.Write(shared[:])
+	h.Write(ephemeralPub[:])
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// keyringKEKs returns the public key-encryption keys of this server and of
+// the alive servers of the region, which new root keys are wrapped for
+func (s *Server) keyringKEKs() []string {
+	own := keyringKEKID(s.keyringKEK)
+	keks := []string{own}
+	for _, member := range s.serf.Members() {
+		valid, parts := isNomadServer(member)
+		if !valid || parts.Region != s.config.Region || member.Status != serf.StatusAlive {
+			continue
+		}
+		if kek := member.Tags[keyringKEKTag]; kek != "" && kek != own {
+			keks = append(keks, kek)
+		}
+	}
+	return keks
+}
+
+// unwrapOwnRootKey returns the key material of a root key wrapped for this
+// server
+func (s *Server) unwrapOwnRootKey(key *structs.RootKey) ([]byte, error) {
+	wrapped, ok := key.WrappedKeys[keyringKEKID(s.keyringKEK)]
+	if !ok {
+		return nil, fmt.Errorf("root key %q is not wrapped for this server", key.KeyID)
+	}
+	return unwrapRootKey(s.keyringKEK, key.KeyID, wrapped)
+}
+
+// wrapRootKeysFor wraps the root keys of the keyring for a server of the
+// region that they aren't wrapped for yet, such as a server that joined
+// after they were created. The leader can only wrap the keys it can unwrap
+// itself.
+func (s *Server) wrapRootKeysFor(member serf.Member) error {
+	kek := member.Tags[keyringKEKTag]
+	if kek == "" {
+		return nil
+	}
+
+	s.keyringLock.Lock()
+	defer s.keyringLock.Unlock()
+
+	iter, err := s.fsm.State().RootKeys(nil)
+	if err != nil {
+		return err
+	}
+	var keys []*structs.RootKey
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		key := raw.(*structs.RootKey)
+		if _, ok := key.WrappedKeys[kek]; ok {
+			continue
+		}
+		material, err := s.unwrapOwnRootKey(key)
+		if err != nil {
+			s.logger.Printf("[WARN] nomad: failed to wrap root key %q for server %q: %v", key.KeyID, member.Name, err)
+			continue
+		}
+		wrapped, err := wrapRootKey(kek, key.KeyID, material)
+		if err != nil {
+			return err
+		}
+		key = key.Copy()
+		key.WrappedKeys[kek] = wrapped
+		keys = append(keys, key)
+	}
+	return s.upsertRootKeys(keys)
+}
+
+// revokeRootKeysFor removes the root keys wrapped for a server that left the
+// region
+func (s *Server) revokeRootKeysFor(member serf.Member) error {
+	kek := member.Tags[keyringKEKTag]
+	if kek == "" || kek == keyringKEKID(s.keyringKEK) {
+		return nil
+	}
+
+	s.keyringLock.Lock()
+	defer s.keyringLock.Unlock()
+
+	iter, err := s.fsm.State().RootKeys(nil)
+	if err != nil {
+		return err
+	}
+	var keys []*structs.RootKey
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		key := raw.(*structs.RootKey)
+		if _, ok := key.WrappedKeys[kek]; !ok {
+			continue
+		}
+		key = key.Copy()
+		delete(key.WrappedKeys, kek)
+		keys = append(keys, key)
+	}
+	return s.upsertRootKeys(keys)
+}
+
+// upsertRootKeys updates the keys of the keyring via Raft
+func (s *Server) upsertRootKeys(keys []*structs.RootKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	req := structs.RootKeyUpsertRequest{
+		RootKeys: keys,
+		WriteRequest: structs.WriteRequest{
+			Region: s.config.Region,
+		},
+	}
+	_, _, err := s.raftApply(structs.RootKeyUpsertRequestType, &req)
+	return err
+}
@@ -0,0 +1,125 @@
+package nomad
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyring_WrapRootKey(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	kek, err := generateKeyringKEK()
+	assert.Nil(err)
+	other, err := generateKeyringKEK()
+	assert.Nil(err)
+	material := make([]byte, rootKeySize)
+	_, err = rand.Read(material)
+	assert.Nil(err)
+
+	wrapped, err := wrapRootKey(keyringKEKID(kek), "key", material)
+	assert.Nil(err)
+	assert.False(bytes.Contains(wrapped, material))
+
+	// Only the server the key is wrapped for can unwrap it
+	out, err := unwrapRootKey(kek, "key", wrapped)
+	assert.Nil(err)
+	assert.Equal(material, out)
+	_, err = unwrapRootKey(other, "key", wrapped)
+	assert.NotNil(err)
+
+	// The wrapped key is bound to the ID of the key
+	_, err = unwrapRootKey(kek, "other", wrapped)
+	assert.NotNil(err)
+	_, err = unwrapRootKey(kek, "key", wrapped[:10])
+	assert.NotNil(err)
+
+	// Keys can't be wrapped for low order points
+	_, err = wrapRootKey(base64.StdEncoding.EncodeToString(make([]byte, 32)), "key", material)
+	assert.NotNil(err)
+}
+
+func TestKeyring_LoadKEK(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	config := &Config{DataDir: dir}
+
+	// The key is generated on the first start and then reused
+	kek, err := loadKeyringKEK(config)
+	assert.Nil(err)
+	again, err := loadKeyringKEK(config)
+	assert.Nil(err)
+	assert.Equal(kek, again)
+
+	info, err := os.Stat(filepath.Join(dir, keyringKEKPath))
+	assert.Nil(err)
+	assert.Equal(os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestKeyring_WrapForJoiningServer(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	upsert := &structs.VariableUpsertRequest{
+		Variable: &structs.Variable{
+			Path:  "nomad/jobs/web",
+			Items: map[string]string{"password": "hunter2"},
+		},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.Upsert", upsert, &resp))
+
+	// Only the wrapped key material is stored
+	key, err := s1.fsm.State().ActiveRootKey(nil)
+	assert.Nil(err)
+	if !assert.NotNil(key) {
+		return
+	}
+	assert.Len(key.WrappedKeys, 1)
+	assert.Contains(key.WrappedKeys, keyringKEKID(s1.keyringKEK))
+
+	// The key is wrapped for servers joining later
+	s2 := testServer(t, func(c *Config) {
+		c.DevDisableBootstrap = true
+	})
+	defer s2.Shutdown()
+	testJoin(t, s1, s2)
+
+	testutil.WaitForResult(func() (bool, error) {
+		key, err := s2.fsm.State().RootKeyByID(nil, key.KeyID)
+		if err != nil || key == nil {
+			return false, err
+		}
+		_, ok := key.WrappedKeys[keyringKEKID(s2.keyringKEK)]
+		return ok, nil
+	}, func(err error) {
+		t.Fatalf("root key not wrapped for the joining server: %v", err)
+	})
+
+	encrypted, err := s2.fsm.State().VariableByPath(nil, structs.DefaultNamespace, "nomad/jobs/web")
+	assert.Nil(err)
+	v, err := s2.decryptVariable(encrypted)
+	assert.Nil(err)
+	if assert.NotNil(v) {
+		assert.Equal(map[string]string{"password": "hunter2"}, v.Items)
+	}
+}
@@ -227,6 +227,16 @@ func (s *Server) establishLeadership(stopCh chan struct{}) error {
 	// Periodically publish job summary metrics
 	go s.publishJobSummaryMetrics(stopCh)
 
+	// Export the cluster state for external analytics
+	if s.config.StateExportConfig.IsEnabled() {
+		go s.exportState(stopCh)
+	}
+
+	// Fire the node hooks on node lifecycle transitions
+	if len(s.config.NodeHooks) != 0 {
+		go s.runNodeHooks(stopCh)
+	}
+
 	// Setup the heartbeat timers. This is done both when starting up or when
 	// a leader fail over happens. Since the timers are maintained by the leader
 	// node, effectively this means all the timers are renewed at the time of failover.
@@ -420,6 +430,8 @@ func (s *Server) schedulePeriodic(stopCh chan struct{}) {
 	defer jobGC.Stop()
 	deploymentGC := time.NewTicker(s.config.DeploymentGCInterval)
 	defer deploymentGC.Stop()
+	expiredTokenGC := time.NewTicker(s.config.ExpiredACLTokenGCInterval)
+	defer expiredTokenGC.Stop()
 
 	// getLatest grabs the latest index from the state store. It returns true if
 	// the index was retrieved successfully.
@@ -452,6 +464,10 @@ func (s *Server) schedulePeriodic(stopCh chan struct{}) {
 			if index, ok := getLatest(); ok {
 				s.evalBroker.Enqueue(s.coreJobEval(structs.CoreJobDeploymentGC, index))
 			}
+		case <-expiredTokenGC.C:
+			if index, ok := getLatest(); ok && s.config.ACLEnabled {
+				s.evalBroker.Enqueue(s.coreJobEval(structs.CoreJobExpiredACLTokenGC, index))
+			}
 		case <-stopCh:
 			return
 		}
@@ -609,6 +625,12 @@ func (s *Server) publishJobSummaryMetrics(stopCh chan struct{}) {
 								Value: name,
 							},
 						}
+						if s.config.MetricsNamespaceLabels {
+							labels = append(labels, metrics.Label{
+								Name:  "namespace",
+								Value: summary.Namespace,
+							})
+						}
 						metrics.SetGaugeWithLabels([]string{"nomad", "job_summary", "queued"},
 							float32(tgSummary.Queued), labels)
 						metrics.SetGaugeWithLabels([]string{"nomad", "job_summary", "complete"},
@@ -717,8 +739,14 @@ func (s *Server) reconcileMember(member serf.Member) error {
 	switch member.Status {
 	case serf.StatusAlive:
 		err = s.addRaftPeer(member, parts)
+		if err == nil {
+			err = s.wrapRootKeysFor(member)
+		}
 	case serf.StatusLeft, StatusReap:
 		err = s.removeRaftPeer(member, parts)
+		if err == nil {
+			err = s.revokeRootKeysFor(member)
+		}
 	}
 	if err != nil {
 		s.logger.Printf("[ERR] nomad: failed to reconcile member: %v: %v",
@@ -0,0 +1,206 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// NetworkPolicy endpoint is used to manage the policies controlling the
+// traffic between allocations on the bridge network of the clients.
+type NetworkPolicy struct {
+	srv *Server
+}
+
+// UpsertPolicies is used to create or update network policies. Since the
+// policies enforce segmentation between namespaces, they are managed by
+// operators.
+func (n *NetworkPolicy) UpsertPolicies(args *structs.NetworkPolicyUpsertRequest, reply *structs.GenericResponse) error {
+	if done, err := n.srv.forward("NetworkPolicy.UpsertPolicies", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "network_policy", "upsert_policies"}, time.Now())
+
+	// Check operator write permissions
+	if aclObj, err := n.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowOperatorWrite() {
+		return structs.ErrPermissionDenied
+	}
+
+	// Validate non-zero set of policies
+	if len(args.Policies) == 0 {
+		return fmt.Errorf("must specify as least one policy")
+	}
+	for idx, policy := range args.Policies {
+		policy.Canonicalize()
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("policy %d invalid: %v", idx, err)
+		}
+	}
+
+	// Update via Raft
+	_, index, err := n.srv.raftApply(structs.NetworkPolicyUpsertRequestType, args)
+	if err != nil {
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// DeletePolicies is used to delete network policies of a namespace
+func (n *NetworkPolicy) DeletePolicies(args *structs.NetworkPolicyDeleteRequest, reply *structs.GenericResponse) error {
+	if done, err := n.srv.forward("NetworkPolicy.DeletePolicies", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "network_policy", "delete_policies"}, time.Now())
+
+	// Check operator write permissions
+	if aclObj, err := n.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowOperatorWrite() {
+		return structs.ErrPermissionDenied
+	}
+
+	// Validate non-zero set of names
+	if len(args.Names) == 0 {
+		return fmt.Errorf("must specify as least one policy")
+	}
+
+	// Update via Raft
+	_, index, err := n.srv.raftApply(structs.NetworkPolicyDeleteRequestType, args)
+	if err != nil {
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// GetPolicy is used to get a network policy of a namespace
+func (n *NetworkPolicy) GetPolicy(args *structs.NetworkPolicySpecificRequest, reply *structs.SingleNetworkPolicyResponse) error {
+	if done, err := n.srv.forward("NetworkPolicy.GetPolicy", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "network_policy", "get_policy"}, time.Now())
+
+	// Check for read-job permissions
+	if aclObj, err := n.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityReadJob) {
+		return structs.ErrPermissionDenied
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			out, err := state.NetworkPolicyByName(ws, args.RequestNamespace(), args.Name)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Policy = out
+			if out != nil {
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the network policy table
+				index, err := state.Index("network_policy")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			n.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return n.srv.blockingRPC(&opts)
+}
+
+// List is used to list the network policies. Clients, which authenticate with
+// their node secret, are returned the policies of all namespaces so they can
+// enforce them. Otherwise the policies of the namespace of the request are
+// returned.
+func (n *NetworkPolicy) List(args *structs.NetworkPolicyListRequest, reply *structs.NetworkPolicyListResponse) error {
+	if done, err := n.srv.forward("NetworkPolicy.List", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "network_policy", "list"}, time.Now())
+
+	allNamespaces := false
+	if args.NodeID != "" {
+		// Clients authenticate using their node secret
+		node, err := n.srv.State().NodeByID(nil, args.NodeID)
+		if err != nil {
+			return err
+		}
+		if node == nil {
+			return fmt.Errorf("node %q not found", args.NodeID)
+		}
+		if node.SecretID != args.SecretID {
+			return fmt.Errorf("node secret ID does not match")
+		}
+		allNamespaces = true
+	} else if aclObj, err := n.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityReadJob) {
+		return structs.ErrPermissionDenied
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			var iter memdb.ResultIterator
+			var err error
+			if allNamespaces {
+				iter, err = state.NetworkPolicies(ws)
+			} else {
+				iter, err = state.NetworkPoliciesByNamespace(ws, args.RequestNamespace())
+			}
+			if err != nil {
+				return err
+			}
+
+			var policies []*structs.NetworkPolicy
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				policies = append(policies, raw.(*structs.NetworkPolicy))
+			}
+			reply.Policies = policies
+
+			// Use the last index that affected the network policy table
+			index, err := state.Index("network_policy")
+			if err != nil {
+				return err
+			}
+
+			// Ensure we never set the index to zero, otherwise a blocking
+			// query cannot be used.
+			if index == 0 {
+				index = 1
+			}
+			reply.Index = index
+
+			// Set the query response
+			n.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return n.srv.blockingRPC(&opts)
+}
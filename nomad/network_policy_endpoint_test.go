@@ -0,0 +1,162 @@
+package nomad
+
+import (
+	"testing"
+
+	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkPolicyEndpoint_UpsertGetListDelete(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Upsert a policy, the namespace of the rule defaults to the policy's
+	upsert := &structs.NetworkPolicyUpsertRequest{
+		Policies: []*structs.NetworkPolicy{
+			{
+				Name:        "db",
+				Services:    []string{"db"},
+				DefaultDeny: true,
+				Rules: []*structs.NetworkPolicyRule{
+					{Action: structs.NetworkPolicyActionAllow, Service: "web", Ports: []int{5432}},
+				},
+			},
+		},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "NetworkPolicy.UpsertPolicies", upsert, &resp))
+	assert.NotZero(resp.Index)
+
+	// Invalid policies are rejected
+	upsert.Policies = []*structs.NetworkPolicy{{Name: "bad name", DefaultDeny: true}}
+	assert.NotNil(msgpackrpc.CallWithCodec(codec, "NetworkPolicy.UpsertPolicies", upsert, &resp))
+
+	// Get the policy
+	get := &structs.NetworkPolicySpecificRequest{
+		Name:         "db",
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var getResp structs.SingleNetworkPolicyResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "NetworkPolicy.GetPolicy", get, &getResp))
+	if assert.NotNil(getResp.Policy) {
+		assert.Equal(structs.DefaultNamespace, getResp.Policy.Namespace)
+		assert.Equal(structs.DefaultNamespace, getResp.Policy.Rules[0].Namespace)
+		assert.EqualValues(resp.Index, getResp.Index)
+	}
+
+	// List the policies of the namespace
+	list := &structs.NetworkPolicyListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var listResp structs.NetworkPolicyListResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "NetworkPolicy.List", list, &listResp))
+	assert.Len(listResp.Policies, 1)
+
+	list.Namespace = "other"
+	listResp = structs.NetworkPolicyListResponse{}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "NetworkPolicy.List", list, &listResp))
+	assert.Len(listResp.Policies, 0)
+
+	// Delete the policy
+	del := &structs.NetworkPolicyDeleteRequest{
+		Names:        []string{"db"},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "NetworkPolicy.DeletePolicies", del, &resp))
+
+	getResp = structs.SingleNetworkPolicyResponse{}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "NetworkPolicy.GetPolicy", get, &getResp))
+	assert.Nil(getResp.Policy)
+	assert.EqualValues(resp.Index, getResp.Index)
+}
+
+func TestNetworkPolicyEndpoint_List_Node(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	node := mock.Node()
+	assert.Nil(s1.fsm.State().UpsertNode(1000, node))
+
+	policies := []*structs.NetworkPolicy{
+		{Name: "a", Namespace: structs.DefaultNamespace, DefaultDeny: true},
+		{Name: "b", Namespace: "other", DefaultDeny: true},
+	}
+	assert.Nil(s1.fsm.State().UpsertNetworkPolicies(1001, policies))
+
+	// Clients authenticate with their node secret and get the policies of
+	// all namespaces
+	list := &structs.NetworkPolicyListRequest{
+		NodeID:       node.ID,
+		SecretID:     node.SecretID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var listResp structs.NetworkPolicyListResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "NetworkPolicy.List", list, &listResp))
+	assert.Len(listResp.Policies, 2)
+	assert.EqualValues(1001, listResp.Index)
+
+	// A wrong secret is rejected
+	list.SecretID = uuid.Generate()
+	err := msgpackrpc.CallWithCodec(codec, "NetworkPolicy.List", list, &listResp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "secret")
+	}
+}
+
+func TestNetworkPolicyEndpoint_ACL(t *testing.T) {
+	t.Parallel()
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	state := s1.fsm.State()
+	readToken := mock.CreatePolicyAndToken(t, state, 1001, "read-job",
+		mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilityReadJob}))
+
+	upsert := &structs.NetworkPolicyUpsertRequest{
+		Policies: []*structs.NetworkPolicy{{Name: "a", DefaultDeny: true}},
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			AuthToken: readToken.SecretID,
+		},
+	}
+
+	// Writes need operator write
+	var resp structs.GenericResponse
+	err := msgpackrpc.CallWithCodec(codec, "NetworkPolicy.UpsertPolicies", upsert, &resp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), structs.ErrPermissionDenied.Error())
+	}
+	upsert.AuthToken = root.SecretID
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "NetworkPolicy.UpsertPolicies", upsert, &resp))
+
+	// Reads need read-job on the namespace
+	list := &structs.NetworkPolicyListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var listResp structs.NetworkPolicyListResponse
+	err = msgpackrpc.CallWithCodec(codec, "NetworkPolicy.List", list, &listResp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), structs.ErrPermissionDenied.Error())
+	}
+	list.AuthToken = readToken.SecretID
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "NetworkPolicy.List", list, &listResp))
+	assert.Len(listResp.Policies, 1)
+}
@@ -59,6 +59,13 @@ func (n *Node) Register(args *structs.NodeRegisterRequest, reply *structs.NodeUp
 	}
 	defer metrics.MeasureSince([]string{"nomad", "client", "register"}, time.Now())
 
+	// Rebuild the node from the delta of its registration
+	if args.Delta != nil {
+		if err := n.applyNodeDelta(args); err != nil {
+			return err
+		}
+	}
+
 	// Validate the arguments
 	if args.Node == nil {
 		return fmt.Errorf("missing node for client registration")
@@ -128,7 +135,12 @@ func (n *Node) Register(args *structs.NodeRegisterRequest, reply *structs.NodeUp
 		originalStatus = originalNode.Status
 	}
 	transitionToReady := transitionedToReady(args.Node.Status, originalStatus)
-	if structs.ShouldDrainNode(args.Node.Status) || transitionToReady {
+
+	// A ready node whose metadata changed may now satisfy or violate the
+	// constraints of jobs
+	metaChanged := originalNode != nil && args.Node.Status == structs.NodeStatusReady &&
+		nodeMetaChanged(originalNode.Meta, args.Node.Meta)
+	if structs.ShouldDrainNode(args.Node.Status) || transitionToReady || metaChanged {
 		evalIDs, evalIndex, err := n.createNodeEvals(args.Node.ID, index)
 		if err != nil {
 			n.srv.logger.Printf("[ERR] nomad.client: eval creation failed: %v", err)
@@ -372,6 +384,32 @@ func (n *Node) UpdateStatus(args *structs.NodeUpdateStatusRequest, reply *struct
 	return nil
 }
 
+// applyNodeDelta replaces the delta of the registration request with the node
+// it results in.
+func (n *Node) applyNodeDelta(args *structs.NodeRegisterRequest) error {
+	if args.Delta.Node == nil {
+		return fmt.Errorf("missing node for client registration")
+	}
+
+	base, err := n.srv.State().NodeByID(nil, args.Delta.Node.ID)
+	if err != nil {
+		return err
+	}
+	if base == nil {
+		return structs.ErrNodeDeltaMismatch
+	}
+
+	node, err := args.Delta.Apply(base)
+	if err != nil {
+		return err
+	}
+	metrics.IncrCounter([]string{"nomad", "client", "register_delta"}, 1)
+
+	args.Node = node
+	args.Delta = nil
+	return nil
+}
+
 // transitionedToReady is a helper that takes a nodes new and old status and
 // returns whether it has transistioned to ready.
 func transitionedToReady(newStatus, oldStatus string) bool {
@@ -380,6 +418,19 @@ func transitionedToReady(newStatus, oldStatus string) bool {
 	return initToReady || terminalToReady
 }
 
+// nodeMetaChanged returns whether the metadata of a node changed.
+func nodeMetaChanged(oldMeta, newMeta map[string]string) bool {
+	if len(oldMeta) != len(newMeta) {
+		return true
+	}
+	for k, v := range oldMeta {
+		if nv, ok := newMeta[k]; !ok || nv != v {
+			return true
+		}
+	}
+	return false
+}
+
 // UpdateDrain is used to update the drain mode of a client node
 func (n *Node) UpdateDrain(args *structs.NodeUpdateDrainRequest,
 	reply *structs.NodeDrainUpdateResponse) error {
@@ -390,7 +441,30 @@ func (n *Node) UpdateDrain(args *structs.NodeUpdateDrainRequest,
 
 	// Check node write permissions
 	if aclObj, err := n.srv.ResolveToken(args.AuthToken); err != nil {
-		return err
+		// If ResolveToken had an unexpected error return that
+		if err != structs.ErrTokenNotFound {
+			return err
+		}
+
+		// Attempt to lookup AuthToken as a Node.SecretID since nodes drain
+		// themselves when leaving and don't have an ACL token.
+		node, stateErr := n.srv.fsm.State().NodeBySecretID(nil, args.AuthToken)
+		if stateErr != nil {
+			// Return the original ResolveToken error with this err
+			var merr multierror.Error
+			merr.Errors = append(merr.Errors, err, stateErr)
+			return merr.ErrorOrNil()
+		}
+
+		// Not a node or a valid ACL token
+		if node == nil {
+			return structs.ErrTokenNotFound
+		}
+
+		// Nodes may only drain themselves
+		if node.ID != args.NodeID {
+			return structs.ErrPermissionDenied
+		}
 	} else if aclObj != nil && !aclObj.AllowNodeWrite() {
 		return structs.ErrPermissionDenied
 	}
@@ -825,6 +899,19 @@ func (n *Node) UpdateAlloc(args *structs.AllocUpdateRequest, reply *structs.Gene
 	for _, alloc := range args.Alloc {
 		alloc.ModifyTime = now
 	}
+
+	// Count the allocations that just failed on the client
+	state := n.srv.fsm.State()
+	for _, alloc := range args.Alloc {
+		if alloc.ClientStatus != structs.AllocClientStatusFailed {
+			continue
+		}
+		existing, err := state.AllocByID(nil, alloc.ID)
+		if err == nil && existing != nil && existing.ClientStatus != structs.AllocClientStatusFailed {
+			emitAllocStopped(alloc.StopReason)
+		}
+	}
+
 	// Add this to the batch
 	n.updatesLock.Lock()
 	n.updates = append(n.updates, args.Alloc...)
@@ -1321,3 +1408,89 @@ func (n *Node) DeriveVaultToken(args *structs.DeriveVaultTokenRequest,
 	n.srv.setQueryMeta(&reply.QueryMeta)
 	return nil
 }
+
+// DeriveWorkloadToken is used by clients to get the workload token of an
+// allocation running on the node. The token is created on the first request
+// and scoped to the namespace of the allocation. It is valid until the
+// allocation stops, after which it is garbage collected.
+func (n *Node) DeriveWorkloadToken(args *structs.DeriveWorkloadTokenRequest,
+	reply *structs.DeriveWorkloadTokenResponse) error {
+	if !n.srv.config.ACLEnabled {
+		return aclDisabled
+	}
+	if done, err := n.srv.forward("Node.DeriveWorkloadToken", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "client", "derive_workload_token"}, time.Now())
+
+	// Verify the arguments
+	if args.NodeID == "" {
+		return fmt.Errorf("missing node ID")
+	}
+	if args.AllocID == "" {
+		return fmt.Errorf("missing allocation ID")
+	}
+
+	// Clients authenticate using their node secret
+	snap, err := n.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	node, err := snap.NodeByID(nil, args.NodeID)
+	if err != nil {
+		return err
+	}
+	if node == nil {
+		return fmt.Errorf("node %q not found", args.NodeID)
+	}
+	if node.SecretID != args.SecretID {
+		return fmt.Errorf("node secret ID does not match")
+	}
+
+	alloc, err := snap.AllocByID(nil, args.AllocID)
+	if err != nil {
+		return err
+	}
+	if alloc == nil || alloc.NodeID != node.ID {
+		return fmt.Errorf("allocation %q not found on node %q", args.AllocID, node.ID)
+	}
+	if alloc.TerminalStatus() {
+		return fmt.Errorf("can't request workload token for terminal allocation")
+	}
+
+	// Return the existing token so that restarted clients keep using the same
+	// one
+	existing, err := snap.ACLTokenByAllocID(nil, alloc.ID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		reply.SecretID = existing.SecretID
+		reply.Index = existing.CreateIndex
+		return nil
+	}
+
+	token := &structs.ACLToken{
+		AccessorID: uuid.Generate(),
+		SecretID:   uuid.Generate(),
+		Name:       fmt.Sprintf("Workload: %s", alloc.ID),
+		Type:       structs.ACLClientToken,
+		CreateTime: time.Now().UTC(),
+		AllocID:    alloc.ID,
+	}
+	token.SetHash()
+
+	// Update via Raft
+	req := &structs.ACLTokenUpsertRequest{
+		Tokens:       []*structs.ACLToken{token},
+		WriteRequest: args.WriteRequest,
+	}
+	_, index, err := n.srv.raftApply(structs.ACLTokenUpsertRequestType, req)
+	if err != nil {
+		return err
+	}
+
+	reply.SecretID = token.SecretID
+	reply.Index = index
+	return nil
+}
@@ -87,6 +87,44 @@ func TestClientEndpoint_Register_SecretMismatch(t *testing.T) {
 	}
 }
 
+func TestClientEndpoint_Register_Delta(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// A delta without a registration can't be applied
+	node := mock.Node()
+	updated := node.Copy()
+	updated.Attributes["driver.docker"] = "1"
+	delete(updated.Attributes, "driver.exec")
+	delta, err := structs.NewNodeDelta(node, updated)
+	assert.Nil(err)
+	req := &structs.NodeRegisterRequest{
+		Delta:        delta,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	err = msgpackrpc.CallWithCodec(codec, "Node.Register", req, &resp)
+	assert.Contains(err.Error(), structs.ErrNodeDeltaMismatch.Error())
+
+	// Register the node and update it with the delta
+	reg := &structs.NodeRegisterRequest{
+		Node:         node,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Node.Register", reg, &resp))
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Node.Register", req, &resp))
+
+	out, err := s1.fsm.State().NodeByID(nil, node.ID)
+	assert.Nil(err)
+	assert.Equal(updated.Attributes, out.Attributes)
+	assert.Equal(node.Meta, out.Meta)
+	assert.Equal(resp.Index, out.ModifyIndex)
+}
+
 func TestClientEndpoint_Deregister(t *testing.T) {
 	t.Parallel()
 	s1 := testServer(t, nil)
@@ -471,6 +509,54 @@ func TestClientEndpoint_Register_GetEvals(t *testing.T) {
 	}
 }
 
+func TestClientEndpoint_Register_MetaChangeEvals(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Register a system job.
+	job := mock.SystemJob()
+	state := s1.fsm.State()
+	if err := state.UpsertJob(1, job); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Register the node as ready
+	node := mock.Node()
+	node.Status = structs.NodeStatusReady
+	reg := &structs.NodeRegisterRequest{
+		Node:         node,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.NodeUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.Register", reg, &resp); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Re-registering the unchanged node doesn't create evals
+	var resp2 structs.NodeUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.Register", reg, &resp2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp2.EvalIDs) != 0 {
+		t.Fatalf("expected no evals; got %#v", resp2.EvalIDs)
+	}
+
+	// Changing the metadata creates an eval for the system job
+	node = node.Copy()
+	node.Meta["rack"] = "r2"
+	reg.Node = node
+	var resp3 structs.NodeUpdateResponse
+	if err := msgpackrpc.CallWithCodec(codec, "Node.Register", reg, &resp3); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(resp3.EvalIDs) != 1 {
+		t.Fatalf("expected one eval; got %#v", resp3.EvalIDs)
+	}
+}
+
 func TestClientEndpoint_UpdateStatus_GetEvals(t *testing.T) {
 	t.Parallel()
 	s1 := testServer(t, nil)
@@ -728,6 +814,24 @@ func TestClientEndpoint_UpdateDrain_ACL(t *testing.T) {
 		var resp structs.NodeDrainUpdateResponse
 		assert.Nil(msgpackrpc.CallWithCodec(codec, "Node.UpdateDrain", dereg, &resp), "RPC")
 	}
+
+	// Try with the node's secret
+	dereg.AuthToken = node.SecretID
+	{
+		var resp structs.NodeDrainUpdateResponse
+		assert.Nil(msgpackrpc.CallWithCodec(codec, "Node.UpdateDrain", dereg, &resp), "RPC")
+	}
+
+	// Try with the secret of another node
+	other := mock.Node()
+	assert.Nil(state.UpsertNode(1004, other), "UpsertNode")
+	dereg.AuthToken = other.SecretID
+	{
+		var resp structs.NodeDrainUpdateResponse
+		err := msgpackrpc.CallWithCodec(codec, "Node.UpdateDrain", dereg, &resp)
+		assert.NotNil(err, "RPC")
+		assert.Equal(err.Error(), structs.ErrPermissionDenied.Error())
+	}
 }
 
 // This test ensures that Nomad marks client state of allocations which are in
@@ -2555,3 +2659,71 @@ func TestClientEndpoint_DeriveVaultToken_VaultError(t *testing.T) {
 		t.Fatalf("bad: %+v", resp.Error)
 	}
 }
+
+func TestClientEndpoint_DeriveWorkloadToken(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	s1, _ := testACLServer(t, nil)
+	defer s1.Shutdown()
+	state := s1.fsm.State()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create the node and an alloc running on it
+	node := mock.Node()
+	assert.Nil(state.UpsertNode(2, node))
+	alloc := mock.Alloc()
+	alloc.NodeID = node.ID
+	assert.Nil(state.UpsertJobSummary(3, mock.JobSummary(alloc.JobID)))
+	assert.Nil(state.UpsertAllocs(4, []*structs.Allocation{alloc}))
+
+	req := &structs.DeriveWorkloadTokenRequest{
+		NodeID:   node.ID,
+		SecretID: uuid.Generate(),
+		AllocID:  alloc.ID,
+		WriteRequest: structs.WriteRequest{
+			Region: "global",
+		},
+	}
+
+	// The node must authenticate
+	var resp structs.DeriveWorkloadTokenResponse
+	err := msgpackrpc.CallWithCodec(codec, "Node.DeriveWorkloadToken", req, &resp)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "node secret ID does not match")
+
+	req.SecretID = node.SecretID
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Node.DeriveWorkloadToken", req, &resp))
+	assert.NotEmpty(resp.SecretID)
+
+	// The token is reused
+	var resp2 structs.DeriveWorkloadTokenResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Node.DeriveWorkloadToken", req, &resp2))
+	assert.Equal(resp.SecretID, resp2.SecretID)
+
+	token, err := state.ACLTokenByAllocID(nil, alloc.ID)
+	assert.Nil(err)
+	assert.NotNil(token)
+	assert.Equal(resp.SecretID, token.SecretID)
+	assert.False(token.Global)
+
+	// The token can read the namespace of the allocation
+	aclObj, err := s1.ResolveToken(resp.SecretID)
+	assert.Nil(err)
+	assert.True(aclObj.AllowNsOp(alloc.Namespace, acl.NamespaceCapabilityReadJob))
+	assert.True(aclObj.AllowNsOp(alloc.Namespace, acl.NamespaceCapabilityReadVariables))
+	assert.False(aclObj.AllowNsOp(alloc.Namespace, acl.NamespaceCapabilitySubmitJob))
+	assert.False(aclObj.AllowNsOp(alloc.Namespace, acl.NamespaceCapabilityWriteVariables))
+	assert.False(aclObj.AllowNsOp("other", acl.NamespaceCapabilityReadJob))
+
+	// The token is invalid once the allocation stops
+	stopped := alloc.Copy()
+	stopped.DesiredStatus = structs.AllocDesiredStatusStop
+	assert.Nil(state.UpsertAllocs(5, []*structs.Allocation{stopped}))
+	_, err = s1.ResolveToken(resp.SecretID)
+	assert.Equal(structs.ErrTokenNotFound, err)
+
+	err = msgpackrpc.CallWithCodec(codec, "Node.DeriveWorkloadToken", req, &resp)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "terminal allocation")
+}
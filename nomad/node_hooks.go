@@ -0,0 +1,397 @@
+package nomad
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	"github.com/hashicorp/go-cleanhttp"
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	// nodeHookQueueSize is the number of events queued for delivery by a
+	// hook. Events that don't fit are dead-lettered.
+	nodeHookQueueSize = 256
+
+	// nodeHookDeadLetterPath is the path of the file of dead-lettered events
+	// in the data directory
+	nodeHookDeadLetterPath = "node_hooks/dead_letters.json"
+
+	// nodeHookOutputLimit bounds the output of failed scripts included in
+	// errors
+	nodeHookOutputLimit = 1024
+)
+
+// NodeHookEvent is a node lifecycle transition delivered to node hooks.
+// Webhooks receive it as the JSON body of a POST request and scripts on
+// their standard input.
+type NodeHookEvent struct {
+	// Event is the transition: registered, down, drained or purged
+	Event  string
+	Region string
+
+	// Index is the Raft index the transition was observed at
+	Index uint64
+	Time  time.Time
+
+	// Node is the node after the transition, or before it was purged. Its
+	// secret is never included.
+	Node *structs.Node
+}
+
+// NodeHookDeadLetter is an event that could not be delivered to a hook.
+// Dead letters are appended as JSON lines to the dead letter file of the
+// leader.
+type NodeHookDeadLetter struct {
+	Hook     string
+	Event    *NodeHookEvent
+	Attempts int
+	Error    string
+}
+
+// nodeHookTransitions returns the lifecycle transitions of the nodes between
+// the previous snapshot, taken at prevIndex, and the current one.
+func nodeHookTransitions(prev *state.StateSnapshot, prevIndex uint64, snap *state.StateSnapshot, index uint64, region string) ([]*NodeHookEvent, error) {
+	now := time.Now().UTC()
+	sanitize := exportTables["nodes"].sanitize
+	var events []*NodeHookEvent
+	add := func(event string, index uint64, node *structs.Node) {
+		events = append(events, &NodeHookEvent{
+			Event:  event,
+			Region: region,
+			Index:  index,
+			Time:   now,
+			Node:   sanitize(node).(*structs.Node),
+		})
+	}
+
+	iter, err := snap.Nodes(nil)
+	if err != nil {
+		return nil, err
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		node := raw.(*structs.Node)
+		if node.ModifyIndex <= prevIndex {
+			continue
+		}
+
+		old, err := prev.NodeByID(nil, node.ID)
+		if err != nil {
+			return nil, err
+		}
+		if old == nil {
+			add(config.NodeHookEventRegistered, node.ModifyIndex, node)
+			continue
+		}
+		if node.Status == structs.NodeStatusDown && old.Status != structs.NodeStatusDown {
+			add(config.NodeHookEventDown, node.ModifyIndex, node)
+		}
+		if node.Drain && !old.Drain {
+			add(config.NodeHookEventDrained, node.ModifyIndex, node)
+		}
+	}
+
+	// Nodes of the previous snapshot that no longer exist were purged
+	iter, err = prev.Nodes(nil)
+	if err != nil {
+		return nil, err
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		node := raw.(*structs.Node)
+		out, err := snap.NodeByID(nil, node.ID)
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			add(config.NodeHookEventPurged, index, node)
+		}
+	}
+	return events, nil
+}
+
+// nodeHookDeadLetters records the events that could not be delivered.
+type nodeHookDeadLetters struct {
+	// path is the file dead letters are appended to. They are only logged if
+	// it is empty.
+	path   string
+	logger *log.Logger
+	l      sync.Mutex
+}
+
+// add records a dead letter
+func (d *nodeHookDeadLetters) add(dl *NodeHookDeadLetter) {
+	metrics.IncrCounter([]string{"nomad", "node_hooks", "dead_letters"}, 1)
+	d.logger.Printf("[ERR] nomad.node_hooks: dead-lettering %s event of node %q for hook %q after %d attempt(s): %s",
+		dl.Event.Event, dl.Event.Node.ID, dl.Hook, dl.Attempts, dl.Error)
+	if d.path == "" {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := codec.NewEncoder(&buf, structs.JsonHandle).Encode(dl); err != nil {
+		d.logger.Printf("[ERR] nomad.node_hooks: failed to encode dead letter: %v", err)
+		return
+	}
+	buf.WriteByte('\n')
+
+	d.l.Lock()
+	defer d.l.Unlock()
+	if err := ensurePath(d.path, false); err != nil {
+		d.logger.Printf("[ERR] nomad.node_hooks: failed to create dead letter directory: %v", err)
+		return
+	}
+	f, err := os.OpenFile(d.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		d.logger.Printf("[ERR] nomad.node_hooks: failed to open dead letter file: %v", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		d.logger.Printf("[ERR] nomad.node_hooks: failed to write dead letter: %v", err)
+	}
+}
+
+// nodeHook delivers the events it fires on to a webhook or a script, one at a
+// time and in order.
+type nodeHook struct {
+	conf        *config.NodeHookConfig
+	client      *http.Client
+	deadLetters *nodeHookDeadLetters
+	logger      *log.Logger
+	queue       chan *NodeHookEvent
+}
+
+// newNodeHook returns a hook of the configuration with its defaults set
+func newNodeHook(c *config.NodeHookConfig, deadLetters *nodeHookDeadLetters, logger *log.Logger) *nodeHook {
+	conf := c.Copy()
+	conf.Canonicalize()
+	return &nodeHook{
+		conf: conf,
+		client: &http.Client{
+			Timeout:   conf.Timeout,
+			Transport: cleanhttp.DefaultTransport(),
+		},
+		deadLetters: deadLetters,
+		logger:      logger,
+		queue:       make(chan *NodeHookEvent, nodeHookQueueSize),
+	}
+}
+
+// enqueue queues the event for delivery if the hook fires on it. The event is
+// dead-lettered if the queue is full.
+func (h *nodeHook) enqueue(ev *NodeHookEvent) {
+	fires := false
+	for _, e := range h.conf.Events {
+		if e == ev.Event {
+			fires = true
+			break
+		}
+	}
+	if !fires {
+		return
+	}
+
+	select {
+	case h.queue <- ev:
+	default:
+		h.deadLetters.add(&NodeHookDeadLetter{
+			Hook:  h.conf.Name,
+			Event: ev,
+			Error: "delivery queue is full",
+		})
+	}
+}
+
+// run delivers the queued events until stopCh is closed
+func (h *nodeHook) run(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case ev := <-h.queue:
+			h.deliver(ev, stopCh)
+		}
+	}
+}
+
+// deliver fires the hook with the event, retrying failures with an
+// exponential backoff. The event is dead-lettered once the retries are
+// exhausted.
+func (h *nodeHook) deliver(ev *NodeHookEvent, stopCh chan struct{}) {
+	backoff := h.conf.RetryInterval
+	attempts := 0
+	for {
+		start := time.Now()
+		err := h.fire(ev)
+		attempts++
+		if err == nil {
+			metrics.MeasureSince([]string{"nomad", "node_hooks", "deliver"}, start)
+			return
+		}
+
+		metrics.IncrCounter([]string{"nomad", "node_hooks", "failures"}, 1)
+		if attempts > *h.conf.Retries {
+			h.deadLetters.add(&NodeHookDeadLetter{
+				Hook:     h.conf.Name,
+				Event:    ev,
+				Attempts: attempts,
+				Error:    err.Error(),
+			})
+			return
+		}
+
+		h.logger.Printf("[WARN] nomad.node_hooks: hook %q failed on %s event of node %q, retrying in %s: %v",
+			h.conf.Name, ev.Event, ev.Node.ID, backoff, err)
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// fire delivers the event once
+func (h *nodeHook) fire(ev *NodeHookEvent) error {
+	var body bytes.Buffer
+	if err := codec.NewEncoder(&body, structs.JsonHandle).Encode(ev); err != nil {
+		return fmt.Errorf("failed to encode event: %v", err)
+	}
+
+	if h.conf.Webhook != "" {
+		return h.post(body.Bytes())
+	}
+	return h.exec(ev, body.Bytes())
+}
+
+// post posts the event to the webhook
+func (h *nodeHook) post(body []byte) error {
+	req, err := http.NewRequest("POST", h.conf.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range h.conf.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// exec runs the script with the event on its standard input and described by
+// its environment
+func (h *nodeHook) exec(ev *NodeHookEvent, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.conf.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, h.conf.Script, h.conf.Args...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"NOMAD_NODE_EVENT="+ev.Event,
+		"NOMAD_NODE_ID="+ev.Node.ID,
+		"NOMAD_NODE_NAME="+ev.Node.Name,
+		"NOMAD_NODE_DATACENTER="+ev.Node.Datacenter,
+		"NOMAD_REGION="+ev.Region,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", h.conf.Timeout)
+		}
+		if len(output) > nodeHookOutputLimit {
+			output = output[:nodeHookOutputLimit]
+		}
+		return fmt.Errorf("script failed: %v: %s", err, bytes.TrimSpace(output))
+	}
+	return nil
+}
+
+// runNodeHooks fires the node hooks on the lifecycle transitions of the nodes
+// while this server is the leader. Transitions are detected by diffing the
+// nodes table, starting when leadership is established, so transitions the
+// previous leader had not delivered yet are not fired again.
+func (s *Server) runNodeHooks(stopCh chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stopCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	deadLetters := &nodeHookDeadLetters{logger: s.logger}
+	if s.config.DataDir != "" {
+		deadLetters.path = filepath.Join(s.config.DataDir, nodeHookDeadLetterPath)
+	}
+
+	hooks := make([]*nodeHook, 0, len(s.config.NodeHooks))
+	for _, conf := range s.config.NodeHooks {
+		h := newNodeHook(conf, deadLetters, s.logger)
+		hooks = append(hooks, h)
+		go h.run(stopCh)
+	}
+
+	var prev *state.StateSnapshot
+	var prevIndex uint64
+	for {
+		// Watch the table before taking the snapshot so that no change is
+		// missed
+		store := s.fsm.State()
+		ws := memdb.NewWatchSet()
+		ws.Add(store.AbandonCh())
+		if _, err := store.Nodes(ws); err != nil {
+			s.logger.Printf("[ERR] nomad.node_hooks: failed to watch nodes: %v", err)
+		}
+
+		snap, err := store.Snapshot()
+		if err != nil {
+			s.logger.Printf("[ERR] nomad.node_hooks: failed to snapshot state: %v", err)
+		} else if index, err := snap.Index("nodes"); err != nil {
+			s.logger.Printf("[ERR] nomad.node_hooks: failed to determine nodes index: %v", err)
+		} else if prev == nil {
+			prev, prevIndex = snap, index
+		} else if index > prevIndex {
+			events, err := nodeHookTransitions(prev, prevIndex, snap, index, s.config.Region)
+			if err != nil {
+				s.logger.Printf("[ERR] nomad.node_hooks: failed to diff nodes: %v", err)
+			} else {
+				for _, ev := range events {
+					for _, h := range hooks {
+						h.enqueue(ev)
+					}
+				}
+				prev, prevIndex = snap, index
+			}
+		}
+
+		// A failure is retried on the next change
+		if err := ws.WatchCtx(ctx); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package nomad
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/helper"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+func TestNodeHookTransitions(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	s := state.TestStateStore(t)
+	node1, node2 := mock.Node(), mock.Node()
+	require.NoError(s.UpsertNode(1000, node1))
+	prev, err := s.Snapshot()
+	require.NoError(err)
+
+	require.NoError(s.UpsertNode(1001, node2))
+	require.NoError(s.UpdateNodeStatus(1002, node1.ID, structs.NodeStatusDown))
+	require.NoError(s.UpdateNodeDrain(1003, node1.ID, true))
+	snap, err := s.Snapshot()
+	require.NoError(err)
+
+	events, err := nodeHookTransitions(prev, 1000, snap, 1003, "global")
+	require.NoError(err)
+	require.Len(events, 3)
+
+	byEvent := make(map[string]*NodeHookEvent)
+	for _, ev := range events {
+		byEvent[ev.Event] = ev
+		require.Equal("global", ev.Region)
+		require.Empty(ev.Node.SecretID)
+	}
+	require.Equal(node2.ID, byEvent[config.NodeHookEventRegistered].Node.ID)
+	require.Equal(node1.ID, byEvent[config.NodeHookEventDown].Node.ID)
+	require.Equal(node1.ID, byEvent[config.NodeHookEventDrained].Node.ID)
+
+	// Purged nodes are reported with their last state
+	prev = snap
+	require.NoError(s.DeleteNode(1004, node2.ID))
+	snap, err = s.Snapshot()
+	require.NoError(err)
+
+	events, err = nodeHookTransitions(prev, 1003, snap, 1004, "global")
+	require.NoError(err)
+	require.Len(events, 1)
+	require.Equal(config.NodeHookEventPurged, events[0].Event)
+	require.Equal(node2.ID, events[0].Node.ID)
+	require.EqualValues(1004, events[0].Index)
+}
+
+func TestNodeHook_Webhook(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	// Fail the first delivery
+	var calls int32
+	received := make(chan *NodeHookEvent, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer abc" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var ev NodeHookEvent
+		if err := codec.NewDecoder(r.Body, structs.JsonHandle).Decode(&ev); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- &ev
+	}))
+	defer ts.Close()
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	h := newNodeHook(&config.NodeHookConfig{
+		Name:          "cmdb",
+		Events:        []string{config.NodeHookEventRegistered},
+		Webhook:       ts.URL,
+		Headers:       map[string]string{"Authorization": "Bearer abc"},
+		RetryInterval: 10 * time.Millisecond,
+	}, &nodeHookDeadLetters{logger: logger}, logger)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go h.run(stopCh)
+
+	// Events the hook doesn't fire on are ignored
+	node := mock.Node()
+	h.enqueue(&NodeHookEvent{Event: config.NodeHookEventDown, Node: node})
+	h.enqueue(&NodeHookEvent{Event: config.NodeHookEventRegistered, Node: node})
+
+	select {
+	case ev := <-received:
+		require.Equal(config.NodeHookEventRegistered, ev.Event)
+		require.Equal(node.ID, ev.Node.ID)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the webhook")
+	}
+	require.EqualValues(2, atomic.LoadInt32(&calls))
+}
+
+func TestNodeHook_Script_DeadLetter(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad")
+	require.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// The script records its event and fails
+	script := filepath.Join(dir, "hook.sh")
+	out := filepath.Join(dir, "out")
+	require.NoError(ioutil.WriteFile(script, []byte(fmt.Sprintf(`#!/bin/sh
+echo "$NOMAD_NODE_EVENT $NOMAD_NODE_ID" >> %s
+echo "dns unavailable"
+exit 1
+`, out)), 0755))
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	deadLetters := &nodeHookDeadLetters{
+		path:   filepath.Join(dir, nodeHookDeadLetterPath),
+		logger: logger,
+	}
+	h := newNodeHook(&config.NodeHookConfig{
+		Name:          "dns",
+		Script:        script,
+		Retries:       helper.IntToPtr(1),
+		RetryInterval: 10 * time.Millisecond,
+	}, deadLetters, logger)
+
+	node := mock.Node()
+	h.deliver(&NodeHookEvent{Event: config.NodeHookEventPurged, Node: node}, make(chan struct{}))
+
+	// The script ran once and was retried once
+	data, err := ioutil.ReadFile(out)
+	require.NoError(err)
+	line := fmt.Sprintf("%s %s\n", config.NodeHookEventPurged, node.ID)
+	require.Equal(line+line, string(data))
+
+	// The event was dead-lettered
+	f, err := os.Open(deadLetters.path)
+	require.NoError(err)
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	require.True(scanner.Scan())
+	var dl NodeHookDeadLetter
+	require.NoError(codec.NewDecoderBytes(scanner.Bytes(), structs.JsonHandle).Decode(&dl))
+	require.Equal("dns", dl.Hook)
+	require.Equal(2, dl.Attempts)
+	require.Equal(node.ID, dl.Event.Node.ID)
+	require.Contains(dl.Error, "dns unavailable")
+	require.False(scanner.Scan())
+}
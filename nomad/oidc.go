@@ -0,0 +1,345 @@
+package nomad
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// oidcRequestTTL is how long a user has to authenticate with the provider
+	// once the OIDC flow of an auth method is started.
+	oidcRequestTTL = 10 * time.Minute
+
+	// oidcClockSkew is the clock skew tolerated when checking the expiration
+	// of identity tokens.
+	oidcClockSkew = time.Minute
+
+	// oidcHTTPTimeout is the timeout of the requests to the providers.
+	oidcHTTPTimeout = 10 * time.Second
+)
+
+// oidcRequest is an OIDC flow that was started but not completed yet.
+type oidcRequest struct {
+	authMethod  string
+	clientNonce string
+	redirectURI string
+	nonce       string
+	expiry      time.Time
+}
+
+// oidcRequests tracks the OIDC flows that were started, by their state
+// parameter. The flows are only tracked by the server that started them, the
+// leader, so a flow must be restarted if the leadership changes.
+type oidcRequests struct {
+	l        sync.Mutex
+	requests map[string]*oidcRequest
+}
+
+func newOIDCRequests() *oidcRequests {
+	return &oidcRequests{requests: make(map[string]*oidcRequest)}
+}
+
+// add tracks the request and returns its state parameter. The expired
+// requests are pruned.
+func (o *oidcRequests) add(req *oidcRequest, now time.Time) string {
+	o.l.Lock()
+	defer o.l.Unlock()
+
+	for state, r := range o.requests {
+		if now.After(r.expiry) {
+			delete(o.requests, state)
+		}
+	}
+
+	state := uuid.Generate()
+	req.expiry = now.Add(oidcRequestTTL)
+	o.requests[state] = req
+	return state
+}
+
+// complete returns the request of the state parameter, which can only be
+// completed once, or nil if it is unknown or expired.
+func (o *oidcRequests) complete(state string, now time.Time) *oidcRequest {
+	o.l.Lock()
+	defer o.l.Unlock()
+
+	req, ok := o.requests[state]
+	if !ok {
+		return nil
+	}
+	delete(o.requests, state)
+	if now.After(req.expiry) {
+		return nil
+	}
+	return req
+}
+
+// oidcProvider is the configuration of an OpenID Connect provider, as
+// discovered from its issuer.
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+
+	client *http.Client
+}
+
+// discoverOIDCProvider fetches the configuration of the provider of the auth
+// method.
+func discoverOIDCProvider(config *structs.ACLAuthMethodConfig) (*oidcProvider, error) {
+	p := &oidcProvider{
+		client: &http.Client{Timeout: oidcHTTPTimeout},
+	}
+	issuer := strings.TrimSuffix(config.OIDCDiscoveryURL, "/")
+	if err := p.getJSON(issuer+"/.well-known/openid-configuration", p); err != nil {
+		return nil, fmt.Errorf("failed to discover the OIDC provider: %v", err)
+	}
+	if strings.TrimSuffix(p.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("OIDC provider issuer %q doesn't match the discovery URL", p.Issuer)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC provider configuration is missing endpoints")
+	}
+	return p, nil
+}
+
+// authURL returns the URL the user authenticates at.
+func (p *oidcProvider) authURL(config *structs.ACLAuthMethodConfig, redirectURI, state, nonce string) string {
+	scopes := append([]string{"openid"}, config.OIDCScopes...)
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", config.OIDCClientID)
+	v.Set("redirect_uri", redirectURI)
+	v.Set("scope", strings.Join(scopes, " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+
+	sep := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.AuthorizationEndpoint + sep + v.Encode()
+}
+
+// exchange exchanges the authorization code for the identity token of the
+// user.
+func (p *oidcProvider) exchange(config *structs.ACLAuthMethodConfig, code, redirectURI string) (string, error) {
+	v := url.Values{}
+	v.Set("grant_type", "authorization_code")
+	v.Set("code", code)
+	v.Set("redirect_uri", redirectURI)
+	req, err := http.NewRequest("POST", p.TokenEndpoint, strings.NewReader(v.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(config.OIDCClientID), url.QueryEscape(config.OIDCClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %v", err)
+	}
+	if out.Error != "" {
+		return "", fmt.Errorf("OIDC provider returned error %q: %s", out.Error, out.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("OIDC provider returned status %d", resp.StatusCode)
+	}
+	if out.IDToken == "" {
+		return "", fmt.Errorf("OIDC provider didn't return an identity token")
+	}
+	return out.IDToken, nil
+}
+
+// verify verifies the signature and the claims of the identity token, and
+// returns its claims.
+func (p *oidcProvider) verify(config *structs.ACLAuthMethodConfig, rawToken, nonce string, now time.Time) (map[string]interface{}, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("identity token is malformed")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("failed to decode identity token header: %v", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported identity token signing algorithm %q", header.Alg)
+	}
+
+	key, err := p.signingKey(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode identity token signature: %v", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, fmt.Errorf("invalid identity token signature")
+	}
+
+	var claims map[string]interface{}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("failed to decode identity token claims: %v", err)
+	}
+
+	if iss, _ := claims["iss"].(string); iss != p.Issuer {
+		return nil, fmt.Errorf("identity token issuer %q doesn't match the provider", iss)
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, fmt.Errorf("identity token nonce doesn't match the request")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok || now.After(time.Unix(int64(exp), 0).Add(oidcClockSkew)) {
+		return nil, fmt.Errorf("identity token is expired")
+	}
+
+	audiences := config.BoundAudiences
+	if len(audiences) == 0 {
+		audiences = []string{config.OIDCClientID}
+	}
+	if !claimContainsAny(claims["aud"], audiences) {
+		return nil, fmt.Errorf("identity token audience isn't bound by the auth method")
+	}
+	return claims, nil
+}
+
+// signingKey returns the key of the provider with the given ID, or its only
+// key if the ID is empty.
+func (p *oidcProvider) signingKey(kid string) (*rsa.PublicKey, error) {
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := p.getJSON(p.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch the OIDC provider keys: %v", err)
+	}
+
+	for _, k := range jwks.Keys {
+		if k.Kty != "RSA" || (k.Kid != kid && !(kid == "" && len(jwks.Keys) == 1)) {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus of key %q: %v", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent of key %q: %v", k.Kid, err)
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	}
+	return nil, fmt.Errorf("OIDC provider has no RSA key %q", kid)
+}
+
+// getJSON decodes the JSON document at the URL.
+func (p *oidcProvider) getJSON(u string, out interface{}) error {
+	resp, err := p.client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// decodeJWTPart decodes the base64url encoded JSON of a part of a JWT.
+func decodeJWTPart(part string, out interface{}) error {
+	buf, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, out)
+}
+
+// claimStrings returns the values of a claim that is a string or a list of
+// strings.
+func claimStrings(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var out []string
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+// claimContainsAny returns whether the claim contains any of the values.
+func claimContainsAny(claim interface{}, values []string) bool {
+	for _, c := range claimStrings(claim) {
+		for _, v := range values {
+			if c == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// oidcTokenPolicies returns the policies the tokens of the identity are
+// associated with.
+func oidcTokenPolicies(config *structs.ACLAuthMethodConfig, claims map[string]interface{}) []string {
+	var policies []string
+	seen := make(map[string]struct{})
+	add := func(names []string) {
+		for _, name := range names {
+			if _, ok := seen[name]; !ok {
+				seen[name] = struct{}{}
+				policies = append(policies, name)
+			}
+		}
+	}
+
+	add(config.Policies)
+	if config.GroupsClaim != "" {
+		for _, group := range claimStrings(claims[config.GroupsClaim]) {
+			add(config.GroupPolicies[group])
+		}
+	}
+	return policies
+}
@@ -3,8 +3,12 @@ package nomad
 import (
 	"fmt"
 	"net"
+	"sort"
 
 	"github.com/hashicorp/consul/agent/consul/autopilot"
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/raft"
 	"github.com/hashicorp/serf/serf"
@@ -252,6 +256,87 @@ func (op *Operator) AutopilotSetConfiguration(args *structs.AutopilotSetConfigRe
 	return nil
 }
 
+// DeploymentConcurrencyGetConfiguration is used to retrieve the deployment
+// concurrency configuration. The configuration is nil if it was never set.
+func (op *Operator) DeploymentConcurrencyGetConfiguration(args *structs.GenericRequest, reply *structs.DeploymentConcurrencyConfigResponse) error {
+	if done, err := op.srv.forward("Operator.DeploymentConcurrencyGetConfiguration", args, args, reply); done {
+		return err
+	}
+
+	// This action requires operator read access.
+	rule, err := op.srv.ResolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.AllowOperatorRead() {
+		return structs.ErrPermissionDenied
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			_, config, err := state.DeploymentConcurrencyConfig(ws)
+			if err != nil {
+				return err
+			}
+			reply.Config = config
+
+			// Use the last index that affected the configuration
+			index, err := state.Index("deployment-concurrency-config")
+			if err != nil {
+				return err
+			}
+
+			// Ensure we never set the index to zero, otherwise a blocking
+			// query cannot be used.
+			if index == 0 {
+				index = 1
+			}
+			reply.Index = index
+
+			// Set the query response
+			op.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return op.srv.blockingRPC(&opts)
+}
+
+// DeploymentConcurrencySetConfiguration is used to set the deployment
+// concurrency configuration.
+func (op *Operator) DeploymentConcurrencySetConfiguration(args *structs.DeploymentConcurrencySetConfigRequest, reply *structs.GenericResponse) error {
+	if done, err := op.srv.forward("Operator.DeploymentConcurrencySetConfiguration", args, args, reply); done {
+		return err
+	}
+
+	// This action requires operator write access.
+	rule, err := op.srv.ResolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.AllowOperatorWrite() {
+		return structs.ErrPermissionDenied
+	}
+
+	if err := args.Config.Validate(); err != nil {
+		return err
+	}
+
+	// Apply the update
+	resp, index, err := op.srv.raftApply(structs.DeploymentConcurrencyRequestType, args)
+	if err != nil {
+		op.srv.logger.Printf("[ERR] nomad.operator: Apply failed: %v", err)
+		return err
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	reply.Index = index
+	return nil
+}
+
 // ServerHealth is used to get the current health of the servers.
 func (op *Operator) ServerHealth(args *structs.GenericRequest, reply *autopilot.OperatorHealthReply) error {
 	// This must be sent to the leader, so we fix the args since we are
@@ -283,3 +368,284 @@ func (op *Operator) ServerHealth(args *structs.GenericRequest, reply *autopilot.
 
 	return nil
 }
+
+// Inventory returns the nodes of the cluster along with the allocations placed
+// on them, the images and artifacts they run and their resources. The
+// inventory is built from a single state snapshot so it is consistent at the
+// returned index. Allocations in namespaces the token can not read are
+// omitted.
+func (op *Operator) Inventory(args *structs.InventoryRequest, reply *structs.InventoryResponse) error {
+	if done, err := op.srv.forward("Operator.Inventory", args, args, reply); done {
+		return err
+	}
+
+	// Check operator and node read permissions
+	aclObj, err := op.srv.ResolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	} else if aclObj != nil && (!aclObj.AllowOperatorRead() || !aclObj.AllowNodeRead()) {
+		return structs.ErrPermissionDenied
+	}
+
+	snap, err := op.srv.State().Snapshot()
+	if err != nil {
+		return err
+	}
+
+	iter, err := snap.Nodes(nil)
+	if err != nil {
+		return err
+	}
+
+	var nodes []*structs.InventoryNode
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		node := raw.(*structs.Node)
+
+		allocs, err := snap.AllocsByNode(nil, node.ID)
+		if err != nil {
+			return err
+		}
+
+		inode := &structs.InventoryNode{
+			ID:          node.ID,
+			Name:        node.Name,
+			Datacenter:  node.Datacenter,
+			NodeClass:   node.NodeClass,
+			Status:      node.Status,
+			Drain:       node.Drain,
+			Resources:   node.Resources,
+			Allocations: make([]*structs.InventoryAllocation, 0, len(allocs)),
+		}
+		for _, alloc := range allocs {
+			if aclObj != nil && !aclObj.AllowNsOp(alloc.Namespace, acl.NamespaceCapabilityReadJob) {
+				continue
+			}
+			inode.Allocations = append(inode.Allocations, inventoryAllocation(alloc))
+		}
+		nodes = append(nodes, inode)
+	}
+
+	index, err := snap.LatestIndex()
+	if err != nil {
+		return err
+	}
+
+	reply.Nodes = nodes
+	reply.Index = index
+	op.srv.setQueryMeta(&reply.QueryMeta)
+	return nil
+}
+
+// inventoryAllocation returns the inventory entry of an allocation.
+func inventoryAllocation(alloc *structs.Allocation) *structs.InventoryAllocation {
+	ialloc := &structs.InventoryAllocation{
+		ID:            alloc.ID,
+		Namespace:     alloc.Namespace,
+		JobID:         alloc.JobID,
+		TaskGroup:     alloc.TaskGroup,
+		DesiredStatus: alloc.DesiredStatus,
+		ClientStatus:  alloc.ClientStatus,
+	}
+	if alloc.Job == nil {
+		return ialloc
+	}
+
+	ialloc.JobVersion = alloc.Job.Version
+	tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+	if tg == nil {
+		return ialloc
+	}
+
+	for _, task := range tg.Tasks {
+		itask := &structs.InventoryTask{
+			Name:      task.Name,
+			Driver:    task.Driver,
+			Resources: alloc.TaskResources[task.Name],
+		}
+		if image, ok := task.Config["image"].(string); ok {
+			itask.Image = image
+		}
+		for _, artifact := range task.Artifacts {
+			itask.Artifacts = append(itask.Artifacts, artifact.GetterSource)
+		}
+		ialloc.Tasks = append(ialloc.Tasks, itask)
+	}
+	return ialloc
+}
+
+// ImageQuery returns the non-terminal allocations with a task that was started
+// from an image with the given digest. Allocations in namespaces the token can
+// not read are omitted.
+func (op *Operator) ImageQuery(args *structs.ImageQueryRequest, reply *structs.ImageQueryResponse) error {
+	if done, err := op.srv.forward("Operator.ImageQuery", args, args, reply); done {
+		return err
+	}
+
+	// Check operator read permissions
+	aclObj, err := op.srv.ResolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowOperatorRead() {
+		return structs.ErrPermissionDenied
+	}
+
+	if args.ImageDigest == "" {
+		return fmt.Errorf("missing image digest")
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			allocs, err := state.AllocsByImageDigest(ws, args.ImageDigest)
+			if err != nil {
+				return err
+			}
+
+			stubs := make([]*structs.AllocListStub, 0, len(allocs))
+			for _, alloc := range allocs {
+				if alloc.TerminalStatus() {
+					continue
+				}
+				if aclObj != nil && !aclObj.AllowNsOp(alloc.Namespace, acl.NamespaceCapabilityReadJob) {
+					continue
+				}
+				stubs = append(stubs, alloc.Stub())
+			}
+			reply.Allocations = stubs
+
+			// Use the last index that affected the allocs table
+			index, err := state.Index("allocs")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			op.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return op.srv.blockingRPC(&opts)
+}
+
+// Utilization returns the total, allocated and running CPU, memory and disk of
+// the cluster grouped by datacenter and node class. Measured resource usage is
+// only known by the clients, so running is the allocated resources of the
+// allocations clients report as running.
+func (op *Operator) Utilization(args *structs.UtilizationRequest, reply *structs.UtilizationResponse) error {
+	if done, err := op.srv.forward("Operator.Utilization", args, args, reply); done {
+		return err
+	}
+
+	// Check operator and node read permissions
+	aclObj, err := op.srv.ResolveToken(args.AuthToken)
+	if err != nil {
+		return err
+	} else if aclObj != nil && (!aclObj.AllowOperatorRead() || !aclObj.AllowNodeRead()) {
+		return structs.ErrPermissionDenied
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			groups, err := clusterUtilization(ws, state)
+			if err != nil {
+				return err
+			}
+			reply.Groups = groups
+
+			// Use the last index that affected the nodes or allocs table
+			index, err := state.Index("nodes")
+			if err != nil {
+				return err
+			}
+			allocIndex, err := state.Index("allocs")
+			if err != nil {
+				return err
+			}
+			if allocIndex > index {
+				index = allocIndex
+			}
+			reply.Index = index
+
+			// Set the query response
+			op.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return op.srv.blockingRPC(&opts)
+}
+
+// clusterUtilization computes the utilization of every datacenter and node
+// class pair, sorted by datacenter and then node class.
+func clusterUtilization(ws memdb.WatchSet, state *state.StateStore) ([]*structs.UtilizationGroup, error) {
+	type groupKey struct {
+		datacenter string
+		class      string
+	}
+	groups := make(map[groupKey]*structs.UtilizationGroup)
+
+	iter, err := state.Nodes(ws)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		node := raw.(*structs.Node)
+
+		key := groupKey{datacenter: node.Datacenter, class: node.NodeClass}
+		group, ok := groups[key]
+		if !ok {
+			group = &structs.UtilizationGroup{
+				Datacenter: node.Datacenter,
+				NodeClass:  node.NodeClass,
+				Total:      &structs.UtilizationResources{},
+				Allocated:  &structs.UtilizationResources{},
+				Running:    &structs.UtilizationResources{},
+			}
+			groups[key] = group
+		}
+
+		if node.Status == structs.NodeStatusReady {
+			group.Nodes++
+			group.Total.Add(node.Resources)
+			group.Total.Subtract(node.Reserved)
+		}
+
+		allocs, err := state.AllocsByNode(ws, node.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, alloc := range allocs {
+			if alloc.TerminalStatus() {
+				continue
+			}
+			group.Allocated.Add(alloc.Resources)
+			if alloc.ClientStatus == structs.AllocClientStatusRunning {
+				group.Running.Add(alloc.Resources)
+			}
+		}
+	}
+
+	out := make([]*structs.UtilizationGroup, 0, len(groups))
+	for _, group := range groups {
+		out = append(out, group)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Datacenter != out[j].Datacenter {
+			return out[i].Datacenter < out[j].Datacenter
+		}
+		return out[i].NodeClass < out[j].NodeClass
+	})
+	return out, nil
+}
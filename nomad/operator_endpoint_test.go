@@ -333,3 +333,172 @@ func TestOperator_RaftRemovePeerByID_ACL(t *testing.T) {
 		assert.Nil(err)
 	}
 }
+
+func TestOperator_Inventory(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	state := s1.fsm.State()
+	node := mock.Node()
+	assert.Nil(state.UpsertNode(1000, node))
+	alloc := mock.Alloc()
+	alloc.NodeID = node.ID
+	assert.Nil(state.UpsertJobSummary(1001, mock.JobSummary(alloc.JobID)))
+	assert.Nil(state.UpsertAllocs(1002, []*structs.Allocation{alloc}))
+
+	arg := structs.InventoryRequest{
+		QueryOptions: structs.QueryOptions{
+			Region: s1.config.Region,
+		},
+	}
+	var reply structs.InventoryResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Operator.Inventory", &arg, &reply))
+	assert.True(reply.Index >= 1002)
+	if assert.Len(reply.Nodes, 1) {
+		inode := reply.Nodes[0]
+		assert.Equal(node.ID, inode.ID)
+		if assert.Len(inode.Allocations, 1) {
+			ialloc := inode.Allocations[0]
+			assert.Equal(alloc.ID, ialloc.ID)
+			if assert.Len(ialloc.Tasks, 1) {
+				assert.Equal("exec", ialloc.Tasks[0].Driver)
+				assert.Equal(alloc.TaskResources["web"].CPU, ialloc.Tasks[0].Resources.CPU)
+			}
+		}
+	}
+}
+
+func TestOperator_ImageQuery(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Create a running and a terminal allocation started from the image
+	state := s1.fsm.State()
+	running := mock.Alloc()
+	running.TaskStates = map[string]*structs.TaskState{
+		"web": {State: structs.TaskStateRunning, ImageDigest: "sha256:aaa"},
+	}
+	stopped := mock.Alloc()
+	stopped.DesiredStatus = structs.AllocDesiredStatusStop
+	stopped.TaskStates = map[string]*structs.TaskState{
+		"web": {State: structs.TaskStateDead, ImageDigest: "sha256:aaa"},
+	}
+	assert.Nil(state.UpsertJobSummary(1000, mock.JobSummary(running.JobID)))
+	assert.Nil(state.UpsertJobSummary(1001, mock.JobSummary(stopped.JobID)))
+	assert.Nil(state.UpsertAllocs(1002, []*structs.Allocation{running, stopped}))
+
+	arg := structs.ImageQueryRequest{
+		ImageDigest: "sha256:aaa",
+		QueryOptions: structs.QueryOptions{
+			Region: s1.config.Region,
+		},
+	}
+	var reply structs.ImageQueryResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Operator.ImageQuery", &arg, &reply))
+	assert.EqualValues(1002, reply.Index)
+	if assert.Len(reply.Allocations, 1) {
+		assert.Equal(running.ID, reply.Allocations[0].ID)
+		assert.Equal(running.Namespace, reply.Allocations[0].Namespace)
+	}
+
+	// A digest is required
+	arg.ImageDigest = ""
+	assert.NotNil(msgpackrpc.CallWithCodec(codec, "Operator.ImageQuery", &arg, &reply))
+}
+
+func TestOperator_Utilization(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Create a node with a running and a pending allocation, and a node that
+	// is down
+	state := s1.fsm.State()
+	node := mock.Node()
+	down := mock.Node()
+	down.Status = structs.NodeStatusDown
+	assert.Nil(state.UpsertNode(1000, node))
+	assert.Nil(state.UpsertNode(1001, down))
+
+	running := mock.Alloc()
+	running.NodeID = node.ID
+	running.ClientStatus = structs.AllocClientStatusRunning
+	pending := mock.Alloc()
+	pending.NodeID = node.ID
+	assert.Nil(state.UpsertJobSummary(1002, mock.JobSummary(running.JobID)))
+	assert.Nil(state.UpsertJobSummary(1003, mock.JobSummary(pending.JobID)))
+	assert.Nil(state.UpsertAllocs(1004, []*structs.Allocation{running, pending}))
+
+	arg := structs.UtilizationRequest{
+		QueryOptions: structs.QueryOptions{
+			Region: s1.config.Region,
+		},
+	}
+	var reply structs.UtilizationResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Operator.Utilization", &arg, &reply))
+	assert.EqualValues(1004, reply.Index)
+	if assert.Len(reply.Groups, 1) {
+		group := reply.Groups[0]
+		assert.Equal(node.Datacenter, group.Datacenter)
+		assert.Equal(node.NodeClass, group.NodeClass)
+		assert.Equal(1, group.Nodes)
+		assert.Equal(&structs.UtilizationResources{CPU: 3900, MemoryMB: 7936, DiskMB: 96 * 1024}, group.Total)
+		assert.Equal(&structs.UtilizationResources{CPU: 1000, MemoryMB: 512, DiskMB: 300}, group.Allocated)
+		assert.Equal(&structs.UtilizationResources{CPU: 500, MemoryMB: 256, DiskMB: 150}, group.Running)
+	}
+}
+
+func TestOperator_DeploymentConcurrencyConfiguration(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// The config is empty until set
+	get := structs.GenericRequest{
+		QueryOptions: structs.QueryOptions{
+			Region: s1.config.Region,
+		},
+	}
+	var reply structs.DeploymentConcurrencyConfigResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Operator.DeploymentConcurrencyGetConfiguration", &get, &reply))
+	assert.Nil(reply.Config)
+
+	// Invalid limits are rejected
+	set := structs.DeploymentConcurrencySetConfigRequest{
+		Config: structs.DeploymentConcurrencyConfig{Limit: -1},
+		WriteRequest: structs.WriteRequest{
+			Region: s1.config.Region,
+		},
+	}
+	var resp structs.GenericResponse
+	assert.NotNil(msgpackrpc.CallWithCodec(codec, "Operator.DeploymentConcurrencySetConfiguration", &set, &resp))
+
+	set.Config = structs.DeploymentConcurrencyConfig{
+		Limit:           10,
+		NamespaceLimits: map[string]int{"web": 3},
+	}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Operator.DeploymentConcurrencySetConfiguration", &set, &resp))
+	assert.NotZero(resp.Index)
+
+	reply = structs.DeploymentConcurrencyConfigResponse{}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Operator.DeploymentConcurrencyGetConfiguration", &get, &reply))
+	if assert.NotNil(reply.Config) {
+		assert.Equal(10, reply.Config.Limit)
+		assert.Equal(map[string]int{"web": 3}, reply.Config.NamespaceLimits)
+		assert.Equal(resp.Index, reply.Index)
+	}
+}
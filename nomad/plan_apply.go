@@ -186,6 +186,13 @@ func (s *Server) asyncPlanWait(waitCh chan struct{}, future raft.ApplyFuture,
 	// Respond to the plan
 	result.AllocIndex = future.Index()
 
+	// Count the allocations stopped by the plan
+	for _, updateList := range result.NodeUpdate {
+		for _, alloc := range updateList {
+			emitAllocStopped(alloc.StopReason)
+		}
+	}
+
 	// If this is a partial plan application, we need to ensure the scheduler
 	// at least has visibility into any placements it made to avoid double placement.
 	// The RefreshIndex computed by evaluatePlan may be stale due to evaluation
@@ -196,6 +203,16 @@ func (s *Server) asyncPlanWait(waitCh chan struct{}, future raft.ApplyFuture,
 	pending.respond(result, nil)
 }
 
+// emitAllocStopped increments the counter of allocations stopped for the given
+// reason.
+func emitAllocStopped(reason string) {
+	if reason == "" {
+		return
+	}
+	metrics.IncrCounterWithLabels([]string{"nomad", "alloc", "stopped"}, 1,
+		[]metrics.Label{{Name: "reason", Value: reason}})
+}
+
 // evaluatePlan is used to determine what portions of a plan
 // can be applied if any. Returns if there should be a plan application
 // which may be partial or if there was an error
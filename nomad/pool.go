@@ -131,6 +131,9 @@ type ConnPool struct {
 	// TLS wrapper
 	tlsWrap tlsutil.RegionWrapper
 
+	// compress sets whether new connections are compressed
+	compress bool
+
 	// Used to indicate the pool is shutdown
 	shutdown   bool
 	shutdownCh chan struct{}
@@ -188,6 +191,15 @@ func (p *ConnPool) ReloadTLS(tlsWrap tlsutil.RegionWrapper) {
 	p.tlsWrap = tlsWrap
 }
 
+// SetCompression sets whether the connections of the pool compress the data
+// sent over them, which the servers must support. Open connections are kept
+// as they are.
+func (p *ConnPool) SetCompression(compress bool) {
+	p.Lock()
+	defer p.Unlock()
+	p.compress = compress
+}
+
 // Acquire is used to get a connection that is
 // pooled or to return a new connection
 func (p *ConnPool) acquire(region string, addr net.Addr, version int) (*Conn, error) {
@@ -282,6 +294,19 @@ func (p *ConnPool) getNewConn(region string, addr net.Addr, version int) (*Conn,
 		conn = tlsConn
 	}
 
+	// Check if compression is enabled
+	p.Lock()
+	compress := p.compress
+	p.Unlock()
+	if compress {
+		// Switch the connection into compressed mode
+		if _, err := conn.Write([]byte{byte(rpcSnappy)}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = newSnappyConn(conn)
+	}
+
 	// Write the multiplex byte to set the mode
 	if _, err := conn.Write([]byte{byte(rpcMultiplex)}); err != nil {
 		conn.Close()
@@ -0,0 +1,190 @@
+package nomad
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/armon/go-metrics"
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// Quarantine endpoint is used to manage the image digests and artifact
+// checksums that are denied cluster-wide.
+type Quarantine struct {
+	srv *Server
+}
+
+// UpsertEntries is used to add or update quarantine entries
+func (q *Quarantine) UpsertEntries(args *structs.QuarantineUpsertRequest, reply *structs.GenericResponse) error {
+	if done, err := q.srv.forward("Quarantine.UpsertEntries", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "quarantine", "upsert_entries"}, time.Now())
+
+	// Check operator write permissions
+	if aclObj, err := q.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowOperatorWrite() {
+		return structs.ErrPermissionDenied
+	}
+
+	// Validate non-zero set of entries
+	if len(args.Entries) == 0 {
+		return fmt.Errorf("must specify as least one entry")
+	}
+	for idx, entry := range args.Entries {
+		entry.Canonicalize()
+		if err := entry.Validate(); err != nil {
+			return fmt.Errorf("entry %d invalid: %v", idx, err)
+		}
+	}
+
+	// Update via Raft
+	_, index, err := q.srv.raftApply(structs.QuarantineUpsertRequestType, args)
+	if err != nil {
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// DeleteEntries is used to remove quarantine entries
+func (q *Quarantine) DeleteEntries(args *structs.QuarantineDeleteRequest, reply *structs.GenericResponse) error {
+	if done, err := q.srv.forward("Quarantine.DeleteEntries", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "quarantine", "delete_entries"}, time.Now())
+
+	// Check operator write permissions
+	if aclObj, err := q.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowOperatorWrite() {
+		return structs.ErrPermissionDenied
+	}
+
+	// Validate non-zero set of digests
+	if len(args.Digests) == 0 {
+		return fmt.Errorf("must specify as least one digest")
+	}
+	for i, digest := range args.Digests {
+		args.Digests[i] = structs.NormalizeQuarantineDigest(digest)
+	}
+
+	// Update via Raft
+	_, index, err := q.srv.raftApply(structs.QuarantineDeleteRequestType, args)
+	if err != nil {
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// List is used to list the quarantine entries. It is called by clients, which
+// authenticate with their node secret, and by operators.
+func (q *Quarantine) List(args *structs.QuarantineListRequest, reply *structs.QuarantineListResponse) error {
+	if done, err := q.srv.forward("Quarantine.List", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "quarantine", "list"}, time.Now())
+
+	if args.NodeID != "" {
+		// Clients authenticate using their node secret
+		node, err := q.srv.State().NodeByID(nil, args.NodeID)
+		if err != nil {
+			return err
+		}
+		if node == nil {
+			return fmt.Errorf("node %q not found", args.NodeID)
+		}
+		if node.SecretID != args.SecretID {
+			return fmt.Errorf("node secret ID does not match")
+		}
+	} else if aclObj, err := q.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowOperatorRead() {
+		return structs.ErrPermissionDenied
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			iter, err := state.QuarantineEntries(ws)
+			if err != nil {
+				return err
+			}
+
+			var entries []*structs.QuarantineEntry
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				entries = append(entries, raw.(*structs.QuarantineEntry))
+			}
+			reply.Entries = entries
+
+			// Use the last index that affected the quarantine table
+			index, err := state.Index("quarantine")
+			if err != nil {
+				return err
+			}
+
+			// Ensure we never set the index to zero, otherwise a blocking
+			// query cannot be used.
+			if index == 0 {
+				index = 1
+			}
+			reply.Index = index
+
+			// Set the query response
+			q.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return q.srv.blockingRPC(&opts)
+}
+
+// quarantinedDigests returns the image digests and artifact checksums pinned
+// by the job. Images referenced by tag are resolved by clients and checked
+// when the task starts.
+func quarantinedDigests(job *structs.Job) []string {
+	var digests []string
+	for _, tg := range job.TaskGroups {
+		for _, task := range tg.Tasks {
+			if image, ok := task.Config["image"].(string); ok {
+				if idx := strings.LastIndex(image, "@"); idx != -1 {
+					digests = append(digests, structs.NormalizeQuarantineDigest(image[idx+1:]))
+				}
+			}
+			for _, artifact := range task.Artifacts {
+				if checksum, ok := artifact.GetterOptions["checksum"]; ok {
+					digests = append(digests, structs.NormalizeQuarantineDigest(checksum))
+				}
+			}
+		}
+	}
+	return digests
+}
+
+// checkQuarantine returns an error if the job references a quarantined image
+// digest or artifact checksum.
+func checkQuarantine(snap *state.StateSnapshot, job *structs.Job) error {
+	for _, digest := range quarantinedDigests(job) {
+		entry, err := snap.QuarantineEntryByDigest(nil, digest)
+		if err != nil {
+			return err
+		}
+		if entry != nil {
+			return fmt.Errorf("job references quarantined %s %q: %s", entry.Type, entry.Digest, entry.Reason)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package nomad
+
+import (
+	"testing"
+
+	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantineEndpoint_UpsertListDelete(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Upsert an entry, the digest is normalized
+	upsert := &structs.QuarantineUpsertRequest{
+		Entries: []*structs.QuarantineEntry{
+			{Digest: " SHA256:ABC ", Type: structs.QuarantineTypeImage, Reason: "CVE"},
+		},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Quarantine.UpsertEntries", upsert, &resp))
+	assert.NotZero(resp.Index)
+
+	// Invalid entries are rejected
+	upsert.Entries = []*structs.QuarantineEntry{{Digest: "abc", Type: structs.QuarantineTypeImage}}
+	assert.NotNil(msgpackrpc.CallWithCodec(codec, "Quarantine.UpsertEntries", upsert, &resp))
+
+	// List the entries
+	list := &structs.QuarantineListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var listResp structs.QuarantineListResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Quarantine.List", list, &listResp))
+	if assert.Len(listResp.Entries, 1) {
+		assert.Equal("sha256:abc", listResp.Entries[0].Digest)
+	}
+
+	// Delete the entry
+	del := &structs.QuarantineDeleteRequest{
+		Digests:      []string{"sha256:abc"},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Quarantine.DeleteEntries", del, &resp))
+
+	listResp = structs.QuarantineListResponse{}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Quarantine.List", list, &listResp))
+	assert.Len(listResp.Entries, 0)
+	assert.EqualValues(resp.Index, listResp.Index)
+}
+
+func TestQuarantineEndpoint_List_Node(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	node := mock.Node()
+	assert.Nil(s1.fsm.State().UpsertNode(1000, node))
+
+	// Clients authenticate with their node secret
+	list := &structs.QuarantineListRequest{
+		NodeID:       node.ID,
+		SecretID:     node.SecretID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var listResp structs.QuarantineListResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Quarantine.List", list, &listResp))
+
+	// A wrong secret is rejected
+	list.SecretID = uuid.Generate()
+	err := msgpackrpc.CallWithCodec(codec, "Quarantine.List", list, &listResp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "secret")
+	}
+}
+
+func TestQuarantineEndpoint_JobRegister(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	entries := []*structs.QuarantineEntry{
+		{Digest: "sha256:abc", Type: structs.QuarantineTypeImage},
+		{Digest: "md5:d41d8cd98f00b204e9800998ecf8427e", Type: structs.QuarantineTypeArtifact},
+	}
+	assert.Nil(s1.fsm.State().UpsertQuarantineEntries(1000, entries))
+
+	register := func(job *structs.Job) error {
+		req := &structs.JobRegisterRequest{
+			Job: job,
+			WriteRequest: structs.WriteRequest{
+				Region:    "global",
+				Namespace: job.Namespace,
+			},
+		}
+		var resp structs.JobRegisterResponse
+		return msgpackrpc.CallWithCodec(codec, "Job.Register", req, &resp)
+	}
+
+	// Jobs pinning a quarantined image are rejected
+	job := mock.Job()
+	job.TaskGroups[0].Tasks[0].Driver = "docker"
+	job.TaskGroups[0].Tasks[0].Config["image"] = "redis@sha256:abc"
+	err := register(job)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "quarantined")
+	}
+
+	// Jobs with a quarantined artifact are rejected
+	job = mock.Job()
+	job.TaskGroups[0].Tasks[0].Artifacts = []*structs.TaskArtifact{
+		{
+			GetterSource:  "http://example.com/foo.tgz",
+			GetterOptions: map[string]string{"checksum": "md5:d41d8cd98f00b204e9800998ecf8427e"},
+		},
+	}
+	err = register(job)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "quarantined")
+	}
+
+	// Other jobs are allowed
+	job = mock.Job()
+	job.TaskGroups[0].Tasks[0].Driver = "docker"
+	job.TaskGroups[0].Tasks[0].Config["image"] = "redis@sha256:123"
+	assert.Nil(register(job))
+}
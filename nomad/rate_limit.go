@@ -0,0 +1,124 @@
+package nomad
+
+import (
+	"math"
+	"sync"
+
+	metrics "github.com/armon/go-metrics"
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// rateLimitClassRead and rateLimitClassWrite are the endpoint classes
+	// that RPC rate limits are configured for.
+	rateLimitClassRead  = "read"
+	rateLimitClassWrite = "write"
+
+	// rateLimiterCacheSize is the number of per token limiters to keep. The
+	// least recently used limiters are evicted, which resets the token's
+	// bucket to full.
+	rateLimiterCacheSize = 4096
+)
+
+// rpcRateLimit is the rate and burst allowed for a single endpoint class.
+type rpcRateLimit struct {
+	limit rate.Limit
+	burst int
+}
+
+// rpcRateLimiter limits the rate of RPCs per ACL token and endpoint class.
+type rpcRateLimiter struct {
+	limits map[string]rpcRateLimit
+
+	// limiters holds a *rate.Limiter per class and token accessor
+	limiters *lru.Cache
+	l        sync.Mutex
+}
+
+// newRPCRateLimiter returns a rate limiter for the configured limits, or nil
+// if no endpoint class is limited.
+func newRPCRateLimiter(config *Config) (*rpcRateLimiter, error) {
+	limits := make(map[string]rpcRateLimit)
+	add := func(class string, limit float64, burst int) {
+		if limit <= 0 {
+			return
+		}
+		if burst <= 0 {
+			burst = int(math.Ceil(limit))
+		}
+		limits[class] = rpcRateLimit{limit: rate.Limit(limit), burst: burst}
+	}
+	add(rateLimitClassRead, config.RPCReadRateLimit, config.RPCReadRateBurst)
+	add(rateLimitClassWrite, config.RPCWriteRateLimit, config.RPCWriteRateBurst)
+
+	if len(limits) == 0 {
+		return nil, nil
+	}
+
+	limiters, err := lru.New(rateLimiterCacheSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rpcRateLimiter{
+		limits:   limits,
+		limiters: limiters,
+	}, nil
+}
+
+// allow returns whether a request of the class made with the token accessor
+// is within its rate.
+func (r *rpcRateLimiter) allow(class, accessor string) bool {
+	limit, ok := r.limits[class]
+	if !ok {
+		return true
+	}
+
+	key := class + "/" + accessor
+
+	r.l.Lock()
+	var limiter *rate.Limiter
+	if raw, ok := r.limiters.Get(key); ok {
+		limiter = raw.(*rate.Limiter)
+	} else {
+		limiter = rate.NewLimiter(limit.limit, limit.burst)
+		r.limiters.Add(key, limiter)
+	}
+	r.l.Unlock()
+
+	return limiter.Allow()
+}
+
+// rateLimit returns ErrTooManyRequests if the ACL token of the request has
+// exceeded the rate of the endpoint class of the RPC. Only requests made with
+// an ACL token are limited; requests without one, as made by clients, or with
+// the leader's token are always allowed.
+func (s *Server) rateLimit(info structs.RPCInfo) error {
+	if s.rpcRateLimiter == nil {
+		return nil
+	}
+
+	secretID := info.RequestToken()
+	if secretID == "" {
+		return nil
+	}
+
+	token, err := s.fsm.State().ACLTokenBySecretID(nil, secretID)
+	if err != nil || token == nil {
+		return nil
+	}
+
+	class := rateLimitClassWrite
+	if info.IsRead() {
+		class = rateLimitClassRead
+	}
+
+	if s.rpcRateLimiter.allow(class, token.AccessorID) {
+		return nil
+	}
+
+	metrics.IncrCounter([]string{"nomad", "rpc", "rate_limited", class}, 1)
+	return structs.ErrTooManyRequests
+}
@@ -0,0 +1,90 @@
+package nomad
+
+import (
+	"testing"
+
+	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRPCRateLimiter_Disabled(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	limiter, err := newRPCRateLimiter(DefaultConfig())
+	assert.Nil(err)
+	assert.Nil(limiter)
+}
+
+func TestRPCRateLimiter_Allow(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	config := DefaultConfig()
+	config.RPCWriteRateLimit = 0.001
+	config.RPCWriteRateBurst = 2
+	limiter, err := newRPCRateLimiter(config)
+	assert.Nil(err)
+	assert.NotNil(limiter)
+
+	// The burst is allowed, then the token is limited
+	assert.True(limiter.allow(rateLimitClassWrite, "a"))
+	assert.True(limiter.allow(rateLimitClassWrite, "a"))
+	assert.False(limiter.allow(rateLimitClassWrite, "a"))
+
+	// Other tokens have their own bucket
+	assert.True(limiter.allow(rateLimitClassWrite, "b"))
+
+	// Reads are not limited
+	for i := 0; i < 10; i++ {
+		assert.True(limiter.allow(rateLimitClassRead, "a"))
+	}
+}
+
+func TestRPCRateLimiter_Endpoint(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	s1, root := testACLServer(t, func(c *Config) {
+		c.RPCReadRateLimit = 0.001
+		c.RPCReadRateBurst = 1
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+
+	// Create a token to be limited
+	token := mock.ACLManagementToken()
+	assert.Nil(s1.fsm.State().UpsertACLTokens(1000, []*structs.ACLToken{token}))
+
+	get := &structs.JobListRequest{
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			Namespace: structs.DefaultNamespace,
+			AuthToken: token.SecretID,
+		},
+	}
+
+	// The first request uses the burst
+	var resp structs.JobListResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.List", get, &resp))
+
+	// The second is rejected
+	err := msgpackrpc.CallWithCodec(codec, "Job.List", get, &resp)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), structs.ErrTooManyRequests.Error())
+
+	// Another token is unaffected
+	get.AuthToken = root.SecretID
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.List", get, &resp))
+
+	// Requests without a token are not limited, only denied
+	get.AuthToken = ""
+	for i := 0; i < 3; i++ {
+		err := msgpackrpc.CallWithCodec(codec, "Job.List", get, &resp)
+		assert.NotNil(err)
+		assert.Contains(err.Error(), structs.ErrPermissionDenied.Error())
+	}
+}
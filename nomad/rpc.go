@@ -8,6 +8,7 @@ import (
 	"math/rand"
 	"net"
 	"net/rpc"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,6 +29,7 @@ const (
 	rpcRaft              = 0x02
 	rpcMultiplex         = 0x03
 	rpcTLS               = 0x04
+	rpcSnappy            = 0x05
 )
 
 const (
@@ -142,6 +144,11 @@ func (s *Server) handleConn(ctx context.Context, conn net.Conn, isTLS bool) {
 		conn = tls.Server(conn, s.rpcTLS)
 		s.handleConn(ctx, conn, true)
 
+	case rpcSnappy:
+		metrics.IncrCounter([]string{"nomad", "rpc", "compressed_conn"}, 1)
+		conn = newSnappyConn(conn)
+		s.handleConn(ctx, conn, isTLS)
+
 	default:
 		s.logger.Printf("[ERR] nomad.rpc: unrecognized RPC byte: %v", buf[0])
 		conn.Close()
@@ -211,6 +218,9 @@ func (s *Server) forward(method string, info structs.RPCInfo, args interface{},
 
 	// Check if we can allow a stale read
 	if info.IsRead() && info.AllowStaleRead() {
+		if err := s.rateLimit(info); err != nil {
+			return true, err
+		}
 		return false, nil
 	}
 
@@ -218,8 +228,12 @@ CHECK_LEADER:
 	// Find the leader
 	isLeader, remoteServer := s.getLeader()
 
-	// Handle the case we are the leader
+	// Handle the case we are the leader. Requests are rate limited by the
+	// server that handles them so forwarded requests are only counted once.
 	if isLeader {
+		if err := s.rateLimit(info); err != nil {
+			return true, err
+		}
 		return false, nil
 	}
 
@@ -308,8 +322,13 @@ func (s *Server) raftApplyFuture(t structs.MessageType, msg interface{}) (raft.A
 		return nil, fmt.Errorf("Failed to encode request: %v", err)
 	}
 
+	// Track the size of entries so operators can find the largest writers
+	n := len(buf)
+	metrics.AddSampleWithLabels([]string{"nomad", "raft", "apply", "entry_size"}, float32(n),
+		[]metrics.Label{{Name: "type", Value: strconv.Itoa(int(t))}})
+
 	// Warn if the command is very large
-	if n := len(buf); n > raftWarnSize {
+	if n > raftWarnSize {
 		s.logger.Printf("[WARN] nomad: Attempting to apply large raft entry (type %d) (%d bytes)", t, n)
 	}
 
@@ -365,6 +384,11 @@ func (s *Server) blockingRPC(opts *blockingOptions) error {
 	var cancel context.CancelFunc
 	var state *state.StateStore
 
+	// Historical reads are served once from the retained state history
+	if opts.queryOpts.IndexAt != 0 {
+		return s.historicalQuery(opts)
+	}
+
 	// Fast path non-blocking
 	if opts.queryOpts.MinQueryIndex == 0 {
 		goto RUN_QUERY
@@ -0,0 +1,45 @@
+package nomad
+
+import (
+	"net"
+	"sync"
+
+	"github.com/golang/snappy"
+)
+
+// snappyConn compresses the data sent over a connection using the snappy
+// framing format. The data of each write is flushed so that RPCs aren't held
+// back waiting for more data to compress.
+type snappyConn struct {
+	net.Conn
+
+	r *snappy.Reader
+
+	w     *snappy.Writer
+	wLock sync.Mutex
+}
+
+// newSnappyConn wraps the connection so that the data sent and received over
+// it is compressed.
+func newSnappyConn(conn net.Conn) net.Conn {
+	return &snappyConn{
+		Conn: conn,
+		r:    snappy.NewReader(conn),
+		w:    snappy.NewBufferedWriter(conn),
+	}
+}
+
+func (c *snappyConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
+
+func (c *snappyConn) Write(b []byte) (int, error) {
+	c.wLock.Lock()
+	defer c.wLock.Unlock()
+
+	n, err := c.w.Write(b)
+	if err != nil {
+		return n, err
+	}
+	return n, c.w.Flush()
+}
@@ -1,6 +1,7 @@
 package nomad
 
 import (
+	"fmt"
 	"net"
 	"net/rpc"
 	"os"
@@ -171,3 +172,33 @@ func TestRPC_PlaintextRPCFailsWhenNotInUpgradeMode(t *testing.T) {
 	err := msgpackrpc.CallWithCodec(codec, "Node.Register", req, &resp)
 	assert.NotNil(err)
 }
+
+func TestRPC_Compression(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	testutil.WaitForLeader(t, s1.RPC)
+
+	pool := NewPool(s1.config.LogOutput, time.Minute, 2, nil)
+	defer pool.Shutdown()
+	pool.SetCompression(true)
+
+	// Register a node with a large fingerprint over the compressed connection
+	node := mock.Node()
+	for i := 0; i < 1000; i++ {
+		node.Attributes[fmt.Sprintf("unique.device.%d", i)] = "fpga"
+	}
+	req := &structs.NodeRegisterRequest{
+		Node:         node,
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.NodeUpdateResponse
+	err := pool.RPC("global", s1.config.RPCAddr, 1, "Node.Register", req, &resp)
+	assert.Nil(err)
+
+	out, err := s1.fsm.State().NodeByID(nil, node.ID)
+	assert.Nil(err)
+	assert.Len(out.Attributes, len(node.Attributes))
+}
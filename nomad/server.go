@@ -29,6 +29,7 @@ import (
 	"github.com/hashicorp/nomad/nomad/state"
 	"github.com/hashicorp/nomad/nomad/structs"
 	"github.com/hashicorp/nomad/nomad/structs/config"
+	"github.com/hashicorp/nomad/scheduler"
 	"github.com/hashicorp/raft"
 	raftboltdb "github.com/hashicorp/raft-boltdb"
 	"github.com/hashicorp/serf/serf"
@@ -167,15 +168,26 @@ type Server struct {
 	// consulCatalog is used for discovering other Nomad Servers via Consul
 	consulCatalog consul.CatalogAPI
 
+	// consulKV is used to verify the Consul keys jobs depend on
+	consulKV consul.KVAPI
+
 	// vault is the client for communicating with Vault.
 	vault VaultClient
 
+	// healthErrors tracks the last error of each subsystem checked by
+	// SubsystemHealth
+	healthErrors *healthErrors
+
 	// Worker used for processing
 	workers []*Worker
 
 	// aclCache is used to maintain the parsed ACL objects
 	aclCache *lru.TwoQueueCache
 
+	// oidcRequests tracks the OIDC flows of the auth methods that were
+	// started by users.
+	oidcRequests *oidcRequests
+
 	// leaderAcl is the management ACL token that is valid when resolved by the
 	// current leader.
 	leaderAcl     string
@@ -185,6 +197,30 @@ type Server struct {
 	// Nomad router.
 	statsFetcher *StatsFetcher
 
+	// stateHistory retains past snapshots of the state store to serve
+	// historical reads. It is nil if state history is disabled.
+	stateHistory *stateHistory
+
+	// eventBroker retains the recent events of the state store for event
+	// stream subscribers. It is nil if the event stream is disabled.
+	eventBroker *eventBroker
+
+	// allocArchive is the store terminal allocations are archived to before
+	// they are garbage collected. It is nil if the archive is disabled.
+	allocArchive allocArchiveStore
+
+	// rpcRateLimiter limits the rate of RPCs per ACL token. It is nil if
+	// rate limiting is disabled.
+	rpcRateLimiter *rpcRateLimiter
+
+	// keyringLock serializes the updates of the keyring variables are
+	// encrypted with
+	keyringLock sync.Mutex
+
+	// keyringKEK is the private X25519 key-encryption key the root keys of
+	// the keyring are wrapped with for this server
+	keyringKEK [32]byte
+
 	// EnterpriseState is used to fill in state for Pro/Ent builds
 	EnterpriseState
 
@@ -210,16 +246,34 @@ type endpoints struct {
 	Operator   *Operator
 	ACL        *ACL
 	Enterprise *EnterpriseEndpoints
+
+	ServiceDiscovery *ServiceDiscovery
+	Quarantine       *Quarantine
+	NetworkPolicy    *NetworkPolicy
+	Event            *Event
+	Variables        *Variables
 }
 
 // NewServer is used to construct a new Nomad server from the
 // configuration, potentially returning an error
-func NewServer(config *Config, consulCatalog consul.CatalogAPI, logger *log.Logger) (*Server, error) {
+func NewServer(config *Config, consulCatalog consul.CatalogAPI, consulKV consul.KVAPI, logger *log.Logger) (*Server, error) {
 	// Check the protocol version
 	if err := config.CheckVersion(); err != nil {
 		return nil, err
 	}
 
+	// Enable the scorer extensions of the schedulers
+	if err := scheduler.ConfigureScorers(config.Scorers); err != nil {
+		return nil, err
+	}
+
+	// Validate the node hooks
+	for _, hook := range config.NodeHooks {
+		if err := hook.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid node hook %q: %v", hook.Name, err)
+		}
+	}
+
 	// Create an eval broker
 	evalBroker, err := NewEvalBroker(
 		config.EvalNackTimeout,
@@ -252,23 +306,50 @@ func NewServer(config *Config, consulCatalog consul.CatalogAPI, logger *log.Logg
 		return nil, err
 	}
 
+	// Create the RPC rate limiter
+	rpcRateLimiter, err := newRPCRateLimiter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the allocation archive
+	var allocArchive allocArchiveStore
+	if config.AllocArchiveConfig.IsEnabled() {
+		allocArchive, err = newAllocArchiveStore(config.AllocArchiveConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to setup allocation archive: %v", err)
+		}
+	}
+
+	// Load the key-encryption key the keyring is wrapped with
+	keyringKEK, err := loadKeyringKEK(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load keyring key-encryption key: %v", err)
+	}
+
 	// Create the server
 	s := &Server{
-		config:        config,
-		consulCatalog: consulCatalog,
-		connPool:      NewPool(config.LogOutput, serverRPCCache, serverMaxStreams, tlsWrap),
-		logger:        logger,
-		rpcServer:     rpc.NewServer(),
-		peers:         make(map[string][]*serverParts),
-		localPeers:    make(map[raft.ServerAddress]*serverParts),
-		reconcileCh:   make(chan serf.Member, 32),
-		eventCh:       make(chan serf.Event, 256),
-		evalBroker:    evalBroker,
-		blockedEvals:  blockedEvals,
-		planQueue:     planQueue,
-		rpcTLS:        incomingTLS,
-		aclCache:      aclCache,
-		shutdownCh:    make(chan struct{}),
+		config:         config,
+		consulCatalog:  consulCatalog,
+		consulKV:       consulKV,
+		connPool:       NewPool(config.LogOutput, serverRPCCache, serverMaxStreams, tlsWrap),
+		logger:         logger,
+		rpcServer:      rpc.NewServer(),
+		peers:          make(map[string][]*serverParts),
+		localPeers:     make(map[raft.ServerAddress]*serverParts),
+		reconcileCh:    make(chan serf.Member, 32),
+		eventCh:        make(chan serf.Event, 256),
+		evalBroker:     evalBroker,
+		blockedEvals:   blockedEvals,
+		planQueue:      planQueue,
+		rpcTLS:         incomingTLS,
+		aclCache:       aclCache,
+		oidcRequests:   newOIDCRequests(),
+		rpcRateLimiter: rpcRateLimiter,
+		allocArchive:   allocArchive,
+		keyringKEK:     keyringKEK,
+		healthErrors:   newHealthErrors(),
+		shutdownCh:     make(chan struct{}),
 	}
 
 	// Create the periodic dispatcher for launching periodic jobs.
@@ -352,6 +433,27 @@ func NewServer(config *Config, consulCatalog consul.CatalogAPI, logger *log.Logg
 	// Emit metrics
 	go s.heartbeatStats()
 
+	// Retain the state history for historical reads
+	if config.StateHistoryRetention > 0 && config.StateHistoryInterval > 0 {
+		s.stateHistory = newStateHistory(config.StateHistoryRetention)
+		go s.retainStateHistory()
+	}
+
+	// Publish the changes of the state store to the event stream
+	if config.EventBufferSize > 0 {
+		var diskLog *eventLog
+		if config.EventDiskBufferSize > 0 && !config.DevMode {
+			diskLog, err = newEventLog(filepath.Join(config.DataDir, eventLogPath), config.EventDiskBufferSize)
+			if err != nil {
+				s.Shutdown()
+				s.logger.Printf("[ERR] nomad: failed to open event log: %s", err)
+				return nil, fmt.Errorf("Failed to open event log: %v", err)
+			}
+		}
+		s.eventBroker = newEventBroker(config.EventBufferSize, diskLog)
+		go s.publishEvents()
+	}
+
 	// Start enterprise background workers
 	s.startEnterpriseBackground()
 
@@ -498,6 +600,13 @@ func (s *Server) Shutdown() error {
 		s.vault.Stop()
 	}
 
+	// Close the event log
+	if s.eventBroker != nil {
+		if err := s.eventBroker.close(); err != nil {
+			s.logger.Printf("[WARN] nomad: Error closing event log: %s", err)
+		}
+	}
+
 	return nil
 }
 
@@ -869,6 +978,11 @@ func (s *Server) setupRPC(tlsWrap tlsutil.RegionWrapper) error {
 	s.endpoints.Status = &Status{s}
 	s.endpoints.System = &System{s}
 	s.endpoints.Search = &Search{s}
+	s.endpoints.ServiceDiscovery = &ServiceDiscovery{s}
+	s.endpoints.Quarantine = &Quarantine{s}
+	s.endpoints.NetworkPolicy = &NetworkPolicy{s}
+	s.endpoints.Event = &Event{s}
+	s.endpoints.Variables = &Variables{s}
 	s.endpoints.Enterprise = NewEnterpriseEndpoints(s)
 
 	// Register the handlers
@@ -885,6 +999,11 @@ func (s *Server) setupRPC(tlsWrap tlsutil.RegionWrapper) error {
 	s.rpcServer.Register(s.endpoints.Status)
 	s.rpcServer.Register(s.endpoints.System)
 	s.rpcServer.Register(s.endpoints.Search)
+	s.rpcServer.Register(s.endpoints.ServiceDiscovery)
+	s.rpcServer.Register(s.endpoints.Quarantine)
+	s.rpcServer.Register(s.endpoints.NetworkPolicy)
+	s.rpcServer.Register(s.endpoints.Event)
+	s.rpcServer.Register(s.endpoints.Variables)
 	s.endpoints.Enterprise.Register(s)
 
 	listener, err := s.createRPCListener()
@@ -1095,6 +1214,7 @@ func (s *Server) setupSerf(conf *serf.Config, ch chan serf.Event, path string) (
 	conf.Tags["id"] = s.config.NodeID
 	conf.Tags["rpc_addr"] = s.rpcAdvertise.(*net.TCPAddr).IP.String()
 	conf.Tags["port"] = fmt.Sprintf("%d", s.rpcAdvertise.(*net.TCPAddr).Port)
+	conf.Tags[keyringKEKTag] = keyringKEKID(s.keyringKEK)
 	if s.config.Bootstrap || (s.config.DevMode && !s.config.DevDisableBootstrap) {
 		conf.Tags["bootstrap"] = "1"
 	}
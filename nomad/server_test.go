@@ -112,7 +112,7 @@ func testServer(t *testing.T, cb func(*Config)) *Server {
 		config.SerfConfig.MemberlistConfig.BindPort = ports[1]
 
 		// Create server
-		server, err := NewServer(config, catalog, logger)
+		server, err := NewServer(config, catalog, consul.NewMockKV(), logger)
 		if err == nil {
 			return server
 		} else if i == 0 {
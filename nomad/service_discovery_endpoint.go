@@ -0,0 +1,273 @@
+package nomad
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	metrics "github.com/armon/go-metrics"
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// ServiceDiscovery endpoint is used to expose the services of running
+// allocations to external service discovery systems
+type ServiceDiscovery struct {
+	srv *Server
+}
+
+// Prometheus returns a target group for every service of the running
+// allocations in the namespace, in the Prometheus HTTP SD format.
+func (s *ServiceDiscovery) Prometheus(args *structs.PrometheusTargetsRequest,
+	reply *structs.PrometheusTargetsResponse) error {
+	if done, err := s.srv.forward("ServiceDiscovery.Prometheus", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "service_discovery", "prometheus"}, time.Now())
+
+	// Check namespace read-job permissions
+	if aclObj, err := s.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityReadJob) {
+		return structs.ErrPermissionDenied
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			iter, err := state.AllocsByNamespace(ws, args.RequestNamespace())
+			if err != nil {
+				return err
+			}
+
+			var groups []*structs.PrometheusTargetGroup
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				alloc := raw.(*structs.Allocation)
+				groups = append(groups, prometheusTargetGroups(alloc)...)
+			}
+			reply.TargetGroups = groups
+
+			// Use the last index that affected the allocs table
+			index, err := state.Index("allocs")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			s.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return s.srv.blockingRPC(&opts)
+}
+
+// Instances is used by clients to look up the instances of the services
+// running in the namespace of an allocation on the node, to render the
+// templates of its tasks
+func (s *ServiceDiscovery) Instances(args *structs.ServiceInstancesRequest,
+	reply *structs.ServiceInstancesResponse) error {
+	if done, err := s.srv.forward("ServiceDiscovery.Instances", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "service_discovery", "instances"}, time.Now())
+
+	// Clients authenticate using their node secret
+	node, err := nodeBySecret(s.srv.State(), args.NodeID, args.SecretID)
+	if err != nil {
+		return err
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			alloc, err := taskAlloc(ws, state, node, args.AllocID, "")
+			if err != nil {
+				return err
+			}
+
+			iter, err := state.AllocsByNamespace(ws, alloc.Namespace)
+			if err != nil {
+				return err
+			}
+
+			var instances []*structs.ServiceInstance
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				instances = append(instances, serviceInstances(raw.(*structs.Allocation), args.Service)...)
+			}
+
+			// Sort the instances so that templates render them in a stable
+			// order
+			sort.Slice(instances, func(i, j int) bool {
+				a, b := instances[i], instances[j]
+				if a.Name != b.Name {
+					return a.Name < b.Name
+				}
+				if a.Address != b.Address {
+					return a.Address < b.Address
+				}
+				if a.Port != b.Port {
+					return a.Port < b.Port
+				}
+				return a.AllocID < b.AllocID
+			})
+			reply.Instances = instances
+
+			// Use the last index that affected the allocs table
+			index, err := state.Index("allocs")
+			if err != nil {
+				return err
+			}
+			reply.Index = index
+
+			// Set the query response
+			s.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return s.srv.blockingRPC(&opts)
+}
+
+// serviceInstances returns the instances of the services of a running
+// allocation whose port can be resolved to a host address. Only the instances
+// of the named service are returned unless the name is empty.
+func serviceInstances(alloc *structs.Allocation, name string) []*structs.ServiceInstance {
+	if alloc.Job == nil || alloc.TerminalStatus() || alloc.ClientStatus != structs.AllocClientStatusRunning {
+		return nil
+	}
+	tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+	if tg == nil {
+		return nil
+	}
+
+	var instances []*structs.ServiceInstance
+	for _, task := range tg.Tasks {
+		resources, ok := alloc.TaskResources[task.Name]
+		if !ok {
+			continue
+		}
+
+		for _, service := range task.Services {
+			if name != "" && service.Name != name {
+				continue
+			}
+			ip, port, ok := serviceAddress(service, resources)
+			if !ok {
+				continue
+			}
+
+			instances = append(instances, &structs.ServiceInstance{
+				Name:      service.Name,
+				Namespace: alloc.Namespace,
+				JobID:     alloc.JobID,
+				TaskGroup: alloc.TaskGroup,
+				Task:      task.Name,
+				AllocID:   alloc.ID,
+				NodeID:    alloc.NodeID,
+				Address:   ip,
+				Port:      port,
+				Tags:      service.Tags,
+			})
+		}
+	}
+	return instances
+}
+
+// prometheusTargetGroups returns a target group for each service of a running
+// allocation whose port can be resolved to a host address.
+func prometheusTargetGroups(alloc *structs.Allocation) []*structs.PrometheusTargetGroup {
+	if alloc.Job == nil || alloc.TerminalStatus() || alloc.ClientStatus != structs.AllocClientStatusRunning {
+		return nil
+	}
+	tg := alloc.Job.LookupTaskGroup(alloc.TaskGroup)
+	if tg == nil {
+		return nil
+	}
+
+	var groups []*structs.PrometheusTargetGroup
+	for _, task := range tg.Tasks {
+		resources, ok := alloc.TaskResources[task.Name]
+		if !ok {
+			continue
+		}
+
+		for _, service := range task.Services {
+			target := serviceTarget(service, resources)
+			if target == "" {
+				continue
+			}
+
+			groups = append(groups, &structs.PrometheusTargetGroup{
+				Targets: []string{target},
+				Labels: map[string]string{
+					"namespace":                   alloc.Namespace,
+					"job":                         alloc.JobID,
+					"task_group":                  alloc.TaskGroup,
+					"task":                        task.Name,
+					"alloc_id":                    alloc.ID,
+					"__meta_nomad_node_id":        alloc.NodeID,
+					"__meta_nomad_service":        service.Name,
+					"__meta_nomad_service_source": "consul",
+					"__meta_nomad_tags":           prometheusTags(service.Tags),
+				},
+			})
+		}
+	}
+	return groups
+}
+
+// serviceTarget resolves the host:port a service is reachable at using the
+// networks assigned to the task. An empty string is returned if the port label
+// does not match an assigned port.
+func serviceTarget(service *structs.Service, resources *structs.Resources) string {
+	ip, port, ok := serviceAddress(service, resources)
+	if !ok {
+		return ""
+	}
+	return net.JoinHostPort(ip, strconv.Itoa(port))
+}
+
+// serviceAddress resolves the address and port a service is reachable at
+// using the networks assigned to the task.
+func serviceAddress(service *structs.Service, resources *structs.Resources) (string, int, bool) {
+	if service.PortLabel == "" || resources == nil {
+		return "", 0, false
+	}
+
+	for _, network := range resources.Networks {
+		if port, ok := network.PortLabels()[service.PortLabel]; ok {
+			return network.IP, port, true
+		}
+	}
+
+	// Numeric port labels are used as is with the first network's address
+	if port, err := strconv.Atoi(service.PortLabel); err == nil && len(resources.Networks) > 0 {
+		return resources.Networks[0].IP, port, true
+	}
+	return "", 0, false
+}
+
+// prometheusTags joins tags following the convention of the Prometheus Consul
+// SD, with leading and trailing separators so tags can be matched with
+// regular expressions such as ".*,http,.*".
+func prometheusTags(tags []string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(",%s,", strings.Join(tags, ","))
+}
@@ -0,0 +1,114 @@
+package nomad
+
+import (
+	"testing"
+
+	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceDiscoveryEndpoint_Prometheus(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Create a running and a pending allocation
+	running := mock.Alloc()
+	running.ClientStatus = structs.AllocClientStatusRunning
+	running.Job.Canonicalize()
+	pending := mock.Alloc()
+
+	state := s1.fsm.State()
+	assert.Nil(state.UpsertJobSummary(998, mock.JobSummary(running.JobID)))
+	assert.Nil(state.UpsertJobSummary(999, mock.JobSummary(pending.JobID)))
+	assert.Nil(state.UpsertAllocs(1000, []*structs.Allocation{running, pending}))
+
+	get := &structs.PrometheusTargetsRequest{
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			Namespace: structs.DefaultNamespace,
+		},
+	}
+	var resp structs.PrometheusTargetsResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "ServiceDiscovery.Prometheus", get, &resp))
+	assert.EqualValues(1000, resp.Index)
+
+	// Only the running allocation's services are targets
+	if assert.Len(resp.TargetGroups, 2) {
+		group := resp.TargetGroups[0]
+		assert.Equal([]string{"192.168.0.100:9876"}, group.Targets)
+		assert.Equal(running.ID, group.Labels["alloc_id"])
+		assert.Equal(running.JobID, group.Labels["job"])
+		assert.Equal(structs.DefaultNamespace, group.Labels["namespace"])
+		assert.Equal("web-frontend", group.Labels["__meta_nomad_service"])
+
+		group = resp.TargetGroups[1]
+		assert.Equal([]string{"192.168.0.100:5000"}, group.Targets)
+		assert.Equal(running.ID, group.Labels["alloc_id"])
+		assert.Equal("web-admin", group.Labels["__meta_nomad_service"])
+	}
+}
+
+func TestServiceDiscoveryEndpoint_Instances(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Create a node running a pending allocation, and a running allocation
+	// whose services are looked up
+	state := s1.fsm.State()
+	node := mock.Node()
+	assert.Nil(state.UpsertNode(997, node))
+
+	pending := mock.Alloc()
+	pending.NodeID = node.ID
+	running := mock.Alloc()
+	running.ClientStatus = structs.AllocClientStatusRunning
+	assert.Nil(state.UpsertJobSummary(998, mock.JobSummary(running.JobID)))
+	assert.Nil(state.UpsertJobSummary(999, mock.JobSummary(pending.JobID)))
+	assert.Nil(state.UpsertAllocs(1000, []*structs.Allocation{running, pending}))
+
+	req := &structs.ServiceInstancesRequest{
+		NodeID:       node.ID,
+		SecretID:     node.SecretID,
+		AllocID:      pending.ID,
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.ServiceInstancesResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "ServiceDiscovery.Instances", req, &resp))
+	assert.EqualValues(1000, resp.Index)
+
+	// Only the running allocation's services are returned, sorted by name
+	if assert.Len(resp.Instances, 2) {
+		assert.Equal("web-admin", resp.Instances[0].Name)
+		assert.Equal("192.168.0.100", resp.Instances[0].Address)
+		assert.Equal(5000, resp.Instances[0].Port)
+		assert.Equal(running.ID, resp.Instances[0].AllocID)
+		assert.Equal("web-frontend", resp.Instances[1].Name)
+		assert.Equal(9876, resp.Instances[1].Port)
+	}
+
+	// Services can be looked up by name
+	req.Service = "web-frontend"
+	var named structs.ServiceInstancesResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "ServiceDiscovery.Instances", req, &named))
+	if assert.Len(named.Instances, 1) {
+		assert.Equal("web-frontend", named.Instances[0].Name)
+	}
+
+	// The allocation of the request must run on the node
+	other := mock.Node()
+	assert.Nil(state.UpsertNode(1001, other))
+	req.NodeID = other.ID
+	req.SecretID = other.SecretID
+	assert.NotNil(msgpackrpc.CallWithCodec(codec, "ServiceDiscovery.Instances", req, &resp))
+}
@@ -0,0 +1,87 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// deploymentConcurrencyConfigTableSchema returns a new table schema used for
+// storing the deployment concurrency configuration
+func deploymentConcurrencyConfigTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "deployment-concurrency-config",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": {
+				Name:         "id",
+				AllowMissing: true,
+				Unique:       true,
+				Indexer: &memdb.ConditionalIndex{
+					Conditional: func(obj interface{}) (bool, error) { return true, nil },
+				},
+			},
+		},
+	}
+}
+
+// DeploymentConcurrencyConfig is used to get the current deployment
+// concurrency configuration. The configuration is nil if it was never set.
+func (s *StateStore) DeploymentConcurrencyConfig(ws memdb.WatchSet) (uint64, *structs.DeploymentConcurrencyConfig, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Get the deployment concurrency config
+	watchCh, c, err := tx.FirstWatch("deployment-concurrency-config", "id")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed deployment concurrency config lookup: %s", err)
+	}
+	ws.Add(watchCh)
+
+	config, ok := c.(*structs.DeploymentConcurrencyConfig)
+	if !ok {
+		return 0, nil, nil
+	}
+
+	return config.ModifyIndex, config, nil
+}
+
+// DeploymentConcurrencySetConfig is used to set the current deployment
+// concurrency configuration.
+func (s *StateStore) DeploymentConcurrencySetConfig(idx uint64, config *structs.DeploymentConcurrencyConfig) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	// Check for an existing config
+	existing, err := tx.First("deployment-concurrency-config", "id")
+	if err != nil {
+		return fmt.Errorf("failed deployment concurrency config lookup: %s", err)
+	}
+
+	// Set the indexes.
+	if existing != nil {
+		config.CreateIndex = existing.(*structs.DeploymentConcurrencyConfig).CreateIndex
+	} else {
+		config.CreateIndex = idx
+	}
+	config.ModifyIndex = idx
+
+	if err := tx.Insert("deployment-concurrency-config", config); err != nil {
+		return fmt.Errorf("failed updating deployment concurrency config: %s", err)
+	}
+	if err := tx.Insert("index", &IndexEntry{"deployment-concurrency-config", idx}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	tx.Commit()
+	return nil
+}
+
+// DeploymentConcurrencyConfigRestore is used to restore the deployment
+// concurrency configuration
+func (r *StateRestore) DeploymentConcurrencyConfigRestore(config *structs.DeploymentConcurrencyConfig) error {
+	if err := r.txn.Insert("deployment-concurrency-config", config); err != nil {
+		return fmt.Errorf("inserting deployment concurrency config failed: %v", err)
+	}
+	return nil
+}
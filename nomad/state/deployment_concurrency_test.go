@@ -0,0 +1,65 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestStateStore_DeploymentConcurrencyConfig(t *testing.T) {
+	s := testStateStore(t)
+
+	// The config is nil until set
+	ws := memdb.NewWatchSet()
+	idx, config, err := s.DeploymentConcurrencyConfig(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx != 0 || config != nil {
+		t.Fatalf("bad: %d %#v", idx, config)
+	}
+
+	expected := &structs.DeploymentConcurrencyConfig{
+		Limit:           10,
+		NamespaceLimits: map[string]int{"web": 3},
+	}
+	if err := s.DeploymentConcurrencySetConfig(1000, expected); err != nil {
+		t.Fatal(err)
+	}
+	if !watchFired(ws) {
+		t.Fatalf("bad")
+	}
+
+	idx, config, err = s.DeploymentConcurrencyConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx != 1000 {
+		t.Fatalf("bad: %d", idx)
+	}
+	if !reflect.DeepEqual(expected, config) {
+		t.Fatalf("bad: %#v, %#v", expected, config)
+	}
+
+	// Updating the config keeps its create index
+	if err := s.DeploymentConcurrencySetConfig(1001, &structs.DeploymentConcurrencyConfig{Limit: 5}); err != nil {
+		t.Fatal(err)
+	}
+	idx, config, err = s.DeploymentConcurrencyConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx != 1001 || config.CreateIndex != 1000 || config.Limit != 5 {
+		t.Fatalf("bad: %d %#v", idx, config)
+	}
+
+	index, err := s.Index("deployment-concurrency-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index != 1001 {
+		t.Fatalf("bad: %d", index)
+	}
+}
@@ -36,14 +36,21 @@ func init() {
 		jobTableSchema,
 		jobSummarySchema,
 		jobVersionSchema,
+		jobSubmissionSchema,
 		deploymentSchema,
 		periodicLaunchTableSchema,
 		evalTableSchema,
 		allocTableSchema,
 		vaultAccessorTableSchema,
 		aclPolicyTableSchema,
+		quarantineTableSchema,
+		networkPolicyTableSchema,
+		variablesTableSchema,
+		rootKeysTableSchema,
 		aclTokenTableSchema,
+		aclAuthMethodTableSchema,
 		autopilotConfigTableSchema,
+		deploymentConcurrencyConfigTableSchema,
 	}...)
 }
 
@@ -231,6 +238,40 @@ func jobVersionSchema() *memdb.TableSchema {
 	}
 }
 
+// jobSubmissionSchema returns the memdb schema for the job submission table.
+// The table stores the source each job version was submitted with.
+func jobSubmissionSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "job_submission",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": {
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+
+				// Use a compound index so the tuple of (Namespace, JobID,
+				// Version) is uniquely identifying
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field: "Namespace",
+						},
+
+						&memdb.StringFieldIndex{
+							Field:     "JobID",
+							Lowercase: true,
+						},
+
+						&memdb.UintFieldIndex{
+							Field: "Version",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 // jobIsGCable satisfies the ConditionalIndexFunc interface and creates an index
 // on whether a job is eligible for garbage collection.
 func jobIsGCable(obj interface{}) (bool, error) {
@@ -487,6 +528,15 @@ func allocTableSchema() *memdb.TableSchema {
 				},
 			},
 
+			// Image digest index is used to lookup allocations by the
+			// digest of the images their tasks were started from
+			"image_digest": {
+				Name:         "image_digest",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer:      &allocImageDigestIndex{},
+			},
+
 			// Eval index is used to lookup allocations by eval
 			"eval": {
 				Name:         "eval",
@@ -566,6 +616,121 @@ func aclPolicyTableSchema() *memdb.TableSchema {
 	}
 }
 
+// quarantineTableSchema returns the MemDB schema for the quarantine table.
+// This table is used to store the image digests and artifact checksums that
+// are denied cluster-wide.
+func quarantineTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "quarantine",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": {
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Digest",
+				},
+			},
+		},
+	}
+}
+
+// networkPolicyTableSchema returns the MemDB schema for the network policy
+// table. This table is used to store the policies controlling the traffic
+// between allocations.
+func networkPolicyTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "network_policy",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": {
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+
+				// Use a compound index so the tuple of (Namespace, Name) is
+				// uniquely identifying
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field: "Namespace",
+						},
+
+						&memdb.StringFieldIndex{
+							Field: "Name",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// variablesTableSchema returns the MemDB schema for the variables table.
+// This table is used to store the encrypted variables of the namespaces.
+func variablesTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "variables",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": {
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+
+				// Use a compound index so the tuple of (Namespace, Path) is
+				// uniquely identifying
+				Indexer: &memdb.CompoundIndex{
+					Indexes: []memdb.Indexer{
+						&memdb.StringFieldIndex{
+							Field: "Namespace",
+						},
+
+						&memdb.StringFieldIndex{
+							Field: "Path",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// rootKeysTableSchema returns the MemDB schema for the root keys table. This
+// table is used to store the keyring the variables are encrypted with.
+func rootKeysTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "root_keys",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": {
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "KeyID",
+				},
+			},
+		},
+	}
+}
+
+// aclAuthMethodTableSchema returns the MemDB schema for the auth methods
+// table. This table is used to store the methods users can authenticate with
+// to be issued ACL tokens.
+func aclAuthMethodTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: "acl_auth_methods",
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": {
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "Name",
+				},
+			},
+		},
+	}
+}
+
 // aclTokenTableSchema returns the MemDB schema for the tokens table.
 // This table is used to store the bearer tokens which are used to authenticate
 func aclTokenTableSchema() *memdb.TableSchema {
@@ -596,6 +761,41 @@ func aclTokenTableSchema() *memdb.TableSchema {
 					Field: "Global",
 				},
 			},
+			"alloc": {
+				Name:         "alloc",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field: "AllocID",
+				},
+			},
 		},
 	}
 }
+
+// allocImageDigestIndex indexes an allocation by the image digests reported
+// for its tasks.
+type allocImageDigestIndex struct {
+	memdb.StringSliceFieldIndex
+}
+
+func (a *allocImageDigestIndex) FromObject(obj interface{}) (bool, [][]byte, error) {
+	alloc, ok := obj.(*structs.Allocation)
+	if !ok {
+		return false, nil, fmt.Errorf("wrong type, got %t should be Allocation", obj)
+	}
+
+	var vals [][]byte
+	for _, ts := range alloc.TaskStates {
+		if ts == nil || ts.ImageDigest == "" {
+			continue
+		}
+
+		// Add the null character as a terminator
+		vals = append(vals, []byte(ts.ImageDigest+"\x00"))
+	}
+	if len(vals) == 0 {
+		return false, nil, nil
+	}
+	return true, vals, nil
+}
@@ -708,6 +708,19 @@ func (s *StateStore) UpsertJob(index uint64, job *structs.Job) error {
 	return nil
 }
 
+// UpsertJobs is used to register a set of jobs in a single transaction
+func (s *StateStore) UpsertJobs(index uint64, jobs []*structs.Job) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+	for _, job := range jobs {
+		if err := s.upsertJobImpl(index, job, false, txn); err != nil {
+			return err
+		}
+	}
+	txn.Commit()
+	return nil
+}
+
 // upsertJobImpl is the implementation for registering a job or updating a job definition
 func (s *StateStore) upsertJobImpl(index uint64, job *structs.Job, keepVersion bool, txn *memdb.Txn) error {
 	// COMPAT 0.7: Upgrade old objects that do not have namespaces
@@ -912,6 +925,9 @@ func (s *StateStore) deleteJobVersions(index uint64, job *structs.Job, txn *memd
 		if _, err = txn.DeleteAll("job_version", "id", j.Namespace, j.ID, j.Version); err != nil {
 			return fmt.Errorf("deleting job versions failed: %v", err)
 		}
+		if err := s.deleteJobSubmission(index, j.Namespace, j.ID, j.Version, txn); err != nil {
+			return err
+		}
 	}
 
 	if err := txn.Insert("index", &IndexEntry{"job_version", index}); err != nil {
@@ -972,9 +988,83 @@ func (s *StateStore) upsertJobVersion(index uint64, job *structs.Job, txn *memdb
 		return fmt.Errorf("failed to delete job %v (%d) from job_version", d.ID, d.Version)
 	}
 
+	// Delete the source the deleted version was submitted with
+	return s.deleteJobSubmission(index, d.Namespace, d.ID, d.Version, txn)
+}
+
+// UpsertJobSubmission is used to store the source a job version was submitted
+// with.
+func (s *StateStore) UpsertJobSubmission(index uint64, sub *structs.JobSubmission) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	// COMPAT 0.7: Upgrade old objects that do not have namespaces
+	if sub.Namespace == "" {
+		sub.Namespace = structs.DefaultNamespace
+	}
+	sub.CreateIndex = index
+
+	if err := txn.Insert("job_submission", sub); err != nil {
+		return fmt.Errorf("job submission insert failed: %v", err)
+	}
+	if err := txn.Insert("index", &IndexEntry{"job_submission", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Commit()
 	return nil
 }
 
+// deleteJobSubmission deletes the source of a job version, if any.
+func (s *StateStore) deleteJobSubmission(index uint64, namespace, id string, version uint64, txn *memdb.Txn) error {
+	n, err := txn.DeleteAll("job_submission", "id", namespace, id, version)
+	if err != nil {
+		return fmt.Errorf("deleting job submission failed: %v", err)
+	}
+	if n == 0 {
+		return nil
+	}
+	if err := txn.Insert("index", &IndexEntry{"job_submission", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	return nil
+}
+
+// JobSubmission returns the source a job version was submitted with, or nil
+// if it was not submitted with its source.
+func (s *StateStore) JobSubmission(ws memdb.WatchSet, namespace, id string, version uint64) (*structs.JobSubmission, error) {
+	txn := s.db.Txn(false)
+
+	// COMPAT 0.7: Upgrade old objects that do not have namespaces
+	if namespace == "" {
+		namespace = structs.DefaultNamespace
+	}
+
+	watchCh, existing, err := txn.FirstWatch("job_submission", "id", namespace, id, version)
+	if err != nil {
+		return nil, fmt.Errorf("job submission lookup failed: %v", err)
+	}
+	ws.Add(watchCh)
+
+	if existing != nil {
+		return existing.(*structs.JobSubmission), nil
+	}
+	return nil, nil
+}
+
+// JobSubmissions returns an iterator over the sources of all job versions
+func (s *StateStore) JobSubmissions(ws memdb.WatchSet) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("job_submission", "id")
+	if err != nil {
+		return nil, err
+	}
+
+	ws.Add(iter.WatchCh())
+	return iter, nil
+}
+
 // JobByID is used to lookup a job by its ID. JobByID returns the current/latest job
 // version.
 func (s *StateStore) JobByID(ws memdb.WatchSet, namespace, id string) (*structs.Job, error) {
@@ -1725,6 +1815,52 @@ func (s *StateStore) UpdateAllocsFromClient(index uint64, allocs []*structs.Allo
 	return nil
 }
 
+// StopAllocs is used to set the desired status of the allocations to stop so
+// that they are stopped by their clients and replaced by the schedulers.
+func (s *StateStore) StopAllocs(index uint64, allocIDs []string, modifyTime int64) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	for _, id := range allocIDs {
+		existing, err := txn.First("allocs", "id", id)
+		if err != nil {
+			return fmt.Errorf("alloc lookup failed: %v", err)
+		}
+		if existing == nil {
+			continue
+		}
+		exist := existing.(*structs.Allocation)
+		if exist.TerminalStatus() {
+			continue
+		}
+
+		copyAlloc := exist.Copy()
+		copyAlloc.DesiredStatus = structs.AllocDesiredStatusStop
+		copyAlloc.DesiredDescription = "alloc was stopped by an operator"
+		copyAlloc.StopReason = structs.AllocStopReasonOperatorStopped
+		copyAlloc.ModifyIndex = index
+		copyAlloc.ModifyTime = modifyTime
+
+		if err := s.updateSummaryWithAlloc(index, copyAlloc, exist, txn); err != nil {
+			return fmt.Errorf("error updating job summary: %v", err)
+		}
+		if err := s.updateEntWithAlloc(index, copyAlloc, exist, txn); err != nil {
+			return err
+		}
+		if err := txn.Insert("allocs", copyAlloc); err != nil {
+			return fmt.Errorf("alloc insert failed: %v", err)
+		}
+	}
+
+	// Update the indexes
+	if err := txn.Insert("index", &IndexEntry{"allocs", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Commit()
+	return nil
+}
+
 // nestedUpdateAllocFromClient is used to nest an update of an allocation with client status
 func (s *StateStore) nestedUpdateAllocFromClient(txn *memdb.Txn, index uint64, alloc *structs.Allocation) error {
 	// Look for existing alloc
@@ -1753,6 +1889,11 @@ func (s *StateStore) nestedUpdateAllocFromClient(txn *memdb.Txn, index uint64, a
 	copyAlloc.TaskStates = alloc.TaskStates
 	copyAlloc.DeploymentStatus = alloc.DeploymentStatus
 
+	// Keep the reason set by the servers unless the client determined one
+	if alloc.StopReason != "" {
+		copyAlloc.StopReason = alloc.StopReason
+	}
+
 	// Update the modify index
 	copyAlloc.ModifyIndex = index
 
@@ -2000,6 +2141,29 @@ func (s *StateStore) AllocsByNode(ws memdb.WatchSet, node string) ([]*structs.Al
 	return out, nil
 }
 
+// AllocsByImageDigest returns all the allocations with a task that was started
+// from an image with the given digest
+func (s *StateStore) AllocsByImageDigest(ws memdb.WatchSet, digest string) ([]*structs.Allocation, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("allocs", "image_digest", digest)
+	if err != nil {
+		return nil, err
+	}
+
+	ws.Add(iter.WatchCh())
+
+	var out []*structs.Allocation
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		out = append(out, raw.(*structs.Allocation))
+	}
+	return out, nil
+}
+
 // AllocsByNode returns all the allocations by node and terminal status
 func (s *StateStore) AllocsByNodeTerminal(ws memdb.WatchSet, node string, terminal bool) ([]*structs.Allocation, error) {
 	txn := s.db.Txn(false)
@@ -2591,6 +2755,52 @@ func (s *StateStore) UpdateDeploymentAllocHealth(index uint64, req *structs.Appl
 	return nil
 }
 
+// UpdateDeploymentCanaryAnalysis is used to append canary analysis snapshots
+// to the task groups of a deployment, dropping the oldest snapshots past
+// structs.MaxCanaryAnalysisSnapshots.
+func (s *StateStore) UpdateDeploymentCanaryAnalysis(index uint64, req *structs.ApplyDeploymentCanaryAnalysisRequest) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	// Retrieve deployment and ensure it is not terminal and is active
+	ws := memdb.NewWatchSet()
+	deployment, err := s.deploymentByIDImpl(ws, req.DeploymentID, txn)
+	if err != nil {
+		return err
+	} else if deployment == nil {
+		return fmt.Errorf("Deployment ID %q couldn't be updated as it does not exist", req.DeploymentID)
+	} else if !deployment.Active() {
+		return fmt.Errorf("Deployment %q has terminal status %q:", deployment.ID, deployment.Status)
+	}
+
+	copy := deployment.Copy()
+	copy.ModifyIndex = index
+	for group, snapshot := range req.Snapshots {
+		state, ok := copy.TaskGroups[group]
+		if !ok {
+			return fmt.Errorf("Deployment %q has no task group %q", deployment.ID, group)
+		}
+
+		state.CanaryAnalysis = append(state.CanaryAnalysis, snapshot)
+		if n := len(state.CanaryAnalysis); n > structs.MaxCanaryAnalysisSnapshots {
+			state.CanaryAnalysis = state.CanaryAnalysis[n-structs.MaxCanaryAnalysisSnapshots:]
+		}
+	}
+
+	// Insert the deployment
+	if err := txn.Insert("deployment", copy); err != nil {
+		return err
+	}
+
+	// Update the index
+	if err := txn.Insert("index", &IndexEntry{"deployment", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Commit()
+	return nil
+}
+
 // LastIndex returns the greatest index value for all indexes
 func (s *StateStore) LatestIndex() (uint64, error) {
 	indexes, err := s.Indexes()
@@ -3334,6 +3544,453 @@ func (s *StateStore) ACLPolicies(ws memdb.WatchSet) (memdb.ResultIterator, error
 	return iter, nil
 }
 
+// UpsertQuarantineEntries is used to create or update a set of quarantine
+// entries
+func (s *StateStore) UpsertQuarantineEntries(index uint64, entries []*structs.QuarantineEntry) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	for _, entry := range entries {
+		// Check if the entry already exists
+		existing, err := txn.First("quarantine", "id", entry.Digest)
+		if err != nil {
+			return fmt.Errorf("quarantine lookup failed: %v", err)
+		}
+
+		// Update all the indexes
+		if existing != nil {
+			entry.CreateIndex = existing.(*structs.QuarantineEntry).CreateIndex
+			entry.ModifyIndex = index
+		} else {
+			entry.CreateIndex = index
+			entry.ModifyIndex = index
+		}
+
+		// Update the entry
+		if err := txn.Insert("quarantine", entry); err != nil {
+			return fmt.Errorf("upserting quarantine entry failed: %v", err)
+		}
+	}
+
+	// Update the indexes table
+	if err := txn.Insert("index", &IndexEntry{"quarantine", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// DeleteQuarantineEntries deletes the quarantine entries with the given
+// digests
+func (s *StateStore) DeleteQuarantineEntries(index uint64, digests []string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	for _, digest := range digests {
+		if _, err := txn.DeleteAll("quarantine", "id", digest); err != nil {
+			return fmt.Errorf("deleting quarantine entry failed: %v", err)
+		}
+	}
+	if err := txn.Insert("index", &IndexEntry{"quarantine", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	txn.Commit()
+	return nil
+}
+
+// QuarantineEntryByDigest is used to lookup a quarantine entry by digest
+func (s *StateStore) QuarantineEntryByDigest(ws memdb.WatchSet, digest string) (*structs.QuarantineEntry, error) {
+	txn := s.db.Txn(false)
+
+	watchCh, existing, err := txn.FirstWatch("quarantine", "id", digest)
+	if err != nil {
+		return nil, fmt.Errorf("quarantine lookup failed: %v", err)
+	}
+	ws.Add(watchCh)
+
+	if existing != nil {
+		return existing.(*structs.QuarantineEntry), nil
+	}
+	return nil, nil
+}
+
+// QuarantineEntries returns an iterator over all the quarantine entries
+func (s *StateStore) QuarantineEntries(ws memdb.WatchSet) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("quarantine", "id")
+	if err != nil {
+		return nil, err
+	}
+	ws.Add(iter.WatchCh())
+	return iter, nil
+}
+
+// UpsertNetworkPolicies is used to create or update a set of network policies
+func (s *StateStore) UpsertNetworkPolicies(index uint64, policies []*structs.NetworkPolicy) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	for _, policy := range policies {
+		// Check if the policy already exists
+		existing, err := txn.First("network_policy", "id", policy.Namespace, policy.Name)
+		if err != nil {
+			return fmt.Errorf("network policy lookup failed: %v", err)
+		}
+
+		// Update all the indexes
+		if existing != nil {
+			policy.CreateIndex = existing.(*structs.NetworkPolicy).CreateIndex
+			policy.ModifyIndex = index
+		} else {
+			policy.CreateIndex = index
+			policy.ModifyIndex = index
+		}
+
+		// Update the policy
+		if err := txn.Insert("network_policy", policy); err != nil {
+			return fmt.Errorf("upserting network policy failed: %v", err)
+		}
+	}
+
+	// Update the indexes table
+	if err := txn.Insert("index", &IndexEntry{"network_policy", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// DeleteNetworkPolicies deletes the network policies of the namespace with the
+// given names
+func (s *StateStore) DeleteNetworkPolicies(index uint64, namespace string, names []string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	for _, name := range names {
+		if _, err := txn.DeleteAll("network_policy", "id", namespace, name); err != nil {
+			return fmt.Errorf("deleting network policy failed: %v", err)
+		}
+	}
+	if err := txn.Insert("index", &IndexEntry{"network_policy", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	txn.Commit()
+	return nil
+}
+
+// NetworkPolicyByName is used to lookup a network policy of a namespace by
+// name
+func (s *StateStore) NetworkPolicyByName(ws memdb.WatchSet, namespace, name string) (*structs.NetworkPolicy, error) {
+	txn := s.db.Txn(false)
+
+	watchCh, existing, err := txn.FirstWatch("network_policy", "id", namespace, name)
+	if err != nil {
+		return nil, fmt.Errorf("network policy lookup failed: %v", err)
+	}
+	ws.Add(watchCh)
+
+	if existing != nil {
+		return existing.(*structs.NetworkPolicy), nil
+	}
+	return nil, nil
+}
+
+// NetworkPoliciesByNamespace returns an iterator over the network policies of
+// a namespace
+func (s *StateStore) NetworkPoliciesByNamespace(ws memdb.WatchSet, namespace string) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("network_policy", "id_prefix", namespace, "")
+	if err != nil {
+		return nil, err
+	}
+	ws.Add(iter.WatchCh())
+	return iter, nil
+}
+
+// NetworkPolicies returns an iterator over all the network policies
+func (s *StateStore) NetworkPolicies(ws memdb.WatchSet) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("network_policy", "id")
+	if err != nil {
+		return nil, err
+	}
+	ws.Add(iter.WatchCh())
+	return iter, nil
+}
+
+// UpsertVariables is used to create or update a set of encrypted variables
+func (s *StateStore) UpsertVariables(index uint64, variables []*structs.VariableEncrypted) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	for _, v := range variables {
+		// Check if the variable already exists
+		existing, err := txn.First("variables", "id", v.Namespace, v.Path)
+		if err != nil {
+			return fmt.Errorf("variable lookup failed: %v", err)
+		}
+
+		// Update all the indexes
+		if existing != nil {
+			v.CreateIndex = existing.(*structs.VariableEncrypted).CreateIndex
+			v.ModifyIndex = index
+		} else {
+			v.CreateIndex = index
+			v.ModifyIndex = index
+		}
+
+		// Update the variable
+		if err := txn.Insert("variables", v); err != nil {
+			return fmt.Errorf("upserting variable failed: %v", err)
+		}
+	}
+
+	// Update the indexes table
+	if err := txn.Insert("index", &IndexEntry{"variables", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// DeleteVariables deletes the variables of a namespace at the given paths
+func (s *StateStore) DeleteVariables(index uint64, namespace string, paths []string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	for _, path := range paths {
+		if _, err := txn.DeleteAll("variables", "id", namespace, path); err != nil {
+			return fmt.Errorf("deleting variable failed: %v", err)
+		}
+	}
+	if err := txn.Insert("index", &IndexEntry{"variables", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	txn.Commit()
+	return nil
+}
+
+// VariableByPath is used to lookup an encrypted variable of a namespace by
+// path
+func (s *StateStore) VariableByPath(ws memdb.WatchSet, namespace, path string) (*structs.VariableEncrypted, error) {
+	txn := s.db.Txn(false)
+
+	watchCh, existing, err := txn.FirstWatch("variables", "id", namespace, path)
+	if err != nil {
+		return nil, fmt.Errorf("variable lookup failed: %v", err)
+	}
+	ws.Add(watchCh)
+
+	if existing != nil {
+		return existing.(*structs.VariableEncrypted), nil
+	}
+	return nil, nil
+}
+
+// VariablesByPrefix returns an iterator over the encrypted variables of a
+// namespace whose path starts with the prefix
+func (s *StateStore) VariablesByPrefix(ws memdb.WatchSet, namespace, prefix string) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	iter, err := txn.Get("variables", "id_prefix", namespace, prefix)
+	if err != nil {
+		return nil, err
+	}
+	ws.Add(iter.WatchCh())
+	return iter, nil
+}
+
+// Variables returns an iterator over all the encrypted variables
+func (s *StateStore) Variables(ws memdb.WatchSet) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("variables", "id")
+	if err != nil {
+		return nil, err
+	}
+	ws.Add(iter.WatchCh())
+	return iter, nil
+}
+
+// UpsertRootKeys is used to add a set of keys to the keyring
+func (s *StateStore) UpsertRootKeys(index uint64, keys []*structs.RootKey) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	for _, key := range keys {
+		// Check if the key already exists
+		existing, err := txn.First("root_keys", "id", key.KeyID)
+		if err != nil {
+			return fmt.Errorf("root key lookup failed: %v", err)
+		}
+
+		// Update all the indexes
+		if existing != nil {
+			key.CreateIndex = existing.(*structs.RootKey).CreateIndex
+			key.ModifyIndex = index
+		} else {
+			key.CreateIndex = index
+			key.ModifyIndex = index
+		}
+
+		// Update the key
+		if err := txn.Insert("root_keys", key); err != nil {
+			return fmt.Errorf("upserting root key failed: %v", err)
+		}
+	}
+
+	// Update the indexes table
+	if err := txn.Insert("index", &IndexEntry{"root_keys", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// RootKeyByID is used to lookup a key of the keyring by ID
+func (s *StateStore) RootKeyByID(ws memdb.WatchSet, id string) (*structs.RootKey, error) {
+	txn := s.db.Txn(false)
+
+	watchCh, existing, err := txn.FirstWatch("root_keys", "id", id)
+	if err != nil {
+		return nil, fmt.Errorf("root key lookup failed: %v", err)
+	}
+	ws.Add(watchCh)
+
+	if existing != nil {
+		return existing.(*structs.RootKey), nil
+	}
+	return nil, nil
+}
+
+// ActiveRootKey returns the key of the keyring new variables are encrypted
+// with, the most recently created one. Nil is returned if the keyring is
+// empty.
+func (s *StateStore) ActiveRootKey(ws memdb.WatchSet) (*structs.RootKey, error) {
+	iter, err := s.RootKeys(ws)
+	if err != nil {
+		return nil, err
+	}
+
+	var active *structs.RootKey
+	for {
+		raw := iter.Next()
+		if raw == nil {
+			break
+		}
+		key := raw.(*structs.RootKey)
+		if active == nil || key.CreateIndex > active.CreateIndex {
+			active = key
+		}
+	}
+	return active, nil
+}
+
+// RootKeys returns an iterator over all the keys of the keyring
+func (s *StateStore) RootKeys(ws memdb.WatchSet) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("root_keys", "id")
+	if err != nil {
+		return nil, err
+	}
+	ws.Add(iter.WatchCh())
+	return iter, nil
+}
+
+// UpsertACLAuthMethods is used to create or update a set of auth methods
+func (s *StateStore) UpsertACLAuthMethods(index uint64, methods []*structs.ACLAuthMethod) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	for _, method := range methods {
+		// Check if the auth method already exists
+		existing, err := txn.First("acl_auth_methods", "id", method.Name)
+		if err != nil {
+			return fmt.Errorf("auth method lookup failed: %v", err)
+		}
+
+		// Update all the indexes
+		if existing != nil {
+			method.CreateIndex = existing.(*structs.ACLAuthMethod).CreateIndex
+			method.ModifyIndex = index
+		} else {
+			method.CreateIndex = index
+			method.ModifyIndex = index
+		}
+
+		// Update the auth method
+		if err := txn.Insert("acl_auth_methods", method); err != nil {
+			return fmt.Errorf("upserting auth method failed: %v", err)
+		}
+	}
+
+	// Update the indexes table
+	if err := txn.Insert("index", &IndexEntry{"acl_auth_methods", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+
+	txn.Commit()
+	return nil
+}
+
+// DeleteACLAuthMethods deletes the auth methods with the given names
+func (s *StateStore) DeleteACLAuthMethods(index uint64, names []string) error {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	for _, name := range names {
+		if _, err := txn.DeleteAll("acl_auth_methods", "id", name); err != nil {
+			return fmt.Errorf("deleting auth method failed: %v", err)
+		}
+	}
+	if err := txn.Insert("index", &IndexEntry{"acl_auth_methods", index}); err != nil {
+		return fmt.Errorf("index update failed: %v", err)
+	}
+	txn.Commit()
+	return nil
+}
+
+// ACLAuthMethodByName is used to lookup an auth method by name
+func (s *StateStore) ACLAuthMethodByName(ws memdb.WatchSet, name string) (*structs.ACLAuthMethod, error) {
+	txn := s.db.Txn(false)
+
+	watchCh, existing, err := txn.FirstWatch("acl_auth_methods", "id", name)
+	if err != nil {
+		return nil, fmt.Errorf("auth method lookup failed: %v", err)
+	}
+	ws.Add(watchCh)
+
+	if existing != nil {
+		return existing.(*structs.ACLAuthMethod), nil
+	}
+	return nil, nil
+}
+
+// ACLAuthMethods returns an iterator over all the auth methods
+func (s *StateStore) ACLAuthMethods(ws memdb.WatchSet) (memdb.ResultIterator, error) {
+	txn := s.db.Txn(false)
+
+	// Walk the entire table
+	iter, err := txn.Get("acl_auth_methods", "id")
+	if err != nil {
+		return nil, err
+	}
+	ws.Add(iter.WatchCh())
+	return iter, nil
+}
+
 // UpsertACLTokens is used to create or update a set of ACL tokens
 func (s *StateStore) UpsertACLTokens(index uint64, tokens []*structs.ACLToken) error {
 	txn := s.db.Txn(true)
@@ -3431,6 +4088,22 @@ func (s *StateStore) ACLTokenBySecretID(ws memdb.WatchSet, secretID string) (*st
 	return nil, nil
 }
 
+// ACLTokenByAllocID is used to lookup the workload token of an allocation
+func (s *StateStore) ACLTokenByAllocID(ws memdb.WatchSet, allocID string) (*structs.ACLToken, error) {
+	txn := s.db.Txn(false)
+
+	watchCh, existing, err := txn.FirstWatch("acl_token", "alloc", allocID)
+	if err != nil {
+		return nil, fmt.Errorf("acl token lookup failed: %v", err)
+	}
+	ws.Add(watchCh)
+
+	if existing != nil {
+		return existing.(*structs.ACLToken), nil
+	}
+	return nil, nil
+}
+
 // ACLTokenByAccessorIDPrefix is used to lookup tokens by prefix
 func (s *StateStore) ACLTokenByAccessorIDPrefix(ws memdb.WatchSet, prefix string) (memdb.ResultIterator, error) {
 	txn := s.db.Txn(false)
@@ -3653,6 +4326,54 @@ func (r *StateRestore) ACLPolicyRestore(policy *structs.ACLPolicy) error {
 	return nil
 }
 
+// JobSubmissionRestore is used to restore the source of a job version
+func (r *StateRestore) JobSubmissionRestore(sub *structs.JobSubmission) error {
+	if err := r.txn.Insert("job_submission", sub); err != nil {
+		return fmt.Errorf("job submission insert failed: %v", err)
+	}
+	return nil
+}
+
+// QuarantineEntryRestore is used to restore a quarantine entry
+func (r *StateRestore) QuarantineEntryRestore(entry *structs.QuarantineEntry) error {
+	if err := r.txn.Insert("quarantine", entry); err != nil {
+		return fmt.Errorf("inserting quarantine entry failed: %v", err)
+	}
+	return nil
+}
+
+// NetworkPolicyRestore is used to restore a network policy
+func (r *StateRestore) NetworkPolicyRestore(policy *structs.NetworkPolicy) error {
+	if err := r.txn.Insert("network_policy", policy); err != nil {
+		return fmt.Errorf("inserting network policy failed: %v", err)
+	}
+	return nil
+}
+
+// VariableRestore is used to restore an encrypted variable
+func (r *StateRestore) VariableRestore(v *structs.VariableEncrypted) error {
+	if err := r.txn.Insert("variables", v); err != nil {
+		return fmt.Errorf("inserting variable failed: %v", err)
+	}
+	return nil
+}
+
+// RootKeyRestore is used to restore a key of the keyring
+func (r *StateRestore) RootKeyRestore(key *structs.RootKey) error {
+	if err := r.txn.Insert("root_keys", key); err != nil {
+		return fmt.Errorf("inserting root key failed: %v", err)
+	}
+	return nil
+}
+
+// ACLAuthMethodRestore is used to restore an auth method
+func (r *StateRestore) ACLAuthMethodRestore(method *structs.ACLAuthMethod) error {
+	if err := r.txn.Insert("acl_auth_methods", method); err != nil {
+		return fmt.Errorf("inserting auth method failed: %v", err)
+	}
+	return nil
+}
+
 // ACLTokenRestore is used to restore an ACL token
 func (r *StateRestore) ACLTokenRestore(token *structs.ACLToken) error {
 	if err := r.txn.Insert("acl_token", token); err != nil {
@@ -1320,6 +1320,63 @@ func TestStateStore_UpdateUpsertJob_JobVersion(t *testing.T) {
 	}
 }
 
+func TestStateStore_UpsertJobSubmission(t *testing.T) {
+	assert := assert.New(t)
+	state := testStateStore(t)
+
+	job := mock.Job()
+	assert.Nil(state.UpsertJob(1000, job))
+
+	sub := &structs.JobSubmission{
+		Source:    "job \"example\" {}",
+		Format:    structs.JobSubmissionFormatHCL,
+		Variables: map[string]string{"foo": "bar"},
+		Namespace: job.Namespace,
+		JobID:     job.ID,
+		Version:   job.Version,
+	}
+
+	// Create a watchset so we can test that upsert fires the watch
+	ws := memdb.NewWatchSet()
+	_, err := state.JobSubmission(ws, job.Namespace, job.ID, job.Version)
+	assert.Nil(err)
+
+	assert.Nil(state.UpsertJobSubmission(1001, sub))
+	assert.True(watchFired(ws))
+
+	ws = memdb.NewWatchSet()
+	out, err := state.JobSubmission(ws, job.Namespace, job.ID, job.Version)
+	assert.Nil(err)
+	assert.Equal(sub, out)
+	assert.EqualValues(1001, out.CreateIndex)
+
+	index, err := state.Index("job_submission")
+	assert.Nil(err)
+	assert.EqualValues(1001, index)
+
+	// Pruning the version deletes its source
+	for i := 1; i <= structs.JobTrackedVersions; i++ {
+		job = job.Copy()
+		job.Priority = i
+		assert.Nil(state.UpsertJob(uint64(1001+i), job))
+	}
+	assert.True(watchFired(ws))
+
+	out, err = state.JobSubmission(nil, job.Namespace, job.ID, 0)
+	assert.Nil(err)
+	assert.Nil(out)
+
+	// Deleting the job deletes the sources of its versions
+	sub = sub.Copy()
+	sub.Version = job.Version
+	assert.Nil(state.UpsertJobSubmission(2000, sub))
+	assert.Nil(state.DeleteJob(2001, job.Namespace, job.ID))
+
+	out, err = state.JobSubmission(nil, job.Namespace, job.ID, job.Version)
+	assert.Nil(err)
+	assert.Nil(out)
+}
+
 func TestStateStore_DeleteJob_Job(t *testing.T) {
 	state := testStateStore(t)
 	job := mock.Job()
@@ -4068,6 +4125,45 @@ func TestStateStore_AllocsByNode(t *testing.T) {
 	}
 }
 
+func TestStateStore_AllocsByImageDigest(t *testing.T) {
+	state := testStateStore(t)
+	assert := assert.New(t)
+
+	alloc1 := mock.Alloc()
+	alloc1.TaskStates = map[string]*structs.TaskState{
+		"web": {State: structs.TaskStateRunning, ImageDigest: "sha256:aaa"},
+	}
+	alloc2 := mock.Alloc()
+	alloc2.TaskStates = map[string]*structs.TaskState{
+		"web":     {State: structs.TaskStateRunning, ImageDigest: "sha256:bbb"},
+		"sidecar": {State: structs.TaskStateRunning, ImageDigest: "sha256:aaa"},
+	}
+	alloc3 := mock.Alloc()
+
+	allocs := []*structs.Allocation{alloc1, alloc2, alloc3}
+	for idx, alloc := range allocs {
+		state.UpsertJobSummary(uint64(900+idx), mock.JobSummary(alloc.JobID))
+	}
+	assert.Nil(state.UpsertAllocs(1000, allocs))
+
+	ws := memdb.NewWatchSet()
+	out, err := state.AllocsByImageDigest(ws, "sha256:aaa")
+	assert.Nil(err)
+	assert.Len(out, 2)
+
+	out, err = state.AllocsByImageDigest(ws, "sha256:bbb")
+	assert.Nil(err)
+	if assert.Len(out, 1) {
+		assert.Equal(alloc2.ID, out[0].ID)
+	}
+
+	out, err = state.AllocsByImageDigest(ws, "sha256:ccc")
+	assert.Nil(err)
+	assert.Empty(out)
+
+	assert.False(watchFired(ws))
+}
+
 func TestStateStore_AllocsByNodeTerminal(t *testing.T) {
 	state := testStateStore(t)
 	var allocs, term, nonterm []*structs.Allocation
@@ -5685,6 +5781,54 @@ func TestStateStore_UpsertDeploymentAllocHealth(t *testing.T) {
 	}
 }
 
+func TestStateStore_UpdateDeploymentCanaryAnalysis(t *testing.T) {
+	assert := assert.New(t)
+	state := testStateStore(t)
+
+	// Insert a deployment
+	d := mock.Deployment()
+	assert.Nil(state.UpsertDeployment(1, d))
+
+	// Append more snapshots than are retained
+	for i := 0; i < structs.MaxCanaryAnalysisSnapshots+1; i++ {
+		req := &structs.ApplyDeploymentCanaryAnalysisRequest{
+			DeploymentID: d.ID,
+			Snapshots: map[string]*structs.CanaryAnalysisSnapshot{
+				"web": {CPUDelta: float64(i)},
+			},
+		}
+		assert.Nil(state.UpdateDeploymentCanaryAnalysis(uint64(2+i), req))
+	}
+
+	ws := memdb.NewWatchSet()
+	out, err := state.DeploymentByID(ws, d.ID)
+	assert.Nil(err)
+	snapshots := out.TaskGroups["web"].CanaryAnalysis
+	if assert.Len(snapshots, structs.MaxCanaryAnalysisSnapshots) {
+		assert.Equal(1.0, snapshots[0].CPUDelta)
+		assert.Equal(float64(structs.MaxCanaryAnalysisSnapshots), snapshots[len(snapshots)-1].CPUDelta)
+	}
+	assert.EqualValues(structs.MaxCanaryAnalysisSnapshots+2, out.ModifyIndex)
+
+	// Unknown groups and terminal deployments are rejected
+	req := &structs.ApplyDeploymentCanaryAnalysisRequest{
+		DeploymentID: d.ID,
+		Snapshots: map[string]*structs.CanaryAnalysisSnapshot{
+			"foo": {},
+		},
+	}
+	assert.NotNil(state.UpdateDeploymentCanaryAnalysis(1000, req))
+
+	assert.Nil(state.UpdateDeploymentStatus(1001, &structs.DeploymentStatusUpdateRequest{
+		DeploymentUpdate: &structs.DeploymentStatusUpdate{
+			DeploymentID: d.ID,
+			Status:       structs.DeploymentStatusSuccessful,
+		},
+	}))
+	req.Snapshots = map[string]*structs.CanaryAnalysisSnapshot{"web": {}}
+	assert.NotNil(state.UpdateDeploymentCanaryAnalysis(1002, req))
+}
+
 func TestStateStore_UpsertVaultAccessors(t *testing.T) {
 	state := testStateStore(t)
 	a := mock.VaultAccessor()
@@ -6538,3 +6682,298 @@ func (n AllocIDSort) Less(i, j int) bool {
 func (n AllocIDSort) Swap(i, j int) {
 	n[i], n[j] = n[j], n[i]
 }
+
+func TestStateStore_UpsertQuarantineEntries(t *testing.T) {
+	state := testStateStore(t)
+	assert := assert.New(t)
+
+	image := &structs.QuarantineEntry{
+		Digest: "sha256:aaa",
+		Type:   structs.QuarantineTypeImage,
+		Reason: "CVE",
+	}
+	artifact := &structs.QuarantineEntry{
+		Digest: "md5:bbb",
+		Type:   structs.QuarantineTypeArtifact,
+	}
+
+	ws := memdb.NewWatchSet()
+	_, err := state.QuarantineEntryByDigest(ws, image.Digest)
+	assert.Nil(err)
+
+	assert.Nil(state.UpsertQuarantineEntries(1000, []*structs.QuarantineEntry{image, artifact}))
+	assert.True(watchFired(ws))
+
+	out, err := state.QuarantineEntryByDigest(nil, image.Digest)
+	assert.Nil(err)
+	assert.Equal(image, out)
+
+	// Updating an entry preserves its create index
+	update := &structs.QuarantineEntry{
+		Digest: "sha256:aaa",
+		Type:   structs.QuarantineTypeImage,
+		Reason: "CVE-2",
+	}
+	assert.Nil(state.UpsertQuarantineEntries(1001, []*structs.QuarantineEntry{update}))
+	out, err = state.QuarantineEntryByDigest(nil, image.Digest)
+	assert.Nil(err)
+	assert.EqualValues(1000, out.CreateIndex)
+	assert.EqualValues(1001, out.ModifyIndex)
+	assert.Equal("CVE-2", out.Reason)
+
+	iter, err := state.QuarantineEntries(nil)
+	assert.Nil(err)
+	count := 0
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		count++
+	}
+	assert.Equal(2, count)
+
+	// Delete an entry
+	ws = memdb.NewWatchSet()
+	_, err = state.QuarantineEntryByDigest(ws, artifact.Digest)
+	assert.Nil(err)
+	assert.Nil(state.DeleteQuarantineEntries(1002, []string{artifact.Digest}))
+	assert.True(watchFired(ws))
+
+	out, err = state.QuarantineEntryByDigest(nil, artifact.Digest)
+	assert.Nil(err)
+	assert.Nil(out)
+
+	index, err := state.Index("quarantine")
+	assert.Nil(err)
+	assert.EqualValues(1002, index)
+}
+
+func TestStateStore_UpsertNetworkPolicies(t *testing.T) {
+	state := testStateStore(t)
+	assert := assert.New(t)
+
+	web := &structs.NetworkPolicy{
+		Name:        "web",
+		Namespace:   structs.DefaultNamespace,
+		DefaultDeny: true,
+	}
+	other := &structs.NetworkPolicy{
+		Name:        "web",
+		Namespace:   "other",
+		DefaultDeny: true,
+	}
+
+	ws := memdb.NewWatchSet()
+	_, err := state.NetworkPolicyByName(ws, web.Namespace, web.Name)
+	assert.Nil(err)
+
+	assert.Nil(state.UpsertNetworkPolicies(1000, []*structs.NetworkPolicy{web, other}))
+	assert.True(watchFired(ws))
+
+	out, err := state.NetworkPolicyByName(nil, web.Namespace, web.Name)
+	assert.Nil(err)
+	assert.Equal(web, out)
+
+	// Updating a policy preserves its create index
+	update := &structs.NetworkPolicy{
+		Name:        "web",
+		Namespace:   structs.DefaultNamespace,
+		Description: "updated",
+		DefaultDeny: true,
+	}
+	assert.Nil(state.UpsertNetworkPolicies(1001, []*structs.NetworkPolicy{update}))
+	out, err = state.NetworkPolicyByName(nil, web.Namespace, web.Name)
+	assert.Nil(err)
+	assert.EqualValues(1000, out.CreateIndex)
+	assert.EqualValues(1001, out.ModifyIndex)
+	assert.Equal("updated", out.Description)
+
+	// Policies are listed by namespace
+	iter, err := state.NetworkPoliciesByNamespace(nil, "other")
+	assert.Nil(err)
+	var policies []*structs.NetworkPolicy
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		policies = append(policies, raw.(*structs.NetworkPolicy))
+	}
+	if assert.Len(policies, 1) {
+		assert.Equal(other, policies[0])
+	}
+
+	iter, err = state.NetworkPolicies(nil)
+	assert.Nil(err)
+	count := 0
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		count++
+	}
+	assert.Equal(2, count)
+
+	// Delete a policy, the policy of the same name in the other namespace is
+	// kept
+	ws = memdb.NewWatchSet()
+	_, err = state.NetworkPolicyByName(ws, other.Namespace, other.Name)
+	assert.Nil(err)
+	assert.Nil(state.DeleteNetworkPolicies(1002, "other", []string{"web"}))
+	assert.True(watchFired(ws))
+
+	out, err = state.NetworkPolicyByName(nil, other.Namespace, other.Name)
+	assert.Nil(err)
+	assert.Nil(out)
+	out, err = state.NetworkPolicyByName(nil, web.Namespace, web.Name)
+	assert.Nil(err)
+	assert.NotNil(out)
+
+	index, err := state.Index("network_policy")
+	assert.Nil(err)
+	assert.EqualValues(1002, index)
+}
+
+func TestStateStore_UpsertVariables(t *testing.T) {
+	state := testStateStore(t)
+	assert := assert.New(t)
+
+	db := &structs.VariableEncrypted{
+		Namespace: structs.DefaultNamespace,
+		Path:      "nomad/jobs/web/db",
+		KeyID:     "key",
+		Data:      []byte("a"),
+	}
+	cache := &structs.VariableEncrypted{
+		Namespace: structs.DefaultNamespace,
+		Path:      "nomad/jobs/web/cache",
+		KeyID:     "key",
+		Data:      []byte("b"),
+	}
+	other := &structs.VariableEncrypted{
+		Namespace: "other",
+		Path:      "nomad/jobs/web/db",
+		KeyID:     "key",
+		Data:      []byte("c"),
+	}
+
+	ws := memdb.NewWatchSet()
+	_, err := state.VariableByPath(ws, db.Namespace, db.Path)
+	assert.Nil(err)
+
+	assert.Nil(state.UpsertVariables(1000, []*structs.VariableEncrypted{db, cache, other}))
+	assert.True(watchFired(ws))
+
+	out, err := state.VariableByPath(nil, db.Namespace, db.Path)
+	assert.Nil(err)
+	assert.Equal(db, out)
+
+	// Updating a variable preserves its create index
+	update := &structs.VariableEncrypted{
+		Namespace: structs.DefaultNamespace,
+		Path:      "nomad/jobs/web/db",
+		KeyID:     "key",
+		Data:      []byte("d"),
+	}
+	assert.Nil(state.UpsertVariables(1001, []*structs.VariableEncrypted{update}))
+	out, err = state.VariableByPath(nil, db.Namespace, db.Path)
+	assert.Nil(err)
+	assert.EqualValues(1000, out.CreateIndex)
+	assert.EqualValues(1001, out.ModifyIndex)
+	assert.Equal([]byte("d"), out.Data)
+
+	// Variables are listed by namespace and path prefix
+	iter, err := state.VariablesByPrefix(nil, structs.DefaultNamespace, "nomad/jobs/web/c")
+	assert.Nil(err)
+	var paths []string
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		paths = append(paths, raw.(*structs.VariableEncrypted).Path)
+	}
+	assert.Equal([]string{cache.Path}, paths)
+
+	iter, err = state.VariablesByPrefix(nil, structs.DefaultNamespace, "")
+	assert.Nil(err)
+	paths = nil
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		paths = append(paths, raw.(*structs.VariableEncrypted).Path)
+	}
+	assert.Equal([]string{cache.Path, db.Path}, paths)
+
+	// Delete a variable, the variable at the same path in the other
+	// namespace is kept
+	ws = memdb.NewWatchSet()
+	_, err = state.VariableByPath(ws, other.Namespace, other.Path)
+	assert.Nil(err)
+	assert.Nil(state.DeleteVariables(1002, "other", []string{other.Path}))
+	assert.True(watchFired(ws))
+
+	out, err = state.VariableByPath(nil, other.Namespace, other.Path)
+	assert.Nil(err)
+	assert.Nil(out)
+	out, err = state.VariableByPath(nil, db.Namespace, db.Path)
+	assert.Nil(err)
+	assert.NotNil(out)
+
+	index, err := state.Index("variables")
+	assert.Nil(err)
+	assert.EqualValues(1002, index)
+}
+
+func TestStateStore_ActiveRootKey(t *testing.T) {
+	state := testStateStore(t)
+	assert := assert.New(t)
+
+	// The keyring is initially empty
+	active, err := state.ActiveRootKey(nil)
+	assert.Nil(err)
+	assert.Nil(active)
+
+	// The most recently created key is active
+	first := &structs.RootKey{KeyID: "b", WrappedKeys: map[string][]byte{"kek": []byte("first")}}
+	second := &structs.RootKey{KeyID: "a", WrappedKeys: map[string][]byte{"kek": []byte("second")}}
+	assert.Nil(state.UpsertRootKeys(1000, []*structs.RootKey{first}))
+	assert.Nil(state.UpsertRootKeys(1001, []*structs.RootKey{second}))
+
+	active, err = state.ActiveRootKey(nil)
+	assert.Nil(err)
+	assert.Equal(second, active)
+
+	out, err := state.RootKeyByID(nil, first.KeyID)
+	assert.Nil(err)
+	assert.Equal(first, out)
+}
+
+func TestStateStore_ACLAuthMethods(t *testing.T) {
+	require := require.New(t)
+	state := testStateStore(t)
+	method := &structs.ACLAuthMethod{
+		Name: "okta",
+		Type: structs.ACLAuthMethodTypeOIDC,
+		Config: &structs.ACLAuthMethodConfig{
+			OIDCDiscoveryURL:    "https://example.com",
+			OIDCClientID:        "nomad",
+			AllowedRedirectURIs: []string{"http://localhost:4649/oidc/callback"},
+		},
+	}
+
+	ws := memdb.NewWatchSet()
+	_, err := state.ACLAuthMethodByName(ws, method.Name)
+	require.NoError(err)
+
+	require.NoError(state.UpsertACLAuthMethods(1000, []*structs.ACLAuthMethod{method}))
+	require.True(watchFired(ws))
+
+	out, err := state.ACLAuthMethodByName(nil, method.Name)
+	require.NoError(err)
+	require.Equal(uint64(1000), out.CreateIndex)
+	require.Equal(uint64(1000), out.ModifyIndex)
+
+	// Updating keeps the create index
+	update := *method
+	update.MaxTokenTTL = time.Minute
+	require.NoError(state.UpsertACLAuthMethods(1001, []*structs.ACLAuthMethod{&update}))
+	out, err = state.ACLAuthMethodByName(nil, method.Name)
+	require.NoError(err)
+	require.Equal(uint64(1000), out.CreateIndex)
+	require.Equal(uint64(1001), out.ModifyIndex)
+	require.Equal(time.Minute, out.MaxTokenTTL)
+
+	index, err := state.Index("acl_auth_methods")
+	require.NoError(err)
+	require.Equal(uint64(1001), index)
+
+	require.NoError(state.DeleteACLAuthMethods(1002, []string{method.Name}))
+	out, err = state.ACLAuthMethodByName(nil, method.Name)
+	require.NoError(err)
+	require.Nil(out)
+}
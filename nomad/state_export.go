@@ -0,0 +1,366 @@
+package nomad
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/armon/go-metrics"
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/ugorji/go/codec"
+)
+
+const (
+	// stateExportSchemaVersion is the version of the format of the exported
+	// batches and markers. It is incremented on incompatible changes.
+	stateExportSchemaVersion = 1
+
+	// stateExportLatestKey is the key of the marker of the last committed
+	// batch, used to resume the export after a leader election.
+	stateExportLatestKey = "LATEST.json"
+)
+
+// StateExportBatch is a batch of changes to the exported tables between two
+// Raft indexes. A full batch holds every object of the tables, and consumers
+// should replace their copy of the tables with it.
+type StateExportBatch struct {
+	SchemaVersion int
+	Region        string
+	Full          bool
+
+	// FromIndex is exclusive and ToIndex inclusive
+	FromIndex uint64
+	ToIndex   uint64
+
+	ExportedAt time.Time
+	Tables     map[string]*StateExportTableDiff
+}
+
+// StateExportTableDiff holds the objects of a table that were created or
+// updated, and the IDs of those that were deleted.
+type StateExportTableDiff struct {
+	Upserted []interface{}
+	Deleted  []string
+}
+
+// StateExportMarker commits a batch. Batches without a marker may be partial
+// writes by a leader that lost leadership and must be ignored by consumers.
+// Each marker's FromIndex is the ToIndex of the previous one unless the batch
+// is full, so following the markers yields every change exactly once.
+type StateExportMarker struct {
+	SchemaVersion int
+	Batch         string
+	Full          bool
+	FromIndex     uint64
+	ToIndex       uint64
+}
+
+// exportTable describes how to export a table of the state store.
+type exportTable struct {
+	// list returns an iterator over the objects of the table
+	list func(s *state.StateStore) (memdb.ResultIterator, error)
+
+	// id returns the ID of an object
+	id func(raw interface{}) string
+
+	// modifyIndex returns the index an object was last modified at
+	modifyIndex func(raw interface{}) uint64
+
+	// exists returns whether an object with the same ID is in the state
+	exists func(s *state.StateStore, raw interface{}) (bool, error)
+
+	// sanitize returns the object as it is exported
+	sanitize func(raw interface{}) interface{}
+}
+
+// exportTables are the tables that may be exported
+var exportTables = map[string]*exportTable{
+	"jobs": {
+		list: func(s *state.StateStore) (memdb.ResultIterator, error) { return s.Jobs(nil) },
+		id: func(raw interface{}) string {
+			job := raw.(*structs.Job)
+			return job.Namespace + "/" + job.ID
+		},
+		modifyIndex: func(raw interface{}) uint64 { return raw.(*structs.Job).ModifyIndex },
+		exists: func(s *state.StateStore, raw interface{}) (bool, error) {
+			job := raw.(*structs.Job)
+			out, err := s.JobByID(nil, job.Namespace, job.ID)
+			return out != nil, err
+		},
+		sanitize: func(raw interface{}) interface{} { return raw },
+	},
+	"allocs": {
+		list:        func(s *state.StateStore) (memdb.ResultIterator, error) { return s.Allocs(nil) },
+		id:          func(raw interface{}) string { return raw.(*structs.Allocation).ID },
+		modifyIndex: func(raw interface{}) uint64 { return raw.(*structs.Allocation).ModifyIndex },
+		exists: func(s *state.StateStore, raw interface{}) (bool, error) {
+			out, err := s.AllocByID(nil, raw.(*structs.Allocation).ID)
+			return out != nil, err
+		},
+		sanitize: func(raw interface{}) interface{} {
+			// The job is exported by the jobs table
+			alloc := raw.(*structs.Allocation).CopySkipJob()
+			alloc.Job = nil
+			return alloc
+		},
+	},
+	"nodes": {
+		list:        func(s *state.StateStore) (memdb.ResultIterator, error) { return s.Nodes(nil) },
+		id:          func(raw interface{}) string { return raw.(*structs.Node).ID },
+		modifyIndex: func(raw interface{}) uint64 { return raw.(*structs.Node).ModifyIndex },
+		exists: func(s *state.StateStore, raw interface{}) (bool, error) {
+			out, err := s.NodeByID(nil, raw.(*structs.Node).ID)
+			return out != nil, err
+		},
+		sanitize: func(raw interface{}) interface{} {
+			// Never export the node's secret
+			node := raw.(*structs.Node).Copy()
+			node.SecretID = ""
+			return node
+		},
+	},
+	"deployments": {
+		list:        func(s *state.StateStore) (memdb.ResultIterator, error) { return s.Deployments(nil) },
+		id:          func(raw interface{}) string { return raw.(*structs.Deployment).ID },
+		modifyIndex: func(raw interface{}) uint64 { return raw.(*structs.Deployment).ModifyIndex },
+		exists: func(s *state.StateStore, raw interface{}) (bool, error) {
+			out, err := s.DeploymentByID(nil, raw.(*structs.Deployment).ID)
+			return out != nil, err
+		},
+		sanitize: func(raw interface{}) interface{} { return raw },
+	},
+}
+
+// stateExporter writes batched diffs of the state store to a sink.
+type stateExporter struct {
+	sink    stateExportSink
+	tables  []string
+	region  string
+	history *stateHistory
+	logger  *log.Logger
+
+	// prev is the last exported snapshot and prevIndex its index. prev is
+	// nil if the next batch must be diffed against prevIndex without a
+	// baseline, or be a full export if prevIndex is zero.
+	prev      *state.StateSnapshot
+	prevIndex uint64
+}
+
+// newStateExporter returns an exporter of the given tables.
+func newStateExporter(sink stateExportSink, tables []string, region string,
+	history *stateHistory, logger *log.Logger) (*stateExporter, error) {
+	for _, t := range tables {
+		if _, ok := exportTables[t]; !ok {
+			return nil, fmt.Errorf("unsupported table %q", t)
+		}
+	}
+	return &stateExporter{
+		sink:    sink,
+		tables:  tables,
+		region:  region,
+		history: history,
+		logger:  logger,
+	}, nil
+}
+
+// resume reads the marker of the last committed batch so that the export
+// continues where the previous leader stopped. The changes since are diffed
+// against the retained state history if it covers the marker, and otherwise
+// a full export is done.
+func (e *stateExporter) resume(latest uint64) error {
+	data, err := e.sink.Get(stateExportLatestKey)
+	if err != nil {
+		return fmt.Errorf("failed to read latest marker: %v", err)
+	}
+	e.prev, e.prevIndex = nil, 0
+	if data == nil {
+		return nil
+	}
+
+	var marker StateExportMarker
+	if err := codec.NewDecoderBytes(data, structs.JsonHandle).Decode(&marker); err != nil {
+		return fmt.Errorf("failed to decode latest marker: %v", err)
+	}
+	if marker.SchemaVersion != stateExportSchemaVersion || marker.ToIndex > latest {
+		// The marker is from an incompatible version or another cluster
+		return nil
+	}
+
+	// Only a snapshot taken exactly at the marker can be diffed against.
+	// An older one misses the objects created and exported after it, so
+	// their deletion would never be exported, and a full export is done
+	// instead.
+	if e.history != nil {
+		if retained, err := e.history.lookup(marker.ToIndex); err == nil && retained.index == marker.ToIndex {
+			e.prev, e.prevIndex = retained.snap, marker.ToIndex
+		}
+	}
+	return nil
+}
+
+// export writes a batch of the changes between the previous export and the
+// snapshot, and commits it with a marker. No batch is written if nothing
+// changed.
+func (e *stateExporter) export(snap *state.StateSnapshot) error {
+	index, err := snap.LatestIndex()
+	if err != nil {
+		return err
+	}
+	if e.prevIndex != 0 && index <= e.prevIndex {
+		return nil
+	}
+
+	// Without a baseline to detect deletions, export everything
+	full := e.prev == nil
+	batch := &StateExportBatch{
+		SchemaVersion: stateExportSchemaVersion,
+		Region:        e.region,
+		Full:          full,
+		ToIndex:       index,
+		ExportedAt:    time.Now().UTC(),
+		Tables:        make(map[string]*StateExportTableDiff, len(e.tables)),
+	}
+	if !full {
+		batch.FromIndex = e.prevIndex
+	}
+
+	for _, name := range e.tables {
+		diff, err := e.diffTable(exportTables[name], snap, full)
+		if err != nil {
+			return fmt.Errorf("failed to export table %q: %v", name, err)
+		}
+		batch.Tables[name] = diff
+	}
+
+	// Batches are keyed by their index range so that retries overwrite the
+	// same object
+	key := fmt.Sprintf("batches/%020d-%020d.json", batch.FromIndex, batch.ToIndex)
+	if err := e.put(key, batch); err != nil {
+		return err
+	}
+
+	marker := &StateExportMarker{
+		SchemaVersion: stateExportSchemaVersion,
+		Batch:         key,
+		Full:          full,
+		FromIndex:     batch.FromIndex,
+		ToIndex:       batch.ToIndex,
+	}
+	if err := e.put(fmt.Sprintf("markers/%020d.json", batch.ToIndex), marker); err != nil {
+		return err
+	}
+	if err := e.put(stateExportLatestKey, marker); err != nil {
+		return err
+	}
+
+	e.prev, e.prevIndex = snap, index
+	return nil
+}
+
+// diffTable returns the changes to the table since the previous export.
+func (e *stateExporter) diffTable(t *exportTable, snap *state.StateSnapshot, full bool) (*StateExportTableDiff, error) {
+	diff := &StateExportTableDiff{
+		Upserted: make([]interface{}, 0),
+		Deleted:  make([]string, 0),
+	}
+
+	iter, err := t.list(&snap.StateStore)
+	if err != nil {
+		return nil, err
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		if full || t.modifyIndex(raw) > e.prevIndex {
+			diff.Upserted = append(diff.Upserted, t.sanitize(raw))
+		}
+	}
+
+	if full {
+		return diff, nil
+	}
+
+	// Objects of the previous snapshot that no longer exist were deleted
+	iter, err = t.list(&e.prev.StateStore)
+	if err != nil {
+		return nil, err
+	}
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		exists, err := t.exists(&snap.StateStore, raw)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			diff.Deleted = append(diff.Deleted, t.id(raw))
+		}
+	}
+	return diff, nil
+}
+
+// put encodes the object as JSON and writes it to the sink
+func (e *stateExporter) put(key string, obj interface{}) error {
+	var buf bytes.Buffer
+	if err := codec.NewEncoder(&buf, structs.JsonHandle).Encode(obj); err != nil {
+		return fmt.Errorf("failed to encode %q: %v", key, err)
+	}
+	if err := e.sink.Put(key, buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write %q: %v", key, err)
+	}
+	return nil
+}
+
+// exportState periodically exports the state store while this server is the
+// leader.
+func (s *Server) exportState(stopCh chan struct{}) {
+	conf := s.config.StateExportConfig
+	sink, err := newStateExportSink(conf)
+	if err != nil {
+		s.logger.Printf("[ERR] nomad.state_export: failed to setup sink: %v", err)
+		return
+	}
+	exporter, err := newStateExporter(sink, conf.Tables, s.config.Region, s.stateHistory, s.logger)
+	if err != nil {
+		s.logger.Printf("[ERR] nomad.state_export: %v", err)
+		return
+	}
+
+	ticker := time.NewTicker(conf.Interval)
+	defer ticker.Stop()
+
+	resumed := false
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		snap, err := s.fsm.State().Snapshot()
+		if err != nil {
+			s.logger.Printf("[ERR] nomad.state_export: failed to snapshot state: %v", err)
+			continue
+		}
+
+		if !resumed {
+			latest, err := snap.LatestIndex()
+			if err != nil {
+				s.logger.Printf("[ERR] nomad.state_export: failed to determine state index: %v", err)
+				continue
+			}
+			if err := exporter.resume(latest); err != nil {
+				s.logger.Printf("[ERR] nomad.state_export: %v", err)
+				continue
+			}
+			resumed = true
+		}
+
+		start := time.Now()
+		if err := exporter.export(snap); err != nil {
+			s.logger.Printf("[ERR] nomad.state_export: failed to export state: %v", err)
+			metrics.IncrCounter([]string{"nomad", "state_export", "errors"}, 1)
+			continue
+		}
+		metrics.MeasureSince([]string{"nomad", "state_export", "export"}, start)
+	}
+}
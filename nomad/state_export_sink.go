@@ -0,0 +1,120 @@
+package nomad
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/hashicorp/nomad/nomad/structs/config"
+)
+
+// stateExportSink is the storage the state exporter writes batches and
+// markers to.
+type stateExportSink interface {
+	// Put writes the object with the given key, replacing any existing one.
+	Put(key string, data []byte) error
+
+	// Get returns the object with the given key or nil if it doesn't exist.
+	Get(key string) ([]byte, error)
+}
+
+// newStateExportSink returns the sink configured by the state export config.
+func newStateExportSink(c *config.StateExportConfig) (stateExportSink, error) {
+	switch c.Sink {
+	case "file":
+		if c.Path == "" {
+			return nil, fmt.Errorf("file sink requires a path")
+		}
+		return &fileExportSink{dir: c.Path}, nil
+	case "s3":
+		if c.Bucket == "" {
+			return nil, fmt.Errorf("s3 sink requires a bucket")
+		}
+		return newS3ExportSink(c.Bucket, c.Prefix, c.Region, c.Endpoint), nil
+	default:
+		return nil, fmt.Errorf("unsupported sink %q: must be \"file\" or \"s3\"", c.Sink)
+	}
+}
+
+// newS3ExportSink returns a sink writing to the bucket. The endpoint may be
+// set to use S3 compatible object stores.
+func newS3ExportSink(bucket, prefix, region, endpoint string) *s3ExportSink {
+	conf := &aws.Config{}
+	if region != "" {
+		conf.Region = aws.String(region)
+	}
+	if endpoint != "" {
+		conf.Endpoint = aws.String(endpoint)
+		conf.S3ForcePathStyle = aws.Bool(true)
+	}
+	return &s3ExportSink{
+		client: s3.New(session.New(conf)),
+		bucket: bucket,
+		prefix: prefix,
+	}
+}
+
+// fileExportSink writes objects as files below a directory.
+type fileExportSink struct {
+	dir string
+}
+
+func (f *fileExportSink) Put(key string, data []byte) error {
+	path := filepath.Join(f.dir, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see partial objects
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (f *fileExportSink) Get(key string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(f.dir, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// s3ExportSink writes objects to an S3 bucket.
+type s3ExportSink struct {
+	client *s3.S3
+	bucket string
+	prefix string
+}
+
+func (s *s3ExportSink) Put(key string, data []byte) error {
+	_, err := s.client.PutObject(&s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.prefix + key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String("application/json"),
+	})
+	return err
+}
+
+func (s *s3ExportSink) Get(key string) ([]byte, error) {
+	out, err := s.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.prefix + key),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NoSuchKey" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer out.Body.Close()
+	return ioutil.ReadAll(out.Body)
+}
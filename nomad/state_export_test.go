@@ -0,0 +1,152 @@
+package nomad
+
+import (
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/assert"
+	"github.com/ugorji/go/codec"
+)
+
+func readExportObject(t *testing.T, dir, key string, out interface{}) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(key)))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := codec.NewDecoderBytes(data, structs.JsonHandle).Decode(out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestStateExporter_Export(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	exporter, err := newStateExporter(&fileExportSink{dir: dir}, []string{"jobs", "nodes"}, "global", nil, logger)
+	assert.Nil(err)
+	assert.Nil(exporter.resume(0))
+
+	s := state.TestStateStore(t)
+	job1, job2 := mock.Job(), mock.Job()
+	node := mock.Node()
+	assert.Nil(s.UpsertJob(1000, job1))
+	assert.Nil(s.UpsertJob(1001, job2))
+	assert.Nil(s.UpsertNode(1002, node))
+
+	// The first batch is a full export
+	snap, err := s.Snapshot()
+	assert.Nil(err)
+	assert.Nil(exporter.export(snap))
+
+	var marker StateExportMarker
+	readExportObject(t, dir, stateExportLatestKey, &marker)
+	assert.True(marker.Full)
+	assert.EqualValues(1002, marker.ToIndex)
+
+	var batch StateExportBatch
+	readExportObject(t, dir, marker.Batch, &batch)
+	assert.Equal(stateExportSchemaVersion, batch.SchemaVersion)
+	assert.Len(batch.Tables["jobs"].Upserted, 2)
+	if assert.Len(batch.Tables["nodes"].Upserted, 1) {
+		// Node secrets are never exported
+		exported := batch.Tables["nodes"].Upserted[0].(map[interface{}]interface{})
+		assert.Equal("", exported["SecretID"])
+	}
+
+	// Nothing changed so no batch is written
+	assert.Nil(exporter.export(snap))
+
+	// The next batch only holds the changes
+	assert.Nil(s.DeleteJob(1003, job1.Namespace, job1.ID))
+	job2 = job2.Copy()
+	job2.Priority = 90
+	assert.Nil(s.UpsertJob(1004, job2))
+
+	snap, err = s.Snapshot()
+	assert.Nil(err)
+	assert.Nil(exporter.export(snap))
+
+	readExportObject(t, dir, stateExportLatestKey, &marker)
+	assert.False(marker.Full)
+	assert.EqualValues(1002, marker.FromIndex)
+	assert.EqualValues(1004, marker.ToIndex)
+
+	batch = StateExportBatch{}
+	readExportObject(t, dir, marker.Batch, &batch)
+	assert.Len(batch.Tables["jobs"].Upserted, 1)
+	assert.Equal([]string{job1.Namespace + "/" + job1.ID}, batch.Tables["jobs"].Deleted)
+	assert.Len(batch.Tables["nodes"].Upserted, 0)
+
+	// Each batch has a marker
+	var first StateExportMarker
+	readExportObject(t, dir, "markers/00000000000000001002.json", &first)
+	assert.True(first.Full)
+}
+
+func TestStateExporter_Resume(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "nomad")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	sink := &fileExportSink{dir: dir}
+
+	s := state.TestStateStore(t)
+	job := mock.Job()
+	assert.Nil(s.UpsertJob(1000, job))
+	snap, err := s.Snapshot()
+	assert.Nil(err)
+
+	exporter, err := newStateExporter(sink, []string{"jobs"}, "global", nil, logger)
+	assert.Nil(err)
+	assert.Nil(exporter.export(snap))
+
+	// A new leader with the state history resumes with a diff
+	history := newStateHistory(time.Hour)
+	history.record(time.Now(), 1000, snap)
+	exporter, err = newStateExporter(sink, []string{"jobs"}, "global", history, logger)
+	assert.Nil(err)
+	assert.Nil(exporter.resume(1000))
+	assert.EqualValues(1000, exporter.prevIndex)
+	assert.NotNil(exporter.prev)
+
+	// A retained snapshot older than the marker falls back to a full export
+	assert.Nil(s.UpsertJob(1001, mock.Job()))
+	snap2, err := s.Snapshot()
+	assert.Nil(err)
+	exporter, err = newStateExporter(sink, []string{"jobs"}, "global", nil, logger)
+	assert.Nil(err)
+	assert.Nil(exporter.resume(1000))
+	assert.Nil(exporter.export(snap2))
+	exporter, err = newStateExporter(sink, []string{"jobs"}, "global", history, logger)
+	assert.Nil(err)
+	assert.Nil(exporter.resume(1001))
+	assert.Nil(exporter.prev)
+	assert.Zero(exporter.prevIndex)
+
+	// Without the history it falls back to a full export
+	exporter, err = newStateExporter(sink, []string{"jobs"}, "global", nil, logger)
+	assert.Nil(err)
+	assert.Nil(exporter.resume(1000))
+	assert.Nil(exporter.prev)
+	assert.Zero(exporter.prevIndex)
+
+	// Unknown tables are rejected
+	_, err = newStateExporter(sink, []string{"foo"}, "global", nil, logger)
+	assert.NotNil(err)
+}
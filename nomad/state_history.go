@@ -0,0 +1,139 @@
+package nomad
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/nomad/nomad/state"
+)
+
+// retainedSnapshot is a snapshot of the state store retained to serve
+// historical reads.
+type retainedSnapshot struct {
+	index uint64
+	taken time.Time
+	snap  *state.StateSnapshot
+}
+
+// stateHistory retains periodic snapshots of the state store for a
+// configurable window so that read endpoints can be served as of a past Raft
+// index. Snapshots of the state store share their unchanged data, so the cost
+// of retaining one grows with the amount of change since it was taken.
+type stateHistory struct {
+	retention time.Duration
+
+	// snaps is ordered from oldest to newest
+	snaps []*retainedSnapshot
+	l     sync.RWMutex
+}
+
+// newStateHistory returns a state history retaining snapshots for the given
+// duration.
+func newStateHistory(retention time.Duration) *stateHistory {
+	return &stateHistory{
+		retention: retention,
+	}
+}
+
+// record retains the snapshot taken at the given index and prunes the
+// snapshots that have fallen outside of the retention window.
+func (h *stateHistory) record(now time.Time, index uint64, snap *state.StateSnapshot) {
+	h.l.Lock()
+	defer h.l.Unlock()
+
+	// A restore from a Raft snapshot can move the index backwards, in which
+	// case the retained history no longer describes this state.
+	if n := len(h.snaps); n != 0 && h.snaps[n-1].index > index {
+		h.snaps = nil
+	}
+	h.snaps = append(h.snaps, &retainedSnapshot{
+		index: index,
+		taken: now,
+		snap:  snap,
+	})
+
+	// Always keep the newest snapshot
+	cutoff := now.Add(-h.retention)
+	i := 0
+	for ; i < len(h.snaps)-1; i++ {
+		if h.snaps[i].taken.After(cutoff) {
+			break
+		}
+	}
+	h.snaps = h.snaps[i:]
+}
+
+// lookup returns the newest retained snapshot at or before the given index.
+func (h *stateHistory) lookup(index uint64) (*retainedSnapshot, error) {
+	h.l.RLock()
+	defer h.l.RUnlock()
+
+	for i := len(h.snaps) - 1; i >= 0; i-- {
+		if h.snaps[i].index <= index {
+			return h.snaps[i], nil
+		}
+	}
+	if len(h.snaps) == 0 {
+		return nil, fmt.Errorf("no state history is retained")
+	}
+	return nil, fmt.Errorf("index %d is older than the retained state history, which starts at index %d",
+		index, h.snaps[0].index)
+}
+
+// retainStateHistory periodically snapshots the state store into the state
+// history until the server shuts down.
+func (s *Server) retainStateHistory() {
+	ticker := time.NewTicker(s.config.StateHistoryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			snap, err := s.fsm.State().Snapshot()
+			if err != nil {
+				s.logger.Printf("[ERR] nomad: failed to snapshot state for history: %v", err)
+				continue
+			}
+			index, err := snap.LatestIndex()
+			if err != nil {
+				s.logger.Printf("[ERR] nomad: failed to determine state history index: %v", err)
+				continue
+			}
+			s.stateHistory.record(time.Now(), index, snap)
+		case <-s.shutdownCh:
+			return
+		}
+	}
+}
+
+// historicalQuery runs the blocking query function once against the state as
+// of the requested index. Queries for an index at or after the current one are
+// served from the current state.
+func (s *Server) historicalQuery(opts *blockingOptions) error {
+	s.setQueryMeta(opts.queryMeta)
+	metrics.IncrCounter([]string{"nomad", "rpc", "query_historical"}, 1)
+
+	snap, err := s.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	latest, err := snap.LatestIndex()
+	if err != nil {
+		return err
+	}
+
+	if opts.queryOpts.IndexAt < latest {
+		if s.stateHistory == nil {
+			return fmt.Errorf("state history is disabled on this server")
+		}
+		retained, err := s.stateHistory.lookup(opts.queryOpts.IndexAt)
+		if err != nil {
+			return err
+		}
+		snap = retained.snap
+	}
+
+	return opts.run(nil, &snap.StateStore)
+}
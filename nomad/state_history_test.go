@@ -0,0 +1,103 @@
+package nomad
+
+import (
+	"testing"
+	"time"
+
+	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateHistory_RecordLookup(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+	h := newStateHistory(time.Hour)
+
+	// Nothing is retained yet
+	_, err := h.lookup(10)
+	assert.NotNil(err)
+
+	now := time.Now()
+	snaps := make([]*state.StateSnapshot, 3)
+	for i := range snaps {
+		snaps[i] = &state.StateSnapshot{}
+	}
+	h.record(now.Add(-2*time.Hour), 10, snaps[0])
+	h.record(now.Add(-30*time.Minute), 20, snaps[1])
+	h.record(now, 30, snaps[2])
+
+	// The snapshot outside of the retention window was pruned
+	_, err = h.lookup(15)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "older than")
+	}
+
+	// Lookups return the newest snapshot at or before the index
+	out, err := h.lookup(25)
+	assert.Nil(err)
+	assert.EqualValues(20, out.index)
+
+	out, err = h.lookup(30)
+	assert.Nil(err)
+	assert.EqualValues(30, out.index)
+
+	// An index moving backwards resets the history
+	h.record(now, 5, snaps[0])
+	out, err = h.lookup(25)
+	assert.Nil(err)
+	assert.EqualValues(5, out.index)
+}
+
+func TestStateHistory_Query(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, func(c *Config) {
+		c.StateHistoryRetention = time.Hour
+		c.StateHistoryInterval = time.Hour
+	})
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Register a job and retain a snapshot of the state
+	job := mock.Job()
+	state := s1.fsm.State()
+	assert.Nil(state.UpsertJob(1000, job))
+	snap, err := state.Snapshot()
+	assert.Nil(err)
+	s1.stateHistory.record(time.Now(), 1000, snap)
+
+	// Deregister the job
+	assert.Nil(state.DeleteJob(1001, job.Namespace, job.ID))
+
+	get := &structs.JobSpecificRequest{
+		JobID: job.ID,
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			Namespace: job.Namespace,
+		},
+	}
+
+	// The current state no longer has the job
+	var resp structs.SingleJobResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.GetJob", get, &resp))
+	assert.Nil(resp.Job)
+
+	// The job is visible as of the index it was registered at
+	get.IndexAt = 1000
+	resp = structs.SingleJobResponse{}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Job.GetJob", get, &resp))
+	if assert.NotNil(resp.Job) {
+		assert.Equal(job.ID, resp.Job.ID)
+	}
+	assert.EqualValues(1000, resp.Index)
+
+	// Reads before the retained history fail
+	get.IndexAt = 999
+	err = msgpackrpc.CallWithCodec(codec, "Job.GetJob", get, &resp)
+	assert.NotNil(err)
+}
@@ -0,0 +1,199 @@
+package structs
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+const (
+	// ACLAuthMethodTypeOIDC is the type of the auth methods that authenticate
+	// users with an OpenID Connect provider.
+	ACLAuthMethodTypeOIDC = "oidc"
+
+	// DefaultACLAuthMethodTokenTTL is the time to live of the tokens created
+	// by an auth method that doesn't set a maximum.
+	DefaultACLAuthMethodTokenTTL = time.Hour
+)
+
+// validACLAuthMethodName is used to validate the name of an auth method.
+var validACLAuthMethodName = regexp.MustCompile("^[a-zA-Z0-9-]{1,128}$")
+
+// ACLAuthMethod is a method users can authenticate with to be issued an ACL
+// token. The tokens are local to the region and expire after MaxTokenTTL.
+type ACLAuthMethod struct {
+	// Name is the unique name of the auth method.
+	Name string
+
+	// Type is the type of the auth method, ACLAuthMethodTypeOIDC.
+	Type string
+
+	// MaxTokenTTL is the time to live of the tokens created by the method.
+	MaxTokenTTL time.Duration
+
+	// Config is the configuration of the identity provider.
+	Config *ACLAuthMethodConfig
+
+	// Raft Indexes
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// ACLAuthMethodConfig is the configuration of the identity provider of an auth
+// method and of the policies the tokens are associated with.
+type ACLAuthMethodConfig struct {
+	// OIDCDiscoveryURL is the issuer of the identity tokens. The provider's
+	// configuration is discovered from its
+	// "/.well-known/openid-configuration" document.
+	OIDCDiscoveryURL string
+
+	// OIDCClientID and OIDCClientSecret are the credentials of Nomad with
+	// the provider.
+	OIDCClientID     string
+	OIDCClientSecret string
+
+	// OIDCScopes are the scopes requested in addition to "openid".
+	OIDCScopes []string
+
+	// BoundAudiences are the audiences at least one of which the identity
+	// token must be issued for. Defaults to the client ID.
+	BoundAudiences []string
+
+	// AllowedRedirectURIs are the URIs the provider may redirect the user to
+	// once authenticated.
+	AllowedRedirectURIs []string
+
+	// Policies are associated with the tokens of every user.
+	Policies []string
+
+	// GroupsClaim is the claim of the identity token listing the groups of
+	// the user, which are mapped to policies by GroupPolicies.
+	GroupsClaim   string
+	GroupPolicies map[string][]string
+}
+
+// Validate returns an error if the auth method is invalid.
+func (a *ACLAuthMethod) Validate() error {
+	var mErr multierror.Error
+	if !validACLAuthMethodName.MatchString(a.Name) {
+		multierror.Append(&mErr, fmt.Errorf("invalid name %q", a.Name))
+	}
+	if a.Type != ACLAuthMethodTypeOIDC {
+		multierror.Append(&mErr, fmt.Errorf("invalid type %q: must be %q", a.Type, ACLAuthMethodTypeOIDC))
+	}
+	if a.MaxTokenTTL < 0 {
+		multierror.Append(&mErr, fmt.Errorf("max token TTL can't be negative"))
+	}
+
+	c := a.Config
+	if c == nil {
+		multierror.Append(&mErr, fmt.Errorf("missing config"))
+		return mErr.ErrorOrNil()
+	}
+	if u, err := url.Parse(c.OIDCDiscoveryURL); err != nil || u.Host == "" {
+		multierror.Append(&mErr, fmt.Errorf("invalid OIDC discovery URL %q", c.OIDCDiscoveryURL))
+	}
+	if c.OIDCClientID == "" {
+		multierror.Append(&mErr, fmt.Errorf("missing OIDC client ID"))
+	}
+	if len(c.AllowedRedirectURIs) == 0 {
+		multierror.Append(&mErr, fmt.Errorf("missing allowed redirect URIs"))
+	}
+	if len(c.GroupPolicies) != 0 && c.GroupsClaim == "" {
+		multierror.Append(&mErr, fmt.Errorf("group policies require a groups claim"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// TokenTTL returns the time to live of the tokens created by the method.
+func (a *ACLAuthMethod) TokenTTL() time.Duration {
+	if a.MaxTokenTTL == 0 {
+		return DefaultACLAuthMethodTokenTTL
+	}
+	return a.MaxTokenTTL
+}
+
+// Stub returns the auth method without its configuration, which contains the
+// client secret.
+func (a *ACLAuthMethod) Stub() *ACLAuthMethodListStub {
+	return &ACLAuthMethodListStub{
+		Name:        a.Name,
+		Type:        a.Type,
+		CreateIndex: a.CreateIndex,
+		ModifyIndex: a.ModifyIndex,
+	}
+}
+
+// ACLAuthMethodListStub is used for listing the auth methods.
+type ACLAuthMethodListStub struct {
+	Name        string
+	Type        string
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// ACLAuthMethodUpsertRequest is used to create or update auth methods.
+type ACLAuthMethodUpsertRequest struct {
+	AuthMethods []*ACLAuthMethod
+	WriteRequest
+}
+
+// ACLAuthMethodDeleteRequest is used to delete auth methods by name.
+type ACLAuthMethodDeleteRequest struct {
+	Names []string
+	WriteRequest
+}
+
+// ACLAuthMethodListRequest is used to list the auth methods.
+type ACLAuthMethodListRequest struct {
+	QueryOptions
+}
+
+// ACLAuthMethodListResponse is used to return the auth methods.
+type ACLAuthMethodListResponse struct {
+	AuthMethods []*ACLAuthMethodListStub
+	QueryMeta
+}
+
+// ACLAuthMethodSpecificRequest is used to read an auth method by name.
+type ACLAuthMethodSpecificRequest struct {
+	Name string
+	QueryOptions
+}
+
+// SingleACLAuthMethodResponse is used to return a single auth method.
+type SingleACLAuthMethodResponse struct {
+	AuthMethod *ACLAuthMethod
+	QueryMeta
+}
+
+// ACLOIDCAuthURLRequest is used to start the OIDC flow of an auth method. The
+// client nonce is generated by the client and must be presented again to
+// complete the flow, which binds it to the client that started it.
+type ACLOIDCAuthURLRequest struct {
+	AuthMethod  string
+	RedirectURI string
+	ClientNonce string
+	WriteRequest
+}
+
+// ACLOIDCAuthURLResponse is the URL of the provider to authenticate the user
+// with.
+type ACLOIDCAuthURLResponse struct {
+	AuthURL string
+	WriteMeta
+}
+
+// ACLOIDCCompleteAuthRequest is used to complete the OIDC flow with the state
+// and authorization code the provider redirected the user with.
+type ACLOIDCCompleteAuthRequest struct {
+	AuthMethod  string
+	ClientNonce string
+	State       string
+	Code        string
+	RedirectURI string
+	WriteRequest
+}
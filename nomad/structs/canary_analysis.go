@@ -0,0 +1,176 @@
+package structs
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// MaxCanaryAnalysisSnapshots is the number of canary analysis snapshots
+	// retained per task group of a deployment. Older snapshots are dropped.
+	MaxCanaryAnalysisSnapshots = 60
+
+	// checkRestartReasonPrefix is the prefix of the restart reason of tasks
+	// restarted by the check watcher because of an unhealthy check
+	checkRestartReasonPrefix = "healthcheck: "
+)
+
+// TaskResourceUsageSummary is the latest resource usage of a task as
+// reported by the client for the canary analysis of deployments.
+type TaskResourceUsageSummary struct {
+	// CPUTicks is the CPU usage of the task in MHz
+	CPUTicks float64
+
+	// MemoryRSS is the resident memory of the task in bytes
+	MemoryRSS uint64
+
+	// Timestamp is the time the usage was measured in UnixNano
+	Timestamp int64
+}
+
+func (u *TaskResourceUsageSummary) Copy() *TaskResourceUsageSummary {
+	if u == nil {
+		return nil
+	}
+	c := new(TaskResourceUsageSummary)
+	*c = *u
+	return c
+}
+
+// CanaryAnalysisSnapshot compares the canaries of a task group of a
+// deployment with the stable allocations of the group at a point in time.
+// Snapshots are collected by the leader while the canaries are awaiting
+// promotion so that external tools can analyze them.
+type CanaryAnalysisSnapshot struct {
+	// Time is the time the snapshot was collected
+	Time time.Time
+
+	// Canary and Stable are the statistics of the canaries and of the
+	// allocations of the group not part of the deployment.
+	Canary *CanaryAnalysisStats
+	Stable *CanaryAnalysisStats
+
+	// CPUDelta and MemoryRSSDelta are the differences of the mean resource
+	// usage of the canaries with the mean usage of the stable allocations.
+	// They are zero if either has no usage reported.
+	CPUDelta       float64
+	MemoryRSSDelta int64
+}
+
+func (s *CanaryAnalysisSnapshot) Copy() *CanaryAnalysisSnapshot {
+	if s == nil {
+		return nil
+	}
+	c := new(CanaryAnalysisSnapshot)
+	*c = *s
+	c.Canary = s.Canary.Copy()
+	c.Stable = s.Stable.Copy()
+	return c
+}
+
+// CanaryAnalysisStats are the statistics of a set of allocations of a
+// canary analysis snapshot.
+type CanaryAnalysisStats struct {
+	// Allocs is the number of allocations
+	Allocs int
+
+	// Running is the number of allocations running on their client
+	Running int
+
+	// Unhealthy is the number of allocations marked unhealthy
+	Unhealthy int
+
+	// TaskRestarts and TaskFailures are the number of restarts and failures
+	// of the tasks of the allocations.
+	TaskRestarts uint64
+	TaskFailures int
+
+	// CheckFailures is the number of restarts of tasks caused by unhealthy
+	// checks, among the task events retained by the allocations.
+	CheckFailures int
+
+	// MeanCPUTicks and MeanMemoryRSS are the mean resource usage of the
+	// allocations reporting it. UsageReported is the number of allocations
+	// whose tasks reported their usage.
+	MeanCPUTicks  float64
+	MeanMemoryRSS uint64
+	UsageReported int
+}
+
+func (s *CanaryAnalysisStats) Copy() *CanaryAnalysisStats {
+	if s == nil {
+		return nil
+	}
+	c := new(CanaryAnalysisStats)
+	*c = *s
+	return c
+}
+
+// NewCanaryAnalysisStats returns the statistics of the allocations.
+func NewCanaryAnalysisStats(allocs []*Allocation) *CanaryAnalysisStats {
+	s := &CanaryAnalysisStats{Allocs: len(allocs)}
+
+	var cpu float64
+	var mem uint64
+	for _, alloc := range allocs {
+		if alloc.ClientStatus == AllocClientStatusRunning {
+			s.Running++
+		}
+		if alloc.DeploymentStatus.IsUnhealthy() {
+			s.Unhealthy++
+		}
+
+		reported := false
+		for _, ts := range alloc.TaskStates {
+			s.TaskRestarts += ts.Restarts
+			if ts.Failed {
+				s.TaskFailures++
+			}
+			for _, e := range ts.Events {
+				if e.Type == TaskRestartSignal && strings.HasPrefix(e.RestartReason, checkRestartReasonPrefix) {
+					s.CheckFailures++
+				}
+			}
+			if ts.ResourceUsage != nil {
+				cpu += ts.ResourceUsage.CPUTicks
+				mem += ts.ResourceUsage.MemoryRSS
+				reported = true
+			}
+		}
+		if reported {
+			s.UsageReported++
+		}
+	}
+
+	if s.UsageReported != 0 {
+		s.MeanCPUTicks = cpu / float64(s.UsageReported)
+		s.MeanMemoryRSS = mem / uint64(s.UsageReported)
+	}
+	return s
+}
+
+// NewCanaryAnalysisSnapshot returns a snapshot comparing the canary
+// allocations with the stable allocations.
+func NewCanaryAnalysisSnapshot(now time.Time, canaries, stable []*Allocation) *CanaryAnalysisSnapshot {
+	s := &CanaryAnalysisSnapshot{
+		Time:   now,
+		Canary: NewCanaryAnalysisStats(canaries),
+		Stable: NewCanaryAnalysisStats(stable),
+	}
+	if s.Canary.UsageReported != 0 && s.Stable.UsageReported != 0 {
+		s.CPUDelta = s.Canary.MeanCPUTicks - s.Stable.MeanCPUTicks
+		s.MemoryRSSDelta = int64(s.Canary.MeanMemoryRSS) - int64(s.Stable.MeanMemoryRSS)
+	}
+	return s
+}
+
+// ApplyDeploymentCanaryAnalysisRequest is used to append canary analysis
+// snapshots to the task groups of a deployment via Raft
+type ApplyDeploymentCanaryAnalysisRequest struct {
+	DeploymentID string
+
+	// Snapshots is the snapshot to append per task group
+	Snapshots map[string]*CanaryAnalysisSnapshot
+
+	WriteRequest
+}
@@ -0,0 +1,78 @@
+package structs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCanaryAnalysisSnapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	canaries := []*Allocation{
+		{
+			ClientStatus:     AllocClientStatusRunning,
+			DeploymentStatus: &AllocDeploymentStatus{Healthy: helper.BoolToPtr(false)},
+			TaskStates: map[string]*TaskState{
+				"web": {
+					Restarts: 2,
+					Failed:   true,
+					Events: []*TaskEvent{
+						NewTaskEvent(TaskRestartSignal).SetRestartReason("healthcheck: check \"alive\" unhealthy"),
+						NewTaskEvent(TaskRestartSignal).SetRestartReason("user requested"),
+					},
+					ResourceUsage: &TaskResourceUsageSummary{CPUTicks: 300, MemoryRSS: 300},
+				},
+			},
+		},
+		{
+			ClientStatus: AllocClientStatusPending,
+		},
+	}
+	stable := []*Allocation{
+		{
+			ClientStatus: AllocClientStatusRunning,
+			TaskStates: map[string]*TaskState{
+				"web": {ResourceUsage: &TaskResourceUsageSummary{CPUTicks: 100, MemoryRSS: 200}},
+			},
+		},
+		{
+			ClientStatus: AllocClientStatusRunning,
+			TaskStates: map[string]*TaskState{
+				"web": {ResourceUsage: &TaskResourceUsageSummary{CPUTicks: 200, MemoryRSS: 400}},
+			},
+		},
+	}
+
+	now := time.Now()
+	s := NewCanaryAnalysisSnapshot(now, canaries, stable)
+	assert.Equal(now, s.Time)
+	assert.Equal(&CanaryAnalysisStats{
+		Allocs:        2,
+		Running:       1,
+		Unhealthy:     1,
+		TaskRestarts:  2,
+		TaskFailures:  1,
+		CheckFailures: 1,
+		MeanCPUTicks:  300,
+		MeanMemoryRSS: 300,
+		UsageReported: 1,
+	}, s.Canary)
+	assert.Equal(&CanaryAnalysisStats{
+		Allocs:        2,
+		Running:       2,
+		MeanCPUTicks:  150,
+		MeanMemoryRSS: 300,
+		UsageReported: 2,
+	}, s.Stable)
+	assert.Equal(150.0, s.CPUDelta)
+	assert.EqualValues(0, s.MemoryRSSDelta)
+
+	// No delta without usage of the stable allocations
+	s = NewCanaryAnalysisSnapshot(now, canaries, nil)
+	assert.Zero(s.CPUDelta)
+	assert.Zero(s.MemoryRSSDelta)
+	assert.Equal(0, s.Stable.Allocs)
+}
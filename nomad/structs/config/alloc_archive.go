@@ -0,0 +1,87 @@
+package config
+
+import "github.com/hashicorp/nomad/helper"
+
+// AllocArchiveConfig configures the archive that terminal allocations are
+// written to before they are garbage collected, so that their history can
+// be queried long after.
+type AllocArchiveConfig struct {
+	// Enabled controls whether terminal allocations are archived.
+	Enabled *bool `mapstructure:"enabled"`
+
+	// Store is where allocations are archived; either "file" or "s3".
+	Store string `mapstructure:"store"`
+
+	// Path is the directory allocations are archived to by the file store.
+	Path string `mapstructure:"path"`
+
+	// Bucket is the bucket allocations are archived to by the s3 store.
+	Bucket string `mapstructure:"bucket"`
+
+	// Prefix is prepended to the keys of the objects written by the s3
+	// store.
+	Prefix string `mapstructure:"prefix"`
+
+	// Region is the region of the bucket used by the s3 store.
+	Region string `mapstructure:"region"`
+
+	// Endpoint overrides the endpoint of the s3 store, to use S3 compatible
+	// object stores.
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// DefaultAllocArchiveConfig returns the canonical defaults for the Nomad
+// `alloc_archive` configuration.
+func DefaultAllocArchiveConfig() *AllocArchiveConfig {
+	return &AllocArchiveConfig{
+		Enabled: helper.BoolToPtr(false),
+		Store:   "file",
+	}
+}
+
+// IsEnabled returns whether the allocation archive is enabled.
+func (c *AllocArchiveConfig) IsEnabled() bool {
+	return c != nil && c.Enabled != nil && *c.Enabled
+}
+
+func (c *AllocArchiveConfig) Merge(b *AllocArchiveConfig) *AllocArchiveConfig {
+	result := c.Copy()
+
+	if b.Enabled != nil {
+		result.Enabled = helper.BoolToPtr(*b.Enabled)
+	}
+	if b.Store != "" {
+		result.Store = b.Store
+	}
+	if b.Path != "" {
+		result.Path = b.Path
+	}
+	if b.Bucket != "" {
+		result.Bucket = b.Bucket
+	}
+	if b.Prefix != "" {
+		result.Prefix = b.Prefix
+	}
+	if b.Region != "" {
+		result.Region = b.Region
+	}
+	if b.Endpoint != "" {
+		result.Endpoint = b.Endpoint
+	}
+
+	return result
+}
+
+// Copy returns a copy of this allocation archive config.
+func (c *AllocArchiveConfig) Copy() *AllocArchiveConfig {
+	if c == nil {
+		return nil
+	}
+
+	nc := new(AllocArchiveConfig)
+	*nc = *c
+	if c.Enabled != nil {
+		nc.Enabled = helper.BoolToPtr(*c.Enabled)
+	}
+	return nc
+}
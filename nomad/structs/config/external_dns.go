@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/helper"
+)
+
+const (
+	// ExternalDNSProviderRoute53 registers the allocations in a Route 53
+	// hosted zone
+	ExternalDNSProviderRoute53 = "route53"
+
+	// ExternalDNSProviderCoreDNS registers the allocations in the etcd
+	// backend of CoreDNS
+	ExternalDNSProviderCoreDNS = "coredns"
+)
+
+// ExternalDNSConfig configures the registration of the addresses of the
+// allocations running on the client with an external DNS provider, for
+// clusters not using Consul. Allocations are registered under the name set by
+// the "external_dns.name" meta of their job or task group.
+type ExternalDNSConfig struct {
+	// Provider is the DNS provider the allocations are registered with
+	Provider string `mapstructure:"provider"`
+
+	// TTL is the TTL of the records unless set by the "external_dns.ttl"
+	// meta of the job or task group
+	TTL time.Duration `mapstructure:"ttl"`
+
+	// HostedZoneID is the ID of the Route 53 hosted zone of the records
+	HostedZoneID string `mapstructure:"hosted_zone_id"`
+
+	// Endpoints are the addresses of the etcd servers of the CoreDNS etcd
+	// backend
+	Endpoints []string `mapstructure:"endpoints"`
+
+	// Prefix is the path of the records in etcd, as configured in the
+	// CoreDNS etcd plugin
+	Prefix string `mapstructure:"prefix"`
+}
+
+// DefaultExternalDNSTTL is the TTL of the records unless configured otherwise
+const DefaultExternalDNSTTL = time.Minute
+
+// DefaultExternalDNSPrefix is the default path of the CoreDNS etcd records
+const DefaultExternalDNSPrefix = "/skydns"
+
+// Validate returns an error if the provider is misconfigured
+func (c *ExternalDNSConfig) Validate() error {
+	var mErr multierror.Error
+	switch c.Provider {
+	case ExternalDNSProviderRoute53:
+		if c.HostedZoneID == "" {
+			multierror.Append(&mErr, fmt.Errorf("route53 provider requires a hosted_zone_id"))
+		}
+	case ExternalDNSProviderCoreDNS:
+		if len(c.Endpoints) == 0 {
+			multierror.Append(&mErr, fmt.Errorf("coredns provider requires etcd endpoints"))
+		}
+	default:
+		multierror.Append(&mErr, fmt.Errorf("unsupported provider %q: must be %q or %q",
+			c.Provider, ExternalDNSProviderRoute53, ExternalDNSProviderCoreDNS))
+	}
+	if c.TTL < 0 {
+		multierror.Append(&mErr, fmt.Errorf("ttl must not be negative"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Copy returns a copy of the configuration
+func (c *ExternalDNSConfig) Copy() *ExternalDNSConfig {
+	if c == nil {
+		return nil
+	}
+
+	nc := new(ExternalDNSConfig)
+	*nc = *c
+	nc.Endpoints = helper.CopySliceString(c.Endpoints)
+	return nc
+}
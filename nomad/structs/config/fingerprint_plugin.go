@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/helper"
+)
+
+// validFingerprintPluginName matches the names the attributes of a plugin can
+// be namespaced under
+var validFingerprintPluginName = regexp.MustCompile("^[a-zA-Z0-9_-]+$")
+
+// FingerprintPluginConfig configures an external fingerprinter plugin run by
+// the client. The attributes and links the plugin fingerprints are set on the
+// node under "plugins.<name>." and the devices it fingerprints are added to
+// those of the node.
+type FingerprintPluginConfig struct {
+	// Name is the name of the plugin
+	Name string `mapstructure:"-"`
+
+	// Command is the absolute path of the plugin binary
+	Command string `mapstructure:"command"`
+
+	// Args are the arguments of the plugin binary
+	Args []string `mapstructure:"args"`
+
+	// Interval is how often the node is fingerprinted by the plugin. The
+	// node is only fingerprinted when the client starts if unset.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Config is passed to the plugin on each fingerprint
+	Config map[string]string `mapstructure:"-"`
+}
+
+// Validate returns an error if the plugin is misconfigured
+func (c *FingerprintPluginConfig) Validate() error {
+	var mErr multierror.Error
+	if !validFingerprintPluginName.MatchString(c.Name) {
+		multierror.Append(&mErr, fmt.Errorf("name %q must only contain alphanumeric characters, dashes and underscores", c.Name))
+	}
+	if !filepath.IsAbs(c.Command) {
+		multierror.Append(&mErr, fmt.Errorf("command must be an absolute path"))
+	}
+	if c.Interval < 0 {
+		multierror.Append(&mErr, fmt.Errorf("interval must not be negative"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Copy returns a copy of the plugin configuration
+func (c *FingerprintPluginConfig) Copy() *FingerprintPluginConfig {
+	if c == nil {
+		return nil
+	}
+
+	nc := new(FingerprintPluginConfig)
+	*nc = *c
+	nc.Args = helper.CopySliceString(c.Args)
+	nc.Config = helper.CopyMapStringString(c.Config)
+	return nc
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/nomad/helper"
+)
+
+const (
+	// NodeHookEventRegistered, NodeHookEventDown, NodeHookEventDrained and
+	// NodeHookEventPurged are the node lifecycle transitions firing hooks
+	NodeHookEventRegistered = "registered"
+	NodeHookEventDown       = "down"
+	NodeHookEventDrained    = "drained"
+	NodeHookEventPurged     = "purged"
+)
+
+// NodeHookEvents are the node lifecycle transitions hooks can be fired on
+var NodeHookEvents = []string{
+	NodeHookEventRegistered,
+	NodeHookEventDown,
+	NodeHookEventDrained,
+	NodeHookEventPurged,
+}
+
+// NodeHookConfig configures a hook fired by the leader on node lifecycle
+// transitions. A hook either calls a webhook or runs a script. Hooks are
+// only configured by the operators of the servers, so the scripts they run
+// are approved by them.
+type NodeHookConfig struct {
+	// Name is the name of the hook
+	Name string `mapstructure:"-"`
+
+	// Events are the transitions firing the hook. Defaults to all of them.
+	Events []string `mapstructure:"events"`
+
+	// Webhook is the URL the events are posted to
+	Webhook string `mapstructure:"webhook"`
+
+	// Headers are added to the requests of the webhook
+	Headers map[string]string `mapstructure:"-"`
+
+	// Script is the absolute path of the script run with the event
+	Script string `mapstructure:"script"`
+
+	// Args are the arguments of the script
+	Args []string `mapstructure:"args"`
+
+	// Retries is the number of times a failed delivery is retried before the
+	// event is dead-lettered. Defaults to 3.
+	Retries *int `mapstructure:"retries"`
+
+	// RetryInterval is the delay before the first retry. It doubles on each
+	// retry. Defaults to 10s.
+	RetryInterval time.Duration `mapstructure:"retry_interval"`
+
+	// Timeout bounds a delivery. Defaults to 30s.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Canonicalize sets the defaults of the unset fields of the hook
+func (c *NodeHookConfig) Canonicalize() {
+	if len(c.Events) == 0 {
+		c.Events = helper.CopySliceString(NodeHookEvents)
+	}
+	if c.Retries == nil {
+		c.Retries = helper.IntToPtr(3)
+	}
+	if c.RetryInterval == 0 {
+		c.RetryInterval = 10 * time.Second
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 30 * time.Second
+	}
+}
+
+// Validate returns an error if the hook is misconfigured
+func (c *NodeHookConfig) Validate() error {
+	var mErr multierror.Error
+	switch {
+	case c.Webhook == "" && c.Script == "":
+		multierror.Append(&mErr, fmt.Errorf("one of webhook or script must be set"))
+	case c.Webhook != "" && c.Script != "":
+		multierror.Append(&mErr, fmt.Errorf("only one of webhook or script may be set"))
+	case c.Script != "" && !filepath.IsAbs(c.Script):
+		multierror.Append(&mErr, fmt.Errorf("script must be an absolute path"))
+	}
+
+	if ok, unknown := helper.SliceStringIsSubset(NodeHookEvents, c.Events); !ok {
+		multierror.Append(&mErr, fmt.Errorf("unknown events %v", unknown))
+	}
+	if c.Retries != nil && *c.Retries < 0 {
+		multierror.Append(&mErr, fmt.Errorf("retries must not be negative"))
+	}
+	if c.RetryInterval < 0 {
+		multierror.Append(&mErr, fmt.Errorf("retry_interval must not be negative"))
+	}
+	if c.Timeout < 0 {
+		multierror.Append(&mErr, fmt.Errorf("timeout must not be negative"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Copy returns a copy of the hook configuration
+func (c *NodeHookConfig) Copy() *NodeHookConfig {
+	if c == nil {
+		return nil
+	}
+
+	nc := new(NodeHookConfig)
+	*nc = *c
+	nc.Events = helper.CopySliceString(c.Events)
+	nc.Headers = helper.CopyMapStringString(c.Headers)
+	nc.Args = helper.CopySliceString(c.Args)
+	if c.Retries != nil {
+		nc.Retries = helper.IntToPtr(*c.Retries)
+	}
+	return nc
+}
@@ -0,0 +1,28 @@
+package config
+
+import "github.com/hashicorp/nomad/helper"
+
+// ScorerConfig enables a scorer extension of the scheduler. Scorers are
+// compiled into the binary and registered under a name.
+type ScorerConfig struct {
+	// Name is the name the scorer is registered with
+	Name string `mapstructure:"-"`
+
+	// Weight multiplies the scores of the scorer. Defaults to 1.
+	Weight float64 `mapstructure:"weight"`
+
+	// Options are passed to the scorer when it is instantiated
+	Options map[string]string `mapstructure:"options"`
+}
+
+// Copy returns a copy of the scorer configuration
+func (c *ScorerConfig) Copy() *ScorerConfig {
+	if c == nil {
+		return nil
+	}
+
+	nc := new(ScorerConfig)
+	*nc = *c
+	nc.Options = helper.CopyMapStringString(c.Options)
+	return nc
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"time"
+
+	"github.com/hashicorp/nomad/helper"
+)
+
+// StateExportConfig configures the exporter that writes batched diffs of the
+// cluster state to external storage for analytics.
+type StateExportConfig struct {
+	// Enabled controls whether the leader exports the cluster state.
+	Enabled *bool `mapstructure:"enabled"`
+
+	// Tables is the set of tables to export. Supported tables are "jobs",
+	// "allocs", "nodes" and "deployments".
+	Tables []string `mapstructure:"tables"`
+
+	// Interval is how often a batch of changes is exported.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Sink is where batches are written; either "file" or "s3".
+	Sink string `mapstructure:"sink"`
+
+	// Path is the directory batches are written to by the file sink.
+	Path string `mapstructure:"path"`
+
+	// Bucket is the bucket batches are written to by the s3 sink.
+	Bucket string `mapstructure:"bucket"`
+
+	// Prefix is prepended to the keys of the objects written by the s3 sink.
+	Prefix string `mapstructure:"prefix"`
+
+	// Region is the region of the bucket used by the s3 sink.
+	Region string `mapstructure:"region"`
+
+	// Endpoint overrides the endpoint of the s3 sink, to use S3 compatible
+	// object stores.
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// DefaultStateExportConfig returns the canonical defaults for the Nomad
+// `state_export` configuration.
+func DefaultStateExportConfig() *StateExportConfig {
+	return &StateExportConfig{
+		Enabled:  helper.BoolToPtr(false),
+		Tables:   []string{"jobs", "allocs", "nodes", "deployments"},
+		Interval: 30 * time.Second,
+		Sink:     "file",
+	}
+}
+
+// IsEnabled returns whether the state export is enabled.
+func (c *StateExportConfig) IsEnabled() bool {
+	return c != nil && c.Enabled != nil && *c.Enabled
+}
+
+func (c *StateExportConfig) Merge(b *StateExportConfig) *StateExportConfig {
+	result := c.Copy()
+
+	if b.Enabled != nil {
+		result.Enabled = helper.BoolToPtr(*b.Enabled)
+	}
+	if len(b.Tables) != 0 {
+		result.Tables = helper.CopySliceString(b.Tables)
+	}
+	if b.Interval != 0 {
+		result.Interval = b.Interval
+	}
+	if b.Sink != "" {
+		result.Sink = b.Sink
+	}
+	if b.Path != "" {
+		result.Path = b.Path
+	}
+	if b.Bucket != "" {
+		result.Bucket = b.Bucket
+	}
+	if b.Prefix != "" {
+		result.Prefix = b.Prefix
+	}
+	if b.Region != "" {
+		result.Region = b.Region
+	}
+	if b.Endpoint != "" {
+		result.Endpoint = b.Endpoint
+	}
+
+	return result
+}
+
+// Copy returns a copy of this state export config.
+func (c *StateExportConfig) Copy() *StateExportConfig {
+	if c == nil {
+		return nil
+	}
+
+	nc := new(StateExportConfig)
+	*nc = *c
+	if c.Enabled != nil {
+		nc.Enabled = helper.BoolToPtr(*c.Enabled)
+	}
+	nc.Tables = helper.CopySliceString(c.Tables)
+	return nc
+}
@@ -0,0 +1,88 @@
+package structs
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CoreIndex is used to index the cores of a node and the cores tasks of
+// allocations on it are pinned to
+type CoreIndex struct {
+	AvailCores []int            // Logical CPUs of the node
+	UsedCores  map[int]struct{} // Used cores by ID
+}
+
+// NewCoreIndex is used to construct a new core index
+func NewCoreIndex() *CoreIndex {
+	return &CoreIndex{
+		UsedCores: make(map[int]struct{}),
+	}
+}
+
+// SetNode is used to setup the available cores. The cores reserved on the
+// node are marked as used.
+func (idx *CoreIndex) SetNode(node *Node) {
+	if node.Resources != nil {
+		idx.AvailCores = node.Resources.CoreIDs
+	}
+	if node.Reserved != nil {
+		idx.AddReserved(node.Reserved)
+	}
+}
+
+// AddAllocs is used to add the cores used by allocations. Returns true if a
+// core is used more than once.
+func (idx *CoreIndex) AddAllocs(allocs []*Allocation) (collide bool) {
+	for _, alloc := range allocs {
+		for _, task := range alloc.TaskResources {
+			if idx.AddReserved(task) {
+				collide = true
+			}
+		}
+	}
+	return
+}
+
+// AddReserved is used to mark the cores of the resources as used, returns
+// true if one of them is already used
+func (idx *CoreIndex) AddReserved(r *Resources) (collide bool) {
+	for _, id := range r.CoreIDs {
+		if _, ok := idx.UsedCores[id]; ok {
+			collide = true
+		}
+		idx.UsedCores[id] = struct{}{}
+	}
+	return
+}
+
+// AssignCores is used to assign the number of cores asked for, returning
+// their IDs. The lowest free cores are assigned so that the tasks of a node
+// are packed together.
+func (idx *CoreIndex) AssignCores(count int) ([]int, error) {
+	var free []int
+	for _, id := range idx.AvailCores {
+		if _, ok := idx.UsedCores[id]; !ok {
+			free = append(free, id)
+		}
+	}
+	if len(free) < count {
+		return nil, fmt.Errorf("%d cores available; %d needed", len(free), count)
+	}
+
+	sort.Ints(free)
+	ids := make([]int, count)
+	copy(ids, free)
+	return ids, nil
+}
+
+// CoreIDsString returns the cores as a comma separated list, the format of
+// cpuset lists.
+func CoreIDsString(ids []int) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.Itoa(id)
+	}
+	return strings.Join(s, ",")
+}
@@ -0,0 +1,56 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoreIndex_AssignCores(t *testing.T) {
+	assert := assert.New(t)
+	node := &Node{
+		Resources: &Resources{CoreIDs: []int{3, 2, 1, 0}},
+		Reserved:  &Resources{CoreIDs: []int{0}},
+	}
+	idx := NewCoreIndex()
+	idx.SetNode(node)
+
+	// The lowest free cores are assigned, skipping the reserved ones
+	ids, err := idx.AssignCores(2)
+	assert.Nil(err)
+	assert.Equal([]int{1, 2}, ids)
+	assert.False(idx.AddReserved(&Resources{CoreIDs: ids}))
+
+	ids, err = idx.AssignCores(2)
+	assert.Nil(ids)
+	assert.EqualError(err, "1 cores available; 2 needed")
+
+	ids, err = idx.AssignCores(1)
+	assert.Nil(err)
+	assert.Equal([]int{3}, ids)
+}
+
+func TestCoreIndex_AddAllocs(t *testing.T) {
+	assert := assert.New(t)
+	idx := NewCoreIndex()
+	idx.SetNode(&Node{Resources: &Resources{CoreIDs: []int{0, 1, 2, 3}}})
+
+	a1 := &Allocation{
+		TaskResources: map[string]*Resources{
+			"web": {Cores: 1, CoreIDs: []int{1}},
+			"db":  {Cores: 1, CoreIDs: []int{2}},
+		},
+	}
+	assert.False(idx.AddAllocs([]*Allocation{a1}))
+	ids, err := idx.AssignCores(2)
+	assert.Nil(err)
+	assert.Equal([]int{0, 3}, ids)
+
+	// Using a core twice collides
+	assert.True(idx.AddAllocs([]*Allocation{a1}))
+}
+
+func TestCoreIDsString(t *testing.T) {
+	assert.Equal(t, "", CoreIDsString(nil))
+	assert.Equal(t, "0,2,3", CoreIDsString([]int{0, 2, 3}))
+}
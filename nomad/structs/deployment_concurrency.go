@@ -0,0 +1,92 @@
+package structs
+
+import (
+	"fmt"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+// DeploymentConcurrencyConfig limits how many deployments may be running at
+// once across the cluster and per namespace. While a limit is configured,
+// deployments are created queued and started by the leader in the order they
+// were created as running deployments complete.
+type DeploymentConcurrencyConfig struct {
+	// Limit is the number of deployments of the cluster which may be running
+	// at once. Zero means no limit.
+	Limit int
+
+	// NamespaceLimits is the number of deployments of a namespace which may
+	// be running at once, keyed by namespace. Zero means no limit.
+	NamespaceLimits map[string]int
+
+	// CreateIndex/ModifyIndex store the create/modify indexes of this configuration.
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Enabled returns whether a limit is configured, in which case deployments
+// are queued.
+func (c *DeploymentConcurrencyConfig) Enabled() bool {
+	if c == nil {
+		return false
+	}
+	if c.Limit > 0 {
+		return true
+	}
+	for _, limit := range c.NamespaceLimits {
+		if limit > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if the configuration is invalid.
+func (c *DeploymentConcurrencyConfig) Validate() error {
+	var mErr multierror.Error
+	if c.Limit < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("limit must be greater than or equal to zero"))
+	}
+	for ns, limit := range c.NamespaceLimits {
+		if ns == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("namespace limit for an empty namespace"))
+		}
+		if limit < 0 {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("limit of namespace %q must be greater than or equal to zero", ns))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// ClusterFull returns whether no more deployments may be started given the
+// number of running deployments of the cluster.
+func (c *DeploymentConcurrencyConfig) ClusterFull(running int) bool {
+	return c != nil && c.Limit > 0 && running >= c.Limit
+}
+
+// NamespaceFull returns whether no more deployments of the namespace may be
+// started given its number of running deployments.
+func (c *DeploymentConcurrencyConfig) NamespaceFull(namespace string, running int) bool {
+	if c == nil {
+		return false
+	}
+	limit := c.NamespaceLimits[namespace]
+	return limit > 0 && running >= limit
+}
+
+// DeploymentConcurrencySetConfigRequest is used by the Operator endpoint to
+// update the deployment concurrency configuration of the cluster.
+type DeploymentConcurrencySetConfigRequest struct {
+	// Config is the new deployment concurrency configuration to use.
+	Config DeploymentConcurrencyConfig
+
+	// WriteRequest holds the ACL token to go along with this request.
+	WriteRequest
+}
+
+// DeploymentConcurrencyConfigResponse is used to return the deployment
+// concurrency configuration.
+type DeploymentConcurrencyConfigResponse struct {
+	Config *DeploymentConcurrencyConfig
+	QueryMeta
+}
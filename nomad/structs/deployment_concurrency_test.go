@@ -0,0 +1,50 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeploymentConcurrencyConfig_Validate(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &DeploymentConcurrencyConfig{
+		Limit:           10,
+		NamespaceLimits: map[string]int{"web": 0},
+	}
+	assert.Nil(config.Validate())
+
+	config.Limit = -1
+	config.NamespaceLimits = map[string]int{"web": -1, "": 1}
+	err := config.Validate()
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "limit must be")
+		assert.Contains(err.Error(), `limit of namespace "web"`)
+		assert.Contains(err.Error(), "empty namespace")
+	}
+}
+
+func TestDeploymentConcurrencyConfig_Limits(t *testing.T) {
+	assert := assert.New(t)
+
+	// No config or limits means no queueing
+	var config *DeploymentConcurrencyConfig
+	assert.False(config.Enabled())
+	assert.False(config.ClusterFull(100))
+	assert.False(config.NamespaceFull("web", 100))
+
+	config = &DeploymentConcurrencyConfig{NamespaceLimits: map[string]int{"web": 0}}
+	assert.False(config.Enabled())
+
+	config = &DeploymentConcurrencyConfig{
+		Limit:           2,
+		NamespaceLimits: map[string]int{"web": 1},
+	}
+	assert.True(config.Enabled())
+	assert.False(config.ClusterFull(1))
+	assert.True(config.ClusterFull(2))
+	assert.False(config.NamespaceFull("web", 0))
+	assert.True(config.NamespaceFull("web", 1))
+	assert.False(config.NamespaceFull("api", 1))
+}
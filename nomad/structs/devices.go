@@ -0,0 +1,120 @@
+package structs
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DeviceIndex is used to index the devices of a node and the devices used by
+// allocations on it
+type DeviceIndex struct {
+	AvailDevices []*DeviceResource   // Devices of the node
+	UsedDevices  map[string]struct{} // Used devices by type and ID
+}
+
+// NewDeviceIndex is used to construct a new device index
+func NewDeviceIndex() *DeviceIndex {
+	return &DeviceIndex{
+		UsedDevices: make(map[string]struct{}),
+	}
+}
+
+// deviceKey returns the key of a device in the index
+func deviceKey(deviceType, id string) string {
+	return deviceType + "/" + id
+}
+
+// SetNode is used to setup the available devices
+func (idx *DeviceIndex) SetNode(node *Node) {
+	if node.Resources != nil {
+		idx.AvailDevices = node.Resources.Devices
+	}
+}
+
+// AddAllocs is used to add the devices used by allocations. Returns true if
+// a device is used more than once.
+func (idx *DeviceIndex) AddAllocs(allocs []*Allocation) (collide bool) {
+	for _, alloc := range allocs {
+		for _, task := range alloc.TaskResources {
+			for _, d := range task.Devices {
+				if idx.AddReserved(d) {
+					collide = true
+				}
+			}
+		}
+	}
+	return
+}
+
+// AddReserved is used to mark the devices as used, returns true if one of
+// them is already used
+func (idx *DeviceIndex) AddReserved(d *DeviceResource) (collide bool) {
+	for _, inst := range d.Instances {
+		key := deviceKey(d.Type, inst.ID)
+		if _, ok := idx.UsedDevices[key]; ok {
+			collide = true
+		}
+		idx.UsedDevices[key] = struct{}{}
+	}
+	return
+}
+
+// AssignDevices is used to assign the devices asked for, returning an offer
+// listing them. The devices are taken from a single NUMA node when possible;
+// the NUMA node with the fewest free devices that fits the ask is used, so
+// the larger pools are left for larger asks. Otherwise the ask spans NUMA
+// nodes, taking from the ones with the most free devices first.
+func (idx *DeviceIndex) AssignDevices(ask *DeviceResource) (*DeviceResource, error) {
+	free := make(map[int][]*DeviceInstance)
+	total := 0
+	for _, d := range idx.AvailDevices {
+		if !d.Matches(ask) {
+			continue
+		}
+		for _, inst := range d.Instances {
+			if _, ok := idx.UsedDevices[deviceKey(d.Type, inst.ID)]; ok {
+				continue
+			}
+			free[inst.NUMANode] = append(free[inst.NUMANode], inst)
+			total++
+		}
+	}
+	if total < ask.Count {
+		return nil, fmt.Errorf("%d %q devices available; %d needed", total, ask.Type, ask.Count)
+	}
+
+	numaNodes := make([]int, 0, len(free))
+	for n := range free {
+		numaNodes = append(numaNodes, n)
+	}
+	sort.Ints(numaNodes)
+
+	var chosen []*DeviceInstance
+	for _, n := range numaNodes {
+		if len(free[n]) >= ask.Count && (chosen == nil || len(free[n]) < len(chosen)) {
+			chosen = free[n]
+		}
+	}
+	if chosen != nil {
+		chosen = chosen[:ask.Count]
+	} else {
+		sort.SliceStable(numaNodes, func(i, j int) bool {
+			return len(free[numaNodes[i]]) > len(free[numaNodes[j]])
+		})
+		for _, n := range numaNodes {
+			need := ask.Count - len(chosen)
+			if need > len(free[n]) {
+				need = len(free[n])
+			}
+			chosen = append(chosen, free[n][:need]...)
+		}
+	}
+
+	offer := ask.Copy()
+	offer.Instances = make([]*DeviceInstance, len(chosen))
+	for i, inst := range chosen {
+		c := *inst
+		offer.Instances[i] = &c
+	}
+	return offer, nil
+}
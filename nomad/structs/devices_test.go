@@ -0,0 +1,92 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testSRIOVNode() *Node {
+	return &Node{
+		Resources: &Resources{
+			Devices: []*DeviceResource{
+				{
+					Type:  DeviceTypeSRIOV,
+					Name:  "eth1",
+					MBits: 10000,
+					Instances: []*DeviceInstance{
+						{ID: "0000:3b:02.0", NUMANode: 0},
+						{ID: "0000:3b:02.1", NUMANode: 0},
+						{ID: "0000:3b:02.2", NUMANode: 0},
+					},
+				},
+				{
+					Type:  DeviceTypeSRIOV,
+					Name:  "eth2",
+					MBits: 25000,
+					Instances: []*DeviceInstance{
+						{ID: "0000:af:02.0", NUMANode: 1},
+						{ID: "0000:af:02.1", NUMANode: 1},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestDeviceIndex_AssignDevices(t *testing.T) {
+	assert := assert.New(t)
+	idx := NewDeviceIndex()
+	idx.SetNode(testSRIOVNode())
+
+	// The smallest NUMA node that fits the ask is used
+	offer, err := idx.AssignDevices(&DeviceResource{Type: DeviceTypeSRIOV, Count: 2})
+	assert.Nil(err)
+	assert.Equal([]string{"0000:af:02.0", "0000:af:02.1"}, offer.InstanceIDs())
+	assert.False(idx.AddReserved(offer))
+
+	// Asks spanning NUMA nodes take from the largest pools first
+	idx = NewDeviceIndex()
+	idx.SetNode(testSRIOVNode())
+	offer, err = idx.AssignDevices(&DeviceResource{Type: DeviceTypeSRIOV, Count: 4})
+	assert.Nil(err)
+	assert.Equal([]string{"0000:3b:02.0", "0000:3b:02.1", "0000:3b:02.2", "0000:af:02.0"}, offer.InstanceIDs())
+
+	// The group and link speed are matched
+	offer, err = idx.AssignDevices(&DeviceResource{Type: DeviceTypeSRIOV, Name: "eth1", Count: 1})
+	assert.Nil(err)
+	assert.Equal([]string{"0000:3b:02.0"}, offer.InstanceIDs())
+
+	offer, err = idx.AssignDevices(&DeviceResource{Type: DeviceTypeSRIOV, MBits: 20000, Count: 3})
+	assert.Nil(offer)
+	assert.Contains(err.Error(), "2 \"sriov\" devices available; 3 needed")
+}
+
+func TestDeviceIndex_AddAllocs(t *testing.T) {
+	assert := assert.New(t)
+	idx := NewDeviceIndex()
+	idx.SetNode(testSRIOVNode())
+
+	alloc := &Allocation{
+		TaskResources: map[string]*Resources{
+			"web": {
+				Devices: []*DeviceResource{
+					{
+						Type:      DeviceTypeSRIOV,
+						Count:     2,
+						Instances: []*DeviceInstance{{ID: "0000:af:02.0"}, {ID: "0000:af:02.1"}},
+					},
+				},
+			},
+		},
+	}
+	assert.False(idx.AddAllocs([]*Allocation{alloc}))
+
+	// The used devices are not assigned again
+	offer, err := idx.AssignDevices(&DeviceResource{Type: DeviceTypeSRIOV, Count: 1})
+	assert.Nil(err)
+	assert.Equal([]string{"0000:3b:02.0"}, offer.InstanceIDs())
+
+	// Using a device twice collides
+	assert.True(idx.AddAllocs([]*Allocation{alloc}))
+}
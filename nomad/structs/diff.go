@@ -51,6 +51,10 @@ type JobDiff struct {
 	Fields     []*FieldDiff
 	Objects    []*ObjectDiff
 	TaskGroups []*TaskGroupDiff
+
+	// RoutingChanges are the changes that affect how traffic reaches the
+	// job's tasks
+	RoutingChanges []*RoutingChange
 }
 
 // Diff returns a diff of two jobs and a potential error if the Jobs are not
@@ -62,7 +66,7 @@ func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
 	diff := &JobDiff{Type: DiffTypeNone}
 	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
 	filter := []string{"ID", "Status", "StatusDescription", "Version", "Stable", "CreateIndex",
-		"ModifyIndex", "JobModifyIndex", "Update", "SubmitTime"}
+		"ModifyIndex", "JobModifyIndex", "Update", "SubmitTime", "Restarts", "IdempotencyToken"}
 
 	if j == nil && other == nil {
 		return diff, nil
@@ -111,6 +115,7 @@ func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
 		return nil, err
 	}
 	diff.TaskGroups = tgs
+	diff.RoutingChanges = routingChanges(j, other)
 
 	// Periodic diff
 	if pDiff := primitiveObjectDiff(j.Periodic, other.Periodic, nil, "Periodic", contextual); pDiff != nil {
@@ -122,6 +127,11 @@ func (j *Job) Diff(other *Job, contextual bool) (*JobDiff, error) {
 		diff.Objects = append(diff.Objects, cDiff)
 	}
 
+	// Secrets diff
+	if sDiff := secretDependenciesDiff(j.Secrets, other.Secrets, contextual); sDiff != nil {
+		diff.Objects = append(diff.Objects, sDiff)
+	}
+
 	// Check to see if there is a diff. We don't use reflect because we are
 	// filtering quite a few fields that will change on each diff.
 	if diff.Type == DiffTypeNone {
@@ -240,6 +250,16 @@ func (tg *TaskGroup) Diff(other *TaskGroup, contextual bool) (*TaskGroupDiff, er
 		diff.Objects = append(diff.Objects, diskDiff)
 	}
 
+	// DNS diff
+	if dnsDiff := dnsConfigDiff(tg.DNS, other.DNS, contextual); dnsDiff != nil {
+		diff.Objects = append(diff.Objects, dnsDiff)
+	}
+
+	// Migrate diff
+	if mDiff := primitiveObjectDiff(tg.Migrate, other.Migrate, nil, "Migrate", contextual); mDiff != nil {
+		diff.Objects = append(diff.Objects, mDiff)
+	}
+
 	// Update diff
 	// COMPAT: Remove "Stagger" in 0.7.0.
 	if uDiff := primitiveObjectDiff(tg.Update, other.Update, []string{"Stagger"}, "Update", contextual); uDiff != nil {
@@ -392,8 +412,7 @@ func (t *Task) Diff(other *Task, contextual bool) (*TaskDiff, error) {
 	}
 
 	// LogConfig diff
-	lDiff := primitiveObjectDiff(t.LogConfig, other.LogConfig, nil, "LogConfig", contextual)
-	if lDiff != nil {
+	if lDiff := t.LogConfig.Diff(other.LogConfig, contextual); lDiff != nil {
 		diff.Objects = append(diff.Objects, lDiff)
 	}
 
@@ -403,6 +422,11 @@ func (t *Task) Diff(other *Task, contextual bool) (*TaskDiff, error) {
 		diff.Objects = append(diff.Objects, dDiff)
 	}
 
+	// DNS diff
+	if dnsDiff := dnsConfigDiff(t.DNS, other.DNS, contextual); dnsDiff != nil {
+		diff.Objects = append(diff.Objects, dnsDiff)
+	}
+
 	// Artifacts diff
 	diffs := primitiveObjectSetDiff(
 		interfaceSlice(t.Artifacts),
@@ -436,6 +460,17 @@ func (t *Task) Diff(other *Task, contextual bool) (*TaskDiff, error) {
 		diff.Objects = append(diff.Objects, tmplDiffs...)
 	}
 
+	// Connectivity checks diff
+	connDiffs := primitiveObjectSetDiff(
+		interfaceSlice(t.ConnectivityChecks),
+		interfaceSlice(other.ConnectivityChecks),
+		nil,
+		"ConnectivityCheck",
+		contextual)
+	if connDiffs != nil {
+		diff.Objects = append(diff.Objects, connDiffs...)
+	}
+
 	return diff, nil
 }
 
@@ -724,6 +759,35 @@ func vaultDiff(old, new *Vault, contextual bool) *ObjectDiff {
 	return diff
 }
 
+// dnsConfigDiff returns the diff of two DNS config objects. If contextual
+// diff is enabled, all fields will be returned, even if no diff occurred.
+func dnsConfigDiff(old, new *DNSConfig, contextual bool) *ObjectDiff {
+	diff := &ObjectDiff{Type: DiffTypeNone, Name: "DNS"}
+	if reflect.DeepEqual(old, new) {
+		return nil
+	} else if old == nil {
+		old = &DNSConfig{}
+		diff.Type = DiffTypeAdded
+	} else if new == nil {
+		new = &DNSConfig{}
+		diff.Type = DiffTypeDeleted
+	} else {
+		diff.Type = DiffTypeEdited
+	}
+
+	if setDiff := stringSetDiff(old.Servers, new.Servers, "Servers", contextual); setDiff != nil {
+		diff.Objects = append(diff.Objects, setDiff)
+	}
+	if setDiff := stringSetDiff(old.Searches, new.Searches, "Searches", contextual); setDiff != nil {
+		diff.Objects = append(diff.Objects, setDiff)
+	}
+	if setDiff := stringSetDiff(old.Options, new.Options, "Options", contextual); setDiff != nil {
+		diff.Objects = append(diff.Objects, setDiff)
+	}
+
+	return diff
+}
+
 // parameterizedJobDiff returns the diff of two parameterized job objects. If
 // contextual diff is enabled, all fields will be returned, even if no diff
 // occurred.
@@ -762,6 +826,37 @@ func parameterizedJobDiff(old, new *ParameterizedJobConfig, contextual bool) *Ob
 	return diff
 }
 
+// secretDependenciesDiff returns the diff of two secret dependencies objects.
+// If contextual diff is enabled, all fields will be returned, even if no diff
+// occurred.
+func secretDependenciesDiff(old, new *SecretDependencies, contextual bool) *ObjectDiff {
+	diff := &ObjectDiff{Type: DiffTypeNone, Name: "Secrets"}
+
+	if reflect.DeepEqual(old, new) {
+		return nil
+	} else if old == nil {
+		old = &SecretDependencies{}
+		diff.Type = DiffTypeAdded
+	} else if new == nil {
+		new = &SecretDependencies{}
+		diff.Type = DiffTypeDeleted
+	} else {
+		diff.Type = DiffTypeEdited
+	}
+
+	if vDiff := stringSetDiff(old.Vault, new.Vault, "Vault", contextual); vDiff != nil {
+		diff.Objects = append(diff.Objects, vDiff)
+	}
+	if vDiff := stringSetDiff(old.Variables, new.Variables, "Variables", contextual); vDiff != nil {
+		diff.Objects = append(diff.Objects, vDiff)
+	}
+	if cDiff := stringSetDiff(old.Consul, new.Consul, "Consul", contextual); cDiff != nil {
+		diff.Objects = append(diff.Objects, cDiff)
+	}
+
+	return diff
+}
+
 // Diff returns a diff of two resource objects. If contextual diff is enabled,
 // non-changed fields will still be returned.
 func (r *Resources) Diff(other *Resources, contextual bool) *ObjectDiff {
@@ -792,9 +887,113 @@ func (r *Resources) Diff(other *Resources, contextual bool) *ObjectDiff {
 		diff.Objects = append(diff.Objects, nDiffs...)
 	}
 
+	// Device Resources diff
+	if dDiffs := deviceResourceDiffs(r.Devices, other.Devices, contextual); dDiffs != nil {
+		diff.Objects = append(diff.Objects, dDiffs...)
+	}
+
+	return diff
+}
+
+// Diff returns a diff of two log configs. If contextual diff is enabled,
+// non-changed fields will still be returned.
+func (l *LogConfig) Diff(other *LogConfig, contextual bool) *ObjectDiff {
+	diff := &ObjectDiff{Type: DiffTypeNone, Name: "LogConfig"}
+	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
+
+	if reflect.DeepEqual(l, other) {
+		return nil
+	} else if l == nil {
+		l = &LogConfig{}
+		diff.Type = DiffTypeAdded
+		newPrimitiveFlat = flatmap.Flatten(other, nil, true)
+	} else if other == nil {
+		other = &LogConfig{}
+		diff.Type = DiffTypeDeleted
+		oldPrimitiveFlat = flatmap.Flatten(l, nil, true)
+	} else {
+		diff.Type = DiffTypeEdited
+		oldPrimitiveFlat = flatmap.Flatten(l, nil, true)
+		newPrimitiveFlat = flatmap.Flatten(other, nil, true)
+	}
+
+	// Diff the primitive fields.
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+
+	// Sinks diff
+	sDiffs := primitiveObjectSetDiff(
+		interfaceSlice(l.Sinks),
+		interfaceSlice(other.Sinks),
+		nil,
+		"Sink",
+		contextual)
+	if sDiffs != nil {
+		diff.Objects = append(diff.Objects, sDiffs...)
+	}
+
 	return diff
 }
 
+// Diff returns a diff of two device asks. If contextual diff is enabled,
+// non-changed fields will still be returned.
+func (d *DeviceResource) Diff(other *DeviceResource, contextual bool) *ObjectDiff {
+	diff := &ObjectDiff{Type: DiffTypeNone, Name: "Device"}
+	var oldPrimitiveFlat, newPrimitiveFlat map[string]string
+
+	if reflect.DeepEqual(d, other) {
+		return nil
+	} else if d == nil {
+		d = &DeviceResource{}
+		diff.Type = DiffTypeAdded
+		newPrimitiveFlat = flatmap.Flatten(other, nil, true)
+	} else if other == nil {
+		other = &DeviceResource{}
+		diff.Type = DiffTypeDeleted
+		oldPrimitiveFlat = flatmap.Flatten(d, nil, true)
+	} else {
+		diff.Type = DiffTypeEdited
+		oldPrimitiveFlat = flatmap.Flatten(d, nil, true)
+		newPrimitiveFlat = flatmap.Flatten(other, nil, true)
+	}
+
+	// Diff the primitive fields.
+	diff.Fields = fieldDiffs(oldPrimitiveFlat, newPrimitiveFlat, contextual)
+	return diff
+}
+
+// deviceResourceDiffs diffs a set of device asks, keyed by their type and
+// name. If contextual diff is enabled, non-changed fields will still be
+// returned.
+func deviceResourceDiffs(old, new []*DeviceResource, contextual bool) []*ObjectDiff {
+	makeSet := func(objects []*DeviceResource) map[string]*DeviceResource {
+		objMap := make(map[string]*DeviceResource, len(objects))
+		for _, obj := range objects {
+			objMap[obj.Type+"/"+obj.Name] = obj
+		}
+		return objMap
+	}
+
+	oldSet := makeSet(old)
+	newSet := makeSet(new)
+
+	var diffs []*ObjectDiff
+	for k, oldV := range oldSet {
+		if diff := oldV.Diff(newSet[k], contextual); diff != nil {
+			diffs = append(diffs, diff)
+		}
+	}
+	for k, newV := range newSet {
+		if oldV, ok := oldSet[k]; !ok {
+			if diff := oldV.Diff(newV, contextual); diff != nil {
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+
+	sort.Sort(ObjectDiffs(diffs))
+	return diffs
+}
+
 // Diff returns a diff of two network resources. If contextual diff is enabled,
 // non-changed fields will still be returned.
 func (r *NetworkResource) Diff(other *NetworkResource, contextual bool) *ObjectDiff {
@@ -0,0 +1,213 @@
+package structs
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The kinds of routing changes
+	RoutingChangePort               = "Port"
+	RoutingChangeService            = "Service"
+	RoutingChangeServicePort        = "Service Port"
+	RoutingChangeServiceTags        = "Service Tags"
+	RoutingChangeServiceAddressMode = "Service Address Mode"
+)
+
+// RoutingChange is a change to a job that affects how traffic reaches its
+// tasks, such as a changed port or service. Routing changes are also part of
+// the field and object diffs; they are listed separately so they stand out.
+type RoutingChange struct {
+	Type      DiffType
+	Kind      string
+	TaskGroup string
+	Task      string
+
+	// Name is the label of the port or the name of the service
+	Name string
+
+	Old, New string
+}
+
+// routingChanges returns the routing changes between two versions of a job,
+// ordered by task group and task. Either job may be empty.
+func routingChanges(old, new *Job) []*RoutingChange {
+	oldGroups := make(map[string]*TaskGroup, len(old.TaskGroups))
+	for _, tg := range old.TaskGroups {
+		oldGroups[tg.Name] = tg
+	}
+	newGroups := make(map[string]*TaskGroup, len(new.TaskGroups))
+	for _, tg := range new.TaskGroups {
+		newGroups[tg.Name] = tg
+	}
+
+	var changes []*RoutingChange
+	for _, group := range unionKeys(taskGroupNames(oldGroups), taskGroupNames(newGroups)) {
+		oldTasks := tasksByName(oldGroups[group])
+		newTasks := tasksByName(newGroups[group])
+		for _, task := range unionKeys(taskNames(oldTasks), taskNames(newTasks)) {
+			changes = append(changes, taskRoutingChanges(group, task, oldTasks[task], newTasks[task])...)
+		}
+	}
+	return changes
+}
+
+// taskRoutingChanges returns the routing changes between two versions of a
+// task. Either task may be nil.
+func taskRoutingChanges(group, task string, old, new *Task) []*RoutingChange {
+	var changes []*RoutingChange
+	add := func(kind, name, oldValue, newValue string, oldOK, newOK bool) {
+		change := &RoutingChange{
+			Kind:      kind,
+			TaskGroup: group,
+			Task:      task,
+			Name:      name,
+			Old:       oldValue,
+			New:       newValue,
+		}
+		switch {
+		case !oldOK && newOK:
+			change.Type = DiffTypeAdded
+		case oldOK && !newOK:
+			change.Type = DiffTypeDeleted
+		case oldValue != newValue:
+			change.Type = DiffTypeEdited
+		default:
+			return
+		}
+		changes = append(changes, change)
+	}
+
+	oldPorts, newPorts := taskPorts(old), taskPorts(new)
+	for _, label := range unionKeys(mapKeys(oldPorts), mapKeys(newPorts)) {
+		o, oldOK := oldPorts[label]
+		n, newOK := newPorts[label]
+		add(RoutingChangePort, label, o, n, oldOK, newOK)
+	}
+
+	oldServices, newServices := taskServices(old), taskServices(new)
+	for _, name := range unionKeys(serviceNames(oldServices), serviceNames(newServices)) {
+		o, oldOK := oldServices[name]
+		n, newOK := newServices[name]
+		if !oldOK || !newOK {
+			add(RoutingChangeService, name, serviceDescription(o), serviceDescription(n), oldOK, newOK)
+			continue
+		}
+		add(RoutingChangeServicePort, name, o.PortLabel, n.PortLabel, true, true)
+		add(RoutingChangeServiceTags, name, serviceTags(o), serviceTags(n), true, true)
+		add(RoutingChangeServiceAddressMode, name, o.AddressMode, n.AddressMode, true, true)
+	}
+
+	return changes
+}
+
+// taskPorts returns the ports of a task by label. Static ports map to their
+// value and dynamic ports to "dynamic".
+func taskPorts(t *Task) map[string]string {
+	ports := make(map[string]string)
+	if t == nil || t.Resources == nil {
+		return ports
+	}
+	for _, n := range t.Resources.Networks {
+		for _, p := range n.ReservedPorts {
+			ports[p.Label] = strconv.Itoa(p.Value)
+		}
+		for _, p := range n.DynamicPorts {
+			ports[p.Label] = "dynamic"
+		}
+	}
+	return ports
+}
+
+// taskServices returns the services of a task by name.
+func taskServices(t *Task) map[string]*Service {
+	services := make(map[string]*Service)
+	if t == nil {
+		return services
+	}
+	for _, s := range t.Services {
+		services[s.Name] = s
+	}
+	return services
+}
+
+// serviceDescription describes an added or deleted service.
+func serviceDescription(s *Service) string {
+	if s == nil {
+		return ""
+	}
+	out := "port " + s.PortLabel
+	if tags := serviceTags(s); tags != "" {
+		out += ", tags " + tags
+	}
+	return out
+}
+
+// serviceTags returns the sorted tags of a service.
+func serviceTags(s *Service) string {
+	tags := make([]string, len(s.Tags))
+	copy(tags, s.Tags)
+	sort.Strings(tags)
+	return strings.Join(tags, ",")
+}
+
+func tasksByName(tg *TaskGroup) map[string]*Task {
+	tasks := make(map[string]*Task)
+	if tg == nil {
+		return tasks
+	}
+	for _, t := range tg.Tasks {
+		tasks[t.Name] = t
+	}
+	return tasks
+}
+
+func taskGroupNames(m map[string]*TaskGroup) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
+
+func taskNames(m map[string]*Task) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
+
+func serviceNames(m map[string]*Service) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
+
+func mapKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// unionKeys returns the sorted union of the keys.
+func unionKeys(a, b []string) []string {
+	set := make(map[string]struct{}, len(a)+len(b))
+	for _, k := range a {
+		set[k] = struct{}{}
+	}
+	for _, k := range b {
+		set[k] = struct{}{}
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -59,6 +59,23 @@ func TestJobDiff(t *testing.T) {
 				ID:   "foo",
 			},
 		},
+		{
+			// Idempotency tokens are ignored
+			Old: &Job{
+				ID:               "foo",
+				Priority:         10,
+				IdempotencyToken: "ci-1",
+			},
+			New: &Job{
+				ID:               "foo",
+				Priority:         10,
+				IdempotencyToken: "ci-2",
+			},
+			Expected: &JobDiff{
+				Type: DiffTypeNone,
+				ID:   "foo",
+			},
+		},
 		{
 			// Primitive only that is has diffs
 			Old: &Job{
@@ -2025,6 +2042,82 @@ func TestTaskGroupDiff(t *testing.T) {
 				},
 			},
 		},
+		{
+			// DNS edited
+			Old: &TaskGroup{
+				DNS: &DNSConfig{
+					Servers:  []string{"10.0.0.53"},
+					Searches: []string{"example.com"},
+				},
+			},
+			New: &TaskGroup{
+				DNS: &DNSConfig{
+					Servers:  []string{"10.0.0.54"},
+					Searches: []string{"example.com"},
+				},
+			},
+			Expected: &TaskGroupDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "DNS",
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeEdited,
+								Name: "Servers",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeAdded,
+										Name: "Servers",
+										Old:  "",
+										New:  "10.0.0.54",
+									},
+									{
+										Type: DiffTypeDeleted,
+										Name: "Servers",
+										Old:  "10.0.0.53",
+										New:  "",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			// Migrate added
+			Old: &TaskGroup{},
+			New: &TaskGroup{
+				Migrate: &MigrateStrategy{
+					Deadline: 30 * time.Minute,
+				},
+			},
+			Expected: &TaskGroupDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeAdded,
+						Name: "Migrate",
+						Fields: []*FieldDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "Deadline",
+								Old:  "",
+								New:  "1800000000000",
+							},
+							{
+								Type: DiffTypeAdded,
+								Name: "Ignore",
+								Old:  "",
+								New:  "false",
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for i, c := range cases {
@@ -2465,6 +2558,63 @@ func TestTaskDiff(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "LogConfig sink added",
+			Old: &Task{
+				LogConfig: &LogConfig{
+					MaxFiles:      1,
+					MaxFileSizeMB: 10,
+				},
+			},
+			New: &Task{
+				LogConfig: &LogConfig{
+					MaxFiles:      1,
+					MaxFileSizeMB: 10,
+					Sinks: []*LogSink{
+						{
+							Type:    LogSinkLoki,
+							Address: "http://loki:3100/loki/api/v1/push",
+							Labels:  map[string]string{"env": "prod"},
+						},
+					},
+				},
+			},
+			Expected: &TaskDiff{
+				Type: DiffTypeEdited,
+				Objects: []*ObjectDiff{
+					{
+						Type: DiffTypeEdited,
+						Name: "LogConfig",
+						Objects: []*ObjectDiff{
+							{
+								Type: DiffTypeAdded,
+								Name: "Sink",
+								Fields: []*FieldDiff{
+									{
+										Type: DiffTypeAdded,
+										Name: "Address",
+										Old:  "",
+										New:  "http://loki:3100/loki/api/v1/push",
+									},
+									{
+										Type: DiffTypeAdded,
+										Name: "Labels[env]",
+										Old:  "",
+										New:  "prod",
+									},
+									{
+										Type: DiffTypeAdded,
+										Name: "Type",
+										Old:  "",
+										New:  "loki",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			Name: "Artifacts edited",
 			Old: &Task{
@@ -2657,6 +2807,12 @@ func TestTaskDiff(t *testing.T) {
 								Old:  "100",
 								New:  "200",
 							},
+							{
+								Type: DiffTypeNone,
+								Name: "Cores",
+								Old:  "0",
+								New:  "0",
+							},
 							{
 								Type: DiffTypeEdited,
 								Name: "DiskMB",
@@ -2669,6 +2825,24 @@ func TestTaskDiff(t *testing.T) {
 								Old:  "100",
 								New:  "100",
 							},
+							{
+								Type: DiffTypeNone,
+								Name: "IOReadMBps",
+								Old:  "0",
+								New:  "0",
+							},
+							{
+								Type: DiffTypeNone,
+								Name: "IOWeight",
+								Old:  "0",
+								New:  "0",
+							},
+							{
+								Type: DiffTypeNone,
+								Name: "IOWriteMBps",
+								Old:  "0",
+								New:  "0",
+							},
 							{
 								Type: DiffTypeNone,
 								Name: "MemoryMB",
@@ -4376,3 +4550,93 @@ func TestTaskDiff(t *testing.T) {
 		})
 	}
 }
+
+func TestJobDiff_RoutingChanges(t *testing.T) {
+	old := &Job{
+		ID: "example",
+		TaskGroups: []*TaskGroup{
+			{
+				Name: "web",
+				Tasks: []*Task{
+					{
+						Name: "frontend",
+						Resources: &Resources{
+							Networks: []*NetworkResource{
+								{
+									ReservedPorts: []Port{{Label: "http", Value: 80}},
+									DynamicPorts:  []Port{{Label: "admin"}},
+								},
+							},
+						},
+						Services: []*Service{
+							{Name: "frontend", PortLabel: "http", Tags: []string{"b", "a"}},
+							{Name: "admin", PortLabel: "admin"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	new := old.Copy()
+	task := new.TaskGroups[0].Tasks[0]
+	task.Resources.Networks[0].ReservedPorts[0].Value = 8080
+	task.Resources.Networks[0].DynamicPorts = nil
+	task.Services = []*Service{
+		{Name: "frontend", PortLabel: "http", Tags: []string{"a", "b", "c"}},
+	}
+
+	diff, err := old.Diff(new, false)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	expected := []*RoutingChange{
+		{
+			Type:      DiffTypeDeleted,
+			Kind:      RoutingChangePort,
+			TaskGroup: "web",
+			Task:      "frontend",
+			Name:      "admin",
+			Old:       "dynamic",
+		},
+		{
+			Type:      DiffTypeEdited,
+			Kind:      RoutingChangePort,
+			TaskGroup: "web",
+			Task:      "frontend",
+			Name:      "http",
+			Old:       "80",
+			New:       "8080",
+		},
+		{
+			Type:      DiffTypeDeleted,
+			Kind:      RoutingChangeService,
+			TaskGroup: "web",
+			Task:      "frontend",
+			Name:      "admin",
+			Old:       "port admin",
+		},
+		{
+			Type:      DiffTypeEdited,
+			Kind:      RoutingChangeServiceTags,
+			TaskGroup: "web",
+			Task:      "frontend",
+			Name:      "frontend",
+			Old:       "a,b",
+			New:       "a,b,c",
+		},
+	}
+	if !reflect.DeepEqual(diff.RoutingChanges, expected) {
+		t.Fatalf("got %#v; want %#v", diff.RoutingChanges, expected)
+	}
+
+	// Jobs without routing changes have none
+	diff, err = old.Diff(old.Copy(), false)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(diff.RoutingChanges) != 0 {
+		t.Fatalf("unexpected routing changes: %#v", diff.RoutingChanges)
+	}
+}
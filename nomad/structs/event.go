@@ -0,0 +1,124 @@
+package structs
+
+import "strings"
+
+const (
+	// The topics of the event stream
+	TopicJob        = "Job"
+	TopicAllocation = "Allocation"
+	TopicDeployment = "Deployment"
+	TopicNode       = "Node"
+
+	// EventTypeUpserted and EventTypeDeleted are the types of events
+	EventTypeUpserted = "Upserted"
+	EventTypeDeleted  = "Deleted"
+
+	// AllNamespacesSentinel is the namespace that selects the events of every
+	// namespace the token can read.
+	AllNamespacesSentinel = "*"
+)
+
+// EventTopics are the topics that can be subscribed to.
+var EventTopics = []string{TopicJob, TopicAllocation, TopicDeployment, TopicNode}
+
+// Event is a change to an object of the state store.
+type Event struct {
+	Topic string
+	Type  string
+
+	// Key is the ID of the object
+	Key string
+
+	// Namespace is the namespace of the object, or empty for objects that
+	// are not namespaced such as nodes
+	Namespace string
+
+	// Index is the Raft index the change was observed at
+	Index uint64
+
+	Payload *EventPayload
+}
+
+// EventPayload holds the object of an event. Only the field of the event's
+// topic is set.
+type EventPayload struct {
+	Job        *Job
+	Allocation *Allocation
+	Deployment *Deployment
+	Node       *Node
+}
+
+// EventStreamRequest is used to read the events after an index.
+type EventStreamRequest struct {
+	// Topics limits the events to the given topics. All topics are returned
+	// if it is empty.
+	Topics []string
+
+	// The events after MinQueryIndex are returned, blocking up to
+	// MaxQueryTime if there are none. The namespace and key prefix of the
+	// events are filtered by the Namespace and Prefix query options;
+	// AllNamespacesSentinel selects all namespaces.
+	QueryOptions
+}
+
+// EventStreamResponse is a batch of events.
+type EventStreamResponse struct {
+	Events []*Event
+	QueryMeta
+}
+
+// EventRetentionRequest is used to read which events are retained.
+type EventRetentionRequest struct {
+	QueryOptions
+}
+
+// EventRetentionResponse reports the range of retained events.
+type EventRetentionResponse struct {
+	Retention *EventRetention
+	QueryMeta
+}
+
+// EventRetention is the range of events retained by a server. Events of an
+// index are retained or evicted together, so a subscriber that has received
+// the events up to OldestIndex - 1 or later can resume without missing any.
+type EventRetention struct {
+	// OldestIndex is the index of the oldest retained event, or zero if no
+	// events are retained
+	OldestIndex uint64
+
+	// LatestIndex is the index of the last published batch of events
+	LatestIndex uint64
+
+	// Durable is whether the events are retained on disk
+	Durable bool
+}
+
+// EventFilter selects the events a subscriber receives.
+type EventFilter struct {
+	Topics    []string
+	Namespace string
+	Prefix    string
+}
+
+// Matches returns whether the event passes the filter. Events that are not
+// namespaced pass any namespace filter.
+func (f *EventFilter) Matches(e *Event) bool {
+	if len(f.Topics) != 0 {
+		found := false
+		for _, t := range f.Topics {
+			if t == e.Topic {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if e.Namespace != "" && f.Namespace != AllNamespacesSentinel && e.Namespace != f.Namespace {
+		return false
+	}
+
+	return strings.HasPrefix(e.Key, f.Prefix)
+}
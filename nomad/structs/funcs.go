@@ -152,6 +152,20 @@ func AllocsFit(node *Node, allocs []*Allocation, netIdx *NetworkIndex) (bool, st
 		return false, "bandwidth exceeded", used, nil
 	}
 
+	// Check that no device is assigned twice
+	devIdx := NewDeviceIndex()
+	devIdx.SetNode(node)
+	if devIdx.AddAllocs(allocs) {
+		return false, "device collision", used, nil
+	}
+
+	// Check that no core is assigned twice
+	coreIdx := NewCoreIndex()
+	coreIdx.SetNode(node)
+	if coreIdx.AddAllocs(allocs) {
+		return false, "core collision", used, nil
+	}
+
 	// Allocations fit!
 	return true, "", used, nil
 }
@@ -292,3 +306,15 @@ func CompileACLObject(cache *lru.TwoQueueCache, policies []*ACLPolicy) (*acl.ACL
 	cache.Add(cacheKey, aclObj)
 	return aclObj, nil
 }
+
+// WorkloadACLPolicy returns the policy of the workload tokens of the
+// allocations in the namespace. Workloads can read the jobs, allocations,
+// services and variables of their namespace.
+func WorkloadACLPolicy(namespace string) *ACLPolicy {
+	rules := fmt.Sprintf("namespace %q {\n  capabilities = [%q, %q]\n}\n",
+		namespace, acl.NamespaceCapabilityReadJob, acl.NamespaceCapabilityReadVariables)
+	return &ACLPolicy{
+		Name:  "_workload-" + namespace,
+		Rules: rules,
+	}
+}
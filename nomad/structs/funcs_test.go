@@ -131,6 +131,64 @@ func TestAllocsFit_PortsOvercommitted(t *testing.T) {
 	}
 }
 
+func TestAllocsFit_DeviceCollision(t *testing.T) {
+	n := testSRIOVNode()
+	n.Resources.CPU = 2000
+	n.Resources.MemoryMB = 2048
+
+	a1 := &Allocation{
+		TaskResources: map[string]*Resources{
+			"web": {
+				Devices: []*DeviceResource{
+					{
+						Type:      DeviceTypeSRIOV,
+						Count:     1,
+						Instances: []*DeviceInstance{{ID: "0000:3b:02.0"}},
+					},
+				},
+			},
+		},
+	}
+
+	// Should fit one allocation
+	fit, dim, _, err := AllocsFit(n, []*Allocation{a1}, nil)
+	assert.Nil(t, err)
+	assert.True(t, fit, dim)
+
+	// Should not fit a second allocation using the same device
+	fit, dim, _, err = AllocsFit(n, []*Allocation{a1, a1}, nil)
+	assert.Nil(t, err)
+	assert.False(t, fit)
+	assert.Equal(t, "device collision", dim)
+}
+
+func TestAllocsFit_CoreCollision(t *testing.T) {
+	n := &Node{
+		Resources: &Resources{
+			CPU:      2000,
+			MemoryMB: 2048,
+			CoreIDs:  []int{0, 1},
+		},
+	}
+
+	a1 := &Allocation{
+		TaskResources: map[string]*Resources{
+			"web": {Cores: 1, CoreIDs: []int{0}},
+		},
+	}
+
+	// Should fit one allocation
+	fit, dim, _, err := AllocsFit(n, []*Allocation{a1}, nil)
+	assert.Nil(t, err)
+	assert.True(t, fit, dim)
+
+	// Should not fit a second allocation pinned to the same core
+	fit, dim, _, err = AllocsFit(n, []*Allocation{a1, a1}, nil)
+	assert.Nil(t, err)
+	assert.False(t, fit)
+	assert.Equal(t, "core collision", dim)
+}
+
 func TestAllocsFit(t *testing.T) {
 	n := &Node{
 		Resources: &Resources{
@@ -0,0 +1,225 @@
+package structs
+
+import (
+	"fmt"
+	"net"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+const (
+	// NetworkPolicyActionAllow allows the traffic matched by a rule.
+	NetworkPolicyActionAllow = "allow"
+
+	// NetworkPolicyActionDeny denies the traffic matched by a rule.
+	NetworkPolicyActionDeny = "deny"
+
+	// NetworkPolicyAnyNamespace matches the allocations of all namespaces as
+	// the source of a rule.
+	NetworkPolicyAnyNamespace = "*"
+)
+
+// NetworkPolicy controls the traffic between allocations on the bridge network
+// of a node. A policy selects allocations of its namespace by the names of
+// their services and lists rules allowing or denying traffic to them from
+// other allocations. Deny rules take precedence over allow rules, and traffic
+// no rule matches is allowed unless a policy selecting the destination sets
+// DefaultDeny.
+type NetworkPolicy struct {
+	// Name is unique within the namespace.
+	Name string
+
+	// Namespace is the namespace of the allocations the policy applies to.
+	Namespace string
+
+	// Description is a human readable description of the policy.
+	Description string
+
+	// Services are the names of the services of the allocations the policy
+	// applies to. A policy without services applies to all the allocations
+	// of the namespace.
+	Services []string
+
+	// DefaultDeny denies the traffic to the selected allocations which is
+	// not allowed by a rule.
+	DefaultDeny bool
+
+	// Rules match traffic to the selected allocations.
+	Rules []*NetworkPolicyRule
+
+	// Raft Indexes
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// NetworkPolicyRule allows or denies traffic from a set of allocations.
+type NetworkPolicyRule struct {
+	// Action is either allow or deny.
+	Action string
+
+	// Namespace is the namespace of the source allocations. It defaults to
+	// the namespace of the policy, and "*" matches all namespaces.
+	Namespace string
+
+	// Service is the name of a service of the source allocations. All the
+	// allocations of the namespace match if it is empty.
+	Service string
+
+	// CIDR is a block of IPv4 or IPv6 source addresses the rule matches
+	// instead of allocations. It can not be combined with a namespace or a
+	// service.
+	CIDR string
+
+	// Ports are the destination ports the rule applies to. The rule applies
+	// to all ports if there are none.
+	Ports []int
+}
+
+// Canonicalize sets the defaults of the policy.
+func (p *NetworkPolicy) Canonicalize() {
+	if p.Namespace == "" {
+		p.Namespace = DefaultNamespace
+	}
+	for _, r := range p.Rules {
+		if r.CIDR != "" {
+			// Store the block in its canonical form so equal blocks
+			// compare equal
+			if _, ipnet, err := net.ParseCIDR(r.CIDR); err == nil {
+				r.CIDR = ipnet.String()
+			}
+			continue
+		}
+		if r.Namespace == "" {
+			r.Namespace = p.Namespace
+		}
+	}
+}
+
+// Validate returns an error if the policy is invalid.
+func (p *NetworkPolicy) Validate() error {
+	var mErr multierror.Error
+	if !validPolicyName.MatchString(p.Name) {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid name %q", p.Name))
+	}
+	if len(p.Description) > maxPolicyDescriptionLength {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("description longer than %d", maxPolicyDescriptionLength))
+	}
+	for i, s := range p.Services {
+		if s == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("service %d is empty", i))
+		}
+	}
+	if len(p.Rules) == 0 && !p.DefaultDeny {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("policy must have rules or deny by default"))
+	}
+	for i, r := range p.Rules {
+		if err := r.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("rule %d invalid: %v", i, err))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Validate returns an error if the rule is invalid.
+func (r *NetworkPolicyRule) Validate() error {
+	switch r.Action {
+	case NetworkPolicyActionAllow, NetworkPolicyActionDeny:
+	default:
+		return fmt.Errorf("invalid action %q: must be one of %q or %q", r.Action,
+			NetworkPolicyActionAllow, NetworkPolicyActionDeny)
+	}
+	if r.CIDR != "" {
+		if _, _, err := net.ParseCIDR(r.CIDR); err != nil {
+			return fmt.Errorf("invalid CIDR %q: %v", r.CIDR, err)
+		}
+		if r.Namespace != "" || r.Service != "" {
+			return fmt.Errorf("CIDR rules can not match a namespace or service")
+		}
+	}
+	for _, port := range r.Ports {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port %d", port)
+		}
+	}
+	return nil
+}
+
+// Selects returns whether the policy applies to an allocation of the
+// namespace with the services.
+func (p *NetworkPolicy) Selects(namespace string, services []string) bool {
+	if namespace != p.Namespace {
+		return false
+	}
+	if len(p.Services) == 0 {
+		return true
+	}
+	for _, s := range p.Services {
+		for _, service := range services {
+			if s == service {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Matches returns whether the rule matches an allocation of the namespace
+// with the services as the source of traffic.
+func (r *NetworkPolicyRule) Matches(namespace string, services []string) bool {
+	if r.CIDR != "" {
+		return false
+	}
+	if r.Namespace != NetworkPolicyAnyNamespace && r.Namespace != namespace {
+		return false
+	}
+	if r.Service == "" {
+		return true
+	}
+	for _, service := range services {
+		if r.Service == service {
+			return true
+		}
+	}
+	return false
+}
+
+// NetworkPolicyUpsertRequest is used to create or update network policies.
+type NetworkPolicyUpsertRequest struct {
+	Policies []*NetworkPolicy
+	WriteRequest
+}
+
+// NetworkPolicyDeleteRequest is used to delete network policies of the
+// namespace of the request by name.
+type NetworkPolicyDeleteRequest struct {
+	Names []string
+	WriteRequest
+}
+
+// NetworkPolicySpecificRequest is used to query a network policy of the
+// namespace of the request.
+type NetworkPolicySpecificRequest struct {
+	Name string
+	QueryOptions
+}
+
+// SingleNetworkPolicyResponse is used to return a single network policy.
+type SingleNetworkPolicyResponse struct {
+	Policy *NetworkPolicy
+	QueryMeta
+}
+
+// NetworkPolicyListRequest is used to list the network policies of the
+// namespace of the request. Clients authenticate using their node ID and
+// secret, and are returned the policies of all namespaces.
+type NetworkPolicyListRequest struct {
+	NodeID   string
+	SecretID string
+	QueryOptions
+}
+
+// NetworkPolicyListResponse is used to return a list of network policies.
+type NetworkPolicyListResponse struct {
+	Policies []*NetworkPolicy
+	QueryMeta
+}
@@ -0,0 +1,95 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetworkPolicy_Validate(t *testing.T) {
+	assert := assert.New(t)
+
+	p := &NetworkPolicy{
+		Name: "db",
+		Rules: []*NetworkPolicyRule{
+			{Action: NetworkPolicyActionAllow, Service: "web", Ports: []int{5432}},
+		},
+	}
+	p.Canonicalize()
+	assert.Equal(DefaultNamespace, p.Namespace)
+	assert.Equal(DefaultNamespace, p.Rules[0].Namespace)
+	assert.Nil(p.Validate())
+
+	// Policies must do something
+	p.Rules = nil
+	assert.NotNil(p.Validate())
+	p.DefaultDeny = true
+	assert.Nil(p.Validate())
+
+	p.Rules = []*NetworkPolicyRule{{Action: "reject"}}
+	err := p.Validate()
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "invalid action")
+	}
+
+	p.Rules = []*NetworkPolicyRule{{Action: NetworkPolicyActionDeny, Ports: []int{70000}}}
+	err = p.Validate()
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "invalid port")
+	}
+
+	p.Rules = nil
+	p.Name = "bad name"
+	assert.NotNil(p.Validate())
+}
+
+func TestNetworkPolicy_CIDRRules(t *testing.T) {
+	assert := assert.New(t)
+
+	p := &NetworkPolicy{
+		Name: "db",
+		Rules: []*NetworkPolicyRule{
+			{Action: NetworkPolicyActionAllow, CIDR: "2001:DB8:0:0::1/64"},
+			{Action: NetworkPolicyActionDeny, CIDR: "10.0.0.7/8"},
+		},
+	}
+	p.Canonicalize()
+	assert.Equal("2001:db8::/64", p.Rules[0].CIDR)
+	assert.Equal("10.0.0.0/8", p.Rules[1].CIDR)
+	assert.Empty(p.Rules[0].Namespace)
+	assert.Nil(p.Validate())
+
+	// CIDR rules do not match allocations
+	assert.False(p.Rules[0].Matches(DefaultNamespace, nil))
+
+	p.Rules = []*NetworkPolicyRule{{Action: NetworkPolicyActionAllow, CIDR: "2001:db8::/129"}}
+	err := p.Validate()
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "invalid CIDR")
+	}
+
+	p.Rules = []*NetworkPolicyRule{{Action: NetworkPolicyActionAllow, CIDR: "10.0.0.0/8", Service: "web"}}
+	assert.NotNil(p.Validate())
+}
+
+func TestNetworkPolicy_SelectsMatches(t *testing.T) {
+	assert := assert.New(t)
+
+	p := &NetworkPolicy{Name: "db", Namespace: "default", Services: []string{"db"}}
+	assert.True(p.Selects("default", []string{"web", "db"}))
+	assert.False(p.Selects("default", []string{"web"}))
+	assert.False(p.Selects("other", []string{"db"}))
+
+	// Policies without services select the whole namespace
+	p.Services = nil
+	assert.True(p.Selects("default", nil))
+
+	r := &NetworkPolicyRule{Action: NetworkPolicyActionAllow, Namespace: "default", Service: "web"}
+	assert.True(r.Matches("default", []string{"web"}))
+	assert.False(r.Matches("default", []string{"db"}))
+	assert.False(r.Matches("other", []string{"web"}))
+
+	r.Namespace = NetworkPolicyAnyNamespace
+	r.Service = ""
+	assert.True(r.Matches("other", nil))
+}
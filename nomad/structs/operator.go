@@ -119,3 +119,128 @@ type AutopilotConfig struct {
 	CreateIndex uint64
 	ModifyIndex uint64
 }
+
+// ImageQueryRequest is used to find the allocations running tasks started from
+// a given image.
+type ImageQueryRequest struct {
+	// ImageDigest is the digest of the image to lookup.
+	ImageDigest string
+
+	QueryOptions
+}
+
+// ImageQueryResponse lists the non-terminal allocations running tasks started
+// from the queried image.
+type ImageQueryResponse struct {
+	Allocations []*AllocListStub
+	QueryMeta
+}
+
+// InventoryRequest is used to request a point-in-time inventory of the nodes
+// in the cluster and the allocations placed on them.
+type InventoryRequest struct {
+	QueryOptions
+}
+
+// InventoryResponse is the inventory of the cluster built from a single
+// snapshot of the state store.
+type InventoryResponse struct {
+	Nodes []*InventoryNode
+	QueryMeta
+}
+
+// InventoryNode describes a node and the allocations placed on it.
+type InventoryNode struct {
+	ID          string
+	Name        string
+	Datacenter  string
+	NodeClass   string
+	Status      string
+	Drain       bool
+	Resources   *Resources
+	Allocations []*InventoryAllocation
+}
+
+// InventoryAllocation describes an allocation and the tasks it runs.
+type InventoryAllocation struct {
+	ID            string
+	Namespace     string
+	JobID         string
+	JobVersion    uint64
+	TaskGroup     string
+	DesiredStatus string
+	ClientStatus  string
+	Tasks         []*InventoryTask
+}
+
+// InventoryTask describes the software a task runs and the resources it
+// was allocated.
+type InventoryTask struct {
+	Name      string
+	Driver    string
+	Image     string
+	Artifacts []string
+	Resources *Resources
+}
+
+// UtilizationRequest is used to request the resource utilization of the
+// cluster.
+type UtilizationRequest struct {
+	QueryOptions
+}
+
+// UtilizationResponse is the resource utilization of the cluster grouped by
+// datacenter and node class, built from a single snapshot of the state store.
+type UtilizationResponse struct {
+	Groups []*UtilizationGroup
+	QueryMeta
+}
+
+// UtilizationGroup is the resource utilization of the nodes of a datacenter
+// and node class.
+type UtilizationGroup struct {
+	Datacenter string
+	NodeClass  string
+
+	// Nodes is the number of ready nodes in the group
+	Nodes int
+
+	// Total is the capacity of the ready nodes, excluding reserved resources
+	Total *UtilizationResources
+
+	// Allocated is the resources of the non-terminal allocations placed on
+	// the nodes of the group
+	Allocated *UtilizationResources
+
+	// Running is the resources of the allocations the clients report as
+	// running
+	Running *UtilizationResources
+}
+
+// UtilizationResources is an amount of the resources tracked by the
+// utilization endpoint.
+type UtilizationResources struct {
+	CPU      int
+	MemoryMB int
+	DiskMB   int
+}
+
+// Add adds the CPU, memory and disk of the resources.
+func (u *UtilizationResources) Add(r *Resources) {
+	if r == nil {
+		return
+	}
+	u.CPU += r.CPU
+	u.MemoryMB += r.MemoryMB
+	u.DiskMB += r.DiskMB
+}
+
+// Subtract subtracts the CPU, memory and disk of the resources.
+func (u *UtilizationResources) Subtract(r *Resources) {
+	if r == nil {
+		return
+	}
+	u.CPU -= r.CPU
+	u.MemoryMB -= r.MemoryMB
+	u.DiskMB -= r.DiskMB
+}
@@ -0,0 +1,89 @@
+package structs
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// QuarantineTypeImage marks an entry as the digest of an image.
+	QuarantineTypeImage = "image"
+
+	// QuarantineTypeArtifact marks an entry as the checksum of an artifact.
+	QuarantineTypeArtifact = "artifact"
+)
+
+// validQuarantineDigest matches digests and checksums of the form
+// "<algorithm>:<hex>", as used by image digests and go-getter checksums.
+var validQuarantineDigest = regexp.MustCompile("^[a-z0-9]+:[a-f0-9]+$")
+
+// QuarantineEntry is an image digest or artifact checksum that is denied
+// cluster-wide. Jobs referencing a quarantined digest are rejected at
+// admission and clients refuse to start tasks that resolve to one.
+type QuarantineEntry struct {
+	// Digest is the image digest or artifact checksum, such as
+	// "sha256:e9ed...".
+	Digest string
+
+	// Type is the kind of object the digest identifies.
+	Type string
+
+	// Reason is a human readable explanation of why the entry was added.
+	Reason string
+
+	// Raft Indexes
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Canonicalize normalizes the digest of the entry.
+func (q *QuarantineEntry) Canonicalize() {
+	q.Digest = NormalizeQuarantineDigest(q.Digest)
+}
+
+// Validate returns an error if the entry is invalid.
+func (q *QuarantineEntry) Validate() error {
+	if !validQuarantineDigest.MatchString(q.Digest) {
+		return fmt.Errorf("invalid digest %q: must be of the form <algorithm>:<hex>", q.Digest)
+	}
+	switch q.Type {
+	case QuarantineTypeImage, QuarantineTypeArtifact:
+	default:
+		return fmt.Errorf("invalid type %q: must be one of %q or %q", q.Type,
+			QuarantineTypeImage, QuarantineTypeArtifact)
+	}
+	return nil
+}
+
+// NormalizeQuarantineDigest returns the digest in the form it is stored in the
+// quarantine list.
+func NormalizeQuarantineDigest(digest string) string {
+	return strings.ToLower(strings.TrimSpace(digest))
+}
+
+// QuarantineUpsertRequest is used to add or update quarantine entries.
+type QuarantineUpsertRequest struct {
+	Entries []*QuarantineEntry
+	WriteRequest
+}
+
+// QuarantineDeleteRequest is used to remove quarantine entries.
+type QuarantineDeleteRequest struct {
+	Digests []string
+	WriteRequest
+}
+
+// QuarantineListRequest is used to list the quarantine entries. Clients
+// authenticate using their node ID and secret.
+type QuarantineListRequest struct {
+	NodeID   string
+	SecretID string
+	QueryOptions
+}
+
+// QuarantineListResponse is used to return the quarantine entries.
+type QuarantineListResponse struct {
+	Entries []*QuarantineEntry
+	QueryMeta
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/hashicorp/nomad/helper/args"
 	"github.com/hashicorp/nomad/helper/uuid"
 	"github.com/mitchellh/copystructure"
+	"github.com/mitchellh/hashstructure"
 	"github.com/ugorji/go/codec"
 
 	hcodec "github.com/hashicorp/go-msgpack/codec"
@@ -42,7 +43,13 @@ var (
 	ErrNoLeader         = fmt.Errorf("No cluster leader")
 	ErrNoRegionPath     = fmt.Errorf("No path to region")
 	ErrTokenNotFound    = errors.New("ACL token not found")
+	ErrTokenExpired     = errors.New("ACL token expired")
 	ErrPermissionDenied = errors.New("Permission denied")
+	ErrTooManyRequests  = errors.New("Too many requests")
+
+	// ErrNodeDeltaMismatch is returned when a node delta doesn't apply to
+	// the registration of the node
+	ErrNodeDeltaMismatch = errors.New("Node delta does not match the node registration")
 
 	// validPolicyName is used to validate a policy name
 	validPolicyName = regexp.MustCompile("^[a-zA-Z0-9-]{1,128}$")
@@ -79,6 +86,19 @@ const (
 	ACLTokenDeleteRequestType
 	ACLTokenBootstrapRequestType
 	AutopilotRequestType
+	QuarantineUpsertRequestType
+	QuarantineDeleteRequestType
+	JobBatchDispatchRequestType
+	NetworkPolicyUpsertRequestType
+	NetworkPolicyDeleteRequestType
+	DeploymentConcurrencyRequestType
+	DeploymentCanaryAnalysisRequestType
+	VariableUpsertRequestType
+	VariableDeleteRequestType
+	RootKeyUpsertRequestType
+	AllocStopRequestType
+	ACLAuthMethodUpsertRequestType
+	ACLAuthMethodDeleteRequestType
 )
 
 const (
@@ -108,6 +128,10 @@ const (
 	GetterModeFile = "file"
 	GetterModeDir  = "dir"
 
+	// ArtifactOptionVaultPath is the artifact option naming the Vault secret
+	// holding the registry credentials of an OCI artifact
+	ArtifactOptionVaultPath = "vault_path"
+
 	// maxPolicyDescriptionLength limits a policy description length
 	maxPolicyDescriptionLength = 256
 
@@ -147,6 +171,7 @@ type NamespacedID struct {
 // RPCInfo is used to describe common information about query
 type RPCInfo interface {
 	RequestRegion() string
+	RequestToken() string
 	IsRead() bool
 	AllowStaleRead() bool
 }
@@ -166,6 +191,10 @@ type QueryOptions struct {
 	// Provided with MinQueryIndex to wait for change.
 	MaxQueryTime time.Duration
 
+	// If set, the query is served from the state as of the given index using
+	// the server's retained state history, and does not block.
+	IndexAt uint64
+
 	// If set, any follower can service the request. Results
 	// may be arbitrarily stale.
 	AllowStale bool
@@ -181,6 +210,10 @@ func (q QueryOptions) RequestRegion() string {
 	return q.Region
 }
 
+func (q QueryOptions) RequestToken() string {
+	return q.AuthToken
+}
+
 func (q QueryOptions) RequestNamespace() string {
 	if q.Namespace == "" {
 		return DefaultNamespace
@@ -213,6 +246,10 @@ func (w WriteRequest) RequestRegion() string {
 	return w.Region
 }
 
+func (w WriteRequest) RequestToken() string {
+	return w.AuthToken
+}
+
 func (w WriteRequest) RequestNamespace() string {
 	if w.Namespace == "" {
 		return DefaultNamespace
@@ -255,6 +292,11 @@ type WriteMeta struct {
 // to register a node as being a schedulable entity.
 type NodeRegisterRequest struct {
 	Node *Node
+
+	// Delta is sent in place of the node by clients updating their
+	// registration, so that their attributes aren't all sent again
+	Delta *NodeDelta
+
 	WriteRequest
 }
 
@@ -355,6 +397,48 @@ type JobRegisterRequest struct {
 	// PolicyOverride is set when the user is attempting to override any policies
 	PolicyOverride bool
 
+	// IdempotencyToken is an optional client supplied token. If the job was
+	// previously registered with the same token, the result of that
+	// registration is returned instead of registering the job again.
+	// Replaying a token with a different job is an error.
+	IdempotencyToken string
+
+	// Submission is the optional source the job was parsed from. It is stored
+	// alongside the registered job version.
+	Submission *JobSubmission
+
+	// Source is an optional client supplied description of where the job
+	// comes from, such as a git SHA. It is recorded in the provenance of the
+	// registered job version.
+	Source string
+
+	// SourceIP is the address of the client that submitted the job to the
+	// agent. It is set by the agent.
+	SourceIP string
+
+	WriteRequest
+}
+
+// JobPatchRequest is used for Job.Patch endpoint to update a registered job by
+// applying a JSON merge patch to its current version.
+type JobPatchRequest struct {
+	JobID string
+
+	// Patch is the JSON merge patch (RFC 7396) to apply to the job.
+	Patch []byte
+
+	// If EnforceIndex is set then the patch is only applied if the passed
+	// JobModifyIndex matches the current Jobs index.
+	EnforceIndex   bool
+	JobModifyIndex uint64
+
+	// PolicyOverride is set when the user is attempting to override any policies
+	PolicyOverride bool
+
+	// SourceIP is the address of the client that submitted the patch to the
+	// agent. It is set by the agent.
+	SourceIP string
+
 	WriteRequest
 }
 
@@ -377,6 +461,15 @@ type JobEvaluateRequest struct {
 	WriteRequest
 }
 
+// JobBatchEvaluateRequest is used to force the evaluation of all the jobs of
+// the namespace. Jobs are evaluated in order of their ID, at most BatchSize
+// per request, starting after NextToken.
+type JobBatchEvaluateRequest struct {
+	BatchSize int
+	NextToken string
+	WriteRequest
+}
+
 // JobSpecificRequest is used when we just need to specify a target job
 type JobSpecificRequest struct {
 	JobID     string
@@ -413,9 +506,37 @@ type JobDispatchRequest struct {
 	WriteRequest
 }
 
+// JobDispatchBatchRequest is used to dispatch many instances of a
+// parameterized job at once.
+type JobDispatchBatchRequest struct {
+	JobID string
+	Items []*JobDispatchItem
+	WriteRequest
+}
+
+// JobDispatchItem is the payload and meta data of a single dispatch in a
+// batch.
+type JobDispatchItem struct {
+	Payload []byte
+	Meta    map[string]string
+}
+
+// JobBatchDispatchRequest is used to register the child jobs and evaluations
+// of a batch dispatch in a single Raft apply.
+type JobBatchDispatchRequest struct {
+	Jobs  []*Job
+	Evals []*Evaluation
+	WriteRequest
+}
+
 // JobValidateRequest is used to validate a job
 type JobValidateRequest struct {
 	Job *Job
+
+	// Enforcement runs the admission checks, Sentinel policies and quota
+	// checks the job would be subject to if it was registered.
+	Enforcement bool
+
 	WriteRequest
 }
 
@@ -434,6 +555,20 @@ type JobRevertRequest struct {
 	WriteRequest
 }
 
+// JobRestartRequest is used to replace the allocations of a job without
+// changing its specification. The allocations are replaced like those of an
+// updated job, following the update strategy of the task groups.
+type JobRestartRequest struct {
+	// JobID is the ID of the job being restarted
+	JobID string
+
+	// EnforcePriorVersion if set will enforce that the job is at the given
+	// version before restarting.
+	EnforcePriorVersion *uint64
+
+	WriteRequest
+}
+
 // JobStabilityRequest is used to marked a job as stable.
 type JobStabilityRequest struct {
 	// Job to set the stability on
@@ -469,6 +604,24 @@ type EvalDeleteRequest struct {
 	WriteRequest
 }
 
+// EvalBatchDeleteRequest is used to delete the evaluations of the namespace
+// that match the filter. Evaluations are deleted in order of their ID, at most
+// BatchSize per request, starting after NextToken.
+type EvalBatchDeleteRequest struct {
+	Filter    string
+	BatchSize int
+	NextToken string
+	WriteRequest
+}
+
+// EvalDeleteByIDRequest is used to delete evaluations by ID. Unlike
+// EvalBatchDeleteRequest, blocked evaluations are deleted too, which is used to
+// remove the evaluations that are stuck.
+type EvalDeleteByIDRequest struct {
+	EvalIDs []string
+	WriteRequest
+}
+
 // EvalSpecificRequest is used when we just need to specify a target evaluation
 type EvalSpecificRequest struct {
 	EvalID string
@@ -492,9 +645,30 @@ type EvalDequeueRequest struct {
 
 // EvalListRequest is used to list the evaluations
 type EvalListRequest struct {
+	// FilterJobID, FilterEvalStatus and FilterTriggeredBy restrict the
+	// evaluations listed to those of the job, status and trigger.
+	FilterJobID       string
+	FilterEvalStatus  string
+	FilterTriggeredBy string
+
 	QueryOptions
 }
 
+// ShouldBeFiltered returns whether the evaluation is excluded by the filters of
+// the request.
+func (req *EvalListRequest) ShouldBeFiltered(e *Evaluation) bool {
+	if req.FilterJobID != "" && req.FilterJobID != e.JobID {
+		return true
+	}
+	if req.FilterEvalStatus != "" && req.FilterEvalStatus != e.Status {
+		return true
+	}
+	if req.FilterTriggeredBy != "" && req.FilterTriggeredBy != e.TriggeredBy {
+		return true
+	}
+	return false
+}
+
 // PlanRequest is used to submit an allocation plan to the leader
 type PlanRequest struct {
 	Plan *Plan
@@ -545,12 +719,64 @@ type AllocListRequest struct {
 	QueryOptions
 }
 
+// AllocBatchStopRequest is used to stop the running allocations of the
+// namespace that match the filter. Allocations are stopped in order of their
+// ID, at most BatchSize per request, starting after NextToken.
+type AllocBatchStopRequest struct {
+	Filter    string
+	BatchSize int
+	NextToken string
+	WriteRequest
+}
+
+// AllocStopRequest is used to stop allocations and create the evaluations
+// replacing them via Raft.
+type AllocStopRequest struct {
+	AllocIDs   []string
+	Evals      []*Evaluation
+	ModifyTime int64
+	WriteRequest
+}
+
+// PrometheusTargetsRequest is used to request the scrape targets of the
+// services running in the cluster
+type PrometheusTargetsRequest struct {
+	QueryOptions
+}
+
+// ServiceInstancesRequest is used by clients to look up the instances of the
+// services running in the namespace of an allocation on the node, to render
+// the templates of its tasks. Clients authenticate using their node ID and
+// secret.
+type ServiceInstancesRequest struct {
+	NodeID   string
+	SecretID string
+	AllocID  string
+
+	// Service is the name of the service to look up. The instances of every
+	// service are returned if it is empty.
+	Service string
+
+	QueryOptions
+}
+
 // AllocSpecificRequest is used to query a specific allocation
 type AllocSpecificRequest struct {
 	AllocID string
 	QueryOptions
 }
 
+// AllocHistoryRequest is used to query the archived allocations of a job
+type AllocHistoryRequest struct {
+	JobID string
+
+	// Since restricts the allocations to those that became terminal at or
+	// after the given Unix nanosecond timestamp. Zero returns all of them.
+	Since int64
+
+	QueryOptions
+}
+
 // AllocsGetRequest is used to query a set of allocations
 type AllocsGetRequest struct {
 	AllocIDs []string
@@ -626,12 +852,52 @@ type DeriveVaultTokenResponse struct {
 	QueryMeta
 }
 
+// DeriveWorkloadTokenRequest is used by clients to get the workload token of
+// an allocation running on the node. Clients authenticate using their node ID
+// and secret.
+type DeriveWorkloadTokenRequest struct {
+	NodeID   string
+	SecretID string
+	AllocID  string
+	WriteRequest
+}
+
+// DeriveWorkloadTokenResponse returns the secret ID of the workload token of
+// the allocation
+type DeriveWorkloadTokenResponse struct {
+	SecretID string
+	WriteMeta
+}
+
 // GenericRequest is used to request where no
 // specific information is needed.
 type GenericRequest struct {
 	QueryOptions
 }
 
+// SystemGCRequest is used to force the garbage collection of the eligible
+// objects. The garbage collection can be restricted to some collections, such
+// as GCCollectionEvals, and to the namespace of the request unless it is empty
+// or AllNamespacesSentinel. Nodes aren't namespaced so they are not collected
+// when restricted to a namespace.
+type SystemGCRequest struct {
+	// Collections restricts the garbage collection to the given collections.
+	// All the collections are garbage collected if it is empty.
+	Collections []string
+
+	// DryRun reports the objects that would be garbage collected without
+	// collecting them.
+	DryRun bool
+
+	WriteRequest
+}
+
+// Scoped returns whether the garbage collection is restricted or is a dry run.
+func (r *SystemGCRequest) Scoped() bool {
+	return len(r.Collections) != 0 || r.DryRun ||
+		(r.Namespace != "" && r.Namespace != AllNamespacesSentinel)
+}
+
 // DeploymentListRequest is used to list the deployments
 type DeploymentListRequest struct {
 	QueryOptions
@@ -747,6 +1013,17 @@ type GenericResponse struct {
 	WriteMeta
 }
 
+// SystemGCResponse is the response of a scoped garbage collection. It lists the
+// objects that were garbage collected, or would be for a dry run.
+type SystemGCResponse struct {
+	Jobs        []NamespacedID
+	Evals       []string
+	Allocs      []string
+	Nodes       []string
+	Deployments []string
+	WriteMeta
+}
+
 // VersionResponse is used for the Status.Version reseponse
 type VersionResponse struct {
 	Build    string
@@ -790,6 +1067,74 @@ type JobValidateResponse struct {
 	// Warnings contains any warnings about the given job. These may include
 	// deprecation warnings.
 	Warnings string
+
+	// EnforcementResults is the result of each enforcement check if
+	// enforcement was requested and the job is valid.
+	EnforcementResults []*JobEnforcementResult
+
+	// Job is the job as it would be registered, after it was canonicalized
+	// and mutated by the servers.
+	Job *Job
+}
+
+// JobRenderResponse is the response of rendering a job specification: the
+// job as it would be registered along with the diagnostics of parsing and
+// validating it.
+type JobRenderResponse struct {
+	// Job is the job as it would be registered. It is nil if the job
+	// specification couldn't be parsed.
+	Job *Job
+
+	// Diagnostics are the errors and warnings about the job specification
+	Diagnostics []*JobDiagnostic
+
+	// EnforcementResults is the result of each enforcement check if the job
+	// is valid.
+	EnforcementResults []*JobEnforcementResult
+}
+
+const (
+	JobDiagnosticError   = "error"
+	JobDiagnosticWarning = "warning"
+)
+
+// JobDiagnostic is an error or warning about a job specification. Errors
+// found while parsing the specification are positioned in its source.
+type JobDiagnostic struct {
+	// Severity is either "error" or "warning"
+	Severity string
+
+	// Summary describes the diagnostic
+	Summary string
+
+	// Filename, Line and Column are the position of the diagnostic in the
+	// source. Line and Column are zero if the position is unknown.
+	Filename string
+	Line     int
+	Column   int
+}
+
+const (
+	JobEnforcementCheckAdmission = "admission"
+	JobEnforcementCheckSentinel  = "sentinel"
+	JobEnforcementCheckQuota     = "quota"
+)
+
+// JobEnforcementResult is the result of an enforcement check run when
+// validating a job.
+type JobEnforcementResult struct {
+	// Check is the enforcement check that was run
+	Check string
+
+	// Passed is whether the job would be admitted by the check
+	Passed bool
+
+	// Error is the reason the check failed
+	Error string
+
+	// Warnings contains any warnings of the check, such as soft-mandatory
+	// Sentinel policy failures.
+	Warnings string
 }
 
 // NodeUpdateResponse is used to respond to a node update
@@ -872,6 +1217,59 @@ type JobDispatchResponse struct {
 	WriteMeta
 }
 
+// JobDispatchBatchResponse is used to respond to a batch dispatch. Results
+// are in the same order as the items of the request.
+type JobDispatchBatchResponse struct {
+	Results         []*JobDispatchItemResult
+	EvalCreateIndex uint64
+	JobCreateIndex  uint64
+	WriteMeta
+}
+
+// JobDispatchItemResult is the result of a single dispatch in a batch. Error
+// is set if the item was rejected, in which case no job was dispatched for it.
+type JobDispatchItemResult struct {
+	DispatchedJobID string
+	EvalID          string
+	Error           string
+}
+
+// JobBatchEvaluateResponse is the response of a batch of job evaluations.
+// NextToken is set if there are more jobs to evaluate.
+type JobBatchEvaluateResponse struct {
+	EvalIDs   []string
+	Skipped   int
+	NextToken string
+	WriteMeta
+}
+
+// EvalBatchDeleteResponse is the response of a batch of evaluation deletions.
+// Skipped counts the matching evaluations that were not deleted because they
+// are pending. NextToken is set if there are more evaluations to check.
+type EvalBatchDeleteResponse struct {
+	Deleted   int
+	Skipped   int
+	NextToken string
+	WriteMeta
+}
+
+// EvalDeleteByIDResponse is the response of an evaluation deletion by ID.
+// Skipped lists the evaluations that were not deleted because they are pending.
+type EvalDeleteByIDResponse struct {
+	Deleted []string
+	Skipped []string
+	WriteMeta
+}
+
+// AllocBatchStopResponse is the response of a batch of allocation stops.
+// NextToken is set if there are more allocations to check.
+type AllocBatchStopResponse struct {
+	Stopped   int
+	EvalIDs   []string
+	NextToken string
+	WriteMeta
+}
+
 // JobListResponse is used for a list request
 type JobListResponse struct {
 	Jobs []*JobListStub
@@ -885,6 +1283,21 @@ type JobVersionsRequest struct {
 	QueryOptions
 }
 
+// JobDiffVersionsRequest is used to diff two versions of a job
+type JobDiffVersionsRequest struct {
+	JobID      string
+	OldVersion uint64
+	NewVersion uint64
+	QueryOptions
+}
+
+// JobDiffVersionsResponse is used to return the diff between two versions of
+// a job
+type JobDiffVersionsResponse struct {
+	Diff *JobDiff
+	QueryMeta
+}
+
 // JobVersionsResponse is used for a job get versions request
 type JobVersionsResponse struct {
 	Versions []*Job
@@ -892,6 +1305,24 @@ type JobVersionsResponse struct {
 	QueryMeta
 }
 
+// JobSubmissionRequest is used to get the source a job version was submitted
+// with.
+type JobSubmissionRequest struct {
+	JobID string
+
+	// Version is the job version to get the source of. The current version
+	// is used if it is nil.
+	Version *uint64
+
+	QueryOptions
+}
+
+// JobSubmissionResponse is used to respond to a job submission request
+type JobSubmissionResponse struct {
+	Submission *JobSubmission
+	QueryMeta
+}
+
 // JobPlanResponse is used to respond to a job plan request
 type JobPlanResponse struct {
 	// Annotations stores annotations explaining decisions the scheduler made.
@@ -993,6 +1424,47 @@ type AllocListResponse struct {
 	QueryMeta
 }
 
+// AllocHistoryResponse is used to return the archived allocations of a job
+type AllocHistoryResponse struct {
+	Allocations []*ArchivedAllocation
+	QueryMeta
+}
+
+// PrometheusTargetsResponse is used to return the scrape targets of the
+// services running in the cluster
+type PrometheusTargetsResponse struct {
+	TargetGroups []*PrometheusTargetGroup
+	QueryMeta
+}
+
+// PrometheusTargetGroup is a set of targets sharing labels. Its encoding
+// matches the Prometheus HTTP service discovery format.
+type PrometheusTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// ServiceInstancesResponse is used to return the instances of services
+type ServiceInstancesResponse struct {
+	Instances []*ServiceInstance
+	QueryMeta
+}
+
+// ServiceInstance is an instance of a service of a running allocation, at the
+// address its port label resolves to.
+type ServiceInstance struct {
+	Name      string
+	Namespace string
+	JobID     string
+	TaskGroup string
+	Task      string
+	AllocID   string
+	NodeID    string
+	Address   string
+	Port      int
+	Tags      []string
+}
+
 // DeploymentListResponse is used for a list request
 type DeploymentListResponse struct {
 	Deployments []*Deployment
@@ -1191,6 +1663,114 @@ func (n *Node) Stub() *NodeListStub {
 	}
 }
 
+// NodeDelta is the change of a node since a registration of it. Nodes with
+// large fingerprints update their registration with deltas.
+type NodeDelta struct {
+	// Node is the node without its attributes, meta and links, whose changes
+	// are sent instead
+	Node *Node
+
+	Attributes MapDelta
+	Meta       MapDelta
+	Links      MapDelta
+
+	// Checksum is the checksum of the attributes, meta and links of the
+	// node, used to detect deltas applied to a different registration
+	Checksum uint64
+}
+
+// MapDelta is the change of a map of strings.
+type MapDelta struct {
+	Set     map[string]string
+	Deleted []string
+}
+
+// NewNodeDelta returns the delta from the base registration of the node.
+func NewNodeDelta(base, node *Node) (*NodeDelta, error) {
+	checksum, err := nodeChecksum(node)
+	if err != nil {
+		return nil, err
+	}
+
+	n := new(Node)
+	*n = *node
+	n.Attributes = nil
+	n.Meta = nil
+	n.Links = nil
+	n.Resources = n.Resources.Copy()
+	n.Reserved = n.Reserved.Copy()
+
+	return &NodeDelta{
+		Node:       n,
+		Attributes: newMapDelta(base.Attributes, node.Attributes),
+		Meta:       newMapDelta(base.Meta, node.Meta),
+		Links:      newMapDelta(base.Links, node.Links),
+		Checksum:   checksum,
+	}, nil
+}
+
+// Apply returns the node resulting from applying the delta to its base
+// registration. ErrNodeDeltaMismatch is returned if the registration isn't
+// the one the delta was computed from.
+func (d *NodeDelta) Apply(base *Node) (*Node, error) {
+	n := d.Node.Copy()
+	n.Attributes = d.Attributes.apply(base.Attributes)
+	n.Meta = d.Meta.apply(base.Meta)
+	n.Links = d.Links.apply(base.Links)
+
+	checksum, err := nodeChecksum(n)
+	if err != nil {
+		return nil, err
+	}
+	if checksum != d.Checksum {
+		return nil, ErrNodeDeltaMismatch
+	}
+	return n, nil
+}
+
+func newMapDelta(base, m map[string]string) MapDelta {
+	var d MapDelta
+	for k, v := range m {
+		if old, ok := base[k]; !ok || old != v {
+			if d.Set == nil {
+				d.Set = make(map[string]string)
+			}
+			d.Set[k] = v
+		}
+	}
+	for k := range base {
+		if _, ok := m[k]; !ok {
+			d.Deleted = append(d.Deleted, k)
+		}
+	}
+	return d
+}
+
+func (d MapDelta) apply(base map[string]string) map[string]string {
+	m := helper.CopyMapStringString(base)
+	if m == nil && len(d.Set) != 0 {
+		m = make(map[string]string, len(d.Set))
+	}
+	for k, v := range d.Set {
+		m[k] = v
+	}
+	for _, k := range d.Deleted {
+		delete(m, k)
+	}
+	return m
+}
+
+// nodeChecksum returns the checksum of the attributes, meta and links of the
+// node.
+func nodeChecksum(n *Node) (uint64, error) {
+	maps := []map[string]string{n.Attributes, n.Meta, n.Links}
+	checksum, err := hashstructure.Hash(maps, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute node checksum: %v", err)
+	}
+	return checksum, nil
+}
+
 // NodeListStub is used to return a subset of job information
 // for the job list
 type NodeListStub struct {
@@ -1235,6 +1815,20 @@ type Resources struct {
 	DiskMB   int
 	IOPS     int
 	Networks Networks
+	Devices  []*DeviceResource
+
+	// Cores is the number of whole cores a task asks to be pinned to.
+	// CoreIDs lists the logical CPUs of a node, or the cores assigned to a
+	// task once allocated.
+	Cores   int
+	CoreIDs []int
+
+	// IOWeight is the relative block IO weight of a task, overriding IOPS,
+	// and IOReadMBps and IOWriteMBps limit its disk bandwidth. They are
+	// applied by the client and not scheduled.
+	IOWeight    int
+	IOReadMBps  int
+	IOWriteMBps int
 }
 
 const (
@@ -1288,6 +1882,24 @@ func (r *Resources) Merge(other *Resources) {
 	if len(other.Networks) != 0 {
 		r.Networks = other.Networks
 	}
+	if len(other.Devices) != 0 {
+		r.Devices = other.Devices
+	}
+	if other.Cores != 0 {
+		r.Cores = other.Cores
+	}
+	if len(other.CoreIDs) != 0 {
+		r.CoreIDs = other.CoreIDs
+	}
+	if other.IOWeight != 0 {
+		r.IOWeight = other.IOWeight
+	}
+	if other.IOReadMBps != 0 {
+		r.IOReadMBps = other.IOReadMBps
+	}
+	if other.IOWriteMBps != 0 {
+		r.IOWriteMBps = other.IOWriteMBps
+	}
 }
 
 func (r *Resources) Canonicalize() {
@@ -1296,10 +1908,19 @@ func (r *Resources) Canonicalize() {
 	if len(r.Networks) == 0 {
 		r.Networks = nil
 	}
+	if len(r.Devices) == 0 {
+		r.Devices = nil
+	}
+	if len(r.CoreIDs) == 0 {
+		r.CoreIDs = nil
+	}
 
 	for _, n := range r.Networks {
 		n.Canonicalize()
 	}
+	for _, d := range r.Devices {
+		d.Canonicalize()
+	}
 }
 
 // MeetsMinResources returns an error if the resources specified are less than
@@ -1317,11 +1938,28 @@ func (r *Resources) MeetsMinResources() error {
 	if r.IOPS < minResources.IOPS {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum IOPS value is %d; got %d", minResources.IOPS, r.IOPS))
 	}
+	if r.Cores < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum Cores value is 0; got %d", r.Cores))
+	}
+	if r.IOWeight != 0 && (r.IOWeight < 10 || r.IOWeight > 1000) {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("IOWeight must be between 10 and 1000; got %d", r.IOWeight))
+	}
+	if r.IOReadMBps < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum IOReadMBps value is 0; got %d", r.IOReadMBps))
+	}
+	if r.IOWriteMBps < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum IOWriteMBps value is 0; got %d", r.IOWriteMBps))
+	}
 	for i, n := range r.Networks {
 		if err := n.MeetsMinResources(); err != nil {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("network resource at index %d failed: %v", i, err))
 		}
 	}
+	for i, d := range r.Devices {
+		if err := d.MeetsMinResources(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("device resource at index %d failed: %v", i, err))
+		}
+	}
 
 	return mErr.ErrorOrNil()
 }
@@ -1340,6 +1978,16 @@ func (r *Resources) Copy() *Resources {
 			newR.Networks[i] = r.Networks[i].Copy()
 		}
 	}
+	if r.Devices != nil {
+		newR.Devices = make([]*DeviceResource, len(r.Devices))
+		for i, d := range r.Devices {
+			newR.Devices[i] = d.Copy()
+		}
+	}
+	if r.CoreIDs != nil {
+		newR.CoreIDs = make([]int, len(r.CoreIDs))
+		copy(newR.CoreIDs, r.CoreIDs)
+	}
 	return newR
 }
 
@@ -1354,8 +2002,8 @@ func (r *Resources) NetIndex(n *NetworkResource) int {
 }
 
 // Superset checks if one set of resources is a superset
-// of another. This ignores network resources, and the NetworkIndex
-// should be used for that.
+// of another. This ignores network and device resources, and the
+// NetworkIndex and DeviceIndex should be used for those.
 func (r *Resources) Superset(other *Resources) (bool, string) {
 	if r.CPU < other.CPU {
 		return false, "cpu"
@@ -1399,6 +2047,102 @@ func (r *Resources) GoString() string {
 	return fmt.Sprintf("*%#v", *r)
 }
 
+const (
+	// DeviceTypeSRIOV is the device type of SR-IOV network virtual functions
+	DeviceTypeSRIOV = "sriov"
+)
+
+// DeviceResource is used to represent a set of devices of a type. A node
+// lists the devices it has, grouped by name. A task asks for Count devices of
+// the type, optionally of the named group and a minimum link speed, and once
+// allocated, Instances lists the devices assigned to it.
+type DeviceResource struct {
+	Type      string            // Type of the devices
+	Name      string            // Name of the group, the physical function for SR-IOV
+	Count     int               // Number of devices asked for
+	MBits     int               // Link speed
+	Instances []*DeviceInstance // Devices of the node or assigned to the task
+}
+
+// DeviceInstance is a single device
+type DeviceInstance struct {
+	ID        string // PCI address of the device
+	NUMANode  int    // NUMA node the device is attached to
+	LocalCPUs string // CPUs local to the device, to pin interrupts and threads to
+}
+
+func (d *DeviceResource) Canonicalize() {
+	// Ensure that an empty and nil slices are treated the same to avoid scheduling
+	// problems since we use reflect DeepEquals.
+	if len(d.Instances) == 0 {
+		d.Instances = nil
+	}
+}
+
+// MeetsMinResources returns an error if the device ask is invalid
+func (d *DeviceResource) MeetsMinResources() error {
+	var mErr multierror.Error
+	if d.Type == "" {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("device type must be specified"))
+	}
+	if d.Count < 1 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum Count value is 1; got %d", d.Count))
+	}
+	if d.MBits < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("MBits can not be negative; got %d", d.MBits))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Copy returns a deep copy of the device resource
+func (d *DeviceResource) Copy() *DeviceResource {
+	if d == nil {
+		return nil
+	}
+	newD := new(DeviceResource)
+	*newD = *d
+	if d.Instances != nil {
+		newD.Instances = make([]*DeviceInstance, len(d.Instances))
+		for i, inst := range d.Instances {
+			newInst := *inst
+			newD.Instances[i] = &newInst
+		}
+	}
+	return newD
+}
+
+// Matches returns whether the devices of the node are of the type and group
+// asked for.
+func (d *DeviceResource) Matches(ask *DeviceResource) bool {
+	if d.Type != ask.Type {
+		return false
+	}
+	if ask.Name != "" && d.Name != ask.Name {
+		return false
+	}
+	return d.MBits >= ask.MBits
+}
+
+// InstanceIDs returns the IDs of the devices
+func (d *DeviceResource) InstanceIDs() []string {
+	ids := make([]string, len(d.Instances))
+	for i, inst := range d.Instances {
+		ids[i] = inst.ID
+	}
+	return ids
+}
+
+// DevicesOfType returns the devices of the given type
+func (r *Resources) DevicesOfType(t string) []*DeviceInstance {
+	var out []*DeviceInstance
+	for _, d := range r.Devices {
+		if d.Type == t {
+			out = append(out, d.Instances...)
+		}
+	}
+	return out
+}
+
 type Port struct {
 	Label string
 	Value int
@@ -1581,6 +2325,10 @@ type Job struct {
 	// for dispatching.
 	ParameterizedJob *ParameterizedJobConfig
 
+	// Secrets declares the secrets the tasks of the job depend on so that
+	// they can be verified when the job is planned.
+	Secrets *SecretDependencies
+
 	// Payload is the payload supplied when the job was dispatched.
 	Payload []byte
 
@@ -1612,6 +2360,18 @@ type Job struct {
 	// UTC
 	SubmitTime int64
 
+	// Restarts is the number of times the job was restarted. It is managed
+	// by the servers: incrementing it creates a new version of the job whose
+	// allocations are all replaced.
+	Restarts uint64
+
+	// IdempotencyToken is the token supplied with the registration that
+	// created this version of the job.
+	IdempotencyToken string
+
+	// Provenance records who submitted this version of the job and from where
+	Provenance *JobProvenance
+
 	// Raft Indexes
 	CreateIndex    uint64
 	ModifyIndex    uint64
@@ -1754,6 +2514,8 @@ func (j *Job) Copy() *Job {
 	nj.Periodic = nj.Periodic.Copy()
 	nj.Meta = helper.CopyMapStringString(nj.Meta)
 	nj.ParameterizedJob = nj.ParameterizedJob.Copy()
+	nj.Secrets = nj.Secrets.Copy()
+	nj.Provenance = nj.Provenance.Copy()
 	return nj
 }
 
@@ -1847,6 +2609,13 @@ func (j *Job) Validate() error {
 		}
 	}
 
+	if j.Secrets != nil {
+		if err := j.Secrets.Validate(); err != nil {
+			outer := fmt.Errorf("Secrets validation failed: %v", err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+	}
+
 	return mErr.ErrorOrNil()
 }
 
@@ -2061,6 +2830,8 @@ func (j *Job) SpecChanged(new *Job) bool {
 	c.ModifyIndex = j.ModifyIndex
 	c.JobModifyIndex = j.JobModifyIndex
 	c.SubmitTime = j.SubmitTime
+	c.IdempotencyToken = j.IdempotencyToken
+	c.Provenance = j.Provenance
 
 	// Deep equals the jobs
 	return !reflect.DeepEqual(j, c)
@@ -2070,6 +2841,31 @@ func (j *Job) SetSubmitTime() {
 	j.SubmitTime = time.Now().UTC().UnixNano()
 }
 
+// JobProvenance records who submitted a version of a job and from where, so
+// that operators can trace who deployed what.
+type JobProvenance struct {
+	// TokenAccessor is the accessor ID of the ACL token the job was submitted
+	// with. It is empty if ACLs are disabled.
+	TokenAccessor string
+
+	// SourceIP is the address of the client that submitted the job to the
+	// agent
+	SourceIP string
+
+	// Source is the optional client supplied description of where the job
+	// comes from, such as a git SHA
+	Source string
+}
+
+func (p *JobProvenance) Copy() *JobProvenance {
+	if p == nil {
+		return nil
+	}
+	np := new(JobProvenance)
+	*np = *p
+	return np
+}
+
 // JobListStub is used to return a subset of job information
 // for the job list
 type JobListStub struct {
@@ -2471,6 +3267,57 @@ func (d *ParameterizedJobConfig) Copy() *ParameterizedJobConfig {
 	return nd
 }
 
+// SecretDependencies are the secrets the tasks of a job read from their
+// templates and environment. Declaring them lets the servers verify that they
+// exist and can be accessed when the job is planned, rather than the tasks
+// failing to render their templates once placed.
+type SecretDependencies struct {
+	// Vault are the Vault paths the tasks read
+	Vault []string
+
+	// Variables are the paths of the variables the tasks read
+	Variables []string
+
+	// Consul are the Consul KV keys the tasks read
+	Consul []string
+}
+
+// Empty returns whether no secrets are declared
+func (s *SecretDependencies) Empty() bool {
+	return s == nil || len(s.Vault)+len(s.Variables)+len(s.Consul) == 0
+}
+
+func (s *SecretDependencies) Copy() *SecretDependencies {
+	if s == nil {
+		return nil
+	}
+	ns := new(SecretDependencies)
+	ns.Vault = helper.CopySliceString(s.Vault)
+	ns.Variables = helper.CopySliceString(s.Variables)
+	ns.Consul = helper.CopySliceString(s.Consul)
+	return ns
+}
+
+func (s *SecretDependencies) Validate() error {
+	var mErr multierror.Error
+	for _, p := range s.Vault {
+		if p == "" || strings.HasPrefix(p, "/") {
+			multierror.Append(&mErr, fmt.Errorf("Invalid Vault path %q", p))
+		}
+	}
+	for _, p := range s.Variables {
+		if !validVariablePath.MatchString(p) {
+			multierror.Append(&mErr, fmt.Errorf("Invalid variable path %q", p))
+		}
+	}
+	for _, k := range s.Consul {
+		if k == "" || strings.HasPrefix(k, "/") {
+			multierror.Append(&mErr, fmt.Errorf("Invalid Consul key %q", k))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
 // DispatchedID returns an ID appropriate for a job dispatched against a
 // particular parameterized job
 func DispatchedID(templateID string, t time.Time) string {
@@ -2502,7 +3349,87 @@ func (d *DispatchPayloadConfig) Validate() error {
 		return fmt.Errorf("destination escapes allocation directory")
 	}
 
-	return nil
+	return nil
+}
+
+// MigrateStrategy overrides how a draining client migrates the allocations of
+// a task group.
+type MigrateStrategy struct {
+	// Deadline is how long a draining client waits for the allocations of
+	// the group to stop, overriding the drain deadline of the client
+	Deadline time.Duration
+
+	// Ignore tells a draining client not to wait for the allocations of the
+	// group, which are killed as soon as the drain starts
+	Ignore bool
+}
+
+func (m *MigrateStrategy) Copy() *MigrateStrategy {
+	if m == nil {
+		return nil
+	}
+	nm := new(MigrateStrategy)
+	*nm = *m
+	return nm
+}
+
+func (m *MigrateStrategy) Validate() error {
+	var mErr multierror.Error
+	if m.Deadline < 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Deadline must not be negative"))
+	}
+	if m.Ignore && m.Deadline != 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("Deadline can't be set when ignoring drains"))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// DNSConfig configures the resolver of the tasks of an allocation. Servers,
+// search domains and options may interpolate node attributes.
+type DNSConfig struct {
+	// Servers are the addresses of the name servers
+	Servers []string
+
+	// Searches are the search domains
+	Searches []string
+
+	// Options are the resolver options, such as "ndots:2"
+	Options []string
+}
+
+func (d *DNSConfig) Copy() *DNSConfig {
+	if d == nil {
+		return nil
+	}
+	return &DNSConfig{
+		Servers:  helper.CopySliceString(d.Servers),
+		Searches: helper.CopySliceString(d.Searches),
+		Options:  helper.CopySliceString(d.Options),
+	}
+}
+
+func (d *DNSConfig) Validate() error {
+	var mErr multierror.Error
+	for _, server := range d.Servers {
+		// Interpolated servers are only known on the client
+		if strings.Contains(server, "${") {
+			continue
+		}
+		if net.ParseIP(server) == nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid DNS server %q: must be an IP address", server))
+		}
+	}
+	for _, search := range d.Searches {
+		if search == "" || strings.ContainsAny(search, " \t") {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid DNS search domain %q", search))
+		}
+	}
+	for _, option := range d.Options {
+		if option == "" || strings.ContainsAny(option, " \t") {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid DNS option %q", option))
+		}
+	}
+	return mErr.ErrorOrNil()
 }
 
 var (
@@ -2625,6 +3552,14 @@ type TaskGroup struct {
 	// EphemeralDisk is the disk resources that the task group requests
 	EphemeralDisk *EphemeralDisk
 
+	// DNS configures the resolver of the tasks of the group that don't
+	// override it
+	DNS *DNSConfig
+
+	// Migrate overrides how a draining client migrates the allocations of
+	// the group
+	Migrate *MigrateStrategy
+
 	// Meta is used to associate arbitrary metadata with this
 	// task group. This is opaque to Nomad.
 	Meta map[string]string
@@ -2653,6 +3588,8 @@ func (tg *TaskGroup) Copy() *TaskGroup {
 	if tg.EphemeralDisk != nil {
 		ntg.EphemeralDisk = tg.EphemeralDisk.Copy()
 	}
+	ntg.DNS = ntg.DNS.Copy()
+	ntg.Migrate = ntg.Migrate.Copy()
 	return ntg
 }
 
@@ -2727,6 +3664,18 @@ func (tg *TaskGroup) Validate(j *Job) error {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("Task Group %v should have an ephemeral disk object", tg.Name))
 	}
 
+	if tg.DNS != nil {
+		if err := tg.DNS.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("DNS validation failed: %v", err))
+		}
+	}
+
+	if tg.Migrate != nil {
+		if err := tg.Migrate.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Migrate validation failed: %v", err))
+		}
+	}
+
 	// Validate the update strategy
 	if u := tg.Update; u != nil {
 		switch j.Type {
@@ -3202,6 +4151,10 @@ const (
 type LogConfig struct {
 	MaxFiles      int
 	MaxFileSizeMB int
+
+	// Sinks are the remote destinations the logs are shipped to, in
+	// addition to the rotated files
+	Sinks []*LogSink
 }
 
 // DefaultLogConfig returns the default LogConfig values.
@@ -3212,6 +4165,21 @@ func DefaultLogConfig() *LogConfig {
 	}
 }
 
+func (l *LogConfig) Copy() *LogConfig {
+	if l == nil {
+		return nil
+	}
+	nl := new(LogConfig)
+	*nl = *l
+	if l.Sinks != nil {
+		nl.Sinks = make([]*LogSink, len(l.Sinks))
+		for i, sink := range l.Sinks {
+			nl.Sinks[i] = sink.Copy()
+		}
+	}
+	return nl
+}
+
 // Validate returns an error if the log config specified are less than
 // the minimum allowed.
 func (l *LogConfig) Validate() error {
@@ -3222,6 +4190,151 @@ func (l *LogConfig) Validate() error {
 	if l.MaxFileSizeMB < 1 {
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("minimum file size is 1MB; got %d", l.MaxFileSizeMB))
 	}
+	for i, sink := range l.Sinks {
+		if err := sink.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("sink %d validation failed: %v", i+1, err))
+		}
+	}
+	return mErr.ErrorOrNil()
+}
+
+const (
+	// LogSinkSyslog ships the log lines as syslog messages over UDP, TCP or
+	// a unix socket
+	LogSinkSyslog = "syslog"
+
+	// LogSinkHTTP posts batches of log lines as newline delimited JSON
+	LogSinkHTTP = "http"
+
+	// LogSinkLoki pushes batches of log lines to the push API of Loki
+	LogSinkLoki = "loki"
+)
+
+// LogSink is a remote destination the logs of a task are shipped to by the
+// client.
+type LogSink struct {
+	// Type is the type of the sink: syslog, http or loki
+	Type string
+
+	// Address is the URL of the sink. Syslog sinks are given as
+	// udp://host:port, tcp://host:port or unix:///path.
+	Address string
+
+	// Labels are attached to the shipped lines, along with the job, task,
+	// allocation and stream of the lines
+	Labels map[string]string
+}
+
+func (l *LogSink) Copy() *LogSink {
+	if l == nil {
+		return nil
+	}
+	nl := new(LogSink)
+	*nl = *l
+	nl.Labels = helper.CopyMapStringString(l.Labels)
+	return nl
+}
+
+// Validate returns an error if the sink has an unknown type or its address
+// isn't valid for its type.
+func (l *LogSink) Validate() error {
+	u, err := url.Parse(l.Address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %v", l.Address, err)
+	}
+
+	var schemes []string
+	switch l.Type {
+	case LogSinkSyslog:
+		schemes = []string{"udp", "tcp", "unix"}
+	case LogSinkHTTP, LogSinkLoki:
+		schemes = []string{"http", "https"}
+	default:
+		return fmt.Errorf("invalid sink type %q; must be one of: %s, %s, %s", l.Type, LogSinkSyslog, LogSinkHTTP, LogSinkLoki)
+	}
+
+	for _, scheme := range schemes {
+		if u.Scheme == scheme {
+			return nil
+		}
+	}
+	return fmt.Errorf("address %q of %s sink must use one of the schemes: %s", l.Address, l.Type, strings.Join(schemes, ", "))
+}
+
+const (
+	// ConnectivityCheckTCP checks that a TCP connection can be established
+	ConnectivityCheckTCP = "tcp"
+
+	// ConnectivityCheckHTTP checks that an HTTP GET request returns a 2xx
+	// status
+	ConnectivityCheckHTTP = "http"
+)
+
+// ConnectivityCheck verifies that an address the task depends on, such as
+// its database or one of its own ports, is reachable once the task is
+// started. The check is retried until it passes and the allocation isn't
+// healthy for its deployment until all of its checks pass.
+type ConnectivityCheck struct {
+	// Name of the check, unique within the task
+	Name string
+
+	// Type is the type of the check: tcp or http
+	Type string
+
+	// Address is the host:port to check. It is interpolated with the
+	// environment of the task.
+	Address string
+
+	// PortLabel is the label of the task's port to check instead of an
+	// address
+	PortLabel string
+
+	// Path is the path of the HTTP request
+	Path string
+
+	// Interval is the interval at which the check is retried until it passes
+	Interval time.Duration
+
+	// Timeout is the timeout of each attempt
+	Timeout time.Duration
+}
+
+func (c *ConnectivityCheck) Copy() *ConnectivityCheck {
+	if c == nil {
+		return nil
+	}
+	nc := new(ConnectivityCheck)
+	*nc = *c
+	return nc
+}
+
+func (c *ConnectivityCheck) Validate() error {
+	var mErr multierror.Error
+	if c.Name == "" {
+		multierror.Append(&mErr, fmt.Errorf("Missing name"))
+	}
+
+	switch c.Type {
+	case ConnectivityCheckTCP:
+		if c.Path != "" {
+			multierror.Append(&mErr, fmt.Errorf("Path is only valid for %q checks", ConnectivityCheckHTTP))
+		}
+	case ConnectivityCheckHTTP:
+	default:
+		multierror.Append(&mErr, fmt.Errorf("Invalid type %q; must be %q or %q",
+			c.Type, ConnectivityCheckTCP, ConnectivityCheckHTTP))
+	}
+
+	if (c.Address == "") == (c.PortLabel == "") {
+		multierror.Append(&mErr, fmt.Errorf("Exactly one of address and port must be set"))
+	}
+
+	if c.Interval <= 0 {
+		multierror.Append(&mErr, fmt.Errorf("Interval must be positive"))
+	}
+	if c.Timeout <= 0 {
+		multierror.Append(&mErr, fmt.Errorf("Timeout must be positive"))
+	}
 	return mErr.ErrorOrNil()
 }
 
@@ -3253,6 +4366,10 @@ type Task struct {
 	// Templates are the set of templates to be rendered for the task.
 	Templates []*Template
 
+	// ConnectivityChecks verify that the addresses the task depends on are
+	// reachable once it is started.
+	ConnectivityChecks []*ConnectivityCheck
+
 	// Constraints can be specified at a task level and apply only to
 	// the particular task.
 	Constraints []*Constraint
@@ -3263,6 +4380,10 @@ type Task struct {
 	// DispatchPayload configures how the task retrieves its input from a dispatch
 	DispatchPayload *DispatchPayloadConfig
 
+	// DNS configures the resolver of the task, overriding the one of its
+	// task group
+	DNS *DNSConfig
+
 	// Meta is used to associate arbitrary metadata with this
 	// task. This is opaque to Nomad.
 	Meta map[string]string
@@ -3315,6 +4436,8 @@ func (t *Task) Copy() *Task {
 	nt.Resources = nt.Resources.Copy()
 	nt.Meta = helper.CopyMapStringString(nt.Meta)
 	nt.DispatchPayload = nt.DispatchPayload.Copy()
+	nt.DNS = nt.DNS.Copy()
+	nt.LogConfig = nt.LogConfig.Copy()
 
 	if t.Artifacts != nil {
 		artifacts := make([]*TaskArtifact, 0, len(t.Artifacts))
@@ -3338,6 +4461,14 @@ func (t *Task) Copy() *Task {
 		nt.Templates = templates
 	}
 
+	if t.ConnectivityChecks != nil {
+		checks := make([]*ConnectivityCheck, len(t.ConnectivityChecks))
+		for i, check := range nt.ConnectivityChecks {
+			checks[i] = check.Copy()
+		}
+		nt.ConnectivityChecks = checks
+	}
+
 	return nt
 }
 
@@ -3458,6 +4589,9 @@ func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
 			outer := fmt.Errorf("Artifact %d validation failed: %v", idx+1, err)
 			mErr.Errors = append(mErr.Errors, outer)
 		}
+		if _, ok := artifact.GetterOptions[ArtifactOptionVaultPath]; ok && t.Vault == nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("Artifact %d reads its credentials from Vault but the task has no vault stanza", idx+1))
+		}
 	}
 
 	if t.Vault != nil {
@@ -3466,6 +4600,12 @@ func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
 		}
 	}
 
+	if t.DNS != nil {
+		if err := t.DNS.Validate(); err != nil {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("DNS validation failed: %v", err))
+		}
+	}
+
 	destinations := make(map[string]int, len(t.Templates))
 	for idx, tmpl := range t.Templates {
 		if err := tmpl.Validate(); err != nil {
@@ -3488,6 +4628,46 @@ func (t *Task) Validate(ephemeralDisk *EphemeralDisk) error {
 		}
 	}
 
+	if err := validateConnectivityChecks(t); err != nil {
+		mErr.Errors = append(mErr.Errors, err)
+	}
+
+	return mErr.ErrorOrNil()
+}
+
+// validateConnectivityChecks validates the connectivity checks of the task
+// and ensures their names are unique and their ports exist.
+func validateConnectivityChecks(t *Task) error {
+	var mErr multierror.Error
+
+	portLabels := make(map[string]struct{})
+	if t.Resources != nil {
+		for _, network := range t.Resources.Networks {
+			for portLabel := range network.PortLabels() {
+				portLabels[portLabel] = struct{}{}
+			}
+		}
+	}
+
+	known := make(map[string]struct{}, len(t.ConnectivityChecks))
+	for i, check := range t.ConnectivityChecks {
+		if err := check.Validate(); err != nil {
+			outer := fmt.Errorf("connectivity_check[%d] %+q validation failed: %s", i, check.Name, err)
+			mErr.Errors = append(mErr.Errors, outer)
+		}
+
+		if _, ok := known[check.Name]; ok {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("connectivity check %q is duplicate", check.Name))
+		}
+		known[check.Name] = struct{}{}
+
+		if check.PortLabel != "" {
+			if _, ok := portLabels[check.PortLabel]; !ok {
+				mErr.Errors = append(mErr.Errors, fmt.Errorf("connectivity check %q references unknown port %q", check.Name, check.PortLabel))
+			}
+		}
+	}
+
 	return mErr.ErrorOrNil()
 }
 
@@ -3799,6 +4979,18 @@ type TaskState struct {
 	// not be started again.
 	FinishedAt time.Time
 
+	// ImageDigest is the resolved digest of the image the task was last
+	// started from, if reported by the driver.
+	ImageDigest string
+
+	// ImageSBOM is a reference to the software bill of materials of the
+	// image the task was last started from, if reported by the driver.
+	ImageSBOM string
+
+	// ResourceUsage is the latest resource usage of the task. It is only
+	// reported for task groups using canaries.
+	ResourceUsage *TaskResourceUsageSummary
+
 	// Series of task events that transition the state of the task.
 	Events []*TaskEvent
 }
@@ -3809,6 +5001,7 @@ func (ts *TaskState) Copy() *TaskState {
 	}
 	copy := new(TaskState)
 	*copy = *ts
+	copy.ResourceUsage = ts.ResourceUsage.Copy()
 
 	if ts.Events != nil {
 		copy.Events = make([]*TaskEvent, len(ts.Events))
@@ -3910,6 +5103,30 @@ const (
 
 	// TaskLeaderDead indicates that the leader task within the has finished.
 	TaskLeaderDead = "Leader Task Dead"
+
+	// TaskConnectivityPassed indicates that a connectivity check of the task
+	// passed.
+	TaskConnectivityPassed = "Connectivity Passed"
+
+	// TaskConnectivityFailed indicates that a connectivity check of the task
+	// failed. The check is retried until it passes.
+	TaskConnectivityFailed = "Connectivity Failed"
+
+	// TaskCheckpointed indicates that the task was checkpointed and stopped
+	// so that it is restored where its allocation is migrated.
+	TaskCheckpointed = "Checkpointed"
+
+	// TaskRestored indicates that the task was restored from the checkpoint
+	// of the allocation it replaces.
+	TaskRestored = "Restored"
+
+	// TaskDriverHealthy indicates that the driver reported the task healthy,
+	// such as with the HEALTHCHECK of a Docker image.
+	TaskDriverHealthy = "Driver Healthy"
+
+	// TaskDriverUnhealthy indicates that the driver reported the task
+	// unhealthy.
+	TaskDriverUnhealthy = "Driver Unhealthy"
 )
 
 // TaskEvent is an event that effects the state of a task and contains meta-data
@@ -3953,6 +5170,18 @@ type TaskEvent struct {
 	// Deprecated, use Details["signal"] to access this.
 	Signal int // The signal that terminated the task.
 
+	// OOMKilled is whether the task was killed for running out of memory.
+	OOMKilled bool
+
+	// MemoryLimitMB and CPULimit are the memory in MB and CPU in MHz the task
+	// was limited to when it terminated.
+	MemoryLimitMB int
+	CPULimit      int
+
+	// StderrTail is the last lines the task wrote to stderr before it
+	// terminated unsuccessfully.
+	StderrTail []string
+
 	// Killing fields
 	// Deprecated, use Details["kill_timeout"] to access this.
 	KillTimeout time.Duration
@@ -4005,6 +5234,10 @@ type TaskEvent struct {
 	// GenericSource is the source of a message.
 	// Deprecated, is redundant with event type.
 	GenericSource string
+
+	// ConnectivityCheck is the name of the connectivity check whose result
+	// the event reports.
+	ConnectivityCheck string
 }
 
 func (event *TaskEvent) PopulateEventDisplayMessage() {
@@ -4073,6 +5306,14 @@ func (event *TaskEvent) PopulateEventDisplayMessage() {
 			parts = append(parts, fmt.Sprintf("Signal: %d", event.Signal))
 		}
 
+		if event.OOMKilled {
+			if event.MemoryLimitMB != 0 {
+				parts = append(parts, fmt.Sprintf("OOM Killed (Memory Limit: %d MB)", event.MemoryLimitMB))
+			} else {
+				parts = append(parts, "OOM Killed")
+			}
+		}
+
 		if event.Message != "" {
 			parts = append(parts, fmt.Sprintf("Exit Message: %q", event.Message))
 		}
@@ -4119,6 +5360,22 @@ func (event *TaskEvent) PopulateEventDisplayMessage() {
 		desc = event.DriverMessage
 	case TaskLeaderDead:
 		desc = "Leader Task in Group dead"
+	case TaskConnectivityPassed:
+		desc = fmt.Sprintf("Connectivity check %q passed", event.ConnectivityCheck)
+	case TaskConnectivityFailed:
+		desc = fmt.Sprintf("Connectivity check %q failed: %s", event.ConnectivityCheck, event.Message)
+	case TaskCheckpointed:
+		desc = "Task checkpointed for migration"
+	case TaskRestored:
+		desc = "Task restored from checkpoint"
+	case TaskDriverHealthy:
+		desc = "Driver reported the task healthy"
+	case TaskDriverUnhealthy:
+		if event.Message != "" {
+			desc = fmt.Sprintf("Driver reported the task unhealthy: %s", event.Message)
+		} else {
+			desc = "Driver reported the task unhealthy"
+		}
 	default:
 		desc = event.Message
 	}
@@ -4143,6 +5400,7 @@ func (te *TaskEvent) Copy() *TaskEvent {
 	}
 	copy := new(TaskEvent)
 	*copy = *te
+	copy.StderrTail = helper.CopySliceString(te.StderrTail)
 	return copy
 }
 
@@ -4190,6 +5448,33 @@ func (e *TaskEvent) SetSignal(s int) *TaskEvent {
 	return e
 }
 
+func (e *TaskEvent) SetOOMKilled(oom bool) *TaskEvent {
+	e.OOMKilled = oom
+	if oom {
+		e.Details["oom_killed"] = "true"
+	}
+	return e
+}
+
+// SetResourceLimits sets the memory in MB and CPU in MHz the task was limited
+// to when it terminated.
+func (e *TaskEvent) SetResourceLimits(memoryMB, cpu int) *TaskEvent {
+	e.MemoryLimitMB = memoryMB
+	e.CPULimit = cpu
+	e.Details["memory_limit"] = fmt.Sprintf("%d", memoryMB)
+	e.Details["cpu_limit"] = fmt.Sprintf("%d", cpu)
+	return e
+}
+
+// SetStderrTail sets the last lines the task wrote to stderr.
+func (e *TaskEvent) SetStderrTail(lines []string) *TaskEvent {
+	if len(lines) != 0 {
+		e.StderrTail = lines
+		e.Details["stderr_tail"] = strings.Join(lines, "\n")
+	}
+	return e
+}
+
 func (e *TaskEvent) SetExitMessage(err error) *TaskEvent {
 	if err != nil {
 		e.Message = err.Error()
@@ -4284,6 +5569,22 @@ func (e *TaskEvent) SetDriverMessage(m string) *TaskEvent {
 	return e
 }
 
+func (e *TaskEvent) SetConnectivityCheck(name string) *TaskEvent {
+	e.ConnectivityCheck = name
+	e.Details["connectivity_check"] = name
+	return e
+}
+
+func (e *TaskEvent) SetImage(digest, sbom string) *TaskEvent {
+	if digest != "" {
+		e.Details["image_digest"] = digest
+	}
+	if sbom != "" {
+		e.Details["image_sbom"] = sbom
+	}
+	return e
+}
+
 // TaskArtifact is an artifact to download before running the task.
 type TaskArtifact struct {
 	// GetterSource is the source to download an artifact using go-getter
@@ -4623,6 +5924,7 @@ const (
 	// DeploymentStatuses are the various states a deployment can be be in
 	DeploymentStatusRunning    = "running"
 	DeploymentStatusPaused     = "paused"
+	DeploymentStatusQueued     = "queued"
 	DeploymentStatusFailed     = "failed"
 	DeploymentStatusSuccessful = "successful"
 	DeploymentStatusCancelled  = "cancelled"
@@ -4632,6 +5934,7 @@ const (
 	DeploymentStatusDescriptionRunning               = "Deployment is running"
 	DeploymentStatusDescriptionRunningNeedsPromotion = "Deployment is running but requires promotion"
 	DeploymentStatusDescriptionPaused                = "Deployment is paused"
+	DeploymentStatusDescriptionQueued                = "Deployment is queued until the number of running deployments is below the concurrency limit"
 	DeploymentStatusDescriptionSuccessful            = "Deployment completed successfully"
 	DeploymentStatusDescriptionStoppedJob            = "Cancelled because job is stopped"
 	DeploymentStatusDescriptionNewerJob              = "Cancelled due to newer version of job"
@@ -4732,7 +6035,7 @@ func (d *Deployment) Copy() *Deployment {
 // Active returns whether the deployment is active or terminal.
 func (d *Deployment) Active() bool {
 	switch d.Status {
-	case DeploymentStatusRunning, DeploymentStatusPaused:
+	case DeploymentStatusRunning, DeploymentStatusPaused, DeploymentStatusQueued:
 		return true
 	default:
 		return false
@@ -4809,6 +6112,10 @@ type DeploymentState struct {
 
 	// UnhealthyAllocs are allocations that have been marked as unhealthy.
 	UnhealthyAllocs int
+
+	// CanaryAnalysis is the series of snapshots comparing the canaries with
+	// the stable allocations, collected while the canaries await promotion.
+	CanaryAnalysis []*CanaryAnalysisSnapshot
 }
 
 func (d *DeploymentState) GoString() string {
@@ -4827,6 +6134,12 @@ func (d *DeploymentState) Copy() *DeploymentState {
 	c := &DeploymentState{}
 	*c = *d
 	c.PlacedCanaries = helper.CopySliceString(d.PlacedCanaries)
+	if d.CanaryAnalysis != nil {
+		c.CanaryAnalysis = make([]*CanaryAnalysisSnapshot, len(d.CanaryAnalysis))
+		for i, s := range d.CanaryAnalysis {
+			c.CanaryAnalysis[i] = s.Copy()
+		}
+	}
 	return c
 }
 
@@ -4856,6 +6169,81 @@ const (
 	AllocClientStatusLost     = "lost"
 )
 
+const (
+	// AllocStopReasonJobStopped is set when the job was stopped or
+	// deregistered.
+	AllocStopReasonJobStopped = "job_stopped"
+
+	// AllocStopReasonJobUpdated is set when the allocation is no longer
+	// needed by the job, such as when its count was reduced or its task group
+	// was removed.
+	AllocStopReasonJobUpdated = "job_updated"
+
+	// AllocStopReasonDeploymentReplaced is set when the allocation was
+	// replaced by one running a newer version of the job.
+	AllocStopReasonDeploymentReplaced = "deployment_replaced"
+
+	// AllocStopReasonDrained is set when the allocation was migrated off of a
+	// draining node.
+	AllocStopReasonDrained = "drained"
+
+	// AllocStopReasonNodeLost is set when the node running the allocation
+	// went down.
+	AllocStopReasonNodeLost = "node_lost"
+
+	// AllocStopReasonOperatorStopped is set when the allocation was stopped
+	// by an operator so that it is replaced.
+	AllocStopReasonOperatorStopped = "operator_stopped"
+
+	// AllocStopReasonTaskFailed is set by the client when a task of the
+	// allocation failed.
+	AllocStopReasonTaskFailed = "task_failed"
+
+	// AllocStopReasonOOM is set by the client when a task of the allocation
+	// failed after being killed for running out of memory.
+	AllocStopReasonOOM = "oom"
+
+	// AllocStopReasonSetupFailed is set by the client when the allocation
+	// could not be setup to run.
+	AllocStopReasonSetupFailed = "setup_failed"
+)
+
+const (
+	// JobSubmissionFormatHCL is the format of job sources written in HCL
+	JobSubmissionFormatHCL = "hcl"
+)
+
+// JobSubmission is the source a job version was parsed from, so that the job
+// can be returned as it was written.
+type JobSubmission struct {
+	// Source is the job specification as submitted
+	Source string
+
+	// Format is the format of the source
+	Format string
+
+	// Variables are the values substituted for the variables of the source
+	Variables map[string]string
+
+	// Namespace, JobID and Version identify the job version the source was
+	// submitted with. They are set when the job is registered.
+	Namespace string
+	JobID     string
+	Version   uint64
+
+	CreateIndex uint64
+}
+
+func (s *JobSubmission) Copy() *JobSubmission {
+	if s == nil {
+		return nil
+	}
+	ns := new(JobSubmission)
+	*ns = *s
+	ns.Variables = helper.CopyMapStringString(s.Variables)
+	return ns
+}
+
 // Allocation is used to allocate the placement of a task group to a node.
 type Allocation struct {
 	// ID of the allocation (UUID)
@@ -4909,6 +6297,10 @@ type Allocation struct {
 	// ClientStatusDescription is meant to provide more human useful information
 	ClientDescription string
 
+	// StopReason is why the allocation was stopped by the servers or failed
+	// on the client. It is one of the AllocStopReason constants.
+	StopReason string
+
 	// TaskStates stores the state of each task,
 	TaskStates map[string]*TaskState
 
@@ -5091,6 +6483,7 @@ func (a *Allocation) Stub() *AllocListStub {
 		ID:                 a.ID,
 		EvalID:             a.EvalID,
 		Name:               a.Name,
+		Namespace:          a.Namespace,
 		NodeID:             a.NodeID,
 		JobID:              a.JobID,
 		JobVersion:         a.Job.Version,
@@ -5099,6 +6492,7 @@ func (a *Allocation) Stub() *AllocListStub {
 		DesiredDescription: a.DesiredDescription,
 		ClientStatus:       a.ClientStatus,
 		ClientDescription:  a.ClientDescription,
+		StopReason:         a.StopReason,
 		TaskStates:         a.TaskStates,
 		DeploymentStatus:   a.DeploymentStatus,
 		CreateIndex:        a.CreateIndex,
@@ -5108,11 +6502,104 @@ func (a *Allocation) Stub() *AllocListStub {
 	}
 }
 
+// Archive returns the summary of the allocation written to the allocation
+// archive. The name of the node is left to the caller since the allocation
+// only references it.
+func (a *Allocation) Archive() *ArchivedAllocation {
+	archived := &ArchivedAllocation{
+		ID:                 a.ID,
+		EvalID:             a.EvalID,
+		Name:               a.Name,
+		Namespace:          a.Namespace,
+		NodeID:             a.NodeID,
+		JobID:              a.JobID,
+		TaskGroup:          a.TaskGroup,
+		Resources:          a.Resources.Copy(),
+		DesiredStatus:      a.DesiredStatus,
+		DesiredDescription: a.DesiredDescription,
+		ClientStatus:       a.ClientStatus,
+		ClientDescription:  a.ClientDescription,
+		CreateTime:         a.CreateTime,
+		ModifyTime:         a.ModifyTime,
+	}
+	if a.Job != nil {
+		archived.JobVersion = a.Job.Version
+	}
+
+	if len(a.TaskStates) != 0 {
+		archived.TaskStates = make(map[string]*ArchivedTaskState, len(a.TaskStates))
+		for task, ts := range a.TaskStates {
+			ats := &ArchivedTaskState{
+				State:      ts.State,
+				Failed:     ts.Failed,
+				Restarts:   ts.Restarts,
+				StartedAt:  ts.StartedAt,
+				FinishedAt: ts.FinishedAt,
+			}
+
+			// The exit of the task is reported by its last termination
+			for i := len(ts.Events) - 1; i >= 0; i-- {
+				if e := ts.Events[i]; e.Type == TaskTerminated {
+					ats.ExitCode = e.ExitCode
+					ats.Signal = e.Signal
+					ats.ExitMessage = e.Message
+					break
+				}
+			}
+			archived.TaskStates[task] = ats
+		}
+	}
+	return archived
+}
+
+// ArchivedAllocation is the summary of a terminal allocation written to the
+// allocation archive before the allocation is garbage collected.
+type ArchivedAllocation struct {
+	ID                 string
+	EvalID             string
+	Name               string
+	Namespace          string
+	NodeID             string
+	NodeName           string
+	JobID              string
+	JobVersion         uint64
+	TaskGroup          string
+	Resources          *Resources
+	DesiredStatus      string
+	DesiredDescription string
+	ClientStatus       string
+	ClientDescription  string
+	TaskStates         map[string]*ArchivedTaskState
+	CreateTime         int64
+	ModifyTime         int64
+
+	// ArchiveTime is the Unix nanosecond timestamp the allocation was
+	// archived at
+	ArchiveTime int64
+}
+
+// ArchivedTaskState is the summary of the state of a task of an archived
+// allocation.
+type ArchivedTaskState struct {
+	State      string
+	Failed     bool
+	Restarts   uint64
+	StartedAt  time.Time
+	FinishedAt time.Time
+
+	// ExitCode, Signal and ExitMessage describe the last termination of the
+	// task, if any
+	ExitCode    int
+	Signal      int
+	ExitMessage string
+}
+
 // AllocListStub is used to return a subset of alloc information
 type AllocListStub struct {
 	ID                 string
 	EvalID             string
 	Name               string
+	Namespace          string
 	NodeID             string
 	JobID              string
 	JobVersion         uint64
@@ -5121,6 +6608,7 @@ type AllocListStub struct {
 	DesiredDescription string
 	ClientStatus       string
 	ClientDescription  string
+	StopReason         string
 	TaskStates         map[string]*TaskState
 	DeploymentStatus   *AllocDeploymentStatus
 	CreateIndex        uint64
@@ -5328,6 +6816,7 @@ const (
 	EvalTriggerDeploymentWatcher = "deployment-watcher"
 	EvalTriggerFailedFollowUp    = "failed-follow-up"
 	EvalTriggerMaxPlans          = "max-plan-attempts"
+	EvalTriggerAllocStop         = "alloc-stop"
 )
 
 const (
@@ -5353,10 +6842,23 @@ const (
 	// check if they are terminal. If so, we delete these out of the system.
 	CoreJobDeploymentGC = "deployment-gc"
 
+	// CoreJobExpiredACLTokenGC is used for the garbage collection of the
+	// local ACL tokens that have expired.
+	CoreJobExpiredACLTokenGC = "expired-acl-token-gc"
+
 	// CoreJobForceGC is used to force garbage collection of all GCable objects.
 	CoreJobForceGC = "force-gc"
 )
 
+const (
+	// The collections a forced garbage collection can be restricted to.
+	GCCollectionJobs        = "jobs"
+	GCCollectionEvals       = "evals"
+	GCCollectionAllocs      = "allocs"
+	GCCollectionNodes       = "nodes"
+	GCCollectionDeployments = "deployments"
+)
+
 // Evaluation is used anytime we need to apply business logic as a result
 // of a change to our desired state (job specification) or the emergent state
 // (registered nodes). When the inputs change, we need to "evaluate" them,
@@ -5458,6 +6960,11 @@ type Evaluation struct {
 	// scheduler.
 	SnapshotIndex uint64
 
+	// IdempotencyToken is the token supplied with the job registration that
+	// created the evaluation, so that retries of a registration that didn't
+	// change the job return the same evaluation.
+	IdempotencyToken string
+
 	// Raft Indexes
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -5664,9 +7171,10 @@ type Plan struct {
 	DeploymentUpdates []*DeploymentStatusUpdate
 }
 
-// AppendUpdate marks the allocation for eviction. The clientStatus of the
-// allocation may be optionally set by passing in a non-empty value.
-func (p *Plan) AppendUpdate(alloc *Allocation, desiredStatus, desiredDesc, clientStatus string) {
+// AppendUpdate marks the allocation for eviction. The clientStatus and
+// stopReason of the allocation may be optionally set by passing in non-empty
+// values.
+func (p *Plan) AppendUpdate(alloc *Allocation, desiredStatus, desiredDesc, clientStatus, stopReason string) {
 	newAlloc := new(Allocation)
 	*newAlloc = *alloc
 
@@ -5688,6 +7196,9 @@ func (p *Plan) AppendUpdate(alloc *Allocation, desiredStatus, desiredDesc, clien
 	if clientStatus != "" {
 		newAlloc.ClientStatus = clientStatus
 	}
+	if stopReason != "" {
+		newAlloc.StopReason = stopReason
+	}
 
 	node := alloc.NodeID
 	existing := p.NodeUpdate[node]
@@ -6016,16 +7527,19 @@ type ACLPolicyUpsertRequest struct {
 
 // ACLToken represents a client token which is used to Authenticate
 type ACLToken struct {
-	AccessorID  string   // Public Accessor ID (UUID)
-	SecretID    string   // Secret ID, private (UUID)
-	Name        string   // Human friendly name
-	Type        string   // Client or Management
-	Policies    []string // Policies this token ties to
-	Global      bool     // Global or Region local
-	Hash        []byte
-	CreateTime  time.Time // Time of creation
-	CreateIndex uint64
-	ModifyIndex uint64
+	AccessorID     string   // Public Accessor ID (UUID)
+	SecretID       string   // Secret ID, private (UUID)
+	Name           string   // Human friendly name
+	Type           string   // Client or Management
+	Policies       []string // Policies this token ties to
+	Global         bool     // Global or Region local
+	Hash           []byte
+	CreateTime     time.Time  // Time of creation
+	ExpirationTime *time.Time // Time of expiration, if the token expires
+	AuthMethod     string     // Auth method the token was created by, if any
+	AllocID        string     // Allocation the token is the workload token of, if any
+	CreateIndex    uint64
+	ModifyIndex    uint64
 }
 
 var (
@@ -6041,15 +7555,18 @@ var (
 )
 
 type ACLTokenListStub struct {
-	AccessorID  string
-	Name        string
-	Type        string
-	Policies    []string
-	Global      bool
-	Hash        []byte
-	CreateTime  time.Time
-	CreateIndex uint64
-	ModifyIndex uint64
+	AccessorID     string
+	Name           string
+	Type           string
+	Policies       []string
+	Global         bool
+	Hash           []byte
+	CreateTime     time.Time
+	ExpirationTime *time.Time
+	AuthMethod     string
+	AllocID        string
+	CreateIndex    uint64
+	ModifyIndex    uint64
 }
 
 // SetHash is used to compute and set the hash of the ACL token
@@ -6071,6 +7588,7 @@ func (a *ACLToken) SetHash() []byte {
 	} else {
 		hash.Write([]byte("local"))
 	}
+	hash.Write([]byte(a.AllocID))
 
 	// Finalize the hash
 	hashVal := hash.Sum(nil)
@@ -6082,18 +7600,26 @@ func (a *ACLToken) SetHash() []byte {
 
 func (a *ACLToken) Stub() *ACLTokenListStub {
 	return &ACLTokenListStub{
-		AccessorID:  a.AccessorID,
-		Name:        a.Name,
-		Type:        a.Type,
-		Policies:    a.Policies,
-		Global:      a.Global,
-		Hash:        a.Hash,
-		CreateTime:  a.CreateTime,
-		CreateIndex: a.CreateIndex,
-		ModifyIndex: a.ModifyIndex,
+		AccessorID:     a.AccessorID,
+		Name:           a.Name,
+		Type:           a.Type,
+		Policies:       a.Policies,
+		Global:         a.Global,
+		Hash:           a.Hash,
+		CreateTime:     a.CreateTime,
+		ExpirationTime: a.ExpirationTime,
+		AuthMethod:     a.AuthMethod,
+		AllocID:        a.AllocID,
+		CreateIndex:    a.CreateIndex,
+		ModifyIndex:    a.ModifyIndex,
 	}
 }
 
+// IsExpired returns whether the token has expired at the given time.
+func (a *ACLToken) IsExpired(now time.Time) bool {
+	return a.ExpirationTime != nil && !now.Before(*a.ExpirationTime)
+}
+
 // Validate is used to sanity check a token
 func (a *ACLToken) Validate() error {
 	var mErr multierror.Error
@@ -6102,13 +7628,19 @@ func (a *ACLToken) Validate() error {
 	}
 	switch a.Type {
 	case ACLClientToken:
-		if len(a.Policies) == 0 {
+		if len(a.Policies) == 0 && a.AllocID == "" {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("client token missing policies"))
 		}
+		if a.AllocID != "" && a.Global {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("workload token cannot be global"))
+		}
 	case ACLManagementToken:
 		if len(a.Policies) != 0 {
 			mErr.Errors = append(mErr.Errors, fmt.Errorf("management token cannot be associated with policies"))
 		}
+		if a.AllocID != "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("management token cannot be a workload token"))
+		}
 	default:
 		mErr.Errors = append(mErr.Errors, fmt.Errorf("token type must be client or management"))
 	}
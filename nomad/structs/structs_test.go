@@ -1586,6 +1586,30 @@ func TestTask_Validate_LogConfig(t *testing.T) {
 	}
 }
 
+func TestLogSink_Validate(t *testing.T) {
+	cases := []struct {
+		sink *LogSink
+		err  string
+	}{
+		{&LogSink{Type: LogSinkSyslog, Address: "udp://127.0.0.1:514"}, ""},
+		{&LogSink{Type: LogSinkSyslog, Address: "unix:///dev/log"}, ""},
+		{&LogSink{Type: LogSinkLoki, Address: "https://loki:3100/loki/api/v1/push"}, ""},
+		{&LogSink{Type: LogSinkHTTP, Address: "udp://127.0.0.1:514"}, "must use one of the schemes"},
+		{&LogSink{Type: "kafka", Address: "tcp://127.0.0.1:9092"}, "invalid sink type"},
+	}
+
+	for _, c := range cases {
+		err := c.sink.Validate()
+		if c.err == "" {
+			if err != nil {
+				t.Errorf("%s %s: unexpected error: %v", c.sink.Type, c.sink.Address, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s %s: expected error %q, got: %v", c.sink.Type, c.sink.Address, c.err, err)
+		}
+	}
+}
+
 func TestTask_Validate_Template(t *testing.T) {
 
 	bad := &Template{}
@@ -1631,6 +1655,91 @@ func TestTask_Validate_Template(t *testing.T) {
 	}
 }
 
+func TestTask_Validate_ArtifactVault(t *testing.T) {
+	task := &Task{
+		Artifacts: []*TaskArtifact{
+			{
+				GetterSource:  "oci://registry.example.com/app:1.0",
+				GetterOptions: map[string]string{ArtifactOptionVaultPath: "secret/registry"},
+			},
+		},
+	}
+	ephemeralDisk := &EphemeralDisk{
+		SizeMB: 1,
+	}
+
+	err := task.Validate(ephemeralDisk)
+	if err == nil || !strings.Contains(err.Error(), "no vault stanza") {
+		t.Fatalf("expected vault stanza error, got: %v", err)
+	}
+
+	task.Vault = &Vault{Policies: []string{"registry"}, ChangeMode: VaultChangeModeRestart}
+	err = task.Validate(ephemeralDisk)
+	if err != nil && strings.Contains(err.Error(), "no vault stanza") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTask_Validate_ConnectivityChecks(t *testing.T) {
+	good := &ConnectivityCheck{
+		Name:      "db",
+		Type:      ConnectivityCheckTCP,
+		PortLabel: "http",
+		Interval:  5 * time.Second,
+		Timeout:   2 * time.Second,
+	}
+	task := &Task{
+		Name:   "web",
+		Driver: "docker",
+		Resources: &Resources{
+			CPU:      100,
+			MemoryMB: 100,
+			IOPS:     10,
+			Networks: []*NetworkResource{
+				{
+					MBits:        10,
+					DynamicPorts: []Port{{Label: "http"}},
+				},
+			},
+		},
+		LogConfig:          DefaultLogConfig(),
+		ConnectivityChecks: []*ConnectivityCheck{good},
+	}
+	ephemeralDisk := DefaultEphemeralDisk()
+	if err := task.Validate(ephemeralDisk); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	bad := good.Copy()
+	bad.Type = "udp"
+	bad.Path = "/health"
+	bad.Address = "10.0.0.1:5432"
+	bad.Interval = 0
+	if err := bad.Validate(); err == nil {
+		t.Fatalf("expected error")
+	} else {
+		for _, expected := range []string{"Invalid type", "Exactly one of address and port", "Interval must be positive"} {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("expected to find %q but found %v", expected, err)
+			}
+		}
+	}
+
+	unknown := good.Copy()
+	unknown.Name = "cache"
+	unknown.PortLabel = "redis"
+	task.ConnectivityChecks = []*ConnectivityCheck{good, good, unknown}
+	err := task.Validate(ephemeralDisk)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	for _, expected := range []string{`"db" is duplicate`, `unknown port "redis"`} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected to find %q but found %v", expected, err)
+		}
+	}
+}
+
 func TestTemplate_Validate(t *testing.T) {
 	cases := []struct {
 		Tmpl         *Template
@@ -1854,6 +1963,43 @@ func TestUpdateStrategy_Validate(t *testing.T) {
 	}
 }
 
+func TestNodeDelta(t *testing.T) {
+	assert := assert.New(t)
+
+	base := testNode()
+	node := base.Copy()
+	node.Attributes["driver.exec"] = "0"
+	node.Attributes["driver.docker"] = "1"
+	delete(node.Attributes, "version")
+	node.Meta = nil
+	node.Links["vault"] = "foobar.global"
+	node.Resources.CPU = 8000
+
+	delta, err := NewNodeDelta(base, node)
+	assert.Nil(err)
+	assert.Nil(delta.Node.Attributes)
+	assert.Equal(map[string]string{"driver.exec": "0", "driver.docker": "1"}, delta.Attributes.Set)
+	assert.Equal([]string{"version"}, delta.Attributes.Deleted)
+	assert.Nil(delta.Meta.Set)
+	assert.Equal([]string{"pci-dss"}, delta.Meta.Deleted)
+
+	applied, err := delta.Apply(base)
+	assert.Nil(err)
+	assert.Equal(node.Attributes, applied.Attributes)
+	assert.Empty(applied.Meta)
+	assert.Equal(node.Links, applied.Links)
+	assert.Equal(8000, applied.Resources.CPU)
+
+	// The base must be left untouched
+	assert.Equal("1", base.Attributes["driver.exec"])
+
+	// Applying the delta to another registration fails
+	other := base.Copy()
+	other.Attributes["kernel.name"] = "darwin"
+	_, err = delta.Apply(other)
+	assert.Equal(ErrNodeDeltaMismatch, err)
+}
+
 func TestResource_NetIndex(t *testing.T) {
 	r := &Resources{
 		Networks: []*NetworkResource{
@@ -2714,6 +2860,53 @@ func TestDispatchPayloadConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestDNSConfig_Validate(t *testing.T) {
+	d := &DNSConfig{
+		Servers:  []string{"10.0.0.53", "2001:db8::53", "${attr.dns.address}"},
+		Searches: []string{"${node.datacenter}.consul"},
+		Options:  []string{"ndots:2"},
+	}
+	if err := d.Validate(); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	d.Servers = []string{"ns.example.com"}
+	d.Searches = []string{"a b"}
+	d.Options = []string{""}
+	err := d.Validate()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	mErr := err.(*multierror.Error)
+	if len(mErr.Errors) != 3 {
+		t.Fatalf("expected 3 errors, got: %v", err)
+	}
+	if !strings.Contains(mErr.Errors[0].Error(), "must be an IP address") {
+		t.Fatalf("bad: %v", err)
+	}
+}
+
+func TestMigrateStrategy_Validate(t *testing.T) {
+	m := &MigrateStrategy{Deadline: 30 * time.Minute}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	m = &MigrateStrategy{Ignore: true}
+	if err := m.Validate(); err != nil {
+		t.Fatalf("bad: %v", err)
+	}
+
+	m.Deadline = -time.Second
+	err := m.Validate()
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if mErr := err.(*multierror.Error); len(mErr.Errors) != 2 {
+		t.Fatalf("expected 2 errors, got: %v", err)
+	}
+}
+
 func TestIsRecoverable(t *testing.T) {
 	if IsRecoverable(nil) {
 		t.Errorf("nil should not be recoverable")
@@ -2865,6 +3058,7 @@ func TestTaskEventPopulate(t *testing.T) {
 		{NewTaskEvent(TaskKilling).SetKillTimeout(1 * time.Second), "Sent interrupt. Waiting 1s before force killing"},
 		{NewTaskEvent(TaskTerminated).SetExitCode(-1).SetSignal(3), "Exit Code: -1, Signal: 3"},
 		{NewTaskEvent(TaskTerminated).SetMessage("Goodbye"), "Exit Code: 0, Exit Message: \"Goodbye\""},
+		{NewTaskEvent(TaskTerminated).SetExitCode(137).SetSignal(9).SetOOMKilled(true).SetResourceLimits(256, 500), "Exit Code: 137, Signal: 9, OOM Killed (Memory Limit: 256 MB)"},
 		{NewTaskEvent(TaskKilled), "Task successfully killed"},
 		{NewTaskEvent(TaskKilled).SetKillError(fmt.Errorf("undead creatures can't be killed")), "undead creatures can't be killed"},
 		{NewTaskEvent(TaskNotRestarting).SetRestartReason("Chaos Monkey did it"), "Chaos Monkey did it"},
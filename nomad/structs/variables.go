@@ -0,0 +1,230 @@
+package structs
+
+import (
+	"fmt"
+	"regexp"
+
+	multierror "github.com/hashicorp/go-multierror"
+)
+
+const (
+	// VariablesMaxSize is the maximum total size of the keys and values of
+	// the items of a variable.
+	VariablesMaxSize = 64 * 1024
+
+	// VariablesJobsPrefix is the path prefix under which the variables a
+	// job's tasks can read are stored. A task can read the variables at
+	// nomad/jobs/<job>, nomad/jobs/<job>/<group> and
+	// nomad/jobs/<job>/<group>/<task>.
+	VariablesJobsPrefix = "nomad/jobs"
+)
+
+// validVariablePath matches the paths of variables: segments of letters,
+// digits and the characters "-", "_", "." and "~" separated by slashes.
+var validVariablePath = regexp.MustCompile(`^[a-zA-Z0-9_.~-]+(/[a-zA-Z0-9_.~-]+)*$`)
+
+// Variable is a set of secret items stored at a path of a namespace. The
+// items are encrypted by the servers before being stored and are only
+// returned decrypted to the requests allowed to read them.
+type Variable struct {
+	// Namespace is the namespace the variable belongs to.
+	Namespace string
+
+	// Path identifies the variable within its namespace.
+	Path string
+
+	// Items are the keys and values of the variable.
+	Items map[string]string
+
+	// Raft Indexes
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Validate returns an error if the variable is invalid.
+func (v *Variable) Validate() error {
+	var mErr multierror.Error
+	if len(v.Path) > 128 || !validVariablePath.MatchString(v.Path) {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("invalid path %q", v.Path))
+	}
+	if len(v.Items) == 0 {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("variable must have at least one item"))
+	}
+	size := 0
+	for k, val := range v.Items {
+		if k == "" {
+			mErr.Errors = append(mErr.Errors, fmt.Errorf("item keys must not be empty"))
+		}
+		size += len(k) + len(val)
+	}
+	if size > VariablesMaxSize {
+		mErr.Errors = append(mErr.Errors, fmt.Errorf("items exceed the maximum size of %d bytes", VariablesMaxSize))
+	}
+	return mErr.ErrorOrNil()
+}
+
+// Stub returns the metadata of the variable, without its items.
+func (v *Variable) Stub() *VariableStub {
+	return &VariableStub{
+		Namespace:   v.Namespace,
+		Path:        v.Path,
+		CreateIndex: v.CreateIndex,
+		ModifyIndex: v.ModifyIndex,
+	}
+}
+
+// VariableEncrypted is a variable as stored in the state store, with its
+// items encrypted by a root key of the keyring.
+type VariableEncrypted struct {
+	Namespace string
+	Path      string
+
+	// KeyID is the ID of the root key the data is encrypted with.
+	KeyID string
+
+	// Data is the nonce followed by the encrypted items.
+	Data []byte
+
+	// Raft Indexes
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Stub returns the metadata of the variable.
+func (v *VariableEncrypted) Stub() *VariableStub {
+	return &VariableStub{
+		Namespace:   v.Namespace,
+		Path:        v.Path,
+		CreateIndex: v.CreateIndex,
+		ModifyIndex: v.ModifyIndex,
+	}
+}
+
+// VariableStub is the metadata of a variable returned when listing
+// variables.
+type VariableStub struct {
+	Namespace   string
+	Path        string
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// RootKey is a key of the keyring the servers encrypt variables with. New
+// variables are encrypted with the most recently created key. The key
+// material is never stored in the clear: it is only stored wrapped with the
+// key-encryption key of each server, which never leaves the server.
+type RootKey struct {
+	KeyID string
+
+	// WrappedKeys is the AES-256 key material wrapped for each server, keyed
+	// by the public key-encryption key of the server.
+	WrappedKeys map[string][]byte
+
+	// Raft Indexes
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// Copy returns a copy of the key whose wrapped keys can be modified
+func (k *RootKey) Copy() *RootKey {
+	if k == nil {
+		return nil
+	}
+	nk := new(RootKey)
+	*nk = *k
+	nk.WrappedKeys = make(map[string][]byte, len(k.WrappedKeys))
+	for kek, wrapped := range k.WrappedKeys {
+		nk.WrappedKeys[kek] = wrapped
+	}
+	return nk
+}
+
+// VariableTaskPaths returns the paths of the variables the task of the job's
+// task group can read, from the least to the most specific.
+func VariableTaskPaths(jobID, group, task string) []string {
+	job := VariablesJobsPrefix + "/" + jobID
+	return []string{
+		job,
+		job + "/" + group,
+		job + "/" + group + "/" + task,
+	}
+}
+
+// VariableUpsertRequest is used to create or update a variable.
+type VariableUpsertRequest struct {
+	Variable *Variable
+	WriteRequest
+}
+
+// VariableEncryptedUpsertRequest is used to store encrypted variables in the
+// state store.
+type VariableEncryptedUpsertRequest struct {
+	Variables []*VariableEncrypted
+	WriteRequest
+}
+
+// VariableDeleteRequest is used to delete a variable of a namespace.
+type VariableDeleteRequest struct {
+	Path string
+	WriteRequest
+}
+
+// VariableSpecificRequest is used to read a variable of a namespace.
+type VariableSpecificRequest struct {
+	Path string
+	QueryOptions
+}
+
+// SingleVariableResponse is used to return a single decrypted variable.
+type SingleVariableResponse struct {
+	Variable *Variable
+	QueryMeta
+}
+
+// VariableListRequest is used to list the variables of a namespace, filtered
+// by path prefix.
+type VariableListRequest struct {
+	QueryOptions
+}
+
+// VariableListResponse is used to return the metadata of variables.
+type VariableListResponse struct {
+	Variables []*VariableStub
+	QueryMeta
+}
+
+// VariableTaskRequest is used by clients to read the items of the variables a
+// task of an allocation running on the node can read. Clients authenticate
+// using their node ID and secret.
+type VariableTaskRequest struct {
+	NodeID   string
+	SecretID string
+	AllocID  string
+	Task     string
+	QueryOptions
+}
+
+// VariableTaskResponse is used to return the items of the variables of a
+// task, merged so that the items of more specific paths take precedence.
+type VariableTaskResponse struct {
+	Items map[string]string
+	QueryMeta
+}
+
+// VariableTaskReadRequest is used by clients to read one of the variables a
+// task of an allocation running on the node can read, to render the templates
+// of the task. Clients authenticate using their node ID and secret.
+type VariableTaskReadRequest struct {
+	NodeID   string
+	SecretID string
+	AllocID  string
+	Task     string
+	Path     string
+	QueryOptions
+}
+
+// RootKeyUpsertRequest is used to add keys to the keyring.
+type RootKeyUpsertRequest struct {
+	RootKeys []*RootKey
+	WriteRequest
+}
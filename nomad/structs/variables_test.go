@@ -0,0 +1,74 @@
+package structs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVariable_Validate(t *testing.T) {
+	cases := []struct {
+		name     string
+		variable *Variable
+		err      string
+	}{
+		{
+			name: "valid",
+			variable: &Variable{
+				Path:  "nomad/jobs/web",
+				Items: map[string]string{"password": "hunter2"},
+			},
+		},
+		{
+			name: "leading slash",
+			variable: &Variable{
+				Path:  "/nomad/jobs/web",
+				Items: map[string]string{"password": "hunter2"},
+			},
+			err: "invalid path",
+		},
+		{
+			name: "empty segment",
+			variable: &Variable{
+				Path:  "nomad//web",
+				Items: map[string]string{"password": "hunter2"},
+			},
+			err: "invalid path",
+		},
+		{
+			name:     "no items",
+			variable: &Variable{Path: "web"},
+			err:      "at least one item",
+		},
+		{
+			name: "empty key",
+			variable: &Variable{
+				Path:  "web",
+				Items: map[string]string{"": "hunter2"},
+			},
+			err: "must not be empty",
+		},
+		{
+			name: "too large",
+			variable: &Variable{
+				Path:  "web",
+				Items: map[string]string{"key": strings.Repeat("a", VariablesMaxSize)},
+			},
+			err: "maximum size",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.variable.Validate()
+			if c.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Fatalf("expected error containing %q, got: %v", c.err, err)
+			}
+		})
+	}
+}
@@ -12,8 +12,9 @@ type System struct {
 }
 
 // GarbageCollect is used to trigger the system to immediately garbage collect nodes, evals
-// and jobs.
-func (s *System) GarbageCollect(args *structs.GenericRequest, reply *structs.GenericResponse) error {
+// and jobs. A scoped garbage collection is done before returning so that the
+// collected objects can be reported.
+func (s *System) GarbageCollect(args *structs.SystemGCRequest, reply *structs.SystemGCResponse) error {
 	if done, err := s.srv.forward("System.GarbageCollect", args, args, reply); done {
 		return err
 	}
@@ -31,7 +32,24 @@ func (s *System) GarbageCollect(args *structs.GenericRequest, reply *structs.Gen
 		return fmt.Errorf("failed to determine state store's index: %v", err)
 	}
 
-	s.srv.evalBroker.Enqueue(s.srv.coreJobEval(structs.CoreJobForceGC, snapshotIndex))
+	if !args.Scoped() {
+		s.srv.evalBroker.Enqueue(s.srv.coreJobEval(structs.CoreJobForceGC, snapshotIndex))
+		return nil
+	}
+
+	gc, err := newScopedGC(args, reply)
+	if err != nil {
+		return err
+	}
+	snap, err := s.srv.fsm.State().Snapshot()
+	if err != nil {
+		return err
+	}
+	core := &CoreScheduler{srv: s.srv, snap: snap, gc: gc}
+	if err := core.Process(s.srv.coreJobEval(structs.CoreJobForceGC, snapshotIndex)); err != nil {
+		return err
+	}
+	reply.Index = snapshotIndex
 	return nil
 }
 
@@ -64,6 +64,85 @@ func TestSystemEndpoint_GarbageCollect(t *testing.T) {
 	})
 }
 
+func TestSystemEndpoint_GarbageCollect_Scoped(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Insert a job that can be GC'd and evals that can be GC'd
+	state := s1.fsm.State()
+	job := mock.Job()
+	job.Type = structs.JobTypeBatch
+	job.Stop = true
+	assert.Nil(state.UpsertJob(1000, job))
+
+	eval := mock.Eval()
+	eval.Status = structs.EvalStatusComplete
+	eval.JobID = job.ID
+	eval2 := mock.Eval()
+	eval2.Status = structs.EvalStatusFailed
+	eval2.Namespace = "other"
+	assert.Nil(state.UpsertEvals(1001, []*structs.Evaluation{eval, eval2}))
+
+	node := mock.Node()
+	node.Status = structs.NodeStatusDown
+	assert.Nil(state.UpsertNode(1002, node))
+
+	// A dry run reports the objects without collecting them
+	req := &structs.SystemGCRequest{
+		DryRun: true,
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: structs.AllNamespacesSentinel,
+		},
+	}
+	var resp structs.SystemGCResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "System.GarbageCollect", req, &resp))
+	assert.Equal([]structs.NamespacedID{{ID: job.ID, Namespace: job.Namespace}}, resp.Jobs)
+	assert.Len(resp.Evals, 2)
+	assert.Contains(resp.Evals, eval.ID)
+	assert.Contains(resp.Evals, eval2.ID)
+	assert.Equal([]string{node.ID}, resp.Nodes)
+
+	ws := memdb.NewWatchSet()
+	out, err := state.JobByID(ws, job.Namespace, job.ID)
+	assert.Nil(err)
+	assert.NotNil(out)
+
+	// Only collect the evals of the namespace
+	req = &structs.SystemGCRequest{
+		Collections: []string{structs.GCCollectionEvals},
+		WriteRequest: structs.WriteRequest{
+			Region:    "global",
+			Namespace: "other",
+		},
+	}
+	resp = structs.SystemGCResponse{}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "System.GarbageCollect", req, &resp))
+	assert.Empty(resp.Jobs)
+	assert.Equal([]string{eval2.ID}, resp.Evals)
+	assert.Empty(resp.Nodes)
+
+	evalOut, err := state.EvalByID(ws, eval2.ID)
+	assert.Nil(err)
+	assert.Nil(evalOut)
+	evalOut, err = state.EvalByID(ws, eval.ID)
+	assert.Nil(err)
+	assert.NotNil(evalOut)
+	nodeOut, err := state.NodeByID(ws, node.ID)
+	assert.Nil(err)
+	assert.NotNil(nodeOut)
+
+	// Unknown collections are rejected
+	req.Collections = []string{"unicorns"}
+	err = msgpackrpc.CallWithCodec(codec, "System.GarbageCollect", req, &resp)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), `unknown garbage collection "unicorns"`)
+}
+
 func TestSystemEndpoint_GarbageCollect_ACL(t *testing.T) {
 	t.Parallel()
 	s1, root := testACLServer(t, nil)
@@ -10,6 +10,7 @@ import (
 	"strconv"
 
 	version "github.com/hashicorp/go-version"
+	"github.com/hashicorp/nomad/helper/filter"
 	"github.com/hashicorp/serf/serf"
 )
 
@@ -208,3 +209,37 @@ func maxUint64(inputs ...uint64) uint64 {
 	}
 	return max
 }
+
+const (
+	// bulkDefaultBatchSize is the number of objects processed per request by
+	// the bulk operations when the request doesn't set a batch size.
+	bulkDefaultBatchSize = 100
+
+	// bulkMaxBatchSize is the maximum number of objects processed per request
+	// by the bulk operations so that a single Raft apply stays small.
+	bulkMaxBatchSize = 1000
+)
+
+// bulkBatchSize returns the batch size to use for a bulk operation request.
+func bulkBatchSize(requested int) int {
+	if requested <= 0 {
+		return bulkDefaultBatchSize
+	}
+	if requested > bulkMaxBatchSize {
+		return bulkMaxBatchSize
+	}
+	return requested
+}
+
+// parseBulkFilter parses the filter of a bulk operation and checks that its
+// selectors are fields of obj.
+func parseBulkFilter(expr string, obj interface{}) (*filter.Filter, error) {
+	f, err := filter.Parse(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %v", err)
+	}
+	if err := f.Validate(obj); err != nil {
+		return nil, fmt.Errorf("invalid filter: %v", err)
+	}
+	return f, nil
+}
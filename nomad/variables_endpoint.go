@@ -0,0 +1,362 @@
+package nomad
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	memdb "github.com/hashicorp/go-memdb"
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/state"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// Variables endpoint is used to manage the encrypted variables of the
+// namespaces.
+type Variables struct {
+	srv *Server
+}
+
+// Upsert is used to create or update a variable. The items are encrypted by
+// the leader before being stored.
+func (v *Variables) Upsert(args *structs.VariableUpsertRequest, reply *structs.GenericResponse) error {
+	if done, err := v.srv.forward("Variables.Upsert", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "variables", "upsert"}, time.Now())
+
+	// Check for write-variables permissions
+	if aclObj, err := v.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityWriteVariables) {
+		return structs.ErrPermissionDenied
+	}
+
+	if args.Variable == nil {
+		return fmt.Errorf("missing variable")
+	}
+	args.Variable.Namespace = args.RequestNamespace()
+	if err := args.Variable.Validate(); err != nil {
+		return err
+	}
+
+	encrypted, err := v.srv.encryptVariable(args.Variable)
+	if err != nil {
+		return err
+	}
+
+	// Update via Raft
+	req := structs.VariableEncryptedUpsertRequest{
+		Variables:    []*structs.VariableEncrypted{encrypted},
+		WriteRequest: args.WriteRequest,
+	}
+	_, index, err := v.srv.raftApply(structs.VariableUpsertRequestType, &req)
+	if err != nil {
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// Delete is used to delete a variable
+func (v *Variables) Delete(args *structs.VariableDeleteRequest, reply *structs.GenericResponse) error {
+	if done, err := v.srv.forward("Variables.Delete", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "variables", "delete"}, time.Now())
+
+	// Check for write-variables permissions
+	if aclObj, err := v.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityWriteVariables) {
+		return structs.ErrPermissionDenied
+	}
+
+	if args.Path == "" {
+		return fmt.Errorf("missing variable path")
+	}
+
+	// Update via Raft
+	_, index, err := v.srv.raftApply(structs.VariableDeleteRequestType, args)
+	if err != nil {
+		return err
+	}
+
+	// Update the index
+	reply.Index = index
+	return nil
+}
+
+// Read is used to read the decrypted items of a variable
+func (v *Variables) Read(args *structs.VariableSpecificRequest, reply *structs.SingleVariableResponse) error {
+	if done, err := v.srv.forward("Variables.Read", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "variables", "read"}, time.Now())
+
+	// Check for read-variables permissions
+	if aclObj, err := v.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityReadVariables) {
+		return structs.ErrPermissionDenied
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			out, err := state.VariableByPath(ws, args.RequestNamespace(), args.Path)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Variable = nil
+			if out != nil {
+				if reply.Variable, err = v.srv.decryptVariable(out); err != nil {
+					return err
+				}
+				reply.Index = out.ModifyIndex
+			} else {
+				// Use the last index that affected the variables table
+				index, err := state.Index("variables")
+				if err != nil {
+					return err
+				}
+				reply.Index = index
+			}
+
+			// Set the query response
+			v.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return v.srv.blockingRPC(&opts)
+}
+
+// List is used to list the metadata of the variables of a namespace whose
+// path starts with the prefix of the request
+func (v *Variables) List(args *structs.VariableListRequest, reply *structs.VariableListResponse) error {
+	if done, err := v.srv.forward("Variables.List", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "variables", "list"}, time.Now())
+
+	// Check for read-variables permissions
+	if aclObj, err := v.srv.ResolveToken(args.AuthToken); err != nil {
+		return err
+	} else if aclObj != nil && !aclObj.AllowNsOp(args.RequestNamespace(), acl.NamespaceCapabilityReadVariables) {
+		return structs.ErrPermissionDenied
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			iter, err := state.VariablesByPrefix(ws, args.RequestNamespace(), args.Prefix)
+			if err != nil {
+				return err
+			}
+
+			var variables []*structs.VariableStub
+			for {
+				raw := iter.Next()
+				if raw == nil {
+					break
+				}
+				variables = append(variables, raw.(*structs.VariableEncrypted).Stub())
+			}
+			reply.Variables = variables
+
+			// Use the last index that affected the variables table
+			index, err := state.Index("variables")
+			if err != nil {
+				return err
+			}
+
+			// Ensure we never set the index to zero, otherwise a blocking
+			// query cannot be used.
+			if index == 0 {
+				index = 1
+			}
+			reply.Index = index
+
+			// Set the query response
+			v.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return v.srv.blockingRPC(&opts)
+}
+
+// Task is used by clients to read the items of the variables a task of an
+// allocation running on the node can read: those stored at the paths of its
+// job, task group and task under the nomad/jobs prefix.
+func (v *Variables) Task(args *structs.VariableTaskRequest, reply *structs.VariableTaskResponse) error {
+	if done, err := v.srv.forward("Variables.Task", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "variables", "task"}, time.Now())
+
+	// Clients authenticate using their node secret
+	node, err := nodeBySecret(v.srv.State(), args.NodeID, args.SecretID)
+	if err != nil {
+		return err
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			alloc, err := taskAlloc(ws, state, node, args.AllocID, args.Task)
+			if err != nil {
+				return err
+			}
+
+			// Merge the items of the paths so that the most specific ones
+			// take precedence
+			items := make(map[string]string)
+			for _, path := range structs.VariableTaskPaths(alloc.JobID, alloc.TaskGroup, args.Task) {
+				out, err := state.VariableByPath(ws, alloc.Namespace, path)
+				if err != nil {
+					return err
+				}
+				if out == nil {
+					continue
+				}
+				variable, err := v.srv.decryptVariable(out)
+				if err != nil {
+					return err
+				}
+				for k, val := range variable.Items {
+					items[k] = val
+				}
+			}
+			reply.Items = items
+
+			// Use the last index that affected the variables table
+			index, err := state.Index("variables")
+			if err != nil {
+				return err
+			}
+
+			// Ensure we never set the index to zero, otherwise a blocking
+			// query cannot be used.
+			if index == 0 {
+				index = 1
+			}
+			reply.Index = index
+
+			// Set the query response
+			v.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return v.srv.blockingRPC(&opts)
+}
+
+// TaskRead is used by clients to read one of the variables a task of an
+// allocation running on the node can read, to render its templates
+func (v *Variables) TaskRead(args *structs.VariableTaskReadRequest, reply *structs.SingleVariableResponse) error {
+	if done, err := v.srv.forward("Variables.TaskRead", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"nomad", "variables", "task_read"}, time.Now())
+
+	// Clients authenticate using their node secret
+	node, err := nodeBySecret(v.srv.State(), args.NodeID, args.SecretID)
+	if err != nil {
+		return err
+	}
+
+	// Setup the blocking query
+	opts := blockingOptions{
+		queryOpts: &args.QueryOptions,
+		queryMeta: &reply.QueryMeta,
+		run: func(ws memdb.WatchSet, state *state.StateStore) error {
+			alloc, err := taskAlloc(ws, state, node, args.AllocID, args.Task)
+			if err != nil {
+				return err
+			}
+
+			// Tasks can only read the variables of their paths
+			readable := false
+			for _, path := range structs.VariableTaskPaths(alloc.JobID, alloc.TaskGroup, args.Task) {
+				if path == args.Path {
+					readable = true
+					break
+				}
+			}
+			if !readable {
+				return structs.ErrPermissionDenied
+			}
+
+			out, err := state.VariableByPath(ws, alloc.Namespace, args.Path)
+			if err != nil {
+				return err
+			}
+
+			// Setup the output
+			reply.Variable = nil
+			if out != nil {
+				if reply.Variable, err = v.srv.decryptVariable(out); err != nil {
+					return err
+				}
+			}
+
+			// Use the last index that affected the variables table
+			index, err := state.Index("variables")
+			if err != nil {
+				return err
+			}
+
+			// Ensure we never set the index to zero, otherwise a blocking
+			// query cannot be used.
+			if index == 0 {
+				index = 1
+			}
+			reply.Index = index
+
+			// Set the query response
+			v.srv.setQueryMeta(&reply.QueryMeta)
+			return nil
+		}}
+	return v.srv.blockingRPC(&opts)
+}
+
+// nodeBySecret returns the node of the given ID if the secret is its own. It
+// is used to authenticate the requests clients make on behalf of their tasks.
+func nodeBySecret(state *state.StateStore, nodeID, secretID string) (*structs.Node, error) {
+	node, err := state.NodeByID(nil, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node %q not found", nodeID)
+	}
+	if node.SecretID != secretID {
+		return nil, fmt.Errorf("node secret ID does not match")
+	}
+	return node, nil
+}
+
+// taskAlloc returns the allocation of the given ID if it runs on the node and
+// its task group has the task. The task is not checked if empty.
+func taskAlloc(ws memdb.WatchSet, state *state.StateStore, node *structs.Node, allocID, task string) (*structs.Allocation, error) {
+	alloc, err := state.AllocByID(ws, allocID)
+	if err != nil {
+		return nil, err
+	}
+	if alloc == nil || alloc.NodeID != node.ID {
+		return nil, fmt.Errorf("allocation %q not found on node %q", allocID, node.ID)
+	}
+	if alloc.Job == nil || alloc.Job.LookupTaskGroup(alloc.TaskGroup) == nil {
+		return nil, fmt.Errorf("task group of allocation %q not found", alloc.ID)
+	}
+	if task != "" && alloc.Job.LookupTaskGroup(alloc.TaskGroup).LookupTask(task) == nil {
+		return nil, fmt.Errorf("task %q not found in allocation %q", task, alloc.ID)
+	}
+	return alloc, nil
+}
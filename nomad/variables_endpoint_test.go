@@ -0,0 +1,273 @@
+package nomad
+
+import (
+	"bytes"
+	"testing"
+
+	msgpackrpc "github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariablesEndpoint_UpsertReadListDelete(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Upsert a variable
+	upsert := &structs.VariableUpsertRequest{
+		Variable: &structs.Variable{
+			Path:  "nomad/jobs/web",
+			Items: map[string]string{"password": "hunter2"},
+		},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.Upsert", upsert, &resp))
+	assert.NotZero(resp.Index)
+
+	// Invalid variables are rejected
+	upsert.Variable = &structs.Variable{Path: "/bad//path", Items: map[string]string{"a": "b"}}
+	assert.NotNil(msgpackrpc.CallWithCodec(codec, "Variables.Upsert", upsert, &resp))
+
+	// The items are encrypted in the state store
+	state := s1.fsm.State()
+	encrypted, err := state.VariableByPath(nil, structs.DefaultNamespace, "nomad/jobs/web")
+	assert.Nil(err)
+	if assert.NotNil(encrypted) {
+		assert.NotEmpty(encrypted.KeyID)
+		assert.False(bytes.Contains(encrypted.Data, []byte("hunter2")))
+	}
+
+	// Read the variable
+	get := &structs.VariableSpecificRequest{
+		Path:         "nomad/jobs/web",
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var getResp structs.SingleVariableResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.Read", get, &getResp))
+	if assert.NotNil(getResp.Variable) {
+		assert.Equal(structs.DefaultNamespace, getResp.Variable.Namespace)
+		assert.Equal(map[string]string{"password": "hunter2"}, getResp.Variable.Items)
+		assert.EqualValues(resp.Index, getResp.Index)
+	}
+
+	// Variables of other namespaces are not visible
+	get.Namespace = "other"
+	getResp = structs.SingleVariableResponse{}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.Read", get, &getResp))
+	assert.Nil(getResp.Variable)
+	get.Namespace = ""
+
+	// List the variables by prefix
+	list := &structs.VariableListRequest{
+		QueryOptions: structs.QueryOptions{Region: "global", Prefix: "nomad/jobs"},
+	}
+	var listResp structs.VariableListResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.List", list, &listResp))
+	if assert.Len(listResp.Variables, 1) {
+		assert.Equal("nomad/jobs/web", listResp.Variables[0].Path)
+	}
+
+	list.Prefix = "other"
+	listResp = structs.VariableListResponse{}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.List", list, &listResp))
+	assert.Len(listResp.Variables, 0)
+
+	// Delete the variable
+	del := &structs.VariableDeleteRequest{
+		Path:         "nomad/jobs/web",
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.Delete", del, &resp))
+
+	getResp = structs.SingleVariableResponse{}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.Read", get, &getResp))
+	assert.Nil(getResp.Variable)
+}
+
+func TestVariablesEndpoint_ACL(t *testing.T) {
+	t.Parallel()
+	s1, root := testACLServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	state := s1.fsm.State()
+	readToken := mock.CreatePolicyAndToken(t, state, 1001, "test-read",
+		mock.NamespacePolicy(structs.DefaultNamespace, "", []string{acl.NamespaceCapabilityReadVariables}))
+	jobToken := mock.CreatePolicyAndToken(t, state, 1002, "test-job",
+		mock.NamespacePolicy(structs.DefaultNamespace, acl.PolicyRead, nil))
+
+	upsert := &structs.VariableUpsertRequest{
+		Variable: &structs.Variable{
+			Path:  "app",
+			Items: map[string]string{"key": "value"},
+		},
+		WriteRequest: structs.WriteRequest{Region: "global"},
+	}
+	var resp structs.GenericResponse
+
+	// Writing requires the write-variables capability
+	upsert.AuthToken = readToken.SecretID
+	err := msgpackrpc.CallWithCodec(codec, "Variables.Upsert", upsert, &resp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), structs.ErrPermissionDenied.Error())
+	}
+	upsert.AuthToken = root.SecretID
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.Upsert", upsert, &resp))
+
+	// Reading requires the read-variables capability, which the read policy
+	// doesn't grant
+	get := &structs.VariableSpecificRequest{
+		Path: "app",
+		QueryOptions: structs.QueryOptions{
+			Region:    "global",
+			AuthToken: jobToken.SecretID,
+		},
+	}
+	var getResp structs.SingleVariableResponse
+	err = msgpackrpc.CallWithCodec(codec, "Variables.Read", get, &getResp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), structs.ErrPermissionDenied.Error())
+	}
+
+	get.AuthToken = readToken.SecretID
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.Read", get, &getResp))
+	if assert.NotNil(getResp.Variable) {
+		assert.Equal("value", getResp.Variable.Items["key"])
+	}
+}
+
+func TestVariablesEndpoint_Task(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Create a node and an allocation running on it
+	state := s1.fsm.State()
+	node := mock.Node()
+	alloc := mock.Alloc()
+	alloc.NodeID = node.ID
+	assert.Nil(state.UpsertNode(1000, node))
+	assert.Nil(state.UpsertJobSummary(1001, mock.JobSummary(alloc.JobID)))
+	assert.Nil(state.UpsertAllocs(1002, []*structs.Allocation{alloc}))
+
+	// Store variables at the paths of the job and of the task, and one the
+	// task can't read
+	paths := structs.VariableTaskPaths(alloc.JobID, alloc.TaskGroup, "web")
+	for path, items := range map[string]map[string]string{
+		paths[0]:            {"user": "web", "password": "job"},
+		paths[2]:            {"password": "task"},
+		"nomad/jobs/other":  {"secret": "other"},
+		paths[2] + "/extra": {"secret": "extra"},
+	} {
+		upsert := &structs.VariableUpsertRequest{
+			Variable:     &structs.Variable{Path: path, Items: items},
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.GenericResponse
+		assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.Upsert", upsert, &resp))
+	}
+
+	// The items of the most specific paths take precedence
+	req := &structs.VariableTaskRequest{
+		NodeID:       node.ID,
+		SecretID:     node.SecretID,
+		AllocID:      alloc.ID,
+		Task:         "web",
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.VariableTaskResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.Task", req, &resp))
+	assert.Equal(map[string]string{"user": "web", "password": "task"}, resp.Items)
+
+	// The node secret must match
+	req.SecretID = "foo"
+	assert.NotNil(msgpackrpc.CallWithCodec(codec, "Variables.Task", req, &resp))
+	req.SecretID = node.SecretID
+
+	// Tasks must be part of allocations running on the node
+	req.Task = "missing"
+	assert.NotNil(msgpackrpc.CallWithCodec(codec, "Variables.Task", req, &resp))
+
+	other := mock.Node()
+	assert.Nil(state.UpsertNode(1003, other))
+	req.Task = "web"
+	req.NodeID = other.ID
+	req.SecretID = other.SecretID
+	assert.NotNil(msgpackrpc.CallWithCodec(codec, "Variables.Task", req, &resp))
+}
+
+func TestVariablesEndpoint_TaskRead(t *testing.T) {
+	t.Parallel()
+	s1 := testServer(t, nil)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	testutil.WaitForLeader(t, s1.RPC)
+	assert := assert.New(t)
+
+	// Create a node and an allocation running on it
+	state := s1.fsm.State()
+	node := mock.Node()
+	alloc := mock.Alloc()
+	alloc.NodeID = node.ID
+	assert.Nil(state.UpsertNode(1000, node))
+	assert.Nil(state.UpsertJobSummary(1001, mock.JobSummary(alloc.JobID)))
+	assert.Nil(state.UpsertAllocs(1002, []*structs.Allocation{alloc}))
+
+	paths := structs.VariableTaskPaths(alloc.JobID, alloc.TaskGroup, "web")
+	for path, items := range map[string]map[string]string{
+		paths[1]:           {"password": "group"},
+		"nomad/jobs/other": {"secret": "other"},
+	} {
+		upsert := &structs.VariableUpsertRequest{
+			Variable:     &structs.Variable{Path: path, Items: items},
+			WriteRequest: structs.WriteRequest{Region: "global"},
+		}
+		var resp structs.GenericResponse
+		assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.Upsert", upsert, &resp))
+	}
+
+	req := &structs.VariableTaskReadRequest{
+		NodeID:       node.ID,
+		SecretID:     node.SecretID,
+		AllocID:      alloc.ID,
+		Task:         "web",
+		Path:         paths[1],
+		QueryOptions: structs.QueryOptions{Region: "global"},
+	}
+	var resp structs.SingleVariableResponse
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.TaskRead", req, &resp))
+	if assert.NotNil(resp.Variable) {
+		assert.Equal(map[string]string{"password": "group"}, resp.Variable.Items)
+	}
+	assert.NotZero(resp.Index)
+
+	// Variables of the task's paths that don't exist are not found
+	req.Path = paths[2]
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Variables.TaskRead", req, &resp))
+	assert.Nil(resp.Variable)
+
+	// Variables of other paths can't be read
+	req.Path = "nomad/jobs/other"
+	err := msgpackrpc.CallWithCodec(codec, "Variables.TaskRead", req, &resp)
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), structs.ErrPermissionDenied.Error())
+	}
+
+	// The node secret must match
+	req.Path = paths[1]
+	req.SecretID = "foo"
+	assert.NotNil(msgpackrpc.CallWithCodec(codec, "Variables.TaskRead", req, &resp))
+}
@@ -120,6 +120,10 @@ type VaultClient interface {
 	// LookupToken takes a token string and returns its capabilities.
 	LookupToken(ctx context.Context, token string) (*vapi.Secret, error)
 
+	// LookupCapabilities takes a token string and a path and returns the
+	// capabilities the token has on the path.
+	LookupCapabilities(ctx context.Context, token, path string) ([]string, error)
+
 	// RevokeTokens takes a set of tokens accessor and revokes the tokens
 	RevokeTokens(ctx context.Context, accessors []*structs.VaultAccessor, committed bool) error
 
@@ -132,6 +136,9 @@ type VaultClient interface {
 	// Stats returns the Vault clients statistics
 	Stats() *VaultStats
 
+	// Health returns an error if Vault can not be reached
+	Health() error
+
 	// EmitStats emits that clients statistics at the given period until stopCh
 	// is called.
 	EmitStats(period time.Duration, stopCh chan struct{})
@@ -818,6 +825,26 @@ func (v *vaultClient) ConnectionEstablished() (bool, error) {
 	return v.connEstablished, v.connEstablishedErr
 }
 
+// Health returns an error if the connection to Vault is not established or
+// Vault can not be reached
+func (v *vaultClient) Health() error {
+	established, err := v.ConnectionEstablished()
+	if !established {
+		if err != nil {
+			return fmt.Errorf("connection not established: %v", err)
+		}
+		return fmt.Errorf("connection not established")
+	}
+
+	v.l.Lock()
+	client := v.client
+	v.l.Unlock()
+	if _, err := client.Sys().Health(); err != nil {
+		return fmt.Errorf("failed to query health: %v", err)
+	}
+	return nil
+}
+
 // Enabled returns whether the client is active
 func (v *vaultClient) Enabled() bool {
 	v.l.Lock()
@@ -938,6 +965,41 @@ func (v *vaultClient) LookupToken(ctx context.Context, token string) (*vapi.Secr
 	return v.auth.Lookup(token)
 }
 
+// LookupCapabilities returns the capabilities the token has on the path. The
+// lookup is done with the token itself so that it doesn't require Nomad's
+// token to be allowed to lookup the capabilities of other tokens.
+func (v *vaultClient) LookupCapabilities(ctx context.Context, token, path string) ([]string, error) {
+	if !v.Enabled() {
+		return nil, fmt.Errorf("Vault integration disabled")
+	}
+
+	if !v.Active() {
+		return nil, fmt.Errorf("Vault client not active")
+	}
+
+	// Check if we have established a connection with Vault
+	if established, err := v.ConnectionEstablished(); !established && err == nil {
+		return nil, structs.NewRecoverableError(fmt.Errorf("Connection to Vault has not been established"), true)
+	} else if !established {
+		return nil, fmt.Errorf("Connection to Vault failed: %v", err)
+	}
+
+	// Track how long the request takes
+	defer metrics.MeasureSince([]string{"nomad", "vault", "lookup_capabilities"}, time.Now())
+
+	// Ensure we are under our rate limit
+	if err := v.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	client, err := v.client.Clone()
+	if err != nil {
+		return nil, err
+	}
+	client.SetToken(token)
+	return client.Sys().CapabilitiesSelf(path)
+}
+
 // PoliciesFrom parses the set of policies returned by a token lookup.
 func PoliciesFrom(s *vapi.Secret) ([]string, error) {
 	if s == nil {
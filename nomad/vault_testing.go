@@ -29,6 +29,10 @@ type TestVaultClient struct {
 	// by the CreateToken call
 	CreateTokenSecret map[string]map[string]*vapi.Secret
 
+	// LookupCapabilitiesResult maps a token and a path to the capabilities
+	// that will be returned by the LookupCapabilities call
+	LookupCapabilitiesResult map[string]map[string][]string
+
 	RevokedTokens []*structs.VaultAccessor
 }
 
@@ -130,6 +134,29 @@ func (v *TestVaultClient) SetCreateTokenSecret(allocID, task string, secret *vap
 	v.CreateTokenSecret[allocID][task] = secret
 }
 
+func (v *TestVaultClient) LookupCapabilities(ctx context.Context, token, path string) ([]string, error) {
+	if v.LookupCapabilitiesResult == nil {
+		return nil, nil
+	}
+	return v.LookupCapabilitiesResult[token][path], nil
+}
+
+// SetLookupCapabilities sets the capabilities that will be returned by the
+// capabilities lookup of the token on the path
+func (v *TestVaultClient) SetLookupCapabilities(token, path string, capabilities []string) {
+	if v.LookupCapabilitiesResult == nil {
+		v.LookupCapabilitiesResult = make(map[string]map[string][]string)
+	}
+
+	paths := v.LookupCapabilitiesResult[token]
+	if paths == nil {
+		paths = make(map[string][]string)
+		v.LookupCapabilitiesResult[token] = paths
+	}
+
+	paths[path] = capabilities
+}
+
 func (v *TestVaultClient) RevokeTokens(ctx context.Context, accessors []*structs.VaultAccessor, committed bool) error {
 	v.RevokedTokens = append(v.RevokedTokens, accessors...)
 	return nil
@@ -140,4 +167,5 @@ func (v *TestVaultClient) SetActive(enabled bool)
 func (v *TestVaultClient) SetConfig(config *config.VaultConfig) error           { return nil }
 func (v *TestVaultClient) Running() bool                                        { return true }
 func (v *TestVaultClient) Stats() *VaultStats                                   { return new(VaultStats) }
+func (v *TestVaultClient) Health() error                                        { return nil }
 func (v *TestVaultClient) EmitStats(period time.Duration, stopCh chan struct{}) {}
@@ -0,0 +1,126 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+const (
+	// CarbonScorerName is the name the carbon-aware scorer is registered
+	// with
+	CarbonScorerName = "carbon"
+
+	// MetaCarbonDeferrable is the job or task group meta key opting a batch
+	// job into carbon-aware placement when set to "true"
+	MetaCarbonDeferrable = "carbon_deferrable"
+
+	// MetaCarbonLatencyBudget is the job or task group meta key overriding
+	// the latency budget of the carbon-aware scorer
+	MetaCarbonLatencyBudget = "carbon_latency_budget"
+
+	// carbonIntensityAttribute is the node attribute fingerprinted with the
+	// carbon intensity of the electricity consumed by the node
+	carbonIntensityAttribute = "power.carbon_intensity"
+
+	// carbonMaxScore is the score of a placement on a node whose electricity
+	// has no carbon intensity. It is in the range of the bin packing score.
+	carbonMaxScore = 10.0
+
+	// defaultCarbonMaxIntensity is the carbon intensity, in grams of CO2
+	// equivalent per kWh, above which nodes are not preferred
+	defaultCarbonMaxIntensity = 1000.0
+
+	// defaultCarbonLatencyBudget is the time since the submission of a job
+	// during which its placements prefer lower-carbon nodes
+	defaultCarbonLatencyBudget = time.Hour
+)
+
+func init() {
+	RegisterScorer(CarbonScorerName, NewCarbonScorer)
+}
+
+// CarbonScorer prefers placing deferrable batch jobs on the nodes consuming
+// the electricity with the lowest carbon intensity. Jobs opt in by setting
+// the carbon_deferrable meta key. The preference only applies during the
+// latency budget of the job, measured from its submission, so that jobs that
+// have been waiting for too long, for example while blocked, are placed
+// without being held to it.
+type CarbonScorer struct {
+	maxIntensity  float64
+	latencyBudget time.Duration
+}
+
+// NewCarbonScorer returns a carbon-aware scorer given its options:
+//
+//   - max_intensity: the carbon intensity, in grams of CO2 equivalent per kWh,
+//     above which nodes are not preferred. Defaults to 1000.
+//   - latency_budget: the default latency budget of the jobs. Defaults to 1h.
+func NewCarbonScorer(options map[string]string) (Scorer, error) {
+	s := &CarbonScorer{
+		maxIntensity:  defaultCarbonMaxIntensity,
+		latencyBudget: defaultCarbonLatencyBudget,
+	}
+
+	if v, ok := options["max_intensity"]; ok {
+		max, err := strconv.ParseFloat(v, 64)
+		if err != nil || max <= 0 {
+			return nil, fmt.Errorf("invalid max_intensity %q: must be a positive number", v)
+		}
+		s.maxIntensity = max
+	}
+	if v, ok := options["latency_budget"]; ok {
+		budget, err := time.ParseDuration(v)
+		if err != nil || budget <= 0 {
+			return nil, fmt.Errorf("invalid latency_budget %q: must be a positive duration", v)
+		}
+		s.latencyBudget = budget
+	}
+
+	return s, nil
+}
+
+func (s *CarbonScorer) Score(ctx Context, job *structs.Job, tg *structs.TaskGroup, option *RankedNode) float64 {
+	if job.Type != structs.JobTypeBatch || carbonMeta(job, tg, MetaCarbonDeferrable) != "true" {
+		return 0
+	}
+
+	budget := s.latencyBudget
+	if v := carbonMeta(job, tg, MetaCarbonLatencyBudget); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			ctx.Logger().Printf("[DEBUG] sched.carbon: job %q has an invalid %s %q", job.ID, MetaCarbonLatencyBudget, v)
+		} else {
+			budget = d
+		}
+	}
+	if job.SubmitTime != 0 && time.Since(time.Unix(0, job.SubmitTime)) > budget {
+		return 0
+	}
+
+	raw, ok := option.Node.Attributes[carbonIntensityAttribute]
+	if !ok {
+		return 0
+	}
+	intensity, err := strconv.ParseFloat(raw, 64)
+	if err != nil || intensity >= s.maxIntensity {
+		return 0
+	}
+	if intensity < 0 {
+		intensity = 0
+	}
+	return carbonMaxScore * (1 - intensity/s.maxIntensity)
+}
+
+// carbonMeta returns the value of the meta key of the task group, falling
+// back to the meta of the job.
+func carbonMeta(job *structs.Job, tg *structs.TaskGroup, key string) string {
+	if tg != nil {
+		if v, ok := tg.Meta[key]; ok {
+			return v
+		}
+	}
+	return job.Meta[key]
+}
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCarbonScorer(t *testing.T) {
+	require := require.New(t)
+	_, ctx := testContext(t)
+
+	scorer, err := NewCarbonScorer(map[string]string{"max_intensity": "800"})
+	require.NoError(err)
+
+	node := func(intensity string) *RankedNode {
+		n := &structs.Node{ID: uuid.Generate(), Attributes: map[string]string{}}
+		if intensity != "" {
+			n.Attributes[carbonIntensityAttribute] = intensity
+		}
+		return &RankedNode{Node: n}
+	}
+	clean, dirty, unknown := node("200"), node("900"), node("")
+
+	job := mock.Job()
+	job.Type = structs.JobTypeBatch
+	job.SubmitTime = time.Now().UnixNano()
+	tg := job.TaskGroups[0]
+
+	// Jobs must opt in
+	require.Zero(scorer.Score(ctx, job, tg, clean))
+
+	job.Meta[MetaCarbonDeferrable] = "true"
+	require.Equal(7.5, scorer.Score(ctx, job, tg, clean))
+	require.Zero(scorer.Score(ctx, job, tg, dirty))
+	require.Zero(scorer.Score(ctx, job, tg, unknown))
+
+	// Task groups may opt out
+	tg.Meta = map[string]string{MetaCarbonDeferrable: "false"}
+	require.Zero(scorer.Score(ctx, job, tg, clean))
+	tg.Meta = nil
+
+	// The preference is dropped past the latency budget
+	job.Meta[MetaCarbonLatencyBudget] = "10m"
+	job.SubmitTime = time.Now().Add(-time.Hour).UnixNano()
+	require.Zero(scorer.Score(ctx, job, tg, clean))
+	job.Meta[MetaCarbonLatencyBudget] = "2h"
+	require.Equal(7.5, scorer.Score(ctx, job, tg, clean))
+
+	// Only batch jobs are deferrable
+	job.Type = structs.JobTypeService
+	require.Zero(scorer.Score(ctx, job, tg, clean))
+}
+
+func TestNewCarbonScorer_Invalid(t *testing.T) {
+	require := require.New(t)
+
+	_, err := NewCarbonScorer(map[string]string{"max_intensity": "0"})
+	require.Error(err)
+	_, err = NewCarbonScorer(map[string]string{"latency_budget": "soon"})
+	require.Error(err)
+}
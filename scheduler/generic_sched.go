@@ -77,6 +77,10 @@ type GenericScheduler struct {
 
 	deployment *structs.Deployment
 
+	// queueDeployments marks whether new deployments are created queued
+	// because the number of running deployments is limited
+	queueDeployments bool
+
 	blocked        *structs.Evaluation
 	failedTGAllocs map[string]*structs.AllocMetric
 	queuedAllocs   map[string]int
@@ -214,6 +218,13 @@ func (s *GenericScheduler) process() (bool, error) {
 		if err != nil {
 			return false, fmt.Errorf("failed to get job deployment %q: %v", s.eval.JobID, err)
 		}
+
+		// Check whether the number of running deployments is limited
+		_, concurrency, err := s.state.DeploymentConcurrencyConfig(ws)
+		if err != nil {
+			return false, fmt.Errorf("failed to get deployment concurrency config: %v", err)
+		}
+		s.queueDeployments = concurrency.Enabled()
 	}
 
 	// Reset the failed allocations
@@ -362,6 +373,7 @@ func (s *GenericScheduler) computeJobAllocs() error {
 	reconciler := NewAllocReconciler(s.ctx.Logger(),
 		genericAllocUpdateFn(s.ctx, s.stack, s.eval.ID),
 		s.batch, s.eval.JobID, s.job, s.deployment, allocs, tainted)
+	reconciler.queueDeployments = s.queueDeployments
 	results := reconciler.Compute()
 	s.logger.Printf("[DEBUG] sched: %#v: %#v", s.eval, results)
 
@@ -386,7 +398,7 @@ func (s *GenericScheduler) computeJobAllocs() error {
 
 	// Handle the stop
 	for _, stop := range results.stop {
-		s.plan.AppendUpdate(stop.alloc, structs.AllocDesiredStatusStop, stop.statusDescription, stop.clientStatus)
+		s.plan.AppendUpdate(stop.alloc, structs.AllocDesiredStatusStop, stop.statusDescription, stop.clientStatus, stop.stopReason)
 	}
 
 	// Handle the in-place updates
@@ -470,9 +482,9 @@ func (s *GenericScheduler) computePlacements(destructive, place []placementResul
 			// placement of its replacement. This allow atomic placements/stops. We
 			// stop the allocation before trying to find a replacement because this
 			// frees the resources currently used by the previous allocation.
-			stopPrevAlloc, stopPrevAllocDesc := missing.StopPreviousAlloc()
+			stopPrevAlloc, stopPrevAllocDesc, stopPrevAllocReason := missing.StopPreviousAlloc()
 			if stopPrevAlloc {
-				s.plan.AppendUpdate(missing.PreviousAllocation(), structs.AllocDesiredStatusStop, stopPrevAllocDesc, "")
+				s.plan.AppendUpdate(missing.PreviousAllocation(), structs.AllocDesiredStatusStop, stopPrevAllocDesc, "", stopPrevAllocReason)
 			}
 
 			// Attempt to match the task group
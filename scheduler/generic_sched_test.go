@@ -2145,11 +2145,16 @@ func TestServiceSched_JobDeregister_Stopped(t *testing.T) {
 	job.Stop = true
 	noErr(t, h.State.UpsertJob(h.NextIndex(), job))
 
+	// Register the node so that the allocations aren't lost
+	node := mock.Node()
+	noErr(t, h.State.UpsertNode(h.NextIndex(), node))
+
 	var allocs []*structs.Allocation
 	for i := 0; i < 10; i++ {
 		alloc := mock.Alloc()
 		alloc.Job = job
 		alloc.JobID = job.ID
+		alloc.NodeID = node.ID
 		allocs = append(allocs, alloc)
 	}
 	for _, alloc := range allocs {
@@ -2181,7 +2186,7 @@ func TestServiceSched_JobDeregister_Stopped(t *testing.T) {
 	plan := h.Plans[0]
 
 	// Ensure the plan evicted all nodes
-	if len(plan.NodeUpdate["12345678-abcd-efab-cdef-123456789abc"]) != len(allocs) {
+	if len(plan.NodeUpdate[node.ID]) != len(allocs) {
 		t.Fatalf("bad: %#v", plan)
 	}
 
@@ -2195,6 +2200,9 @@ func TestServiceSched_JobDeregister_Stopped(t *testing.T) {
 		if alloc.Job == nil {
 			t.Fatalf("bad: %#v", alloc)
 		}
+		if alloc.StopReason != structs.AllocStopReasonJobStopped {
+			t.Fatalf("bad stop reason: %q", alloc.StopReason)
+		}
 	}
 
 	// Ensure no remaining allocations
@@ -2281,6 +2289,9 @@ func TestServiceSched_NodeDown(t *testing.T) {
 		if out.ClientStatus != structs.AllocClientStatusLost && out.DesiredStatus != structs.AllocDesiredStatusStop {
 			t.Fatalf("bad alloc: %#v", out)
 		}
+		if out.StopReason != structs.AllocStopReasonNodeLost {
+			t.Fatalf("bad stop reason: %q", out.StopReason)
+		}
 	}
 
 	h.AssertEvalStatus(t, structs.EvalStatusComplete)
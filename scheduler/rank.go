@@ -181,6 +181,16 @@ OUTER:
 		netIdx.SetNode(option.Node)
 		netIdx.AddAllocs(proposed)
 
+		// Index the existing device usage
+		devIdx := structs.NewDeviceIndex()
+		devIdx.SetNode(option.Node)
+		devIdx.AddAllocs(proposed)
+
+		// Index the existing core usage
+		coreIdx := structs.NewCoreIndex()
+		coreIdx.SetNode(option.Node)
+		coreIdx.AddAllocs(proposed)
+
 		// Assign the resources for each task
 		total := &structs.Resources{
 			DiskMB: iter.taskGroup.EphemeralDisk.SizeMB,
@@ -206,6 +216,38 @@ OUTER:
 				taskResources.Networks = []*structs.NetworkResource{offer}
 			}
 
+			// Assign the devices asked for
+			for i, ask := range taskResources.Devices {
+				offer, err := devIdx.AssignDevices(ask)
+				if offer == nil {
+					iter.ctx.Metrics().ExhaustedNode(option.Node,
+						fmt.Sprintf("devices: %s", err))
+					netIdx.Release()
+					continue OUTER
+				}
+
+				// Reserve the devices to prevent another task from using them
+				devIdx.AddReserved(offer)
+				taskResources.Devices[i] = offer
+			}
+
+			// Assign the cores asked for. The task is accounted the compute
+			// of the cores in place of its CPU ask, as it is pinned to them.
+			if taskResources.Cores > 0 {
+				ids, err := coreIdx.AssignCores(taskResources.Cores)
+				if err != nil {
+					iter.ctx.Metrics().ExhaustedNode(option.Node,
+						fmt.Sprintf("cores: %s", err))
+					netIdx.Release()
+					continue OUTER
+				}
+
+				// Reserve the cores to prevent another task from using them
+				taskResources.CoreIDs = ids
+				coreIdx.AddReserved(taskResources)
+				taskResources.CPU = option.Node.Resources.CPU * len(ids) / len(coreIdx.AvailCores)
+			}
+
 			// Store the task resource
 			option.SetTaskResources(task, taskResources)
 
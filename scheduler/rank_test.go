@@ -1,6 +1,7 @@
 package scheduler
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/hashicorp/nomad/helper/uuid"
@@ -362,6 +363,142 @@ func TestBinPackIterator_ExistingAlloc_PlannedEvict(t *testing.T) {
 	}
 }
 
+func TestBinPackIterator_Devices(t *testing.T) {
+	_, ctx := testContext(t)
+	sriov := func(ids ...string) []*structs.DeviceResource {
+		d := &structs.DeviceResource{Type: structs.DeviceTypeSRIOV, Name: "eth1", MBits: 10000}
+		for _, id := range ids {
+			d.Instances = append(d.Instances, &structs.DeviceInstance{ID: id})
+		}
+		return []*structs.DeviceResource{d}
+	}
+	nodes := []*RankedNode{
+		{
+			Node: &structs.Node{
+				// Not enough devices
+				ID: uuid.Generate(),
+				Resources: &structs.Resources{
+					CPU:      2048,
+					MemoryMB: 2048,
+					Devices:  sriov("0000:3b:02.0"),
+				},
+			},
+		},
+		{
+			Node: &structs.Node{
+				ID: uuid.Generate(),
+				Resources: &structs.Resources{
+					CPU:      2048,
+					MemoryMB: 2048,
+					Devices:  sriov("0000:3b:02.0", "0000:3b:02.1"),
+				},
+			},
+		},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	ask := func() *structs.Resources {
+		return &structs.Resources{
+			CPU:      512,
+			MemoryMB: 512,
+			Devices:  []*structs.DeviceResource{{Type: structs.DeviceTypeSRIOV, Count: 1}},
+		}
+	}
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Tasks: []*structs.Task{
+			{Name: "web", Resources: ask()},
+			{Name: "sidecar", Resources: ask()},
+		},
+	}
+	binp := NewBinPackIterator(ctx, static, false, 0)
+	binp.SetTaskGroup(taskGroup)
+
+	out := collectRanked(binp)
+	if len(out) != 1 || out[0] != nodes[1] {
+		t.Fatalf("Bad: %v", out)
+	}
+
+	// Each task is assigned its own device
+	web := out[0].TaskResources["web"].Devices[0].InstanceIDs()
+	sidecar := out[0].TaskResources["sidecar"].Devices[0].InstanceIDs()
+	if len(web) != 1 || len(sidecar) != 1 || web[0] == sidecar[0] {
+		t.Fatalf("Bad: %v %v", web, sidecar)
+	}
+
+	// The ask of the task is not modified
+	if len(taskGroup.Tasks[0].Resources.Devices[0].Instances) != 0 {
+		t.Fatalf("Bad: %v", taskGroup.Tasks[0].Resources.Devices[0])
+	}
+	if ctx.Metrics().DimensionExhausted["devices: 0 \"sriov\" devices available; 1 needed"] != 1 {
+		t.Fatalf("Bad: %v", ctx.Metrics().DimensionExhausted)
+	}
+}
+
+func TestBinPackIterator_Cores(t *testing.T) {
+	_, ctx := testContext(t)
+	nodes := []*RankedNode{
+		{
+			Node: &structs.Node{
+				// Not enough free cores
+				ID: uuid.Generate(),
+				Resources: &structs.Resources{
+					CPU:      4000,
+					MemoryMB: 2048,
+					CoreIDs:  []int{0, 1, 2, 3},
+				},
+				Reserved: &structs.Resources{
+					CoreIDs: []int{0, 1},
+				},
+			},
+		},
+		{
+			Node: &structs.Node{
+				ID: uuid.Generate(),
+				Resources: &structs.Resources{
+					CPU:      4000,
+					MemoryMB: 2048,
+					CoreIDs:  []int{0, 1, 2, 3},
+				},
+			},
+		},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	taskGroup := &structs.TaskGroup{
+		EphemeralDisk: &structs.EphemeralDisk{},
+		Tasks: []*structs.Task{
+			{Name: "web", Resources: &structs.Resources{CPU: 100, MemoryMB: 512, Cores: 2}},
+			{Name: "sidecar", Resources: &structs.Resources{CPU: 100, MemoryMB: 512, Cores: 1}},
+		},
+	}
+	binp := NewBinPackIterator(ctx, static, false, 0)
+	binp.SetTaskGroup(taskGroup)
+
+	out := collectRanked(binp)
+	if len(out) != 1 || out[0] != nodes[1] {
+		t.Fatalf("Bad: %v", out)
+	}
+
+	// Each task is pinned to its own cores and accounted their compute
+	web := out[0].TaskResources["web"]
+	sidecar := out[0].TaskResources["sidecar"]
+	if !reflect.DeepEqual(web.CoreIDs, []int{0, 1}) || web.CPU != 2000 {
+		t.Fatalf("Bad: %v", web)
+	}
+	if !reflect.DeepEqual(sidecar.CoreIDs, []int{2}) || sidecar.CPU != 1000 {
+		t.Fatalf("Bad: %v", sidecar)
+	}
+
+	// The ask of the task is not modified
+	if taskGroup.Tasks[0].Resources.CoreIDs != nil {
+		t.Fatalf("Bad: %v", taskGroup.Tasks[0].Resources)
+	}
+	if ctx.Metrics().DimensionExhausted["cores: 0 cores available; 1 needed"] != 1 {
+		t.Fatalf("Bad: %v", ctx.Metrics().DimensionExhausted)
+	}
+}
+
 func TestJobAntiAffinity_PlannedAlloc(t *testing.T) {
 	_, ctx := testContext(t)
 	nodes := []*RankedNode{
@@ -52,6 +52,11 @@ type allocReconciler struct {
 	// deploymentFailed marks whether the deployment is failed
 	deploymentFailed bool
 
+	// queueDeployments marks whether new deployments are created queued. A
+	// queued deployment makes no placements or destructive updates, besides
+	// replacing lost allocations, until the leader starts it.
+	queueDeployments bool
+
 	// taintedNodes contains a map of nodes that are tainted
 	taintedNodes map[string]*structs.Node
 
@@ -237,9 +242,9 @@ func (a *allocReconciler) cancelDeployments() {
 func (a *allocReconciler) handleStop(m allocMatrix) {
 	for group, as := range m {
 		untainted, migrate, lost := as.filterByTainted(a.taintedNodes)
-		a.markStop(untainted, "", allocNotNeeded)
-		a.markStop(migrate, "", allocNotNeeded)
-		a.markStop(lost, structs.AllocClientStatusLost, allocLost)
+		a.markStop(untainted, "", allocNotNeeded, structs.AllocStopReasonJobStopped)
+		a.markStop(migrate, "", allocNotNeeded, structs.AllocStopReasonJobStopped)
+		a.markStop(lost, structs.AllocClientStatusLost, allocLost, structs.AllocStopReasonNodeLost)
 		desiredChanges := new(structs.DesiredUpdates)
 		desiredChanges.Stop = uint64(len(as))
 		a.result.desiredTGUpdates[group] = desiredChanges
@@ -247,13 +252,14 @@ func (a *allocReconciler) handleStop(m allocMatrix) {
 }
 
 // markStop is a helper for marking a set of allocation for stop with a
-// particular client status and description.
-func (a *allocReconciler) markStop(allocs allocSet, clientStatus, statusDescription string) {
+// particular client status, description and stop reason.
+func (a *allocReconciler) markStop(allocs allocSet, clientStatus, statusDescription, stopReason string) {
 	for _, alloc := range allocs {
 		a.result.stop = append(a.result.stop, allocStopResult{
 			alloc:             alloc,
 			clientStatus:      clientStatus,
 			statusDescription: statusDescription,
+			stopReason:        stopReason,
 		})
 	}
 }
@@ -273,9 +279,9 @@ func (a *allocReconciler) computeGroup(group string, all allocSet) bool {
 	// need to do is stop everything
 	if tg == nil {
 		untainted, migrate, lost := all.filterByTainted(a.taintedNodes)
-		a.markStop(untainted, "", allocNotNeeded)
-		a.markStop(migrate, "", allocNotNeeded)
-		a.markStop(lost, structs.AllocClientStatusLost, allocLost)
+		a.markStop(untainted, "", allocNotNeeded, structs.AllocStopReasonJobUpdated)
+		a.markStop(migrate, "", allocNotNeeded, structs.AllocStopReasonJobUpdated)
+		a.markStop(lost, structs.AllocClientStatusLost, allocLost, structs.AllocStopReasonNodeLost)
 		desiredChanges.Stop = uint64(len(untainted) + len(migrate) + len(lost))
 		return true
 	}
@@ -339,7 +345,18 @@ func (a *allocReconciler) computeGroup(group string, all allocSet) bool {
 	strategy := tg.Update
 	canariesPromoted := dstate != nil && dstate.Promoted
 	requireCanary := numDestructive != 0 && strategy != nil && len(canaries) < strategy.Canary && !canariesPromoted
-	if requireCanary && !a.deploymentPaused && !a.deploymentFailed {
+
+	// deploymentQueued tracks whether the deployment waits for the leader to
+	// start it, either because it is queued or because it is created queued.
+	deploymentQueued := (a.deployment != nil && a.deployment.Status == structs.DeploymentStatusQueued) ||
+		(a.queueDeployments && a.deployment == nil && strategy != nil && !existingDeployment)
+
+	if requireCanary && deploymentQueued {
+		// The canaries are placed once the deployment is started
+		if !existingDeployment {
+			dstate.DesiredCanaries = strategy.Canary
+		}
+	} else if requireCanary && !a.deploymentPaused && !a.deploymentFailed {
 		number := strategy.Canary - len(canaries)
 		number = helper.IntMin(numDestructive, number)
 		desiredChanges.Canary += uint64(number)
@@ -361,7 +378,7 @@ func (a *allocReconciler) computeGroup(group string, all allocSet) bool {
 	limit := a.computeLimit(tg, untainted, destructive, migrate, canaryState)
 
 	// Place if:
-	// * The deployment is not paused or failed
+	// * The deployment is not paused, failed or queued
 	// * Not placing any canaries
 	// * If there are any canaries that they have been promoted
 	place := a.computePlacements(tg, nameIndex, untainted, migrate)
@@ -371,7 +388,7 @@ func (a *allocReconciler) computeGroup(group string, all allocSet) bool {
 
 	// deploymentPlaceReady tracks whether the deployment is in a state where
 	// placements can be made without any other consideration.
-	deploymentPlaceReady := !a.deploymentPaused && !a.deploymentFailed && !canaryState
+	deploymentPlaceReady := !a.deploymentPaused && !a.deploymentFailed && !deploymentQueued && !canaryState
 
 	if deploymentPlaceReady {
 		desiredChanges.Place += uint64(len(place))
@@ -385,7 +402,7 @@ func (a *allocReconciler) computeGroup(group string, all allocSet) bool {
 		// We are in a situation where we shouldn't be placing more than we need
 		// to but we have lost allocations. It is a very weird user experience
 		// if you have a node go down and Nomad doesn't replace the allocations
-		// because the deployment is paused/failed so we only place to recover
+		// because the deployment is paused/failed/queued so we only place to recover
 		// the lost allocations.
 		allowed := helper.IntMin(len(lost), len(place))
 		desiredChanges.Place += uint64(allowed)
@@ -406,6 +423,7 @@ func (a *allocReconciler) computeGroup(group string, all allocSet) bool {
 				placeTaskGroup:        tg,
 				stopAlloc:             alloc,
 				stopStatusDescription: allocUpdating,
+				stopReason:            structs.AllocStopReasonDeploymentReplaced,
 			})
 		}
 	} else {
@@ -430,6 +448,7 @@ func (a *allocReconciler) computeGroup(group string, all allocSet) bool {
 			a.result.stop = append(a.result.stop, allocStopResult{
 				alloc:             alloc,
 				statusDescription: allocNodeTainted,
+				stopReason:        structs.AllocStopReasonDrained,
 			})
 			continue
 		}
@@ -443,6 +462,7 @@ func (a *allocReconciler) computeGroup(group string, all allocSet) bool {
 		a.result.stop = append(a.result.stop, allocStopResult{
 			alloc:             alloc,
 			statusDescription: allocMigrating,
+			stopReason:        structs.AllocStopReasonDrained,
 		})
 		a.result.place = append(a.result.place, allocPlaceResult{
 			name:          alloc.Name,
@@ -462,6 +482,10 @@ func (a *allocReconciler) computeGroup(group string, all allocSet) bool {
 		// A previous group may have made the deployment already
 		if a.deployment == nil {
 			a.deployment = structs.NewDeployment(a.job)
+			if a.queueDeployments {
+				a.deployment.Status = structs.DeploymentStatusQueued
+				a.deployment.StatusDescription = structs.DeploymentStatusDescriptionQueued
+			}
 			a.result.deployment = a.deployment
 		}
 
@@ -538,7 +562,7 @@ func (a *allocReconciler) handleGroupCanaries(all allocSet, desiredChanges *stru
 	// stopSet is the allocSet that contains the canaries we desire to stop from
 	// above.
 	stopSet := all.fromKeys(stop)
-	a.markStop(stopSet, "", allocNotNeeded)
+	a.markStop(stopSet, "", allocNotNeeded, structs.AllocStopReasonDeploymentReplaced)
 	desiredChanges.Stop += uint64(len(stopSet))
 	all = all.difference(stopSet)
 
@@ -552,8 +576,8 @@ func (a *allocReconciler) handleGroupCanaries(all allocSet, desiredChanges *stru
 
 		canaries = all.fromKeys(canaryIDs)
 		untainted, migrate, lost := canaries.filterByTainted(a.taintedNodes)
-		a.markStop(migrate, "", allocMigrating)
-		a.markStop(lost, structs.AllocClientStatusLost, allocLost)
+		a.markStop(migrate, "", allocMigrating, structs.AllocStopReasonDrained)
+		a.markStop(lost, structs.AllocClientStatusLost, allocLost, structs.AllocStopReasonNodeLost)
 
 		canaries = untainted
 		all = all.difference(migrate, lost)
@@ -639,7 +663,7 @@ func (a *allocReconciler) computeStop(group *structs.TaskGroup, nameIndex *alloc
 	// here since it is on a lost node
 	var stop allocSet
 	stop = stop.union(lost)
-	a.markStop(lost, structs.AllocClientStatusLost, allocLost)
+	a.markStop(lost, structs.AllocClientStatusLost, allocLost, structs.AllocStopReasonNodeLost)
 
 	// If we are still deploying or creating canaries, don't stop them
 	if canaryState {
@@ -662,6 +686,7 @@ func (a *allocReconciler) computeStop(group *structs.TaskGroup, nameIndex *alloc
 				a.result.stop = append(a.result.stop, allocStopResult{
 					alloc:             alloc,
 					statusDescription: allocNotNeeded,
+					stopReason:        structs.AllocStopReasonDeploymentReplaced,
 				})
 				delete(untainted, id)
 
@@ -684,6 +709,7 @@ func (a *allocReconciler) computeStop(group *structs.TaskGroup, nameIndex *alloc
 			a.result.stop = append(a.result.stop, allocStopResult{
 				alloc:             alloc,
 				statusDescription: allocNotNeeded,
+				stopReason:        structs.AllocStopReasonJobUpdated,
 			})
 			delete(migrate, id)
 			stop[id] = alloc
@@ -704,6 +730,7 @@ func (a *allocReconciler) computeStop(group *structs.TaskGroup, nameIndex *alloc
 			a.result.stop = append(a.result.stop, allocStopResult{
 				alloc:             alloc,
 				statusDescription: allocNotNeeded,
+				stopReason:        structs.AllocStopReasonJobUpdated,
 			})
 			delete(untainted, id)
 
@@ -721,6 +748,7 @@ func (a *allocReconciler) computeStop(group *structs.TaskGroup, nameIndex *alloc
 		a.result.stop = append(a.result.stop, allocStopResult{
 			alloc:             alloc,
 			statusDescription: allocNotNeeded,
+			stopReason:        structs.AllocStopReasonJobUpdated,
 		})
 		delete(untainted, id)
 
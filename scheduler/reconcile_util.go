@@ -27,8 +27,8 @@ type placementResult interface {
 	PreviousAllocation() *structs.Allocation
 
 	// StopPreviousAlloc returns whether the previous allocation should be
-	// stopped and if so the status description.
-	StopPreviousAlloc() (bool, string)
+	// stopped and if so the status description and stop reason.
+	StopPreviousAlloc() (bool, string, string)
 }
 
 // allocStopResult contains the information required to stop a single allocation
@@ -36,6 +36,7 @@ type allocStopResult struct {
 	alloc             *structs.Allocation
 	clientStatus      string
 	statusDescription string
+	stopReason        string
 }
 
 // allocPlaceResult contains the information required to place a single
@@ -47,11 +48,11 @@ type allocPlaceResult struct {
 	previousAlloc *structs.Allocation
 }
 
-func (a allocPlaceResult) TaskGroup() *structs.TaskGroup           { return a.taskGroup }
-func (a allocPlaceResult) Name() string                            { return a.name }
-func (a allocPlaceResult) Canary() bool                            { return a.canary }
-func (a allocPlaceResult) PreviousAllocation() *structs.Allocation { return a.previousAlloc }
-func (a allocPlaceResult) StopPreviousAlloc() (bool, string)       { return false, "" }
+func (a allocPlaceResult) TaskGroup() *structs.TaskGroup             { return a.taskGroup }
+func (a allocPlaceResult) Name() string                              { return a.name }
+func (a allocPlaceResult) Canary() bool                              { return a.canary }
+func (a allocPlaceResult) PreviousAllocation() *structs.Allocation   { return a.previousAlloc }
+func (a allocPlaceResult) StopPreviousAlloc() (bool, string, string) { return false, "", "" }
 
 // allocDestructiveResult contains the information required to do a destructive
 // update. Destructive changes should be applied atomically, as in the old alloc
@@ -61,14 +62,15 @@ type allocDestructiveResult struct {
 	placeTaskGroup        *structs.TaskGroup
 	stopAlloc             *structs.Allocation
 	stopStatusDescription string
+	stopReason            string
 }
 
 func (a allocDestructiveResult) TaskGroup() *structs.TaskGroup           { return a.placeTaskGroup }
 func (a allocDestructiveResult) Name() string                            { return a.placeName }
 func (a allocDestructiveResult) Canary() bool                            { return false }
 func (a allocDestructiveResult) PreviousAllocation() *structs.Allocation { return a.stopAlloc }
-func (a allocDestructiveResult) StopPreviousAlloc() (bool, string) {
-	return true, a.stopStatusDescription
+func (a allocDestructiveResult) StopPreviousAlloc() (bool, string, string) {
+	return true, a.stopStatusDescription, a.stopReason
 }
 
 // allocMatrix is a mapping of task groups to their allocation set.
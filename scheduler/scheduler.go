@@ -87,6 +87,10 @@ type State interface {
 	// LatestDeploymentByJobID returns the latest deployment matching the given
 	// job ID
 	LatestDeploymentByJobID(ws memdb.WatchSet, namespace, jobID string) (*structs.Deployment, error)
+
+	// DeploymentConcurrencyConfig returns the configuration limiting the
+	// number of running deployments
+	DeploymentConcurrencyConfig(ws memdb.WatchSet) (uint64, *structs.DeploymentConcurrencyConfig, error)
 }
 
 // Planner interface is used to submit a task allocation plan.
@@ -0,0 +1,139 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
+)
+
+// Scorer is a scoring extension of the generic stack. Scorers allow custom
+// binaries to rank nodes by properties Nomad is unaware of, such as energy
+// efficiency or license locality, without modifying the rank iterators.
+// Scorers are registered under a name with RegisterScorer, typically from an
+// init function, and are only applied once enabled in the server
+// configuration.
+type Scorer interface {
+	// Score returns the score of placing the task group of the job on the
+	// ranked node. The score is multiplied by the configured weight and
+	// added to the score of the node. Scorers must not modify the node.
+	Score(ctx Context, job *structs.Job, tg *structs.TaskGroup, option *RankedNode) float64
+}
+
+// ScorerFactory is used to instantiate a scorer given the options of its
+// configuration
+type ScorerFactory func(options map[string]string) (Scorer, error)
+
+var (
+	// scorerFactories are the registered scorers by name
+	scorerFactories = make(map[string]ScorerFactory)
+
+	// enabledScorers are the scorers applied by the generic stack
+	enabledScorers []*weightedScorer
+
+	scorersLock sync.RWMutex
+)
+
+// weightedScorer is an enabled scorer along with its name and weight
+type weightedScorer struct {
+	name   string
+	weight float64
+	scorer Scorer
+}
+
+// RegisterScorer registers a scorer factory under the given name. It panics
+// if a scorer is already registered with the name.
+func RegisterScorer(name string, factory ScorerFactory) {
+	scorersLock.Lock()
+	defer scorersLock.Unlock()
+
+	if _, ok := scorerFactories[name]; ok {
+		panic(fmt.Sprintf("scorer %q registered twice", name))
+	}
+	scorerFactories[name] = factory
+}
+
+// ConfigureScorers instantiates the registered scorers enabled by the
+// configurations and sets them as the scorers applied by the generic stack.
+// It is called when the server starts.
+func ConfigureScorers(configs []*config.ScorerConfig) error {
+	scorersLock.Lock()
+	defer scorersLock.Unlock()
+
+	scorers := make([]*weightedScorer, 0, len(configs))
+	for _, c := range configs {
+		factory, ok := scorerFactories[c.Name]
+		if !ok {
+			return fmt.Errorf("unknown scorer %q", c.Name)
+		}
+		scorer, err := factory(c.Options)
+		if err != nil {
+			return fmt.Errorf("failed to configure scorer %q: %v", c.Name, err)
+		}
+		weight := c.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		scorers = append(scorers, &weightedScorer{
+			name:   c.Name,
+			weight: weight,
+			scorer: scorer,
+		})
+	}
+
+	enabledScorers = scorers
+	return nil
+}
+
+// ScorerIterator is a RankIterator applying the enabled scorer extensions to
+// the ranked nodes.
+type ScorerIterator struct {
+	ctx     Context
+	source  RankIterator
+	job     *structs.Job
+	tg      *structs.TaskGroup
+	scorers []*weightedScorer
+}
+
+// NewScorerIterator is used to create a ScorerIterator applying the scorers
+// enabled when it is created.
+func NewScorerIterator(ctx Context, source RankIterator) *ScorerIterator {
+	scorersLock.RLock()
+	defer scorersLock.RUnlock()
+
+	return &ScorerIterator{
+		ctx:     ctx,
+		source:  source,
+		scorers: enabledScorers,
+	}
+}
+
+func (iter *ScorerIterator) SetJob(job *structs.Job) {
+	iter.job = job
+}
+
+func (iter *ScorerIterator) SetTaskGroup(tg *structs.TaskGroup) {
+	iter.tg = tg
+}
+
+func (iter *ScorerIterator) Next() *RankedNode {
+	option := iter.source.Next()
+	if option == nil {
+		return nil
+	}
+
+	for _, s := range iter.scorers {
+		score := s.weight * s.scorer.Score(iter.ctx, iter.job, iter.tg, option)
+		if score == 0 {
+			continue
+		}
+		option.Score += score
+		iter.ctx.Metrics().ScoreNode(option.Node, s.name, score)
+	}
+	return option
+}
+
+func (iter *ScorerIterator) Reset() {
+	iter.source.Reset()
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/nomad/helper/uuid"
+	"github.com/hashicorp/nomad/nomad/mock"
+	"github.com/hashicorp/nomad/nomad/structs"
+	"github.com/hashicorp/nomad/nomad/structs/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// classScorer scores the nodes of a class
+type classScorer struct {
+	class string
+}
+
+func (s *classScorer) Score(ctx Context, job *structs.Job, tg *structs.TaskGroup, option *RankedNode) float64 {
+	if option.Node.NodeClass == s.class {
+		return 10
+	}
+	return 0
+}
+
+func TestScorerIterator(t *testing.T) {
+	assert := assert.New(t)
+	_, ctx := testContext(t)
+
+	nodes := []*RankedNode{
+		{Node: &structs.Node{ID: uuid.Generate(), NodeClass: "efficient"}},
+		{Node: &structs.Node{ID: uuid.Generate(), NodeClass: "legacy"}},
+	}
+	static := NewStaticRankIterator(ctx, nodes)
+
+	iter := NewScorerIterator(ctx, static)
+	iter.scorers = []*weightedScorer{
+		{name: "energy", weight: 1.5, scorer: &classScorer{class: "efficient"}},
+	}
+	job := mock.Job()
+	iter.SetJob(job)
+	iter.SetTaskGroup(job.TaskGroups[0])
+
+	out := collectRanked(iter)
+	if assert.Len(out, 2) {
+		assert.Equal(15.0, out[0].Score)
+		assert.Equal(0.0, out[1].Score)
+	}
+}
+
+func TestConfigureScorers_Errors(t *testing.T) {
+	assert := assert.New(t)
+
+	// Unknown scorers are rejected
+	err := ConfigureScorers([]*config.ScorerConfig{{Name: "unknown"}})
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "unknown scorer")
+	}
+
+	// Factory errors are returned
+	RegisterScorer("test-failing", func(options map[string]string) (Scorer, error) {
+		return nil, fmt.Errorf("missing class")
+	})
+	err = ConfigureScorers([]*config.ScorerConfig{{Name: "test-failing"}})
+	if assert.NotNil(err) {
+		assert.Contains(err.Error(), "missing class")
+	}
+
+	// Registering a name twice panics
+	assert.Panics(func() {
+		RegisterScorer("test-failing", nil)
+	})
+}
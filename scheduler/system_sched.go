@@ -208,14 +208,18 @@ func (s *SystemScheduler) computeJobAllocs() error {
 	s.logger.Printf("[DEBUG] sched: %#v: %#v", s.eval, diff)
 
 	// Add all the allocs to stop
+	stopReason := structs.AllocStopReasonJobUpdated
+	if s.job.Stopped() {
+		stopReason = structs.AllocStopReasonJobStopped
+	}
 	for _, e := range diff.stop {
-		s.plan.AppendUpdate(e.Alloc, structs.AllocDesiredStatusStop, allocNotNeeded, "")
+		s.plan.AppendUpdate(e.Alloc, structs.AllocDesiredStatusStop, allocNotNeeded, "", stopReason)
 	}
 
 	// Lost allocations should be transitioned to desired status stop and client
 	// status lost.
 	for _, e := range diff.lost {
-		s.plan.AppendUpdate(e.Alloc, structs.AllocDesiredStatusStop, allocLost, structs.AllocClientStatusLost)
+		s.plan.AppendUpdate(e.Alloc, structs.AllocDesiredStatusStop, allocLost, structs.AllocClientStatusLost, structs.AllocStopReasonNodeLost)
 	}
 
 	// Attempt to do the upgrades in place
@@ -235,7 +239,7 @@ func (s *SystemScheduler) computeJobAllocs() error {
 	}
 
 	// Treat non in-place updates as an eviction and new placement.
-	s.limitReached = evictAndPlace(s.ctx, diff, diff.update, allocUpdating, &limit)
+	s.limitReached = evictAndPlace(s.ctx, diff, diff.update, allocUpdating, structs.AllocStopReasonDeploymentReplaced, &limit)
 
 	// Nothing remaining to do if placement is not required
 	if len(diff.place) == 0 {
@@ -338,6 +338,11 @@ func tasksUpdated(jobA, jobB *structs.Job, taskGroup string) bool {
 	a := jobA.LookupTaskGroup(taskGroup)
 	b := jobB.LookupTaskGroup(taskGroup)
 
+	// Restarting the job replaces all of its allocations
+	if jobA.Restarts != jobB.Restarts {
+		return true
+	}
+
 	// If the number of tasks do not match, clearly there is an update
 	if len(a.Tasks) != len(b.Tasks) {
 		return true
@@ -401,6 +406,29 @@ func tasksUpdated(jobA, jobB *structs.Job, taskGroup string) bool {
 			}
 		}
 
+		// Inspect the devices, which are attached when the task starts
+		if len(at.Resources.Devices) != len(bt.Resources.Devices) {
+			return true
+		}
+		for idx := range at.Resources.Devices {
+			ad := at.Resources.Devices[idx]
+			bd := bt.Resources.Devices[idx]
+			if ad.Type != bd.Type || ad.Name != bd.Name || ad.Count != bd.Count || ad.MBits != bd.MBits {
+				return true
+			}
+		}
+
+		// Inspect the cores, which the task is pinned to when it starts
+		if at.Resources.Cores != bt.Resources.Cores {
+			return true
+		}
+
+		// Inspect the disk IO limits, which are applied when the task starts
+		if ar, br := at.Resources, bt.Resources; ar.IOWeight != br.IOWeight ||
+			ar.IOReadMBps != br.IOReadMBps || ar.IOWriteMBps != br.IOWriteMBps {
+			return true
+		}
+
 		// Inspect the non-network resources
 		if ar, br := at.Resources, bt.Resources; ar.CPU != br.CPU {
 			return true
@@ -508,7 +536,7 @@ func inplaceUpdate(ctx Context, eval *structs.Evaluation, job *structs.Job,
 		// Otherwise we would be trying to fit the tasks current resources and
 		// updated resources. After select is called we can remove the evict.
 		ctx.Plan().AppendUpdate(update.Alloc, structs.AllocDesiredStatusStop,
-			allocInPlace, "")
+			allocInPlace, "", "")
 
 		// Attempt to match the task group
 		option, _ := stack.Select(update.TaskGroup)
@@ -521,13 +549,15 @@ func inplaceUpdate(ctx Context, eval *structs.Evaluation, job *structs.Job,
 			continue
 		}
 
-		// Restore the network offers from the existing allocation.
-		// We do not allow network resources (reserved/dynamic ports)
-		// to be updated. This is guarded in taskUpdated, so we can
-		// safely restore those here.
+		// Restore the network, device and core offers from the existing
+		// allocation. We do not allow network resources (reserved/dynamic
+		// ports), devices or cores to be updated. This is guarded in
+		// taskUpdated, so we can safely restore those here.
 		for task, resources := range option.TaskResources {
 			existing := update.Alloc.TaskResources[task]
 			resources.Networks = existing.Networks
+			resources.Devices = existing.Devices
+			resources.CoreIDs = existing.CoreIDs
 		}
 
 		// Create a shallow copy
@@ -555,11 +585,11 @@ func inplaceUpdate(ctx Context, eval *structs.Evaluation, job *structs.Job,
 // evictAndPlace is used to mark allocations for evicts and add them to the
 // placement queue. evictAndPlace modifies both the diffResult and the
 // limit. It returns true if the limit has been reached.
-func evictAndPlace(ctx Context, diff *diffResult, allocs []allocTuple, desc string, limit *int) bool {
+func evictAndPlace(ctx Context, diff *diffResult, allocs []allocTuple, desc, reason string, limit *int) bool {
 	n := len(allocs)
 	for i := 0; i < n && i < *limit; i++ {
 		a := allocs[i]
-		ctx.Plan().AppendUpdate(a.Alloc, structs.AllocDesiredStatusStop, desc, "")
+		ctx.Plan().AppendUpdate(a.Alloc, structs.AllocDesiredStatusStop, desc, "", reason)
 		diff.place = append(diff.place, a)
 	}
 	if n <= *limit {
@@ -714,7 +744,7 @@ func updateNonTerminalAllocsToLost(plan *structs.Plan, tainted map[string]*struc
 			alloc.DesiredStatus == structs.AllocDesiredStatusStop &&
 			(alloc.ClientStatus == structs.AllocClientStatusRunning ||
 				alloc.ClientStatus == structs.AllocClientStatusPending) {
-			plan.AppendUpdate(alloc, structs.AllocDesiredStatusStop, allocLost, structs.AllocClientStatusLost)
+			plan.AppendUpdate(alloc, structs.AllocDesiredStatusStop, allocLost, structs.AllocClientStatusLost, structs.AllocStopReasonNodeLost)
 		}
 	}
 }
@@ -764,7 +794,7 @@ func genericAllocUpdateFn(ctx Context, stack Stack, evalID string) allocUpdateTy
 		// the current allocation is discounted when checking for feasability.
 		// Otherwise we would be trying to fit the tasks current resources and
 		// updated resources. After select is called we can remove the evict.
-		ctx.Plan().AppendUpdate(existing, structs.AllocDesiredStatusStop, allocInPlace, "")
+		ctx.Plan().AppendUpdate(existing, structs.AllocDesiredStatusStop, allocInPlace, "", "")
 
 		// Attempt to match the task group
 		option, _ := stack.Select(newTG)
@@ -777,13 +807,15 @@ func genericAllocUpdateFn(ctx Context, stack Stack, evalID string) allocUpdateTy
 			return false, true, nil
 		}
 
-		// Restore the network offers from the existing allocation.
-		// We do not allow network resources (reserved/dynamic ports)
-		// to be updated. This is guarded in taskUpdated, so we can
-		// safely restore those here.
+		// Restore the network, device and core offers from the existing
+		// allocation. We do not allow network resources (reserved/dynamic
+		// ports), devices or cores to be updated. This is guarded in
+		// taskUpdated, so we can safely restore those here.
 		for task, resources := range option.TaskResources {
 			existingResources := existing.TaskResources[task]
 			resources.Networks = existingResources.Networks
+			resources.Devices = existingResources.Devices
+			resources.CoreIDs = existingResources.CoreIDs
 		}
 
 		// Create a shallow copy
@@ -564,6 +564,43 @@ func TestTasksUpdated(t *testing.T) {
 	if !tasksUpdated(j1, j18, name) {
 		t.Fatal("bad")
 	}
+
+	// Ask for devices
+	j19 := mock.Job()
+	j19.TaskGroups[0].Tasks[0].Resources.Devices = []*structs.DeviceResource{
+		{Type: structs.DeviceTypeSRIOV, Count: 1},
+	}
+	if !tasksUpdated(j1, j19, name) {
+		t.Fatal("bad")
+	}
+
+	// Change the device count
+	j20 := j19.Copy()
+	j20.TaskGroups[0].Tasks[0].Resources.Devices[0].Count = 2
+	if !tasksUpdated(j19, j20, name) {
+		t.Fatal("bad")
+	}
+
+	// Restart the job
+	j21 := j1.Copy()
+	j21.Restarts++
+	if !tasksUpdated(j1, j21, name) {
+		t.Fatal("bad")
+	}
+
+	// Ask for cores
+	j22 := mock.Job()
+	j22.TaskGroups[0].Tasks[0].Resources.Cores = 2
+	if !tasksUpdated(j1, j22, name) {
+		t.Fatal("bad")
+	}
+
+	// Limit the disk bandwidth
+	j23 := mock.Job()
+	j23.TaskGroups[0].Tasks[0].Resources.IOWriteMBps = 50
+	if !tasksUpdated(j1, j23, name) {
+		t.Fatal("bad")
+	}
 }
 
 func TestEvictAndPlace_LimitLessThanAllocs(t *testing.T) {
@@ -577,7 +614,7 @@ func TestEvictAndPlace_LimitLessThanAllocs(t *testing.T) {
 	diff := &diffResult{}
 
 	limit := 2
-	if !evictAndPlace(ctx, diff, allocs, "", &limit) {
+	if !evictAndPlace(ctx, diff, allocs, "", "", &limit) {
 		t.Fatal("evictAndReplace() should have returned true")
 	}
 
@@ -601,7 +638,7 @@ func TestEvictAndPlace_LimitEqualToAllocs(t *testing.T) {
 	diff := &diffResult{}
 
 	limit := 4
-	if evictAndPlace(ctx, diff, allocs, "", &limit) {
+	if evictAndPlace(ctx, diff, allocs, "", "", &limit) {
 		t.Fatal("evictAndReplace() should have returned false")
 	}
 
@@ -923,7 +960,7 @@ func TestEvictAndPlace_LimitGreaterThanAllocs(t *testing.T) {
 	diff := &diffResult{}
 
 	limit := 6
-	if evictAndPlace(ctx, diff, allocs, "", &limit) {
+	if evictAndPlace(ctx, diff, allocs, "", "", &limit) {
 		t.Fatal("evictAndReplace() should have returned false")
 	}
 